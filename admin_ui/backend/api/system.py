@@ -4789,7 +4789,9 @@ def _update_plan_failure_detail(
         ")\n\n"
         "Use --local-changes=overwrite only after preserving any local source edits. "
         "Only .git/.agent plus Git-tracked paths and their parents are repaired; untracked "
-        "files are untouched and temporary parent traversal is restored."
+        "files are untouched and temporary parent traversal is restored. "
+        "agent update refuses a target that is not signed by a key in the host keyring; "
+        "add --insecure only after checking the target commit."
     )
 
 
@@ -5249,6 +5251,9 @@ class UpdateRunRequest(BaseModel):
     update_cli_host: bool = True
     cli_install_path: Optional[str] = None
     force_active_calls: bool = False
+    # Passes --insecure: accept a target commit/tag that is unsigned or signed by a
+    # key missing from the host keyring. A bad signature is still refused.
+    allow_unsigned: bool = False
 
 
 class UpdateRunResponse(BaseModel):
@@ -5331,6 +5336,7 @@ async def updates_run(body: UpdateRunRequest):
                 "cli_install_path": cli_path,
                 "repo_root": host_root,
                 "force_active_calls": bool(body.force_active_calls),
+                "allow_unsigned": bool(body.allow_unsigned),
             }
             _write_update_job_marker(job_id, payload)
         except Exception as e:
@@ -5376,6 +5382,7 @@ async def updates_run(body: UpdateRunRequest):
         "AAVA_UPDATE_UPDATE_CLI_HOST": "true" if body.update_cli_host else "false",
         "AAVA_UPDATE_BUILD_CLI_FROM_SOURCE": "true",
         "AAVA_UPDATE_FORCE_ACTIVE_CALLS": "true" if body.force_active_calls else "false",
+        "AAVA_UPDATE_ALLOW_UNSIGNED": "true" if body.allow_unsigned else "false",
     }
     if cli_path:
         env["AAVA_UPDATE_CLI_INSTALL_PATH"] = cli_path
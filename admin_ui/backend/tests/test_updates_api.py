@@ -527,8 +527,9 @@ def test_update_plan_failure_preserves_long_stderr_and_explicit_flags(tmp_path)
     assert updater_output in detail
     assert (
         "agent update --ref main --checkout=true --include-ui=false "
-        "--local-changes=retain --self-update=false"
+        "--local-changes=retain --self-update=false\n"
     ) in detail
+    assert "add --insecure only after checking the target commit" in detail
 
 
 @pytest.mark.parametrize("ref", ["7.4.0", "v7.4.0"])
@@ -704,6 +705,37 @@ def test_find_active_update_job_ignores_stale_jobs(monkeypatch, tmp_path) -> Non
     assert active["job_id"] == active_id
 
 
+@pytest.mark.asyncio
+@pytest.mark.parametrize("allow_unsigned", [False, True])
+async def test_updates_run_passes_unsigned_target_choice_to_runner(
+    monkeypatch, tmp_path, allow_unsigned: bool
+) -> None:
+    monkeypatch.setenv("PROJECT_ROOT", str(tmp_path))
+    monkeypatch.setattr(system, "_project_host_root_from_admin_ui_container", lambda: str(tmp_path))
+    monkeypatch.setattr(system, "_docker_sock_host_path_from_admin_ui_container", lambda: "/var/run/docker.sock")
+    monkeypatch.setattr(system, "_current_project_head_sha", lambda: "0123456789ab")
+    monkeypatch.setattr(system, "_ensure_updater_image_for_ref", lambda _root, tag, **_kwargs: tag)
+    started = {}
+
+    class FakeContainers:
+        def run(self, image, **kwargs):
+            started.update(kwargs)
+
+    class FakeClient:
+        containers = FakeContainers()
+
+    monkeypatch.setattr(system.docker, "from_env", lambda: FakeClient())
+
+    response = await system.updates_run(
+        system.UpdateRunRequest(ref="main", local_changes="abort", allow_unsigned=allow_unsigned)
+    )
+
+    expected = "true" if allow_unsigned else "false"
+    assert started["environment"]["AAVA_UPDATE_ALLOW_UNSIGNED"] == expected
+    job, _state_path, _log_path = system._read_update_job(response.job_id)
+    assert job["allow_unsigned"] is allow_unsigned
+
+
 @pytest.mark.asyncio
 async def test_updates_job_log_returns_full_log(monkeypatch, tmp_path) -> None:
     monkeypatch.setenv("PROJECT_ROOT", str(tmp_path))
@@ -105,6 +105,7 @@ const UpdatesPage = () => {
   const [updateCliHost, setUpdateCliHost] = useState(true);
   const [cliInstallPath, setCliInstallPath] = useState('');
   const [forceActiveCalls, setForceActiveCalls] = useState(false);
+  const [allowUnsigned, setAllowUnsigned] = useState(false);
   const [plan, setPlan] = useState<UpdatePlan | null>(null);
   const [planLoading, setPlanLoading] = useState(false);
   const [planError, setPlanError] = useState<string | null>(null);
@@ -401,7 +402,7 @@ const UpdatesPage = () => {
 
     const ok = await confirm({
       title: 'Proceed with Update?',
-      description: `Target: ${targetRef || 'unknown'}\nMode: ${targetMode === 'stable' ? 'stable release' : targetMode === 'main' ? 'main hotfixes' : 'advanced branch'}\nUpdate UI: ${includeUI ? 'yes' : 'no'}\nUpdate CLI: ${updateCliHost ? 'yes' : 'no'}\nLocal changes: ${localChangeText}\nWill rebuild: ${rebuild}\nWill restart: ${restart}\nSkipped services: ${skipped}\nFiles changed: ${plan.changed_file_count ?? 'unknown'}\nActive calls: ${typeof plan.active_calls === 'number' ? plan.active_calls : 'unknown'}${forceActiveCalls ? ' (override enabled)' : ''}\n\nServices may restart during update.`,
+      description: `Target: ${targetRef || 'unknown'}\nMode: ${targetMode === 'stable' ? 'stable release' : targetMode === 'main' ? 'main hotfixes' : 'advanced branch'}\nUpdate UI: ${includeUI ? 'yes' : 'no'}\nUpdate CLI: ${updateCliHost ? 'yes' : 'no'}\nLocal changes: ${localChangeText}\nWill rebuild: ${rebuild}\nWill restart: ${restart}\nSkipped services: ${skipped}\nFiles changed: ${plan.changed_file_count ?? 'unknown'}\nActive calls: ${typeof plan.active_calls === 'number' ? plan.active_calls : 'unknown'}${forceActiveCalls ? ' (override enabled)' : ''}\nSignature: ${allowUnsigned ? 'unsigned target accepted (--insecure)' : 'required'}\n\nServices may restart during update.`,
       confirmText: 'Start Update',
       variant: 'default'
     });
@@ -416,6 +417,7 @@ const UpdatesPage = () => {
         update_cli_host: updateCliHost,
         cli_install_path: cliInstallPath.trim() || null,
         force_active_calls: forceActiveCalls,
+        allow_unsigned: allowUnsigned,
       });
       const id = res.data.job_id;
       setJobId(id);
@@ -923,6 +925,15 @@ const UpdatesPage = () => {
         <div className="space-y-3">
           {runError && <div className="text-sm text-destructive">{runError}</div>}
           {renderUpdaterImageStatus('run')}
+          <label className="flex items-center gap-2 text-xs text-muted-foreground">
+            <input
+              type="checkbox"
+              checked={allowUnsigned}
+              onChange={(e) => setAllowUnsigned(e.target.checked)}
+              className="rounded border-border"
+            />
+            Accept a target that is unsigned or signed by a key not in the host keyring (a bad signature is always refused)
+          </label>
           <div className="flex items-center gap-2">
             <button
               onClick={runUpdate}
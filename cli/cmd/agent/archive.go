@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/archive"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/digest"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveRedact string
+	archiveJSON   bool
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Copy RCA reports, recordings and metrics to S3-compatible object storage",
+	Long: `Keep the evidence of calls and checks in object storage (AWS S3, MinIO,
+Ceph, Backblaze B2, Wasabi, ...) so it survives a rebuild of the host.
+
+The store is configured in .agent/storage.yaml (or AAVA_STORAGE_FILE):
+
+  endpoint: https://s3.eu-central-1.amazonaws.com   # default from region
+  region: eu-central-1
+  bucket: acme-pbx-evidence
+  prefix: pbx1            # default: this host's name
+  path_style: false       # true for MinIO and most self-hosted stores
+  sink: true              # archive every agent rca, check and digest report
+  recordings: true        # add a call's recordings and captures to its bundle
+  retention:              # retention tag per kind, for lifecycle rules
+    calls: 400d
+    recordings: 90d
+    checks: 90d
+    digests: 400d
+    metrics: 2y
+  tags:
+    site: berlin
+
+Credentials are AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and, for temporary
+credentials, AWS_SESSION_TOKEN, from the environment or .env.
+
+Objects are laid out per call and per day:
+
+  <prefix>/calls/<call_id>/rca.json, rca.html, recordings and captures
+  <prefix>/checks/<date>/<time>.json
+  <prefix>/digests/<date>/<time>.json
+  <prefix>/metrics/<date>/samples.jsonl, checks.jsonl, calls.json, ...
+
+Each object is tagged kind=<kind>, retention=<value> and the configured tags.
+The CLI does not delete anything; add lifecycle rules on the retention tag
+to the bucket to expire objects.`,
+}
+
+var archiveCallCmd = &cobra.Command{
+	Use:   "call [call_id...]",
+	Short: "Analyze calls and upload their RCA bundle",
+	Long: `Analyze each call as agent rca --no-llm does and upload its bundle: the
+report as JSON and as the HTML page of agent rca --format html, and, with
+recordings: true in storage.yaml, the call's Asterisk recordings and
+agent capture packet captures. Without a call ID the most recent call is
+archived.
+
+--redact masks personal data in the report and page as agent rca --redact
+does; recordings and captures are uploaded as they are.
+
+Examples:
+  agent archive call 1761518880.2191
+  agent archive call --redact 1761518880.2191 1761518900.2200`,
+	ValidArgsFunction: completeCallIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var redactor *redact.Redactor
+		if cmd.Flags().Changed("redact") {
+			var err error
+			if redactor, err = redact.Parse(archiveRedact); err != nil {
+				return contract.UsageError(err)
+			}
+			redactor.SetNER(redact.NERFromEnv())
+		}
+		arc, err := openArchive()
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			args = []string{"last"}
+		}
+		plugins := plugin.Load()
+		res := newArchiveResult(arc)
+		for _, callID := range args {
+			archiveCall(arc, res, callID, redactor, plugins)
+		}
+		return res.finish()
+	},
+}
+
+var archiveMetricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Upload the quality and check histories, the call index and the engine's metrics",
+	Long: `Upload today's copy of the histories the CLI keeps: the call quality
+samples and events (.agent/quality/*.jsonl, with the agent check and
+resource histories), the call index (.agent/calls.json, which holds caller
+numbers) and a snapshot of ai_engine's Prometheus /metrics. Running it again
+the same day replaces that day's copy, so a daily cron job keeps one copy
+per day:
+
+  30 6 * * * cd /opt/asterisk-ai-voice-agent && agent archive metrics`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		arc, err := openArchive()
+		if err != nil {
+			return err
+		}
+		res := newArchiveResult(arc)
+		now := time.Now()
+		files, _ := filepath.Glob(filepath.Join(troubleshoot.TrendDir(), "*.jsonl"))
+		sort.Strings(files)
+		files = append(files, troubleshoot.CallIndexPath())
+		for _, file := range files {
+			if _, err := os.Stat(file); err != nil {
+				continue
+			}
+			key := arc.MetricsKey(filepath.Base(file), now)
+			res.add(archive.KindMetrics, key, file, arc.PutFile(archive.KindMetrics, key, file))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if text, err := engineapi.New().Metrics(ctx); err != nil {
+			res.Errors = append(res.Errors, "engine metrics: "+err.Error())
+		} else {
+			key := arc.MetricsKey("engine-metrics.prom", now)
+			res.add(archive.KindMetrics, key, "ai_engine /metrics", arc.PutBytes(archive.KindMetrics, key, []byte(text), "text/plain; version=0.0.4"))
+		}
+		return res.finish()
+	},
+}
+
+// openArchive loads storage.yaml and the credentials for the archive commands.
+func openArchive() (*archive.Archive, error) {
+	troubleshoot.LoadEnvFile()
+	cfg, err := archive.Load(archive.Path())
+	if err != nil {
+		return nil, contract.UsageError(err)
+	}
+	if cfg == nil {
+		return nil, contract.UsageError(fmt.Errorf("no object storage configured; create %s (see agent archive --help)", archive.Path()))
+	}
+	arc, err := archive.New(cfg, deploymentHost())
+	if err != nil {
+		return nil, contract.UsageError(err)
+	}
+	return arc, nil
+}
+
+// archiveCall uploads one call's bundle. The analysis runs plugins but no
+// sinks or hooks: the report is being archived, not reported again.
+func archiveCall(arc *archive.Archive, res *archiveResult, callID string, red *redact.Redactor, plugins []plugin.Plugin) {
+	runner := troubleshoot.NewRunner(callID, "", false, false, true, false, false, false, verbose)
+	runner.SetSilent(true)
+	runner.SetRedactor(red)
+	runner.SetPlugins(plugins, version)
+	if err := runner.Run(); err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("call %s: %v", callID, err))
+		return
+	}
+	rep := runner.Report()
+	if rep == nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("call %s: no analysis", callID))
+		return
+	}
+	key := arc.CallKey(rep.CallID, "rca.json")
+	res.add(archive.KindCall, key, "report", arc.PutJSON(archive.KindCall, key, rep))
+	var page bytes.Buffer
+	if err := runner.WriteHTML(&page, version); err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("call %s: HTML report: %v", rep.CallID, err))
+	} else {
+		key := arc.CallKey(rep.CallID, "rca.html")
+		res.add(archive.KindCall, key, "HTML report", arc.PutBytes(archive.KindCall, key, page.Bytes(), "text/html; charset=utf-8"))
+	}
+	archiveCallFiles(arc, rep.CallID, res)
+}
+
+// archiveCallFiles uploads the call's recordings and captures when storage.yaml asks for them.
+func archiveCallFiles(arc *archive.Archive, callID string, res *archiveResult) {
+	if !arc.Config().Recordings {
+		return
+	}
+	files, err := troubleshoot.CallFiles(callID, filepath.Join(".agent", "captures"), recordingPath())
+	if err != nil {
+		res.Errors = append(res.Errors, fmt.Sprintf("call %s: %v", callID, err))
+	}
+	for _, f := range files {
+		key := arc.CallKey(callID, filepath.Base(f.Path))
+		res.add(archive.KindRecording, key, f.Path, arc.PutFile(archive.KindRecording, key, f.Path))
+	}
+}
+
+// archiveSink archives a finished report when storage.yaml has sink: true.
+// Like the sink plugins it runs after the report is printed, so failures go
+// to stderr and do not change the exit code.
+func archiveSink(kind string, report any) {
+	cfg, err := archive.Load(archive.Path())
+	if cfg == nil || !cfg.Sink {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "archive: %v\n", err)
+		}
+		return
+	}
+	troubleshoot.LoadEnvFile()
+	arc, err := archive.New(cfg, deploymentHost())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "archive: %v\n", err)
+		return
+	}
+	res := newArchiveResult(arc)
+	switch rep := report.(type) {
+	case *troubleshoot.RCAReport:
+		key := arc.CallKey(rep.CallID, "rca.json")
+		res.add(archive.KindCall, key, "report", arc.PutJSON(archive.KindCall, key, rep))
+		archiveCallFiles(arc, rep.CallID, res)
+	case *check.Report:
+		key := arc.ReportKey(archive.KindCheck, rep.Timestamp)
+		res.add(archive.KindCheck, key, "report", arc.PutJSON(archive.KindCheck, key, rep))
+	case *digest.Digest:
+		key := arc.ReportKey(archive.KindDigest, rep.To)
+		res.add(archive.KindDigest, key, "digest", arc.PutJSON(archive.KindDigest, key, rep))
+	default:
+		return
+	}
+	for _, e := range res.Errors {
+		fmt.Fprintf(os.Stderr, "archive: %s\n", e)
+	}
+	if verbose {
+		for _, u := range res.Uploaded {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Archived %s\n", u.Key)
+		}
+	}
+}
+
+// archiveResult collects the uploads of an archive command for its output.
+type archiveResult struct {
+	SchemaVersion int              `json:"schema_version"`
+	Location      string           `json:"location"`
+	Uploaded      []archivedObject `json:"uploaded"`
+	Errors        []string         `json:"errors,omitempty"`
+}
+
+type archivedObject struct {
+	Kind   string `json:"kind"`
+	Key    string `json:"key"`
+	Source string `json:"source"`
+}
+
+func newArchiveResult(arc *archive.Archive) *archiveResult {
+	return &archiveResult{SchemaVersion: contract.SchemaVersion, Location: arc.Location(), Uploaded: []archivedObject{}}
+}
+
+func (r *archiveResult) add(kind, key, source string, err error) {
+	if err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", source, err))
+		return
+	}
+	r.Uploaded = append(r.Uploaded, archivedObject{Kind: kind, Key: key, Source: source})
+}
+
+// finish prints what was uploaded. Anything that failed is an environment error.
+func (r *archiveResult) finish() error {
+	if format := structuredOutput(archiveJSON); format.Structured() {
+		if err := output.Write(os.Stdout, format, r); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Archived %d object(s) to %s\n", len(r.Uploaded), r.Location)
+		for _, u := range r.Uploaded {
+			fmt.Printf("  %-10s %s\n", u.Kind, u.Key)
+		}
+		for _, e := range r.Errors {
+			fmt.Fprintf(os.Stderr, "❌ %s\n", e)
+		}
+	}
+	if len(r.Errors) > 0 {
+		return contract.Exit(contract.Environment, errors.New("some uploads failed"))
+	}
+	return nil
+}
+
+func init() {
+	archiveCallCmd.Flags().StringVar(&archiveRedact, "redact", "", "mask personal data in the report: all, or a list of phone, email, card, name")
+	archiveCallCmd.Flags().Lookup("redact").NoOptDefVal = "all"
+	for _, c := range []*cobra.Command{archiveCallCmd, archiveMetricsCmd} {
+		c.Flags().BoolVar(&archiveJSON, "json", false, "output as JSON")
+	}
+	archiveCmd.AddCommand(archiveCallCmd, archiveMetricsCmd)
+	rootCmd.AddCommand(archiveCmd)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/wizard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ariUserName     string
+	ariUserPassword string
+	ariUserPort     string
+	ariUserBind     string
+	ariUserApply    bool
+	ariUserNoEnv    bool
+)
+
+var ariUserCmd = &cobra.Command{
+	Use:   "ari-user",
+	Short: "Create the ARI user ai_engine logs in with",
+	Long: `Generate the ari.conf and http.conf stanzas for an ARI user with a random
+strong password.
+
+Without --apply the stanzas are printed with the file each belongs in. With
+--apply they are written into the Asterisk configuration (docker exec into the
+Asterisk container, or /etc/asterisk on this host), ARI and HTTP are reloaded,
+ASTERISK_ARI_USERNAME/ASTERISK_ARI_PASSWORD are written to .env, and the login
+is tested. Re-running replaces the stanzas written earlier.
+
+When ari.conf or http.conf include *_custom.conf files (FreePBX), the stanzas
+go there so GUI-managed files are not edited.
+
+Examples:
+  agent config ari-user
+  agent config ari-user --apply
+  agent config ari-user --username aava --port 8088 --apply`,
+	Args: cobra.NoArgs,
+	RunE: runARIUser,
+}
+
+func init() {
+	ariUserCmd.Flags().StringVar(&ariUserName, "username", "AsteriskAIVoiceAgent", "ARI username")
+	ariUserCmd.Flags().StringVar(&ariUserPassword, "password", "", "ARI password (default: random 32 characters)")
+	ariUserCmd.Flags().StringVar(&ariUserPort, "port", "8088", "http.conf bindport ARI is served on")
+	ariUserCmd.Flags().StringVar(&ariUserBind, "bind", "0.0.0.0", "http.conf bindaddr")
+	ariUserCmd.Flags().BoolVar(&ariUserApply, "apply", false, "write the stanzas, reload Asterisk, update .env and test the login")
+	ariUserCmd.Flags().BoolVar(&ariUserNoEnv, "no-env", false, "with --apply, do not update .env")
+	configCmd.AddCommand(ariUserCmd)
+}
+
+// ariTarget is one stanza and the file it is written to.
+type ariTarget struct {
+	file   string
+	stanza string
+}
+
+func runARIUser(cmd *cobra.Command, args []string) error {
+	if strings.ContainsAny(ariUserName, "[]; \t") || ariUserName == "" {
+		return fmt.Errorf("invalid ARI username %q", ariUserName)
+	}
+	password := ariUserPassword
+	if password == "" {
+		p, err := asterisk.RandomPassword()
+		if err != nil {
+			return err
+		}
+		password = p
+	}
+	if strings.ContainsAny(password, ";\n") {
+		return fmt.Errorf("password must not contain ';' or newlines")
+	}
+
+	targets := ariUserTargets(ariUserName, password)
+	if !ariUserApply {
+		for _, t := range targets {
+			fmt.Printf("; ---- %s ----\n%s\n", t.file, t.stanza)
+		}
+		fmt.Println("Then run:  asterisk -rx \"module reload http\" && asterisk -rx \"module reload res_ari.so\"")
+		fmt.Printf("and set in .env:  ASTERISK_ARI_USERNAME=%s  ASTERISK_ARI_PASSWORD=%s\n", ariUserName, password)
+		fmt.Println("Or re-run with --apply to do all of this and test the login.")
+		return nil
+	}
+
+	for _, t := range targets {
+		current, _ := asterisk.ReadFile(t.file)
+		if err := asterisk.WriteFile(t.file, asterisk.UpsertBlock(current, t.stanza)); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Updated %s\n", t.file)
+	}
+	for _, module := range []string{"http", "res_ari.so"} {
+		if _, _, err := asterisk.CLI("module reload " + module); err != nil {
+			return fmt.Errorf("reload %s: %w", module, err)
+		}
+	}
+	fmt.Println("✅ Reloaded http and res_ari")
+
+	cfg, err := wizard.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.AsteriskUsername, cfg.AsteriskPassword = ariUserName, password
+	if ariUserPort != "8088" || cfg.AsteriskARIPort != "" {
+		cfg.AsteriskARIPort = ariUserPort
+	}
+	if cfg.AsteriskHost == "" {
+		cfg.AsteriskHost = "127.0.0.1"
+	}
+	if !ariUserNoEnv {
+		if err := cfg.SaveEnv(); err != nil {
+			return fmt.Errorf("failed to save .env: %w", err)
+		}
+		fmt.Printf("✅ Wrote ASTERISK_ARI_USERNAME/ASTERISK_ARI_PASSWORD to %s\n", cfg.EnvPath)
+	} else {
+		fmt.Printf("   ASTERISK_ARI_USERNAME=%s\n   ASTERISK_ARI_PASSWORD=%s\n", ariUserName, password)
+	}
+
+	if err := wizard.TestARIConnectivity(cfg.ARIBaseURL(), ariUserName, password); err != nil {
+		fmt.Printf("❌ ARI login at %s failed: %v\n", cfg.ARIBaseURL(), err)
+		return fmt.Errorf("ARI user written but login failed")
+	}
+	fmt.Printf("✅ ARI login works at %s\n", cfg.ARIBaseURL())
+	if !ariUserNoEnv {
+		fmt.Println("   Recreate ai_engine to pick up the new credentials: docker compose up -d --force-recreate ai_engine")
+	}
+	return nil
+}
+
+// ariUserTargets places each stanza. ARI and HTTP are only enabled when the current
+// configuration does not already do so, to avoid duplicate [general] sections.
+func ariUserTargets(username, password string) []ariTarget {
+	ariConf, _ := asterisk.ReadConf("ari.conf")
+	httpConf, _ := asterisk.ReadConf("http.conf")
+	in := func(name string) string { return path.Join(asterisk.ConfigDir, name) }
+
+	var targets []ariTarget
+	if !confEnabled(ariConf) {
+		targets = append(targets, ariTarget{in(asterisk.IncludeTarget(ariConf, "ari.conf", "ari_general_custom.conf")), asterisk.ARIGeneralStanza()})
+	}
+	targets = append(targets, ariTarget{in(asterisk.IncludeTarget(ariConf, "ari.conf", "ari_additional_custom.conf")), asterisk.ARIUserStanza(username, password)})
+	port := asterisk.ParseConf(httpConf)["general"]["bindport"]
+	if port == "" {
+		port = "8088"
+	}
+	if !confEnabled(httpConf) || port != ariUserPort {
+		targets = append(targets, ariTarget{in(asterisk.IncludeTarget(httpConf, "http.conf", "http_custom.conf")), asterisk.HTTPStanza(ariUserBind, ariUserPort)})
+	}
+	return targets
+}
+
+func confEnabled(text string) bool {
+	switch strings.ToLower(asterisk.ParseConf(text)["general"]["enabled"]) {
+	case "yes", "true", "1", "on":
+		return true
+	}
+	return false
+}
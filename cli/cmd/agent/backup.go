@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/backup"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupJSON           bool
+	backupShowSecrets    bool
+	backupOutput         string
+	backupIncludeMedia   bool
+	backupPassphraseFile string
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Create encrypted backups and restore update or migration backups",
+	Long: `Every agent update copies .env, config/ai-agent*.yaml, config/users.json,
+config/contexts/, config/overlays/ and the operator databases to
+.agent/update-backups/<timestamp>.
+
+A backup is named by its timestamp. Any unique prefix works, and "latest" picks
+the newest one.
+
+agent backup create writes the whole deployment state to one encrypted archive
+for moving it to a new host; agent backup restore <file> unpacks it there.
+
+Examples:
+  agent backup list
+  agent backup diff latest
+  agent backup diff 20260101_020000 config/ai-agent.local.yaml
+  agent backup restore 20260101_020000 config/ai-agent.local.yaml
+  agent backup create -o /tmp/pbx1.aava
+  agent backup restore /tmp/pbx1.aava`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List update backups, newest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := resolveRepoRootForFix()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		all, err := backup.List(root)
+		if err != nil {
+			return err
+		}
+		if structuredOutput(backupJSON).Structured() {
+			if all == nil {
+				all = []backup.Snapshot{}
+			}
+			return writeBackupJSON(struct {
+				SchemaVersion int               `json:"schema_version"`
+				Backups       []backup.Snapshot `json:"backups"`
+			}{contract.SchemaVersion, all})
+		}
+		if len(all) == 0 {
+			fmt.Println("No update backups yet. agent update creates one before changing anything.")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintln(tw, "BACKUP\tCREATED\tFILES")
+		for _, s := range all {
+			fmt.Fprintf(tw, "%s\t%s\t%d\n", s.ID, s.Created.Local().Format("2006-01-02 15:04"), len(s.Files))
+		}
+		return tw.Flush()
+	},
+}
+
+var backupDiffCmd = &cobra.Command{
+	Use:   "diff <timestamp> [path]",
+	Short: "Compare a backup with the current config",
+	Long: `Show what changed between a backup and the current checkout, optionally limited
+to one file or directory. .env values are replaced by a short fingerprint unless
+--show-secrets is given, so changed keys are still visible.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, snap, err := findBackup(args[0])
+		if err != nil {
+			return err
+		}
+		changes, err := snap.Diff(root, optionalArg(args, 1), !backupShowSecrets)
+		if err != nil {
+			return err
+		}
+		if structuredOutput(backupJSON).Structured() {
+			if changes == nil {
+				changes = []backup.Change{}
+			}
+			return writeBackupJSON(struct {
+				SchemaVersion int             `json:"schema_version"`
+				Backup        string          `json:"backup"`
+				Changes       []backup.Change `json:"changes"`
+			}{contract.SchemaVersion, snap.ID, changes})
+		}
+		if len(changes) == 0 {
+			fmt.Printf("No differences from backup %s\n", snap.ID)
+			return nil
+		}
+		for _, c := range changes {
+			switch {
+			case c.Status == "missing":
+				fmt.Printf("Only in backup: %s\n", c.Path)
+			case c.Status == "added":
+				fmt.Printf("Not in backup: %s\n", c.Path)
+			case c.Binary:
+				fmt.Printf("Binary file %s differs\n", c.Path)
+			default:
+				fmt.Print(c.Diff)
+			}
+		}
+		return nil
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <timestamp|archive> [path]",
+	Short: "Restore a backup, or one file or directory from it",
+	Long: `Copy files from a backup back into the checkout. The files being replaced are
+saved first to a pre-restore-<timestamp> backup, so a restore can be undone.
+
+Databases under data/ are restored only when named explicitly, and only while
+ai_engine is stopped. Restart the containers afterwards to load the restored config.
+
+Given an archive written by agent backup create, everything in it is restored,
+including the databases, and ai_engine must be stopped.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if info, err := os.Stat(args[0]); err == nil && info.Mode().IsRegular() {
+			return restoreArchive(args[0], optionalArg(args, 1))
+		}
+		root, snap, err := findBackup(args[0])
+		if err != nil {
+			return err
+		}
+		path := optionalArg(args, 1)
+		explicitDB := strings.HasSuffix(path, ".db")
+		if explicitDB && aiEngineRunning() {
+			return contract.EnvironmentError(errors.New("ai_engine is running; stop it before restoring a database (docker compose stop ai_engine)"))
+		}
+		restored, undoID, err := snap.Restore(root, path, func(rel string) bool {
+			return strings.HasPrefix(rel, "data/") && !explicitDB
+		})
+		if err != nil {
+			if len(restored) == 0 {
+				return contract.UsageError(err)
+			}
+			return err
+		}
+		if structuredOutput(backupJSON).Structured() {
+			return writeBackupJSON(struct {
+				SchemaVersion int      `json:"schema_version"`
+				Backup        string   `json:"backup"`
+				Restored      []string `json:"restored"`
+				Undo          string   `json:"undo,omitempty"`
+			}{contract.SchemaVersion, snap.ID, restored, undoID})
+		}
+		label := "restored backup " + snap.ID
+		if path != "" {
+			label = fmt.Sprintf("restored %s from backup %s", path, snap.ID)
+		}
+		recordTrendEvent(troubleshoot.EventConfig, label)
+		for _, rel := range restored {
+			fmt.Printf("Restored %s from %s\n", rel, snap.ID)
+		}
+		if undoID != "" {
+			fmt.Printf("Previous files saved; undo with: agent backup restore %s\n", undoID)
+		}
+		fmt.Println("Restart to apply: docker compose up -d --force-recreate ai_engine admin_ui")
+		return nil
+	},
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Write the deployment state to an encrypted archive",
+	Long: `Archive .env, config/ (including contexts), secrets/, the .agent state (call
+index, reports and baselines) and online snapshots of the operator and call
+history databases. --include-media adds asterisk_media/ and the call recordings
+in ASTERISK_RECORDING_PATH.
+
+The archive is encrypted with AES-256-GCM under a passphrase read from
+AAVA_BACKUP_PASSPHRASE, --passphrase-file, or the terminal.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := resolveRepoRootForFix()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		host, _ := os.Hostname()
+		out := backupOutput
+		if out == "" {
+			out = filepath.Join(root, fmt.Sprintf("agent-backup-%s-%s.aava", sanitizeBackupID(host), time.Now().UTC().Format("20060102_150405")))
+		}
+		if out, err = filepath.Abs(out); err != nil {
+			return err
+		}
+		if err := os.Chdir(root); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		passphrase, err := backupPassphrase(true)
+		if err != nil {
+			return err
+		}
+
+		// Databases are snapshotted the same way agent update backs them up, so
+		// the archive is consistent while calls are running.
+		staging, err := os.MkdirTemp("", "agent-backup-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(staging)
+		for _, db := range archiveDatabases {
+			if err := backupSQLiteIfExists(db, staging); err != nil {
+				return err
+			}
+		}
+
+		sources := []backup.Source{
+			{Name: ".env", Path: ".env"},
+			{Name: "config", Path: "config"},
+			{Name: "secrets", Path: "secrets"},
+			{Name: ".agent", Path: ".agent"},
+			{Name: "data", Path: filepath.Join(staging, "data")},
+		}
+		if backupIncludeMedia {
+			sources = append(sources,
+				backup.Source{Name: "asterisk_media", Path: "asterisk_media"},
+				backup.Source{Name: "recordings", Path: recordingPath()},
+			)
+		}
+
+		tmp, err := os.CreateTemp(filepath.Dir(out), "."+filepath.Base(out)+".tmp-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		m, err := backup.CreateArchive(tmp, passphrase, backup.Manifest{
+			Created:    time.Now().UTC(),
+			Host:       host,
+			CLIVersion: version,
+			Media:      backupIncludeMedia,
+		}, sources, skipArchiveState)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), out); err != nil {
+			return err
+		}
+		info, _ := os.Stat(out)
+		fmt.Printf("Wrote %s (%d files, %.1f MB)\n", out, len(m.Files), float64(info.Size())/1e6)
+		fmt.Println("Keep the passphrase: the archive cannot be restored without it.")
+		fmt.Printf("On the new host: agent backup restore %s\n", filepath.Base(out))
+		return nil
+	},
+}
+
+// archiveDatabases are snapshotted into every archive.
+var archiveDatabases = []string{
+	filepath.Join("data", "operator", "agents.db"),
+	filepath.Join("data", "call_history.db"),
+}
+
+// skipArchiveState drops .agent content that is host-specific or is itself a
+// backup: update snapshots, update locks and jobs, the installed CLI binary,
+// fleet logs and packet captures.
+func skipArchiveState(name string) bool {
+	for _, p := range []string{".agent/update-backups", ".agent/check-fix-backups", ".agent/updates", ".agent/bin", ".agent/fleet/logs", ".agent/captures"} {
+		if name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func recordingPath() string {
+	if v := os.Getenv("ASTERISK_RECORDING_PATH"); v != "" {
+		return v
+	}
+	if v, _ := dotenvValue(".env", "ASTERISK_RECORDING_PATH"); v != "" {
+		return v
+	}
+	return "/var/spool/asterisk/monitor"
+}
+
+// restoreArchive unpacks an archive from agent backup create into the checkout.
+func restoreArchive(file, path string) error {
+	root, err := resolveRepoRootForFix()
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	if file, err = filepath.Abs(file); err != nil {
+		return err
+	}
+	if err := os.Chdir(root); err != nil {
+		return contract.EnvironmentError(err)
+	}
+	if aiEngineRunning() {
+		return contract.EnvironmentError(errors.New("ai_engine is running; stop the stack before restoring an archive (docker compose stop ai_engine admin_ui)"))
+	}
+	passphrase, err := backupPassphrase(false)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	a, err := backup.OpenArchive(f, passphrase)
+	if err != nil {
+		if errors.Is(err, backup.ErrPassphrase) {
+			return contract.UsageError(err)
+		}
+		return err
+	}
+	defer a.Close()
+
+	recordings := recordingPath()
+	var local []string
+	for _, name := range a.Manifest.Files {
+		if backup.Covers(path, name) && !strings.HasPrefix(name, "recordings/") {
+			local = append(local, name)
+		}
+	}
+	undoID, err := backup.Preserve(root, local)
+	if err != nil {
+		return err
+	}
+	// A WAL left next to a restored database would be replayed into it.
+	for _, db := range archiveDatabases {
+		name := filepath.ToSlash(db)
+		if backup.Covers(path, name) && contains(a.Manifest.Files, name) {
+			for _, suffix := range []string{"-wal", "-shm"} {
+				if !contains(a.Manifest.Files, name+suffix) {
+					_ = os.Remove(db + suffix)
+				}
+			}
+		}
+	}
+	restored, err := a.Extract(func(name string) string {
+		switch {
+		case !backup.Covers(path, name):
+			return ""
+		case strings.HasPrefix(name, "recordings/"):
+			return filepath.Join(recordings, filepath.FromSlash(strings.TrimPrefix(name, "recordings/")))
+		}
+		return filepath.Join(root, filepath.FromSlash(name))
+	})
+	if err != nil {
+		return err
+	}
+	if len(restored) == 0 {
+		return contract.UsageError(fmt.Errorf("archive has no file matching %q", path))
+	}
+	recordTrendEvent(troubleshoot.EventConfig, "restored archive "+filepath.Base(file))
+	if structuredOutput(backupJSON).Structured() {
+		return writeBackupJSON(struct {
+			SchemaVersion int             `json:"schema_version"`
+			Archive       string          `json:"archive"`
+			Manifest      backup.Manifest `json:"manifest"`
+			Restored      []string        `json:"restored"`
+			Undo          string          `json:"undo,omitempty"`
+		}{contract.SchemaVersion, file, a.Manifest, restored, undoID})
+	}
+	fmt.Printf("Restored %d files from %s (created %s on %s)\n", len(restored), file, a.Manifest.Created.Local().Format("2006-01-02 15:04"), emptyDash(a.Manifest.Host))
+	if undoID != "" {
+		fmt.Printf("Previous files saved; undo with: agent backup restore %s\n", undoID)
+	}
+	fmt.Println("Review .env for host-specific values (ASTERISK_HOST, addresses), then start the stack: docker compose up -d")
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// backupPassphrase reads the archive passphrase from AAVA_BACKUP_PASSPHRASE,
+// --passphrase-file or the terminal, asking twice when creating an archive.
+func backupPassphrase(confirm bool) ([]byte, error) {
+	if v := os.Getenv("AAVA_BACKUP_PASSPHRASE"); v != "" {
+		return []byte(v), nil
+	}
+	if backupPassphraseFile != "" {
+		data, err := os.ReadFile(backupPassphraseFile)
+		if err != nil {
+			return nil, contract.UsageError(err)
+		}
+		if p := strings.TrimRight(string(data), "\r\n"); p != "" {
+			return []byte(p), nil
+		}
+		return nil, contract.UsageError(fmt.Errorf("%s is empty", backupPassphraseFile))
+	}
+	if !stdinIsTerminal() {
+		return nil, contract.UsageError(errors.New("no passphrase: set AAVA_BACKUP_PASSPHRASE or use --passphrase-file"))
+	}
+	p := readSecret("Backup passphrase: ")
+	if p == "" {
+		return nil, contract.UsageError(errors.New("empty passphrase"))
+	}
+	if confirm && readSecret("Repeat passphrase: ") != p {
+		return nil, contract.UsageError(errors.New("passphrases do not match"))
+	}
+	return []byte(p), nil
+}
+
+func readSecret(prompt string) string {
+	fmt.Fprint(os.Stderr, prompt)
+	setTerminalEcho(false)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	setTerminalEcho(true)
+	fmt.Fprintln(os.Stderr)
+	return strings.TrimRight(line, "\r\n")
+}
+
+func findBackup(id string) (string, *backup.Snapshot, error) {
+	root, err := resolveRepoRootForFix()
+	if err != nil {
+		return "", nil, contract.EnvironmentError(err)
+	}
+	snap, err := backup.Find(root, id)
+	if err != nil {
+		return "", nil, contract.UsageError(err)
+	}
+	return root, snap, nil
+}
+
+func optionalArg(args []string, i int) string {
+	if len(args) > i {
+		return args[i]
+	}
+	return ""
+}
+
+func writeBackupJSON(v any) error {
+	return output.Write(os.Stdout, structuredOutput(backupJSON), v)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{backupListCmd, backupDiffCmd, backupRestoreCmd} {
+		c.Flags().BoolVar(&backupJSON, "json", false, "output as JSON")
+	}
+	backupDiffCmd.Flags().BoolVar(&backupShowSecrets, "show-secrets", false, "print .env values instead of fingerprints")
+	backupCreateCmd.Flags().StringVarP(&backupOutput, "output-file", "o", "", "archive to write (default agent-backup-<host>-<time>.aava)")
+	backupCreateCmd.Flags().BoolVar(&backupIncludeMedia, "include-media", false, "also archive asterisk_media/ and call recordings")
+	for _, c := range []*cobra.Command{backupCreateCmd, backupRestoreCmd} {
+		c.Flags().StringVar(&backupPassphraseFile, "passphrase-file", "", "read the archive passphrase from this file")
+	}
+
+	backupCmd.AddCommand(backupListCmd, backupDiffCmd, backupCreateCmd, backupRestoreCmd)
+	rootCmd.AddCommand(backupCmd)
+}
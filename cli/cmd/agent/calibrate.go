@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/calibrate"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+// calibrateIDBase keeps calibration call IDs apart from load test, chaos and
+// failover drill ones.
+const calibrateIDBase = 820000
+
+// calibrateCallerWaitMS is the Wait(1) the load test caller context runs
+// before it plays the recording. The recording ends in --hold of silence for
+// the reply, so the caller waits only calibrateCallerHold more after it.
+const (
+	calibrateCallerWaitMS = 1000
+	calibrateCallerHold   = 2
+)
+
+// calibratePhrases are the two halves of the test utterance; the pause goes
+// between them, where a caller would stop to think.
+var calibratePhrases = [2]string{"I'd like to book an appointment", "for next Tuesday afternoon, please."}
+
+var (
+	calibrateDir      = filepath.Join(".agent", "calibration")
+	calibrateSoundDir = filepath.Join("asterisk_media", "ai-generated")
+)
+
+var (
+	calibrateProvider string
+	calibrateContext  string
+	calibrateTTS      string
+	calibratePauses   []int
+	calibrateMaxPause int
+	calibrateLead     time.Duration
+	calibrateHold     int
+	calibrateReplay   bool
+	calibrateYes      bool
+	calibrateJSON     bool
+)
+
+var calibrateCmd = &cobra.Command{
+	Use:   "calibrate",
+	Short: "Measure end-of-speech detection with test calls and tune the silence timer",
+	Long: `Find how long a caller can pause before the agent takes the turn, and set
+the silence timer that ends caller turns to match.
+
+Each test call speaks one sentence with a pause of known length in the middle
+("I'd like to book an appointment ... for next Tuesday afternoon, please"),
+plus one call without a pause. The sentence is synthesized once with the
+engine's TTS (--tts) and saved under .agent/calibration/, so --replay runs the
+same calls again after a change (--pauses, --lead and --hold then come from
+the saved calls). Calls run one at a time through the
+[aava-loadtest-caller] context, as agent loadtest does, after --lead of
+silence for the agent's greeting.
+
+For each call the engine logs give the delay from the end of the caller's
+speech to the agent's reply (and, for providers that log their turn latency,
+how much of it passed before the turn ended), and whether the pause cut the
+turn: the agent replied or a provider turn started before the caller
+finished, or Call History holds the sentence as two caller messages.
+
+The recommendation is the timer that keeps pauses up to --max-pause inside the
+caller's turn, plus 150ms, and no longer: it is raised when a shorter pause
+cut the turn and lowered when longer pauses did not. The timer is:
+
+  openai_realtime, grok   providers.<name>.turn_detection.silence_duration_ms
+  google_live             providers.<name>.vad_silence_duration_ms
+  local STT pipelines     pipelines.<name>.options.stt.segment_silence_ms
+  Azure STT pipelines     pipelines.<name>.options.stt.vad_silence_timeout_ms
+
+Other providers end turns inside their own service and are measured only.
+On confirmation (or with --yes) the value is written to
+config/ai-agent.local.yaml.
+
+The calls use real provider sessions and are billed. Exits with the warning
+code when the timer should change and was not written, and with the failure
+code when no call reached the engine.
+
+Examples:
+  agent calibrate
+  agent calibrate --provider openai_realtime --max-pause 900
+  agent calibrate --provider local_hybrid --pauses 300,500,800,1200
+  agent calibrate --provider local_hybrid --replay`,
+	Args: cobra.NoArgs,
+	RunE: runCalibrate,
+}
+
+func runCalibrate(cmd *cobra.Command, args []string) error {
+	if calibrateMaxPause < 100 || calibrateMaxPause > 3000 {
+		return contract.UsageError(errors.New("--max-pause must be from 100 to 3000 ms"))
+	}
+	for _, p := range calibratePauses {
+		if p < 100 || p > 5000 {
+			return contract.UsageError(fmt.Errorf("pause %dms is out of range; --pauses must be from 100 to 5000 ms", p))
+		}
+	}
+	if calibrateLead < 2*time.Second || calibrateHold < 3 {
+		return contract.UsageError(errors.New("--lead must be at least 2s and --hold at least 3 seconds"))
+	}
+	troubleshoot.LoadEnvFile()
+	format := structuredOutput(calibrateJSON)
+	progress := os.Stdout
+	if format.Structured() {
+		progress = os.Stderr
+	}
+
+	cfg, err := failoverConfig()
+	if err != nil {
+		return err
+	}
+	name := calibrateProvider
+	if name == "" {
+		name, _ = cfg["default_provider"].(string)
+	}
+	if name == "" {
+		return contract.UsageError(errors.New("default_provider is not set; pass --provider"))
+	}
+	knob, why, err := calibrate.ResolveKnob(cfg, name)
+	if err != nil {
+		return contract.UsageError(err)
+	}
+	if knob != nil {
+		fmt.Fprintf(progress, "Calibrating %s: %s is %dms", name, knob.Key, knob.CurrentMS)
+		if knob.Default {
+			fmt.Fprint(progress, " (default)")
+		}
+		fmt.Fprintln(progress)
+	} else {
+		fmt.Fprintf(progress, "⚠️  %s; measuring only\n", why)
+	}
+
+	ari, err := loadtestARIConfig()
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	agentContext := calibrateContext
+	if agentContext == "" {
+		agentContext = dialplan.ContextName(name)
+	}
+	if err := verifyLoadtestDialplan(agentContext, ari.AppName); err != nil {
+		return contract.EnvironmentError(err)
+	}
+	if info, err := os.Stat(calibrateSoundDir); err != nil || !info.IsDir() {
+		return contract.EnvironmentError(fmt.Errorf("%s not found; run from the project directory, where ai_engine shares it with Asterisk", calibrateSoundDir))
+	}
+
+	patterns, audio, err := calibratePatterns(cfg, name, progress)
+	if err != nil {
+		return err
+	}
+	var total time.Duration
+	for _, p := range patterns {
+		total += time.Duration(calibrateCallerWaitMS+p.LengthMS)*time.Millisecond + calibrateCallerHold*time.Second
+	}
+	if !calibrateConfirm(format, fmt.Sprintf("Place %d test calls into [%s], one at a time (about %s)? They are billed like real calls.", len(patterns), agentContext, total.Round(time.Second))) {
+		return contract.Exit(contract.Warn, errors.New("calibration cancelled; no calls were placed"))
+	}
+	for i, p := range patterns {
+		path := filepath.Join(calibrateSoundDir, p.Name+".ulaw")
+		if err := os.WriteFile(path, audio[i], 0o644); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		defer os.Remove(path)
+	}
+
+	start := time.Now()
+	ids := make([]string, len(patterns))
+	for i, p := range patterns {
+		ids[i] = fmt.Sprintf("%d.%d", start.Unix(), calibrateIDBase+i+1)
+		fmt.Fprintf(progress, "  📞 %s: %dms pause...\n", ids[i], p.PauseMS)
+		if err := originateLoadtestCall(ari, agentContext, ids[i], i+1, "ai-generated/"+p.Name, calibrateCallerHold); err != nil {
+			return contract.EnvironmentError(fmt.Errorf("test call failed: %w", err))
+		}
+		waitLoadtestCalls(ari, []string{ids[i] + "-caller"}, time.Duration(calibrateCallerWaitMS+p.LengthMS)*time.Millisecond+time.Minute)
+	}
+	fmt.Fprintln(progress, "Reading the engine logs and Call History...")
+	time.Sleep(3 * time.Second)
+	lines, err := loadtestLogLines(time.Since(start) + time.Minute)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+
+	calls := make([]troubleshoot.EndpointingCall, len(patterns))
+	logged := 0
+	for i, p := range patterns {
+		turns := -1
+		if transcript, err := troubleshoot.CallTranscript(ids[i]); err == nil {
+			turns = 0
+			for _, t := range transcript {
+				if t.Role == "user" {
+					turns++
+				}
+			}
+		}
+		calls[i] = troubleshoot.AnalyzeEndpointing(ids[i], troubleshoot.FilterCallLines(lines, ids[i]), p.Schedule(calibrateCallerWaitMS), turns)
+		if calls[i].Logged {
+			logged++
+		}
+	}
+	rec := calibrate.Recommend(knob, calls, calibrateMaxPause)
+
+	if !format.Structured() {
+		printCalibration(calls, rec)
+	}
+	written := ""
+	if rec.Change && calibrateConfirm(format, fmt.Sprintf("Write %s: %d to config/ai-agent.local.yaml?", rec.Key, rec.RecommendedMS)) {
+		if written, err = writeCalibration(knob.Patch(rec.RecommendedMS)); err != nil {
+			return err
+		}
+		fmt.Fprintf(progress, "✓ Wrote %s: %d to %s\n", rec.Key, rec.RecommendedMS, written)
+		fmt.Fprintln(progress, "   New calls get it after: agent context reload (or a restart of ai_engine)")
+	} else if rec.Change {
+		fmt.Fprintf(progress, "Not written; set %s: %d yourself, or rerun with --replay --yes\n", rec.Key, rec.RecommendedMS)
+	}
+
+	if format.Structured() {
+		if err := output.Write(os.Stdout, format, map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"provider":       name,
+			"context":        agentContext,
+			"knob":           knob,
+			"max_pause_ms":   calibrateMaxPause,
+			"patterns":       patterns,
+			"calls":          calls,
+			"recommendation": rec,
+			"written":        written,
+		}); err != nil {
+			return err
+		}
+	}
+	switch {
+	case logged == 0:
+		return contract.Exit(contract.Fail, errors.New("no test call reached the engine; check the dialplan context and ARI"))
+	case rec.Change && written == "":
+		return contract.Exit(contract.Warn, nil)
+	}
+	return nil
+}
+
+// calibratePatterns loads the saved test calls for --replay, or synthesizes
+// the test phrases with the engine's TTS and saves a call for each pause.
+func calibratePatterns(cfg map[string]any, name string, progress *os.File) ([]calibrate.Pattern, [][]byte, error) {
+	if calibrateReplay {
+		patterns, audio, err := calibrate.Load(calibrateDir)
+		if err != nil {
+			return nil, nil, contract.UsageError(fmt.Errorf("%w; run agent calibrate without --replay first", err))
+		}
+		fmt.Fprintf(progress, "Replaying %d saved test calls from %s\n", len(patterns), calibrateDir)
+		return patterns, audio, nil
+	}
+	speaker := calibrateTTS
+	if _, ok := cfg["pipelines"].(map[string]any)[name]; ok && speaker == "" {
+		speaker = name
+	}
+	target, err := tts.Resolve(cfg, speaker, chaosEnv)
+	if err != nil {
+		return nil, nil, contract.UsageError(fmt.Errorf("%w; name the TTS that speaks the test caller with --tts", err))
+	}
+	fmt.Fprintf(progress, "Synthesizing the test caller with %s...\n", target.Label())
+	var clips [2][]byte
+	for i, text := range calibratePhrases {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		clip, err := tts.Synthesize(ctx, target, "", text)
+		cancel()
+		if err != nil {
+			return nil, nil, contract.EnvironmentError(err)
+		}
+		if clip.Encoding != "mulaw" && clip.Encoding != "ulaw" || clip.SampleRate != 8000 {
+			return nil, nil, contract.EnvironmentError(fmt.Errorf("%s speaks %s at %d Hz; the test caller needs 8 kHz μ-law, so pick another TTS with --tts", target.Label(), clip.Encoding, clip.SampleRate))
+		}
+		clips[i] = clip.Audio
+	}
+	lead := int(calibrateLead.Milliseconds())
+	pauses := append([]int{0}, calibratePauses...)
+	patterns := make([]calibrate.Pattern, len(pauses))
+	audio := make([][]byte, len(pauses))
+	for i, pause := range pauses {
+		patterns[i], audio[i] = calibrate.Compose(clips[0], clips[1], lead, pause, calibrateHold*1000)
+	}
+	if err := calibrate.Save(calibrateDir, patterns, audio); err != nil {
+		return nil, nil, err
+	}
+	return patterns, audio, nil
+}
+
+// calibrateConfirm asks question on a terminal; --yes answers it, and
+// structured or unattended runs without --yes decline.
+func calibrateConfirm(format output.Format, question string) bool {
+	if calibrateYes {
+		return true
+	}
+	if format.Structured() || !stdinIsTerminal() {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// writeCalibration merges patch into config/ai-agent.local.yaml, which the
+// engine applies over the base config and any environment overlay.
+func writeCalibration(patch map[string]any) (string, error) {
+	path := configmerge.LocalPath(filepath.Join("config", "ai-agent.yaml"))
+	local := map[string]any{}
+	if _, err := os.Stat(path); err == nil {
+		m, err := configmerge.ReadYAMLFile(path)
+		if err != nil {
+			return "", contract.EnvironmentError(fmt.Errorf("failed to parse %s: %w", path, err))
+		}
+		if m != nil {
+			local = m
+		}
+	} else if !os.IsNotExist(err) {
+		return "", contract.EnvironmentError(err)
+	}
+	if err := configmerge.WriteYAMLFileAtomic(path, configmerge.DeepMerge(local, patch)); err != nil {
+		return "", contract.EnvironmentError(err)
+	}
+	return path, nil
+}
+
+func printCalibration(calls []troubleshoot.EndpointingCall, r calibrate.Recommendation) {
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "CALL\tPAUSE\tCALLER TURNS\tREPLY\tBEFORE TURN END\tVERDICT")
+	for _, c := range calls {
+		turns, detection := "-", "-"
+		if c.CallerTurns >= 0 {
+			turns = fmt.Sprint(c.CallerTurns)
+		}
+		if c.DetectionMS != nil {
+			detection = fmt.Sprintf("%dms", *c.DetectionMS)
+		}
+		fmt.Fprintf(tw, "%s\t%dms\t%s\t%s\t%s\t%s\n", c.CallID, c.PauseMS, turns, loadtestMS(float64(c.ReplyMS)), detection, c.Verdict)
+	}
+	tw.Flush()
+	fmt.Println()
+	for _, c := range calls {
+		if c.Verdict != troubleshoot.EndpointOK {
+			fmt.Printf("  ⚠️  %s: %s\n", c.CallID, c.Finding)
+		}
+	}
+	if r.ReplyP50MS > 0 {
+		fmt.Printf("Median reply: %.1fs after the caller finished\n", float64(r.ReplyP50MS)/1000)
+	}
+	switch {
+	case r.Key == "":
+		fmt.Printf("ℹ️  %s\n", r.Reason)
+	case r.Change:
+		fmt.Printf("💡 %s: %dms → %dms (%s)\n", r.Key, r.CurrentMS, r.RecommendedMS, r.Reason)
+	default:
+		fmt.Printf("✓ %s: keep %dms (%s)\n", r.Key, r.CurrentMS, r.Reason)
+	}
+}
+
+func init() {
+	calibrateCmd.Flags().StringVar(&calibrateProvider, "provider", "", "provider or pipeline to calibrate (default: default_provider)")
+	calibrateCmd.Flags().StringVar(&calibrateContext, "context", "", "dialplan context the test calls enter (default: from --provider)")
+	calibrateCmd.Flags().StringVar(&calibrateTTS, "tts", "", "pipeline or *_tts provider that speaks the test caller (default: --provider when it is a pipeline, else active_pipeline)")
+	calibrateCmd.Flags().IntSliceVar(&calibratePauses, "pauses", []int{300, 600, 900, 1400}, "pauses (ms) inside the test sentence, one call each")
+	calibrateCmd.Flags().IntVar(&calibrateMaxPause, "max-pause", 700, "longest pause (ms) that must not end the caller's turn")
+	calibrateCmd.Flags().DurationVar(&calibrateLead, "lead", 8*time.Second, "silence before the caller speaks, for the agent's greeting")
+	calibrateCmd.Flags().IntVar(&calibrateHold, "hold", 8, "seconds of silence after the caller speaks, for the agent's reply")
+	calibrateCmd.Flags().BoolVar(&calibrateReplay, "replay", false, "replay the test calls saved by the last run instead of synthesizing new ones")
+	calibrateCmd.Flags().BoolVarP(&calibrateYes, "yes", "y", false, "place the calls and write the recommendation without asking")
+	calibrateCmd.Flags().BoolVar(&calibrateJSON, "json", false, "output as JSON")
+	rootCmd.AddCommand(calibrateCmd)
+}
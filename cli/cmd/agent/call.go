@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+// outboundIDBase keeps outbound test call IDs apart from the load test and
+// drill ones.
+const outboundIDBase = 830000
+
+// outboundNumber is what --to may hold, so it cannot change the dial string.
+var outboundNumber = regexp.MustCompile(`^\+?[0-9*#]{2,32}$`)
+
+var (
+	outboundTo          string
+	outboundProvider    string
+	outboundContext     string
+	outboundCount       int
+	outboundPace        time.Duration
+	outboundDial        string
+	outboundCallerID    string
+	outboundRingTimeout int
+	outboundMaxDuration time.Duration
+	outboundYes         bool
+	outboundJSON        bool
+)
+
+var callCmd = &cobra.Command{
+	Use:   "call",
+	Short: "Place test calls through the agent",
+	Long: `Place test calls through the agent and score them as agent rca would.
+
+Examples:
+  agent call originate --to 5551234 --provider openai_realtime`,
+}
+
+var callOriginateCmd = &cobra.Command{
+	Use:   "originate",
+	Short: "Have the agent call a number and report answer and connect rates",
+	Long: `Test outbound use cases, such as appointment reminders, by having the agent
+call a number --count times, one call every --pace.
+
+Each call is originated through ARI to --dial, a dial string in which {to}
+is replaced by --to. The default, Local/{to}@from-internal, dials out through
+the PBX's outbound routes; use PJSIP/{to}@<trunk> to dial a trunk directly.
+When the callee picks up, the call enters the agent's dialplan context
+(--context, or the one generated for --provider) and the agent takes it.
+Calls still up after --max-duration are hung up.
+
+For each call the report says whether it was answered and whether it
+connected, meaning the engine logged it, and scores the connected calls from
+their ai_engine logs. The answer rate is answered of placed calls; the
+connect rate is connected of answered calls. Unanswered calls show their CDR
+disposition and hangup cause when the CDR is readable.
+
+The command exits with the warning code when a call was not answered, did
+not connect or scored below 70, and with the failure code when none
+connected. These are real calls: they ring real phones and are billed by the
+trunk and the providers. On a terminal the command asks before dialing
+unless --yes is given.
+
+Examples:
+  agent call originate --to 5551234 --provider openai_realtime
+  agent call originate --to +15551234567 --context from-ai-agent --count 5 --pace 30s --yes
+  agent call originate --to 5551234 --dial 'PJSIP/{to}@carrier' --caller-id '"Clinic" <5550000>' --json`,
+	Args: cobra.NoArgs,
+	RunE: runCallOriginate,
+}
+
+func runCallOriginate(cmd *cobra.Command, args []string) error {
+	if !outboundNumber.MatchString(outboundTo) {
+		return contract.UsageError(errors.New("--to must be a phone number: digits, * and #, with an optional leading +"))
+	}
+	if !strings.Contains(outboundDial, "{to}") {
+		return contract.UsageError(errors.New("--dial must contain {to}"))
+	}
+	if outboundCount < 1 {
+		return contract.UsageError(errors.New("--count must be at least 1"))
+	}
+	if outboundCount > 1 && outboundPace < time.Second {
+		return contract.UsageError(errors.New("--pace must be at least 1s"))
+	}
+	if outboundRingTimeout < 5 || outboundRingTimeout > 120 {
+		return contract.UsageError(errors.New("--ring-timeout must be from 5 to 120 seconds"))
+	}
+	if outboundMaxDuration < 30*time.Second {
+		return contract.UsageError(errors.New("--max-duration must be at least 30s"))
+	}
+	troubleshoot.LoadEnvFile()
+	cfg, err := loadtestARIConfig()
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	agentContext := outboundContext
+	if agentContext == "" {
+		agentContext = dialplan.ContextName(outboundProvider)
+	}
+	if err := verifyAgentContext(agentContext, cfg.AppName); err != nil {
+		return contract.EnvironmentError(err)
+	}
+	endpoint := strings.ReplaceAll(outboundDial, "{to}", outboundTo)
+
+	format := structuredOutput(outboundJSON)
+	if !outboundYes && !format.Structured() && stdinIsTerminal() {
+		fmt.Fprintf(os.Stderr, "Place %d call(s) to %s through %s? [y/N]: ", outboundCount, outboundTo, endpoint)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+		default:
+			return contract.Exit(contract.Warn, errors.New("cancelled; no calls were placed"))
+		}
+	}
+	progress := os.Stdout
+	if format.Structured() {
+		progress = os.Stderr
+	}
+
+	start := time.Now()
+	calls := make([]troubleshoot.OutboundCall, outboundCount)
+	track := newOutboundTracker(cfg, outboundMaxDuration)
+	for i := range calls {
+		if i > 0 {
+			track.waitUntil(calls[i-1].Started.Add(outboundPace))
+		}
+		id := fmt.Sprintf("%d.%d", start.Unix(), outboundIDBase+i+1)
+		calls[i] = troubleshoot.OutboundCall{CallID: id, To: outboundTo, Started: time.Now()}
+		fmt.Fprintf(progress, "▶ Call %d/%d to %s (%s)...\n", i+1, outboundCount, outboundTo, id)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err := asterisk.Originate(ctx, cfg, asterisk.OriginateRequest{
+			Endpoint:  endpoint,
+			Context:   agentContext,
+			Extension: "s",
+			Priority:  1,
+			CallerID:  outboundCallerID,
+			Timeout:   outboundRingTimeout,
+			ChannelID: id,
+		})
+		cancel()
+		if err != nil {
+			calls[i].Error = err.Error()
+			fmt.Fprintf(progress, "  ❌ %s: %v\n", id, err)
+			continue
+		}
+		track.add(id)
+	}
+	track.drain(time.Now().Add(time.Duration(outboundRingTimeout)*time.Second + outboundMaxDuration + 30*time.Second))
+
+	fmt.Fprintln(progress, "Scoring calls from ai_engine logs...")
+	lines, err := loadtestLogLines(time.Since(start) + time.Minute)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	recs, _ := cdr.Recent(start.Add(-time.Minute)) // best-effort
+	for i, c := range calls {
+		c.Answered = track.answered[c.CallID]
+		c = troubleshoot.ScoreOutboundCall(c, troubleshoot.FilterCallLines(lines, c.CallID))
+		if c.Error == "" && !c.Answered {
+			c.Hangup = outboundHangup(recs, c.CallID)
+		}
+		calls[i] = c
+	}
+
+	report := troubleshoot.SummarizeOutbound(calls)
+	if format.Structured() {
+		if err := output.Write(os.Stdout, format, map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"to":             outboundTo,
+			"endpoint":       endpoint,
+			"context":        agentContext,
+			"summary":        report,
+			"calls":          calls,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printOutboundReport(calls, report)
+	}
+	switch {
+	case report.Connected == 0:
+		return contract.Exit(contract.Fail, nil)
+	case report.Connected < report.Placed || report.LowQuality > 0:
+		return contract.Exit(contract.Warn, nil)
+	}
+	return nil
+}
+
+// outboundTracker polls ARI for the placed calls, noting which were answered
+// and hanging up those that run past the limit.
+type outboundTracker struct {
+	cfg      asterisk.ARIConfig
+	limit    time.Duration
+	live     map[string]time.Time // call id → placed
+	answered map[string]bool
+}
+
+func newOutboundTracker(cfg asterisk.ARIConfig, limit time.Duration) *outboundTracker {
+	return &outboundTracker{cfg: cfg, limit: limit, live: map[string]time.Time{}, answered: map[string]bool{}}
+}
+
+func (t *outboundTracker) add(id string) {
+	t.live[id] = time.Now()
+}
+
+// waitUntil polls every two seconds until deadline.
+func (t *outboundTracker) waitUntil(deadline time.Time) {
+	for time.Now().Before(deadline) {
+		time.Sleep(min(2*time.Second, time.Until(deadline)))
+		t.poll()
+	}
+}
+
+// drain polls until every call has ended, hanging up those still up at
+// deadline.
+func (t *outboundTracker) drain(deadline time.Time) {
+	for len(t.live) > 0 && time.Now().Before(deadline) {
+		time.Sleep(2 * time.Second)
+		t.poll()
+	}
+	for id := range t.live {
+		t.hangup(id)
+	}
+}
+
+func (t *outboundTracker) poll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+	channels, err := asterisk.ListChannels(ctx, t.cfg)
+	cancel()
+	if err != nil {
+		return
+	}
+	up := map[string]asterisk.Channel{}
+	for _, ch := range channels {
+		up[ch.ID] = ch
+	}
+	for id, placed := range t.live {
+		ch, ok := up[id]
+		if !ok {
+			delete(t.live, id)
+			continue
+		}
+		if strings.EqualFold(ch.State, "Up") {
+			t.answered[id] = true
+		}
+		if time.Since(placed) > t.limit {
+			t.hangup(id)
+		}
+	}
+}
+
+func (t *outboundTracker) hangup(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+	_ = asterisk.Hangup(ctx, t.cfg, id)
+	cancel()
+	delete(t.live, id)
+}
+
+// outboundHangup is the CDR disposition and hangup cause of the call.
+func outboundHangup(recs []cdr.Record, callID string) string {
+	for _, r := range recs {
+		if r.UniqueID != callID {
+			continue
+		}
+		if r.HangupCauseText != "" {
+			return fmt.Sprintf("%s (%s)", r.Disposition, r.HangupCauseText)
+		}
+		return r.Disposition
+	}
+	return ""
+}
+
+func printOutboundReport(calls []troubleshoot.OutboundCall, r troubleshoot.OutboundReport) {
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "CALL ID\tSTARTED\tOUTCOME\tQUALITY\tTURNS\tP95\tNOTE")
+	for _, c := range calls {
+		quality := "-"
+		if c.Score != nil {
+			quality = fmt.Sprintf("%.0f", *c.Score)
+		}
+		note := c.Error
+		if note == "" {
+			note = c.Hangup
+		}
+		if note == "" && len(c.Issues) > 0 {
+			note = c.Issues[0]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", c.CallID, c.Started.Format("15:04:05"), c.Outcome(), quality,
+			len(c.LatenciesMS), loadtestMS(c.LatencyP95MS), emptyDash(note))
+	}
+	tw.Flush()
+	fmt.Println()
+
+	reached := r.Placed - r.Failed
+	fmt.Printf("Answered %d of %d call(s) (%.0f%%); %d of %d answered call(s) reached the agent (%.0f%%)\n",
+		r.Answered, r.Placed, r.AnswerRate*100, r.Connected, r.Answered, r.ConnectRate*100)
+	if r.Scored > 0 {
+		fmt.Printf("Quality avg %.0f, min %.0f; turn latency p50 %s, p95 %s\n",
+			r.ScoreAvg, r.ScoreMin, loadtestMS(r.LatencyP50MS), loadtestMS(r.LatencyP95MS))
+	}
+	if r.Failed > 0 {
+		fmt.Printf("  ❌ %d call(s) could not be originated\n", r.Failed)
+	}
+	if n := reached - r.Answered; n > 0 {
+		fmt.Printf("  ⚠️  %d call(s) were not answered\n", n)
+	}
+	if n := r.Answered - r.Connected; n > 0 {
+		fmt.Printf("  ⚠️  %d answered call(s) never reached the agent; check the dialplan context and run agent rca\n", n)
+	}
+	if r.LowQuality > 0 {
+		fmt.Printf("  ⚠️  %d call(s) scored below 70; run agent rca <call_id>\n", r.LowQuality)
+	}
+	switch {
+	case r.Connected == 0:
+		fmt.Println("❌ No call reached the agent")
+	case r.Connected == r.Placed && r.LowQuality == 0:
+		fmt.Println("✅ Every call reached the agent")
+	}
+}
+
+func init() {
+	callOriginateCmd.Flags().StringVar(&outboundTo, "to", "", "number to call")
+	callOriginateCmd.Flags().StringVar(&outboundProvider, "provider", "", "provider whose generated dialplan context takes the answered calls")
+	callOriginateCmd.Flags().StringVar(&outboundContext, "context", "", "dialplan context that enters the agent's Stasis app (default: from --provider)")
+	callOriginateCmd.Flags().IntVar(&outboundCount, "count", 1, "number of calls to place")
+	callOriginateCmd.Flags().DurationVar(&outboundPace, "pace", 10*time.Second, "time between the starts of two calls")
+	callOriginateCmd.Flags().StringVar(&outboundDial, "dial", "Local/{to}@from-internal", "dial string; {to} is replaced by --to")
+	callOriginateCmd.Flags().StringVar(&outboundCallerID, "caller-id", "", `caller ID presented to the callee, e.g. '"Clinic" <5550000>'`)
+	callOriginateCmd.Flags().IntVar(&outboundRingTimeout, "ring-timeout", 30, "seconds to let a call ring before giving up")
+	callOriginateCmd.Flags().DurationVar(&outboundMaxDuration, "max-duration", 5*time.Minute, "hang up calls still up after this long")
+	callOriginateCmd.Flags().BoolVarP(&outboundYes, "yes", "y", false, "do not ask for confirmation")
+	callOriginateCmd.Flags().BoolVar(&outboundJSON, "json", false, "output as JSON")
+	callCmd.AddCommand(callOriginateCmd)
+	rootCmd.AddCommand(callCmd)
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/capture"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/chaos"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hostinfo"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/privilege"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captureCall      string
+	captureDuration  time.Duration
+	captureWait      time.Duration
+	captureOutput    string
+	captureWhere     string
+	captureInterface string
+	captureImage     string
+	captureJSON      bool
+	captureNoLLM     bool
+)
+
+var captureCmd = &cobra.Command{
+	Use:   "capture",
+	Short: "Capture a call's RTP or AudioSocket packets and add them to the RCA report",
+	Long: `Run tcpdump on the engine's media ports during the next call, then read the
+capture and add what was on the wire to the call's RCA report: packet loss,
+jitter, reordering, gaps and whether the codec and packet time match the
+configuration. While the call is up, Asterisk's RTP statistics for the
+caller channel are read through ARI; together with the capture (and any
+RTCP in it) they give an estimated MOS (ITU-T G.107 E-model) per media leg
+and for the call, which counts toward the quality verdict.
+
+Only the configured transport is captured: UDP on external_media.port_range
+for ExternalMedia, TCP on audiosocket.port for AudioSocket. SIP on UDP 5060
+is captured too, when it crosses the interface, for the call's SIP ladder.
+The interface is the one the engine uses to reach ASTERISK_HOST, unless
+--interface is given.
+
+tcpdump runs on the host when it is installed and may open raw sockets (the
+CLI runs as root, or tcpdump has cap_net_raw; see agent privileges),
+otherwise in a throwaway helper container (--image) that shares the engine's
+network namespace. --where host or --where container forces one.
+
+With --call next (the default), the command waits up to --wait for a call to
+start and captures until it ends or --duration has passed. --call with the
+ID of a call that is up captures the rest of it. The capture is kept in
+.agent/captures/<call_id>.pcap, or --output; agent rca --pcap analyzes a
+saved capture again.
+
+Examples:
+  agent capture --call next --duration 60
+  agent capture --call 1761518880.2191 --duration 30s --json
+  agent capture --where container --interface eth0`,
+	Args: cobra.NoArgs,
+	RunE: runCapture,
+}
+
+func runCapture(cmd *cobra.Command, args []string) error {
+	if captureDuration <= 0 || captureWait <= 0 {
+		return contract.UsageError(errors.New("--duration and --wait must be positive"))
+	}
+	if captureCall != "next" && !isChannelIDArg(captureCall) {
+		return contract.UsageError(fmt.Errorf("--call must be next or the channel ID of a call that is up, not %q (past calls' packets are gone)", captureCall))
+	}
+	switch captureWhere {
+	case "auto", "host", "container":
+	default:
+		return contract.UsageError(fmt.Errorf("--where must be auto, host or container, not %q", captureWhere))
+	}
+	troubleshoot.LoadEnvFile()
+	format := structuredOutput(captureJSON)
+	progress := os.Stdout
+	if format.Structured() {
+		progress = os.Stderr
+	}
+
+	opts, filter, err := captureOptions()
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	where := captureWhere
+	switch {
+	case where == "auto" && privilege.CanCaptureOnHost():
+		where = "host"
+	case where == "auto", where == "container":
+		where = "container"
+		if op, ok := privilege.Find(privilege.Diagnose(), privilege.OpDocker); ok && op.Status == privilege.StatusDenied {
+			return contract.EnvironmentError(fmt.Errorf("tcpdump cannot run on this host without root or cap_net_raw, nor in a helper container: %s; %s", op.Message, op.Fix))
+		}
+	case !privilege.CanCaptureOnHost():
+		path, err := exec.LookPath("tcpdump")
+		if err != nil {
+			return contract.EnvironmentError(errors.New("--where host needs tcpdump installed on this host"))
+		}
+		return contract.EnvironmentError(fmt.Errorf("--where host needs root or cap_net_raw on %s: %s", path, privilege.CaptureFix(path)))
+	}
+	dev := captureInterface
+	if dev == "" {
+		if dev, err = captureRouteDevice(where); err != nil {
+			return contract.EnvironmentError(err)
+		}
+	}
+
+	client := engineapi.New()
+	known := map[string]bool{}
+	tracking := true
+	sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	sessions, err := client.Sessions(sctx)
+	cancel()
+	switch {
+	case err != nil && captureCall != "next":
+		return contract.EnvironmentError(fmt.Errorf("cannot confirm call %s is up: %w", captureCall, err))
+	case err != nil:
+		// Older engines: capture for --duration and find the call in the logs.
+		tracking = false
+		fmt.Fprintf(progress, "⚠️  Engine sessions are unavailable (%v); capturing for %s\n", err, captureDuration)
+	case captureCall != "next":
+		if _, ok := sessions.Find(captureCall); !ok {
+			return contract.EnvironmentError(fmt.Errorf("call %s is not up; capture --call next and place a call", captureCall))
+		}
+	default:
+		for _, s := range sessions.Sessions {
+			known[s.CallID] = true
+		}
+	}
+
+	dir := filepath.Join(".agent", "captures")
+	path := captureOutput
+	if path == "" {
+		path = filepath.Join(dir, "capture-"+time.Now().Format("20060102-150405")+".pcap")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return contract.EnvironmentError(err)
+	}
+	stopCapture, err := startCapture(where, dev, filter, path)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	defer stopCapture()
+	fmt.Fprintf(progress, "📡 Capturing %s on %s (%s)\n", filter, dev, where)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	callID := ""
+	if captureCall != "next" {
+		callID = captureCall
+	}
+	started := time.Now()
+	if tracking && callID == "" {
+		fmt.Fprintf(progress, "  📞 Waiting up to %s for the next call...\n", captureWait)
+		callID, started = captureNextCall(ctx, client, known, time.Now().Add(captureWait))
+		if callID == "" {
+			stopCapture()
+			if ctx.Err() != nil {
+				return contract.Exit(contract.Warn, errors.New("interrupted before a call started"))
+			}
+			return contract.Exit(contract.Warn, fmt.Errorf("no call started within %s", captureWait))
+		}
+		fmt.Fprintf(progress, "  Call %s started; capturing until it ends or %s\n", callID, captureDuration)
+	}
+	// Asterisk's own RTP statistics for the caller channel feed the MOS
+	// estimate; the last snapshot before hangup covers the most of the call.
+	var rtpStats *asterisk.RTPStats
+	ari, ariErr := loadtestARIConfig()
+	pollStats := func(ctx context.Context) {
+		if ariErr != nil || callID == "" {
+			return
+		}
+		if s, err := asterisk.RTPStatistics(ctx, ari, callID); err == nil && s != nil {
+			rtpStats = s
+		}
+	}
+	captureUntil(ctx, client, callID, tracking, started.Add(captureDuration), pollStats)
+	stopCapture()
+	end := time.Now()
+
+	if callID == "" {
+		// The engine logs the call's RCA lines as it ends.
+		time.Sleep(3 * time.Second)
+		if lines, err := loadtestLogLines(time.Since(started) + time.Minute); err == nil {
+			if ids := troubleshoot.CallsDuring(lines, started, end); len(ids) > 0 {
+				callID = ids[len(ids)-1]
+			}
+		}
+		if callID == "" {
+			return contract.Exit(contract.Warn, fmt.Errorf("no call was logged during the capture; it is kept in %s", path))
+		}
+	} else {
+		time.Sleep(3 * time.Second)
+	}
+	if captureOutput == "" {
+		named := filepath.Join(dir, callID+".pcap")
+		if err := os.Rename(path, named); err == nil {
+			path = named
+		}
+	}
+	fmt.Fprintf(progress, "Capture saved to %s; analyzing call %s...\n", path, callID)
+
+	rep, err := analyzeCaptureFile(path, opts)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	runner := troubleshoot.NewRunner(callID, "", false, false, captureNoLLM, false, false, format.Structured(), verbose)
+	runner.SetFormat(format)
+	runner.SetQuiet(quiet)
+	runner.SetCapture(rep)
+	runner.SetProfile(outputProfile)
+	runner.SetRTPStats(rtpStats)
+	err = runner.Run()
+	if format.Structured() && err != nil {
+		return contract.Exit(contract.CodeOf(err), nil)
+	}
+	if err != nil {
+		return err
+	}
+	if code := runner.ExitCode(); code != contract.OK {
+		return contract.Exit(code, nil)
+	}
+	return nil
+}
+
+// isChannelIDArg reports whether s looks like an Asterisk channel ID.
+func isChannelIDArg(s string) bool {
+	a, b, ok := strings.Cut(s, ".")
+	if !ok {
+		return false
+	}
+	_, errA := strconv.ParseUint(a, 10, 64)
+	_, errB := strconv.ParseUint(b, 10, 64)
+	return errA == nil && errB == nil
+}
+
+// secondsDuration is a duration flag that also takes bare seconds ("60").
+type secondsDuration struct{ d *time.Duration }
+
+func (v secondsDuration) String() string {
+	if v.d == nil {
+		return "0s"
+	}
+	return v.d.String()
+}
+
+func (v secondsDuration) Set(s string) error {
+	if n, err := strconv.Atoi(s); err == nil {
+		*v.d = time.Duration(n) * time.Second
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New("want seconds or a duration such as 90s or 2m")
+	}
+	*v.d = d
+	return nil
+}
+
+func (v secondsDuration) Type() string { return "duration" }
+
+// captureOptions returns the media ports of the configured transport and the
+// tcpdump filter that matches them, plus SIP on its standard port for the
+// call's ladder.
+func captureOptions() (capture.Options, string, error) {
+	opts := dialplan.LoadOptions(configLayers()...)
+	if opts.Transport == "externalmedia" {
+		spec := opts.ExternalMediaPorts
+		if spec == "" {
+			spec = "18080:18099"
+		}
+		lo, hi, ok := chaos.ParsePortRange(spec)
+		if !ok {
+			return capture.Options{}, "", fmt.Errorf("bad external_media.port_range %q", spec)
+		}
+		return capture.Options{RTPLow: uint16(lo), RTPHigh: uint16(hi)}, fmt.Sprintf("udp portrange %d-%d or udp port 5060", lo, hi), nil
+	}
+	port, err := strconv.Atoi(emptyDefault(opts.AudioSocketPort, "8090"))
+	if err != nil || port < 1 || port > 65535 {
+		return capture.Options{}, "", fmt.Errorf("bad audiosocket.port %q", opts.AudioSocketPort)
+	}
+	return capture.Options{AudioSocketPort: uint16(port)}, fmt.Sprintf("tcp port %d or udp port 5060", port), nil
+}
+
+func emptyDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// analyzeCaptureFile reads a pcap and measures the media streams in it.
+func analyzeCaptureFile(path string, opts capture.Options) (*capture.Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	packets, err := capture.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	rep := capture.Analyze(packets, opts)
+	rep.File = path
+	return &rep, nil
+}
+
+// captureRouteDevice is the interface the engine reaches ASTERISK_HOST over.
+func captureRouteDevice(where string) (string, error) {
+	host := chaosEnv("ASTERISK_HOST")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	ips, err := net.LookupHost(host)
+	if err != nil || len(ips) == 0 {
+		return "", fmt.Errorf("cannot resolve ASTERISK_HOST %s: %v; pass --interface", host, err)
+	}
+	if where == "host" {
+		dev, err := hostinfo.Local().RouteInterface(ips[0])
+		if err != nil {
+			return "", fmt.Errorf("%v; pass --interface", err)
+		}
+		return dev, nil
+	}
+	out, err := captureHelper(context.Background(), "sh", "-c", chaos.RouteScript(ips[:1])).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("route lookup failed: %v: %s; pass --interface", err, bytes.TrimSpace(out))
+	}
+	dev, ok := chaos.RouteDevices(string(out))[ips[0]]
+	if !ok {
+		return "", fmt.Errorf("no route to ASTERISK_HOST %s; pass --interface", host)
+	}
+	return dev, nil
+}
+
+// captureHelper runs argv in the helper image, in the engine's network
+// namespace, with the capabilities tcpdump needs.
+func captureHelper(ctx context.Context, argv ...string) *exec.Cmd {
+	args := []string{"run", "--rm", "-i",
+		"--name", fmt.Sprintf("aava-capture-%d", os.Getpid()),
+		"--network", "container:" + deployment.EngineContainer(),
+		"--cap-add", "NET_ADMIN", "--cap-add", "NET_RAW",
+		"--entrypoint", argv[0], captureImage}
+	return exec.CommandContext(ctx, "docker", append(args, argv[1:]...)...)
+}
+
+// startCapture starts tcpdump writing to path; the returned func stops it,
+// letting it flush, and may be called more than once.
+func startCapture(where, dev, filter, path string) (func(), error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	tcpdump := []string{"tcpdump", "-i", dev, "-n", "-s", "0", "-U", "-w"}
+	var cmd *exec.Cmd
+	if where == "host" {
+		cmd = exec.Command(tcpdump[0], append(append(tcpdump[1:], path), filter)...)
+	} else {
+		cmd = captureHelper(context.Background(), append(append(tcpdump, "-"), filter)...)
+		cmd.Stdout = f
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("start tcpdump: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	// tcpdump fails fast on a bad interface or missing privileges.
+	select {
+	case err := <-done:
+		f.Close()
+		return nil, fmt.Errorf("tcpdump exited: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	case <-time.After(2 * time.Second):
+	}
+	stopped := false
+	return func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		_ = cmd.Process.Signal(os.Interrupt)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			_ = cmd.Process.Kill()
+			<-done
+		}
+		f.Close()
+	}, nil
+}
+
+// captureNextCall polls the engine until a call not in known starts.
+func captureNextCall(ctx context.Context, client *engineapi.Client, known map[string]bool, deadline time.Time) (string, time.Time) {
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		sctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		s, err := client.Sessions(sctx)
+		cancel()
+		if err == nil {
+			for _, sess := range s.Sessions {
+				if !known[sess.CallID] {
+					return sess.CallID, time.Now()
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+	return "", time.Time{}
+}
+
+// captureUntil returns at deadline, on interrupt, or when the call is no
+// longer up; without session tracking it waits for the deadline. poll runs
+// every five seconds meanwhile.
+func captureUntil(ctx context.Context, client *engineapi.Client, callID string, tracking bool, deadline time.Time, poll func(context.Context)) {
+	var polled time.Time
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		if time.Since(polled) >= 5*time.Second {
+			pctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			poll(pctx)
+			cancel()
+			polled = time.Now()
+		}
+		if tracking {
+			sctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			s, err := client.Sessions(sctx)
+			cancel()
+			if err == nil {
+				if _, up := s.Find(callID); !up {
+					// Let the last packets and the teardown reach the capture.
+					time.Sleep(time.Second)
+					return
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func init() {
+	captureCmd.Flags().StringVar(&captureCall, "call", "next", "next, or the channel ID of a call that is up")
+	captureDuration = time.Minute
+	captureCmd.Flags().Var(secondsDuration{&captureDuration}, "duration", "longest time to capture once the call is up, in seconds or as a duration")
+	captureCmd.Flags().DurationVar(&captureWait, "wait", 5*time.Minute, "how long to wait for the next call")
+	captureCmd.Flags().StringVarP(&captureOutput, "output", "o", "", "pcap file to write (default .agent/captures/<call_id>.pcap)")
+	captureCmd.Flags().StringVar(&captureWhere, "where", "auto", "where tcpdump runs: auto, host or container")
+	captureCmd.Flags().StringVar(&captureInterface, "interface", "", "interface to capture on (default: the route to ASTERISK_HOST)")
+	captureCmd.Flags().StringVar(&captureImage, "image", "nicolaka/netshoot", "helper image with tcpdump, for --where container")
+	captureCmd.Flags().BoolVar(&captureJSON, "json", false, "output the RCA report with the capture as JSON")
+	captureCmd.Flags().BoolVar(&captureNoLLM, "no-llm", false, "skip the AI diagnosis")
+	rootCmd.AddCommand(captureCmd)
+}
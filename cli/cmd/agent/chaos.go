@@ -0,0 +1,503 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/chaos"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+// chaosIDBase keeps chaos test call IDs apart from load test ones.
+const chaosIDBase = 800000
+
+var (
+	chaosProvider string
+	chaosTargets  []string
+	chaosDuration time.Duration
+	chaosDelay    time.Duration
+	chaosJitter   time.Duration
+	chaosLoss     float64
+	chaosCall     bool
+	chaosAudio    string
+	chaosContext  string
+	chaosTCImage  string
+	chaosJSON     bool
+)
+
+var chaosCmd = &cobra.Command{
+	Use:   "chaos",
+	Short: "Simulate provider outages, latency and packet loss to rehearse failures",
+	Long: `Impair the network between ai_engine and a provider for a while, during a
+test call, and report how the engine fell back.
+
+Faults are applied with tc netem in the engine's network namespace, from a
+throwaway helper container (--tc-image, which must ship tc and ip) with
+NET_ADMIN. Only traffic to the provider's addresses and port is affected;
+ARI, AudioSocket and RTP are left alone.
+
+The provider's endpoints come from config/ai-agent.yaml: --provider (default:
+default_provider) names a provider or a pipeline, whose stt, llm and tts
+providers are all impaired. --target host[:port] picks endpoints directly.
+Host names are resolved on this machine.
+
+While the fault is on, place a test call, or pass --call to originate a
+synthetic one as agent loadtest does. The fault is removed when --duration
+ends or on Ctrl-C; if the CLI is killed first, run "agent chaos clear".
+
+Each call that was up during the fault is then read from the ai_engine logs:
+
+  HANDLED     the provider recovered (reconnect), or the engine applied its
+              on_provider_failure action or hung up instead of leaving dead air
+  DEGRADED    the call went on with poor quality or errors, or nothing noticed
+              an outage
+  UNHANDLED   the provider failed and the call stayed up with no agent
+
+The command exits with the warning code when a call degraded or no call ran
+during the fault, and with the failure code when a call was unhandled.
+
+"agent chaos rtp" impairs the ExternalMedia RTP path instead of a provider.
+
+Examples:
+  agent chaos outage --provider openai_realtime --call
+  agent chaos latency --delay 1500ms --jitter 300ms --duration 2m
+  agent chaos loss --loss 20 --target api.deepgram.com
+  agent chaos clear`,
+}
+
+var chaosOutageCmd = &cobra.Command{
+	Use:   "outage",
+	Short: "Drop every packet to the provider",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runChaos(chaos.Fault{Kind: chaos.Outage})
+	},
+}
+
+var chaosLatencyCmd = &cobra.Command{
+	Use:   "latency",
+	Short: "Delay packets to the provider by --delay ± --jitter",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runChaos(chaos.Fault{Kind: chaos.Latency, Delay: chaosDelay, Jitter: chaosJitter})
+	},
+}
+
+var chaosLossCmd = &cobra.Command{
+	Use:   "loss",
+	Short: "Drop --loss percent of packets to the provider",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runChaos(chaos.Fault{Kind: chaos.Loss, LossPct: chaosLoss})
+	},
+}
+
+var chaosClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove a fault left behind by an interrupted agent chaos run",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, err := chaosExec("tc qdisc show")
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		devs := chaos.FaultedDevices(chaos.ParseQdiscs(out))
+		if len(devs) == 0 {
+			fmt.Println("✅ No chaos fault is installed")
+			return nil
+		}
+		if _, err := chaosExec(chaos.ClearScript(devs)); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("✅ Removed the fault from %s\n", strings.Join(devs, ", "))
+		return nil
+	},
+}
+
+func runChaos(fault chaos.Fault) error {
+	if err := fault.Validate(); err != nil {
+		return contract.UsageError(err)
+	}
+	if chaosDuration < 10*time.Second {
+		return contract.UsageError(errors.New("--duration must be at least 10s"))
+	}
+	troubleshoot.LoadEnvFile()
+	format := structuredOutput(chaosJSON)
+	progress := os.Stdout
+	if format.Structured() {
+		progress = os.Stderr
+	}
+
+	targets, err := chaosResolveTargets()
+	if err != nil {
+		return err
+	}
+	rules, err := chaosRules(targets)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	if err := chaosCheckDevices(rules); err != nil {
+		return contract.EnvironmentError(err)
+	}
+
+	client := engineapi.New()
+	before, err := chaosHealth(client)
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("ai_engine health is unavailable: %w", err))
+	}
+	var cfg asterisk.ARIConfig
+	agentContext := ""
+	if chaosCall {
+		if cfg, err = loadtestARIConfig(); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		agentContext = chaosContext
+		if agentContext == "" {
+			agentContext = dialplan.ContextName(chaosProvider)
+		}
+		if err := verifyLoadtestDialplan(agentContext, cfg.AppName); err != nil {
+			return contract.EnvironmentError(err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	removeFault, err := chaosApply(fault, rules)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	defer removeFault()
+
+	start := time.Now()
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.String())
+	}
+	fmt.Fprintf(progress, "⚡ Injecting %s on traffic to %s for %s\n", fault, strings.Join(names, ", "), chaosDuration)
+	callID := ""
+	if chaosCall {
+		callID = fmt.Sprintf("%d.%d", start.Unix(), chaosIDBase+1)
+		if err := originateLoadtestCall(cfg, agentContext, callID, 1, chaosAudio, int(chaosDuration.Seconds())); err != nil {
+			fmt.Fprintf(progress, "  ❌ Test call failed: %v\n", err)
+			callID = ""
+		} else {
+			fmt.Fprintf(progress, "  📞 Test call %s placed into [%s]\n", callID, agentContext)
+		}
+	} else {
+		fmt.Fprintln(progress, "  📞 Place a test call now")
+	}
+
+	maxCalls, degradedSeen := chaosWatch(ctx, client, progress, start.Add(chaosDuration))
+	removeFault()
+	end := time.Now()
+	interrupted := ctx.Err() != nil
+	if interrupted {
+		fmt.Fprintln(progress, "Interrupted; fault removed")
+	} else {
+		fmt.Fprintln(progress, "Fault removed; checking recovery...")
+	}
+	if callID != "" {
+		waitLoadtestCalls(cfg, []string{callID + "-caller"}, 2*time.Minute)
+	} else {
+		time.Sleep(5 * time.Second)
+	}
+	recovered, recoveredAfter := chaosRecovery(client, 30*time.Second)
+
+	lines, err := loadtestLogLines(time.Since(start) + time.Minute)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	report := troubleshoot.AnalyzeFault(lines, start, end, fault.Kind == chaos.Outage)
+
+	code := contract.OK
+	switch {
+	case report.Unhandled() > 0:
+		code = contract.Fail
+	case len(report.Calls) == 0 || !recovered:
+		code = contract.Warn
+	}
+	for _, c := range report.Calls {
+		if c.Verdict == troubleshoot.FaultDegraded && code == contract.OK {
+			code = contract.Warn
+		}
+	}
+
+	if format.Structured() {
+		if err := output.Write(os.Stdout, format, map[string]any{
+			"schema_version":       contract.SchemaVersion,
+			"fault":                fault.Kind,
+			"impairment":           fault.String(),
+			"targets":              targets,
+			"rules":                rules,
+			"duration":             end.Sub(start).Round(time.Second).String(),
+			"interrupted":          interrupted,
+			"test_call":            callID,
+			"health_before":        before.Status,
+			"health_degraded":      degradedSeen,
+			"max_active_calls":     maxCalls,
+			"recovered":            recovered,
+			"recovery_seconds":     recoveredAfter.Seconds(),
+			"calls":                report.Calls,
+			"reconnect_attempts":   report.Reconnects,
+			"reconnects_succeeded": report.Reconnected,
+			"reconnects_exhausted": report.GaveUp,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printChaosReport(report, degradedSeen, recovered, recoveredAfter)
+	}
+	if code != contract.OK {
+		return contract.Exit(code, nil)
+	}
+	return nil
+}
+
+// chaosResolveTargets returns --target endpoints, or the provider's from
+// ai-agent.yaml, with their addresses resolved.
+func chaosResolveTargets() ([]chaos.Target, error) {
+	var targets []chaos.Target
+	if len(chaosTargets) > 0 {
+		for _, s := range chaosTargets {
+			t, err := chaos.ParseTarget(s)
+			if err != nil {
+				return nil, contract.UsageError(fmt.Errorf("--target: %w", err))
+			}
+			targets = append(targets, t)
+		}
+	} else {
+		cfg := map[string]any{}
+		for _, path := range configLayers() {
+			if m, err := configmerge.ReadYAMLFile(path); err == nil {
+				cfg = configmerge.DeepMerge(cfg, m)
+			}
+		}
+		if len(cfg) == 0 {
+			return nil, contract.EnvironmentError(errors.New("config/ai-agent.yaml not found; run from the project directory or pass --target"))
+		}
+		ts, err := chaos.ProviderTargets(cfg, chaosProvider, chaosEnv)
+		if err != nil {
+			return nil, contract.UsageError(err)
+		}
+		targets = ts
+	}
+	return chaosLookup(targets)
+}
+
+// chaosLookup resolves the targets' host names on this machine.
+func chaosLookup(targets []chaos.Target) ([]chaos.Target, error) {
+	for i, t := range targets {
+		if t.Host == "localhost" {
+			targets[i].IPs = []string{"127.0.0.1"}
+			continue
+		}
+		ips, err := net.LookupIP(t.Host)
+		if err != nil || len(ips) == 0 {
+			return nil, contract.EnvironmentError(fmt.Errorf("cannot resolve %s: %v; pass --target with an address", t.Host, err))
+		}
+		for _, ip := range ips {
+			targets[i].IPs = append(targets[i].IPs, ip.String())
+		}
+		sort.Strings(targets[i].IPs)
+	}
+	return targets, nil
+}
+
+// chaosRules finds the interface the engine routes each target address over.
+func chaosRules(targets []chaos.Target) ([]chaos.Rule, error) {
+	var ips []string
+	for _, t := range targets {
+		ips = append(ips, t.IPs...)
+	}
+	out, err := chaosExec(chaos.RouteScript(ips))
+	if err != nil {
+		return nil, err
+	}
+	devs := chaos.RouteDevices(out)
+	var rules []chaos.Rule
+	for _, t := range targets {
+		for _, ip := range t.IPs {
+			dev, ok := devs[ip]
+			if !ok {
+				return nil, fmt.Errorf("ai_engine has no route to %s (%s)", ip, t.Host)
+			}
+			rules = append(rules, chaos.Rule{Dev: dev, IP: ip, Port: t.Port})
+		}
+	}
+	return rules, nil
+}
+
+// chaosCheckDevices refuses to touch interfaces that already carry a fault
+// or a custom root qdisc.
+func chaosCheckDevices(rules []chaos.Rule) error {
+	out, err := chaosExec("tc qdisc show")
+	if err != nil {
+		return err
+	}
+	return chaos.CheckDevices(chaos.ParseQdiscs(out), chaos.Devices(rules))
+}
+
+// chaosApply installs the fault; the returned func removes it and may be
+// called more than once.
+func chaosApply(fault chaos.Fault, rules []chaos.Rule) (func(), error) {
+	devs := chaos.Devices(rules)
+	if _, err := chaosExec(chaos.ApplyScript(fault, rules)); err != nil {
+		_, _ = chaosExec(chaos.ClearScript(devs))
+		return nil, fmt.Errorf("failed to apply the fault: %w", err)
+	}
+	removed := false
+	return func() {
+		if removed {
+			return
+		}
+		removed = true
+		if _, err := chaosExec(chaos.ClearScript(devs)); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to remove the fault: %v\n   Run: agent chaos clear\n", err)
+		}
+	}, nil
+}
+
+// chaosEnv reads a setting from the environment, then .env.
+func chaosEnv(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	v, _ := dotenvValue(".env", key)
+	return v
+}
+
+// chaosExec runs a shell script in a helper container that shares the
+// engine's network namespace and may change its queueing disciplines.
+func chaosExec(script string) (string, error) {
+	cmd := exec.Command("docker", "run", "--rm",
+		"--network", "container:"+deployment.EngineContainer(),
+		"--cap-add", "NET_ADMIN",
+		"--entrypoint", "sh",
+		chaosTCImage, "-c", script)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s helper failed: %v: %s", chaosTCImage, err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+func chaosHealth(client *engineapi.Client) (*engineapi.Health, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return client.Health(ctx)
+}
+
+// chaosWatch polls engine health until deadline, reporting status changes.
+// It returns the most active calls seen and whether health degraded.
+func chaosWatch(ctx context.Context, client *engineapi.Client, progress *os.File, deadline time.Time) (int, bool) {
+	maxCalls, degraded := 0, false
+	last := ""
+	for time.Now().Before(deadline) {
+		if h, err := chaosHealth(client); err == nil {
+			if h.ActiveCalls > maxCalls {
+				maxCalls = h.ActiveCalls
+			}
+			status := h.Status
+			var notReady []string
+			for name, p := range h.Providers {
+				if !p.Ready {
+					notReady = append(notReady, name)
+				}
+			}
+			sort.Strings(notReady)
+			if len(notReady) > 0 {
+				status += ", not ready: " + strings.Join(notReady, ", ")
+			}
+			if h.Status != "healthy" {
+				degraded = true
+			}
+			if status != last {
+				fmt.Fprintf(progress, "  %s engine %s, %d active call(s)\n", time.Now().Format("15:04:05"), status, h.ActiveCalls)
+				last = status
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return maxCalls, degraded
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return maxCalls, degraded
+}
+
+// chaosRecovery waits up to limit for the engine to report healthy again.
+func chaosRecovery(client *engineapi.Client, limit time.Duration) (bool, time.Duration) {
+	start := time.Now()
+	for {
+		if h, err := chaosHealth(client); err == nil && h.Status == "healthy" {
+			return true, time.Since(start)
+		}
+		if time.Since(start) > limit {
+			return false, time.Since(start)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+func printChaosReport(r troubleshoot.FaultReport, degraded, recovered bool, after time.Duration) {
+	fmt.Println()
+	if degraded {
+		fmt.Println("  ⚠️  Engine health reported degraded during the fault")
+	}
+	if r.Reconnects > 0 {
+		fmt.Printf("  🔁 %d reconnect attempt(s): %d succeeded, %d gave up\n", r.Reconnects, r.Reconnected, r.GaveUp)
+	}
+	if recovered {
+		fmt.Printf("  ✅ Engine healthy %s after the fault was removed\n", after.Round(time.Second))
+	} else {
+		fmt.Printf("  ⚠️  Engine not healthy %s after the fault was removed\n", after.Round(time.Second))
+	}
+	fmt.Println()
+	if len(r.Calls) == 0 {
+		fmt.Println("⚠️  No call was up during the fault; place a test call while it runs or pass --call")
+		return
+	}
+	for _, c := range r.Calls {
+		icon := "✅"
+		switch c.Verdict {
+		case troubleshoot.FaultDegraded:
+			icon = "⚠️ "
+		case troubleshoot.FaultUnhandled:
+			icon = "❌"
+		}
+		fmt.Printf("%s %s %s: %s\n", icon, c.CallID, c.Verdict, c.Finding)
+	}
+}
+
+func init() {
+	chaosCmd.PersistentFlags().StringVar(&chaosProvider, "provider", "", "provider or pipeline whose endpoints are impaired (default: default_provider)")
+	chaosCmd.PersistentFlags().StringSliceVar(&chaosTargets, "target", nil, "endpoint to impair as host[:port] or URL, instead of the provider's (repeatable)")
+	chaosCmd.PersistentFlags().DurationVar(&chaosDuration, "duration", time.Minute, "how long the fault stays on")
+	chaosCmd.PersistentFlags().BoolVar(&chaosCall, "call", false, "originate a synthetic test call through the agent loadtest caller context")
+	chaosCmd.PersistentFlags().StringVar(&chaosAudio, "audio", "hello-world", "recording the --call caller plays")
+	chaosCmd.PersistentFlags().StringVar(&chaosContext, "context", "", "dialplan context the --call test call enters (default: from --provider)")
+	chaosCmd.PersistentFlags().StringVar(&chaosTCImage, "tc-image", "nicolaka/netshoot", "helper image with tc and ip")
+	chaosCmd.PersistentFlags().BoolVar(&chaosJSON, "json", false, "output as JSON")
+	chaosLatencyCmd.Flags().DurationVar(&chaosDelay, "delay", 800*time.Millisecond, "added delay per packet")
+	chaosLatencyCmd.Flags().DurationVar(&chaosJitter, "jitter", 100*time.Millisecond, "random variation of the delay")
+	chaosLossCmd.Flags().Float64Var(&chaosLoss, "loss", 30, "percent of packets dropped")
+	chaosCmd.AddCommand(chaosOutageCmd, chaosLatencyCmd, chaosLossCmd, chaosClearCmd)
+	rootCmd.AddCommand(chaosCmd)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/chaos"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rtpDelay    time.Duration
+	rtpJitter   time.Duration
+	rtpLoss     float64
+	rtpReorder  float64
+	rtpBaseline bool
+)
+
+var chaosRTPCmd = &cobra.Command{
+	Use:   "rtp",
+	Short: "Impair the ExternalMedia RTP path and show how call quality responds",
+	Long: `Add delay, jitter, packet loss and reordering to the RTP between Asterisk and
+ai_engine's ExternalMedia port range (external_media.port_range), then read
+the RCA metrics of the calls made meanwhile: quality score, jitter buffer
+underflows, drift and turn latency. Use it to check the streaming jitter
+buffer settings against the networks callers are really on.
+
+The impairment is a tc netem qdisc on the interface the engine reaches
+ASTERISK_HOST over, matching UDP to or from the port range. When Asterisk and
+the engine share a host, RTP both ways is impaired; when Asterisk is remote,
+only the agent's audio towards Asterisk is. audio_transport must be
+externalmedia.
+
+With --call, a synthetic test call is placed while the impairment is on, as
+agent loadtest does. Add --baseline to place a clean call first and compare
+the two. Without --call, place a test call while the command runs. Inbound
+packet loss and reordering seen by the engine are reported when it logs at
+debug level.
+
+The command exits with the warning code when an impaired call's quality
+dropped or its jitter buffer ran dry more often than the clean call's.
+
+Examples:
+  agent chaos rtp --loss 2 --delay 60ms --jitter 30ms --call --baseline
+  agent chaos rtp --delay 80ms --jitter 40ms --reorder 5 --duration 2m`,
+	Args: cobra.NoArgs,
+	RunE: runChaosRTP,
+}
+
+// rtpRun is a call placed by agent chaos rtp.
+type rtpRun struct {
+	id       string
+	impaired bool
+}
+
+func runChaosRTP(cmd *cobra.Command, args []string) error {
+	fault := chaos.Fault{Kind: chaos.RTP, Delay: rtpDelay, Jitter: rtpJitter, LossPct: rtpLoss, Reorder: rtpReorder}
+	if err := fault.Validate(); err != nil {
+		return contract.UsageError(err)
+	}
+	if chaosDuration < 10*time.Second {
+		return contract.UsageError(errors.New("--duration must be at least 10s"))
+	}
+	if len(chaosTargets) > 0 {
+		return contract.UsageError(errors.New("--target does not apply: rtp impairs the ExternalMedia port range"))
+	}
+	if rtpBaseline && !chaosCall {
+		return contract.UsageError(errors.New("--baseline needs --call"))
+	}
+	troubleshoot.LoadEnvFile()
+	format := structuredOutput(chaosJSON)
+	progress := os.Stdout
+	if format.Structured() {
+		progress = os.Stderr
+	}
+
+	opts := dialplan.LoadOptions(configLayers()...)
+	if opts.Transport != "externalmedia" {
+		return contract.EnvironmentError(fmt.Errorf("audio_transport is %q; RTP impairment needs externalmedia (AudioSocket runs over TCP)", opts.Transport))
+	}
+	ports := opts.ExternalMediaPorts
+	if ports == "" {
+		ports = "18080:18099"
+	}
+	host := chaosEnv("ASTERISK_HOST")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	ips, err := net.LookupHost(host)
+	if err != nil || len(ips) == 0 {
+		return contract.EnvironmentError(fmt.Errorf("cannot resolve ASTERISK_HOST %s: %v", host, err))
+	}
+	out, err := chaosExec(chaos.RouteScript(ips[:1]))
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	dev, ok := chaos.RouteDevices(out)[ips[0]]
+	if !ok {
+		return contract.EnvironmentError(fmt.Errorf("ai_engine has no route to ASTERISK_HOST %s", host))
+	}
+	rules, err := chaos.PortRangeRules(dev, ports)
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("external_media.port_range: %w", err))
+	}
+	if err := chaosCheckDevices(rules); err != nil {
+		return contract.EnvironmentError(err)
+	}
+	client := engineapi.New()
+	if _, err := chaosHealth(client); err != nil {
+		return contract.EnvironmentError(fmt.Errorf("ai_engine health is unavailable: %w", err))
+	}
+	var cfg asterisk.ARIConfig
+	agentContext := ""
+	if chaosCall {
+		if cfg, err = loadtestARIConfig(); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		agentContext = chaosContext
+		if agentContext == "" {
+			agentContext = dialplan.ContextName(chaosProvider)
+		}
+		if err := verifyLoadtestDialplan(agentContext, cfg.AppName); err != nil {
+			return contract.EnvironmentError(err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	start := time.Now()
+	hold := int(chaosDuration.Seconds())
+	var runs []rtpRun
+	place := func(seq int, impaired bool) {
+		id := fmt.Sprintf("%d.%d", start.Unix(), chaosIDBase+seq)
+		if err := originateLoadtestCall(cfg, agentContext, id, seq, chaosAudio, hold); err != nil {
+			fmt.Fprintf(progress, "  ❌ Test call failed: %v\n", err)
+			return
+		}
+		runs = append(runs, rtpRun{id: id, impaired: impaired})
+	}
+	if rtpBaseline {
+		fmt.Fprintf(progress, "▶ Clean baseline call (%s)...\n", chaosDuration)
+		place(1, false)
+		if n := len(runs); n > 0 {
+			waitLoadtestCalls(cfg, []string{runs[n-1].id + "-caller"}, chaosDuration+2*time.Minute)
+		}
+		if ctx.Err() != nil {
+			return contract.Exit(contract.Warn, errors.New("interrupted before the impairment"))
+		}
+	}
+
+	removeFault, err := chaosApply(fault, rules)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	defer removeFault()
+	faultStart := time.Now()
+	fmt.Fprintf(progress, "⚡ Injecting %s on RTP ports %s (%s) for %s\n", fault, ports, dev, chaosDuration)
+	if chaosCall {
+		place(2, true)
+	} else {
+		fmt.Fprintln(progress, "  📞 Place a test call now")
+	}
+	chaosWatch(ctx, client, progress, faultStart.Add(chaosDuration))
+	removeFault()
+	end := time.Now()
+	fmt.Fprintln(progress, "Impairment removed; scoring calls...")
+	for _, r := range runs {
+		if r.impaired {
+			waitLoadtestCalls(cfg, []string{r.id + "-caller"}, 2*time.Minute)
+		}
+	}
+	if !chaosCall {
+		time.Sleep(5 * time.Second)
+	}
+
+	lines, err := loadtestLogLines(time.Since(start) + time.Minute)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	if !chaosCall {
+		for _, id := range troubleshoot.CallsDuring(lines, faultStart, end) {
+			runs = append(runs, rtpRun{id: id, impaired: true})
+		}
+	}
+	calls := []troubleshoot.RTPCall{}
+	impaired := 0
+	for _, r := range runs {
+		calls = append(calls, troubleshoot.AnalyzeRTPCall(r.id, troubleshoot.FilterCallLines(lines, r.id), r.impaired))
+		if r.impaired {
+			impaired++
+		}
+	}
+	findings, degraded := troubleshoot.RTPFindings(calls)
+	if impaired == 0 {
+		findings = []string{"no call was up during the impairment; place a test call while it runs or pass --call"}
+	}
+
+	if format.Structured() {
+		if err := output.Write(os.Stdout, format, map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"impairment":     fault.String(),
+			"port_range":     ports,
+			"dev":            dev,
+			"duration":       end.Sub(faultStart).Round(time.Second).String(),
+			"calls":          calls,
+			"findings":       findings,
+			"degraded":       degraded,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printRTPReport(calls, findings, degraded || impaired == 0)
+	}
+	if degraded || impaired == 0 {
+		return contract.Exit(contract.Warn, nil)
+	}
+	return nil
+}
+
+func printRTPReport(calls []troubleshoot.RTPCall, findings []string, warn bool) {
+	fmt.Println()
+	if len(calls) > 0 {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintln(tw, "CALL\tRTP\tQUALITY\tUNDERFLOWS\tDRIFT\tTURNS\tP50\tP95\tLOST\tREORDERED")
+		for _, c := range calls {
+			run, quality := "clean", "-"
+			if c.Impaired {
+				run = "impaired"
+			}
+			if c.Score != nil {
+				quality = fmt.Sprintf("%.0f", *c.Score)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%.1f%%\t%d\t%s\t%s\t%d\t%d\n", c.CallID, run, quality, c.Underflows, c.WorstDriftPct,
+				c.Turns, loadtestMS(c.LatencyP50MS), loadtestMS(c.LatencyP95MS), c.RTPLost, c.RTPOutOfOrder)
+		}
+		tw.Flush()
+		fmt.Println()
+	}
+	icon := "✅"
+	if warn {
+		icon = "⚠️ "
+	}
+	for _, f := range findings {
+		fmt.Printf("%s %s\n", icon, f)
+	}
+}
+
+func init() {
+	chaosRTPCmd.Flags().DurationVar(&rtpDelay, "delay", 60*time.Millisecond, "added delay per RTP packet")
+	chaosRTPCmd.Flags().DurationVar(&rtpJitter, "jitter", 30*time.Millisecond, "random variation of the delay")
+	chaosRTPCmd.Flags().Float64Var(&rtpLoss, "loss", 1, "percent of RTP packets dropped")
+	chaosRTPCmd.Flags().Float64Var(&rtpReorder, "reorder", 0, "percent of RTP packets sent ahead of the delayed ones")
+	chaosRTPCmd.Flags().BoolVar(&rtpBaseline, "baseline", false, "place a clean --call first and compare")
+	chaosCmd.AddCommand(chaosRTPCmd)
+}
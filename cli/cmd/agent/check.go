@@ -3,20 +3,31 @@ package main
 import (
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"time"
 
 	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/telemetry"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
 	"github.com/spf13/cobra"
 )
 
 var (
-	checkJSON   bool
-	checkFix    bool
-	checkLocal  bool
-	checkRemote string
+	checkJSON    bool
+	checkFix     bool
+	checkLocal   bool
+	checkRemote  string
+	checkProfile string
+	checkCleanup bool
+	checkMaxAge  time.Duration
 )
 
 var checkCmd = &cobra.Command{
@@ -32,26 +43,58 @@ Probes:
   - ai_engine container status, network mode, mounts
   - In-container checks via: docker exec ai_engine python -
   - ARI reachability and app registration (container-side only)
+  - ai_engine memory and open files over a short window and across earlier
+    runs, with the memory each call leaves behind
+  - Channel leaks: calls up longer than --max-call-age, ExternalMedia,
+    AudioSocket and Snoop helper channels whose call is gone, and bridges
+    with no caller left in them
   - Transport compatibility + advertise host alignment
   - Best-effort internet/DNS reachability (no external containers)
+  - Site checks from plugins in ~/.agent/plugins (see agent plugins)
+
+Profiles (--profile) select the checks and probe timeouts:
+  quick        Docker, ai_engine, config and ARI; 5s probes
+  full         every check; 30s probes
+  pre-update   state an update must preserve, plus outbound reachability
+  post-update  containers, mounts, config, transport and ARI after an update
+               (used by agent update)
+  constrained  every check, with CPU, memory and temperature thresholds for
+               ARM64 and small hosts such as a Raspberry Pi; 60s probes
+
+Without --profile, an ARM Docker host or one with less than 4 GiB of RAM gets
+constrained and any other host gets full. Both check the host's resources, that
+the images match its architecture (not emulated), and that the local_ai_server
+models fit its memory.
+
+--cleanup hangs up the leaked channels and destroys the leaked bridges via
+ARI after the report. It re-lists ARI from this host first and only touches
+what is still leaked, so run it on the PBX host (not with --host).
 
 Exit codes:
   0 - PASS (no warnings)
   1 - WARN (non-critical issues)
-  2 - FAIL (critical issues)`,
+  2 - FAIL (critical issues)
+  3 - invalid flags or arguments
+  4 - docker or the ai_engine container not available`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// --local or --remote: check local_ai_server components
 		if checkLocal || checkRemote != "" {
+			if structuredOutput(checkJSON) == output.YAML {
+				return contract.UsageError(errors.New("--local/--remote support text or json output only"))
+			}
 			return runCheckLocalServer(cmd)
 		}
 
 		if checkFix {
-			if checkJSON {
-				return errors.New("--fix cannot be combined with --json")
+			if structuredOutput(checkJSON).Structured() {
+				return contract.UsageError(errors.New("--fix supports text output only; drop --json or --output"))
+			}
+			if deployment.Current().Remote() {
+				return contract.UsageError(errors.New("--fix restores files in the local checkout; run it on the server (not with --host)"))
 			}
 			exitCode, err := runCheckWithFix()
 			if exitCode != 0 {
-				os.Exit(exitCode)
+				return contract.Exit(exitCode, nil)
 			}
 			if err != nil {
 				return err
@@ -59,47 +102,123 @@ Exit codes:
 			return nil
 		}
 
-		runner := check.NewRunner(verbose, version, buildTime)
-		report, err := runner.Run()
-
-		if report == nil {
-			report = &check.Report{
-				Version:   version,
-				BuildTime: buildTime,
-				Timestamp: time.Now(),
-				Items: []check.Item{
-					{
-						Name:    "agent check",
-						Status:  check.StatusFail,
-						Message: "failed to generate diagnostics report",
-						Details: func() string {
-							if err != nil {
-								return err.Error()
-							}
-							return "unknown error"
-						}(),
-					},
-				},
+		name := checkProfile
+		if name == "" {
+			name = check.DefaultProfile
+			if info, err := dockerapi.InfoFor(deployment.EngineContainer()); err == nil {
+				name = check.SuggestProfile(info.Architecture, info.MemTotal)
 			}
 		}
+		profile, err := check.FindProfile(name)
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if checkMaxAge < 0 {
+			return contract.UsageError(errors.New("--max-call-age must be positive"))
+		}
+		if checkCleanup && deployment.Current().Remote() {
+			return contract.UsageError(errors.New("--cleanup talks to ARI from this host; run it on the server (not with --host)"))
+		}
+		plugins := plugin.Load()
+		report, err := runStandardCheck(profile, checkMaxAge, plugins)
 
-		if checkJSON {
-			_ = report.OutputJSON(os.Stdout)
+		result := reportResult(report, err, checkJSON)
+		runSinks(plugins, "check", report)
+		if !checkCleanup {
+			return result
+		}
+		// Structured output stays parseable; the cleanup log goes to stderr.
+		w := os.Stdout
+		if structuredOutput(checkJSON).Structured() {
+			w = os.Stderr
 		} else {
-			report.OutputText(os.Stdout)
+			fmt.Fprintln(w)
+		}
+		maxAge := checkMaxAge
+		if maxAge == 0 {
+			maxAge = check.DefaultMaxCallAge
+		}
+		if cerr := cleanupChannelLeaks(w, report.ChannelLeaks, maxAge); cerr != nil {
+			return cerr
 		}
+		return result
+	},
+}
 
-		exitCode := 0
-		if err != nil || report.FailCount > 0 {
-			exitCode = 2
-		} else if report.WarnCount > 0 {
-			exitCode = 1
+// runStandardCheck runs the check report with its side effects: telemetry,
+// the Asterisk restart event, the resource sample the next run compares
+// against and the run summary agent digest reads. When the checks cannot run, the report holds one failing item that
+// says why. agent check and agent serve share it.
+func runStandardCheck(profile check.Profile, maxAge time.Duration, plugins []plugin.Plugin) (*check.Report, error) {
+	runner := check.NewRunner(verbose, version, buildTime)
+	runner.Profile = profile
+	runner.MaxCallAge = maxAge
+	runner.Plugins = plugins
+	runner.ResourceHistory, _ = troubleshoot.LoadTrendSeries(resourceSeries, time.Now().Add(-troubleshoot.RegressionWindow), resourceSampleAt)
+	for _, c := range troubleshoot.IndexedCalls(math.MaxInt) {
+		runner.CallStarts = append(runner.CallStarts, c.Timestamp)
+	}
+	report, err := runner.Run()
+	if report == nil {
+		details := "unknown error"
+		if err != nil {
+			details = err.Error()
 		}
-		if exitCode != 0 {
-			os.Exit(exitCode)
+		report = &check.Report{
+			Version:   version,
+			BuildTime: buildTime,
+			Timestamp: time.Now(),
+			Items: []check.Item{
+				{Name: "agent check", Status: check.StatusFail, Message: "failed to generate diagnostics report", Details: details},
+			},
 		}
+		_ = troubleshoot.RecordTrendSeries(checkSeries, report.Summary(), checkRunAt)
+		return report, err
+	}
+	telemetry.RecordCheck(telemetry.Counts{Pass: report.PassCount, Warn: report.WarnCount, Fail: report.FailCount, Skip: report.SkipCount})
+	if report.AsteriskStartedAt != nil {
+		// Deduplicated by start time, so only an actual restart adds an event.
+		_ = troubleshoot.RecordTrendEvent(troubleshoot.TrendEvent{At: *report.AsteriskStartedAt, Kind: troubleshoot.EventAsteriskRestart, Label: "Asterisk restarted"})
+	}
+	if report.ResourceSample != nil {
+		_ = troubleshoot.RecordTrendSeries(resourceSeries, *report.ResourceSample, resourceSampleAt)
+	}
+	_ = troubleshoot.RecordTrendSeries(checkSeries, report.Summary(), checkRunAt)
+	return report, err
+}
+
+// resourceSeries keeps the engine resource samples each agent check takes, so
+// the next run can see growth across the container's lifetime.
+const resourceSeries = "resources.jsonl"
+
+func resourceSampleAt(s check.ResourceSample) time.Time { return s.At }
+
+// checkSeries keeps a summary of each agent check run for agent digest.
+const checkSeries = "checks.jsonl"
+
+func checkRunAt(s check.RunSummary) time.Time { return s.At }
+
+// reportResult prints a check report as JSON, quiet or full text and returns its
+// contract exit code. The report already names the failure, so err is not printed.
+func reportResult(report *check.Report, err error, jsonOut bool) error {
+	switch f := structuredOutput(jsonOut); {
+	case f.Structured():
+		_ = report.Encode(os.Stdout, f)
+	case quiet:
+		report.OutputQuiet(os.Stdout)
+	default:
+		report.OutputText(os.Stdout, outputProfile)
+	}
+	code := contract.ReportCode(report.WarnCount, report.FailCount)
+	if contract.CodeOf(err) == contract.Environment {
+		code = contract.Environment
+	} else if err != nil {
+		code = contract.Fail
+	}
+	if code == contract.OK {
 		return nil
-	},
+	}
+	return contract.Exit(code, nil)
 }
 
 // runCheckLocalServer shells out to scripts/check_local_server.py
@@ -120,7 +239,7 @@ func runCheckLocalServer(cmd *cobra.Command) error {
 	} else if checkRemote != "" {
 		pyArgs = append(pyArgs, "--remote", checkRemote)
 	}
-	if checkJSON {
+	if structuredOutput(checkJSON) == output.JSON {
 		pyArgs = append(pyArgs, "--json")
 	}
 	if noColor {
@@ -135,7 +254,7 @@ func runCheckLocalServer(cmd *cobra.Command) error {
 
 	if err := pyCmd.Run(); err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			os.Exit(exitErr.ExitCode())
+			return contract.Exit(exitErr.ExitCode(), nil)
 		}
 		return fmt.Errorf("local server check failed: %w", err)
 	}
@@ -147,5 +266,9 @@ func init() {
 	checkCmd.Flags().BoolVar(&checkFix, "fix", false, "attempt automatic recovery from recent backups and re-run diagnostics")
 	checkCmd.Flags().BoolVar(&checkLocal, "local", false, "check local_ai_server on this host (ws://127.0.0.1:8765)")
 	checkCmd.Flags().StringVar(&checkRemote, "remote", "", "check remote local_ai_server at IP address")
+	checkCmd.Flags().StringVar(&checkProfile, "profile", "", "check set: quick, full, pre-update, post-update or constrained (default: constrained on ARM and small hosts, else full)")
+	checkCmd.Flags().BoolVar(&checkCleanup, "cleanup", false, "hang up stuck calls and orphaned channels and destroy leaked bridges via ARI")
+	checkCmd.Flags().DurationVar(&checkMaxAge, "max-call-age", check.DefaultMaxCallAge, "how long a call may be up before it counts as stuck")
+	checkCmd.MarkFlagsMutuallyExclusive("cleanup", "fix")
 	rootCmd.AddCommand(checkCmd)
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+)
+
+// cleanupChannelLeaks hangs up the leaked channels and destroys the leaked
+// bridges the check found. It lists ARI again from this host first and only
+// touches what both views agree on, so a call that ended or a helper that got
+// bridged in the meantime is left alone. Channels go first: destroying a
+// bridge does not hang up what is left in it.
+func cleanupChannelLeaks(w io.Writer, found []asterisk.Leak, maxAge time.Duration) error {
+	if len(found) == 0 {
+		fmt.Fprintln(w, "Cleanup: no leaked channels or bridges")
+		return nil
+	}
+	cfg, err := loadtestARIConfig()
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("cleanup: %w", err))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	channels, err := asterisk.ListChannels(ctx, cfg)
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("cleanup: %w", err))
+	}
+	bridges, err := asterisk.ListBridges(ctx, cfg)
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("cleanup: %w", err))
+	}
+	still := map[string]bool{}
+	for _, l := range asterisk.FindLeaks(channels, bridges, cfg.AppName, maxAge, time.Now()) {
+		still[l.Kind+" "+l.ID] = true
+	}
+
+	fmt.Fprintf(w, "Cleaning up %d leak(s) via ARI at %s...\n", len(found), cfg.BaseURL)
+	var hungUp, destroyed, failed int
+	for _, bridgePass := range []bool{false, true} {
+		for _, l := range found {
+			if l.IsBridge() != bridgePass {
+				continue
+			}
+			if !still[l.Kind+" "+l.ID] {
+				fmt.Fprintf(w, "  skipped %s %s: no longer leaked\n", l.Kind, l.ID)
+				continue
+			}
+			if bridgePass {
+				err = asterisk.DestroyBridge(ctx, cfg, l.ID)
+			} else {
+				err = asterisk.Hangup(ctx, cfg, l.ID)
+			}
+			switch {
+			case err != nil:
+				failed++
+				fmt.Fprintf(w, "  failed %s %s: %v\n", l.Kind, l.ID, err)
+			case bridgePass:
+				destroyed++
+				fmt.Fprintf(w, "  destroyed bridge %s\n", l.ID)
+			default:
+				hungUp++
+				fmt.Fprintf(w, "  hung up %s %s (%s)\n", l.Kind, l.ID, l.Name)
+			}
+		}
+	}
+	fmt.Fprintf(w, "Cleanup: hung up %d channel(s), destroyed %d bridge(s)", hungUp, destroyed)
+	if failed > 0 {
+		fmt.Fprintf(w, ", %d failed\n", failed)
+		return contract.Exit(contract.Fail, nil)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
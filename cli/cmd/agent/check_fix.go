@@ -52,7 +52,7 @@ func runCheckWithFix() (int, error) {
 			},
 		}
 	}
-	before.OutputText(os.Stdout)
+	before.OutputText(os.Stdout, outputProfile)
 
 	noIssues := beforeErr == nil && before.FailCount == 0 && before.WarnCount == 0
 	if noIssues {
@@ -78,7 +78,7 @@ func runCheckWithFix() (int, error) {
 	if after == nil {
 		return 2, errors.New("post-fix diagnostics failed: report unavailable")
 	}
-	after.OutputText(os.Stdout)
+	after.OutputText(os.Stdout, outputProfile)
 
 	if afterErr != nil || after.FailCount > 0 {
 		return 2, nil
@@ -113,6 +113,7 @@ func runBackupRecovery() (*fixSummary, error) {
 		filepath.Join("config", "ai-agent.local.yaml"),
 		filepath.Join("config", "users.json"),
 		filepath.Join("config", "contexts"),
+		filepath.Join("config", configmerge.OverlayDir),
 	} {
 		if err := backupPathIfExists(rel, prefixBackup); err != nil {
 			return summary, fmt.Errorf("failed to snapshot current state (%s): %w", rel, err)
@@ -516,7 +517,7 @@ func restartCoreServices() error {
 
 	// Fallback path: restart each service and attempt up if restart fails.
 	for _, svc := range []string{"ai_engine", "admin_ui"} {
-		if _, err := runCmd("docker", "compose", "restart", svc); err != nil {
+		if err := restartService(svc); err != nil {
 			if _, err2 := runCmd("docker", "compose", "up", "-d", "--no-build", svc); err2 != nil {
 				return fmt.Errorf("failed to restart %s (restart error: %v; up error: %w)", svc, err, err2)
 			}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate shell completion scripts",
+	Long: `Print a completion script for bash, zsh, fish or PowerShell.
+
+Besides commands and flags, --call completes recent call IDs from the call
+index (.agent/calls.json) and --symptom completes the known symptom names.
+Run 'agent rca --list' once to populate the index.
+
+Load for the current shell:
+  source <(agent completion bash)
+  source <(agent completion zsh)
+  agent completion fish | source
+  agent completion powershell | Out-String | Invoke-Expression
+
+Install permanently:
+  agent completion bash | sudo tee /etc/bash_completion.d/agent >/dev/null
+  agent completion zsh > "${fpath[1]}/_agent"
+  agent completion fish > ~/.config/fish/completions/agent.fish
+  agent completion powershell >> $PROFILE`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		return nil
+	},
+}
+
+// completeCallIDs offers indexed call IDs, newest first, described by start
+// time, caller and duration.
+func completeCallIDs(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var out []string
+	for _, c := range troubleshoot.IndexedCalls(50) {
+		if !strings.HasPrefix(c.ID, toComplete) {
+			continue
+		}
+		desc := c.Timestamp.Local().Format("2006-01-02 15:04")
+		if c.CallerNumber != "" {
+			desc += " " + c.CallerNumber
+		}
+		if c.Duration != "" {
+			desc += " " + c.Duration
+		}
+		out = append(out, fmt.Sprintf("%s\t%s", c.ID, desc))
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+func completeSymptoms(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	out := make([]string, 0, len(troubleshoot.Symptoms))
+	for _, s := range troubleshoot.Symptoms {
+		out = append(out, s.Name+"\t"+s.Description)
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	// Replace cobra's default completion command, which has a subcommand per shell,
+	// with one that takes the shell as its argument.
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+}
@@ -1,11 +1,20 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/config"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/wizard"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var configCmd = &cobra.Command{
@@ -33,10 +42,50 @@ Exit codes:
 	RunE: runValidate,
 }
 
+var presetCmd = &cobra.Command{
+	Use:   "preset [name]",
+	Short: "List or apply a provider preset",
+	Long: `List the curated provider presets, or apply one.
+
+A preset selects the provider or pipeline together with the transport format,
+audio profile and provider codec/sample-rate options it needs, so the
+interdependent settings are never mixed by hand. Applying writes the preset
+to config/ai-agent.local.yaml and AUDIO_TRANSPORT to .env.
+
+Examples:
+  agent config preset
+  agent config preset openai-realtime --show
+  agent config preset local`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPreset,
+}
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Print the effective config for an environment",
+	Long: `Print the config the engine runs with: config/ai-agent.yaml, then the
+environment overlay config/overlays/<env>.yaml, then config/ai-agent.local.yaml,
+deep-merged in that order. A null in a later layer deletes the key.
+
+The engine picks the environment from AAVA_CONFIG_ENV (in .env or its
+environment); --env defaults to the same value, and --env "" renders without
+an overlay. Overlays are operator-owned: agent update backs them up and never
+replaces them. ${VAR} references are printed as written, not expanded.
+
+Examples:
+  agent config render --env prod
+  agent config render --env staging > /tmp/staging.yaml
+  diff <(agent config render --env staging) <(agent config render --env prod)`,
+	Args: cobra.NoArgs,
+	RunE: runRender,
+}
+
 var (
 	configFile   string
 	configFix    bool
 	configStrict bool
+	presetShow   bool
+	renderEnv    string
 )
 
 func init() {
@@ -44,7 +93,14 @@ func init() {
 	validateCmd.Flags().BoolVar(&configFix, "fix", false, "Attempt to auto-fix issues")
 	validateCmd.Flags().BoolVar(&configStrict, "strict", false, "Treat warnings as errors")
 
+	presetCmd.Flags().BoolVar(&presetShow, "show", false, "print the preset overlay without applying it")
+
+	renderCmd.Flags().StringVar(&renderEnv, "env", "", "environment overlay to apply (default: "+configmerge.EnvVar+")")
+	renderCmd.Flags().StringVar(&configFile, "file", "config/ai-agent.yaml", "Path to the base configuration file")
+
 	configCmd.AddCommand(validateCmd)
+	configCmd.AddCommand(presetCmd)
+	configCmd.AddCommand(renderCmd)
 	rootCmd.AddCommand(configCmd)
 }
 
@@ -108,7 +164,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	}
 
 	if exitCode != 0 {
-		os.Exit(exitCode)
+		return contract.Exit(exitCode, nil)
 	}
 
 	return nil
@@ -154,3 +210,95 @@ func printValidationResult(result *config.ValidationResult) {
 		fmt.Println("✅ Configuration is valid")
 	}
 }
+
+func runPreset(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		for _, p := range wizard.Presets {
+			fmt.Printf("%-24s %s\n", p.Name, p.Title)
+			fmt.Printf("%-24s %s\n", "", p.Description)
+		}
+		return nil
+	}
+	p, err := wizard.FindPreset(args[0])
+	if err != nil {
+		return err
+	}
+	if presetShow {
+		fmt.Print(strings.TrimLeft(p.Overlay, "\n"))
+		return nil
+	}
+
+	cfg, err := wizard.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.ApplyPreset(p)
+	if err := cfg.SaveEnv(); err != nil {
+		return fmt.Errorf("failed to save .env: %w", err)
+	}
+	if err := cfg.SaveYAML(""); err != nil {
+		return fmt.Errorf("failed to update YAML: %w", err)
+	}
+	fmt.Printf("✅ Applied preset %s (provider: %s)\n", p.Name, cfg.DefaultProvider)
+	recordTrendEvent(troubleshoot.EventConfig, "preset "+p.Name+" applied")
+	for _, key := range cfg.RequiredEnvKeys() {
+		if cfg.GetKey(key) == "" {
+			fmt.Printf("⚠️  %s is not set in .env\n", key)
+		}
+	}
+	fmt.Println("   Recreate ai_engine to apply: agent setup, or docker compose up -d --force-recreate ai_engine")
+	return nil
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	env := renderEnv
+	if !cmd.Flags().Changed("env") {
+		env = configEnv()
+	}
+	if env != "" {
+		if err := configmerge.ValidEnv(env); err != nil {
+			return contract.UsageError(err)
+		}
+	}
+	cfg, layers, err := configmerge.Effective(configFile, env)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && env != "" && len(configmerge.Envs(configFile)) > 0 {
+			err = fmt.Errorf("%w (overlays: %s)", err, strings.Join(configmerge.Envs(configFile), ", "))
+		}
+		return contract.EnvironmentError(err)
+	}
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if env == "" {
+		env = "(none)"
+	}
+	fmt.Printf("# Effective config, environment %s\n", env)
+	for _, path := range layers {
+		fmt.Printf("#   %s\n", filepath.ToSlash(path))
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// configEnv is the config environment the engine runs with: AAVA_CONFIG_ENV
+// from the environment, else from .env.
+func configEnv() string {
+	if v := strings.TrimSpace(os.Getenv(configmerge.EnvVar)); v != "" {
+		return v
+	}
+	v, _ := dotenvValue(".env", configmerge.EnvVar)
+	return strings.TrimSpace(v)
+}
+
+// configLayers returns the config files the engine merges, for commands that
+// read settings from them. A broken environment selection falls back to the
+// base and local files; agent config render reports it.
+func configLayers() []string {
+	base := filepath.Join("config", "ai-agent.yaml")
+	if layers, err := configmerge.Layers(base, configEnv()); err == nil {
+		return layers
+	}
+	return []string{base, configmerge.LocalPath(base)}
+}
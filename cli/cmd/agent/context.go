@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contexts"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	contextLintDir   string
+	contextLintModel string
+	contextLintJSON  bool
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Work with the context files in config/contexts/",
+	Long: `Work with the context files in config/contexts/: one agent (name, prompt,
+greeting, provider and tools) per YAML file, merged into ai-agent.yaml's
+contexts when the engine loads its configuration.`,
+}
+
+var contextLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Validate context files before the engine loads them",
+	Long: `Validate every context file in config/contexts/. The engine and the Admin UI
+import into agents.db silently skip a file they cannot load, so a broken file
+only shows when its agent is missing or stale.
+
+Each file is checked for:
+  - YAML syntax, a single document and a name (files failing these are skipped)
+  - unknown fields and values of the wrong type
+  - providers, pipelines and audio profiles missing from ai-agent.yaml
+  - duplicate context names, in other files or inline in ai-agent.yaml
+  - prompt and greeting placeholders: unknown variables (not built in and not
+    a pre-call tool output), {{name}}, { name } and unbalanced braces
+  - ${VAR} references not set in .env
+  - a greeting (or a prompt's "respond in ..." instruction) in a language
+    the context's TTS voice does not speak or its STT does not transcribe
+  - the prompt's estimated tokens against the context window of the model it
+    runs on (LOCAL_LLM_CONTEXT for the local LLM); --model checks all files
+    against one model instead
+
+Exit codes: 0 clean, 1 warnings, 2 errors.
+
+Examples:
+  agent context lint
+  agent context lint --model gpt-4o-mini
+  agent context lint --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := resolveRepoRootForFix()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		dir := contextLintDir
+		if dir == "" {
+			dir = filepath.Join(root, "config", "contexts")
+		}
+		opts := contexts.LoadOptions(root)
+		opts.Model = contextLintModel
+		res, err := contexts.Lint(dir, opts)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		errs, warnings := res.Counts()
+
+		if format := structuredOutput(contextLintJSON); format.Structured() {
+			if err := output.Write(os.Stdout, format, map[string]any{
+				"schema_version": contract.SchemaVersion,
+				"dir":            res.Dir,
+				"files":          res.Files,
+				"findings":       res.Findings,
+				"errors":         errs,
+				"warnings":       warnings,
+			}); err != nil {
+				return err
+			}
+		} else {
+			printContextLint(res, errs, warnings)
+		}
+		if code := contract.ReportCode(warnings, errs); code != contract.OK {
+			return contract.Exit(code, nil)
+		}
+		return nil
+	},
+}
+
+func printContextLint(res *contexts.Result, errs, warnings int) {
+	if len(res.Files) == 0 {
+		fmt.Printf("No context files in %s\n", res.Dir)
+		return
+	}
+	fmt.Printf("Linting %d context file(s) in %s\n\n", len(res.Files), res.Dir)
+	for _, f := range res.Files {
+		line := f.File
+		if f.Context != "" {
+			line += " (" + f.Context + ")"
+		}
+		if b := f.Budget; b != nil {
+			line += fmt.Sprintf(": ~%d prompt tokens on %s", b.Tokens, b.Model)
+			if b.Window > 0 {
+				line += fmt.Sprintf(" (%.0f%% of %d)", 100*float64(b.Tokens)/float64(b.Window), b.Window)
+			}
+		}
+		fmt.Println(line)
+		for _, fd := range res.Findings {
+			if fd.File != f.File {
+				continue
+			}
+			icon := "⚠️ "
+			if fd.Severity == contexts.SeverityError {
+				icon = "❌"
+			}
+			fmt.Printf("  %s %s\n", icon, fd.Message)
+		}
+	}
+	fmt.Println()
+	if errs+warnings == 0 {
+		fmt.Println("✓ All context files valid")
+		return
+	}
+	fmt.Printf("%d error(s), %d warning(s)\n", errs, warnings)
+}
+
+func init() {
+	contextLintCmd.Flags().StringVar(&contextLintDir, "dir", "", "contexts directory (default config/contexts in the project)")
+	contextLintCmd.Flags().StringVar(&contextLintModel, "model", "", "estimate every prompt's tokens for this model instead of the one it runs on")
+	contextLintCmd.Flags().BoolVar(&contextLintJSON, "json", false, "output as JSON")
+
+	contextCmd.AddCommand(contextLintCmd)
+	rootCmd.AddCommand(contextCmd)
+}
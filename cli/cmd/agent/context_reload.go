@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contexts"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	contextReloadForce bool
+	contextReloadJSON  bool
+)
+
+var contextReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reload prompts and contexts in the running engine without a restart",
+	Long: `Lint config/contexts/, then ask ai_engine to reload its configuration
+(ai-agent.yaml, ai-agent.local.yaml and config/contexts/) through its health
+server's /reload endpoint. Active calls keep the configuration they started
+with; new calls get the reloaded one. No call is dropped.
+
+The reload is confirmed from the engine log ("Configuration reload completed")
+and the config hash /health reports before and after. Context files that
+changed get a new version for agent experiment.
+
+Agents are read from agents.db on every call, so edits made in Admin UI apply
+without a reload. Edits to config/contexts/ reach agents.db when they are
+imported: Admin UI > Agents > Migration Status > Import YAML changes.
+
+A reload is refused when the lint finds errors, since the engine would skip
+those files; --force reloads anyway.
+
+Exit codes: 0 reloaded, 1 reloaded but not confirmed in the log, 2 reload
+failed or lint errors, 4 engine not reachable.
+
+Examples:
+  agent context reload
+  agent context reload --force`,
+	Args: cobra.NoArgs,
+	RunE: runContextReload,
+}
+
+// Engine log messages that end a reload, successful or not.
+const reloadLogCompleted = "Configuration reload completed"
+
+var reloadLogFailed = []string{"Reload validation failed", "Configuration reload failed"}
+
+// contextReloadReport is the --json payload.
+type contextReloadReport struct {
+	SchemaVersion  int                     `json:"schema_version"`
+	LintErrors     int                     `json:"lint_errors"`
+	LintWarnings   int                     `json:"lint_warnings"`
+	Reload         *engineapi.ReloadResult `json:"reload,omitempty"`
+	Error          string                  `json:"error,omitempty"`
+	LogConfirmed   bool                    `json:"log_confirmed"`
+	LogLine        string                  `json:"log_line,omitempty"`
+	ConfigHashFrom string                  `json:"config_hash_before,omitempty"`
+	ConfigHashTo   string                  `json:"config_hash_after,omitempty"`
+	AgentsDB       bool                    `json:"agents_db"`
+	Versions       []string                `json:"versions_recorded,omitempty"`
+}
+
+func runContextReload(cmd *cobra.Command, args []string) error {
+	root, err := resolveRepoRootForFix()
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	format := structuredOutput(contextReloadJSON)
+	rep := contextReloadReport{SchemaVersion: contract.SchemaVersion}
+	if _, err := os.Stat(filepath.Join(root, "data", "operator", "agents.db")); err == nil {
+		rep.AgentsDB = true
+	}
+
+	lint, err := contexts.Lint(filepath.Join(root, "config", "contexts"), contexts.LoadOptions(root))
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	rep.LintErrors, rep.LintWarnings = lint.Counts()
+	if rep.LintErrors+rep.LintWarnings > 0 && !format.Structured() {
+		printContextLint(lint, rep.LintErrors, rep.LintWarnings)
+		fmt.Println()
+	}
+	if rep.LintErrors > 0 && !contextReloadForce {
+		if format.Structured() {
+			rep.Error = "context files have errors; not reloading"
+			if err := output.Write(os.Stdout, format, rep); err != nil {
+				return err
+			}
+			return contract.Exit(contract.Fail, nil)
+		}
+		return contract.Exit(contract.Fail, errors.New("context files have errors; fix them or reload with --force"))
+	}
+
+	client := engineapi.New()
+	before, err := client.Health(context.Background())
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("ai_engine: %w", err))
+	}
+	rep.ConfigHashFrom = before.ConfigHash
+	since := time.Now().Add(-2 * time.Second).UTC().Format(time.RFC3339)
+	seen, _ := reloadLogLines(since)
+
+	if !format.Structured() {
+		fmt.Printf("Reloading configuration in %s...\n", client.Container)
+	}
+	client.Timeout = engineapi.ReloadTimeout
+	res, reloadErr := client.Reload(context.Background())
+	rep.Reload = res
+	if errors.Is(reloadErr, engineapi.ErrUnsupported) {
+		return contract.EnvironmentError(errors.New("this engine version has no /reload endpoint; restart ai_engine to apply changes"))
+	}
+	if errors.Is(reloadErr, engineapi.ErrUnavailable) {
+		return contract.EnvironmentError(fmt.Errorf("ai_engine: %w", reloadErr))
+	}
+	if reloadErr != nil {
+		rep.Error = reloadErr.Error()
+	}
+
+	// The completion line is logged just before the response is sent; give
+	// the log driver a moment to flush it.
+	for attempt := 0; attempt < 5 && res != nil; attempt++ {
+		lines, err := reloadLogLines(since)
+		if err != nil {
+			break
+		}
+		if len(lines) > len(seen) {
+			rep.LogLine = lines[len(lines)-1]
+			rep.LogConfirmed = strings.Contains(rep.LogLine, reloadLogCompleted)
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	client.Timeout = engineapi.DefaultTimeout
+	if after, err := client.Health(context.Background()); err == nil {
+		rep.ConfigHashTo = after.ConfigHash
+	}
+
+	// Tag calls from now on with the reloaded versions for agent experiment.
+	if reloadErr == nil {
+		added, _ := troubleshoot.RecordContextVersions(contextFileVersions(lint, time.Now()))
+		for _, v := range added {
+			rep.Versions = append(rep.Versions, v.Context+"@"+v.Version)
+		}
+	}
+
+	code := contract.OK
+	switch {
+	case reloadErr != nil:
+		code = contract.Fail
+	case !rep.LogConfirmed:
+		code = contract.Warn
+	}
+	if format.Structured() {
+		if err := output.Write(os.Stdout, format, rep); err != nil {
+			return err
+		}
+	} else {
+		printContextReload(rep)
+	}
+	if code != contract.OK {
+		return contract.Exit(code, nil)
+	}
+	return nil
+}
+
+func printContextReload(rep contextReloadReport) {
+	if res := rep.Reload; res != nil {
+		for _, c := range res.Changes {
+			fmt.Printf("  • %s\n", c)
+		}
+	}
+	if rep.Error != "" {
+		fmt.Printf("❌ Reload failed: %s\n", rep.Error)
+		fmt.Println("   The engine keeps its current configuration. Check: agent logs --since 2m")
+		return
+	}
+	switch {
+	case rep.LogConfirmed:
+		fmt.Println("✓ Reload confirmed in the engine log")
+	case rep.LogLine != "":
+		fmt.Printf("⚠️  Engine log reports: %s\n", rep.LogLine)
+	default:
+		fmt.Println("⚠️  Reload answered but not seen in the engine log yet; check: agent logs --since 2m")
+	}
+	switch {
+	case rep.ConfigHashFrom == "" || rep.ConfigHashTo == "":
+	case rep.ConfigHashFrom == rep.ConfigHashTo:
+		fmt.Printf("  Config hash unchanged (%s): nothing changed since the last load\n", shortHash(rep.ConfigHashTo))
+	default:
+		fmt.Printf("  Config hash %s -> %s\n", shortHash(rep.ConfigHashFrom), shortHash(rep.ConfigHashTo))
+	}
+	fmt.Println("  New calls use the reloaded configuration; active calls keep theirs.")
+	if len(rep.Versions) > 0 {
+		fmt.Printf("  Recorded for agent experiment: %s\n", strings.Join(rep.Versions, ", "))
+	}
+	if rep.AgentsDB {
+		fmt.Println("  Agents come from agents.db: import config/contexts/ edits in Admin UI > Agents > Migration Status.")
+	}
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+// reloadLogLines returns the engine log lines since the given time that end a
+// configuration reload.
+func reloadLogLines(since string) ([]string, error) {
+	rc, err := deployment.OpenEngineLogs(since)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var lines []string
+	sc := bufio.NewScanner(rc)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := troubleshoot.StripANSI(sc.Text())
+		if strings.Contains(line, reloadLogCompleted) {
+			lines = append(lines, strings.TrimSpace(line))
+			continue
+		}
+		for _, m := range reloadLogFailed {
+			if strings.Contains(line, m) {
+				lines = append(lines, strings.TrimSpace(line))
+				break
+			}
+		}
+	}
+	return lines, sc.Err()
+}
+
+func init() {
+	contextReloadCmd.Flags().BoolVar(&contextReloadForce, "force", false, "reload even when the context files have lint errors")
+	contextReloadCmd.Flags().BoolVar(&contextReloadJSON, "json", false, "output as JSON")
+
+	contextCmd.AddCommand(contextReloadCmd)
+}
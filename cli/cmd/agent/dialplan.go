@@ -2,8 +2,12 @@ package main
 
 import (
 	"fmt"
+	"path"
+	"strings"
 
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
 	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
 	"github.com/spf13/cobra"
 )
 
@@ -13,27 +17,75 @@ var dialplanCmd = &cobra.Command{
 	Long: `Generate Asterisk dialplan snippets for the chosen provider.
 
 This command prints the dialplan configuration that you need to add
-to your Asterisk extensions_custom.conf file.`,
+to your Asterisk extensions_custom.conf file. The Stasis app name and the
+transport notes (AudioSocket host/port or ExternalMedia RTP ports) are read
+from config/ai-agent.yaml, its local override and .env.
+
+On FreePBX (detected from /etc/freepbx.conf or the FreePBX header in
+extensions.conf, or forced with --freepbx) the snippet targets
+extensions_custom.conf, files FreePBX regenerates on Apply Config are refused
+as --file, and --extension hooks the number into from-internal-custom rather
+than editing from-internal.
+
+With --verify, the generated context is checked in the running Asterisk
+(docker exec, or asterisk -rx on this host) and the command exits non-zero
+when it is missing or does not enter Stasis.`,
 	RunE: runDialplan,
 }
 
 var (
-	dialplanProvider string
-	dialplanAgent    string
-	dialplanFile     string
+	dialplanProvider  string
+	dialplanAgent     string
+	dialplanFile      string
+	dialplanTransport string
+	dialplanVerify    bool
+	dialplanExtension string
+	dialplanFreePBX   bool
 )
 
 func init() {
 	dialplanCmd.Flags().StringVar(&dialplanProvider, "provider", "", "Provider to generate dialplan for (openai_realtime, deepgram, local_hybrid, google_live)")
 	dialplanCmd.Flags().StringVar(&dialplanAgent, "agent", "default", "Agent slug to select with AI_AGENT")
 	dialplanCmd.Flags().StringVar(&dialplanFile, "file", "/etc/asterisk/extensions_custom.conf", "Target dialplan file location")
+	dialplanCmd.Flags().StringVar(&dialplanTransport, "transport", "", "audiosocket or externalmedia (default: from configuration)")
+	dialplanCmd.Flags().BoolVar(&dialplanVerify, "verify", false, "check that the context is loaded in the running Asterisk")
+	dialplanCmd.Flags().StringVar(&dialplanExtension, "extension", "", "also generate an internal extension number that reaches the agent")
+	dialplanCmd.Flags().BoolVar(&dialplanFreePBX, "freepbx", false, "generate for FreePBX even when it is not detected")
 
 	rootCmd.AddCommand(dialplanCmd)
 }
 
 func runDialplan(cmd *cobra.Command, args []string) error {
-	// Generate snippet
-	snippet := dialplan.GenerateAgentSnippet(dialplanAgent, dialplanProvider)
+	// Generate snippet for this installation's app name and transport
+	troubleshoot.LoadEnvFile()
+	opts := dialplan.LoadOptions(configLayers()...)
+	opts.Agent, opts.Provider = dialplanAgent, dialplanProvider
+	if dialplanTransport != "" {
+		switch t := strings.ToLower(dialplanTransport); t {
+		case "audiosocket", "externalmedia":
+			opts.Transport = t
+		default:
+			return fmt.Errorf("--transport must be audiosocket or externalmedia")
+		}
+	}
+	if dialplanVerify {
+		return verifyDialplan(opts)
+	}
+	freePBXSource, freePBX := asterisk.DetectFreePBX()
+	if dialplanFreePBX {
+		freePBX = true
+	}
+	target := dialplanFile
+	opts.Extension = dialplanExtension
+	if freePBX {
+		opts.FromContext = dialplan.FreePBXFromContext
+		if asterisk.IsFreePBXManaged(target) {
+			custom := path.Join(path.Dir(target), asterisk.FreePBXCustomFile(target))
+			fmt.Printf("⚠️  %s is regenerated by FreePBX on Apply Config; using %s instead\n", target, custom)
+			target = custom
+		}
+	}
+	snippet := dialplan.Generate(opts)
 	providerName := dialplan.GetProviderDisplayName(dialplanProvider)
 	if dialplanProvider == "" {
 		providerName = "configured agent provider"
@@ -49,29 +101,45 @@ func runDialplan(cmd *cobra.Command, args []string) error {
 	// Print instructions
 	fmt.Println("")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("Add this snippet to:", dialplanFile)
+	fmt.Println("Add this snippet to:", target)
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("")
 	fmt.Println(snippet)
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("")
 
-	// Print FreePBX instructions
-	fmt.Println("FreePBX Setup:")
-	fmt.Println("  1. Navigate to: Admin → Config Edit")
-	fmt.Println("  2. Click: Asterisk Custom Configuration Files → extensions_custom.conf")
-	fmt.Println("  3. Paste the snippet above")
-	fmt.Println("  4. Save and Apply Config")
-	fmt.Println("")
-	fmt.Println("  5. Create Custom Destination:")
-	fmt.Println("     Admin → Custom Destination → Add")
 	ctx := getContextName(dialplanProvider)
-	fmt.Printf("     Target: %s,s,1\n", ctx)
-	fmt.Printf("     Description: AI Voice Agent - %s\n", providerName)
-	fmt.Println("")
-	fmt.Println("  6. Use in IVR/Inbound Route:")
-	fmt.Println("     Select your new Custom Destination as call target")
-	fmt.Println("")
+	if freePBX {
+		if freePBXSource != "" {
+			fmt.Printf("FreePBX Setup (detected from %s):\n", freePBXSource)
+		} else {
+			fmt.Println("FreePBX Setup:")
+		}
+		fmt.Println("  1. Navigate to: Admin → Config Edit")
+		fmt.Printf("  2. Click: Asterisk Custom Configuration Files → %s\n", path.Base(target))
+		fmt.Println("  3. Paste the snippet above")
+		fmt.Println("  4. Save and Apply Config")
+		fmt.Println("     (extensions.conf and *_additional.conf are rewritten on Apply Config — do not edit them)")
+		fmt.Println("")
+		fmt.Println("  5. Create Custom Destination:")
+		fmt.Println("     Admin → Custom Destination → Add")
+		fmt.Printf("     Target: %s,s,1\n", ctx)
+		fmt.Printf("     Description: AI Voice Agent - %s\n", providerName)
+		fmt.Println("")
+		fmt.Println("  6. Use in IVR/Inbound Route:")
+		fmt.Println("     Select your new Custom Destination as call target")
+		if dialplanExtension != "" {
+			fmt.Printf("     Extensions can dial %s directly (from-internal includes %s)\n", dialplanExtension, dialplan.FreePBXFromContext)
+		}
+		fmt.Println("")
+	} else {
+		fmt.Println("Asterisk Setup:")
+		fmt.Printf("  1. Append the snippet above to %s\n", target)
+		fmt.Println("  2. Reload: asterisk -rx \"dialplan reload\"")
+		fmt.Printf("  3. Route calls with Goto(%s,s,1) from your inbound context\n", ctx)
+		fmt.Println("  (FreePBX not detected; use --freepbx for the Config Edit steps)")
+		fmt.Println("")
+	}
 
 	// Print context override notes
 	fmt.Println("Per-Call Overrides:")
@@ -85,3 +153,22 @@ func runDialplan(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func verifyDialplan(opts dialplan.Options) error {
+	ctx := dialplan.ContextName(opts.Provider)
+	app := opts.AppName
+	if app == "" {
+		app = dialplan.DefaultAppName
+	}
+	out, source, err := asterisk.CLI("dialplan show " + ctx)
+	if err != nil {
+		return err
+	}
+	if err := dialplan.VerifyContext(out, ctx, app); err != nil {
+		fmt.Printf("❌ %v (%s)\n", err, source)
+		fmt.Println("   Add the snippet from `agent dialplan` and run: asterisk -rx \"dialplan reload\"")
+		return fmt.Errorf("dialplan verification failed")
+	}
+	fmt.Printf("✅ [%s] is loaded and enters Stasis(%s) (%s)\n", ctx, app, source)
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/digest"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	digestDaily   bool
+	digestSince   time.Duration
+	digestTo      []string
+	digestNoCheck bool
+	digestJSON    bool
+)
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Summary of the last day's calls, call quality, checks and incidents",
+	Long: `Summarize the last 24 hours (--daily, the default) or --since:
+
+  Calls       how many, how many agent rca analyzed, their average quality
+              and how many were critical (score below 50)
+  Checks      agent check runs and the items that failed, still failing or not
+  Incidents   critical calls, Asterisk restarts and quality regressions
+  Top issues  the known issues analyzed calls matched most
+  Changes     updates, config changes and agent annotate notes
+
+A quick agent check is run first so the digest covers the current state;
+--no-check leaves it out. Quality is recorded when agent rca analyzes a
+call, so calls nobody analyzed count only toward the total.
+
+--to picks where the digest goes (stdout, email, slack; several may be
+given). Email and Slack are set up in .env:
+
+  AAVA_DIGEST_SMTP, AAVA_DIGEST_SMTP_USER, AAVA_DIGEST_SMTP_PASSWORD,
+  AAVA_DIGEST_EMAIL_FROM, AAVA_DIGEST_EMAIL_TO   email
+  AAVA_DIGEST_SLACK_WEBHOOK                      Slack incoming webhook
+
+Sink plugins receive the digest too, as kind "digest". Run it from cron:
+
+  0 7 * * * cd /opt/asterisk-ai-voice-agent && agent digest --daily --to email,slack
+
+Exit codes: 0 nothing to report, 1 incidents or checks that failed earlier,
+2 a check still failing, 4 delivery failed.
+
+Examples:
+  agent digest --daily
+  agent digest --since 168h --to email
+  agent digest --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		period := 24 * time.Hour
+		if cmd.Flags().Changed("since") {
+			if digestSince <= 0 {
+				return contract.UsageError(errors.New("--since must be positive"))
+			}
+			period = digestSince
+		}
+		stdout, email, slack := false, false, false
+		for _, to := range digestTo {
+			switch strings.ToLower(strings.TrimSpace(to)) {
+			case "stdout":
+				stdout = true
+			case "email":
+				email = true
+			case "slack":
+				slack = true
+			default:
+				return contract.UsageError(fmt.Errorf("unknown --to %q (use stdout, email or slack)", to))
+			}
+		}
+		format := structuredOutput(digestJSON)
+		if format.Structured() && !stdout {
+			return contract.UsageError(errors.New("--json and --output print the digest; add stdout to --to"))
+		}
+
+		troubleshoot.LoadEnvFile()
+		var mail digest.Email
+		var webhook string
+		var err error
+		if email {
+			if mail, err = digest.EmailFromEnv(); err != nil {
+				return contract.UsageError(err)
+			}
+		}
+		if slack {
+			if webhook, err = digest.SlackWebhook(); err != nil {
+				return contract.UsageError(err)
+			}
+		}
+
+		plugins := plugin.Load()
+		d, err := buildDigest(period, plugins)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		if stdout {
+			if format.Structured() {
+				if err := output.Write(os.Stdout, format, d); err != nil {
+					return err
+				}
+			} else {
+				d.WriteText(os.Stdout)
+			}
+		}
+		runSinks(plugins, "digest", d)
+
+		var failed []string
+		if email {
+			if err := mail.Send(d); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		if slack {
+			if err := digest.PostSlack(&http.Client{Timeout: 15 * time.Second}, webhook, d); err != nil {
+				failed = append(failed, err.Error())
+			}
+		}
+		if len(failed) > 0 {
+			return contract.EnvironmentError(errors.New(strings.Join(failed, "; ")))
+		}
+		if code := d.Code(); code != contract.OK {
+			return contract.Exit(code, nil)
+		}
+		return nil
+	},
+}
+
+// buildDigest gathers the period up to now: calls, quality samples, check runs
+// and trend events. Calls come from the call index, refreshed from the logs
+// when they can be read.
+func buildDigest(period time.Duration, plugins []plugin.Plugin) (*digest.Digest, error) {
+	in := digest.Input{Host: deploymentHost()}
+	if !digestNoCheck {
+		// The run is recorded in the check history read below.
+		profile, _ := check.FindProfile("quick")
+		if _, err := runStandardCheck(profile, 0, plugins); err != nil {
+			in.Notes = append(in.Notes, "agent check could not run: "+err.Error())
+		}
+	}
+	in.To = time.Now()
+	in.From = in.To.Add(-period)
+	calls, err := troubleshoot.RecentCalls(math.MaxInt, nil)
+	if err != nil {
+		in.Notes = append(in.Notes, fmt.Sprintf("calls are from the call index as last refreshed (%v)", err))
+		calls = troubleshoot.IndexedCalls(math.MaxInt)
+	}
+	in.Calls = calls
+
+	// Regressions after the week's events are still worth reporting.
+	samples, events, regressions, err := loadTrend(in.To.Add(-troubleshoot.RegressionWindow))
+	if err != nil {
+		return nil, err
+	}
+	in.Samples, in.Events, in.Regressions = samples, events, regressions
+	if in.Checks, err = troubleshoot.LoadTrendSeries(checkSeries, in.From, checkRunAt); err != nil {
+		return nil, err
+	}
+	return digest.Build(in), nil
+}
+
+// deploymentHost names the deployment in digests and archives: the --host
+// target, or this host.
+func deploymentHost() string {
+	if d := deployment.Current(); d.Remote() {
+		return d.DockerHost
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+func init() {
+	f := digestCmd.Flags()
+	f.BoolVar(&digestDaily, "daily", false, "summarize the last 24 hours (the default)")
+	f.DurationVar(&digestSince, "since", 0, "summarize this far back instead of a day (e.g. 168h)")
+	f.StringSliceVar(&digestTo, "to", []string{"stdout"}, "where to deliver the digest: stdout, email, slack")
+	f.BoolVar(&digestNoCheck, "no-check", false, "do not run a quick agent check first")
+	f.BoolVar(&digestJSON, "json", false, "output as JSON")
+	digestCmd.MarkFlagsMutuallyExclusive("daily", "since")
+	_ = digestCmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions([]string{"stdout", "email", "slack"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.AddCommand(digestCmd)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/manpage"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	docsManDir    string
+	docsSchemaDir string
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate CLI reference documentation",
+}
+
+var docsManCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Generate man pages",
+	Long: `Write one section 1 man page per visible command (agent.1, agent-check.1,
+agent-fleet-doctor.1, ...) into --dir.
+
+The page date comes from SOURCE_DATE_EPOCH when set, so packaged pages are
+reproducible.
+
+Examples:
+  agent docs man --dir ./man
+  sudo agent docs man --dir /usr/local/share/man/man1 && sudo mandb
+  man ./man/agent-check.1`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		written, err := manpage.Generate(rootCmd, docsManDir, manpage.Header{
+			Source: "agent " + version,
+			Manual: "Asterisk AI Voice Agent",
+			Date:   manDate().Format("Jan 2006"),
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %d man pages to %s\n", len(written), docsManDir)
+		return nil
+	},
+}
+
+var docsSchemaCmd = &cobra.Command{
+	Use:   "schema [name]",
+	Short: "Print the JSON Schema of a --json report",
+	Long: fmt.Sprintf(`Print the JSON Schema of a machine-readable report, or list the published
+schemas. Each report carries "schema_version" (currently %d): within a version,
+fields are neither removed nor renamed nor retyped, and new fields may appear,
+so consumers should ignore properties they do not know.
+
+Examples:
+  agent docs schema
+  agent docs schema rca > rca-report.schema.json
+  agent docs schema --dir ./schemas`, contract.SchemaVersion),
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if docsSchemaDir != "" {
+			if len(args) > 0 {
+				return contract.UsageError(fmt.Errorf("--dir writes every schema and takes no name"))
+			}
+			if err := os.MkdirAll(docsSchemaDir, 0755); err != nil {
+				return contract.EnvironmentError(err)
+			}
+			for _, r := range schema.Reports {
+				raw, err := r.Published()
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(filepath.Join(docsSchemaDir, r.File), raw, 0644); err != nil {
+					return contract.EnvironmentError(err)
+				}
+			}
+			fmt.Printf("Wrote %d schemas to %s\n", len(schema.Reports), docsSchemaDir)
+			return nil
+		}
+		if len(args) == 0 {
+			for _, r := range schema.Reports {
+				fmt.Printf("%-8s %-26s %s\n", r.Name, r.File, r.Command)
+			}
+			return nil
+		}
+		r, ok := schema.Lookup(args[0])
+		if !ok {
+			return contract.UsageError(fmt.Errorf("no schema %q (see agent docs schema)", args[0]))
+		}
+		raw, err := r.Published()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(raw)
+		return err
+	},
+}
+
+func manDate() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now()
+}
+
+func init() {
+	docsManCmd.Flags().StringVar(&docsManDir, "dir", "man", "directory to write the pages into")
+	docsSchemaCmd.Flags().StringVar(&docsSchemaDir, "dir", "", "write every schema into this directory")
+	docsCmd.AddCommand(docsManCmd, docsSchemaCmd)
+	rootCmd.AddCommand(docsCmd)
+}
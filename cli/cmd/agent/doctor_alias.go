@@ -5,7 +5,8 @@ import (
 )
 
 var (
-	doctorJSON bool
+	doctorJSON    bool
+	doctorCleanup bool
 )
 
 var doctorCmd = &cobra.Command{
@@ -14,12 +15,13 @@ var doctorCmd = &cobra.Command{
 	Hidden: true,
 	Long:   "Alias of `agent check` retained for backwards compatibility.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		checkJSON = doctorJSON
+		checkJSON, checkCleanup = doctorJSON, doctorCleanup
 		return checkCmd.RunE(cmd, args)
 	},
 }
 
 func init() {
 	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "output as JSON (JSON only)")
+	doctorCmd.Flags().BoolVar(&doctorCleanup, "cleanup", false, "hang up stuck calls and orphaned channels and destroy leaked bridges via ARI")
 	rootCmd.AddCommand(doctorCmd)
 }
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contexts"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	experimentA    string
+	experimentB    string
+	experimentDays int
+	experimentJSON bool
+)
+
+var experimentCmd = &cobra.Command{
+	Use:   "experiment",
+	Short: "Compare prompt and context variants on real calls",
+	Long: `Compare two context variants on the calls they handled: two contexts served
+side by side (a dialplan split between sales and sales_b), or two versions of
+one context before and after a prompt change.
+
+A context's version is the fingerprint of its file in config/contexts/. It is
+recorded by agent context reload and agent experiment tag, and every call is
+tagged with the version its context had when the call started.`,
+}
+
+var experimentTagCmd = &cobra.Command{
+	Use:   "tag [<context> <version>]",
+	Short: "Record the current version of each context",
+	Long: `Record the version of every context file in config/contexts/, so calls from
+now on are tagged with it. agent context reload does this after each reload;
+run it after restarting ai_engine or importing the files into agents.db.
+
+For a context edited in Admin UI, whose prompt lives in agents.db rather than
+a file, give the context and a version label of your own.
+
+Examples:
+  agent experiment tag
+  agent experiment tag sales shorter-greeting`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return contract.UsageError(errors.New("give both a context and a version, or neither"))
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		now := time.Now()
+		var versions []troubleshoot.ContextVersion
+		if len(args) == 2 {
+			name, version := strings.TrimSpace(args[0]), strings.TrimSpace(args[1])
+			if name == "" || version == "" || strings.Contains(name, "@") || strings.Contains(version, "@") {
+				return contract.UsageError(errors.New("context and version must be non-empty and may not contain @"))
+			}
+			versions = append(versions, troubleshoot.ContextVersion{At: now, Context: name, Version: version, Source: "tag"})
+		} else {
+			root, err := resolveRepoRootForFix()
+			if err != nil {
+				return contract.EnvironmentError(err)
+			}
+			res, err := contexts.Lint(filepath.Join(root, "config", "contexts"), contexts.LoadOptions(root))
+			if err != nil {
+				return contract.EnvironmentError(err)
+			}
+			versions = contextFileVersions(res, now)
+			if len(versions) == 0 {
+				fmt.Printf("No loadable context files in %s\n", res.Dir)
+				return nil
+			}
+		}
+		added, err := troubleshoot.RecordContextVersions(versions)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		if len(added) == 0 {
+			fmt.Println("No context changed since its last recorded version.")
+			return nil
+		}
+		for _, v := range added {
+			fmt.Printf("Recorded %s@%s\n", v.Context, v.Version)
+		}
+		return nil
+	},
+}
+
+var experimentReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Compare quality, turn latency and duration between two variants",
+	Long: `Compare the calls of two variants over the last days. A variant is a context
+name, or context@version for one version of it (a prefix of the version is
+enough; agent experiment report --a sales lists the recorded versions).
+
+For each metric the report shows both medians and a two-sided Mann-Whitney
+test (p < 0.05 with at least 5 calls a side is significant):
+  - quality score, for calls analyzed by agent rca (agent rca --last N scores
+    a batch)
+  - average turn latency and call duration, from Call History
+
+Calls and latencies come from Call History in ai_engine; quality scores and
+context versions are kept in .agent/quality/.
+
+Examples:
+  agent experiment report --a sales --b sales_b
+  agent experiment report --a sales@3f2a91c0 --b sales@8c1d04e7 --days 30`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if experimentDays < 1 || experimentDays > 90 {
+			return contract.UsageError(errors.New("--days must be between 1 and 90"))
+		}
+		if experimentA == "" {
+			return contract.UsageError(errors.New("--a is required"))
+		}
+		a, err := troubleshoot.ParseVariant(experimentA)
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if experimentB == "" {
+			return listContextVersions(a.Context)
+		}
+		b, err := troubleshoot.ParseVariant(experimentB)
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if a == b {
+			return contract.UsageError(errors.New("--a and --b select the same calls"))
+		}
+		since := time.Now().AddDate(0, 0, -experimentDays)
+		calls, err := troubleshoot.LoadExperimentCalls(since)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		rep := troubleshoot.CompareVariants(calls, a, b)
+
+		if format := structuredOutput(experimentJSON); format.Structured() {
+			return output.Write(os.Stdout, format, map[string]any{
+				"schema_version": contract.SchemaVersion,
+				"days":           experimentDays,
+				"report":         rep,
+			})
+		}
+		printExperiment(rep, since)
+		return nil
+	},
+}
+
+// contextFileVersions returns the versions of the context files the engine can
+// load; a file with errors is skipped by the engine, so it has no live version.
+func contextFileVersions(res *contexts.Result, at time.Time) []troubleshoot.ContextVersion {
+	broken := map[string]bool{}
+	for _, f := range res.Findings {
+		if f.Severity == contexts.SeverityError {
+			broken[f.File] = true
+		}
+	}
+	var out []troubleshoot.ContextVersion
+	for _, f := range res.Files {
+		if f.Version != "" && !broken[f.File] {
+			out = append(out, troubleshoot.ContextVersion{At: at, Context: f.Context, Version: f.Version, Source: f.File})
+		}
+	}
+	return out
+}
+
+func listContextVersions(context string) error {
+	versions, err := troubleshoot.LoadContextVersions()
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	var shown int
+	for _, v := range versions {
+		if v.Context != context {
+			continue
+		}
+		if shown == 0 {
+			fmt.Printf("Recorded versions of %s:\n", context)
+		}
+		shown++
+		fmt.Printf("  %s  %s@%s  (%s)\n", v.At.Local().Format("2006-01-02 15:04"), v.Context, v.Version, v.Source)
+	}
+	if shown == 0 {
+		fmt.Printf("No recorded versions of %s. Run: agent experiment tag\n", context)
+	}
+	fmt.Println("\nCompare two variants with --b, e.g. --a context@version --b context@version")
+	return nil
+}
+
+func printExperiment(rep troubleshoot.ExperimentReport, since time.Time) {
+	fmt.Printf("Experiment since %s: A = %s (%d call(s)), B = %s (%d call(s))\n\n",
+		since.Format("2006-01-02"), rep.A, rep.CallsA, rep.B, rep.CallsB)
+	if rep.CallsA == 0 || rep.CallsB == 0 {
+		fmt.Println("One variant has no calls in the window; nothing to compare.")
+		fmt.Println("Check the names against Call History's context_name, and list versions with: agent experiment report --a <context>")
+		return
+	}
+	labels := map[string]string{}
+	for _, m := range troubleshoot.ExperimentMetrics {
+		labels[m.Name] = m.Label
+	}
+	fmt.Printf("  %-14s %10s %10s %9s %7s\n", "metric", "A median", "B median", "calls", "p")
+	for _, m := range rep.Metrics {
+		p := "-"
+		if m.P != nil {
+			p = fmt.Sprintf("%.3f", *m.P)
+		}
+		verdict := ""
+		switch {
+		case m.Winner != "":
+			verdict = strings.ToUpper(m.Winner) + " better"
+		case m.Different:
+			verdict = "differs"
+		}
+		fmt.Printf("  %-14s %10s %10s %9s %7s  %s\n", labels[m.Metric],
+			experimentValue(m.MedianA, m.Metric), experimentValue(m.MedianB, m.Metric),
+			fmt.Sprintf("%d/%d", m.NA, m.NB), p, verdict)
+	}
+	fmt.Println()
+	fmt.Println("p < 0.05 is significant; \"-\" means fewer than 5 calls on a side.")
+	if rep.Metrics[0].NA+rep.Metrics[0].NB < rep.CallsA+rep.CallsB {
+		fmt.Println("Quality scores cover analyzed calls only; score more with: agent rca --last N")
+	}
+}
+
+func experimentValue(v *float64, metric string) string {
+	if v == nil {
+		return "-"
+	}
+	switch metric {
+	case "turn_latency_ms":
+		return fmt.Sprintf("%.0fms", *v)
+	case "duration_s":
+		return (time.Duration(math.Round(*v)) * time.Second).String()
+	}
+	return fmt.Sprintf("%.0f", *v)
+}
+
+func init() {
+	experimentReportCmd.Flags().StringVar(&experimentA, "a", "", "variant A: context or context@version")
+	experimentReportCmd.Flags().StringVar(&experimentB, "b", "", "variant B: context or context@version (omit to list A's versions)")
+	experimentReportCmd.Flags().IntVar(&experimentDays, "days", 14, "how many days of calls to compare (1-90)")
+	experimentReportCmd.Flags().BoolVar(&experimentJSON, "json", false, "output as JSON")
+
+	experimentCmd.AddCommand(experimentTagCmd, experimentReportCmd)
+	rootCmd.AddCommand(experimentCmd)
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/chaos"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/failover"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/wizard"
+	"github.com/spf13/cobra"
+)
+
+// failoverIDBase keeps drill call IDs apart from chaos and load test ones.
+const failoverIDBase = 810000
+
+var (
+	failoverProvider string
+	failoverFallback string
+	failoverContext  string
+	failoverAudio    string
+	failoverDuration time.Duration
+	failoverJSON     bool
+)
+
+var failoverCmd = &cobra.Command{
+	Use:   "failover",
+	Short: "Validate and drill the provider fallback chain",
+	Long: `Check what happens to a call when its provider fails, and rehearse it.
+
+The engine falls back in two ways. A call whose provider or pipeline is not
+loaded runs default_provider. A provider that fails to start on an answered
+call triggers on_provider_failure: with dialplan_redirect the caller leaves
+Stasis for provider_failure_redirect_context, whose Set(AI_PROVIDER=...) names
+the fallback provider; a context without Stasis sends the caller to a queue,
+voicemail or similar instead.
+
+The redirect context is read from Asterisk (dialplan show). --fallback names
+the fallback provider when Asterisk is not reachable.
+
+Examples:
+  agent failover check
+  agent failover check --provider openai_realtime
+  agent failover drill --provider openai_realtime`,
+}
+
+var failoverCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that the fallback chain is fully configured",
+	Long: `Check each step of the fallback chain for --provider (default:
+default_provider): the primary, default_provider and the fallback exist, are
+enabled and have their API keys in .env; on_provider_failure redirects to a
+context loaded in Asterisk; the fallback is not the primary, does not share its
+endpoints and sends the caller the same audio format.
+
+Exits with the warning code when there is no fallback or a step is doubtful,
+and with the failure code when a step would not take a call.`,
+	Args: cobra.NoArgs,
+	RunE: runFailoverCheck,
+}
+
+var failoverDrillCmd = &cobra.Command{
+	Use:   "drill",
+	Short: "Fail the primary provider during a test call and time the switchover",
+	Long: `Drop all traffic from ai_engine to the primary provider's endpoints (as agent
+chaos outage does), originate a synthetic test call into the primary's context
+(as agent chaos --call does), and read the ai_engine logs to see whether the
+fallback took the call.
+
+The report gives the detection time, from the call reaching the engine to the
+primary failing, and the switchover time, from the failure to the fallback
+provider starting (or, for a redirect that leaves the engine, to the redirect).
+
+The chain must pass agent failover check without errors and redirect on
+failure, and the fallback must not share an endpoint with the primary. The
+fault is removed when the call ends, after --duration, or on Ctrl-C; if the CLI
+is killed first, run "agent chaos clear".
+
+Exits with the failure code when the fallback did not engage, and with the
+warning code when the primary never failed during the call.`,
+	Args: cobra.NoArgs,
+	RunE: runFailoverDrill,
+}
+
+func runFailoverCheck(cmd *cobra.Command, args []string) error {
+	troubleshoot.LoadEnvFile()
+	cfg, err := failoverConfig()
+	if err != nil {
+		return err
+	}
+	chain, err := failoverChain(cfg)
+	if err != nil {
+		return err
+	}
+	report := failover.Validate(cfg, chain, chaosEnv, wizard.ProviderEnvKeys)
+	format := structuredOutput(failoverJSON)
+	if format.Structured() {
+		if err := output.Write(os.Stdout, format, map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"chain":          chain,
+			"checks":         report,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printFailoverCheck(os.Stdout, chain, report)
+	}
+	if code := contract.ReportCode(len(report.Warnings), len(report.Errors)); code != contract.OK {
+		return contract.Exit(code, nil)
+	}
+	return nil
+}
+
+func runFailoverDrill(cmd *cobra.Command, args []string) error {
+	if failoverDuration < 20*time.Second {
+		return contract.UsageError(errors.New("--duration must be at least 20s"))
+	}
+	troubleshoot.LoadEnvFile()
+	format := structuredOutput(failoverJSON)
+	progress := os.Stdout
+	if format.Structured() {
+		progress = os.Stderr
+	}
+
+	cfg, err := failoverConfig()
+	if err != nil {
+		return err
+	}
+	chain, err := failoverChain(cfg)
+	if err != nil {
+		return err
+	}
+	report := failover.Validate(cfg, chain, chaosEnv, wizard.ProviderEnvKeys)
+	printFailoverCheck(progress, chain, report)
+	if len(report.Errors) > 0 {
+		return contract.Exit(contract.Fail, errors.New("fix the fallback chain before drilling it"))
+	}
+	if chain.Action != failover.ActionRedirect {
+		return contract.Exit(contract.Fail, fmt.Errorf("on_provider_failure is %s, so there is no fallback to drill", chain.Action))
+	}
+
+	primaryTargets, err := chaos.ProviderTargets(cfg, chain.Primary, chaosEnv)
+	if err != nil {
+		return contract.UsageError(err)
+	}
+	targets, err := chaosLookup(primaryTargets)
+	if err != nil {
+		return err
+	}
+	if chain.Fallback != "" {
+		if err := failoverSeparate(cfg, chain, targets); err != nil {
+			return err
+		}
+	}
+	rules, err := chaosRules(targets)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	if err := chaosCheckDevices(rules); err != nil {
+		return contract.EnvironmentError(err)
+	}
+
+	ari, err := loadtestARIConfig()
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	agentContext := failoverContext
+	if agentContext == "" {
+		agentContext = dialplan.ContextName(chain.Primary)
+	}
+	if err := verifyLoadtestDialplan(agentContext, ari.AppName); err != nil {
+		return contract.EnvironmentError(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	removeFault, err := chaosApply(chaos.Fault{Kind: chaos.Outage}, rules)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	defer removeFault()
+
+	start := time.Now()
+	var names []string
+	for _, t := range targets {
+		names = append(names, t.String())
+	}
+	fmt.Fprintf(progress, "\n⚡ Dropping traffic to %s (%s)\n", strings.Join(names, ", "), chain.Primary)
+	callID := fmt.Sprintf("%d.%d", start.Unix(), failoverIDBase+1)
+	if err := originateLoadtestCall(ari, agentContext, callID, 1, failoverAudio, int(failoverDuration.Seconds())); err != nil {
+		return contract.EnvironmentError(fmt.Errorf("test call failed: %w", err))
+	}
+	fmt.Fprintf(progress, "  📞 Test call %s placed into [%s]; waiting for it to end (up to %s)\n", callID, agentContext, failoverDuration)
+
+	done := make(chan struct{})
+	go func() {
+		waitLoadtestCalls(ari, []string{callID + "-caller"}, failoverDuration+30*time.Second)
+		close(done)
+	}()
+	interrupted := false
+	select {
+	case <-done:
+	case <-ctx.Done():
+		interrupted = true
+		hctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+		_ = asterisk.Hangup(hctx, ari, callID+"-caller")
+		cancel()
+	}
+	removeFault()
+	end := time.Now()
+	if interrupted {
+		fmt.Fprintln(progress, "Interrupted; fault removed")
+	} else {
+		fmt.Fprintln(progress, "Fault removed; reading the engine logs...")
+	}
+	time.Sleep(3 * time.Second)
+
+	lines, err := loadtestLogLines(time.Since(start) + time.Minute)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	sw := troubleshoot.AnalyzeSwitchover(callID, troubleshoot.FilterCallLines(lines, callID), chain.Fallback)
+
+	code := contract.OK
+	switch sw.Verdict {
+	case troubleshoot.SwitchMissed:
+		code = contract.Fail
+	case troubleshoot.SwitchNoFailure:
+		code = contract.Warn
+	}
+	if format.Structured() {
+		if err := output.Write(os.Stdout, format, map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"chain":          chain,
+			"checks":         report,
+			"targets":        targets,
+			"rules":          rules,
+			"context":        agentContext,
+			"duration":       end.Sub(start).Round(time.Second).String(),
+			"interrupted":    interrupted,
+			"switchover":     sw,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printSwitchover(sw)
+	}
+	if code != contract.OK {
+		return contract.Exit(code, nil)
+	}
+	return nil
+}
+
+func failoverConfig() (map[string]any, error) {
+	cfg, _, err := configmerge.Effective(filepath.Join("config", "ai-agent.yaml"), configEnv())
+	if err != nil {
+		return nil, contract.EnvironmentError(fmt.Errorf("%w; run from the project directory", err))
+	}
+	return cfg, nil
+}
+
+// failoverChain resolves the chain and reads its redirect context from
+// Asterisk, unless --fallback names the fallback.
+func failoverChain(cfg map[string]any) (failover.Chain, error) {
+	chain, err := failover.Resolve(cfg, failoverProvider)
+	if err != nil {
+		return chain, contract.UsageError(err)
+	}
+	if failoverFallback != "" {
+		chain.Fallback = failoverFallback
+		return chain, nil
+	}
+	if chain.Action == failover.ActionRedirect && chain.Context != "" {
+		if out, _, err := asterisk.CLI("dialplan show " + chain.Context); err == nil {
+			chain.SetTarget(failover.ParseTarget(out, chain.Context))
+		}
+	}
+	return chain, nil
+}
+
+// failoverSeparate refuses a drill whose outage would also cut the fallback off.
+func failoverSeparate(cfg map[string]any, chain failover.Chain, primary []chaos.Target) error {
+	fallback, err := chaos.ProviderTargets(cfg, chain.Fallback, chaosEnv)
+	if err != nil {
+		return nil // nothing known to collide with
+	}
+	if fallback, err = chaosLookup(fallback); err != nil {
+		return err
+	}
+	cut := map[string]bool{}
+	for _, t := range primary {
+		for _, ip := range t.IPs {
+			cut[fmt.Sprintf("%s:%d", ip, t.Port)] = true
+		}
+	}
+	for _, t := range fallback {
+		for _, ip := range t.IPs {
+			if cut[fmt.Sprintf("%s:%d", ip, t.Port)] {
+				return contract.EnvironmentError(fmt.Errorf("%s and the fallback %s both use %s, so the outage would cut the fallback off too", chain.Primary, chain.Fallback, t))
+			}
+		}
+	}
+	return nil
+}
+
+func printFailoverCheck(w *os.File, chain failover.Chain, r failover.Report) {
+	fmt.Fprintf(w, "Fallback chain for %s\n", chain.Primary)
+	onFailure := chain.Action
+	if chain.Redirect != "" {
+		onFailure += " → " + chain.Redirect
+	}
+	fmt.Fprintf(w, "  on failure: %s\n", onFailure)
+	if chain.Fallback != "" {
+		fmt.Fprintf(w, "  fallback:   %s\n", chain.Fallback)
+	}
+	fmt.Fprintln(w)
+	for _, s := range r.Passed {
+		fmt.Fprintf(w, "✓ %s\n", s)
+	}
+	for _, s := range r.Warnings {
+		fmt.Fprintf(w, "⚠️  %s\n", s)
+	}
+	for _, s := range r.Errors {
+		fmt.Fprintf(w, "❌ %s\n", s)
+	}
+	fmt.Fprintf(w, "\nSummary: %d passed, %d warning(s), %d error(s)\n", len(r.Passed), len(r.Warnings), len(r.Errors))
+}
+
+func printSwitchover(sw troubleshoot.Switchover) {
+	fmt.Println()
+	icon := "✅"
+	switch sw.Verdict {
+	case troubleshoot.SwitchMissed:
+		icon = "❌"
+	case troubleshoot.SwitchNoFailure:
+		icon = "⚠️ "
+	}
+	fmt.Printf("%s %s %s: %s\n", icon, sw.CallID, sw.Verdict, sw.Finding)
+	if sw.Failure != "" {
+		fmt.Printf("   detection %.1fs (call start to primary failure)", float64(sw.DetectionMS)/1000)
+		if sw.Verdict == troubleshoot.SwitchEngaged {
+			fmt.Printf(", switchover %.1fs", float64(sw.SwitchoverMS)/1000)
+		}
+		fmt.Println()
+	}
+}
+
+func init() {
+	failoverCmd.PersistentFlags().StringVar(&failoverProvider, "provider", "", "primary provider or pipeline (default: default_provider)")
+	failoverCmd.PersistentFlags().StringVar(&failoverFallback, "fallback", "", "provider or pipeline the redirect context runs, instead of reading it from Asterisk")
+	failoverCmd.PersistentFlags().BoolVar(&failoverJSON, "json", false, "output as JSON")
+	failoverDrillCmd.Flags().StringVar(&failoverContext, "context", "", "dialplan context the test call enters (default: from --provider)")
+	failoverDrillCmd.Flags().StringVar(&failoverAudio, "audio", "hello-world", "recording the test caller plays")
+	failoverDrillCmd.Flags().DurationVar(&failoverDuration, "duration", 90*time.Second, "how long the test caller stays on the line")
+	failoverDrillCmd.Flags().StringVar(&chaosTCImage, "tc-image", "nicolaka/netshoot", "helper image with tc and ip")
+	failoverCmd.AddCommand(failoverCheckCmd, failoverDrillCmd)
+	rootCmd.AddCommand(failoverCmd)
+}
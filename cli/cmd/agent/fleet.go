@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/fleet"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fleetSites      []string
+	fleetParallel   int
+	fleetJSON       bool
+	fleetHost       string
+	fleetContext    string
+	fleetProjectDir string
+)
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Run diagnostics and updates across registered deployments",
+	Long: `Manage several deployments (for example one per customer PBX) from one machine.
+
+Sites are stored in .agent/fleet.yaml. Each site is reached through its docker
+endpoint (ssh://user@server, tcp://..., or a docker context); updates run
+agent update over ssh inside the site's project_dir.
+
+Examples:
+  agent fleet add acme --host ssh://ops@pbx.acme.example --project-dir /opt/Asterisk-AI-Voice-Agent
+  agent fleet doctor
+  agent fleet update --site acme -- --plan
+  agent fleet report --json`,
+}
+
+var fleetAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Register or replace a site",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reg, err := fleet.Load(fleet.Path())
+		if err != nil {
+			return err
+		}
+		site := fleet.Site{Name: args[0], Host: strings.TrimSpace(fleetHost), Context: strings.TrimSpace(fleetContext), ProjectDir: strings.TrimSpace(fleetProjectDir)}
+		if err := reg.Add(site); err != nil {
+			return err
+		}
+		if err := reg.Save(fleet.Path()); err != nil {
+			return err
+		}
+		fmt.Printf("Registered %s (%s) in %s\n", site.Name, site.Endpoint(), fleet.Path())
+		return nil
+	},
+}
+
+var fleetRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Unregister a site",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reg, err := fleet.Load(fleet.Path())
+		if err != nil {
+			return err
+		}
+		if err := reg.Remove(args[0]); err != nil {
+			return err
+		}
+		return reg.Save(fleet.Path())
+	},
+}
+
+var fleetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered sites",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reg, err := fleet.Load(fleet.Path())
+		if err != nil {
+			return err
+		}
+		if structuredOutput(fleetJSON).Structured() {
+			sites := reg.Sites
+			if sites == nil {
+				sites = []fleet.Site{}
+			}
+			return writeFleetJSON(struct {
+				SchemaVersion int          `json:"schema_version"`
+				Sites         []fleet.Site `json:"sites"`
+			}{contract.SchemaVersion, sites})
+		}
+		if len(reg.Sites) == 0 {
+			fmt.Println("No sites registered. Add one with: agent fleet add <name> --host ssh://user@server")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintln(tw, "SITE\tENDPOINT\tPROJECT DIR")
+		for _, s := range reg.Sites {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, s.Endpoint(), emptyDash(s.ProjectDir))
+		}
+		return tw.Flush()
+	},
+}
+
+var fleetDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Run agent check on every site",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		results, err := runFleet(fleetDoctorSite)
+		if err != nil {
+			return err
+		}
+		if structuredOutput(fleetJSON).Structured() {
+			_ = writeFleetJSON(fleetPayload{SchemaVersion: contract.SchemaVersion, Results: results})
+		} else {
+			for _, r := range results {
+				printFleetResult(r)
+			}
+			printFleetTotals(results)
+		}
+		return exitFleet(results)
+	},
+}
+
+var fleetUpdateCmd = &cobra.Command{
+	Use:   "update [-- <agent update flags>]",
+	Short: "Run agent update on every site",
+	Long: `Run agent update on every selected site over ssh. Flags after -- are passed to
+agent update unchanged (for example: agent fleet update -- --plan). Sites run one
+at a time unless --parallel is raised; each site's output is saved under
+.agent/fleet/logs/.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		passthrough := args
+		results, err := runFleet(func(s fleet.Site) fleet.Result { return fleetUpdateSite(s, passthrough) })
+		if err != nil {
+			return err
+		}
+		if structuredOutput(fleetJSON).Structured() {
+			_ = writeFleetJSON(fleetPayload{SchemaVersion: contract.SchemaVersion, Results: results})
+		} else {
+			for _, r := range results {
+				printFleetResult(r)
+			}
+			printFleetTotals(results)
+		}
+		return exitFleet(results)
+	},
+}
+
+var fleetReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Aggregated health matrix across sites",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		results, err := runFleet(fleetDoctorSite)
+		if err != nil {
+			return err
+		}
+		if structuredOutput(fleetJSON).Structured() {
+			_ = writeFleetJSON(struct {
+				SchemaVersion int            `json:"schema_version"`
+				Timestamp     time.Time      `json:"timestamp"`
+				Totals        map[string]int `json:"totals"`
+				Sites         []fleet.Result `json:"sites"`
+			}{contract.SchemaVersion, time.Now(), fleet.Totals(results), results})
+			return exitFleet(results)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintln(tw, "SITE\tSTATUS\tSUMMARY\tFAILING CHECKS\tTIME")
+		for _, r := range results {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Site, strings.ToUpper(r.Status), r.Summary, emptyDash(strings.Join(r.Failing, ", ")), r.Duration.Round(time.Second))
+		}
+		_ = tw.Flush()
+		printFleetTotals(results)
+		return exitFleet(results)
+	},
+}
+
+func runFleet(fn func(fleet.Site) fleet.Result) ([]fleet.Result, error) {
+	reg, err := fleet.Load(fleet.Path())
+	if err != nil {
+		return nil, err
+	}
+	sites, err := reg.Select(fleetSites)
+	if err != nil {
+		return nil, err
+	}
+	return fleet.Run(sites, fleetParallel, fn), nil
+}
+
+// fleetChild runs this agent binary against one site. Each site gets its own process
+// so per-deployment state (descriptor, docker endpoint) never leaks between sites.
+func fleetChild(s fleet.Site, args ...string) (stdout []byte, combined string, exitCode int, err error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, "", -1, err
+	}
+	var out, errBuf bytes.Buffer
+	cmd := exec.Command(self, append([]string{"--no-color"}, args...)...)
+	cmd.Env = s.Env()
+	cmd.Stdout = &out
+	cmd.Stderr = &errBuf
+	err = cmd.Run()
+	exitCode = 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode, err = exitErr.ExitCode(), nil
+	}
+	return out.Bytes(), out.String() + errBuf.String(), exitCode, err
+}
+
+func fleetDoctorSite(s fleet.Site) fleet.Result {
+	stdout, combined, _, err := fleetChild(s, "check", "--json")
+	if err != nil {
+		return fleet.Result{Status: fleet.StatusError, Summary: err.Error(), Output: combined}
+	}
+	var rep check.Report
+	if err := json.Unmarshal(stdout, &rep); err != nil {
+		return fleet.Result{Status: fleet.StatusError, Summary: "unreachable or no report: " + firstLine(combined), Output: combined}
+	}
+	res := fleet.Result{
+		Status:  fleet.StatusPass,
+		Summary: fmt.Sprintf("%d pass, %d warn, %d fail", rep.PassCount, rep.WarnCount, rep.FailCount),
+		Output:  combined,
+	}
+	switch {
+	case rep.FailCount > 0:
+		res.Status = fleet.StatusFail
+	case rep.WarnCount > 0:
+		res.Status = fleet.StatusWarn
+	}
+	for _, item := range rep.Items {
+		if item.Status == check.StatusFail {
+			res.Failing = append(res.Failing, item.Name)
+		}
+	}
+	return res
+}
+
+func fleetUpdateSite(s fleet.Site, passthrough []string) fleet.Result {
+	if s.Host == "" || !strings.HasPrefix(s.Host, "ssh://") {
+		return fleet.Result{Status: fleet.StatusError, Summary: "updates need an ssh:// host (docker contexts cannot run git on the server)"}
+	}
+	if s.ProjectDir == "" {
+		return fleet.Result{Status: fleet.StatusError, Summary: "no project_dir registered (agent fleet add " + s.Name + " --project-dir ...)"}
+	}
+	_, combined, code, err := fleetChild(s, append([]string{"update"}, passthrough...)...)
+	logPath := saveFleetLog(s.Name, "update", combined)
+	switch {
+	case err != nil:
+		return fleet.Result{Status: fleet.StatusError, Summary: err.Error(), Output: combined}
+	case code != 0:
+		return fleet.Result{Status: fleet.StatusFail, Summary: fmt.Sprintf("exit %d: %s (log: %s)", code, lastLine(combined), logPath), Output: combined}
+	}
+	return fleet.Result{Status: fleet.StatusPass, Summary: "updated (log: " + logPath + ")", Output: combined}
+}
+
+func saveFleetLog(site, action, output string) string {
+	dir := filepath.Join(".agent", "fleet", "logs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "-"
+	}
+	p := filepath.Join(dir, fmt.Sprintf("%s-%s-%s.log", site, action, time.Now().UTC().Format("20060102T150405Z")))
+	if err := os.WriteFile(p, []byte(output), 0o644); err != nil {
+		return "-"
+	}
+	return p
+}
+
+func printFleetResult(r fleet.Result) {
+	icon := map[string]string{fleet.StatusPass: "✅", fleet.StatusWarn: "⚠️ ", fleet.StatusFail: "❌", fleet.StatusError: "❌"}[r.Status]
+	fmt.Printf("%s %s (%s): %s\n", icon, r.Site, r.Endpoint, r.Summary)
+	if len(r.Failing) > 0 {
+		fmt.Printf("   failing: %s\n", strings.Join(r.Failing, ", "))
+	}
+	if verbose && strings.TrimSpace(r.Output) != "" {
+		for _, line := range strings.Split(strings.TrimRight(r.Output, "\n"), "\n") {
+			fmt.Printf("   | %s\n", line)
+		}
+	}
+}
+
+func printFleetTotals(results []fleet.Result) {
+	t := fleet.Totals(results)
+	fmt.Printf("\n%d site(s): %d pass, %d warn, %d fail, %d unreachable\n", len(results), t[fleet.StatusPass], t[fleet.StatusWarn], t[fleet.StatusFail], t[fleet.StatusError])
+}
+
+func exitFleet(results []fleet.Result) error {
+	if code := fleet.ExitCode(results); code != 0 {
+		return contract.Exit(code, nil)
+	}
+	return nil
+}
+
+// fleetPayload wraps doctor and update results so they carry the schema version.
+type fleetPayload struct {
+	SchemaVersion int            `json:"schema_version"`
+	Results       []fleet.Result `json:"results"`
+}
+
+func writeFleetJSON(v any) error {
+	return output.Write(os.Stdout, structuredOutput(fleetJSON), v)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+func emptyDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	fleetAddCmd.Flags().StringVar(&fleetHost, "host", "", "docker endpoint, e.g. ssh://ops@pbx.example.com")
+	fleetAddCmd.Flags().StringVar(&fleetContext, "context", "", "docker context name (alternative to --host)")
+	fleetAddCmd.Flags().StringVar(&fleetProjectDir, "project-dir", "", "repository checkout on the server (needed for fleet update)")
+
+	for _, c := range []*cobra.Command{fleetDoctorCmd, fleetUpdateCmd, fleetReportCmd} {
+		c.Flags().StringSliceVar(&fleetSites, "site", nil, "limit to these sites (repeatable or comma-separated)")
+		c.Flags().BoolVar(&fleetJSON, "json", false, "output as JSON")
+	}
+	fleetListCmd.Flags().BoolVar(&fleetJSON, "json", false, "output as JSON")
+	fleetDoctorCmd.Flags().IntVar(&fleetParallel, "parallel", 4, "sites to check concurrently")
+	fleetReportCmd.Flags().IntVar(&fleetParallel, "parallel", 4, "sites to check concurrently")
+	fleetUpdateCmd.Flags().IntVar(&fleetParallel, "parallel", 1, "sites to update concurrently")
+
+	fleetCmd.AddCommand(fleetAddCmd, fleetRemoveCmd, fleetListCmd, fleetDoctorCmd, fleetUpdateCmd, fleetReportCmd)
+	rootCmd.AddCommand(fleetCmd)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Show the hook scripts run on updates and analyzed calls",
+	Long: `Show which hook scripts are installed in .agent/hooks.
+
+A hook is an executable named after its hook point, run with a JSON event on
+stdin and its output sent to stderr:
+
+  pre-update        before agent update changes anything; a non-zero exit
+                    aborts the update
+  post-update       after agent update, with its result and check counts
+  post-rca          after agent rca or agent troubleshoot analyzed a call,
+                    with the call's report
+  on-critical-call  after post-rca when the call's result is FAIL
+
+Only pre-update can stop a command; other hooks that fail are reported and
+ignored. AAVA_HOOKS=off turns hooks off for a run.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if hooks.Disabled() {
+			fmt.Println("⚠️  AAVA_HOOKS=off: hooks are not run")
+			fmt.Println()
+		}
+		broken := 0
+		for _, name := range hooks.Names {
+			path, ok := hooks.Find(name)
+			if !ok {
+				fmt.Printf("  %-18s not installed\n", name)
+				continue
+			}
+			if err := hooks.Usable(path); err != nil {
+				fmt.Printf("❌ %-18s %v\n", name, err)
+				broken++
+				continue
+			}
+			fmt.Printf("✓ %-18s %s\n", name, path)
+		}
+		if broken > 0 {
+			return contract.Exit(contract.Warn, nil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hooksCmd)
+}
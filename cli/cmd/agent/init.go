@@ -3,43 +3,79 @@ package main
 import (
 	"fmt"
 
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/wizard"
 	"github.com/spf13/cobra"
 )
 
 var (
 	initNonInteractive bool
-	initTemplate       string
+	initAnswers        string
+	initPreset         string
 )
 
 var initCmd = &cobra.Command{
-	Use:    "init",
-	Short:  "Interactive setup wizard",
-	Hidden: true, // v5.0: prefer `agent setup`
-	Long: `Interactive setup wizard for Asterisk AI Voice Agent.
+	Use:   "init",
+	Short: "First-run setup wizard for a new installation",
+	Long: `First-run setup wizard for Asterisk AI Voice Agent.
 
-Guides you through configuration:
-  - Asterisk ARI credentials
+Creates .env and config/ai-agent.yaml from the shipped templates when they
+are missing, then guides you through:
+  - Asterisk ARI credentials (offered from /etc/asterisk/ari*.conf when
+    Asterisk runs on this host) with a live ARI login test
   - Audio transport (AudioSocket/ExternalMedia)
-  - AI provider selection (OpenAI, Deepgram, Anthropic, etc.)
-  - Pipeline configuration
-  - Configuration validation
+  - AI provider or pipeline selection, or a curated --preset
+  - API keys, tested against the provider where supported
+  - Starting the stack with docker compose, then agent check
 
-This can be run multiple times to reconfigure the system.`,
+Use agent setup to reconfigure an installation that is already running.
+
+For provisioning tools, --non-interactive reads a YAML or JSON answers file
+(--answers) and fills anything it leaves empty from the environment
+(ASTERISK_HOST, ASTERISK_ARI_USERNAME, OPENAI_API_KEY, ...). It runs the same
+validation and exits non-zero, before .env or overrides are updated, when a
+check fails.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if initAnswers != "" && !initNonInteractive {
+			return fmt.Errorf("--answers requires --non-interactive")
+		}
 		if initNonInteractive {
-			return fmt.Errorf("--non-interactive is not implemented; refusing to report a false successful setup")
+			answers, err := wizard.LoadAnswers(initAnswers)
+			if err != nil {
+				return err
+			}
+			if initPreset != "" {
+				answers.Preset = initPreset
+			}
+			return wizard.RunNonInteractive(answers)
+		}
+		var preset *wizard.Preset
+		if initPreset != "" {
+			p, err := wizard.FindPreset(initPreset)
+			if err != nil {
+				return err
+			}
+			preset = &p
+		}
+		w, err := wizard.NewInitWizard()
+		if err != nil {
+			return fmt.Errorf("failed to initialize wizard: %w", err)
+		}
+		if preset != nil {
+			w.UsePreset(*preset)
 		}
-		if initTemplate != "" {
-			return fmt.Errorf("--template is not implemented; use the interactive target selector in `agent setup`")
+		if err := w.Run(); err != nil {
+			return err
 		}
-		fmt.Println("Note: `agent init` is a legacy alias; launching `agent setup`.")
-		return setupCmd.RunE(cmd, args)
+		return checkCmd.RunE(cmd, args)
 	},
 }
 
 func init() {
-	initCmd.Flags().BoolVar(&initNonInteractive, "non-interactive", false, "non-interactive mode (use defaults)")
-	initCmd.Flags().StringVar(&initTemplate, "template", "", "config template: local|cloud|hybrid|openai-agent|deepgram-agent")
+	initCmd.Flags().BoolVar(&initNonInteractive, "non-interactive", false, "configure without prompts from --answers and the environment")
+	initCmd.Flags().StringVar(&initAnswers, "answers", "", "YAML or JSON answers file for --non-interactive")
+	initCmd.Flags().StringVar(&initPreset, "preset", "", "provider preset: openai-realtime, deepgram-gpt-elevenlabs, local (see: agent config preset)")
+	initCmd.Flags().StringVar(&initPreset, "template", "", "")
+	_ = initCmd.Flags().MarkDeprecated("template", "use --preset")
 
 	rootCmd.AddCommand(initCmd)
 }
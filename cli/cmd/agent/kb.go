@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/knowledge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+// maxPackSize bounds a fingerprint pack fetched by agent kb import.
+const maxPackSize = 1 << 20
+
+var (
+	kbExportID        string
+	kbExportTitle     string
+	kbExportCause     string
+	kbExportFix       []string
+	kbExportDocs      string
+	kbExportLines     []string
+	kbExportMetrics   []string
+	kbExportProvider  string
+	kbExportTransport string
+	kbExportOut       string
+
+	kbImportName    string
+	kbImportReplace bool
+
+	kbListJSON bool
+)
+
+var kbCmd = &cobra.Command{
+	Use:   "kb",
+	Short: "Share troubleshooting fingerprints with other deployments",
+	Long: `Share the known issue fingerprints agent rca applies before its LLM.
+
+A fingerprint exported from a solved call carries no call data: log text is
+redacted and its numbers, IDs and quoting generalized. Imported packs are
+kept in .agent/knowledge/ and extend the built-in set and
+config/rca-knowledge.yaml.`,
+}
+
+var kbExportCmd = &cobra.Command{
+	Use:   "export [call_id]",
+	Short: "Write an anonymized fingerprint from a solved call",
+	Long: `Write a fingerprint pack for a call whose problem you solved, to share with
+other deployments or add to config/rca-knowledge.yaml.
+
+The call's first error line (else its first warning) becomes the log pattern;
+--line picks others by a substring. --metric adds conditions over the metric
+names of agent rca --json, and --provider/--transport limit the issue. The
+fingerprint is checked to match the call before it is written.
+
+Examples:
+  agent kb export 1761234567.42 --title "Dialplan sends the wrong AudioSocket UUID" \
+      --fix "Pass \${UNIQUEID} to AudioSocket()" -o audiosocket-uuid.yaml
+  agent kb export --title "Barge-in too eager" --metric "BargeInCount > 10" \
+      --fix "Raise barge_in.min_ms to 400"`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if strings.TrimSpace(kbExportTitle) == "" {
+			return contract.UsageError(fmt.Errorf("--title is required"))
+		}
+		if len(kbExportFix) == 0 {
+			return contract.UsageError(fmt.Errorf("--fix is required"))
+		}
+		callID := "last"
+		if len(args) == 1 {
+			callID = args[0]
+		}
+		runner := troubleshoot.NewRunner(callID, "", false, false, true, false, false, false, verbose)
+		runner.SetSilent(true)
+		if err := runner.Run(); err != nil && contract.CodeOf(err) >= contract.Usage {
+			return err
+		}
+		issue, err := runner.Fingerprint(troubleshoot.FingerprintOptions{
+			ID:        kbExportID,
+			Title:     kbExportTitle,
+			Cause:     kbExportCause,
+			Fix:       kbExportFix,
+			Docs:      kbExportDocs,
+			Lines:     kbExportLines,
+			Metrics:   kbExportMetrics,
+			Provider:  kbExportProvider,
+			Transport: kbExportTransport,
+		})
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		raw, err := knowledge.Encode([]knowledge.Issue{issue})
+		if err != nil {
+			return err
+		}
+		if kbExportOut == "" {
+			_, err = os.Stdout.Write(raw)
+			return err
+		}
+		if err := os.WriteFile(kbExportOut, raw, 0644); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("✓ Wrote %s to %s\n", issue.ID, kbExportOut)
+		return nil
+	},
+}
+
+var kbImportCmd = &cobra.Command{
+	Use:   "import <url|file>",
+	Short: "Add a fingerprint pack to the knowledge base",
+	Long: `Add a fingerprint pack (a knowledge file, as agent kb export writes) from a
+URL or file. The pack is validated and saved in .agent/knowledge/; agent rca
+applies it after the built-in issues and config/rca-knowledge.yaml.
+
+An issue whose ID is already known from another source is refused unless
+--replace is given; the pack's issue then takes its place.
+
+Examples:
+  agent kb import https://example.com/ava-fingerprints.yaml
+  agent kb import audiosocket-uuid.yaml --name team`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src := args[0]
+		raw, err := readPack(src)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		name := packName(kbImportName, src)
+		if name == "" {
+			return contract.UsageError(fmt.Errorf("cannot name the pack after %s; give --name", src))
+		}
+		dest := filepath.Join(knowledge.PackDir, name+".yaml")
+		pack, err := knowledge.Parse(raw, dest)
+		if err != nil {
+			return contract.UsageError(fmt.Errorf("%s: %w", src, err))
+		}
+		if len(pack.Issues) == 0 {
+			return contract.UsageError(fmt.Errorf("%s has no issues", src))
+		}
+
+		kb, _ := knowledge.Load()
+		var added, replaced []string
+		for _, is := range pack.Issues {
+			old, ok := kb.Lookup(is.ID)
+			switch {
+			case !ok:
+				added = append(added, is.ID)
+			case old.Source == dest || kbImportReplace:
+				replaced = append(replaced, is.ID)
+			default:
+				return contract.UsageError(fmt.Errorf("issue %s is already known from %s; use --replace to take the pack's", is.ID, old.Source))
+			}
+		}
+
+		if err := os.MkdirAll(knowledge.PackDir, 0755); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		if err := os.WriteFile(dest, raw, 0644); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("✓ Imported %d issue(s) into %s\n", len(pack.Issues), dest)
+		if len(added) > 0 {
+			fmt.Printf("  New: %s\n", strings.Join(added, ", "))
+		}
+		if len(replaced) > 0 {
+			fmt.Printf("  Replaced: %s\n", strings.Join(replaced, ", "))
+		}
+		return nil
+	},
+}
+
+var kbListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the known issues and where each comes from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kb, loadErr := knowledge.Load()
+		var issues []knowledge.Issue
+		for _, is := range kb.Issues {
+			if !is.Disabled {
+				issues = append(issues, is)
+			}
+		}
+		if format := structuredOutput(kbListJSON); format.Structured() {
+			type entry struct {
+				ID     string `json:"id"`
+				Title  string `json:"title"`
+				Source string `json:"source"`
+			}
+			entries := make([]entry, 0, len(issues))
+			for _, is := range issues {
+				entries = append(entries, entry{ID: is.ID, Title: is.Title, Source: is.Source})
+			}
+			payload := map[string]any{
+				"schema_version": contract.SchemaVersion,
+				"issues":         entries,
+			}
+			if loadErr != nil {
+				payload["error"] = loadErr.Error()
+			}
+			if err := output.Write(os.Stdout, format, payload); err != nil {
+				return err
+			}
+		} else {
+			if loadErr != nil {
+				fmt.Printf("⚠️  %v\n\n", loadErr)
+			}
+			for _, is := range issues {
+				fmt.Printf("%-36s %-28s %s\n", is.ID, is.Source, is.Title)
+			}
+		}
+		if loadErr != nil {
+			return contract.Exit(contract.Warn, nil)
+		}
+		return nil
+	},
+}
+
+var kbRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove an imported fingerprint pack",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := packName(args[0], "")
+		path := filepath.Join(knowledge.PackDir, name+".yaml")
+		if err := os.Remove(path); err != nil {
+			if os.IsNotExist(err) {
+				return contract.UsageError(fmt.Errorf("no imported pack %q (see agent kb list)", args[0]))
+			}
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("✓ Removed %s\n", path)
+		return nil
+	},
+}
+
+// readPack fetches a pack from an http(s) URL or reads it from a file.
+func readPack(src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.ReadFile(src)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", src, resp.Status)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxPackSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxPackSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", src, maxPackSize)
+	}
+	return raw, nil
+}
+
+// packName is the pack's file name in PackDir: name if given, else the base
+// name of src, limited to letters, digits, dashes and underscores.
+func packName(name, src string) string {
+	if name == "" {
+		base := src
+		if i := strings.LastIndexAny(base, `/\`); i >= 0 {
+			base = base[i+1:]
+		}
+		base, _, _ = strings.Cut(base, "?")
+		name = strings.TrimSuffix(strings.TrimSuffix(base, ".yaml"), ".yml")
+	}
+	name = strings.TrimSuffix(name, ".yaml")
+	return strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, name), "-")
+}
+
+func init() {
+	kbExportCmd.Flags().StringVar(&kbExportTitle, "title", "", "what the problem is (required)")
+	kbExportCmd.Flags().StringArrayVar(&kbExportFix, "fix", nil, "a step that fixed it (repeatable, required)")
+	kbExportCmd.Flags().StringVar(&kbExportCause, "cause", "", "why it happens")
+	kbExportCmd.Flags().StringVar(&kbExportID, "id", "", "issue ID (default from the title)")
+	kbExportCmd.Flags().StringVar(&kbExportDocs, "docs", "", "where the fix is documented")
+	kbExportCmd.Flags().StringArrayVar(&kbExportLines, "line", nil, "fingerprint the error or warning line containing this text (repeatable)")
+	kbExportCmd.Flags().StringArrayVar(&kbExportMetrics, "metric", nil, `metric condition the call meets, e.g. "GateClosures > 20" (repeatable)`)
+	kbExportCmd.Flags().StringVar(&kbExportProvider, "provider", "", "limit the issue to this provider (name prefix)")
+	kbExportCmd.Flags().StringVar(&kbExportTransport, "transport", "", "limit the issue to this audio transport")
+	kbExportCmd.Flags().StringVarP(&kbExportOut, "output", "o", "", "write the pack to this file instead of stdout")
+
+	kbImportCmd.Flags().StringVar(&kbImportName, "name", "", "pack name in .agent/knowledge (default from the URL or file)")
+	kbImportCmd.Flags().BoolVar(&kbImportReplace, "replace", false, "let the pack's issues replace known ones with the same ID")
+
+	kbListCmd.Flags().BoolVar(&kbListJSON, "json", false, "output as JSON")
+
+	kbCmd.AddCommand(kbExportCmd, kbImportCmd, kbListCmd, kbRemoveCmd)
+	rootCmd.AddCommand(kbCmd)
+}
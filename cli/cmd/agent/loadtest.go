@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+// loadtestIDBase keeps synthetic call IDs apart from the sequence numbers
+// Asterisk gives real channels in the same second.
+const loadtestIDBase = 900000
+
+var (
+	loadtestMaxCalls int
+	loadtestStep     int
+	loadtestHold     int
+	loadtestAudio    string
+	loadtestProvider string
+	loadtestContext  string
+	loadtestJSON     bool
+)
+
+var loadtestCmd = &cobra.Command{
+	Use:   "loadtest",
+	Short: "Ramp simultaneous synthetic calls and find where call quality degrades",
+	Long: `Measure how many concurrent calls this deployment handles before call quality
+drops, without a SIPp rig.
+
+Calls are originated through ARI as Local channels into the agent's dialplan
+context. The caller half of each call runs the [aava-loadtest-caller] context:
+it plays a recording (--audio, any Asterisk sound name or path without
+extension) and stays on the line for --hold seconds while the agent answers.
+
+Load ramps in levels of --step calls up to --max-calls. All calls of a level
+run at the same time and the next level starts when they have ended. Each
+call is then scored from its ai_engine logs as agent rca would, and each level
+reports its quality score and turn latency percentiles (p50/p95/p99).
+
+A level is degraded when a call does not reach the engine, when its average
+quality score is below 70 or 10 points below the first level's, or when its
+p95 turn latency is 1.5x the first level's. The command exits with the
+warning code when a level degraded.
+
+Add the caller context to extensions_custom.conf first; the command prints it
+when Asterisk does not have it. Synthetic calls use real provider sessions and
+are billed like any other call.
+
+To drive the load from SIPp instead, generate a scenario with
+"agent loadtest export-sipp" and score the calls with "agent loadtest score".
+
+Examples:
+  agent loadtest --max-calls 10 --step 2
+  agent loadtest --audio custom/caller-question --hold 20 --provider deepgram
+  agent loadtest --max-calls 20 --step 5 --json`,
+	Args: cobra.NoArgs,
+	RunE: runLoadtest,
+}
+
+func runLoadtest(cmd *cobra.Command, args []string) error {
+	if loadtestStep < 1 || loadtestMaxCalls < loadtestStep {
+		return contract.UsageError(errors.New("--step must be at least 1 and no more than --max-calls"))
+	}
+	if loadtestHold < 1 {
+		return contract.UsageError(errors.New("--hold must be at least 1 second"))
+	}
+	if strings.TrimSpace(loadtestAudio) == "" {
+		return contract.UsageError(errors.New("--audio is empty"))
+	}
+	troubleshoot.LoadEnvFile()
+	cfg, err := loadtestARIConfig()
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	agentContext := loadtestAgentContext()
+	if err := verifyLoadtestDialplan(agentContext, cfg.AppName); err != nil {
+		return contract.EnvironmentError(err)
+	}
+
+	format := structuredOutput(loadtestJSON)
+	progress := os.Stdout
+	if format.Structured() {
+		progress = os.Stderr
+	}
+	start := time.Now()
+	var calls []troubleshoot.LoadCall
+	seq := 0
+	for _, level := range loadtestLevels(loadtestMaxCalls, loadtestStep) {
+		fmt.Fprintf(progress, "▶ %d concurrent call(s)...\n", level)
+		placed := make([]troubleshoot.LoadCall, level)
+		var active []string
+		for i := range placed {
+			seq++
+			id := fmt.Sprintf("%d.%d", start.Unix(), loadtestIDBase+seq)
+			placed[i] = troubleshoot.LoadCall{CallID: id, Concurrency: level}
+			if err := originateLoadtestCall(cfg, agentContext, id, seq, loadtestAudio, loadtestHold); err != nil {
+				placed[i].Error = err.Error()
+				fmt.Fprintf(progress, "  ❌ %s: %v\n", id, err)
+				continue
+			}
+			active = append(active, id+"-caller")
+		}
+		waitLoadtestCalls(cfg, active, time.Duration(loadtestHold)*time.Second+2*time.Minute)
+		calls = append(calls, placed...)
+	}
+
+	fmt.Fprintln(progress, "Scoring calls from ai_engine logs...")
+	lines, err := loadtestLogLines(time.Since(start) + time.Minute)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	for i, c := range calls {
+		if c.Error != "" {
+			continue
+		}
+		scored := troubleshoot.AnalyzeLoadCall(c.CallID, troubleshoot.FilterCallLines(lines, c.CallID))
+		scored.Concurrency = c.Concurrency
+		calls[i] = scored
+	}
+	return writeLoadReport(format, calls, map[string]any{
+		"context":      agentContext,
+		"audio":        loadtestAudio,
+		"hold_seconds": loadtestHold,
+	})
+}
+
+// loadtestARIConfig reads the ARI connection settings from the environment
+// and .env.
+func loadtestARIConfig() (asterisk.ARIConfig, error) {
+	cfg := asterisk.ARIConfigFromEnv(func(key string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		v, _ := dotenvValue(".env", key)
+		return v
+	})
+	if cfg.Username == "" {
+		return cfg, errors.New("ASTERISK_ARI_USERNAME is not set")
+	}
+	return cfg, nil
+}
+
+// originateLoadtestCall places one synthetic call into agentContext. The
+// agent half gets channel id; the caller half, id-caller, plays audio and
+// stays on the line for hold seconds.
+func originateLoadtestCall(cfg asterisk.ARIConfig, agentContext, id string, seq int, audio string, hold int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := asterisk.Originate(ctx, cfg, asterisk.OriginateRequest{
+		Endpoint:       fmt.Sprintf("Local/s@%s/n", agentContext),
+		Context:        dialplan.LoadtestContext,
+		Extension:      "s",
+		Priority:       1,
+		CallerID:       fmt.Sprintf("\"AAVA loadtest\" <%d>", seq),
+		Timeout:        30,
+		ChannelID:      id + "-caller",
+		OtherChannelID: id,
+		Variables: map[string]string{
+			"AAVA_LOADTEST_AUDIO": audio,
+			"AAVA_LOADTEST_HOLD":  strconv.Itoa(hold),
+		},
+	})
+	return err
+}
+
+// writeLoadReport summarizes scored calls by concurrency level; it exits with
+// the warning code when a level degraded.
+func writeLoadReport(format output.Format, calls []troubleshoot.LoadCall, fields map[string]any) error {
+	report := troubleshoot.SummarizeLoad(calls)
+	if format.Structured() {
+		payload := map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"steps":          report.Steps,
+			"max_healthy":    report.MaxHealthy,
+			"degraded_at":    report.DegradedAt,
+			"calls":          calls,
+		}
+		for k, v := range fields {
+			payload[k] = v
+		}
+		if err := output.Write(os.Stdout, format, payload); err != nil {
+			return err
+		}
+	} else {
+		printLoadReport(report)
+	}
+	if report.DegradedAt > 0 {
+		return contract.Exit(contract.Warn, nil)
+	}
+	return nil
+}
+
+// loadtestAgentContext is the context --context names, or the one agent
+// dialplan generates for --provider.
+func loadtestAgentContext() string {
+	if loadtestContext != "" {
+		return loadtestContext
+	}
+	return dialplan.ContextName(loadtestProvider)
+}
+
+// loadtestLevels is the ramp: step, 2*step, ... ending at --max-calls.
+func loadtestLevels(top, step int) []int {
+	var levels []int
+	for n := step; n < top; n += step {
+		levels = append(levels, n)
+	}
+	return append(levels, top)
+}
+
+// verifyLoadtestDialplan checks that Asterisk has the agent context and the
+// load test caller context, printing the caller snippet when it is missing.
+func verifyLoadtestDialplan(agentContext, app string) error {
+	if err := verifyAgentContext(agentContext, app); err != nil {
+		return err
+	}
+	out, source, err := asterisk.CLI("dialplan show " + dialplan.LoadtestContext)
+	if err != nil {
+		return err
+	}
+	if err := dialplan.VerifyLoadtestContext(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Add this to extensions_custom.conf and run: asterisk -rx \"dialplan reload\"\n\n%s\n", dialplan.LoadtestSnippet())
+		return fmt.Errorf("%v (%s)", err, source)
+	}
+	return nil
+}
+
+// verifyAgentContext checks that Asterisk has the context that enters the
+// agent's Stasis app.
+func verifyAgentContext(agentContext, app string) error {
+	out, source, err := asterisk.CLI("dialplan show " + agentContext)
+	if err != nil {
+		return err
+	}
+	if err := dialplan.VerifyContext(out, agentContext, app); err != nil {
+		return fmt.Errorf("%v (%s); pick the agent's context with --context or --provider", err, source)
+	}
+	return nil
+}
+
+// waitLoadtestCalls polls ARI until the caller channels are gone, hanging up
+// any still up after limit.
+func waitLoadtestCalls(cfg asterisk.ARIConfig, ids []string, limit time.Duration) {
+	deadline := time.Now().Add(limit)
+	for len(ids) > 0 && time.Now().Before(deadline) {
+		time.Sleep(2 * time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+		channels, err := asterisk.ListChannels(ctx, cfg)
+		cancel()
+		if err != nil {
+			continue
+		}
+		up := map[string]bool{}
+		for _, ch := range channels {
+			up[ch.ID] = true
+		}
+		remaining := ids[:0]
+		for _, id := range ids {
+			if up[id] {
+				remaining = append(remaining, id)
+			}
+		}
+		ids = remaining
+	}
+	for _, id := range ids {
+		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+		_ = asterisk.Hangup(ctx, cfg, id)
+		cancel()
+	}
+}
+
+// loadtestLogLines reads the engine log lines written in the last window.
+func loadtestLogLines(window time.Duration) ([]string, error) {
+	src, err := deployment.OpenEngineLogs(fmt.Sprintf("%ds", int(window.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	var lines []string
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, troubleshoot.StripANSI(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
+	return lines, nil
+}
+
+func printLoadReport(r troubleshoot.LoadReport) {
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "CALLS\tFAILED\tQUALITY AVG\tQUALITY MIN\tTURNS\tP50\tP95\tP99\tSTATUS")
+	for _, s := range r.Steps {
+		avg, lowest := "-", "-"
+		if s.Scored > 0 {
+			avg, lowest = fmt.Sprintf("%.0f", s.ScoreAvg), fmt.Sprintf("%.0f", s.ScoreMin)
+		}
+		status := "ok"
+		if s.Degraded {
+			status = "degraded"
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", s.Concurrency, s.Failed, avg, lowest, s.Turns,
+			loadtestMS(s.LatencyP50MS), loadtestMS(s.LatencyP95MS), loadtestMS(s.LatencyP99MS), status)
+	}
+	tw.Flush()
+	fmt.Println()
+	for _, s := range r.Steps {
+		for _, reason := range s.Reasons {
+			fmt.Printf("  ⚠️  %d call(s): %s\n", s.Concurrency, reason)
+		}
+	}
+	switch {
+	case r.DegradedAt == 0:
+		fmt.Printf("✅ Quality held at every level up to %d concurrent call(s)\n", r.MaxHealthy)
+	case r.MaxHealthy == 0:
+		fmt.Printf("❌ Quality degraded from the first level (%d call(s))\n", r.DegradedAt)
+	default:
+		fmt.Printf("⚠️  Quality holds up to %d concurrent call(s) and degrades at %d\n", r.MaxHealthy, r.DegradedAt)
+	}
+}
+
+func loadtestMS(ms float64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0fms", ms)
+}
+
+func init() {
+	loadtestCmd.PersistentFlags().IntVar(&loadtestMaxCalls, "max-calls", 10, "highest number of simultaneous calls")
+	loadtestCmd.PersistentFlags().IntVar(&loadtestStep, "step", 2, "calls added at each level of the ramp")
+	loadtestCmd.PersistentFlags().IntVar(&loadtestHold, "hold", 30, "seconds each caller stays on the line after the recording")
+	loadtestCmd.Flags().StringVar(&loadtestAudio, "audio", "hello-world", "recording the caller plays (Asterisk sound name or path without extension)")
+	loadtestCmd.PersistentFlags().StringVar(&loadtestProvider, "provider", "", "provider whose generated dialplan context receives the calls")
+	loadtestCmd.PersistentFlags().StringVar(&loadtestContext, "context", "", "dialplan context that enters the agent's Stasis app (default: from --provider)")
+	loadtestCmd.PersistentFlags().BoolVar(&loadtestJSON, "json", false, "output as JSON")
+	rootCmd.AddCommand(loadtestCmd)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/pjsip"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/sipp"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+// sippSyntheticAudio is the length of the generated caller media when no WAV
+// is given.
+const sippSyntheticAudio = 3 * time.Second
+
+var (
+	sippOut      string
+	sippIP       string
+	sippTrunk    string
+	sippCodec    string
+	sippWAV      string
+	sippSIPPort  int
+	sippCaller   string
+	sippScoreFor time.Duration
+)
+
+var loadtestExportSIPpCmd = &cobra.Command{
+	Use:   "export-sipp",
+	Short: "Write a SIPp scenario, caller media and pjsip endpoint for external load tests",
+	Long: `Generate what an external SIPp rig needs to load the agent:
+
+  uac.xml                SIPp scenario: call, play the caller media, hold, hang up
+  caller.pcap            the caller media as RTP, replayed with play_pcap_audio
+  pjsip_aava_sipp.conf   endpoint aava-sipp, matching the SIPp host's address,
+                         that sends its calls into the agent's dialplan context
+
+The media codec and dtmf_mode are copied from the trunk named by --trunk (read
+from the pjsip config), so the agent sees the same audio format as on real
+calls; --codec overrides the codec. Without either, ulaw is used. --wav takes
+an 8 kHz mono 16-bit recording of a caller; without it a few seconds of
+synthetic voiced bursts are generated, which exercise the media path and VAD
+but do not ask the agent anything.
+
+SIPp calls present caller ID 5550100. After the run, "agent loadtest score"
+finds them in the ai_engine logs and reports quality by concurrency level.
+SIPp must be built with pcap support (sipp -v lists PCAP).
+
+Examples:
+  agent loadtest export-sipp --sipp-ip 10.0.0.50 --trunk my-trunk
+  agent loadtest export-sipp --sipp-ip 10.0.0.50 --wav caller-question.wav --hold 20 --out /tmp/sipp`,
+	Args: cobra.NoArgs,
+	RunE: runExportSIPp,
+}
+
+var loadtestScoreCmd = &cobra.Command{
+	Use:   "score",
+	Short: "Score the calls an external load test placed",
+	Long: `Find the calls from the load test caller ID (5550100 unless --caller is
+given) in the ai_engine logs of the last --since, score each one as agent rca
+does, and report quality and turn latency by concurrency level. A call's level
+is the most load test calls that were up at once while it lasted.
+
+Degradation is judged as in agent loadtest, and the command exits with the
+warning code when a level degraded.
+
+Examples:
+  agent loadtest score
+  agent loadtest score --since 30m --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sippScoreFor <= 0 {
+			return contract.UsageError(errors.New("--since must be positive"))
+		}
+		lines, err := loadtestLogLines(sippScoreFor)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		calls := troubleshoot.ScoreCallsFrom(lines, sippCaller, time.Now().Add(-sippScoreFor))
+		if len(calls) == 0 {
+			return contract.EnvironmentError(fmt.Errorf("no calls from %s in the last %s of ai_engine logs", sippCaller, sippScoreFor))
+		}
+		return writeLoadReport(structuredOutput(loadtestJSON), calls, map[string]any{
+			"caller": sippCaller,
+			"since":  sippScoreFor.String(),
+		})
+	},
+}
+
+func runExportSIPp(cmd *cobra.Command, args []string) error {
+	if strings.TrimSpace(sippIP) == "" {
+		return contract.UsageError(errors.New("--sipp-ip is required: the address Asterisk sees SIPp calls from"))
+	}
+	if loadtestHold < 1 {
+		return contract.UsageError(errors.New("--hold must be at least 1 second"))
+	}
+	troubleshoot.LoadEnvFile()
+
+	codec, _ := sipp.CodecFor("ulaw")
+	dtmf := ""
+	matched := ""
+	if sippTrunk != "" {
+		text, _, err := pjsip.ReadConfig()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		ep, ok := findEndpoint(pjsip.ParseEndpoints(text), sippTrunk)
+		if !ok {
+			return contract.UsageError(fmt.Errorf("no pjsip endpoint named %q", sippTrunk))
+		}
+		found := false
+		for _, name := range ep.Allow {
+			if c, ok := sipp.CodecFor(name); ok {
+				codec, found = c, true
+				break
+			}
+		}
+		if !found && sippCodec == "" {
+			return contract.EnvironmentError(fmt.Errorf("trunk %s allows %s but no ulaw or alaw; pass --codec to pick the G.711 codec SIPp sends", sippTrunk, strings.Join(ep.Allow, ",")))
+		}
+		dtmf = pjsip.EffectiveDTMFMode(ep)
+		matched = fmt.Sprintf(" (matched to trunk %s)", sippTrunk)
+	}
+	if sippCodec != "" {
+		c, ok := sipp.CodecFor(sippCodec)
+		if !ok {
+			return contract.UsageError(fmt.Errorf("--codec must be ulaw or alaw; SIPp replays G.711 media"))
+		}
+		codec = c
+	}
+
+	samples := sipp.SyntheticSpeech(sippSyntheticAudio)
+	if sippWAV != "" {
+		f, err := os.Open(sippWAV)
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		samples, err = sipp.ReadWAV(f)
+		f.Close()
+		if err != nil {
+			return contract.UsageError(fmt.Errorf("%s: %w", sippWAV, err))
+		}
+	}
+	audioMS := sipp.DurationMS(samples)
+	agentContext := loadtestAgentContext()
+
+	var media bytes.Buffer
+	if err := sipp.WritePCAP(&media, samples, codec); err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		"caller.pcap": media.Bytes(),
+		"uac.xml": []byte(sipp.Scenario(sipp.ScenarioOptions{
+			CallerNumber: sippCaller,
+			Codec:        codec,
+			PCAP:         "caller.pcap",
+			AudioMS:      audioMS,
+			HoldMS:       loadtestHold * 1000,
+		})),
+		"pjsip_aava_sipp.conf": []byte(sipp.Endpoint(sipp.EndpointOptions{Context: agentContext, Codec: codec, DTMFMode: dtmf, Match: sippIP})),
+	}
+	if err := os.MkdirAll(sippOut, 0o755); err != nil {
+		return contract.EnvironmentError(err)
+	}
+	var written []string
+	for _, name := range []string{"uac.xml", "caller.pcap", "pjsip_aava_sipp.conf"} {
+		path := filepath.Join(sippOut, name)
+		if err := os.WriteFile(path, files[name], 0o644); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		written = append(written, path)
+	}
+
+	// Start --step calls per call length, so concurrency ramps by --step until
+	// SIPp's limit of --max-calls.
+	period := 1000 + audioMS + loadtestHold*1000
+	command := fmt.Sprintf("sipp %s:%d -sf uac.xml -s s -i %s -l %d -r %d -rp %d -m %d -trace_stat",
+		sippAsteriskHost(), sippSIPPort, sippIP, loadtestMaxCalls, loadtestStep, period, 2*loadtestMaxCalls)
+
+	if format := structuredOutput(loadtestJSON); format.Structured() {
+		return output.Write(os.Stdout, format, map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"files":          written,
+			"context":        agentContext,
+			"endpoint":       sipp.EndpointName,
+			"codec":          codec.Name,
+			"audio_ms":       audioMS,
+			"caller":         sippCaller,
+			"command":        command,
+		})
+	}
+	fmt.Printf("Wrote %s\n", strings.Join(written, ", "))
+	fmt.Printf("Calls arrive on pjsip endpoint %s from %s into [%s] with %s%s.\n\n", sipp.EndpointName, sippIP, agentContext, codec.Name, matched)
+	fmt.Printf("1. Add %s to pjsip_custom.conf and run: asterisk -rx \"pjsip reload\"\n", filepath.Join(sippOut, "pjsip_aava_sipp.conf"))
+	fmt.Printf("2. Copy uac.xml and caller.pcap to the SIPp host and, in that directory, run:\n   %s\n", command)
+	fmt.Println("3. When SIPp finishes: agent loadtest score --since 1h")
+	return nil
+}
+
+func findEndpoint(endpoints []pjsip.Endpoint, name string) (pjsip.Endpoint, bool) {
+	for _, ep := range endpoints {
+		if ep.Name == name {
+			return ep, true
+		}
+	}
+	return pjsip.Endpoint{}, false
+}
+
+// sippAsteriskHost is the address SIPp should call; a loopback ASTERISK_HOST
+// is not reachable from the SIPp host.
+func sippAsteriskHost() string {
+	host := os.Getenv("ASTERISK_HOST")
+	if host == "" {
+		host, _ = dotenvValue(".env", "ASTERISK_HOST")
+	}
+	switch strings.TrimSpace(host) {
+	case "", "127.0.0.1", "localhost", "::1":
+		return "<asterisk-ip>"
+	}
+	return host
+}
+
+func init() {
+	loadtestExportSIPpCmd.Flags().StringVar(&sippOut, "out", "sipp", "directory to write the scenario, media and pjsip snippet to")
+	loadtestExportSIPpCmd.Flags().StringVar(&sippIP, "sipp-ip", "", "address Asterisk sees SIPp calls from (required)")
+	loadtestExportSIPpCmd.Flags().StringVar(&sippTrunk, "trunk", "", "pjsip endpoint whose codec and dtmf_mode the test calls copy")
+	loadtestExportSIPpCmd.Flags().StringVar(&sippCodec, "codec", "", "ulaw or alaw (default: the trunk's, else ulaw)")
+	loadtestExportSIPpCmd.Flags().StringVar(&sippWAV, "wav", "", "8 kHz mono 16-bit WAV the caller plays (default: synthetic voiced bursts)")
+	loadtestExportSIPpCmd.Flags().IntVar(&sippSIPPort, "sip-port", 5060, "Asterisk SIP port")
+	for _, c := range []*cobra.Command{loadtestExportSIPpCmd, loadtestScoreCmd} {
+		c.Flags().StringVar(&sippCaller, "caller", sipp.DefaultCallerNumber, "caller ID the load test calls present")
+	}
+	loadtestScoreCmd.Flags().DurationVar(&sippScoreFor, "since", time.Hour, "how far back in the ai_engine logs to look")
+	loadtestCmd.AddCommand(loadtestExportSIPpCmd, loadtestScoreCmd)
+}
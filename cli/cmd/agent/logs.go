@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsCallID    string
+	logsLevel     string
+	logsSince     string
+	logsTail      int
+	logsFollow    bool
+	logsContainer string
+	logsRedact    string
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "View ai_engine logs with call-aware filtering",
+	Long: `View container logs with ANSI colors stripped and optional filtering.
+
+With --call, lines are correlated the same way agent rca does: the caller
+channel id plus any AudioSocket/ExternalMedia helper channels and bridges
+referenced on its lines.
+
+Examples:
+  agent logs --since 1h --level error
+  agent logs --call 1761518880.2191
+  agent logs --call 1761518880.2191 --follow
+  agent logs --call 1761518880.2191 --redact   # mask phone numbers, emails, cards, names`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := troubleshoot.ParseLevel(logsLevel); err != nil {
+			return err
+		}
+		var mask func(string) string
+		if cmd.Flags().Changed("redact") {
+			red, err := redact.Parse(logsRedact)
+			if err != nil {
+				return contract.UsageError(err)
+			}
+			mask = red.String
+		}
+		since := strings.TrimSpace(logsSince)
+		if since == "" && !cmd.Flags().Changed("since") {
+			// Calls are often investigated well after they happen; match agent rca's window.
+			since = "1h"
+			if logsCallID != "" {
+				since = os.Getenv("RCA_LOG_SINCE")
+				if since == "" {
+					since = "72h"
+				}
+			}
+		}
+		return troubleshoot.StreamLogs(troubleshoot.LogOptions{
+			Container: logsContainer,
+			CallID:    strings.TrimSpace(logsCallID),
+			Level:     logsLevel,
+			Since:     since,
+			Tail:      logsTail,
+			Follow:    logsFollow,
+			Redact:    mask,
+		}, os.Stdout)
+	},
+}
+
+func init() {
+	logsCmd.Flags().StringVar(&logsCallID, "call", "", "only show lines for this call (and its helper channels)")
+	logsCmd.Flags().StringVar(&logsLevel, "level", "", "minimum level: debug, info, warning, error, critical")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "show logs since duration or timestamp (default 1h, or 72h with --call)")
+	logsCmd.Flags().IntVar(&logsTail, "tail", 0, "number of lines from the end of the logs (0 = all in window)")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "stream new log lines")
+	logsCmd.Flags().StringVar(&logsRedact, "redact", "", "mask personal data in the lines: all, or a list of phone, email, card, name")
+	logsCmd.Flags().Lookup("redact").NoOptDefVal = "all"
+	logsCmd.Flags().StringVar(&logsContainer, "container", "", "container to read logs from (default: ai_engine from .agent/deployment.yaml)")
+	rootCmd.AddCommand(logsCmd)
+}
@@ -1,26 +1,109 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/telemetry"
 	"github.com/spf13/cobra"
 )
 
 var (
-	version   = "7.1.1-dev" // Overridden at build time via -ldflags
-	buildTime = "unknown"   // Overridden at build time via -ldflags
-	verbose   bool
-	noColor   bool
+	version     = "7.1.1-dev" // Overridden at build time via -ldflags
+	buildTime   = "unknown"   // Overridden at build time via -ldflags
+	verbose     bool
+	quiet       bool
+	noColor     bool
+	dockerHost  string
+	outputFlag  string
+	profileFlag string
+
+	// outputFormat is the parsed --output value.
+	outputFormat = output.Text
+	// outputProfile is the parsed --output-profile value, or the deployment's
+	// output_profile.
+	outputProfile = output.Support
+	// flushPlain drains stdout/stderr rewritten to ASCII; see redirectPlain.
+	flushPlain = func() {}
 )
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return contract.UsageError(err)
+	})
+	err := rootCmd.Execute()
+	if err != nil {
+		if msg := err.Error(); msg != "" {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+	}
+	telemetry.SendDue(version)
+	flushPlain()
+	if err != nil {
+		os.Exit(exitCode(err))
+	}
+}
+
+// redirectPlain routes stdout and stderr, including child processes and
+// fatih/color output, through output.PlainWriter so emoji and box drawing become
+// ASCII.
+func redirectPlain() {
+	stdout, stderr := os.Stdout, os.Stderr
+	var wg sync.WaitGroup
+	pipe := func(dst *os.File) *os.File {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return dst
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = io.Copy(output.PlainWriter(dst), r)
+		}()
+		return w
+	}
+	os.Stdout, os.Stderr = pipe(stdout), pipe(stderr)
+	color.Output, color.Error = os.Stdout, os.Stderr
+	flushPlain = func() {
+		os.Stdout.Close()
+		os.Stderr.Close()
+		wg.Wait()
+		os.Stdout, os.Stderr = stdout, stderr
+		flushPlain = func() {}
+	}
+}
+
+// structuredOutput is the format for a command that also has its own --json flag.
+func structuredOutput(jsonFlag bool) output.Format {
+	if jsonFlag {
+		return output.JSON
 	}
+	return outputFormat
+}
+
+// cobraUsagePrefixes start the argument and command errors cobra returns itself.
+var cobraUsagePrefixes = []string{"unknown command", "accepts ", "requires at least", "requires at most", "received ", "invalid argument", "required flag", "if any flags in the group"}
+
+// exitCode applies the contract exit codes, treating cobra's own argument errors
+// as usage errors.
+func exitCode(err error) int {
+	var ce *contract.Error
+	if !errors.As(err, &ce) {
+		for _, p := range cobraUsagePrefixes {
+			if strings.HasPrefix(err.Error(), p) {
+				return contract.Usage
+			}
+		}
+	}
+	return contract.CodeOf(err)
 }
 
 var rootCmd = &cobra.Command{
@@ -37,15 +120,43 @@ Available commands:
 		version),
 	SilenceUsage:  true,
 	SilenceErrors: true,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Auto-disable color when stdout isn't a TTY; allow explicit opt-out as well.
 		isTTY := false
 		if fi, err := os.Stdout.Stat(); err == nil {
 			isTTY = (fi.Mode() & os.ModeCharDevice) != 0
 		}
-		if noColor || !isTTY {
+		if noColor || quiet || !isTTY || output.Plain() {
 			color.NoColor = true
 		}
+		f, err := output.Parse(outputFlag)
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		outputFormat = f
+		profile := deployment.Current().OutputProfile
+		if cmd.Flags().Changed("output-profile") {
+			profile = profileFlag
+		}
+		if outputProfile, err = output.ParseProfile(profile); err != nil {
+			return contract.UsageError(err)
+		}
+		// NO_COLOR and TERM=dumb also mean no emoji; CI logs and dumb terminals show them as garbage.
+		if noColor || output.Plain() {
+			redirectPlain()
+		}
+		// Target the configured docker daemon/compose project for every child docker call.
+		if err := deployment.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring %v\n", err)
+		}
+		if dockerHost != "" {
+			if err := deployment.ValidateHost(dockerHost); err != nil {
+				return err
+			}
+			deployment.Current().SetHost(dockerHost)
+		}
+		deployment.Current().Apply()
+		return nil
 	},
 }
 
@@ -57,13 +168,25 @@ func init() {
 	rootCmd.Long = fmt.Sprintf(`Asterisk AI Voice Agent CLI (%s) - Setup, diagnostics, and RCA
 
 Primary commands:
+  init        First-run setup of a new installation
   setup       Configure or reconfigure this installation
   check       Standard system diagnostics (JSON available)
   rca         Evidence-based post-call analysis
+  logs        View logs with call-aware filtering
+  watch       Follow live calls over AMI
+  tui         Live dashboard of containers, calls and logs
+  digest      Daily summary of calls, checks and incidents
   config      Validate configuration files
   dialplan    Generate an AI_AGENT dialplan snippet
   update      Plan or apply safe updates
+  fleet       Check or update several deployments
+  archive     Copy reports and recordings to object storage
+  secrets     Keep provider keys in the OS keyring, an encrypted file or a secrets manager
   version     Show CLI build information`, version)
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
-	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable color output")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "plain output without banners, colors or emojis (check, doctor, rca, update)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable color and emoji output (also NO_COLOR or TERM=dumb)")
+	rootCmd.PersistentFlags().StringVar(&outputFlag, "output", "text", "output format: text, json or yaml (for commands with structured output)")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "output-profile", "", "text detail for the reader: operator, support or developer (default $AAVA_OUTPUT_PROFILE, output_profile in .agent/deployment.yaml, or support)")
+	rootCmd.PersistentFlags().StringVar(&dockerHost, "host", "", "docker endpoint of a remote deployment, e.g. ssh://user@server (default: DOCKER_HOST)")
 }
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/grafana"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// grafanaDatasourceName is the datasource --prometheus-url creates or updates.
+const grafanaDatasourceName = "AAVA Prometheus"
+
+var (
+	grafanaURL        string
+	grafanaTokenFile  string
+	grafanaPromURL    string
+	grafanaDatasource string
+	grafanaFolder     string
+	grafanaPrint      bool
+	grafanaJSON       bool
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Observability for the ai_engine Prometheus metrics",
+	Long: `Tools for the Prometheus metrics ai_engine exports on its health port
+(http://127.0.0.1:15000/metrics by default).`,
+}
+
+var grafanaBootstrapCmd = &cobra.Command{
+	Use:   "grafana-bootstrap",
+	Short: "Create the Grafana datasource and dashboard for ai_engine metrics",
+	Long: `Provision a Grafana dashboard for the ai_engine Prometheus metrics through the
+Grafana HTTP API: call volume, playback quality (frames sent without underflow
+filler), turn-response and first-audio latency percentiles, underflows, call
+duration and stream end reasons.
+
+With --prometheus-url the Prometheus datasource "AAVA Prometheus" is created
+or updated. Otherwise an existing Prometheus datasource is used: the one named
+by --datasource, the default one, or the only one. Running it again updates
+the dashboard in place.
+
+Authentication uses a service account token from GRAFANA_TOKEN or
+--token-file, or basic auth from GRAFANA_USER and GRAFANA_PASSWORD.
+
+Prometheus must already scrape ai_engine. Its metrics endpoint binds to
+127.0.0.1:15000; scrape it from the same host or expose it as described in
+the README.
+
+Examples:
+  GRAFANA_TOKEN=glsa_... agent metrics grafana-bootstrap --url http://grafana:3000
+  agent metrics grafana-bootstrap --prometheus-url http://prometheus:9090
+  agent metrics grafana-bootstrap --print > aava-dashboard.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if grafanaPrint {
+			dashboard := grafana.Dashboard(grafanaDatasource)
+			if grafanaDatasource == "" {
+				// Grafana's dashboard import asks for the datasource to bind.
+				dashboard = grafana.Dashboard("${DS_PROMETHEUS}")
+				dashboard["__inputs"] = []map[string]string{{"name": "DS_PROMETHEUS", "label": "Prometheus", "type": "datasource", "pluginId": "prometheus", "pluginName": "Prometheus"}}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(dashboard)
+		}
+
+		client, err := grafanaClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		version, err := client.Health(ctx)
+		if err != nil {
+			return contract.EnvironmentError(fmt.Errorf("grafana at %s is not reachable: %w", client.BaseURL, err))
+		}
+		ds, err := grafanaPrometheusDatasource(ctx, client)
+		if err != nil {
+			return err
+		}
+		if err := client.EnsureFolder(ctx, "aava", grafanaFolder); err != nil {
+			return contract.EnvironmentError(fmt.Errorf("create folder %q: %w", grafanaFolder, err))
+		}
+		path, err := client.SaveDashboard(ctx, grafana.Dashboard(ds.UID), "aava", "agent metrics grafana-bootstrap "+version)
+		if err != nil {
+			return contract.EnvironmentError(fmt.Errorf("save dashboard: %w", err))
+		}
+		dashboardURL := strings.TrimRight(client.BaseURL, "/") + path
+
+		if format := structuredOutput(grafanaJSON); format.Structured() {
+			return output.Write(os.Stdout, format, map[string]any{
+				"schema_version":  contract.SchemaVersion,
+				"grafana_version": version,
+				"datasource":      ds,
+				"dashboard_uid":   grafana.DashboardUID,
+				"dashboard_url":   dashboardURL,
+			})
+		}
+		fmt.Printf("Grafana %s: datasource %q (uid %s)\n", version, ds.Name, ds.UID)
+		fmt.Printf("Dashboard: %s\n", dashboardURL)
+		fmt.Println("Panels stay empty until Prometheus scrapes ai_engine's /metrics (port 15000).")
+		return nil
+	},
+}
+
+func grafanaClient() (*grafana.Client, error) {
+	base := strings.TrimSpace(grafanaURL)
+	if base == "" {
+		base = strings.TrimSpace(os.Getenv("GRAFANA_URL"))
+	}
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	c := &grafana.Client{
+		HTTP:     &http.Client{Timeout: 15 * time.Second},
+		BaseURL:  strings.TrimRight(base, "/"),
+		Token:    strings.TrimSpace(os.Getenv("GRAFANA_TOKEN")),
+		User:     strings.TrimSpace(os.Getenv("GRAFANA_USER")),
+		Password: os.Getenv("GRAFANA_PASSWORD"),
+	}
+	if grafanaTokenFile != "" {
+		raw, err := os.ReadFile(grafanaTokenFile)
+		if err != nil {
+			return nil, contract.UsageError(err)
+		}
+		c.Token = strings.TrimSpace(string(raw))
+	}
+	if c.Token == "" && c.User == "" {
+		return nil, contract.UsageError(errors.New("no Grafana credentials: set GRAFANA_TOKEN (service account token), use --token-file, or set GRAFANA_USER and GRAFANA_PASSWORD"))
+	}
+	return c, nil
+}
+
+// grafanaPrometheusDatasource creates the datasource for --prometheus-url, or picks
+// an existing Prometheus datasource.
+func grafanaPrometheusDatasource(ctx context.Context, c *grafana.Client) (grafana.Datasource, error) {
+	if grafanaPromURL != "" {
+		ds, err := c.UpsertDatasource(ctx, grafana.Datasource{Name: grafanaDatasourceName, Type: "prometheus", URL: grafanaPromURL, Access: "proxy"})
+		if err != nil {
+			return ds, contract.EnvironmentError(fmt.Errorf("create datasource: %w", err))
+		}
+		return ds, nil
+	}
+	all, err := c.Datasources(ctx)
+	if err != nil {
+		return grafana.Datasource{}, contract.EnvironmentError(fmt.Errorf("list datasources: %w", err))
+	}
+	var proms []grafana.Datasource
+	for _, ds := range all {
+		if ds.Type != "prometheus" {
+			continue
+		}
+		if grafanaDatasource != "" && (ds.Name == grafanaDatasource || ds.UID == grafanaDatasource) {
+			return ds, nil
+		}
+		proms = append(proms, ds)
+	}
+	if grafanaDatasource != "" {
+		return grafana.Datasource{}, contract.UsageError(fmt.Errorf("no Prometheus datasource named %q", grafanaDatasource))
+	}
+	switch len(proms) {
+	case 0:
+		return grafana.Datasource{}, contract.UsageError(errors.New("grafana has no Prometheus datasource; pass --prometheus-url to create one"))
+	case 1:
+		return proms[0], nil
+	}
+	var names []string
+	for _, ds := range proms {
+		if ds.IsDefault {
+			return ds, nil
+		}
+		names = append(names, ds.Name)
+	}
+	return grafana.Datasource{}, contract.UsageError(fmt.Errorf("several Prometheus datasources (%s); choose one with --datasource", strings.Join(names, ", ")))
+}
+
+func init() {
+	f := grafanaBootstrapCmd.Flags()
+	f.StringVar(&grafanaURL, "url", "", "Grafana base URL (default $GRAFANA_URL or http://localhost:3000)")
+	f.StringVar(&grafanaTokenFile, "token-file", "", "read the Grafana service account token from this file")
+	f.StringVar(&grafanaPromURL, "prometheus-url", "", "create or update the \""+grafanaDatasourceName+"\" datasource pointing at this Prometheus")
+	f.StringVar(&grafanaDatasource, "datasource", "", "name or UID of an existing Prometheus datasource to use")
+	f.StringVar(&grafanaFolder, "folder", "Asterisk AI Voice Agent", "Grafana folder for the dashboard")
+	f.BoolVar(&grafanaPrint, "print", false, "print the dashboard JSON instead of calling Grafana")
+	f.BoolVar(&grafanaJSON, "json", false, "output as JSON")
+
+	metricsCmd.AddCommand(grafanaBootstrapCmd)
+	rootCmd.AddCommand(metricsCmd)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+var pluginsListJSON bool
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "Site plugins that extend agent check and agent rca",
+	Long: `Site plugins add checks, call analysis and report destinations without
+forking the CLI. A plugin is any executable in ~/.agent/plugins (or
+AAVA_PLUGIN_DIR) that speaks JSON over stdin and stdout:
+
+  check    adds items to agent check (e.g. a site's SBC health)
+  analyze  adds errors, warnings and likely causes to agent rca
+  sink     receives the agent check and agent rca reports and agent digest
+           (e.g. a ticketing or monitoring system)
+
+The CLI runs "<plugin> describe" to learn which hooks a plugin implements.
+Plugins that others can write to are not run. AAVA_PLUGINS=off turns plugins
+off for a run. See the CLI guide for the protocol.`,
+}
+
+var pluginsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the installed plugins and their hooks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := plugin.Dir()
+		plugins := plugin.Discover(dir)
+		broken := 0
+		for _, p := range plugins {
+			if p.Err != nil {
+				broken++
+			}
+		}
+		if format := structuredOutput(pluginsListJSON); format.Structured() {
+			type entry struct {
+				Name        string   `json:"name"`
+				Path        string   `json:"path"`
+				Description string   `json:"description,omitempty"`
+				Hooks       []string `json:"hooks"`
+				Error       string   `json:"error,omitempty"`
+			}
+			entries := make([]entry, 0, len(plugins))
+			for _, p := range plugins {
+				e := entry{Name: p.Name, Path: p.Path, Description: p.Description, Hooks: p.Hooks}
+				if e.Hooks == nil {
+					e.Hooks = []string{}
+				}
+				if p.Err != nil {
+					e.Error = p.Err.Error()
+				}
+				entries = append(entries, e)
+			}
+			payload := map[string]any{
+				"schema_version": contract.SchemaVersion,
+				"dir":            dir,
+				"disabled":       plugin.Disabled(),
+				"plugins":        entries,
+			}
+			if err := output.Write(os.Stdout, format, payload); err != nil {
+				return err
+			}
+		} else {
+			if plugin.Disabled() {
+				fmt.Println("⚠️  AAVA_PLUGINS=off: plugins are not run")
+				fmt.Println()
+			}
+			if len(plugins) == 0 {
+				fmt.Printf("No plugins in %s\n", dir)
+				return nil
+			}
+			for _, p := range plugins {
+				if p.Err != nil {
+					fmt.Printf("❌ %-24s %s\n", p.Name, p.Err)
+					continue
+				}
+				fmt.Printf("✓ %-24s %-20s %s\n", p.Name, strings.Join(p.Hooks, ","), p.Description)
+			}
+		}
+		if broken > 0 {
+			return contract.Exit(contract.Warn, nil)
+		}
+		return nil
+	},
+}
+
+// runSinks hands a finished report to the sink plugins and the object storage
+// archive. The report is already printed, so failures go to stderr and do not
+// change the exit code.
+func runSinks(plugins []plugin.Plugin, kind string, report any) {
+	for _, p := range plugin.With(plugins, plugin.HookSink) {
+		if err := p.Sink(version, kind, report); err != nil {
+			fmt.Fprintf(os.Stderr, "plugin %s: %v\n", p.Name, err)
+		}
+	}
+	archiveSink(kind, report)
+}
+
+func init() {
+	pluginsListCmd.Flags().BoolVar(&pluginsListJSON, "json", false, "output as JSON")
+
+	pluginsCmd.AddCommand(pluginsListCmd)
+	rootCmd.AddCommand(pluginsCmd)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/privilege"
+	"github.com/spf13/cobra"
+)
+
+var (
+	privilegesJSON       bool
+	privilegesSudoHelper bool
+	privilegesUser       string
+)
+
+var privilegesCmd = &cobra.Command{
+	Use:   "privileges",
+	Short: "Show which operations this user may run, and how to grant the rest",
+	Long: `agent does not need root. Show, for this user, whether each operation
+has the access it needs:
+
+  docker    the Docker socket (docker group membership)
+  asterisk  reading /etc/asterisk and asterisk -rx, when Asterisk runs on this host
+  capture   raw sockets for tcpdump (root or cap_net_raw); otherwise agent
+            capture runs tcpdump in a helper container
+  firewall  listing ufw, iptables and nft rules for agent check
+
+Operations that lack access fall back or report why instead of failing
+outright. --sudo-helper prints the sudoers entries agent uses: only the
+read-only firewall listings, run with sudo -n. The other operations are
+granted with group memberships and file capabilities, which it lists as
+comments, because a sudoers entry for them would amount to root.
+
+Exits 1 when an operation is degraded or denied.
+
+Examples:
+  agent privileges
+  agent privileges --sudo-helper > aava-agent && sudo visudo -cf aava-agent`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if privilegesSudoHelper {
+			name := privilegesUser
+			if name == "" {
+				if os.Geteuid() == 0 && os.Getenv("SUDO_USER") != "" {
+					name = os.Getenv("SUDO_USER")
+				} else if u, err := user.Current(); err == nil {
+					name = u.Username
+				}
+			}
+			if name == "" {
+				return contract.UsageError(fmt.Errorf("cannot tell the current user; pass --user"))
+			}
+			fmt.Print(privilege.SudoHelper(name))
+			return nil
+		}
+
+		ops := privilege.Diagnose()
+		limited := false
+		for _, op := range ops {
+			limited = limited || op.Status == privilege.StatusDegraded || op.Status == privilege.StatusDenied
+		}
+		if format := structuredOutput(privilegesJSON); format.Structured() {
+			if err := output.Write(os.Stdout, format, map[string]any{
+				"schema_version": contract.SchemaVersion,
+				"operations":     ops,
+			}); err != nil {
+				return err
+			}
+		} else {
+			for _, op := range ops {
+				mark := "✓"
+				switch op.Status {
+				case privilege.StatusDegraded:
+					mark = "⚠️ "
+				case privilege.StatusDenied:
+					mark = "✗"
+				case privilege.StatusSkipped:
+					mark = "-"
+				}
+				fmt.Printf("%s %-9s %s\n", mark, op.Name, op.Message)
+				if op.Fix != "" {
+					fmt.Printf("  %-9s fix: %s\n", "", op.Fix)
+				}
+			}
+		}
+		if limited {
+			return contract.Exit(contract.Warn, nil)
+		}
+		return nil
+	},
+}
+
+func init() {
+	privilegesCmd.Flags().BoolVar(&privilegesJSON, "json", false, "output as JSON")
+	privilegesCmd.Flags().BoolVar(&privilegesSudoHelper, "sudo-helper", false, "print the minimal sudoers entries for agent")
+	privilegesCmd.Flags().StringVar(&privilegesUser, "user", "", "user the sudoers entries are for (default: the invoking user)")
+	rootCmd.AddCommand(privilegesCmd)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/backup"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purgeCalls  []string
+	purgeBefore string
+	purgeDryRun bool
+	purgeYes    bool
+	purgeJSON   bool
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete the locally stored data of calls (data-subject deletion requests)",
+	Long: `Delete everything this host keeps about a call outside the engine:
+
+  - its entry in the call index (.agent/calls.json)
+  - its sample in the quality history (.agent/quality/samples.jsonl)
+  - its packet captures (.agent/captures/<call_id>.pcap)
+  - its Asterisk recordings in ASTERISK_RECORDING_PATH, matched by channel ID
+    the way the Admin UI's recording playback finds them
+
+--call takes a channel ID or a phone number; a number selects every indexed
+call from or to it. Repeat --call for several. --before <date> purges every
+call that started before the date instead (YYYY-MM-DD, or RFC 3339).
+
+What was deleted is printed; --dry-run only lists it. On a terminal the
+command asks before deleting unless --yes is given.
+
+The Call History record (delete it on the Admin UI's Call History page), the
+engine and Asterisk logs, CDRs, update backups and migration archives are
+not changed; the command lists the backups and archives that may still hold
+the call.
+
+Examples:
+  agent purge --call 1761518880.2191
+  agent purge --call "+15551234567" --dry-run
+  agent purge --before 2026-01-01 --yes --json`,
+	Args: cobra.NoArgs,
+	RunE: runPurge,
+}
+
+// PurgeReport is the --json output of agent purge.
+type PurgeReport struct {
+	SchemaVersion int                      `json:"schema_version"`
+	DryRun        bool                     `json:"dry_run"`
+	Calls         []string                 `json:"calls,omitempty"`
+	Before        *time.Time               `json:"before,omitempty"`
+	Purged        []troubleshoot.PurgeItem `json:"purged"`
+	NotChanged    []string                 `json:"not_changed,omitempty"`
+	Error         string                   `json:"error,omitempty"`
+}
+
+func runPurge(cmd *cobra.Command, args []string) error {
+	if len(purgeCalls) == 0 && purgeBefore == "" {
+		return contract.UsageError(errors.New("give --call <id|number> or --before <date>"))
+	}
+	opts := troubleshoot.PurgeOptions{
+		CaptureDir:   filepath.Join(".agent", "captures"),
+		RecordingDir: recordingPath(),
+		DryRun:       purgeDryRun,
+	}
+	rep := &PurgeReport{SchemaVersion: contract.SchemaVersion, DryRun: purgeDryRun, Purged: []troubleshoot.PurgeItem{}}
+	if purgeBefore != "" {
+		before, err := parsePurgeBefore(purgeBefore)
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if before.After(time.Now()) {
+			return contract.UsageError(fmt.Errorf("--before %s is in the future", purgeBefore))
+		}
+		opts.Before, rep.Before = before, &before
+	} else {
+		ids, err := troubleshoot.ResolvePurgeCalls(purgeCalls)
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		opts.CallIDs, rep.Calls = ids, ids
+	}
+
+	format := structuredOutput(purgeJSON)
+	if !purgeDryRun && !purgeYes && !format.Structured() && stdinIsTerminal() {
+		preview := opts
+		preview.DryRun = true
+		found, err := troubleshoot.Purge(preview)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		if len(found) == 0 {
+			fmt.Println("Nothing stored for the selected calls.")
+			printPurgeNotChanged(purgeNotChanged())
+			return nil
+		}
+		printPurgeItems(found, "Will delete")
+		fmt.Fprint(os.Stderr, "Delete these? [y/N]: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+		default:
+			return contract.Exit(contract.Warn, errors.New("purge cancelled; nothing was deleted"))
+		}
+	}
+
+	items, err := troubleshoot.Purge(opts)
+	rep.Purged = append(rep.Purged, items...)
+	rep.NotChanged = purgeNotChanged()
+	if err != nil {
+		rep.Error = err.Error()
+	}
+	if format.Structured() {
+		if werr := output.Write(os.Stdout, format, rep); werr != nil {
+			return werr
+		}
+	} else {
+		verb := "Deleted"
+		if purgeDryRun {
+			verb = "Would delete"
+		}
+		if len(items) == 0 {
+			fmt.Println("Nothing stored for the selected calls.")
+		} else {
+			printPurgeItems(items, verb)
+		}
+		printPurgeNotChanged(rep.NotChanged)
+	}
+	if err != nil {
+		if format.Structured() {
+			return contract.Exit(contract.Environment, nil)
+		}
+		return contract.EnvironmentError(fmt.Errorf("purge stopped: %w", err))
+	}
+	return nil
+}
+
+// parsePurgeBefore reads a local date (midnight) or an RFC 3339 time.
+func parsePurgeBefore(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("--before %q is not a date (YYYY-MM-DD) or RFC 3339 time", s)
+}
+
+// purgeNotChanged names the stores a purge leaves alone that may still hold
+// the calls.
+func purgeNotChanged() []string {
+	notes := []string{
+		"Call History record in data/call_history.db (delete it on the Admin UI's Call History page)",
+		"ai_engine and Asterisk logs and CDRs (kept until they rotate)",
+	}
+	if entries, err := os.ReadDir(backup.Dir); err == nil && len(entries) > 0 {
+		notes = append(notes, fmt.Sprintf("%d update backup(s) in %s (snapshots of call_history.db)", len(entries), backup.Dir))
+	}
+	if archives, _ := filepath.Glob("*.aava"); len(archives) > 0 {
+		notes = append(notes, fmt.Sprintf("migration archive(s) %s (call index, quality history and call_history.db)", strings.Join(archives, ", ")))
+	}
+	return notes
+}
+
+func printPurgeItems(items []troubleshoot.PurgeItem, verb string) {
+	calls := map[string]bool{}
+	for _, it := range items {
+		calls[it.CallID] = true
+	}
+	fmt.Printf("%s %d item(s) for %d call(s):\n", verb, len(items), len(calls))
+	for _, it := range items {
+		id := it.CallID
+		if id == "" {
+			id = "-"
+		}
+		fmt.Printf("  %-15s %-18s %s\n", it.Kind, id, it.Path)
+	}
+}
+
+func printPurgeNotChanged(notes []string) {
+	if len(notes) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Not changed:")
+	for _, n := range notes {
+		fmt.Printf("  • %s\n", n)
+	}
+}
+
+func init() {
+	purgeCmd.Flags().StringSliceVar(&purgeCalls, "call", nil, "channel ID, or phone number for every indexed call from or to it (repeatable)")
+	purgeCmd.Flags().StringVar(&purgeBefore, "before", "", "purge every call that started before this date (YYYY-MM-DD or RFC 3339)")
+	purgeCmd.Flags().BoolVar(&purgeDryRun, "dry-run", false, "list what would be deleted without deleting it")
+	purgeCmd.Flags().BoolVarP(&purgeYes, "yes", "y", false, "do not ask for confirmation")
+	purgeCmd.Flags().BoolVar(&purgeJSON, "json", false, "output as JSON")
+	_ = purgeCmd.RegisterFlagCompletionFunc("call", completeCallIDs)
+	purgeCmd.MarkFlagsMutuallyExclusive("call", "before")
+	rootCmd.AddCommand(purgeCmd)
+}
@@ -1,11 +1,22 @@
 package main
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/capture"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/otlp"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
 	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +27,20 @@ var (
 	rcaLLM    bool
 	rcaNoLLM  bool
 	rcaLocal  bool
+	rcaList   bool
+	rcaBuffer bool
+	rcaEcho   time.Duration
+	rcaPcap   string
+	rcaLast   int
+	rcaFull   bool
+	rcaConv   bool
+	rcaRedact string
+
+	rcaOTLP         bool
+	rcaOTLPEndpoint string
+
+	rcaOpenIssue bool
+	rcaFormat    string
 )
 
 var rcaCmd = &cobra.Command{
@@ -23,21 +48,175 @@ var rcaCmd = &cobra.Command{
 	Short: "Post-call root cause analysis",
 	Long: `Analyze the most recent call (or a specific call ID) and print an RCA report.
 
+The call may also be given as a caller or dialed phone number
+(--call "+15551234567") or a time (--call "today 14:05"); it is resolved
+through the call index to the matching channel ID.
+
+Use --list to show recent calls from the local call index (.agent/calls.json),
+which is refreshed incrementally from new ai_engine log output.
+
 Use --local to generate a Community Test Matrix submission template
 for the last local-provider call (collects hardware, model config,
 and latency data automatically).
 
+Use --otlp to export the call as an OpenTelemetry trace: a span for the call,
+one per turn, and STT, LLM and TTS spans inside each turn, sent over OTLP/HTTP
+(JSON) to --otlp-endpoint or OTEL_EXPORTER_OTLP_ENDPOINT.
+
+Known problems are matched from the troubleshooting knowledge base (log
+patterns and metric conditions, built in and from config/rca-knowledge.yaml)
+and shown under Known Issues with their fix; a call they explain skips the
+AI diagnosis unless --llm is given.
+
+The report's Echo Path section compares caller speech onsets with agent
+playback. Onsets within --echo-window of agent audio starting or stopping
+count as echo; later ones during playback as genuine barge-ins.
+
+Use --buffer to show the call's jitter buffer instead of the report: fill
+level over time as an ASCII chart against the min start and low watermark
+thresholds, with underflows, resets, audio gate changes and barge-ins marked.
+
+Use --pcap to add a packet capture of the call's media (from agent capture
+or tcpdump) to the report: loss, jitter, reordering, gaps, codec and ptime
+of the RTP or AudioSocket streams.
+
+Use --last N to analyze the N most recent calls in one run and print a
+summary: one line per call, oldest first, with its quality score, result
+and top issue. --full prints each call's report before the summary. The
+exit code is that of the worst call.
+
+Use --conversation to score the call's transcript from Call History with
+the AI diagnosis LLM (OPENAI_API_KEY or ANTHROPIC_API_KEY): greeting,
+staying on topic, task completion and hallucination, each 1-5 with a reason.
+The scores are added to the report (conversation_quality in --json) and do
+not change the exit code.
+
+Use --redact to mask personal data before the report is printed, exported
+or sent to an LLM: phone numbers, email addresses, payment card numbers and
+names become [PHONE], [EMAIL], [CARD] and [NAME]. --redact masks every
+category; --redact=phone,email only those. Names are the call's caller ID
+name, plus the names said in the transcript when AAVA_REDACT_NER_URL points
+at an Ollama server (model AAVA_REDACT_NER_MODEL, default llama3.2).
+
+Use --open-issue to draft a GitHub bug report from the call: summary,
+findings, known issues, versions and a metrics table, laid out like the
+project's bug report template and redacted as with --redact. It is saved in
+.agent/issues/ and opened as a pre-filled new-issue page in the browser (or
+printed as a link); with AAVA_GITHUB_TOKEN set, the issue is created through
+the GitHub API instead, after confirmation. AAVA_ISSUE_REPO files it on a
+fork (owner/repo).
+
+Use --format html to write the report as a self-contained HTML page instead:
+findings, a timeline chart of the call's turns (STT, LLM and TTS), the metric
+tables, the jitter buffer graph and the transcript from Call History. It
+needs no network access to view, so it can be attached to a ticket or mailed
+to a customer:
+  agent rca --call 1761518880.2191 --format html --redact > call.html
+
+Analyzer plugins in ~/.agent/plugins add their findings to the report, and
+sink plugins receive the finished report (see agent plugins).
+
+Budgets in .agent/budgets.yaml (AAVA_BUDGETS_FILE) hold every call to a p95
+response latency, a duration and an estimated cost, from per-minute rates by
+pipeline or provider. A call over budget gets a finding of the budget's
+severity: a warning makes the call WARN and costs 10 quality points; a
+critical one makes it FAIL, which runs the on-critical-call hook:
+  max_response_latency_ms: 1500
+  max_duration: 10m
+  max_call_cost: 0.50
+  currency: USD
+  rates: {default: 0.02, openai_realtime: 0.10}   # per minute
+  severity: {call_cost: critical}   # response_latency, call_duration
+  overrides: {google_live: {max_response_latency_ms: 2500}}
+
 This is the recommended post-call troubleshooting command.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// --local mode: generate community test matrix submission
 		if rcaLocal {
 			if len(args) > 0 {
-				return fmt.Errorf("--local reports the latest local call and cannot be combined with a call ID")
+				return contract.UsageError(fmt.Errorf("--local reports the latest local call and cannot be combined with a call ID"))
 			}
 			return runLocalTestReport(cmd)
 		}
 
+		if rcaList && (rcaCallID != "" || len(args) > 0) {
+			return contract.UsageError(fmt.Errorf("--list cannot be combined with a call ID"))
+		}
+		if rcaEcho < 0 {
+			return contract.UsageError(fmt.Errorf("--echo-window must not be negative"))
+		}
+		if rcaBuffer && rcaList {
+			return contract.UsageError(fmt.Errorf("--buffer shows one call and cannot be combined with --list"))
+		}
+		if rcaOTLPEndpoint != "" {
+			rcaOTLP = true
+		}
+		if rcaLast < 0 {
+			return contract.UsageError(fmt.Errorf("--last must be positive"))
+		}
+		if rcaFull && rcaLast == 0 {
+			return contract.UsageError(fmt.Errorf("--full applies to --last"))
+		}
+		if rcaLast > 0 && (rcaCallID != "" || len(args) > 0) {
+			return contract.UsageError(fmt.Errorf("--last analyzes the most recent calls and cannot be combined with a call ID"))
+		}
+		if rcaConv && rcaNoLLM {
+			return contract.UsageError(fmt.Errorf("--conversation is scored by the LLM and cannot be combined with --no-llm"))
+		}
+		if rcaConv && (rcaList || rcaBuffer) {
+			return contract.UsageError(fmt.Errorf("--conversation applies to analyzed calls and cannot be combined with --list or --buffer"))
+		}
+		if rcaOpenIssue && (rcaList || rcaBuffer || rcaLast > 0) {
+			return contract.UsageError(fmt.Errorf("--open-issue reports one analyzed call and cannot be combined with --list, --buffer or --last"))
+		}
+		switch rcaFormat {
+		case "text", "html":
+		default:
+			return contract.UsageError(fmt.Errorf("invalid --format %q (expected text or html)", rcaFormat))
+		}
+		htmlReport := rcaFormat == "html"
+		if htmlReport && (rcaList || rcaBuffer || rcaLast > 0 || structuredOutput(rcaJSON).Structured()) {
+			return contract.UsageError(fmt.Errorf("--format html renders one analyzed call and cannot be combined with --list, --buffer, --last or structured output"))
+		}
+		if rcaOTLP && rcaList {
+			return contract.UsageError(fmt.Errorf("--otlp exports one analyzed call and cannot be combined with --list"))
+		}
+		var redactor *redact.Redactor
+		if cmd.Flags().Changed("redact") || rcaOpenIssue {
+			spec := rcaRedact
+			if rcaOpenIssue {
+				// Issues are public: mask every category whatever --redact says.
+				spec = "all"
+			}
+			var err error
+			if redactor, err = redact.Parse(spec); err != nil {
+				return contract.UsageError(err)
+			}
+			redactor.SetNER(redact.NERFromEnv())
+		}
+		var pcapReport *capture.Report
+		if rcaPcap != "" {
+			opts, _, err := captureOptions()
+			if err != nil {
+				return contract.EnvironmentError(err)
+			}
+			if pcapReport, err = analyzeCaptureFile(rcaPcap, opts); err != nil {
+				return contract.EnvironmentError(err)
+			}
+		}
+		budgets, err := budget.Load(budget.Path())
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		var exporter *otlp.Exporter
+		if rcaOTLP {
+			var err error
+			if exporter, err = otlpExporter(); err != nil {
+				return err
+			}
+		}
+
 		callID := rcaCallID
 		if callID == "" && len(args) == 1 {
 			callID = args[0]
@@ -46,6 +225,7 @@ This is the recommended post-call troubleshooting command.`,
 			callID = "last"
 		}
 
+		format := structuredOutput(rcaJSON)
 		runner := troubleshoot.NewRunner(
 			callID,
 			"",    // symptom
@@ -53,18 +233,118 @@ This is the recommended post-call troubleshooting command.`,
 			false, // collectOnly
 			rcaNoLLM,
 			rcaLLM, // forceLLM
-			false,  // list
-			rcaJSON,
+			rcaList,
+			format.Structured(),
 			verbose,
 		)
-		err := runner.Run()
-		if rcaJSON && err != nil {
-			os.Exit(1)
+		runner.SetFormat(format)
+		runner.SetQuiet(quiet)
+		runner.SetBufferView(rcaBuffer)
+		runner.SetEchoWindow(rcaEcho)
+		runner.SetLast(rcaLast, rcaFull)
+		runner.SetConversationQuality(rcaConv)
+		runner.SetRedactor(redactor)
+		runner.SetProfile(outputProfile)
+		runner.SetBudgets(budgets)
+		plugins := plugin.Load()
+		runner.SetPlugins(plugins, version)
+		if pcapReport != nil {
+			runner.SetCapture(pcapReport)
+		}
+		if htmlReport {
+			runner.SetSilent(true)
+		}
+		err = runner.Run()
+		if format.Structured() && err != nil {
+			// The JSON payload already carries the error.
+			return contract.Exit(contract.CodeOf(err), nil)
+		}
+		if err != nil {
+			return err
+		}
+		if htmlReport {
+			if err := runner.WriteHTML(os.Stdout, version); err != nil {
+				return err
+			}
 		}
-		return err
+		if rep := runner.Report(); rep != nil {
+			runSinks(plugins, "rca", rep)
+			runCallHooks(runner, rep)
+		}
+		if exporter != nil {
+			if err := exportCallTrace(runner, exporter); err != nil {
+				return err
+			}
+		}
+		if rcaOpenIssue {
+			if err := fileCallIssue(runner); err != nil {
+				return err
+			}
+		}
+		if code := runner.ExitCode(); code != contract.OK {
+			return contract.Exit(code, nil)
+		}
+		return nil
 	},
 }
 
+// otlpExporter configures the exporter from --otlp-endpoint and the standard
+// OTEL_EXPORTER_OTLP_* variables. Only the http/json protocol is implemented.
+func otlpExporter() (*otlp.Exporter, error) {
+	for _, k := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if p := strings.TrimSpace(os.Getenv(k)); p != "" {
+			if p == "grpc" {
+				return nil, contract.UsageError(fmt.Errorf("%s=grpc is not supported; point the exporter at the collector's OTLP/HTTP port (4318)", k))
+			}
+			break
+		}
+	}
+	endpoint := otlp.TracesURL(otlp.DefaultEndpoint, false)
+	switch {
+	case rcaOTLPEndpoint != "":
+		endpoint = otlp.TracesURL(rcaOTLPEndpoint, false)
+	case strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")) != "":
+		endpoint = otlp.TracesURL(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"), true)
+	case strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")) != "":
+		endpoint = otlp.TracesURL(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), false)
+	}
+	headers := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")
+	if headers == "" {
+		headers = os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")
+	}
+	parsed, err := otlp.ParseHeaders(headers)
+	if err != nil {
+		return nil, contract.UsageError(err)
+	}
+	return &otlp.Exporter{HTTP: &http.Client{Timeout: 10 * time.Second}, Endpoint: endpoint, Headers: parsed}, nil
+}
+
+// exportCallTrace sends the analyzed call to the collector; the report is
+// already printed, so progress goes to stderr.
+func exportCallTrace(runner *troubleshoot.Runner, exporter *otlp.Exporter) error {
+	spans, err := runner.CallTrace()
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("build trace: %w", err))
+	}
+	service := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
+	if service == "" {
+		service = "asterisk-ai-voice-agent"
+	}
+	resource := map[string]any{"service.name": service}
+	if host, err := os.Hostname(); err == nil {
+		resource["host.name"] = host
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := exporter.Export(ctx, resource, "agent rca", spans); err != nil {
+		return contract.EnvironmentError(fmt.Errorf("export trace to %s: %w", exporter.Endpoint, err))
+	}
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Exported %d span(s) to %s (trace ID %s)\n", len(spans), exporter.Endpoint, hex.EncodeToString(spans[0].TraceID[:]))
+	}
+	return nil
+}
+
 // runLocalTestReport shells out to scripts/local_test_report.py
 func runLocalTestReport(cmd *cobra.Command) error {
 	// Find project root (walk up from cwd looking for .env or docker-compose.yml)
@@ -120,14 +400,46 @@ func findProjectRoot() (string, error) {
 }
 
 func init() {
-	rcaCmd.Flags().StringVar(&rcaCallID, "call", "", "analyze specific call ID (default: last)")
+	rcaCmd.Flags().StringVar(&rcaCallID, "call", "", "analyze specific call: channel ID, phone number, or time like \"today 14:05\" (default: last)")
 	rcaCmd.Flags().BoolVar(&rcaLLM, "llm", false, "force LLM analysis (even for healthy calls)")
 	rcaCmd.Flags().BoolVar(&rcaNoLLM, "no-llm", false, "disable external LLM analysis; report deterministic evidence only")
 	rcaCmd.Flags().BoolVar(&rcaJSON, "json", false, "output as JSON (JSON only)")
+	rcaCmd.Flags().BoolVar(&rcaList, "list", false, "list recent calls from the call index")
 	rcaCmd.Flags().BoolVar(&rcaLocal, "local", false, "generate Community Test Matrix submission for local provider")
+	rcaCmd.Flags().BoolVar(&rcaBuffer, "buffer", false, "show the call's jitter buffer fill level, underflows and resets instead of the report")
+	rcaCmd.Flags().DurationVar(&rcaEcho, "echo-window", troubleshoot.DefaultEchoWindow, "caller speech this soon after agent audio starts or stops counts as echo")
+	rcaCmd.Flags().StringVar(&rcaPcap, "pcap", "", "add a pcap of the call's RTP or AudioSocket packets to the report")
+	rcaCmd.Flags().IntVar(&rcaLast, "last", 0, "analyze the N most recent calls and summarize them")
+	rcaCmd.Flags().BoolVar(&rcaFull, "full", false, "with --last, print each call's report before the summary")
+	rcaCmd.Flags().BoolVar(&rcaConv, "conversation", false, "score the transcript against a conversation rubric with the LLM")
+	rcaCmd.Flags().StringVar(&rcaRedact, "redact", "", "mask personal data in the report, exports and LLM prompts: all, or a list of phone, email, card, name")
+	rcaCmd.Flags().Lookup("redact").NoOptDefVal = "all"
+	rcaCmd.Flags().BoolVar(&rcaOpenIssue, "open-issue", false, "draft a redacted GitHub bug report from the call and open it (or create it with $AAVA_GITHUB_TOKEN)")
+	rcaCmd.Flags().StringVar(&rcaFormat, "format", "text", "report format: text, or html for a self-contained page to attach or mail")
+	rcaCmd.Flags().BoolVar(&rcaOTLP, "otlp", false, "export the call as an OpenTelemetry trace over OTLP/HTTP")
+	rcaCmd.Flags().StringVar(&rcaOTLPEndpoint, "otlp-endpoint", "", "OTLP/HTTP collector base URL (default $OTEL_EXPORTER_OTLP_ENDPOINT or "+otlp.DefaultEndpoint+"); implies --otlp")
+	_ = rcaCmd.RegisterFlagCompletionFunc("call", completeCallIDs)
+	_ = rcaCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "html"}, cobra.ShellCompDirectiveNoFileComp))
 	rcaCmd.MarkFlagsMutuallyExclusive("llm", "no-llm")
 	rcaCmd.MarkFlagsMutuallyExclusive("local", "call")
 	rcaCmd.MarkFlagsMutuallyExclusive("local", "llm")
 	rcaCmd.MarkFlagsMutuallyExclusive("local", "no-llm")
+	rcaCmd.MarkFlagsMutuallyExclusive("local", "list")
+	rcaCmd.MarkFlagsMutuallyExclusive("local", "otlp")
+	rcaCmd.MarkFlagsMutuallyExclusive("local", "otlp-endpoint")
+	rcaCmd.MarkFlagsMutuallyExclusive("buffer", "llm")
+	rcaCmd.MarkFlagsMutuallyExclusive("buffer", "local")
+	rcaCmd.MarkFlagsMutuallyExclusive("buffer", "otlp")
+	rcaCmd.MarkFlagsMutuallyExclusive("buffer", "otlp-endpoint")
+	rcaCmd.MarkFlagsMutuallyExclusive("pcap", "local")
+	rcaCmd.MarkFlagsMutuallyExclusive("pcap", "list")
+	rcaCmd.MarkFlagsMutuallyExclusive("pcap", "buffer")
+	rcaCmd.MarkFlagsMutuallyExclusive("last", "list")
+	rcaCmd.MarkFlagsMutuallyExclusive("last", "local")
+	rcaCmd.MarkFlagsMutuallyExclusive("last", "buffer")
+	rcaCmd.MarkFlagsMutuallyExclusive("last", "pcap")
+	rcaCmd.MarkFlagsMutuallyExclusive("last", "otlp")
+	rcaCmd.MarkFlagsMutuallyExclusive("last", "otlp-endpoint")
+	rcaCmd.MarkFlagsMutuallyExclusive("format", "local")
 	rootCmd.AddCommand(rcaCmd)
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hooks"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+// runCallHooks runs .agent/hooks/post-rca for the analyzed call, and
+// on-critical-call when it failed. The report is already printed, so hook
+// failures go to stderr and do not change the exit code.
+func runCallHooks(runner *troubleshoot.Runner, rep *troubleshoot.RCAReport) {
+	code := runner.ExitCode()
+	result := rcaResult(code)
+	names := []string{hooks.PostRCA}
+	if code == contract.Fail {
+		names = append(names, hooks.OnCriticalCall)
+	}
+	for _, name := range names {
+		e := hooks.Event{Hook: name, CLIVersion: version, Call: &hooks.Call{CallID: rep.CallID, Result: result, Report: rep}}
+		if _, err := hooks.Run(e); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+	}
+}
+
+// rcaResult is the verdict agent rca prints for its exit code.
+func rcaResult(code int) string {
+	switch code {
+	case contract.Fail:
+		return "FAIL"
+	case contract.Warn:
+		return "WARN"
+	}
+	return "PASS"
+}
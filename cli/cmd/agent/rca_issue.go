@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/statecrypt"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+// maxIssueURL is the longest new-issue link GitHub accepts; a longer body is
+// cut down to fit.
+const maxIssueURL = 8000
+
+// githubAPI is the GitHub REST API base; tests point it at a local server.
+var githubAPI = "https://api.github.com"
+
+// issueRepo is where agent rca --open-issue files bug reports.
+func issueRepo() string {
+	if r := strings.Trim(strings.TrimSpace(os.Getenv("AAVA_ISSUE_REPO")), "/"); r != "" {
+		return r
+	}
+	return cliReleaseRepo
+}
+
+// fileCallIssue drafts a bug report for the analyzed call and opens or
+// creates it; the report is already printed, so progress goes to stderr.
+func fileCallIssue(runner *troubleshoot.Runner) error {
+	draft, err := runner.Issue(troubleshoot.IssueEnv{
+		CLIVersion:     version,
+		ProjectVersion: projectVersion(),
+		OS:             hostOSName(),
+	})
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("draft issue: %w", err))
+	}
+	path, err := saveIssueDraft(draft)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	fmt.Fprintf(os.Stderr, "\nIssue draft saved to %s\n", path)
+	if key, _, _ := statecrypt.Key(); key != nil {
+		fmt.Fprintf(os.Stderr, "It is encrypted; read it with: agent state cat %s\n", path)
+	}
+
+	repo := issueRepo()
+	if token := strings.TrimSpace(os.Getenv("AAVA_GITHUB_TOKEN")); token != "" {
+		if stdinIsTerminal() {
+			fmt.Fprintf(os.Stderr, "Create %q on %s? Review the draft first; the issue is public. [y/N]: ", draft.Title, repo)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y", "yes":
+			default:
+				fmt.Fprintln(os.Stderr, "Not created.")
+				return nil
+			}
+		}
+		link, err := createGitHubIssue(repo, token, draft.Title, draft.Markdown())
+		if err != nil {
+			return contract.EnvironmentError(fmt.Errorf("create issue: %w", err))
+		}
+		fmt.Fprintf(os.Stderr, "✓ Created %s\n", link)
+		return nil
+	}
+
+	link := newIssueURL(repo, draft.Title, draft.Markdown())
+	if len(link) > maxIssueURL {
+		body := draft.Body + fmt.Sprintf("\n## Diagnostics\n<!-- The metrics table did not fit in the link; paste it from %s -->\n", path)
+		link = newIssueURL(repo, draft.Title, body)
+		fmt.Fprintf(os.Stderr, "The metrics table did not fit in the link; paste it from %s.\n", path)
+	}
+	if openBrowser(link) {
+		fmt.Fprintln(os.Stderr, "Opened the pre-filled issue in your browser. Review it before submitting.")
+	} else {
+		fmt.Fprintf(os.Stderr, "Open this link to file the issue (review it before submitting):\n%s\n", link)
+	}
+	return nil
+}
+
+var issueDraftDir = filepath.Join(".agent", "issues")
+
+// saveIssueDraft keeps the full draft in .agent/issues/, for pasting what a
+// link cannot carry and for reports filed later. It is encrypted like the rest
+// of the agent state when a state key is set (agent state cat shows it).
+func saveIssueDraft(draft troubleshoot.IssueDraft) (string, error) {
+	if err := os.MkdirAll(issueDraftDir, 0755); err != nil {
+		return "", err
+	}
+	name := strings.NewReplacer("/", "_", `\`, "_", ":", "_").Replace(draft.CallID)
+	path := filepath.Join(issueDraftDir, name+".md")
+	content := "# " + draft.Title + "\n\n" + draft.Markdown()
+	return path, statecrypt.WriteFile(path, []byte(content), 0644)
+}
+
+func newIssueURL(repo, title, body string) string {
+	q := url.Values{}
+	q.Set("title", title)
+	q.Set("body", body)
+	q.Set("labels", "bug")
+	return "https://github.com/" + repo + "/issues/new?" + q.Encode()
+}
+
+// createGitHubIssue files the issue and returns its link.
+func createGitHubIssue(repo, token, title, body string) (string, error) {
+	payload, err := json.Marshal(map[string]any{"title": title, "body": body, "labels": []string{"bug"}})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, githubAPI+"/repos/"+repo+"/issues", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		HTMLURL string `json:"html_url"`
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	if resp.StatusCode != http.StatusCreated {
+		if out.Message != "" {
+			return "", fmt.Errorf("%s: %s", resp.Status, out.Message)
+		}
+		return "", errors.New(resp.Status)
+	}
+	return out.HTMLURL, nil
+}
+
+// openBrowser opens the link on a desktop; it reports false on a headless
+// server or when no opener is available.
+func openBrowser(link string) bool {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return false
+		}
+		cmd = exec.Command("xdg-open", link)
+	}
+	return cmd.Start() == nil
+}
+
+// projectVersion is the checkout's release, as git describes it.
+func projectVersion() string {
+	out, err := runGitCmd("describe", "--tags", "--always")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// hostOSName is the distribution's name, e.g. "Ubuntu 22.04.4 LTS".
+func hostOSName() string {
+	raw, err := os.ReadFile("/etc/os-release")
+	if err == nil {
+		for _, line := range strings.Split(string(raw), "\n") {
+			if v, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+				return strings.Trim(strings.TrimSpace(v), `"`)
+			}
+		}
+	}
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
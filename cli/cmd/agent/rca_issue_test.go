@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateGitHubIssue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/ava/issues" || r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "Bad credentials"}`))
+			return
+		}
+		var in map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if in["title"] != "[BUG] x" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"html_url": "https://github.com/acme/ava/issues/7"}`))
+	}))
+	defer srv.Close()
+	prev := githubAPI
+	githubAPI = srv.URL
+	defer func() { githubAPI = prev }()
+
+	link, err := createGitHubIssue("acme/ava", "tok", "[BUG] x", "body")
+	if err != nil || link != "https://github.com/acme/ava/issues/7" {
+		t.Fatalf("link = %q, err = %v", link, err)
+	}
+	if _, err := createGitHubIssue("acme/ava", "bad", "[BUG] x", "body"); err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNewIssueURL(t *testing.T) {
+	t.Setenv("AAVA_ISSUE_REPO", "/acme/ava/")
+	got := newIssueURL(issueRepo(), "[BUG] a & b", "line 1\nline 2")
+	if !strings.HasPrefix(got, "https://github.com/acme/ava/issues/new?") || !strings.Contains(got, "title=%5BBUG%5D+a+%26+b") || !strings.Contains(got, "body=line+1%0Aline+2") {
+		t.Errorf("url = %s", got)
+	}
+}
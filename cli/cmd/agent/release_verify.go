@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// releaseSigningKey is the base64 ed25519 public key that signs SHA256SUMS in
+// CLI releases. Release builds set it via -ldflags (CLI_SIGNING_PUBKEY in the
+// Makefile); a fork builds with its own key. It is deliberately not read from
+// the environment, which .env can set.
+var releaseSigningKey = ""
+
+// errUnsigned marks an artifact that carries no verifiable signature, as opposed
+// to one whose signature is wrong. Only the former may be bypassed with --insecure.
+var errUnsigned = errors.New("unsigned")
+
+func releasePublicKey() (ed25519.PublicKey, error) {
+	raw := strings.TrimSpace(releaseSigningKey)
+	if raw == "" {
+		return nil, fmt.Errorf("%w: this build has no release signing key", errUnsigned)
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("release signing key is not a base64 ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// verifySHA256SUMS checks SHA256SUMS.sig (base64 or raw ed25519 signature over
+// the SHA256SUMS bytes) published next to a release's checksums.
+func verifySHA256SUMS(ctx context.Context, tag string, sums []byte) error {
+	key, err := releasePublicKey()
+	if err != nil {
+		return err
+	}
+	sig, err := httpGetBytes(ctx, releaseAssetURL(tag, "SHA256SUMS.sig"))
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusNotFound {
+		return fmt.Errorf("%w: release %s has no SHA256SUMS.sig", errUnsigned, tag)
+	}
+	if err != nil {
+		// Anything but a missing file could be an attacker hiding the signature.
+		return fmt.Errorf("cannot fetch SHA256SUMS.sig for %s: %w", tag, err)
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+		sig = decoded
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(key, sums, sig) {
+		return fmt.Errorf("SHA256SUMS signature for %s does not match the release signing key", tag)
+	}
+	return nil
+}
+
+// verifyGitSignature requires a valid GPG (or SSH, per gpg.format) signature on
+// the update target: the tag itself for tag updates, otherwise the target
+// commit. Trust comes from the operator's keyring, as with git itself.
+func verifyGitSignature(rev string, isTag bool) error {
+	sub := "verify-commit"
+	if isTag {
+		sub = "verify-tag"
+	}
+	out, err := runGitCmd(sub, "--raw", rev)
+	if err == nil {
+		return nil
+	}
+	return gitSignatureError(rev, out, err)
+}
+
+// gitSignatureError tells a missing or unverifiable signature from a bad one
+// in git verify-commit/verify-tag --raw output. A signature by a key that is
+// not in the keyring, such as GitHub's web-flow key on merge commits, proves
+// nothing either way, so it counts as unsigned; a signature that does not
+// match the content never does.
+func gitSignatureError(rev, out string, err error) error {
+	switch {
+	case strings.Contains(out, "[GNUPG:] BADSIG"):
+		return fmt.Errorf("signature check of %s failed: bad signature: %w", rev, err)
+	case strings.TrimSpace(out) == "", strings.Contains(out, "no signature found"), strings.Contains(out, "cannot verify a non-tag object"):
+		// verify-commit prints nothing for an unsigned commit; lightweight tags are not tag objects.
+		return fmt.Errorf("%w: %s is not signed", errUnsigned, rev)
+	case strings.Contains(out, "[GNUPG:] NO_PUBKEY"), strings.Contains(out, "[GNUPG:] ERRSIG"),
+		strings.Contains(out, "No principal matched"), strings.Contains(out, "allowedSignersFile needs to be configured"):
+		// gpg, or ssh-keygen for gpg.format=ssh, has no trusted key for the signer.
+		return fmt.Errorf("%w: %s is signed by a key that is not in your keyring", errUnsigned, rev)
+	case strings.Contains(out, "cannot run gpg"), strings.Contains(out, "cannot run ssh-keygen"):
+		return fmt.Errorf("%w: no signature tool to verify %s (%s)", errUnsigned, rev, out)
+	}
+	return fmt.Errorf("signature check of %s failed: %w", rev, err)
+}
+
+// enforceSignature applies --insecure: unsigned artifacts pass with a warning,
+// bad signatures never do.
+func enforceSignature(what string, err error, insecure bool) error {
+	if err == nil {
+		return nil
+	}
+	if insecure && errors.Is(err, errUnsigned) {
+		fmt.Fprintf(os.Stderr, "Warning: %s not verified (--insecure): %v\n", what, err)
+		return nil
+	}
+	if errors.Is(err, errUnsigned) {
+		return fmt.Errorf("refusing unverified %s: %w (re-run with --insecure to accept it)", what, err)
+	}
+	return fmt.Errorf("refusing %s: %w", what, err)
+}
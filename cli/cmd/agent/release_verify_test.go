@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitSignatureError(t *testing.T) {
+	exit := errors.New("exit status 1")
+	for _, tc := range []struct {
+		name     string
+		out      string
+		unsigned bool
+	}{
+		{"unsigned commit", "", true},
+		{"lightweight tag", "error: 0123abc: cannot verify a non-tag object of type commit.", true},
+		{"key not in keyring", "[GNUPG:] NEWSIG\n[GNUPG:] ERRSIG B5690EEEBB952194 1 10 00 1700000000 9 -\n[GNUPG:] NO_PUBKEY B5690EEEBB952194", true},
+		{"ssh signer not allowed", "Good \"git\" signature with ED25519 key SHA256:abc\nNo principal matched.", true},
+		{"no gpg", "error: cannot run gpg: No such file or directory", true},
+		{"bad signature", "[GNUPG:] NEWSIG\n[GNUPG:] KEY_CONSIDERED 0123 0\n[GNUPG:] BADSIG B5690EEEBB952194 Release Bot <release@example.com>", false},
+		{"revoked key", "[GNUPG:] NEWSIG\n[GNUPG:] REVKEYSIG B5690EEEBB952194 Release Bot <release@example.com>", false},
+	} {
+		err := gitSignatureError("v7.2.0", tc.out, exit)
+		if errors.Is(err, errUnsigned) != tc.unsigned {
+			t.Errorf("%s: %v, want unsigned=%v", tc.name, err, tc.unsigned)
+		}
+	}
+
+	// --insecure accepts an unknown key but never a bad signature.
+	if err := enforceSignature("update target", gitSignatureError("abc", "[GNUPG:] NO_PUBKEY B5690EEEBB952194", exit), true); err != nil {
+		t.Errorf("--insecure with an unknown key = %v", err)
+	}
+	if err := enforceSignature("update target", gitSignatureError("abc", "[GNUPG:] BADSIG B5690EEEBB952194 x", exit), true); err == nil || !strings.Contains(err.Error(), "bad signature") {
+		t.Errorf("--insecure with a bad signature = %v", err)
+	}
+}
+
+func TestVerifySHA256SUMSOnlyTreatsMissingSignatureAsUnsigned(t *testing.T) {
+	useSigningKey(t)
+	sums := []byte(strings.Repeat("0", 64) + "  agent-linux-amd64\n")
+
+	serveRelease(t, "v7.2.0", map[string]string{"SHA256SUMS": string(sums)})
+	if err := verifySHA256SUMS(context.Background(), "v7.2.0", sums); !errors.Is(err, errUnsigned) {
+		t.Fatalf("404 on SHA256SUMS.sig = %v, want unsigned", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	releaseDownloadBase = srv.URL
+	err := verifySHA256SUMS(context.Background(), "v7.2.0", sums)
+	if err == nil || errors.Is(err, errUnsigned) {
+		t.Fatalf("502 on SHA256SUMS.sig = %v, want a hard failure", err)
+	}
+	if err := enforceSignature("release", err, true); err == nil {
+		t.Fatal("--insecure must not accept a signature that could not be fetched")
+	}
+}
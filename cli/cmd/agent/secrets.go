@@ -0,0 +1,332 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretsStatusJSON bool
+	secretsRenderPath string
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Keep provider API keys in the OS keyring, an encrypted file or a secrets manager instead of .env",
+	Long: `Provider API keys (OPENAI_API_KEY, DEEPGRAM_API_KEY, ...) live in plain text
+in .env by default. Set secrets: in .agent/deployment.yaml (or AAVA_SECRETS)
+to keep them elsewhere:
+
+  secrets: keyring   # the OS keyring: secret-tool on Linux, the keychain on macOS
+  secrets: file      # .agent/secrets.env.age, encrypted with age
+  secrets: sops      # .agent/secrets.sops.env, encrypted and edited with sops
+  secrets: vault     # HashiCorp Vault KV v2 (VAULT_ADDR, VAULT_TOKEN)
+  secrets: aws       # AWS Secrets Manager, through the aws CLI
+  secrets: gcp       # Google Secret Manager, through gcloud
+
+The wizard, agent check, agent demo and the LLM analysis of agent rca then
+read keys from the process environment, then the store, then .env. For the
+file store, age decrypts with the identity in AAVA_AGE_IDENTITY (default
+~/.config/age/keys.txt) and encrypts to AAVA_AGE_RECIPIENTS or to that
+identity's public key.
+
+  agent secrets migrate   # move the keys from .env into the store
+  agent secrets status
+
+ai_engine reads its keys from its environment when it is created. Either
+render them to a file its env_file loads (agent secrets render, with the
+override from agent secrets template compose), or list them without values
+under ai_engine environment: in docker-compose.override.yml and create it
+from an environment that has them: agent setup and agent update do, and
+agent secrets run does for any other command.`,
+}
+
+var secretsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the secrets store and where each provider key comes from",
+	Long: `Show the configured store and, for each provider key that is set, where it
+resolves from; values are never printed. Exits 1 when the store cannot be
+read, or when keys are still in plain .env while a store is configured.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := secrets.Store()
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		storeErr := secrets.Check()
+		dotenv := secretsDotEnv()
+		type keyStatus struct {
+			Name   string `json:"name"`
+			Source string `json:"source"`
+		}
+		keys := []keyStatus{}
+		plain := 0
+		for _, name := range secrets.Names {
+			if v, source := secrets.Lookup(name, dotenv); v != "" {
+				keys = append(keys, keyStatus{Name: name, Source: source})
+				if source == secrets.SourceDotEnv && store != secrets.StoreEnv {
+					plain++
+				}
+			}
+		}
+		if format := structuredOutput(secretsStatusJSON); format.Structured() {
+			v := map[string]any{
+				"schema_version": contract.SchemaVersion,
+				"store":          store,
+				"keys":           keys,
+			}
+			if storeErr != nil {
+				v["store_error"] = storeErr.Error()
+			}
+			if err := output.Write(os.Stdout, format, v); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Store: %s (%s)\n", store, secrets.Label(store))
+			if storeErr != nil {
+				fmt.Printf("  ✗ %v\n", storeErr)
+			}
+			if len(keys) == 0 {
+				fmt.Println("No provider keys set.")
+			}
+			for _, k := range keys {
+				fmt.Printf("  %-22s %s\n", k.Name, k.Source)
+			}
+			if plain > 0 {
+				fmt.Printf("\n%d key(s) are still in plain .env; agent secrets migrate moves them.\n", plain)
+			}
+		}
+		if storeErr != nil || plain > 0 {
+			return contract.Exit(contract.Warn, nil)
+		}
+		return nil
+	},
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <NAME>",
+	Short: "Store a provider key",
+	Long: `Store a provider key in the configured store. The value is read from
+standard input, or prompted for without echo on a terminal, so it does not
+end up in the shell history:
+
+  agent secrets set OPENAI_API_KEY
+  pass show openai | agent secrets set OPENAI_API_KEY`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.ToUpper(args[0])
+		if !secrets.IsName(name) {
+			return contract.UsageError(fmt.Errorf("%s is not a provider key (%s)", name, strings.Join(secrets.Names, ", ")))
+		}
+		store, err := secretsStore()
+		if err != nil {
+			return err
+		}
+		var value string
+		if stdinIsTerminal() {
+			value = readSecret(name + ": ")
+		} else {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return contract.EnvironmentError(err)
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+		if value == "" {
+			return contract.UsageError(errors.New("empty value"))
+		}
+		if err := secrets.Set(name, value); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("✓ %s stored in the %s.\n", name, secrets.Label(store))
+		if v := secretsDotEnv()[name]; v != "" {
+			fmt.Println("  .env still has it and is only read when the store has none; agent secrets migrate removes it.")
+		}
+		printSecretsComposeHint([]string{name})
+		return nil
+	},
+}
+
+var secretsDeleteCmd = &cobra.Command{
+	Use:   "delete <NAME>",
+	Short: "Remove a provider key from the store",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.ToUpper(args[0])
+		store, err := secretsStore()
+		if err != nil {
+			return err
+		}
+		if err := secrets.Delete(name); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("✓ %s removed from the %s.\n", name, secrets.Label(store))
+		return nil
+	},
+}
+
+var secretsMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move the provider keys from .env into the store",
+	Long: `Store every provider key set in .env in the configured store, then remove
+those lines from .env. Other .env settings are left as they are.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := secretsStore()
+		if err != nil {
+			return err
+		}
+		if err := secrets.Check(); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		path := secrets.DotEnvPath()
+		dotenv := secretsDotEnv()
+		var moved []string
+		for _, name := range secrets.Names {
+			v := dotenv[name]
+			if v == "" {
+				continue
+			}
+			if err := secrets.Set(name, v); err != nil {
+				return contract.EnvironmentError(fmt.Errorf("store %s: %w", name, err))
+			}
+			moved = append(moved, name)
+		}
+		if len(moved) == 0 {
+			fmt.Printf("No provider keys in %s.\n", path)
+			return nil
+		}
+		if _, err := secrets.RemoveFromDotEnv(path, moved); err != nil {
+			return contract.EnvironmentError(fmt.Errorf("keys stored, but %s was not updated: %w", path, err))
+		}
+		fmt.Printf("✓ Moved %s from %s to the %s.\n", strings.Join(moved, ", "), path, secrets.Label(store))
+		printSecretsComposeHint(moved)
+		return nil
+	},
+}
+
+var secretsRunCmd = &cobra.Command{
+	Use:   "run -- <command> [args...]",
+	Short: "Run a command with the stored provider keys in its environment",
+	Long: `Run a command with the provider keys from the store added to its
+environment, for the docker compose commands agent does not run itself:
+
+  agent secrets run -- docker compose up -d --force-recreate ai_engine
+
+Keys already in the environment are kept. The command's exit code is
+returned.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := secrets.Store(); err != nil {
+			return contract.UsageError(err)
+		}
+		if err := secrets.Check(); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		c := exec.Command(args[0], args[1:]...)
+		c.Env = secrets.Environ()
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		err := c.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return contract.Exit(exitErr.ExitCode(), nil)
+		}
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		return nil
+	},
+}
+
+var secretsRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Write the stored keys to the file ai_engine's env_file loads",
+	Long: `Write the provider keys the store holds to a dotenv file readable only by
+its owner, by default /run/aava/secrets.env on tmpfs, for the env_file entry
+from agent secrets template compose. Run it before docker compose up, for
+example from a systemd unit, or let a Vault Agent render the same file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := secretsStore(); err != nil {
+			return err
+		}
+		n, err := secrets.Render(secretsRenderPath)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("✓ Rendered %d provider key(s) to %s.\n", n, secretsRenderPath)
+		return nil
+	},
+}
+
+var secretsTemplateCmd = &cobra.Command{
+	Use:   "template <compose|vault-agent>",
+	Short: "Print a template that gives ai_engine the stored keys at startup",
+	Long: `Print a template for loading the stored keys when ai_engine is created:
+
+  compose      a docker-compose.override.yml adding the rendered file to
+               ai_engine's env_file (docker compose 2.24+)
+  vault-agent  a Vault Agent template rendering the Vault entry to that file;
+               with a command stanza that recreates ai_engine, rotated keys
+               reach the engine without agent`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"compose", "vault-agent"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "compose":
+			fmt.Print(secrets.ComposeTemplate(secretsRenderPath))
+		case "vault-agent":
+			fmt.Print(secrets.VaultAgentTemplate())
+		default:
+			return contract.UsageError(fmt.Errorf("unknown template %q: compose or vault-agent", args[0]))
+		}
+		return nil
+	},
+}
+
+// secretsStore returns the configured store, refusing env, which has no
+// store to write to.
+func secretsStore() (string, error) {
+	store, err := secrets.Store()
+	if err != nil {
+		return "", contract.UsageError(err)
+	}
+	if store == secrets.StoreEnv {
+		return "", contract.UsageError(errors.New("keys are kept in .env: set secrets: in .agent/deployment.yaml (or AAVA_SECRETS) first; see agent secrets --help"))
+	}
+	return store, nil
+}
+
+func secretsDotEnv() map[string]string {
+	raw, _ := os.ReadFile(secrets.DotEnvPath())
+	return secrets.ParseDotEnv(raw)
+}
+
+// printSecretsComposeHint explains how stored keys reach ai_engine.
+func printSecretsComposeHint(names []string) {
+	fmt.Println("\nai_engine does not read the store. Either run agent secrets render and add the")
+	fmt.Println("output of agent secrets template compose to docker-compose.override.yml, or list")
+	fmt.Printf("%s under ai_engine environment: there. Then recreate it:\n", strings.Join(names, ", "))
+	fmt.Println("  agent secrets run -- docker compose up -d --force-recreate ai_engine")
+}
+
+func init() {
+	secretsStatusCmd.Flags().BoolVar(&secretsStatusJSON, "json", false, "output as JSON")
+	// Flags after the command name belong to the command, not to agent.
+	secretsRunCmd.Flags().SetInterspersed(false)
+
+	secretsRenderCmd.Flags().StringVar(&secretsRenderPath, "path", secrets.DefaultRenderPath, "file to write the keys to")
+	secretsTemplateCmd.Flags().StringVar(&secretsRenderPath, "path", secrets.DefaultRenderPath, "rendered file the template refers to")
+
+	secretsCmd.AddCommand(secretsStatusCmd, secretsSetCmd, secretsDeleteCmd, secretsMigrateCmd, secretsRunCmd, secretsRenderCmd, secretsTemplateCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/spf13/cobra"
+)
+
+// cliReleaseRepo publishes the agent binaries and their SHA256SUMS.
+const cliReleaseRepo = "hkjarral/AVA-AI-Voice-Agent-for-Asterisk"
+
+// releaseDownloadBase is the asset URL prefix; tests point it at a local server.
+var releaseDownloadBase = "https://github.com/" + cliReleaseRepo + "/releases/download"
+
+var (
+	selfUpdateVersion  string
+	selfUpdateCheck    bool
+	selfUpdateForce    bool
+	selfUpdateInsecure bool
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update the agent CLI binary",
+	Long: `Replace this agent binary with a release build for the current OS and
+architecture.
+
+The asset is checked against the release's SHA256SUMS, whose ed25519
+signature (SHA256SUMS.sig) must match the release signing key built into this
+binary; unsigned releases are refused unless --insecure. The new binary is run
+once ('version') before it replaces the installed binary. The replacement is a
+rename within the binary's directory, so an interrupted update leaves the old
+binary in place. The previous binary is kept as agent.bak.<timestamp>.
+
+This updates only the CLI; use 'agent update' to update the deployment.
+
+Examples:
+  agent self-update                    # latest release
+  agent self-update --check            # report whether an update exists
+  agent self-update --version v7.2.0   # pin (or roll back to) a release`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		binName, ok := releaseBinaryName(runtime.GOOS, runtime.GOARCH)
+		if !ok {
+			return contract.EnvironmentError(fmt.Errorf("no release binary for %s/%s", runtime.GOOS, runtime.GOARCH))
+		}
+
+		current := strings.TrimSpace(version)
+		target := normalizeReleaseTag(selfUpdateVersion)
+		if target == "" {
+			latest, err := fetchLatestReleaseTag(context.Background(), cliReleaseRepo)
+			if err != nil {
+				return contract.EnvironmentError(fmt.Errorf("could not look up the latest release: %w", err))
+			}
+			target = latest
+		} else if _, _, _, ok := parseSemver(target); !ok {
+			return contract.UsageError(fmt.Errorf("--version must look like v1.2.3 (got %q)", selfUpdateVersion))
+		}
+
+		pinned := selfUpdateVersion != ""
+		upToDate := strings.EqualFold(current, target) || (!pinned && compareSemver(current, target) >= 0 && isReleaseVersion(current))
+		if selfUpdateCheck {
+			if upToDate {
+				fmt.Printf("agent %s is up to date\n", current)
+				return nil
+			}
+			fmt.Printf("agent %s -> %s available; run: agent self-update\n", current, target)
+			return contract.Exit(contract.Warn, nil)
+		}
+		if upToDate && !selfUpdateForce {
+			fmt.Printf("agent %s is up to date (use --force to reinstall)\n", current)
+			return nil
+		}
+
+		exePath, err := os.Executable()
+		if err != nil {
+			return contract.EnvironmentError(fmt.Errorf("could not locate the running binary: %w", err))
+		}
+		if resolved, err := filepath.EvalSymlinks(exePath); err == nil && resolved != "" {
+			exePath = resolved
+		}
+		if err := ensureWritableDir(filepath.Dir(exePath)); err != nil {
+			return contract.EnvironmentError(fmt.Errorf("%s is not writable (re-run with sudo?): %w", filepath.Dir(exePath), err))
+		}
+
+		fmt.Printf("Downloading %s %s...\n", binName, target)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		payload, err := downloadReleaseBinary(ctx, target, binName, selfUpdateInsecure)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Checksum verified against SHA256SUMS")
+
+		if err := smokeTestBinary(payload, filepath.Dir(exePath)); err != nil {
+			return fmt.Errorf("downloaded binary failed to run, not installed: %w", err)
+		}
+		if err := installBinary(payload, exePath); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", exePath, err)
+		}
+		fmt.Printf("Updated %s: %s -> %s\n", exePath, current, target)
+		return nil
+	},
+}
+
+func releaseAssetURL(tag, name string) string {
+	return releaseDownloadBase + "/" + tag + "/" + name
+}
+
+// normalizeReleaseTag accepts "7.2.0" or "v7.2.0" and returns the release tag.
+func normalizeReleaseTag(v string) string {
+	v = strings.TrimLeft(strings.TrimSpace(v), "vV")
+	if v == "" {
+		return ""
+	}
+	return "v" + v
+}
+
+// isReleaseVersion reports whether v is a tagged build; dev builds always update.
+func isReleaseVersion(v string) bool {
+	_, _, _, ok := parseSemver(v)
+	return ok && strings.HasPrefix(strings.ToLower(v), "v")
+}
+
+// smokeTestBinary runs `<new binary> version` from a temp file next to the
+// install path before the swap.
+func smokeTestBinary(payload []byte, dir string) error {
+	f, err := os.CreateTemp(dir, ".agent.check.*")
+	if err != nil {
+		return err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if _, err := f.Write(payload); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		exe := path + ".exe"
+		if err := os.Rename(path, exe); err != nil {
+			return err
+		}
+		defer os.Remove(exe)
+		path = exe
+	}
+	if err := os.Chmod(path, 0o755); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	if !strings.Contains(string(out), "Version:") {
+		return errors.New("unexpected 'version' output")
+	}
+	return nil
+}
+
+func init() {
+	selfUpdateCmd.Flags().StringVar(&selfUpdateVersion, "version", "", "install this release tag instead of the latest (e.g. v7.2.0)")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheck, "check", false, "only report whether a newer release exists (exit 1 when it does)")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false, "reinstall even when already on the target version")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateInsecure, "insecure", false, "accept a release whose SHA256SUMS is not signed")
+	rootCmd.AddCommand(selfUpdateCmd)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func serveRelease(t *testing.T, tag string, assets map[string]string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := assets[strings.TrimPrefix(r.URL.Path, "/"+tag+"/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	prev := releaseDownloadBase
+	releaseDownloadBase = srv.URL
+	t.Cleanup(func() { releaseDownloadBase = prev })
+}
+
+// useSigningKey installs a fresh release signing key and returns its private half.
+func useSigningKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := releaseSigningKey
+	releaseSigningKey = base64.StdEncoding.EncodeToString(pub)
+	t.Cleanup(func() { releaseSigningKey = prev })
+	return priv
+}
+
+func TestDownloadReleaseBinaryVerifiesChecksum(t *testing.T) {
+	priv := useSigningKey(t)
+	payload := "new-binary"
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte(payload)))
+	sums := sum + "  agent-linux-amd64\n" + strings.Repeat("0", 64) + "  agent-linux-arm64\n"
+	serveRelease(t, "v7.2.0", map[string]string{
+		"SHA256SUMS":        sums,
+		"SHA256SUMS.sig":    base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(sums))),
+		"agent-linux-amd64": payload,
+		"agent-linux-arm64": payload,
+	})
+
+	got, err := downloadReleaseBinary(context.Background(), "v7.2.0", "agent-linux-amd64", false)
+	if err != nil || string(got) != payload {
+		t.Fatalf("download = %q, %v", got, err)
+	}
+	if _, err := downloadReleaseBinary(context.Background(), "v7.2.0", "agent-linux-arm64", false); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("tampered asset should fail the checksum, got %v", err)
+	}
+	if _, err := downloadReleaseBinary(context.Background(), "v7.2.0", "agent-darwin-arm64", false); err == nil {
+		t.Fatal("asset missing from SHA256SUMS should fail")
+	}
+}
+
+func TestDownloadReleaseBinaryRequiresSignature(t *testing.T) {
+	priv := useSigningKey(t)
+	payload := "new-binary"
+	sums := fmt.Sprintf("%x  agent-linux-amd64\n", sha256.Sum256([]byte(payload)))
+	serveRelease(t, "v7.2.0", map[string]string{"SHA256SUMS": sums, "agent-linux-amd64": payload})
+	if _, err := downloadReleaseBinary(context.Background(), "v7.2.0", "agent-linux-amd64", false); err == nil || !strings.Contains(err.Error(), "--insecure") {
+		t.Fatalf("unsigned release should be refused, got %v", err)
+	}
+	if _, err := downloadReleaseBinary(context.Background(), "v7.2.0", "agent-linux-amd64", true); err != nil {
+		t.Fatalf("--insecure should accept an unsigned release: %v", err)
+	}
+
+	forged := ed25519.Sign(priv, []byte("something else"))
+	serveRelease(t, "v7.2.1", map[string]string{"SHA256SUMS": sums, "SHA256SUMS.sig": string(forged), "agent-linux-amd64": payload})
+	if _, err := downloadReleaseBinary(context.Background(), "v7.2.1", "agent-linux-amd64", true); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("bad signature must fail even with --insecure, got %v", err)
+	}
+}
+
+func TestInstallBinaryReplacesAndKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "agent")
+	if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := installBinary([]byte("new"), exe); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "new" {
+		t.Fatalf("installed = %q", got)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "agent.bak.*"))
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v", backups)
+	}
+	if got, _ := os.ReadFile(backups[0]); string(got) != "old" {
+		t.Fatalf("backup = %q", got)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, ".agent.new.*")); len(leftovers) != 0 {
+		t.Fatalf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestNormalizeReleaseTag(t *testing.T) {
+	for in, want := range map[string]string{"": "", "7.2.0": "v7.2.0", "v7.2.0": "v7.2.0", " V7.1.0 ": "v7.1.0"} {
+		if got := normalizeReleaseTag(in); got != want {
+			t.Errorf("normalizeReleaseTag(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/schema"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+// apiTokenFile keeps the generated API token across restarts of agent serve.
+var apiTokenFile = filepath.Join(".agent", "api-token")
+
+var (
+	serveListen    string
+	serveTokenFile string
+	serveTLSCert   string
+	serveTLSKey    string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve check, rca, the call list and the quality trend over an HTTP API",
+	Long: `Serve the diagnostics over an authenticated HTTP API, so the Admin UI and
+external dashboards can run them without SSH access or parsing CLI output.
+
+Endpoints (JSON, the same documents as the commands' --json output):
+  GET /v1/health                 liveness and CLI version (no token needed)
+  GET /v1/check?profile=quick    agent check
+  GET /v1/calls?limit=20         the recent calls in the call index
+  GET /v1/calls/{id}/rca         agent rca for a call ID, or "last"
+                                 (?llm=auto|off|force, ?redact=all|phone,...)
+  GET /v1/trend?days=30          agent trend
+  GET /v1/schemas/{name}         the JSON Schema of the check or rca report
+
+Every other endpoint needs "Authorization: Bearer <token>". The token is
+AAVA_API_TOKEN, the contents of --token-file, or else one generated on first
+start and kept in .agent/api-token. Diagnostics run one at a time; further
+requests wait their turn.
+
+Serve over TLS with --tls-cert and --tls-key, or behind a TLS-terminating
+proxy, when listening beyond localhost.
+
+Examples:
+  agent serve
+  agent serve --listen :7070 --tls-cert server.crt --tls-key server.key
+  curl -H "Authorization: Bearer $(cat .agent/api-token)" localhost:7070/v1/check`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (serveTLSCert == "") != (serveTLSKey == "") {
+			return contract.UsageError(errors.New("--tls-cert and --tls-key go together"))
+		}
+		token, source, err := apiToken(serveTokenFile)
+		if err != nil {
+			return err
+		}
+		ln, err := net.Listen("tcp", serveListen)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+
+		api := newAPIServer(token)
+		srv := &http.Server{
+			Handler:           api.routes(),
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		}
+		scheme := "http"
+		if serveTLSCert != "" {
+			scheme = "https"
+		}
+		fmt.Fprintf(os.Stderr, "Serving the agent API on %s://%s (token from %s)\n", scheme, ln.Addr(), source)
+		if scheme == "http" && !loopbackAddr(ln.Addr()) {
+			fmt.Fprintln(os.Stderr, "⚠️  Listening beyond localhost without TLS: the token is sent in clear text. Use --tls-cert/--tls-key or a TLS proxy.")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		errc := make(chan error, 1)
+		go func() {
+			if serveTLSCert != "" {
+				errc <- srv.ServeTLS(ln, serveTLSCert, serveTLSKey)
+			} else {
+				errc <- srv.Serve(ln)
+			}
+		}()
+		select {
+		case err := <-errc:
+			return contract.EnvironmentError(err)
+		case <-ctx.Done():
+		}
+		fmt.Fprintln(os.Stderr, "Shutting down")
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdown)
+	},
+}
+
+// apiToken finds the bearer token and says where it came from. Without one,
+// a token is generated and kept for the next start.
+func apiToken(file string) (token, source string, err error) {
+	if t := strings.TrimSpace(os.Getenv("AAVA_API_TOKEN")); t != "" {
+		return t, "AAVA_API_TOKEN", nil
+	}
+	if file != "" {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return "", "", contract.UsageError(fmt.Errorf("--token-file: %w", err))
+		}
+		if t := strings.TrimSpace(string(raw)); t != "" {
+			return t, file, nil
+		}
+		return "", "", contract.UsageError(fmt.Errorf("--token-file %s is empty", file))
+	}
+	if raw, err := os.ReadFile(apiTokenFile); err == nil {
+		if t := strings.TrimSpace(string(raw)); t != "" {
+			return t, apiTokenFile, nil
+		}
+	}
+	if token, err = generateAPIToken(); err != nil {
+		return "", "", err
+	}
+	return token, apiTokenFile + ", generated", nil
+}
+
+// generateAPIToken makes a new token and keeps it in apiTokenFile.
+func generateAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", contract.EnvironmentError(err)
+	}
+	token := hex.EncodeToString(b)
+	if err := os.MkdirAll(filepath.Dir(apiTokenFile), 0o755); err != nil {
+		return "", contract.EnvironmentError(err)
+	}
+	if err := os.WriteFile(apiTokenFile, []byte(token+"\n"), 0o600); err != nil {
+		return "", contract.EnvironmentError(err)
+	}
+	return token, nil
+}
+
+func loopbackAddr(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// apiServer serves the diagnostics. The functions that run them are fields so
+// tests can stand in for docker and the logs.
+type apiServer struct {
+	token string
+	log   io.Writer // one line per request
+	// busy admits one diagnostic at a time: they share the .agent state and
+	// the engine's logs.
+	busy chan struct{}
+
+	check func(profile check.Profile) (*check.Report, error)
+	calls func(limit int, red *redact.Redactor) ([]troubleshoot.Call, error)
+	rca   func(callID, llm string, red *redact.Redactor) (*troubleshoot.RCAReport, error)
+	trend func(days int) (map[string]any, error)
+}
+
+func newAPIServer(token string) *apiServer {
+	return &apiServer{
+		token: token,
+		log:   os.Stderr,
+		busy:  make(chan struct{}, 1),
+		check: func(profile check.Profile) (*check.Report, error) {
+			plugins := plugin.Load()
+			report, err := runStandardCheck(profile, check.DefaultMaxCallAge, plugins)
+			runSinks(plugins, "check", report)
+			return report, err
+		},
+		calls: troubleshoot.RecentCalls,
+		rca:   apiAnalyzeCall,
+		trend: func(days int) (map[string]any, error) {
+			since := time.Now().AddDate(0, 0, -days)
+			samples, events, regressions, err := loadTrend(since)
+			if err != nil {
+				return nil, contract.EnvironmentError(err)
+			}
+			payload := trendPayload(days, since, samples, events, regressions)
+			payload["schema_version"] = contract.SchemaVersion
+			return payload, nil
+		},
+	}
+}
+
+// apiAnalyzeCall is agent rca for one call, without printing; plugins and
+// hooks run as they do for the command.
+func apiAnalyzeCall(callID, llm string, red *redact.Redactor) (*troubleshoot.RCAReport, error) {
+	runner := troubleshoot.NewRunner(callID, "", false, false, llm == "off", llm == "force", false, false, verbose)
+	runner.SetSilent(true)
+	runner.SetRedactor(red)
+	budgets, err := budget.Load(budget.Path())
+	if err != nil {
+		return nil, contract.UsageError(err)
+	}
+	runner.SetBudgets(budgets)
+	plugins := plugin.Load()
+	runner.SetPlugins(plugins, version)
+	if err := runner.Run(); err != nil {
+		return nil, err
+	}
+	rep := runner.Report()
+	if rep == nil {
+		return nil, contract.EnvironmentError(fmt.Errorf("no analysis for call %s", callID))
+	}
+	runSinks(plugins, "rca", rep)
+	runCallHooks(runner, rep)
+	return rep, nil
+}
+
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", s.health)
+	mux.Handle("GET /v1/check", s.authorized(s.diagnostic(s.runCheck)))
+	mux.Handle("GET /v1/calls", s.authorized(s.diagnostic(s.listCalls)))
+	mux.Handle("GET /v1/calls/{id}/rca", s.authorized(s.diagnostic(s.callRCA)))
+	mux.Handle("GET /v1/trend", s.authorized(http.HandlerFunc(s.qualityTrend)))
+	mux.Handle("GET /v1/schemas/{name}", s.authorized(http.HandlerFunc(s.reportSchema)))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusNotFound, errors.New("no such endpoint; see agent serve --help"))
+	})
+	return logRequests(s.log, mux)
+}
+
+func (s *apiServer) authorized(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="agent"`)
+			writeAPIError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// diagnostic waits for the running diagnostic to finish, or for the client to
+// give up.
+func (s *apiServer) diagnostic(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case s.busy <- struct{}{}:
+			defer func() { <-s.busy }()
+			next(w, r)
+		case <-r.Context().Done():
+		}
+	})
+}
+
+func (s *apiServer) health(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, map[string]any{
+		"schema_version": contract.SchemaVersion,
+		"status":         "ok",
+		"version":        version,
+	})
+}
+
+func (s *apiServer) runCheck(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("profile")
+	if name == "" {
+		name = check.DefaultProfile
+	}
+	profile, err := check.FindProfile(name)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	// The report says what failed, including checks that could not run, so it
+	// is the answer whatever the error.
+	report, _ := s.check(profile)
+	w.Header().Set("Content-Type", "application/json")
+	_ = report.Encode(w, output.JSON)
+}
+
+func (s *apiServer) listCalls(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", 20, 1, 500)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	red, err := queryRedactor(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	calls, err := s.calls(limit, red)
+	if err != nil {
+		writeAPIError(w, apiStatus(contract.EnvironmentError(err)), err)
+		return
+	}
+	if calls == nil {
+		calls = []troubleshoot.Call{}
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]any{
+		"schema_version": contract.SchemaVersion,
+		"calls":          calls,
+	})
+}
+
+func (s *apiServer) callRCA(w http.ResponseWriter, r *http.Request) {
+	llm := r.URL.Query().Get("llm")
+	switch llm {
+	case "":
+		llm = "auto"
+	case "auto", "off", "force":
+	default:
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("llm must be auto, off or force, not %q", llm))
+		return
+	}
+	red, err := queryRedactor(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	rep, err := s.rca(r.PathValue("id"), llm, red)
+	if err != nil {
+		writeAPIError(w, apiStatus(err), err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, rep)
+}
+
+func (s *apiServer) qualityTrend(w http.ResponseWriter, r *http.Request) {
+	days, err := queryInt(r, "days", 30, 1, 90)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	payload, err := s.trend(days)
+	if err != nil {
+		writeAPIError(w, apiStatus(err), err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, payload)
+}
+
+func (s *apiServer) reportSchema(w http.ResponseWriter, r *http.Request) {
+	rep, ok := schema.Lookup(r.PathValue("name"))
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no schema %q", r.PathValue("name")))
+		return
+	}
+	raw, err := rep.Published()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	_, _ = w.Write(raw)
+}
+
+func queryInt(r *http.Request, name string, def, min, max int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be a number from %d to %d", name, min, max)
+	}
+	return n, nil
+}
+
+// queryRedactor is ?redact=, as agent rca --redact takes it.
+func queryRedactor(r *http.Request) (*redact.Redactor, error) {
+	spec, ok := r.URL.Query()["redact"]
+	if !ok {
+		return nil, nil
+	}
+	red, err := redact.Parse(strings.Join(spec, ","))
+	if err != nil {
+		return nil, err
+	}
+	red.SetNER(redact.NERFromEnv())
+	return red, nil
+}
+
+// apiStatus maps a command's exit code to an HTTP status.
+func apiStatus(err error) int {
+	switch contract.CodeOf(err) {
+	case contract.Usage:
+		return http.StatusBadRequest
+	case contract.Environment:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]any{
+		"schema_version": contract.SchemaVersion,
+		"error":          err.Error(),
+	})
+}
+
+// statusRecorder keeps the status for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func logRequests(log io.Writer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		fmt.Fprintf(log, "%s %s %s %d %s\n", start.Format(time.RFC3339), r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:7070", "address to listen on, e.g. :7070 for every interface")
+	serveCmd.Flags().StringVar(&serveTokenFile, "token-file", "", "file holding the bearer token (default AAVA_API_TOKEN or .agent/api-token)")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "TLS certificate file")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "TLS private key file")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+func testAPIServer() *apiServer {
+	s := newAPIServer("s3cret")
+	s.log = io.Discard
+	s.check = func(p check.Profile) (*check.Report, error) {
+		return &check.Report{Profile: p.Name, Items: []check.Item{{Name: "Docker", Status: check.StatusFail}}}, errors.New("agent check failed")
+	}
+	s.calls = func(limit int, red *redact.Redactor) ([]troubleshoot.Call, error) {
+		c := troubleshoot.Call{ID: "1761518880.2191", CallerNumber: "+15551234567"}
+		red.Known(redact.Phone, c.CallerNumber)
+		red.Walk(&c)
+		return []troubleshoot.Call{c}, nil
+	}
+	s.rca = func(callID, llm string, red *redact.Redactor) (*troubleshoot.RCAReport, error) {
+		if callID == "missing" {
+			return nil, contract.EnvironmentError(errors.New("no logs found for call_id: missing"))
+		}
+		return &troubleshoot.RCAReport{CallID: callID, Symptom: llm}, nil
+	}
+	return s
+}
+
+func apiGet(t *testing.T, h http.Handler, path, token string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: %v in %s", path, err, rec.Body)
+	}
+	return rec.Code, body
+}
+
+func TestAPIServer(t *testing.T) {
+	h := testAPIServer().routes()
+
+	if code, body := apiGet(t, h, "/v1/health", ""); code != http.StatusOK || body["status"] != "ok" {
+		t.Errorf("health: %d %v", code, body)
+	}
+	for _, token := range []string{"", "wrong"} {
+		if code, _ := apiGet(t, h, "/v1/check", token); code != http.StatusUnauthorized {
+			t.Errorf("token %q: status %d, want 401", token, code)
+		}
+	}
+
+	// A failed check is still a report.
+	code, body := apiGet(t, h, "/v1/check?profile=quick", "s3cret")
+	if code != http.StatusOK || body["profile"] != "quick" || body["fail_count"] != float64(1) {
+		t.Errorf("check: %d %v", code, body)
+	}
+	if code, _ := apiGet(t, h, "/v1/check?profile=nope", "s3cret"); code != http.StatusBadRequest {
+		t.Errorf("unknown profile: status %d, want 400", code)
+	}
+
+	code, body = apiGet(t, h, "/v1/calls?redact=phone", "s3cret")
+	calls, _ := body["calls"].([]any)
+	if code != http.StatusOK || len(calls) != 1 || strings.Contains(mustJSON(t, calls), "5551234567") {
+		t.Errorf("calls: %d %v", code, body)
+	}
+	if code, _ := apiGet(t, h, "/v1/calls?limit=0", "s3cret"); code != http.StatusBadRequest {
+		t.Errorf("limit=0: status %d, want 400", code)
+	}
+
+	if code, body := apiGet(t, h, "/v1/calls/last/rca?llm=off", "s3cret"); code != http.StatusOK || body["call_id"] != "last" || body["symptom"] != "off" {
+		t.Errorf("rca: %d %v", code, body)
+	}
+	if code, body := apiGet(t, h, "/v1/calls/missing/rca", "s3cret"); code != http.StatusServiceUnavailable || body["error"] == nil {
+		t.Errorf("rca of a call without logs: %d %v", code, body)
+	}
+	if code, _ := apiGet(t, h, "/v1/calls/last/rca?llm=maybe", "s3cret"); code != http.StatusBadRequest {
+		t.Errorf("llm=maybe: status %d, want 400", code)
+	}
+	if code, _ := apiGet(t, h, "/v1/schemas/rca", "s3cret"); code != http.StatusOK {
+		t.Errorf("schema: status %d", code)
+	}
+	if code, _ := apiGet(t, h, "/v1/nope", "s3cret"); code != http.StatusNotFound {
+		t.Errorf("unknown endpoint: status %d, want 404", code)
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	dir := t.TempDir()
+	old := apiTokenFile
+	apiTokenFile = dir + "/api-token"
+	defer func() { apiTokenFile = old }()
+	t.Setenv("AAVA_API_TOKEN", "")
+
+	first, _, err := apiToken("")
+	if err != nil || len(first) != 64 {
+		t.Fatalf("generated token %q, %v", first, err)
+	}
+	if info, err := os.Stat(apiTokenFile); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("token file: %v, %v", info, err)
+	}
+	if again, _, _ := apiToken(""); again != first {
+		t.Error("the generated token should be kept across starts")
+	}
+	t.Setenv("AAVA_API_TOKEN", "from-env")
+	if tok, src, _ := apiToken(""); tok != "from-env" || src != "AAVA_API_TOKEN" {
+		t.Errorf("token %q from %s", tok, src)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/systemd"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serviceUser    string
+	servicePrint   bool
+	serviceNoStart bool
+	serviceListen  string
+)
+
+var installServiceCmd = &cobra.Command{
+	Use:   "install-service monitor|serve [-- agent flags]",
+	Short: "Install a hardened systemd unit for agent watch or agent serve",
+	Long: `Generate and install a systemd unit that keeps a long-running mode up:
+
+  monitor  agent watch --json: live call events from AMI, into the journal
+  serve    agent serve: the authenticated diagnostics API
+
+The unit runs in this checkout (agent reads .env, config/ and .agent there)
+as a dynamic user in the docker group, with no capabilities (serve on a port
+below 1024 gets CAP_NET_BIND_SERVICE only), a read-only system apart from
+.agent, and Restart=always. The docker group is given write access to .agent.
+--user runs the unit as an existing account instead; use it when the checkout
+or .env is not readable by other users, e.g. under /root.
+
+serve reads its token from .agent/api-token, generated when missing, as a
+systemd credential. Flags after -- are passed to the command.
+
+Installing needs root and systemd. --print writes the unit to stdout instead.
+
+Examples:
+  sudo agent install-service serve --listen :7070 -- --tls-cert /etc/aava/api.crt --tls-key /etc/aava/api.key
+  sudo agent install-service monitor -- --engine-poll 10s
+  agent install-service serve --print
+  journalctl -u aava-serve -f`,
+	ValidArgs: []string{"monitor", "serve"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modeArgs, extra := splitDashArgs(cmd, args)
+		if len(modeArgs) != 1 {
+			return contract.UsageError(errors.New("name one service: monitor or serve"))
+		}
+		mode, err := systemd.FindMode(modeArgs[0])
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if cmd.Flags().Changed("listen") && mode.Name != "serve" {
+			return contract.UsageError(errors.New("--listen applies to serve only"))
+		}
+		if !servicePrint {
+			if err := requireSystemd(); err != nil {
+				return err
+			}
+		}
+
+		spec, err := serviceSpec(mode, extra)
+		if err != nil {
+			return err
+		}
+		if servicePrint {
+			fmt.Print(systemd.Render(spec))
+			return nil
+		}
+
+		warned := prepareServiceDir(spec)
+		if spec.TokenFile != "" {
+			if raw, err := os.ReadFile(spec.TokenFile); err != nil || len(raw) == 0 {
+				if _, err := generateAPIToken(); err != nil {
+					return err
+				}
+				fmt.Printf("Generated the API token in %s\n", apiTokenFile)
+			}
+		}
+		path, err := systemd.Install(spec, !serviceNoStart)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		unit := systemd.UnitName(mode.Name)
+		fmt.Printf("✓ Installed %s\n", path)
+		if serviceNoStart {
+			fmt.Printf("  Enabled; start it with: sudo systemctl start %s\n", unit)
+		} else {
+			fmt.Printf("  Started; status: systemctl status %s, logs: journalctl -u %s -f\n", unit, unit)
+		}
+		if warned {
+			return contract.Exit(contract.Warn, nil)
+		}
+		return nil
+	},
+}
+
+var uninstallServiceCmd = &cobra.Command{
+	Use:   "uninstall-service monitor|serve",
+	Short: "Stop and remove a unit installed by agent install-service",
+	Long: `Stop, disable and remove the systemd unit agent install-service installed.
+The API token and the rest of .agent are kept.
+
+Examples:
+  sudo agent uninstall-service serve`,
+	ValidArgs: []string{"monitor", "serve"},
+	Args:      cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mode, err := systemd.FindMode(args[0])
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if err := requireSystemd(); err != nil {
+			return err
+		}
+		if err := systemd.Uninstall(mode.Name); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				fmt.Printf("%s is not installed\n", systemd.UnitName(mode.Name))
+				return nil
+			}
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("✓ Removed %s\n", systemd.UnitPath(mode.Name))
+		return nil
+	},
+}
+
+func init() {
+	installServiceCmd.Flags().StringVar(&serviceUser, "user", "", "run as this existing account instead of a dynamic user")
+	installServiceCmd.Flags().BoolVar(&servicePrint, "print", false, "print the unit instead of installing it")
+	installServiceCmd.Flags().BoolVar(&serviceNoStart, "no-start", false, "enable the unit without starting it")
+	installServiceCmd.Flags().StringVar(&serviceListen, "listen", "127.0.0.1:7070", "address agent serve listens on (serve only)")
+	rootCmd.AddCommand(installServiceCmd)
+	rootCmd.AddCommand(uninstallServiceCmd)
+}
+
+// splitDashArgs separates the arguments before -- from those after it.
+func splitDashArgs(cmd *cobra.Command, args []string) (before, after []string) {
+	if n := cmd.ArgsLenAtDash(); n >= 0 {
+		return args[:n], args[n:]
+	}
+	return args, nil
+}
+
+func requireSystemd() error {
+	if runtime.GOOS != "linux" {
+		return contract.EnvironmentError(errors.New("systemd services are only available on Linux"))
+	}
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return contract.EnvironmentError(errors.New("systemctl not found; this host does not run systemd"))
+	}
+	if os.Geteuid() != 0 {
+		return contract.EnvironmentError(errors.New("installing a systemd unit needs root; run it with sudo, or use --print"))
+	}
+	return nil
+}
+
+func serviceSpec(mode systemd.Mode, extra []string) (systemd.Spec, error) {
+	bin, err := os.Executable()
+	if err != nil {
+		return systemd.Spec{}, contract.EnvironmentError(err)
+	}
+	if resolved, err := filepath.EvalSymlinks(bin); err == nil {
+		bin = resolved
+	}
+	dir, err := findGitRootFromCWD()
+	if err != nil {
+		if dir, err = os.Getwd(); err != nil {
+			return systemd.Spec{}, contract.EnvironmentError(err)
+		}
+	}
+	spec := systemd.Spec{Mode: mode, Binary: bin, Dir: dir, User: serviceUser, Args: extra}
+	if serviceUser != "" {
+		if _, err := user.Lookup(serviceUser); err != nil {
+			return spec, contract.UsageError(fmt.Errorf("--user: %w", err))
+		}
+	} else if _, err := user.LookupGroup("docker"); err == nil {
+		spec.Groups = []string{"docker"}
+	}
+	if mode.Name == "serve" {
+		spec.Args = append([]string{"--listen", serviceListen}, extra...)
+		spec.TokenFile = filepath.Join(dir, apiTokenFile)
+		if _, port, err := net.SplitHostPort(serviceListen); err == nil {
+			if n, err := strconv.Atoi(port); err == nil && n > 0 && n < 1024 {
+				spec.BindLowPort = true
+			}
+		}
+	}
+	return spec, nil
+}
+
+// prepareServiceDir lets a dynamic user write .agent through the docker group
+// and warns about what it cannot read. It reports whether it warned.
+func prepareServiceDir(spec systemd.Spec) (warned bool) {
+	warn := func(format string, args ...any) {
+		fmt.Printf("⚠️  "+format+"\n", args...)
+		warned = true
+	}
+	stateDir := filepath.Join(spec.Dir, ".agent")
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		warn("cannot create %s: %v", stateDir, err)
+		return
+	}
+	if spec.User != "" {
+		return
+	}
+	if d := systemd.Unreachable(spec.Dir); d != "" {
+		warn("%s is closed to other users, so the dynamic user cannot reach the checkout; install with --user <account>", d)
+	}
+	if st, err := os.Stat(filepath.Join(spec.Dir, ".env")); err == nil && st.Mode().Perm()&0o044 == 0 {
+		warn(".env is readable by its owner only; chgrp docker .env && chmod 640 .env, or install with --user <account>")
+	}
+	g, err := user.LookupGroup("docker")
+	if err != nil {
+		warn("no docker group: the dynamic user can neither use Docker nor write %s; install with --user <account>", stateDir)
+		return
+	}
+	gid, _ := strconv.Atoi(g.Gid)
+	err = filepath.WalkDir(stateDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		st, err := os.Lstat(path)
+		if err != nil || st.Mode()&fs.ModeSymlink != 0 {
+			return err
+		}
+		if err := os.Chown(path, -1, gid); err != nil {
+			return err
+		}
+		mode := st.Mode().Perm() | 0o060
+		if d.IsDir() {
+			mode |= 0o010 | fs.ModeSetgid
+		}
+		return os.Chmod(path, mode)
+	})
+	if err != nil {
+		warn("cannot give the docker group write access to %s: %v", stateDir, err)
+	}
+	return
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
 	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/wizard"
 	"github.com/spf13/cobra"
 )
@@ -46,6 +47,7 @@ Notes:
 		if err := w.Run(); err != nil {
 			return err
 		}
+		recordTrendEvent(troubleshoot.EventConfig, "reconfigured with agent setup")
 
 		// Run agent check at the end as the standard post-setup validation.
 		runner := checkCmd.RunE
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/statecrypt"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stateStatusJSON   bool
+	stateKeygenRing   bool
+	stateKeygenForce  bool
+	stateDecryptForce bool
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Encrypt the local agent state that can hold caller data",
+	Long: `The CLI keeps state in .agent that can identify callers: the call index
+(.agent/calls.json, with caller numbers and names), the quality and check
+histories (.agent/quality/*.jsonl) and issue drafts (.agent/issues). On a
+shared PBX host, encrypt it at rest with a key of your own.
+
+Encryption is on while a key is available, from AAVA_STATE_KEY or the OS
+keyring (the Secret Service through secret-tool on Linux, the login
+keychain on macOS). New and updated state is then written with AES-256-GCM;
+state written before stays readable until agent state encrypt converts it.
+Without the key, encrypted state cannot be read, and the CLI will not write
+plain data over it: keep the key where every account and cron job that runs
+agent can reach it, but not in the project's .env next to the data.
+
+  agent state keygen            # print a new key for AAVA_STATE_KEY
+  agent state keygen --keyring  # or keep it in the OS keyring
+  agent state encrypt           # encrypt the state written so far
+  agent state status
+
+To change keys, set the old key in AAVA_STATE_OLD_KEY and the new one in
+AAVA_STATE_KEY, and run agent state encrypt. Packet captures and Asterisk
+recordings are not encrypted; agent purge deletes them.`,
+}
+
+var stateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the key in use and which state files are encrypted",
+	Long: `Show where the state key comes from and, for each state file, how many of
+its records are encrypted. Exits 1 when some state is in plain text while a
+key is set, or encrypted while none is.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, source, err := statecrypt.Key()
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		files, err := stateInspect()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		sealed, plain := 0, 0
+		for _, f := range files {
+			sealed += f.Sealed
+			plain += f.Plain
+		}
+		if format := structuredOutput(stateStatusJSON); format.Structured() {
+			if err := output.Write(os.Stdout, format, map[string]any{
+				"schema_version": contract.SchemaVersion,
+				"encryption":     key != nil,
+				"key_source":     source,
+				"files":          files,
+			}); err != nil {
+				return err
+			}
+		} else {
+			if key != nil {
+				fmt.Printf("Encryption: on (key from %s)\n", source)
+			} else {
+				fmt.Println("Encryption: off (no AAVA_STATE_KEY and no key in the OS keyring)")
+			}
+			if len(files) == 0 {
+				fmt.Println("No state files yet.")
+			}
+			for _, f := range files {
+				fmt.Printf("  %-40s %s\n", f.Path, stateFileSummary(f))
+			}
+			switch {
+			case key != nil && plain > 0:
+				fmt.Println("\nSome state is in plain text; agent state encrypt converts it.")
+			case key == nil && sealed > 0:
+				fmt.Println("\nSome state is encrypted, but no key is available to read it.")
+			}
+		}
+		if (key != nil && plain > 0) || (key == nil && sealed > 0) {
+			return contract.Exit(contract.Warn, nil)
+		}
+		return nil
+	},
+}
+
+var stateKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Make a new state key",
+	Long: `Print a new random key for AAVA_STATE_KEY, or with --keyring store it in
+the OS keyring instead. A key already in the keyring is only replaced with
+--force; decrypt the state first, or it can no longer be read.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := statecrypt.NewKey()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		if !stateKeygenRing {
+			fmt.Println(key)
+			fmt.Fprintln(os.Stderr, "Set it as AAVA_STATE_KEY for every account and job that runs agent, and keep a copy: encrypted state cannot be read without it.")
+			return nil
+		}
+		if _, source, _ := statecrypt.Key(); source == statecrypt.SourceKeyring && !stateKeygenForce {
+			return contract.UsageError(errors.New("the OS keyring already holds a state key; use --force to replace it"))
+		}
+		if err := statecrypt.StoreKey(key); err != nil {
+			return contract.EnvironmentError(fmt.Errorf("store the key: %w", err))
+		}
+		fmt.Println("✓ State key stored in the OS keyring. Keep a copy elsewhere; encrypted state cannot be read without it:")
+		fmt.Println(key)
+		return nil
+	},
+}
+
+var stateEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the state files with the current key",
+	Long: `Rewrite every state file encrypted with the current key. Records already
+encrypted with AAVA_STATE_OLD_KEY are re-encrypted, for changing keys.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, _, err := statecrypt.Key()
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if key == nil {
+			return contract.UsageError(errors.New("no state key: set AAVA_STATE_KEY or run agent state keygen --keyring"))
+		}
+		from := key
+		if v := strings.TrimSpace(os.Getenv("AAVA_STATE_OLD_KEY")); v != "" {
+			if from, err = statecrypt.ParseKey(v); err != nil {
+				return contract.UsageError(fmt.Errorf("AAVA_STATE_OLD_KEY: %w", err))
+			}
+		}
+		return stateConvert(from, key, "Encrypted")
+	},
+}
+
+var stateDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Write the state files back in plain text",
+	Long: `Rewrite every state file in plain text, before removing the key. Needs
+--force, since the state then holds caller data unprotected.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !stateDecryptForce {
+			return contract.UsageError(errors.New("this writes caller data in plain text; use --force to confirm"))
+		}
+		key, _, err := statecrypt.Key()
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if key == nil {
+			return contract.UsageError(statecrypt.ErrNoKey)
+		}
+		return stateConvert(key, nil, "Decrypted")
+	},
+}
+
+var stateCatCmd = &cobra.Command{
+	Use:   "cat <file>",
+	Short: "Print a state file decrypted",
+	Long: `Print a state file with its encrypted records decrypted, for example an
+issue draft agent rca --open-issue saved in .agent/issues.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, _, err := statecrypt.Key()
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		data, err := statecrypt.Decrypt(args[0], key)
+		if err != nil {
+			if errors.Is(err, statecrypt.ErrNoKey) || errors.Is(err, statecrypt.ErrKey) {
+				return contract.UsageError(err)
+			}
+			return contract.EnvironmentError(err)
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	},
+}
+
+// stateFiles lists the state files that can hold caller data.
+func stateFiles() []string {
+	var files []string
+	if _, err := os.Stat(troubleshoot.CallIndexPath()); err == nil {
+		files = append(files, troubleshoot.CallIndexPath())
+	}
+	histories, _ := filepath.Glob(filepath.Join(troubleshoot.TrendDir(), "*.jsonl"))
+	drafts, _ := filepath.Glob(filepath.Join(issueDraftDir, "*.md"))
+	sort.Strings(histories)
+	sort.Strings(drafts)
+	return append(append(files, histories...), drafts...)
+}
+
+func stateInspect() ([]statecrypt.Status, error) {
+	out := []statecrypt.Status{}
+	for _, path := range stateFiles() {
+		st, err := statecrypt.Inspect(path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func stateFileSummary(s statecrypt.Status) string {
+	switch {
+	case s.Sealed == 0 && s.Plain == 0:
+		return "empty"
+	case s.Plain == 0:
+		return "encrypted"
+	case s.Sealed == 0:
+		return "plain text"
+	}
+	return fmt.Sprintf("%d encrypted, %d plain text record(s)", s.Sealed, s.Plain)
+}
+
+// stateConvert rewrites every state file from one key to another (nil for
+// plain text) and reports how many changed.
+func stateConvert(from, to []byte, verb string) error {
+	changed := 0
+	for _, path := range stateFiles() {
+		ok, err := statecrypt.Convert(path, from, to)
+		if err != nil {
+			if errors.Is(err, statecrypt.ErrKey) {
+				return contract.UsageError(err)
+			}
+			return contract.EnvironmentError(err)
+		}
+		if ok {
+			changed++
+			if verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG] %s %s\n", verb, path)
+			}
+		}
+	}
+	fmt.Printf("✓ %s %d state file(s).\n", verb, changed)
+	return nil
+}
+
+func init() {
+	stateStatusCmd.Flags().BoolVar(&stateStatusJSON, "json", false, "output as JSON")
+	stateKeygenCmd.Flags().BoolVar(&stateKeygenRing, "keyring", false, "store the key in the OS keyring instead of printing it")
+	stateKeygenCmd.Flags().BoolVar(&stateKeygenForce, "force", false, "replace a key already in the OS keyring")
+	stateDecryptCmd.Flags().BoolVar(&stateDecryptForce, "force", false, "confirm writing the state in plain text")
+
+	stateCmd.AddCommand(stateStatusCmd, stateKeygenCmd, stateEncryptCmd, stateDecryptCmd, stateCatCmd)
+	rootCmd.AddCommand(stateCmd)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	telemetryOnEndpoint  string
+	telemetryPreviewJSON bool
+	telemetryStatusJSON  bool
+)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Opt in to or out of anonymous usage statistics",
+	Long: `Anonymous usage statistics help prioritize development: the CLI version,
+agent check pass/warn/fail counts and which built-in known issues agent rca
+matched. Logs, transcripts, call IDs, phone numbers, names, addresses and
+config are never recorded.
+
+Telemetry is off until agent telemetry on. Until then the statistics are only
+gathered in .agent/telemetry.json, so agent telemetry preview can show exactly
+what would be sent. agent telemetry off stops gathering and sending and drops
+what was gathered; DO_NOT_TRACK=1 or AAVA_TELEMETRY=off does the same for one
+process.`,
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether statistics are gathered and sent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := telemetry.Load()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		status := telemetryStatus(s)
+		if format := structuredOutput(telemetryStatusJSON); format.Structured() {
+			return output.Write(os.Stdout, format, map[string]any{
+				"schema_version": contract.SchemaVersion,
+				"status":         status,
+				"setting":        s.Setting,
+				"sending":        s.Enabled(),
+				"recording":      s.Recording(),
+				"endpoint":       s.EndpointURL(),
+				"last_sent":      s.LastSent,
+				"file":           telemetry.Path(),
+			})
+		}
+		fmt.Printf("Telemetry: %s\n", status)
+		if s.Recording() && !s.Since.IsZero() {
+			fmt.Printf("Gathered since %s: %d check run(s), %d analyzed call(s)\n", s.Since.Local().Format("2006-01-02"), s.CheckRuns, s.RCARuns)
+		}
+		if s.LastSent != nil {
+			fmt.Printf("Last sent: %s\n", s.LastSent.Local().Format("2006-01-02 15:04"))
+		}
+		if s.Setting == telemetry.Undecided {
+			fmt.Println("\nSee what would be sent with agent telemetry preview; opt in with agent telemetry on.")
+		}
+		return nil
+	},
+}
+
+var telemetryPreviewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Show exactly what would be sent",
+	Long: `Print the report the next send would post, byte for byte as JSON. The
+statistics are those gathered since the last send.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := telemetry.Load()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		report := s.Report(version, time.Now())
+		if format := structuredOutput(telemetryPreviewJSON); format.Structured() {
+			return output.Write(os.Stdout, format, report)
+		}
+		fmt.Printf("Telemetry: %s\n", telemetryStatus(s))
+		if s.Enabled() && s.EndpointURL() != "" {
+			fmt.Printf("Sent to %s at most once every %s:\n\n", s.EndpointURL(), telemetry.Interval)
+		} else {
+			fmt.Print("Would be sent once telemetry is on:\n\n")
+		}
+		raw, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(raw))
+		return nil
+	},
+}
+
+var telemetryOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Opt in to sending anonymous usage statistics",
+	Long: `Opt in to sending the statistics agent telemetry preview shows, at most once a
+day after a command. Reports go to --endpoint, or AAVA_TELEMETRY_URL; nothing
+is sent without one.
+
+Examples:
+  agent telemetry on --endpoint https://telemetry.example.com/v1/report`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if telemetryOnEndpoint != "" {
+			u, err := url.Parse(telemetryOnEndpoint)
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return contract.UsageError(fmt.Errorf("--endpoint must be an http(s) URL"))
+			}
+		}
+		s, err := telemetry.Load()
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+		if err := s.TurnOn(telemetryOnEndpoint); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		if err := s.Save(); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Println("✓ Telemetry on. Thank you!")
+		if env := telemetry.EnvOff(); env != "" {
+			fmt.Printf("  %s is set, so nothing is gathered or sent while it is.\n", env)
+		} else if s.EndpointURL() == "" {
+			fmt.Println("  No endpoint is set (--endpoint or AAVA_TELEMETRY_URL), so nothing is sent yet.")
+		}
+		fmt.Println("  agent telemetry preview shows what is sent; agent telemetry off opts out.")
+		return nil
+	},
+}
+
+var telemetryOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Stop gathering and sending usage statistics",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := telemetry.Load()
+		if err != nil {
+			// A corrupt file must not keep telemetry on; off overwrites it.
+			s = &telemetry.State{}
+		}
+		s.TurnOff()
+		if err := s.Save(); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Println("✓ Telemetry off. Nothing is gathered or sent, and gathered statistics were dropped.")
+		return nil
+	},
+}
+
+// telemetryStatus describes the setting in a few words.
+func telemetryStatus(s *telemetry.State) string {
+	if env := telemetry.EnvOff(); env != "" {
+		return "off (" + env + " is set)"
+	}
+	switch s.Setting {
+	case telemetry.On:
+		if s.EndpointURL() == "" {
+			return "on, no endpoint set"
+		}
+		return "on, sending to " + s.EndpointURL()
+	case telemetry.Off:
+		return "off"
+	}
+	return "not enabled (gathered locally only)"
+}
+
+func init() {
+	telemetryOnCmd.Flags().StringVar(&telemetryOnEndpoint, "endpoint", "", "URL reports are posted to (default $AAVA_TELEMETRY_URL)")
+	telemetryPreviewCmd.Flags().BoolVar(&telemetryPreviewJSON, "json", false, "output only the report, as JSON")
+	telemetryStatusCmd.Flags().BoolVar(&telemetryStatusJSON, "json", false, "output as JSON")
+
+	telemetryCmd.AddCommand(telemetryStatusCmd, telemetryPreviewCmd, telemetryOnCmd, telemetryOffCmd)
+	rootCmd.AddCommand(telemetryCmd)
+}
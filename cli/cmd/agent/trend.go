@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/backup"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trendDays int
+	trendCSV  bool
+	trendJSON bool
+)
+
+var trendCmd = &cobra.Command{
+	Use:   "trend",
+	Short: "Call quality over time, with regressions after updates and config changes",
+	Long: `Show how call quality moved over recent days: the quality score, audio drift
+and underflow rate of every call analyzed by agent rca or agent troubleshoot.
+
+Each metric is drawn as a sparkline of daily averages. Around each event
+(updates, config changes, Asterisk restarts and "agent annotate" notes),
+calls in the 7 days before it are compared with calls after it. A metric
+that is significantly worse afterwards (one-sided Mann-Whitney test,
+p < 0.05, at least 5 calls each side) is reported as a regression and the
+command exits with the warning code.
+
+History is kept for 90 days in .agent/quality/.
+
+Examples:
+  agent trend
+  agent trend --days 14
+  agent trend --csv > quality.csv`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if trendDays < 1 || trendDays > 90 {
+			return contract.UsageError(errors.New("--days must be between 1 and 90"))
+		}
+		if trendCSV && trendJSON {
+			return contract.UsageError(errors.New("--csv cannot be combined with --json"))
+		}
+		now := time.Now()
+		since := now.AddDate(0, 0, -trendDays)
+		if trendCSV {
+			samples, err := troubleshoot.LoadQualitySamples(since.Add(-troubleshoot.RegressionWindow))
+			if err != nil {
+				return contract.EnvironmentError(err)
+			}
+			return writeTrendCSV(samples, since)
+		}
+		samples, events, regressions, err := loadTrend(since)
+		if err != nil {
+			return contract.EnvironmentError(err)
+		}
+
+		format := structuredOutput(trendJSON)
+		if format.Structured() {
+			if err := output.Write(os.Stdout, format, trendPayload(trendDays, since, samples, events, regressions)); err != nil {
+				return err
+			}
+		} else {
+			printTrend(samples, events, regressions, now)
+		}
+		if len(regressions) > 0 {
+			return contract.Exit(contract.Warn, nil)
+		}
+		return nil
+	},
+}
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate <text>",
+	Short: "Record a deployment event for agent trend",
+	Long: `Record a change that agent trend should compare call quality against, such
+as a provider switch, a network change or a dialplan edit.
+
+Updates, agent setup, agent config preset, agent backup restore and Asterisk
+restarts seen by agent check are recorded automatically.
+
+Examples:
+  agent annotate "switched to Deepgram"
+  agent annotate "moved PBX to new VLAN"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text := strings.TrimSpace(args[0])
+		if text == "" {
+			return contract.UsageError(errors.New("annotation is empty"))
+		}
+		now := time.Now()
+		if err := troubleshoot.RecordTrendEvent(troubleshoot.TrendEvent{At: now, Kind: troubleshoot.EventNote, Label: text}); err != nil {
+			return contract.EnvironmentError(err)
+		}
+		fmt.Printf("Recorded %q at %s\n", text, now.Format("2006-01-02 15:04"))
+		return nil
+	},
+}
+
+// loadTrend reads the quality samples and events from since (and the
+// comparison window before it) and finds the regressions around the events.
+func loadTrend(since time.Time) ([]troubleshoot.QualitySample, []troubleshoot.TrendEvent, []troubleshoot.Regression, error) {
+	samples, err := troubleshoot.LoadQualitySamples(since.Add(-troubleshoot.RegressionWindow))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	events, err := trendEvents(since)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return samples, events, troubleshoot.DetectRegressions(samples, events), nil
+}
+
+// trendPayload is agent trend --json, also served by agent serve.
+func trendPayload(days int, since time.Time, samples []troubleshoot.QualitySample, events []troubleshoot.TrendEvent, regressions []troubleshoot.Regression) map[string]any {
+	var shown []troubleshoot.QualitySample
+	for _, s := range samples {
+		if !s.At.Before(since) {
+			shown = append(shown, s)
+		}
+	}
+	return map[string]any{
+		"days":        days,
+		"calls":       shown,
+		"events":      events,
+		"regressions": regressions,
+	}
+}
+
+// recordTrendEvent records an automatic event; failures only matter with --verbose.
+func recordTrendEvent(kind, label string) {
+	err := troubleshoot.RecordTrendEvent(troubleshoot.TrendEvent{At: time.Now(), Kind: kind, Label: label})
+	if err != nil && verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Could not record %s event: %v\n", kind, err)
+	}
+}
+
+// trendEvents gathers recorded events since since. Update snapshots and the last
+// edit of each config file add the changes made before events were recorded, or
+// by hand; one close to a recorded event of the same kind is the same change.
+func trendEvents(since time.Time) ([]troubleshoot.TrendEvent, error) {
+	recorded, err := troubleshoot.LoadTrendEvents(since)
+	if err != nil {
+		return nil, err
+	}
+	root, err := resolveRepoRootForFix()
+	if err != nil {
+		return nil, err
+	}
+	events := append([]troubleshoot.TrendEvent{}, recorded...)
+	snaps, _ := backup.List(root)
+	for _, s := range snaps {
+		if s.Created.After(since) && !nearTrendEvent(recorded, troubleshoot.EventUpdate, s.Created, time.Hour) {
+			events = append(events, troubleshoot.TrendEvent{At: s.Created, Kind: troubleshoot.EventUpdate, Label: "update (backup " + s.ID + ")"})
+		}
+	}
+	for _, rel := range []string{".env", filepath.Join("config", "ai-agent.yaml"), filepath.Join("config", "ai-agent.local.yaml")} {
+		st, err := os.Stat(filepath.Join(root, rel))
+		if err != nil || !st.ModTime().After(since) || nearTrendEvent(recorded, "", st.ModTime(), 10*time.Minute) {
+			continue
+		}
+		events = append(events, troubleshoot.TrendEvent{At: st.ModTime(), Kind: troubleshoot.EventConfig, Label: filepath.ToSlash(rel) + " edited"})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	return events, nil
+}
+
+// nearTrendEvent reports a recorded event of kind (any kind when empty) within d of at.
+func nearTrendEvent(events []troubleshoot.TrendEvent, kind string, at time.Time, d time.Duration) bool {
+	for _, e := range events {
+		if (kind == "" || e.Kind == kind) && e.At.Sub(at).Abs() <= d {
+			return true
+		}
+	}
+	return false
+}
+
+func printTrend(samples []troubleshoot.QualitySample, events []troubleshoot.TrendEvent, regressions []troubleshoot.Regression, now time.Time) {
+	y, m, d := now.Date()
+	first := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(trendDays - 1))
+	n := 0
+	for _, s := range samples {
+		if !s.At.Before(first) {
+			n++
+		}
+	}
+	fmt.Printf("Call quality, %s to %s (%d analyzed call(s))\n", first.Format("2006-01-02"), now.Format("2006-01-02"), n)
+	if n == 0 {
+		fmt.Println("No analyzed calls yet. Quality is recorded each time agent rca analyzes a call.")
+		return
+	}
+	for _, metric := range troubleshoot.TrendMetrics {
+		daily := troubleshoot.DailyMeans(samples, metric, trendDays, now)
+		fmt.Printf("  %-10s %s  %s\n", metric, troubleshoot.Sparkline(daily), trendRange(daily, metric))
+	}
+
+	marks := []rune(strings.Repeat(" ", trendDays))
+	var shown []troubleshoot.TrendEvent
+	for _, ev := range events {
+		if i := troubleshoot.DayIndex(first, ev.At); i >= 0 && i < trendDays {
+			marks[i] = '^'
+			shown = append(shown, ev)
+		}
+	}
+	if len(shown) > 0 {
+		fmt.Printf("  %-10s %s\n", "events", strings.TrimRight(string(marks), " "))
+		for _, ev := range shown {
+			fmt.Printf("    %s  %s\n", ev.At.Format("2006-01-02 15:04"), ev.Label)
+		}
+	}
+
+	fmt.Println()
+	if len(regressions) == 0 {
+		fmt.Println("No significant regressions after recorded events.")
+		return
+	}
+	fmt.Println("Regressions:")
+	for _, r := range regressions {
+		fmt.Printf("  %s worse after %s (%s): median %s -> %s (%d calls before, %d after, p=%.3f)\n",
+			r.Metric, r.Event.Label, r.Event.At.Format("2006-01-02 15:04"),
+			trendValue(r.Before, r.Metric), trendValue(r.After, r.Metric), r.NBefore, r.NAfter, r.P)
+	}
+}
+
+// trendRange summarizes the days that had calls.
+func trendRange(daily []float64, metric string) string {
+	lo, hi, last := math.Inf(1), math.Inf(-1), math.NaN()
+	for _, v := range daily {
+		if !math.IsNaN(v) {
+			lo, hi, last = math.Min(lo, v), math.Max(hi, v), v
+		}
+	}
+	if math.IsNaN(last) {
+		return "no data"
+	}
+	return fmt.Sprintf("min %s  max %s  last %s", trendValue(lo, metric), trendValue(hi, metric), trendValue(last, metric))
+}
+
+func trendValue(v float64, metric string) string {
+	if metric == "score" {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f%%", v)
+}
+
+func writeTrendCSV(samples []troubleshoot.QualitySample, since time.Time) error {
+	w := csv.NewWriter(os.Stdout)
+	_ = w.Write([]string{"call_id", "time", "score", "drift_pct", "underflow_rate_pct", "transport"})
+	for _, s := range samples {
+		if s.At.Before(since) {
+			continue
+		}
+		drift := ""
+		if s.DriftPct != nil {
+			drift = strconv.FormatFloat(*s.DriftPct, 'f', 2, 64)
+		}
+		_ = w.Write([]string{
+			s.CallID,
+			s.At.Format(time.RFC3339),
+			strconv.FormatFloat(s.Score, 'f', 1, 64),
+			drift,
+			strconv.FormatFloat(s.Underflow, 'f', 3, 64),
+			s.Transport,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func init() {
+	trendCmd.Flags().IntVar(&trendDays, "days", 30, "how many days to show (1-90)")
+	trendCmd.Flags().BoolVar(&trendCSV, "csv", false, "print one row per analyzed call as CSV")
+	trendCmd.Flags().BoolVar(&trendJSON, "json", false, "output as JSON")
+	rootCmd.AddCommand(trendCmd, annotateCmd)
+}
@@ -1,8 +1,9 @@
 package main
 
 import (
-	"os"
-
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
 	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
 	"github.com/spf13/cobra"
 )
@@ -37,6 +38,9 @@ Symptoms:
   echo            Agent hears itself
   interruption    Self-interruption loop
   one-way         Only one direction works
+  dtmf            Keypad input ignored
+  call-drop       Call drops mid-conversation
+  voicemail       Agent talked to an answering machine
 
 Requirements:
   - Docker container 'ai_engine' must be running
@@ -56,6 +60,11 @@ Features:
 			troubleshootCallID = "last"
 		}
 
+		budgets, err := budget.Load(budget.Path())
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		format := structuredOutput(troubleshootJSON)
 		runner := troubleshoot.NewRunner(
 			troubleshootCallID,
 			troubleshootSymptom,
@@ -64,12 +73,21 @@ Features:
 			troubleshootNoLLM,
 			troubleshootForceLLM,
 			troubleshootList,
-			troubleshootJSON,
+			format.Structured(),
 			verbose,
 		)
-		err := runner.Run()
-		if troubleshootJSON && err != nil {
-			os.Exit(1)
+		runner.SetFormat(format)
+		runner.SetProfile(outputProfile)
+		runner.SetBudgets(budgets)
+		plugins := plugin.Load()
+		runner.SetPlugins(plugins, version)
+		err = runner.Run()
+		if rep := runner.Report(); rep != nil {
+			runSinks(plugins, "rca", rep)
+			runCallHooks(runner, rep)
+		}
+		if format.Structured() && err != nil {
+			return contract.Exit(contract.CodeOf(err), nil)
 		}
 		return err
 	},
@@ -79,12 +97,14 @@ func init() {
 	troubleshootCmd.Flags().StringVarP(&troubleshootCallID, "call", "c", "", "analyze specific call ID")
 	troubleshootCmd.Flags().BoolVarP(&troubleshootList, "list", "l", false, "list recent calls")
 	troubleshootCmd.Flags().Bool("last", false, "analyze most recent call")
-	troubleshootCmd.Flags().StringVarP(&troubleshootSymptom, "symptom", "s", "", "symptom: no-audio|garbled|echo|interruption|one-way")
+	troubleshootCmd.Flags().StringVarP(&troubleshootSymptom, "symptom", "s", "", "symptom: no-audio|garbled|echo|interruption|one-way|dtmf|call-drop|voicemail")
 	troubleshootCmd.Flags().BoolVarP(&troubleshootInteractive, "interactive", "i", false, "interactive mode")
 	troubleshootCmd.Flags().BoolVar(&troubleshootCollectOnly, "collect-only", false, "only collect logs, no analysis")
 	troubleshootCmd.Flags().BoolVar(&troubleshootNoLLM, "no-llm", false, "skip LLM analysis")
 	troubleshootCmd.Flags().BoolVar(&troubleshootForceLLM, "llm", false, "force LLM analysis (even for healthy calls)")
 	troubleshootCmd.Flags().BoolVar(&troubleshootJSON, "json", false, "output as JSON (JSON only)")
+	_ = troubleshootCmd.RegisterFlagCompletionFunc("call", completeCallIDs)
+	_ = troubleshootCmd.RegisterFlagCompletionFunc("symptom", completeSymptoms)
 
 	rootCmd.AddCommand(troubleshootCmd)
 }
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/locale"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/tts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ttsPipeline string
+	ttsLanguage string
+	ttsVoice    string
+	ttsText     string
+	ttsOut      string
+	ttsTimeout  time.Duration
+	ttsJSON     bool
+)
+
+var ttsCmd = &cobra.Command{
+	Use:   "tts",
+	Short: "List TTS voices and preview them in the call's audio format",
+	Long: `List the voices a pipeline's TTS provider offers and hear one before
+putting it in the config.
+
+--pipeline names a pipeline or a *_tts provider (default: active_pipeline,
+else default_provider). Full agent providers choose their voice inside their
+own session and are not supported.
+
+Examples:
+  agent tts voices
+  agent tts voices --pipeline hybrid_elevenlabs --language es
+  agent tts preview --voice en-US-AriaNeural --text "Thanks for calling Acme"
+  agent tts preview --voice nova --out sample.wav`,
+}
+
+var ttsVoicesCmd = &cobra.Command{
+	Use:   "voices",
+	Short: "List the voices of the configured TTS provider",
+	Long: `List the voices of the TTS provider, with their language. The configured
+voice is marked with *.
+
+ElevenLabs, Azure, Google, Deepgram and CAMB AI voices come from the provider's
+API, with the key from .env. OpenAI and Groq voices are built in. Local AI
+server voices are read from models/ and are chosen with KOKORO_VOICE or
+LOCAL_TTS_MODEL_PATH rather than per pipeline.`,
+	Args: cobra.NoArgs,
+	RunE: runTTSVoices,
+}
+
+var ttsPreviewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Synthesize a sample with the engine's TTS adapter",
+	Long: `Synthesize --text with the pipeline's TTS adapter inside ai_engine, with
+the engine's config, options and credentials and --voice in place of the
+configured voice. The sample is in the exact encoding and sample rate the
+engine streams to callers (8 kHz μ-law on most pipelines).
+
+The sample is played on this host (aplay, paplay, afplay, ffplay or sox), or
+written with --out: as WAV for a .wav name, else as raw audio such as the
+.ulaw files Asterisk plays.`,
+	Args: cobra.NoArgs,
+	RunE: runTTSPreview,
+}
+
+func runTTSVoices(cmd *cobra.Command, args []string) error {
+	troubleshoot.LoadEnvFile()
+	target, err := ttsTarget()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ttsTimeout)
+	defer cancel()
+	voices, err := tts.Voices(ctx, target, chaosEnv, "models")
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	if ttsLanguage != "" {
+		if locale.Base(ttsLanguage) == "" {
+			return contract.UsageError(fmt.Errorf("unknown language %q", ttsLanguage))
+		}
+		kept := voices[:0]
+		for _, v := range voices {
+			if v.Speaks(ttsLanguage) {
+				kept = append(kept, v)
+			}
+		}
+		voices = kept
+	}
+
+	format := structuredOutput(ttsJSON)
+	if format.Structured() {
+		return output.Write(os.Stdout, format, map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"target":         target,
+			"voices":         voices,
+		})
+	}
+	fmt.Printf("Voices for %s", target.Label())
+	if target.Voice != "" {
+		fmt.Printf(" (configured: %s)", target.Voice)
+	}
+	fmt.Println()
+	if len(voices) == 0 {
+		fmt.Println("  none found")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "  \tVOICE\tNAME\tLANGUAGE\tGENDER\tNOTES")
+	for _, v := range voices {
+		mark := ""
+		if v.ID == target.Voice {
+			mark = "*"
+		}
+		lang := emptyDash(v.Locale)
+		if v.Locale == "" && v.Language != "" {
+			lang = locale.Name(v.Language)
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\n", mark, v.ID, emptyDash(v.Name), lang, emptyDash(v.Gender), emptyDash(v.Notes))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if target.VoiceKey != "" {
+		fmt.Printf("\nHear one with: agent tts preview --voice <VOICE>; set it as %s.\n", ttsVoiceSetting(target))
+	}
+	return nil
+}
+
+func runTTSPreview(cmd *cobra.Command, args []string) error {
+	if strings.TrimSpace(ttsText) == "" {
+		return contract.UsageError(errors.New("--text is empty"))
+	}
+	troubleshoot.LoadEnvFile()
+	target, err := ttsTarget()
+	if err != nil {
+		return err
+	}
+	format := structuredOutput(ttsJSON)
+	if format.Structured() && ttsOut == "" {
+		return contract.UsageError(errors.New("--json needs --out for the audio"))
+	}
+	progress := os.Stdout
+	if format.Structured() {
+		progress = os.Stderr
+	}
+	voice := ttsVoice
+	if voice == "" {
+		voice = target.Voice
+	}
+	fmt.Fprintf(progress, "Synthesizing with %s, voice %s...\n", target.Label(), emptyDash(voice))
+
+	ctx, cancel := context.WithTimeout(context.Background(), ttsTimeout)
+	defer cancel()
+	clip, err := tts.Synthesize(ctx, target, ttsVoice, ttsText)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	fmt.Fprintf(progress, "✓ %.1fs of %s at %d Hz\n", clip.Seconds(), clip.Encoding, clip.SampleRate)
+
+	path := ttsOut
+	if path == "" {
+		f, err := os.CreateTemp("", "agent-tts-*.wav")
+		if err != nil {
+			return err
+		}
+		path = f.Name()
+		f.Close()
+		defer os.Remove(path)
+	}
+	if err := writeTTSClip(path, clip); err != nil {
+		return err
+	}
+
+	if format.Structured() {
+		return output.Write(os.Stdout, format, map[string]any{
+			"schema_version": contract.SchemaVersion,
+			"target":         target,
+			"voice":          voice,
+			"clip":           clip,
+			"seconds":        clip.Seconds(),
+			"file":           path,
+		})
+	}
+	if ttsOut != "" {
+		fmt.Printf("✓ Wrote %s\n", path)
+		return nil
+	}
+	player, err := tts.Player(path)
+	if err != nil {
+		return contract.EnvironmentError(err)
+	}
+	player.Stdout, player.Stderr = os.Stdout, os.Stderr
+	if err := player.Run(); err != nil {
+		return contract.EnvironmentError(fmt.Errorf("%s: %w", filepath.Base(player.Path), err))
+	}
+	if target.VoiceKey != "" && ttsVoice != "" && ttsVoice != target.Voice {
+		fmt.Printf("To use it, set %s to %s\n", ttsVoiceSetting(target), ttsVoice)
+	}
+	return nil
+}
+
+// ttsTarget resolves --pipeline against the merged config.
+func ttsTarget() (tts.Target, error) {
+	cfg, err := failoverConfig()
+	if err != nil {
+		return tts.Target{}, err
+	}
+	target, err := tts.Resolve(cfg, ttsPipeline, chaosEnv)
+	if err != nil {
+		return target, contract.UsageError(err)
+	}
+	return target, nil
+}
+
+// ttsVoiceSetting names the config key a chosen voice goes in.
+func ttsVoiceSetting(t tts.Target) string {
+	if t.Pipeline != "" {
+		return fmt.Sprintf("pipelines.%s.options.tts.%s", t.Pipeline, t.VoiceKey)
+	}
+	return fmt.Sprintf("providers.%s.%s", t.Provider, t.VoiceKey)
+}
+
+// writeTTSClip writes the clip as WAV for a .wav path, else as raw audio.
+func writeTTSClip(path string, clip *tts.Clip) error {
+	if !strings.EqualFold(filepath.Ext(path), ".wav") {
+		return os.WriteFile(path, clip.Audio, 0o644)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := clip.WriteWAV(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func init() {
+	ttsCmd.PersistentFlags().StringVar(&ttsPipeline, "pipeline", "", "pipeline or *_tts provider (default: active_pipeline, else default_provider)")
+	ttsCmd.PersistentFlags().DurationVar(&ttsTimeout, "timeout", 60*time.Second, "how long to wait for the provider")
+	ttsCmd.PersistentFlags().BoolVar(&ttsJSON, "json", false, "output as JSON")
+	ttsVoicesCmd.Flags().StringVar(&ttsLanguage, "language", "", "only voices that speak this language (es, pt-BR, German...)")
+	ttsPreviewCmd.Flags().StringVar(&ttsVoice, "voice", "", "voice to preview (default: the configured voice)")
+	ttsPreviewCmd.Flags().StringVar(&ttsText, "text", "Hello! Thanks for calling. How can I help you today?", "text to speak")
+	ttsPreviewCmd.Flags().StringVarP(&ttsOut, "out", "o", "", "write the sample to this file (.wav, else raw) instead of playing it")
+	ttsCmd.AddCommand(ttsVoicesCmd, ttsPreviewCmd)
+	rootCmd.AddCommand(ttsCmd)
+}
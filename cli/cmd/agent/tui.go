@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/tui"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+var tuiRefresh time.Duration
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Live dashboard of containers, calls, call quality and the engine log",
+	Long: `Show the deployment on one screen:
+
+  Containers    state, health and restarts of ai_engine, local_ai_server and admin_ui
+  Active calls  the engine's sessions and the conversation stage each is in
+                (greeting, listening, processing)
+  Recent calls  the latest calls from the call index with their quality score
+  Engine log    a live tail of the ai_engine log
+
+Select a call with ↑/↓ (tab switches between the active and recent lists)
+and press Enter to analyze it as agent rca does. Plugins analyze the call
+too, but sinks and hooks are not run from the dashboard; use agent rca for
+that and for the full report. q quits.
+
+Examples:
+  agent tui
+  agent tui --refresh 5s
+  agent tui --host ssh://ops@pbx1`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if tuiRefresh <= 0 {
+			return contract.UsageError(fmt.Errorf("--refresh must be positive"))
+		}
+		if !stdinIsTerminal() {
+			return contract.UsageError(errors.New("agent tui needs a terminal; use agent watch or agent rca --list in scripts"))
+		}
+		troubleshoot.LoadEnvFile()
+		if color.NoColor {
+			lipgloss.SetColorProfile(termenv.Ascii)
+		}
+		plugins := plugin.Load()
+		sessions := engineapi.New()
+		m := tui.New(tui.Sources{
+			Containers: tuiContainers,
+			Sessions: func(ctx context.Context) ([]engineapi.Session, error) {
+				s, err := sessions.Sessions(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return s.Sessions, nil
+			},
+			Calls: func() ([]troubleshoot.Call, error) { return troubleshoot.RecentCalls(20, nil) },
+			Analyze: func(callID string) (tui.RCA, error) {
+				return tuiAnalyzeCall(callID, plugins)
+			},
+		}, tuiRefresh)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
+		go tailEngineLog(ctx, p)
+		if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+			return err
+		}
+		return nil
+	},
+}
+
+// tuiContainers inspects the stack's containers, in the order agent check
+// lists them. Optional containers that do not exist are left out.
+func tuiContainers() []tui.Container {
+	var out []tui.Container
+	for _, name := range []string{deployment.EngineContainer(), deployment.LocalAIContainer(), deployment.AdminUIContainer()} {
+		raw, err := dockerapi.Inspect(name)
+		if err != nil {
+			if name == deployment.EngineContainer() {
+				out = append(out, tui.Container{Name: name, Err: errors.New("container not found")})
+			}
+			continue
+		}
+		var arr []struct {
+			RestartCount int `json:"RestartCount"`
+			State        struct {
+				Status string `json:"Status"`
+				Health *struct {
+					Status string `json:"Status"`
+				} `json:"Health"`
+			} `json:"State"`
+		}
+		if err := json.Unmarshal(raw, &arr); err != nil || len(arr) == 0 {
+			out = append(out, tui.Container{Name: name, Err: errors.New("inspect output not understood")})
+			continue
+		}
+		c := tui.Container{Name: name, Status: arr[0].State.Status, Restarts: arr[0].RestartCount}
+		if h := arr[0].State.Health; h != nil {
+			c.Health = h.Status
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// tuiAnalyzeCall is agent rca for one call, without printing. The dashboard
+// only shows the result, so sinks and hooks are left to agent rca.
+func tuiAnalyzeCall(callID string, plugins []plugin.Plugin) (tui.RCA, error) {
+	runner := troubleshoot.NewRunner(callID, "", false, false, false, false, false, false, false)
+	runner.SetSilent(true)
+	runner.SetPlugins(plugins, version)
+	if err := runner.Run(); err != nil {
+		return tui.RCA{}, err
+	}
+	rep := runner.Report()
+	if rep == nil {
+		return tui.RCA{}, fmt.Errorf("no analysis for call %s", callID)
+	}
+	return tui.RCA{Report: rep, Result: rcaResult(runner.ExitCode())}, nil
+}
+
+// tailEngineLog follows the engine log, as agent logs -f does, and sends the
+// lines to the dashboard until ctx ends.
+func tailEngineLog(ctx context.Context, p *tea.Program) {
+	r, w := io.Pipe()
+	go func() {
+		err := troubleshoot.StreamLogs(troubleshoot.LogOptions{Since: "10m", Tail: 200, Follow: true}, w)
+		if err == nil {
+			err = errors.New("log stream ended")
+		}
+		w.CloseWithError(err)
+	}()
+	go func() {
+		<-ctx.Done()
+		r.Close()
+	}()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		p.Send(tui.LogLine(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		p.Send(tui.LogLine("(" + err.Error() + ")"))
+	}
+}
+
+func init() {
+	tuiCmd.Flags().DurationVar(&tuiRefresh, "refresh", 2*time.Second, "how often containers and active calls are refreshed (recent calls every 5 refreshes)")
+	rootCmd.AddCommand(tuiCmd)
+}
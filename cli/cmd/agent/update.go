@@ -23,6 +23,13 @@ import (
 
 	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
 	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
 	"github.com/spf13/cobra"
 )
 
@@ -58,6 +65,12 @@ var (
 	updateBackupID       string
 	updatePlan           bool
 	updatePlanJSON       bool
+	updateInsecure       bool
+	updateYes            bool
+	updateRebaseLocal    bool
+	updateSchedule       string
+	updateForce          bool
+	updateDrainTimeout   time.Duration
 	gitSafeDirectory     string
 )
 
@@ -81,9 +94,19 @@ var updateCmd = &cobra.Command{
 	Long: `Update Asterisk AI Voice Agent to the latest code and apply changes safely.
 
 This command:
-  - Backs up operator config (.env, config/ai-agent.local.yaml, config/users.json, config/contexts/)
+  - Backs up operator config (.env, config/ai-agent.local.yaml, config/users.json, config/contexts/,
+    config/overlays/)
   - Takes consistent SQLite snapshots of agents.db and call_history.db when present
   - Also snapshots config/ai-agent.yaml for recovery/migration if it was edited locally
+  - Refuses a target commit (or tag) without a valid GPG/SSH signature unless --insecure
+  - Shows the new CHANGELOG entries and migration notes and asks to continue (skip with --yes)
+  - Runs .agent/hooks/pre-update before changing anything (a failing hook aborts) and
+    .agent/hooks/post-update with the outcome (see agent hooks)
+  - With --schedule (or maintenance_window in .agent/deployment.yaml), waits for the
+    maintenance window and for active calls to finish before changing anything
+  - Refuses to restart ai_engine while calls are active (engine stats or ARI) unless --force
+  - Drains ai_engine before restarting it: new calls go back to the dialplan and
+    active calls get up to --drain-timeout to finish
   - Safely fast-forwards to origin/main (no forced merges by default)
   - Preserves local tracked changes using git stash (optional)
   - Rebuilds/restarts only the containers impacted by the change set
@@ -93,12 +116,31 @@ Safety notes:
   - If you edited config/ai-agent.yaml directly, updates can conflict. This updater automatically migrates
     those edits into config/ai-agent.local.yaml and resets config/ai-agent.yaml back to upstream defaults.
   - No hard resets are performed.
-  - Fast-forward only: if your branch has diverged, the update stops with guidance.`,
+  - Fast-forward only: if your branch has local commits the target lacks, the update lists them
+    and stops, unless --rebase-local reapplies them on top of the target.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if deployment.Current().Remote() {
+			return runRemoteUpdate()
+		}
 		return runUpdate()
 	},
 }
 
+// runRemoteUpdate runs agent update on the remote host over ssh: git, backups and
+// compose builds need the checkout itself, which only exists on that machine.
+func runRemoteUpdate() error {
+	args := deployment.StripHostFlag(os.Args[1:])
+	cmd, err := deployment.Current().SSHCommand(args)
+	if err != nil {
+		return fmt.Errorf("agent update against %s: %w", deployment.Current().DockerHost, err)
+	}
+	printUpdateInfo("Running on %s: %s", deployment.Current().DockerHost, strings.Join(cmd.Args, " "))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 func init() {
 	updateCmd.Flags().StringVar(&updateRemote, "remote", "origin", "git remote name")
 	updateCmd.Flags().StringVar(&updateRef, "ref", "main", "git ref to update to (branch like main, or tag like v6.2.0)")
@@ -114,6 +156,12 @@ func init() {
 	updateCmd.Flags().StringVar(&updateBackupID, "backup-id", "", "use a stable backup identifier (creates .agent/update-backups/<id>)")
 	updateCmd.Flags().BoolVar(&updatePlan, "plan", false, "print the update plan (git/diff/docker actions) without applying it")
 	updateCmd.Flags().BoolVar(&updatePlanJSON, "plan-json", false, "when used with --plan, output the plan as JSON")
+	updateCmd.Flags().StringVar(&updateSchedule, "schedule", "", "wait for a maintenance window before updating, e.g. \"Sat 02:00\" or \"Mon-Fri 01:00-03:00\" (\"now\" ignores a configured window)")
+	updateCmd.Flags().BoolVar(&updateForce, "force", false, "restart containers even while calls are active")
+	updateCmd.Flags().DurationVar(&updateDrainTimeout, "drain-timeout", 5*time.Minute, "before restarting ai_engine, stop new calls and wait this long for active ones (0 disables)")
+	updateCmd.Flags().BoolVarP(&updateYes, "yes", "y", false, "do not ask for confirmation after showing the release notes")
+	updateCmd.Flags().BoolVar(&updateRebaseLocal, "rebase-local", false, "reapply local commits on top of the target instead of stopping when the branch has diverged")
+	updateCmd.Flags().BoolVar(&updateInsecure, "insecure", false, "accept an unsigned (or unknown-key) update target commit/tag and unsigned CLI release checksums")
 	rootCmd.AddCommand(updateCmd)
 }
 
@@ -132,40 +180,49 @@ type updateContext struct {
 	composeChanged    bool
 
 	skippedServices map[string]string // service -> "rebuild"|"restart" (filtered by flags)
+
+	// applying is set once the pre-update hook let the update proceed; from
+	// then on the post-update hook reports the outcome.
+	applying             bool
+	checkWarn, checkFail int
 }
 
 type updatePlanReport struct {
-	RepoRoot         string            `json:"repo_root"`
-	Remote           string            `json:"remote"`
-	Ref              string            `json:"ref"`
-	CurrentBranch    string            `json:"current_branch"`
-	TargetBranch     string            `json:"target_branch"`
-	Checkout         bool              `json:"checkout"`
-	WouldCheckout    bool              `json:"would_checkout"`
-	OldSHA           string            `json:"old_sha"`
-	NewSHA           string            `json:"new_sha"`
-	Relation         string            `json:"relation"` // equal|behind|ahead|diverged
-	CodeChanged      bool              `json:"code_changed"`
-	UpdateAvailable  bool              `json:"update_available"`
-	Dirty            bool              `json:"dirty"`
-	NoStash          bool              `json:"no_stash"`
-	StashUntracked   bool              `json:"stash_untracked"`
-	LocalChanges     string            `json:"local_changes"`
-	WouldStash       bool              `json:"would_stash"`
-	WouldOverwrite   bool              `json:"would_overwrite"`
-	WouldAbort       bool              `json:"would_abort"`
-	RebuildMode      string            `json:"rebuild_mode"`
-	ComposeChanged   bool              `json:"compose_changed"`
-	ServicesRebuild  []string          `json:"services_rebuild"`
-	ServicesRestart  []string          `json:"services_restart"`
-	SkippedServices  map[string]string `json:"skipped_services,omitempty"`
-	ChangedFileCount int               `json:"changed_file_count"`
-	ChangedFiles     []string          `json:"changed_files,omitempty"`
-	FilesTruncated   bool              `json:"changed_files_truncated,omitempty"`
-	LocalFileCount   int               `json:"local_file_count"`
-	LocalFiles       []string          `json:"local_files,omitempty"`
-	LocalFilesTrunc  bool              `json:"local_files_truncated,omitempty"`
-	Warnings         []string          `json:"warnings,omitempty"`
+	SchemaVersion    int                 `json:"schema_version"`
+	RepoRoot         string              `json:"repo_root"`
+	Remote           string              `json:"remote"`
+	Ref              string              `json:"ref"`
+	CurrentBranch    string              `json:"current_branch"`
+	TargetBranch     string              `json:"target_branch"`
+	Checkout         bool                `json:"checkout"`
+	WouldCheckout    bool                `json:"would_checkout"`
+	OldSHA           string              `json:"old_sha"`
+	NewSHA           string              `json:"new_sha"`
+	Relation         string              `json:"relation"` // equal|behind|ahead|diverged
+	CodeChanged      bool                `json:"code_changed"`
+	UpdateAvailable  bool                `json:"update_available"`
+	Dirty            bool                `json:"dirty"`
+	NoStash          bool                `json:"no_stash"`
+	StashUntracked   bool                `json:"stash_untracked"`
+	LocalChanges     string              `json:"local_changes"`
+	WouldStash       bool                `json:"would_stash"`
+	WouldOverwrite   bool                `json:"would_overwrite"`
+	WouldAbort       bool                `json:"would_abort"`
+	RebuildMode      string              `json:"rebuild_mode"`
+	ComposeChanged   bool                `json:"compose_changed"`
+	ServicesRebuild  []string            `json:"services_rebuild"`
+	ServicesRestart  []string            `json:"services_restart"`
+	SkippedServices  map[string]string   `json:"skipped_services,omitempty"`
+	ChangedFileCount int                 `json:"changed_file_count"`
+	ChangedFiles     []string            `json:"changed_files,omitempty"`
+	FilesTruncated   bool                `json:"changed_files_truncated,omitempty"`
+	LocalFileCount   int                 `json:"local_file_count"`
+	LocalFiles       []string            `json:"local_files,omitempty"`
+	LocalFilesTrunc  bool                `json:"local_files_truncated,omitempty"`
+	LocalCommits     []string            `json:"local_commits,omitempty"`
+	WouldRebase      bool                `json:"would_rebase,omitempty"`
+	ReleaseNotes     *updateReleaseNotes `json:"release_notes,omitempty"`
+	Warnings         []string            `json:"warnings,omitempty"`
 }
 
 func runUpdate() (retErr error) {
@@ -176,10 +233,10 @@ func runUpdate() (retErr error) {
 
 	repoRoot, err := gitShowTopLevel()
 	if err != nil {
-		return err
+		return contract.EnvironmentError(err)
 	}
 	if err := os.Chdir(repoRoot); err != nil {
-		return fmt.Errorf("failed to chdir to repo root: %w", err)
+		return contract.EnvironmentError(fmt.Errorf("failed to chdir to repo root: %w", err))
 	}
 
 	ctx := &updateContext{
@@ -190,10 +247,16 @@ func runUpdate() (retErr error) {
 	}
 
 	defer func() {
-		if retErr != nil && !updatePlan {
+		if retErr != nil && !updatePlan && !errors.Is(retErr, errUpdateCancelled) {
 			printUpdateFailureRecovery(ctx, retErr)
 		}
 	}()
+	// Runs before the recovery advice above, which should be printed last.
+	defer func() {
+		if ctx.applying {
+			runPostUpdateHook(ctx, retErr)
+		}
+	}()
 
 	ctx.oldSHA, err = gitRevParse("HEAD")
 	if err != nil {
@@ -205,12 +268,22 @@ func runUpdate() (retErr error) {
 		return runUpdatePlan(ctx)
 	}
 
+	window, err := updateMaintenanceWindow()
+	if err != nil {
+		return err
+	}
+
 	releaseLock, err := acquireUpdateLock(ctx.repoRoot)
 	if err != nil {
 		return err
 	}
 	defer releaseLock()
 
+	if window != nil {
+		printUpdateStep("Waiting for maintenance window")
+		waitForMaintenanceWindow(window)
+	}
+
 	printUpdateStep("Creating backups")
 	if err := createUpdateBackups(ctx); err != nil {
 		return err
@@ -242,6 +315,15 @@ func runUpdate() (retErr error) {
 		return err
 	}
 	ctx.newSHA = targetSHA
+	if strings.TrimSpace(targetSHA) != strings.TrimSpace(ctx.oldSHA) {
+		sigErr := verifyGitSignature(targetRev, isTag)
+		if err := enforceSignature("update target "+targetLabel, sigErr, updateInsecure); err != nil {
+			return err
+		}
+		if sigErr == nil {
+			printUpdateInfo("Signature verified: %s (%s)", targetLabel, shortSHA(targetSHA))
+		}
+	}
 
 	currentBranch, _ := gitCurrentBranch()
 	branchMismatch := false
@@ -261,6 +343,9 @@ func runUpdate() (retErr error) {
 				return existsErr
 			}
 			if exists {
+				if other := gitBranchWorktree(updateRef, ctx.repoRoot); other != "" {
+					return fmt.Errorf("branch %q is checked out in another worktree (%s); update that worktree instead, or check out %q there first", updateRef, other, updateRef)
+				}
 				checkoutExistingBranch = true
 				branchHead, err = gitRevParse(updateRef)
 				if err != nil {
@@ -282,20 +367,43 @@ func runUpdate() (retErr error) {
 	}
 
 	finalSHA := branchHead
+	rebaseLocal := false
 	if strings.TrimSpace(branchHead) == strings.TrimSpace(targetSHA) {
 		printUpdateInfo("Already up to date on %s (%s)", updateRef, shortSHA(branchHead))
 		finalSHA = branchHead
 	} else if updateAvailable {
 		finalSHA = targetSHA
-	} else if remoteIsAncestor {
-		printUpdateInfo("Local branch is ahead of %s; skipping fast-forward update", targetLabel)
-		finalSHA = branchHead
 	} else {
-		return fmt.Errorf("cannot fast-forward: local branch has diverged from %s (resolve manually and re-run)", targetLabel)
+		localCommits, err := gitLocalCommits(targetSHA, branchHead)
+		if err != nil {
+			return err
+		}
+		switch {
+		case remoteIsAncestor:
+			printUpdateInfo("Local branch is ahead of %s by %d commit(s); skipping fast-forward update", targetLabel, len(localCommits))
+			printLocalCommits(localCommits)
+			finalSHA = branchHead
+		case !updateRebaseLocal:
+			return divergedError(targetLabel, localCommits)
+		default:
+			printUpdateInfo("Local branch has %d commit(s) that %s lacks; they will be reapplied on top of it:", len(localCommits), targetLabel)
+			printLocalCommits(localCommits)
+			rebaseLocal = true
+			finalSHA = targetSHA
+		}
 	}
 	ctx.newSHA = finalSHA
 
 	if strings.TrimSpace(ctx.oldSHA) != strings.TrimSpace(ctx.newSHA) {
+		printUpdateStep(fmt.Sprintf("Release notes %s..%s", shortSHA(ctx.oldSHA), shortSHA(ctx.newSHA)))
+		notes := loadReleaseNotes(ctx.oldSHA, ctx.newSHA)
+		printReleaseNotes(notes)
+		if err := confirmReleaseNotes(notes); err != nil {
+			return contract.Exit(contract.Fail, err)
+		}
+
+		// With --rebase-local this also includes the files the local commits touch,
+		// which only makes the rebuild decision more conservative.
 		ctx.changedFiles, err = gitDiffNames(ctx.oldSHA, ctx.newSHA)
 		if err != nil {
 			return err
@@ -307,6 +415,11 @@ func runUpdate() (retErr error) {
 		}
 	}
 
+	if err := runPreUpdateHook(ctx); err != nil {
+		return err
+	}
+	ctx.applying = true
+
 	printUpdateStep("Checking working tree")
 	localFiles, err := gitDirtyFiles(updateStashUntracked)
 	if err != nil {
@@ -360,6 +473,20 @@ func runUpdate() (retErr error) {
 			return err
 		}
 	}
+	if rebaseLocal {
+		printUpdateStep("Reapplying local commits")
+		rebaseOnto := targetRemoteRef
+		if isTag {
+			rebaseOnto = updateRef
+		}
+		if err := gitRebaseLocal(rebaseOnto); err != nil {
+			return err
+		}
+		if ctx.newSHA, err = gitRevParse("HEAD"); err != nil {
+			return err
+		}
+		printUpdateInfo("Local commits reapplied on top of %s (now %s)", targetLabel, shortSHA(ctx.newSHA))
+	}
 
 	if restoreOperatorConfigAfterMerge {
 		printUpdateStep("Restoring operator config")
@@ -390,6 +517,9 @@ func runUpdate() (retErr error) {
 	if err := applyDockerActions(ctx); err != nil {
 		return err
 	}
+	if strings.TrimSpace(ctx.oldSHA) != strings.TrimSpace(ctx.newSHA) {
+		recordTrendEvent(troubleshoot.EventUpdate, fmt.Sprintf("update applied %s..%s", shortSHA(ctx.oldSHA), shortSHA(ctx.newSHA)))
+	}
 
 	if updateSkipCheck {
 		printUpdateSummary(ctx, "", 0, 0)
@@ -398,6 +528,7 @@ func runUpdate() (retErr error) {
 
 	printUpdateStep("Running agent check")
 	report, status, warnCount, failCount, err := runPostUpdateCheckWithRetry(60*time.Second, 5*time.Second)
+	ctx.checkWarn, ctx.checkFail = warnCount, failCount
 	printPostUpdateCheck(report, warnCount, failCount)
 	printUpdateSummary(ctx, status, warnCount, failCount)
 	if err != nil {
@@ -406,6 +537,9 @@ func runUpdate() (retErr error) {
 	if failCount > 0 {
 		return errors.New("post-update check reported failures")
 	}
+	if warnCount > 0 {
+		return contract.Exit(contract.Warn, nil)
+	}
 	return nil
 }
 
@@ -483,6 +617,7 @@ func runUpdatePlan(ctx *updateContext) error {
 	// Git treats a commit as its own ancestor, so when SHAs match `gitIsAncestor(old,new)` is true.
 	// For plan/reporting, treat identical SHAs as "no update available".
 	updateAvailable = updateAvailable && codeChanged
+	var notes *updateReleaseNotes
 	if codeChanged {
 		ctx.changedFiles, err = gitDiffNames(ctx.oldSHA, ctx.newSHA)
 		if err != nil {
@@ -490,6 +625,7 @@ func runUpdatePlan(ctx *updateContext) error {
 		}
 		decideDockerActions(ctx)
 		applyServiceFilters(ctx)
+		notes = loadReleaseNotes(ctx.oldSHA, ctx.newSHA)
 	} else {
 		ctx.changedFiles = nil
 	}
@@ -499,6 +635,7 @@ func runUpdatePlan(ctx *updateContext) error {
 	wouldAbort := dirty && localPolicy == localChangesAbort
 
 	relation := "equal"
+	var localCommits []string
 	if codeChanged {
 		switch {
 		case updateAvailable:
@@ -508,6 +645,11 @@ func runUpdatePlan(ctx *updateContext) error {
 		default:
 			relation = "diverged"
 		}
+		if !updateAvailable {
+			if localCommits, err = gitLocalCommits(ctx.newSHA, ctx.oldSHA); err != nil {
+				return err
+			}
+		}
 	}
 
 	limit := 200
@@ -555,6 +697,11 @@ func runUpdatePlan(ctx *updateContext) error {
 		LocalFileCount:   len(localFiles),
 		LocalFiles:       localPreview,
 		LocalFilesTrunc:  localTruncated,
+		LocalCommits:     localCommits,
+		WouldRebase:      relation == "diverged" && updateRebaseLocal,
+	}
+	if !notes.empty() {
+		rep.ReleaseNotes = notes
 	}
 	if len(ctx.skippedServices) > 0 {
 		rep.SkippedServices = ctx.skippedServices
@@ -572,13 +719,16 @@ func runUpdatePlan(ctx *updateContext) error {
 		rep.Warnings = append(rep.Warnings, fmt.Sprintf("Local branch is ahead of %s/%s; no fast-forward update available.", updateRemote, updateRef))
 	}
 	if !updateAvailable && !remoteIsAncestor && strings.TrimSpace(ctx.newSHA) != strings.TrimSpace(ctx.oldSHA) {
-		rep.Warnings = append(rep.Warnings, fmt.Sprintf("Local branch has diverged from %s/%s; update requires manual resolution.", updateRemote, updateRef))
+		if updateRebaseLocal {
+			rep.Warnings = append(rep.Warnings, fmt.Sprintf("Local branch has %d commit(s) that %s/%s lacks; --rebase-local will reapply them on top of it.", len(localCommits), updateRemote, updateRef))
+		} else {
+			rep.Warnings = append(rep.Warnings, fmt.Sprintf("Local branch has %d commit(s) that %s/%s lacks; re-run with --rebase-local to reapply them on top of it.", len(localCommits), updateRemote, updateRef))
+		}
 	}
 
-	if updatePlanJSON {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		return enc.Encode(rep)
+	if f := structuredOutput(updatePlanJSON); f.Structured() {
+		rep.SchemaVersion = contract.SchemaVersion
+		return output.Write(os.Stdout, f, rep)
 	}
 
 	printUpdateStep("Update plan")
@@ -590,7 +740,15 @@ func runUpdatePlan(ctx *updateContext) error {
 	} else if wouldStash {
 		printUpdateInfo("Would stash: working tree has local changes")
 	}
+	if len(localCommits) > 0 {
+		printUpdateInfo("Local commits not in %s:", rev)
+		printLocalCommits(localCommits)
+	}
 	printDockerActionsPlanned(ctx)
+	if codeChanged {
+		printUpdateStep("Release notes")
+		printReleaseNotes(notes)
+	}
 	if len(rep.Warnings) > 0 {
 		for _, w := range rep.Warnings {
 			printUpdateInfo("Warning: %s", w)
@@ -631,7 +789,7 @@ func maybeSelfUpdateAndReexec() {
 		return
 	}
 
-	latest, err := fetchLatestReleaseTag(context.Background(), "hkjarral/AVA-AI-Voice-Agent-for-Asterisk")
+	latest, err := fetchLatestReleaseTag(context.Background(), cliReleaseRepo)
 	if err != nil || latest == "" {
 		return
 	}
@@ -654,7 +812,8 @@ func maybeSelfUpdateAndReexec() {
 		return
 	}
 
-	if err := selfUpdateFromGitHubRelease(latest, binName, exePath); err != nil {
+	if err := selfUpdateFromGitHubRelease(latest, binName, exePath, updateInsecure); err != nil {
+		printUpdateInfo("CLI self-update skipped: %v", err)
 		printSelfUpdateHint()
 		return
 	}
@@ -689,7 +848,7 @@ func releaseBinaryName(goos string, goarch string) (string, bool) {
 	return "", false
 }
 
-func selfUpdateFromGitHubRelease(tag string, binName string, installPath string) error {
+func selfUpdateFromGitHubRelease(tag string, binName string, installPath string, insecure bool) error {
 	installDir := filepath.Dir(installPath)
 	if installDir == "" {
 		return errors.New("invalid install path")
@@ -698,30 +857,46 @@ func selfUpdateFromGitHubRelease(tag string, binName string, installPath string)
 		return err
 	}
 
-	base := fmt.Sprintf("https://github.com/hkjarral/AVA-AI-Voice-Agent-for-Asterisk/releases/download/%s", tag)
-	binURL := base + "/" + binName
-	sumsURL := base + "/SHA256SUMS"
-
 	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 	defer cancel()
 
-	sums, err := httpGetBytes(ctx, sumsURL)
+	payload, err := downloadReleaseBinary(ctx, tag, binName, insecure)
 	if err != nil {
 		return err
 	}
+	return installBinary(payload, installPath)
+}
+
+// downloadReleaseBinary fetches a release asset and checks it against the
+// release's SHA256SUMS, whose signature must verify unless insecure is set.
+func downloadReleaseBinary(ctx context.Context, tag string, binName string, insecure bool) ([]byte, error) {
+	sums, err := httpGetBytes(ctx, releaseAssetURL(tag, "SHA256SUMS"))
+	if err != nil {
+		return nil, err
+	}
+	if err := enforceSignature("release "+tag+" SHA256SUMS", verifySHA256SUMS(ctx, tag, sums), insecure); err != nil {
+		return nil, err
+	}
 	expected, err := parseSHA256SUMS(sums, binName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	payload, err := httpGetBytes(ctx, binURL)
+	payload, err := httpGetBytes(ctx, releaseAssetURL(tag, binName))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	actual := fmt.Sprintf("%x", sha256.Sum256(payload))
 	if !strings.EqualFold(actual, expected) {
-		return fmt.Errorf("checksum mismatch for %s", binName)
+		return nil, fmt.Errorf("checksum mismatch for %s", binName)
 	}
+	return payload, nil
+}
+
+// installBinary backs up the binary at installPath and swaps payload in through a
+// temp file in the same directory, so the path never holds a partial binary.
+func installBinary(payload []byte, installPath string) error {
+	installDir := filepath.Dir(installPath)
 
 	// Backup existing binary (best-effort).
 	if _, err := os.Stat(installPath); err == nil {
@@ -735,13 +910,24 @@ func selfUpdateFromGitHubRelease(tag string, binName string, installPath string)
 	}
 	_ = os.Chmod(tmp, 0o755)
 
-	if err := os.Rename(tmp, installPath); err != nil {
+	if err := replaceBinary(tmp, installPath); err != nil {
 		_ = os.Remove(tmp)
 		return err
 	}
 	return nil
 }
 
+// httpStatusError is a non-2xx answer from httpGetBytes.
+type httpStatusError struct {
+	URL    string
+	Code   int
+	Status string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("GET %s failed: %s", e.URL, e.Status)
+}
+
 func httpGetBytes(ctx context.Context, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -755,7 +941,7 @@ func httpGetBytes(ctx context.Context, url string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("GET %s failed: %s", url, resp.Status)
+		return nil, &httpStatusError{URL: url, Code: resp.StatusCode, Status: resp.Status}
 	}
 	return io.ReadAll(resp.Body)
 }
@@ -792,7 +978,7 @@ func ensureWritableDir(dir string) error {
 }
 
 func printSelfUpdateHint() {
-	latest, err := fetchLatestReleaseTag(context.Background(), "hkjarral/AVA-AI-Voice-Agent-for-Asterisk")
+	latest, err := fetchLatestReleaseTag(context.Background(), cliReleaseRepo)
 	if err != nil || latest == "" {
 		return
 	}
@@ -805,7 +991,7 @@ func printSelfUpdateHint() {
 		return
 	}
 	fmt.Printf("Notice: a newer agent CLI is available (%s -> %s). Update with:\n", current, latest)
-	fmt.Printf("  curl -sSL https://raw.githubusercontent.com/hkjarral/AVA-AI-Voice-Agent-for-Asterisk/main/scripts/install-cli.sh | bash\n")
+	fmt.Printf("  agent self-update\n")
 }
 
 func fetchLatestReleaseTag(ctx context.Context, repo string) (string, error) {
@@ -927,6 +1113,7 @@ func createUpdateBackups(ctx *updateContext) error {
 		filepath.Join("config", "ai-agent.local.yaml"),
 		filepath.Join("config", "users.json"),
 		filepath.Join("config", "contexts"),
+		filepath.Join("config", configmerge.OverlayDir),
 	}
 
 	for _, rel := range paths {
@@ -980,8 +1167,7 @@ with dst:
     src.backup(dst)
 dst.close(); src.close()
 `
-	cmd := exec.Command("docker", "exec", "ai_engine", "python3", "-c", script, containerSrc, containerTmp)
-	if out, err := cmd.CombinedOutput(); err != nil {
+	if out, err := dockerapi.ExecCombinedOutput(context.Background(), deployment.EngineContainer(), "python3", "-c", script, containerSrc, containerTmp); err != nil {
 		// The container looked up as running but the exec failed (e.g. it became
 		// unhealthy mid-update). Fall back to a host copy rather than aborting.
 		printUpdateInfo("online SQLite backup failed for %s (%s); falling back to host copy", relPath, strings.TrimSpace(string(out)))
@@ -1000,16 +1186,13 @@ dst.close(); src.close()
 // A non-running or unreachable container (docker absent, daemon down) returns
 // false so callers fall back to a host-side copy.
 func aiEngineRunning() bool {
-	out, err := runCmd("docker", "ps", "--filter", "name=^ai_engine$", "--filter", "status=running", "--format", "{{.Names}}")
-	if err != nil {
-		return false
-	}
-	for _, line := range strings.Split(out, "\n") {
-		if strings.TrimSpace(line) == "ai_engine" {
-			return true
-		}
+	out, err := dockerapi.Inspect(deployment.EngineContainer())
+	var cis []struct {
+		State struct {
+			Running bool `json:"Running"`
+		} `json:"State"`
 	}
-	return false
+	return err == nil && json.Unmarshal(out, &cis) == nil && len(cis) > 0 && cis[0].State.Running
 }
 
 // backupSQLiteHostCopy copies a SQLite DB and any -wal/-shm sidecars directly
@@ -1371,15 +1554,17 @@ func restoreOperatorConfigFromBackup(ctx *updateContext) error {
 		printUpdateInfo("Restored %s", rel)
 	}
 
-	// Restore contexts directory if backed up.
-	ctxSrc := filepath.Join(ctx.backupDir, "config", "contexts")
-	if info, err := os.Stat(ctxSrc); err == nil && info.IsDir() {
-		ctxDst := filepath.Join("config", "contexts")
-		_ = os.RemoveAll(ctxDst)
-		if err := copyDir(ctxSrc, ctxDst); err != nil {
-			return fmt.Errorf("failed to restore config/contexts from backup: %w", err)
+	// Restore the contexts and environment overlay directories if backed up.
+	for _, name := range []string{"contexts", configmerge.OverlayDir} {
+		src := filepath.Join(ctx.backupDir, "config", name)
+		if info, err := os.Stat(src); err == nil && info.IsDir() {
+			dst := filepath.Join("config", name)
+			_ = os.RemoveAll(dst)
+			if err := copyDir(src, dst); err != nil {
+				return fmt.Errorf("failed to restore config/%s from backup: %w", name, err)
+			}
+			printUpdateInfo("Restored config/%s/", name)
 		}
-		printUpdateInfo("Restored config/contexts/")
 	}
 
 	ctx.stashed = false
@@ -1565,7 +1750,7 @@ func gitFetchTags(remote string) error {
 func gitMergeFastForward(remoteRef string) error {
 	_, err := runGitCmd("merge", "--ff-only", remoteRef)
 	if err != nil {
-		return fmt.Errorf("git merge --ff-only %s failed (branch likely diverged or local conflicts). Fix manually and retry: %w", remoteRef, err)
+		return fmt.Errorf("git merge --ff-only %s failed (untracked or unstashed local files likely conflict with incoming changes). Move them aside and retry: %w", remoteRef, err)
 	}
 	return nil
 }
@@ -1705,16 +1890,20 @@ func preflightDockerChangeGuard(ctx *updateContext) error {
 	if !updateHasDockerChanges(ctx) {
 		return nil
 	}
-	if _, err := runCmd("docker", "compose", "version"); err != nil {
+	if deployment.Current().OnKubernetes() {
+		if _, err := kube.Current(); err != nil {
+			return fmt.Errorf("the Kubernetes API is required before updating checkout because restarts are planned: %w", err)
+		}
+	} else if _, err := runCmd("docker", "compose", "version"); err != nil {
 		return fmt.Errorf("docker compose is required before updating checkout because Docker changes are planned: %w", err)
 	}
-	if !updateMayTouchAIEngine(ctx) || envBool("AAVA_UPDATE_FORCE_ACTIVE_CALLS") {
+	if !updateMayTouchAIEngine(ctx) || updateForce || envBool("AAVA_UPDATE_FORCE_ACTIVE_CALLS") {
 		return nil
 	}
 
-	activeCalls, reachable, err := queryActiveCalls()
-	if err == nil && reachable && activeCalls > 0 {
-		return fmt.Errorf("refusing to update checkout while %d active call(s) are in progress; retry after calls complete or set AAVA_UPDATE_FORCE_ACTIVE_CALLS=true", activeCalls)
+	activeCalls, err := activeCallCount()
+	if err == nil && activeCalls > 0 {
+		return fmt.Errorf("refusing to update checkout while %d active call(s) are in progress; retry after calls complete, schedule it with --schedule, or pass --force", activeCalls)
 	}
 	if err != nil {
 		printUpdateInfo("WARN: unable to check active calls before updating checkout: %v", err)
@@ -1722,10 +1911,13 @@ func preflightDockerChangeGuard(ctx *updateContext) error {
 	return nil
 }
 
-func applyDockerActions(ctx *updateContext) error {
+func applyDockerActions(ctx *updateContext) (retErr error) {
 	if len(ctx.servicesToRebuild) == 0 && len(ctx.servicesToRestart) == 0 && !ctx.composeChanged {
 		return nil
 	}
+	if deployment.Current().OnKubernetes() {
+		return applyKubeActions(ctx)
+	}
 
 	if _, err := runCmd("docker", "compose", "version"); err != nil {
 		return fmt.Errorf("docker compose is required but not available: %w", err)
@@ -1737,18 +1929,10 @@ func applyDockerActions(ctx *updateContext) error {
 	// plus any services we explicitly intend to rebuild/restart.
 	runningServices := map[string]bool{}
 	runningServicesKnown := false
-	out, err := runCmd("docker", "compose", "ps", "--services", "--status", "running")
-	if err != nil {
-		// Fallback for older compose versions (or environments where --status isn't supported).
-		out, err = runCmd("docker", "compose", "ps", "--services")
-	}
-	if err == nil {
+	if services, err := dockerapi.ComposeServices(deployment.ComposeProject(), false); err == nil {
 		runningServicesKnown = true
-		for _, line := range strings.Split(out, "\n") {
-			svc := strings.TrimSpace(line)
-			if svc != "" {
-				runningServices[svc] = true
-			}
+		for _, svc := range services {
+			runningServices[svc] = true
 		}
 	}
 
@@ -1778,14 +1962,21 @@ func applyDockerActions(ctx *updateContext) error {
 		})
 	}
 
-	if runningServices["ai_engine"] && (ctx.composeChanged || containsString(rebuildServices, "ai_engine") || containsString(restartServices, "ai_engine")) && !envBool("AAVA_UPDATE_FORCE_ACTIVE_CALLS") {
-		activeCalls, reachable, err := queryActiveCalls()
-		if err == nil && reachable && activeCalls > 0 {
-			printUpdateInfo("WARN: %d active call(s) started after update checkout; continuing to keep code and containers aligned", activeCalls)
-		}
-		if err != nil {
-			printUpdateInfo("WARN: unable to check active calls before ai_engine restart: %v", err)
+	// Drain before anything can recreate ai_engine; on failure put it back into service.
+	// Images are built first so the engine does not turn calls away for the whole build.
+	if runningServices["ai_engine"] && (ctx.composeChanged || containsString(rebuildServices, "ai_engine") || containsString(restartServices, "ai_engine")) {
+		if containsString(rebuildServices, "ai_engine") && updateDrainTimeout > 0 {
+			printUpdateInfo("Building images before draining: %s", strings.Join(rebuildServices, ", "))
+			if _, err := runCmd("docker", append([]string{"compose", "build"}, rebuildServices...)...); err != nil {
+				return fmt.Errorf("docker compose build failed: %w", err)
+			}
 		}
+		undrain := drainEngine(updateDrainTimeout)
+		defer func() {
+			if retErr != nil {
+				undrain()
+			}
+		}()
 	}
 
 	if ctx.composeChanged {
@@ -1842,7 +2033,7 @@ func applyDockerActions(ctx *updateContext) error {
 	}
 
 	for _, svc := range restartServices {
-		if _, err := runCmd("docker", "compose", "restart", svc); err != nil {
+		if err := restartService(svc); err != nil {
 			// Fallback: start/recreate service if restart fails.
 			if _, err2 := runCmd("docker", "compose", "up", "-d", "--no-build", svc); err2 != nil {
 				return fmt.Errorf("failed to restart %s (restart error: %v; up error: %w)", svc, err, err2)
@@ -1867,56 +2058,6 @@ func envBool(name string) bool {
 	return v == "1" || v == "true" || v == "yes" || v == "on"
 }
 
-func configuredHealthPort() int {
-	const defaultPort = 15000
-	if port, ok := parseHealthPort(os.Getenv("HEALTH_BIND_PORT")); ok {
-		return port
-	}
-	if raw, ok := dotenvValue(".env", "HEALTH_BIND_PORT"); ok {
-		if port, ok := parseHealthPort(raw); ok {
-			return port
-		}
-	}
-
-	cfg := map[string]any{}
-	if base, err := configmerge.ReadYAMLFile(filepath.Join("config", "ai-agent.yaml")); err == nil {
-		cfg = base
-	}
-	if local, err := configmerge.ReadYAMLFile(filepath.Join("config", "ai-agent.local.yaml")); err == nil {
-		cfg = configmerge.DeepMerge(cfg, local)
-	}
-	if health, ok := cfg["health"].(map[string]any); ok {
-		if port, ok := parseHealthPortValue(health["port"]); ok {
-			return port
-		}
-	}
-	return defaultPort
-}
-
-func parseHealthPortValue(raw any) (int, bool) {
-	switch v := raw.(type) {
-	case int:
-		return parseHealthPort(strconv.Itoa(v))
-	case int64:
-		return parseHealthPort(strconv.FormatInt(v, 10))
-	case float64:
-		if v == float64(int(v)) {
-			return parseHealthPort(strconv.Itoa(int(v)))
-		}
-	case string:
-		return parseHealthPort(v)
-	}
-	return 0, false
-}
-
-func parseHealthPort(raw string) (int, bool) {
-	p, err := strconv.Atoi(strings.TrimSpace(raw))
-	if err != nil || p < 1 || p > 65535 {
-		return 0, false
-	}
-	return p, true
-}
-
 func dotenvValue(path, key string) (string, bool) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -1943,46 +2084,6 @@ func dotenvValue(path, key string) (string, bool) {
 	return "", false
 }
 
-func queryActiveCalls() (int, bool, error) {
-	port := configuredHealthPort()
-	script := fmt.Sprintf(`
-import json, urllib.request
-try:
-    with urllib.request.urlopen("http://127.0.0.1:%d/sessions/stats", timeout=3) as resp:
-        print(resp.read().decode("utf-8"))
-except Exception as e:
-    print(json.dumps({"_probe_error": str(e)}))
-`, port)
-	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, "docker", "exec", "ai_engine", "python3", "-c", script)
-	out, err := cmd.CombinedOutput()
-	if ctx.Err() == context.DeadlineExceeded {
-		return 0, false, fmt.Errorf("docker exec ai_engine sessions/stats timed out after 8s")
-	}
-	if err != nil {
-		return 0, false, fmt.Errorf("docker exec ai_engine sessions/stats failed: %w (%s)", err, strings.TrimSpace(string(out)))
-	}
-	var payload map[string]any
-	if err := json.Unmarshal(bytes.TrimSpace(out), &payload); err != nil {
-		return 0, true, err
-	}
-	if probeErr, ok := payload["_probe_error"].(string); ok && strings.TrimSpace(probeErr) != "" {
-		return 0, false, errors.New(probeErr)
-	}
-	for _, key := range []string{"active_calls", "active_sessions"} {
-		if raw, ok := payload[key]; ok {
-			switch v := raw.(type) {
-			case float64:
-				return int(v), true, nil
-			case int:
-				return v, true, nil
-			}
-		}
-	}
-	return 0, true, nil
-}
-
 func filterSlice(in []string, keep func(string) bool) []string {
 	if len(in) == 0 {
 		return nil
@@ -1998,6 +2099,7 @@ func filterSlice(in []string, keep func(string) bool) []string {
 
 func runPostUpdateCheck() (report *check.Report, status string, warnCount int, failCount int, err error) {
 	runner := check.NewRunner(verbose, version, buildTime)
+	runner.Profile, _ = check.FindProfile("post-update")
 	report, runErr := runner.Run()
 	if report == nil {
 		return nil, "FAIL", 0, 1, fmt.Errorf("agent check failed: %w", runErr)
@@ -2079,6 +2181,12 @@ func printUpdateFailureRecovery(ctx *updateContext, err error) {
 			filepath.Join(ctx.backupDir, "config", "contexts"),
 			filepath.Join("config", "contexts"),
 		)
+		fmt.Println("  # Same for environment overlays (if you use them):")
+		fmt.Printf("  rm -rf %s && cp -r %s %s\n",
+			filepath.Join("config", configmerge.OverlayDir),
+			filepath.Join(ctx.backupDir, "config", configmerge.OverlayDir),
+			filepath.Join("config", configmerge.OverlayDir),
+		)
 	}
 
 	if ctx.stashed {
@@ -2124,10 +2232,13 @@ func printUpdateSummary(ctx *updateContext, checkStatus string, warnCount int, f
 }
 
 func updateHumanWriter() io.Writer {
-	// When emitting machine-readable JSON plans, keep human output on stderr so stdout stays valid JSON.
-	if updatePlan && updatePlanJSON {
+	// When emitting machine-readable plans, keep human output on stderr so stdout stays parseable.
+	if updatePlan && structuredOutput(updatePlanJSON).Structured() {
 		return os.Stderr
 	}
+	if quiet {
+		return io.Discard
+	}
 	return os.Stdout
 }
 
@@ -2144,6 +2255,18 @@ func printDockerActionsPlanned(ctx *updateContext) {
 		printUpdateInfo("No container rebuild/restart required")
 		return
 	}
+	if deployment.Current().OnKubernetes() {
+		all := map[string]bool{}
+		for _, m := range []map[string]bool{ctx.servicesToRebuild, ctx.servicesToRestart} {
+			for svc := range m {
+				all[svc] = true
+			}
+		}
+		if len(all) > 0 {
+			printUpdateInfo("Will rollout-restart on Kubernetes: %s", strings.Join(sortedKeys(all), ", "))
+		}
+		return
+	}
 	if ctx.composeChanged {
 		printUpdateInfo("Compose files changed (will run docker compose up --no-build --remove-orphans)")
 	}
@@ -2159,8 +2282,10 @@ func printPostUpdateCheck(report *check.Report, warnCount int, failCount int) {
 	if report == nil {
 		return
 	}
-	if verbose || warnCount > 0 || failCount > 0 {
-		report.OutputText(os.Stdout)
+	if quiet {
+		report.OutputQuiet(os.Stdout)
+	} else if verbose || warnCount > 0 || failCount > 0 {
+		report.OutputText(os.Stdout, outputProfile)
 	}
 }
 
@@ -2181,9 +2306,22 @@ func shortSHA(sha string) string {
 	return sha
 }
 
+// restartService is docker compose restart for one service, through the Engine API
+// when the daemon is reachable.
+func restartService(svc string) error {
+	if verbose {
+		fmt.Printf(" → restart %s\n", svc)
+	}
+	return dockerapi.RestartService(deployment.ComposeProject(), svc)
+}
+
 func runCmd(name string, args ...string) (string, error) {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
+	if name == "docker" && len(args) > 1 && args[0] == "compose" && args[1] == "up" {
+		// Containers created here need the provider keys kept outside .env.
+		cmd.Env = secrets.Environ()
+	}
 	if verbose {
 		fmt.Printf(" → %s %s\n", name, strings.Join(args, " "))
 		var buf bytes.Buffer
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+)
+
+// drainPollInterval is how often the drain step re-counts active calls.
+const drainPollInterval = 5 * time.Second
+
+// errDrainUnsupported means the running engine predates POST /drain.
+var errDrainUnsupported = errors.New("engine does not support draining (upgrade in progress from an older release)")
+
+// drainEngine stops ai_engine from taking new calls and waits up to timeout for
+// the active ones to finish. The returned undo puts the engine back into service
+// when the restart does not happen; a restart clears the drain flag by itself.
+func drainEngine(timeout time.Duration) (undo func()) {
+	undo = func() {}
+	if timeout <= 0 || updateForce || envBool("AAVA_UPDATE_FORCE_ACTIVE_CALLS") {
+		return undo
+	}
+
+	printUpdateStep("Draining ai_engine")
+	if err := setEngineDraining(true); err != nil {
+		printUpdateInfo("WARN: %v; waiting for active calls without turning new calls away", err)
+	} else {
+		printUpdateInfo("New calls are returned to the dialplan until ai_engine restarts")
+		undo = func() {
+			if err := setEngineDraining(false); err != nil {
+				printUpdateInfo("WARN: could not take ai_engine out of drain: %v (restart it to resume taking calls)", err)
+			}
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	last := -1
+	for {
+		calls, err := activeCallCount()
+		if err != nil {
+			printUpdateInfo("WARN: unable to count active calls: %v; restarting now", err)
+			return undo
+		}
+		if calls == 0 {
+			printUpdateInfo("No active calls")
+			return undo
+		}
+		if time.Now().After(deadline) {
+			printUpdateInfo("WARN: %d call(s) still active after %s; restarting anyway", calls, timeout)
+			return undo
+		}
+		if calls != last {
+			printUpdateInfo("Waiting for %d active call(s) to finish (up to %s)", calls, time.Until(deadline).Round(time.Second))
+			last = calls
+		}
+		time.Sleep(minDuration(drainPollInterval, time.Until(deadline)+time.Millisecond))
+	}
+}
+
+// setEngineDraining calls POST /drain on the engine health server.
+func setEngineDraining(draining bool) error {
+	err := engineapi.New().SetDraining(context.Background(), draining)
+	if errors.Is(err, engineapi.ErrUnsupported) {
+		return errDrainUnsupported
+	}
+	return err
+}
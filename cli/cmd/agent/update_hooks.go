@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hooks"
+)
+
+// updateHookEvent describes the planned update for the update hooks.
+func updateHookEvent(ctx *updateContext, hook string) hooks.Event {
+	u := &hooks.Update{
+		Ref:          updateRef,
+		From:         strings.TrimSpace(ctx.oldSHA),
+		To:           strings.TrimSpace(ctx.newSHA),
+		ChangedFiles: append([]string{}, ctx.changedFiles...),
+		Rebuild:      sortedServices(ctx.servicesToRebuild),
+		Restart:      sortedServices(ctx.servicesToRestart),
+	}
+	return hooks.Event{Hook: hook, CLIVersion: version, Update: u}
+}
+
+func sortedServices(m map[string]bool) []string {
+	out := []string{}
+	for svc, on := range m {
+		if on {
+			out = append(out, svc)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// runPreUpdateHook runs .agent/hooks/pre-update before the update changes
+// anything; a failing hook aborts the update.
+func runPreUpdateHook(ctx *updateContext) error {
+	if _, ok := hooks.Find(hooks.PreUpdate); !ok || hooks.Disabled() {
+		return nil
+	}
+	printUpdateStep("Running pre-update hook")
+	if _, err := hooks.Run(updateHookEvent(ctx, hooks.PreUpdate)); err != nil {
+		return contract.Exit(contract.Fail, fmt.Errorf("update aborted: %w", err))
+	}
+	return nil
+}
+
+// runPostUpdateHook runs .agent/hooks/post-update with the update's outcome.
+// It cannot change the outcome, so a failure is only reported.
+func runPostUpdateHook(ctx *updateContext, updateErr error) {
+	e := updateHookEvent(ctx, hooks.PostUpdate)
+	e.Update.WarnCount, e.Update.FailCount = ctx.checkWarn, ctx.checkFail
+	switch contract.CodeOf(updateErr) {
+	case contract.OK:
+		e.Update.Result = "ok"
+	case contract.Warn:
+		e.Update.Result = "warn"
+	default:
+		e.Update.Result = "fail"
+		e.Update.Error = updateErr.Error()
+	}
+	if _, err := hooks.Run(e); err != nil {
+		fmt.Fprintf(os.Stderr, "WARN: %v\n", err)
+	}
+}
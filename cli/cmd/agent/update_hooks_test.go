@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hooks"
+)
+
+func TestUpdateHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks")
+	}
+	old := hooks.Dir
+	hooks.Dir = t.TempDir()
+	defer func() { hooks.Dir = old }()
+	write := func(name, script string) {
+		if err := os.WriteFile(filepath.Join(hooks.Dir, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := &updateContext{
+		oldSHA:            "1111111",
+		newSHA:            "2222222",
+		changedFiles:      []string{"src/engine.py"},
+		servicesToRebuild: map[string]bool{"ai_engine": true},
+		servicesToRestart: map[string]bool{"admin_ui": true, "local_ai_server": false},
+	}
+
+	if err := runPreUpdateHook(ctx); err != nil {
+		t.Fatalf("no hook installed: %v", err)
+	}
+	write(hooks.PreUpdate, "exit 1\n")
+	if err := runPreUpdateHook(ctx); contract.CodeOf(err) != contract.Fail {
+		t.Errorf("a failing pre-update hook should abort the update, got %v", err)
+	}
+
+	event := filepath.Join(hooks.Dir, "event.json")
+	write(hooks.PostUpdate, "cat > "+event+"\n")
+	ctx.checkWarn = 2
+	runPostUpdateHook(ctx, contract.Exit(contract.Warn, nil))
+	var e hooks.Event
+	raw, _ := os.ReadFile(event)
+	if err := json.Unmarshal(raw, &e); err != nil || e.Update == nil {
+		t.Fatalf("event = %s (%v)", raw, err)
+	}
+	u := e.Update
+	if u.From != "1111111" || u.To != "2222222" || u.Result != "warn" || u.WarnCount != 2 || u.Error != "" {
+		t.Errorf("update = %+v", u)
+	}
+	if len(u.Rebuild) != 1 || u.Rebuild[0] != "ai_engine" || len(u.Restart) != 1 || u.Restart[0] != "admin_ui" {
+		t.Errorf("services: rebuild %v, restart %v", u.Rebuild, u.Restart)
+	}
+
+	runPostUpdateHook(ctx, errors.New("docker compose up failed"))
+	raw, _ = os.ReadFile(event)
+	e = hooks.Event{}
+	_ = json.Unmarshal(raw, &e)
+	if e.Update == nil || e.Update.Result != "fail" || e.Update.Error != "docker compose up failed" {
+		t.Errorf("event = %s", raw)
+	}
+}
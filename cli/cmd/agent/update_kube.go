@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+)
+
+// applyKubeActions is applyDockerActions for runtime: kubernetes. Nothing is
+// built here and the manifests are the operator's, so rebuilds and restarts
+// both become a rollout restart of each service's workload; it picks up a new
+// image when the pod spec pulls one (imagePullPolicy: Always or a moved tag).
+func applyKubeActions(ctx *updateContext) (retErr error) {
+	s, err := kube.Current()
+	if err != nil {
+		return fmt.Errorf("kubernetes: %w", err)
+	}
+
+	services := map[string]bool{}
+	for svc := range ctx.servicesToRebuild {
+		services[svc] = true
+	}
+	for svc := range ctx.servicesToRestart {
+		services[svc] = true
+	}
+	if !updateIncludeUI {
+		delete(services, "admin_ui")
+	}
+	if ctx.composeChanged {
+		printUpdateInfo("WARN: compose files changed; port the change to your Kubernetes manifests (agent update does not apply them)")
+	}
+	if rebuild := sortedKeys(ctx.servicesToRebuild); len(rebuild) > 0 {
+		printUpdateInfo("Images are not built on Kubernetes; push new images for %s before the restart picks them up", strings.Join(rebuild, ", "))
+	}
+
+	// Like compose, leave alone services that have no pod: the operator does not run them.
+	bg := context.Background()
+	var restart []string
+	for _, svc := range sortedKeys(services) {
+		lookup, cancel := context.WithTimeout(bg, 10*time.Second)
+		_, err := s.FindPod(lookup, deployment.ServiceContainer(svc))
+		cancel()
+		if kube.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("kubernetes: find %s pod: %w", svc, err)
+		}
+		restart = append(restart, svc)
+	}
+
+	if containsString(restart, "ai_engine") {
+		undrain := drainEngine(updateDrainTimeout)
+		defer func() {
+			if retErr != nil {
+				undrain()
+			}
+		}()
+	}
+	for _, svc := range restart {
+		rctx, cancel := context.WithTimeout(bg, 30*time.Second)
+		w, err := s.Restart(rctx, deployment.ServiceContainer(svc))
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to restart %s: %w", svc, err)
+		}
+		printUpdateInfo("Restarted %s (kubectl -n %s rollout status %s)", svc, s.Namespace, w)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// localCommitPreview caps how many local commits are listed in messages.
+const localCommitPreview = 20
+
+// gitLocalCommits lists the commits reachable from head but not from upstream,
+// oldest first, as "<short sha> <subject>".
+func gitLocalCommits(upstream, head string) ([]string, error) {
+	out, err := runGitCmd("log", "--reverse", "--format=%h %s", upstream+".."+head)
+	if err != nil {
+		return nil, fmt.Errorf("git log %s..%s failed: %w", shortSHA(upstream), shortSHA(head), err)
+	}
+	var commits []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			commits = append(commits, line)
+		}
+	}
+	return commits, nil
+}
+
+func printLocalCommits(commits []string) {
+	for i, c := range commits {
+		if i == localCommitPreview {
+			printUpdateInfo("  ... and %d more", len(commits)-i)
+			break
+		}
+		printUpdateInfo("  %s", c)
+	}
+}
+
+// divergedError explains a branch that carries local commits the target lacks.
+func divergedError(targetLabel string, commits []string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cannot fast-forward: your branch has %d local commit(s) that %s does not contain:\n", len(commits), targetLabel)
+	for i, c := range commits {
+		if i == localCommitPreview {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(commits)-i)
+			break
+		}
+		fmt.Fprintf(&b, "  %s\n", c)
+	}
+	fmt.Fprintf(&b, "Re-run with --rebase-local to reapply them on top of %s, or move them to another branch and reset this one to %s", targetLabel, targetLabel)
+	return errors.New(b.String())
+}
+
+// gitRebaseLocal replays the branch's local commits onto target. On conflict the
+// rebase is aborted, leaving the branch exactly as it was.
+func gitRebaseLocal(target string) error {
+	if _, err := runGitCmd("rebase", target); err != nil {
+		_, _ = runGitCmd("rebase", "--abort")
+		return fmt.Errorf("reapplying local commits onto %s conflicted; the rebase was aborted and your branch is unchanged. Rebase manually (git rebase %s) and re-run: %w", target, target, err)
+	}
+	return nil
+}
+
+// gitBranchWorktree returns the path of another worktree that has branch checked
+// out, or "" when none does. git refuses to check out such a branch twice.
+func gitBranchWorktree(branch, repoRoot string) string {
+	out, err := runGitCmd("worktree", "list", "--porcelain")
+	if err != nil {
+		return ""
+	}
+	var path string
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			path = strings.TrimSpace(strings.TrimPrefix(line, "worktree "))
+		case strings.TrimSpace(line) == "branch refs/heads/"+branch:
+			if filepath.Clean(path) != filepath.Clean(repoRoot) {
+				return path
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// initDivergedRepo leaves HEAD on main with one local commit ("local") that the
+// "upstream" branch lacks, and one upstream commit that main lacks.
+func initDivergedRepo(t *testing.T, upstreamFile string) {
+	t.Helper()
+	initDiscardLocalChangesRepo(t)
+	runLocalGit(t, "branch", "-M", "main")
+	runLocalGit(t, "branch", "upstream")
+
+	if err := os.WriteFile("local.txt", []byte("patch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runLocalGit(t, "add", "local.txt")
+	runLocalGit(t, "commit", "-m", "local patch")
+
+	runLocalGit(t, "checkout", "-q", "upstream")
+	if err := os.WriteFile(upstreamFile, []byte("upstream\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runLocalGit(t, "add", upstreamFile)
+	runLocalGit(t, "commit", "-m", "upstream change")
+	runLocalGit(t, "checkout", "-q", "main")
+}
+
+func TestGitLocalCommitsListsCommitsMissingUpstream(t *testing.T) {
+	initDivergedRepo(t, "tracked.txt")
+
+	commits, err := gitLocalCommits("upstream", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || !strings.HasSuffix(commits[0], " local patch") {
+		t.Fatalf("local commits = %q", commits)
+	}
+	msg := divergedError("origin/main", commits).Error()
+	if !strings.Contains(msg, "1 local commit(s)") || !strings.Contains(msg, "local patch") || !strings.Contains(msg, "--rebase-local") {
+		t.Fatalf("diverged error = %q", msg)
+	}
+}
+
+func TestGitRebaseLocalReappliesCommits(t *testing.T) {
+	initDivergedRepo(t, "tracked.txt")
+
+	if err := gitRebaseLocal("upstream"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := gitIsAncestor("upstream", "HEAD"); !ok {
+		t.Fatal("HEAD should contain upstream after rebase")
+	}
+	if got, _ := os.ReadFile("tracked.txt"); string(got) != "upstream\n" {
+		t.Fatalf("tracked.txt = %q", got)
+	}
+	if _, err := os.Stat("local.txt"); err != nil {
+		t.Fatalf("local commit was not reapplied: %v", err)
+	}
+}
+
+func TestGitRebaseLocalAbortsOnConflict(t *testing.T) {
+	initDivergedRepo(t, "local.txt")
+	before, _ := gitRevParse("HEAD")
+
+	err := gitRebaseLocal("upstream")
+	if err == nil || !strings.Contains(err.Error(), "unchanged") {
+		t.Fatalf("gitRebaseLocal = %v", err)
+	}
+	after, _ := gitRevParse("HEAD")
+	if before != after {
+		t.Fatalf("HEAD moved from %s to %s", before, after)
+	}
+	if branch, _ := gitCurrentBranch(); branch != "main" {
+		t.Fatalf("still rebasing: branch = %q", branch)
+	}
+}
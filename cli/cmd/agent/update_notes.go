@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/changelog"
+)
+
+// releaseNotesLineLimit caps each printed changelog entry; the full text stays in
+// CHANGELOG.md at the target commit.
+const releaseNotesLineLimit = 40
+
+// errUpdateCancelled is returned when the operator declines the release notes
+// prompt. Nothing in the checkout or containers has changed at that point.
+var errUpdateCancelled = errors.New("update cancelled; the checkout and containers were not changed")
+
+type updateReleaseNotes struct {
+	Entries []changelog.Entry `json:"entries,omitempty"`
+	Notes   []changelog.Note  `json:"migration_notes,omitempty"`
+}
+
+func (n *updateReleaseNotes) empty() bool {
+	return n == nil || (len(n.Entries) == 0 && len(n.Notes) == 0)
+}
+
+// loadReleaseNotes reads CHANGELOG.md and docs/MIGRATION.md as of newSHA and keeps
+// what oldSHA did not have yet. Missing files yield no notes.
+func loadReleaseNotes(oldSHA, newSHA string) *updateReleaseNotes {
+	newLog, err := gitShowFile(newSHA, "CHANGELOG.md")
+	if err != nil {
+		return nil
+	}
+	oldLog, _ := gitShowFile(oldSHA, "CHANGELOG.md")
+	migration, _ := gitShowFile(newSHA, "docs/MIGRATION.md")
+	entries := changelog.Between(oldLog, newLog)
+	return &updateReleaseNotes{Entries: entries, Notes: changelog.MigrationNotes(migration, entries)}
+}
+
+func gitShowFile(rev, path string) ([]byte, error) {
+	args := []string{"show", rev + ":" + path}
+	if gitSafeDirectory != "" {
+		args = append([]string{"-c", "safe.directory=" + gitSafeDirectory}, args...)
+	}
+	return exec.Command("git", args...).Output()
+}
+
+func printReleaseNotes(notes *updateReleaseNotes) {
+	if notes.empty() {
+		printUpdateInfo("No new CHANGELOG entries between these commits")
+		return
+	}
+	w := updateHumanWriter()
+	for _, e := range notes.Entries {
+		heading := e.Version
+		if e.Date != "" {
+			heading += " (" + e.Date + ")"
+		}
+		fmt.Fprintf(w, "\n--- %s ---\n", heading)
+		printLimitedLines(e.Body)
+	}
+	if len(notes.Notes) > 0 {
+		fmt.Fprintf(w, "\n!!! Migration notes: review before continuing\n")
+		for _, n := range notes.Notes {
+			fmt.Fprintf(w, "\n--- %s ---\n", n.Title)
+			printLimitedLines(n.Body)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func printLimitedLines(body string) {
+	w := updateHumanWriter()
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if i >= releaseNotesLineLimit {
+			fmt.Fprintf(w, "  ... %d more line(s); see CHANGELOG.md and docs/MIGRATION.md\n", len(lines)-i)
+			return
+		}
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// confirmReleaseNotes asks before any git or container change. --yes and
+// non-interactive runs (the Admin UI updater) proceed after the notes are printed.
+func confirmReleaseNotes(notes *updateReleaseNotes) error {
+	if updateYes || notes.empty() || !stdinIsTerminal() {
+		return nil
+	}
+	fmt.Fprint(os.Stderr, "Continue with the update? [y/N]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errUpdateCancelled
+}
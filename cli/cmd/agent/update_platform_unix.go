@@ -4,6 +4,7 @@ package main
 
 import (
 	"os"
+	"os/exec"
 	"syscall"
 )
 
@@ -22,3 +23,20 @@ func unlockUpdateFile(f *os.File) {
 func execReplace(exePath string, args []string, env []string) {
 	_ = syscall.Exec(exePath, args, env)
 }
+
+// replaceBinary renames the new binary over the installed one; a running
+// process keeps its open inode.
+func replaceBinary(tmp, dst string) error {
+	return os.Rename(tmp, dst)
+}
+
+// setTerminalEcho turns echo of typed characters on stdin on or off.
+func setTerminalEcho(on bool) {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	_ = cmd.Run()
+}
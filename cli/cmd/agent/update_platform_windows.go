@@ -33,9 +33,42 @@ func execReplace(exePath string, args []string, env []string) {
 	cmd.Stderr = os.Stderr
 	err := cmd.Run()
 	if err == nil {
+		flushPlain()
 		os.Exit(0)
 	}
 	if exitErr, ok := err.(*exec.ExitError); ok {
+		flushPlain()
 		os.Exit(exitErr.ExitCode())
 	}
 }
+
+// replaceBinary swaps in the new binary. Windows cannot overwrite a running
+// executable but can rename it, so the current one moves aside to <dst>.old,
+// which the next replacement removes.
+func replaceBinary(tmp, dst string) error {
+	old := dst + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(dst, old); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Rename(old, dst)
+		return err
+	}
+	return nil
+}
+
+// setTerminalEcho turns echo of typed characters on stdin on or off.
+func setTerminalEcho(on bool) {
+	h := windows.Handle(os.Stdin.Fd())
+	var mode uint32
+	if windows.GetConsoleMode(h, &mode) != nil {
+		return
+	}
+	if on {
+		mode |= windows.ENABLE_ECHO_INPUT
+	} else {
+		mode &^= windows.ENABLE_ECHO_INPUT
+	}
+	_ = windows.SetConsoleMode(h, mode)
+}
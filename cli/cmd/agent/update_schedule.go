@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/maintenance"
+)
+
+// activeCallPollInterval is how often a scheduled update re-checks for calls
+// once its maintenance window is open.
+const activeCallPollInterval = 30 * time.Second
+
+// updateMaintenanceWindow returns the window from --schedule, falling back to
+// maintenance_window in the deployment descriptor. "now" disables it.
+func updateMaintenanceWindow() (*maintenance.Window, error) {
+	spec := strings.TrimSpace(updateSchedule)
+	if spec == "" {
+		spec = strings.TrimSpace(deployment.Current().MaintenanceWindow)
+	}
+	if spec == "" || strings.EqualFold(spec, "now") {
+		return nil, nil
+	}
+	w, err := maintenance.Parse(spec)
+	if err != nil {
+		return nil, contract.UsageError(err)
+	}
+	return w, nil
+}
+
+// waitForMaintenanceWindow blocks until the window is open and no calls are
+// active. A window that closes while calls are still up rolls over to the next one.
+func waitForMaintenanceWindow(w *maintenance.Window) {
+	waitingForCalls := false
+	for {
+		now := time.Now()
+		end, open := w.Current(now)
+		if !open {
+			next := w.Next(now)
+			printUpdateInfo("Waiting for maintenance window %s: opens %s (in %s)", w.Spec, next.Format("Mon 2006-01-02 15:04 MST"), next.Sub(now).Round(time.Minute))
+			time.Sleep(time.Until(next))
+			waitingForCalls = false
+			continue
+		}
+		if updateForce {
+			return
+		}
+		calls, err := activeCallCount()
+		if err != nil {
+			printUpdateInfo("WARN: unable to check active calls: %v", err)
+			return
+		}
+		if calls == 0 {
+			printUpdateInfo("Maintenance window %s is open and no calls are active", w.Spec)
+			return
+		}
+		if !waitingForCalls {
+			printUpdateInfo("%d active call(s); waiting for them to finish (window closes %s)", calls, end.Format("15:04 MST"))
+			waitingForCalls = true
+		}
+		time.Sleep(minDuration(activeCallPollInterval, time.Until(end)))
+	}
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// activeCallCount asks the engine (/sessions/stats) and Asterisk (ARI channels in
+// the agent's Stasis app) and keeps the higher count, so calls are still seen when
+// one side is unreachable. It fails only when neither answers.
+func activeCallCount() (int, error) {
+	var engineCalls int
+	sessions, engineErr := engineapi.New().Sessions(context.Background())
+	if engineErr == nil {
+		engineCalls = sessions.ActiveCalls
+	}
+	ariCalls, ariErr := queryARIActiveCalls()
+	switch {
+	case engineErr != nil && ariErr != nil:
+		return 0, fmt.Errorf("engine: %v; ARI: %v", engineErr, ariErr)
+	case engineErr != nil:
+		return ariCalls, nil
+	case ariErr != nil:
+		return engineCalls, nil
+	}
+	if ariCalls > engineCalls {
+		return ariCalls, nil
+	}
+	return engineCalls, nil
+}
+
+func queryARIActiveCalls() (int, error) {
+	cfg := asterisk.ARIConfigFromEnv(func(key string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		v, _ := dotenvValue(".env", key)
+		return v
+	})
+	if cfg.Username == "" {
+		return 0, errors.New("ASTERISK_ARI_USERNAME is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
+	defer cancel()
+	channels, err := asterisk.ListChannels(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+	return asterisk.AgentCalls(channels, cfg.AppName), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dialplan"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/wizard"
+	"github.com/spf13/cobra"
+)
+
+var upgradeAsteriskJSON bool
+
+var upgradeAsteriskCmd = &cobra.Command{
+	Use:   "upgrade-asterisk-config",
+	Short: "Audit Asterisk after a major upgrade",
+	Long: `Check the running Asterisk for what a major upgrade (for example 18 → 20)
+breaks in the agent's setup, and report what needs adjusting:
+
+  - Version, and the version seen by the previous audit (.agent/asterisk-version)
+  - chan_audiosocket vs app_audiosocket: ai_engine originates AudioSocket
+    channels over ARI, so the channel driver must be loaded, not only the app
+  - ARI modules and the channel operations ai_engine calls (channels.json)
+  - rtp.conf ranges against external_media.port_range
+  - chan_sip peers on Asterisk 21+, where chan_sip was removed
+
+Asterisk is reached through docker exec into the Asterisk container, or
+asterisk -rx on this host; ARI uses the .env credentials.
+
+Exit codes:
+  0 - PASS (no warnings)
+  1 - WARN (non-critical issues)
+  2 - FAIL (critical issues)
+  4 - Asterisk CLI not reachable`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		troubleshoot.LoadEnvFile()
+		opts := dialplan.LoadOptions(configLayers()...)
+		audit := check.AsteriskAudit{
+			Transport:          opts.Transport,
+			ExternalMediaPorts: opts.ExternalMediaPorts,
+			StatePath:          filepath.Join(".agent", "asterisk-version"),
+		}
+		if cfg, err := wizard.LoadConfig(); err == nil {
+			if cfg.AsteriskHost == "" {
+				cfg.AsteriskHost = "127.0.0.1"
+			}
+			audit.ARIBaseURL = cfg.ARIBaseURL()
+			audit.ARIUsername, audit.ARIPassword = cfg.AsteriskUsername, cfg.AsteriskPassword
+		}
+
+		runner := check.NewRunner(verbose, version, buildTime)
+		report, err := runner.RunAsteriskAudit(audit)
+		return reportResult(report, err, upgradeAsteriskJSON)
+	},
+}
+
+func init() {
+	upgradeAsteriskCmd.Flags().BoolVar(&upgradeAsteriskJSON, "json", false, "output as JSON (JSON only)")
+	rootCmd.AddCommand(upgradeAsteriskCmd)
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -10,11 +13,19 @@ var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
 	Long:  "Display the version of the agent CLI tool",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if outputFormat.Structured() {
+			return output.Write(os.Stdout, outputFormat, struct {
+				SchemaVersion int    `json:"schema_version"`
+				Version       string `json:"version"`
+				BuildTime     string `json:"build_time"`
+			}{contract.SchemaVersion, version, buildTime})
+		}
 		fmt.Printf("Asterisk AI Voice Agent CLI\n")
 		fmt.Printf("Version:    %s\n", version)
 		fmt.Printf("Built:      %s\n", buildTime)
 		fmt.Printf("Repository: https://github.com/hkjarral/AVA-AI-Voice-Agent-for-Asterisk\n")
+		return nil
 	},
 }
 
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/ami"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchJSON        bool
+	watchVar         string
+	watchAddr        string
+	watchEngineEvery time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Follow live calls through the Asterisk Manager Interface",
+	Long: `Connect to AMI and print call events as they happen: new channels,
+answer, DTMF digits, channel variables and hangups with cause and duration.
+
+This works on systems where ai_engine does not log ARI events verbosely
+enough for agent logs --call. Credentials come from .env:
+  ASTERISK_AMI_USERNAME, ASTERISK_AMI_SECRET
+  ASTERISK_HOST, ASTERISK_AMI_PORT (default 5038), or AAVA_AMI_ADDR=host:port
+
+Every --engine-poll interval it also asks ai_engine's health server for its
+active sessions and prints "engine" lines when a call starts, changes
+conversation state or ends on the engine side. With budgets in
+.agent/budgets.yaml (see agent rca --help), it prints a "budget" line the
+first time a live call passes its duration or cost budget; calls are timed
+from the poll that first saw them.
+
+With endpoints in .agent/webhooks.yaml (or AAVA_WEBHOOKS_FILE), each call the
+engine ends is analyzed as agent rca does 15 seconds later, and its
+disposition (caller, duration, outcome, conversation summary, quality score)
+is posted to each endpoint as JSON, signed with HMAC-SHA256 and retried with
+backoff; a "webhook" line reports each delivery:
+
+  endpoints:
+    - name: crm
+      url: https://crm.example.com/hooks/calls
+      secret_env: CRM_WEBHOOK_SECRET   # HMAC key, from the environment or .env
+      headers:
+        Authorization: Bearer ${CRM_API_TOKEN}
+      retries: 3                       # default 3
+      timeout: 10s                     # per attempt, default 10s
+      transcript: summary              # none, summary (default) or full
+
+The summary comes from the LLM agent rca uses to score conversations
+(OPENAI_API_KEY or ANTHROPIC_API_KEY) and is left out without one.
+
+Examples:
+  agent watch
+  agent watch --engine-poll 0
+  agent watch --var 'AI_*,DIALSTATUS'
+  agent watch --json | jq .`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		troubleshoot.LoadEnvFile()
+		budgets, err := budget.Load(budget.Path())
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		hooksCfg, err := webhook.Load(webhook.Path())
+		if err != nil {
+			return contract.UsageError(err)
+		}
+		if hooksCfg != nil && watchEngineEvery <= 0 {
+			return contract.UsageError(fmt.Errorf("%s needs the engine poll; drop --engine-poll 0", webhook.Path()))
+		}
+		cfg := ami.ConfigFromEnv()
+		if watchAddr != "" {
+			cfg.Addr = watchAddr
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		client, err := ami.Dial(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		format := structuredOutput(watchJSON)
+		if !format.Structured() {
+			fmt.Fprintf(os.Stderr, "Watching calls on %s (Ctrl+C to stop)\n", cfg.Addr)
+		}
+
+		varGlobs := splitList(watchVar)
+		tracker := ami.NewTracker()
+		enc := json.NewEncoder(os.Stdout)
+		events, errc := client.Events(ctx, ami.CallEvents)
+		var engine <-chan engineUpdate
+		if watchEngineEvery > 0 {
+			engine = pollEngineSessions(ctx, watchEngineEvery, budgets)
+		}
+		var ended chan<- endedCall
+		var deliveries <-chan webhookDelivery
+		if hooksCfg != nil {
+			ended, deliveries = exportDispositions(ctx, hooksCfg, budgets)
+		}
+		for {
+			var ev ami.Message
+			select {
+			case u, ok := <-engine:
+				if !ok {
+					engine = nil
+					continue
+				}
+				printEngineUpdate(format, enc, u)
+				if u.Ended && ended != nil {
+					select {
+					case ended <- endedCall{CallID: u.Session.CallID, At: time.Now()}:
+					default:
+						fmt.Fprintf(os.Stderr, "⚠️  %s: too many calls waiting for their webhook; disposition not sent\n", u.Session.CallID)
+					}
+				}
+				continue
+			case d, ok := <-deliveries:
+				if !ok {
+					deliveries = nil
+					continue
+				}
+				printWebhookDelivery(format, enc, d)
+				continue
+			case m, ok := <-events:
+				if !ok {
+					return <-errc
+				}
+				ev = m
+			}
+			if ev.Event() == "VarSet" && !matchAny(varGlobs, ev["Variable"]) {
+				continue
+			}
+			if ev.Event() == "DTMFBegin" {
+				continue // DTMFEnd carries the digit and duration
+			}
+			ch := tracker.Observe(ev)
+			if format.Structured() {
+				line := map[string]any{"schema_version": contract.SchemaVersion}
+				for k, v := range ev {
+					line[k] = v
+				}
+				if format == output.YAML {
+					// One YAML document per event.
+					fmt.Println("---")
+					_ = output.Write(os.Stdout, format, line)
+				} else {
+					_ = enc.Encode(line)
+				}
+				continue
+			}
+			if line := describeWatchEvent(ev, ch); line != "" {
+				fmt.Printf("%s  %s\n", time.Now().Format("15:04:05"), line)
+			}
+		}
+	},
+}
+
+func describeWatchEvent(ev ami.Message, ch *ami.Channel) string {
+	if ch == nil {
+		return ""
+	}
+	switch ev.Event() {
+	case "Newchannel":
+		from := ch.CallerIDNum
+		if ch.CallerIDName != "" {
+			from = fmt.Sprintf("%s <%s>", ch.CallerIDName, ch.CallerIDNum)
+		}
+		return fmt.Sprintf("%s  new     %s from %s to %s", ch.UniqueID, ch.Name, orDash(from), orDash(ch.Exten))
+	case "Newstate":
+		if ev["ChannelStateDesc"] != "Up" {
+			return ""
+		}
+		return fmt.Sprintf("%s  answer  %s", ch.UniqueID, ch.Name)
+	case "DTMFEnd":
+		return fmt.Sprintf("%s  dtmf    %s", ch.UniqueID, ev["Digit"])
+	case "VarSet":
+		return fmt.Sprintf("%s  var     %s=%s", ch.UniqueID, ev["Variable"], ev["Value"])
+	case "Hangup":
+		cause := ch.CauseText
+		if cause == "" {
+			cause = "unknown cause"
+		}
+		return fmt.Sprintf("%s  hangup  %s after %s (%s, %d)", ch.UniqueID, ch.Name, ch.Duration.Round(time.Second), cause, ch.Cause)
+	}
+	return ""
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func matchAny(globs []string, name string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	watchCmd.Flags().BoolVar(&watchJSON, "json", false, "print raw AMI events as JSON lines")
+	watchCmd.Flags().StringVar(&watchVar, "var", "AI_*", "comma-separated channel variable globs to show from VarSet events (empty = none)")
+	watchCmd.Flags().StringVar(&watchAddr, "ami", "", "AMI address host:port (default: from .env)")
+	watchCmd.Flags().DurationVar(&watchEngineEvery, "engine-poll", 5*time.Second, "how often to ask ai_engine for its active sessions (0 = AMI events only)")
+	rootCmd.AddCommand(watchCmd)
+}
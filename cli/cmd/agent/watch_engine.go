@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+)
+
+// engineUpdate is a change in the engine's view of one call, a live call over
+// one of its budgets, or a poll error.
+type engineUpdate struct {
+	Session engineapi.Session
+	Ended   bool
+	Breach  *budget.Breach
+	Err     error
+}
+
+// pollEngineSessions reports calls starting, changing conversation state and
+// ending as the engine's /sessions/stats sees them, and, with budgets, each
+// call the first time it passes its duration or cost budget. Errors are sent
+// once per outage; an engine without the endpoint ends the polling.
+func pollEngineSessions(ctx context.Context, every time.Duration, budgets *budget.Config) <-chan engineUpdate {
+	out := make(chan engineUpdate)
+	go func() {
+		defer close(out)
+		client := engineapi.New()
+		prev := map[string]engineapi.Session{}
+		live := newLiveBudgets(budgets)
+		failing := false
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			var updates []engineUpdate
+			s, err := client.Sessions(ctx)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				return
+			case err != nil:
+				if !failing {
+					updates = append(updates, engineUpdate{Err: err})
+				}
+				failing = true
+			default:
+				failing = false
+				var next map[string]engineapi.Session
+				updates, next = diffEngineSessions(prev, s.Sessions)
+				prev = next
+				updates = append(updates, live.check(s.Sessions, time.Now())...)
+			}
+			for _, u := range updates {
+				select {
+				case out <- u:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if errors.Is(err, engineapi.ErrUnsupported) {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func diffEngineSessions(prev map[string]engineapi.Session, sessions []engineapi.Session) ([]engineUpdate, map[string]engineapi.Session) {
+	var updates []engineUpdate
+	next := make(map[string]engineapi.Session, len(sessions))
+	for _, s := range sessions {
+		next[s.CallID] = s
+		if old, ok := prev[s.CallID]; !ok || old.Status != s.Status || old.ConversationState != s.ConversationState {
+			updates = append(updates, engineUpdate{Session: s})
+		}
+	}
+	for id, s := range prev {
+		if _, ok := next[id]; !ok {
+			updates = append(updates, engineUpdate{Session: s, Ended: true})
+		}
+	}
+	return updates, next
+}
+
+// liveBudgets times the engine's calls from the poll that first saw them, so
+// a call already up when agent watch started is timed from then.
+type liveBudgets struct {
+	cfg     *budget.Config
+	since   map[string]time.Time
+	flagged map[string]bool // call ID + budget
+}
+
+func newLiveBudgets(cfg *budget.Config) *liveBudgets {
+	return &liveBudgets{cfg: cfg, since: map[string]time.Time{}, flagged: map[string]bool{}}
+}
+
+// check reports the breaches of sessions not reported before.
+func (b *liveBudgets) check(sessions []engineapi.Session, now time.Time) []engineUpdate {
+	if b.cfg == nil {
+		return nil
+	}
+	var updates []engineUpdate
+	up := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		up[s.CallID] = true
+		since, ok := b.since[s.CallID]
+		if !ok {
+			b.since[s.CallID] = now
+			continue
+		}
+		res := b.cfg.Evaluate(budget.Usage{Pipeline: s.Pipeline, Provider: s.Provider, Duration: now.Sub(since)})
+		for _, br := range res.Breaches {
+			if key := s.CallID + "/" + br.Budget; !b.flagged[key] {
+				b.flagged[key] = true
+				br := br
+				updates = append(updates, engineUpdate{Session: s, Breach: &br})
+			}
+		}
+	}
+	for id := range b.since {
+		if !up[id] {
+			delete(b.since, id)
+			for _, name := range budget.Names {
+				delete(b.flagged, id+"/"+name)
+			}
+		}
+	}
+	return updates
+}
+
+func describeEngineUpdate(u engineUpdate) string {
+	if u.Err != nil {
+		if errors.Is(u.Err, engineapi.ErrUnsupported) {
+			return "engine does not report sessions (older release); showing AMI events only"
+		}
+		return fmt.Sprintf("engine status unavailable: %v", u.Err)
+	}
+	s := u.Session
+	if u.Breach != nil {
+		return fmt.Sprintf("%s  budget  %s (%s)", s.CallID, u.Breach.Finding, u.Breach.Severity)
+	}
+	if u.Ended {
+		return fmt.Sprintf("%s  engine  session ended", s.CallID)
+	}
+	target := s.Provider
+	if s.Pipeline != "" {
+		target = "pipeline " + s.Pipeline
+	}
+	return fmt.Sprintf("%s  engine  %s via %s (%s)", s.CallID, orDash(s.ConversationState), orDash(target), orDash(s.Status))
+}
+
+// printEngineUpdate writes an update next to the AMI events: one line with the
+// same time column, or one object with "source": "engine" in structured output.
+// Poll errors go to stderr in text mode.
+func printEngineUpdate(format output.Format, enc *json.Encoder, u engineUpdate) {
+	if format.Structured() {
+		line := map[string]any{"schema_version": contract.SchemaVersion, "source": "engine"}
+		if u.Err != nil {
+			line["error"] = u.Err.Error()
+		} else {
+			line["call_id"] = u.Session.CallID
+			line["provider"] = u.Session.Provider
+			line["pipeline"] = u.Session.Pipeline
+			line["status"] = u.Session.Status
+			line["conversation_state"] = u.Session.ConversationState
+			line["ended"] = u.Ended
+			if u.Breach != nil {
+				line["budget"] = u.Breach
+			}
+		}
+		if format == output.YAML {
+			fmt.Println("---")
+			_ = output.Write(os.Stdout, format, line)
+		} else {
+			_ = enc.Encode(line)
+		}
+		return
+	}
+	if u.Err != nil {
+		fmt.Fprintln(os.Stderr, describeEngineUpdate(u))
+		return
+	}
+	fmt.Printf("%s  %s\n", time.Now().Format("15:04:05"), describeEngineUpdate(u))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/webhook"
+)
+
+// webhookSettle is how long after the engine ends a call its disposition is
+// sent, so that Call History and the CDR hold the call.
+const webhookSettle = 15 * time.Second
+
+// endedCall is a call the engine has ended.
+type endedCall struct {
+	CallID string
+	At     time.Time
+}
+
+// webhookDelivery is the outcome of sending one call's disposition.
+type webhookDelivery struct {
+	CallID  string
+	Results []webhook.Result
+	Err     error // the call could not be analyzed
+}
+
+// exportDispositions analyzes each call sent on the returned channel once it
+// has settled and posts its disposition to the configured endpoints. Calls
+// are handled one at a time, in the order they ended.
+func exportDispositions(ctx context.Context, cfg *webhook.Config, budgets *budget.Config) (chan<- endedCall, <-chan webhookDelivery) {
+	calls := make(chan endedCall, 256)
+	out := make(chan webhookDelivery)
+	go func() {
+		defer close(out)
+		for {
+			var c endedCall
+			select {
+			case c = <-calls:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-time.After(time.Until(c.At.Add(webhookSettle))):
+			case <-ctx.Done():
+				return
+			}
+			d := sendDisposition(ctx, cfg, budgets, c.CallID)
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return calls, out
+}
+
+func sendDisposition(ctx context.Context, cfg *webhook.Config, budgets *budget.Config, callID string) webhookDelivery {
+	res := webhookDelivery{CallID: callID}
+	runner := troubleshoot.NewRunner(callID, "", false, false, true, false, false, false, verbose)
+	runner.SetSilent(true)
+	runner.SetBudgets(budgets)
+	runner.SetConversationQuality(cfg.Wants(webhook.TranscriptSummary))
+	if err := runner.Run(); err != nil {
+		res.Err = err
+		return res
+	}
+	d, err := runner.Disposition(cfg.Wants(webhook.TranscriptFull))
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	for _, e := range cfg.Endpoints {
+		payload := d
+		switch e.Transcript {
+		case webhook.TranscriptNone:
+			payload.Summary, payload.Transcript = "", nil
+		case webhook.TranscriptSummary:
+			payload.Transcript = nil
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		res.Results = append(res.Results, e.Deliver(ctx, webhook.EventCallCompleted, callID, body))
+	}
+	return res
+}
+
+// printWebhookDelivery writes one "webhook" line per endpoint next to the AMI
+// events, or one object per endpoint with "source": "webhook" in structured
+// output.
+func printWebhookDelivery(format output.Format, enc *json.Encoder, d webhookDelivery) {
+	if format.Structured() {
+		lines := []map[string]any{}
+		if d.Err != nil {
+			lines = append(lines, map[string]any{"error": d.Err.Error()})
+		}
+		for _, r := range d.Results {
+			line := map[string]any{"endpoint": r.Endpoint, "status": r.Status, "attempts": r.Attempts}
+			if r.Error != "" {
+				line["error"] = r.Error
+			}
+			lines = append(lines, line)
+		}
+		for _, line := range lines {
+			line["schema_version"] = contract.SchemaVersion
+			line["source"] = "webhook"
+			line["call_id"] = d.CallID
+			if format == output.YAML {
+				fmt.Println("---")
+				_ = output.Write(os.Stdout, format, line)
+			} else {
+				_ = enc.Encode(line)
+			}
+		}
+		return
+	}
+	now := time.Now().Format("15:04:05")
+	if d.Err != nil {
+		fmt.Printf("%s  %s  webhook  no disposition sent: %v\n", now, d.CallID, d.Err)
+		return
+	}
+	for _, r := range d.Results {
+		if r.Error != "" {
+			fmt.Printf("%s  %s  webhook  %s failed after %d attempt(s): %s\n", now, d.CallID, r.Endpoint, r.Attempts, r.Error)
+			continue
+		}
+		fmt.Printf("%s  %s  webhook  sent to %s (HTTP %d)\n", now, d.CallID, r.Endpoint, r.Status)
+	}
+}
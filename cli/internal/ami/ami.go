@@ -0,0 +1,224 @@
+// Package ami is a minimal Asterisk Manager Interface client for live call events
+// (Newchannel, Hangup, VarSet, DTMF). It is an alternative to engine logs on systems
+// where ARI events are not logged verbosely enough to track calls.
+package ami
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPort is the manager.conf default.
+const DefaultPort = "5038"
+
+// Message is one AMI packet (event or response). Keys keep AMI's capitalization.
+type Message map[string]string
+
+// Event returns the event name ("" for responses).
+func (m Message) Event() string { return m["Event"] }
+
+// Config holds the manager.conf account used to connect.
+type Config struct {
+	Addr     string // host:port
+	Username string
+	Secret   string
+	Timeout  time.Duration
+}
+
+// ConfigFromEnv reads AAVA_AMI_ADDR (or ASTERISK_HOST + ASTERISK_AMI_PORT),
+// ASTERISK_AMI_USERNAME and ASTERISK_AMI_SECRET, following the .env naming of the
+// ARI settings.
+func ConfigFromEnv() Config {
+	addr := strings.TrimSpace(os.Getenv("AAVA_AMI_ADDR"))
+	if addr == "" {
+		host := strings.TrimSpace(os.Getenv("ASTERISK_HOST"))
+		if host == "" {
+			host = "127.0.0.1"
+		}
+		port := strings.TrimSpace(os.Getenv("ASTERISK_AMI_PORT"))
+		if port == "" {
+			port = DefaultPort
+		}
+		addr = net.JoinHostPort(host, port)
+	}
+	return Config{
+		Addr:     addr,
+		Username: strings.TrimSpace(os.Getenv("ASTERISK_AMI_USERNAME")),
+		Secret:   os.Getenv("ASTERISK_AMI_SECRET"),
+		Timeout:  10 * time.Second,
+	}
+}
+
+// Client is one logged-in manager session.
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	mu     sync.Mutex
+	nextID int
+}
+
+// Dial connects and logs in with event delivery enabled.
+func Dial(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.Username == "" {
+		return nil, errors.New("AMI username not set (ASTERISK_AMI_USERNAME)")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("AMI connect %s: %w", cfg.Addr, err)
+	}
+	c := NewClient(conn)
+	if err := c.login(cfg, timeout); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewClient wraps an established connection (tests, custom transports). Call Login
+// before reading events.
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *Client) login(cfg Config, timeout time.Duration) error {
+	_ = c.conn.SetDeadline(time.Now().Add(timeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	banner, err := c.r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("AMI banner: %w", err)
+	}
+	if !strings.HasPrefix(banner, "Asterisk Call Manager") {
+		return fmt.Errorf("unexpected AMI banner %q", strings.TrimSpace(banner))
+	}
+	return c.Login(cfg.Username, cfg.Secret)
+}
+
+// Login authenticates after the banner has been read.
+func (c *Client) Login(username, secret string) error {
+	id, err := c.Send("Login", Message{"Username": username, "Secret": secret, "Events": "on"})
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := c.Read()
+		if err != nil {
+			return fmt.Errorf("AMI login: %w", err)
+		}
+		if msg["ActionID"] != id {
+			continue
+		}
+		if !strings.EqualFold(msg["Response"], "Success") {
+			return fmt.Errorf("AMI login rejected: %s", msg["Message"])
+		}
+		return nil
+	}
+}
+
+// Send writes an action and returns its ActionID.
+func (c *Client) Send(action string, fields Message) (string, error) {
+	c.mu.Lock()
+	c.nextID++
+	id := fmt.Sprintf("aava-%d", c.nextID)
+	c.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Action: %s\r\nActionID: %s\r\n", action, id)
+	for k, v := range fields {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	b.WriteString("\r\n")
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Read returns the next packet. Packets are "Key: Value" lines ending with a blank line.
+func (c *Client) Read() (Message, error) {
+	msg := Message{}
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(msg) == 0 {
+				continue
+			}
+			return msg, nil
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		msg[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+}
+
+// Events reads packets until ctx is cancelled or the connection fails, delivering the
+// events named in want (all events when want is empty). The error channel receives the
+// terminating error (nil on cancellation) and is then closed.
+func (c *Client) Events(ctx context.Context, want map[string]bool) (<-chan Message, <-chan error) {
+	events := make(chan Message, 64)
+	errc := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		_ = c.conn.SetReadDeadline(time.Now())
+	}()
+	go func() {
+		defer close(events)
+		defer close(errc)
+		for {
+			msg, err := c.Read()
+			if err != nil {
+				if ctx.Err() != nil {
+					err = nil
+				}
+				errc <- err
+				return
+			}
+			name := msg.Event()
+			if name == "" || (len(want) > 0 && !want[name]) {
+				continue
+			}
+			select {
+			case events <- msg:
+			case <-ctx.Done():
+				errc <- nil
+				return
+			}
+		}
+	}()
+	return events, errc
+}
+
+// Close logs off and closes the connection.
+func (c *Client) Close() error {
+	_, _ = c.Send("Logoff", nil)
+	return c.conn.Close()
+}
+
+// CallEvents are the events used for call tracking.
+var CallEvents = map[string]bool{
+	"Newchannel": true,
+	"Hangup":     true,
+	"VarSet":     true,
+	"DTMFBegin":  true,
+	"DTMFEnd":    true,
+	"Newstate":   true,
+}
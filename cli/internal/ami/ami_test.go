@@ -0,0 +1,126 @@
+package ami
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeManager accepts a Login, answers it, then writes the given events.
+func fakeManager(t *testing.T, conn net.Conn, events []string) {
+	t.Helper()
+	go func() {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("Asterisk Call Manager/9.0.0\r\n"))
+		var actionID string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "ActionID: ") {
+				actionID = strings.TrimPrefix(line, "ActionID: ")
+			}
+			if line == "" && actionID != "" {
+				break
+			}
+		}
+		conn.Write([]byte("Event: FullyBooted\r\nStatus: Fully Booted\r\n\r\n"))
+		conn.Write([]byte("Response: Success\r\nActionID: " + actionID + "\r\nMessage: Authentication accepted\r\n\r\n"))
+		for _, ev := range events {
+			conn.Write([]byte(ev))
+		}
+		// Keep the connection open until the client goes away.
+		_, _ = r.ReadString(0)
+	}()
+}
+
+func TestEventsFiltersCallEvents(t *testing.T) {
+	server, client := net.Pipe()
+	fakeManager(t, server, []string{
+		"Event: Newchannel\r\nChannel: PJSIP/trunk-00000001\r\nCallerIDNum: 15551234567\r\nExten: 7000\r\nUniqueid: 1761518880.2191\r\n\r\n",
+		"Event: RTCPSent\r\nUniqueid: 1761518880.2191\r\n\r\n",
+		"Event: DTMFEnd\r\nUniqueid: 1761518880.2191\r\nDigit: 5\r\n\r\n",
+		"Event: Hangup\r\nUniqueid: 1761518880.2191\r\nCause: 16\r\nCause-txt: Normal Clearing\r\n\r\n",
+	})
+
+	c := NewClient(client)
+	if _, err := c.r.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Login("aava", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	events, _ := c.Events(ctx, CallEvents)
+
+	var got []string
+	for ev := range events {
+		got = append(got, ev.Event())
+		if ev.Event() == "Hangup" {
+			break
+		}
+	}
+	if strings.Join(got, ",") != "Newchannel,DTMFEnd,Hangup" {
+		t.Fatalf("events = %v", got)
+	}
+}
+
+func TestLoginRejected(t *testing.T) {
+	server, client := net.Pipe()
+	go func() {
+		defer server.Close()
+		r := bufio.NewReader(server)
+		var id string
+		for {
+			line, _ := r.ReadString('\n')
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "ActionID: ") {
+				id = strings.TrimPrefix(line, "ActionID: ")
+			}
+			if line == "" {
+				break
+			}
+		}
+		server.Write([]byte("Response: Error\r\nActionID: " + id + "\r\nMessage: Authentication failed\r\n\r\n"))
+	}()
+	err := NewClient(client).Login("aava", "wrong")
+	if err == nil || !strings.Contains(err.Error(), "Authentication failed") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestTrackerFollowsCall(t *testing.T) {
+	now := time.Date(2026, 1, 30, 14, 0, 0, 0, time.UTC)
+	tr := NewTracker()
+	tr.now = func() time.Time { return now }
+
+	tr.Observe(Message{"Event": "Newchannel", "Uniqueid": "1.1", "Channel": "PJSIP/trunk-1", "CallerIDNum": "15551234567", "CallerIDName": "<unknown>"})
+	now = now.Add(2 * time.Second)
+	tr.Observe(Message{"Event": "Newstate", "Uniqueid": "1.1", "ChannelStateDesc": "Up"})
+	tr.Observe(Message{"Event": "DTMFEnd", "Uniqueid": "1.1", "Digit": "4"})
+	tr.Observe(Message{"Event": "DTMFEnd", "Uniqueid": "1.1", "Digit": "2", "Direction": "Received"})
+	tr.Observe(Message{"Event": "DTMFEnd", "Uniqueid": "1.1", "Digit": "9", "Direction": "Sent"})
+	if n := len(tr.Active()); n != 1 {
+		t.Fatalf("active = %d", n)
+	}
+	now = now.Add(40 * time.Second)
+	ch := tr.Observe(Message{"Event": "Hangup", "Uniqueid": "1.1", "Cause": "16", "Cause-txt": "Normal Clearing"})
+
+	if ch.CallerIDNum != "15551234567" || ch.CallerIDName != "" || ch.DTMF != "42" {
+		t.Fatalf("channel = %+v", ch)
+	}
+	if ch.Answered == nil || ch.Cause != 16 || ch.Duration != 42*time.Second {
+		t.Fatalf("hangup = %+v", ch)
+	}
+	if n := len(tr.Active()); n != 0 {
+		t.Fatalf("active after hangup = %d", n)
+	}
+}
@@ -0,0 +1,92 @@
+package ami
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Channel is a channel followed from Newchannel to Hangup.
+type Channel struct {
+	UniqueID     string        `json:"uniqueid"`
+	LinkedID     string        `json:"linkedid,omitempty"`
+	Name         string        `json:"channel"`
+	CallerIDNum  string        `json:"caller_id_num,omitempty"`
+	CallerIDName string        `json:"caller_id_name,omitempty"`
+	Context      string        `json:"context,omitempty"`
+	Exten        string        `json:"exten,omitempty"`
+	Start        time.Time     `json:"start"`
+	Answered     *time.Time    `json:"answered,omitempty"`
+	DTMF         string        `json:"dtmf,omitempty"`
+	Cause        int           `json:"cause,omitempty"`
+	CauseText    string        `json:"cause_txt,omitempty"`
+	Duration     time.Duration `json:"-"` // set on Hangup
+}
+
+// Tracker keeps state for channels that are up.
+type Tracker struct {
+	channels map[string]*Channel
+	now      func() time.Time
+}
+
+// NewTracker returns an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{channels: map[string]*Channel{}, now: time.Now}
+}
+
+// Observe applies an event and returns the channel it refers to (nil when the event
+// has no Uniqueid). After a Hangup, the returned channel is no longer active and
+// carries its cause and duration.
+func (t *Tracker) Observe(m Message) *Channel {
+	id := m["Uniqueid"]
+	if id == "" {
+		return nil
+	}
+	ch := t.channels[id]
+	if ch == nil {
+		ch = &Channel{UniqueID: id, Start: t.now()}
+		if m.Event() != "Hangup" {
+			t.channels[id] = ch
+		}
+	}
+	set := func(dst *string, key string) {
+		if v := m[key]; v != "" && v != "<unknown>" {
+			*dst = v
+		}
+	}
+	set(&ch.Name, "Channel")
+	set(&ch.LinkedID, "Linkedid")
+	set(&ch.CallerIDNum, "CallerIDNum")
+	set(&ch.CallerIDName, "CallerIDName")
+	set(&ch.Context, "Context")
+	set(&ch.Exten, "Exten")
+
+	switch m.Event() {
+	case "Newstate":
+		if m["ChannelStateDesc"] == "Up" && ch.Answered == nil {
+			at := t.now()
+			ch.Answered = &at
+		}
+	case "DTMFEnd":
+		// Digits Asterisk sends toward the channel were not pressed on it.
+		if m["Direction"] != "Sent" {
+			ch.DTMF += m["Digit"]
+		}
+	case "Hangup":
+		ch.Cause, _ = strconv.Atoi(m["Cause"])
+		ch.CauseText = m["Cause-txt"]
+		ch.Duration = t.now().Sub(ch.Start)
+		delete(t.channels, id)
+	}
+	return ch
+}
+
+// Active returns channels that are up, oldest first.
+func (t *Tracker) Active() []*Channel {
+	out := make([]*Channel, 0, len(t.channels))
+	for _, ch := range t.channels {
+		out = append(out, ch)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
@@ -0,0 +1,267 @@
+// Package archive copies the evidence the CLI produces to S3-compatible object
+// storage, so it outlives the host: RCA reports and the call's recordings and
+// packet captures under a prefix per call, agent check and agent digest
+// reports, and the quality and check histories. Each object is tagged with its
+// kind and a retention value, for the bucket's lifecycle rules to expire.
+//
+// The store is configured in .agent/storage.yaml; credentials come from the
+// environment (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN).
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Object kinds, which are also the retention keys in storage.yaml.
+const (
+	KindCall      = "calls"      // RCA reports
+	KindRecording = "recordings" // call recordings and packet captures
+	KindCheck     = "checks"
+	KindDigest    = "digests"
+	KindMetrics   = "metrics"
+)
+
+// Kinds lists the object kinds.
+var Kinds = []string{KindCall, KindRecording, KindCheck, KindDigest, KindMetrics}
+
+// Timeout bounds one upload.
+const Timeout = 5 * time.Minute
+
+var (
+	// tagPattern is what S3 allows in tag keys and values.
+	tagPattern = regexp.MustCompile(`^[A-Za-z0-9 +\-=._:/@]{1,256}$`)
+	unsafeKey  = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// Config is .agent/storage.yaml.
+type Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	Prefix    string `yaml:"prefix"`
+	PathStyle bool   `yaml:"path_style"`
+	// Sink archives every agent rca, agent check and agent digest report as
+	// it is printed.
+	Sink bool `yaml:"sink"`
+	// Recordings adds a call's recordings and captures when its report is archived.
+	Recordings bool `yaml:"recordings"`
+	// Retention is the retention tag value per kind, e.g. calls: 400d.
+	Retention map[string]string `yaml:"retention"`
+	// Tags are added to every object.
+	Tags map[string]string `yaml:"tags"`
+}
+
+// Path returns the configuration file.
+func Path() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_STORAGE_FILE")); p != "" {
+		return p
+	}
+	return filepath.Join(".agent", "storage.yaml")
+}
+
+// Load reads and checks the configuration. A missing file returns nil and no
+// error: archival is off.
+func Load(file string) (*Config, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(raw, cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	if strings.TrimSpace(c.Bucket) == "" {
+		return errors.New("bucket is required")
+	}
+	if c.Region == "" {
+		c.Region = "us-east-1"
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = "https://s3." + c.Region + ".amazonaws.com"
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("endpoint %q is not an http(s) URL", c.Endpoint)
+	}
+	c.Prefix = strings.Trim(c.Prefix, "/")
+	for kind, v := range c.Retention {
+		if !knownKind(kind) {
+			return fmt.Errorf("retention: unknown kind %q (use %s)", kind, strings.Join(Kinds, ", "))
+		}
+		if !tagPattern.MatchString(v) {
+			return fmt.Errorf("retention: %s: %q is not a valid tag value", kind, v)
+		}
+	}
+	if len(c.Tags) > 8 {
+		return errors.New("tags: at most 8 (S3 allows 10 per object; kind and retention use two)")
+	}
+	for k, v := range c.Tags {
+		if k == "kind" || k == "retention" || !tagPattern.MatchString(k) || (v != "" && !tagPattern.MatchString(v)) {
+			return fmt.Errorf("tags: %q=%q is not allowed", k, v)
+		}
+	}
+	return nil
+}
+
+func knownKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Archive uploads to the configured store.
+type Archive struct {
+	cfg *Config
+	s3  *S3
+}
+
+// New connects cfg with the credentials in the environment. host names the
+// deployment and is the prefix when the configuration leaves it empty.
+func New(cfg *Config, host string) (*Archive, error) {
+	access := strings.TrimSpace(os.Getenv("AWS_ACCESS_KEY_ID"))
+	secret := strings.TrimSpace(os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	if access == "" || secret == "" {
+		return nil, errors.New("object storage needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY (in the environment or .env)")
+	}
+	endpoint, _ := url.Parse(cfg.Endpoint)
+	c := *cfg
+	if c.Prefix == "" {
+		c.Prefix = safeSegment(host)
+	}
+	return &Archive{cfg: &c, s3: &S3{
+		Endpoint:     endpoint,
+		Region:       c.Region,
+		Bucket:       c.Bucket,
+		PathStyle:    c.PathStyle,
+		AccessKey:    access,
+		SecretKey:    secret,
+		SessionToken: strings.TrimSpace(os.Getenv("AWS_SESSION_TOKEN")),
+		HTTP:         &http.Client{Timeout: Timeout},
+	}}, nil
+}
+
+// Config returns the configuration in use, with the prefix filled in.
+func (a *Archive) Config() *Config { return a.cfg }
+
+// Location is the store and prefix, for messages: s3://bucket/prefix/.
+func (a *Archive) Location() string {
+	return "s3://" + path.Join(a.cfg.Bucket, a.cfg.Prefix) + "/"
+}
+
+// CallKey is where a file of a call's bundle is kept:
+// <prefix>/calls/<call_id>/<name>.
+func (a *Archive) CallKey(callID, name string) string {
+	return a.key(KindCall, safeSegment(callID), safeSegment(name))
+}
+
+// ReportKey is where a check or digest report taken at t is kept:
+// <prefix>/<kind>/<YYYY-MM-DD>/<time>.json.
+func (a *Archive) ReportKey(kind string, t time.Time) string {
+	t = t.UTC()
+	return a.key(kind, t.Format("2006-01-02"), t.Format("150405Z")+".json")
+}
+
+// MetricsKey is where a history file exported at t is kept:
+// <prefix>/metrics/<YYYY-MM-DD>/<name>.
+func (a *Archive) MetricsKey(name string, t time.Time) string {
+	return a.key(KindMetrics, t.UTC().Format("2006-01-02"), safeSegment(name))
+}
+
+func (a *Archive) key(parts ...string) string {
+	if a.cfg.Prefix != "" {
+		parts = append([]string{a.cfg.Prefix}, parts...)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Tags are the object's tags: the configured ones, its kind and its retention.
+func (a *Archive) Tags(kind string) map[string]string {
+	tags := map[string]string{"kind": kind}
+	for k, v := range a.cfg.Tags {
+		tags[k] = v
+	}
+	if r := a.cfg.Retention[kind]; r != "" {
+		tags["retention"] = r
+	}
+	return tags
+}
+
+// PutJSON uploads v as indented JSON.
+func (a *Archive) PutJSON(kind, key string, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return a.PutBytes(kind, key, append(raw, '\n'), "application/json")
+}
+
+// PutBytes uploads data.
+func (a *Archive) PutBytes(kind, key string, data []byte, contentType string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	return a.s3.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType, a.Tags(kind))
+}
+
+// PutFile uploads a file.
+func (a *Archive) PutFile(kind, key, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	return a.s3.Put(ctx, key, f, st.Size(), contentType(file), a.Tags(kind))
+}
+
+func contentType(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		return "application/json"
+	case ".jsonl":
+		return "application/x-ndjson"
+	case ".html":
+		return "text/html; charset=utf-8"
+	case ".wav":
+		return "audio/wav"
+	case ".pcap":
+		return "application/vnd.tcpdump.pcap"
+	}
+	return "application/octet-stream"
+}
+
+// safeSegment keeps a key segment to characters every store accepts.
+func safeSegment(s string) string {
+	return strings.Trim(unsafeKey.ReplaceAllString(s, "_"), "_.")
+}
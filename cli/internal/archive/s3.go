@@ -0,0 +1,202 @@
+package archive
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 uploads objects to an S3-compatible store (AWS S3, MinIO, Ceph RGW,
+// Backblaze B2, Wasabi, ...) with Signature Version 4. Only single PUTs are
+// needed, so there is no SDK.
+type S3 struct {
+	Endpoint     *url.URL
+	Region       string
+	Bucket       string
+	PathStyle    bool
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	HTTP         *http.Client
+	now          func() time.Time
+}
+
+// Put uploads body as key. size is the body's length; the body is read once
+// to sign it and again to send it.
+func (c *S3) Put(ctx context.Context, key string, body io.ReadSeeker, size int64, contentType string, tags map[string]string) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	u := c.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	if len(tags) > 0 {
+		req.Header.Set("X-Amz-Tagging", encodeTags(tags))
+	}
+	c.sign(req, hex.EncodeToString(h.Sum(nil)))
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var s3err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(raw, &s3err) == nil && s3err.Code != "" {
+		return fmt.Errorf("put %s: %s: %s (%s)", key, resp.Status, s3err.Message, s3err.Code)
+	}
+	return fmt.Errorf("put %s: %s", key, resp.Status)
+}
+
+// objectURL addresses the object virtual-hosted style (bucket.endpoint/key),
+// or path style (endpoint/bucket/key) as most self-hosted stores want.
+func (c *S3) objectURL(key string) *url.URL {
+	u := *c.Endpoint
+	base := strings.TrimSuffix(u.Path, "/")
+	if c.PathStyle {
+		u.Path = base + "/" + c.Bucket + "/" + key
+	} else {
+		u.Host = c.Bucket + "." + u.Host
+		u.Path = base + "/" + key
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	return &u
+}
+
+// sign adds the SigV4 Authorization header, signing every header already set
+// plus host, x-amz-date, x-amz-content-sha256 and the session token.
+func (c *S3) sign(req *http.Request, payloadHash string) {
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	t := now().UTC()
+	stamp := t.Format("20060102T150405Z")
+	day := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, name := range names {
+		canonHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + c.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hexSHA256(canonical)
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), day)
+	for _, part := range []string{c.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.AccessKey, scope, signed, signature))
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string{}, q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode is SigV4's URI encoding: everything but the unreserved characters
+// is percent-encoded, and '/' too unless it separates path segments.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch >= 'A' && ch <= 'Z', ch >= 'a' && ch <= 'z', ch >= '0' && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~':
+			b.WriteByte(ch)
+		case ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// encodeTags is the x-amz-tagging header: a URL query of the tags.
+func encodeTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, uriEncode(k, true)+"="+uriEncode(tags[k], true))
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package asterisk
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// RandomPassword returns a 32-character URL-safe password; it contains no
+// characters that need quoting in .env or Asterisk config files.
+func RandomPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// managedBlock marks the stanzas this CLI writes so re-running replaces them.
+func managedBlock(kind, name, body string) string {
+	return fmt.Sprintf("; BEGIN aava %s %s (managed by agent config ari-user)\n%s; END aava %s %s\n", kind, name, body, kind, name)
+}
+
+// ARIUserStanza is the ari.conf section for an ARI user with a plain password.
+func ARIUserStanza(username, password string) string {
+	return managedBlock("ari-user", username, fmt.Sprintf("[%s]\ntype = user\nread_only = no\npassword = %s\npassword_format = plain\n", username, password))
+}
+
+// HTTPStanza enables the built-in HTTP server ARI is served on.
+func HTTPStanza(bindAddr, port string) string {
+	return managedBlock("http", "general", fmt.Sprintf("[general]\nenabled = yes\nbindaddr = %s\nbindport = %s\n", bindAddr, port))
+}
+
+// ARIGeneralStanza enables ARI itself.
+func ARIGeneralStanza() string {
+	return managedBlock("ari", "general", "[general]\nenabled = yes\n")
+}
+
+// UpsertBlock replaces the managed block with the same BEGIN line in text, or
+// appends block when there is none.
+func UpsertBlock(text, block string) string {
+	begin := strings.SplitN(block, "\n", 2)[0]
+	endLine := strings.Replace(strings.SplitN(begin, " (", 2)[0], "; BEGIN", "; END", 1)
+	if i := strings.Index(text, begin); i >= 0 {
+		if j := strings.Index(text[i:], endLine); j >= 0 {
+			end := i + j + len(endLine)
+			if end < len(text) && text[end] == '\n' {
+				end++
+			}
+			return text[:i] + block + text[end:]
+		}
+	}
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if text != "" {
+		text += "\n"
+	}
+	return text + block
+}
+
+var includePattern = regexp.MustCompile(`(?m)^\s*#(?:try)?include\s*=?>?\s*"?([^"\s]+)"?`)
+
+// IncludeTarget picks the file to write custom stanzas for conf (e.g. "ari.conf"):
+// a preferred include when conf has it, else the first included *_custom.conf (as
+// FreePBX does, so its GUI-managed files stay untouched), otherwise conf itself.
+func IncludeTarget(confText, conf string, prefer ...string) string {
+	var includes []string
+	for _, m := range includePattern.FindAllStringSubmatch(confText, -1) {
+		includes = append(includes, path.Base(m[1]))
+	}
+	for _, want := range prefer {
+		for _, name := range includes {
+			if name == want {
+				return name
+			}
+		}
+	}
+	for _, name := range includes {
+		if strings.HasSuffix(name, "_custom.conf") {
+			return name
+		}
+	}
+	return conf
+}
+
+// WriteFile replaces a file in the Asterisk container, or on this host when the
+// container is not available and the deployment is local.
+func WriteFile(p, content string) error {
+	container := deployment.AsteriskContainer()
+	var out bytes.Buffer
+	opts := dockerapi.ExecOptions{Stdin: strings.NewReader(content), Stdout: &out, Stderr: &out}
+	if err := dockerapi.Exec(context.Background(), container, opts, "sh", "-c", `cat > "$1"`, "sh", p); err == nil {
+		return nil
+	} else if deployment.Current().Remote() {
+		return fmt.Errorf("write %s via docker exec %s: %v: %s", p, container, err, strings.TrimSpace(out.String()))
+	}
+	return os.WriteFile(p, []byte(content), 0o640)
+}
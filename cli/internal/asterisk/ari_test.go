@@ -0,0 +1,45 @@
+package asterisk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpsertBlockReplacesManagedStanza(t *testing.T) {
+	text := "[general]\nenabled = yes\n"
+	once := UpsertBlock(text, ARIUserStanza("aava", "first"))
+	twice := UpsertBlock(once, ARIUserStanza("aava", "second"))
+	if strings.Count(twice, "[aava]") != 1 || strings.Contains(twice, "first") || !strings.Contains(twice, "password = second") {
+		t.Fatalf("upserted:\n%s", twice)
+	}
+	if !strings.HasPrefix(twice, text) {
+		t.Fatalf("existing content changed:\n%s", twice)
+	}
+	users := ParseConf(twice)["aava"]
+	if users["type"] != "user" || users["password_format"] != "plain" {
+		t.Fatalf("parsed = %v", users)
+	}
+}
+
+func TestIncludeTargetPrefersFreePBXCustomFiles(t *testing.T) {
+	freepbx := "[general]\n#include ari_general_additional.conf\n#include ari_general_custom.conf\n#include ari_additional.conf\n#include ari_additional_custom.conf\n"
+	if got := IncludeTarget(freepbx, "ari.conf", "ari_additional_custom.conf"); got != "ari_additional_custom.conf" {
+		t.Fatalf("user target = %s", got)
+	}
+	if got := IncludeTarget(freepbx, "ari.conf"); got != "ari_general_custom.conf" {
+		t.Fatalf("general target = %s", got)
+	}
+	if got := IncludeTarget("[general]\nenabled = yes\n", "ari.conf", "ari_additional_custom.conf"); got != "ari.conf" {
+		t.Fatalf("plain Asterisk target = %s", got)
+	}
+}
+
+func TestRandomPasswordIsConfSafe(t *testing.T) {
+	p, err := RandomPassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 32 || strings.ContainsAny(p, ";=#\"' \n") {
+		t.Fatalf("password %q", p)
+	}
+}
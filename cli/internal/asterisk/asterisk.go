@@ -0,0 +1,58 @@
+// Package asterisk runs Asterisk CLI commands and reads /etc/asterisk files, in the
+// Asterisk container when there is one and on this host otherwise.
+package asterisk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// ConfigDir is where Asterisk reads its configuration.
+const ConfigDir = "/etc/asterisk"
+
+// CLI runs `asterisk -rx command` and returns its output and where it ran
+// ("docker exec <container>" or "local").
+func CLI(command string) (string, string, error) {
+	container := deployment.AsteriskContainer()
+	out, execErr := dockerapi.ExecCombinedOutput(context.Background(), container, "asterisk", "-rx", command)
+	if execErr == nil {
+		return string(out), "docker exec " + container, nil
+	}
+	if deployment.Current().Remote() {
+		return "", "", fmt.Errorf("asterisk -rx %q via docker exec %s: %v", command, container, execErr)
+	}
+	out, err := exec.Command("asterisk", "-rx", command).CombinedOutput()
+	if err != nil {
+		return "", "", fmt.Errorf("asterisk -rx %q failed (docker exec %s: %v; local: %v)", command, container, execErr, strings.TrimSpace(string(out)))
+	}
+	return string(out), "local", nil
+}
+
+// ReadFile reads a file from the Asterisk container, or from this host when the
+// container is not available and the deployment is local.
+func ReadFile(path string) (string, error) {
+	container := deployment.AsteriskContainer()
+	out, execErr := dockerapi.ExecOutput(context.Background(), container, "cat", path)
+	if execErr == nil {
+		return string(out), nil
+	}
+	if deployment.Current().Remote() {
+		return "", fmt.Errorf("%s not readable via docker exec %s: %v", path, container, execErr)
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrPermission) {
+		return "", fmt.Errorf("%w (agent privileges shows how to grant read access)", err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
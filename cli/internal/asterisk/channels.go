@@ -0,0 +1,127 @@
+package asterisk
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ARIConfig is the ARI endpoint and login the engine uses.
+type ARIConfig struct {
+	BaseURL   string // scheme://host:port
+	Username  string
+	Password  string
+	AppName   string
+	SSLVerify bool
+}
+
+// ARIConfigFromEnv builds the config from the .env keys the engine reads;
+// lookup returns the value for a key (process env first, then .env).
+func ARIConfigFromEnv(lookup func(string) string) ARIConfig {
+	or := func(key, def string) string {
+		if v := strings.TrimSpace(lookup(key)); v != "" {
+			return v
+		}
+		return def
+	}
+	verify := strings.ToLower(or("ASTERISK_ARI_SSL_VERIFY", "true"))
+	return ARIConfig{
+		BaseURL:   fmt.Sprintf("%s://%s", or("ASTERISK_ARI_SCHEME", "http"), net.JoinHostPort(or("ASTERISK_HOST", "127.0.0.1"), or("ASTERISK_ARI_PORT", "8088"))),
+		Username:  or("ASTERISK_ARI_USERNAME", ""),
+		Password:  lookup("ASTERISK_ARI_PASSWORD"),
+		AppName:   or("ASTERISK_APP_NAME", "asterisk-ai-voice-agent"),
+		SSLVerify: verify != "0" && verify != "false" && verify != "no",
+	}
+}
+
+// Channel is the subset of an ARI channel used to count calls.
+type Channel struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	CreationTime string `json:"creationtime,omitempty"`
+	Dialplan     struct {
+		AppName string `json:"app_name"`
+		AppData string `json:"app_data"`
+	} `json:"dialplan"`
+}
+
+// mediaChannelPrefixes are the helper channels the engine creates per call; they
+// are not calls of their own.
+var mediaChannelPrefixes = []string{"UnicastRTP/", "AudioSocket/", "Snoop/"}
+
+// ListChannels returns every channel Asterisk currently has.
+func ListChannels(ctx context.Context, cfg ARIConfig) ([]Channel, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(cfg.BaseURL, "/")+"/ari/channels", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ariDo(cfg, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ARI /channels: HTTP %d", resp.StatusCode)
+	}
+	var channels []Channel
+	if err := json.NewDecoder(resp.Body).Decode(&channels); err != nil {
+		return nil, fmt.Errorf("ARI /channels: %w", err)
+	}
+	return channels, nil
+}
+
+// ariDo sends an authenticated ARI request. A rejected login is an error; other
+// statuses are left to the caller.
+func ariDo(cfg ARIConfig, req *http.Request) (*http.Response, error) {
+	req.SetBasicAuth(cfg.Username, cfg.Password)
+	client := &http.Client{Timeout: 5 * time.Second}
+	if !cfg.SSLVerify {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} // #nosec G402 -- mirrors ASTERISK_ARI_SSL_VERIFY=false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ARI %s: %w", cfg.BaseURL, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, fmt.Errorf("ARI rejected username/password (HTTP 401)")
+	}
+	return resp, nil
+}
+
+// AgentCalls counts the caller channels currently in the agent's Stasis app,
+// ignoring the media helper channels the engine adds to each call.
+func AgentCalls(channels []Channel, appName string) int {
+	n := 0
+	for _, ch := range channels {
+		if inApp(ch, appName) && !isMediaChannel(ch.Name) {
+			n++
+		}
+	}
+	return n
+}
+
+// inApp reports whether the channel is in Stasis under appName (any app when
+// appName is empty).
+func inApp(ch Channel, appName string) bool {
+	if !strings.EqualFold(ch.Dialplan.AppName, "Stasis") {
+		return false
+	}
+	app := strings.SplitN(ch.Dialplan.AppData, ",", 2)[0]
+	return appName == "" || strings.TrimSpace(app) == appName
+}
+
+func isMediaChannel(name string) bool {
+	for _, p := range mediaChannelPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package asterisk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListChannelsCountsAgentCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, _ := r.BasicAuth(); u != "aava" || p != "secret" || r.URL.Path != "/ari/channels" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `[
+			{"id":"1","name":"PJSIP/trunk-0001","dialplan":{"app_name":"Stasis","app_data":"asterisk-ai-voice-agent"}},
+			{"id":"2","name":"UnicastRTP/127.0.0.1:18080-0002","dialplan":{"app_name":"Stasis","app_data":"asterisk-ai-voice-agent"}},
+			{"id":"3","name":"PJSIP/6001-0003","dialplan":{"app_name":"Dial","app_data":"PJSIP/6002"}},
+			{"id":"4","name":"PJSIP/trunk-0004","dialplan":{"app_name":"Stasis","app_data":"other-app,foo"}}
+		]`)
+	}))
+	defer srv.Close()
+
+	cfg := ARIConfigFromEnv(func(k string) string {
+		return map[string]string{"ASTERISK_ARI_USERNAME": "aava", "ASTERISK_ARI_PASSWORD": "secret"}[k]
+	})
+	if cfg.BaseURL != "http://127.0.0.1:8088" || cfg.AppName != "asterisk-ai-voice-agent" || !cfg.SSLVerify {
+		t.Fatalf("defaults = %+v", cfg)
+	}
+	cfg.BaseURL = srv.URL
+	channels, err := ListChannels(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := AgentCalls(channels, cfg.AppName); got != 1 {
+		t.Fatalf("AgentCalls = %d, want 1", got)
+	}
+	if got := AgentCalls(channels, ""); got != 2 {
+		t.Fatalf("AgentCalls(any app) = %d, want 2", got)
+	}
+
+	cfg.Password = "wrong"
+	if _, err := ListChannels(context.Background(), cfg); err == nil {
+		t.Fatal("bad credentials should fail")
+	}
+}
+
+func TestOriginateAndHangup(t *testing.T) {
+	var gotVars map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/ari/channels":
+			q := r.URL.Query()
+			if q.Get("endpoint") != "Local/s@from-ai-agent/n" || q.Get("context") != "aava-loadtest-caller" || q.Get("priority") != "1" || q.Get("otherChannelId") != "1700000000.900001" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"message":"bad query"}`)
+				return
+			}
+			if q.Get("channelId") == "taken" {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"message":"Channel with given unique ID already exists"}`)
+				return
+			}
+			var body struct {
+				Variables map[string]string `json:"variables"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			gotVars = body.Variables
+			fmt.Fprintf(w, `{"id":%q,"name":"Local/s@from-ai-agent-00000001;1","state":"Down"}`, q.Get("channelId"))
+		case r.Method == http.MethodDelete && r.URL.Path == "/ari/channels/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := ARIConfig{BaseURL: srv.URL, Username: "aava", Password: "secret"}
+	req := OriginateRequest{
+		Endpoint: "Local/s@from-ai-agent/n", Context: "aava-loadtest-caller", Extension: "s", Priority: 1,
+		ChannelID: "1700000000.900001-caller", OtherChannelID: "1700000000.900001",
+		Variables: map[string]string{"AAVA_LOADTEST_AUDIO": "hello-world"},
+	}
+	ch, err := Originate(context.Background(), cfg, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.ID != "1700000000.900001-caller" || gotVars["AAVA_LOADTEST_AUDIO"] != "hello-world" {
+		t.Fatalf("channel = %+v, variables = %v", ch, gotVars)
+	}
+	req.ChannelID = "taken"
+	if _, err := Originate(context.Background(), cfg, req); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("conflict err = %v", err)
+	}
+
+	if err := Hangup(context.Background(), cfg, ch.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := Hangup(context.Background(), cfg, "gone"); err != nil {
+		t.Fatalf("hangup of a finished channel: %v", err)
+	}
+}
+
+func TestRTPStatistics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ari/channels/1700000000.1/rtp_statistics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"txcount":1500,"rxcount":1480,"txploss":3,"rxploss":20,"txjitter":0.004,"rxjitter":0.012,"rtt":0.08,"local_ssrc":1,"channel_uniqueid":"1700000000.1"}`)
+	}))
+	defer srv.Close()
+
+	cfg := ARIConfig{BaseURL: srv.URL}
+	stats, err := RTPStatistics(context.Background(), cfg, "1700000000.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats == nil || stats.RxCount != 1480 || stats.RxPLoss != 20 || stats.RxJitter != 0.012 || stats.RTT != 0.08 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats, err := RTPStatistics(context.Background(), cfg, "gone"); stats != nil || err != nil {
+		t.Fatalf("finished channel: %+v, %v", stats, err)
+	}
+}
+
+func TestFindLeaks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/ari/channels":
+			fmt.Fprint(w, `[
+				{"id":"1.1","name":"PJSIP/trunk-0001","creationtime":"2026-03-10T06:00:00.000+0000","dialplan":{"app_name":"Stasis","app_data":"asterisk-ai-voice-agent"}},
+				{"id":"1.2","name":"UnicastRTP/127.0.0.1:18080-0002","creationtime":"2026-03-10T06:00:01.000+0000","dialplan":{"app_name":"Stasis","app_data":"asterisk-ai-voice-agent"}},
+				{"id":"2.1","name":"UnicastRTP/127.0.0.1:18080-0003","creationtime":"2026-03-10T08:00:00.000+0000","dialplan":{"app_name":"Stasis","app_data":"asterisk-ai-voice-agent"}},
+				{"id":"2.2","name":"Snoop/2.0-00000004","creationtime":"2026-03-10T08:00:00.000+0000","dialplan":{"app_name":"Stasis","app_data":"asterisk-ai-voice-agent"}},
+				{"id":"3.1","name":"PJSIP/trunk-0005","creationtime":"2026-03-10T08:59:00.000+0000","dialplan":{"app_name":"Stasis","app_data":"asterisk-ai-voice-agent"}},
+				{"id":"3.2","name":"AudioSocket/127.0.0.1:8090-0006","creationtime":"2026-03-10T08:59:50.000+0000","dialplan":{"app_name":"Stasis","app_data":"asterisk-ai-voice-agent"}},
+				{"id":"4.1","name":"PJSIP/6001-0007","creationtime":"2026-03-10T01:00:00.000+0000","dialplan":{"app_name":"Dial","app_data":"PJSIP/6002"}},
+				{"id":"5.1","name":"UnicastRTP/127.0.0.1:20000-0008","creationtime":"2026-03-10T07:00:00.000+0000","dialplan":{"app_name":"Stasis","app_data":"ivr-app"}}
+			]`)
+		case r.Method == http.MethodGet && r.URL.Path == "/ari/bridges":
+			fmt.Fprint(w, `[
+				{"id":"b1","bridge_type":"mixing","creator":"Stasis","channels":["1.1","1.2"],"creationtime":"2026-03-10T06:00:00.000+0000"},
+				{"id":"b2","bridge_type":"mixing","creator":"Stasis","channels":["2.1"],"creationtime":"2026-03-10T08:00:00.000+0000"},
+				{"id":"b3","name":"bridge_1a2b3c4d","bridge_type":"mixing","creator":"Stasis","channels":[],"creationtime":"2026-03-10T08:30:00.000+0000"},
+				{"id":"b4","bridge_type":"mixing","creator":"Stasis","channels":["3.1"],"creationtime":"2026-03-10T08:59:00.000+0000"},
+				{"id":"b5","bridge_type":"mixing","creator":"ConfBridge","channels":[],"creationtime":"2026-03-10T07:00:00.000+0000"},
+				{"id":"b6","name":"ivr-holding","bridge_type":"holding","creator":"Stasis","channels":[],"creationtime":"2026-03-10T07:00:00.000+0000"},
+				{"id":"b7","bridge_type":"mixing","creator":"Stasis","channels":["5.1"],"creationtime":"2026-03-10T07:00:00.000+0000"}
+			]`)
+		case r.Method == http.MethodDelete && r.URL.Path == "/ari/bridges/b3":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := ARIConfig{BaseURL: srv.URL}
+	channels, err := ListChannels(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bridges, err := ListBridges(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b6 and b7 belong to another Stasis app: the engine's bridges are named
+	// bridge_<hex> or still hold one of its channels.
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	var got []string
+	for _, l := range FindLeaks(channels, bridges, "asterisk-ai-voice-agent", 2*time.Hour, now) {
+		got = append(got, l.Kind+" "+l.ID+": "+l.Reason)
+	}
+	want := []string{
+		"stuck_call 1.1: up 3h0m0s, longer than 2h0m0s",
+		"bridge b2: bridge holds only 1 media helper channel(s)",
+		"orphan_helper 2.1: not bridged with any caller",
+		"orphan_helper 2.2: snooped channel 2.0 is gone",
+		"bridge b3: empty bridge",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("leaks:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if err := DestroyBridge(context.Background(), cfg, "b3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DestroyBridge(context.Background(), cfg, "gone"); err != nil {
+		t.Fatalf("destroying a removed bridge: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package asterisk
+
+import (
+	"bufio"
+	"path"
+	"strings"
+)
+
+// ParseConf reads the [section] key = value format of /etc/asterisk files into
+// lower-cased keys. Template markers such as [name](+) are stripped; comments and
+// #include lines are ignored. Repeated sections are merged.
+func ParseConf(text string) map[string]map[string]string {
+	out := map[string]map[string]string{}
+	section := ""
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				continue
+			}
+			section = strings.TrimSpace(line[1:end])
+			if out[section] == nil {
+				out[section] = map[string]string{}
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || section == "" {
+			continue
+		}
+		out[section][strings.ToLower(strings.TrimSpace(strings.TrimSuffix(k, ">")))] = strings.TrimSpace(v)
+	}
+	return out
+}
+
+// ReadConf reads name from ConfigDir with the files it #includes appended, so
+// settings that FreePBX keeps in *_additional.conf are seen. Unreadable includes
+// are skipped.
+func ReadConf(name string) (string, error) {
+	text, err := ReadFile(path.Join(ConfigDir, name))
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString(text)
+	for _, m := range includePattern.FindAllStringSubmatch(text, -1) {
+		inc := m[1]
+		if !path.IsAbs(inc) {
+			inc = path.Join(ConfigDir, inc)
+		}
+		if body, err := ReadFile(inc); err == nil {
+			sb.WriteString("\n")
+			sb.WriteString(body)
+		}
+	}
+	return sb.String(), nil
+}
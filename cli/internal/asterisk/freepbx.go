@@ -0,0 +1,78 @@
+package asterisk
+
+import (
+	"path"
+	"strings"
+)
+
+// freePBXHeader opens every file FreePBX regenerates on Apply Config.
+const freePBXHeader = "auto-generated by FreePBX"
+
+// freePBXMarkers are files that only exist on a FreePBX system. Asterisk in a
+// container usually lacks them, so extensions.conf is checked as well.
+var freePBXMarkers = []string{"/etc/freepbx.conf", "/etc/amportal.conf"}
+
+// FreePBXManagedFiles are the /etc/asterisk files FreePBX writes itself. Hand
+// edits to them are lost on the next Apply Config.
+var FreePBXManagedFiles = []string{
+	"extensions.conf",
+	"extensions_additional.conf",
+	"ari.conf",
+	"ari_additional.conf",
+	"ari_general_additional.conf",
+	"http.conf",
+	"http_additional.conf",
+	"manager.conf",
+	"manager_additional.conf",
+	"pjsip.conf",
+	"pjsip.endpoint.conf",
+	"pjsip.transports.conf",
+	"sip_additional.conf",
+}
+
+// DetectFreePBX reports whether the Asterisk configuration belongs to FreePBX, and
+// the file that showed it.
+func DetectFreePBX() (string, bool) {
+	for _, p := range freePBXMarkers {
+		if _, err := ReadFile(p); err == nil {
+			return p, true
+		}
+	}
+	p := path.Join(ConfigDir, "extensions.conf")
+	if text, err := ReadFile(p); err == nil && IsFreePBXGenerated(text) {
+		return p, true
+	}
+	return "", false
+}
+
+// IsFreePBXGenerated reports whether text carries the FreePBX "do not edit" header.
+func IsFreePBXGenerated(text string) bool {
+	head := text
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return strings.Contains(strings.ToLower(head), strings.ToLower(freePBXHeader))
+}
+
+// IsFreePBXManaged reports whether FreePBX owns name (a file under ConfigDir). The
+// *_custom.conf files are the ones meant for hand edits.
+func IsFreePBXManaged(name string) bool {
+	name = path.Base(name)
+	if strings.HasSuffix(name, "_custom.conf") || strings.HasSuffix(name, "_custom_post.conf") {
+		return false
+	}
+	for _, m := range FreePBXManagedFiles {
+		if name == m {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "_additional.conf")
+}
+
+// FreePBXCustomFile is the hand-editable file FreePBX includes for name, e.g.
+// extensions_custom.conf for extensions.conf or extensions_additional.conf.
+func FreePBXCustomFile(name string) string {
+	base := strings.TrimSuffix(path.Base(name), ".conf")
+	base = strings.TrimSuffix(base, "_additional")
+	return base + "_custom.conf"
+}
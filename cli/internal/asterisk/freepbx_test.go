@@ -0,0 +1,24 @@
+package asterisk
+
+import "testing"
+
+func TestFreePBXManagedFiles(t *testing.T) {
+	for name, want := range map[string]bool{
+		"extensions.conf":                          true,
+		"/etc/asterisk/extensions_additional.conf": true,
+		"queues_additional.conf":                   true,
+		"extensions_custom.conf":                   false,
+		"pjsip.endpoint_custom_post.conf":          false,
+		"musiconhold.conf":                         false,
+	} {
+		if got := IsFreePBXManaged(name); got != want {
+			t.Errorf("IsFreePBXManaged(%q) = %t, want %t", name, got, want)
+		}
+	}
+	if got := FreePBXCustomFile("extensions_additional.conf"); got != "extensions_custom.conf" {
+		t.Errorf("custom file = %q", got)
+	}
+	if !IsFreePBXGenerated(";--------;\n; Do NOT edit this file as it is auto-generated by FreePBX.\n") || IsFreePBXGenerated("[general]\n") {
+		t.Error("FreePBX header detection")
+	}
+}
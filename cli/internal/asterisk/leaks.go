@@ -0,0 +1,181 @@
+package asterisk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bridge is the subset of an ARI bridge used to find leaks.
+type Bridge struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name,omitempty"`
+	Technology   string   `json:"technology,omitempty"`
+	BridgeType   string   `json:"bridge_type,omitempty"`
+	Creator      string   `json:"creator,omitempty"`
+	Channels     []string `json:"channels"`
+	CreationTime string   `json:"creationtime,omitempty"`
+}
+
+// ListBridges returns every bridge ARI knows about.
+func ListBridges(ctx context.Context, cfg ARIConfig) ([]Bridge, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(cfg.BaseURL, "/")+"/ari/bridges", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ariDo(cfg, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ARI /bridges: HTTP %d", resp.StatusCode)
+	}
+	var bridges []Bridge
+	if err := json.NewDecoder(resp.Body).Decode(&bridges); err != nil {
+		return nil, fmt.Errorf("ARI /bridges: %w", err)
+	}
+	return bridges, nil
+}
+
+// DestroyBridge removes a bridge; one that is already gone is not an error.
+func DestroyBridge(ctx context.Context, cfg ARIConfig, bridgeID string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, strings.TrimRight(cfg.BaseURL, "/")+"/ari/bridges/"+url.PathEscape(bridgeID), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ariDo(cfg, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("ARI destroy bridge %s: HTTP %d", bridgeID, resp.StatusCode)
+}
+
+// Leak kinds.
+const (
+	LeakStuckCall = "stuck_call"    // a caller channel up far longer than any real call
+	LeakOrphan    = "orphan_helper" // an ExternalMedia, AudioSocket or Snoop channel whose call is gone
+	LeakBridge    = "bridge"        // a bridge with no caller left in it
+)
+
+// engineBridgeName matches the names the engine gives its bridges
+// (src/ari_client.py create_bridge). Other Stasis apps create bridges too, so
+// the ARI creator alone does not make a bridge ours.
+var engineBridgeName = regexp.MustCompile(`^bridge_[0-9a-f]{8}$`)
+
+// leakGrace is how long a helper channel or bridge may go without a caller; the
+// engine creates them just before bridging the call.
+const leakGrace = time.Minute
+
+// Leak is a channel or bridge that outlived its call.
+type Leak struct {
+	Kind       string `json:"kind"`
+	ID         string `json:"id"`
+	Name       string `json:"name,omitempty"`
+	AgeSeconds int64  `json:"age_seconds,omitempty"` // 0 when ARI gave no creation time
+	Reason     string `json:"reason"`
+}
+
+// IsBridge reports whether the leak is removed by destroying a bridge rather
+// than hanging up a channel.
+func (l Leak) IsBridge() bool { return l.Kind == LeakBridge }
+
+// FindLeaks returns the caller channels in appName's Stasis app older than
+// maxAge, the media helper channels no longer bridged with a caller and the
+// engine's bridges no caller is in, oldest first. A bridge is the engine's when
+// it has the engine's bridge name or still holds a channel in appName.
+func FindLeaks(channels []Channel, bridges []Bridge, appName string, maxAge time.Duration, now time.Time) []Leak {
+	byID := make(map[string]Channel, len(channels))
+	for _, ch := range channels {
+		byID[ch.ID] = ch
+	}
+	withCaller := map[string]bool{} // helper channel ID -> shares a bridge with a caller
+	var leaks []Leak
+	for _, b := range bridges {
+		caller, ours := false, engineBridgeName.MatchString(b.Name)
+		for _, id := range b.Channels {
+			ch, ok := byID[id]
+			if ok && !isMediaChannel(ch.Name) {
+				caller = true
+			}
+			ours = ours || ok && inApp(ch, appName)
+		}
+		for _, id := range b.Channels {
+			withCaller[id] = withCaller[id] || caller
+		}
+		if caller || !ours {
+			continue
+		}
+		age, known := ariAge(b.CreationTime, now)
+		if known && age < leakGrace || !known && len(b.Channels) > 0 {
+			continue
+		}
+		reason := "empty bridge"
+		if len(b.Channels) > 0 {
+			reason = fmt.Sprintf("bridge holds only %d media helper channel(s)", len(b.Channels))
+		}
+		leaks = append(leaks, Leak{Kind: LeakBridge, ID: b.ID, Name: b.Name, AgeSeconds: int64(age / time.Second), Reason: reason})
+	}
+
+	for _, ch := range channels {
+		age, known := ariAge(ch.CreationTime, now)
+		if !isMediaChannel(ch.Name) {
+			if inApp(ch, appName) && known && maxAge > 0 && age > maxAge {
+				leaks = append(leaks, Leak{Kind: LeakStuckCall, ID: ch.ID, Name: ch.Name, AgeSeconds: int64(age / time.Second),
+					Reason: fmt.Sprintf("up %s, longer than %s", age.Round(time.Minute), maxAge)})
+			}
+			continue
+		}
+		if !known || age < leakGrace || (ch.Dialplan.AppName != "" && !inApp(ch, appName)) {
+			continue
+		}
+		reason := ""
+		if spied, ok := snoopTarget(ch.Name); ok {
+			if _, up := byID[spied]; !up {
+				reason = "snooped channel " + spied + " is gone"
+			}
+		} else if !withCaller[ch.ID] {
+			reason = "not bridged with any caller"
+		}
+		if reason != "" {
+			leaks = append(leaks, Leak{Kind: LeakOrphan, ID: ch.ID, Name: ch.Name, AgeSeconds: int64(age / time.Second), Reason: reason})
+		}
+	}
+	sort.SliceStable(leaks, func(i, j int) bool { return leaks[i].AgeSeconds > leaks[j].AgeSeconds })
+	return leaks
+}
+
+// snoopTarget returns the unique ID of the channel a Snoop/<uniqueid>-<n>
+// channel spies on.
+func snoopTarget(name string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, "Snoop/")
+	if !ok {
+		return "", false
+	}
+	if i := strings.LastIndex(rest, "-"); i > 0 {
+		rest = rest[:i]
+	}
+	return rest, true
+}
+
+// ariAge is how long ago an ARI timestamp such as 2026-03-10T09:15:02.123+0000
+// was; known is false when it cannot be read.
+func ariAge(ts string, now time.Time) (age time.Duration, known bool) {
+	for _, layout := range []string{"2006-01-02T15:04:05.000-0700", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, strings.TrimSpace(ts)); err == nil {
+			return now.Sub(t), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package asterisk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// OriginateRequest places a call from Endpoint into the dialplan at
+// Context,Extension,Priority.
+type OriginateRequest struct {
+	Endpoint  string // e.g. Local/s@from-ai-agent/n
+	Context   string
+	Extension string
+	Priority  int
+	CallerID  string
+	Timeout   int // seconds to wait for an answer; 0 uses the ARI default (30)
+
+	// ChannelID and OtherChannelID name the originated channel and, for Local
+	// channels, its second half, so the caller knows the call IDs in advance.
+	ChannelID      string
+	OtherChannelID string
+
+	Variables map[string]string
+}
+
+// Originate creates a channel through ARI (POST /ari/channels).
+func Originate(ctx context.Context, cfg ARIConfig, o OriginateRequest) (Channel, error) {
+	q := url.Values{}
+	q.Set("endpoint", o.Endpoint)
+	set := func(key, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("context", o.Context)
+	set("extension", o.Extension)
+	if o.Priority > 0 {
+		q.Set("priority", strconv.Itoa(o.Priority))
+	}
+	set("callerId", o.CallerID)
+	if o.Timeout > 0 {
+		q.Set("timeout", strconv.Itoa(o.Timeout))
+	}
+	set("channelId", o.ChannelID)
+	set("otherChannelId", o.OtherChannelID)
+
+	body, err := json.Marshal(map[string]any{"variables": o.Variables})
+	if err != nil {
+		return Channel{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(cfg.BaseURL, "/")+"/ari/channels?"+q.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return Channel{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := ariDo(cfg, req)
+	if err != nil {
+		return Channel{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var ariErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&ariErr) == nil && ariErr.Message != "" {
+			return Channel{}, fmt.Errorf("ARI originate %s: HTTP %d: %s", o.Endpoint, resp.StatusCode, ariErr.Message)
+		}
+		return Channel{}, fmt.Errorf("ARI originate %s: HTTP %d", o.Endpoint, resp.StatusCode)
+	}
+	var ch Channel
+	if err := json.NewDecoder(resp.Body).Decode(&ch); err != nil {
+		return Channel{}, fmt.Errorf("ARI originate: %w", err)
+	}
+	return ch, nil
+}
+
+// Hangup ends a channel through ARI. A channel that is already gone is not an
+// error.
+func Hangup(ctx context.Context, cfg ARIConfig, channelID string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, strings.TrimRight(cfg.BaseURL, "/")+"/ari/channels/"+url.PathEscape(channelID), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ariDo(cfg, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("ARI hangup %s: HTTP %d", channelID, resp.StatusCode)
+}
@@ -0,0 +1,49 @@
+package asterisk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RTPStats is Asterisk's view of a channel's RTP session, from GET
+// /ari/channels/{id}/rtp_statistics. Jitter and round trip are in seconds;
+// the tx figures are what the far end reported in RTCP.
+type RTPStats struct {
+	TxCount  int     `json:"txcount"`
+	RxCount  int     `json:"rxcount"`
+	TxPLoss  int     `json:"txploss"`
+	RxPLoss  int     `json:"rxploss"`
+	TxJitter float64 `json:"txjitter"`
+	RxJitter float64 `json:"rxjitter"`
+	RTT      float64 `json:"rtt"`
+}
+
+// RTPStatistics reads a channel's RTP statistics. A channel that is gone or
+// has no RTP session returns nil stats and no error.
+func RTPStatistics(ctx context.Context, cfg ARIConfig, channelID string) (*RTPStats, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(cfg.BaseURL, "/")+"/ari/channels/"+url.PathEscape(channelID)+"/rtp_statistics", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ariDo(cfg, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("ARI rtp_statistics %s: HTTP %d", channelID, resp.StatusCode)
+	}
+	var stats RTPStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("ARI rtp_statistics: %w", err)
+	}
+	return &stats, nil
+}
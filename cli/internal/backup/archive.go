@@ -0,0 +1,244 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchiveVersion is the manifest format written by CreateArchive.
+const ArchiveVersion = 1
+
+const manifestName = "manifest.json"
+
+// Manifest is the first entry of an archive and describes what it holds.
+type Manifest struct {
+	Version    int       `json:"version"`
+	Created    time.Time `json:"created"`
+	Host       string    `json:"host,omitempty"`
+	CLIVersion string    `json:"cli_version,omitempty"`
+	Media      bool      `json:"media"`
+	Files      []string  `json:"files"`
+}
+
+// Source is a file or directory to archive. Name is its slash-separated path in
+// the archive; directories are walked and their files stored under Name.
+type Source struct {
+	Name string
+	Path string
+}
+
+// CreateArchive writes sources as an encrypted, gzipped tar to w. Missing sources
+// are skipped. skip, when set, is called with archive names and drops matches.
+func CreateArchive(w io.Writer, passphrase []byte, m Manifest, sources []Source, skip func(name string) bool) (Manifest, error) {
+	if len(passphrase) == 0 {
+		return m, errors.New("a passphrase is required")
+	}
+	type entry struct{ name, path string }
+	var entries []entry
+	for _, src := range sources {
+		err := filepath.WalkDir(src.Path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && p == src.Path {
+					return nil
+				}
+				return err
+			}
+			rel, _ := filepath.Rel(src.Path, p)
+			name := path.Join(src.Name, filepath.ToSlash(rel))
+			if skip != nil && skip(name) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() {
+				entries = append(entries, entry{name, p})
+			}
+			return nil
+		})
+		if err != nil {
+			return m, err
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	m.Version = ArchiveVersion
+	m.Files = m.Files[:0]
+	for _, e := range entries {
+		m.Files = append(m.Files, e.name)
+	}
+
+	enc, err := newEncryptWriter(w, passphrase)
+	if err != nil {
+		return m, err
+	}
+	gz := gzip.NewWriter(enc)
+	tw := tar.NewWriter(gz)
+	manifest, _ := json.MarshalIndent(m, "", "  ")
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0o600, Size: int64(len(manifest)), ModTime: m.Created}); err != nil {
+		return m, err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return m, err
+	}
+	for _, e := range entries {
+		if err := addFile(tw, e.name, e.path); err != nil {
+			return m, fmt.Errorf("archiving %s: %w", e.name, err)
+		}
+	}
+	for _, c := range []io.Closer{tw, gz, enc} {
+		if err := c.Close(); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+func addFile(tw *tar.Writer, name, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, f, info.Size())
+	return err
+}
+
+// Archive is a decrypted archive staged in a temporary file.
+type Archive struct {
+	Manifest Manifest
+	tmp      *os.File
+}
+
+// OpenArchive decrypts and authenticates the whole archive before returning, so
+// a wrong passphrase or a damaged file is reported before anything is restored.
+func OpenArchive(r io.Reader, passphrase []byte) (*Archive, error) {
+	tmp, err := os.CreateTemp("", "agent-backup-*.tar.gz")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(tmp.Name())
+	a := &Archive{tmp: tmp}
+	if err := decrypt(tmp, r, passphrase); err != nil {
+		a.Close()
+		return nil, err
+	}
+	err = a.walk(func(h *tar.Header, body io.Reader) error {
+		if h.Name != manifestName {
+			return errors.New("archive does not start with a manifest")
+		}
+		if err := json.NewDecoder(body).Decode(&a.Manifest); err != nil {
+			return fmt.Errorf("invalid manifest: %w", err)
+		}
+		return errStopWalk
+	})
+	if err == nil && a.Manifest.Version > ArchiveVersion {
+		err = fmt.Errorf("archive format %d is newer than this CLI supports (%d); run agent self-update", a.Manifest.Version, ArchiveVersion)
+	}
+	if err != nil {
+		a.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+var errStopWalk = errors.New("stop")
+
+func (a *Archive) walk(fn func(h *tar.Header, body io.Reader) error) error {
+	if _, err := a.tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	gz, err := gzip.NewReader(a.tmp)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(h, tr); err != nil {
+			if err == errStopWalk {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Extract writes the archived files. dest maps an archive name to the host path
+// to write it to; returning "" skips the file. Names that are absolute or climb
+// out with ".." are rejected.
+func (a *Archive) Extract(dest func(name string) string) ([]string, error) {
+	var written []string
+	err := a.walk(func(h *tar.Header, body io.Reader) error {
+		if h.Name == manifestName || h.Typeflag != tar.TypeReg {
+			return nil
+		}
+		if !safeName(h.Name) {
+			return fmt.Errorf("refusing unsafe path %q in archive", h.Name)
+		}
+		target := dest(h.Name)
+		if target == "" {
+			return nil
+		}
+		if err := writeFile(target, body, fs.FileMode(h.Mode).Perm()); err != nil {
+			return fmt.Errorf("restoring %s: %w", h.Name, err)
+		}
+		written = append(written, h.Name)
+		return nil
+	})
+	return written, err
+}
+
+// Close removes the staged plaintext.
+func (a *Archive) Close() error {
+	return a.tmp.Close()
+}
+
+func safeName(name string) bool {
+	return name != "" && !path.IsAbs(name) && path.Clean(name) == name && name != ".." && !strings.HasPrefix(name, "../")
+}
+
+func writeFile(dst string, r io.Reader, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
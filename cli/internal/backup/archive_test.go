@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() { kdfIterations = 1000 }
+
+func TestPBKDF2Vector(t *testing.T) {
+	// RFC 7914 section 11.
+	got := hex.EncodeToString(pbkdf2(sha256.New, []byte("passwd"), []byte("salt"), 1, 64))
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if got != want {
+		t.Fatalf("pbkdf2 = %s", got)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	write(t, filepath.Join(src, ".env"), "OPENAI_API_KEY=sk-1\n")
+	write(t, filepath.Join(src, "config", "contexts", "sales.yaml"), "prompt: sell\n")
+	write(t, filepath.Join(src, ".agent", "calls.json"), "[]")
+	write(t, filepath.Join(src, ".agent", "update-backups", "x", ".env"), "old")
+	big := bytes.Repeat([]byte("0123456789abcdef"), 3*chunkSize/16+7)
+	write(t, filepath.Join(src, "data", "call_history.db"), string(big))
+
+	var buf bytes.Buffer
+	m, err := CreateArchive(&buf, []byte("correct horse"), Manifest{Created: time.Now(), Host: "pbx1"}, []Source{
+		{".env", filepath.Join(src, ".env")},
+		{"config", filepath.Join(src, "config")},
+		{".agent", filepath.Join(src, ".agent")},
+		{"data/call_history.db", filepath.Join(src, "data", "call_history.db")},
+		{"missing", filepath.Join(src, "missing")},
+	}, func(name string) bool { return name == ".agent/update-backups" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(m.Files, ","); got != ".agent/calls.json,.env,config/contexts/sales.yaml,data/call_history.db" {
+		t.Fatalf("files = %s", got)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("sk-1")) || bytes.Contains(buf.Bytes(), []byte("prompt")) {
+		t.Fatal("archive is not encrypted")
+	}
+
+	if _, err := OpenArchive(bytes.NewReader(buf.Bytes()), []byte("wrong")); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("wrong passphrase: %v", err)
+	}
+	if _, err := OpenArchive(bytes.NewReader(buf.Bytes()[:buf.Len()-100]), []byte("correct horse")); !errors.Is(err, ErrPassphrase) {
+		t.Fatalf("truncated archive: %v", err)
+	}
+
+	a, err := OpenArchive(bytes.NewReader(buf.Bytes()), []byte("correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if a.Manifest.Host != "pbx1" || len(a.Manifest.Files) != 4 {
+		t.Fatalf("manifest = %+v", a.Manifest)
+	}
+	dst := t.TempDir()
+	written, err := a.Extract(func(name string) string {
+		if name == ".env" {
+			return ""
+		}
+		return filepath.Join(dst, filepath.FromSlash(name))
+	})
+	if err != nil || len(written) != 3 {
+		t.Fatalf("Extract = %v, %v", written, err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dst, "data", "call_history.db")); !bytes.Equal(got, big) {
+		t.Fatal("large file did not round-trip")
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".env")); !os.IsNotExist(err) {
+		t.Fatal("skipped file was written")
+	}
+}
+
+func TestExtractRejectsUnsafePaths(t *testing.T) {
+	var buf bytes.Buffer
+	enc, _ := newEncryptWriter(&buf, []byte("pw"))
+	gz := gzip.NewWriter(enc)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{manifestName, "../escape"} {
+		body := []byte("{}")
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(body))})
+		tw.Write(body)
+	}
+	tw.Close()
+	gz.Close()
+	enc.Close()
+
+	a, err := OpenArchive(&buf, []byte("pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	dst := t.TempDir()
+	if _, err := a.Extract(func(name string) string { return filepath.Join(dst, name) }); err == nil || !strings.Contains(err.Error(), "unsafe") {
+		t.Fatalf("Extract = %v", err)
+	}
+}
@@ -0,0 +1,266 @@
+// Package backup reads the operator-config snapshots agent update writes to
+// .agent/update-backups/<id>: listing them, diffing them against the checkout and
+// restoring single files.
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dir is where agent update writes snapshots, relative to the repo root.
+var Dir = filepath.Join(".agent", "update-backups")
+
+// Snapshot is one backup directory.
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Path    string    `json:"path"`
+	Created time.Time `json:"created"`
+	Files   []string  `json:"files"` // slash-separated, relative to the repo root
+}
+
+// Change is one file that differs between a snapshot and the checkout.
+type Change struct {
+	Path   string `json:"path"`
+	Status string `json:"status"` // modified | missing (deleted since backup) | added (new since backup)
+	Binary bool   `json:"binary,omitempty"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+// List returns the snapshots under root, newest first.
+func List(root string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(root, Dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []Snapshot
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		s, err := load(root, e.Name())
+		if err != nil {
+			continue
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.After(out[j].Created) })
+	return out, nil
+}
+
+func load(root, id string) (*Snapshot, error) {
+	dir := filepath.Join(root, Dir, id)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{ID: id, Path: dir, Created: info.ModTime()}
+	if t, err := time.ParseInLocation("20060102_150405", id, time.UTC); err == nil {
+		s.Created = t
+	}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		s.Files = append(s.Files, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(s.Files)
+	return s, err
+}
+
+// Find resolves id to a snapshot: an exact directory name, a unique prefix of
+// one, or "latest".
+func Find(root, id string) (*Snapshot, error) {
+	all, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return nil, fmt.Errorf("no backups in %s", filepath.Join(root, Dir))
+	}
+	if id == "latest" {
+		return &all[0], nil
+	}
+	var matches []Snapshot
+	for _, s := range all {
+		if s.ID == id {
+			return &s, nil
+		}
+		if strings.HasPrefix(s.ID, id) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no backup %q (see agent backup list)", id)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("backup %q is ambiguous: %d backups match", id, len(matches))
+}
+
+// Covers reports whether rel is path or lies under it; an empty path covers everything.
+func Covers(path, rel string) bool {
+	path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+	return path == "" || path == "." || rel == path || strings.HasPrefix(rel, path+"/")
+}
+
+// Diff compares the snapshot with the checkout at root, limited to path when set.
+// Files added since the backup are reported only inside directories it captured
+// (config/contexts/). redact masks .env values.
+func (s *Snapshot) Diff(root, path string, redact bool) ([]Change, error) {
+	var out []Change
+	seen := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, rel := range s.Files {
+		if !Covers(path, rel) {
+			continue
+		}
+		seen[rel] = true
+		if d := filepath.ToSlash(filepath.Dir(rel)); strings.HasPrefix(d, "config/contexts") {
+			dirs[d] = true
+		}
+		old, err := os.ReadFile(filepath.Join(s.Path, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		cur, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		switch {
+		case os.IsNotExist(err):
+			out = append(out, Change{Path: rel, Status: "missing"})
+			continue
+		case err != nil:
+			return nil, err
+		}
+		if bytes.Equal(old, cur) {
+			continue
+		}
+		c := Change{Path: rel, Status: "modified"}
+		if isBinary(old) || isBinary(cur) {
+			c.Binary = true
+		} else {
+			a, b := string(old), string(cur)
+			if redact && filepath.Base(rel) == ".env" {
+				a, b = RedactEnv(a), RedactEnv(b)
+			}
+			c.Diff = UnifiedDiff("backup/"+rel, rel, a, b)
+		}
+		out = append(out, c)
+	}
+	for d := range dirs {
+		entries, _ := os.ReadDir(filepath.Join(root, filepath.FromSlash(d)))
+		for _, e := range entries {
+			rel := d + "/" + e.Name()
+			if !e.IsDir() && !seen[rel] && Covers(path, rel) {
+				out = append(out, Change{Path: rel, Status: "added"})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// Restore copies the snapshot's files under path (everything when empty) back into
+// root. The files it overwrites are first saved to a new "pre-restore-<time>"
+// snapshot so the restore itself can be undone with agent backup restore.
+func (s *Snapshot) Restore(root, path string, skip func(rel string) bool) (restored []string, undoID string, err error) {
+	var files []string
+	for _, rel := range s.Files {
+		if Covers(path, rel) && (skip == nil || !skip(rel)) {
+			files = append(files, rel)
+		}
+	}
+	if len(files) == 0 {
+		return nil, "", fmt.Errorf("backup %s has no file matching %q", s.ID, path)
+	}
+
+	undoID, err = Preserve(root, files)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, rel := range files {
+		if err := copyFile(filepath.Join(s.Path, filepath.FromSlash(rel)), filepath.Join(root, filepath.FromSlash(rel))); err != nil {
+			return restored, undoID, err
+		}
+		restored = append(restored, rel)
+	}
+	return restored, undoID, nil
+}
+
+// Preserve copies the files at rels that exist under root into a new
+// "pre-restore-<time>" snapshot and returns its ID, or "" when none exist.
+func Preserve(root string, rels []string) (string, error) {
+	id := "pre-restore-" + time.Now().UTC().Format("20060102_150405")
+	dir := filepath.Join(root, Dir, id)
+	saved := false
+	for _, rel := range rels {
+		cur := filepath.Join(root, filepath.FromSlash(rel))
+		if _, err := os.Stat(cur); err != nil {
+			continue
+		}
+		if err := copyFile(cur, filepath.Join(dir, filepath.FromSlash(rel))); err != nil {
+			return "", fmt.Errorf("saving current %s before restore: %w", rel, err)
+		}
+		saved = true
+	}
+	if !saved {
+		return "", nil
+	}
+	return id, nil
+}
+
+// copyFile writes through a temp file in the destination directory and keeps the
+// source's permissions, so .env stays private and a reader never sees half a file.
+func copyFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return writeFile(dst, f, info.Mode().Perm())
+}
+
+func isBinary(b []byte) bool {
+	if len(b) > 8000 {
+		b = b[:8000]
+	}
+	return bytes.IndexByte(b, 0) >= 0
+}
+
+var envAssignRe = regexp.MustCompile(`^(\s*(?:export\s+)?[A-Za-z_][A-Za-z0-9_]*\s*=).+$`)
+
+// RedactEnv replaces every .env value with a short fingerprint, so a diff still
+// shows which keys changed without printing secrets.
+func RedactEnv(text string) string {
+	var out strings.Builder
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := envAssignRe.FindStringSubmatch(line); m != nil && !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			sum := sha256.Sum256([]byte(line[len(m[1]):]))
+			line = fmt.Sprintf("%s<redacted:%x>", m[1], sum[:3])
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
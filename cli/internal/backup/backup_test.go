@@ -0,0 +1,115 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, path, text string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setup(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	snap := filepath.Join(root, Dir, "20260101_020000")
+	write(t, filepath.Join(snap, ".env"), "OPENAI_API_KEY=sk-old\nASTERISK_HOST=127.0.0.1\n")
+	write(t, filepath.Join(snap, "config", "ai-agent.local.yaml"), "default_provider: openai_realtime\n")
+	write(t, filepath.Join(snap, "config", "contexts", "sales.yaml"), "prompt: sell\n")
+	write(t, filepath.Join(root, Dir, "20260201_020000", ".env"), "X=1\n")
+
+	write(t, filepath.Join(root, ".env"), "OPENAI_API_KEY=sk-new\nASTERISK_HOST=127.0.0.1\n")
+	write(t, filepath.Join(root, "config", "ai-agent.local.yaml"), "default_provider: deepgram\n")
+	write(t, filepath.Join(root, "config", "contexts", "support.yaml"), "prompt: help\n")
+	return root
+}
+
+func TestListAndFind(t *testing.T) {
+	root := setup(t)
+	all, err := List(root)
+	if err != nil || len(all) != 2 || all[0].ID != "20260201_020000" {
+		t.Fatalf("List = %+v, %v", all, err)
+	}
+	if got := strings.Join(all[1].Files, ","); got != ".env,config/ai-agent.local.yaml,config/contexts/sales.yaml" {
+		t.Fatalf("files = %s", got)
+	}
+	if s, err := Find(root, "latest"); err != nil || s.ID != "20260201_020000" {
+		t.Fatalf("latest = %+v, %v", s, err)
+	}
+	if s, err := Find(root, "202601"); err != nil || s.ID != "20260101_020000" {
+		t.Fatalf("prefix = %+v, %v", s, err)
+	}
+	if _, err := Find(root, "2026"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("ambiguous prefix should fail, got %v", err)
+	}
+}
+
+func TestDiffRedactsEnvAndReportsAddedAndMissing(t *testing.T) {
+	root := setup(t)
+	s, _ := Find(root, "20260101")
+	changes, err := s.Diff(root, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := map[string]string{}
+	for _, c := range changes {
+		status[c.Path] = c.Status
+	}
+	want := map[string]string{".env": "modified", "config/ai-agent.local.yaml": "modified", "config/contexts/sales.yaml": "missing", "config/contexts/support.yaml": "added"}
+	for p, st := range want {
+		if status[p] != st {
+			t.Errorf("%s = %q, want %q (all: %v)", p, status[p], st, status)
+		}
+	}
+	for _, c := range changes {
+		if c.Path == ".env" && (strings.Contains(c.Diff, "sk-") || !strings.Contains(c.Diff, "-OPENAI_API_KEY=<redacted:")) {
+			t.Errorf(".env diff leaks or lost the key:\n%s", c.Diff)
+		}
+		if c.Path == "config/ai-agent.local.yaml" && !strings.Contains(c.Diff, "-default_provider: openai_realtime\n+default_provider: deepgram\n") {
+			t.Errorf("yaml diff:\n%s", c.Diff)
+		}
+	}
+	only, _ := s.Diff(root, "config/contexts", true)
+	if len(only) != 2 {
+		t.Errorf("path filter = %+v", only)
+	}
+}
+
+func TestRestoreSavesCurrentFilesFirst(t *testing.T) {
+	root := setup(t)
+	s, _ := Find(root, "20260101")
+	restored, undo, err := s.Restore(root, "config/ai-agent.local.yaml", nil)
+	if err != nil || len(restored) != 1 || undo == "" {
+		t.Fatalf("Restore = %v, %q, %v", restored, undo, err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "config", "ai-agent.local.yaml")); string(got) != "default_provider: openai_realtime\n" {
+		t.Fatalf("restored = %q", got)
+	}
+	saved, _ := os.ReadFile(filepath.Join(root, Dir, undo, "config", "ai-agent.local.yaml"))
+	if string(saved) != "default_provider: deepgram\n" {
+		t.Fatalf("pre-restore copy = %q", saved)
+	}
+	if _, _, err := s.Restore(root, "config/nope.yaml", nil); err == nil {
+		t.Fatal("restoring a path the backup lacks should fail")
+	}
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	b := strings.Replace(strings.Replace(a, "2\n", "two\n", 1), "14\n", "", 1)
+	got := UnifiedDiff("a", "b", a, b)
+	if strings.Count(got, "@@ ") != 2 || !strings.Contains(got, "@@ -1,5 +1,5 @@\n 1\n-2\n+two\n") || !strings.Contains(got, "@@ -11,5 +11,4 @@\n 11\n 12\n 13\n-14\n 15\n") {
+		t.Fatalf("diff:\n%s", got)
+	}
+	if UnifiedDiff("a", "b", a, a) != "" {
+		t.Fatal("equal inputs should produce no diff")
+	}
+}
@@ -0,0 +1,190 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Encrypted archives are a header followed by AES-256-GCM sealed chunks:
+//
+//	magic[8] salt[16] iterations[4] noncePrefix[4]
+//	{ length[4] ciphertext[length] }...
+//
+// Each chunk's nonce is noncePrefix plus a counter, and its additional data marks
+// the last chunk, so reordered, dropped or truncated chunks fail to open.
+var archiveMagic = []byte("AAVABK1\x00")
+
+const chunkSize = 64 * 1024
+
+// kdfIterations is the PBKDF2-SHA256 cost for new archives; tests lower it.
+var kdfIterations = 600_000
+
+// ErrPassphrase is returned when an archive cannot be decrypted with the given
+// passphrase (or has been tampered with).
+var ErrPassphrase = errors.New("wrong passphrase or corrupted archive")
+
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint64
+	buf     []byte
+}
+
+func newEncryptWriter(w io.Writer, passphrase []byte) (*encryptWriter, error) {
+	salt := make([]byte, 16)
+	prefix := make([]byte, 4)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, salt, kdfIterations)
+	if err != nil {
+		return nil, err
+	}
+	header := append(append([]byte{}, archiveMagic...), salt...)
+	header = binary.BigEndian.AppendUint32(header, uint32(kdfIterations))
+	header = append(header, prefix...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, prefix: prefix, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		take := min(chunkSize-len(e.buf), len(p))
+		e.buf = append(e.buf, p[:take]...)
+		p = p[take:]
+		if len(e.buf) == chunkSize {
+			if err := e.seal(false); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close seals the final chunk, which may be empty.
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(final bool) error {
+	ct := e.aead.Seal(nil, e.nonce(), e.buf, chunkAD(final))
+	e.counter++
+	e.buf = e.buf[:0]
+	if _, err := e.w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(ct)))); err != nil {
+		return err
+	}
+	_, err := e.w.Write(ct)
+	return err
+}
+
+func (e *encryptWriter) nonce() []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, e.prefix...), e.counter)
+}
+
+// decrypt reads a whole encrypted archive from r and writes the plaintext to w.
+// Nothing should be trusted until it returns nil.
+func decrypt(w io.Writer, r io.Reader, passphrase []byte) error {
+	header := make([]byte, len(archiveMagic)+16+4+4)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(archiveMagic)]) != string(archiveMagic) {
+		return errors.New("not an agent backup archive")
+	}
+	rest := header[len(archiveMagic):]
+	salt, iterations, prefix := rest[:16], binary.BigEndian.Uint32(rest[16:20]), rest[20:24]
+	if iterations == 0 || iterations > 10_000_000 {
+		return fmt.Errorf("unsupported key derivation cost %d", iterations)
+	}
+	aead, err := newAEAD(passphrase, salt, int(iterations))
+	if err != nil {
+		return err
+	}
+	d := &encryptWriter{aead: aead, prefix: prefix}
+	lenBuf := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, lenBuf); err != nil {
+			return fmt.Errorf("archive is truncated: %w", ErrPassphrase)
+		}
+		size := binary.BigEndian.Uint32(lenBuf)
+		if size > chunkSize+uint32(aead.Overhead()) {
+			return ErrPassphrase
+		}
+		ct := make([]byte, size)
+		if _, err := io.ReadFull(r, ct); err != nil {
+			return fmt.Errorf("archive is truncated: %w", ErrPassphrase)
+		}
+		nonce := d.nonce()
+		d.counter++
+		if pt, err := aead.Open(nil, nonce, ct, chunkAD(false)); err == nil {
+			if _, err := w.Write(pt); err != nil {
+				return err
+			}
+			continue
+		}
+		pt, err := aead.Open(nil, nonce, ct, chunkAD(true))
+		if err != nil {
+			return ErrPassphrase
+		}
+		if _, err := w.Write(pt); err != nil {
+			return err
+		}
+		if n, _ := r.Read(lenBuf[:1]); n != 0 {
+			return errors.New("unexpected data after the end of the archive")
+		}
+		return nil
+	}
+}
+
+func chunkAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+func newAEAD(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2(sha256.New, passphrase, salt, iterations, 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, 12)
+}
+
+// pbkdf2 is RFC 8018 PBKDF2; the module targets a Go release without crypto/pbkdf2.
+func pbkdf2(h func() hash.Hash, password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+	var out []byte
+	u := make([]byte, hashLen)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(nil, uint32(block)))
+		u = prf.Sum(u[:0])
+		t := append([]byte{}, u...)
+		for n := 1; n < iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range t {
+				t[i] ^= u[i]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
@@ -0,0 +1,146 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffLines bounds the LCS table; config files are far smaller than this.
+const maxDiffLines = 5000
+
+const diffContext = 3
+
+type editOp struct {
+	kind byte // ' ', '-', '+'
+	text string
+}
+
+// UnifiedDiff returns a unified diff of a -> b labelled with the two names, or ""
+// when they are equal.
+func UnifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	al, bl := splitLines(a), splitLines(b)
+	if len(al) > maxDiffLines || len(bl) > maxDiffLines {
+		return fmt.Sprintf("--- %s\n+++ %s\n(files differ; too large to diff)\n", aName, bName)
+	}
+	ops := editScript(al, bl)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// Grow a hunk around this change until diffContext*2 unchanged lines separate it from the next.
+		start := max(i-diffContext, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > diffContext*2 {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = run
+		}
+		aStart, bStart := lineNumbers(ops, start)
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// editScript is the classic LCS walk; good enough for files of a few hundred lines.
+func editScript(a, b []string) []editOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []editOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, editOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, editOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, editOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, editOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, editOp{'+', b[j]})
+	}
+	return ops
+}
+
+// lineNumbers returns the 1-based a and b line numbers of ops[idx].
+func lineNumbers(ops []editOp, idx int) (int, int) {
+	a, b := 1, 1
+	for _, op := range ops[:idx] {
+		if op.kind != '+' {
+			a++
+		}
+		if op.kind != '-' {
+			b++
+		}
+	}
+	return a, b
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,256 @@
+// Package budget holds the per-call limits an operator sets for a deployment:
+// how slow the agent may be to reply, how much a call may cost and how long
+// it may last. agent rca reports a call over a limit as a finding, and agent
+// watch flags live calls that pass the duration and cost limits.
+//
+// The limits are configured in .agent/budgets.yaml. Cost is estimated from
+// the call's duration and a per-minute rate for the provider or pipeline
+// that handled it; the engine does not log the providers' token usage.
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Budget names, which are also the keys of severity in budgets.yaml.
+const (
+	Latency  = "response_latency"
+	Cost     = "call_cost"
+	Duration = "call_duration"
+)
+
+// Names lists the budgets.
+var Names = []string{Latency, Cost, Duration}
+
+// Severities. A warning breach makes agent rca warn; a critical one fails the
+// call, which runs the on-critical-call hook.
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Limits are the budgets; a zero limit is not checked.
+type Limits struct {
+	MaxResponseLatencyMS int           `yaml:"max_response_latency_ms" json:"max_response_latency_ms,omitempty"`
+	MaxCallCost          float64       `yaml:"max_call_cost" json:"max_call_cost,omitempty"`
+	MaxDuration          time.Duration `yaml:"max_duration" json:"-"`
+}
+
+// Config is .agent/budgets.yaml.
+type Config struct {
+	Limits `yaml:",inline"`
+	// Currency labels costs; it is not converted.
+	Currency string `yaml:"currency"`
+	// Rates is the cost per minute of a call by pipeline or provider name,
+	// with "default" for the rest.
+	Rates map[string]float64 `yaml:"rates"`
+	// Severity is warning or critical per budget; warning when unset.
+	Severity map[string]string `yaml:"severity"`
+	// Overrides replace limits for calls on one pipeline or provider.
+	Overrides map[string]Limits `yaml:"overrides"`
+}
+
+// Path returns the configuration file.
+func Path() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_BUDGETS_FILE")); p != "" {
+		return p
+	}
+	return filepath.Join(".agent", "budgets.yaml")
+}
+
+// Load reads and checks the configuration. A missing file returns nil and no
+// error: no call is held to a budget.
+func Load(file string) (*Config, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(raw, cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	if c.Currency == "" {
+		c.Currency = "USD"
+	}
+	check := func(where string, l Limits) error {
+		if l.MaxResponseLatencyMS < 0 || l.MaxCallCost < 0 || l.MaxDuration < 0 {
+			return fmt.Errorf("%slimits cannot be negative", where)
+		}
+		if l.MaxDuration > 0 && l.MaxDuration < time.Second {
+			return fmt.Errorf("%smax_duration %v is under a second", where, l.MaxDuration)
+		}
+		return nil
+	}
+	if err := check("", c.Limits); err != nil {
+		return err
+	}
+	costed := c.MaxCallCost > 0
+	for name, l := range c.Overrides {
+		if err := check("overrides: "+name+": ", l); err != nil {
+			return err
+		}
+		costed = costed || l.MaxCallCost > 0
+	}
+	for name, rate := range c.Rates {
+		if rate < 0 {
+			return fmt.Errorf("rates: %s: the rate cannot be negative", name)
+		}
+	}
+	for name, sev := range c.Severity {
+		if !known(name) {
+			return fmt.Errorf("severity: unknown budget %q (use %s)", name, strings.Join(Names, ", "))
+		}
+		if sev != SeverityWarning && sev != SeverityCritical {
+			return fmt.Errorf("severity: %s: %q is neither %s nor %s", name, sev, SeverityWarning, SeverityCritical)
+		}
+	}
+	if costed && len(c.Rates) == 0 {
+		return errors.New("max_call_cost needs rates to estimate a call's cost from")
+	}
+	if c.MaxResponseLatencyMS == 0 && c.MaxCallCost == 0 && c.MaxDuration == 0 && len(c.Overrides) == 0 {
+		return errors.New("no budget is set (max_response_latency_ms, max_call_cost, max_duration)")
+	}
+	return nil
+}
+
+func known(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage is what a call used, as far as it is known; zero values are unknown.
+type Usage struct {
+	Pipeline string
+	Provider string
+	// ResponseLatencyMS is the call's p95 turn latency.
+	ResponseLatencyMS float64
+	Duration          time.Duration
+}
+
+// Breach is a call over one budget.
+type Breach struct {
+	Budget   string  `json:"budget"`
+	Severity string  `json:"severity"`
+	Value    float64 `json:"value"`
+	Limit    float64 `json:"limit"`
+	Unit     string  `json:"unit"` // ms, seconds or the currency
+	Finding  string  `json:"finding"`
+}
+
+// Critical reports whether the breach fails the call.
+func (b Breach) Critical() bool {
+	return b.Severity == SeverityCritical
+}
+
+// Result is a call checked against its budgets.
+type Result struct {
+	Limits Limits `json:"limits"`
+	// MaxDurationSeconds is Limits.MaxDuration, which JSON cannot carry as is.
+	MaxDurationSeconds float64  `json:"max_duration_seconds,omitempty"`
+	Cost               *float64 `json:"estimated_cost,omitempty"`
+	Currency           string   `json:"currency,omitempty"`
+	Breaches           []Breach `json:"breaches"`
+}
+
+// LimitsFor is the limits for a call on pipeline or provider: the pipeline's
+// override, else the provider's, else the top-level limits. An override only
+// replaces the limits it sets.
+func (c *Config) LimitsFor(pipeline, provider string) Limits {
+	l := c.Limits
+	for _, name := range []string{provider, pipeline} {
+		o, ok := c.Overrides[name]
+		if name == "" || !ok {
+			continue
+		}
+		if o.MaxResponseLatencyMS > 0 {
+			l.MaxResponseLatencyMS = o.MaxResponseLatencyMS
+		}
+		if o.MaxCallCost > 0 {
+			l.MaxCallCost = o.MaxCallCost
+		}
+		if o.MaxDuration > 0 {
+			l.MaxDuration = o.MaxDuration
+		}
+	}
+	return l
+}
+
+// Rate is the cost per minute of a call on pipeline or provider.
+func (c *Config) Rate(pipeline, provider string) (float64, bool) {
+	for _, name := range []string{pipeline, provider, "default"} {
+		if rate, ok := c.Rates[name]; ok && name != "" {
+			return rate, true
+		}
+	}
+	return 0, false
+}
+
+// EstimateCost is the cost of a call of d on pipeline or provider, rounded to
+// four decimals.
+func (c *Config) EstimateCost(pipeline, provider string, d time.Duration) (float64, bool) {
+	rate, ok := c.Rate(pipeline, provider)
+	if !ok || d <= 0 {
+		return 0, false
+	}
+	return math.Round(d.Minutes()*rate*10000) / 10000, true
+}
+
+func (c *Config) severity(budget string) string {
+	if s := c.Severity[budget]; s != "" {
+		return s
+	}
+	return SeverityWarning
+}
+
+// Evaluate checks a call's usage against its limits. Budgets whose usage is
+// unknown are not checked.
+func (c *Config) Evaluate(u Usage) *Result {
+	l := c.LimitsFor(u.Pipeline, u.Provider)
+	r := &Result{Limits: l, MaxDurationSeconds: l.MaxDuration.Seconds(), Breaches: []Breach{}}
+	if l.MaxResponseLatencyMS > 0 && u.ResponseLatencyMS > float64(l.MaxResponseLatencyMS) {
+		r.Breaches = append(r.Breaches, Breach{
+			Budget: Latency, Severity: c.severity(Latency), Value: u.ResponseLatencyMS, Limit: float64(l.MaxResponseLatencyMS), Unit: "ms",
+			Finding: fmt.Sprintf("response latency over budget: p95 %.0fms, budget %dms", u.ResponseLatencyMS, l.MaxResponseLatencyMS),
+		})
+	}
+	if l.MaxDuration > 0 && u.Duration > l.MaxDuration {
+		r.Breaches = append(r.Breaches, Breach{
+			Budget: Duration, Severity: c.severity(Duration), Value: u.Duration.Seconds(), Limit: l.MaxDuration.Seconds(), Unit: "seconds",
+			Finding: fmt.Sprintf("call duration over budget: %s, budget %s", u.Duration.Round(time.Second), l.MaxDuration),
+		})
+	}
+	if cost, ok := c.EstimateCost(u.Pipeline, u.Provider, u.Duration); ok {
+		r.Cost, r.Currency = &cost, c.Currency
+		if l.MaxCallCost > 0 && cost > l.MaxCallCost {
+			r.Breaches = append(r.Breaches, Breach{
+				Budget: Cost, Severity: c.severity(Cost), Value: cost, Limit: l.MaxCallCost, Unit: c.Currency,
+				Finding: fmt.Sprintf("call cost over budget: about %.2f %s, budget %.2f %s", cost, c.Currency, l.MaxCallCost, c.Currency),
+			})
+		}
+	}
+	sort.SliceStable(r.Breaches, func(i, j int) bool { return r.Breaches[i].Critical() && !r.Breaches[j].Critical() })
+	return r
+}
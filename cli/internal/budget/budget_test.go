@@ -0,0 +1,96 @@
+package budget
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBudgets = `
+max_response_latency_ms: 1500
+max_call_cost: 0.50
+max_duration: 10m
+currency: EUR
+rates:
+  default: 0.02
+  openai_realtime: 0.10
+severity:
+  call_cost: critical
+overrides:
+  google_live:
+    max_response_latency_ms: 2500
+`
+
+func load(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "budgets.yaml")
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(file)
+}
+
+func TestLoad(t *testing.T) {
+	if cfg, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); cfg != nil || err != nil {
+		t.Fatalf("missing file = %v, %v", cfg, err)
+	}
+	cfg, err := load(t, testBudgets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxDuration != 10*time.Minute || cfg.MaxCallCost != 0.5 || cfg.Currency != "EUR" {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+	for yaml, want := range map[string]string{
+		"max_duration: 500ms\n":                              "under a second",
+		"max_duration: 600\n":                                "time.Duration",
+		"max_call_cost: 1\n":                                 "needs rates",
+		"overrides:\n  x:\n    max_call_cost: 1\n":           "needs rates",
+		"max_duration: 5m\nseverity:\n  latency: critical\n": "unknown budget",
+		"max_duration: 5m\nseverity:\n  call_cost: page\n":   "neither",
+		"currency: USD\n":                                    "no budget",
+		"max_response_latency_ms: -1\n":                      "negative",
+		"max_duration: 5m\nrates:\n  default: -0.1\n":        "negative",
+	} {
+		if _, err := load(t, yaml); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: err = %v, want %q", yaml, err, want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	cfg, err := load(t, testBudgets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := cfg.Evaluate(Usage{Provider: "openai_realtime", ResponseLatencyMS: 1800, Duration: 12 * time.Minute})
+	if len(r.Breaches) != 3 || r.Cost == nil || *r.Cost != 1.2 || r.Currency != "EUR" {
+		t.Fatalf("over everything = %+v", r)
+	}
+	if b := r.Breaches[0]; b.Budget != Cost || !b.Critical() || b.Limit != 0.5 {
+		t.Errorf("critical breach first: %+v", b)
+	}
+	if b := r.Breaches[1]; b.Budget != Latency || b.Critical() || b.Value != 1800 {
+		t.Errorf("latency breach: %+v", b)
+	}
+
+	// The override raises the latency budget; the default rate applies.
+	r = cfg.Evaluate(Usage{Provider: "google_live", ResponseLatencyMS: 1800, Duration: 3 * time.Minute})
+	if len(r.Breaches) != 0 || r.Limits.MaxResponseLatencyMS != 2500 || *r.Cost != 0.06 {
+		t.Fatalf("override = %+v", r)
+	}
+	// A pipeline's rate wins over its provider's.
+	cfg.Rates["local_hybrid"] = 0.01
+	if cost, _ := cfg.EstimateCost("local_hybrid", "openai_realtime", time.Minute); cost != 0.01 {
+		t.Errorf("pipeline rate: %v", cost)
+	}
+
+	// Unknown usage is not checked.
+	r = cfg.Evaluate(Usage{Provider: "openai_realtime"})
+	if len(r.Breaches) != 0 || r.Cost != nil {
+		t.Fatalf("unknown usage = %+v", r)
+	}
+}
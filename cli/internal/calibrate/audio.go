@@ -0,0 +1,142 @@
+package calibrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+// Test caller audio is 8 kHz μ-law, the format Asterisk plays .ulaw files in.
+const (
+	bytesPerMS  = 8
+	ulawSilence = 0xFF
+)
+
+// Pattern is one test caller's recording: silence while the agent greets,
+// the first phrase, a pause, the second phrase, then silence for the agent
+// to reply into. Offsets are milliseconds into the recording.
+type Pattern struct {
+	Name      string `json:"name"` // Asterisk sound name, without directory or extension
+	PauseMS   int    `json:"pause_ms"`
+	SpeechMS  int    `json:"speech_from_ms"`
+	PauseAtMS int    `json:"pause_at_ms"` // from the start of speech
+	EndMS     int    `json:"speech_to_ms"`
+	LengthMS  int    `json:"length_ms"`
+}
+
+// Schedule is when the caller speaks, from the call's first engine line,
+// for a caller that waits waitMS before playing the recording.
+func (p Pattern) Schedule(waitMS int) troubleshoot.SpeechSchedule {
+	return troubleshoot.SpeechSchedule{
+		StartMS:   int64(waitMS + p.SpeechMS),
+		PauseAtMS: int64(p.PauseAtMS),
+		PauseMS:   int64(p.PauseMS),
+		EndMS:     int64(waitMS + p.EndMS),
+	}
+}
+
+// Compose builds the recording for a pause of pauseMS between two μ-law
+// phrases, whose own leading and trailing silence is trimmed first.
+func Compose(first, second []byte, leadMS, pauseMS, tailMS int) (Pattern, []byte) {
+	first, second = Trim(first), Trim(second)
+	p := Pattern{Name: fmt.Sprintf("aava-calib-%d", pauseMS), PauseMS: pauseMS, SpeechMS: leadMS}
+	var audio []byte
+	audio = appendSilence(audio, leadMS)
+	audio = append(audio, first...)
+	p.PauseAtMS = len(first) / bytesPerMS
+	audio = appendSilence(audio, pauseMS)
+	audio = append(audio, second...)
+	p.EndMS = len(audio) / bytesPerMS
+	audio = appendSilence(audio, tailMS)
+	p.LengthMS = len(audio) / bytesPerMS
+	return p, audio
+}
+
+func appendSilence(audio []byte, ms int) []byte {
+	for i := 0; i < ms*bytesPerMS; i++ {
+		audio = append(audio, ulawSilence)
+	}
+	return audio
+}
+
+// Trim cuts the silence before the first and after the last 10ms frame
+// with speech in it.
+func Trim(ulaw []byte) []byte {
+	const frame = 10 * bytesPerMS
+	voiced := func(f []byte) bool {
+		for _, b := range f {
+			if s := ulawLinear(b); s > 800 || s < -800 {
+				return true
+			}
+		}
+		return false
+	}
+	from, to := -1, 0
+	for i := 0; i < len(ulaw); i += frame {
+		end := min(i+frame, len(ulaw))
+		if voiced(ulaw[i:end]) {
+			if from < 0 {
+				from = i
+			}
+			to = end
+		}
+	}
+	if from < 0 {
+		return nil
+	}
+	return ulaw[from:to]
+}
+
+// ulawLinear decodes a G.711 μ-law byte to a 16-bit sample.
+func ulawLinear(u byte) int {
+	u = ^u
+	s := ((int(u&0x0f) << 3) + 0x84) << ((u >> 4) & 0x07)
+	s -= 0x84
+	if u&0x80 != 0 {
+		return -s
+	}
+	return s
+}
+
+// manifest is the file Save writes the patterns to.
+const manifest = "patterns.json"
+
+// Save writes the recordings as <name>.ulaw files and their patterns to dir,
+// for a later run to replay.
+func Save(dir string, patterns []Pattern, audio [][]byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for i, p := range patterns {
+		if err := os.WriteFile(filepath.Join(dir, p.Name+".ulaw"), audio[i], 0o644); err != nil {
+			return err
+		}
+	}
+	b, err := json.MarshalIndent(patterns, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifest), append(b, '\n'), 0o644)
+}
+
+// Load reads the patterns and recordings Save wrote to dir.
+func Load(dir string) ([]Pattern, [][]byte, error) {
+	b, err := os.ReadFile(filepath.Join(dir, manifest))
+	if err != nil {
+		return nil, nil, fmt.Errorf("no saved calibration calls: %w", err)
+	}
+	var patterns []Pattern
+	if err := json.Unmarshal(b, &patterns); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", filepath.Join(dir, manifest), err)
+	}
+	audio := make([][]byte, len(patterns))
+	for i, p := range patterns {
+		if audio[i], err = os.ReadFile(filepath.Join(dir, p.Name+".ulaw")); err != nil {
+			return nil, nil, err
+		}
+	}
+	return patterns, audio, nil
+}
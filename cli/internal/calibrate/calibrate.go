@@ -0,0 +1,224 @@
+// Package calibrate tunes the silence timer that ends a caller's turn. It
+// builds test callers that speak one utterance with a pause of known length
+// inside it, finds the timer each provider or pipeline ends turns with, and
+// turns the measured calls into a recommended value for it.
+//
+// A timer that is too short ends the turn when the caller pauses for thought
+// and the agent talks over them; one that is too long adds its length to
+// every reply. The recommendation is the shortest timer that still keeps
+// pauses up to a chosen length inside the caller's turn.
+package calibrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+// Knob is the config setting that holds a provider's end-of-turn silence
+// timer.
+type Knob struct {
+	Path      []string `json:"-"`
+	Key       string   `json:"key"` // dotted config path
+	CurrentMS int      `json:"current_ms"`
+	// Default is set when the config does not set the timer and CurrentMS is
+	// the engine's or the provider's default.
+	Default bool `json:"default,omitempty"`
+	MinMS   int  `json:"-"`
+	MaxMS   int  `json:"-"`
+}
+
+// Patch is the config overlay that sets the timer to ms.
+func (k Knob) Patch(ms int) map[string]any {
+	var patch any = ms
+	for i := len(k.Path) - 1; i >= 0; i-- {
+		patch = map[string]any{k.Path[i]: patch}
+	}
+	return patch.(map[string]any)
+}
+
+// ResolveKnob finds the silence timer of name, a provider or a pipeline. It
+// returns a nil knob and the reason when the engine has no such timer for it
+// (the turn is then measured but not tuned), and an error when name is
+// neither.
+func ResolveKnob(cfg map[string]any, name string) (*Knob, string, error) {
+	providers := mapAt(cfg, "providers")
+	if pl, ok := mapAt(cfg, "pipelines")[name].(map[string]any); ok {
+		stt := str(pl, "stt")
+		options := mapAt(mapAt(pl, "options"), "stt")
+		k := &Knob{Path: []string{"pipelines", name, "options", "stt"}, MinMS: 100, MaxMS: 5000}
+		pc, _ := providers[stt].(map[string]any)
+		switch kind(stt, pc) {
+		case "local":
+			k.Path = append(k.Path, "segment_silence_ms")
+			k.CurrentMS, k.Default = timer("segment_silence_ms", 500, options)
+		case "azure":
+			k.Path = append(k.Path, "vad_silence_timeout_ms")
+			k.CurrentMS, k.Default = timer("vad_silence_timeout_ms", 300, options, pc)
+		default:
+			return nil, fmt.Sprintf("pipeline %s transcribes with %s, whose end of turn the engine does not set a timer for", name, emptyTo(stt, "no stt")), nil
+		}
+		k.Key = strings.Join(k.Path, ".")
+		return k, "", nil
+	}
+	pc, ok := providers[name].(map[string]any)
+	if !ok {
+		return nil, "", fmt.Errorf("%s is neither a provider nor a pipeline", name)
+	}
+	k := &Knob{Path: []string{"providers", name}, MinMS: 100, MaxMS: 3000}
+	switch kind(name, pc) {
+	case "openai_realtime":
+		// Without turn_detection the engine sends OpenAI's server_vad, whose
+		// default is 500ms; with it, silence_duration_ms defaults to 200.
+		k.Path = append(k.Path, "turn_detection", "silence_duration_ms")
+		if td, ok := pc["turn_detection"].(map[string]any); ok {
+			k.CurrentMS, k.Default = timer("silence_duration_ms", 200, td)
+		} else {
+			k.CurrentMS, k.Default = 500, true
+		}
+	case "grok":
+		k.Path = append(k.Path, "turn_detection", "silence_duration_ms")
+		k.CurrentMS, k.Default = timer("silence_duration_ms", 600, mapAt(pc, "turn_detection"))
+	case "google_live":
+		k.Path = append(k.Path, "vad_silence_duration_ms")
+		k.CurrentMS, k.Default = timer("vad_silence_duration_ms", 500, pc)
+	default:
+		return nil, fmt.Sprintf("%s ends turns inside its own service, with no silence timer the engine sets", name), nil
+	}
+	k.Key = strings.Join(k.Path, ".")
+	return k, "", nil
+}
+
+// kind is the provider's type, else its name, as the engine tells providers
+// apart.
+func kind(name string, pc map[string]any) string {
+	k := str(pc, "type")
+	if k == "" || k == "full" {
+		k = name
+	}
+	for _, known := range []string{"openai_realtime", "grok", "google_live", "local", "azure"} {
+		if strings.HasPrefix(k, known) {
+			return known
+		}
+	}
+	return k
+}
+
+// timer reads key from the first map that sets it, else returns def.
+func timer(key string, def int, from ...map[string]any) (int, bool) {
+	for _, m := range from {
+		switch v := m[key].(type) {
+		case int:
+			return v, false
+		case int64:
+			return int(v), false
+		case float64:
+			return int(v), false
+		}
+	}
+	return def, true
+}
+
+// Recommendation is the silence timer the calibration calls argue for.
+type Recommendation struct {
+	Key           string `json:"key,omitempty"`
+	CurrentMS     int    `json:"current_ms,omitempty"`
+	RecommendedMS int    `json:"recommended_ms,omitempty"`
+	Change        bool   `json:"change"`
+	// ShortestCutMS is the shortest test pause that ended the caller's turn
+	// and LongestKeptMS the longest one that did not.
+	ShortestCutMS int64 `json:"shortest_cut_pause_ms,omitempty"`
+	LongestKeptMS int64 `json:"longest_kept_pause_ms,omitempty"`
+	// ReplyP50MS is the median time from the end of the caller's speech to
+	// the agent's reply over the calls that kept the turn.
+	ReplyP50MS int64  `json:"reply_p50_ms,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// MarginMS is added over the longest pause a caller must be allowed, so a
+// turn is not cut by a pause just past it.
+const MarginMS = 150
+
+// Recommend reads the calls' cutoffs against the longest pause a caller
+// should be able to make (maxPauseMS). The provider's effective cutoff lies
+// between the longest kept and the shortest cut test pause; the timer moves
+// by the distance from that cutoff to maxPauseMS plus MarginMS, rounded to
+// 50ms and kept within the knob's range. k may be nil, for a provider whose
+// timer the engine does not set.
+func Recommend(k *Knob, calls []troubleshoot.EndpointingCall, maxPauseMS int) Recommendation {
+	var r Recommendation
+	var replies []int64
+	for _, c := range calls {
+		switch c.Verdict {
+		case troubleshoot.EndpointCutoff:
+			if c.PauseMS > 0 && (r.ShortestCutMS == 0 || c.PauseMS < r.ShortestCutMS) {
+				r.ShortestCutMS = c.PauseMS
+			}
+		case troubleshoot.EndpointOK:
+			replies = append(replies, c.ReplyMS)
+			if c.PauseMS > r.LongestKeptMS {
+				r.LongestKeptMS = c.PauseMS
+			}
+		}
+	}
+	if len(replies) > 0 {
+		sort.Slice(replies, func(i, j int) bool { return replies[i] < replies[j] })
+		r.ReplyP50MS = replies[len(replies)/2]
+	}
+	if k == nil {
+		r.Reason = "measured only; the engine sets no silence timer for this provider"
+		return r
+	}
+	r.Key, r.CurrentMS, r.RecommendedMS = k.Key, k.CurrentMS, k.CurrentMS
+	target := int64(maxPauseMS + MarginMS)
+
+	var rec int64
+	switch {
+	case r.ShortestCutMS > 0 && r.ShortestCutMS <= int64(maxPauseMS):
+		rec = int64(k.CurrentMS) + target - r.ShortestCutMS
+		r.Reason = fmt.Sprintf("a %dms pause ended the caller's turn; pauses up to %dms should not", r.ShortestCutMS, maxPauseMS)
+	case r.ShortestCutMS > 0:
+		rec = int64(k.CurrentMS) + target - r.ShortestCutMS
+		r.Reason = fmt.Sprintf("turns end at pauses of about %dms, more than the %dms callers need", r.ShortestCutMS, maxPauseMS)
+	case r.LongestKeptMS > int64(maxPauseMS):
+		rec = int64(k.CurrentMS) + target - r.LongestKeptMS
+		r.Reason = fmt.Sprintf("no test pause ended the turn, up to %dms, more than the %dms callers need", r.LongestKeptMS, maxPauseMS)
+	case r.LongestKeptMS > 0:
+		r.Reason = fmt.Sprintf("no test pause ended the turn; add pauses longer than %dms to find how far the timer can come down", maxPauseMS)
+		return r
+	default:
+		r.Reason = "no call gave a usable result"
+		return r
+	}
+	rec = (rec + 25) / 50 * 50
+	rec = max(int64(k.MinMS), min(int64(k.MaxMS), rec))
+	if r.ShortestCutMS == 0 || r.ShortestCutMS > int64(maxPauseMS) {
+		// Coming down is only worth it when it shortens every reply.
+		rec = min(rec, int64(k.CurrentMS))
+	}
+	r.RecommendedMS = int(rec)
+	r.Change = r.RecommendedMS != k.CurrentMS
+	if !r.Change {
+		r.Reason += "; the current timer is right"
+	}
+	return r
+}
+
+func mapAt(m map[string]any, key string) map[string]any {
+	v, _ := m[key].(map[string]any)
+	return v
+}
+
+func str(m map[string]any, key string) string {
+	v, _ := m[key].(string)
+	return strings.TrimSpace(v)
+}
+
+func emptyTo(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
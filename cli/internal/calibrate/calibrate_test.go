@@ -0,0 +1,150 @@
+package calibrate
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+const testConfig = `
+providers:
+  openai_realtime:
+    type: openai_realtime
+    turn_detection:
+      silence_duration_ms: 1000
+  openai_plain:
+    type: openai_realtime
+  google_live:
+    type: full
+    vad_silence_duration_ms: 500
+  deepgram:
+    type: full
+  local_stt:
+    type: local
+  azure_stt:
+    type: azure
+    vad_silence_timeout_ms: 400
+  openai_stt:
+    type: openai
+pipelines:
+  local_hybrid:
+    stt: local_stt
+    options:
+      stt:
+        segment_silence_ms: 700
+  azure_hybrid:
+    stt: azure_stt
+  openai_hybrid:
+    stt: openai_stt
+`
+
+func TestResolveKnob(t *testing.T) {
+	cfg, err := configmerge.ParseYAML([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name, key string
+		ms        int
+		def       bool
+	}{
+		{"openai_realtime", "providers.openai_realtime.turn_detection.silence_duration_ms", 1000, false},
+		{"openai_plain", "providers.openai_plain.turn_detection.silence_duration_ms", 500, true},
+		{"google_live", "providers.google_live.vad_silence_duration_ms", 500, false},
+		{"local_hybrid", "pipelines.local_hybrid.options.stt.segment_silence_ms", 700, false},
+		{"azure_hybrid", "pipelines.azure_hybrid.options.stt.vad_silence_timeout_ms", 400, false},
+	} {
+		k, why, err := ResolveKnob(cfg, tc.name)
+		if err != nil || k == nil {
+			t.Fatalf("%s: %v, %v (%s)", tc.name, k, err, why)
+		}
+		if k.Key != tc.key || k.CurrentMS != tc.ms || k.Default != tc.def {
+			t.Errorf("%s: %+v", tc.name, k)
+		}
+	}
+	for _, name := range []string{"deepgram", "openai_hybrid"} {
+		if k, why, err := ResolveKnob(cfg, name); err != nil || k != nil || why == "" {
+			t.Errorf("%s: %+v, %q, %v", name, k, why, err)
+		}
+	}
+	if _, _, err := ResolveKnob(cfg, "nope"); err == nil {
+		t.Error("unknown name resolved")
+	}
+
+	k, _, _ := ResolveKnob(cfg, "local_hybrid")
+	want := map[string]any{"pipelines": map[string]any{"local_hybrid": map[string]any{"options": map[string]any{"stt": map[string]any{"segment_silence_ms": 900}}}}}
+	if got := k.Patch(900); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Patch = %v", got)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	k := &Knob{Key: "x", CurrentMS: 500, MinMS: 100, MaxMS: 3000}
+	call := func(pause int64, verdict string, reply int64) troubleshoot.EndpointingCall {
+		return troubleshoot.EndpointingCall{PauseMS: pause, Verdict: verdict, ReplyMS: reply}
+	}
+
+	// Cut at 600ms with 700ms wanted: raise by 700+150-600.
+	calls := []troubleshoot.EndpointingCall{
+		call(0, troubleshoot.EndpointOK, 900),
+		call(300, troubleshoot.EndpointOK, 1000),
+		call(600, troubleshoot.EndpointCutoff, 0),
+		call(900, troubleshoot.EndpointCutoff, 0),
+	}
+	r := Recommend(k, calls, 700)
+	if !r.Change || r.RecommendedMS != 750 || r.ShortestCutMS != 600 || r.LongestKeptMS != 300 || r.ReplyP50MS != 1000 {
+		t.Fatalf("raise = %+v", r)
+	}
+
+	// Nothing cut up to 1400ms: come down, but not below the knob's floor.
+	calls = []troubleshoot.EndpointingCall{call(400, troubleshoot.EndpointOK, 1500), call(1400, troubleshoot.EndpointOK, 1600)}
+	if r := Recommend(&Knob{CurrentMS: 1000, MinMS: 100, MaxMS: 3000}, calls, 700); !r.Change || r.RecommendedMS != 450 {
+		t.Fatalf("lower = %+v", r)
+	}
+	if r := Recommend(&Knob{CurrentMS: 1000, MinMS: 600, MaxMS: 3000}, calls, 700); r.RecommendedMS != 600 {
+		t.Fatalf("floor = %+v", r)
+	}
+
+	// Kept pauses all within the wanted length: nothing to learn.
+	if r := Recommend(k, calls[:1], 700); r.Change || r.RecommendedMS != 500 {
+		t.Fatalf("no evidence = %+v", r)
+	}
+	if r := Recommend(nil, calls, 700); r.Change || r.ReplyP50MS != 1600 || r.Key != "" {
+		t.Fatalf("no knob = %+v", r)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	speech := func(ms int) []byte {
+		b := make([]byte, ms*bytesPerMS)
+		for i := range b {
+			b[i] = 0x10 // loud
+		}
+		return b
+	}
+	quiet := bytes.Repeat([]byte{ulawSilence}, 200*bytesPerMS)
+	first := append(append(append([]byte{}, quiet...), speech(1000)...), quiet...)
+	p, audio := Compose(first, speech(800), 5000, 600, 4000)
+	want := Pattern{Name: "aava-calib-600", PauseMS: 600, SpeechMS: 5000, PauseAtMS: 1000, EndMS: 7400, LengthMS: 11400}
+	if p != want || len(audio) != 11400*bytesPerMS {
+		t.Fatalf("Compose = %+v, %d bytes", p, len(audio))
+	}
+	if s := p.Schedule(1000); s.StartMS != 6000 || s.EndMS != 8400 || s.PauseAtMS != 1000 {
+		t.Fatalf("Schedule = %+v", s)
+	}
+	if Trim(quiet) != nil {
+		t.Fatal("silence kept")
+	}
+
+	dir := t.TempDir()
+	if err := Save(dir, []Pattern{p}, [][]byte{audio}); err != nil {
+		t.Fatal(err)
+	}
+	patterns, clips, err := Load(dir)
+	if err != nil || len(patterns) != 1 || patterns[0] != p || !bytes.Equal(clips[0], audio) {
+		t.Fatalf("Load = %+v, %v", patterns, err)
+	}
+}
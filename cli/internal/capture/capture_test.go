@@ -0,0 +1,224 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Unix(1769800000, 0)
+
+// pcapWriter builds a Linux cooked (SLL) capture, as tcpdump -i any writes.
+type pcapWriter struct{ bytes.Buffer }
+
+func newPcap() *pcapWriter {
+	w := &pcapWriter{}
+	for _, v := range []any{uint32(0xa1b2c3d4), uint16(2), uint16(4), int32(0), uint32(0), uint32(65535), uint32(linkSLL)} {
+		binary.Write(&w.Buffer, binary.LittleEndian, v)
+	}
+	return w
+}
+
+func (w *pcapWriter) packet(at time.Duration, src, dst [4]byte, sport, dport uint16, proto byte, transport []byte) {
+	ip := make([]byte, 20)
+	ip[0], ip[9] = 0x45, proto
+	binary.BigEndian.PutUint16(ip[2:], uint16(20+len(transport)))
+	copy(ip[12:], src[:])
+	copy(ip[16:], dst[:])
+	binary.BigEndian.PutUint16(transport[0:], sport)
+	binary.BigEndian.PutUint16(transport[2:], dport)
+	frame := append(make([]byte, 16), append(ip, transport...)...)
+	frame[14], frame[15] = 0x08, 0x00
+	ts := t0.Add(at)
+	for _, v := range []uint32{uint32(ts.Unix()), uint32(ts.Nanosecond() / 1000), uint32(len(frame)), uint32(len(frame))} {
+		binary.Write(&w.Buffer, binary.LittleEndian, v)
+	}
+	w.Write(frame)
+}
+
+func (w *pcapWriter) rtp(at time.Duration, src, dst [4]byte, sport, dport uint16, pt byte, seq uint16, ts uint32, payload int) {
+	udp := make([]byte, 8+12+payload)
+	binary.BigEndian.PutUint16(udp[4:], uint16(len(udp)))
+	udp[8], udp[9] = 0x80, pt
+	binary.BigEndian.PutUint16(udp[10:], seq)
+	binary.BigEndian.PutUint32(udp[12:], ts)
+	binary.BigEndian.PutUint32(udp[16:], 0x41415641)
+	w.packet(at, src, dst, sport, dport, 17, udp)
+}
+
+func (w *pcapWriter) tcp(at time.Duration, src, dst [4]byte, sport, dport uint16, seq uint32, payload []byte) {
+	seg := append(make([]byte, 20), payload...)
+	binary.BigEndian.PutUint32(seg[4:], seq)
+	seg[12], seg[13] = 5<<4, 0x18
+	w.packet(at, src, dst, sport, dport, 6, seg)
+}
+
+var (
+	asterisk = [4]byte{10, 0, 0, 2}
+	engine   = [4]byte{10, 0, 0, 5}
+)
+
+func TestAnalyzeRTP(t *testing.T) {
+	w := newPcap()
+	// Asterisk to engine: ulaw, 20ms. Packet 5 is lost, 8 and 9 swap, 12 is
+	// sent twice and nothing arrives for 300ms after 20.
+	at := time.Duration(0)
+	for seq := uint16(0); seq < 50; seq++ {
+		at += 20 * time.Millisecond
+		if seq == 21 {
+			at += 300 * time.Millisecond
+		}
+		switch seq {
+		case 5:
+			continue
+		case 8:
+			w.rtp(at+20*time.Millisecond, asterisk, engine, 14000, 18085, 0, 9, 9*160, 160)
+			w.rtp(at+21*time.Millisecond, asterisk, engine, 14000, 18085, 0, 8, 8*160, 160)
+			continue
+		case 9:
+			continue
+		case 12:
+			w.rtp(at, asterisk, engine, 14000, 18085, 0, seq, uint32(seq)*160, 160)
+		}
+		w.rtp(at, asterisk, engine, 14000, 18085, 0, seq, uint32(seq)*160, 160)
+	}
+	// Engine to Asterisk: the agent pauses for a second between two turns.
+	at = 0
+	for seq := uint16(0); seq < 40; seq++ {
+		at += 20 * time.Millisecond
+		if seq == 20 {
+			at += time.Second
+		}
+		ts := uint32(seq) * 160
+		if seq >= 20 {
+			ts += 8000
+		}
+		w.rtp(at, engine, asterisk, 18085, 14000, 0, seq, ts, 160)
+	}
+
+	packets, err := Read(&w.Buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := Analyze(packets, Options{RTPLow: 18080, RTPHigh: 18099})
+	if len(rep.Streams) != 2 {
+		t.Fatalf("streams = %+v", rep.Streams)
+	}
+	in, out := rep.Streams[0], rep.Streams[1]
+	if in.Direction != ToEngine || in.EnginePort() != 18085 || in.Codec != "ulaw" || in.PtimeMS != 20 {
+		t.Fatalf("inbound = %+v", in)
+	}
+	if in.Lost != 1 || in.OutOfOrder != 1 || in.Duplicates != 1 || len(in.Gaps) != 1 || in.Gaps[0].Paused {
+		t.Fatalf("inbound = %+v", in)
+	}
+	if out.Direction != FromEngine || len(out.Gaps) != 1 || !out.Gaps[0].Paused || out.Lost != 0 {
+		t.Fatalf("outbound = %+v", out)
+	}
+
+	findings := strings.Join(Findings(rep.Streams, Expect{Codec: "alaw"}), "\n")
+	for _, want := range []string{
+		"RTP to engine: 2.0% packet loss (1 of 50)",
+		"RTP to engine: 1 packet(s) out of order",
+		"RTP to engine: 1 gap(s) of 100ms or more, longest 320ms",
+		"RTP to engine: carries ulaw but alaw is configured",
+	} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("findings missing %q:\n%s", want, findings)
+		}
+	}
+	if strings.Contains(findings, "RTP from engine: 1 gap") {
+		t.Errorf("the agent's pause between turns is not a finding:\n%s", findings)
+	}
+}
+
+func TestRTCPReports(t *testing.T) {
+	w := newPcap()
+	for seq := uint16(0); seq < 50; seq++ {
+		w.rtp(time.Duration(seq)*20*time.Millisecond, engine, asterisk, 18085, 14000, 0, seq, uint32(seq)*160, 160)
+	}
+	rtcp := func(at time.Duration, src, dst [4]byte, sport, dport uint16, body []byte) {
+		w.packet(at, src, dst, sport, dport, 17, append(make([]byte, 8), body...))
+	}
+	// The engine's sender report, then Asterisk's receiver report about the
+	// engine's stream: 13/256 lost, jitter of 80 samples, and the report was
+	// held 150ms after the SR arrived.
+	sr := make([]byte, 28)
+	sr[0], sr[1] = 0x80, rtcpSR
+	binary.BigEndian.PutUint16(sr[2:], 6)
+	binary.BigEndian.PutUint32(sr[10:], 0x12345678)
+	rtcp(500*time.Millisecond, engine, asterisk, 18086, 14001, sr)
+	rr := make([]byte, 32)
+	rr[0], rr[1] = 0x81, rtcpRR
+	binary.BigEndian.PutUint16(rr[2:], 7)
+	binary.BigEndian.PutUint32(rr[8:], 0x41415641)
+	rr[12] = 13
+	binary.BigEndian.PutUint32(rr[20:], 80)
+	binary.BigEndian.PutUint32(rr[24:], 0x12345678)
+	binary.BigEndian.PutUint32(rr[28:], 9830) // 150ms in 1/65536s)
+	rtcp(700*time.Millisecond, asterisk, engine, 14001, 18086, rr)
+
+	packets, err := Read(&w.Buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := Analyze(packets, Options{RTPLow: 18080, RTPHigh: 18099})
+	if len(rep.Streams) != 1 || rep.Streams[0].RTCP == nil {
+		t.Fatalf("streams = %+v", rep.Streams)
+	}
+	r := rep.Streams[0].RTCP
+	if r.Reports != 1 || r.FractionLostPct != 5.1 || r.JitterMS != 10 || r.RTTMS < 49 || r.RTTMS > 51 {
+		t.Fatalf("rtcp = %+v", r)
+	}
+	findings := Findings(rep.Streams, Expect{})
+	if len(findings) != 1 || !strings.Contains(findings[0], "reported up to 5.1% loss in RTCP") {
+		t.Fatalf("findings = %v", findings)
+	}
+}
+
+func TestAnalyzeAudioSocket(t *testing.T) {
+	w := newPcap()
+	frame := func(kind byte, n int) []byte {
+		b := make([]byte, 3+n)
+		b[0] = kind
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		return b
+	}
+	// Asterisk connects to the engine and sends the call UUID, then 20ms
+	// slin frames; one segment is retransmitted.
+	seq := uint32(1000)
+	w.tcp(0, asterisk, engine, 40000, 8090, seq, frame(0x01, 16))
+	seq += 19
+	for i := 0; i < 30; i++ {
+		f := frame(0x10, 320)
+		w.tcp(time.Duration(i+1)*20*time.Millisecond, asterisk, engine, 40000, 8090, seq, f)
+		if i == 10 {
+			w.tcp(time.Duration(i+1)*20*time.Millisecond+5*time.Millisecond, asterisk, engine, 40000, 8090, seq, f)
+		}
+		seq += uint32(len(f))
+	}
+	packets, err := Read(&w.Buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := Analyze(packets, Options{AudioSocketPort: 8090})
+	if len(rep.Streams) != 1 {
+		t.Fatalf("streams = %+v", rep.Streams)
+	}
+	s := rep.Streams[0]
+	if s.Transport != "audiosocket" || s.Direction != ToEngine || s.Codec != "slin" || s.PtimeMS != 20 || s.Duplicates != 1 || s.Packets != 32 {
+		t.Fatalf("stream = %+v", s)
+	}
+	findings := Findings(rep.Streams, Expect{Codec: "ulaw"})
+	if len(findings) != 2 || !strings.Contains(findings[0], "retransmission") || !strings.Contains(findings[1], "carries slin but ulaw") {
+		t.Fatalf("findings = %v", findings)
+	}
+}
+
+func TestReadRejectsPcapng(t *testing.T) {
+	ng := []byte{0x0a, 0x0d, 0x0d, 0x0a, 0, 0, 0, 0, 0x4d, 0x3c, 0x2b, 0x1a, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	if _, err := Read(bytes.NewReader(ng)); err == nil || !strings.Contains(err.Error(), "pcapng") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package capture
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Thresholds above which a stream's measurements become findings.
+const (
+	lossPctLimit  = 1.0
+	jitterLimitMS = 30.0
+	retransLimit  = 0.01 // share of AudioSocket segments sent twice
+)
+
+// Expect is what the call's media should look like.
+type Expect struct {
+	Codec   string  // external_media.codec or audiosocket.format
+	PtimeMS float64 // 0 means 20ms
+}
+
+// NormalizeCodec maps Asterisk and SDP codec names onto the names streams
+// are reported with.
+func NormalizeCodec(name string) string {
+	switch n := strings.ToLower(strings.TrimSpace(name)); n {
+	case "ulaw", "mulaw", "pcmu", "g711u", "g711_ulaw":
+		return "ulaw"
+	case "alaw", "pcma", "g711a", "g711_alaw":
+		return "alaw"
+	case "slin", "slin8", "linear16", "pcm16", "l16":
+		return "slin"
+	default:
+		return n
+	}
+}
+
+// Findings describes the loss, jitter, reordering, gaps and codec or packet
+// time mismatches in streams, worst first within each stream.
+func Findings(streams []Stream, e Expect) []string {
+	var out []string
+	want := NormalizeCodec(e.Codec)
+	ptime := e.PtimeMS
+	if ptime == 0 {
+		ptime = 20
+	}
+	for _, s := range streams {
+		say := func(format string, args ...any) {
+			out = append(out, s.Label()+": "+fmt.Sprintf(format, args...))
+		}
+		if s.LossPct >= lossPctLimit {
+			say("%.1f%% packet loss (%d of %d)", s.LossPct, s.Lost, s.Lost+s.Packets-s.Duplicates)
+		} else if r := s.RTCP; r != nil && r.FractionLostPct >= lossPctLimit {
+			say("the receiver reported up to %.1f%% loss in RTCP", r.FractionLostPct)
+		}
+		if s.JitterMS > jitterLimitMS || s.MaxJitterMS > 2*jitterLimitMS {
+			say("jitter %.1fms (peak %.1fms)", s.JitterMS, s.MaxJitterMS)
+		}
+		if s.OutOfOrder > 0 {
+			say("%d packet(s) out of order", s.OutOfOrder)
+		}
+		if s.Transport == "audiosocket" && s.Packets > 0 && float64(s.Duplicates)/float64(s.Packets) >= retransLimit {
+			say("%d TCP retransmission(s) in %d segments", s.Duplicates, s.Packets)
+		}
+		if gaps := reportedGaps(s); len(gaps) > 0 {
+			longest := Stream{Gaps: gaps}.LongestGap()
+			say("%d gap(s) of %s or more, longest %.0fms at %s", len(gaps), GapThreshold, longest.MS, longest.At.Local().Format("15:04:05.000"))
+		}
+		if want != "" && s.Codec != "" && !codecMatches(s.Codec, want) {
+			say("carries %s but %s is configured", s.Codec, want)
+		}
+		if s.PtimeMS > 0 && s.PtimeMS != ptime {
+			say("ptime %gms, expected %gms", s.PtimeMS, ptime)
+		}
+	}
+	return out
+}
+
+// reportedGaps leaves out the pauses that are normal: the engine sends
+// audio only while the agent speaks, so gaps it paused for are expected.
+func reportedGaps(s Stream) []Gap {
+	if s.Direction == ToEngine {
+		return s.Gaps
+	}
+	if s.Transport == "audiosocket" {
+		return nil
+	}
+	var out []Gap
+	for _, g := range s.Gaps {
+		if !g.Paused {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func codecMatches(got, want string) bool {
+	if got == "g711" {
+		return want == "ulaw" || want == "alaw"
+	}
+	return got == want
+}
@@ -0,0 +1,198 @@
+// Package capture reads tcpdump captures of a call's media and measures the
+// RTP and AudioSocket streams in them: loss, jitter, reordering, gaps and the
+// codec and packet time actually on the wire.
+package capture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// Packet is a UDP or TCP packet read from a capture.
+type Packet struct {
+	Time     time.Time
+	Src, Dst netip.AddrPort
+	UDP      bool
+	Seq      uint32 // TCP sequence number
+	SYN      bool
+	Payload  []byte
+}
+
+// Link types tcpdump writes.
+const (
+	linkNull     = 0
+	linkEthernet = 1
+	linkRaw      = 101
+	linkLoop     = 108
+	linkSLL      = 113
+	linkIPv4     = 228
+	linkIPv6     = 229
+	linkSLL2     = 276
+)
+
+// Read returns the UDP and TCP packets of a pcap file, as written by
+// tcpdump -w. Other packets, IP fragments after the first and truncated
+// records are skipped.
+func Read(r io.Reader) ([]Packet, error) {
+	var hdr [24]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, errors.New("not a pcap file: too short")
+	}
+	var order binary.ByteOrder
+	nano := false
+	switch {
+	case binary.LittleEndian.Uint32(hdr[:4]) == 0xa1b2c3d4:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(hdr[:4]) == 0xa1b2c3d4:
+		order = binary.BigEndian
+	case binary.LittleEndian.Uint32(hdr[:4]) == 0xa1b23c4d:
+		order, nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(hdr[:4]) == 0xa1b23c4d:
+		order, nano = binary.BigEndian, true
+	case binary.LittleEndian.Uint32(hdr[:4]) == 0x0a0d0d0a:
+		return nil, errors.New("pcapng is not supported; capture with tcpdump -w or convert with editcap -F pcap")
+	default:
+		return nil, errors.New("not a pcap file")
+	}
+	link := order.Uint32(hdr[20:24]) & 0x0fffffff
+
+	var out []Packet
+	var rec [16]byte
+	for {
+		if _, err := io.ReadFull(r, rec[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return out, nil
+			}
+			return out, err
+		}
+		sec, frac := int64(order.Uint32(rec[0:4])), int64(order.Uint32(rec[4:8]))
+		size := order.Uint32(rec[8:12])
+		if size > 1<<18 {
+			return out, fmt.Errorf("corrupt pcap record of %d bytes", size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return out, nil
+		}
+		if !nano {
+			frac *= 1000
+		}
+		p, ok := decode(link, data)
+		if !ok {
+			continue
+		}
+		p.Time = time.Unix(sec, frac)
+		out = append(out, p)
+	}
+}
+
+// decode strips the link layer and parses the IP and transport headers.
+func decode(link uint32, data []byte) (Packet, bool) {
+	var ip []byte
+	switch link {
+	case linkEthernet:
+		if len(data) < 14 {
+			return Packet{}, false
+		}
+		etherType, off := binary.BigEndian.Uint16(data[12:14]), 14
+		for (etherType == 0x8100 || etherType == 0x88a8) && len(data) >= off+4 {
+			etherType, off = binary.BigEndian.Uint16(data[off+2:off+4]), off+4
+		}
+		if etherType != 0x0800 && etherType != 0x86dd {
+			return Packet{}, false
+		}
+		ip = data[off:]
+	case linkSLL:
+		if len(data) < 16 {
+			return Packet{}, false
+		}
+		ip = data[16:]
+	case linkSLL2:
+		if len(data) < 20 {
+			return Packet{}, false
+		}
+		ip = data[20:]
+	case linkNull, linkLoop:
+		if len(data) < 4 {
+			return Packet{}, false
+		}
+		ip = data[4:]
+	case linkRaw, linkIPv4, linkIPv6:
+		ip = data
+	default:
+		return Packet{}, false
+	}
+	return decodeIP(ip)
+}
+
+func decodeIP(b []byte) (Packet, bool) {
+	if len(b) < 1 {
+		return Packet{}, false
+	}
+	var p Packet
+	var proto byte
+	var body []byte
+	var src, dst netip.Addr
+	switch b[0] >> 4 {
+	case 4:
+		ihl := int(b[0]&0x0f) * 4
+		if ihl < 20 || len(b) < ihl {
+			return Packet{}, false
+		}
+		if binary.BigEndian.Uint16(b[6:8])&0x1fff != 0 {
+			return Packet{}, false // not the first fragment
+		}
+		total := int(binary.BigEndian.Uint16(b[2:4]))
+		if total < ihl || total > len(b) {
+			total = len(b)
+		}
+		proto = b[9]
+		src, _ = netip.AddrFromSlice(b[12:16])
+		dst, _ = netip.AddrFromSlice(b[16:20])
+		body = b[ihl:total]
+	case 6:
+		if len(b) < 40 {
+			return Packet{}, false
+		}
+		proto = b[6]
+		src, _ = netip.AddrFromSlice(b[8:24])
+		dst, _ = netip.AddrFromSlice(b[24:40])
+		end := 40 + int(binary.BigEndian.Uint16(b[4:6]))
+		if end > len(b) {
+			end = len(b)
+		}
+		body = b[40:end]
+	default:
+		return Packet{}, false
+	}
+	switch proto {
+	case 17:
+		if len(body) < 8 {
+			return Packet{}, false
+		}
+		p.UDP = true
+		p.Src = netip.AddrPortFrom(src, binary.BigEndian.Uint16(body[0:2]))
+		p.Dst = netip.AddrPortFrom(dst, binary.BigEndian.Uint16(body[2:4]))
+		p.Payload = body[8:]
+	case 6:
+		if len(body) < 20 {
+			return Packet{}, false
+		}
+		off := int(body[12]>>4) * 4
+		if off < 20 || off > len(body) {
+			return Packet{}, false
+		}
+		p.Src = netip.AddrPortFrom(src, binary.BigEndian.Uint16(body[0:2]))
+		p.Dst = netip.AddrPortFrom(dst, binary.BigEndian.Uint16(body[2:4]))
+		p.Seq = binary.BigEndian.Uint32(body[4:8])
+		p.SYN = body[13]&0x02 != 0
+		p.Payload = body[off:]
+	default:
+		return Packet{}, false
+	}
+	return p, true
+}
@@ -0,0 +1,87 @@
+package capture
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// RTCP packet types.
+const (
+	rtcpSR = 200
+	rtcpRR = 201
+)
+
+// RTCPReport is what the receiver of a stream said about it in RTCP
+// receiver report blocks.
+type RTCPReport struct {
+	Reports         int     `json:"reports"`
+	FractionLostPct float64 `json:"fraction_lost_pct"` // worst reporting interval
+	CumulativeLost  int     `json:"cumulative_lost"`
+	JitterMS        float64 `json:"jitter_ms"` // as last reported
+	RTTMS           float64 `json:"rtt_ms,omitempty"`
+}
+
+func isRTCP(b []byte) bool {
+	return len(b) >= 8 && b[0]>>6 == 2 && b[1] >= 200 && b[1] <= 204
+}
+
+// applyRTCP attaches the report blocks in packets to the streams they are
+// about. The round trip is measured when the sender report a block refers to
+// passed the capture point too, so no clock needs to be in sync.
+func applyRTCP(streams []Stream, packets []Packet) {
+	bySSRC := map[uint32]*Stream{}
+	for i := range streams {
+		if s := &streams[i]; s.Transport == "rtp" && bySSRC[s.SSRC] == nil {
+			bySSRC[s.SSRC] = s
+		}
+	}
+	srSeen := map[uint32]time.Time{} // middle 32 bits of an SR's NTP time
+	for _, p := range packets {
+		for b := p.Payload; isRTCP(b); {
+			n := (int(binary.BigEndian.Uint16(b[2:4])) + 1) * 4
+			if n > len(b) {
+				break
+			}
+			body, count := b[:n], int(b[0]&0x1f)
+			b = b[n:]
+			off := 8
+			switch body[1] {
+			case rtcpSR:
+				if len(body) < 28 {
+					continue
+				}
+				srSeen[binary.BigEndian.Uint32(body[10:14])] = p.Time
+				off = 28
+			case rtcpRR:
+			default:
+				continue
+			}
+			for i := 0; i < count && off+24 <= len(body); i, off = i+1, off+24 {
+				blk := body[off : off+24]
+				s := bySSRC[binary.BigEndian.Uint32(blk[0:4])]
+				if s == nil {
+					continue
+				}
+				if s.RTCP == nil {
+					s.RTCP = &RTCPReport{}
+				}
+				r := s.RTCP
+				r.Reports++
+				if fl := round1(float64(blk[4]) * 100 / 256); fl > r.FractionLostPct {
+					r.FractionLostPct = fl
+				}
+				r.CumulativeLost = int(binary.BigEndian.Uint32(blk[4:8]) & 0x7fffff)
+				if s.SampleRate > 0 {
+					r.JitterMS = round1(float64(binary.BigEndian.Uint32(blk[12:16])) * 1000 / float64(s.SampleRate))
+				}
+				lsr, dlsr := binary.BigEndian.Uint32(blk[16:20]), binary.BigEndian.Uint32(blk[20:24])
+				if at, ok := srSeen[lsr]; ok && lsr != 0 {
+					rtt := p.Time.Sub(at) - time.Duration(float64(dlsr)/65536*float64(time.Second))
+					if rtt > 0 {
+						r.RTTMS = ms(rtt)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package capture
+
+import (
+	"bytes"
+	"time"
+)
+
+// SIPPacket is one SIP message seen in a capture.
+type SIPPacket struct {
+	Time time.Time
+	Src  string
+	Dst  string
+	Text string
+}
+
+// isSIP reports whether b starts with a SIP response or request line.
+func isSIP(b []byte) bool {
+	if bytes.HasPrefix(b, []byte("SIP/2.0 ")) {
+		return true
+	}
+	method, rest, ok := bytes.Cut(b, []byte(" "))
+	if !ok || len(method) == 0 || len(method) > 10 {
+		return false
+	}
+	for _, c := range method {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return bytes.HasPrefix(rest, []byte("sip:")) || bytes.HasPrefix(rest, []byte("sips:")) || bytes.HasPrefix(rest, []byte("tel:"))
+}
@@ -0,0 +1,462 @@
+package capture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stream directions, relative to ai_engine.
+const (
+	ToEngine   = "to_engine"
+	FromEngine = "from_engine"
+)
+
+// GapThreshold is the shortest pause between packets reported as a gap.
+const GapThreshold = 100 * time.Millisecond
+
+// Options say which ports carry the engine's call media.
+type Options struct {
+	RTPLow, RTPHigh uint16 // external_media.port_range
+	AudioSocketPort uint16
+}
+
+// Gap is a pause in a stream's packets.
+type Gap struct {
+	At time.Time `json:"at"`
+	MS float64   `json:"ms"`
+	// Paused is set when the RTP timestamps advanced across the gap too: the
+	// sender had nothing to send, rather than its packets being held up.
+	Paused bool `json:"sender_paused,omitempty"`
+}
+
+// Stream is one direction of a call's media.
+type Stream struct {
+	Transport   string      `json:"transport"` // rtp or audiosocket
+	Direction   string      `json:"direction"`
+	Src         string      `json:"src"`
+	Dst         string      `json:"dst"`
+	SSRC        uint32      `json:"ssrc,omitempty"`
+	PayloadType *int        `json:"payload_type,omitempty"`
+	Codec       string      `json:"codec"`
+	SampleRate  int         `json:"sample_rate,omitempty"`
+	PtimeMS     float64     `json:"ptime_ms,omitempty"`
+	Packets     int         `json:"packets"`
+	Lost        int         `json:"lost"`
+	LossPct     float64     `json:"loss_pct"`
+	OutOfOrder  int         `json:"out_of_order"`
+	Duplicates  int         `json:"duplicates"` // repeated RTP packets or TCP retransmissions
+	JitterMS    float64     `json:"jitter_ms"`  // RFC 3550 interarrival jitter at the end of the stream
+	MaxJitterMS float64     `json:"max_jitter_ms"`
+	Gaps        []Gap       `json:"gaps,omitempty"`
+	RTCP        *RTCPReport `json:"rtcp,omitempty"`
+	Start       time.Time   `json:"start"`
+	End         time.Time   `json:"end"`
+
+	enginePort uint16
+}
+
+// EnginePort is the engine's end of the stream.
+func (s Stream) EnginePort() uint16 {
+	return s.enginePort
+}
+
+// Label names the stream in findings.
+func (s Stream) Label() string {
+	name := "RTP"
+	if s.Transport == "audiosocket" {
+		name = "AudioSocket"
+	}
+	if s.Direction == ToEngine {
+		return name + " to engine"
+	}
+	return name + " from engine"
+}
+
+// LongestGap is the longest pause in the stream.
+func (s Stream) LongestGap() (g Gap) {
+	for _, x := range s.Gaps {
+		if x.MS > g.MS {
+			g = x
+		}
+	}
+	return g
+}
+
+// Report is what a capture showed.
+type Report struct {
+	File     string   `json:"file,omitempty"`
+	Packets  int      `json:"packets"`
+	Streams  []Stream `json:"streams"`
+	Findings []string `json:"findings,omitempty"`
+	// SIP holds the SIP messages sent over UDP, for the call's ladder.
+	SIP []SIPPacket `json:"-"`
+}
+
+// Analyze splits the packets into RTP streams on the ExternalMedia port range
+// and AudioSocket connections on its port, and measures each. SIP over UDP on
+// any port is kept as it is.
+func Analyze(packets []Packet, o Options) Report {
+	rep := Report{Packets: len(packets), Streams: []Stream{}}
+	type flow struct {
+		key     string
+		packets []Packet
+	}
+	var flows []*flow
+	var rtcp []Packet
+	byKey := map[string]*flow{}
+	add := func(key string, p Packet) {
+		f := byKey[key]
+		if f == nil {
+			f = &flow{key: key}
+			byKey[key] = f
+			flows = append(flows, f)
+		}
+		f.packets = append(f.packets, p)
+	}
+	inRange := func(port uint16) bool { return o.RTPHigh > 0 && port >= o.RTPLow && port <= o.RTPHigh }
+	for _, p := range packets {
+		switch {
+		case p.UDP && (inRange(p.Dst.Port()) || inRange(p.Src.Port())):
+			h, ok := parseRTP(p.Payload)
+			if !ok {
+				if isRTCP(p.Payload) {
+					rtcp = append(rtcp, p)
+				}
+				continue
+			}
+			add(fmt.Sprintf("rtp %s %s %08x", p.Src, p.Dst, h.ssrc), p)
+		case !p.UDP && o.AudioSocketPort > 0 && (p.Dst.Port() == o.AudioSocketPort || p.Src.Port() == o.AudioSocketPort):
+			add(fmt.Sprintf("tcp %s %s", p.Src, p.Dst), p)
+		case p.UDP && isSIP(p.Payload):
+			rep.SIP = append(rep.SIP, SIPPacket{Time: p.Time, Src: p.Src.String(), Dst: p.Dst.String(), Text: string(p.Payload)})
+		}
+	}
+	for _, f := range flows {
+		var s Stream
+		if strings.HasPrefix(f.key, "rtp ") {
+			s = analyzeRTP(f.packets)
+			s.Direction, s.enginePort = FromEngine, f.packets[0].Src.Port()
+			if inRange(f.packets[0].Dst.Port()) {
+				s.Direction, s.enginePort = ToEngine, f.packets[0].Dst.Port()
+			}
+		} else {
+			var ok bool
+			if s, ok = analyzeAudioSocket(f.packets); !ok {
+				continue
+			}
+			s.Direction, s.enginePort = FromEngine, o.AudioSocketPort
+			if f.packets[0].Dst.Port() == o.AudioSocketPort {
+				s.Direction = ToEngine
+			}
+		}
+		s.Src, s.Dst = f.packets[0].Src.String(), f.packets[0].Dst.String()
+		rep.Streams = append(rep.Streams, s)
+	}
+	applyRTCP(rep.Streams, rtcp)
+	sort.SliceStable(rep.Streams, func(i, j int) bool { return rep.Streams[i].Start.Before(rep.Streams[j].Start) })
+	return rep
+}
+
+type rtpHeader struct {
+	pt      int
+	seq     uint16
+	ts      uint32
+	ssrc    uint32
+	payload []byte
+}
+
+func parseRTP(b []byte) (rtpHeader, bool) {
+	if len(b) < 12 || b[0]>>6 != 2 {
+		return rtpHeader{}, false
+	}
+	h := rtpHeader{pt: int(b[1] & 0x7f), seq: binary.BigEndian.Uint16(b[2:4]), ts: binary.BigEndian.Uint32(b[4:8]), ssrc: binary.BigEndian.Uint32(b[8:12])}
+	if h.pt >= 72 && h.pt <= 76 {
+		return rtpHeader{}, false // RTCP on an odd port of the range
+	}
+	off := 12 + 4*int(b[0]&0x0f)
+	if b[0]&0x10 != 0 && len(b) >= off+4 {
+		off += 4 + 4*int(binary.BigEndian.Uint16(b[off+2:off+4]))
+	}
+	end := len(b)
+	if b[0]&0x20 != 0 && end > off {
+		end -= int(b[end-1])
+	}
+	if off > end {
+		return rtpHeader{}, false
+	}
+	h.payload = b[off:end]
+	return h, true
+}
+
+// staticCodecs are the static RTP payload types Asterisk sends.
+var staticCodecs = map[int]struct {
+	name string
+	rate int
+}{
+	0:  {"ulaw", 8000},
+	3:  {"gsm", 8000},
+	8:  {"alaw", 8000},
+	9:  {"g722", 8000}, // 16 kHz audio on an 8 kHz RTP clock
+	18: {"g729", 8000},
+}
+
+func analyzeRTP(packets []Packet) Stream {
+	s := Stream{Transport: "rtp", Packets: len(packets), Start: packets[0].Time, End: packets[len(packets)-1].Time}
+	headers := make([]rtpHeader, len(packets))
+	ptCount := map[int]int{}
+	for i, p := range packets {
+		headers[i], _ = parseRTP(p.Payload)
+		ptCount[headers[i].pt]++
+	}
+	s.SSRC = headers[0].ssrc
+	pt := headers[0].pt
+	for k, n := range ptCount {
+		if n > ptCount[pt] || (n == ptCount[pt] && k < pt) {
+			pt = k
+		}
+	}
+	s.PayloadType = &pt
+
+	// Sequence numbers: loss, reordering and duplicates.
+	seen := map[int64]bool{}
+	ext := make([]int64, len(packets))
+	dup := make([]bool, len(packets))
+	var lo, hi int64
+	for i, h := range headers {
+		if i == 0 {
+			ext[i], lo, hi = int64(h.seq), int64(h.seq), int64(h.seq)
+		} else {
+			ext[i] = hi + int64(int16(h.seq-uint16(hi)))
+		}
+		switch {
+		case seen[ext[i]]:
+			s.Duplicates++
+			dup[i] = true
+			continue
+		case ext[i] < hi:
+			s.OutOfOrder++
+		default:
+			hi = ext[i]
+		}
+		if ext[i] < lo {
+			lo = ext[i]
+		}
+		seen[ext[i]] = true
+	}
+	expected := int(hi - lo + 1)
+	if s.Lost = expected - len(seen); s.Lost < 0 {
+		s.Lost = 0
+	}
+	if expected > 0 {
+		s.LossPct = round1(float64(s.Lost) * 100 / float64(expected))
+	}
+
+	// Packet time and clock rate from the audio packets in sequence.
+	steps, intervals := map[uint32]int{}, []float64{}
+	payloadLen := 0
+	for i := 1; i < len(headers); i++ {
+		a, b := headers[i-1], headers[i]
+		if dup[i] || dup[i-1] || a.pt != pt || b.pt != pt {
+			continue
+		}
+		if ext[i] == ext[i-1]+1 {
+			steps[b.ts-a.ts]++
+			intervals = append(intervals, packets[i].Time.Sub(packets[i-1].Time).Seconds())
+		}
+		payloadLen = len(b.payload)
+	}
+	var step uint32
+	for k, n := range steps {
+		if n > steps[step] || (n == steps[step] && k < step) {
+			step = k
+		}
+	}
+	if c, ok := staticCodecs[pt]; ok {
+		s.Codec, s.SampleRate = c.name, c.rate
+	} else if step > 0 && len(intervals) > 0 {
+		// Dynamic payload type: Asterisk's slin family, 16-bit samples on a
+		// clock that is the sample rate.
+		sort.Float64s(intervals)
+		s.SampleRate = nearestRate(float64(step) / intervals[len(intervals)/2])
+		s.Codec = fmt.Sprintf("PT %d", pt)
+		if payloadLen == int(step)*2 {
+			s.Codec = slinName(s.SampleRate)
+		}
+	} else {
+		s.Codec = fmt.Sprintf("PT %d", pt)
+	}
+	if step == 0 || s.SampleRate == 0 {
+		return s
+	}
+	s.PtimeMS = round1(float64(step) * 1000 / float64(s.SampleRate))
+
+	// Interarrival jitter (RFC 3550 6.4.1) and gaps, in arrival order.
+	rate := float64(s.SampleRate)
+	var j float64
+	prev := -1
+	for i, h := range headers {
+		if dup[i] || h.pt != pt {
+			continue
+		}
+		if prev >= 0 {
+			arrival := packets[i].Time.Sub(packets[prev].Time)
+			d := arrival.Seconds()*rate - float64(int32(h.ts-headers[prev].ts))
+			j += (math.Abs(d) - j) / 16
+			if jm := j * 1000 / rate; jm > s.MaxJitterMS {
+				s.MaxJitterMS = jm
+			}
+			if arrival >= GapThreshold {
+				advanced := float64(int32(h.ts-headers[prev].ts)) / rate
+				s.Gaps = append(s.Gaps, Gap{At: packets[prev].Time, MS: ms(arrival), Paused: advanced >= arrival.Seconds()/2})
+			}
+		}
+		prev = i
+	}
+	s.JitterMS, s.MaxJitterMS = round1(j*1000/rate), round1(s.MaxJitterMS)
+	return s
+}
+
+// kindSlin is the first AudioSocket audio frame kind; 0x10-0x18 carry signed
+// linear audio at the sample rates in slinRates.
+const kindSlin = 0x10
+
+var slinRates = []int{8000, 12000, 16000, 24000, 32000, 44100, 48000, 96000, 192000}
+
+// analyzeAudioSocket reassembles one direction of an AudioSocket connection
+// and measures its audio frames. TCP retransmissions count as duplicates and
+// segments that arrive ahead of a missing one as out of order.
+func analyzeAudioSocket(packets []Packet) (Stream, bool) {
+	s := Stream{Transport: "audiosocket"}
+	var next uint32
+	started := false
+	pending := map[uint32]Packet{}
+	var buf []byte
+	type frame struct {
+		at    time.Time
+		bytes int
+	}
+	var frames []frame
+	kind := -1
+	consume := func(p Packet) {
+		buf = append(buf, p.Payload...)
+		next += uint32(len(p.Payload))
+		for len(buf) >= 3 {
+			n := int(binary.BigEndian.Uint16(buf[1:3]))
+			if len(buf) < 3+n {
+				break
+			}
+			if k := int(buf[0]); k >= kindSlin && k < kindSlin+len(slinRates) {
+				kind = k
+				frames = append(frames, frame{at: p.Time, bytes: n})
+			}
+			buf = buf[3+n:]
+		}
+	}
+	for _, p := range packets {
+		if p.SYN {
+			next, started = p.Seq+1, true
+			continue
+		}
+		if len(p.Payload) == 0 {
+			continue
+		}
+		if !started {
+			next, started = p.Seq, true
+		}
+		s.Packets++
+		d := int32(p.Seq - next)
+		if d < 0 {
+			if int32(p.Seq+uint32(len(p.Payload))-next) <= 0 {
+				s.Duplicates++
+				continue
+			}
+			p.Payload = p.Payload[-d:]
+		} else if d > 0 {
+			pending[p.Seq] = p
+			s.OutOfOrder++
+			continue
+		}
+		consume(p)
+		for q, ok := pending[next]; ok; q, ok = pending[next] {
+			delete(pending, next)
+			consume(q)
+		}
+	}
+	if len(frames) == 0 {
+		return s, false
+	}
+	s.Start, s.End = frames[0].at, frames[len(frames)-1].at
+	s.SampleRate = slinRates[kind-kindSlin]
+
+	sizes := map[int]int{}
+	var intervals []float64
+	for i, f := range frames {
+		sizes[f.bytes]++
+		if i > 0 {
+			if d := f.at.Sub(frames[i-1].at).Seconds(); d > 0 {
+				intervals = append(intervals, d)
+			}
+		}
+	}
+	size := frames[0].bytes
+	for k, n := range sizes {
+		if n > sizes[size] || (n == sizes[size] && k < size) {
+			size = k
+		}
+	}
+	// The frame kind says signed linear, but the engine may have asked for
+	// G.711; the byte rate tells them apart.
+	bytesPerMS := float64(s.SampleRate*2) / 1000
+	s.Codec = slinName(s.SampleRate)
+	if len(intervals) > 0 {
+		sort.Float64s(intervals)
+		if perMS := float64(size) / (intervals[len(intervals)/2] * 1000); kind == kindSlin && perMS > 6 && perMS < 11 {
+			s.Codec, bytesPerMS = "g711", 8
+		}
+	}
+	s.PtimeMS = round1(float64(size) / bytesPerMS)
+
+	var j float64
+	for i := 1; i < len(frames); i++ {
+		arrival := frames[i].at.Sub(frames[i-1].at)
+		d := arrival.Seconds()*1000 - float64(frames[i-1].bytes)/bytesPerMS
+		j += (math.Abs(d) - j) / 16
+		if j > s.MaxJitterMS {
+			s.MaxJitterMS = j
+		}
+		if arrival >= GapThreshold {
+			s.Gaps = append(s.Gaps, Gap{At: frames[i-1].at, MS: ms(arrival)})
+		}
+	}
+	s.JitterMS, s.MaxJitterMS = round1(j), round1(s.MaxJitterMS)
+	return s, true
+}
+
+func nearestRate(r float64) int {
+	best := slinRates[0]
+	for _, c := range slinRates {
+		if math.Abs(float64(c)-r) < math.Abs(float64(best)-r) {
+			best = c
+		}
+	}
+	return best
+}
+
+func slinName(rate int) string {
+	if rate == 8000 {
+		return "slin"
+	}
+	return fmt.Sprintf("slin%d", rate/1000)
+}
+
+func ms(d time.Duration) float64 {
+	return round1(float64(d) / float64(time.Millisecond))
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
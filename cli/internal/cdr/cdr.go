@@ -0,0 +1,328 @@
+// Package cdr reads Asterisk call detail records (CDR) and channel event logs (CEL)
+// so call listings and RCA reports can use billing-grade duration, disposition and
+// hangup cause instead of values scraped from ai_engine logs.
+//
+// Sources are cdr_csv / cel_custom CSV files (read locally, or from the Asterisk
+// container when the file is not on this host) and a MySQL CDR database through the
+// mysql client. Callers treat ErrUnavailable as "fall back to log scraping".
+package cdr
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// ErrUnavailable means no CDR source is configured or reachable.
+var ErrUnavailable = errors.New("no CDR source available")
+
+// Record is one CDR row, with hangup details from CEL when available.
+type Record struct {
+	UniqueID    string    `json:"uniqueid"`
+	LinkedID    string    `json:"linkedid,omitempty"`
+	Src         string    `json:"src,omitempty"`
+	Dst         string    `json:"dst,omitempty"`
+	DContext    string    `json:"dcontext,omitempty"`
+	CallerID    string    `json:"clid,omitempty"`
+	Channel     string    `json:"channel,omitempty"`
+	DstChannel  string    `json:"dstchannel,omitempty"`
+	LastApp     string    `json:"lastapp,omitempty"`
+	Start       time.Time `json:"start"`
+	Answer      time.Time `json:"answer,omitempty"`
+	End         time.Time `json:"end,omitempty"`
+	Duration    int       `json:"duration"` // seconds, start to end
+	BillSec     int       `json:"billsec"`  // seconds, answer to end
+	Disposition string    `json:"disposition,omitempty"`
+
+	HangupCause     int    `json:"hangup_cause,omitempty"`
+	HangupCauseText string `json:"hangup_cause_text,omitempty"`
+	HangupSource    string `json:"hangup_source,omitempty"`
+	Source          string `json:"source"`
+}
+
+// Answered reports whether the CDR disposition is ANSWERED.
+func (r Record) Answered() bool {
+	return strings.EqualFold(r.Disposition, "ANSWERED")
+}
+
+// Stasis reports whether the call reached a Stasis application (the AI agent).
+func (r Record) Stasis() bool {
+	return strings.EqualFold(r.LastApp, "Stasis")
+}
+
+// Recent returns records that started at or after since, newest first.
+func Recent(since time.Time) ([]Record, error) {
+	cfg := deployment.Current().CDR
+	var recs []Record
+	var err error
+	switch strings.ToLower(cfg.Source) {
+	case "none", "off":
+		return nil, ErrUnavailable
+	case "mysql":
+		recs, err = mysqlRecent(cfg.MySQL, since)
+	case "csv", "auto", "":
+		recs, err = csvRecent(cfg, since)
+	default:
+		return nil, fmt.Errorf("unknown cdr source %q (auto, csv, mysql, none)", cfg.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sortNewestFirst(recs)
+	return recs, nil
+}
+
+// Lookup returns the CDR for a channel uniqueid, searching records since the given time.
+func Lookup(uniqueID string, since time.Time) (*Record, error) {
+	recs, err := Recent(since)
+	if err != nil {
+		return nil, err
+	}
+	for i := range recs {
+		if recs[i].UniqueID == uniqueID {
+			return &recs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func sortNewestFirst(recs []Record) {
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Start.After(recs[j].Start) })
+}
+
+// cdrTimeLayout is the format cdr_csv and the cdr table use for start/answer/end.
+const cdrTimeLayout = "2006-01-02 15:04:05"
+
+func parseCDRTime(s string) time.Time {
+	t, err := time.ParseInLocation(cdrTimeLayout, strings.TrimSpace(s), time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// openCSV opens a CSV file on this host, or cats it from the Asterisk container when
+// the file is not here (containerized Asterisk, or a remote deployment).
+func openCSV(path string) (io.ReadCloser, error) {
+	if !deployment.Current().Remote() {
+		if f, err := os.Open(path); err == nil {
+			return f, nil
+		}
+	}
+	out, err := dockerapi.ExecOutput(context.Background(), deployment.AsteriskContainer(), "cat", path)
+	if err != nil {
+		return nil, ErrUnavailable
+	}
+	return io.NopCloser(strings.NewReader(string(out))), nil
+}
+
+// Master.csv columns written by cdr_csv. uniqueid (and userfield) are appended when
+// loguniqueid (loguserfield) is enabled; rows without a uniqueid cannot be matched.
+const (
+	csvSrc = 1 + iota
+	csvDst
+	csvDContext
+	csvCLID
+	csvChannel
+	csvDstChannel
+	csvLastApp
+	csvLastData
+	csvStart
+	csvAnswer
+	csvEnd
+	csvDuration
+	csvBillSec
+	csvDisposition
+	csvAMAFlags
+	csvUniqueID
+	csvUserField
+)
+
+func csvRecent(cfg deployment.CDR, since time.Time) ([]Record, error) {
+	rc, err := openCSV(cfg.CSV)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	recs, err := parseCDRCSV(rc, since)
+	if err != nil {
+		return nil, err
+	}
+	if cel, err := openCSV(cfg.CELCSV); err == nil {
+		defer cel.Close()
+		applyHangups(recs, parseCELHangups(cel, since))
+	}
+	return recs, nil
+}
+
+func parseCDRCSV(r io.Reader, since time.Time) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	var recs []Record
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cdr csv: %w", err)
+		}
+		if len(row) <= csvUniqueID || strings.TrimSpace(row[csvUniqueID]) == "" {
+			continue
+		}
+		start := parseCDRTime(row[csvStart])
+		if start.IsZero() || start.Before(since) {
+			continue
+		}
+		dur, _ := strconv.Atoi(strings.TrimSpace(row[csvDuration]))
+		bill, _ := strconv.Atoi(strings.TrimSpace(row[csvBillSec]))
+		recs = append(recs, Record{
+			UniqueID:    strings.TrimSpace(row[csvUniqueID]),
+			Src:         row[csvSrc],
+			Dst:         row[csvDst],
+			DContext:    row[csvDContext],
+			CallerID:    row[csvCLID],
+			Channel:     row[csvChannel],
+			DstChannel:  row[csvDstChannel],
+			LastApp:     row[csvLastApp],
+			Start:       start,
+			Answer:      parseCDRTime(row[csvAnswer]),
+			End:         parseCDRTime(row[csvEnd]),
+			Duration:    dur,
+			BillSec:     bill,
+			Disposition: row[csvDisposition],
+			Source:      "cdr-csv",
+		})
+	}
+	return recs, nil
+}
+
+// cel_custom sample mapping (cel_custom.conf): eventtype, eventtime, cid name, cid num,
+// ANI, RDNIS, DNID, exten, context, channame, appname, appdata, amaflags, accountcode,
+// uniqueid, linkedid, bridgepeer, userfield, userdeftype, eventextra.
+const (
+	celEventType = 0
+	celEventTime = 1
+	celUniqueID  = 14
+	celLinkedID  = 15
+	celExtra     = 19
+)
+
+type hangup struct {
+	cause    int
+	source   string
+	linkedID string
+}
+
+func parseCELHangups(r io.Reader, since time.Time) map[string]hangup {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	out := map[string]hangup{}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				continue
+			}
+			break
+		}
+		if len(row) <= celExtra || row[celEventType] != "HANGUP" {
+			continue
+		}
+		if t := parseCELTime(row[celEventTime]); !t.IsZero() && t.Before(since) {
+			continue
+		}
+		h := parseHangupExtra(row[celExtra])
+		h.linkedID = row[celLinkedID]
+		out[row[celUniqueID]] = h
+	}
+	return out
+}
+
+// parseCELTime accepts the default "2006-01-02 15:04:05.000000" and epoch "1769818882.123456".
+func parseCELTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05.999999", s, time.Local); err == nil {
+		return t
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(int64(f), 0)
+	}
+	return time.Time{}
+}
+
+// parseHangupExtra reads the CEL HANGUP extra: {"hangupcause":16,"hangupsource":"PJSIP/..","dialstatus":""}.
+func parseHangupExtra(extra string) hangup {
+	var v struct {
+		Cause  json.Number `json:"hangupcause"`
+		Source string      `json:"hangupsource"`
+	}
+	_ = json.Unmarshal([]byte(extra), &v)
+	cause, _ := v.Cause.Int64()
+	return hangup{cause: int(cause), source: v.Source}
+}
+
+func applyHangups(recs []Record, hangups map[string]hangup) {
+	for i := range recs {
+		h, ok := hangups[recs[i].UniqueID]
+		if !ok {
+			continue
+		}
+		recs[i].HangupCause = h.cause
+		recs[i].HangupCauseText = CauseText(h.cause)
+		recs[i].HangupSource = h.source
+		if recs[i].LinkedID == "" {
+			recs[i].LinkedID = h.linkedID
+		}
+	}
+}
+
+// causeText covers the Q.850 causes seen on SIP trunks.
+var causeText = map[int]string{
+	1:   "Unallocated number",
+	3:   "No route to destination",
+	16:  "Normal clearing",
+	17:  "User busy",
+	18:  "No user responding",
+	19:  "No answer",
+	21:  "Call rejected",
+	22:  "Number changed",
+	27:  "Destination out of order",
+	28:  "Invalid number format",
+	31:  "Normal, unspecified",
+	34:  "No circuit available",
+	38:  "Network out of order",
+	41:  "Temporary failure",
+	42:  "Switching equipment congestion",
+	58:  "Bearer capability not available",
+	102: "Recovery on timer expiry",
+	127: "Interworking, unspecified",
+}
+
+// CauseText names a Q.850 hangup cause.
+func CauseText(cause int) string {
+	if cause == 0 {
+		return ""
+	}
+	if s, ok := causeText[cause]; ok {
+		return s
+	}
+	return fmt.Sprintf("cause %d", cause)
+}
@@ -0,0 +1,73 @@
+package cdr
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCDRCSVWithCELHangups(t *testing.T) {
+	t.Parallel()
+
+	master := strings.Join([]string{
+		`"","+15551234567","8005550100","from-trunk","""Jane"" <+15551234567>","PJSIP/trunk-0000001a","","Stasis","asterisk-ai-voice-agent","2026-01-30 17:21:40","2026-01-30 17:21:40","2026-01-30 17:24:45",185,185,"ANSWERED","DOCUMENTATION","1769818882.1484",""`,
+		`"","6001","6000","from-internal","6001","PJSIP/6001-00000001","","Dial","PJSIP/6000","2026-01-29 09:00:00","","2026-01-29 09:00:20",20,0,"NO ANSWER","DOCUMENTATION","1769700000.1000",""`,
+		`"","6001","6000","from-internal","6001","PJSIP/6001-00000002","","Dial","PJSIP/6000","2026-01-30 10:00:00","","2026-01-30 10:00:20",20,0,"NO ANSWER","DOCUMENTATION"`,
+	}, "\n")
+	since := time.Date(2026, 1, 30, 0, 0, 0, 0, time.Local)
+	recs, err := parseCDRCSV(strings.NewReader(master), since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("expected only the in-window row with a uniqueid, got %+v", recs)
+	}
+	rec := recs[0]
+	if rec.UniqueID != "1769818882.1484" || rec.Duration != 185 || !rec.Answered() || !rec.Stasis() || rec.CallerID != `"Jane" <+15551234567>` {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+
+	cel := `"HANGUP","2026-01-30 17:24:45.000000","Jane","+15551234567","","","8005550100","8005550100","from-trunk","PJSIP/trunk-0000001a","","","DOCUMENTATION","","1769818882.1484","1769818882.1484","","","","{""hangupcause"":16,""hangupsource"":""PJSIP/trunk-0000001a"",""dialstatus"":""""}"`
+	applyHangups(recs, parseCELHangups(strings.NewReader(cel), since))
+	if recs[0].HangupCause != 16 || recs[0].HangupCauseText != "Normal clearing" || recs[0].HangupSource != "PJSIP/trunk-0000001a" {
+		t.Fatalf("hangup not applied: %+v", recs[0])
+	}
+	if CauseText(99) != "cause 99" || CauseText(0) != "" {
+		t.Fatalf("CauseText fallback")
+	}
+}
+
+func TestParseQueueLog(t *testing.T) {
+	t.Parallel()
+
+	log := strings.Join([]string{
+		`1769818800|NONE|NONE|NONE|QUEUESTART|`,
+		`1769818842|1769818842.100|sales|NONE|ENTERQUEUE||+15551234567|3`,
+		`1769818852|1769818842.100|sales|PJSIP/2001|RINGNOANSWER|10000`,
+		`1769818882|1769818842.100|sales|Local/ai@from-ai|CONNECT|40|1769818882.104|2`,
+		`1769818890|1769818890.200|support|NONE|ENTERQUEUE||+15557654321|1`,
+		`1769818945|1769818890.200|support|NONE|ABANDON|1|1|55`,
+		`1769818950|1769818950.300|support|NONE|EXITWITHTIMEOUT|2|2|30`,
+		`1769818960|1769818960.400|support|NONE|ENTERQUEUE||+15550000000|1`,
+		`1769818970|1769818999.999|support|NONE|ENTERQUEUE||+15550000001|2`,
+	}, "\n")
+	ids := map[string]bool{"1769818842.100": true, "1769818890.200": true, "1769818950.300": true, "1769818960.400": true}
+	visits := parseQueueLog(strings.NewReader(log), ids, time.Unix(1769818800, 0))
+	if len(visits) != 4 {
+		t.Fatalf("visits = %+v", visits)
+	}
+	if v := visits[0]; v.Queue != "sales" || v.Outcome != QueueAnswered || v.WaitSeconds != 40 || v.Position != 3 ||
+		v.Member != "Local/ai@from-ai" || v.MemberUniqueID != "1769818882.104" {
+		t.Errorf("answered = %+v", v)
+	}
+	if v := visits[1]; v.Outcome != QueueAbandoned || v.WaitSeconds != 55 || v.Left.Sub(v.Entered) != 55*time.Second {
+		t.Errorf("abandoned = %+v", v)
+	}
+	// The ENTERQUEUE is older than the log: dated back from the wait.
+	if v := visits[2]; v.Outcome != QueueTimeout || v.Entered != time.Unix(1769818920, 0) {
+		t.Errorf("timeout = %+v", v)
+	}
+	if v := visits[3]; v.Outcome != QueueWaiting || !v.Left.IsZero() {
+		t.Errorf("waiting = %+v", v)
+	}
+}
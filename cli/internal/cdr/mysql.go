@@ -0,0 +1,129 @@
+package cdr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+)
+
+var sqlIdentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// mysqlColumns is the subset of the standard cdr table (cdr_mysql, cdr_adaptive_odbc,
+// FreePBX asteriskcdrdb.cdr) the CLI reads. calldate is the call start.
+var mysqlColumns = []string{"uniqueid", "linkedid", "calldate", "clid", "src", "dst", "dcontext", "channel", "dstchannel", "lastapp", "duration", "billsec", "disposition"}
+
+// mysqlQuery runs one statement through the mysql client and returns tab-separated rows.
+// The password comes from AAVA_CDR_DB_PASSWORD via MYSQL_PWD so it never appears in ps.
+func mysqlQuery(cfg deployment.CDRMySQL, query string) ([][]string, error) {
+	if cfg.Database == "" {
+		return nil, fmt.Errorf("%w: cdr.mysql.database is not set", ErrUnavailable)
+	}
+	if _, err := exec.LookPath("mysql"); err != nil {
+		return nil, fmt.Errorf("%w: mysql client not installed", ErrUnavailable)
+	}
+	args := []string{"--batch", "--skip-column-names", "--raw"}
+	if cfg.Host != "" {
+		args = append(args, "-h", cfg.Host)
+	}
+	if cfg.Port != "" {
+		args = append(args, "-P", cfg.Port)
+	}
+	if cfg.User != "" {
+		args = append(args, "-u", cfg.User)
+	}
+	args = append(args, cfg.Database, "-e", query)
+	cmd := exec.Command("mysql", args...)
+	cmd.Env = os.Environ()
+	if pw := os.Getenv("AAVA_CDR_DB_PASSWORD"); pw != "" {
+		cmd.Env = append(cmd.Env, "MYSQL_PWD="+pw)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("mysql cdr query failed: %w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+	var rows [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		rows = append(rows, strings.Split(scanner.Text(), "\t"))
+	}
+	return rows, scanner.Err()
+}
+
+func mysqlRecent(cfg deployment.CDRMySQL, since time.Time) ([]Record, error) {
+	if !sqlIdentPattern.MatchString(cfg.Table) || !sqlIdentPattern.MatchString(cfg.CELTable) {
+		return nil, fmt.Errorf("invalid cdr table name %q / %q", cfg.Table, cfg.CELTable)
+	}
+	sinceSQL := since.Local().Format(cdrTimeLayout)
+	rows, err := mysqlQuery(cfg, fmt.Sprintf(
+		"SELECT %s FROM %s WHERE calldate >= '%s' AND uniqueid <> '' ORDER BY calldate DESC LIMIT 5000",
+		strings.Join(mysqlColumns, ", "), cfg.Table, sinceSQL))
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < len(mysqlColumns) {
+			continue
+		}
+		col := func(i int) string {
+			if row[i] == "NULL" {
+				return ""
+			}
+			return row[i]
+		}
+		dur, _ := strconv.Atoi(col(10))
+		bill, _ := strconv.Atoi(col(11))
+		start := parseCDRTime(col(2))
+		rec := Record{
+			UniqueID:    col(0),
+			LinkedID:    col(1),
+			Start:       start,
+			CallerID:    col(3),
+			Src:         col(4),
+			Dst:         col(5),
+			DContext:    col(6),
+			Channel:     col(7),
+			DstChannel:  col(8),
+			LastApp:     col(9),
+			Duration:    dur,
+			BillSec:     bill,
+			Disposition: col(12),
+			Source:      "cdr-mysql",
+		}
+		if !start.IsZero() {
+			rec.End = start.Add(time.Duration(dur) * time.Second)
+			if bill > 0 {
+				rec.Answer = rec.End.Add(-time.Duration(bill) * time.Second)
+			}
+		}
+		recs = append(recs, rec)
+	}
+
+	// CEL is optional; many installs only log CDR.
+	if celRows, err := mysqlQuery(cfg, fmt.Sprintf(
+		"SELECT uniqueid, linkedid, extra FROM %s WHERE eventtype = 'HANGUP' AND eventtime >= '%s'",
+		cfg.CELTable, sinceSQL)); err == nil {
+		hangups := map[string]hangup{}
+		for _, row := range celRows {
+			if len(row) < 3 {
+				continue
+			}
+			h := parseHangupExtra(row[2])
+			h.linkedID = row[1]
+			hangups[row[0]] = h
+		}
+		applyHangups(recs, hangups)
+	}
+	return recs, nil
+}
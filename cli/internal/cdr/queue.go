@@ -0,0 +1,132 @@
+package cdr
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+)
+
+// Queue visit outcomes.
+const (
+	QueueAnswered  = "answered"  // a member took the call
+	QueueAbandoned = "abandoned" // the caller hung up while waiting
+	QueueTimeout   = "timeout"   // the queue's timeout sent the caller on
+	QueueEmpty     = "empty"     // the queue had no members left to ring
+	QueueKey       = "key"       // the caller pressed a key to leave
+	QueueWaiting   = "waiting"   // no outcome logged yet
+)
+
+// QueueVisit is one caller's stay in one app_queue queue, from queue_log.
+type QueueVisit struct {
+	Queue   string    `json:"queue"`
+	CallID  string    `json:"callid"` // the caller channel's uniqueid
+	Entered time.Time `json:"entered"`
+	Left    time.Time `json:"left,omitempty"`
+	// WaitSeconds is the hold time queue_log records for the outcome.
+	WaitSeconds int    `json:"wait_seconds"`
+	Position    int    `json:"position,omitempty"` // on entering the queue
+	Outcome     string `json:"outcome"`
+	// Member and MemberUniqueID are the member that answered and its channel.
+	Member         string `json:"member,omitempty"`
+	MemberUniqueID string `json:"member_uniqueid,omitempty"`
+}
+
+// QueueVisits returns the queue visits since the given time of the callers
+// with the given uniqueids, oldest first.
+func QueueVisits(callIDs []string, since time.Time) ([]QueueVisit, error) {
+	cfg := deployment.Current().CDR
+	switch strings.ToLower(cfg.Source) {
+	case "none", "off":
+		return nil, ErrUnavailable
+	}
+	rc, err := openCSV(cfg.QueueLog)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	ids := map[string]bool{}
+	for _, id := range callIDs {
+		if id != "" {
+			ids[id] = true
+		}
+	}
+	return parseQueueLog(rc, ids, since), nil
+}
+
+// parseQueueLog reads queue_log rows, "epoch|callid|queue|agent|event|data...",
+// into visits: ENTERQUEUE opens one and CONNECT, ABANDON or an EXIT event
+// closes it. A visit whose ENTERQUEUE is older than the log is dated back
+// from its wait time.
+func parseQueueLog(r io.Reader, ids map[string]bool, since time.Time) []QueueVisit {
+	var visits []QueueVisit
+	open := map[string]int{} // callid|queue → index in visits
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		f := strings.Split(strings.TrimSpace(sc.Text()), "|")
+		if len(f) < 5 || !ids[f[1]] {
+			continue
+		}
+		epoch, err := strconv.ParseFloat(f[0], 64)
+		if err != nil {
+			continue
+		}
+		at := time.Unix(int64(epoch), 0)
+		if at.Before(since) {
+			continue
+		}
+		callID, queue, member, event, data := f[1], f[2], f[3], f[4], f[5:]
+		arg := func(i int) string {
+			if i < len(data) {
+				return strings.TrimSpace(data[i])
+			}
+			return ""
+		}
+		key := callID + "|" + queue
+		if event == "ENTERQUEUE" {
+			pos, _ := strconv.Atoi(arg(2))
+			open[key] = len(visits)
+			visits = append(visits, QueueVisit{Queue: queue, CallID: callID, Entered: at, Position: pos, Outcome: QueueWaiting})
+			continue
+		}
+		var outcome, wait string
+		switch event {
+		case "CONNECT":
+			outcome, wait = QueueAnswered, arg(0)
+		case "ABANDON":
+			outcome, wait = QueueAbandoned, arg(2)
+		case "EXITWITHTIMEOUT":
+			outcome, wait = QueueTimeout, arg(2)
+		case "EXITEMPTY":
+			outcome, wait = QueueEmpty, arg(2)
+		case "EXITWITHKEY":
+			outcome, wait = QueueKey, arg(3)
+		default:
+			continue
+		}
+		i, ok := open[key]
+		if !ok {
+			i = len(visits)
+			visits = append(visits, QueueVisit{Queue: queue, CallID: callID})
+		}
+		delete(open, key)
+		v := &visits[i]
+		v.Left, v.Outcome = at, outcome
+		if n, err := strconv.Atoi(wait); err == nil {
+			v.WaitSeconds = n
+		} else if !v.Entered.IsZero() {
+			v.WaitSeconds = int(at.Sub(v.Entered).Seconds())
+		}
+		if v.Entered.IsZero() {
+			v.Entered = at.Add(-time.Duration(v.WaitSeconds) * time.Second)
+		}
+		if outcome == QueueAnswered {
+			v.Member, v.MemberUniqueID = member, arg(1)
+		}
+	}
+	return visits
+}
@@ -0,0 +1,163 @@
+// Package changelog extracts the release notes an update brings in: the
+// CHANGELOG.md entries added between two checkouts and the matching sections of
+// docs/MIGRATION.md.
+package changelog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Entry is one "## [version] - date" block of CHANGELOG.md.
+type Entry struct {
+	Version string `json:"version"` // "7.5.0" or "Unreleased"
+	Date    string `json:"date,omitempty"`
+	Body    string `json:"body"`
+}
+
+// Note is a migration item an operator must act on: a "## vA to vB" section of
+// docs/MIGRATION.md, or a breaking/migration/removed subsection of a changelog entry.
+type Note struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+var (
+	entryHeadingRe     = regexp.MustCompile(`^##\s+\[?([^\]\s]+)\]?(?:\s*-\s*(\S+))?`)
+	migrationHeadingRe = regexp.MustCompile(`^##\s+(.+?)\s+to\s+v?([0-9]+\.[0-9]+(?:\.[0-9x]+)?)`)
+	migrationTitleRe   = regexp.MustCompile(`(?i)breaking|migration|deprecat|removed|renamed`)
+)
+
+// Parse splits a Keep a Changelog file into entries, newest first as written.
+func Parse(data []byte) []Entry {
+	var out []Entry
+	var cur *Entry
+	var body []string
+	flush := func() {
+		if cur != nil {
+			cur.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			out = append(out, *cur)
+		}
+		body = nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			cur = nil
+			if m := entryHeadingRe.FindStringSubmatch(line); m != nil {
+				cur = &Entry{Version: m[1], Date: m[2]}
+			}
+			continue
+		}
+		if cur != nil {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return out
+}
+
+// Between returns the entries of newData that oldData does not have. The
+// Unreleased entry counts as new when its text changed.
+func Between(oldData, newData []byte) []Entry {
+	old := map[string]string{}
+	for _, e := range Parse(oldData) {
+		old[strings.ToLower(e.Version)] = e.Body
+	}
+	var out []Entry
+	for _, e := range Parse(newData) {
+		prev, seen := old[strings.ToLower(e.Version)]
+		if !seen || (strings.EqualFold(e.Version, "Unreleased") && prev != e.Body) {
+			if e.Body != "" {
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
+
+// MigrationNotes collects what operators must act on for entries: the
+// docs/MIGRATION.md sections whose target version is among them, then any
+// breaking/migration subsections inside the entries themselves.
+func MigrationNotes(migrationDoc []byte, entries []Entry) []Note {
+	versions := map[string]bool{}
+	for _, e := range entries {
+		versions[strings.TrimPrefix(strings.ToLower(e.Version), "v")] = true
+	}
+
+	var out []Note
+	var cur *Note
+	var body []string
+	flush := func() {
+		if cur != nil {
+			cur.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			out = append(out, *cur)
+		}
+		cur, body = nil, nil
+	}
+	for _, line := range strings.Split(string(migrationDoc), "\n") {
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			if m := migrationHeadingRe.FindStringSubmatch(line); m != nil && matchesVersion(versions, m[2]) {
+				cur = &Note{Title: strings.TrimSpace(strings.TrimPrefix(line, "## "))}
+			}
+			continue
+		}
+		if cur != nil {
+			body = append(body, line)
+		}
+	}
+	flush()
+
+	for _, e := range entries {
+		for _, sec := range subsections(e.Body) {
+			if migrationTitleRe.MatchString(sec.Title) {
+				out = append(out, Note{Title: e.Version + ": " + sec.Title, Body: sec.Body})
+			}
+		}
+	}
+	return out
+}
+
+// matchesVersion accepts "7.5.0" against "7.5.0", and "6.5.x" or "6.5" against
+// any patch of that minor.
+func matchesVersion(versions map[string]bool, target string) bool {
+	if versions[target] {
+		return true
+	}
+	minor := strings.TrimSuffix(target, ".x")
+	if strings.Count(minor, ".") != 1 {
+		return false
+	}
+	for v := range versions {
+		if strings.HasPrefix(v, minor+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func subsections(body string) []Note {
+	var out []Note
+	var cur *Note
+	var lines []string
+	flush := func() {
+		if cur != nil {
+			cur.Body = strings.TrimSpace(strings.Join(lines, "\n"))
+			out = append(out, *cur)
+		}
+		cur, lines = nil, nil
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "### ") || strings.HasPrefix(line, "#### ") {
+			flush()
+			cur = &Note{Title: strings.TrimSpace(strings.TrimLeft(line, "# "))}
+			continue
+		}
+		if cur != nil {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return out
+}
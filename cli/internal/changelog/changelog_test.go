@@ -0,0 +1,89 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+)
+
+const oldLog = `# Changelog
+
+## [Unreleased]
+
+## [7.4.1] - 2026-07-18
+
+### Fixed
+
+- Outbound campaign fixes.
+`
+
+const newLog = `# Changelog
+
+## [Unreleased]
+
+### Fixed
+
+- Docs consistency.
+
+## [7.5.0] - 2026-07-22
+
+### Added
+
+- Enhanced 8 kHz profile.
+
+### Breaking Changes
+
+- ` + "`tts.voice`" + ` renamed to ` + "`tts.voice_id`" + `.
+
+## [7.4.1] - 2026-07-18
+
+### Fixed
+
+- Outbound campaign fixes.
+`
+
+const migration = `# Migration Guide
+
+## v7.4.1 to v7.5.0
+
+Assign the enhanced profile per Agent.
+
+## v7.3.x to v7.4.0
+
+Contexts become Agents.
+
+## v6.4.2 to v6.5.x
+
+Standard upgrade.
+`
+
+func TestBetween(t *testing.T) {
+	got := Between([]byte(oldLog), []byte(newLog))
+	if len(got) != 2 || got[0].Version != "Unreleased" || got[1].Version != "7.5.0" || got[1].Date != "2026-07-22" {
+		t.Fatalf("Between = %+v", got)
+	}
+	if !strings.Contains(got[1].Body, "Enhanced 8 kHz") || strings.Contains(got[1].Body, "Outbound") {
+		t.Fatalf("7.5.0 body = %q", got[1].Body)
+	}
+	if again := Between([]byte(newLog), []byte(newLog)); len(again) != 0 {
+		t.Fatalf("identical changelogs should yield nothing, got %+v", again)
+	}
+}
+
+func TestMigrationNotes(t *testing.T) {
+	notes := MigrationNotes([]byte(migration), Between([]byte(oldLog), []byte(newLog)))
+	if len(notes) != 2 {
+		t.Fatalf("notes = %+v", notes)
+	}
+	if notes[0].Title != "v7.4.1 to v7.5.0" || !strings.Contains(notes[0].Body, "enhanced profile") {
+		t.Errorf("migration doc note = %+v", notes[0])
+	}
+	if notes[1].Title != "7.5.0: Breaking Changes" || !strings.Contains(notes[1].Body, "renamed") {
+		t.Errorf("changelog note = %+v", notes[1])
+	}
+	if got := MigrationNotes([]byte(migration), []Entry{{Version: "6.5.3"}}); len(got) != 1 || got[0].Title != "v6.4.2 to v6.5.x" {
+		t.Errorf("6.5.3 should match only the v6.5.x section: %+v", got)
+	}
+	if got := MigrationNotes([]byte(migration), []Entry{{Version: "7.4.2"}}); len(got) != 0 {
+		t.Errorf("7.4.2 has no migration section: %+v", got)
+	}
+}
@@ -0,0 +1,301 @@
+// Package chaos injects network faults into ai_engine's traffic with tc
+// netem, so provider outages, slow or lossy provider links and impaired RTP
+// can be rehearsed before they happen on real calls.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault kinds.
+const (
+	Outage  = "outage"  // every packet to the provider is dropped
+	Latency = "latency" // packets to the provider are delayed
+	Loss    = "loss"    // a share of packets to the provider is dropped
+	RTP     = "rtp"     // ExternalMedia RTP is delayed, jittered, dropped or reordered
+)
+
+// Fault is a network impairment applied to provider traffic.
+type Fault struct {
+	Kind    string
+	Delay   time.Duration
+	Jitter  time.Duration
+	LossPct float64 // percent of packets dropped
+	Reorder float64 // percent of packets sent ahead of the delayed ones
+}
+
+// Validate checks the fault's parameters.
+func (f Fault) Validate() error {
+	switch f.Kind {
+	case Outage:
+		return nil
+	case Latency:
+		if f.Delay <= 0 {
+			return errors.New("delay must be positive")
+		}
+		if f.Jitter < 0 || f.Jitter > f.Delay {
+			return errors.New("jitter must be between 0 and the delay")
+		}
+		return nil
+	case Loss:
+		if f.LossPct <= 0 || f.LossPct >= 100 {
+			return errors.New("loss must be above 0 and below 100 percent (use outage for 100)")
+		}
+		return nil
+	case RTP:
+		if f.Delay < 0 || f.Jitter < 0 || f.LossPct < 0 || f.LossPct >= 100 || f.Reorder < 0 || f.Reorder > 100 {
+			return errors.New("delay and jitter must not be negative, and loss and reorder must be percentages below 100")
+		}
+		if f.Delay == 0 && f.LossPct == 0 {
+			return errors.New("set --delay or --loss")
+		}
+		if f.Delay == 0 && (f.Jitter > 0 || f.Reorder > 0) {
+			return errors.New("jitter and reorder need --delay")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown fault %q", f.Kind)
+}
+
+func (f Fault) String() string {
+	if f.Kind == Outage {
+		return "outage (100% packet loss)"
+	}
+	var parts []string
+	if f.Delay > 0 {
+		if f.Jitter > 0 {
+			parts = append(parts, fmt.Sprintf("%s ±%s added latency", f.Delay, f.Jitter))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s added latency", f.Delay))
+		}
+	}
+	if f.LossPct > 0 {
+		parts = append(parts, fmt.Sprintf("%s%% packet loss", percent(f.LossPct)))
+	}
+	if f.Reorder > 0 {
+		parts = append(parts, fmt.Sprintf("%s%% reordering", percent(f.Reorder)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// netem is the netem qdisc arguments for the fault.
+func (f Fault) netem() string {
+	if f.Kind == Outage {
+		return "loss 100%"
+	}
+	var args []string
+	if f.Delay > 0 {
+		d := fmt.Sprintf("delay %dms", f.Delay.Milliseconds())
+		if f.Jitter > 0 {
+			d += fmt.Sprintf(" %dms distribution normal", f.Jitter.Milliseconds())
+		}
+		args = append(args, d)
+	}
+	if f.LossPct > 0 {
+		args = append(args, fmt.Sprintf("loss %s%%", percent(f.LossPct)))
+	}
+	if f.Reorder > 0 {
+		args = append(args, fmt.Sprintf("reorder %s%%", percent(f.Reorder)))
+	}
+	return strings.Join(args, " ")
+}
+
+func percent(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Target is a provider endpoint the fault applies to.
+type Target struct {
+	Provider string   `json:"provider,omitempty"`
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	IPs      []string `json:"ips,omitempty"`
+}
+
+func (t Target) String() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// ParseTarget parses host[:port]; the port defaults to 443.
+func ParseTarget(s string) (Target, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Target{}, errors.New("empty target")
+	}
+	if strings.Contains(s, "://") {
+		return targetFromURL(s)
+	}
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return Target{Host: strings.Trim(s, "[]"), Port: 443}, nil
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return Target{}, fmt.Errorf("bad port in %q", s)
+	}
+	return Target{Host: host, Port: n}, nil
+}
+
+func targetFromURL(raw string) (Target, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return Target{}, fmt.Errorf("bad URL %q", raw)
+	}
+	t := Target{Host: u.Hostname()}
+	if p := u.Port(); p != "" {
+		t.Port, _ = strconv.Atoi(p)
+	} else if u.Scheme == "http" || u.Scheme == "ws" {
+		t.Port = 80
+	} else {
+		t.Port = 443
+	}
+	return t, nil
+}
+
+// knownHosts are the endpoints of providers whose config names no URL,
+// matched against the provider's name and then its type.
+var knownHosts = []struct {
+	match string
+	hosts []string
+}{
+	{"openai", []string{"api.openai.com"}},
+	{"deepgram", []string{"agent.deepgram.com", "api.deepgram.com"}},
+	{"google", []string{"generativelanguage.googleapis.com"}},
+	{"elevenlabs", []string{"api.elevenlabs.io"}},
+	{"grok", []string{"api.x.ai"}},
+	{"groq", []string{"api.groq.com"}},
+	{"minimax", []string{"api.minimax.io"}},
+}
+
+// defaultLocalWS is where the local provider reaches local_ai_server.
+const defaultLocalWS = "ws://127.0.0.1:8765"
+
+var envRef = regexp.MustCompile(`\$\{(\w+)(?::-([^}]*))?\}`)
+
+// expandEnv resolves ${VAR} and ${VAR:-default} the way the engine does.
+func expandEnv(s string, env func(string) string) string {
+	return envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRef.FindStringSubmatch(ref)
+		if v := env(m[1]); v != "" {
+			return v
+		}
+		return m[2]
+	})
+}
+
+// ProviderTargets returns the endpoints the engine dials for a provider or a
+// pipeline's providers, from the merged ai-agent.yaml. An empty name means
+// default_provider. Hosts come from the config's *url keys, falling back to
+// the provider's well-known API host.
+func ProviderTargets(cfg map[string]any, name string, env func(string) string) ([]Target, error) {
+	if name == "" {
+		name = strings.TrimSpace(fmt.Sprint(cfg["default_provider"]))
+		if name == "" || name == "<nil>" {
+			return nil, errors.New("default_provider is not set")
+		}
+	}
+	seen := map[string]bool{}
+	var out []Target
+	add := func(provider string, ts []Target) {
+		for _, t := range ts {
+			t.Provider = provider
+			if !seen[t.String()] {
+				seen[t.String()] = true
+				out = append(out, t)
+			}
+		}
+	}
+
+	providers, _ := cfg["providers"].(map[string]any)
+	pipelines, _ := cfg["pipelines"].(map[string]any)
+	if p, ok := pipelines[name].(map[string]any); ok {
+		options, _ := p["options"].(map[string]any)
+		for _, component := range []string{"stt", "llm", "tts"} {
+			provider, _ := p[component].(string)
+			if opts, ok := options[component].(map[string]any); ok {
+				add(provider, urlTargets(opts, env))
+			}
+			if provider == "" {
+				continue
+			}
+			pc, _ := providers[provider].(map[string]any)
+			add(provider, providerTargets(provider, pc, env))
+		}
+		if len(out) == 0 {
+			return nil, fmt.Errorf("pipeline %s names no provider endpoints; pass --target", name)
+		}
+		return out, nil
+	}
+	pc, ok := providers[name].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("no provider or pipeline named %q in ai-agent.yaml", name)
+	}
+	add(name, providerTargets(name, pc, env))
+	if len(out) == 0 {
+		return nil, fmt.Errorf("provider %s has no known endpoint; pass --target", name)
+	}
+	return out, nil
+}
+
+func providerTargets(name string, pc map[string]any, env func(string) string) []Target {
+	if ts := urlTargets(pc, env); len(ts) > 0 {
+		return ts
+	}
+	kind, _ := pc["type"].(string)
+	for _, key := range []string{strings.ToLower(name), strings.ToLower(kind)} {
+		if strings.HasPrefix(key, "local") {
+			t, err := ParseTarget(expandEnv("${LOCAL_WS_URL:-"+defaultLocalWS+"}", env))
+			if err != nil {
+				return nil
+			}
+			return []Target{t}
+		}
+		if strings.Contains(key, "azure") {
+			region, _ := pc["region"].(string)
+			if region == "" {
+				region = "eastus"
+			}
+			return []Target{{Host: region + ".stt.speech.microsoft.com", Port: 443}, {Host: region + ".tts.speech.microsoft.com", Port: 443}}
+		}
+		for _, k := range knownHosts {
+			if key != "" && strings.Contains(key, k.match) {
+				var ts []Target
+				for _, h := range k.hosts {
+					ts = append(ts, Target{Host: h, Port: 443})
+				}
+				return ts
+			}
+		}
+	}
+	return nil
+}
+
+// urlTargets parses the endpoints in the *url keys of a config section.
+func urlTargets(section map[string]any, env func(string) string) []Target {
+	var keys []string
+	for k := range section {
+		if strings.HasSuffix(k, "url") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var out []Target
+	for _, k := range keys {
+		raw, ok := section[k].(string)
+		if !ok || !strings.Contains(expandEnv(raw, env), "://") {
+			continue
+		}
+		if t, err := targetFromURL(expandEnv(raw, env)); err == nil {
+			out = append(out, t)
+		}
+	}
+	return out
+}
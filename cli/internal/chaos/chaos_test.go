@@ -0,0 +1,149 @@
+package chaos
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProviderTargetsFromPipeline(t *testing.T) {
+	cfg := map[string]any{
+		"default_provider": "hybrid",
+		"pipelines": map[string]any{
+			"hybrid": map[string]any{
+				"stt": "local_stt",
+				"llm": "openai_llm",
+				"tts": "elevenlabs_tts",
+				"options": map[string]any{
+					"stt": map[string]any{"ws_url": "${LOCAL_WS_URL:-ws://127.0.0.1:8765}"},
+				},
+			},
+		},
+		"providers": map[string]any{
+			"local_stt":      map[string]any{"ws_url": "${LOCAL_WS_URL:-ws://127.0.0.1:8765}"},
+			"openai_llm":     map[string]any{"chat_base_url": "https://api.openai.com/v1"},
+			"elevenlabs_tts": map[string]any{"type": "elevenlabs"},
+		},
+	}
+	env := func(string) string { return "" }
+	got, err := ProviderTargets(cfg, "", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tg := range got {
+		names = append(names, tg.Provider+"="+tg.String())
+	}
+	want := "local_stt=127.0.0.1:8765 openai_llm=api.openai.com:443 elevenlabs_tts=api.elevenlabs.io:443"
+	if strings.Join(names, " ") != want {
+		t.Fatalf("targets = %v", names)
+	}
+
+	env = func(key string) string {
+		if key == "LOCAL_WS_URL" {
+			return "ws://10.0.0.9:9000"
+		}
+		return ""
+	}
+	if got, _ := ProviderTargets(cfg, "local_stt", env); len(got) != 1 || got[0].String() != "10.0.0.9:9000" {
+		t.Fatalf("env override = %+v", got)
+	}
+	if _, err := ProviderTargets(cfg, "missing", env); err == nil {
+		t.Fatal("unknown provider accepted")
+	}
+}
+
+func TestApplyScriptFiltersOnlyTargets(t *testing.T) {
+	f := Fault{Kind: Latency, Delay: 800 * time.Millisecond, Jitter: 100 * time.Millisecond}
+	if err := f.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	got := ApplyScript(f, []Rule{
+		{Dev: "eth0", IP: "162.159.140.245", Port: 443},
+		{Dev: "eth0", IP: "2606:4700::6812:1", Port: 443},
+		{Dev: "lo", IP: "127.0.0.1", Port: 8765},
+	})
+	for _, want := range []string{
+		"tc qdisc add dev eth0 root handle 1: prio bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\n",
+		"tc qdisc add dev eth0 parent 1:4 handle 40: netem delay 800ms 100ms distribution normal\n",
+		"protocol ip prio 1 u32 match ip dst 162.159.140.245/32 match ip dport 443 0xffff flowid 1:4\n",
+		"protocol ipv6 prio 2 u32 match ip6 dst 2606:4700::6812:1/128 match ip6 dport 443 0xffff flowid 1:4\n",
+		"tc filter add dev lo parent 1: protocol ip prio 1 u32 match ip dst 127.0.0.1/32 match ip dport 8765 0xffff flowid 1:4\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("script missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "root handle") != 2 {
+		t.Fatalf("expected one prio root per device:\n%s", got)
+	}
+	if (Fault{Kind: Loss, LossPct: 100}).Validate() == nil {
+		t.Fatal("100% loss should be an outage")
+	}
+}
+
+func TestCheckDevicesAndFaultedDevices(t *testing.T) {
+	show := `qdisc noqueue 0: dev lo root refcnt 2
+qdisc mq 0: dev eth0 root
+qdisc fq_codel 0: dev eth0 parent :1 limit 10240p flows 1024
+qdisc prio 1: dev eth1 root refcnt 2 bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1
+qdisc netem 40: dev eth1 parent 1:4 limit 1000 loss 100%
+qdisc htb 1: dev eth2 root refcnt 2 r2q 10 default 0`
+	qs := ParseQdiscs(show)
+	if err := CheckDevices(qs, []string{"lo", "eth0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckDevices(qs, []string{"eth1"}); err == nil || !strings.Contains(err.Error(), "agent chaos clear") {
+		t.Fatalf("leftover fault err = %v", err)
+	}
+	if err := CheckDevices(qs, []string{"eth2"}); err == nil || !strings.Contains(err.Error(), "htb") {
+		t.Fatalf("custom qdisc err = %v", err)
+	}
+	if got := FaultedDevices(qs); len(got) != 1 || got[0] != "eth1" {
+		t.Fatalf("faulted = %v", got)
+	}
+}
+
+func TestRouteDevices(t *testing.T) {
+	out := "104.18.33.45 104.18.33.45 via 10.0.0.1 dev ens3 src 10.0.0.5 uid 0 \\    cache \n" +
+		"127.0.0.1 local 127.0.0.1 dev lo src 127.0.0.1 uid 0 \\    cache <local> \n"
+	got := RouteDevices(out)
+	if got["104.18.33.45"] != "ens3" || got["127.0.0.1"] != "lo" {
+		t.Fatalf("devices = %v", got)
+	}
+}
+
+func TestPortRangeRules(t *testing.T) {
+	rules, err := PortRangeRules("lo", "18080:18099")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 4 {
+		t.Fatalf("rules = %+v", rules)
+	}
+	f := Fault{Kind: RTP, Delay: 60 * time.Millisecond, Jitter: 30 * time.Millisecond, LossPct: 2, Reorder: 5}
+	if err := f.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	got := ApplyScript(f, rules)
+	for _, want := range []string{
+		"netem delay 60ms 30ms distribution normal loss 2% reorder 5%\n",
+		"match ip protocol 17 0xff match ip sport 18080 0xfff0 flowid 1:4\n",
+		"match ip protocol 17 0xff match ip sport 18096 0xfffc flowid 1:4\n",
+		"match ip protocol 17 0xff match ip dport 18080 0xfff0 flowid 1:4\n",
+		"match ip protocol 17 0xff match ip dport 18096 0xfffc flowid 1:4\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("script missing %q:\n%s", want, got)
+		}
+	}
+	if rules, _ := PortRangeRules("lo", "4000-4000"); len(rules) != 2 || rules[0].Mask != 0xffff {
+		t.Fatalf("single port = %+v", rules)
+	}
+	if _, err := PortRangeRules("lo", "18099:18080"); err == nil {
+		t.Fatal("reversed range accepted")
+	}
+	if (Fault{Kind: RTP, Jitter: 20 * time.Millisecond, LossPct: 1}).Validate() == nil {
+		t.Fatal("jitter without delay accepted")
+	}
+}
@@ -0,0 +1,220 @@
+package chaos
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The fault hangs off band 4 of a prio root qdisc. Only the filters send
+// traffic to that band, so everything else on the interface is queued as
+// before.
+const (
+	rootHandle  = "1:"
+	faultBand   = "1:4"
+	netemHandle = "40:"
+	// priomap is the kernel's default mapping onto the first three bands.
+	priomap = "1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1"
+)
+
+// defaultRoots are root qdiscs the kernel or distribution installs; anything
+// else was configured by someone and is not replaced.
+var defaultRoots = map[string]bool{"noqueue": true, "mq": true, "fq_codel": true, "fq": true, "pfifo_fast": true}
+
+// Rule sends the traffic to one address and port, or to a block of ports,
+// through the fault.
+type Rule struct {
+	Dev    string `json:"dev"`
+	IP     string `json:"ip,omitempty"` // destination; empty matches any
+	Port   int    `json:"port"`
+	Mask   uint16 `json:"mask,omitempty"`   // port mask; 0 matches Port alone
+	Source bool   `json:"source,omitempty"` // match the source port instead
+	UDP    bool   `json:"udp,omitempty"`
+}
+
+// PortRangeRules returns rules for UDP traffic to or from the ports of spec,
+// "18080:18099" or "18080-18099", on dev.
+func PortRangeRules(dev, spec string) ([]Rule, error) {
+	lo, hi, ok := ParsePortRange(spec)
+	if !ok {
+		return nil, fmt.Errorf("bad port range %q", spec)
+	}
+	var rules []Rule
+	for _, source := range []bool{true, false} {
+		for p := lo; p <= hi; {
+			size := 1
+			for p%(size*2) == 0 && p+size*2-1 <= hi {
+				size *= 2
+			}
+			rules = append(rules, Rule{Dev: dev, Port: p, Mask: uint16(0xffff &^ (size - 1)), Source: source, UDP: true})
+			p += size
+		}
+	}
+	return rules, nil
+}
+
+// ParsePortRange parses a port range, "18080:18099" or "18080-18099", or a
+// single port.
+func ParsePortRange(spec string) (int, int, bool) {
+	spec = strings.TrimSpace(spec)
+	a, b, found := strings.Cut(spec, ":")
+	if !found {
+		a, b, found = strings.Cut(spec, "-")
+	}
+	if !found {
+		b = a
+	}
+	lo, err1 := strconv.Atoi(strings.TrimSpace(a))
+	hi, err2 := strconv.Atoi(strings.TrimSpace(b))
+	if err1 != nil || err2 != nil || lo < 1 || hi > 65535 || lo > hi {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
+// ApplyScript returns the shell commands that install fault f for rules.
+func ApplyScript(f Fault, rules []Rule) string {
+	var sb strings.Builder
+	sb.WriteString("set -e\n")
+	for _, dev := range Devices(rules) {
+		fmt.Fprintf(&sb, "tc qdisc add dev %s root handle %s prio bands 4 priomap %s\n", dev, rootHandle, priomap)
+		fmt.Fprintf(&sb, "tc qdisc add dev %s parent %s handle %s netem %s\n", dev, faultBand, netemHandle, f.netem())
+	}
+	for _, r := range rules {
+		fmt.Fprintf(&sb, "tc filter add dev %s parent %s %s flowid %s\n", r.Dev, rootHandle, r.match(), faultBand)
+	}
+	return sb.String()
+}
+
+// match is the protocol and u32 selector of the rule's filter.
+func (r Rule) match() string {
+	proto, prio, sel := "ip", 1, "ip"
+	var m []string
+	if ip := net.ParseIP(r.IP); ip != nil && ip.To4() == nil {
+		proto, prio, sel = "ipv6", 2, "ip6"
+		m = append(m, fmt.Sprintf("match ip6 dst %s/128", r.IP))
+	} else if r.IP != "" {
+		m = append(m, fmt.Sprintf("match ip dst %s/32", r.IP))
+	}
+	if r.UDP {
+		m = append(m, fmt.Sprintf("match %s protocol 17 0xff", sel))
+	}
+	dir, mask := "dport", r.Mask
+	if r.Source {
+		dir = "sport"
+	}
+	if mask == 0 {
+		mask = 0xffff
+	}
+	m = append(m, fmt.Sprintf("match %s %s %d 0x%04x", sel, dir, r.Port, mask))
+	return fmt.Sprintf("protocol %s prio %d u32 %s", proto, prio, strings.Join(m, " "))
+}
+
+// ClearScript returns the shell commands that remove the fault from devs,
+// restoring the kernel's default root qdisc.
+func ClearScript(devs []string) string {
+	var sb strings.Builder
+	for _, dev := range devs {
+		fmt.Fprintf(&sb, "tc qdisc del dev %s root 2>/dev/null || true\n", dev)
+	}
+	return sb.String()
+}
+
+// RouteScript returns shell commands that print, for each address, the
+// address followed by its "ip route get" line.
+func RouteScript(ips []string) string {
+	var sb strings.Builder
+	for _, ip := range ips {
+		fmt.Fprintf(&sb, "echo \"%s $(ip -o route get %s)\"\n", ip, ip)
+	}
+	return sb.String()
+}
+
+// RouteDevices maps each address in RouteScript output to the interface
+// that routes it.
+func RouteDevices(out string) map[string]string {
+	devs := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		for i := 1; i+1 < len(fields); i++ {
+			if fields[i] == "dev" {
+				devs[fields[0]] = fields[i+1]
+				break
+			}
+		}
+	}
+	return devs
+}
+
+// Qdisc is one line of "tc qdisc show".
+type Qdisc struct {
+	Kind   string
+	Handle string
+	Dev    string
+	Parent string // "root" for a root qdisc
+}
+
+// ParseQdiscs parses "tc qdisc show" output.
+func ParseQdiscs(out string) []Qdisc {
+	var qs []Qdisc
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] != "qdisc" || fields[3] != "dev" {
+			continue
+		}
+		q := Qdisc{Kind: fields[1], Handle: fields[2], Dev: fields[4], Parent: fields[5]}
+		if q.Parent == "parent" && len(fields) > 6 {
+			q.Parent = fields[6]
+		}
+		qs = append(qs, q)
+	}
+	return qs
+}
+
+// FaultedDevices returns the interfaces that carry a fault installed by
+// ApplyScript.
+func FaultedDevices(qs []Qdisc) []string {
+	var devs []string
+	for _, q := range qs {
+		if q.Kind == "netem" && q.Handle == netemHandle && q.Parent == faultBand {
+			devs = append(devs, q.Dev)
+		}
+	}
+	sort.Strings(devs)
+	return devs
+}
+
+// CheckDevices refuses interfaces that already carry a fault or a root qdisc
+// someone configured, since applying and clearing the fault would remove it.
+func CheckDevices(qs []Qdisc, devs []string) error {
+	faulted := map[string]bool{}
+	for _, dev := range FaultedDevices(qs) {
+		faulted[dev] = true
+	}
+	for _, dev := range devs {
+		if faulted[dev] {
+			return fmt.Errorf("%s still carries a fault from an earlier run; remove it with: agent chaos clear", dev)
+		}
+		for _, q := range qs {
+			if q.Dev == dev && q.Parent == "root" && !defaultRoots[q.Kind] {
+				return fmt.Errorf("%s has a %s root qdisc that the fault would replace; remove it first or test on another host", dev, q.Kind)
+			}
+		}
+	}
+	return nil
+}
+
+// Devices returns the interfaces rules use, in order.
+func Devices(rules []Rule) []string {
+	seen := map[string]bool{}
+	var devs []string
+	for _, r := range rules {
+		if !seen[r.Dev] {
+			seen[r.Dev] = true
+			devs = append(devs, r.Dev)
+		}
+	}
+	return devs
+}
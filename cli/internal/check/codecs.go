@@ -0,0 +1,52 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/pjsip"
+)
+
+// checkCodecNegotiation compares pjsip endpoint allow lists with the format the engine expects.
+func (r *Runner) checkCodecNegotiation(cfg *configSummary) Item {
+	const name = "Codec Negotiation"
+	if cfg == nil {
+		return Item{Name: name, Status: StatusSkip, Message: "config unavailable"}
+	}
+
+	text, source, err := pjsip.ReadConfig()
+	if err != nil {
+		return Item{
+			Name:        name,
+			Status:      StatusSkip,
+			Message:     "pjsip config not readable (Asterisk remote or not containerized)",
+			Details:     err.Error(),
+			Remediation: "Set ASTERISK_CONTAINER if Asterisk runs in a differently named container, or on the PBX run: asterisk -rx \"pjsip show endpoints\"",
+		}
+	}
+
+	expected := pjsip.ExpectedFormat(cfg.AudioTransport, cfg.AudioSocket.Format, cfg.ExternalMedia.Codec)
+	audit := pjsip.Audit(pjsip.ParseEndpoints(text), expected)
+	audit.Source = source
+
+	details := []string{
+		"source=" + audit.Source,
+		fmt.Sprintf("expected=%s %s@%dHz", expected.Transport, expected.Codec, expected.SampleRate),
+	}
+	for _, ep := range audit.Endpoints {
+		details = append(details, fmt.Sprintf("%s allow=%s", ep.Name, emptyTo(strings.Join(ep.Allow, ","), "(default)")))
+	}
+	if len(audit.Endpoints) == 0 {
+		return Item{Name: name, Status: StatusSkip, Message: "no pjsip endpoints found", Details: strings.Join(details, "\n")}
+	}
+	if len(audit.Issues) == 0 {
+		return Item{Name: name, Status: StatusPass, Message: "endpoint codecs align with engine format", Details: strings.Join(details, "\n")}
+	}
+	return Item{
+		Name:        name,
+		Status:      StatusWarn,
+		Message:     fmt.Sprintf("%d codec negotiation issue(s)", len(audit.Issues)),
+		Details:     strings.Join(append(audit.Issues, details...), "\n"),
+		Remediation: "Put an 8k codec first on trunk endpoints (disallow=all, allow=ulaw,alaw) or align audiosocket.format/external_media.codec with the trunk.",
+	}
+}
@@ -0,0 +1,272 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"gopkg.in/yaml.v3"
+)
+
+// composeService is the subset of a compose service definition the CLI depends on.
+type composeService struct {
+	ContainerName string      `yaml:"container_name"`
+	NetworkMode   string      `yaml:"network_mode"`
+	Networks      interface{} `yaml:"networks"`
+	Volumes       []yaml.Node `yaml:"volumes"`
+	EnvFile       interface{} `yaml:"env_file"`
+	Environment   interface{} `yaml:"environment"`
+	Build         interface{} `yaml:"build"`
+}
+
+type composeFile struct {
+	Name     string                    `yaml:"name"`
+	Services map[string]composeService `yaml:"services"`
+}
+
+// expectedService describes what the pipeline needs from one compose service.
+type expectedService struct {
+	Required bool
+	Mounts   []string // container paths that must be mounted
+}
+
+var expectedComposeServices = map[string]expectedService{
+	"ai_engine":       {Required: true, Mounts: []string{"/app/config", "/app/data", "/mnt/asterisk_media"}},
+	"admin_ui":        {Mounts: []string{"/app/project", "/var/run/docker.sock", "/app/data"}},
+	"local_ai_server": {Mounts: []string{"/app/models", "/app/data"}},
+}
+
+// Variables the engine must read from .env; setting them in compose `environment:` shadows
+// edits made from the Admin UI.
+var composeShadowedEnv = []string{"ASTERISK_HOST", "TZ", "UVICORN_HOST", "UVICORN_PORT", "JWT_SECRET"}
+
+// checkComposeTopology parses docker-compose.yml and flags drift that would break the pipeline.
+func (r *Runner) checkComposeTopology() Item {
+	const name = "Compose Topology"
+	if dockerHostIsRemote() {
+		return Item{Name: name, Status: StatusSkip, Message: "skipped (remote docker host; compose file lives on the server)"}
+	}
+	path, err := findComposeFile()
+	if err != nil {
+		return Item{Name: name, Status: StatusSkip, Message: "docker-compose.yml not found", Details: err.Error(), Remediation: "Run agent check from the project root."}
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Item{Name: name, Status: StatusWarn, Message: "cannot read docker-compose.yml", Details: err.Error()}
+	}
+	var cf composeFile
+	if err := yaml.Unmarshal(raw, &cf); err != nil {
+		return Item{
+			Name:        name,
+			Status:      StatusFail,
+			Message:     "invalid YAML in docker-compose.yml",
+			Details:     err.Error(),
+			Remediation: "Fix the YAML syntax or restore the file: git checkout -- docker-compose.yml",
+		}
+	}
+
+	fails, warns := validateComposeTopology(&cf)
+	details := append([]string{"file=" + path}, append(fails, warns...)...)
+	switch {
+	case len(fails) > 0:
+		return Item{
+			Name:        name,
+			Status:      StatusFail,
+			Message:     "compose topology is broken",
+			Details:     strings.Join(details, "\n"),
+			Remediation: "Compare with upstream: git diff -- docker-compose.yml (put local changes in docker-compose.override.yml)",
+		}
+	case len(warns) > 0:
+		return Item{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     "compose topology has drifted",
+			Details:     strings.Join(details, "\n"),
+			Remediation: "Compare with upstream: git diff -- docker-compose.yml (put local changes in docker-compose.override.yml)",
+		}
+	}
+	return Item{Name: name, Status: StatusPass, Message: "services, mounts and env wiring look ok", Details: "file=" + path}
+}
+
+func validateComposeTopology(cf *composeFile) (fails []string, warns []string) {
+	if project := deployment.ComposeProject(); cf.Name != "" && cf.Name != project {
+		warns = append(warns, fmt.Sprintf("compose project name is %q (agent update/check expect %q; set compose_project in %s)", cf.Name, project, deployment.Path()))
+	}
+
+	names := make([]string, 0, len(expectedComposeServices))
+	for n := range expectedComposeServices {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, svcName := range names {
+		want := expectedComposeServices[svcName]
+		svc, ok := cf.Services[svcName]
+		if !ok {
+			msg := fmt.Sprintf("service %s is missing", svcName)
+			if renamed := findRenamedService(cf, svcName); renamed != "" {
+				msg = fmt.Sprintf("service %s is missing (renamed to %s?)", svcName, renamed)
+			}
+			if want.Required {
+				fails = append(fails, msg)
+			} else {
+				warns = append(warns, msg)
+			}
+			continue
+		}
+
+		if expected := expectedContainerName(svcName); svc.ContainerName != "" && svc.ContainerName != expected {
+			warns = append(warns, fmt.Sprintf("service %s has container_name %q (the CLI expects %q; set containers.%s in %s)", svcName, svc.ContainerName, expected, svcName, deployment.Path()))
+		}
+
+		mounted := map[string]bool{}
+		for _, v := range svc.Volumes {
+			if target := composeVolumeTarget(&v); target != "" {
+				mounted[target] = true
+			}
+		}
+		for _, m := range want.Mounts {
+			if mounted[m] {
+				continue
+			}
+			msg := fmt.Sprintf("service %s does not mount %s", svcName, m)
+			if want.Required {
+				fails = append(fails, msg)
+			} else {
+				warns = append(warns, msg)
+			}
+		}
+
+		if !composeListContains(svc.EnvFile, ".env") {
+			warns = append(warns, fmt.Sprintf("service %s does not load env_file .env", svcName))
+		}
+		for _, key := range composeShadowedEnv {
+			if composeEnvHas(svc.Environment, key) {
+				warns = append(warns, fmt.Sprintf("service %s sets %s in environment: (shadows .env and Admin UI edits)", svcName, key))
+			}
+		}
+
+		if svcName == "ai_engine" && svc.NetworkMode != "host" && svc.Networks == nil {
+			warns = append(warns, "ai_engine is not on host networking and declares no networks (Asterisk reachability depends on port publishing)")
+		}
+	}
+	return fails, warns
+}
+
+// expectedContainerName maps a stock compose service to its configured container name.
+func expectedContainerName(svcName string) string {
+	return deployment.ServiceContainer(svcName)
+}
+
+// findRenamedService looks for a service that still carries the expected container_name.
+func findRenamedService(cf *composeFile, svcName string) string {
+	expected := expectedContainerName(svcName)
+	for n, svc := range cf.Services {
+		if svc.ContainerName == expected {
+			return n
+		}
+	}
+	return ""
+}
+
+// composeVolumeTarget returns the container path of a short ("src:dst:mode") or long
+// ({source, target}) volume entry.
+func composeVolumeTarget(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		parts := splitComposeVolume(n.Value)
+		if len(parts) >= 2 {
+			return strings.TrimRight(parts[1], "/")
+		}
+		return strings.TrimRight(parts[0], "/")
+	case yaml.MappingNode:
+		var long struct {
+			Target string `yaml:"target"`
+		}
+		if err := n.Decode(&long); err == nil {
+			return strings.TrimRight(long.Target, "/")
+		}
+	}
+	return ""
+}
+
+// splitComposeVolume splits on ':' outside of ${VAR:-default} interpolations.
+func splitComposeVolume(s string) []string {
+	var parts []string
+	depth, last := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}' && depth > 0:
+			depth--
+		case s[i] == ':' && depth == 0:
+			parts = append(parts, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, s[last:])
+}
+
+func composeListContains(v interface{}, want string) bool {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimPrefix(t, "./") == want
+	case []interface{}:
+		for _, item := range t {
+			switch e := item.(type) {
+			case string:
+				if strings.TrimPrefix(e, "./") == want {
+					return true
+				}
+			case map[string]interface{}:
+				if p, _ := e["path"].(string); strings.TrimPrefix(p, "./") == want {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func composeEnvHas(v interface{}, key string) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		_, ok := t[key]
+		return ok
+	case []interface{}:
+		for _, item := range t {
+			s, _ := item.(string)
+			if k, _, _ := strings.Cut(s, "="); strings.TrimSpace(k) == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findComposeFile looks for docker-compose.yml in the working directory and its parents.
+func findComposeFile() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, n := range []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"} {
+			p := filepath.Join(dir, n)
+			if _, err := os.Stat(p); err == nil {
+				return p, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no docker-compose.yml in working directory or parents")
+		}
+		dir = parent
+	}
+}
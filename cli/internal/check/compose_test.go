@@ -0,0 +1,50 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateComposeTopologyFlagsDrift(t *testing.T) {
+	t.Parallel()
+
+	doc := `
+name: asterisk-ai-voice-agent
+services:
+  engine:
+    container_name: ai_engine
+    network_mode: host
+  admin_ui:
+    container_name: admin_ui
+    network_mode: host
+    env_file: [.env]
+    volumes:
+      - ./:/app/project
+      - ${DOCKER_SOCK:-/var/run/docker.sock}:/var/run/docker.sock
+      - type: bind
+        source: ./data
+        target: /app/data
+    environment:
+      - ASTERISK_HOST=${ASTERISK_HOST:-127.0.0.1}
+`
+	var cf composeFile
+	if err := yaml.Unmarshal([]byte(doc), &cf); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	fails, warns := validateComposeTopology(&cf)
+	if len(fails) != 1 || !strings.Contains(fails[0], "renamed to engine") {
+		t.Fatalf("expected renamed ai_engine failure, got %v", fails)
+	}
+	joined := strings.Join(warns, "\n")
+	if !strings.Contains(joined, "admin_ui sets ASTERISK_HOST") {
+		t.Fatalf("expected shadowed env warning, got %v", warns)
+	}
+	if strings.Contains(joined, "admin_ui does not mount") {
+		t.Fatalf("long-syntax volume should satisfy /app/data: %v", warns)
+	}
+	if !strings.Contains(joined, "local_ai_server is missing") {
+		t.Fatalf("expected optional service warning, got %v", warns)
+	}
+}
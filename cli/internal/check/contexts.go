@@ -0,0 +1,51 @@
+package check
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contexts"
+)
+
+// checkContextFiles lints config/contexts/ next to the compose file. The
+// engine and the agents.db import skip a context file they cannot load without
+// logging it, so the first sign is otherwise a missing or stale agent.
+func (r *Runner) checkContextFiles() Item {
+	const name = "Context files"
+	if dockerHostIsRemote() {
+		return Item{Name: name, Status: StatusSkip, Message: "skipped (remote docker host; config/contexts lives on the server)"}
+	}
+	composePath, err := findComposeFile()
+	if err != nil {
+		return Item{Name: name, Status: StatusSkip, Message: "docker-compose.yml not found", Remediation: "Run agent check from the project root."}
+	}
+	root := filepath.Dir(composePath)
+	res, err := contexts.Lint(filepath.Join(root, "config", "contexts"), contexts.LoadOptions(root))
+	if err != nil {
+		return Item{Name: name, Status: StatusWarn, Message: "could not read config/contexts", Details: err.Error()}
+	}
+	return evaluateContextLint(res)
+}
+
+func evaluateContextLint(res *contexts.Result) Item {
+	item := Item{Name: "Context files"}
+	if len(res.Files) == 0 {
+		item.Status, item.Message = StatusSkip, "no context files"
+		return item
+	}
+	errs, warnings := res.Counts()
+	var details []string
+	for _, f := range res.Findings {
+		details = append(details, fmt.Sprintf("%s: %s: %s", f.File, f.Severity, f.Message))
+	}
+	item.Details = strings.Join(details, "\n")
+	if errs+warnings == 0 {
+		item.Status, item.Message = StatusPass, fmt.Sprintf("%d context file(s) valid", len(res.Files))
+		return item
+	}
+	item.Status = StatusWarn
+	item.Message = fmt.Sprintf("%d error(s), %d warning(s) in %d context file(s)", errs, warnings, len(res.Files))
+	item.Remediation = "Run: agent context lint. Files with errors are skipped when the engine loads its config and when Admin UI imports contexts into agents.db."
+	return item
+}
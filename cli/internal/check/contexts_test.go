@@ -0,0 +1,29 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contexts"
+)
+
+func TestEvaluateContextLint(t *testing.T) {
+	res := &contexts.Result{Files: []contexts.File{{File: "sales.yaml"}, {File: "support.yaml"}}}
+	if item := evaluateContextLint(res); item.Status != StatusPass || item.Message != "2 context file(s) valid" {
+		t.Fatalf("clean: %+v", item)
+	}
+	res.Findings = []contexts.Finding{
+		{File: "support.yaml", Severity: contexts.SeverityError, Message: "missing name; the engine skips files without one"},
+		{File: "sales.yaml", Severity: contexts.SeverityWarning, Message: `unknown field "colour" is ignored by the engine`},
+	}
+	item := evaluateContextLint(res)
+	if item.Status != StatusWarn || item.Message != "1 error(s), 1 warning(s) in 2 context file(s)" || !strings.Contains(item.Remediation, "agent context lint") {
+		t.Fatalf("findings: %+v", item)
+	}
+	if !strings.HasPrefix(item.Details, "support.yaml: error: missing name") {
+		t.Fatalf("details = %q", item.Details)
+	}
+	if item := evaluateContextLint(&contexts.Result{}); item.Status != StatusSkip {
+		t.Fatalf("no files: %+v", item)
+	}
+}
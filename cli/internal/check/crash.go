@@ -0,0 +1,164 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// restartLoopThreshold is the number of restarts within restartLoopWindow that counts as a loop.
+const (
+	restartLoopThreshold = 3
+	restartLoopWindow    = 10 * time.Minute
+)
+
+// restartSample is persisted between runs so restart counts can be trended.
+type restartSample struct {
+	Count int       `json:"count"`
+	At    time.Time `json:"at"`
+}
+
+// crashDiagnosis records the container's restart count for the next run and
+// returns its restart loop, OOM kill and crash exit problems; crashing is set
+// when the container is going down on its own rather than just stopped.
+func (r *Runner) crashDiagnosis(name string, ci *containerInspect) (problems []string, crashing bool) {
+	samples := loadRestartSamples()
+	prev := samples[name]
+	now := time.Now()
+	samples[name] = restartSample{Count: ci.RestartCount, At: now}
+	saveRestartSamples(samples)
+	return containerProblems(ci, prev, now)
+}
+
+// containerProblems is crashDiagnosis without the persisted samples.
+func containerProblems(ci *containerInspect, prev restartSample, now time.Time) (problems []string, crashing bool) {
+	if loop, why := detectRestartLoop(ci, prev, now); loop {
+		crashing = true
+		problems = append(problems, "restart loop ("+why+")")
+	}
+	if ci.State.OOMKilled {
+		crashing = true
+		problems = append(problems, "OOM-killed (raise the memory limit or use smaller models)")
+	}
+	if !ci.State.Running && !ci.State.Restarting && ci.State.ExitCode != 0 {
+		crashing = true
+		msg := fmt.Sprintf("exited with code %d %s", ci.State.ExitCode, exitCodeMeaning(ci.State.ExitCode))
+		if ci.State.Error != "" {
+			msg += ": " + ci.State.Error
+		}
+		problems = append(problems, strings.TrimSpace(msg))
+	}
+	return problems, crashing
+}
+
+// detectRestartLoop reports whether the container is flapping, using the live Restarting flag,
+// the RestartCount delta since the previous run, and how recently it last started.
+func detectRestartLoop(ci *containerInspect, prev restartSample, now time.Time) (bool, string) {
+	if ci.State.Restarting {
+		return true, "docker reports Restarting"
+	}
+	if !prev.At.IsZero() && now.Sub(prev.At) <= restartLoopWindow {
+		if delta := ci.RestartCount - prev.Count; delta >= restartLoopThreshold {
+			return true, fmt.Sprintf("+%d restarts in %s", delta, now.Sub(prev.At).Round(time.Second))
+		}
+	}
+	if ci.RestartCount >= restartLoopThreshold && !ci.State.StartedAt.IsZero() && now.Sub(ci.State.StartedAt) < time.Minute {
+		return true, fmt.Sprintf("%d restarts, last start %s ago", ci.RestartCount, now.Sub(ci.State.StartedAt).Round(time.Second))
+	}
+	return false, ""
+}
+
+func exitCodeMeaning(code int) string {
+	switch code {
+	case 1:
+		return "(application error)"
+	case 125, 126, 127:
+		return "(container failed to start)"
+	case 134:
+		return "(SIGABRT)"
+	case 137:
+		return "(SIGKILL, often OOM)"
+	case 139:
+		return "(SIGSEGV)"
+	case 143:
+		return "(SIGTERM)"
+	}
+	return ""
+}
+
+// lastStackTrace returns the last Python traceback in the container's recent logs.
+func lastStackTrace(name string) string {
+	out, _ := dockerapi.LogsOutput(name, dockerapi.LogsOptions{Tail: 400})
+	return lastTraceback(string(out))
+}
+
+// lastTraceback returns the last Python traceback block (header through exception line).
+func lastTraceback(logs string) string {
+	all := strings.Split(logs, "\n")
+	start := -1
+	for i := len(all) - 1; i >= 0; i-- {
+		if strings.Contains(all[i], "Traceback (most recent call last):") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+	block := []string{all[start]}
+	for _, l := range all[start+1:] {
+		trimmed := strings.TrimRight(l, "\r")
+		if trimmed == "" {
+			break
+		}
+		block = append(block, trimmed)
+		// Frames are indented; the first non-indented line is the exception itself.
+		if !strings.HasPrefix(trimmed, " ") && !strings.HasPrefix(trimmed, "\t") {
+			break
+		}
+		if len(block) >= 40 {
+			break
+		}
+	}
+	return strings.Join(block, "\n")
+}
+
+func restartSamplesPath() string {
+	return filepath.Join(".agent", "container-restarts.json")
+}
+
+func loadRestartSamples() map[string]restartSample {
+	samples := map[string]restartSample{}
+	raw, err := os.ReadFile(restartSamplesPath())
+	if err != nil {
+		return samples
+	}
+	_ = json.Unmarshal(raw, &samples)
+	return samples
+}
+
+// saveRestartSamples is best-effort; a read-only checkout just loses trending.
+func saveRestartSamples(samples map[string]restartSample) {
+	raw, err := json.MarshalIndent(samples, "", "  ")
+	if err != nil {
+		return
+	}
+	path := restartSamplesPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, raw, 0o644)
+}
+
+// crashMessage is the first problem, with a count of the others.
+func crashMessage(problems []string) string {
+	if len(problems) > 1 {
+		return fmt.Sprintf("%s (+%d more)", problems[0], len(problems)-1)
+	}
+	return problems[0]
+}
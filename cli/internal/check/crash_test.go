@@ -0,0 +1,68 @@
+package check
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastTracebackReturnsFinalBlock(t *testing.T) {
+	logs := strings.Join([]string{
+		"Traceback (most recent call last):",
+		"  File \"/app/old.py\", line 1, in <module>",
+		"ValueError: old",
+		"INFO engine restarted",
+		"Traceback (most recent call last):",
+		"  File \"/app/main.py\", line 42, in <module>",
+		"    main()",
+		"RuntimeError: ARI connection refused",
+		"INFO shutting down",
+	}, "\n")
+	tb := lastTraceback(logs)
+	if !strings.HasSuffix(tb, "RuntimeError: ARI connection refused") || strings.Contains(tb, "old.py") {
+		t.Fatalf("unexpected traceback:\n%s", tb)
+	}
+}
+
+func TestDetectRestartLoopUsesPreviousSample(t *testing.T) {
+	now := time.Now()
+	ci := &containerInspect{RestartCount: 7}
+	ci.State.Running = true
+	ci.State.StartedAt = now.Add(-5 * time.Minute)
+
+	if loop, _ := detectRestartLoop(ci, restartSample{}, now); loop {
+		t.Fatalf("stable container without history should not be a loop")
+	}
+	if loop, why := detectRestartLoop(ci, restartSample{Count: 3, At: now.Add(-2 * time.Minute)}, now); !loop {
+		t.Fatalf("expected loop from restart delta")
+	} else if !strings.Contains(why, "+4 restarts") {
+		t.Fatalf("unexpected reason: %s", why)
+	}
+	ci.State.StartedAt = now.Add(-20 * time.Second)
+	if loop, why := detectRestartLoop(ci, restartSample{}, now); !loop || !strings.Contains(why, "last start 20s ago") {
+		t.Fatalf("recent restart: loop=%v %s", loop, why)
+	}
+}
+
+func TestContainerProblems(t *testing.T) {
+	now := time.Now()
+	ci := &containerInspect{}
+	ci.State.Status = "exited"
+	ci.State.ExitCode = 137
+	ci.State.OOMKilled = true
+
+	problems, crashing := containerProblems(ci, restartSample{}, now)
+	if !crashing || len(problems) != 2 || problems[1] != "exited with code 137 (SIGKILL, often OOM)" {
+		t.Fatalf("oom kill: crashing=%v %q", crashing, problems)
+	}
+	if msg := crashMessage(problems); !strings.HasPrefix(msg, "OOM-killed") || !strings.HasSuffix(msg, "(+1 more)") {
+		t.Errorf("message = %q", msg)
+	}
+
+	// A clean stop is not a crash.
+	ci = &containerInspect{}
+	ci.State.Status = "exited"
+	if problems, crashing := containerProblems(ci, restartSample{}, now); crashing || len(problems) != 0 {
+		t.Fatalf("clean stop: crashing=%v %q", crashing, problems)
+	}
+}
@@ -0,0 +1,193 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dnsProviderHosts are resolved alongside ASTERISK_HOST.
+var dnsProviderHosts = []string{"api.openai.com", "api.deepgram.com", "agent.deepgram.com", "generativelanguage.googleapis.com"}
+
+// dockerEmbeddedDNS is the resolver docker injects on user-defined networks.
+const dockerEmbeddedDNS = "127.0.0.11"
+
+type dnsResult struct {
+	Host  string   `json:"host"`
+	Addrs []string `json:"addrs"`
+	MS    int      `json:"ms"`
+	Error string   `json:"error"`
+}
+
+type dnsProbe struct {
+	Nameservers []string    `json:"nameservers"`
+	Search      []string    `json:"search"`
+	Results     []dnsResult `json:"results"`
+}
+
+// dnsProbeScript resolves with the container's own resolv.conf. The embedded
+// resolver at 127.0.0.11 only answers inside the container's network namespace,
+// so the lookup has to run there rather than from the host.
+const dnsProbeScript = `
+import json, socket, time
+
+hosts = json.loads(%q)
+out = {"nameservers": [], "search": [], "results": []}
+try:
+    with open("/etc/resolv.conf") as f:
+        for line in f:
+            parts = line.split()
+            if len(parts) >= 2 and parts[0] == "nameserver":
+                out["nameservers"].append(parts[1])
+            elif len(parts) >= 2 and parts[0] in ("search", "domain"):
+                out["search"].extend(parts[1:])
+except Exception:
+    pass
+for host in hosts:
+    item = {"host": host, "addrs": [], "ms": 0, "error": ""}
+    t0 = time.time()
+    try:
+        infos = socket.getaddrinfo(host, None, proto=socket.IPPROTO_TCP)
+        item["addrs"] = sorted({i[4][0] for i in infos})
+    except Exception as e:
+        item["error"] = str(e)
+    item["ms"] = int((time.time() - t0) * 1000)
+    out["results"].append(item)
+print(json.dumps(out))
+`
+
+// checkContainerDNS resolves ASTERISK_HOST and the provider hostnames inside
+// ai_engine and compares the answers with this host's resolver.
+func (r *Runner) checkContainerDNS(env *envSummary) Item {
+	asterisk := ""
+	if env != nil {
+		asterisk = strings.TrimSpace(env.AsteriskHost)
+	}
+	hosts := append([]string{}, dnsProviderHosts...)
+	if asterisk != "" && net.ParseIP(asterisk) == nil {
+		hosts = append([]string{asterisk}, hosts...)
+	} else {
+		asterisk = ""
+	}
+	spec, _ := json.Marshal(hosts)
+
+	raw, err := r.dockerExecPython(fmt.Sprintf(dnsProbeScript, string(spec)))
+	if err != nil {
+		return Item{Name: "Container DNS", Status: StatusSkip, Message: "probe unavailable", Details: err.Error()}
+	}
+	var probe dnsProbe
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &probe); err != nil {
+		return Item{Name: "Container DNS", Status: StatusSkip, Message: "invalid probe output", Details: string(raw)}
+	}
+
+	// Against a remote docker host this machine's resolver says nothing about the server's.
+	var hostSide map[string]dnsResult
+	if !dockerHostIsRemote() {
+		hostSide = resolveOnHost(hosts, r.timeout())
+	}
+	return evaluateDNS(&probe, hostSide, asterisk)
+}
+
+func resolveOnHost(hosts []string, timeout time.Duration) map[string]dnsResult {
+	out := make(map[string]dnsResult, len(hosts))
+	for _, h := range hosts {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout/3)
+		t0 := time.Now()
+		addrs, err := net.DefaultResolver.LookupHost(ctx, h)
+		cancel()
+		res := dnsResult{Host: h, Addrs: addrs, MS: int(time.Since(t0).Milliseconds())}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		sort.Strings(res.Addrs)
+		out[h] = res
+	}
+	return out
+}
+
+// evaluateDNS grades the container's answers. ASTERISK_HOST failing to resolve
+// is a failure (ARI cannot connect); answers that share no address with the
+// host's are a warning, since split-horizon DNS or a stale /etc/hosts entry then
+// sends the engine somewhere else. Providers sit behind CDNs, so differing
+// addresses are normal for them and only a container-side failure is flagged.
+// hostSide is nil when the host comparison is not meaningful.
+func evaluateDNS(p *dnsProbe, hostSide map[string]dnsResult, asteriskHost string) Item {
+	var fails, warns []string
+	details := []string{fmt.Sprintf("resolv.conf nameservers=%s search=%s",
+		emptyTo(strings.Join(p.Nameservers, ","), "(none)"), emptyTo(strings.Join(p.Search, ","), "(none)"))}
+
+	for _, c := range p.Results {
+		line := fmt.Sprintf("%s container=%s", c.Host, dnsAnswer(c))
+		h, compared := hostSide[c.Host]
+		if compared {
+			line += " host=" + dnsAnswer(h)
+		}
+		details = append(details, line)
+
+		isAsterisk := c.Host == asteriskHost
+		switch {
+		case c.Error != "" && isAsterisk:
+			fails = append(fails, fmt.Sprintf("ASTERISK_HOST %s does not resolve inside ai_engine", c.Host))
+		case c.Error != "" && compared && h.Error == "":
+			warns = append(warns, fmt.Sprintf("%s resolves on the host but not inside ai_engine", c.Host))
+		case c.Error == "" && isAsterisk && compared && h.Error == "" && !sharesAddr(c.Addrs, h.Addrs):
+			warns = append(warns, fmt.Sprintf("ASTERISK_HOST %s resolves to %s inside ai_engine but %s on the host",
+				c.Host, strings.Join(c.Addrs, ","), strings.Join(h.Addrs, ",")))
+		}
+	}
+
+	if len(fails)+len(warns) == 0 {
+		return Item{Name: "Container DNS", Status: StatusPass, Message: "names resolve inside ai_engine", Details: strings.Join(details, "\n")}
+	}
+	remediation := "Check /etc/hosts and extra_hosts for ASTERISK_HOST, or set it to an IP address."
+	if onlyLoopbackResolvers(p.Nameservers) {
+		remediation = "The container's only resolver is a loopback stub, which a bridge network cannot reach; set dns: in docker-compose.yml (or \"dns\" in /etc/docker/daemon.json) to a reachable server and recreate ai_engine."
+	} else if len(warns) > 0 && len(fails) == 0 {
+		remediation = "Docker's embedded DNS forwards to the host's upstream servers; check firewall rules for outbound DNS from docker networks, or set dns: for ai_engine in docker-compose.yml. " + remediation
+	}
+	all := strings.Join(append(append(fails, warns...), details...), "\n")
+	if len(fails) > 0 {
+		return Item{Name: "Container DNS", Status: StatusFail, Message: "name resolution inside ai_engine is broken", Details: all, Remediation: remediation}
+	}
+	return Item{Name: "Container DNS", Status: StatusWarn, Message: "ai_engine resolves differently from the host", Details: all, Remediation: remediation}
+}
+
+func dnsAnswer(r dnsResult) string {
+	if r.Error != "" {
+		return "error(" + r.Error + ")"
+	}
+	return fmt.Sprintf("%s (%dms)", strings.Join(r.Addrs, ","), r.MS)
+}
+
+func sharesAddr(a, b []string) bool {
+	seen := make(map[string]bool, len(a))
+	for _, x := range a {
+		seen[x] = true
+	}
+	for _, x := range b {
+		if seen[x] {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyLoopbackResolvers reports a resolv.conf copied from a host running a local
+// stub (systemd-resolved at 127.0.0.53), which is unreachable from a bridge network.
+func onlyLoopbackResolvers(ns []string) bool {
+	if len(ns) == 0 {
+		return false
+	}
+	for _, n := range ns {
+		ip := net.ParseIP(n)
+		if ip == nil || !ip.IsLoopback() || n == dockerEmbeddedDNS {
+			return false
+		}
+	}
+	return true
+}
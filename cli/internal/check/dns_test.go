@@ -0,0 +1,55 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateDNS(t *testing.T) {
+	probe := &dnsProbe{Nameservers: []string{"127.0.0.11"}, Results: []dnsResult{
+		{Host: "pbx.lan", Addrs: []string{"10.0.0.5"}},
+		{Host: "api.openai.com", Addrs: []string{"162.159.140.245"}},
+	}}
+	host := map[string]dnsResult{
+		"pbx.lan":        {Host: "pbx.lan", Addrs: []string{"10.0.0.5"}},
+		"api.openai.com": {Host: "api.openai.com", Addrs: []string{"172.66.0.243"}},
+	}
+	if item := evaluateDNS(probe, host, "pbx.lan"); item.Status != StatusPass {
+		t.Fatalf("status = %s: %s", item.Status, item.Details)
+	}
+
+	// The Asterisk host resolving somewhere else inside the container warns.
+	host["pbx.lan"] = dnsResult{Host: "pbx.lan", Addrs: []string{"192.168.1.20"}}
+	item := evaluateDNS(probe, host, "pbx.lan")
+	if item.Status != StatusWarn || !strings.Contains(item.Details, "resolves to 10.0.0.5 inside ai_engine but 192.168.1.20 on the host") {
+		t.Fatalf("mismatch: %s %q", item.Status, item.Details)
+	}
+
+	// Without a host comparison the mismatch cannot be seen.
+	if item := evaluateDNS(probe, nil, "pbx.lan"); item.Status != StatusPass {
+		t.Fatalf("remote: status = %s", item.Status)
+	}
+}
+
+func TestEvaluateDNSContainerFailures(t *testing.T) {
+	probe := &dnsProbe{Nameservers: []string{"127.0.0.53"}, Results: []dnsResult{
+		{Host: "pbx.lan", Error: "[Errno -2] Name or service not known"},
+		{Host: "api.deepgram.com", Error: "[Errno -3] Temporary failure in name resolution"},
+	}}
+	host := map[string]dnsResult{
+		"pbx.lan":          {Host: "pbx.lan", Addrs: []string{"10.0.0.5"}},
+		"api.deepgram.com": {Host: "api.deepgram.com", Addrs: []string{"35.190.1.1"}},
+	}
+	item := evaluateDNS(probe, host, "pbx.lan")
+	if item.Status != StatusFail {
+		t.Fatalf("status = %s", item.Status)
+	}
+	for _, want := range []string{"ASTERISK_HOST pbx.lan does not resolve", "api.deepgram.com resolves on the host but not inside ai_engine"} {
+		if !strings.Contains(item.Details, want) {
+			t.Errorf("details missing %q:\n%s", want, item.Details)
+		}
+	}
+	if !strings.Contains(item.Remediation, "loopback stub") {
+		t.Errorf("remediation = %q", item.Remediation)
+	}
+}
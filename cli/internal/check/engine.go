@@ -0,0 +1,141 @@
+package check
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+)
+
+// engineStreamStallWarn is how long an active stream may go without a chunk
+// from the provider before it counts as stalled. The jitter buffer itself is
+// bounded, so its depth alone says little.
+const engineStreamStallWarn = 5 * time.Second
+
+// ariLogMarkers are the ari_client lines that change the connection state,
+// used when the health server cannot be asked.
+var ariLogMarkers = []struct {
+	text      string
+	connected bool
+}{
+	{"successfully connected to ari websocket", true},
+	{"failed to connect to ari", false},
+	{"disconnected from ari", false},
+}
+
+// checkEngineStatus reports the engine's live state from its health server and
+// falls back to the log tail when the server cannot be reached.
+func (r *Runner) checkEngineStatus() Item {
+	client := engineapi.New()
+	client.Timeout = r.timeout()
+	h, err := client.Health(context.Background())
+	if err != nil {
+		src, logErr := openEngineLogTail()
+		if logErr != nil {
+			return Item{Name: "Engine status", Status: StatusWarn, Message: "health server and logs unavailable", Details: err.Error() + "\n" + logErr.Error()}
+		}
+		defer src.Close()
+		return evaluateEngineLogs(src, err)
+	}
+	// Buffer gauges are extra detail; an engine without /metrics still passes.
+	streaming, _ := client.Streaming(context.Background())
+	return evaluateEngineStatus(h, streaming)
+}
+
+func evaluateEngineStatus(h *engineapi.Health, streaming *engineapi.Streaming) Item {
+	item := Item{Name: "Engine status"}
+	details := []string{
+		fmt.Sprintf("status=%s", emptyTo(h.Status, "unknown")),
+		fmt.Sprintf("ari_connected=%t", h.ARIConnected),
+		fmt.Sprintf("audio_transport=%s active_calls=%d", emptyTo(h.AudioTransport, "unknown"), h.ActiveCalls),
+	}
+	if h.AudioTransport == "audiosocket" {
+		details = append(details, fmt.Sprintf("audiosocket listening=%t connections=%d", h.AudioSocket.Listening, h.AudioSocket.ActiveConnections))
+	}
+
+	var names, notReady []string
+	for name := range h.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := h.Providers[name]
+		if p.Ready {
+			details = append(details, fmt.Sprintf("provider %s: ready", name))
+			continue
+		}
+		notReady = append(notReady, name)
+		details = append(details, fmt.Sprintf("provider %s: not ready (%s)", name, emptyTo(p.Reason, "no reason given")))
+	}
+
+	stalled := false
+	if streaming != nil && streaming.ActiveStreams > 0 {
+		details = append(details, fmt.Sprintf("streaming: %d active, jitter buffer depth %.0f, last chunk %.1fs ago",
+			streaming.ActiveStreams, streaming.JitterBufferDepth, streaming.LastChunkAgeSeconds))
+		stalled = streaming.LastChunkAgeSeconds >= engineStreamStallWarn.Seconds()
+	}
+	if h.UptimeSeconds > 0 {
+		details = append(details, "uptime="+(time.Duration(h.UptimeSeconds)*time.Second).String())
+	}
+	for _, w := range h.ConfigWarnings {
+		details = append(details, "config warning: "+w)
+	}
+	item.Details = strings.Join(details, "\n")
+
+	switch {
+	case !h.ARIConnected:
+		item.Status, item.Message = StatusFail, "ai_engine is not connected to ARI"
+		item.Remediation = "Check the ARI checks above and docker logs ai_engine for the connection error"
+	case h.Status != "healthy":
+		item.Status, item.Message = StatusFail, "ai_engine is degraded: the default provider or audio transport is not ready"
+		item.Remediation = "Check provider API keys and audio transport settings; see the details for the provider that is not ready"
+	case len(notReady) > 0:
+		item.Status = StatusWarn
+		item.Message = fmt.Sprintf("ready; provider(s) not ready: %s", strings.Join(notReady, ", "))
+		item.Remediation = "Contexts that use these providers will fail; check their API keys and configuration"
+	case stalled:
+		item.Status = StatusWarn
+		item.Message = fmt.Sprintf("ready; a stream has had no provider audio for %.1fs", streaming.LastChunkAgeSeconds)
+		item.Remediation = "The provider stopped sending audio mid-response; check the call with agent rca"
+	default:
+		item.Status = StatusPass
+		item.Message = fmt.Sprintf("ready, %d active call(s)", h.ActiveCalls)
+	}
+	return item
+}
+
+// evaluateEngineLogs derives the ARI connection state from the last marker in
+// the log tail. It always warns: the health server should answer when the
+// container runs.
+func evaluateEngineLogs(logs io.Reader, apiErr error) Item {
+	item := Item{
+		Name:        "Engine status",
+		Status:      StatusWarn,
+		Remediation: "Check that ai_engine finished starting and that HEALTH_BIND_PORT matches the engine's health port",
+	}
+	state := ""
+	sc := bufio.NewScanner(logs)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		l := strings.ToLower(sc.Text())
+		for _, m := range ariLogMarkers {
+			if strings.Contains(l, m.text) {
+				state = map[bool]string{true: "connected", false: "not connected"}[m.connected]
+			}
+		}
+	}
+	details := []string{"health server: " + apiErr.Error()}
+	if state == "" {
+		item.Message = "health server unavailable; no ARI connection lines in recent logs"
+	} else {
+		item.Message = "health server unavailable; logs say ARI is " + state
+		details = append(details, "from logs (may lag): ari "+state)
+	}
+	item.Details = strings.Join(details, "\n")
+	return item
+}
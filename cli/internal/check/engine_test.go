@@ -0,0 +1,62 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+)
+
+func TestEvaluateEngineStatus(t *testing.T) {
+	h := &engineapi.Health{
+		Status:         "healthy",
+		ARIConnected:   true,
+		AudioTransport: "audiosocket",
+		ActiveCalls:    2,
+		Providers:      map[string]engineapi.Provider{"deepgram": {Ready: true}},
+	}
+	if item := evaluateEngineStatus(h, nil); item.Status != StatusPass || item.Message != "ready, 2 active call(s)" {
+		t.Fatalf("healthy: %s %q", item.Status, item.Message)
+	}
+
+	h.Providers["openai_realtime"] = engineapi.Provider{Ready: false, Reason: "missing_config"}
+	item := evaluateEngineStatus(h, nil)
+	if item.Status != StatusWarn || !strings.Contains(item.Message, "openai_realtime") ||
+		!strings.Contains(item.Details, "provider openai_realtime: not ready (missing_config)") {
+		t.Fatalf("provider not ready: %s %q %q", item.Status, item.Message, item.Details)
+	}
+	delete(h.Providers, "openai_realtime")
+
+	stalled := &engineapi.Streaming{ActiveStreams: 1, JitterBufferDepth: 3, LastChunkAgeSeconds: 7.5}
+	if item := evaluateEngineStatus(h, stalled); item.Status != StatusWarn || !strings.Contains(item.Message, "7.5s") {
+		t.Fatalf("stalled stream: %s %q", item.Status, item.Message)
+	}
+
+	h.Status = "degraded"
+	if item := evaluateEngineStatus(h, nil); item.Status != StatusFail {
+		t.Fatalf("degraded: %s", item.Status)
+	}
+	h.ARIConnected = false
+	if item := evaluateEngineStatus(h, nil); item.Status != StatusFail || !strings.Contains(item.Message, "ARI") {
+		t.Fatalf("no ARI: %s %q", item.Status, item.Message)
+	}
+}
+
+func TestEvaluateEngineLogsUsesLastARIMarker(t *testing.T) {
+	apiErr := fmt.Errorf("%w: connection refused", engineapi.ErrUnavailable)
+	logs := strings.Join([]string{
+		`{"event":"Successfully connected to ARI WebSocket.","level":"info"}`,
+		`{"event":"Disconnected from ARI.","level":"info"}`,
+	}, "\n")
+	item := evaluateEngineLogs(strings.NewReader(logs), apiErr)
+	if item.Status != StatusWarn || !strings.HasSuffix(item.Message, "ARI is not connected") || !strings.Contains(item.Details, "connection refused") {
+		t.Fatalf("disconnected: %s %q %q", item.Status, item.Message, item.Details)
+	}
+
+	item = evaluateEngineLogs(strings.NewReader("2026-01-30 [info] Pipeline runner started"), errors.New("x"))
+	if !strings.Contains(item.Message, "no ARI connection lines") {
+		t.Fatalf("no markers: %q", item.Message)
+	}
+}
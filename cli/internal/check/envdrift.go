@@ -0,0 +1,128 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+	"gopkg.in/yaml.v3"
+)
+
+// checkEnvDrift compares .env with the environment ai_engine was created with.
+// docker compose reads env_file only when it creates a container, so an edited
+// .env followed by a plain restart leaves the engine on the old values.
+func (r *Runner) checkEnvDrift(ci *containerInspect) Item {
+	const name = "Env Drift"
+	if dockerHostIsRemote() {
+		return Item{Name: name, Status: StatusSkip, Message: "skipped (remote docker host; .env lives on the server)"}
+	}
+	if kube.Enabled() {
+		return Item{Name: name, Status: StatusSkip, Message: "skipped (Kubernetes; the pod environment comes from its manifest and secrets)"}
+	}
+	composePath, err := findComposeFile()
+	if err != nil {
+		return Item{Name: name, Status: StatusSkip, Message: "docker-compose.yml not found", Remediation: "Run agent check from the project root."}
+	}
+	envPath := filepath.Join(filepath.Dir(composePath), ".env")
+	raw, err := os.ReadFile(envPath)
+	if err != nil {
+		return Item{Name: name, Status: StatusSkip, Message: ".env not found", Details: err.Error()}
+	}
+
+	// Keys set in compose environment: legitimately differ from .env.
+	var cf composeFile
+	var svcEnv interface{}
+	if data, err := os.ReadFile(composePath); err == nil && yaml.Unmarshal(data, &cf) == nil {
+		svcEnv = cf.Services["ai_engine"].Environment
+	}
+	changed, missing := envDrift(parseDotEnv(raw), ci.Config.Env, func(key string) bool { return composeEnvHas(svcEnv, key) })
+	if len(changed)+len(missing) == 0 {
+		return Item{Name: name, Status: StatusPass, Message: "container environment matches .env"}
+	}
+
+	var details []string
+	for _, k := range changed {
+		details = append(details, k+": differs")
+	}
+	for _, k := range missing {
+		details = append(details, k+": not set in container")
+	}
+	msg := fmt.Sprintf("%d .env value(s) differ from the running container", len(changed)+len(missing))
+	if info, err := os.Stat(envPath); err == nil && !ci.State.StartedAt.IsZero() && info.ModTime().After(ci.State.StartedAt) {
+		msg = fmt.Sprintf("ai_engine is stale: .env changed after the container started (%d value(s) differ)", len(changed)+len(missing))
+		details = append(details,
+			"container_started="+ci.State.StartedAt.Local().Format(time.RFC3339),
+			"env_modified="+info.ModTime().Format(time.RFC3339))
+	}
+	return Item{
+		Name:        name,
+		Status:      StatusWarn,
+		Message:     msg,
+		Details:     strings.Join(details, "\n"),
+		Remediation: "Recreate the container to load .env: docker compose up -d --force-recreate ai_engine (docker compose restart keeps the old environment)",
+	}
+}
+
+// envDrift returns the .env keys whose value differs in the container and the
+// keys the container lacks. Keys for which ignore is true, and values that use
+// ${VAR} interpolation, are not compared. Only key names are returned.
+func envDrift(dotenv map[string]string, containerEnv []string, ignore func(key string) bool) (changed, missing []string) {
+	live := make(map[string]string, len(containerEnv))
+	for _, kv := range containerEnv {
+		k, v, _ := strings.Cut(kv, "=")
+		live[k] = v
+	}
+	for k, want := range dotenv {
+		if ignore(k) || strings.Contains(want, "${") {
+			continue
+		}
+		got, ok := live[k]
+		switch {
+		case !ok:
+			missing = append(missing, k)
+		case got != want:
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(missing)
+	return changed, missing
+}
+
+// parseDotEnv reads KEY=VALUE lines the way docker compose env_file does:
+// optional "export", surrounding quotes removed, and " #" comments stripped from
+// unquoted values. A later assignment overrides an earlier one.
+func parseDotEnv(data []byte) map[string]string {
+	out := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0]:
+			value = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		out[key] = value
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	got := parseDotEnv([]byte(`# comment
+OPENAI_API_KEY=sk-1
+export TZ=Europe/Berlin
+QUOTED="a # not a comment"
+SINGLE='x'
+INLINE=value # comment
+EMPTY=
+OPENAI_API_KEY=sk-2
+not a pair
+`))
+	want := map[string]string{
+		"OPENAI_API_KEY": "sk-2",
+		"TZ":             "Europe/Berlin",
+		"QUOTED":         "a # not a comment",
+		"SINGLE":         "x",
+		"INLINE":         "value",
+		"EMPTY":          "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseDotEnv = %#v", got)
+	}
+}
+
+func TestEnvDrift(t *testing.T) {
+	dotenv := map[string]string{
+		"OPENAI_API_KEY": "new",
+		"ASTERISK_HOST":  "10.0.0.5",
+		"DEEPGRAM_KEY":   "dg",
+		"PYTHONPATH":     "/other",
+		"DERIVED":        "${ASTERISK_HOST}:8088",
+	}
+	container := []string{"OPENAI_API_KEY=old", "ASTERISK_HOST=10.0.0.5", "PYTHONPATH=/app", "PATH=/usr/bin"}
+	changed, missing := envDrift(dotenv, container, func(k string) bool { return k == "PYTHONPATH" })
+	if !reflect.DeepEqual(changed, []string{"OPENAI_API_KEY"}) || !reflect.DeepEqual(missing, []string{"DEEPGRAM_KEY"}) {
+		t.Fatalf("envDrift = %v, %v", changed, missing)
+	}
+}
@@ -0,0 +1,523 @@
+package check
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hostinfo"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/privilege"
+)
+
+// fwVerdict is the outcome of evaluating one firewall backend against the RTP range.
+type fwVerdict int
+
+const (
+	fwNoOpinion fwVerdict = iota // backend inactive or no rule touches the range
+	fwAllowed
+	fwPartial // only part of the range is allowed
+	fwBlocked
+)
+
+// checkRTPFirewall inspects host firewall rules (ufw, iptables, nftables) and docker-proxy
+// mappings for the ExternalMedia UDP range. One-way audio is almost always the RTP return
+// path being dropped or rewritten somewhere between Asterisk and ai_engine.
+func (r *Runner) checkRTPFirewall(cfg *configSummary, ci *containerInspect) Item {
+	const name = "RTP Firewall/NAT"
+	if cfg == nil {
+		return Item{Name: name, Status: StatusSkip, Message: "config unavailable"}
+	}
+	if strings.ToLower(strings.TrimSpace(cfg.AudioTransport)) != "externalmedia" {
+		return Item{Name: name, Status: StatusSkip, Message: "ExternalMedia not in use"}
+	}
+	start, end, ok := rtpPortRange(cfg.ExternalMedia.PortRange, cfg.ExternalMedia.RTPPort)
+	if !ok {
+		return Item{Name: name, Status: StatusSkip, Message: "no ExternalMedia RTP port configured"}
+	}
+	if dockerHostIsRemote() {
+		return Item{
+			Name:    name,
+			Status:  StatusSkip,
+			Message: "DOCKER_HOST is remote; firewall rules must be checked on that host",
+			Details: fmt.Sprintf("rtp_range=udp/%d-%d", start, end),
+		}
+	}
+
+	details := []string{fmt.Sprintf("rtp_range=udp/%d-%d", start, end)}
+	// Listing rules needs root, or the sudoers entry from agent privileges.
+	const unreadable = "unreadable (needs root; agent privileges --sudo-helper)"
+	var problems []string
+	var remediation []string
+
+	// ufw (front-end for iptables/nftables on Ubuntu/Debian).
+	if out, err := privilege.Command("ufw", "status").CombinedOutput(); err == nil {
+		v, active := ufwVerdict(string(out), start, end)
+		switch {
+		case !active:
+			details = append(details, "ufw=inactive")
+		case v == fwAllowed:
+			details = append(details, "ufw=active (range allowed)")
+		case v == fwPartial:
+			details = append(details, "ufw=active (range partially allowed)")
+			problems = append(problems, "ufw allows only part of the RTP range")
+			remediation = append(remediation, fmt.Sprintf("sudo ufw allow %d:%d/udp", start, end))
+		default:
+			details = append(details, "ufw=active (no rule for range)")
+			problems = append(problems, "ufw is active and has no allow rule for the RTP range")
+			remediation = append(remediation, fmt.Sprintf("sudo ufw allow %d:%d/udp", start, end))
+		}
+	} else if notPermitted(out) {
+		details = append(details, "ufw="+unreadable)
+	}
+
+	// iptables (legacy or iptables-nft shim).
+	if out, err := privilege.Command("iptables", "-S").CombinedOutput(); err == nil {
+		v, policyDrop := iptablesVerdict(string(out), start, end)
+		details = append(details, fmt.Sprintf("iptables_input=%s (policy_drop=%t)", verdictString(v), policyDrop))
+		switch {
+		case v == fwBlocked || (v == fwNoOpinion && policyDrop):
+			problems = append(problems, "iptables INPUT drops the RTP range")
+			remediation = append(remediation, fmt.Sprintf("sudo iptables -I INPUT -p udp --dport %d:%d -j ACCEPT", start, end))
+		case v == fwPartial:
+			problems = append(problems, "iptables INPUT allows only part of the RTP range")
+			remediation = append(remediation, fmt.Sprintf("sudo iptables -I INPUT -p udp --dport %d:%d -j ACCEPT", start, end))
+		}
+	} else if notPermitted(out) {
+		details = append(details, "iptables="+unreadable)
+	}
+
+	// nftables.
+	if out, err := privilege.Command("nft", "list", "ruleset").CombinedOutput(); err == nil {
+		v, policyDrop := nftVerdict(string(out), start, end)
+		details = append(details, fmt.Sprintf("nft_input=%s (policy_drop=%t)", verdictString(v), policyDrop))
+		switch {
+		case v == fwBlocked || (v == fwNoOpinion && policyDrop):
+			problems = append(problems, "nftables input hook drops the RTP range")
+			remediation = append(remediation, fmt.Sprintf("nft add rule inet filter input udp dport %d-%d accept", start, end))
+		case v == fwPartial:
+			problems = append(problems, "nftables input hook allows only part of the RTP range")
+			remediation = append(remediation, fmt.Sprintf("nft add rule inet filter input udp dport %d-%d accept", start, end))
+		}
+	} else if notPermitted(out) {
+		details = append(details, "nft="+unreadable)
+	}
+
+	// Bridge networking: RTP must be published, and docker-proxy rewrites the source address.
+	if ci != nil && strings.ToLower(strings.TrimSpace(ci.HostConfig.NetworkMode)) != "host" {
+		published := 0
+		for p := start; p <= end; p++ {
+			if len(ci.NetworkSettings.Ports[fmt.Sprintf("%d/udp", p)]) > 0 {
+				published++
+			}
+		}
+		details = append(details, fmt.Sprintf("published_udp=%d/%d", published, end-start+1))
+		switch {
+		case published == 0:
+			problems = append(problems, "ai_engine uses bridge networking but the RTP range is not published")
+			remediation = append(remediation, fmt.Sprintf("Publish \"%d-%d:%d-%d/udp\" or use network_mode: host.", start, end, start, end))
+		case published < end-start+1:
+			problems = append(problems, "only part of the RTP range is published from ai_engine")
+			remediation = append(remediation, fmt.Sprintf("Publish the full range \"%d-%d:%d-%d/udp\".", start, end, start, end))
+		}
+
+		if proxied := dockerProxyUDPPorts(start, end); proxied > 0 {
+			details = append(details, fmt.Sprintf("docker_proxy_udp=%d", proxied))
+			problems = append(problems, "docker-proxy relays RTP, so ai_engine sees the bridge gateway as the source (asymmetric path)")
+			remediation = append(remediation, "Use network_mode: host, or disable the userland proxy (\"userland-proxy\": false in daemon.json) and include the bridge gateway in external_media.allowed_remote_hosts.")
+		}
+	}
+
+	if len(problems) == 0 {
+		return Item{Name: name, Status: StatusPass, Message: "no blocking rules found for RTP range", Details: strings.Join(details, "\n")}
+	}
+	return Item{
+		Name:        name,
+		Status:      StatusWarn,
+		Message:     "RTP return path may be blocked or asymmetric (one-way audio risk)",
+		Details:     strings.Join(append(problems, details...), "\n"),
+		Remediation: strings.Join(remediation, "\n"),
+	}
+}
+
+func rtpPortRange(portRange string, rtpPort int) (int, int, bool) {
+	if start, end, ok := parsePortSpec(portRange); ok {
+		return start, end, true
+	}
+	if rtpPort > 0 && rtpPort <= 65535 {
+		return rtpPort, rtpPort, true
+	}
+	return 0, 0, false
+}
+
+// parsePortSpec parses "18080", "18080:18099" or "18080-18099".
+func parsePortSpec(spec string) (int, int, bool) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 0, 0, false
+	}
+	sep := ":"
+	if !strings.Contains(spec, sep) {
+		sep = "-"
+	}
+	parts := strings.SplitN(spec, sep, 2)
+	start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || start < 1 || start > 65535 {
+		return 0, 0, false
+	}
+	end := start
+	if len(parts) == 2 {
+		end, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || end < start || end > 65535 {
+			return 0, 0, false
+		}
+	}
+	return start, end, true
+}
+
+// specCoverage reports how a comma-separated port list covers [start,end].
+func specCoverage(specs string, start, end int) fwVerdict {
+	covered := 0
+	for _, spec := range strings.Split(specs, ",") {
+		s, e, ok := parsePortSpec(spec)
+		if !ok {
+			continue
+		}
+		lo, hi := maxInt(s, start), minInt(e, end)
+		if hi >= lo {
+			covered += hi - lo + 1
+		}
+	}
+	switch {
+	case covered == 0:
+		return fwNoOpinion
+	case covered >= end-start+1:
+		return fwAllowed
+	default:
+		return fwPartial
+	}
+}
+
+// ufwVerdict evaluates `ufw status` output.
+func ufwVerdict(out string, start, end int) (fwVerdict, bool) {
+	active := false
+	best := fwNoOpinion
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Status:") {
+			active = strings.Contains(line, "active") && !strings.Contains(line, "inactive")
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "ALLOW") {
+			continue
+		}
+		target := strings.TrimSuffix(fields[0], "(v6)")
+		if target == "Anywhere" {
+			return fwAllowed, active
+		}
+		ports, proto, _ := strings.Cut(target, "/")
+		if proto != "" && proto != "udp" {
+			continue
+		}
+		if v := specCoverage(ports, start, end); v > best {
+			best = v
+		}
+	}
+	return best, active
+}
+
+// iptablesVerdict evaluates `iptables -S` output from the INPUT chain in rule order,
+// following jumps and gotos into user chains such as ufw's. The first UDP rule that
+// touches the range decides; otherwise the INPUT policy applies.
+func iptablesVerdict(out string, start, end int) (fwVerdict, bool) {
+	chains := map[string][][]string{}
+	policyDrop := false
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "-P":
+			if fields[1] == "INPUT" && len(fields) >= 3 {
+				policyDrop = fields[2] == "DROP" || fields[2] == "REJECT"
+			}
+		case "-N":
+			if _, ok := chains[fields[1]]; !ok {
+				chains[fields[1]] = nil
+			}
+		case "-A":
+			chains[fields[1]] = append(chains[fields[1]], fields)
+		}
+	}
+	v, _ := iptablesChainVerdict(chains, "INPUT", start, end, map[string]bool{})
+	return v, policyDrop
+}
+
+// iptablesChainVerdict evaluates one chain. decided is false when the packet falls
+// off the end of the chain or hits RETURN, so evaluation resumes in the caller.
+func iptablesChainVerdict(chains map[string][][]string, chain string, start, end int, visited map[string]bool) (v fwVerdict, decided bool) {
+	if visited[chain] {
+		return fwNoOpinion, false
+	}
+	visited[chain] = true
+	defer delete(visited, chain)
+
+	for _, fields := range chains[chain] {
+		opt := iptablesOpts(fields)
+		if opt["-i"] == "lo" || opt["-s"] != "" && strings.HasPrefix(opt["-s"], "127.") {
+			continue
+		}
+		target, jump := opt["-j"], true
+		if opt["-g"] != "" {
+			target, jump = opt["-g"], false
+		}
+		proto := opt["-p"]
+		ports := opt["--dport"]
+		if ports == "" {
+			ports = opt["--dports"]
+		}
+		if _, user := chains[target]; user {
+			// A jump applies to the range when it matches all UDP traffic to it.
+			if !iptablesUnconditional(opt) || proto != "" && proto != "udp" ||
+				ports != "" && specCoverage(ports, start, end) == fwNoOpinion {
+				continue
+			}
+			if v, decided := iptablesChainVerdict(chains, target, start, end, visited); decided {
+				return v, true
+			}
+			if !jump {
+				// The end of a goto target returns past this chain.
+				return fwNoOpinion, false
+			}
+			continue
+		}
+		if proto == "" && ports == "" && opt["-m"] == "" {
+			switch target {
+			case "ACCEPT":
+				return fwAllowed, true
+			case "RETURN":
+				return fwNoOpinion, false
+			}
+		}
+		if proto != "udp" || ports == "" {
+			continue
+		}
+		v := specCoverage(ports, start, end)
+		if v == fwNoOpinion {
+			continue
+		}
+		switch target {
+		case "ACCEPT":
+			return v, true
+		case "DROP", "REJECT":
+			return fwBlocked, true
+		}
+	}
+	return fwNoOpinion, false
+}
+
+// iptablesUnconditional reports whether a rule matches on nothing but protocol and
+// destination port, so every packet to the RTP range takes it.
+func iptablesUnconditional(opt map[string]string) bool {
+	for k, v := range opt {
+		switch k {
+		case "-A", "-j", "-g", "-p", "--dport", "--dports", "--comment":
+		case "-m":
+			if v != "udp" && v != "multiport" && v != "comment" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func iptablesOpts(fields []string) map[string]string {
+	opt := map[string]string{}
+	for i := 0; i < len(fields); i++ {
+		if !strings.HasPrefix(fields[i], "-") || i+1 >= len(fields) {
+			continue
+		}
+		if _, seen := opt[fields[i]]; !seen {
+			opt[fields[i]] = fields[i+1]
+		}
+		i++
+	}
+	return opt
+}
+
+// nftVerdict evaluates `nft list ruleset` output for chains hooked on input, following
+// jump and goto into the other chains of the same table.
+func nftVerdict(out string, start, end int) (fwVerdict, bool) {
+	chains := map[string][]string{}
+	var hooked []string
+	policyDrop := false
+	table, chain := "", ""
+	for _, raw := range strings.Split(out, "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case strings.HasPrefix(line, "table "):
+			table = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(line, "table ")), "{")
+			chain = ""
+		case strings.HasPrefix(line, "chain "):
+			chain = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "chain "), "{"))
+			chains[nftChainKey(table, chain)] = nil
+		case chain == "" || line == "" || line == "}":
+		case strings.Contains(line, "hook input"):
+			hooked = append(hooked, nftChainKey(table, chain))
+			if strings.Contains(line, "policy drop") {
+				policyDrop = true
+			}
+		default:
+			key := nftChainKey(table, chain)
+			chains[key] = append(chains[key], line)
+		}
+	}
+	for _, key := range hooked {
+		if v, decided := nftChainVerdict(chains, key, start, end, map[string]bool{}); decided {
+			return v, policyDrop
+		}
+	}
+	return fwNoOpinion, policyDrop
+}
+
+func nftChainKey(table, chain string) string {
+	return strings.TrimSpace(table) + "/" + chain
+}
+
+// nftChainVerdict evaluates one chain, like iptablesChainVerdict.
+func nftChainVerdict(chains map[string][]string, key string, start, end int, visited map[string]bool) (fwVerdict, bool) {
+	if visited[key] {
+		return fwNoOpinion, false
+	}
+	visited[key] = true
+	defer delete(visited, key)
+
+	table, _, _ := strings.Cut(key, "/")
+	for _, line := range chains[key] {
+		fields := strings.Fields(line)
+		if n := len(fields); n >= 2 && (fields[n-2] == "jump" || fields[n-2] == "goto") {
+			target := nftChainKey(table, fields[n-1])
+			if _, ok := chains[target]; !ok {
+				continue
+			}
+			matches := nftMatches(line, fields[n-2])
+			if matches != "" && !strings.Contains(matches, "udp dport") ||
+				matches != "" && specCoverage(nftDportSpec(matches), start, end) == fwNoOpinion {
+				continue
+			}
+			if v, decided := nftChainVerdict(chains, target, start, end, visited); decided {
+				return v, true
+			}
+			if fields[n-2] == "goto" {
+				return fwNoOpinion, false
+			}
+			continue
+		}
+		if line == "return" || strings.HasSuffix(line, " return") && nftMatches(line, "return") == "" {
+			return fwNoOpinion, false
+		}
+		if !strings.Contains(line, "udp dport") {
+			continue
+		}
+		v := specCoverage(nftDportSpec(line), start, end)
+		if v == fwNoOpinion {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(line, "accept"):
+			return v, true
+		case strings.HasSuffix(line, "drop"), strings.HasSuffix(line, "reject"):
+			return fwBlocked, true
+		}
+	}
+	return fwNoOpinion, false
+}
+
+// nftMatches is a rule's match expressions: the text before its verdict, without
+// counters and comments.
+func nftMatches(line, verdict string) string {
+	if i := strings.LastIndex(line, verdict); i >= 0 {
+		line = line[:i]
+	}
+	if i := strings.Index(line, "comment \""); i >= 0 {
+		line = line[:i]
+	}
+	var keep []string
+	fields := strings.Fields(line)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "counter":
+			continue
+		case "packets", "bytes":
+			i++
+			continue
+		}
+		keep = append(keep, fields[i])
+	}
+	return strings.Join(keep, " ")
+}
+
+// nftDportSpec extracts the port list after "udp dport" as "a-b,c".
+func nftDportSpec(line string) string {
+	spec := strings.TrimSpace(strings.SplitN(line, "udp dport", 2)[1])
+	spec = strings.TrimPrefix(spec, "{")
+	if i := strings.Index(spec, "}"); i >= 0 {
+		spec = spec[:i]
+	} else if f := strings.Fields(spec); len(f) > 0 {
+		spec = f[0]
+	}
+	return strings.ReplaceAll(spec, " ", "")
+}
+
+// dockerProxyUDPPorts counts docker-proxy processes relaying UDP ports inside [start,end].
+func dockerProxyUDPPorts(start, end int) int {
+	lines, err := hostinfo.Local().Processes("docker-proxy")
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, line := range lines {
+		opt := iptablesOpts(strings.Fields(line))
+		if opt["-proto"] != "udp" {
+			continue
+		}
+		if p, err := strconv.Atoi(opt["-host-port"]); err == nil && p >= start && p <= end {
+			count++
+		}
+	}
+	return count
+}
+
+func notPermitted(out []byte) bool {
+	s := strings.ToLower(string(out))
+	return strings.Contains(s, "permission denied") || strings.Contains(s, "operation not permitted") || strings.Contains(s, "you need to be root")
+}
+
+func verdictString(v fwVerdict) string {
+	switch v {
+	case fwAllowed:
+		return "allowed"
+	case fwPartial:
+		return "partial"
+	case fwBlocked:
+		return "blocked"
+	}
+	return "no-rule"
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,129 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIptablesVerdictPolicyDropWithoutRule(t *testing.T) {
+	t.Parallel()
+
+	rules := "-P INPUT DROP\n-A INPUT -i lo -j ACCEPT\n-A INPUT -p tcp -m tcp --dport 22 -j ACCEPT\n"
+	v, policyDrop := iptablesVerdict(rules, 18080, 18099)
+	if v != fwNoOpinion || !policyDrop {
+		t.Fatalf("expected no rule + policy drop, got %v %t", v, policyDrop)
+	}
+
+	rules += "-A INPUT -p udp -m multiport --dports 10000:20000 -j ACCEPT\n"
+	if v, _ := iptablesVerdict(rules, 18080, 18099); v != fwAllowed {
+		t.Fatalf("expected multiport rule to allow range, got %v", v)
+	}
+}
+
+func TestIptablesVerdictPartialRange(t *testing.T) {
+	t.Parallel()
+
+	rules := "-P INPUT DROP\n-A INPUT -p udp -m udp --dport 18080:18089 -j ACCEPT\n"
+	if v, _ := iptablesVerdict(rules, 18080, 18099); v != fwPartial {
+		t.Fatalf("expected partial, got %v", v)
+	}
+}
+
+func TestUfwVerdict(t *testing.T) {
+	t.Parallel()
+
+	out := "Status: active\n\nTo                         Action      From\n--                         ------      ----\n22/tcp                     ALLOW       Anywhere\n18080:18099/udp            ALLOW       Anywhere\n"
+	v, active := ufwVerdict(out, 18080, 18099)
+	if !active || v != fwAllowed {
+		t.Fatalf("expected active+allowed, got %v %t", v, active)
+	}
+	if _, active := ufwVerdict("Status: inactive\n", 18080, 18099); active {
+		t.Fatalf("expected inactive")
+	}
+}
+
+func TestNftVerdict(t *testing.T) {
+	t.Parallel()
+
+	ruleset := `table inet filter {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ct state established,related accept
+		udp dport { 18080-18099 } accept
+	}
+}`
+	v, policyDrop := nftVerdict(ruleset, 18080, 18099)
+	if v != fwAllowed || !policyDrop {
+		t.Fatalf("expected allowed under drop policy, got %v %t", v, policyDrop)
+	}
+}
+
+func TestIptablesVerdictFollowsUfwChains(t *testing.T) {
+	t.Parallel()
+
+	rules := `-P INPUT DROP
+-N ufw-before-input
+-N ufw-user-input
+-N ufw-after-input
+-N ufw-skip-to-policy-input
+-A INPUT -j ufw-before-input
+-A INPUT -j ufw-after-input
+-A ufw-before-input -i lo -j ACCEPT
+-A ufw-before-input -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A ufw-before-input -j ufw-user-input
+-A ufw-user-input -p tcp -m tcp --dport 22 -j ACCEPT
+-A ufw-after-input -p udp -m udp --dport 137 -j ufw-skip-to-policy-input
+-A ufw-skip-to-policy-input -j DROP
+`
+	v, policyDrop := iptablesVerdict(rules, 18080, 18099)
+	if v != fwNoOpinion || !policyDrop {
+		t.Fatalf("ufw without an allow rule: got %v %t", v, policyDrop)
+	}
+
+	allowed := rules + "-A ufw-user-input -p udp -m multiport --dports 18080:18099 -j ACCEPT\n"
+	if v, _ := iptablesVerdict(allowed, 18080, 18099); v != fwAllowed {
+		t.Fatalf("ufw-user-input allow: got %v", v)
+	}
+
+	// A goto whose target falls through skips the rest of the chain that took it.
+	gone := "-P INPUT DROP\n-N tail\n-A INPUT -g tail\n-A INPUT -p udp -m udp --dport 18080:18099 -j ACCEPT\n-A tail -p tcp -j ACCEPT\n"
+	if v, _ := iptablesVerdict(gone, 18080, 18099); v != fwNoOpinion {
+		t.Fatalf("goto fall-through: got %v", v)
+	}
+
+	// Chains that jump into each other do not loop.
+	loop := "-P INPUT ACCEPT\n-N a\n-N b\n-A INPUT -j a\n-A a -j b\n-A b -j a\n-A b -p udp --dport 18080:18099 -j DROP\n"
+	if v, _ := iptablesVerdict(loop, 18080, 18099); v != fwBlocked {
+		t.Fatalf("jump loop: got %v", v)
+	}
+}
+
+func TestNftVerdictFollowsJumpAndGoto(t *testing.T) {
+	t.Parallel()
+
+	ruleset := `table ip filter {
+	chain INPUT {
+		type filter hook input priority filter; policy drop;
+		counter packets 10 bytes 900 jump ufw-before-input
+	}
+	chain ufw-before-input {
+		iifname "lo" counter packets 0 bytes 0 accept
+		ct state related,established counter packets 5 bytes 400 accept
+		counter packets 3 bytes 200 goto ufw-user-input
+		udp dport 18080-18099 counter packets 0 bytes 0 drop
+	}
+	chain ufw-user-input {
+		meta l4proto udp udp dport 18080-18099 counter packets 0 bytes 0 accept
+	}
+}`
+	v, policyDrop := nftVerdict(ruleset, 18080, 18099)
+	if v != fwAllowed || !policyDrop {
+		t.Fatalf("allow behind jump and goto: got %v %t", v, policyDrop)
+	}
+
+	// Without the allow, the goto target falls through past the drop rule to the policy.
+	ruleset = strings.Replace(ruleset, "meta l4proto udp udp dport 18080-18099 counter packets 0 bytes 0 accept", "tcp dport 22 accept", 1)
+	if v, _ := nftVerdict(ruleset, 18080, 18099); v != fwNoOpinion {
+		t.Fatalf("goto fall-through: got %v", v)
+	}
+}
@@ -0,0 +1,69 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+)
+
+// checkFreePBX looks for agent configuration in files FreePBX regenerates, where it
+// is lost on the next Apply Config.
+func (r *Runner) checkFreePBX(cfg *configSummary) Item {
+	const name = "FreePBX"
+	source, ok := asterisk.DetectFreePBX()
+	if !ok {
+		return Item{Name: name, Status: StatusSkip, Message: "FreePBX not detected"}
+	}
+	app := "asterisk-ai-voice-agent"
+	if cfg != nil && strings.TrimSpace(cfg.AppName) != "" {
+		app = strings.TrimSpace(cfg.AppName)
+	}
+
+	files := map[string]string{}
+	for _, f := range append(append([]string{}, asterisk.FreePBXManagedFiles...), "extensions_custom.conf") {
+		if text, err := asterisk.ReadFile(path.Join(asterisk.ConfigDir, f)); err == nil {
+			files[f] = text
+		}
+	}
+	misplaced, custom := freePBXFindings(files, app)
+
+	details := []string{"detected_from=" + source}
+	if custom {
+		details = append(details, fmt.Sprintf("extensions_custom.conf enters Stasis(%s)", app))
+	}
+	if len(misplaced) == 0 {
+		return Item{Name: name, Status: StatusPass, Message: "no agent config in GUI-managed files", Details: strings.Join(details, "\n")}
+	}
+	return Item{
+		Name:        name,
+		Status:      StatusWarn,
+		Message:     fmt.Sprintf("agent config in %d GUI-managed file(s)", len(misplaced)),
+		Details:     strings.Join(append(misplaced, details...), "\n"),
+		Remediation: "Move it to the matching *_custom.conf (Admin → Config Edit); FreePBX rewrites these files on Apply Config. Generate the dialplan with: agent dialplan --freepbx",
+	}
+}
+
+// freePBXFindings lists managed files holding agent contexts, Stasis(app) steps or
+// blocks written by this CLI, and whether extensions_custom.conf enters Stasis(app).
+func freePBXFindings(files map[string]string, app string) ([]string, bool) {
+	var misplaced []string
+	for f, text := range files {
+		if !asterisk.IsFreePBXManaged(f) {
+			continue
+		}
+		var found []string
+		for _, marker := range []string{"Stasis(" + app, "[from-ai-agent", "; BEGIN aava"} {
+			if strings.Contains(text, marker) {
+				found = append(found, marker)
+			}
+		}
+		if len(found) > 0 {
+			misplaced = append(misplaced, fmt.Sprintf("%s: %s (use %s)", f, strings.Join(found, ", "), asterisk.FreePBXCustomFile(f)))
+		}
+	}
+	sort.Strings(misplaced)
+	return misplaced, strings.Contains(files["extensions_custom.conf"], "Stasis("+app)
+}
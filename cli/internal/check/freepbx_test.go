@@ -0,0 +1,23 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFreePBXFindingsFlagsManagedFiles(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"extensions_additional.conf": "[from-ai-agent]\nexten => s,1,Stasis(asterisk-ai-voice-agent)\n",
+		"ari_additional.conf":        "[admin]\ntype = user\n",
+		"extensions_custom.conf":     "[from-ai-agent]\nexten => s,1,Stasis(asterisk-ai-voice-agent)\n",
+	}
+	misplaced, custom := freePBXFindings(files, "asterisk-ai-voice-agent")
+	if len(misplaced) != 1 || !strings.HasPrefix(misplaced[0], "extensions_additional.conf:") || !strings.Contains(misplaced[0], "use extensions_custom.conf") {
+		t.Fatalf("misplaced = %v", misplaced)
+	}
+	if !custom {
+		t.Fatal("expected the extensions_custom.conf context to be found")
+	}
+}
@@ -0,0 +1,279 @@
+package check
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// asteriskSoundsDir is where sound: URIs resolve on the Asterisk host.
+const asteriskSoundsDir = "/var/lib/asterisk/sounds"
+
+// aiGeneratedDir is the sounds subdirectory pipeline greetings and TTS
+// replies are played from: ai_engine writes sound:ai-generated/<id>.ulaw to
+// ./asterisk_media/ai-generated and Asterisk reads it back through a symlink
+// or bind mount.
+const aiGeneratedDir = "ai-generated"
+
+// soundFormats are the file extensions Asterisk plays a sound from, with the
+// sample rate each one implies.
+var soundFormats = []struct {
+	ext  string
+	rate int
+}{
+	{"ulaw", 8000}, {"ul", 8000}, {"pcm", 8000}, {"alaw", 8000}, {"al", 8000},
+	{"gsm", 8000}, {"g729", 8000}, {"sln", 8000}, {"raw", 8000}, {"wav", 8000},
+	{"WAV", 8000}, {"g722", 16000}, {"sln16", 16000}, {"wav16", 16000},
+}
+
+// mediaRef is one configured announcement: where it is set and its ARI media URI.
+type mediaRef struct {
+	Source string `json:"source"`
+	URI    string `json:"uri"`
+}
+
+// mediaRefsScript lists the media URIs the engine plays on its own: agents'
+// connection audio (agents.db, then legacy YAML contexts), the hangup
+// fallback prompt, and outbound campaigns' consent and voicemail-drop prompts.
+const mediaRefsScript = `
+import json, os, sqlite3
+import yaml
+
+refs = []
+def add(source, uri):
+    if isinstance(uri, str) and uri.strip():
+        refs.append({"source": source, "uri": uri.strip()})
+
+cfg = {}
+for p in ("/app/config/ai-agent.yaml", "/app/config/ai-agent.local.yaml"):
+    try:
+        with open(p) as f:
+            d = yaml.safe_load(f) or {}
+        for k, v in d.items():
+            if isinstance(cfg.get(k), dict) and isinstance(v, dict):
+                cfg[k] = {**cfg[k], **v}
+            else:
+                cfg[k] = v
+    except Exception:
+        pass
+hangup = ((cfg.get("tools") or {}).get("hangup_call") or {})
+add("tools.hangup_call.fallback_media_uri", hangup.get("fallback_media_uri") or hangup.get("farewell_fallback_media_uri"))
+for name, ctx in (cfg.get("contexts") or {}).items():
+    if isinstance(ctx, dict):
+        add("contexts.%s.connection_audio" % name, ctx.get("connection_audio"))
+
+def query(path, sql):
+    if not os.path.exists(path):
+        return []
+    try:
+        c = sqlite3.connect("file:%s?mode=ro" % path, uri=True, timeout=2.0)
+        try:
+            return c.execute(sql).fetchall()
+        finally:
+            c.close()
+    except Exception:
+        return []
+
+for slug, extra in query("/app/data/operator/agents.db", "SELECT slug, extra_json FROM agents WHERE is_active=1"):
+    try:
+        add("agent %s connection_audio" % slug, (json.loads(extra or "{}") or {}).get("connection_audio"))
+    except Exception:
+        pass
+history = os.getenv("CALL_HISTORY_DB_PATH", "/app/data/call_history.db")
+for name, consent, vm in query(history, "SELECT name, consent_media_uri, voicemail_drop_media_uri FROM outbound_campaigns"):
+    add("campaign %s consent" % name, consent)
+    add("campaign %s voicemail drop" % name, vm)
+print(json.dumps(refs))
+`
+
+// checkGreetingMedia verifies on the Asterisk host that the ai-generated
+// directory and the configured announcement files are there, readable by the
+// asterisk user and in a format Asterisk plays at the rate it expects.
+func (r *Runner) checkGreetingMedia() Item {
+	if dockerHostIsRemote() {
+		return Item{Name: "Greeting media", Status: StatusSkip, Message: "remote docker host; run agent check on the Asterisk server"}
+	}
+	if st, err := os.Stat(asteriskSoundsDir); err != nil || !st.IsDir() {
+		return Item{Name: "Greeting media", Status: StatusSkip, Message: "no Asterisk sounds directory on this host", Details: "path=" + asteriskSoundsDir}
+	}
+	var refs []mediaRef
+	raw, err := r.dockerExecPython(mediaRefsScript)
+	if err == nil {
+		err = json.Unmarshal(bytes.TrimSpace(raw), &refs)
+	}
+	item := evaluateGreetingMedia(refs, asteriskSoundsDir, asteriskReader())
+	if err != nil {
+		item.Details = strings.TrimSpace(item.Details + "\ncould not read configured media URIs: " + err.Error())
+	}
+	return item
+}
+
+// readableFunc reports whether the Asterisk user can read path; known is
+// false when that cannot be told.
+type readableFunc func(path string) (ok, known bool)
+
+func evaluateGreetingMedia(refs []mediaRef, soundsDir string, readable readableFunc) Item {
+	item := Item{Name: "Greeting media"}
+	var problems, details []string
+	fail := false
+
+	gen := filepath.Join(soundsDir, aiGeneratedDir)
+	if st, err := os.Stat(gen); err != nil || !st.IsDir() {
+		fail = true
+		problems = append(problems, "sound:"+aiGeneratedDir+" is missing")
+		details = append(details, fmt.Sprintf("%s: missing; pipeline greetings and TTS replies cannot play", gen))
+	} else if ok, known := readable(gen); known && !ok {
+		fail = true
+		problems = append(problems, "sound:"+aiGeneratedDir+" is not readable by asterisk")
+		details = append(details, fmt.Sprintf("%s: not readable/traversable by the asterisk user", gen))
+	} else {
+		target, _ := filepath.EvalSymlinks(gen)
+		details = append(details, fmt.Sprintf("%s: ok (-> %s)", gen, emptyTo(target, gen)))
+	}
+
+	for _, ref := range refs {
+		scheme, name, _ := strings.Cut(ref.URI, ":")
+		switch scheme {
+		case "sound", "recording":
+		default:
+			details = append(details, fmt.Sprintf("%s: %s (not a file)", ref.Source, ref.URI))
+			continue
+		}
+		if scheme == "recording" && !filepath.IsAbs(name) {
+			name = filepath.Join("/var/spool/asterisk/recording", name)
+		}
+		files := soundFiles(soundsDir, name)
+		if len(files) == 0 {
+			fail = true
+			problems = append(problems, ref.URI+" not found")
+			details = append(details, fmt.Sprintf("%s: %s not found under %s (or %s/en)", ref.Source, ref.URI, soundsDir, soundsDir))
+			continue
+		}
+		var good []string
+		for _, f := range files {
+			if ok, known := readable(f.path); known && !ok {
+				details = append(details, fmt.Sprintf("%s: %s is not readable by the asterisk user", ref.Source, f.path))
+				continue
+			}
+			if err := checkSoundFormat(f.path, f.rate); err != nil {
+				details = append(details, fmt.Sprintf("%s: %s: %v", ref.Source, f.path, err))
+				continue
+			}
+			good = append(good, filepath.Base(f.path))
+		}
+		if len(good) == 0 {
+			fail = true
+			problems = append(problems, ref.URI+" unusable")
+			continue
+		}
+		details = append(details, fmt.Sprintf("%s: %s ok (%s)", ref.Source, ref.URI, strings.Join(good, ", ")))
+	}
+
+	item.Details = strings.Join(details, "\n")
+	if !fail {
+		item.Status = StatusPass
+		item.Message = fmt.Sprintf("ai-generated directory and %d configured prompt(s) ok", len(refs))
+		return item
+	}
+	item.Status = StatusWarn
+	item.Message = strings.Join(problems, "; ")
+	item.Remediation = "Run ./install.sh (or preflight.sh --apply-fixes) to link asterisk_media/ai-generated into " + soundsDir +
+		". Make prompts readable by asterisk (chown asterisk: <file>; chmod 644 <file>) and convert WAVs with: sox in.wav -r 8000 -c 1 -b 16 out.wav"
+	return item
+}
+
+type soundFile struct {
+	path string
+	rate int
+}
+
+// soundFiles finds the files Asterisk would consider for a sound name: the
+// name in the default language directory, then at the top of the sounds
+// directory (or as given, when absolute), in every format it plays.
+func soundFiles(soundsDir, name string) []soundFile {
+	bases := []string{filepath.Join(soundsDir, "en", name), filepath.Join(soundsDir, name)}
+	if filepath.IsAbs(name) {
+		bases = []string{name}
+	}
+	var out []soundFile
+	for _, base := range bases {
+		for _, f := range soundFormats {
+			if st, err := os.Stat(base + "." + f.ext); err == nil && st.Mode().IsRegular() {
+				out = append(out, soundFile{base + "." + f.ext, f.rate})
+			}
+		}
+		if len(out) > 0 {
+			break
+		}
+	}
+	return out
+}
+
+// checkSoundFormat rejects empty files and WAVs whose header does not match
+// what Asterisk's wav (8 kHz) and wav16 (16 kHz) readers expect.
+func checkSoundFormat(path string, rate int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if st.Size() == 0 {
+		return fmt.Errorf("empty file")
+	}
+	ext := filepath.Ext(path)
+	if ext != ".wav" && ext != ".wav16" {
+		return nil
+	}
+	head := make([]byte, 4096)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if len(head) < 12 || string(head[0:4]) != "RIFF" || string(head[8:12]) != "WAVE" {
+		return fmt.Errorf("not a WAV file")
+	}
+	for off := 12; off+8 <= len(head); off += 8 + int(binary.LittleEndian.Uint32(head[off+4:off+8])) {
+		body := head[off+8:]
+		if string(head[off:off+4]) != "fmt " || len(body) < 16 {
+			continue
+		}
+		format := binary.LittleEndian.Uint16(body[0:2])
+		channels := binary.LittleEndian.Uint16(body[2:4])
+		got := binary.LittleEndian.Uint32(body[4:8])
+		bits := binary.LittleEndian.Uint16(body[14:16])
+		if format != 1 || channels != 1 || bits != 16 || int(got) != rate {
+			return fmt.Errorf("WAV is format %d, %d channel(s), %d Hz, %d-bit; %s needs PCM mono %d Hz 16-bit", format, channels, got, bits, ext, rate)
+		}
+		return nil
+	}
+	return fmt.Errorf("WAV has no fmt chunk")
+}
+
+// asteriskReader checks permissions as the local asterisk user; without one
+// nothing can be told.
+func asteriskReader() readableFunc {
+	u, err := user.Lookup("asterisk")
+	if err != nil {
+		return func(string) (bool, bool) { return false, false }
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	groups := map[uint32]bool{}
+	ids, _ := u.GroupIds()
+	for _, g := range append(ids, u.Gid) {
+		if n, err := strconv.Atoi(g); err == nil {
+			groups[uint32(n)] = true
+		}
+	}
+	return func(path string) (bool, bool) {
+		return canRead(path, uint32(uid), groups)
+	}
+}
@@ -0,0 +1,80 @@
+package check
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeWAV(t *testing.T, path string, rate uint32, channels uint16) {
+	t.Helper()
+	data := make([]byte, 160)
+	hdr := make([]byte, 44)
+	copy(hdr[0:], "RIFF")
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(36+len(data)))
+	copy(hdr[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(hdr[16:], 16)
+	binary.LittleEndian.PutUint16(hdr[20:], 1)
+	binary.LittleEndian.PutUint16(hdr[22:], channels)
+	binary.LittleEndian.PutUint32(hdr[24:], rate)
+	binary.LittleEndian.PutUint32(hdr[28:], rate*uint32(channels)*2)
+	binary.LittleEndian.PutUint16(hdr[32:], channels*2)
+	binary.LittleEndian.PutUint16(hdr[34:], 16)
+	copy(hdr[36:], "data")
+	binary.LittleEndian.PutUint32(hdr[40:], uint32(len(data)))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(hdr, data...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvaluateGreetingMedia(t *testing.T) {
+	sounds := t.TempDir()
+	writeWAV(t, filepath.Join(sounds, "custom", "please-wait.wav"), 8000, 1)
+	writeWAV(t, filepath.Join(sounds, "en", "custom", "consent.wav"), 44100, 2)
+	if err := os.WriteFile(filepath.Join(sounds, "custom", "bye.ulaw"), []byte{0xff, 0xff}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	refs := []mediaRef{
+		{Source: "agent sales connection_audio", URI: "sound:custom/please-wait"},
+		{Source: "agent support connection_audio", URI: "tone:ring"},
+		{Source: "campaign spring consent", URI: "sound:custom/consent"},
+		{Source: "tools.hangup_call.fallback_media_uri", URI: "sound:custom/bye"},
+		{Source: "campaign spring voicemail drop", URI: "sound:custom/missing"},
+	}
+	readable := func(path string) (bool, bool) { return !strings.HasSuffix(path, ".ulaw"), true }
+
+	item := evaluateGreetingMedia(refs, sounds, readable)
+	if item.Status != StatusWarn {
+		t.Fatalf("status = %s", item.Status)
+	}
+	for _, want := range []string{"sound:ai-generated is missing", "sound:custom/consent unusable", "sound:custom/bye unusable", "sound:custom/missing not found"} {
+		if !strings.Contains(item.Message, want) {
+			t.Errorf("message missing %q: %q", want, item.Message)
+		}
+	}
+	for _, want := range []string{
+		"agent sales connection_audio: sound:custom/please-wait ok (please-wait.wav)",
+		"tone:ring (not a file)",
+		"consent.wav: WAV is format 1, 2 channel(s), 44100 Hz, 16-bit; .wav needs PCM mono 8000 Hz 16-bit",
+		"bye.ulaw is not readable by the asterisk user",
+	} {
+		if !strings.Contains(item.Details, want) {
+			t.Errorf("details missing %q:\n%s", want, item.Details)
+		}
+	}
+	if !strings.Contains(item.Remediation, "sox in.wav -r 8000 -c 1 -b 16 out.wav") {
+		t.Errorf("remediation = %q", item.Remediation)
+	}
+
+	if err := os.Mkdir(filepath.Join(sounds, aiGeneratedDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if item := evaluateGreetingMedia(refs[:2], sounds, readable); item.Status != StatusPass {
+		t.Fatalf("good media: %s %q\n%s", item.Status, item.Message, item.Details)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build !windows
+
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// canRead walks path and its parents as uid with groups: every directory
+// needs search permission and the target needs read (and search, for a
+// directory).
+func canRead(path string, uid uint32, groups map[uint32]bool) (ok, known bool) {
+	if uid == 0 {
+		return true, true
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, true
+	}
+	allowed := func(p string, want os.FileMode) (bool, bool) {
+		st, err := os.Stat(p)
+		if err != nil {
+			return false, true
+		}
+		sys, ok := st.Sys().(*syscall.Stat_t)
+		if !ok {
+			return false, false
+		}
+		mode := st.Mode().Perm()
+		switch {
+		case sys.Uid == uid:
+			return mode&(want<<6) == want<<6, true
+		case groups[sys.Gid]:
+			return mode&(want<<3) == want<<3, true
+		}
+		return mode&want == want, true
+	}
+	want := os.FileMode(4)
+	if st, err := os.Stat(resolved); err == nil && st.IsDir() {
+		want = 5
+	}
+	if ok, known := allowed(resolved, want); !ok || !known {
+		return ok, known
+	}
+	for dir := filepath.Dir(resolved); ; dir = filepath.Dir(dir) {
+		if ok, known := allowed(dir, 1); !ok || !known {
+			return ok, known
+		}
+		if dir == filepath.Dir(dir) {
+			return true, true
+		}
+	}
+}
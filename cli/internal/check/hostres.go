@@ -0,0 +1,415 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+)
+
+// dockerInfo returns the daemon's /info (on Kubernetes, ai_engine's node), fetched once per run.
+func (r *Runner) dockerInfo() (dockerapi.SystemInfo, error) {
+	if r.sysInfo == nil && r.sysInfoErr == nil {
+		info, err := dockerapi.InfoFor(deployment.EngineContainer())
+		r.sysInfo, r.sysInfoErr = &info, err
+	}
+	return *r.sysInfo, r.sysInfoErr
+}
+
+// normalizeArch maps uname -m names to the GOARCH names images use.
+func normalizeArch(arch string) string {
+	switch a := strings.ToLower(strings.TrimSpace(arch)); a {
+	case "x86_64", "x86-64":
+		return "amd64"
+	case "aarch64", "armv8", "armv8l":
+		return "arm64"
+	case "armv7l", "armv7", "armv6l", "armhf", "armel":
+		return "arm"
+	default:
+		return a
+	}
+}
+
+// hostProbeScript reads the load, available memory and CPU temperature from
+// inside ai_engine; /proc/loadavg, /proc/meminfo and the thermal zones are
+// the host's.
+const hostProbeScript = `
+import json, os
+
+out = {"load1": None, "mem_available_kb": None, "temp_millic": None}
+try:
+    out["load1"] = os.getloadavg()[0]
+except Exception:
+    pass
+try:
+    with open("/proc/meminfo") as f:
+        for line in f:
+            if line.startswith("MemAvailable:"):
+                out["mem_available_kb"] = int(line.split()[1])
+except Exception:
+    pass
+try:
+    with open("/sys/class/thermal/thermal_zone0/temp") as f:
+        out["temp_millic"] = int(f.read().strip())
+except Exception:
+    pass
+print(json.dumps(out))
+`
+
+type hostSample struct {
+	Load1          *float64 `json:"load1"`
+	MemAvailableKB *int64   `json:"mem_available_kb"`
+	TempMilliC     *int64   `json:"temp_millic"`
+}
+
+// checkHostResources compares the Docker host's CPUs, memory, load and
+// temperature with the profile's limits.
+func (r *Runner) checkHostResources() Item {
+	info, err := r.dockerInfo()
+	if err != nil {
+		return Item{Name: "Host Resources", Status: StatusSkip, Message: "docker info unavailable", Details: err.Error()}
+	}
+	var sample hostSample
+	if raw, err := r.dockerExecPython(hostProbeScript); err == nil {
+		_ = json.Unmarshal(bytes.TrimSpace(raw), &sample)
+	}
+	return evaluateHostResources(info, sample, r.limits(), r.Profile.Name)
+}
+
+func evaluateHostResources(info dockerapi.SystemInfo, s hostSample, lim hostLimits, profile string) Item {
+	item := Item{Name: "Host Resources"}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	details := []string{
+		fmt.Sprintf("arch=%s cpus=%d memory=%s", emptyTo(info.Architecture, "unknown"), info.NCPU, formatBytes(info.MemTotal)),
+		fmt.Sprintf("thresholds (%s profile): cpus>=%d memory>=%s available>=%s load/cpu<=%.1f temp<=%.0f°C",
+			profile, lim.minCPUs, formatBytes(lim.minMemory), formatBytes(lim.minAvailable), lim.loadPerCPU, lim.maxTempC),
+	}
+	var findings []string
+	summary := []string{fmt.Sprintf("%d CPU(s)", info.NCPU), formatBytes(info.MemTotal) + " RAM"}
+
+	if info.NCPU > 0 && info.NCPU < lim.minCPUs {
+		findings = append(findings, fmt.Sprintf("only %d CPU(s)", info.NCPU))
+	}
+	if info.MemTotal > 0 && info.MemTotal < lim.minMemory {
+		findings = append(findings, fmt.Sprintf("only %s RAM", formatBytes(info.MemTotal)))
+	}
+	if s.MemAvailableKB != nil {
+		avail := *s.MemAvailableKB << 10
+		details = append(details, "available="+formatBytes(avail))
+		summary[1] += fmt.Sprintf(" (%s available)", formatBytes(avail))
+		if avail < lim.minAvailable {
+			findings = append(findings, fmt.Sprintf("only %s memory available", formatBytes(avail)))
+		}
+	}
+	if s.Load1 != nil && info.NCPU > 0 {
+		per := *s.Load1 / float64(info.NCPU)
+		details = append(details, fmt.Sprintf("load1=%.2f (%.2f per CPU)", *s.Load1, per))
+		summary = append(summary, fmt.Sprintf("load %.2f/CPU", per))
+		if per > lim.loadPerCPU {
+			findings = append(findings, fmt.Sprintf("load %.2f per CPU", per))
+		}
+	}
+	if s.TempMilliC != nil && *s.TempMilliC > 0 {
+		c := float64(*s.TempMilliC) / 1000
+		details = append(details, fmt.Sprintf("cpu_temp=%.1f°C", c))
+		if c > lim.maxTempC {
+			findings = append(findings, fmt.Sprintf("CPU at %.0f°C (throttling likely)", c))
+		}
+	}
+
+	item.Details = strings.Join(details, "\n")
+	if len(findings) == 0 {
+		item.Status = StatusPass
+		item.Message = strings.Join(summary, ", ")
+		return item
+	}
+	item.Status = StatusWarn
+	item.Message = strings.Join(findings, "; ")
+	item.Remediation = "Real-time audio needs CPU and memory headroom: stop other workloads, prefer cloud or hybrid providers over local models, and on a Raspberry Pi use a heatsink or fan."
+	return item
+}
+
+// imageArch is the architecture of one service's image.
+type imageArch struct {
+	Service   string
+	Image     string
+	Arch      string // GOARCH style
+	Variant   string
+	ExecError bool // its last start failed with "exec format error"
+}
+
+// checkImageArchitecture compares the architecture of the stack's images with
+// the Docker host's. An amd64 image on an ARM host (or the reverse) runs under
+// emulation when binfmt is set up, slowly, and fails with "exec format error"
+// when it is not.
+func (r *Runner) checkImageArchitecture() Item {
+	info, err := r.dockerInfo()
+	if err != nil {
+		return Item{Name: "Image Architecture", Status: StatusSkip, Message: "docker info unavailable", Details: err.Error()}
+	}
+	var images []imageArch
+	for _, svc := range []struct{ service, container string }{
+		{"ai_engine", deployment.EngineContainer()},
+		{"admin_ui", deployment.AdminUIContainer()},
+		{"local_ai_server", deployment.LocalAIContainer()},
+	} {
+		out, err := dockerapi.Inspect(svc.container)
+		var cis []containerInspect
+		if err != nil || json.Unmarshal(out, &cis) != nil || len(cis) == 0 {
+			continue // not deployed; the container checks report it
+		}
+		ci := cis[0]
+		ia := imageArch{Service: svc.service, Image: ci.Config.Image}
+		if out, err := dockerapi.InspectImage(ci.Image); err == nil {
+			var imgs []struct {
+				Architecture string `json:"Architecture"`
+				Variant      string `json:"Variant"`
+			}
+			if json.Unmarshal(out, &imgs) == nil && len(imgs) > 0 {
+				ia.Arch, ia.Variant = imgs[0].Architecture, imgs[0].Variant
+			}
+		}
+		if !ci.State.Running {
+			logs, _ := dockerapi.LogsOutput(svc.container, dockerapi.LogsOptions{Tail: 20})
+			ia.ExecError = bytes.Contains(logs, []byte("exec format error"))
+		}
+		images = append(images, ia)
+	}
+	item := evaluateImageArch(info.Architecture, images)
+	if kube.Enabled() && item.Remediation != "" {
+		item.Remediation = fmt.Sprintf("Push images built for linux/%s (docker buildx build --platform linux/amd64,linux/arm64 --push) and restart with agent update, or pin the pods to matching nodes with a kubernetes.io/arch nodeSelector.", normalizeArch(info.Architecture))
+	}
+	return item
+}
+
+func evaluateImageArch(hostArch string, images []imageArch) Item {
+	item := Item{Name: "Image Architecture"}
+	host := normalizeArch(hostArch)
+	if len(images) == 0 {
+		item.Status = StatusSkip
+		item.Message = "no stack containers to inspect"
+		return item
+	}
+	var details, broken, emulated, rebuild []string
+	for _, img := range images {
+		arch := emptyTo(img.Arch, "unknown")
+		if img.Variant != "" {
+			arch += "/" + img.Variant
+		}
+		details = append(details, fmt.Sprintf("%s: %s (%s)", img.Service, arch, img.Image))
+		switch {
+		case img.ExecError:
+			broken = append(broken, img.Service)
+			rebuild = append(rebuild, img.Service)
+		case img.Arch != "" && host != "" && normalizeArch(img.Arch) != host:
+			emulated = append(emulated, fmt.Sprintf("%s is %s", img.Service, img.Arch))
+			rebuild = append(rebuild, img.Service)
+		}
+	}
+	details = append(details, "host: "+emptyTo(host, "unknown"))
+	item.Details = strings.Join(details, "\n")
+
+	sort.Strings(rebuild)
+	item.Remediation = fmt.Sprintf("Build the images on this host so they match its architecture: docker compose build %s && docker compose up -d %s. Images copied or pulled from an %s machine need platform: linux/%s in docker-compose.override.yml and a rebuild.",
+		strings.Join(rebuild, " "), strings.Join(rebuild, " "), otherArch(host), emptyTo(host, "arm64"))
+	switch {
+	case len(broken) > 0:
+		item.Status = StatusFail
+		item.Message = fmt.Sprintf("%s failed with exec format error: built for another architecture than this %s host", strings.Join(broken, ", "), emptyTo(host, "unknown"))
+	case len(emulated) > 0:
+		item.Status = StatusWarn
+		item.Message = fmt.Sprintf("%s on this %s host; it runs under emulation, too slowly for real-time audio", strings.Join(emulated, ", "), host)
+	default:
+		item.Status = StatusPass
+		item.Message = "images match the host (" + emptyTo(host, "unknown") + ")"
+		item.Remediation = ""
+	}
+	return item
+}
+
+func otherArch(host string) string {
+	if host == "amd64" {
+		return "ARM"
+	}
+	return "x86_64"
+}
+
+// modelProbeScript sizes the model files local_ai_server loads for its
+// configured backends, mirroring local_ai_server/config.py's defaults, and
+// totals the resident memory of the container's processes.
+const modelProbeScript = `
+import json, os
+
+def env(name, default=""):
+    return (os.environ.get(name) or default).strip()
+
+def truthy(v):
+    return v.lower() in ("1", "true", "yes", "y", "on")
+
+def size(p):
+    if os.path.isfile(p):
+        return os.path.getsize(p)
+    if os.path.isdir(p):
+        t = 0
+        for root, _, files in os.walk(p):
+            for f in files:
+                try:
+                    t += os.path.getsize(os.path.join(root, f))
+                except OSError:
+                    pass
+        return t
+    return None
+
+mode = env("LOCAL_AI_MODE").lower() or ("full" if truthy(env("GPU_AVAILABLE", "0")) else "minimal")
+stt = env("LOCAL_STT_BACKEND", "vosk").lower()
+tts = env("LOCAL_TTS_BACKEND", "piper").lower()
+stt_paths = {
+    "vosk": env("LOCAL_STT_MODEL_PATH", "/app/models/stt/vosk-model-en-us-0.22"),
+    "sherpa": env("SHERPA_MODEL_PATH", "/app/models/stt/sherpa"),
+    "t-one": env("TONE_MODEL_PATH", "/app/models/stt/t-one"),
+    "tone": env("TONE_MODEL_PATH", "/app/models/stt/t-one"),
+    "whisper_cpp": env("WHISPER_CPP_MODEL_PATH") or env("LOCAL_WHISPER_CPP_MODEL_PATH", "/app/models/stt/ggml-base.en.bin"),
+}
+if truthy(env("KROKO_EMBEDDED", "0")):
+    stt_paths["kroko"] = env("KROKO_MODEL_PATH", "/app/models/kroko/kroko-en-v1.0.onnx")
+tts_paths = {
+    "piper": env("LOCAL_TTS_MODEL_PATH", "/app/models/tts/en_US-lessac-medium.onnx"),
+    "kokoro": env("KOKORO_MODEL_PATH", "/app/models/tts/kokoro"),
+    "silero": env("SILERO_MODEL_PATH", "/app/models/tts/silero"),
+    "matcha": env("MATCHA_MODEL_PATH", "/app/models/tts/matcha/model.onnx"),
+}
+
+out = {"mode": mode, "models": [], "rss_kb": 0, "mem_available_kb": None}
+def add(role, backend, path):
+    out["models"].append({"role": role, "backend": backend, "path": path, "bytes": size(path) if path else None})
+add("stt", stt, stt_paths.get(stt, ""))
+add("tts", tts, tts_paths.get(tts, ""))
+if mode != "minimal":
+    add("llm", "llama.cpp", env("LOCAL_LLM_MODEL_PATH", "/app/models/llm/phi-3-mini-4k-instruct.Q4_K_M.gguf"))
+for p in os.listdir("/proc"):
+    if not p.isdigit() or int(p) == os.getpid():
+        continue
+    try:
+        with open("/proc/%s/status" % p) as f:
+            for line in f:
+                if line.startswith("VmRSS:"):
+                    out["rss_kb"] += int(line.split()[1])
+    except Exception:
+        pass
+try:
+    with open("/proc/meminfo") as f:
+        for line in f:
+            if line.startswith("MemAvailable:"):
+                out["mem_available_kb"] = int(line.split()[1])
+except Exception:
+    pass
+print(json.dumps(out))
+`
+
+type modelFootprint struct {
+	Mode           string      `json:"mode"`
+	Models         []modelFile `json:"models"`
+	RSSKB          int64       `json:"rss_kb"`
+	MemAvailableKB *int64      `json:"mem_available_kb"`
+}
+
+type modelFile struct {
+	Role    string `json:"role"`
+	Backend string `json:"backend"`
+	Path    string `json:"path"`  // empty for backends that download their own
+	Bytes   *int64 `json:"bytes"` // nil when missing
+}
+
+// checkModelMemory warns when the models local_ai_server is configured to
+// load do not fit the memory the host (or the container's limit) can give it.
+func (r *Runner) checkModelMemory(localAI *containerInspect) Item {
+	if localAI == nil || !localAI.State.Running {
+		return Item{Name: "Local AI Memory", Status: StatusSkip, Message: "local_ai_server not running"}
+	}
+	info, err := r.dockerInfo()
+	if err != nil {
+		return Item{Name: "Local AI Memory", Status: StatusSkip, Message: "docker info unavailable", Details: err.Error()}
+	}
+	raw, err := r.dockerExecPythonIn(deployment.LocalAIContainer(), modelProbeScript)
+	if err != nil {
+		return Item{Name: "Local AI Memory", Status: StatusWarn, Message: "probe failed", Details: err.Error()}
+	}
+	var fp modelFootprint
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &fp); err != nil {
+		return Item{Name: "Local AI Memory", Status: StatusWarn, Message: "invalid probe output", Details: string(raw)}
+	}
+	return evaluateModelMemory(fp, info.MemTotal, localAI.HostConfig.Memory, localAI.State.OOMKilled, r.limits())
+}
+
+// evaluateModelMemory treats a model's file size as the memory it takes once
+// loaded, which holds for GGUF and ONNX weights and errs low for the rest.
+func evaluateModelMemory(fp modelFootprint, memTotal, memLimit int64, oomKilled bool, lim hostLimits) Item {
+	item := Item{Name: "Local AI Memory"}
+	var need int64
+	details := []string{"mode=" + emptyTo(fp.Mode, "unknown")}
+	for _, m := range fp.Models {
+		switch {
+		case m.Path == "":
+			details = append(details, fmt.Sprintf("%s: %s (size not known)", m.Role, m.Backend))
+		case m.Bytes == nil:
+			details = append(details, fmt.Sprintf("%s: %s %s (missing)", m.Role, m.Backend, m.Path))
+		default:
+			need += *m.Bytes
+			details = append(details, fmt.Sprintf("%s: %s %s %s", m.Role, m.Backend, m.Path, formatBytes(*m.Bytes)))
+		}
+	}
+	budget, of := memTotal, "the host's"
+	if memLimit > 0 && (budget <= 0 || memLimit < budget) {
+		budget, of = memLimit, "the container's"
+	}
+	rss := fp.RSSKB << 10
+	details = append(details, fmt.Sprintf("models=%s local_ai_rss=%s memory=%s limit=%s", formatBytes(need), formatBytes(rss), formatBytes(memTotal), limitString(memLimit)))
+
+	var findings []string
+	if oomKilled {
+		findings = append(findings, "local_ai_server was last stopped for running out of memory")
+	}
+	if budget > 0 {
+		switch share := float64(need) / float64(budget); {
+		case need > budget:
+			findings = append(findings, fmt.Sprintf("models need ~%s but %s memory is %s", formatBytes(need), of, formatBytes(budget)))
+		case share > lim.modelShare:
+			findings = append(findings, fmt.Sprintf("models need ~%s, %.0f%% of %s %s (keep under %.0f%% for Asterisk and ai_engine)",
+				formatBytes(need), 100*share, of, formatBytes(budget), 100*lim.modelShare))
+		}
+	}
+	// Memory the models could still get: what is free plus what local_ai_server
+	// already holds (the models, once loaded).
+	if fp.MemAvailableKB != nil {
+		room := *fp.MemAvailableKB<<10 + rss
+		details = append(details, "available="+formatBytes(*fp.MemAvailableKB<<10))
+		if need > room {
+			findings = append(findings, fmt.Sprintf("models need ~%s but only %s is available to local_ai_server", formatBytes(need), formatBytes(room)))
+		}
+	}
+
+	item.Details = strings.Join(details, "\n")
+	if len(findings) == 0 {
+		item.Status = StatusPass
+		item.Message = fmt.Sprintf("models need ~%s of %s %s", formatBytes(need), of, formatBytes(budget))
+		return item
+	}
+	item.Status = StatusWarn
+	item.Message = strings.Join(findings, "; ")
+	item.Remediation = "Use smaller models (a vosk-model-small STT, a 1-3B Q4 GGUF for LOCAL_LLM_MODEL_PATH), set LOCAL_AI_MODE=minimal to skip the local LLM, or move the LLM to a cloud provider with a hybrid pipeline."
+	return item
+}
+
+func limitString(n int64) string {
+	if n <= 0 {
+		return "none"
+	}
+	return formatBytes(n)
+}
@@ -0,0 +1,98 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+func TestEvaluateHostResources(t *testing.T) {
+	pi := dockerapi.SystemInfo{Architecture: "aarch64", NCPU: 4, MemTotal: 4 << 30}
+	f := func(v float64) *float64 { return &v }
+	n := func(v int64) *int64 { return &v }
+
+	// A Pi 4 with 2 GiB free and a load of 2: fine by the default limits, too
+	// busy for the constrained ones.
+	s := hostSample{Load1: f(2.0), MemAvailableKB: n(2 << 20), TempMilliC: n(62000)}
+	if item := evaluateHostResources(pi, s, defaultHostLimits, ""); item.Status != StatusPass || item.Message != "4 CPU(s), 4.0 GiB RAM (2.0 GiB available), load 0.50/CPU" {
+		t.Fatalf("default limits: %s %q", item.Status, item.Message)
+	}
+	s.Load1 = f(3.2)
+	item := evaluateHostResources(pi, s, constrainedHostLimits, "constrained")
+	if item.Status != StatusWarn || item.Message != "load 0.80 per CPU" || !strings.Contains(item.Details, "thresholds (constrained profile)") {
+		t.Fatalf("constrained load: %s %q %q", item.Status, item.Message, item.Details)
+	}
+
+	s = hostSample{MemAvailableKB: n(200 << 10), TempMilliC: n(83500)}
+	item = evaluateHostResources(dockerapi.SystemInfo{NCPU: 1, MemTotal: 1 << 30}, s, constrainedHostLimits, "constrained")
+	for _, want := range []string{"only 1 CPU(s)", "only 200 MiB memory available", "CPU at 84°C"} {
+		if item.Status != StatusWarn || !strings.Contains(item.Message, want) {
+			t.Fatalf("small host: %s %q missing %q", item.Status, item.Message, want)
+		}
+	}
+	if strings.Contains(item.Message, "RAM") {
+		t.Fatalf("1 GiB is enough for the constrained profile: %q", item.Message)
+	}
+}
+
+func TestEvaluateImageArch(t *testing.T) {
+	images := []imageArch{
+		{Service: "ai_engine", Image: "asterisk-ai-voice-agent-ai_engine", Arch: "arm64"},
+		{Service: "admin_ui", Image: "asterisk-ai-voice-agent-admin_ui", Arch: "arm64", Variant: "v8"},
+	}
+	if item := evaluateImageArch("aarch64", images); item.Status != StatusPass || item.Message != "images match the host (arm64)" || item.Remediation != "" {
+		t.Fatalf("matching: %s %q", item.Status, item.Message)
+	}
+
+	images[1].Arch = "amd64"
+	item := evaluateImageArch("aarch64", images)
+	if item.Status != StatusWarn || !strings.Contains(item.Message, "admin_ui is amd64 on this arm64 host") ||
+		!strings.Contains(item.Remediation, "docker compose build admin_ui ") || !strings.Contains(item.Remediation, "platform: linux/arm64") {
+		t.Fatalf("emulated: %s %q %q", item.Status, item.Message, item.Remediation)
+	}
+
+	images[0].ExecError = true
+	item = evaluateImageArch("aarch64", images)
+	if item.Status != StatusFail || !strings.HasPrefix(item.Message, "ai_engine failed with exec format error") ||
+		!strings.Contains(item.Remediation, "docker compose build admin_ui ai_engine") {
+		t.Fatalf("exec format error: %s %q %q", item.Status, item.Message, item.Remediation)
+	}
+
+	if item := evaluateImageArch("x86_64", nil); item.Status != StatusSkip {
+		t.Fatalf("no containers: %s", item.Status)
+	}
+}
+
+func TestEvaluateModelMemory(t *testing.T) {
+	n := func(v int64) *int64 { return &v }
+	fp := modelFootprint{Mode: "full", RSSKB: 300 << 10, MemAvailableKB: n(1 << 20), Models: []modelFile{
+		{"stt", "vosk", "/app/models/stt/vosk-model-small-en-us-0.15", n(40 << 20)},
+		{"tts", "piper", "/app/models/tts/en_US-lessac-medium.onnx", n(60 << 20)},
+		{"llm", "llama.cpp", "/app/models/llm/phi-3-mini-4k-instruct.Q4_K_M.gguf", n(2300 << 20)},
+	}}
+
+	// 2.3 GiB of models on a 4 GiB Pi with 1.3 GiB free for them.
+	item := evaluateModelMemory(fp, 4<<30, 0, false, constrainedHostLimits)
+	for _, want := range []string{"models need ~2.3 GiB, 59% of the host's 4.0 GiB (keep under 50%", "only 1.3 GiB is available to local_ai_server"} {
+		if item.Status != StatusWarn || !strings.Contains(item.Message, want) {
+			t.Fatalf("pi: %s %q missing %q", item.Status, item.Message, want)
+		}
+	}
+	if !strings.Contains(item.Remediation, "LOCAL_AI_MODE=minimal") {
+		t.Fatalf("remediation: %q", item.Remediation)
+	}
+
+	// The same models on a 16 GiB server with a 2 GiB container limit.
+	fp.MemAvailableKB = n(12 << 20)
+	item = evaluateModelMemory(fp, 16<<30, 2<<30, true, defaultHostLimits)
+	if item.Status != StatusWarn || !strings.Contains(item.Message, "out of memory") || !strings.Contains(item.Message, "but the container's memory is 2.0 GiB") {
+		t.Fatalf("limit: %s %q", item.Status, item.Message)
+	}
+
+	// Minimal mode loads no LLM.
+	fp.Models = fp.Models[:2]
+	if item := evaluateModelMemory(fp, 4<<30, 0, false, constrainedHostLimits); item.Status != StatusPass || item.Message != "models need ~100 MiB of the host's 4.0 GiB" {
+		t.Fatalf("minimal: %s %q", item.Status, item.Message)
+	}
+}
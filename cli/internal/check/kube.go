@@ -0,0 +1,85 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+)
+
+// checkKubernetes stands in for the Docker CLI, daemon and compose checks when
+// the stack runs on Kubernetes: the API server must answer, and kubectl must be
+// installed for the in-container probes (they go through kubectl exec).
+func (r *Runner) checkKubernetes() Item {
+	s, err := kube.Current()
+	if err != nil {
+		return Item{
+			Name:        "Kubernetes",
+			Status:      StatusFail,
+			Message:     "cannot load the kubeconfig",
+			Details:     err.Error(),
+			Remediation: "Set kubernetes.kubeconfig / kubernetes.context in " + deployment.Path() + " (or KUBECONFIG); on k3s: sudo chmod 644 /etc/rancher/k3s/k3s.yaml or copy it to ~/.kube/config",
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	names := []string{deployment.EngineContainer(), deployment.AdminUIContainer(), deployment.LocalAIContainer()}
+	pods, err := s.Pods(ctx, s.Namespace, fmt.Sprintf("%s in (%s)", s.Target.Label, strings.Join(names, ",")))
+	if err != nil {
+		return Item{
+			Name:        "Kubernetes",
+			Status:      StatusFail,
+			Message:     "API server not reachable",
+			Details:     err.Error(),
+			Remediation: "Check that the cluster is up and the kubeconfig user may list pods: kubectl -n " + s.Namespace + " get pods",
+		}
+	}
+	_, lookErr := exec.LookPath("kubectl")
+	return evaluateKubernetes(s.Config(), s.Namespace, s.Target.Label, pods, lookErr == nil)
+}
+
+func evaluateKubernetes(cfg kube.Config, namespace, label string, pods []kube.Pod, kubectl bool) Item {
+	item := Item{Name: "Kubernetes", Status: StatusPass}
+	where := namespace
+	if cfg.Context != "" {
+		where = cfg.Context + "/" + namespace
+	}
+	item.Message = fmt.Sprintf("%s: %d stack pod(s)", where, len(pods))
+
+	details := []string{"server=" + cfg.Server, "namespace=" + namespace, "label=" + label}
+	byName := map[string][]string{}
+	for _, p := range pods {
+		name := p.Metadata.Labels[label]
+		byName[name] = append(byName[name], p.Metadata.Name+" ("+strings.ToLower(emptyTo(p.Status.Phase, "unknown"))+")")
+	}
+	var keys []string
+	for k := range byName {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		details = append(details, k+": "+strings.Join(byName[k], ", "))
+	}
+	item.Details = strings.Join(details, "\n")
+
+	if !kubectl {
+		item.Status = StatusWarn
+		item.Message += "; kubectl not found"
+		item.Remediation = "Install kubectl (on k3s: k3s kubectl, or link /usr/local/bin/kubectl); the in-container probes run through kubectl exec"
+	}
+	return item
+}
+
+// missingPodRemediation is the hint for a container with no pod.
+func missingPodRemediation(name string) string {
+	s, err := kube.Current()
+	if err != nil {
+		return "Check the kubernetes settings in " + deployment.Path()
+	}
+	return fmt.Sprintf("Label the pod template %s, or set kubernetes.label / containers in %s; list pods with: kubectl -n %s get pods -L %s",
+		s.Selector(name), deployment.Path(), s.Namespace, s.Target.Label)
+}
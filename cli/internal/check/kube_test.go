@@ -0,0 +1,31 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+)
+
+func TestEvaluateKubernetes(t *testing.T) {
+	pods := make([]kube.Pod, 3)
+	for i, p := range []struct{ name, app, phase string }{
+		{"ai-engine-7d9-x2", "ai_engine", "Running"},
+		{"ai-engine-7d9-k4", "ai_engine", "Pending"},
+		{"admin-ui-0", "admin_ui", "Running"},
+	} {
+		pods[i].Metadata.Name, pods[i].Metadata.Labels, pods[i].Status.Phase = p.name, map[string]string{"app": p.app}, p.phase
+	}
+	cfg := kube.Config{Server: "https://10.0.0.5:6443", Context: "k3s"}
+
+	item := evaluateKubernetes(cfg, "voice", "app", pods, true)
+	if item.Status != StatusPass || item.Message != "k3s/voice: 3 stack pod(s)" ||
+		!strings.Contains(item.Details, "admin_ui: admin-ui-0 (running)\nai_engine: ai-engine-7d9-x2 (running), ai-engine-7d9-k4 (pending)") {
+		t.Fatalf("%s %q %q", item.Status, item.Message, item.Details)
+	}
+
+	item = evaluateKubernetes(kube.Config{}, "default", "app", nil, false)
+	if item.Status != StatusWarn || item.Message != "default: 0 stack pod(s); kubectl not found" || !strings.Contains(item.Remediation, "kubectl exec") {
+		t.Fatalf("no kubectl: %s %q", item.Status, item.Message)
+	}
+}
@@ -0,0 +1,40 @@
+package check
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contexts"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/locale"
+)
+
+// checkLanguages compares the language each enabled provider and pipeline is
+// asked to transcribe and speak with what its STT model and TTS voice
+// support. A mismatch does not fail a call: the caller is transcribed as
+// nothing or as nonsense, or hears the wrong voice.
+func (r *Runner) checkLanguages() Item {
+	const name = "Languages"
+	if dockerHostIsRemote() {
+		return Item{Name: name, Status: StatusSkip, Message: "skipped (remote docker host; config lives on the server)"}
+	}
+	composePath, err := findComposeFile()
+	if err != nil {
+		return Item{Name: name, Status: StatusSkip, Message: "docker-compose.yml not found", Remediation: "Run agent check from the project root."}
+	}
+	opts := contexts.LoadOptions(filepath.Dir(composePath))
+	return evaluateLanguages(locale.Check(opts.Config, func(k string) string { return opts.Env[k] }))
+}
+
+func evaluateLanguages(findings []string) Item {
+	item := Item{Name: "Languages"}
+	if len(findings) == 0 {
+		item.Status, item.Message = StatusPass, "STT models and TTS voices match their configured languages"
+		return item
+	}
+	item.Status = StatusWarn
+	item.Message = fmt.Sprintf("%d language mismatch(es) between STT, TTS and their models", len(findings))
+	item.Details = strings.Join(findings, "\n")
+	item.Remediation = "Pick a model or voice for the language (a multilingual one, or e.g. vosk-model-small-es for Spanish), or set the language it supports. Run agent context lint to compare greetings with their voices."
+	return item
+}
@@ -0,0 +1,16 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateLanguages(t *testing.T) {
+	if item := evaluateLanguages(nil); item.Status != StatusPass {
+		t.Fatalf("clean: %+v", item)
+	}
+	item := evaluateLanguages([]string{"providers.deepgram.stt_language is es (Spanish), but providers.deepgram.model flux-general-en only handles English"})
+	if item.Status != StatusWarn || item.Message != "1 language mismatch(es) between STT, TTS and their models" || !strings.Contains(item.Details, "flux-general-en") {
+		t.Fatalf("mismatch: %+v", item)
+	}
+}
@@ -0,0 +1,67 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+)
+
+// DefaultMaxCallAge is how long a call may be up before the leak check calls
+// it stuck. Agent calls rarely last more than a few minutes.
+const DefaultMaxCallAge = 2 * time.Hour
+
+// checkChannelLeaks looks for calls up far longer than expected and for media
+// helper channels and bridges the engine left behind, from the channel and
+// bridge lists the ARI probe fetched inside ai_engine.
+func (r *Runner) checkChannelLeaks(ari *ariProbe, now time.Time) (Item, []asterisk.Leak) {
+	maxAge := r.MaxCallAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxCallAge
+	}
+	return evaluateChannelLeaks(ari, maxAge, now)
+}
+
+func evaluateChannelLeaks(ari *ariProbe, maxAge time.Duration, now time.Time) (Item, []asterisk.Leak) {
+	item := Item{Name: "Channel leaks"}
+	switch {
+	case ari == nil || !ari.OK:
+		item.Status, item.Message = StatusSkip, "ARI unavailable"
+		return item, nil
+	case ari.ListError != "":
+		item.Status, item.Message, item.Details = StatusWarn, "could not list channels and bridges", ari.ListError
+		return item, nil
+	}
+
+	leaks := asterisk.FindLeaks(ari.Channels, ari.Bridges, ari.AppName, maxAge, now)
+	counts := map[string]int{}
+	details := []string{fmt.Sprintf("channels=%d bridges=%d max_call_age=%s", len(ari.Channels), len(ari.Bridges), maxAge)}
+	for _, l := range leaks {
+		counts[l.Kind]++
+		name := l.ID
+		if l.Name != "" {
+			name = fmt.Sprintf("%s (%s)", l.Name, l.ID)
+		}
+		details = append(details, fmt.Sprintf("%s %s: %s", l.Kind, name, l.Reason))
+	}
+	item.Details = strings.Join(details, "\n")
+	if len(leaks) == 0 {
+		item.Status, item.Message = StatusPass, "no stuck calls or orphaned channels"
+		return item, nil
+	}
+
+	var parts []string
+	for _, k := range []struct{ kind, label string }{
+		{asterisk.LeakStuckCall, "stuck call(s)"},
+		{asterisk.LeakOrphan, "orphaned helper channel(s)"},
+		{asterisk.LeakBridge, "leaked bridge(s)"},
+	} {
+		if counts[k.kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[k.kind], k.label))
+		}
+	}
+	item.Status, item.Message = StatusWarn, strings.Join(parts, ", ")
+	item.Remediation = "Leaked channels hold PBX resources until Asterisk restarts. Run: agent check --cleanup to hang them up and destroy the bridges via ARI."
+	return item, leaks
+}
@@ -0,0 +1,41 @@
+package check
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateChannelLeaks(t *testing.T) {
+	raw := `{"ok": true, "app_name": "asterisk-ai-voice-agent",
+		"channels": [
+			{"id": "1.1", "name": "PJSIP/trunk-0001", "creationtime": "2026-03-10T05:00:00.000+0000", "dialplan": {"app_name": "Stasis", "app_data": "asterisk-ai-voice-agent"}},
+			{"id": "2.1", "name": "UnicastRTP/127.0.0.1:18080-0002", "creationtime": "2026-03-10T08:00:00.000+0000", "dialplan": {"app_name": "Stasis", "app_data": "asterisk-ai-voice-agent"}}
+		],
+		"bridges": [{"id": "b1", "name": "bridge_5e6f7a8b", "creator": "Stasis", "channels": [], "creationtime": "2026-03-10T08:00:00.000+0000"}]}`
+	var probe ariProbe
+	if err := json.Unmarshal([]byte(raw), &probe); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	item, leaks := evaluateChannelLeaks(&probe, DefaultMaxCallAge, now)
+	if item.Status != StatusWarn || item.Message != "1 stuck call(s), 1 orphaned helper channel(s), 1 leaked bridge(s)" || len(leaks) != 3 {
+		t.Fatalf("leaks: %s %q %+v", item.Status, item.Message, leaks)
+	}
+	if !strings.Contains(item.Details, "stuck_call PJSIP/trunk-0001 (1.1): up 4h0m0s") || !strings.Contains(item.Remediation, "--cleanup") {
+		t.Fatalf("details = %q", item.Details)
+	}
+
+	// Helpers and bridges get a minute to be joined by their caller.
+	if item, leaks := evaluateChannelLeaks(&probe, 5*time.Hour, now.Add(-59*time.Minute-30*time.Second)); item.Status != StatusPass || leaks != nil {
+		t.Fatalf("new call: %s %q %+v", item.Status, item.Message, leaks)
+	}
+	probe.ListError = "HTTP Error 403: Forbidden"
+	if item, _ := evaluateChannelLeaks(&probe, DefaultMaxCallAge, now); item.Status != StatusWarn || item.Details != probe.ListError {
+		t.Fatalf("list error: %+v", item)
+	}
+	if item, _ := evaluateChannelLeaks(nil, DefaultMaxCallAge, now); item.Status != StatusSkip {
+		t.Fatalf("no ARI: %s", item.Status)
+	}
+}
@@ -0,0 +1,128 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+const (
+	// logSchemaSampleLines is how many recent engine log lines are validated.
+	logSchemaSampleLines = 2000
+	// logSchemaFileTail bounds how much of a log file is read for the sample.
+	logSchemaFileTail = 1 << 20
+	// logSchemaShownViolations caps the distinct violations listed in details.
+	logSchemaShownViolations = 8
+)
+
+// checkLogSchema validates a sample of recent engine log lines against the
+// schema agent rca and the call index parse.
+func (r *Runner) checkLogSchema() Item {
+	src, err := openEngineLogTail()
+	if err != nil {
+		return Item{Name: "Engine log schema", Status: StatusSkip, Message: "engine logs unavailable", Details: err.Error()}
+	}
+	defer src.Close()
+	res, err := logschema.Validate(src)
+	if err != nil {
+		return Item{Name: "Engine log schema", Status: StatusSkip, Message: "engine logs unreadable", Details: err.Error()}
+	}
+	return evaluateLogSchema(res)
+}
+
+// openEngineLogTail returns the last part of the engine log: the file's last
+// MiB when logs go to a file, else the container's last lines.
+func openEngineLogTail() (io.ReadCloser, error) {
+	path, ok := deployment.Current().EngineLogFile()
+	if !ok {
+		return dockerapi.LogsStream(deployment.EngineContainer(), dockerapi.LogsOptions{Tail: logSchemaSampleLines})
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if st.Size() <= logSchemaFileTail {
+		return f, nil
+	}
+	if _, err := f.Seek(st.Size()-logSchemaFileTail, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	_, _ = br.ReadString('\n') // drop the partial first line
+	return struct {
+		io.Reader
+		io.Closer
+	}{br, f}, nil
+}
+
+func evaluateLogSchema(res logschema.Result) Item {
+	item := Item{Name: "Engine log schema"}
+	if res.Lines == 0 {
+		item.Status, item.Message = StatusSkip, "no engine log lines to sample"
+		return item
+	}
+	if res.JSONLines == 0 {
+		item.Status = StatusWarn
+		item.Message = fmt.Sprintf("engine logs are not JSON (%d lines sampled)", res.Lines)
+		item.Details = "console output (LOG_FORMAT=console) is parsed best-effort and cannot be validated"
+		item.Remediation = "Set LOG_FORMAT=json in .env and recreate ai_engine"
+		return item
+	}
+
+	var seen []string
+	for name, n := range res.Events {
+		seen = append(seen, fmt.Sprintf("%s=%d", name, n))
+	}
+	sort.Strings(seen)
+	details := []string{"contract events: " + emptyTo(strings.Join(seen, ", "), "(none in sample)")}
+
+	if len(res.Violations) == 0 {
+		item.Status = StatusPass
+		item.Message = fmt.Sprintf("%d JSON lines match schema v%d", res.JSONLines, res.Version)
+		item.Details = strings.Join(details, "\n")
+		return item
+	}
+
+	// The same renamed field repeats on every call; group by what broke.
+	type group struct {
+		first logschema.Violation
+		count int
+	}
+	var order []string
+	groups := map[string]*group{}
+	for _, v := range res.Violations {
+		key := v.Event + "\x00" + v.Field + "\x00" + v.Problem
+		if g := groups[key]; g != nil {
+			g.count++
+			continue
+		}
+		groups[key] = &group{first: v, count: 1}
+		order = append(order, key)
+	}
+	for i, key := range order {
+		if i == logSchemaShownViolations {
+			details = append(details, fmt.Sprintf("... and %d more", len(order)-i))
+			break
+		}
+		g := groups[key]
+		details = append(details, fmt.Sprintf("%s (x%d)", g.first, g.count))
+	}
+	item.Status = StatusWarn
+	item.Message = fmt.Sprintf("%d violation(s) of schema v%d in %d JSON lines", len(res.Violations), res.Version, res.JSONLines)
+	item.Details = strings.Join(details, "\n")
+	item.Remediation = "Field names in ai_engine logs no longer match what agent rca parses, so RCA can miss evidence; run agent self-update to match the engine, or report the mismatch"
+	return item
+}
@@ -0,0 +1,34 @@
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+func TestEvaluateLogSchema(t *testing.T) {
+	good := `{"event":"PROVIDER SEGMENT BYTES","level":"info","timestamp":"2026-01-30T17:21:45Z","call_id":"1.2","provider_bytes":100,"enqueued_bytes":100,"enqueued_ratio":1}`
+	res, _ := logschema.Validate(strings.NewReader(good + "\n" + good))
+	if item := evaluateLogSchema(res); item.Status != StatusPass || !strings.Contains(item.Details, "PROVIDER SEGMENT BYTES=2") {
+		t.Fatalf("pass: %s %q", item.Status, item.Details)
+	}
+
+	// A renamed field repeats on every line but is listed once.
+	renamed := strings.Replace(good, `"enqueued_ratio"`, `"ratio"`, 1)
+	res, _ = logschema.Validate(strings.NewReader(renamed + "\n" + renamed + "\n" + good))
+	item := evaluateLogSchema(res)
+	if item.Status != StatusWarn || !strings.Contains(item.Message, "2 violation(s) of schema v1") ||
+		!strings.Contains(item.Details, `line 1: "PROVIDER SEGMENT BYTES" enqueued_ratio: missing (x2)`) {
+		t.Fatalf("renamed: %s %q %q", item.Status, item.Message, item.Details)
+	}
+
+	res, _ = logschema.Validate(strings.NewReader("2026-01-30 17:21:45 [info     ] PROVIDER SEGMENT BYTES call_id=1.2\n"))
+	if item := evaluateLogSchema(res); item.Status != StatusWarn || !strings.Contains(item.Remediation, "LOG_FORMAT=json") {
+		t.Fatalf("console: %s %q", item.Status, item.Remediation)
+	}
+
+	if item := evaluateLogSchema(logschema.Result{}); item.Status != StatusSkip {
+		t.Fatalf("empty: %s", item.Status)
+	}
+}
@@ -0,0 +1,37 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+)
+
+// pluginChecks runs the check plugins. A plugin that fails or answers with an
+// unknown status is reported as a warning rather than dropped.
+func (r *Runner) pluginChecks() []Item {
+	var items []Item
+	for _, p := range plugin.With(r.Plugins, plugin.HookCheck) {
+		got, err := p.Check(r.Version, r.Profile.Name)
+		if err != nil {
+			items = append(items, Item{
+				Name:        "Plugin " + p.Name,
+				Status:      StatusWarn,
+				Message:     "plugin check failed",
+				Details:     err.Error(),
+				Remediation: fmt.Sprintf("Run %s check by hand, or remove it from the plugin directory", p.Path),
+			})
+			continue
+		}
+		for _, it := range got {
+			item := Item{Name: it.Name, Status: Status(it.Status), Message: it.Message, Details: it.Details, Remediation: it.Remediation}
+			switch item.Status {
+			case StatusPass, StatusWarn, StatusFail, StatusSkip:
+			default:
+				item.Status = StatusWarn
+				item.Details = fmt.Sprintf("plugin %s returned unknown status %q", p.Name, it.Status)
+			}
+			items = append(items, item)
+		}
+	}
+	return items
+}
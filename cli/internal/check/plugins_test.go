@@ -0,0 +1,43 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+)
+
+func TestPluginChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugins")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+describe) echo '{"hooks":["check"]}' ;;
+check) echo '{"items":[{"name":"SBC","status":"fail","message":"trunk down"},{"name":"SBC TLS","status":"ok","message":"?"}]}' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "sbc"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "down"), []byte("#!/bin/sh\n[ \"$1\" = describe ] && echo '{\"hooks\":[\"check\"]}' && exit 0\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	r := &Runner{Version: "1.0", Profile: Profile{Name: "full"}, Plugins: plugin.Discover(dir)}
+	items := r.pluginChecks()
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3: %+v", len(items), items)
+	}
+	if items[0].Name != "Plugin down" || items[0].Status != StatusWarn {
+		t.Errorf("a failing plugin should warn: %+v", items[0])
+	}
+	if items[1].Status != StatusFail || items[1].Message != "trunk down" {
+		t.Errorf("item = %+v", items[1])
+	}
+	if items[2].Status != StatusWarn || items[2].Details == "" {
+		t.Errorf("an unknown status should warn with a reason: %+v", items[2])
+	}
+}
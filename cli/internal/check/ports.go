@@ -0,0 +1,203 @@
+package check
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hostinfo"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+)
+
+// stackPort is a port the stack expects to own, and which processes may hold it.
+type stackPort struct {
+	Name     string
+	Proto    string // "tcp" or "udp"
+	Port     int
+	Owners   []string // expected process names, matched against /proc/<pid>/comm
+	Required bool     // when false, "not listening" is idle rather than a warning
+}
+
+// portState is the observed state of a single port.
+type portState int
+
+const (
+	portIdle portState = iota
+	portOwned
+	portStolen
+	portInUseUnknown
+)
+
+// checkPortBindings looks up who holds each port the stack needs on this host:
+// ARI (when Asterisk is local), AudioSocket, the ExternalMedia RTP range and the
+// admin UI. It tells a port that is idle from one another process took, from
+// /proc/net and /proc/<pid>/fd, or bind probes where /proc is not available.
+func (r *Runner) checkPortBindings(cfg *configSummary, env *envSummary, ci *containerInspect) Item {
+	const name = "Port Bindings"
+	if kube.Enabled() {
+		return Item{Name: name, Status: StatusSkip, Message: "skipped (Kubernetes; ports belong to the pods)"}
+	}
+	if dockerHostIsRemote() {
+		return Item{Name: name, Status: StatusSkip, Message: "DOCKER_HOST is remote; ports must be checked on that host"}
+	}
+	ports := stackPorts(cfg, env, ci)
+
+	sockets, procErr := hostinfo.Sockets()
+	var owners map[string]hostinfo.Owner
+	if procErr == nil {
+		owners = hostinfo.SocketOwners()
+	}
+
+	var lines, stolen, idleRequired []string
+	unknown := 0
+	for _, p := range ports {
+		state, holder, addr := classifyPort(p, sockets, owners, procErr == nil)
+		label := fmt.Sprintf("%s %s/%d", p.Name, p.Proto, p.Port)
+		switch state {
+		case portOwned:
+			lines = append(lines, fmt.Sprintf("✓ %s held by %s (pid %d) on %s", label, holder.Comm, holder.PID, addr))
+		case portStolen:
+			stolen = append(stolen, label)
+			lines = append(lines, fmt.Sprintf("✗ %s held by unexpected process %s (pid %d) on %s; expected %s",
+				label, holder.Comm, holder.PID, addr, strings.Join(p.Owners, "/")))
+		case portInUseUnknown:
+			unknown++
+			where := addr
+			if where == "" {
+				where = "bind probe"
+			}
+			lines = append(lines, fmt.Sprintf("? %s in use (%s); owner unknown, re-run as root for process details", label, where))
+		default:
+			if p.Required {
+				idleRequired = append(idleRequired, label)
+				lines = append(lines, fmt.Sprintf("- %s not listening", label))
+			} else if r.Verbose {
+				lines = append(lines, fmt.Sprintf("- %s idle", label))
+			}
+		}
+	}
+	if procErr != nil && r.Verbose {
+		lines = append(lines, fmt.Sprintf("(/proc/net unavailable: %v; used bind probes instead)", procErr))
+	}
+	details := strings.Join(lines, "\n")
+
+	switch {
+	case len(stolen) > 0:
+		return Item{
+			Name:        name,
+			Status:      StatusFail,
+			Message:     "port conflict: " + strings.Join(stolen, ", ") + " held by another process",
+			Details:     details,
+			Remediation: "Stop the conflicting process or move the stack to free ports (audiosocket.port, external_media.port_range, UVICORN_PORT); for RTP overlaps, narrow Asterisk rtp.conf rtpstart/rtpend",
+		}
+	case len(idleRequired) > 0:
+		return Item{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     "idle, not listening: " + strings.Join(idleRequired, ", "),
+			Details:     details,
+			Remediation: "Check that the owning service is running: docker compose ps (logs: docker logs ai_engine)",
+		}
+	case unknown > 0:
+		return Item{Name: name, Status: StatusPass, Message: fmt.Sprintf("%d port(s) in use by processes that could not be identified", unknown), Details: details}
+	}
+	return Item{Name: name, Status: StatusPass, Message: fmt.Sprintf("%d stack port(s) checked, no conflicts", len(ports)), Details: details}
+}
+
+// stackPorts lists the ports the stack needs from the engine's effective config
+// and environment.
+func stackPorts(cfg *configSummary, env *envSummary, ci *containerInspect) []stackPort {
+	transport := "audiosocket"
+	asPort := 8090
+	var rtpStart, rtpEnd int
+	if cfg != nil {
+		if t := strings.ToLower(strings.TrimSpace(cfg.AudioTransport)); t != "" {
+			transport = t
+		}
+		if cfg.AudioSocket.Port > 0 {
+			asPort = cfg.AudioSocket.Port
+		}
+		if transport == "externalmedia" {
+			rtpStart, rtpEnd, _ = rtpPortRange(cfg.ExternalMedia.PortRange, cfg.ExternalMedia.RTPPort)
+		}
+	}
+	engine := []string{"python", "python3", "docker-proxy"}
+
+	var ports []stackPort
+	// ARI is only bound on this host when Asterisk runs locally.
+	if env != nil {
+		if h := strings.TrimSpace(env.AsteriskHost); h == "" || h == "127.0.0.1" || h == "localhost" {
+			ports = append(ports, stackPort{Name: "ARI", Proto: "tcp", Port: portOr(env.AsteriskARIPort, 8088), Owners: []string{"asterisk", "docker-proxy"}, Required: true})
+		}
+	}
+	ports = append(ports, stackPort{Name: "AudioSocket", Proto: "tcp", Port: asPort, Owners: engine, Required: transport == "audiosocket"})
+	for p := rtpStart; p > 0 && p <= rtpEnd; p++ {
+		ports = append(ports, stackPort{Name: "ExternalMedia RTP", Proto: "udp", Port: p, Owners: engine})
+	}
+	uiPort := ""
+	if ci != nil {
+		uiPort = envValue(ci.Config.Env, "UVICORN_PORT")
+	}
+	ports = append(ports, stackPort{Name: "Admin UI", Proto: "tcp", Port: portOr(uiPort, 3003), Owners: []string{"python", "python3", "uvicorn", "docker-proxy"}})
+	return ports
+}
+
+// classifyPort decides whether a port is idle, held by an expected process,
+// held by something else, or held by a process that cannot be identified.
+func classifyPort(p stackPort, sockets []hostinfo.Socket, owners map[string]hostinfo.Owner, procOK bool) (portState, hostinfo.Owner, string) {
+	if !procOK {
+		if hostinfo.BindInUse(p.Proto, p.Port) {
+			return portInUseUnknown, hostinfo.Owner{}, ""
+		}
+		return portIdle, hostinfo.Owner{}, ""
+	}
+	unknownAddr := ""
+	for _, s := range sockets {
+		if s.Proto != p.Proto || s.Port != p.Port {
+			continue
+		}
+		addr := net.JoinHostPort(s.Addr, strconv.Itoa(s.Port))
+		holder, ok := owners[s.Inode]
+		if !ok {
+			unknownAddr = addr
+			continue
+		}
+		if !ownerExpected(holder.Comm, p.Owners) {
+			return portStolen, holder, addr
+		}
+		return portOwned, holder, addr
+	}
+	if unknownAddr != "" {
+		return portInUseUnknown, hostinfo.Owner{}, unknownAddr
+	}
+	return portIdle, hostinfo.Owner{}, ""
+}
+
+func ownerExpected(comm string, owners []string) bool {
+	comm = strings.ToLower(strings.TrimSpace(comm))
+	for _, o := range owners {
+		if strings.HasPrefix(comm, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// envValue returns key's value from a container's KEY=value environment.
+func envValue(env []string, key string) string {
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+func portOr(raw string, def int) int {
+	p, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || p < 1 || p > 65535 {
+		return def
+	}
+	return p
+}
@@ -0,0 +1,60 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hostinfo"
+)
+
+func TestClassifyPort(t *testing.T) {
+	p := stackPort{Name: "AudioSocket", Proto: "tcp", Port: 8090, Owners: []string{"python3"}}
+	sockets := []hostinfo.Socket{{Proto: "tcp", Addr: "0.0.0.0", Port: 8090, Inode: "1"}}
+
+	if st, _, _ := classifyPort(p, sockets, map[string]hostinfo.Owner{"1": {PID: 10, Comm: "python3"}}, true); st != portOwned {
+		t.Errorf("python3 holder: state %d, want owned", st)
+	}
+	st, holder, addr := classifyPort(p, sockets, map[string]hostinfo.Owner{"1": {PID: 11, Comm: "nginx"}}, true)
+	if st != portStolen || holder.Comm != "nginx" || addr != "0.0.0.0:8090" {
+		t.Errorf("nginx holder: state %d holder %+v addr %q", st, holder, addr)
+	}
+	if st, _, _ := classifyPort(p, sockets, nil, true); st != portInUseUnknown {
+		t.Errorf("unknown holder: state %d, want in use", st)
+	}
+	if st, _, _ := classifyPort(p, nil, nil, true); st != portIdle {
+		t.Errorf("no socket: state %d, want idle", st)
+	}
+	// A UDP socket on the same number is not the TCP port.
+	udp := []hostinfo.Socket{{Proto: "udp", Addr: "0.0.0.0", Port: 8090, Inode: "2"}}
+	if st, _, _ := classifyPort(p, udp, nil, true); st != portIdle {
+		t.Errorf("udp socket: state %d, want idle", st)
+	}
+}
+
+func TestStackPorts(t *testing.T) {
+	cfg := &configSummary{AudioTransport: "externalmedia"}
+	cfg.ExternalMedia.PortRange = "18080:18081"
+	env := &envSummary{AsteriskHost: "10.0.0.5"}
+	ci := &containerInspect{}
+	ci.Config.Env = []string{"PATH=/usr/bin", "UVICORN_PORT=4000"}
+
+	var got []string
+	for _, p := range stackPorts(cfg, env, ci) {
+		got = append(got, p.Name)
+		if p.Name == "AudioSocket" && p.Required {
+			t.Errorf("AudioSocket required under externalmedia")
+		}
+		if p.Name == "Admin UI" && p.Port != 4000 {
+			t.Errorf("Admin UI port = %d, want 4000", p.Port)
+		}
+	}
+	// Remote Asterisk: no ARI port on this host.
+	want := []string{"AudioSocket", "ExternalMedia RTP", "ExternalMedia RTP", "Admin UI"}
+	if len(got) != len(want) {
+		t.Fatalf("ports = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ports = %v, want %v", got, want)
+		}
+	}
+}
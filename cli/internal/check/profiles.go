@@ -0,0 +1,172 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Profile selects which checks a run performs and how long each docker probe may
+// take. The zero Profile runs everything with the full timeout.
+type Profile struct {
+	Name        string
+	Description string
+	Timeout     time.Duration // per docker command / in-container probe
+	skip        map[string]bool
+	limits      *hostLimits // nil uses defaultHostLimits
+}
+
+// hostLimits are the thresholds the host resource and local model checks
+// warn at.
+type hostLimits struct {
+	minCPUs      int
+	minMemory    int64   // total RAM
+	minAvailable int64   // MemAvailable
+	loadPerCPU   float64 // 1-minute load average per CPU
+	maxTempC     float64 // CPU temperature
+	modelShare   float64 // of RAM (or the container limit) local models may use
+}
+
+var defaultHostLimits = hostLimits{
+	minCPUs:      2,
+	minMemory:    2 << 30,
+	minAvailable: 512 << 20,
+	loadPerCPU:   1.0,
+	maxTempC:     85,
+	modelShare:   0.75,
+}
+
+// constrainedHostLimits suit a Raspberry Pi class machine: less memory is
+// normal, but slow cores leave less room before audio stutters, and the SoC
+// throttles from 80°C.
+var constrainedHostLimits = hostLimits{
+	minCPUs:      2,
+	minMemory:    1 << 30,
+	minAvailable: 256 << 20,
+	loadPerCPU:   0.7,
+	maxTempC:     80,
+	modelShare:   0.5,
+}
+
+// Check keys used by Profile.skip.
+const (
+	checkKeyTopology   = "topology"
+	checkKeyLocalAI    = "local_ai"
+	checkKeyModels     = "models"
+	checkKeyPaths      = "paths"
+	checkKeyHistory    = "call_history"
+	checkKeyAgentsDB   = "agents_db"
+	checkKeyFirewall   = "firewall"
+	checkKeyCodecs     = "codecs"
+	checkKeyFreePBX    = "freepbx"
+	checkKeyDialplan   = "dialplan"
+	checkKeyNetwork    = "network"
+	checkKeyEnvDrift   = "env_drift"
+	checkKeySecrets    = "provider_keys"
+	checkKeyDNS        = "dns"
+	checkKeyTLS        = "tls"
+	checkKeyProviderWS = "provider_ws"
+	checkKeyLogSchema  = "log_schema"
+	checkKeyEngine     = "engine_status"
+	checkKeyLeaks      = "channel_leaks"
+	checkKeyResources  = "resource_trend"
+	checkKeyGreeting   = "greeting_media"
+	checkKeyContexts   = "context_files"
+	checkKeyLanguages  = "languages"
+	checkKeyHostRes    = "host_resources"
+	checkKeyImageArch  = "image_arch"
+	checkKeyModelMem   = "model_memory"
+	checkKeyPorts      = "ports"
+)
+
+// DefaultProfile is used when no --profile is given.
+const DefaultProfile = "full"
+
+const defaultTimeout = 30 * time.Second
+
+// Profiles are the sets `agent check --profile` accepts.
+var Profiles = []Profile{
+	{
+		Name:        "quick",
+		Description: "Docker, ai_engine, config and ARI only; short timeouts",
+		Timeout:     5 * time.Second,
+		skip: map[string]bool{
+			checkKeyTopology: true, checkKeyLocalAI: true, checkKeyModels: true, checkKeyPaths: true,
+			checkKeyHistory: true, checkKeyAgentsDB: true, checkKeyFirewall: true, checkKeyCodecs: true,
+			checkKeyFreePBX: true, checkKeyNetwork: true, checkKeyTLS: true, checkKeyProviderWS: true,
+			checkKeyDNS: true, checkKeyLogSchema: true, checkKeyResources: true,
+			checkKeyGreeting: true, checkKeyHostRes: true, checkKeyImageArch: true, checkKeyModelMem: true,
+			checkKeyPorts: true,
+		},
+	},
+	{
+		Name:        "full",
+		Description: "Every check (default)",
+		Timeout:     defaultTimeout,
+	},
+	{
+		Name:        "pre-update",
+		Description: "State an update must preserve (mounts, databases, config) and outbound reachability",
+		Timeout:     15 * time.Second,
+		skip:        map[string]bool{checkKeyFirewall: true, checkKeyCodecs: true, checkKeyFreePBX: true, checkKeyDialplan: true},
+	},
+	{
+		Name:        "post-update",
+		Description: "What an update can break: containers, mounts, config, transport and ARI; PBX-side checks skipped",
+		Timeout:     15 * time.Second,
+		skip:        map[string]bool{checkKeyFirewall: true, checkKeyCodecs: true, checkKeyFreePBX: true, checkKeyNetwork: true, checkKeyResources: true, checkKeyHostRes: true},
+	},
+	{
+		Name:        "constrained",
+		Description: "ARM64 and small hosts such as a Raspberry Pi: every check, with CPU/memory thresholds for them and longer timeouts",
+		Timeout:     60 * time.Second,
+		limits:      &constrainedHostLimits,
+	},
+}
+
+// SuggestProfile picks the profile for a Docker host from its architecture
+// (uname -m or GOARCH style) and total memory: constrained for ARM and hosts
+// with less than 4 GiB, otherwise DefaultProfile.
+func SuggestProfile(arch string, memTotal int64) string {
+	switch normalizeArch(arch) {
+	case "arm64", "arm":
+		return "constrained"
+	}
+	if memTotal > 0 && memTotal < 4<<30 {
+		return "constrained"
+	}
+	return DefaultProfile
+}
+
+// FindProfile looks a profile up by name.
+func FindProfile(name string) (Profile, error) {
+	for _, p := range Profiles {
+		if p.Name == strings.ToLower(strings.TrimSpace(name)) {
+			return p, nil
+		}
+	}
+	names := make([]string, 0, len(Profiles))
+	for _, p := range Profiles {
+		names = append(names, p.Name)
+	}
+	return Profile{}, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// runs reports whether the runner's profile includes the check.
+func (r *Runner) runs(key string) bool {
+	return !r.Profile.skip[key]
+}
+
+func (r *Runner) limits() hostLimits {
+	if r.Profile.limits != nil {
+		return *r.Profile.limits
+	}
+	return defaultHostLimits
+}
+
+func (r *Runner) timeout() time.Duration {
+	if r.Profile.Timeout > 0 {
+		return r.Profile.Timeout
+	}
+	return defaultTimeout
+}
@@ -0,0 +1,55 @@
+package check
+
+import "testing"
+
+func TestProfilesSelectChecks(t *testing.T) {
+	t.Parallel()
+
+	quick, err := FindProfile("Quick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Runner{Profile: quick}
+	if r.runs(checkKeyNetwork) || r.runs(checkKeyAgentsDB) || !r.runs(checkKeyDialplan) || !r.runs(checkKeyEngine) || r.timeout() != quick.Timeout {
+		t.Fatalf("quick profile selection wrong: %+v", quick)
+	}
+
+	post, _ := FindProfile("post-update")
+	r.Profile = post
+	if r.runs(checkKeyFirewall) || !r.runs(checkKeyAgentsDB) {
+		t.Fatalf("post-update profile selection wrong: %+v", post)
+	}
+
+	if (&Runner{}).timeout() != defaultTimeout || !(&Runner{}).runs(checkKeyFreePBX) {
+		t.Fatal("zero profile should run every check")
+	}
+	constrained, _ := FindProfile("constrained")
+	r.Profile = constrained
+	if !r.runs(checkKeyModelMem) || r.limits() != constrainedHostLimits || (&Runner{}).limits() != defaultHostLimits {
+		t.Fatalf("constrained profile selection wrong: %+v", constrained)
+	}
+	if _, err := FindProfile("nope"); err == nil {
+		t.Fatal("expected unknown profile error")
+	}
+}
+
+func TestSuggestProfile(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		arch string
+		mem  int64
+		want string
+	}{
+		{"aarch64", 8 << 30, "constrained"},
+		{"armv7l", 1 << 30, "constrained"},
+		{"x86_64", 2 << 30, "constrained"},
+		{"x86_64", 16 << 30, DefaultProfile},
+		{"", 0, DefaultProfile},
+	}
+	for _, c := range cases {
+		if got := SuggestProfile(c.arch, c.mem); got != c.want {
+			t.Errorf("SuggestProfile(%q, %d) = %q, want %q", c.arch, c.mem, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// providerFirstMessageWarn is when a slow first message starts to warn; the
+// engine waits for it before it can answer the caller.
+const providerFirstMessageWarn = 3 * time.Second
+
+// providerWSProbe is one realtime provider's websocket handshake as measured
+// from inside ai_engine.
+type providerWSProbe struct {
+	Provider       string `json:"provider"`
+	URL            string `json:"url"`
+	KeySet         bool   `json:"key_set"`
+	ConnectMS      *int   `json:"connect_ms"`
+	FirstMessageMS *int   `json:"first_message_ms"`
+	FirstMessage   string `json:"first_message"` // the message type
+	Status         int    `json:"status"`        // HTTP status of a rejected upgrade
+	Error          string `json:"error"`
+}
+
+// providerWSScript connects with the engine's own websockets library, so proxy
+// handling and TLS match what a call does. Each provider's first server message
+// completes its auth handshake: OpenAI sends session.created, Deepgram Welcome.
+const providerWSScript = `
+import asyncio, json, os, time
+import yaml
+import websockets
+
+def deep_merge(base, override):
+    merged = dict(base)
+    for k, v in override.items():
+        if isinstance(merged.get(k), dict) and isinstance(v, dict):
+            merged[k] = deep_merge(merged[k], v)
+        else:
+            merged[k] = v
+    return merged
+
+cfg = {}
+with open("/app/config/ai-agent.yaml") as f:
+    cfg = yaml.safe_load(f) or {}
+if os.path.isfile("/app/config/ai-agent.local.yaml"):
+    with open("/app/config/ai-agent.local.yaml") as f:
+        local = yaml.safe_load(f) or {}
+    if isinstance(local, dict):
+        cfg = deep_merge(cfg, local)
+providers = cfg.get("providers") or {}
+default = cfg.get("default_provider") or ""
+
+def key(env, pcfg):
+    v = os.getenv(env, "").strip()
+    if not v:
+        v = str(pcfg.get("api_key") or "").strip()
+        if v.startswith("${"):
+            v = ""
+    return v
+
+targets = []
+oa = providers.get("openai_realtime") or {}
+if oa.get("enabled") or default == "openai_realtime":
+    base = str(oa.get("base_url") or "").strip()
+    if base.startswith("${") or not base.startswith(("ws://", "wss://")):
+        base = "wss://api.openai.com/v1/realtime"
+    k = key("OPENAI_API_KEY", oa)
+    headers = [("Authorization", "Bearer " + k)]
+    if str(oa.get("api_version") or "ga").lower() == "beta":
+        headers.append(("OpenAI-Beta", "realtime=v1"))
+    if oa.get("organization"):
+        headers.append(("OpenAI-Organization", str(oa["organization"])))
+    if oa.get("project_id"):
+        headers.append(("OpenAI-Project", str(oa["project_id"])))
+    targets.append(("openai_realtime", base.rstrip("/") + "?model=" + str(oa.get("model") or "gpt-realtime"), headers, bool(k)))
+dg = providers.get("deepgram") or {}
+if dg.get("enabled") or default == "deepgram":
+    k = key("DEEPGRAM_API_KEY", dg)
+    url = str(dg.get("voice_agent_base_url") or "wss://agent.deepgram.com/v1/agent/converse")
+    targets.append(("deepgram", url, [("Authorization", "Token " + k)], bool(k)))
+
+async def probe(name, url, headers, key_set):
+    out = {"provider": name, "url": url.split("?")[0], "key_set": key_set, "connect_ms": None, "first_message_ms": None, "first_message": "", "status": 0, "error": ""}
+    if not key_set:
+        out["error"] = "API key not set"
+        return out
+    t0 = time.monotonic()
+    try:
+        async with websockets.connect(url, additional_headers=headers, open_timeout=%d, close_timeout=1) as ws:
+            out["connect_ms"] = int((time.monotonic() - t0) * 1000)
+            raw = await asyncio.wait_for(ws.recv(), timeout=%d)
+            out["first_message_ms"] = int((time.monotonic() - t0) * 1000)
+            try:
+                msg = json.loads(raw)
+                out["first_message"] = str(msg.get("type") or "")
+                if "error" in out["first_message"].lower():
+                    out["error"] = json.dumps(msg.get("error") or msg.get("description") or msg)[:300]
+            except Exception:
+                out["first_message"] = "(non-JSON)"
+    except Exception as e:
+        resp = getattr(e, "response", None)
+        out["status"] = int(getattr(resp, "status_code", 0) or getattr(e, "status_code", 0) or 0)
+        out["error"] = (type(e).__name__ + ": " + str(e))[:300]
+    return out
+
+async def main():
+    return await asyncio.gather(*(probe(*t) for t in targets))
+
+print(json.dumps({"probes": asyncio.run(main()) if targets else []}))
+`
+
+// checkProviderWebsockets opens a websocket to each enabled realtime provider
+// from inside ai_engine and completes the auth handshake. HTTP reachability
+// alone misses proxies and firewalls that block the websocket upgrade.
+func (r *Runner) checkProviderWebsockets() Item {
+	// Connect and first message each get a third of the exec timeout.
+	secs := int(r.timeout().Seconds()) / 3
+	switch {
+	case secs < 2:
+		secs = 2
+	case secs > 10:
+		secs = 10
+	}
+	raw, err := r.dockerExecPython(fmt.Sprintf(providerWSScript, secs, secs))
+	if err != nil {
+		return Item{Name: "Provider WebSocket", Status: StatusSkip, Message: "probe unavailable", Details: err.Error()}
+	}
+	var res struct {
+		Probes []providerWSProbe `json:"probes"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &res); err != nil {
+		return Item{Name: "Provider WebSocket", Status: StatusSkip, Message: "invalid probe output", Details: string(raw)}
+	}
+	return evaluateProviderWebsockets(res.Probes)
+}
+
+func evaluateProviderWebsockets(probes []providerWSProbe) Item {
+	const name = "Provider WebSocket"
+	if len(probes) == 0 {
+		return Item{Name: name, Status: StatusSkip, Message: "no realtime websocket provider enabled (openai_realtime, deepgram)"}
+	}
+	var details, failed, slow []string
+	for _, p := range probes {
+		line := p.Provider + " " + p.URL
+		if p.ConnectMS != nil {
+			line += fmt.Sprintf(" connect_ms=%d", *p.ConnectMS)
+		}
+		if p.FirstMessageMS != nil {
+			line += fmt.Sprintf(" first_message_ms=%d first_message=%s", *p.FirstMessageMS, emptyTo(p.FirstMessage, "(none)"))
+		}
+		if p.Status != 0 {
+			line += fmt.Sprintf(" http_status=%d", p.Status)
+		}
+		if p.Error != "" {
+			line += " error=" + p.Error
+		}
+		details = append(details, line)
+		switch {
+		case p.Error != "":
+			failed = append(failed, p.Provider)
+		case p.FirstMessageMS != nil && time.Duration(*p.FirstMessageMS)*time.Millisecond > providerFirstMessageWarn:
+			slow = append(slow, p.Provider)
+		}
+	}
+
+	if len(failed) > 0 {
+		remediation := "If HTTPS works but the websocket does not, a proxy or firewall is blocking the upgrade: allow wss:// to the provider, or set HTTPS_PROXY/NO_PROXY in .env and recreate ai_engine."
+		for _, p := range probes {
+			if p.Status == 401 || p.Status == 403 || !p.KeySet {
+				remediation = "Check the provider API key (OPENAI_API_KEY / DEEPGRAM_API_KEY; agent secrets status shows where it comes from), then recreate ai_engine: docker compose up -d --force-recreate ai_engine"
+				break
+			}
+		}
+		return Item{
+			Name:        name,
+			Status:      StatusFail,
+			Message:     "websocket handshake failed: " + strings.Join(failed, ", "),
+			Details:     strings.Join(details, "\n"),
+			Remediation: remediation,
+		}
+	}
+	if len(slow) > 0 {
+		return Item{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("slow first message from %s (over %s)", strings.Join(slow, ", "), providerFirstMessageWarn),
+			Details:     strings.Join(details, "\n"),
+			Remediation: "Callers hear silence while the session starts. Check proxy latency and DNS from the engine host.",
+		}
+	}
+	return Item{Name: name, Status: StatusPass, Message: "connected and authenticated", Details: strings.Join(details, "\n")}
+}
@@ -0,0 +1,39 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func ms(v int) *int { return &v }
+
+func TestEvaluateProviderWebsockets(t *testing.T) {
+	if item := evaluateProviderWebsockets(nil); item.Status != StatusSkip {
+		t.Fatalf("no providers: status = %s", item.Status)
+	}
+
+	ok := providerWSProbe{Provider: "openai_realtime", URL: "wss://api.openai.com/v1/realtime", KeySet: true, ConnectMS: ms(180), FirstMessageMS: ms(420), FirstMessage: "session.created"}
+	item := evaluateProviderWebsockets([]providerWSProbe{ok})
+	if item.Status != StatusPass || !strings.Contains(item.Details, "connect_ms=180 first_message_ms=420 first_message=session.created") {
+		t.Fatalf("pass: %s %q", item.Status, item.Details)
+	}
+
+	slow := providerWSProbe{Provider: "deepgram", URL: "wss://agent.deepgram.com/v1/agent/converse", KeySet: true, ConnectMS: ms(900), FirstMessageMS: ms(4200), FirstMessage: "Welcome"}
+	if item := evaluateProviderWebsockets([]providerWSProbe{ok, slow}); item.Status != StatusWarn || !strings.Contains(item.Message, "deepgram") {
+		t.Fatalf("slow: %s %q", item.Status, item.Message)
+	}
+
+	rejected := providerWSProbe{Provider: "deepgram", URL: "wss://agent.deepgram.com/v1/agent/converse", KeySet: true, Status: 401, Error: "InvalidStatus: server rejected WebSocket connection: HTTP 401"}
+	item = evaluateProviderWebsockets([]providerWSProbe{ok, rejected})
+	if item.Status != StatusFail || !strings.Contains(item.Message, "deepgram") || !strings.Contains(item.Remediation, "API key") {
+		t.Fatalf("auth: %s %q %q", item.Status, item.Message, item.Remediation)
+	}
+	if !strings.Contains(item.Details, "http_status=401") {
+		t.Errorf("details missing status: %q", item.Details)
+	}
+
+	blocked := providerWSProbe{Provider: "openai_realtime", URL: "wss://api.openai.com/v1/realtime", KeySet: true, Error: "TimeoutError: timed out during opening handshake"}
+	if item := evaluateProviderWebsockets([]providerWSProbe{blocked}); item.Status != StatusFail || !strings.Contains(item.Remediation, "proxy or firewall") {
+		t.Fatalf("blocked: %s %q", item.Status, item.Remediation)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
 )
 
 type Status string
@@ -27,9 +31,19 @@ type Item struct {
 }
 
 type Report struct {
-	Version   string    `json:"version"`
-	BuildTime string    `json:"build_time"`
-	Timestamp time.Time `json:"timestamp"`
+	SchemaVersion int       `json:"schema_version"`
+	Version       string    `json:"version"`
+	BuildTime     string    `json:"build_time"`
+	Timestamp     time.Time `json:"timestamp"`
+	Profile       string    `json:"profile,omitempty"`
+	// AsteriskStartedAt is when Asterisk last started, as reported by ARI.
+	AsteriskStartedAt *time.Time `json:"asterisk_started_at,omitempty"`
+	// ChannelLeaks are the stuck calls, orphaned helper channels and leaked
+	// bridges found over ARI, for agent check --cleanup.
+	ChannelLeaks []asterisk.Leak `json:"channel_leaks,omitempty"`
+	// ResourceSample is the engine's latest memory and file-descriptor reading,
+	// kept by agent check for the next run's resource trend.
+	ResourceSample *ResourceSample `json:"resource_sample,omitempty"`
 
 	Items []Item `json:"items"`
 
@@ -57,15 +71,50 @@ func (r *Report) finalizeCounts() {
 	r.Total = len(r.Items)
 }
 
+// RunSummary is what agent check keeps of each run for agent digest: the
+// counts and the checks that failed, without their details.
+type RunSummary struct {
+	At      time.Time `json:"at"`
+	Profile string    `json:"profile,omitempty"`
+	Pass    int       `json:"pass"`
+	Warn    int       `json:"warn"`
+	Fail    int       `json:"fail"`
+	Failed  []Item    `json:"failed,omitempty"`
+}
+
+// Summary returns the run's summary.
+func (r *Report) Summary() RunSummary {
+	r.finalizeCounts()
+	s := RunSummary{At: r.Timestamp, Profile: r.Profile, Pass: r.PassCount, Warn: r.WarnCount, Fail: r.FailCount}
+	for _, item := range r.Items {
+		if item.Status == StatusFail {
+			s.Failed = append(s.Failed, Item{Name: item.Name, Status: item.Status, Message: item.Message})
+		}
+	}
+	return s
+}
+
 func (r *Report) OutputJSON(w io.Writer) error {
 	r.finalizeCounts()
+	r.SchemaVersion = contract.SchemaVersion
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(r)
 }
 
-func (r *Report) OutputText(w io.Writer) {
+// Encode writes the report in a structured --output format.
+func (r *Report) Encode(w io.Writer, f output.Format) error {
+	r.finalizeCounts()
+	r.SchemaVersion = contract.SchemaVersion
+	return output.Write(w, f, r)
+}
+
+// OutputText prints the report for a terminal. The operator profile leaves
+// out passing and skipped checks, their details and the build line; support
+// and developer print everything.
+func (r *Report) OutputText(w io.Writer, p output.Profile) {
 	r.finalizeCounts()
+	operator := p == output.Operator
 
 	green := color.New(color.FgGreen, color.Bold).SprintFunc()
 	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
@@ -84,12 +133,18 @@ func (r *Report) OutputText(w io.Writer) {
 	if r.Version != "" {
 		fmt.Fprintf(w, "%s %s\n", gray("CLI Version:"), r.Version)
 	}
-	if r.BuildTime != "" && r.BuildTime != "unknown" {
+	if r.BuildTime != "" && r.BuildTime != "unknown" && !operator {
 		fmt.Fprintf(w, "%s %s\n", gray("Build:"), r.BuildTime)
 	}
+	if r.Profile != "" && r.Profile != DefaultProfile {
+		fmt.Fprintf(w, "%s %s\n", gray("Profile:"), r.Profile)
+	}
 	fmt.Fprintln(w)
 
 	for i, item := range r.Items {
+		if operator && (item.Status == StatusPass || item.Status == StatusSkip) {
+			continue
+		}
 		var icon string
 		var paint func(a ...interface{}) string
 		switch item.Status {
@@ -111,7 +166,7 @@ func (r *Report) OutputText(w io.Writer) {
 		}
 
 		fmt.Fprintf(w, "[%d/%d] %-26s %s %s\n", i+1, r.Total, item.Name+"...", icon, paint(item.Message))
-		if item.Details != "" {
+		if item.Details != "" && !operator {
 			fmt.Fprintf(w, "      %s\n", gray(item.Details))
 		}
 		if item.Remediation != "" && (item.Status == StatusFail || item.Status == StatusWarn) {
@@ -139,3 +194,25 @@ func (r *Report) OutputText(w io.Writer) {
 	}
 	fmt.Fprintln(w)
 }
+
+// OutputQuiet prints one plain line per warning or failure and the overall result,
+// without banners, colors or emojis.
+func (r *Report) OutputQuiet(w io.Writer) {
+	r.finalizeCounts()
+	for _, item := range r.Items {
+		if item.Status != StatusWarn && item.Status != StatusFail {
+			continue
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", strings.ToUpper(string(item.Status)), item.Name, item.Message)
+		if item.Remediation != "" {
+			fmt.Fprintf(w, "  remediation: %s\n", item.Remediation)
+		}
+	}
+	overall := "PASS"
+	if r.FailCount > 0 {
+		overall = "FAIL"
+	} else if r.WarnCount > 0 {
+		overall = "WARN"
+	}
+	fmt.Fprintf(w, "%s pass=%d warn=%d fail=%d skip=%d\n", overall, r.PassCount, r.WarnCount, r.FailCount, r.SkipCount)
+}
@@ -0,0 +1,249 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ResourceSample is one reading of the engine process's memory and open files.
+// agent check keeps them (see Report.ResourceSample) so a later run can tell a
+// slow leak from a busy afternoon.
+type ResourceSample struct {
+	At time.Time `json:"at"`
+	// ContainerStarted ties the sample to one ai_engine container; a restart
+	// starts a new series.
+	ContainerStarted time.Time `json:"container_started"`
+	RSSBytes         int64     `json:"rss_bytes"`
+	FDs              int       `json:"fds"`
+	Threads          int       `json:"threads,omitempty"`
+}
+
+// Window sampling: a few readings a couple of seconds apart catch a fast leak
+// without making agent check noticeably slower.
+const (
+	resourceWindowSamples  = 5
+	resourceWindowInterval = 2 * time.Second
+)
+
+// Leak thresholds. Python's RSS moves with every call, so growth only counts
+// when it is both mostly one-way and large.
+const (
+	resourceWindowRSSGrowth = 32 << 20 // within the sampling window
+	resourceWindowFDGrowth  = 20
+	resourceTrendMinSpan    = time.Hour
+	resourceTrendMinSamples = 3
+	resourceTrendRising     = 0.7      // share of changes that were increases
+	resourceTrendRSSGrowth  = 64 << 20 // and at least 25% of the first sample
+	resourceTrendFDGrowth   = 100      // and at least 50% of the first sample
+)
+
+// resourceProbeScript samples the engine process (main.py, else PID 1) from
+// /proc inside the container.
+const resourceProbeScript = `
+import json, os, time
+
+n, interval = %d, %f
+pid = 1
+for p in os.listdir("/proc"):
+    if not p.isdigit() or int(p) == os.getpid():
+        continue
+    try:
+        with open("/proc/%%s/cmdline" %% p, "rb") as f:
+            if b"main.py" in f.read():
+                pid = int(p)
+                break
+    except Exception:
+        pass
+
+out = {"pid": pid, "samples": [], "error": None}
+try:
+    for i in range(n):
+        s = {"t": time.time(), "rss_kb": 0, "fds": len(os.listdir("/proc/%%d/fd" %% pid)), "threads": 0}
+        with open("/proc/%%d/status" %% pid) as f:
+            for line in f:
+                if line.startswith("VmRSS:"):
+                    s["rss_kb"] = int(line.split()[1])
+                elif line.startswith("Threads:"):
+                    s["threads"] = int(line.split()[1])
+        out["samples"].append(s)
+        if i < n - 1:
+            time.sleep(interval)
+except Exception as e:
+    out["error"] = str(e)
+print(json.dumps(out))
+`
+
+type resourceProbe struct {
+	PID     int `json:"pid"`
+	Samples []struct {
+		T       float64 `json:"t"`
+		RSSKB   int64   `json:"rss_kb"`
+		FDs     int     `json:"fds"`
+		Threads int     `json:"threads"`
+	} `json:"samples"`
+	Error string `json:"error"`
+}
+
+// checkResourceTrend samples the engine's memory and file descriptors over a
+// short window and, with the samples earlier runs kept, over the container's
+// lifetime.
+func (r *Runner) checkResourceTrend(ci *containerInspect) (Item, *ResourceSample) {
+	raw, err := r.dockerExecPython(fmt.Sprintf(resourceProbeScript, resourceWindowSamples, resourceWindowInterval.Seconds()))
+	if err != nil {
+		return Item{Name: "Resource trend", Status: StatusWarn, Message: "probe failed", Details: err.Error()}, nil
+	}
+	var probe resourceProbe
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &probe); err != nil {
+		return Item{Name: "Resource trend", Status: StatusWarn, Message: "invalid probe output", Details: string(raw)}, nil
+	}
+	if len(probe.Samples) == 0 {
+		return Item{Name: "Resource trend", Status: StatusWarn, Message: "could not read the engine process", Details: probe.Error}, nil
+	}
+	window := make([]ResourceSample, 0, len(probe.Samples))
+	for _, s := range probe.Samples {
+		window = append(window, ResourceSample{
+			At:               time.Unix(0, int64(s.T*float64(time.Second))),
+			ContainerStarted: ci.State.StartedAt,
+			RSSBytes:         s.RSSKB << 10,
+			FDs:              s.FDs,
+			Threads:          s.Threads,
+		})
+	}
+	last := window[len(window)-1]
+	return evaluateResourceTrend(window, r.ResourceHistory, r.CallStarts, ci.HostConfig.Memory), &last
+}
+
+// evaluateResourceTrend looks for one-way growth in the window and across the
+// history samples from the same container, estimates the memory each call
+// leaves behind from the calls started in between, and projects when a memory
+// limit would be reached.
+func evaluateResourceTrend(window, history []ResourceSample, callStarts []time.Time, memLimit int64) Item {
+	item := Item{Name: "Resource trend"}
+	last := window[len(window)-1]
+	first := window[0]
+	details := []string{fmt.Sprintf("rss=%s fds=%d threads=%d", formatBytes(last.RSSBytes), last.FDs, last.Threads)}
+	if memLimit > 0 {
+		details = append(details, fmt.Sprintf("memory_limit=%s (%.0f%% used)", formatBytes(memLimit), 100*float64(last.RSSBytes)/float64(memLimit)))
+	}
+	var findings []string
+
+	span := last.At.Sub(first.At).Round(time.Second)
+	details = append(details, fmt.Sprintf("window: %d samples over %s, rss %s, fds %+d", len(window), span, formatBytesDelta(last.RSSBytes-first.RSSBytes), last.FDs-first.FDs))
+	if g := last.RSSBytes - first.RSSBytes; g >= resourceWindowRSSGrowth && risingShare(window, rssOf) == 1 {
+		findings = append(findings, fmt.Sprintf("memory grew %s in %s", formatBytes(g), span))
+	}
+	if g := last.FDs - first.FDs; g >= resourceWindowFDGrowth && risingShare(window, fdsOf) == 1 {
+		findings = append(findings, fmt.Sprintf("open files grew by %d in %s", g, span))
+	}
+
+	// The kept samples from this container, then the newest reading.
+	var series []ResourceSample
+	for _, s := range history {
+		if s.ContainerStarted.Equal(last.ContainerStarted) && s.At.Before(first.At) {
+			series = append(series, s)
+		}
+	}
+	series = append(series, last)
+	head := series[0]
+	trendSpan := last.At.Sub(head.At)
+	if len(series) < resourceTrendMinSamples || trendSpan < resourceTrendMinSpan {
+		details = append(details, fmt.Sprintf("trend: %d sample(s) from this container over %s; need %d over %s (re-run agent check periodically)",
+			len(series), trendSpan.Round(time.Minute), resourceTrendMinSamples, resourceTrendMinSpan))
+	} else {
+		calls := 0
+		for _, t := range callStarts {
+			if t.After(head.At) && !t.After(last.At) {
+				calls++
+			}
+		}
+		grew := last.RSSBytes - head.RSSBytes
+		trend := fmt.Sprintf("trend: %d samples over %s, rss %s -> %s, fds %d -> %d, %d call(s)",
+			len(series), formatSpan(trendSpan), formatBytes(head.RSSBytes), formatBytes(last.RSSBytes), head.FDs, last.FDs, calls)
+		perCall := ""
+		if calls > 0 {
+			perCall = fmt.Sprintf("%s per call", formatBytesDelta(grew/int64(calls)))
+			trend += ", " + perCall
+		}
+		details = append(details, trend)
+
+		if grew >= resourceTrendRSSGrowth && grew*4 >= head.RSSBytes && risingShare(series, rssOf) >= resourceTrendRising {
+			f := fmt.Sprintf("memory grew from %s to %s over %s", formatBytes(head.RSSBytes), formatBytes(last.RSSBytes), formatSpan(trendSpan))
+			if perCall != "" {
+				f += " (~" + perCall + ")"
+			}
+			if memLimit > last.RSSBytes {
+				eta := time.Duration(float64(memLimit-last.RSSBytes) / float64(grew) * float64(trendSpan))
+				f += fmt.Sprintf("; at this rate the %s limit is reached in ~%s", formatBytes(memLimit), formatSpan(eta))
+			}
+			findings = append(findings, f)
+		}
+		if g := last.FDs - head.FDs; g >= resourceTrendFDGrowth && 2*g >= head.FDs && risingShare(series, fdsOf) >= resourceTrendRising {
+			findings = append(findings, fmt.Sprintf("open files grew from %d to %d over %s", head.FDs, last.FDs, formatSpan(trendSpan)))
+		}
+	}
+
+	item.Details = strings.Join(details, "\n")
+	if len(findings) == 0 {
+		item.Status = StatusPass
+		item.Message = fmt.Sprintf("%s RSS, %d open files, no sustained growth", formatBytes(last.RSSBytes), last.FDs)
+		return item
+	}
+	item.Status = StatusWarn
+	item.Message = "possible leak: " + strings.Join(findings, "; ")
+	item.Remediation = "Compare with agent check after the next calls. If growth continues, restart ai_engine in a quiet period (docker compose restart ai_engine) and report it with this output."
+	return item
+}
+
+func rssOf(s ResourceSample) int64 { return s.RSSBytes }
+func fdsOf(s ResourceSample) int64 { return int64(s.FDs) }
+
+// risingShare is the share of changes between consecutive samples that were
+// increases; unchanged readings do not count either way.
+func risingShare(series []ResourceSample, value func(ResourceSample) int64) float64 {
+	up, changes := 0, 0
+	for i := 1; i < len(series); i++ {
+		switch d := value(series[i]) - value(series[i-1]); {
+		case d > 0:
+			up++
+			changes++
+		case d < 0:
+			changes++
+		}
+	}
+	if changes == 0 {
+		return 0
+	}
+	return float64(up) / float64(changes)
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.0f MiB", float64(n)/(1<<20))
+	default:
+		return fmt.Sprintf("%d KiB", n>>10)
+	}
+}
+
+func formatBytesDelta(n int64) string {
+	if n < 0 {
+		return "-" + formatBytes(-n)
+	}
+	return "+" + formatBytes(n)
+}
+
+func formatSpan(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%.0fd", d.Hours()/24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%.0fh", d.Hours())
+	default:
+		return d.Round(time.Minute).String()
+	}
+}
@@ -0,0 +1,63 @@
+package check
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateResourceTrend(t *testing.T) {
+	started := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	now := started.Add(6 * time.Hour)
+	sample := func(at time.Time, rssMiB int64, fds int) ResourceSample {
+		return ResourceSample{At: at, ContainerStarted: started, RSSBytes: rssMiB << 20, FDs: fds}
+	}
+	window := func(rssMiB int64, fds int, step int64, fdStep int) []ResourceSample {
+		var w []ResourceSample
+		for i := 0; i < resourceWindowSamples; i++ {
+			w = append(w, sample(now.Add(time.Duration(i)*resourceWindowInterval), rssMiB+int64(i)*step, fds+i*fdStep))
+		}
+		return w
+	}
+
+	// A fresh container has no history yet.
+	item := evaluateResourceTrend(window(300, 80, 0, 0), nil, nil, 0)
+	if item.Status != StatusPass || !strings.Contains(item.Details, "trend: 1 sample(s)") {
+		t.Fatalf("no history: %s %q %q", item.Status, item.Message, item.Details)
+	}
+
+	// File descriptors climbing on every reading of the window.
+	if item := evaluateResourceTrend(window(300, 80, 0, 6), nil, nil, 0); item.Status != StatusWarn || !strings.Contains(item.Message, "open files grew by 24 in 8s") {
+		t.Fatalf("fd window: %s %q", item.Status, item.Message)
+	}
+
+	// 200 MiB -> 500 MiB over six hours with one dip, 150 calls, 1 GiB limit.
+	history := []ResourceSample{
+		sample(started.Add(time.Hour), 200, 60),
+		sample(started.Add(2*time.Hour), 260, 61),
+		sample(started.Add(3*time.Hour), 250, 60),
+		sample(started.Add(4*time.Hour), 340, 62),
+		sample(started.Add(5*time.Hour), 420, 61),
+		{At: started.Add(5 * time.Hour), ContainerStarted: started.Add(-time.Hour), RSSBytes: 900 << 20}, // previous container
+	}
+	var calls []time.Time
+	for i := 0; i < 150; i++ {
+		calls = append(calls, started.Add(time.Hour+time.Minute+time.Duration(i)*2*time.Minute))
+	}
+	item = evaluateResourceTrend(window(500, 62, 0, 0), history, calls, 1<<30)
+	for _, want := range []string{"memory grew from 200 MiB to 500 MiB over 5h", "~+2 MiB per call", "1.0 GiB limit is reached in ~9h"} {
+		if item.Status != StatusWarn || !strings.Contains(item.Message, want) {
+			t.Fatalf("trend: %s %q missing %q", item.Status, item.Message, want)
+		}
+	}
+	if !strings.Contains(item.Details, "trend: 6 samples over 5h, rss 200 MiB -> 500 MiB, fds 60 -> 62, 150 call(s)") {
+		t.Fatalf("details = %q", item.Details)
+	}
+
+	// The same growth going up and down is load, not a leak.
+	history[2].RSSBytes, history[4].RSSBytes = 150<<20, 180<<20
+	history[1].RSSBytes = 400 << 20
+	if item := evaluateResourceTrend(window(500, 62, 0, 0), history, calls, 0); item.Status != StatusPass {
+		t.Fatalf("noisy: %s %q", item.Status, item.Message)
+	}
+}
@@ -2,6 +2,7 @@ package check
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,12 +12,34 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hostinfo"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/privilege"
 )
 
 type Runner struct {
 	Verbose   bool
 	Version   string
 	BuildTime string
+	Profile   Profile
+	// MaxCallAge is how long a call may be up before it counts as stuck;
+	// zero uses DefaultMaxCallAge.
+	MaxCallAge time.Duration
+	// ResourceHistory are resource samples earlier runs kept and CallStarts
+	// the start times of recent calls, for the resource trend check.
+	ResourceHistory []ResourceSample
+	CallStarts      []time.Time
+	// Plugins add site checks after the built-in ones.
+	Plugins []plugin.Plugin
+
+	sysInfo    *dockerapi.SystemInfo // cached by dockerInfo
+	sysInfoErr error
 }
 
 func NewRunner(verbose bool, version, buildTime string) *Runner {
@@ -28,43 +51,89 @@ func (r *Runner) Run() (*Report, error) {
 		Version:   r.Version,
 		BuildTime: r.BuildTime,
 		Timestamp: time.Now(),
+		Profile:   r.Profile.Name,
 		Items:     []Item{},
 	}
 
 	// Host context (best-effort).
 	rep.Items = append(rep.Items, r.checkHost())
 
-	// Docker prerequisites.
-	if item := r.checkDockerCLI(); item.Status == StatusFail {
-		rep.Items = append(rep.Items, item)
-		rep.finalizeCounts()
-		return rep, errors.New("docker not available")
+	if kube.Enabled() {
+		// The cluster replaces docker and compose; pods stand in for containers.
+		if item := r.checkKubernetes(); item.Status == StatusFail {
+			rep.Items = append(rep.Items, item)
+			rep.finalizeCounts()
+			return rep, contract.EnvironmentError(errors.New("kubernetes API not reachable"))
+		} else {
+			rep.Items = append(rep.Items, item)
+		}
 	} else {
-		rep.Items = append(rep.Items, item)
+		// Docker prerequisites.
+		if item := r.checkDockerCLI(); item.Status == StatusFail {
+			rep.Items = append(rep.Items, item)
+			rep.finalizeCounts()
+			return rep, contract.EnvironmentError(errors.New("docker not available"))
+		} else {
+			rep.Items = append(rep.Items, item)
+		}
+		rep.Items = append(rep.Items, r.checkDockerDaemon())
+		rep.Items = append(rep.Items, r.checkCompose())
+		if r.runs(checkKeyTopology) {
+			rep.Items = append(rep.Items, r.checkComposeTopology())
+		}
 	}
-	rep.Items = append(rep.Items, r.checkDockerDaemon())
-	rep.Items = append(rep.Items, r.checkCompose())
 
-	// Container must exist for docker-exec probes.
-	inspect, inspectItem := r.inspectContainer("ai_engine")
+	// Container must exist and run for docker-exec probes.
+	inspect, inspectItem := r.inspectContainer(deployment.EngineContainer())
 	rep.Items = append(rep.Items, inspectItem)
-	if inspectItem.Status == StatusFail {
+	if inspect == nil || !inspect.State.Running {
 		rep.finalizeCounts()
-		return rep, errors.New("ai_engine container not available")
+		return rep, contract.EnvironmentError(errors.New("ai_engine container not available"))
 	}
 
 	rep.Items = append(rep.Items, r.checkNetworkMode(inspect))
 	rep.Items = append(rep.Items, r.checkMounts(inspect))
+	if r.runs(checkKeyHostRes) {
+		rep.Items = append(rep.Items, r.checkHostResources())
+	}
+	if r.runs(checkKeyImageArch) {
+		rep.Items = append(rep.Items, r.checkImageArchitecture())
+	}
+	if r.runs(checkKeyEnvDrift) {
+		rep.Items = append(rep.Items, r.checkEnvDrift(inspect))
+	}
+	if r.runs(checkKeySecrets) {
+		rep.Items = append(rep.Items, r.checkProviderKeys(inspect))
+	}
+	if r.runs(checkKeyContexts) {
+		rep.Items = append(rep.Items, r.checkContextFiles())
+	}
+	if r.runs(checkKeyLanguages) {
+		rep.Items = append(rep.Items, r.checkLanguages())
+	}
 
-	// Local AI server status (always reported; WARN if not running).
-	localAIInspect, localAIItem := r.inspectOptionalContainer("local_ai_server")
-	rep.Items = append(rep.Items, localAIItem)
-	rep.Items = append(rep.Items, r.checkModelsMount(inspect, localAIInspect))
+	// Local AI server status (reported unless the profile skips it; WARN if not running).
+	if r.runs(checkKeyLocalAI) {
+		localAIInspect, localAIItem := r.inspectOptionalContainer(deployment.LocalAIContainer())
+		rep.Items = append(rep.Items, localAIItem)
+		if r.runs(checkKeyModels) {
+			rep.Items = append(rep.Items, r.checkModelsMount(inspect, localAIInspect))
+		}
+		if r.runs(checkKeyModelMem) && localAIInspect != nil {
+			rep.Items = append(rep.Items, r.checkModelMemory(localAIInspect))
+		}
+	}
 
 	// In-container probes (python-only; no curl).
-	rep.Items = append(rep.Items, r.checkInContainerPaths())
-	rep.Items = append(rep.Items, r.checkCallHistorySQLite())
-	rep.Items = append(rep.Items, r.checkAgentsDB())
+	if r.runs(checkKeyPaths) {
+		rep.Items = append(rep.Items, r.checkInContainerPaths())
+	}
+	if r.runs(checkKeyHistory) {
+		rep.Items = append(rep.Items, r.checkCallHistorySQLite())
+	}
+	if r.runs(checkKeyAgentsDB) {
+		rep.Items = append(rep.Items, r.checkAgentsDB())
+	}
 
 	cfg, cfgItem := r.readEffectiveConfig()
 	rep.Items = append(rep.Items, cfgItem)
@@ -74,12 +143,60 @@ func (r *Runner) Run() (*Report, error) {
 
 	rep.Items = append(rep.Items, r.checkTransportCompatibility(cfg))
 	rep.Items = append(rep.Items, r.checkAdvertiseHosts(cfg, env, inspect))
+	if r.runs(checkKeyPorts) {
+		rep.Items = append(rep.Items, r.checkPortBindings(cfg, env, inspect))
+	}
+	if r.runs(checkKeyFirewall) {
+		rep.Items = append(rep.Items, r.checkRTPFirewall(cfg, inspect))
+	}
+	if r.runs(checkKeyCodecs) {
+		rep.Items = append(rep.Items, r.checkCodecNegotiation(cfg))
+	}
+	if r.runs(checkKeyFreePBX) {
+		rep.Items = append(rep.Items, r.checkFreePBX(cfg))
+	}
 
 	ari, ariItem := r.probeARI(cfg, env)
 	rep.Items = append(rep.Items, ariItem)
-	rep.Items = append(rep.Items, r.dialplanGuidance(cfg, env, ari))
+	if ari != nil {
+		rep.AsteriskStartedAt = parseARITime(ari.StartupTime)
+	}
+	if r.runs(checkKeyDialplan) {
+		rep.Items = append(rep.Items, r.dialplanGuidance(cfg, env, ari))
+	}
+	if r.runs(checkKeyEngine) {
+		rep.Items = append(rep.Items, r.checkEngineStatus())
+	}
+	if r.runs(checkKeyGreeting) {
+		rep.Items = append(rep.Items, r.checkGreetingMedia())
+	}
+	if r.runs(checkKeyResources) {
+		item, sample := r.checkResourceTrend(inspect)
+		rep.Items = append(rep.Items, item)
+		rep.ResourceSample = sample
+	}
+	if r.runs(checkKeyLeaks) {
+		item, leaks := r.checkChannelLeaks(ari, time.Now())
+		rep.Items = append(rep.Items, item)
+		rep.ChannelLeaks = leaks
+	}
 
-	rep.Items = append(rep.Items, r.bestEffortNetwork(env))
+	if r.runs(checkKeyNetwork) {
+		rep.Items = append(rep.Items, r.bestEffortNetwork(env))
+	}
+	if r.runs(checkKeyDNS) {
+		rep.Items = append(rep.Items, r.checkContainerDNS(env))
+	}
+	if r.runs(checkKeyTLS) {
+		rep.Items = append(rep.Items, r.checkTLS(env))
+	}
+	if r.runs(checkKeyProviderWS) {
+		rep.Items = append(rep.Items, r.checkProviderWebsockets())
+	}
+	if r.runs(checkKeyLogSchema) {
+		rep.Items = append(rep.Items, r.checkLogSchema())
+	}
+	rep.Items = append(rep.Items, r.pluginChecks()...)
 
 	rep.finalizeCounts()
 	if rep.FailCount > 0 {
@@ -92,17 +209,21 @@ func (r *Runner) Run() (*Report, error) {
 }
 
 func (r *Runner) checkHost() Item {
-	host, _ := os.Hostname()
-	kernel := runtime.GOOS + "/" + runtime.GOARCH
-	if out, err := exec.Command("uname", "-r").Output(); err == nil {
-		kernel = strings.TrimSpace(string(out))
+	// The Docker host: this machine, or the remote daemon's from the Docker API.
+	info, err := hostinfo.Current().Describe()
+	if err != nil && info.Hostname == "" {
+		return Item{Name: "Host", Status: StatusPass, Message: "Host context unavailable", Details: err.Error()}
+	}
+	kernel := info.Kernel
+	if kernel == "" {
+		kernel = runtime.GOOS + "/" + runtime.GOARCH
 	}
 
 	return Item{
 		Name:    "Host",
 		Status:  StatusPass,
 		Message: "Host context collected",
-		Details: fmt.Sprintf("hostname=%s\nkernel=%s", host, kernel),
+		Details: fmt.Sprintf("hostname=%s\nkernel=%s\nos=%s", info.Hostname, kernel, info.OS),
 	}
 }
 
@@ -119,8 +240,21 @@ func (r *Runner) checkDockerCLI() Item {
 }
 
 func (r *Runner) checkDockerDaemon() Item {
-	cmd := exec.Command("docker", "info")
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "docker", "info")
 	if out, err := cmd.CombinedOutput(); err != nil {
+		if notPermitted(out) {
+			if op, ok := privilege.Find(privilege.Diagnose(), privilege.OpDocker); ok && op.Status == privilege.StatusDenied {
+				return Item{
+					Name:        "Docker Daemon",
+					Status:      StatusFail,
+					Message:     "no permission to use the Docker socket",
+					Details:     op.Message,
+					Remediation: op.Fix,
+				}
+			}
+		}
 		return Item{
 			Name:        "Docker Daemon",
 			Status:      StatusFail,
@@ -154,26 +288,42 @@ func (r *Runner) checkCompose() Item {
 }
 
 type containerInspect struct {
-	ID   string `json:"Id"`
-	Name string `json:"Name"`
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	Image        string `json:"Image"` // image ID
+	RestartCount int    `json:"RestartCount"`
 
 	Config struct {
 		Image  string            `json:"Image"`
 		Labels map[string]string `json:"Labels"`
+		Env    []string          `json:"Env"`
 	} `json:"Config"`
 
 	State struct {
-		Status  string `json:"Status"`
-		Running bool   `json:"Running"`
-		Health  *struct {
+		Status     string    `json:"Status"`
+		Running    bool      `json:"Running"`
+		Restarting bool      `json:"Restarting"`
+		StartedAt  time.Time `json:"StartedAt"`
+		OOMKilled  bool      `json:"OOMKilled"`
+		ExitCode   int       `json:"ExitCode"`
+		Error      string    `json:"Error"`
+		Health     *struct {
 			Status string `json:"Status"`
 		} `json:"Health"`
 	} `json:"State"`
 
 	HostConfig struct {
 		NetworkMode string `json:"NetworkMode"`
+		Memory      int64  `json:"Memory"` // limit in bytes; 0 when unlimited
 	} `json:"HostConfig"`
 
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"Ports"`
+	} `json:"NetworkSettings"`
+
 	Mounts []struct {
 		Source      string `json:"Source"`
 		Destination string `json:"Destination"`
@@ -183,7 +333,10 @@ type containerInspect struct {
 }
 
 func (r *Runner) inspectContainer(name string) (*containerInspect, Item) {
-	out, err := exec.Command("docker", "inspect", name).CombinedOutput()
+	out, err := dockerapi.Inspect(name)
+	if err != nil && kube.Enabled() {
+		return nil, Item{Name: "Container " + name, Status: StatusFail, Message: "no pod found", Details: strings.TrimSpace(string(out)), Remediation: missingPodRemediation(name)}
+	}
 	if err != nil {
 		return nil, Item{
 			Name:        "Container " + name,
@@ -214,21 +367,40 @@ func (r *Runner) inspectContainer(name string) (*containerInspect, Item) {
 		fmt.Sprintf("image=%s", ci.Config.Image),
 		fmt.Sprintf("status=%s", ci.State.Status),
 		fmt.Sprintf("network_mode=%s", ci.HostConfig.NetworkMode),
+		fmt.Sprintf("restarts=%d", ci.RestartCount),
 	}
 	if health != "" {
 		details = append(details, "health="+health)
 	}
 
+	// A running engine in a restart loop still fails, but the probes below can run.
+	remediation := ""
+	if problems, crashing := r.crashDiagnosis(name, &ci); len(problems) > 0 {
+		st = StatusFail
+		msg = crashMessage(problems)
+		details = append(problems, details...)
+		if crashing {
+			if tb := lastStackTrace(name); tb != "" {
+				details = append(details, "", "Last "+name+" stack trace:", tb)
+			}
+			remediation = "Run: agent rca (and docker logs --tail 200 " + name + ")"
+		}
+	}
+
 	return &ci, Item{
-		Name:    "Container " + name,
-		Status:  st,
-		Message: msg,
-		Details: strings.Join(details, "\n"),
+		Name:        "Container " + name,
+		Status:      st,
+		Message:     msg,
+		Details:     strings.Join(details, "\n"),
+		Remediation: remediation,
 	}
 }
 
 func (r *Runner) inspectOptionalContainer(name string) (*containerInspect, Item) {
-	out, err := exec.Command("docker", "inspect", name).CombinedOutput()
+	out, err := dockerapi.Inspect(name)
+	if err != nil && kube.Enabled() {
+		return nil, Item{Name: "Container " + name, Status: StatusWarn, Message: "no pod found (optional)", Details: strings.TrimSpace(string(out)), Remediation: missingPodRemediation(name)}
+	}
 	if err != nil {
 		return nil, Item{
 			Name:        "Container " + name,
@@ -261,10 +433,17 @@ func (r *Runner) inspectOptionalContainer(name string) (*containerInspect, Item)
 		fmt.Sprintf("image=%s", ci.Config.Image),
 		fmt.Sprintf("status=%s", ci.State.Status),
 		fmt.Sprintf("network_mode=%s", ci.HostConfig.NetworkMode),
+		fmt.Sprintf("restarts=%d", ci.RestartCount),
 	}
 	if health != "" {
 		details = append(details, "health="+health)
 	}
+	if problems, _ := r.crashDiagnosis(name, &ci); len(problems) > 0 {
+		st = StatusWarn
+		msg = crashMessage(problems)
+		details = append(problems, details...)
+		remediation = "Check: docker logs --tail 200 " + name
+	}
 
 	return &ci, Item{
 		Name:        "Container " + name,
@@ -447,24 +626,22 @@ print(json.dumps({"paths": out}))
 }
 
 func dockerHostIsRemote() bool {
-	host := strings.TrimSpace(os.Getenv("DOCKER_HOST"))
-	if host == "" {
-		return false
-	}
-	if strings.HasPrefix(host, "unix://") {
-		return host != "unix:///var/run/docker.sock"
-	}
-	return true
+	return deployment.Current().Remote()
 }
 
 func (r *Runner) dockerExecPython(script string) ([]byte, error) {
-	cmd := exec.Command("docker", "exec", "-i", "ai_engine", "python", "-")
-	cmd.Stdin = strings.NewReader(script)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return out, fmt.Errorf("docker exec python failed: %w\n%s", err, strings.TrimSpace(string(out)))
+	return r.dockerExecPythonIn(deployment.EngineContainer(), script)
+}
+
+func (r *Runner) dockerExecPythonIn(container, script string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	var buf bytes.Buffer
+	opts := dockerapi.ExecOptions{Stdin: strings.NewReader(script), Stdout: &buf, Stderr: &buf}
+	if err := dockerapi.Exec(ctx, container, opts, "python", "-"); err != nil {
+		return buf.Bytes(), fmt.Errorf("docker exec python failed: %w\n%s", err, strings.TrimSpace(buf.String()))
 	}
-	return out, nil
+	return buf.Bytes(), nil
 }
 
 func (r *Runner) checkInContainerPaths() Item {
@@ -649,6 +826,7 @@ type configSummary struct {
 		RTPHost    string   `json:"rtp_host"`
 		RTPPort    int      `json:"rtp_port"`
 		PortRange  string   `json:"port_range"`
+		Codec      string   `json:"codec"`
 		AllowedIPs []string `json:"allowed_remote_hosts"`
 	} `json:"external_media"`
 }
@@ -695,6 +873,7 @@ try:
             "rtp_host": (external_media.get("rtp_host") or ""),
             "rtp_port": int(external_media.get("rtp_port") or 0),
             "port_range": (external_media.get("port_range") or ""),
+            "codec": (external_media.get("codec") or ""),
             "allowed_remote_hosts": list(external_media.get("allowed_remote_hosts") or []),
         },
     }
@@ -887,6 +1066,11 @@ type ariProbe struct {
 	AsteriskVersion string `json:"asterisk_version,omitempty"`
 	AppName         string `json:"app_name,omitempty"`
 	AppRegistered   bool   `json:"app_registered,omitempty"`
+	StartupTime     string `json:"startup_time,omitempty"`
+	// Channels and Bridges feed the leak check; ListError is why they are missing.
+	Channels  []asterisk.Channel `json:"channels,omitempty"`
+	Bridges   []asterisk.Bridge  `json:"bridges,omitempty"`
+	ListError string             `json:"list_error,omitempty"`
 }
 
 func (r *Runner) probeARI(cfg *configSummary, env *envSummary) (*ariProbe, Item) {
@@ -938,6 +1122,7 @@ try:
         try:
             j = json.loads(data.decode("utf-8"))
             out["asterisk_version"] = j.get("system", {}).get("version")
+            out["startup_time"] = j.get("status", {}).get("startup_time")
         except Exception:
             pass
 
@@ -955,6 +1140,15 @@ try:
 except Exception as e:
     out["error"] = str(e)
 
+if out["ok"]:
+    try:
+        with req(base + "/ari/channels") as resp:
+            out["channels"] = json.loads(resp.read().decode("utf-8"))
+        with req(base + "/ari/bridges") as resp:
+            out["bridges"] = json.loads(resp.read().decode("utf-8"))
+    except Exception as e:
+        out["list_error"] = str(e)
+
 print(json.dumps(out))
 `, expectedApp)
 
@@ -982,6 +1176,9 @@ print(json.dumps(out))
 	if probe.AsteriskVersion != "" {
 		details = append(details, "asterisk_version="+probe.AsteriskVersion)
 	}
+	if started := parseARITime(probe.StartupTime); started != nil {
+		details = append(details, "asterisk_started="+started.Local().Format(time.RFC3339))
+	}
 	if probe.AppRegistered {
 		details = append(details, "ari_app_registered=true")
 	} else {
@@ -999,6 +1196,16 @@ print(json.dumps(out))
 	return &probe, Item{Name: "ARI", Status: StatusPass, Message: msg, Details: strings.Join(details, "\n")}
 }
 
+// parseARITime reads an ARI timestamp such as 2026-03-10T09:15:02.123+0000.
+func parseARITime(s string) *time.Time {
+	for _, layout := range []string{"2006-01-02T15:04:05.000-0700", time.RFC3339Nano} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
 func (r *Runner) dialplanGuidance(cfg *configSummary, env *envSummary, ari *ariProbe) Item {
 	app := "asterisk-ai-voice-agent"
 	if cfg != nil && strings.TrimSpace(cfg.AppName) != "" {
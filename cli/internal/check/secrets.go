@@ -0,0 +1,109 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
+)
+
+// checkProviderKeys reports where the provider API keys resolve from. With a
+// store other than .env configured, keys still in plain .env are flagged, and
+// so are stored keys ai_engine was not created with: compose passes them only
+// from a rendered env_file, or when the service lists them under environment:
+// and the shell that ran docker compose up had them set.
+func (r *Runner) checkProviderKeys(ci *containerInspect) Item {
+	const name = "Provider Keys"
+	store, err := secrets.Store()
+	if err != nil {
+		return Item{Name: name, Status: StatusWarn, Message: "invalid secrets setting", Details: err.Error(), Remediation: "Set secrets: to env, keyring, file, sops, vault, aws or gcp in .agent/deployment.yaml"}
+	}
+	if err := secrets.Check(); err != nil {
+		return Item{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     "cannot read the " + secrets.Label(store),
+			Details:     err.Error(),
+			Remediation: "Check the store's tool and credentials (secret-tool, age and AAVA_AGE_IDENTITY, sops, VAULT_ADDR and VAULT_TOKEN, the aws or gcloud login); agent secrets status shows what resolves",
+		}
+	}
+
+	dotenvPath := secrets.DotEnvPath()
+	if composePath, err := findComposeFile(); err == nil {
+		dotenvPath = filepath.Join(filepath.Dir(composePath), ".env")
+	}
+	raw, _ := os.ReadFile(dotenvPath)
+	dotenv := parseDotEnv(raw)
+	sources, plain, notPassed := keyPlacement(store, func(key string) (string, string) { return secrets.Lookup(key, dotenv) }, ci.Config.Env)
+	if len(sources) == 0 {
+		return Item{Name: name, Status: StatusSkip, Message: "no provider keys set (local pipelines need none)"}
+	}
+
+	var details []string
+	for _, key := range secrets.Names {
+		if source, ok := sources[key]; ok {
+			details = append(details, key+": "+source)
+		}
+	}
+	switch {
+	case len(plain) > 0:
+		return Item{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("%d provider key(s) still in plain .env while secrets: is %s", len(plain), store),
+			Details:     strings.Join(append(details, "plain: "+strings.Join(plain, ", ")), "\n"),
+			Remediation: "Move them into the " + secrets.Label(store) + ": agent secrets migrate",
+		}
+	case len(notPassed) > 0:
+		return Item{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("ai_engine does not have %d stored provider key(s)", len(notPassed)),
+			Details:     strings.Join(append(details, "missing or different in ai_engine: "+strings.Join(notPassed, ", ")), "\n"),
+			Remediation: "Render them for env_file (agent secrets render, with the override from agent secrets template compose) or list them under ai_engine environment: and use agent secrets run; then recreate ai_engine: docker compose up -d --force-recreate ai_engine",
+		}
+	}
+	return Item{
+		Name:    name,
+		Status:  StatusPass,
+		Message: fmt.Sprintf("%d provider key(s) set (secrets: %s)", len(sources), store),
+		Details: strings.Join(details, "\n"),
+	}
+}
+
+// keyPlacement returns where each set provider key resolves from, the keys
+// found in plain .env although a store is configured, and the keys resolved
+// from the store that containerEnv lacks or holds a different value for.
+func keyPlacement(store string, lookup func(string) (string, string), containerEnv []string) (map[string]string, []string, []string) {
+	live := make(map[string]string, len(containerEnv))
+	for _, kv := range containerEnv {
+		k, v, _ := strings.Cut(kv, "=")
+		live[k] = v
+	}
+	sources := map[string]string{}
+	var plain, notPassed []string
+	for _, key := range secrets.Names {
+		value, source := lookup(key)
+		if value == "" {
+			continue
+		}
+		sources[key] = source
+		switch source {
+		case secrets.SourceDotEnv:
+			if store != secrets.StoreEnv {
+				plain = append(plain, key)
+			}
+		case secrets.SourceEnvironment:
+		default: // held by the store
+			if live[key] != value {
+				notPassed = append(notPassed, key)
+			}
+		}
+	}
+	sort.Strings(plain)
+	sort.Strings(notPassed)
+	return sources, plain, notPassed
+}
@@ -0,0 +1,37 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
+)
+
+func TestKeyPlacement(t *testing.T) {
+	t.Parallel()
+
+	resolved := map[string][2]string{
+		"OPENAI_API_KEY":   {"sk-ring", secrets.SourceKeyring},
+		"DEEPGRAM_API_KEY": {"dg-vault", "Vault secret/aava-agent"},
+		"GROQ_API_KEY":     {"gsk-plain", secrets.SourceDotEnv},
+		"XAI_API_KEY":      {"xai-shell", secrets.SourceEnvironment},
+	}
+	lookup := func(key string) (string, string) { return resolved[key][0], resolved[key][1] }
+	container := []string{"OPENAI_API_KEY=sk-ring", "DEEPGRAM_API_KEY=dg-old", "GROQ_API_KEY=gsk-plain"}
+
+	sources, plain, notPassed := keyPlacement(secrets.StoreKeyring, lookup, container)
+	if len(sources) != 4 || sources["GROQ_API_KEY"] != secrets.SourceDotEnv {
+		t.Errorf("sources = %v", sources)
+	}
+	if !reflect.DeepEqual(plain, []string{"GROQ_API_KEY"}) {
+		t.Errorf("plain = %v", plain)
+	}
+	if !reflect.DeepEqual(notPassed, []string{"DEEPGRAM_API_KEY"}) {
+		t.Errorf("notPassed = %v", notPassed)
+	}
+
+	// Keys in .env are expected when the store is env.
+	if _, plain, _ := keyPlacement(secrets.StoreEnv, lookup, container); plain != nil {
+		t.Errorf("env store: plain = %v", plain)
+	}
+}
@@ -0,0 +1,257 @@
+package check
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// certExpiryWarn is how close to expiry a certificate starts to warn.
+const certExpiryWarn = 21 * 24 * time.Hour
+
+// tlsProviderEndpoints are the hosts the realtime providers connect to over wss://.
+var tlsProviderEndpoints = []string{"api.openai.com", "api.deepgram.com", "generativelanguage.googleapis.com"}
+
+// tlsProbe is what the in-container script reports.
+type tlsProbe struct {
+	CAFile      string            `json:"cafile"`
+	CAPath      string            `json:"capath"`
+	CAFileOK    bool              `json:"cafile_exists"`
+	CACerts     int               `json:"ca_certs"`
+	CAOverrides map[string]bool   `json:"ca_overrides"` // env var -> file exists
+	Proxies     map[string]string `json:"proxies"`
+	Endpoints   []tlsEndpoint     `json:"endpoints"`
+}
+
+type tlsEndpoint struct {
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	ViaProxy bool   `json:"via_proxy"`
+	Verify   bool   `json:"verify"`
+	Verified bool   `json:"verified"`
+	Error    string `json:"error"`
+	Cert     string `json:"cert"` // base64 DER of the leaf, even when verification failed
+}
+
+const tlsProbeScript = `
+import base64, json, os, socket, ssl, urllib.parse, urllib.request
+
+endpoints = json.loads(%q)
+out = {"ca_overrides": {}, "proxies": {}, "endpoints": []}
+paths = ssl.get_default_verify_paths()
+out["cafile"] = paths.cafile or ""
+out["capath"] = paths.capath or ""
+out["cafile_exists"] = bool(paths.cafile and os.path.isfile(paths.cafile))
+try:
+    out["ca_certs"] = ssl.create_default_context().cert_store_stats().get("x509_ca", 0)
+except Exception:
+    out["ca_certs"] = 0
+for var in ("SSL_CERT_FILE", "REQUESTS_CA_BUNDLE", "CURL_CA_BUNDLE"):
+    v = os.getenv(var, "")
+    if v:
+        out["ca_overrides"][var] = os.path.exists(v)
+proxies = urllib.request.getproxies()
+for k in ("https", "http", "no"):
+    if proxies.get(k):
+        out["proxies"][k] = proxies[k]
+
+def connect(host, port, direct):
+    proxy = proxies.get("https") or proxies.get("http")
+    if proxy and not direct and not urllib.request.proxy_bypass_environment(host):
+        p = urllib.parse.urlsplit(proxy if "://" in proxy else "http://" + proxy)
+        s = socket.create_connection((p.hostname, p.port or 3128), timeout=4)
+        req = "CONNECT %%s:%%d HTTP/1.1\r\nHost: %%s:%%d\r\n" %% (host, port, host, port)
+        if p.username:
+            cred = urllib.parse.unquote(p.username) + ":" + urllib.parse.unquote(p.password or "")
+            req += "Proxy-Authorization: Basic " + base64.b64encode(cred.encode()).decode() + "\r\n"
+        s.sendall((req + "\r\n").encode())
+        resp = b""
+        while b"\r\n\r\n" not in resp and len(resp) < 8192:
+            chunk = s.recv(1024)
+            if not chunk:
+                break
+            resp += chunk
+        status = resp.split(b"\r\n", 1)[0].decode("latin-1", "replace")
+        if " 200" not in status:
+            s.close()
+            raise OSError("proxy CONNECT refused: " + status)
+        return s, True
+    return socket.create_connection((host, port), timeout=4), False
+
+for ep in endpoints:
+    item = dict(ep, via_proxy=False, verified=False, error="", cert="")
+    try:
+        sock, item["via_proxy"] = connect(ep["host"], ep["port"], ep["direct"])
+        ctx = ssl.create_default_context()
+        if not ep["verify"]:
+            ctx.check_hostname = False
+            ctx.verify_mode = ssl.CERT_NONE
+        try:
+            with ctx.wrap_socket(sock, server_hostname=ep["host"]) as tls:
+                item["verified"] = ep["verify"]
+                item["cert"] = base64.b64encode(tls.getpeercert(binary_form=True) or b"").decode()
+        except ssl.SSLCertVerificationError as e:
+            item["error"] = "certificate verify failed: " + (e.verify_message or str(e))
+            sock2, _ = connect(ep["host"], ep["port"], ep["direct"])
+            insecure = ssl._create_unverified_context()
+            with insecure.wrap_socket(sock2, server_hostname=ep["host"]) as tls:
+                item["cert"] = base64.b64encode(tls.getpeercert(binary_form=True) or b"").decode()
+    except Exception as e:
+        item["error"] = item["error"] or str(e)
+    out["endpoints"].append(item)
+
+print(json.dumps(out))
+`
+
+// checkTLS validates outbound TLS from inside ai_engine: the CA bundle the engine
+// verifies with, the proxy it will use, a verified handshake to each realtime
+// provider, and the certificate of ARI when it runs over https.
+func (r *Runner) checkTLS(env *envSummary) Item {
+	type target struct {
+		Name   string `json:"name"`
+		Host   string `json:"host"`
+		Port   int    `json:"port"`
+		Verify bool   `json:"verify"`
+		Direct bool   `json:"direct"` // the engine's ARI client does not use proxy env vars
+	}
+	var targets []target
+	for _, h := range tlsProviderEndpoints {
+		targets = append(targets, target{Name: h, Host: h, Port: 443, Verify: true})
+	}
+	if env != nil && strings.EqualFold(strings.TrimSpace(env.AsteriskARIScheme), "https") {
+		port := 8089
+		fmt.Sscanf(strings.TrimSpace(env.AsteriskARIPort), "%d", &port)
+		verify := true
+		switch strings.ToLower(strings.TrimSpace(env.AsteriskARISSLVerify)) {
+		case "0", "false", "no":
+			verify = false
+		}
+		targets = append(targets, target{Name: "ARI", Host: emptyTo(env.AsteriskHost, "127.0.0.1"), Port: port, Verify: verify, Direct: true})
+	}
+	spec, _ := json.Marshal(targets)
+
+	raw, err := r.dockerExecPython(fmt.Sprintf(tlsProbeScript, string(spec)))
+	if err != nil {
+		return Item{Name: "TLS", Status: StatusSkip, Message: "probe unavailable", Details: err.Error()}
+	}
+	var probe tlsProbe
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &probe); err != nil {
+		return Item{Name: "TLS", Status: StatusSkip, Message: "invalid probe output", Details: string(raw)}
+	}
+	return evaluateTLS(&probe, hostProxy(), time.Now())
+}
+
+// hostProxy returns the proxy configured for this CLI process, if any.
+func hostProxy() string {
+	for _, k := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if v := strings.TrimSpace(os.Getenv(k)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func evaluateTLS(p *tlsProbe, hostProxy string, now time.Time) Item {
+	var fails, warns, details []string
+
+	ca := p.CAFile
+	if ca == "" {
+		ca = p.CAPath
+	}
+	details = append(details, fmt.Sprintf("ca_bundle=%s ca_certs=%d", emptyTo(ca, "(none)"), p.CACerts))
+	if p.CACerts == 0 && !p.CAFileOK {
+		fails = append(fails, "no CA certificates found in the container")
+	}
+	for v, ok := range p.CAOverrides {
+		if !ok {
+			fails = append(fails, fmt.Sprintf("%s points to a file that does not exist in the container", v))
+		}
+	}
+
+	proxy := p.Proxies["https"]
+	if proxy == "" {
+		proxy = p.Proxies["http"]
+	}
+	if proxy != "" {
+		details = append(details,
+			"proxy="+redactProxy(proxy)+" no_proxy="+emptyTo(p.Proxies["no"], "(unset)"),
+			"note=realtime provider websockets use the proxy; ARI and aiohttp-based HTTP pipelines connect directly")
+	} else if hostProxy != "" {
+		warns = append(warns, fmt.Sprintf("this host uses proxy %s but ai_engine has no HTTPS_PROXY (add it to .env)", redactProxy(hostProxy)))
+	}
+
+	for _, ep := range p.Endpoints {
+		line := fmt.Sprintf("%s %s:%d", ep.Name, ep.Host, ep.Port)
+		if ep.ViaProxy {
+			line += " via_proxy=true"
+		}
+		var cert *x509.Certificate
+		if der, err := base64.StdEncoding.DecodeString(ep.Cert); err == nil && len(der) > 0 {
+			cert, _ = x509.ParseCertificate(der)
+		}
+		if cert != nil {
+			line += fmt.Sprintf(" issuer=%q expires=%s", cert.Issuer.CommonName, cert.NotAfter.Format("2006-01-02"))
+		}
+		switch {
+		case ep.Error != "" && strings.Contains(ep.Error, "certificate verify failed"):
+			line += " error=" + ep.Error
+			if ep.Name == "ARI" {
+				fails = append(fails, "ARI certificate does not verify: "+ep.Error)
+			} else {
+				fails = append(fails, fmt.Sprintf("%s certificate does not verify (a TLS-inspecting proxy or missing CA?): %s", ep.Host, ep.Error))
+			}
+		case ep.Error != "" && strings.Contains(ep.Error, "proxy CONNECT"):
+			line += " error=" + ep.Error
+			warns = append(warns, fmt.Sprintf("%s: %s", ep.Host, ep.Error))
+		case ep.Error != "":
+			// Plain reachability is reported by Internet/DNS; only note it here.
+			line += " error=" + ep.Error
+		case ep.Verified:
+			line += " verified=true"
+		default:
+			line += " verified=false (ASTERISK_ARI_SSL_VERIFY=false)"
+		}
+		if cert != nil {
+			left := cert.NotAfter.Sub(now)
+			switch {
+			case left <= 0:
+				fails = append(fails, fmt.Sprintf("%s certificate expired on %s", ep.Name, cert.NotAfter.Format("2006-01-02")))
+			case left < certExpiryWarn:
+				warns = append(warns, fmt.Sprintf("%s certificate expires in %d day(s)", ep.Name, int(left.Hours()/24)))
+			}
+		}
+		details = append(details, line)
+	}
+
+	all := strings.Join(append(append(append([]string{}, fails...), warns...), details...), "\n")
+	switch {
+	case len(fails) > 0:
+		return Item{
+			Name:        "TLS",
+			Status:      StatusFail,
+			Message:     "outbound TLS from ai_engine is broken",
+			Details:     all,
+			Remediation: "If a corporate proxy inspects TLS, mount its CA into ai_engine and set SSL_CERT_FILE; renew expired ARI certificates",
+		}
+	case len(warns) > 0:
+		return Item{Name: "TLS", Status: StatusWarn, Message: "outbound TLS has warnings", Details: all, Remediation: "Set HTTPS_PROXY/NO_PROXY in .env and recreate ai_engine; renew certificates before they expire"}
+	}
+	return Item{Name: "TLS", Status: StatusPass, Message: "CA bundle and provider certificates verify", Details: all}
+}
+
+// redactProxy hides credentials embedded in a proxy URL.
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return proxy
+	}
+	u.User = url.User("***")
+	return u.String()
+}
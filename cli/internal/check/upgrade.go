@@ -0,0 +1,383 @@
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+)
+
+// AsteriskAudit is the agent configuration the post-upgrade audit compares the
+// running Asterisk against. It is read on the host, so the audit works while
+// ai_engine is stopped.
+type AsteriskAudit struct {
+	Transport          string // audiosocket | externalmedia
+	ExternalMediaPorts string // external_media.port_range, e.g. 18080:18099
+
+	ARIBaseURL  string // scheme://host:port
+	ARIUsername string
+	ARIPassword string
+
+	// StatePath records the last audited Asterisk version so an upgrade is
+	// reported (.agent/asterisk-version).
+	StatePath string
+}
+
+// RunAsteriskAudit checks what an Asterisk major upgrade tends to break for the
+// agent: the AudioSocket channel driver, the ARI resources ai_engine calls, RTP
+// port ranges and removed channel drivers.
+func (r *Runner) RunAsteriskAudit(a AsteriskAudit) (*Report, error) {
+	rep := &Report{
+		Version:   r.Version,
+		BuildTime: r.BuildTime,
+		Timestamp: time.Now(),
+		Items:     []Item{},
+	}
+
+	out, source, err := asterisk.CLI("core show version")
+	if err != nil {
+		rep.Items = append(rep.Items, Item{
+			Name:        "Asterisk Version",
+			Status:      StatusFail,
+			Message:     "Asterisk CLI not reachable",
+			Details:     err.Error(),
+			Remediation: "Set ASTERISK_CONTAINER if Asterisk runs in a differently named container, or run this on the PBX host.",
+		})
+		rep.finalizeCounts()
+		return rep, contract.EnvironmentError(errors.New("asterisk not reachable"))
+	}
+	version, major := parseAsteriskVersion(out)
+	rep.Items = append(rep.Items, versionItem(version, major, source, a.StatePath))
+
+	modules, _, _ := asterisk.CLI("module show like audiosocket")
+	modulesConf, _ := asterisk.ReadConf("modules.conf")
+	rep.Items = append(rep.Items, audioSocketItem(a.Transport, parseModules(modules), modulesConf))
+
+	ariModules, _, _ := asterisk.CLI("module show like ari")
+	rep.Items = append(rep.Items, r.ariSchemaItem(a, parseModules(ariModules)))
+
+	rtpConf, _ := asterisk.ReadConf("rtp.conf")
+	rep.Items = append(rep.Items, rtpItem(a.Transport, a.ExternalMediaPorts, asterisk.ParseConf(rtpConf)["general"]))
+
+	if major >= 21 {
+		sipConf, _ := asterisk.ReadConf("sip.conf")
+		rep.Items = append(rep.Items, chanSIPItem(sipConf))
+	}
+
+	rep.finalizeCounts()
+	if rep.FailCount > 0 {
+		return rep, errors.New("asterisk audit failed")
+	}
+	if version != "" && a.StatePath != "" {
+		if err := os.MkdirAll(filepath.Dir(a.StatePath), 0o755); err == nil {
+			_ = os.WriteFile(a.StatePath, []byte(version+"\n"), 0o644)
+		}
+	}
+	return rep, nil
+}
+
+var versionPattern = regexp.MustCompile(`Asterisk\s+(?:certified/)?((\d+)[\w.\-~]*)`)
+
+// parseAsteriskVersion reads `core show version` output.
+func parseAsteriskVersion(out string) (string, int) {
+	m := versionPattern.FindStringSubmatch(out)
+	if m == nil {
+		return "", 0
+	}
+	major, _ := strconv.Atoi(m[2])
+	return m[1], major
+}
+
+func versionItem(version string, major int, source, statePath string) Item {
+	const name = "Asterisk Version"
+	if version == "" {
+		return Item{Name: name, Status: StatusWarn, Message: "could not parse `core show version`", Details: "source=" + source}
+	}
+	details := []string{"source=" + source, "version=" + version}
+	msg := "Asterisk " + version
+	if b, err := os.ReadFile(statePath); err == nil {
+		prev := strings.TrimSpace(string(b))
+		_, prevMajor := parseAsteriskVersion("Asterisk " + prev)
+		details = append(details, "last_audited="+prev)
+		if prevMajor != 0 && prevMajor != major {
+			msg = fmt.Sprintf("Asterisk upgraded %d → %d (%s)", prevMajor, major, version)
+		}
+	}
+	if major < 18 {
+		return Item{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     msg,
+			Details:     strings.Join(details, "\n"),
+			Remediation: "Asterisk 18 or newer is required for chan_audiosocket; ExternalMedia needs 16.6 or newer.",
+		}
+	}
+	return Item{Name: name, Status: StatusPass, Message: msg, Details: strings.Join(details, "\n")}
+}
+
+// parseModules reads `module show like` output into module → running.
+func parseModules(out string) map[string]bool {
+	mods := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.HasSuffix(fields[0], ".so") {
+			continue
+		}
+		mods[fields[0]] = strings.Contains(line, "Running") && !strings.Contains(line, "Not Running")
+	}
+	return mods
+}
+
+// audioSocketItem: ai_engine originates AudioSocket/host:port/uuid channels over
+// ARI, which needs the chan_audiosocket channel driver. app_audiosocket only adds
+// the AudioSocket() dialplan application, and upgrades built from source often
+// leave the channel driver out of menuselect.
+func audioSocketItem(transport string, mods map[string]bool, modulesConf string) Item {
+	const name = "AudioSocket Modules"
+	var details []string
+	for _, m := range []string{"res_audiosocket.so", "chan_audiosocket.so", "app_audiosocket.so"} {
+		state := "not loaded"
+		if running, ok := mods[m]; ok && running {
+			state = "running"
+		} else if ok {
+			state = "not running"
+		}
+		details = append(details, m+"="+state)
+	}
+	noload := noloadModules(modulesConf)
+	for _, m := range []string{"res_audiosocket.so", "chan_audiosocket.so"} {
+		if noload[m] {
+			details = append(details, "modules.conf: noload => "+m)
+		}
+	}
+
+	if transport != "audiosocket" {
+		return Item{Name: name, Status: StatusSkip, Message: "audio_transport is " + emptyTo(transport, "unset"), Details: strings.Join(details, "\n")}
+	}
+	if mods["chan_audiosocket.so"] && mods["res_audiosocket.so"] {
+		return Item{Name: name, Status: StatusPass, Message: "chan_audiosocket and res_audiosocket are running", Details: strings.Join(details, "\n")}
+	}
+	msg := "chan_audiosocket is not running"
+	if mods["app_audiosocket.so"] {
+		msg = "only app_audiosocket is running; ai_engine needs the chan_audiosocket channel driver"
+	}
+	return Item{
+		Name:    name,
+		Status:  StatusFail,
+		Message: msg,
+		Details: strings.Join(details, "\n"),
+		Remediation: "Load it: asterisk -rx \"module load chan_audiosocket.so\" (remove any noload line in modules.conf). " +
+			"Source builds: enable chan_audiosocket under Channel Drivers in menuselect, rebuild and reinstall. " +
+			"Or switch the agent to audio_transport: externalmedia.",
+	}
+}
+
+// noloadModules lists modules.conf noload entries.
+func noloadModules(modulesConf string) map[string]bool {
+	out := map[string]bool{}
+	for _, line := range strings.Split(modulesConf, "\n") {
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(k), ">")) == "noload" {
+			out[strings.TrimSpace(strings.TrimPrefix(v, ">"))] = true
+		}
+	}
+	return out
+}
+
+// ariChannelsDoc is the part of /ari/api-docs/channels.json the audit reads.
+type ariChannelsDoc struct {
+	APIVersion string `json:"apiVersion"`
+	APIs       []struct {
+		Path       string `json:"path"`
+		Operations []struct {
+			HTTPMethod string `json:"httpMethod"`
+			Parameters []struct {
+				Name string `json:"name"`
+			} `json:"parameters"`
+		} `json:"operations"`
+	} `json:"apis"`
+}
+
+func (r *Runner) ariSchemaItem(a AsteriskAudit, mods map[string]bool) Item {
+	const name = "ARI Schema"
+	var missing []string
+	for _, m := range []string{"res_ari.so", "res_ari_channels.so", "res_ari_bridges.so", "res_stasis.so"} {
+		if !mods[m] {
+			missing = append(missing, m)
+		}
+	}
+	if len(mods) > 0 && len(missing) > 0 {
+		return Item{
+			Name:        name,
+			Status:      StatusFail,
+			Message:     "ARI modules not running: " + strings.Join(missing, ", "),
+			Remediation: "Load them (asterisk -rx \"module load <name>\") and check modules.conf for noload lines.",
+		}
+	}
+	if a.ARIBaseURL == "" || a.ARIUsername == "" {
+		return Item{Name: name, Status: StatusSkip, Message: "ARI credentials not set in .env"}
+	}
+
+	url := strings.TrimRight(a.ARIBaseURL, "/") + "/ari/api-docs/channels.json"
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return Item{Name: name, Status: StatusSkip, Message: err.Error()}
+	}
+	req.SetBasicAuth(a.ARIUsername, a.ARIPassword)
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		return Item{Name: name, Status: StatusWarn, Message: "ARI not reachable from this host", Details: err.Error()}
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if resp.StatusCode != http.StatusOK {
+		return Item{Name: name, Status: StatusWarn, Message: fmt.Sprintf("GET %s: HTTP %d", url, resp.StatusCode)}
+	}
+	return ariSchemaFindings(a.Transport, body)
+}
+
+// ariSchemaFindings checks the channel operations ai_engine uses: POST /channels
+// for AudioSocket originates and POST /channels/externalMedia for RTP.
+func ariSchemaFindings(transport string, body []byte) Item {
+	const name = "ARI Schema"
+	var doc ariChannelsDoc
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return Item{Name: name, Status: StatusWarn, Message: "channels.json not parseable", Details: err.Error()}
+	}
+	params := map[string][]string{}
+	for _, api := range doc.APIs {
+		for _, op := range api.Operations {
+			if op.HTTPMethod != "POST" {
+				continue
+			}
+			names := []string{}
+			for _, p := range op.Parameters {
+				names = append(names, p.Name)
+			}
+			params[api.Path] = names
+		}
+	}
+	var issues []string
+	if _, ok := params["/channels"]; !ok {
+		issues = append(issues, "POST /channels missing")
+	}
+	if em, ok := params["/channels/externalMedia"]; !ok {
+		if transport == "externalmedia" {
+			issues = append(issues, "POST /channels/externalMedia missing (needs Asterisk 16.6+)")
+		}
+	} else {
+		for _, want := range []string{"app", "external_host", "format"} {
+			if !contains(em, want) {
+				issues = append(issues, "externalMedia has no "+want+" parameter")
+			}
+		}
+	}
+	details := "apiVersion=" + emptyTo(doc.APIVersion, "unknown")
+	if len(issues) == 0 {
+		return Item{Name: name, Status: StatusPass, Message: "channel operations used by ai_engine are present (ARI " + emptyTo(doc.APIVersion, "unknown") + ")", Details: details}
+	}
+	return Item{
+		Name:        name,
+		Status:      StatusFail,
+		Message:     strings.Join(issues, "; "),
+		Details:     details,
+		Remediation: "Check that res_ari_channels.so matches the running Asterisk build (stale modules in /usr/lib/asterisk/modules after an upgrade).",
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// rtpItem reports the rtp.conf range. With ExternalMedia on the Asterisk host the
+// engine's port_range must not fall inside it, or Asterisk may take those ports.
+func rtpItem(transport, emPorts string, general map[string]string) Item {
+	const name = "RTP Settings"
+	start, end := general["rtpstart"], general["rtpend"]
+	details := []string{
+		"rtpstart=" + emptyTo(start, "(default 5000)"),
+		"rtpend=" + emptyTo(end, "(default 31000)"),
+		"strictrtp=" + emptyTo(general["strictrtp"], "(default yes)"),
+	}
+	if transport != "externalmedia" {
+		return Item{Name: name, Status: StatusPass, Message: "rtp.conf read", Details: strings.Join(details, "\n")}
+	}
+	details = append(details, "external_media.port_range="+emptyTo(emPorts, "18080:18099"))
+	aStart, aEnd := atoiOr(start, 5000), atoiOr(end, 31000)
+	lo, hi, ok := parsePortRange(emptyTo(emPorts, "18080:18099"))
+	if ok && lo <= aEnd && hi >= aStart {
+		return Item{
+			Name:        name,
+			Status:      StatusWarn,
+			Message:     fmt.Sprintf("external_media.port_range %d-%d overlaps rtp.conf %d-%d", lo, hi, aStart, aEnd),
+			Details:     strings.Join(details, "\n"),
+			Remediation: "When ai_engine shares the host with Asterisk, move external_media.port_range outside rtpstart/rtpend (or narrow them in rtp.conf).",
+		}
+	}
+	return Item{Name: name, Status: StatusPass, Message: "ExternalMedia ports do not overlap rtp.conf", Details: strings.Join(details, "\n")}
+}
+
+func atoiOr(s string, def int) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+		return n
+	}
+	return def
+}
+
+func parsePortRange(s string) (int, int, bool) {
+	a, b, found := strings.Cut(strings.ReplaceAll(s, "-", ":"), ":")
+	lo, err := strconv.Atoi(strings.TrimSpace(a))
+	if err != nil {
+		return 0, 0, false
+	}
+	if !found {
+		return lo, lo, true
+	}
+	hi, err := strconv.Atoi(strings.TrimSpace(b))
+	if err != nil {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
+// chanSIPItem: chan_sip was removed in Asterisk 21, so peers left in sip.conf stop
+// registering after the upgrade.
+func chanSIPItem(sipConf string) Item {
+	const name = "chan_sip"
+	var peers []string
+	for section, kv := range asterisk.ParseConf(sipConf) {
+		if section != "general" && len(kv) > 0 {
+			peers = append(peers, section)
+		}
+	}
+	if len(peers) == 0 {
+		return Item{Name: name, Status: StatusPass, Message: "no sip.conf peers"}
+	}
+	sort.Strings(peers)
+	return Item{
+		Name:        name,
+		Status:      StatusWarn,
+		Message:     fmt.Sprintf("%d sip.conf section(s) but chan_sip was removed in Asterisk 21", len(peers)),
+		Details:     strings.Join(peers, ", "),
+		Remediation: "Migrate the trunks and phones to pjsip (contrib/scripts/sip_to_pjsip/sip_to_pjsip.py).",
+	}
+}
@@ -0,0 +1,63 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAudioSocketItemNeedsChannelDriver(t *testing.T) {
+	t.Parallel()
+
+	out := `Module                         Description                              Use Count  Status      Support Level
+app_audiosocket.so             AudioSocket Application                  0          Running              extended
+res_audiosocket.so             AudioSocket support                      1          Running              extended
+chan_audiosocket.so            AudioSocket Channel                      0          Not Running          extended
+3 modules loaded
+`
+	mods := parseModules(out)
+	item := audioSocketItem("audiosocket", mods, "[modules]\nautoload=yes\nnoload => chan_audiosocket.so\n")
+	if item.Status != StatusFail || !strings.Contains(item.Message, "only app_audiosocket") {
+		t.Fatalf("item = %+v", item)
+	}
+	if !strings.Contains(item.Details, "noload => chan_audiosocket.so") {
+		t.Fatalf("details = %s", item.Details)
+	}
+
+	mods["chan_audiosocket.so"] = true
+	if item := audioSocketItem("audiosocket", mods, ""); item.Status != StatusPass {
+		t.Fatalf("expected pass, got %+v", item)
+	}
+}
+
+func TestParseAsteriskVersion(t *testing.T) {
+	t.Parallel()
+
+	for out, want := range map[string]int{
+		"Asterisk 20.5.2 built by root @ pbx on a x86_64 running Linux": 20,
+		"Asterisk certified/18.9-cert7 built by mockbuild":              18,
+		"garbage": 0,
+	} {
+		if _, major := parseAsteriskVersion(out); major != want {
+			t.Errorf("parseAsteriskVersion(%q) major = %d, want %d", out, major, want)
+		}
+	}
+}
+
+func TestARISchemaAndRTPFindings(t *testing.T) {
+	t.Parallel()
+
+	doc := `{"apiVersion":"10.0.0","apis":[{"path":"/channels","operations":[{"httpMethod":"POST","parameters":[{"name":"endpoint"}]}]}]}`
+	if item := ariSchemaFindings("externalmedia", []byte(doc)); item.Status != StatusFail || !strings.Contains(item.Message, "externalMedia missing") {
+		t.Fatalf("schema item = %+v", item)
+	}
+	if item := ariSchemaFindings("audiosocket", []byte(doc)); item.Status != StatusPass {
+		t.Fatalf("audiosocket schema item = %+v", item)
+	}
+
+	if item := rtpItem("externalmedia", "18080:18099", map[string]string{"rtpstart": "10000", "rtpend": "20000"}); item.Status != StatusWarn {
+		t.Fatalf("rtp item = %+v", item)
+	}
+	if item := rtpItem("externalmedia", "18080:18099", map[string]string{"rtpstart": "10000", "rtpend": "17999"}); item.Status != StatusPass {
+		t.Fatalf("rtp item = %+v", item)
+	}
+}
@@ -0,0 +1,104 @@
+package configmerge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvVar selects the config environment. When it is set, the engine and agent
+// merge config/overlays/<env>.yaml between ai-agent.yaml and ai-agent.local.yaml.
+const EnvVar = "AAVA_CONFIG_ENV"
+
+// OverlayDir holds the per-environment overlays, next to ai-agent.yaml.
+const OverlayDir = "overlays"
+
+var envName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidEnv checks that env can name an overlay file.
+func ValidEnv(env string) error {
+	if !envName.MatchString(env) {
+		return fmt.Errorf("invalid config environment %q (use lowercase letters, digits, - and _)", env)
+	}
+	return nil
+}
+
+// OverlayPath is the overlay of env for the base config at basePath.
+func OverlayPath(basePath, env string) string {
+	return filepath.Join(filepath.Dir(basePath), OverlayDir, env+".yaml")
+}
+
+// LocalPath is the operator-local override of the base config at basePath:
+// config/ai-agent.yaml → config/ai-agent.local.yaml.
+func LocalPath(basePath string) string {
+	ext := filepath.Ext(basePath)
+	return strings.TrimSuffix(basePath, ext) + ".local" + ext
+}
+
+// Layers returns the files that make up the effective config, in merge order:
+// the base, the overlay of env when env is set, and the local override when it
+// exists. A missing base or overlay is an error, as it is for the engine.
+func Layers(basePath, env string) ([]string, error) {
+	if _, err := os.Stat(basePath); err != nil {
+		return nil, err
+	}
+	layers := []string{basePath}
+	if env != "" {
+		if err := ValidEnv(env); err != nil {
+			return nil, err
+		}
+		overlay := OverlayPath(basePath, env)
+		if _, err := os.Stat(overlay); err != nil {
+			return nil, fmt.Errorf("config environment %q has no overlay: %w", env, err)
+		}
+		layers = append(layers, overlay)
+	}
+	if local := LocalPath(basePath); fileExists(local) {
+		layers = append(layers, local)
+	}
+	return layers, nil
+}
+
+// Effective merges the layers of basePath for env with DeepMerge, so a null
+// in a later layer deletes the key. It returns the merged config and the files
+// it read.
+func Effective(basePath, env string) (map[string]any, []string, error) {
+	layers, err := Layers(basePath, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := map[string]any{}
+	for _, path := range layers {
+		m, err := ReadYAMLFile(path)
+		if err != nil {
+			return nil, layers, fmt.Errorf("%s: %w", path, err)
+		}
+		cfg = DeepMerge(cfg, m)
+	}
+	return cfg, layers, nil
+}
+
+// Envs lists the environments that have an overlay next to basePath.
+func Envs(basePath string) []string {
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(basePath), OverlayDir))
+	if err != nil {
+		return nil
+	}
+	var envs []string
+	for _, e := range entries {
+		env, ok := strings.CutSuffix(e.Name(), ".yaml")
+		if ok && !e.IsDir() && ValidEnv(env) == nil {
+			envs = append(envs, env)
+		}
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+func fileExists(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && !st.IsDir()
+}
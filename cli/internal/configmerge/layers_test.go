@@ -0,0 +1,56 @@
+package configmerge
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveMergesOverlayBeforeLocal(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, body string) {
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := filepath.Join(dir, "ai-agent.yaml")
+	write("ai-agent.yaml", "default_provider: local\nlogging: {level: debug, format: console}\nbarge_in: {enabled: true}\n")
+	write("overlays/prod.yaml", "default_provider: openai_realtime\nlogging: {level: info}\nbarge_in: null\n")
+	write("overlays/staging.yaml", "logging: {level: info}\n")
+	write("overlays/README.md", "not an overlay\n")
+	write("ai-agent.local.yaml", "logging: {format: json}\n")
+
+	cfg, layers, err := Effective(base, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"default_provider": "openai_realtime",
+		"logging":          map[string]any{"level": "info", "format": "json"},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("effective = %#v", cfg)
+	}
+	if len(layers) != 3 || layers[1] != filepath.Join(dir, "overlays", "prod.yaml") || layers[2] != LocalPath(base) {
+		t.Fatalf("layers = %v", layers)
+	}
+
+	if layers, _ := Layers(base, ""); len(layers) != 2 {
+		t.Fatalf("no env: layers = %v", layers)
+	}
+	if _, err := Layers(base, "dev"); err == nil || !strings.Contains(err.Error(), `config environment "dev" has no overlay`) {
+		t.Fatalf("missing overlay: %v", err)
+	}
+	if _, err := Layers(base, "../prod"); err == nil || !strings.Contains(err.Error(), "invalid config environment") {
+		t.Fatalf("bad env name: %v", err)
+	}
+	if got := Envs(base); !reflect.DeepEqual(got, []string{"prod", "staging"}) {
+		t.Fatalf("Envs = %v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package contexts
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Budget is a prompt's estimated size against the model it runs on.
+type Budget struct {
+	Model  string `json:"model"`
+	Source string `json:"source"` // where the model came from, e.g. "provider openai_realtime"
+	Tokens int    `json:"estimated_tokens"`
+	Window int    `json:"context_window,omitempty"` // 0 when unknown
+}
+
+// modelFamily is a group of models sharing a tokenizer density and a context
+// window. Realtime and Live sessions have far smaller windows than the text
+// models they are named after.
+type modelFamily struct {
+	match         string // substring of the lower-cased model name
+	window        int
+	charsPerToken float64
+}
+
+var modelFamilies = []modelFamily{
+	{"realtime", 32768, 4},
+	{"native-audio", 131072, 4},
+	{"gemini", 32768, 4},
+	{"gpt-4.1", 1047576, 4},
+	{"gpt-5", 400000, 4},
+	{"gpt-4o", 128000, 4},
+	{"gpt-3.5", 16385, 4},
+}
+
+// localCharsPerToken is the density of the small-vocabulary tokenizers of
+// GGUF models (Phi, Llama 2): English packs fewer characters per token.
+const localCharsPerToken = 3.3
+
+// defaultCharsPerToken is used for models not in modelFamilies.
+const defaultCharsPerToken = 4
+
+// estimateBudget estimates the prompt's tokens for the model the context runs
+// on (or opts.Model) and looks up that model's context window.
+func estimateBudget(prompt string, ctx map[string]any, opts Options) Budget {
+	b := Budget{Model: opts.Model, Source: "--model"}
+	local := false
+	if b.Model == "" {
+		b.Model, b.Source, local = resolveModel(ctx, opts.Config)
+	}
+	if b.Model == "" {
+		b.Model = "unknown"
+	}
+	ratio := float64(defaultCharsPerToken)
+	lower := strings.ToLower(b.Model)
+	switch {
+	case local || lower == "local" || strings.HasSuffix(lower, ".gguf"):
+		ratio = localCharsPerToken
+		b.Window = localWindow(opts.Env)
+	default:
+		for _, f := range modelFamilies {
+			if strings.Contains(lower, f.match) {
+				ratio, b.Window = f.charsPerToken, f.window
+				break
+			}
+		}
+	}
+	b.Tokens = int(math.Ceil(float64(len([]rune(prompt))) / ratio))
+	return b
+}
+
+// resolveModel follows the engine's choice of LLM for a context: its
+// pipeline, else its provider, else the active pipeline or default provider.
+func resolveModel(ctx, cfg map[string]any) (model, source string, local bool) {
+	pipelines, _ := cfg["pipelines"].(map[string]any)
+	providers, _ := cfg["providers"].(map[string]any)
+	name, kind := str(ctx["pipeline"]), "pipeline"
+	if name == "" {
+		name, kind = str(ctx["provider"]), "provider"
+	}
+	if name == "" {
+		name, kind = str(cfg["active_pipeline"]), "pipeline"
+	}
+	if name == "" {
+		name, kind = str(cfg["default_provider"]), "provider"
+	}
+	// A provider field may name a pipeline too.
+	p, isPipeline := pipelines[name].(map[string]any)
+	if kind == "provider" && providers[name] != nil {
+		isPipeline = false
+	}
+	if isPipeline {
+		source = "pipeline " + name
+		opts, _ := p["options"].(map[string]any)
+		llmOpts, _ := opts["llm"].(map[string]any)
+		if m := str(llmOpts["model"]); m != "" {
+			return m, source, strings.HasPrefix(str(p["llm"]), "local")
+		}
+		name = str(p["llm"])
+		if strings.HasPrefix(name, "local") {
+			return "local", source, true
+		}
+	} else {
+		source = "provider " + name
+	}
+	pc, _ := providers[name].(map[string]any)
+	if pc == nil {
+		if llm, ok := cfg["llm"].(map[string]any); ok && str(llm["model"]) != "" {
+			return str(llm["model"]), "llm.model", false
+		}
+		return "", source, false
+	}
+	local = str(pc["type"]) == "local" || name == "local"
+	for _, k := range []string{"llm_model", "chat_model", "think_model", "model"} {
+		if m := str(pc[k]); m != "" {
+			return m, source, local
+		}
+	}
+	if local {
+		return "local", source, true
+	}
+	return "", source, false
+}
+
+// localWindow is the Local AI Server's llama.cpp context size: LOCAL_LLM_CONTEXT,
+// else 2048 with a GPU and 768 without. A prompt past it fails the call.
+func localWindow(env map[string]string) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(env["LOCAL_LLM_CONTEXT"])); err == nil && n > 0 {
+		return n
+	}
+	switch strings.ToLower(strings.TrimSpace(env["GPU_AVAILABLE"])) {
+	case "1", "true", "yes", "on":
+		return 2048
+	}
+	return 768
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
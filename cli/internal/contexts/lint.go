@@ -0,0 +1,495 @@
+// Package contexts lints the context files in config/contexts/: one agent
+// (prompt, greeting, provider and tools) per YAML file, merged into the
+// engine's contexts when it loads its config and imported into agents.db by
+// the Admin UI. Both skip a file they cannot load without saying so, so a typo
+// only shows when the agent is missing or stale.
+package contexts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/locale"
+	"gopkg.in/yaml.v3"
+)
+
+// Severities. An error means the engine ignores the file or cannot use a
+// setting in it; a warning means it loads but probably not as intended.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Size limits. The engine has none; past these a file is almost certainly a
+// mistake (a pasted document, a binary) or a greeting no caller waits through.
+const (
+	maxFileBytes     = 1 << 20
+	maxGreetingChars = 1000 // about a minute of speech
+)
+
+// versionLen is how many hex digits of a file's SHA-256 make its version.
+const versionLen = 8
+
+// Finding is one problem in a context file.
+type Finding struct {
+	File     string `json:"file"`
+	Context  string `json:"context,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// File is the summary of one linted file.
+type File struct {
+	File        string  `json:"file"`
+	Context     string  `json:"context,omitempty"`
+	Bytes       int64   `json:"bytes"`
+	PromptChars int     `json:"prompt_chars"`
+	Version     string  `json:"version,omitempty"` // content fingerprint, see agent experiment
+	Budget      *Budget `json:"budget,omitempty"`
+}
+
+// Result is the outcome of linting a contexts directory.
+type Result struct {
+	Dir      string    `json:"dir"`
+	Files    []File    `json:"files"`
+	Findings []Finding `json:"findings"`
+}
+
+// Counts returns the number of error and warning findings.
+func (r *Result) Counts() (errs, warnings int) {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	return errs, warnings
+}
+
+// Options carry what the files are checked against.
+type Options struct {
+	// Config is the merged ai-agent.yaml and ai-agent.local.yaml: inline
+	// contexts, providers, pipelines, profiles and pre-call tool outputs.
+	Config map[string]any
+	// Env is the engine environment (.env). The engine expands $VAR and
+	// ${VAR} in a context file before parsing it, and the local LLM's
+	// context window comes from LOCAL_LLM_CONTEXT.
+	Env map[string]string
+	// Model, when set, is used for every token budget instead of the model
+	// each context resolves to.
+	Model string
+}
+
+// fieldKind is the YAML type a context field must have.
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindStringList
+	kindMap
+	kindListOrMap
+	kindBool
+)
+
+// fields are the context keys the engine reads (ContextConfig), with
+// system_prompt as the alias for prompt and description for the Admin UI.
+var fields = map[string]fieldKind{
+	"name":                              kindString,
+	"description":                       kindString,
+	"prompt":                            kindString,
+	"system_prompt":                     kindString,
+	"greeting":                          kindString,
+	"profile":                           kindString,
+	"provider":                          kindString,
+	"voice":                             kindString,
+	"pipeline":                          kindString,
+	"background_music":                  kindString,
+	"connection_audio":                  kindString,
+	"email_recipient":                   kindString,
+	"email_from":                        kindString,
+	"tools":                             kindStringList,
+	"pre_call_tools":                    kindStringList,
+	"post_call_tools":                   kindStringList,
+	"disable_global_pre_call_tools":     kindStringList,
+	"disable_global_in_call_tools":      kindStringList,
+	"disable_global_in_call_http_tools": kindStringList,
+	"disable_global_post_call_tools":    kindStringList,
+	"in_call_http_tools":                kindListOrMap,
+	"tool_configs":                      kindMap,
+	"no_input":                          kindMap,
+	"email_enabled":                     kindBool,
+}
+
+// Lint checks every context file in dir. A missing directory is not an
+// error: contexts are optional.
+func Lint(dir string, opts Options) (*Result, error) {
+	res := &Result{Dir: dir, Files: []File{}, Findings: []Finding{}}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	inline, _ := opts.Config["contexts"].(map[string]any)
+	outputs := outputVariables(opts.Config)
+	seen := map[string]string{} // context name -> first file defining it
+
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		l := &linter{res: res, file: e.Name()}
+		f := File{File: e.Name()}
+		l.lintFile(filepath.Join(dir, e.Name()), ext, &f, opts, outputs)
+		if f.Context != "" {
+			if _, ok := inline[f.Context]; ok {
+				l.add(SeverityWarning, "context %q is also defined inline in ai-agent.yaml, which wins; this file is not used", f.Context)
+			} else if first, ok := seen[f.Context]; ok {
+				l.add(SeverityWarning, "context %q is also defined in %s; the engine loads only one of them", f.Context, first)
+			} else {
+				seen[f.Context] = e.Name()
+			}
+		}
+		res.Files = append(res.Files, f)
+	}
+	sort.SliceStable(res.Findings, func(i, j int) bool { return res.Findings[i].File < res.Findings[j].File })
+	return res, nil
+}
+
+type linter struct {
+	res     *Result
+	file    string
+	context string
+}
+
+func (l *linter) add(severity, format string, args ...any) {
+	l.res.Findings = append(l.res.Findings, Finding{File: l.file, Context: l.context, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// unsetVar matches the ${NAME} references Python's os.path.expandvars leaves
+// in place when NAME is not set.
+var unsetVar = regexp.MustCompile(`\$\{([A-Za-z_]\w*)\}`)
+
+func (l *linter) lintFile(path, ext string, f *File, opts Options, outputs map[string]bool) {
+	st, err := os.Stat(path)
+	if err != nil {
+		l.add(SeverityError, "%v", err)
+		return
+	}
+	f.Bytes = st.Size()
+	if st.Size() > maxFileBytes {
+		l.add(SeverityError, "file is %d KiB; context files over %d KiB are not linted", st.Size()>>10, maxFileBytes>>10)
+		return
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		l.add(SeverityError, "%v", err)
+		return
+	}
+	if ext == ".json" {
+		l.add(SeverityWarning, "the engine loads only .yaml and .yml files; rename it to %s.yaml (JSON is valid YAML)", strings.TrimSuffix(f.File, filepath.Ext(f.File)))
+	}
+	unset := map[string]bool{}
+	for _, m := range unsetVar.FindAllStringSubmatch(string(raw), -1) {
+		if _, ok := opts.Env[m[1]]; !ok && !unset[m[1]] {
+			unset[m[1]] = true
+			l.add(SeverityWarning, "%s is not set in .env and stays literally in the text (unless the container environment sets it)", m[0])
+		}
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(expandVars(string(raw), opts.Env))))
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			l.add(SeverityError, "file is empty; the engine skips it")
+		} else {
+			l.add(SeverityError, "does not parse, the engine skips it: %v", err)
+		}
+		return
+	}
+	var extra any
+	if dec.Decode(&extra) != io.EOF {
+		l.add(SeverityError, "holds more than one YAML document; the engine cannot load it")
+		return
+	}
+	ctx, ok := doc.(map[string]any)
+	if !ok {
+		l.add(SeverityError, "top level must be a mapping of context fields; the engine skips it")
+		return
+	}
+	name, isString := ctx["name"].(string)
+	if strings.TrimSpace(name) == "" {
+		if ctx["name"] != nil && !isString {
+			l.add(SeverityError, "name must be a string, not %s; the engine skips the file", yamlType(ctx["name"]))
+		} else {
+			l.add(SeverityError, "missing name; the engine skips files without one")
+		}
+		return
+	}
+	l.context = strings.TrimSpace(name)
+	f.Context = l.context
+	sum := sha256.Sum256(raw)
+	f.Version = hex.EncodeToString(sum[:])[:versionLen]
+
+	l.checkFields(ctx)
+	l.checkReferences(ctx, opts.Config)
+
+	prompt, _ := ctx["prompt"].(string)
+	promptField := "prompt"
+	if _, set := ctx["prompt"]; !set {
+		prompt, _ = ctx["system_prompt"].(string)
+		promptField = "system_prompt"
+	}
+	if strings.TrimSpace(prompt) == "" {
+		l.add(SeverityWarning, "no prompt; calls use the provider's default instructions")
+	}
+	f.PromptChars = len([]rune(prompt))
+	greeting, _ := ctx["greeting"].(string)
+	if n := len([]rune(greeting)); n > maxGreetingChars {
+		l.add(SeverityWarning, "greeting is %d characters (over %d); callers hear it before they can speak", n, maxGreetingChars)
+	}
+
+	vars := make(map[string]bool, len(outputs))
+	for k := range outputs {
+		vars[k] = true
+	}
+	if defs, ok := ctx["in_call_http_tools"].(map[string]any); ok {
+		for k := range outputVariables(defs) {
+			vars[k] = true
+		}
+	}
+	for _, p := range templateProblems(prompt, vars) {
+		l.add(SeverityWarning, "%s: %s", promptField, p)
+	}
+	for _, p := range templateProblems(greeting, vars) {
+		l.add(SeverityWarning, "greeting: %s", p)
+	}
+	l.checkLanguage(ctx, prompt, greeting, opts)
+
+	b := estimateBudget(prompt, ctx, opts)
+	f.Budget = &b
+	switch {
+	case b.Window == 0:
+	case b.Tokens > b.Window:
+		l.add(SeverityError, "prompt is ~%d tokens, more than %s's %d-token context window", b.Tokens, b.Model, b.Window)
+	case 2*b.Tokens > b.Window:
+		l.add(SeverityWarning, "prompt is ~%d tokens, over half of %s's %d-token context window; little is left for the conversation and tools", b.Tokens, b.Model, b.Window)
+	}
+}
+
+// checkLanguage flags a context whose greeting, or the language its prompt
+// asks for, is one its voice does not speak or its STT does not transcribe.
+func (l *linter) checkLanguage(ctx map[string]any, prompt, greeting string, opts Options) {
+	lang, what := locale.Detect(greeting), "greeting is in"
+	if lang == "" {
+		lang, what = locale.Instructed(prompt), "prompt asks for"
+	}
+	if lang == "" {
+		return
+	}
+	stt, tts, ok := locale.ForContext(opts.Config, ctx, func(k string) string { return opts.Env[k] })
+	if !ok {
+		return
+	}
+	if !locale.Matches(tts.Language(), lang) {
+		l.add(SeverityWarning, "%s %s, but %s speaks %s", what, locale.Name(lang), tts.Provider, tts.Describe())
+	}
+	if !locale.Matches(stt.Language(), lang) {
+		l.add(SeverityWarning, "%s %s, but %s transcribes callers in %s", what, locale.Name(lang), stt.Provider, stt.Describe())
+	}
+}
+
+// checkFields flags keys the engine ignores and values of the wrong type.
+func (l *linter) checkFields(ctx map[string]any) {
+	keys := make([]string, 0, len(ctx))
+	for k := range ctx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := ctx[k]
+		kind, known := fields[k]
+		if !known {
+			l.add(SeverityWarning, "unknown field %q is ignored by the engine", k)
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		var want string
+		switch kind {
+		case kindString:
+			if _, ok := v.(string); !ok {
+				want = "a string"
+			}
+		case kindStringList:
+			if !isStringList(v) {
+				want = "a list of names"
+			}
+		case kindMap:
+			if _, ok := v.(map[string]any); !ok {
+				want = "a mapping"
+			}
+		case kindListOrMap:
+			if _, ok := v.(map[string]any); !ok && !isStringList(v) {
+				want = "a list of tool names or a mapping of tool definitions"
+			}
+		case kindBool:
+			switch b := v.(type) {
+			case bool:
+			case int:
+				if b != 0 && b != 1 {
+					want = "true or false"
+				}
+			default:
+				want = "true or false"
+			}
+		}
+		if want != "" {
+			l.add(SeverityError, "%s must be %s, not %s", k, want, yamlType(v))
+		}
+	}
+}
+
+// checkReferences flags a provider, pipeline or audio profile the config
+// does not define, and a disabled provider.
+func (l *linter) checkReferences(ctx, cfg map[string]any) {
+	providers, _ := cfg["providers"].(map[string]any)
+	pipelines, _ := cfg["pipelines"].(map[string]any)
+	if p, _ := ctx["provider"].(string); p != "" && providers != nil {
+		pc, ok := providers[p].(map[string]any)
+		_, isPipeline := pipelines[p]
+		switch {
+		case !ok && !isPipeline:
+			l.add(SeverityError, "provider %q is not defined in ai-agent.yaml", p)
+		case ok && pc["enabled"] == false:
+			l.add(SeverityWarning, "provider %q is disabled (enabled: false)", p)
+		}
+	}
+	if p, _ := ctx["pipeline"].(string); p != "" && pipelines != nil {
+		if _, ok := pipelines[p]; !ok {
+			l.add(SeverityError, "pipeline %q is not defined in ai-agent.yaml", p)
+		}
+	}
+	if p, _ := ctx["profile"].(string); p != "" {
+		if profiles, ok := cfg["profiles"].(map[string]any); ok {
+			if _, ok := profiles[p]; !ok {
+				l.add(SeverityError, "audio profile %q is not defined in ai-agent.yaml", p)
+			}
+		}
+	}
+}
+
+func isStringList(v any) bool {
+	list, ok := v.([]any)
+	if !ok {
+		return false
+	}
+	for _, e := range list {
+		if _, ok := e.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func yamlType(v any) string {
+	switch v.(type) {
+	case string:
+		return "a string"
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "a mapping"
+	case bool:
+		return "a boolean"
+	case int, float64:
+		return "a number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// expandVars mirrors Python's os.path.expandvars: $NAME and ${NAME} are
+// replaced when NAME is set and left as they are otherwise.
+func expandVars(s string, env map[string]string) string {
+	return envRef.ReplaceAllStringFunc(s, func(m string) string {
+		name := strings.Trim(m[1:], "{}")
+		if v, ok := env[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+var envRef = regexp.MustCompile(`\$(\w+|\{[^}]*\})`)
+
+// outputVariables collects the names every output_variables mapping under v
+// defines: the variables pre-call lookups add to the prompt.
+func outputVariables(v any) map[string]bool {
+	out := map[string]bool{}
+	var walk func(any)
+	walk = func(v any) {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, c := range t {
+				if m, ok := c.(map[string]any); ok && k == "output_variables" {
+					for name := range m {
+						out[name] = true
+					}
+					continue
+				}
+				walk(c)
+			}
+		case []any:
+			for _, c := range t {
+				walk(c)
+			}
+		}
+	}
+	walk(v)
+	return out
+}
+
+// LoadOptions reads the options for the deployment at root: the merged
+// ai-agent.yaml and ai-agent.local.yaml, and .env.
+func LoadOptions(root string) Options {
+	opts := Options{Config: map[string]any{}, Env: map[string]string{}}
+	for _, name := range []string{"ai-agent.yaml", "ai-agent.local.yaml"} {
+		if m, err := configmerge.ReadYAMLFile(filepath.Join(root, "config", name)); err == nil {
+			opts.Config = configmerge.DeepMerge(opts.Config, m)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(root, ".env"))
+	if err != nil {
+		return opts
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.HasPrefix(key, "#") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		opts.Env[key] = value
+	}
+	return opts
+}
@@ -0,0 +1,186 @@
+package contexts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"sales.yaml": `name: sales
+provider: openai_realtime
+greeting: "Hi {caller_name}, thanks for calling ${COMPANY}."
+prompt: |
+  You are the sales agent for ${COMPANY}. Today is {today}.
+  The caller's account is {ghl_contact_name} and their plan is {plan.tier}.
+  Greet them as {{caller_name}} and mention { lead_id } and {calller_number}.
+  Reply as {"ok": true}.
+tools: [transfer, hangup_call]
+`,
+		"support.yml": `name: support
+pipeline: local_only
+prompt: "` + strings.Repeat("word ", 800) + `"
+email_enabled: yes please
+colour: blue
+`,
+		"sales2.yaml":  "name: sales\nprompt: copy\n",
+		"inline.yaml":  "name: demo\nprompt: shadowed\nprovider: nowhere\n",
+		"broken.yaml":  "name: x\nprompt: [unclosed\n",
+		"noname.yaml":  "prompt: orphan\n",
+		"two.yaml":     "name: a\n---\nname: b\n",
+		"agent.json":   `{"name": "json", "prompt": "Hello {caller_name"}`,
+		"notes.txt":    "not a context",
+		".hidden.yaml": "name: hidden\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := map[string]any{
+		"default_provider": "openai_realtime",
+		"contexts":         map[string]any{"demo": map[string]any{}},
+		"providers": map[string]any{
+			"openai_realtime": map[string]any{"model": "gpt-realtime"},
+		},
+		"pipelines": map[string]any{
+			"local_only": map[string]any{"llm": "local_llm"},
+		},
+		"tools": map[string]any{
+			"crm": map[string]any{"phase": "pre_call", "output_variables": map[string]any{"ghl_contact_name": "contacts[0].name"}},
+		},
+	}
+	res, err := Lint(dir, Options{Config: cfg, Env: map[string]string{"COMPANY": "Acme"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Files) != 8 {
+		t.Fatalf("files = %+v", res.Files)
+	}
+
+	got := map[string][]string{}
+	for _, f := range res.Findings {
+		got[f.File] = append(got[f.File], f.Severity+": "+f.Message)
+	}
+	want := map[string][]string{
+		"sales.yaml": {
+			"warning: prompt: {plan.tier} is not a built-in variable",
+			"warning: prompt: {{caller_name}} renders as {value}",
+			`warning: prompt: "{ lead_id }" is not a placeholder the engine fills; write {lead_id}`,
+			"(did you mean {caller_number}?)",
+		},
+		"support.yml": {
+			"error: email_enabled must be true or false, not a string",
+			`warning: unknown field "colour"`,
+			"error: prompt is ~1213 tokens, more than local's 768-token context window",
+		},
+		"sales2.yaml": {`warning: context "sales" is also defined in sales.yaml`},
+		"inline.yaml": {`error: provider "nowhere" is not defined`, `warning: context "demo" is also defined inline`},
+		"broken.yaml": {"error: does not parse, the engine skips it"},
+		"noname.yaml": {"error: missing name"},
+		"two.yaml":    {"error: holds more than one YAML document"},
+		"agent.json":  {"warning: the engine loads only .yaml and .yml files; rename it to agent.yaml", "warning: prompt: 1 unclosed {"},
+	}
+	for file, subs := range want {
+		joined := strings.Join(got[file], "\n")
+		for _, s := range subs {
+			if !strings.Contains(joined, s) {
+				t.Errorf("%s: missing %q in:\n%s", file, s, joined)
+			}
+		}
+		if len(got[file]) != len(subs) {
+			t.Errorf("%s: %d finding(s), want %d:\n%s", file, len(got[file]), len(subs), joined)
+		}
+	}
+	if errs, warnings := res.Counts(); errs != 6 || warnings != 9 {
+		t.Errorf("counts = %d errors, %d warnings", errs, warnings)
+	}
+
+	for _, f := range res.Files {
+		if f.File == "sales2.yaml" && f.Version != "f0d0130f" {
+			t.Errorf("sales2 version = %q", f.Version)
+		}
+		if f.File == "noname.yaml" && f.Version != "" {
+			t.Errorf("noname version = %q, want none", f.Version)
+		}
+		if f.File == "sales.yaml" && (f.Budget == nil || f.Budget.Model != "gpt-realtime" || f.Budget.Source != "provider openai_realtime" || f.Budget.Window != 32768) {
+			t.Errorf("sales budget = %+v", f.Budget)
+		}
+	}
+
+	// --model overrides the resolved model; an unset ${VAR} is reported.
+	res, err = Lint(dir, Options{Config: cfg, Model: "gpt-4o-mini", Env: map[string]string{"LOCAL_LLM_CONTEXT": "4096"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range res.Files {
+		if f.File == "support.yml" && (f.Budget.Model != "gpt-4o-mini" || f.Budget.Tokens != 1000 || f.Budget.Window != 128000) {
+			t.Errorf("support budget = %+v", f.Budget)
+		}
+	}
+	found := false
+	for _, f := range res.Findings {
+		found = found || f.File == "sales.yaml" && strings.HasPrefix(f.Message, "${COMPANY} is not set in .env")
+	}
+	if !found {
+		t.Errorf("unset ${COMPANY} not reported: %+v", res.Findings)
+	}
+
+	if res, err := Lint(filepath.Join(dir, "missing"), Options{}); err != nil || len(res.Files) != 0 {
+		t.Fatalf("missing dir: %+v %v", res, err)
+	}
+}
+
+func TestLintLanguage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ventas.yaml":  "name: ventas\npipeline: azure\ngreeting: Hola, gracias por llamar. ¿En qué puedo ayudarle?\nprompt: Eres el agente de ventas.\n",
+		"voiced.yaml":  "name: voiced\npipeline: azure\nvoice: es-MX-DaliaNeural\ngreeting: Hola, gracias por llamar. ¿En qué puedo ayudarle?\nprompt: Eres el agente de ventas.\n",
+		"support.yaml": "name: support\ngreeting: Thanks for calling, how can I help you today?\nprompt: Always respond in German.\n",
+		"default.yaml": "name: default\ngreeting: Hello, how can I help you?\nprompt: Be brief.\n",
+		"berlin.yaml":  "name: berlin\nprompt: You take bookings. Always respond in German.\n",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := map[string]any{
+		"default_provider": "local",
+		"providers": map[string]any{
+			"local":     map[string]any{"type": "full", "stt_model": "models/stt/vosk-model-en-us-0.22", "tts_voice": "models/tts/en_US-lessac-medium.onnx"},
+			"azure_stt": map[string]any{"capabilities": []any{"stt"}, "language": "es-ES"},
+			"azure_tts": map[string]any{"capabilities": []any{"tts"}, "voice_name": "en-US-JennyNeural"},
+		},
+		"pipelines": map[string]any{"azure": map[string]any{"stt": "azure_stt", "tts": "azure_tts"}},
+	}
+	res, err := Lint(dir, Options{Config: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, f := range res.Findings {
+		got[f.File] = append(got[f.File], f.Message)
+	}
+	if want := "greeting is in Spanish, but azure_tts speaks English (providers.azure_tts.voice_name en-US-JennyNeural)"; len(got["ventas.yaml"]) != 1 || got["ventas.yaml"][0] != want {
+		t.Errorf("ventas: %q", got["ventas.yaml"])
+	}
+	if len(got["voiced.yaml"]) != 0 || len(got["default.yaml"]) != 0 {
+		t.Errorf("unexpected: %q", got)
+	}
+	if len(got["support.yaml"]) != 0 {
+		t.Errorf("the greeting wins over the prompt: %q", got["support.yaml"])
+	}
+	berlin := strings.Join(got["berlin.yaml"], "\n")
+	for _, want := range []string{
+		"prompt asks for German, but local speaks English (providers.local.tts_voice models/tts/en_US-lessac-medium.onnx)",
+		"prompt asks for German, but local transcribes callers in English (providers.local.stt_model models/stt/vosk-model-en-us-0.22)",
+	} {
+		if !strings.Contains(berlin, want) {
+			t.Errorf("berlin lacks %q:\n%s", want, berlin)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package contexts
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// BuiltinVariables are the placeholders the engine fills in every prompt and
+// greeting (_apply_prompt_template_substitution). Pre-call tools add their
+// output_variables; anything else is left in the text as written.
+var BuiltinVariables = []string{
+	"caller_name", "caller_number", "caller_id", "call_id", "context_name",
+	"call_direction", "campaign_id", "lead_id", "current_date",
+	"current_weekday", "current_time", "current_datetime_iso", "today",
+}
+
+var (
+	placeholder = regexp.MustCompile(`\{([\w.]+)\}`)
+	// nearMiss is brace content that looks meant as a placeholder but is not
+	// one the engine recognizes: padded with spaces or using dashes.
+	nearMiss = regexp.MustCompile(`\{\s*([A-Za-z_][\w.-]*)\s*\}`)
+)
+
+// templateProblems returns what would not render as intended in text: unknown
+// placeholders, {{name}} (the engine fills the inner braces and leaves the
+// outer ones), placeholders it does not recognize and unbalanced braces.
+func templateProblems(text string, outputs map[string]bool) []string {
+	if text == "" {
+		return nil
+	}
+	var problems []string
+	reported := map[string]bool{}
+	report := func(p string) {
+		if !reported[p] {
+			reported[p] = true
+			problems = append(problems, p)
+		}
+	}
+
+	for _, m := range placeholder.FindAllStringSubmatchIndex(text, -1) {
+		name := text[m[2]:m[3]]
+		if m[0] > 0 && text[m[0]-1] == '{' && m[1] < len(text) && text[m[1]] == '}' {
+			report(fmt.Sprintf("{{%s}} renders as {value}; use single braces", name))
+			continue
+		}
+		if !knownVariable(name, outputs) {
+			report(fmt.Sprintf("{%s} is not a built-in variable or a pre-call tool output and is sent as written%s", name, suggest(name, outputs)))
+		}
+	}
+	for _, m := range nearMiss.FindAllStringSubmatch(text, -1) {
+		if placeholder.MatchString(m[0]) {
+			continue
+		}
+		fixed := strings.ReplaceAll(m[1], "-", "_")
+		report(fmt.Sprintf("%q is not a placeholder the engine fills; write {%s}", m[0], fixed))
+	}
+
+	depth := 0
+	for i, r := range text {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				report(fmt.Sprintf("unmatched } at character %d", i+1))
+				continue
+			}
+			depth--
+		}
+	}
+	if depth > 0 {
+		report(fmt.Sprintf("%d unclosed {", depth))
+	}
+	return problems
+}
+
+// knownVariable applies the engine's lookup: the name as written, then with
+// dots turned into underscores (patient.name -> patient_name).
+func knownVariable(name string, outputs map[string]bool) bool {
+	for _, n := range []string{name, strings.ReplaceAll(name, ".", "_")} {
+		if outputs[n] {
+			return true
+		}
+		for _, b := range BuiltinVariables {
+			if n == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// suggest names the closest known variable for a likely typo.
+func suggest(name string, outputs map[string]bool) string {
+	candidates := append([]string{}, BuiltinVariables...)
+	for k := range outputs {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(name), c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean {%s}?)", best)
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
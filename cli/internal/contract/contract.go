@@ -0,0 +1,75 @@
+// Package contract is the machine-readable interface of the agent CLI: the
+// process exit codes and the schema version carried by every JSON payload.
+package contract
+
+import "errors"
+
+// Exit codes shared by doctor, check, rca, update and the other reporting commands.
+const (
+	OK          = 0 // success, nothing to report
+	Warn        = 1 // completed with non-critical warnings
+	Fail        = 2 // critical failure or failed checks
+	Usage       = 3 // invalid flags or arguments
+	Environment = 4 // docker, a container, the checkout or Asterisk not available
+)
+
+// SchemaVersion is the "schema_version" of every JSON payload. It is bumped when a
+// field is removed or changes meaning; new fields do not bump it. The check and
+// rca reports are published as JSON Schemas in internal/schema.
+const SchemaVersion = 1
+
+// Error carries an exit code up through cobra's RunE. A nil Err exits with the
+// code and prints nothing.
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Exit returns an error that makes the CLI exit with code, printing err when set.
+func Exit(code int, err error) error {
+	return &Error{Code: code, Err: err}
+}
+
+// UsageError marks err as caused by invalid flags or arguments.
+func UsageError(err error) error {
+	return &Error{Code: Usage, Err: err}
+}
+
+// EnvironmentError marks err as caused by a missing prerequisite rather than a
+// failed check.
+func EnvironmentError(err error) error {
+	return &Error{Code: Environment, Err: err}
+}
+
+// CodeOf maps err to an exit code: nil is OK, an Error carries its own code, and
+// anything else is Fail.
+func CodeOf(err error) int {
+	if err == nil {
+		return OK
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return Fail
+}
+
+// ReportCode is the exit code for a report with the given counts.
+func ReportCode(warnCount, failCount int) int {
+	switch {
+	case failCount > 0:
+		return Fail
+	case warnCount > 0:
+		return Warn
+	}
+	return OK
+}
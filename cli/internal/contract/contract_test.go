@@ -0,0 +1,29 @@
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	env := EnvironmentError(errors.New("docker not available"))
+	for err, want := range map[error]int{
+		nil:                                OK,
+		errors.New("boom"):                 Fail,
+		UsageError(errors.New("bad flag")): Usage,
+		env:                                Environment,
+		fmt.Errorf("wrapped: %w", env):     Environment,
+		Exit(Warn, nil):                    Warn,
+	} {
+		if got := CodeOf(err); got != want {
+			t.Errorf("CodeOf(%v) = %d, want %d", err, got, want)
+		}
+	}
+	if env.Error() != "docker not available" || Exit(Warn, nil).Error() != "" {
+		t.Error("Error() should pass the wrapped message through unchanged")
+	}
+	if ReportCode(3, 0) != Warn || ReportCode(3, 1) != Fail || ReportCode(0, 0) != OK {
+		t.Error("ReportCode")
+	}
+}
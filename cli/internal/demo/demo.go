@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
 )
 
 var (
@@ -295,14 +297,10 @@ func (r *Runner) loadEnvFile() map[string]string {
 	return envMap
 }
 
-// getEnvKey gets value from OS environment or .env map
+// getEnvKey gets value from OS environment, the secrets store or .env map
 func (r *Runner) getEnvKey(key string, envMap map[string]string) string {
-	// Check OS environment first
-	if val := os.Getenv(key); val != "" {
-		return val
-	}
-	// Fallback to .env map
-	return envMap[key]
+	val, _ := secrets.Lookup(key, envMap)
+	return val
 }
 
 // testLogs checks for recent errors in container logs
@@ -311,7 +309,7 @@ func (r *Runner) testLogs() error {
 		infoColor.Printf("  → Checking recent container logs...\n")
 	}
 	
-	cmd := exec.Command("docker", "logs", "--since", "5m", "ai_engine")
+	cmd := exec.Command("docker", "logs", "--since", "5m", deployment.EngineContainer())
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("warning: could not read container logs")
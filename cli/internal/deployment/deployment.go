@@ -0,0 +1,356 @@
+// Package deployment describes where this installation's containers and logs live,
+// so the CLI works on renamed, remote, or multi-instance deployments.
+//
+// Values come from .agent/deployment.yaml (or AAVA_DEPLOYMENT_FILE) and may be
+// overridden by environment variables. Unset values fall back to the stock
+// docker-compose.yml names.
+package deployment
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+	"gopkg.in/yaml.v3"
+)
+
+// Stock names from docker-compose.yml.
+const (
+	DefaultEngineContainer   = "ai_engine"
+	DefaultAdminUIContainer  = "admin_ui"
+	DefaultLocalAIContainer  = "local_ai_server"
+	DefaultAsteriskContainer = "asterisk"
+	DefaultComposeProject    = "asterisk-ai-voice-agent"
+	DefaultAsteriskLog       = "/var/log/asterisk/full"
+	DefaultCDRCSV            = "/var/log/asterisk/cdr-csv/Master.csv"
+	DefaultCELCSV            = "/var/log/asterisk/cel-custom/Master.csv"
+	DefaultQueueLog          = "/var/log/asterisk/queue_log"
+)
+
+// Runtimes a deployment can use.
+const (
+	RuntimeCompose    = "compose"
+	RuntimeKubernetes = "kubernetes"
+)
+
+// Containers maps stack roles to container names.
+type Containers struct {
+	Engine   string `yaml:"ai_engine" json:"ai_engine"`
+	AdminUI  string `yaml:"admin_ui" json:"admin_ui"`
+	LocalAI  string `yaml:"local_ai_server" json:"local_ai_server"`
+	Asterisk string `yaml:"asterisk" json:"asterisk"`
+}
+
+// Logs selects where logs are read from. Engine is "docker" (default) or a file path
+// for deployments that log to disk; Asterisk is the path of the Asterisk full log.
+type Logs struct {
+	Engine   string `yaml:"ai_engine" json:"ai_engine"`
+	Asterisk string `yaml:"asterisk" json:"asterisk"`
+}
+
+// CDR selects where Asterisk call detail records are read from. Source is "auto"
+// (default: CSV when the file exists), "csv", "mysql", or "none". The MySQL password
+// is taken from AAVA_CDR_DB_PASSWORD, never from the file.
+type CDR struct {
+	Source string   `yaml:"source" json:"source"`
+	CSV    string   `yaml:"csv" json:"csv"`
+	CELCSV string   `yaml:"cel_csv" json:"cel_csv"`
+	MySQL  CDRMySQL `yaml:"mysql" json:"mysql,omitempty"`
+
+	// QueueLog is app_queue's queue_log, read for calls that wait in a queue.
+	QueueLog string `yaml:"queue_log" json:"queue_log"`
+}
+
+// CDRMySQL is a cdr_adaptive_odbc / cdr_mysql database (FreePBX: asteriskcdrdb).
+type CDRMySQL struct {
+	Host     string `yaml:"host" json:"host,omitempty"`
+	Port     string `yaml:"port" json:"port,omitempty"`
+	User     string `yaml:"user" json:"user,omitempty"`
+	Database string `yaml:"database" json:"database,omitempty"`
+	Table    string `yaml:"table" json:"table,omitempty"`
+	CELTable string `yaml:"cel_table" json:"cel_table,omitempty"`
+}
+
+// Secrets selects the provider API key store: env (.env, default), keyring,
+// file (age), sops, vault, aws or gcp. The scalar form "secrets: keyring"
+// sets only Store. Credentials for Vault and the clouds come from their usual
+// environment (VAULT_TOKEN, the aws and gcloud CLI logins), never the file.
+type Secrets struct {
+	Store string `yaml:"store" json:"store"`
+	// File is the age or SOPS file for those stores.
+	File  string       `yaml:"file" json:"file,omitempty"`
+	Vault SecretsVault `yaml:"vault" json:"vault,omitempty"`
+	AWS   SecretsAWS   `yaml:"aws" json:"aws,omitempty"`
+	GCP   SecretsGCP   `yaml:"gcp" json:"gcp,omitempty"`
+}
+
+// SecretsVault is a KV version 2 entry holding every key as a field.
+type SecretsVault struct {
+	Address   string `yaml:"address" json:"address,omitempty"`
+	Namespace string `yaml:"namespace" json:"namespace,omitempty"`
+	Mount     string `yaml:"mount" json:"mount,omitempty"`
+	Path      string `yaml:"path" json:"path,omitempty"`
+}
+
+// SecretsAWS is an AWS Secrets Manager secret holding a JSON object of keys.
+type SecretsAWS struct {
+	SecretID string `yaml:"secret_id" json:"secret_id,omitempty"`
+	Region   string `yaml:"region" json:"region,omitempty"`
+}
+
+// SecretsGCP is a Google Secret Manager secret holding a JSON object of keys.
+type SecretsGCP struct {
+	Project string `yaml:"project" json:"project,omitempty"`
+	Secret  string `yaml:"secret" json:"secret,omitempty"`
+}
+
+// UnmarshalYAML accepts "secrets: keyring" as well as the mapping form.
+func (s *Secrets) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		s.Store = n.Value
+		return nil
+	}
+	type plain Secrets
+	return n.Decode((*plain)(s))
+}
+
+// Kubernetes locates the stack on a cluster (runtime: kubernetes). Each
+// container name under containers: is the value of Label on its pods, so
+// ai_engine is found as app=ai_engine by default.
+type Kubernetes struct {
+	// Kubeconfig is a KUBECONFIG-style list of files; empty reads
+	// ~/.kube/config, then /etc/rancher/k3s/k3s.yaml, then the pod's service account.
+	Kubeconfig string `yaml:"kubeconfig" json:"kubeconfig,omitempty"`
+	Context    string `yaml:"context" json:"context,omitempty"`
+	Namespace  string `yaml:"namespace" json:"namespace,omitempty"`
+	Label      string `yaml:"label" json:"label"`
+}
+
+// Deployment is the resolved deployment descriptor.
+type Deployment struct {
+	// Runtime is what runs the containers: compose (default) or kubernetes.
+	Runtime        string     `yaml:"runtime" json:"runtime"`
+	Kubernetes     Kubernetes `yaml:"kubernetes" json:"kubernetes"`
+	ComposeProject string     `yaml:"compose_project" json:"compose_project"`
+	DockerContext  string     `yaml:"docker_context" json:"docker_context,omitempty"`
+	DockerHost     string     `yaml:"docker_host" json:"docker_host,omitempty"`
+	// ProjectDir is the repository checkout on the docker host; required for remote updates.
+	ProjectDir string     `yaml:"project_dir" json:"project_dir,omitempty"`
+	Containers Containers `yaml:"containers" json:"containers"`
+	Logs       Logs       `yaml:"logs" json:"logs"`
+	CDR        CDR        `yaml:"cdr" json:"cdr"`
+	// MaintenanceWindow defers agent update, e.g. "Sat 02:00" or "Mon-Fri 01:00-03:00".
+	MaintenanceWindow string `yaml:"maintenance_window" json:"maintenance_window,omitempty"`
+	// OutputProfile is the default --output-profile: operator, support or developer.
+	OutputProfile string `yaml:"output_profile" json:"output_profile,omitempty"`
+	// Secrets is where provider API keys are kept (see Secrets).
+	Secrets Secrets `yaml:"secrets" json:"secrets"`
+
+	// Source is the descriptor file that was loaded (empty when only defaults/env apply).
+	Source string `yaml:"-" json:"source,omitempty"`
+}
+
+var (
+	loadOnce sync.Once
+	current  *Deployment
+	loadErr  error
+)
+
+// Path returns the descriptor location.
+func Path() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_DEPLOYMENT_FILE")); p != "" {
+		return p
+	}
+	return filepath.Join(".agent", "deployment.yaml")
+}
+
+// Current returns the process-wide descriptor, loading it on first use. A malformed
+// file is reported by Err and otherwise treated as absent.
+func Current() *Deployment {
+	loadOnce.Do(func() {
+		current, loadErr = Load(Path())
+		if current == nil {
+			current = resolve(&Deployment{})
+		}
+	})
+	return current
+}
+
+// Err returns the error (if any) from loading the descriptor.
+func Err() error {
+	Current()
+	return loadErr
+}
+
+// Load reads a descriptor file, applies environment overrides and fills defaults.
+// A missing file is not an error.
+func Load(path string) (*Deployment, error) {
+	d := &Deployment{}
+	raw, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(raw, d); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", path, err)
+		}
+		d.Source = path
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return resolve(d), nil
+}
+
+func resolve(d *Deployment) *Deployment {
+	override(&d.Runtime, kube.EnvRuntime)
+	override(&d.Kubernetes.Kubeconfig, "KUBECONFIG")
+	override(&d.Kubernetes.Context, kube.EnvContext)
+	override(&d.Kubernetes.Namespace, kube.EnvNamespace)
+	override(&d.Kubernetes.Label, kube.EnvLabel)
+	override(&d.Containers.Engine, "AAVA_ENGINE_CONTAINER")
+	override(&d.Containers.AdminUI, "AAVA_ADMIN_UI_CONTAINER")
+	override(&d.Containers.LocalAI, "AAVA_LOCAL_AI_CONTAINER")
+	override(&d.Containers.Asterisk, "ASTERISK_CONTAINER")
+	override(&d.ComposeProject, "COMPOSE_PROJECT_NAME")
+	override(&d.DockerContext, "DOCKER_CONTEXT")
+	override(&d.DockerHost, "DOCKER_HOST")
+	override(&d.ProjectDir, "AAVA_PROJECT_DIR")
+	override(&d.Logs.Engine, "AAVA_ENGINE_LOG")
+	override(&d.Logs.Asterisk, "RCA_ASTERISK_LOG")
+	override(&d.CDR.Source, "AAVA_CDR_SOURCE")
+	override(&d.CDR.CSV, "AAVA_CDR_CSV")
+	override(&d.CDR.CELCSV, "AAVA_CEL_CSV")
+	override(&d.CDR.QueueLog, "AAVA_QUEUE_LOG")
+	override(&d.CDR.MySQL.Host, "AAVA_CDR_DB_HOST")
+	override(&d.CDR.MySQL.Port, "AAVA_CDR_DB_PORT")
+	override(&d.CDR.MySQL.User, "AAVA_CDR_DB_USER")
+	override(&d.CDR.MySQL.Database, "AAVA_CDR_DB_NAME")
+	override(&d.MaintenanceWindow, "AAVA_MAINTENANCE_WINDOW")
+	override(&d.OutputProfile, "AAVA_OUTPUT_PROFILE")
+	override(&d.Secrets.Store, "AAVA_SECRETS")
+	override(&d.Secrets.File, "AAVA_SECRETS_FILE")
+	override(&d.Secrets.Vault.Address, "VAULT_ADDR")
+	override(&d.Secrets.Vault.Namespace, "VAULT_NAMESPACE")
+	override(&d.Secrets.Vault.Path, "AAVA_VAULT_PATH")
+	override(&d.Secrets.AWS.SecretID, "AAVA_AWS_SECRET_ID")
+	override(&d.Secrets.AWS.Region, "AWS_REGION")
+	override(&d.Secrets.GCP.Project, "AAVA_GCP_PROJECT")
+	override(&d.Secrets.GCP.Secret, "AAVA_GCP_SECRET")
+
+	orDefault(&d.Runtime, RuntimeCompose)
+	d.Runtime = strings.ToLower(d.Runtime)
+	orDefault(&d.Kubernetes.Label, kube.DefaultLabel)
+	orDefault(&d.Containers.Engine, DefaultEngineContainer)
+	orDefault(&d.Containers.AdminUI, DefaultAdminUIContainer)
+	orDefault(&d.Containers.LocalAI, DefaultLocalAIContainer)
+	orDefault(&d.Containers.Asterisk, DefaultAsteriskContainer)
+	orDefault(&d.ComposeProject, DefaultComposeProject)
+	orDefault(&d.Logs.Engine, "docker")
+	orDefault(&d.Logs.Asterisk, DefaultAsteriskLog)
+	orDefault(&d.CDR.Source, "auto")
+	orDefault(&d.CDR.CSV, DefaultCDRCSV)
+	orDefault(&d.CDR.CELCSV, DefaultCELCSV)
+	orDefault(&d.CDR.QueueLog, DefaultQueueLog)
+	orDefault(&d.CDR.MySQL.Table, "cdr")
+	orDefault(&d.CDR.MySQL.CELTable, "cel")
+	orDefault(&d.Secrets.Store, "env")
+	orDefault(&d.Secrets.Vault.Mount, "secret")
+	orDefault(&d.Secrets.Vault.Path, "aava-agent")
+	orDefault(&d.Secrets.AWS.SecretID, "aava-agent/provider-keys")
+	orDefault(&d.Secrets.GCP.Secret, "aava-agent-provider-keys")
+	return d
+}
+
+func override(field *string, env string) {
+	if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+		*field = v
+	}
+}
+
+func orDefault(field *string, def string) {
+	*field = strings.TrimSpace(*field)
+	if *field == "" {
+		*field = def
+	}
+}
+
+// Apply exports the docker context/host and compose project into the process
+// environment so every docker / docker compose child process targets the same daemon.
+// On Kubernetes it exports the cluster target instead, for internal/kube.
+func (d *Deployment) Apply() {
+	if d.OnKubernetes() {
+		_ = os.Setenv(kube.EnvRuntime, RuntimeKubernetes)
+		setIf("KUBECONFIG", d.Kubernetes.Kubeconfig)
+		setIf(kube.EnvContext, d.Kubernetes.Context)
+		setIf(kube.EnvNamespace, d.Kubernetes.Namespace)
+		setIf(kube.EnvLabel, d.Kubernetes.Label)
+		return
+	}
+	if d.DockerHost != "" {
+		_ = os.Setenv("DOCKER_HOST", d.DockerHost)
+	} else if d.DockerContext != "" {
+		// DOCKER_HOST wins over DOCKER_CONTEXT in the docker CLI; only set one.
+		_ = os.Setenv("DOCKER_CONTEXT", d.DockerContext)
+	}
+	if d.ComposeProject != "" {
+		_ = os.Setenv("COMPOSE_PROJECT_NAME", d.ComposeProject)
+	}
+}
+
+func setIf(env, v string) {
+	if v != "" {
+		_ = os.Setenv(env, v)
+	}
+}
+
+// OnKubernetes reports whether the stack runs on Kubernetes rather than compose.
+func (d *Deployment) OnKubernetes() bool { return d.Runtime == RuntimeKubernetes }
+
+// EngineLogFile returns the engine log path when logs are read from disk.
+func (d *Deployment) EngineLogFile() (string, bool) {
+	if d.Logs.Engine == "" || d.Logs.Engine == "docker" {
+		return "", false
+	}
+	return d.Logs.Engine, true
+}
+
+// EngineContainer returns the ai_engine container name.
+func EngineContainer() string { return Current().Containers.Engine }
+
+// AdminUIContainer returns the admin_ui container name.
+func AdminUIContainer() string { return Current().Containers.AdminUI }
+
+// LocalAIContainer returns the local_ai_server container name.
+func LocalAIContainer() string { return Current().Containers.LocalAI }
+
+// ServiceContainer maps a stock compose service to its configured container name.
+func ServiceContainer(service string) string {
+	switch service {
+	case "ai_engine":
+		return EngineContainer()
+	case "admin_ui":
+		return AdminUIContainer()
+	case "local_ai_server":
+		return LocalAIContainer()
+	}
+	return service
+}
+
+// AsteriskContainer returns the Asterisk container name (when Asterisk is containerized).
+func AsteriskContainer() string { return Current().Containers.Asterisk }
+
+// ComposeProject returns the compose project name.
+func ComposeProject() string { return Current().ComposeProject }
+
+// OpenEngineLogs streams ai_engine log output for the given window
+// (docker logs --since). File-based log sources are streamed whole; callers filter
+// by call id anyway.
+func OpenEngineLogs(since string) (io.ReadCloser, error) {
+	if path, ok := Current().EngineLogFile(); ok {
+		return os.Open(path)
+	}
+	return dockerapi.LogsStream(EngineContainer(), dockerapi.LogsOptions{Since: strings.TrimSpace(since)})
+}
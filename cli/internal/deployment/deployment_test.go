@@ -0,0 +1,84 @@
+package deployment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAppliesFileThenEnvThenDefaults(t *testing.T) {
+	for _, k := range []string{"AAVA_ENGINE_CONTAINER", "AAVA_ADMIN_UI_CONTAINER", "AAVA_LOCAL_AI_CONTAINER", "ASTERISK_CONTAINER", "COMPOSE_PROJECT_NAME", "DOCKER_CONTEXT", "DOCKER_HOST", "AAVA_ENGINE_LOG", "RCA_ASTERISK_LOG"} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("AAVA_ADMIN_UI_CONTAINER", "ui-b")
+
+	path := filepath.Join(t.TempDir(), "deployment.yaml")
+	doc := "compose_project: tenant-b\ndocker_context: pbx2\ncontainers:\n  ai_engine: engine-b\n  admin_ui: ignored\nlogs:\n  ai_engine: /var/log/aava/engine.log\n"
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	d, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if d.Containers.Engine != "engine-b" || d.Containers.AdminUI != "ui-b" || d.Containers.LocalAI != DefaultLocalAIContainer {
+		t.Fatalf("containers=%+v", d.Containers)
+	}
+	if d.ComposeProject != "tenant-b" || d.DockerContext != "pbx2" || d.Source != path {
+		t.Fatalf("deployment=%+v", d)
+	}
+	if p, ok := d.EngineLogFile(); !ok || p != "/var/log/aava/engine.log" {
+		t.Fatalf("EngineLogFile=%q ok=%v", p, ok)
+	}
+
+	t.Setenv("AAVA_SECRETS", "")
+	t.Setenv("VAULT_ADDR", "")
+	for doc, want := range map[string]string{"secrets: keyring\n": "keyring", "secrets:\n  store: vault\n  vault:\n    path: pbx/west\n": "vault"} {
+		if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		d, err := Load(path)
+		if err != nil || d.Secrets.Store != want || d.Secrets.Vault.Mount != "secret" {
+			t.Fatalf("secrets %q = %+v err=%v", doc, d.Secrets, err)
+		}
+		if want == "vault" && d.Secrets.Vault.Path != "pbx/west" {
+			t.Fatalf("vault path = %q", d.Secrets.Vault.Path)
+		}
+	}
+
+	missing, err := Load(filepath.Join(t.TempDir(), "absent.yaml"))
+	if err != nil || missing.Containers.Engine != DefaultEngineContainer || missing.Logs.Engine != "docker" {
+		t.Fatalf("missing file should yield defaults: %+v err=%v", missing, err)
+	}
+}
+
+func TestKubernetesRuntime(t *testing.T) {
+	for _, k := range []string{"AAVA_RUNTIME", "KUBECONFIG", "AAVA_K8S_CONTEXT", "AAVA_K8S_NAMESPACE", "AAVA_K8S_LABEL", "DOCKER_HOST", "DOCKER_CONTEXT", "COMPOSE_PROJECT_NAME"} {
+		t.Setenv(k, "")
+	}
+	path := filepath.Join(t.TempDir(), "deployment.yaml")
+	doc := "runtime: Kubernetes\ndocker_host: ssh://pbx\nkubernetes:\n  context: k3s-pbx\n  namespace: voice\n"
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AAVA_K8S_LABEL", "app.kubernetes.io/name")
+	d, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !d.OnKubernetes() || d.Kubernetes.Context != "k3s-pbx" || d.Kubernetes.Label != "app.kubernetes.io/name" {
+		t.Fatalf("deployment=%+v", d)
+	}
+
+	d.Apply()
+	if os.Getenv("AAVA_RUNTIME") != RuntimeKubernetes || os.Getenv("AAVA_K8S_NAMESPACE") != "voice" || os.Getenv("DOCKER_HOST") != "" {
+		t.Fatalf("env: runtime=%q namespace=%q docker_host=%q", os.Getenv("AAVA_RUNTIME"), os.Getenv("AAVA_K8S_NAMESPACE"), os.Getenv("DOCKER_HOST"))
+	}
+
+	t.Setenv("AAVA_RUNTIME", "")
+	t.Setenv("AAVA_K8S_LABEL", "")
+	if d, _ = Load(filepath.Join(t.TempDir(), "absent.yaml")); d.OnKubernetes() || d.Runtime != RuntimeCompose || d.Kubernetes.Label != "app" {
+		t.Fatalf("default runtime=%q label=%q", d.Runtime, d.Kubernetes.Label)
+	}
+}
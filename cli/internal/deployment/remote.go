@@ -0,0 +1,118 @@
+package deployment
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// ValidateHost checks a --host / DOCKER_HOST value. ssh:// endpoints are dialed by the
+// docker CLI (docker system dial-stdio on the remote side); tcp:// and unix:// are
+// passed through unchanged.
+func ValidateHost(host string) error {
+	host = strings.TrimSpace(host)
+	u, err := url.Parse(host)
+	if err != nil {
+		return fmt.Errorf("invalid --host %q: %w", host, err)
+	}
+	switch u.Scheme {
+	case "ssh":
+		if u.Hostname() == "" {
+			return fmt.Errorf("invalid --host %q: expected ssh://user@server[:port]", host)
+		}
+	case "tcp", "unix", "npipe":
+	default:
+		return fmt.Errorf("invalid --host %q: expected ssh://, tcp://, or unix://", host)
+	}
+	return nil
+}
+
+// SetHost points the descriptor (and every later docker call) at host.
+func (d *Deployment) SetHost(host string) {
+	d.DockerHost = strings.TrimSpace(host)
+	d.DockerContext = ""
+}
+
+// Remote reports whether docker calls target a daemon on another machine. Host-side
+// probes (files under /etc/asterisk, /proc, the local checkout) do not describe that
+// machine and must be skipped. Unix sockets (rootless docker, Podman), Docker
+// Desktop's named pipe and tcp:// to loopback all reach a daemon on this machine.
+func (d *Deployment) Remote() bool {
+	host := strings.TrimSpace(d.DockerHost)
+	if host == "" || strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "npipe://") {
+		return false
+	}
+	u, err := url.Parse(host)
+	if err != nil || u.Scheme != "tcp" {
+		return true
+	}
+	return !isLoopback(u.Hostname())
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// SSHCommand builds an ssh invocation that runs `agent <args>` inside ProjectDir on the
+// remote host. It is used for commands that need the checkout itself (agent update).
+func (d *Deployment) SSHCommand(args []string) (*exec.Cmd, error) {
+	u, err := url.Parse(strings.TrimSpace(d.DockerHost))
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+		return nil, fmt.Errorf("remote execution requires an ssh:// host (got %q)", d.DockerHost)
+	}
+	if strings.TrimSpace(d.ProjectDir) == "" {
+		return nil, fmt.Errorf("remote execution requires project_dir in %s (or AAVA_PROJECT_DIR)", Path())
+	}
+	target := u.Hostname()
+	if u.User != nil && u.User.Username() != "" {
+		target = u.User.Username() + "@" + target
+	}
+	sshArgs := []string{}
+	if p := u.Port(); p != "" {
+		sshArgs = append(sshArgs, "-p", p)
+	}
+	dir := shellQuote(d.ProjectDir)
+	if rest, ok := strings.CutPrefix(d.ProjectDir, "~/"); ok {
+		dir = "~/" + shellQuote(rest)
+	}
+	remote := "cd " + dir + " && agent"
+	for _, a := range args {
+		remote += " " + shellQuote(a)
+	}
+	sshArgs = append(sshArgs, target, remote)
+	return exec.Command("ssh", sshArgs...), nil
+}
+
+// StripHostFlag removes --host (and its value) from a CLI argument list before it is
+// forwarded to a remote agent.
+func StripHostFlag(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--host" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(a, "--host=") {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r == '/' || r == '=' || r == ':' || r == ',' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,62 @@
+package deployment
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSSHCommandForwardsArgsIntoProjectDir(t *testing.T) {
+	t.Parallel()
+
+	if err := ValidateHost("ssh://ops@pbx-west:2222"); err != nil {
+		t.Fatalf("ValidateHost: %v", err)
+	}
+	if err := ValidateHost("http://pbx-west"); err == nil {
+		t.Fatalf("expected http:// to be rejected")
+	}
+
+	d := &Deployment{DockerHost: "ssh://ops@pbx-west:2222", ProjectDir: "/opt/aava"}
+	if !d.Remote() {
+		t.Fatalf("ssh host should be remote")
+	}
+	args := StripHostFlag([]string{"update", "--host", "ssh://ops@pbx-west:2222", "--ref", "v7.2.0", "--host=x", "--plan"})
+	cmd, err := d.SSHCommand(args)
+	if err != nil {
+		t.Fatalf("SSHCommand: %v", err)
+	}
+	got := strings.Join(cmd.Args, " ")
+	want := "ssh -p 2222 ops@pbx-west cd /opt/aava && agent update --ref v7.2.0 --plan"
+	if got != want {
+		t.Fatalf("got  %q\nwant %q", got, want)
+	}
+
+	if _, err := (&Deployment{DockerHost: "tcp://10.0.0.5:2376", ProjectDir: "/opt/aava"}).SSHCommand(nil); err == nil {
+		t.Fatalf("expected tcp:// host to be rejected for remote execution")
+	}
+	if (&Deployment{DockerHost: "unix:///var/run/docker.sock"}).Remote() {
+		t.Fatalf("default unix socket is local")
+	}
+	if (&Deployment{DockerHost: "npipe:////./pipe/docker_engine"}).Remote() {
+		t.Fatalf("Docker Desktop named pipe is local")
+	}
+}
+
+func TestRemoteTreatsSocketsAndLoopbackAsLocal(t *testing.T) {
+	t.Parallel()
+
+	for host, remote := range map[string]bool{
+		"":                                  false,
+		"unix:///var/run/docker.sock":       false,
+		"unix:///run/user/1000/docker.sock": false,
+		"unix:///run/podman/podman.sock":    false,
+		"tcp://127.0.0.1:2375":              false,
+		"tcp://localhost:2376":              false,
+		"tcp://[::1]:2375":                  false,
+		"tcp://10.0.0.5:2376":               true,
+		"tcp://pbx-west:2376":               true,
+	} {
+		if got := (&Deployment{DockerHost: host}).Remote(); got != remote {
+			t.Errorf("Remote(%q) = %t, want %t", host, got, remote)
+		}
+	}
+}
@@ -18,29 +18,153 @@ func GenerateSnippet(provider string) string {
 	return GenerateAgentSnippet("default", provider)
 }
 
+// DefaultAppName is the Stasis application the engine registers by default.
+const DefaultAppName = "asterisk-ai-voice-agent"
+
+// Options describe the installation a snippet is generated for. Empty transport
+// fields leave the transport notes out.
+type Options struct {
+	Agent    string
+	Provider string
+	AppName  string // asterisk.app_name
+
+	Transport          string // audiosocket | externalmedia
+	AudioSocketHost    string
+	AudioSocketPort    string
+	AudioSocketFormat  string
+	ExternalMediaHost  string
+	ExternalMediaPorts string // external_media.port_range
+	ExternalMediaCodec string
+
+	// Extension, when set, adds an extension number in FromContext that jumps to
+	// the agent context. FreePBX includes from-internal-custom in from-internal,
+	// so that is where it goes there instead of editing extensions.conf.
+	Extension   string
+	FromContext string
+}
+
+// FreePBXFromContext is the FreePBX hook context for internal dial-by-number.
+const FreePBXFromContext = "from-internal-custom"
+
 // GenerateAgentSnippet emits the v7 dialplan form. AI_AGENT selects the
 // operator-managed agent; AI_PROVIDER is optional and only needed as an
 // explicit per-call override.
 func GenerateAgentSnippet(agent, provider string) string {
-	ctx := getContextForProvider(provider)
-	if strings.TrimSpace(agent) == "" {
+	return Generate(Options{Agent: agent, Provider: provider})
+}
+
+// Generate emits the snippet for an installation. The engine creates the
+// AudioSocket or ExternalMedia channel itself over ARI, so the dialplan only
+// enters Stasis; the transport lines are comments recording what Asterisk must
+// be able to reach.
+func Generate(o Options) string {
+	ctx := getContextForProvider(o.Provider)
+	agent := strings.TrimSpace(o.Agent)
+	if agent == "" {
 		agent = "default"
 	}
+	app := strings.TrimSpace(o.AppName)
+	if app == "" {
+		app = DefaultAppName
+	}
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("; AI Voice Agent - %s\n", ctx.Description))
+	for _, note := range transportNotes(o) {
+		sb.WriteString("; " + note + "\n")
+	}
 	sb.WriteString(fmt.Sprintf("[%s]\n", ctx.Name))
 	sb.WriteString(fmt.Sprintf("exten => s,1,NoOp(%s)\n", ctx.Description))
 	sb.WriteString(fmt.Sprintf(" same => n,Set(AI_AGENT=%s)\n", agent))
-	if strings.TrimSpace(provider) != "" {
+	if strings.TrimSpace(o.Provider) != "" {
 		sb.WriteString(fmt.Sprintf(" same => n,Set(AI_PROVIDER=%s)\n", ctx.Provider))
 	}
-	sb.WriteString(" same => n,Stasis(asterisk-ai-voice-agent)\n")
+	sb.WriteString(fmt.Sprintf(" same => n,Stasis(%s)\n", app))
 	sb.WriteString(" same => n,Hangup()\n")
 
+	if ext := strings.TrimSpace(o.Extension); ext != "" {
+		sb.WriteString(fmt.Sprintf("\n; Dial %s to reach the agent\n", ext))
+		sb.WriteString(fmt.Sprintf("[%s]\n", emptyTo(o.FromContext, "from-internal")))
+		sb.WriteString(fmt.Sprintf("exten => %s,1,Goto(%s,s,1)\n", ext, ctx.Name))
+	}
+
+	return sb.String()
+}
+
+func transportNotes(o Options) []string {
+	switch o.Transport {
+	case "audiosocket":
+		host := emptyTo(o.AudioSocketHost, "ai_engine host")
+		if host == "0.0.0.0" || host == "127.0.0.1" {
+			host = "ai_engine host"
+		}
+		return []string{
+			fmt.Sprintf("Transport: AudioSocket (%s) to %s:%s, opened by ai_engine over ARI;", emptyTo(o.AudioSocketFormat, "slin"), host, emptyTo(o.AudioSocketPort, "8090")),
+			"Asterisk needs res_audiosocket/chan_audiosocket loaded and TCP access to that port.",
+		}
+	case "externalmedia":
+		return []string{
+			fmt.Sprintf("Transport: ExternalMedia RTP (%s) to %s ports %s, opened by ai_engine over ARI;", emptyTo(o.ExternalMediaCodec, "ulaw"), emptyTo(o.ExternalMediaHost, "ai_engine host"), emptyTo(o.ExternalMediaPorts, "18080:18099")),
+			"those UDP ports must be reachable from Asterisk.",
+		}
+	}
+	return nil
+}
+
+func emptyTo(s, fallback string) string {
+	if strings.TrimSpace(s) == "" {
+		return fallback
+	}
+	return s
+}
+
+// ContextName is the context the snippet defines for a provider.
+func ContextName(provider string) string {
+	return getContextForProvider(provider).Name
+}
+
+// VerifyContext checks `dialplan show <context>` output for a Stasis(app) step.
+func VerifyContext(output, context, app string) error {
+	if strings.Contains(output, "There is no existence of") || !strings.Contains(output, "Context '"+context+"'") {
+		return fmt.Errorf("context [%s] is not loaded in Asterisk", context)
+	}
+	if !strings.Contains(output, "Stasis("+app) {
+		return fmt.Errorf("context [%s] is loaded but has no Stasis(%s) step", context, app)
+	}
+	return nil
+}
+
+// LoadtestContext is where agent loadtest sends the caller half of each
+// synthetic call.
+const LoadtestContext = "aava-loadtest-caller"
+
+// LoadtestSnippet is the caller side of a load test call: once the agent
+// answers it plays the recording named by AAVA_LOADTEST_AUDIO, then stays on
+// the line for AAVA_LOADTEST_HOLD seconds so the agent can respond.
+func LoadtestSnippet() string {
+	var sb strings.Builder
+	sb.WriteString("; AI Voice Agent - load test caller (agent loadtest)\n")
+	sb.WriteString(fmt.Sprintf("[%s]\n", LoadtestContext))
+	sb.WriteString("exten => s,1,NoOp(Load test caller)\n")
+	sb.WriteString(" same => n,Wait(1)\n")
+	sb.WriteString(" same => n,Playback(${AAVA_LOADTEST_AUDIO})\n")
+	sb.WriteString(" same => n,Wait(${AAVA_LOADTEST_HOLD})\n")
+	sb.WriteString(" same => n,Hangup()\n")
 	return sb.String()
 }
 
+// VerifyLoadtestContext checks `dialplan show aava-loadtest-caller` output for
+// the Playback step.
+func VerifyLoadtestContext(output string) error {
+	if strings.Contains(output, "There is no existence of") || !strings.Contains(output, "Context '"+LoadtestContext+"'") {
+		return fmt.Errorf("context [%s] is not loaded in Asterisk", LoadtestContext)
+	}
+	if !strings.Contains(output, "Playback(${AAVA_LOADTEST_AUDIO})") {
+		return fmt.Errorf("context [%s] is loaded but does not play ${AAVA_LOADTEST_AUDIO}", LoadtestContext)
+	}
+	return nil
+}
+
 // getContextForProvider returns context info for a provider
 func getContextForProvider(provider string) Context {
 	contexts := map[string]Context{
@@ -0,0 +1,83 @@
+package dialplan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateUsesAppNameAndTransport(t *testing.T) {
+	got := Generate(Options{
+		Agent:           "sales",
+		Provider:        "deepgram",
+		AppName:         "my-agent",
+		Transport:       "audiosocket",
+		AudioSocketHost: "10.8.0.5",
+		AudioSocketPort: "9092",
+	})
+	for _, want := range []string{"[from-ai-agent-deepgram]", "Set(AI_AGENT=sales)", "Set(AI_PROVIDER=deepgram)", "Stasis(my-agent)", "10.8.0.5:9092"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("snippet missing %q:\n%s", want, got)
+		}
+	}
+	if plain := GenerateAgentSnippet("", ""); !strings.Contains(plain, "Stasis("+DefaultAppName+")") || strings.Contains(plain, "Transport:") {
+		t.Errorf("default snippet:\n%s", plain)
+	}
+}
+
+func TestGenerateFreePBXExtension(t *testing.T) {
+	got := Generate(Options{Extension: "7000", FromContext: FreePBXFromContext})
+	if !strings.Contains(got, "[from-internal-custom]\nexten => 7000,1,Goto(from-ai-agent,s,1)\n") {
+		t.Errorf("snippet:\n%s", got)
+	}
+}
+
+func TestLoadOptionsMergesLocalAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "ai-agent.yaml")
+	local := filepath.Join(dir, "ai-agent.local.yaml")
+	os.WriteFile(base, []byte("asterisk: {app_name: base-app}\naudio_transport: audiosocket\naudiosocket: {port: 8090}\nexternal_media: {port_range: '18080:18099'}\n"), 0o644)
+	os.WriteFile(local, []byte("asterisk: {app_name: local-app}\n"), 0o644)
+	t.Setenv("AUDIO_TRANSPORT", "externalmedia")
+
+	o := LoadOptions(base, local)
+	if o.AppName != "local-app" || o.Transport != "externalmedia" || o.ExternalMediaPorts != "18080:18099" || o.AudioSocketPort != "8090" {
+		t.Fatalf("options = %+v", o)
+	}
+}
+
+func TestVerifyContext(t *testing.T) {
+	shown := `[ Context 'from-ai-agent' created by 'pbx_config' ]
+  's' =>            1. NoOp(AI Agent)                             [extensions_custom.conf:3]
+                    2. Set(AI_AGENT=default)                      [extensions_custom.conf:4]
+                    3. Stasis(asterisk-ai-voice-agent)            [extensions_custom.conf:5]
+`
+	if err := VerifyContext(shown, "from-ai-agent", DefaultAppName); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyContext(shown, "from-ai-agent", "other-app"); err == nil {
+		t.Fatal("accepted a context without the app's Stasis step")
+	}
+	if err := VerifyContext("There is no existence of 'from-ai-agent' context\n", "from-ai-agent", DefaultAppName); err == nil {
+		t.Fatal("accepted a missing context")
+	}
+}
+
+func TestLoadtestSnippetVerifies(t *testing.T) {
+	snippet := LoadtestSnippet()
+	if !strings.Contains(snippet, "["+LoadtestContext+"]") || !strings.Contains(snippet, "Wait(${AAVA_LOADTEST_HOLD})") {
+		t.Fatalf("snippet:\n%s", snippet)
+	}
+	shown := `[ Context 'aava-loadtest-caller' created by 'pbx_config' ]
+  's' =>            1. NoOp(Load test caller)                     [extensions_custom.conf:12]
+                    2. Wait(1)                                    [extensions_custom.conf:13]
+                    3. Playback(${AAVA_LOADTEST_AUDIO})           [extensions_custom.conf:14]
+`
+	if err := VerifyLoadtestContext(shown); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyLoadtestContext("There is no existence of 'aava-loadtest-caller' context\n"); err == nil {
+		t.Fatal("accepted a missing context")
+	}
+}
@@ -0,0 +1,55 @@
+package dialplan
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+)
+
+// LoadOptions fills the app name and transport settings from the config layers
+// (config/ai-agent.yaml, an environment overlay, the local override; merged in
+// order) and the .env-style environment overrides the engine applies
+// (AUDIO_TRANSPORT, AUDIOSOCKET_*, *_ADVERTISE_HOST). Missing files are skipped.
+func LoadOptions(paths ...string) Options {
+	cfg := map[string]any{}
+	for _, path := range paths {
+		m, err := configmerge.ReadYAMLFile(path)
+		if err != nil {
+			continue
+		}
+		cfg = configmerge.DeepMerge(cfg, m)
+	}
+	str := func(path ...string) string {
+		var cur any = cfg
+		for _, k := range path {
+			m, ok := cur.(map[string]any)
+			if !ok {
+				return ""
+			}
+			cur = m[k]
+		}
+		if cur == nil {
+			return ""
+		}
+		return strings.TrimSpace(fmt.Sprint(cur))
+	}
+	env := func(key, fallback string) string {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	return Options{
+		AppName:            str("asterisk", "app_name"),
+		Transport:          strings.ToLower(env("AUDIO_TRANSPORT", str("audio_transport"))),
+		AudioSocketHost:    env("AUDIOSOCKET_ADVERTISE_HOST", env("AUDIOSOCKET_HOST", str("audiosocket", "host"))),
+		AudioSocketPort:    env("AUDIOSOCKET_PORT", str("audiosocket", "port")),
+		AudioSocketFormat:  env("AUDIOSOCKET_FORMAT", str("audiosocket", "format")),
+		ExternalMediaHost:  env("EXTERNAL_MEDIA_ADVERTISE_HOST", str("external_media", "rtp_host")),
+		ExternalMediaPorts: str("external_media", "port_range"),
+		ExternalMediaCodec: str("external_media", "codec"),
+	}
+}
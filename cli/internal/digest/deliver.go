@@ -0,0 +1,131 @@
+package digest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Email is where a digest is mailed, read from the environment (or .env):
+//
+//	AAVA_DIGEST_SMTP           host:port of the mail server (port 587 if left out)
+//	AAVA_DIGEST_SMTP_USER      login, when the server needs one
+//	AAVA_DIGEST_SMTP_PASSWORD  its password
+//	AAVA_DIGEST_EMAIL_FROM     sender address
+//	AAVA_DIGEST_EMAIL_TO       recipients, comma separated
+type Email struct {
+	Addr     string
+	User     string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailFromEnv returns the email settings, or an error naming what is missing.
+func EmailFromEnv() (Email, error) {
+	e := Email{
+		Addr:     strings.TrimSpace(os.Getenv("AAVA_DIGEST_SMTP")),
+		User:     strings.TrimSpace(os.Getenv("AAVA_DIGEST_SMTP_USER")),
+		Password: os.Getenv("AAVA_DIGEST_SMTP_PASSWORD"),
+		From:     strings.TrimSpace(os.Getenv("AAVA_DIGEST_EMAIL_FROM")),
+	}
+	for _, to := range strings.Split(os.Getenv("AAVA_DIGEST_EMAIL_TO"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			e.To = append(e.To, to)
+		}
+	}
+	var missing []string
+	if e.Addr == "" {
+		missing = append(missing, "AAVA_DIGEST_SMTP")
+	}
+	if e.From == "" {
+		missing = append(missing, "AAVA_DIGEST_EMAIL_FROM")
+	}
+	if len(e.To) == 0 {
+		missing = append(missing, "AAVA_DIGEST_EMAIL_TO")
+	}
+	if len(missing) > 0 {
+		return Email{}, fmt.Errorf("email delivery needs %s", strings.Join(missing, ", "))
+	}
+	if _, _, err := net.SplitHostPort(e.Addr); err != nil {
+		e.Addr = net.JoinHostPort(e.Addr, "587")
+	}
+	return e, nil
+}
+
+// Send mails the digest as plain text. The server's STARTTLS is used when it
+// offers it, and a login is only sent over TLS or to localhost.
+func (e Email) Send(d *Digest) error {
+	var auth smtp.Auth
+	if e.User != "" {
+		host, _, _ := net.SplitHostPort(e.Addr)
+		auth = smtp.PlainAuth("", e.User, e.Password, host)
+	}
+	msg := e.message(d, time.Now())
+	if err := smtp.SendMail(e.Addr, auth, e.From, e.To, msg); err != nil {
+		return fmt.Errorf("send email via %s: %w", e.Addr, err)
+	}
+	return nil
+}
+
+func (e Email) message(d *Digest, now time.Time) []byte {
+	var body bytes.Buffer
+	d.WriteText(&body)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", d.Title()+": "+d.Headline()))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// SlackWebhook is the Slack incoming webhook URL from AAVA_DIGEST_SLACK_WEBHOOK.
+func SlackWebhook() (string, error) {
+	webhook := strings.TrimSpace(os.Getenv("AAVA_DIGEST_SLACK_WEBHOOK"))
+	if webhook == "" {
+		return "", errors.New("Slack delivery needs AAVA_DIGEST_SLACK_WEBHOOK (an incoming webhook URL)")
+	}
+	return webhook, nil
+}
+
+// PostSlack posts the digest to a Slack incoming webhook, the text in a code
+// block so its columns line up. The webhook URL is a secret and is kept out of
+// the errors.
+func PostSlack(client *http.Client, webhook string, d *Digest) error {
+	var text bytes.Buffer
+	d.WriteText(&text)
+	payload, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*: %s\n```\n%s```", d.Title(), d.Headline(), text.String()),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("post to Slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("post to Slack: %s: %s", resp.Status, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
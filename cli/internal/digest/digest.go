@@ -0,0 +1,351 @@
+// Package digest summarizes a period of a deployment's operation for agent
+// digest: how many calls it took and how good they were, which agent check
+// items failed, the incidents worth a look and the known issues seen most.
+// The digest is printed, mailed or posted to Slack (see deliver.go).
+package digest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+const (
+	// CriticalScore is the quality score below which agent rca calls a call
+	// critical; each such call is an incident.
+	CriticalScore = 50
+	// maxCallIncidents bounds the critical calls listed, worst first.
+	maxCallIncidents = 10
+	// maxTopIssues is how many known issues are listed.
+	maxTopIssues = 5
+)
+
+// Incident kinds.
+const (
+	IncidentCriticalCall    = "critical_call"
+	IncidentRegression      = "regression"
+	IncidentAsteriskRestart = "asterisk_restart"
+)
+
+// Input is what a digest is built from. Calls, samples, check runs and events
+// outside From..To are ignored; regressions are taken as given.
+type Input struct {
+	From, To    time.Time
+	Host        string
+	Calls       []troubleshoot.Call
+	Samples     []troubleshoot.QualitySample
+	Checks      []check.RunSummary
+	Events      []troubleshoot.TrendEvent
+	Regressions []troubleshoot.Regression
+	// Notes explain data the digest could not get, such as logs that could not be read.
+	Notes []string
+}
+
+// Digest is the summary, as printed by agent digest --json and sent to sinks.
+type Digest struct {
+	SchemaVersion int                       `json:"schema_version"`
+	Host          string                    `json:"host,omitempty"`
+	From          time.Time                 `json:"from"`
+	To            time.Time                 `json:"to"`
+	Calls         CallStats                 `json:"calls"`
+	Checks        CheckStats                `json:"checks"`
+	Incidents     []Incident                `json:"incidents"`
+	TopIssues     []IssueCount              `json:"top_issues"`
+	Changes       []troubleshoot.TrendEvent `json:"changes"`
+	Notes         []string                  `json:"notes,omitempty"`
+}
+
+// CallStats counts the period's calls. Quality covers the calls agent rca analyzed.
+type CallStats struct {
+	Total          int      `json:"total"`
+	Analyzed       int      `json:"analyzed"`
+	AverageQuality *float64 `json:"average_quality,omitempty"`
+	Critical       int      `json:"critical"`
+}
+
+// CheckStats summarizes the agent check runs of the period.
+type CheckStats struct {
+	Runs        int           `json:"runs"`
+	FailingRuns int           `json:"failing_runs"`
+	Failed      []FailedCheck `json:"failed"`
+}
+
+// FailedCheck is a check item that failed in at least one run.
+type FailedCheck struct {
+	Name    string    `json:"name"`
+	Runs    int       `json:"runs"`
+	LastAt  time.Time `json:"last_at"`
+	Message string    `json:"message"`
+	// Failing is set when the item failed in the period's latest run.
+	Failing bool `json:"failing"`
+}
+
+// Incident is something that went wrong during the period.
+type Incident struct {
+	At      time.Time `json:"at"`
+	Kind    string    `json:"kind"`
+	Summary string    `json:"summary"`
+	CallID  string    `json:"call_id,omitempty"`
+}
+
+// IssueCount is a known issue and how many analyzed calls matched it.
+type IssueCount struct {
+	Title string `json:"title"`
+	Calls int    `json:"calls"`
+}
+
+// Build summarizes in.
+func Build(in Input) *Digest {
+	d := &Digest{
+		SchemaVersion: contract.SchemaVersion,
+		Host:          in.Host,
+		From:          in.From,
+		To:            in.To,
+		Incidents:     []Incident{},
+		TopIssues:     []IssueCount{},
+		Changes:       []troubleshoot.TrendEvent{},
+		Notes:         in.Notes,
+	}
+	within := func(t time.Time) bool { return !t.Before(in.From) && !t.After(in.To) }
+
+	for _, c := range in.Calls {
+		if within(c.Timestamp) {
+			d.Calls.Total++
+		}
+	}
+
+	var critical []troubleshoot.QualitySample
+	issues := map[string]int{}
+	sum := 0.0
+	for _, s := range in.Samples {
+		if !within(s.At) {
+			continue
+		}
+		d.Calls.Analyzed++
+		sum += s.Score
+		if s.Score < CriticalScore {
+			critical = append(critical, s)
+		}
+		for _, title := range s.KnownIssues {
+			issues[title]++
+		}
+	}
+	if d.Calls.Analyzed > 0 {
+		avg := sum / float64(d.Calls.Analyzed)
+		d.Calls.AverageQuality = &avg
+	}
+	d.Calls.Critical = len(critical)
+	sort.SliceStable(critical, func(i, j int) bool { return critical[i].Score < critical[j].Score })
+	if len(critical) > maxCallIncidents {
+		critical = critical[:maxCallIncidents]
+	}
+	for _, s := range critical {
+		d.Incidents = append(d.Incidents, Incident{
+			At: s.At, Kind: IncidentCriticalCall, CallID: s.CallID,
+			Summary: fmt.Sprintf("call %s scored %.0f/100", s.CallID, s.Score),
+		})
+	}
+
+	for title, n := range issues {
+		d.TopIssues = append(d.TopIssues, IssueCount{Title: title, Calls: n})
+	}
+	sort.Slice(d.TopIssues, func(i, j int) bool {
+		if d.TopIssues[i].Calls != d.TopIssues[j].Calls {
+			return d.TopIssues[i].Calls > d.TopIssues[j].Calls
+		}
+		return d.TopIssues[i].Title < d.TopIssues[j].Title
+	})
+	if len(d.TopIssues) > maxTopIssues {
+		d.TopIssues = d.TopIssues[:maxTopIssues]
+	}
+
+	d.Checks = checkStats(in.Checks, within)
+
+	for _, e := range in.Events {
+		if !within(e.At) {
+			continue
+		}
+		if e.Kind == troubleshoot.EventAsteriskRestart {
+			d.Incidents = append(d.Incidents, Incident{At: e.At, Kind: IncidentAsteriskRestart, Summary: e.Label})
+			continue
+		}
+		d.Changes = append(d.Changes, e)
+	}
+	for _, r := range in.Regressions {
+		d.Incidents = append(d.Incidents, Incident{
+			At: r.Event.At, Kind: IncidentRegression,
+			Summary: fmt.Sprintf("%s worse after %s: median %s -> %s", r.Metric, r.Event.Label, metricValue(r.Before, r.Metric), metricValue(r.After, r.Metric)),
+		})
+	}
+	sort.SliceStable(d.Incidents, func(i, j int) bool { return d.Incidents[i].At.Before(d.Incidents[j].At) })
+	return d
+}
+
+// checkStats counts the runs in the period and groups the failed items by name.
+func checkStats(runs []check.RunSummary, within func(time.Time) bool) CheckStats {
+	stats := CheckStats{Failed: []FailedCheck{}}
+	byName := map[string]int{}
+	var latest *check.RunSummary
+	for i, run := range runs {
+		if !within(run.At) {
+			continue
+		}
+		stats.Runs++
+		if latest == nil || !run.At.Before(latest.At) {
+			latest = &runs[i]
+		}
+		if len(run.Failed) > 0 {
+			stats.FailingRuns++
+		}
+		for _, item := range run.Failed {
+			n, ok := byName[item.Name]
+			if !ok {
+				n = len(stats.Failed)
+				byName[item.Name] = n
+				stats.Failed = append(stats.Failed, FailedCheck{Name: item.Name})
+			}
+			f := &stats.Failed[n]
+			f.Runs++
+			if !run.At.Before(f.LastAt) {
+				f.LastAt, f.Message = run.At, item.Message
+			}
+		}
+	}
+	if latest != nil {
+		for _, item := range latest.Failed {
+			stats.Failed[byName[item.Name]].Failing = true
+		}
+	}
+	sort.SliceStable(stats.Failed, func(i, j int) bool {
+		if stats.Failed[i].Failing != stats.Failed[j].Failing {
+			return stats.Failed[i].Failing
+		}
+		return stats.Failed[i].Runs > stats.Failed[j].Runs
+	})
+	return stats
+}
+
+// Code is the digest's exit code: Fail while a check is still failing, Warn
+// for checks that failed earlier in the period and for incidents.
+func (d *Digest) Code() int {
+	for _, f := range d.Checks.Failed {
+		if f.Failing {
+			return contract.Fail
+		}
+	}
+	if len(d.Checks.Failed) > 0 || len(d.Incidents) > 0 {
+		return contract.Warn
+	}
+	return contract.OK
+}
+
+// Title names the digest and its period, as the email subject does.
+func (d *Digest) Title() string {
+	title := "Digest"
+	if d.To.Sub(d.From) == 24*time.Hour {
+		title = "Daily digest"
+	}
+	if d.Host != "" {
+		title += " for " + d.Host
+	}
+	return title
+}
+
+// Headline is a one-line summary of the digest.
+func (d *Digest) Headline() string {
+	parts := []string{fmt.Sprintf("%d call(s)", d.Calls.Total)}
+	if d.Calls.AverageQuality != nil {
+		parts = append(parts, fmt.Sprintf("average quality %.0f", *d.Calls.AverageQuality))
+	}
+	if n := len(d.Checks.Failed); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed check(s)", n))
+	}
+	if n := len(d.Incidents); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d incident(s)", n))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteText writes the digest as plain text; the email body and the Slack
+// message are this text too.
+func (d *Digest) WriteText(w io.Writer) {
+	const stamp = "2006-01-02 15:04"
+	fmt.Fprintf(w, "%s, %s to %s\n", d.Title(), d.From.Format(stamp), d.To.Format(stamp+" MST"))
+	fmt.Fprintf(w, "Result: %s\n\n", result(d.Code()))
+
+	fmt.Fprintf(w, "Calls: %d", d.Calls.Total)
+	if d.Calls.Analyzed > 0 {
+		fmt.Fprintf(w, ", %d analyzed, average quality %.0f/100, %d critical (below %d)", d.Calls.Analyzed, *d.Calls.AverageQuality, d.Calls.Critical, CriticalScore)
+	} else if d.Calls.Total > 0 {
+		fmt.Fprint(w, ", none analyzed (quality is recorded when agent rca analyzes a call)")
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "Checks: %d run(s)", d.Checks.Runs)
+	if d.Checks.Runs > 0 {
+		fmt.Fprintf(w, ", %d with failures", d.Checks.FailingRuns)
+	}
+	fmt.Fprintln(w)
+	for _, f := range d.Checks.Failed {
+		state := "since passed"
+		if f.Failing {
+			state = "still failing"
+		}
+		fmt.Fprintf(w, "  ❌ %s: failed in %d run(s), %s; last %s: %s\n", f.Name, f.Runs, state, f.LastAt.Format("15:04"), f.Message)
+	}
+
+	fmt.Fprintln(w)
+	if len(d.Incidents) == 0 {
+		fmt.Fprintln(w, "Incidents: none")
+	} else {
+		fmt.Fprintln(w, "Incidents:")
+		for _, inc := range d.Incidents {
+			fmt.Fprintf(w, "  %s  %s\n", inc.At.Format(stamp), inc.Summary)
+		}
+		if more := d.Calls.Critical - maxCallIncidents; more > 0 {
+			fmt.Fprintf(w, "  ... and %d more critical call(s)\n", more)
+		}
+	}
+	if len(d.TopIssues) > 0 {
+		fmt.Fprintln(w, "Top issues:")
+		for _, issue := range d.TopIssues {
+			fmt.Fprintf(w, "  %3d call(s)  %s\n", issue.Calls, issue.Title)
+		}
+	}
+	if len(d.Changes) > 0 {
+		fmt.Fprintln(w, "Changes:")
+		for _, e := range d.Changes {
+			fmt.Fprintf(w, "  %s  %s\n", e.At.Format(stamp), e.Label)
+		}
+	}
+	if len(d.Notes) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, note := range d.Notes {
+		fmt.Fprintf(w, "Note: %s\n", note)
+	}
+}
+
+func result(code int) string {
+	switch code {
+	case contract.Fail:
+		return "FAIL"
+	case contract.Warn:
+		return "WARN"
+	}
+	return "PASS"
+}
+
+// metricValue formats a trend metric as agent trend does.
+func metricValue(v float64, metric string) string {
+	if metric == "score" {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f%%", v)
+}
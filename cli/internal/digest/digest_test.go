@@ -0,0 +1,192 @@
+package digest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+var to = time.Date(2026, 10, 15, 7, 0, 0, 0, time.UTC)
+
+func hoursAgo(h float64) time.Time { return to.Add(-time.Duration(h * float64(time.Hour))) }
+
+func sample() Input {
+	return Input{
+		From: to.Add(-24 * time.Hour),
+		To:   to,
+		Host: "pbx1",
+		Calls: []troubleshoot.Call{
+			{ID: "1.1", Timestamp: hoursAgo(20)},
+			{ID: "1.2", Timestamp: hoursAgo(10)},
+			{ID: "1.3", Timestamp: hoursAgo(2)},
+			{ID: "0.9", Timestamp: hoursAgo(30)},
+		},
+		Samples: []troubleshoot.QualitySample{
+			{CallID: "1.1", At: hoursAgo(20), Score: 96},
+			{CallID: "1.2", At: hoursAgo(10), Score: 40, KnownIssues: []string{"Provider closed the socket", "Jitter buffer too small"}},
+			{CallID: "1.3", At: hoursAgo(2), Score: 80, KnownIssues: []string{"Provider closed the socket"}},
+			{CallID: "0.9", At: hoursAgo(30), Score: 10},
+		},
+		Checks: []check.RunSummary{
+			{At: hoursAgo(23), Pass: 20},
+			{At: hoursAgo(12), Pass: 18, Fail: 2, Failed: []check.Item{{Name: "ARI", Message: "connection refused"}, {Name: "Disk space", Message: "91% used"}}},
+			{At: hoursAgo(1), Pass: 19, Fail: 1, Failed: []check.Item{{Name: "Disk space", Message: "93% used"}}},
+		},
+		Events: []troubleshoot.TrendEvent{
+			{At: hoursAgo(12.5), Kind: troubleshoot.EventAsteriskRestart, Label: "Asterisk restarted"},
+			{At: hoursAgo(5), Kind: troubleshoot.EventNote, Label: "switched to Deepgram"},
+			{At: hoursAgo(48), Kind: troubleshoot.EventUpdate, Label: "update (backup 1)"},
+		},
+		Regressions: []troubleshoot.Regression{
+			{Event: troubleshoot.TrendEvent{At: hoursAgo(48), Label: "update (backup 1)"}, Metric: "score", Before: 92, After: 71},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	d := Build(sample())
+	if d.Calls.Total != 3 || d.Calls.Analyzed != 3 || d.Calls.Critical != 1 {
+		t.Errorf("calls = %+v", d.Calls)
+	}
+	if d.Calls.AverageQuality == nil || *d.Calls.AverageQuality != 72 {
+		t.Errorf("average quality = %v", d.Calls.AverageQuality)
+	}
+	if d.Checks.Runs != 3 || d.Checks.FailingRuns != 2 || len(d.Checks.Failed) != 2 {
+		t.Fatalf("checks = %+v", d.Checks)
+	}
+	if f := d.Checks.Failed[0]; f.Name != "Disk space" || !f.Failing || f.Runs != 2 || f.Message != "93% used" {
+		t.Errorf("first failed check = %+v", f)
+	}
+	if f := d.Checks.Failed[1]; f.Name != "ARI" || f.Failing {
+		t.Errorf("second failed check = %+v", f)
+	}
+	var kinds []string
+	for _, inc := range d.Incidents {
+		kinds = append(kinds, inc.Kind)
+	}
+	if got, want := strings.Join(kinds, ","), "regression,asterisk_restart,critical_call"; got != want {
+		t.Errorf("incidents = %s, want %s", got, want)
+	}
+	if len(d.TopIssues) != 2 || d.TopIssues[0] != (IssueCount{Title: "Provider closed the socket", Calls: 2}) {
+		t.Errorf("top issues = %+v", d.TopIssues)
+	}
+	if len(d.Changes) != 1 || d.Changes[0].Label != "switched to Deepgram" {
+		t.Errorf("changes = %+v", d.Changes)
+	}
+	if d.Code() != contract.Fail {
+		t.Errorf("code = %d with a check still failing", d.Code())
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	Build(sample()).WriteText(&buf)
+	text := buf.String()
+	for _, want := range []string{
+		"Daily digest for pbx1, 2026-10-14 07:00 to 2026-10-15 07:00 UTC",
+		"Result: FAIL",
+		"Calls: 3, 3 analyzed, average quality 72/100, 1 critical (below 50)",
+		"Checks: 3 run(s), 2 with failures",
+		"❌ Disk space: failed in 2 run(s), still failing; last 06:00: 93% used",
+		"❌ ARI: failed in 1 run(s), since passed",
+		"2026-10-14 21:00  call 1.2 scored 40/100",
+		"score worse after update (backup 1): median 92 -> 71",
+		"2026-10-14 18:30  Asterisk restarted",
+		"  2 call(s)  Provider closed the socket",
+		"switched to Deepgram",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestQuietPeriod(t *testing.T) {
+	d := Build(Input{From: to.Add(-24 * time.Hour), To: to, Checks: []check.RunSummary{{At: hoursAgo(1), Pass: 20}}})
+	if d.Code() != contract.OK {
+		t.Errorf("code = %d for a quiet day", d.Code())
+	}
+	var buf bytes.Buffer
+	d.WriteText(&buf)
+	for _, want := range []string{"Daily digest, ", "Result: PASS", "Calls: 0\n", "Incidents: none"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text lacks %q:\n%s", want, buf.String())
+		}
+	}
+	// JSON lists are empty, not null, for dashboards that iterate them.
+	raw, _ := json.Marshal(d)
+	for _, want := range []string{`"incidents":[]`, `"top_issues":[]`, `"failed":[]`, `"changes":[]`} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("JSON lacks %s: %s", want, raw)
+		}
+	}
+}
+
+func TestEmailFromEnv(t *testing.T) {
+	t.Setenv("AAVA_DIGEST_SMTP", "")
+	t.Setenv("AAVA_DIGEST_EMAIL_FROM", "")
+	t.Setenv("AAVA_DIGEST_EMAIL_TO", "")
+	if _, err := EmailFromEnv(); err == nil || !strings.Contains(err.Error(), "AAVA_DIGEST_SMTP, AAVA_DIGEST_EMAIL_FROM, AAVA_DIGEST_EMAIL_TO") {
+		t.Errorf("err = %v", err)
+	}
+	t.Setenv("AAVA_DIGEST_SMTP", "mail.example.com")
+	t.Setenv("AAVA_DIGEST_EMAIL_FROM", "pbx@example.com")
+	t.Setenv("AAVA_DIGEST_EMAIL_TO", "ops@example.com, oncall@example.com,")
+	e, err := EmailFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Addr != "mail.example.com:587" || len(e.To) != 2 {
+		t.Errorf("email = %+v", e)
+	}
+
+	msg := string(e.message(Build(sample()), to))
+	for _, want := range []string{
+		"To: ops@example.com, oncall@example.com\r\n",
+		"Subject: Daily digest for pbx1: 3 call(s), average quality 72, 2 failed check(s), 3 incident(s)\r\n",
+		"Content-Transfer-Encoding: 8bit\r\n\r\nDaily digest",
+		"Result: FAIL\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message lacks %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestPostSlack(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		if got["text"] == "" {
+			http.Error(w, "no_text", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	if err := PostSlack(srv.Client(), srv.URL, Build(sample())); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got["text"], "*Daily digest for pbx1*: 3 call(s)") || !strings.Contains(got["text"], "```\nDaily digest") {
+		t.Errorf("text = %q", got["text"])
+	}
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer bad.Close()
+	if err := PostSlack(bad.Client(), bad.URL, Build(sample())); err == nil || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("err = %v", err)
+	}
+	if err := PostSlack(http.DefaultClient, "http://127.0.0.1:1/services/T000/B000/secret", Build(sample())); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("err = %v", err)
+	}
+}
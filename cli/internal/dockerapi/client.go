@@ -0,0 +1,541 @@
+// Package dockerapi is a small Docker Engine API client built on net/http.
+//
+// It covers what the CLI asks of the daemon (container inspect, list, logs and
+// exec with multiplexed stream demux, and restarting compose services) for unix://
+// and tcp:// endpoints, with TLS from DOCKER_TLS_VERIFY and DOCKER_CERT_PATH the way
+// the docker CLI reads them. ssh:// hosts and docker contexts go through the docker
+// CLI, which handles the ssh transport; the package-level functions fall back to it
+// automatically. Compose up and build read the compose files and have no Engine API
+// equivalent, so they stay on `docker compose` (see compose.go). On Kubernetes
+// deployments Inspect, the logs functions and Exec answer from the stack's pods
+// instead (see kube.go).
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+)
+
+const defaultSocket = "/var/run/docker.sock"
+
+// ErrUnsupportedHost is returned by New for endpoints this client does not dial (ssh://).
+var ErrUnsupportedHost = errors.New("docker endpoint requires the docker CLI")
+
+// APIError is a non-2xx response from the daemon.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docker API %d: %s", e.Status, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 (no such container).
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
+
+// Client talks to one daemon.
+type Client struct {
+	http *http.Client
+	base string
+	// dial opens a raw connection to the daemon for hijacked (exec) streams.
+	dial func(ctx context.Context) (net.Conn, error)
+}
+
+// New builds a client for DOCKER_HOST (default unix:///var/run/docker.sock).
+// DOCKER_CONTEXT and ssh:// hosts return ErrUnsupportedHost. tcp:// hosts use TLS
+// when DOCKER_TLS_VERIFY or DOCKER_CERT_PATH is set.
+func New() (*Client, error) {
+	host := strings.TrimSpace(os.Getenv("DOCKER_HOST"))
+	if host == "" {
+		if strings.TrimSpace(os.Getenv("DOCKER_CONTEXT")) != "" {
+			return nil, ErrUnsupportedHost
+		}
+		host = "unix://" + defaultSocket
+	}
+	return NewForHost(host)
+}
+
+// NewForHost builds a client for an explicit endpoint.
+func NewForHost(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "unix":
+		sock := u.Path
+		dial := func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", sock)
+		}
+		tr := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) { return dial(ctx) },
+		}
+		return &Client{http: &http.Client{Transport: tr}, base: "http://docker", dial: dial}, nil
+	case "tcp", "http", "https":
+		cfg, err := tlsConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if cfg == nil && u.Scheme != "https" {
+			return &Client{http: &http.Client{}, base: "http://" + u.Host, dial: tcpDialer(u.Host, nil)}, nil
+		}
+		tr := &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: cfg}
+		return &Client{http: &http.Client{Transport: tr}, base: "https://" + u.Host, dial: tcpDialer(u.Host, cfg)}, nil
+	}
+	return nil, ErrUnsupportedHost
+}
+
+// tlsConfigFromEnv is the TLS setup the docker CLI derives from DOCKER_CERT_PATH
+// (ca.pem, cert.pem and key.pem, default ~/.docker) and DOCKER_TLS_VERIFY, or nil
+// when neither is set. Without DOCKER_TLS_VERIFY the daemon certificate is not
+// verified, as with docker --tls.
+func tlsConfigFromEnv() (*tls.Config, error) {
+	dir := strings.TrimSpace(os.Getenv("DOCKER_CERT_PATH"))
+	verify := strings.TrimSpace(os.Getenv("DOCKER_TLS_VERIFY")) != ""
+	if dir == "" {
+		if !verify {
+			return nil, nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: !verify}
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.pem"))
+	switch {
+	case err == nil:
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("%s: no certificates found", filepath.Join(dir, "ca.pem"))
+		}
+	case verify:
+		return nil, fmt.Errorf("DOCKER_TLS_VERIFY is set: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	switch {
+	case err == nil:
+		cfg.Certificates = []tls.Certificate{cert}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// tcpDialer dials addr, over TLS when cfg is set.
+func tcpDialer(addr string, cfg *tls.Config) func(ctx context.Context) (net.Conn, error) {
+	if cfg == nil {
+		return func(ctx context.Context) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", addr)
+		}
+	}
+	cfg = cfg.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	return func(ctx context.Context) (net.Conn, error) {
+		d := tls.Dialer{Config: cfg}
+		return d.DialContext(ctx, "tcp", addr)
+	}
+}
+
+// NewWithHTTP builds a client around an existing http.Client (tests, custom transports).
+// Hijacked streams dial the host of baseURL directly.
+func NewWithHTTP(hc *http.Client, baseURL string) *Client {
+	c := &Client{http: hc, base: strings.TrimRight(baseURL, "/")}
+	if u, err := url.Parse(c.base); err == nil {
+		var cfg *tls.Config
+		if u.Scheme == "https" {
+			cfg = &tls.Config{InsecureSkipVerify: true}
+		}
+		c.dial = tcpDialer(u.Host, cfg)
+	}
+	return c
+}
+
+func (c *Client) get(ctx context.Context, path string, q url.Values) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, path, q, nil)
+}
+
+// do sends a request with an optional JSON body and turns non-2xx answers into *APIError.
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, body any) (*http.Response, error) {
+	u := c.base + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	var rd io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rd = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, apiError(resp)
+	}
+	return resp, nil
+}
+
+func apiError(resp *http.Response) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if json.Unmarshal(raw, &body) != nil || body.Message == "" {
+		body.Message = strings.TrimSpace(string(raw))
+	}
+	return &APIError{Status: resp.StatusCode, Message: body.Message}
+}
+
+// ImageInspectRaw returns the raw JSON object of GET /images/{name}/json.
+func (c *Client) ImageInspectRaw(ctx context.Context, name string) ([]byte, error) {
+	resp, err := c.get(ctx, "/images/"+name+"/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// InspectRaw returns the raw JSON object of GET /containers/{name}/json.
+func (c *Client) InspectRaw(ctx context.Context, name string) ([]byte, error) {
+	resp, err := c.get(ctx, "/containers/"+url.PathEscape(name)+"/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// SystemInfo is the part of GET /info the CLI reads: the daemon's machine.
+type SystemInfo struct {
+	Name            string `json:"Name"`
+	KernelVersion   string `json:"KernelVersion"`
+	OperatingSystem string `json:"OperatingSystem"`
+	OSType          string `json:"OSType"`
+	Architecture    string `json:"Architecture"` // uname -m style: x86_64, aarch64
+	NCPU            int    `json:"NCPU"`
+	MemTotal        int64  `json:"MemTotal"`
+}
+
+// SystemInfo returns GET /info.
+func (c *Client) SystemInfo(ctx context.Context) (SystemInfo, error) {
+	var info SystemInfo
+	resp, err := c.get(ctx, "/info", nil)
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	return info, err
+}
+
+// ContainerSummary is one entry of GET /containers/json.
+type ContainerSummary struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Status string            `json:"Status"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// List returns containers (all=true includes stopped ones).
+func (c *Client) List(ctx context.Context, all bool) ([]ContainerSummary, error) {
+	return c.ListFiltered(ctx, all, nil)
+}
+
+// LogsOptions mirrors the docker logs flags the CLI uses.
+type LogsOptions struct {
+	Since      string // duration ("72h") or RFC3339 timestamp
+	Until      string
+	Tail       int
+	Timestamps bool
+	Follow     bool
+}
+
+// Logs streams container stdout+stderr in arrival order. Non-TTY containers use the
+// multiplexed stream format, which is demuxed here.
+func (c *Client) Logs(ctx context.Context, name string, opts LogsOptions) (io.ReadCloser, error) {
+	raw, err := c.InspectRaw(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var ci struct {
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	_ = json.Unmarshal(raw, &ci)
+
+	q := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	now := time.Now()
+	if opts.Since != "" {
+		ts, err := apiTime(opts.Since, now)
+		if err != nil {
+			return nil, err
+		}
+		q.Set("since", ts)
+	}
+	if opts.Until != "" {
+		ts, err := apiTime(opts.Until, now)
+		if err != nil {
+			return nil, err
+		}
+		q.Set("until", ts)
+	}
+	if opts.Tail > 0 {
+		q.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	if opts.Timestamps {
+		q.Set("timestamps", "1")
+	}
+	if opts.Follow {
+		q.Set("follow", "1")
+	}
+	resp, err := c.get(ctx, "/containers/"+url.PathEscape(name)+"/logs", q)
+	if err != nil {
+		return nil, err
+	}
+	if ci.Config.Tty {
+		return resp.Body, nil
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		err := Demux(pw, resp.Body)
+		resp.Body.Close()
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// Demux copies the payload of a multiplexed (stdout/stderr framed) stream into dst.
+// Each frame is an 8-byte header: stream type, 3 zero bytes, big-endian payload size.
+func Demux(dst io.Writer, src io.Reader) error {
+	return demuxStreams(dst, dst, src)
+}
+
+// demuxStreams is Demux with stdout and stderr frames kept apart.
+func demuxStreams(stdout, stderr io.Writer, src io.Reader) error {
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(src, hdr[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		dst := stdout
+		switch hdr[0] {
+		case 0, 1:
+		case 2:
+			dst = stderr
+		default:
+			return fmt.Errorf("unexpected stream type %d in docker stream", hdr[0])
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[4:]))
+		if _, err := io.CopyN(dst, src, size); err != nil {
+			return err
+		}
+	}
+}
+
+// apiTime converts a docker-CLI style duration or timestamp into the API's
+// seconds[.nanos] form.
+func apiTime(v string, now time.Time) (string, error) {
+	v = strings.TrimSpace(v)
+	if d, err := time.ParseDuration(v); err == nil {
+		return strconv.FormatInt(now.Add(-d).Unix(), 10), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond()), nil
+		}
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid time %q (expected a duration like 1h or an RFC3339 timestamp)", v)
+}
+
+// Inspect returns `docker inspect`-shaped output (a JSON array with one object) so
+// existing parsers keep working. It uses the Engine API when reachable and falls back
+// to the docker CLI otherwise (ssh:// hosts, contexts, API errors other than 404).
+func Inspect(name string) ([]byte, error) {
+	if kube.Enabled() {
+		return kubeInspect(name)
+	}
+	if c, err := New(); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		raw, err := c.InspectRaw(ctx, name)
+		if err == nil {
+			return append(append([]byte("["), raw...), ']'), nil
+		}
+		if IsNotFound(err) {
+			return []byte(err.Error()), err
+		}
+	}
+	return exec.Command("docker", "inspect", name).CombinedOutput()
+}
+
+// Info describes the daemon's machine, from the Engine API when reachable and
+// the docker CLI otherwise (ssh:// hosts, contexts).
+func Info() (SystemInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if c, err := New(); err == nil {
+		if info, err := c.SystemInfo(ctx); err == nil {
+			return info, nil
+		}
+	}
+	var info SystemInfo
+	out, err := exec.CommandContext(ctx, "docker", "info", "--format", "{{json .}}").Output()
+	if err != nil {
+		return info, fmt.Errorf("docker info: %w", err)
+	}
+	err = json.Unmarshal(out, &info)
+	return info, err
+}
+
+// InspectImage returns `docker image inspect`-shaped output for one image,
+// named by ID or reference, the same way Inspect does for containers.
+func InspectImage(name string) ([]byte, error) {
+	if c, err := New(); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		raw, err := c.ImageInspectRaw(ctx, name)
+		if err == nil {
+			return append(append([]byte("["), raw...), ']'), nil
+		}
+		if IsNotFound(err) {
+			return []byte(err.Error()), err
+		}
+	}
+	return exec.Command("docker", "image", "inspect", name).CombinedOutput()
+}
+
+// LogsOutput returns combined stdout+stderr log output like `docker logs` run with
+// CombinedOutput, preferring the Engine API and falling back to the docker CLI.
+func LogsOutput(name string, opts LogsOptions) ([]byte, error) {
+	rc, err := LogsStream(name, opts)
+	if err != nil {
+		if IsNotFound(err) {
+			return []byte(err.Error()), err
+		}
+		return nil, err
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, rc)
+	return buf.Bytes(), err
+}
+
+// LogsStream is the streaming form of LogsOutput; the reader yields an error if the
+// docker CLI fallback exits non-zero.
+func LogsStream(name string, opts LogsOptions) (io.ReadCloser, error) {
+	if kube.Enabled() {
+		return kubeLogs(name, opts)
+	}
+	if c, err := New(); err == nil {
+		rc, err := c.Logs(context.Background(), name, opts)
+		if err == nil {
+			return rc, nil
+		}
+		if IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return startCommandStream(exec.Command("docker", CLILogsArgs(name, opts)...))
+}
+
+// cmdStream is a running command whose combined output is read through a pipe.
+type cmdStream struct {
+	*io.PipeReader
+	done chan struct{}
+}
+
+func (s *cmdStream) Close() error {
+	err := s.PipeReader.Close()
+	<-s.done
+	return err
+}
+
+func startCommandStream(cmd *exec.Cmd) (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	s := &cmdStream{PipeReader: pr, done: make(chan struct{})}
+	go func() {
+		err := cmd.Wait()
+		if err == nil {
+			err = io.EOF
+		}
+		pw.CloseWithError(err)
+		close(s.done)
+	}()
+	return s, nil
+}
+
+// CLILogsArgs builds the equivalent `docker logs` argument list.
+func CLILogsArgs(name string, opts LogsOptions) []string {
+	args := []string{"logs"}
+	if opts.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	if opts.Since != "" {
+		args = append(args, "--since", opts.Since)
+	}
+	if opts.Until != "" {
+		args = append(args, "--until", opts.Until)
+	}
+	if opts.Tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(opts.Tail))
+	}
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+	return append(args, name)
+}
@@ -0,0 +1,179 @@
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func frame(stream byte, payload string) []byte {
+	hdr := make([]byte, 8)
+	hdr[0] = stream
+	binary.BigEndian.PutUint32(hdr[4:], uint32(len(payload)))
+	return append(hdr, payload...)
+}
+
+func TestLogsDemuxesMultiplexedStream(t *testing.T) {
+	t.Parallel()
+
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/ai_engine/json":
+			_, _ = w.Write([]byte(`{"Name":"/ai_engine","Config":{"Tty":false}}`))
+		case "/containers/ai_engine/logs":
+			gotQuery = r.URL.RawQuery
+			var b bytes.Buffer
+			b.Write(frame(1, "stdout line\n"))
+			b.Write(frame(2, "stderr line\n"))
+			_, _ = w.Write(b.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such container: x"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTP(srv.Client(), srv.URL)
+	rc, err := c.Logs(context.Background(), "ai_engine", LogsOptions{Tail: 50, Timestamps: true})
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	out, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(out) != "stdout line\nstderr line\n" {
+		t.Fatalf("demuxed output=%q", out)
+	}
+	if gotQuery != "stderr=1&stdout=1&tail=50&timestamps=1" {
+		t.Fatalf("query=%q", gotQuery)
+	}
+
+	if _, err := c.InspectRaw(context.Background(), "missing"); !IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestAPITimeAcceptsDurationsAndTimestamps(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_800_000_000, 0)
+	if got, _ := apiTime("1h", now); got != "1799996400" {
+		t.Fatalf("duration=%q", got)
+	}
+	if got, _ := apiTime("2027-01-15T07:00:00Z", now); got != "1799996400.000000000" {
+		t.Fatalf("timestamp=%q", got)
+	}
+	if _, err := apiTime("yesterday", now); err == nil {
+		t.Fatalf("expected invalid time error")
+	}
+}
+
+func TestKubeLogOptionsConvertsTimes(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_800_000_000, 0)
+	ko, err := kubeLogOptions(LogsOptions{Since: "1h", Until: "2027-01-15T07:00:00.25Z", Tail: 50}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ko.Since.Equal(time.Unix(1_799_996_400, 0)) || !ko.Until.Equal(time.Unix(1_799_996_400, 250_000_000)) || ko.Tail != 50 {
+		t.Fatalf("options=%+v", ko)
+	}
+	if _, err := kubeLogOptions(LogsOptions{Until: "later"}, now); err == nil {
+		t.Fatalf("expected invalid time error")
+	}
+}
+
+// writeClientCert writes a self-signed client certificate as cert.pem and key.pem.
+func writeClientCert(t *testing.T, dir string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, block := range map[string]*pem.Block{
+		"cert.pem": {Type: "CERTIFICATE", Bytes: der},
+		"key.pem":  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNewUsesTLSFromEnv(t *testing.T) {
+	var clientCerts int
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCerts = len(r.TLS.PeerCertificates)
+		_, _ = w.Write([]byte(`{"Name":"docker-tls","OSType":"linux"}`))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(dir, "ca.pem"), ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	writeClientCert(t, dir)
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(srv.URL, "https://"))
+	t.Setenv("DOCKER_CERT_PATH", dir)
+	t.Setenv("DOCKER_TLS_VERIFY", "1")
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := c.SystemInfo(context.Background())
+	if err != nil {
+		t.Fatalf("SystemInfo: %v", err)
+	}
+	if info.Name != "docker-tls" || clientCerts != 1 {
+		t.Fatalf("info=%+v client certs=%d", info, clientCerts)
+	}
+
+	// Verification needs the CA; without DOCKER_TLS_VERIFY the client certificate is still sent.
+	if err := os.Remove(filepath.Join(dir, "ca.pem")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(); err == nil {
+		t.Fatal("DOCKER_TLS_VERIFY without ca.pem accepted")
+	}
+	t.Setenv("DOCKER_TLS_VERIFY", "")
+	if c, err = New(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SystemInfo(context.Background()); err != nil || clientCerts != 1 {
+		t.Fatalf("SystemInfo without verification: %v (client certs=%d)", err, clientCerts)
+	}
+}
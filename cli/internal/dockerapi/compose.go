@@ -0,0 +1,153 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Compose has no Engine API of its own: `docker compose up` and `build` read the
+// compose files and stay on the CLI. What the CLI asks of a running project, its
+// services and restarting one, is answered from the labels compose puts on the
+// containers it creates.
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+)
+
+// restartTimeout is how long a restart waits for a container to stop before
+// killing it, as in docker compose restart.
+const restartTimeout = 10 * time.Second
+
+// ListFiltered is List with Engine API filters, e.g. {"label": {"k=v"}}.
+func (c *Client) ListFiltered(ctx context.Context, all bool, filters map[string][]string) ([]ContainerSummary, error) {
+	q := url.Values{}
+	if all {
+		q.Set("all", "1")
+	}
+	if len(filters) > 0 {
+		raw, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		q.Set("filters", string(raw))
+	}
+	resp, err := c.get(ctx, "/containers/json", q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out []ContainerSummary
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// composeContainers lists project's containers, of one service when service is set.
+func (c *Client) composeContainers(ctx context.Context, project, service string, all bool) ([]ContainerSummary, error) {
+	labels := []string{composeProjectLabel + "=" + project}
+	if service != "" {
+		labels = append(labels, composeServiceLabel+"="+service)
+	}
+	return c.ListFiltered(ctx, all, map[string][]string{"label": labels})
+}
+
+// ComposeServices is `docker compose ps --services`: the services of project
+// that have containers, sorted, only running ones unless all is set.
+func (c *Client) ComposeServices(ctx context.Context, project string, all bool) ([]string, error) {
+	list, err := c.composeContainers(ctx, project, "", all)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var services []string
+	for _, ct := range list {
+		if svc := ct.Labels[composeServiceLabel]; svc != "" && !seen[svc] {
+			seen[svc] = true
+			services = append(services, svc)
+		}
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// RestartService is `docker compose restart service`: POST /containers/{id}/restart
+// for each of the service's containers. A service without containers is an error,
+// since there is nothing to restart; create it with compose up.
+func (c *Client) RestartService(ctx context.Context, project, service string) error {
+	list, err := c.composeContainers(ctx, project, service, true)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("no containers for service %s in compose project %s", service, project)
+	}
+	q := url.Values{"t": {strconv.Itoa(int(restartTimeout.Seconds()))}}
+	for _, ct := range list {
+		resp, err := c.do(ctx, http.MethodPost, "/containers/"+url.PathEscape(ct.ID)+"/restart", q, nil)
+		if err != nil {
+			return fmt.Errorf("restart %s: %w", service, err)
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// ComposeServices lists the services of project with running containers (all
+// of them with all set), from the Engine API when reachable and `docker compose
+// ps --services` otherwise.
+func ComposeServices(project string, all bool) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if c, err := New(); err == nil {
+		if services, err := c.ComposeServices(ctx, project, all); err == nil {
+			return services, nil
+		}
+	}
+	args := []string{"compose", "-p", project, "ps", "--services"}
+	if !all {
+		args = append(args, "--status", "running")
+	}
+	out, err := exec.CommandContext(ctx, "docker", args...).Output()
+	if err != nil && !all {
+		// Older compose releases have no --status.
+		out, err = exec.CommandContext(ctx, "docker", args[:5]...).Output()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("docker compose ps: %w", err)
+	}
+	var services []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if svc := strings.TrimSpace(line); svc != "" {
+			services = append(services, svc)
+		}
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// RestartService restarts a compose service's containers, through the Engine
+// API when reachable and `docker compose restart` otherwise.
+func RestartService(project, service string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if c, err := New(); err == nil {
+		// A daemon that answers the lookup restarts through the API; its errors are final.
+		if _, err := c.composeContainers(ctx, project, service, true); err == nil {
+			return c.RestartService(ctx, project, service)
+		}
+	}
+	out, err := exec.CommandContext(ctx, "docker", "compose", "-p", project, "restart", service).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker compose restart %s: %w: %s", service, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package dockerapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/kube"
+)
+
+// ExecOptions wires a command run with Exec. A nil Stdin leaves stdin detached
+// (docker exec without -i); nil writers discard that stream.
+type ExecOptions struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// ExitError is a command that ran in the container and exited non-zero.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExecCreate is POST /containers/{name}/exec; it returns the exec instance ID.
+func (c *Client) ExecCreate(ctx context.Context, name string, stdin bool, argv ...string) (string, error) {
+	body := map[string]any{
+		"AttachStdin":  stdin,
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Tty":          false,
+		"Cmd":          argv,
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/containers/"+url.PathEscape(name)+"/exec", nil, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// ExecStart is POST /exec/{id}/start on a hijacked connection: stdin, when set,
+// is copied to the command and closed for writing at EOF, and the multiplexed
+// output is demuxed into stdout and stderr until the command exits.
+func (c *Client) ExecStart(ctx context.Context, id string, opts ExecOptions) error {
+	if c.dial == nil {
+		return ErrUnsupportedHost
+	}
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	req, err := http.NewRequest(http.MethodPost, c.base+"/exec/"+url.PathEscape(id)+"/start",
+		bytes.NewReader([]byte(`{"Detach":false,"Tty":false}`)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	if err := req.Write(conn); err != nil {
+		return contextErr(ctx, err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return apiError(resp)
+	}
+
+	if opts.Stdin != nil {
+		go func() {
+			_, _ = io.Copy(conn, opts.Stdin)
+			if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+				_ = cw.CloseWrite()
+			}
+		}()
+	}
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	return contextErr(ctx, demuxStreams(stdout, stderr, br))
+}
+
+// ExecExitCode is the exit code from GET /exec/{id}/json. The daemon can take
+// a moment to record it after the output stream closes, so a still-running
+// instance is polled briefly.
+func (c *Client) ExecExitCode(ctx context.Context, id string) (int, error) {
+	for i := 0; ; i++ {
+		resp, err := c.get(ctx, "/exec/"+url.PathEscape(id)+"/json", nil)
+		if err != nil {
+			return 0, err
+		}
+		var st struct {
+			Running  bool `json:"Running"`
+			ExitCode int  `json:"ExitCode"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&st)
+		resp.Body.Close()
+		if err != nil {
+			return 0, err
+		}
+		if !st.Running {
+			return st.ExitCode, nil
+		}
+		if i == 20 {
+			return 0, fmt.Errorf("exec %s still running after its output closed", id)
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// Exec runs argv in container name like `docker exec [-i]`, and returns
+// *ExitError when it exits non-zero.
+func (c *Client) Exec(ctx context.Context, name string, opts ExecOptions, argv ...string) error {
+	id, err := c.ExecCreate(ctx, name, opts.Stdin != nil, argv...)
+	if err != nil {
+		return err
+	}
+	return c.runExec(ctx, id, opts)
+}
+
+func (c *Client) runExec(ctx context.Context, id string, opts ExecOptions) error {
+	if err := c.ExecStart(ctx, id, opts); err != nil {
+		return err
+	}
+	code, err := c.ExecExitCode(ctx, id)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+func contextErr(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Exec runs argv inside container name, through the Engine API when the daemon
+// is reachable and `docker exec` otherwise (ssh:// hosts, contexts); on
+// Kubernetes, kubectl exec in its pod. A command that exits non-zero returns
+// *ExitError either way.
+func Exec(ctx context.Context, name string, opts ExecOptions, argv ...string) error {
+	if !kube.Enabled() {
+		if c, err := New(); err == nil {
+			id, err := c.ExecCreate(ctx, name, opts.Stdin != nil, argv...)
+			if err == nil {
+				return c.runExec(ctx, id, opts)
+			}
+			// Nothing ran yet, so only an unreachable daemon falls through to the CLI.
+			var apiErr *APIError
+			if errors.As(err, &apiErr) || ctx.Err() != nil {
+				return err
+			}
+		}
+	}
+	cmd := execCommand(ctx, name, opts.Stdin != nil, argv...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = opts.Stdin, opts.Stdout, opts.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Code: exitErr.ExitCode()}
+	}
+	return err
+}
+
+// ExecOutput runs argv in container name and returns its stdout, like
+// exec.Cmd.Output.
+func ExecOutput(ctx context.Context, name string, argv ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := Exec(ctx, name, ExecOptions{Stdout: &stdout}, argv...)
+	return stdout.Bytes(), err
+}
+
+// ExecCombinedOutput runs argv in container name and returns its stdout and
+// stderr together, like exec.Cmd.CombinedOutput.
+func ExecCombinedOutput(ctx context.Context, name string, argv ...string) ([]byte, error) {
+	var out bytes.Buffer
+	err := Exec(ctx, name, ExecOptions{Stdout: &out, Stderr: &out}, argv...)
+	return out.Bytes(), err
+}
+
+// execCommand is the docker CLI (or kubectl) form of Exec.
+func execCommand(ctx context.Context, name string, stdin bool, argv ...string) *exec.Cmd {
+	if kube.Enabled() {
+		return kube.ExecCommand(ctx, name, stdin, argv...)
+	}
+	args := []string{"exec"}
+	if stdin {
+		args = append(args, "-i")
+	}
+	args = append(append(args, name), argv...)
+	return exec.CommandContext(ctx, "docker", args...)
+}
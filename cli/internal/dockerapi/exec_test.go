@@ -0,0 +1,133 @@
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// execServer answers the three exec endpoints: it echoes stdin back on stdout,
+// writes a line to stderr, and reports exit code 3.
+func execServer(t *testing.T, created *map[string]any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/containers/ai_engine/exec":
+			_ = json.NewDecoder(r.Body).Decode(created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id":"e1"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/exec/e1/start":
+			if r.Header.Get("Upgrade") != "tcp" {
+				t.Errorf("start without upgrade: %v", r.Header)
+			}
+			_, _ = io.ReadAll(r.Body)
+			conn, brw, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack: %v", err)
+				return
+			}
+			defer conn.Close()
+			_, _ = brw.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+			_ = brw.Flush()
+			// Reading to EOF proves the client closed its write side.
+			stdin, _ := io.ReadAll(brw)
+			_, _ = conn.Write(frame(1, "got "+string(stdin)))
+			_, _ = conn.Write(frame(2, "warning\n"))
+		case r.Method == http.MethodGet && r.URL.Path == "/exec/e1/json":
+			_, _ = w.Write([]byte(`{"Running":false,"ExitCode":3}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such container: x"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExecStreamsStdinAndReportsExitCode(t *testing.T) {
+	t.Parallel()
+
+	created := map[string]any{}
+	srv := execServer(t, &created)
+	c := NewWithHTTP(srv.Client(), srv.URL)
+
+	var stdout, stderr bytes.Buffer
+	err := c.Exec(context.Background(), "ai_engine", ExecOptions{
+		Stdin:  strings.NewReader("print(1)\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}, "python", "-")
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Fatalf("err = %v, want exit status 3", err)
+	}
+	if stdout.String() != "got print(1)\n" || stderr.String() != "warning\n" {
+		t.Fatalf("stdout=%q stderr=%q", stdout.String(), stderr.String())
+	}
+	if created["AttachStdin"] != true || created["Tty"] != false {
+		t.Fatalf("create body = %v", created)
+	}
+	if cmd, _ := created["Cmd"].([]any); len(cmd) != 2 || cmd[0] != "python" || cmd[1] != "-" {
+		t.Fatalf("Cmd = %v", created["Cmd"])
+	}
+}
+
+func TestExecMissingContainerIsNotFound(t *testing.T) {
+	t.Parallel()
+
+	srv := execServer(t, &map[string]any{})
+	c := NewWithHTTP(srv.Client(), srv.URL)
+	err := c.Exec(context.Background(), "asterisk", ExecOptions{}, "cat", "/etc/asterisk/pjsip.conf")
+	if !IsNotFound(err) {
+		t.Fatalf("err = %v, want 404", err)
+	}
+}
+
+func TestComposeServicesAndRestartUseLabels(t *testing.T) {
+	t.Parallel()
+
+	var filters []string
+	var restarted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/containers/json":
+			filters = append(filters, r.URL.Query().Get("filters"))
+			_, _ = w.Write([]byte(`[
+				{"Id":"a1","Labels":{"com.docker.compose.service":"ai_engine"}},
+				{"Id":"b1","Labels":{"com.docker.compose.service":"admin_ui"}},
+				{"Id":"a2","Labels":{"com.docker.compose.service":"ai_engine"}}]`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/restart"):
+			restarted = append(restarted, r.URL.Path+"?"+r.URL.RawQuery)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	c := NewWithHTTP(srv.Client(), srv.URL)
+
+	services, err := c.ComposeServices(context.Background(), "aava", false)
+	if err != nil || strings.Join(services, ",") != "admin_ui,ai_engine" {
+		t.Fatalf("services = %v, %v", services, err)
+	}
+	if want := `{"label":["com.docker.compose.project=aava"]}`; filters[0] != want {
+		t.Fatalf("filters = %s, want %s", filters[0], want)
+	}
+
+	if err := c.RestartService(context.Background(), "aava", "ai_engine"); err != nil {
+		t.Fatalf("RestartService: %v", err)
+	}
+	if want := `{"label":["com.docker.compose.project=aava","com.docker.compose.service=ai_engine"]}`; filters[1] != want {
+		t.Fatalf("filters = %s, want %s", filters[1], want)
+	}
+	// The stub ignores the service filter, so every listed container is restarted.
+	if len(restarted) != 3 || restarted[0] != "/containers/a1/restart?t=10" {
+		t.Fatalf("restarted = %v", restarted)
+	}
+}
@@ -0,0 +1,303 @@
+// Package engineapi reads live state from ai_engine's health server: readiness
+// and providers from /health, calls from /sessions/stats and streaming buffer
+// gauges from /metrics, and asks it to reload its configuration (/reload). The
+// server binds to loopback inside the container by default, so every request
+// runs through docker exec and the container's python3; that also satisfies
+// the localhost rule of the protected endpoints.
+package engineapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// DefaultTimeout bounds one request including the docker exec round trip.
+const DefaultTimeout = 8 * time.Second
+
+// ReloadTimeout bounds POST /reload, which reloads the config and rebuilds the
+// tool registry before answering.
+const ReloadTimeout = 30 * time.Second
+
+// requestTimeout is how long the in-container request may take: the client
+// timeout less what docker exec itself needs.
+func requestTimeout(timeout time.Duration) time.Duration {
+	if t := timeout - 5*time.Second; t >= 3*time.Second {
+		return t
+	}
+	return 3 * time.Second
+}
+
+var (
+	// ErrUnavailable means the health server could not be reached: the
+	// container is down, docker exec failed or nothing listens on the port.
+	ErrUnavailable = errors.New("engine health server unavailable")
+	// ErrUnsupported means the running engine predates the endpoint.
+	ErrUnsupported = errors.New("endpoint not supported by this engine version")
+)
+
+// requestScript performs one request and prints the status and body as JSON,
+// so HTTP errors and connection failures come back the same way.
+const requestScript = `
+import json, sys, urllib.request, urllib.error
+method, url, body, timeout = sys.argv[1], sys.argv[2], sys.argv[3], float(sys.argv[4])
+headers = {"Content-Type": "application/json"} if body else {}
+req = urllib.request.Request(url, data=body.encode() if body else None, headers=headers, method=method)
+try:
+    with urllib.request.urlopen(req, timeout=timeout) as resp:
+        print(json.dumps({"status": resp.status, "body": resp.read().decode("utf-8", "replace")}))
+except urllib.error.HTTPError as e:
+    print(json.dumps({"status": e.code, "body": e.read().decode("utf-8", "replace")}))
+except Exception as e:
+    print(json.dumps({"error": str(e)}))
+`
+
+// Client talks to the health server of one engine container.
+type Client struct {
+	Container string
+	Port      int
+	Timeout   time.Duration
+
+	// run executes argv inside the container and returns its output.
+	run func(ctx context.Context, container string, argv ...string) ([]byte, error)
+}
+
+// New returns a client for the configured engine container and health port.
+func New() *Client {
+	return &Client{
+		Container: deployment.EngineContainer(),
+		Port:      ConfiguredPort(),
+		Timeout:   DefaultTimeout,
+		run:       dockerExec,
+	}
+}
+
+func dockerExec(ctx context.Context, container string, argv ...string) ([]byte, error) {
+	return dockerapi.ExecCombinedOutput(ctx, container, argv...)
+}
+
+// Provider is one provider's readiness as /health reports it.
+type Provider struct {
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Health is the subset of /health the CLI reads.
+type Health struct {
+	Status         string              `json:"status"` // healthy or degraded
+	ARIConnected   bool                `json:"ari_connected"`
+	AudioTransport string              `json:"audio_transport"`
+	ActiveCalls    int                 `json:"active_calls"`
+	UptimeSeconds  float64             `json:"uptime_seconds"`
+	ConfigHash     string              `json:"config_hash,omitempty"`
+	Providers      map[string]Provider `json:"providers"`
+	AudioSocket    struct {
+		Listening         bool `json:"listening"`
+		ActiveConnections int  `json:"active_connections"`
+	} `json:"audiosocket"`
+	ConfigWarnings []string `json:"config_warnings,omitempty"`
+}
+
+// Session is one active call from /sessions/stats.
+type Session struct {
+	CallID            string `json:"call_id"`
+	Provider          string `json:"provider,omitempty"`
+	Pipeline          string `json:"pipeline,omitempty"`
+	Context           string `json:"context,omitempty"`
+	Status            string `json:"status,omitempty"`
+	ConversationState string `json:"conversation_state,omitempty"`
+}
+
+// Sessions is the /sessions/stats payload.
+type Sessions struct {
+	ActiveCalls      int       `json:"active_calls"`
+	ActivePlaybacks  int       `json:"active_playbacks"`
+	ProviderSessions int       `json:"provider_sessions"`
+	Sessions         []Session `json:"sessions"`
+	Draining         bool      `json:"draining"`
+}
+
+// Find returns the active session for callID.
+func (s *Sessions) Find(callID string) (Session, bool) {
+	for _, sess := range s.Sessions {
+		if sess.CallID == callID {
+			return sess, true
+		}
+	}
+	return Session{}, false
+}
+
+// Health reads /health.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var h Health
+	if err := c.getJSON(ctx, "/health", &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Sessions reads /sessions/stats.
+func (c *Client) Sessions(ctx context.Context) (*Sessions, error) {
+	var s Sessions
+	if err := c.getJSON(ctx, "/sessions/stats", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetDraining turns new calls away (true) or resumes taking them (false).
+func (c *Client) SetDraining(ctx context.Context, draining bool) error {
+	body, _ := json.Marshal(map[string]bool{"draining": draining})
+	status, resp, err := c.do(ctx, http.MethodPost, "/drain", string(body))
+	if err != nil {
+		return err
+	}
+	if err := statusError("/drain", status); err != nil {
+		return err
+	}
+	var payload struct {
+		Draining *bool `json:"draining"`
+	}
+	if err := json.Unmarshal([]byte(resp), &payload); err != nil {
+		return fmt.Errorf("invalid /drain response: %w", err)
+	}
+	if payload.Draining == nil || *payload.Draining != draining {
+		return errors.New("engine did not confirm the drain state")
+	}
+	return nil
+}
+
+// ReloadResult is the /reload payload.
+type ReloadResult struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message,omitempty"`
+	Changes []string `json:"changes,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+	Note    string   `json:"note,omitempty"`
+	// Error is set instead of Message when the reload was refused (403, 409).
+	Error string `json:"error,omitempty"`
+}
+
+// Reload asks the engine to reload its configuration (ai-agent.yaml, the
+// local override and config/contexts/) for new calls; active calls keep theirs.
+// A refused or failed reload returns the engine's payload along with the error.
+func (c *Client) Reload(ctx context.Context) (*ReloadResult, error) {
+	status, body, err := c.do(ctx, http.MethodPost, "/reload", "")
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+		return nil, statusError("/reload", status)
+	}
+	var res ReloadResult
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		if err := statusError("/reload", status); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid /reload response: %w", err)
+	}
+	if err := statusError("/reload", status); err != nil {
+		var reasons []string
+		for _, r := range append([]string{res.Error, res.Message}, res.Errors...) {
+			if r != "" {
+				reasons = append(reasons, r)
+			}
+		}
+		if len(reasons) > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.Join(reasons, "; "))
+		}
+		return &res, err
+	}
+	if !res.Success {
+		return &res, fmt.Errorf("reload completed with errors: %s", strings.Join(res.Errors, "; "))
+	}
+	return &res, nil
+}
+
+// Streaming reads the streaming playback gauges from /metrics.
+func (c *Client) Streaming(ctx context.Context) (*Streaming, error) {
+	status, body, err := c.do(ctx, http.MethodGet, "/metrics", "")
+	if err != nil {
+		return nil, err
+	}
+	if err := statusError("/metrics", status); err != nil {
+		return nil, err
+	}
+	return parseStreaming(body), nil
+}
+
+// Metrics returns the /metrics exposition as served, for archiving.
+func (c *Client) Metrics(ctx context.Context) (string, error) {
+	status, body, err := c.do(ctx, http.MethodGet, "/metrics", "")
+	if err != nil {
+		return "", err
+	}
+	if err := statusError("/metrics", status); err != nil {
+		return "", err
+	}
+	return body, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	status, body, err := c.do(ctx, http.MethodGet, path, "")
+	if err != nil {
+		return err
+	}
+	if err := statusError(path, status); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		return fmt.Errorf("invalid %s response: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, body string) (int, string, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := "http://127.0.0.1:" + strconv.Itoa(c.Port) + path
+	reqTimeout := strconv.FormatFloat(requestTimeout(timeout).Seconds(), 'f', -1, 64)
+	out, err := c.run(ctx, c.Container, "python3", "-c", requestScript, method, url, body, reqTimeout)
+	if ctx.Err() == context.DeadlineExceeded {
+		return 0, "", fmt.Errorf("%w: docker exec %s %s timed out after %s", ErrUnavailable, c.Container, path, timeout)
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("%w: docker exec %s failed: %v (%s)", ErrUnavailable, c.Container, err, strings.TrimSpace(string(out)))
+	}
+	var resp struct {
+		Status int    `json:"status"`
+		Body   string `json:"body"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(out), &resp); err != nil {
+		return 0, "", fmt.Errorf("unexpected probe output: %s", strings.TrimSpace(string(out)))
+	}
+	if resp.Error != "" {
+		return 0, "", fmt.Errorf("%w: %s", ErrUnavailable, resp.Error)
+	}
+	return resp.Status, resp.Body, nil
+}
+
+func statusError(path string, status int) error {
+	switch {
+	case status == http.StatusNotFound || status == http.StatusMethodNotAllowed:
+		return fmt.Errorf("%s: %w", path, ErrUnsupported)
+	case status < 200 || status > 299:
+		return fmt.Errorf("%s returned HTTP %d", path, status)
+	}
+	return nil
+}
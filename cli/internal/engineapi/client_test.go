@@ -0,0 +1,133 @@
+package engineapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type route struct {
+	status int
+	body   string
+}
+
+// fakeClient answers requests with a canned status and body per path; other
+// paths fail like a closed port.
+func fakeClient(t *testing.T, routes map[string]route) (*Client, *[]string) {
+	t.Helper()
+	var calls []string
+	c := &Client{Container: "ai_engine", Port: 15000}
+	c.run = func(ctx context.Context, container string, argv ...string) ([]byte, error) {
+		if len(argv) != 7 || argv[0] != "python3" {
+			t.Fatalf("argv = %q", argv)
+		}
+		method, url, body := argv[3], argv[4], argv[5]
+		calls = append(calls, method+" "+url+" "+body)
+		path := strings.TrimPrefix(url, "http://127.0.0.1:15000")
+		r, ok := routes[path]
+		if !ok {
+			return []byte(`{"error": "<urlopen error [Errno 111] Connection refused>"}`), nil
+		}
+		out, _ := json.Marshal(map[string]any{"status": r.status, "body": r.body})
+		return out, nil
+	}
+	return c, &calls
+}
+
+func TestHealthAndSessions(t *testing.T) {
+	c, _ := fakeClient(t, map[string]route{
+		"/health":         {200, `{"status":"degraded","ari_connected":true,"active_calls":1,"providers":{"deepgram":{"ready":false,"reason":"missing_config"}},"audiosocket":{"listening":true,"active_connections":1}}`},
+		"/sessions/stats": {200, `{"active_calls":1,"sessions":[{"call_id":"1769818882.1484","provider":"deepgram","status":"connected"}],"draining":false}`},
+	})
+	h, err := c.Health(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != "degraded" || !h.ARIConnected || h.Providers["deepgram"].Reason != "missing_config" || !h.AudioSocket.Listening {
+		t.Fatalf("health = %+v", h)
+	}
+	s, err := c.Sessions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess, ok := s.Find("1769818882.1484"); !ok || sess.Provider != "deepgram" || s.ActiveCalls != 1 {
+		t.Fatalf("sessions = %+v", s)
+	}
+}
+
+func TestErrorsDistinguishDownFromOld(t *testing.T) {
+	c, _ := fakeClient(t, map[string]route{
+		"/sessions/stats": {404, "404: Not Found"},
+		"/health":         {500, `{"status":"error"}`},
+	})
+	if _, err := c.Sessions(context.Background()); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("404: %v", err)
+	}
+	if _, err := c.Health(context.Background()); err == nil || errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "HTTP 500") {
+		t.Fatalf("500: %v", err)
+	}
+	if _, err := c.Streaming(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("refused: %v", err)
+	}
+
+	c.run = func(ctx context.Context, container string, argv ...string) ([]byte, error) {
+		return []byte("Error response from daemon: container is not running"), errors.New("exit status 1")
+	}
+	if _, err := c.Health(context.Background()); !errors.Is(err, ErrUnavailable) || !strings.Contains(err.Error(), "not running") {
+		t.Fatalf("docker exec: %v", err)
+	}
+}
+
+func TestSetDraining(t *testing.T) {
+	c, calls := fakeClient(t, map[string]route{"/drain": {200, `{"draining":true,"active_calls":2}`}})
+	if err := c.SetDraining(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if got := (*calls)[0]; got != `POST http://127.0.0.1:15000/drain {"draining":true}` {
+		t.Fatalf("request = %s", got)
+	}
+	if err := c.SetDraining(context.Background(), false); err == nil {
+		t.Fatal("unconfirmed drain state accepted")
+	}
+}
+
+func TestParseStreaming(t *testing.T) {
+	text := strings.Join([]string{
+		"# HELP ai_agent_streaming_active Number of calls with streaming playback active",
+		"# TYPE ai_agent_streaming_active gauge",
+		"ai_agent_streaming_active 2.0",
+		"ai_agent_streaming_jitter_buffer_depth 14.0",
+		"ai_agent_streaming_last_chunk_age_seconds 0.35",
+		`ai_agent_streaming_bytes_total{call_id="1.2"} 1024.0`,
+	}, "\n")
+	s := parseStreaming(text)
+	if s.ActiveStreams != 2 || s.JitterBufferDepth != 14 || s.LastChunkAgeSeconds != 0.35 {
+		t.Fatalf("streaming = %+v", s)
+	}
+}
+
+func TestReload(t *testing.T) {
+	c, calls := fakeClient(t, map[string]route{"/reload": {200, `{"success":true,"message":"Configuration reloaded","changes":["Configuration file reloaded","Configuration updated"],"errors":[],"tool_generation":3}`}})
+	res, err := c.Reload(context.Background())
+	if err != nil || !res.Success || len(res.Changes) != 2 {
+		t.Fatalf("reload = %+v, %v", res, err)
+	}
+	if got := (*calls)[0]; got != "POST http://127.0.0.1:15000/reload " {
+		t.Fatalf("request = %q", got)
+	}
+
+	c, _ = fakeClient(t, map[string]route{"/reload": {409, `{"success":false,"error":"A configuration reload is already in progress"}`}})
+	if res, err := c.Reload(context.Background()); err == nil || res == nil || !strings.Contains(err.Error(), "HTTP 409: A configuration reload is already in progress") {
+		t.Fatalf("409: %+v, %v", res, err)
+	}
+	c, _ = fakeClient(t, map[string]route{"/reload": {500, `{"success":false,"message":"Configuration saved but could not be applied","errors":["unknown pipeline"]}`}})
+	if _, err := c.Reload(context.Background()); err == nil || !strings.HasSuffix(err.Error(), "could not be applied; unknown pipeline") {
+		t.Fatalf("500: %v", err)
+	}
+	c, _ = fakeClient(t, map[string]route{"/reload": {404, "404: Not Found"}})
+	if _, err := c.Reload(context.Background()); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("404: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package engineapi
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// Streaming is the engine's streaming playback state. The gauges are maxima
+// across active streams, not per call.
+type Streaming struct {
+	ActiveStreams       int     `json:"active_streams"`
+	JitterBufferDepth   float64 `json:"jitter_buffer_depth"` // queued chunks
+	LastChunkAgeSeconds float64 `json:"last_chunk_age_seconds"`
+}
+
+// parseStreaming picks the streaming gauges out of Prometheus text exposition.
+// Missing gauges stay zero.
+func parseStreaming(text string) *Streaming {
+	s := &Streaming{}
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "ai_agent_streaming_active":
+			s.ActiveStreams = int(v)
+		case "ai_agent_streaming_jitter_buffer_depth":
+			s.JitterBufferDepth = v
+		case "ai_agent_streaming_last_chunk_age_seconds":
+			s.LastChunkAgeSeconds = v
+		}
+	}
+	return s
+}
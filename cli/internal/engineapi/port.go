@@ -0,0 +1,91 @@
+package engineapi
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+)
+
+// DefaultPort is the engine's health server port when nothing overrides it.
+const DefaultPort = 15000
+
+// ConfiguredPort resolves the health server port the way the engine does,
+// relative to the project root: HEALTH_BIND_PORT from the environment, then
+// .env, then health.port in config/ai-agent(.local).yaml.
+func ConfiguredPort() int {
+	if port, ok := parsePort(os.Getenv("HEALTH_BIND_PORT")); ok {
+		return port
+	}
+	if raw, ok := dotenvValue(".env", "HEALTH_BIND_PORT"); ok {
+		if port, ok := parsePort(raw); ok {
+			return port
+		}
+	}
+
+	cfg := map[string]any{}
+	if base, err := configmerge.ReadYAMLFile(filepath.Join("config", "ai-agent.yaml")); err == nil {
+		cfg = base
+	}
+	if local, err := configmerge.ReadYAMLFile(filepath.Join("config", "ai-agent.local.yaml")); err == nil {
+		cfg = configmerge.DeepMerge(cfg, local)
+	}
+	if health, ok := cfg["health"].(map[string]any); ok {
+		if port, ok := parsePortValue(health["port"]); ok {
+			return port
+		}
+	}
+	return DefaultPort
+}
+
+func parsePortValue(raw any) (int, bool) {
+	switch v := raw.(type) {
+	case int:
+		return parsePort(strconv.Itoa(v))
+	case int64:
+		return parsePort(strconv.FormatInt(v, 10))
+	case float64:
+		if v == float64(int(v)) {
+			return parsePort(strconv.Itoa(int(v)))
+		}
+	case string:
+		return parsePort(v)
+	}
+	return 0, false
+}
+
+func parsePort(raw string) (int, bool) {
+	p, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || p < 1 || p > 65535 {
+		return 0, false
+	}
+	return p, true
+}
+
+func dotenvValue(path, key string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) != key {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 {
+			if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
+				value = value[1 : len(value)-1]
+			}
+		}
+		return value, true
+	}
+	return "", false
+}
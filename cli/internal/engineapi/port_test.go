@@ -0,0 +1,64 @@
+package engineapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	prev, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(prev) })
+	return dir
+}
+
+func TestConfiguredPortPrecedence(t *testing.T) {
+	root := chdirTemp(t)
+	t.Setenv("HEALTH_BIND_PORT", "")
+
+	if err := os.MkdirAll(filepath.Join(root, "config"), 0o755); err != nil {
+		t.Fatalf("mkdir config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "config", "ai-agent.yaml"), []byte("health:\n  port: 16000\n"), 0o644); err != nil {
+		t.Fatalf("write base config: %v", err)
+	}
+	if got := ConfiguredPort(); got != 16000 {
+		t.Fatalf("base YAML port mismatch: got %d want 16000", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "config", "ai-agent.local.yaml"), []byte("health:\n  port: 17000\n"), 0o644); err != nil {
+		t.Fatalf("write local config: %v", err)
+	}
+	if got := ConfiguredPort(); got != 17000 {
+		t.Fatalf("local YAML port mismatch: got %d want 17000", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("HEALTH_BIND_PORT=18000\n"), 0o644); err != nil {
+		t.Fatalf("write .env: %v", err)
+	}
+	if got := ConfiguredPort(); got != 18000 {
+		t.Fatalf(".env port mismatch: got %d want 18000", got)
+	}
+
+	t.Setenv("HEALTH_BIND_PORT", "19000")
+	if got := ConfiguredPort(); got != 19000 {
+		t.Fatalf("environment port mismatch: got %d want 19000", got)
+	}
+}
+
+func TestConfiguredPortDefault(t *testing.T) {
+	chdirTemp(t)
+	t.Setenv("HEALTH_BIND_PORT", "")
+
+	if got := ConfiguredPort(); got != DefaultPort {
+		t.Fatalf("default port mismatch: got %d want %d", got, DefaultPort)
+	}
+}
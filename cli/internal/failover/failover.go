@@ -0,0 +1,379 @@
+// Package failover reads the engine's provider fallback chain from the merged
+// config and checks that every step of it can take a call.
+//
+// The engine has two fallbacks. A call whose provider or pipeline is not
+// loaded is served by default_provider. A provider that fails to start on an
+// answered call triggers on_provider_failure; with dialplan_redirect the
+// caller leaves Stasis for provider_failure_redirect_context, which may enter
+// the engine again with another AI_PROVIDER (the fallback provider) or send
+// the caller elsewhere in the dialplan.
+package failover
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/chaos"
+)
+
+// on_provider_failure actions.
+const (
+	ActionAnnounceHangup = "announce_hangup"
+	ActionRedirect       = "dialplan_redirect"
+	ActionLeaveOpen      = "leave_open"
+)
+
+// Chain is what the engine does with a call whose primary provider fails.
+type Chain struct {
+	Primary string `json:"primary"`
+	Default string `json:"default_provider"`
+	Action  string `json:"on_provider_failure"`
+	// Redirect is the dialplan_redirect target as context,extension,priority.
+	Redirect  string `json:"redirect,omitempty"`
+	Context   string `json:"-"`
+	Extension string `json:"-"`
+	Priority  int    `json:"-"`
+	// Fallback is the provider or pipeline the redirect target runs when it
+	// enters the engine again; empty when it leaves the engine for good.
+	Fallback string `json:"fallback,omitempty"`
+	// Target is the redirect context as Asterisk has it loaded, when read.
+	Target *Target `json:"redirect_target,omitempty"`
+}
+
+// Resolve reads the chain for primary (default_provider when empty) from cfg.
+func Resolve(cfg map[string]any, primary string) (Chain, error) {
+	c := Chain{
+		Default:   str(cfg["default_provider"]),
+		Action:    str(cfg["on_provider_failure"]),
+		Context:   str(cfg["provider_failure_redirect_context"]),
+		Extension: str(cfg["provider_failure_redirect_extension"]),
+		Priority:  1,
+	}
+	if c.Action == "" {
+		c.Action = ActionAnnounceHangup
+	}
+	if c.Extension == "" {
+		c.Extension = "s"
+	}
+	if p, err := strconv.Atoi(str(cfg["provider_failure_redirect_priority"])); err == nil && p > 0 {
+		c.Priority = p
+	}
+	c.Primary = primary
+	if c.Primary == "" {
+		c.Primary = c.Default
+	}
+	if c.Primary == "" {
+		return c, fmt.Errorf("default_provider is not set; pass --provider")
+	}
+	if c.Action == ActionRedirect && c.Context != "" {
+		c.Redirect = fmt.Sprintf("%s,%s,%d", c.Context, c.Extension, c.Priority)
+	}
+	return c, nil
+}
+
+// Target is what a redirect context does with the caller.
+type Target struct {
+	Loaded bool `json:"loaded"`
+	// Stasis is set when the context enters the engine again.
+	Stasis   bool   `json:"stasis"`
+	Provider string `json:"ai_provider,omitempty"`
+	Agent    string `json:"ai_agent,omitempty"`
+}
+
+var (
+	setProvider = regexp.MustCompile(`Set\(AI_PROVIDER=([^)\s]+)\)`)
+	setAgent    = regexp.MustCompile(`Set\(AI_AGENT=([^)\s]+)\)`)
+)
+
+// ParseTarget reads `dialplan show <context>` output for the redirect context.
+func ParseTarget(output, context string) Target {
+	if strings.Contains(output, "There is no existence of") || !strings.Contains(output, "Context '"+context+"'") {
+		return Target{}
+	}
+	t := Target{Loaded: true, Stasis: strings.Contains(output, "Stasis(")}
+	if m := setProvider.FindStringSubmatch(output); m != nil {
+		t.Provider = m[1]
+	}
+	if m := setAgent.FindStringSubmatch(output); m != nil {
+		t.Agent = m[1]
+	}
+	return t
+}
+
+// SetTarget records the redirect context and the fallback it implies. A
+// context that enters Stasis without AI_PROVIDER runs default_provider unless
+// its agent picks another.
+func (c *Chain) SetTarget(t Target) {
+	c.Target = &t
+	if !t.Stasis {
+		return
+	}
+	c.Fallback = t.Provider
+	if c.Fallback == "" {
+		c.Fallback = c.Default
+	}
+}
+
+// Report is the outcome of Validate.
+type Report struct {
+	Passed   []string `json:"passed"`
+	Warnings []string `json:"warnings"`
+	Errors   []string `json:"errors"`
+}
+
+// Validate checks that each step of c exists, is enabled and has its keys
+// set, that the fallback does not share the primary's endpoints and that it
+// speaks the same audio format to the caller. env reads .env settings; keys
+// lists the env vars a provider needs beyond the ${VAR} references in its
+// config.
+func Validate(cfg map[string]any, c Chain, env func(string) string, keys func(string) []string) Report {
+	r := Report{Passed: []string{}, Warnings: []string{}, Errors: []string{}}
+	pass := func(format string, args ...any) { r.Passed = append(r.Passed, fmt.Sprintf(format, args...)) }
+	warn := func(format string, args ...any) { r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...)) }
+	fail := func(format string, args ...any) { r.Errors = append(r.Errors, fmt.Sprintf(format, args...)) }
+
+	step := func(role, name string) bool {
+		comp, ok := lookup(cfg, name)
+		if !ok {
+			fail("%s %s is neither a provider nor a pipeline in ai-agent.yaml", role, name)
+			return false
+		}
+		ok = true
+		for _, p := range comp.providers {
+			if !p.known {
+				fail("%s %s uses provider %s, which is not configured", role, name, p.name)
+				ok = false
+			} else if !p.enabled {
+				fail("%s %s uses provider %s, which is disabled (enabled: false)", role, name, p.name)
+				ok = false
+			}
+		}
+		var missing []string
+		for _, key := range requiredKeys(comp, keys) {
+			if strings.TrimSpace(env(key)) == "" {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			fail("%s %s needs %s, not set in .env", role, name, strings.Join(missing, ", "))
+			ok = false
+		}
+		if ok {
+			pass("%s %s is configured (%s)", role, name, comp.kind)
+		}
+		return ok
+	}
+
+	primaryOK := step("primary", c.Primary)
+	if c.Default != "" && c.Default != c.Primary {
+		step("default_provider", c.Default)
+	}
+
+	switch c.Action {
+	case ActionLeaveOpen:
+		fail("on_provider_failure is leave_open: when %s fails the caller stays on a dead line; use dialplan_redirect", c.Primary)
+		return r
+	case ActionAnnounceHangup:
+		warn("no fallback: on_provider_failure is announce_hangup, so callers hear provider_failure_prompt and are hung up when %s fails; set dialplan_redirect and provider_failure_redirect_context to fail over", c.Primary)
+		return r
+	case ActionRedirect:
+		if c.Context == "" {
+			fail("on_provider_failure is dialplan_redirect but provider_failure_redirect_context is not set; the engine announces and hangs up instead")
+			return r
+		}
+	default:
+		fail("on_provider_failure %q is not one of announce_hangup, dialplan_redirect, leave_open", c.Action)
+		return r
+	}
+
+	switch {
+	case c.Target == nil:
+		if c.Fallback == "" {
+			warn("could not read [%s] from Asterisk; pass --fallback to name the provider it runs", c.Context)
+			return r
+		}
+	case !c.Target.Loaded:
+		fail("provider_failure_redirect_context [%s] is not loaded in Asterisk; the engine announces and hangs up instead", c.Context)
+		return r
+	case !c.Target.Stasis:
+		pass("on failure callers are redirected to %s, which leaves the engine (no AI fallback)", c.Redirect)
+		return r
+	case c.Target.Provider == "":
+		warn("[%s] enters the engine without AI_PROVIDER; assuming it runs default_provider %s (set AI_PROVIDER to make the fallback explicit)", c.Context, c.Fallback)
+	}
+
+	if c.Fallback == c.Primary {
+		fail("the fallback after %s is %s itself, so a failure redirects the caller into the same provider; set AI_PROVIDER in [%s]", c.Redirect, c.Primary, c.Context)
+		return r
+	}
+	if step("fallback", c.Fallback) && primaryOK {
+		pass("on failure callers are redirected to %s and served by %s", c.Redirect, c.Fallback)
+	}
+
+	if shared := sharedHosts(cfg, c.Primary, c.Fallback, env); len(shared) > 0 {
+		warn("%s and %s both reach %s, so an outage there takes down the fallback too", c.Primary, c.Fallback, strings.Join(shared, ", "))
+	}
+	pf, ff := callerFormats(cfg, c.Primary), callerFormats(cfg, c.Fallback)
+	for _, dir := range []string{"input", "output"} {
+		a, b := pf[dir], ff[dir]
+		switch {
+		case a == "" || b == "":
+		case a != b:
+			warn("%s %s is %s but %s %s is %s; the redirected call keeps the same transport and audio profile", c.Primary, dir, a, c.Fallback, dir, b)
+		default:
+			pass("%s and %s agree on caller %s audio (%s)", c.Primary, c.Fallback, dir, a)
+		}
+	}
+	return r
+}
+
+type providerRef struct {
+	name    string
+	known   bool
+	enabled bool
+	conf    map[string]any
+}
+
+type component struct {
+	kind      string // "provider" or "pipeline"
+	providers []providerRef
+	options   []map[string]any // pipeline options per stage
+}
+
+func lookup(cfg map[string]any, name string) (component, bool) {
+	providers, _ := cfg["providers"].(map[string]any)
+	ref := func(n string) providerRef {
+		pc, ok := providers[n].(map[string]any)
+		enabled := true
+		if v, set := pc["enabled"].(bool); set {
+			enabled = v
+		}
+		return providerRef{name: n, known: ok, enabled: enabled, conf: pc}
+	}
+	pipelines, _ := cfg["pipelines"].(map[string]any)
+	if p, ok := pipelines[name].(map[string]any); ok {
+		c := component{kind: "pipeline"}
+		options, _ := p["options"].(map[string]any)
+		for _, stage := range []string{"stt", "llm", "tts"} {
+			if n := str(p[stage]); n != "" {
+				c.providers = append(c.providers, ref(n))
+			}
+			if o, ok := options[stage].(map[string]any); ok {
+				c.options = append(c.options, o)
+			}
+		}
+		return c, true
+	}
+	if _, ok := providers[name].(map[string]any); ok {
+		return component{kind: "provider", providers: []providerRef{ref(name)}}, true
+	}
+	return component{}, false
+}
+
+// keyRef matches ${VAR} without a default: a setting the engine cannot run without.
+var keyRef = regexp.MustCompile(`\$\{(\w+)\}`)
+
+func requiredKeys(c component, keys func(string) []string) []string {
+	seen := map[string]bool{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch t := v.(type) {
+		case string:
+			for _, m := range keyRef.FindAllStringSubmatch(t, -1) {
+				seen[m[1]] = true
+			}
+		case map[string]any:
+			for _, vv := range t {
+				walk(vv)
+			}
+		case []any:
+			for _, vv := range t {
+				walk(vv)
+			}
+		}
+	}
+	for _, p := range c.providers {
+		walk(p.conf)
+		if keys != nil {
+			for _, k := range keys(p.name) {
+				seen[k] = true
+			}
+		}
+	}
+	for _, o := range c.options {
+		walk(o)
+	}
+	out := make([]string, 0, len(seen))
+	for k := range seen {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sharedHosts(cfg map[string]any, a, b string, env func(string) string) []string {
+	ta, _ := chaos.ProviderTargets(cfg, a, env)
+	tb, _ := chaos.ProviderTargets(cfg, b, env)
+	hosts := map[string]bool{}
+	for _, t := range ta {
+		hosts[t.String()] = true
+	}
+	var shared []string
+	for _, t := range tb {
+		if hosts[t.String()] {
+			shared = append(shared, t.String())
+			delete(hosts, t.String())
+		}
+	}
+	return shared
+}
+
+// callerFormats returns the audio a provider or pipeline takes from and sends
+// to the caller, as encoding@rate, where the config states it.
+func callerFormats(cfg map[string]any, name string) map[string]string {
+	out := map[string]string{}
+	c, ok := lookup(cfg, name)
+	if !ok {
+		return out
+	}
+	if c.kind == "provider" {
+		pc := c.providers[0].conf
+		out["input"] = format(pc["input_encoding"], pc["input_sample_rate_hz"])
+		out["output"] = format(pc["target_encoding"], pc["target_sample_rate_hz"])
+		return out
+	}
+	for _, o := range c.options {
+		if f, ok := o["format"].(map[string]any); ok {
+			out["output"] = format(f["encoding"], f["sample_rate"])
+		}
+	}
+	return out
+}
+
+func format(encoding, rate any) string {
+	enc := strings.ToLower(str(encoding))
+	switch enc {
+	case "":
+		return ""
+	case "mulaw", "mu-law", "g711_ulaw", "pcmu":
+		enc = "ulaw"
+	case "alaw", "g711_alaw", "pcma":
+		enc = "alaw"
+	case "linear16", "pcm16", "slin", "slin16", "s16le":
+		enc = "pcm16"
+	}
+	if r := str(rate); r != "" {
+		return enc + "@" + r
+	}
+	return enc
+}
+
+func str(v any) string {
+	if v == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(v))
+}
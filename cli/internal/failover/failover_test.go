@@ -0,0 +1,154 @@
+package failover
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+)
+
+const testConfig = `
+default_provider: openai_realtime
+on_provider_failure: dialplan_redirect
+provider_failure_redirect_context: from-ai-agent-local
+providers:
+  openai_realtime:
+    enabled: true
+    base_url: wss://api.openai.com/v1/realtime
+    input_encoding: ulaw
+    input_sample_rate_hz: 8000
+    target_encoding: mulaw
+    target_sample_rate_hz: 8000
+  google_live:
+    enabled: true
+    api_key: ${GOOGLE_API_KEY}
+    input_encoding: ulaw
+    input_sample_rate_hz: 8000
+    target_encoding: linear16
+    target_sample_rate_hz: 16000
+  local:
+    enabled: true
+    ws_url: ${LOCAL_WS_URL:-ws://127.0.0.1:8765}
+    input_encoding: mulaw
+    input_sample_rate_hz: 8000
+    target_encoding: g711_ulaw
+    target_sample_rate_hz: 8000
+  openai_llm:
+    enabled: false
+    api_key: ${OPENAI_API_KEY}
+  local_stt: {enabled: true, ws_url: "${LOCAL_WS_URL:-ws://127.0.0.1:8765}"}
+  local_tts: {enabled: true, ws_url: "${LOCAL_WS_URL:-ws://127.0.0.1:8765}"}
+pipelines:
+  hybrid:
+    stt: local_stt
+    llm: openai_llm
+    tts: local_tts
+`
+
+const localContext = `[ Context 'from-ai-agent-local' created by 'pbx_config' ]
+  's' =>            1. NoOp(AI Voice Agent - Local)                [extensions_custom.conf:12]
+                    2. Set(AI_AGENT=default)                       [extensions_custom.conf:13]
+                    3. Set(AI_PROVIDER=local)                      [extensions_custom.conf:14]
+                    4. Stasis(asterisk-ai-voice-agent)             [extensions_custom.conf:15]
+                    5. Hangup()                                    [extensions_custom.conf:16]
+
+-= 1 extension (5 priorities) in 1 context. =-`
+
+func testChain(t *testing.T, yml, primary string) (map[string]any, Chain) {
+	t.Helper()
+	cfg, err := configmerge.ParseYAML([]byte(yml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Resolve(cfg, primary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg, c
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func keys(name string) []string {
+	if name == "openai_realtime" {
+		return []string{"OPENAI_API_KEY"}
+	}
+	return nil
+}
+
+func TestValidateRedirectToLocal(t *testing.T) {
+	cfg, c := testChain(t, testConfig, "")
+	if c.Primary != "openai_realtime" || c.Redirect != "from-ai-agent-local,s,1" {
+		t.Fatalf("chain = %+v", c)
+	}
+	c.SetTarget(ParseTarget(localContext, "from-ai-agent-local"))
+	if c.Fallback != "local" || c.Target.Agent != "default" {
+		t.Fatalf("fallback = %+v", c)
+	}
+
+	r := Validate(cfg, c, env(map[string]string{"OPENAI_API_KEY": "sk"}), keys)
+	if len(r.Errors) != 0 || len(r.Warnings) != 0 {
+		t.Fatalf("report = %+v", r)
+	}
+	if !strings.Contains(strings.Join(r.Passed, "\n"), "agree on caller output audio (ulaw@8000)") {
+		t.Fatalf("formats not compared: %v", r.Passed)
+	}
+
+	r = Validate(cfg, c, env(nil), keys)
+	if len(r.Errors) != 1 || !strings.Contains(r.Errors[0], "needs OPENAI_API_KEY") {
+		t.Fatalf("missing key: %+v", r)
+	}
+}
+
+func TestValidateFindsBrokenFallbacks(t *testing.T) {
+	cfg, c := testChain(t, testConfig, "")
+	c.Fallback = "google_live"
+	r := Validate(cfg, c, env(map[string]string{"OPENAI_API_KEY": "sk"}), keys)
+	all := strings.Join(append(r.Errors, r.Warnings...), "\n")
+	if strings.Contains(all, "could not read") {
+		t.Fatalf("a named fallback needs no Asterisk: %s", all)
+	}
+	for _, want := range []string{"fallback google_live needs GOOGLE_API_KEY", "output is ulaw@8000 but google_live output is pcm16@16000"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("report lacks %q:\n%s", want, all)
+		}
+	}
+
+	c.Fallback = "hybrid"
+	if r := Validate(cfg, c, env(map[string]string{"OPENAI_API_KEY": "sk"}), keys); len(r.Errors) != 1 || !strings.Contains(r.Errors[0], "openai_llm, which is disabled") {
+		t.Fatalf("disabled stage: %+v", r)
+	}
+
+	c.Fallback = "openai_realtime"
+	if r := Validate(cfg, c, env(map[string]string{"OPENAI_API_KEY": "sk"}), keys); len(r.Errors) != 1 || !strings.Contains(r.Errors[0], "openai_realtime itself") {
+		t.Fatalf("self fallback: %+v", r)
+	}
+
+	c.Fallback = ""
+	c.SetTarget(ParseTarget("There is no existence of 'from-ai-agent-local' context", "from-ai-agent-local"))
+	if r := Validate(cfg, c, env(map[string]string{"OPENAI_API_KEY": "sk"}), keys); len(r.Errors) != 1 || !strings.Contains(r.Errors[0], "not loaded in Asterisk") {
+		t.Fatalf("unloaded context: %+v", r)
+	}
+}
+
+func TestValidateWithoutRedirect(t *testing.T) {
+	for action, want := range map[string]string{
+		"announce_hangup": "no fallback",
+		"leave_open":      "dead line",
+	} {
+		yml := strings.Replace(testConfig, "on_provider_failure: dialplan_redirect", "on_provider_failure: "+action, 1)
+		cfg, c := testChain(t, yml, "")
+		r := Validate(cfg, c, env(map[string]string{"OPENAI_API_KEY": "sk"}), keys)
+		if got := strings.Join(append(r.Errors, r.Warnings...), "\n"); !strings.Contains(got, want) {
+			t.Errorf("%s: %s", action, got)
+		}
+	}
+
+	_, c := testChain(t, testConfig, "google_live")
+	c.SetTarget(ParseTarget("[ Context 'from-ai-agent-local' created by 'pbx_config' ]\n  's' => 1. VoiceMail(100@default)\n", "from-ai-agent-local"))
+	if c.Fallback != "" || !c.Target.Loaded || c.Target.Stasis {
+		t.Fatalf("voicemail target = %+v", c)
+	}
+}
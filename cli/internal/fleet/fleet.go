@@ -0,0 +1,228 @@
+// Package fleet keeps a registry of deployments (customer PBXes) and runs agent
+// commands against each of them with aggregated, per-site results.
+package fleet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"gopkg.in/yaml.v3"
+)
+
+// Site is one registered deployment. Either Host (ssh://, tcp://) or Context selects the
+// docker endpoint; ProjectDir is the checkout on that host (needed for updates).
+type Site struct {
+	Name       string `yaml:"name" json:"name"`
+	Host       string `yaml:"host,omitempty" json:"host,omitempty"`
+	Context    string `yaml:"context,omitempty" json:"context,omitempty"`
+	ProjectDir string `yaml:"project_dir,omitempty" json:"project_dir,omitempty"`
+}
+
+// Registry is the on-disk list of sites.
+type Registry struct {
+	Sites []Site `yaml:"sites"`
+}
+
+var siteNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Path returns the registry location.
+func Path() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_FLEET_FILE")); p != "" {
+		return p
+	}
+	return filepath.Join(".agent", "fleet.yaml")
+}
+
+// Load reads the registry; a missing file is an empty registry.
+func Load(path string) (*Registry, error) {
+	reg := &Registry{}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return reg, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(raw, reg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return reg, nil
+}
+
+// Save writes the registry, sorted by site name.
+func (reg *Registry) Save(path string) error {
+	sort.Slice(reg.Sites, func(i, j int) bool { return reg.Sites[i].Name < reg.Sites[j].Name })
+	raw, err := yaml.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
+
+// Add registers or replaces a site.
+func (reg *Registry) Add(s Site) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if !siteNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("invalid site name %q (letters, digits, '.', '_' and '-')", s.Name)
+	}
+	if s.Host == "" && s.Context == "" {
+		return errors.New("a site needs --host or --context")
+	}
+	if s.Host != "" {
+		if err := deployment.ValidateHost(s.Host); err != nil {
+			return err
+		}
+	}
+	for i := range reg.Sites {
+		if reg.Sites[i].Name == s.Name {
+			reg.Sites[i] = s
+			return nil
+		}
+	}
+	reg.Sites = append(reg.Sites, s)
+	return nil
+}
+
+// Remove deletes a site by name.
+func (reg *Registry) Remove(name string) error {
+	for i := range reg.Sites {
+		if reg.Sites[i].Name == name {
+			reg.Sites = append(reg.Sites[:i], reg.Sites[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no site named %q", name)
+}
+
+// Select returns the named sites (all sites when names is empty), in registry order.
+func (reg *Registry) Select(names []string) ([]Site, error) {
+	if len(names) == 0 {
+		if len(reg.Sites) == 0 {
+			return nil, fmt.Errorf("no sites registered (add one with: agent fleet add <name> --host ssh://user@server)")
+		}
+		return reg.Sites, nil
+	}
+	want := map[string]bool{}
+	for _, n := range names {
+		want[n] = true
+	}
+	var out []Site
+	for _, s := range reg.Sites {
+		if want[s.Name] {
+			out = append(out, s)
+			delete(want, s.Name)
+		}
+	}
+	if len(want) > 0 {
+		missing := make([]string, 0, len(want))
+		for n := range want {
+			missing = append(missing, n)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("unknown site(s): %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// Endpoint describes the site's docker endpoint for display.
+func (s Site) Endpoint() string {
+	if s.Host != "" {
+		return s.Host
+	}
+	return "context:" + s.Context
+}
+
+// Env returns the environment a child agent process needs to target this site.
+func (s Site) Env() []string {
+	env := make([]string, 0, len(os.Environ())+3)
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "DOCKER_HOST=") || strings.HasPrefix(kv, "DOCKER_CONTEXT=") || strings.HasPrefix(kv, "AAVA_PROJECT_DIR=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	if s.Host != "" {
+		env = append(env, "DOCKER_HOST="+s.Host)
+	} else if s.Context != "" {
+		env = append(env, "DOCKER_CONTEXT="+s.Context)
+	}
+	if s.ProjectDir != "" {
+		env = append(env, "AAVA_PROJECT_DIR="+s.ProjectDir)
+	}
+	return env
+}
+
+// Result statuses. StatusError means the site could not be evaluated at all.
+const (
+	StatusPass  = "pass"
+	StatusWarn  = "warn"
+	StatusFail  = "fail"
+	StatusError = "error"
+)
+
+// Result is the outcome of one command on one site.
+type Result struct {
+	Site     string        `json:"site"`
+	Endpoint string        `json:"endpoint"`
+	Status   string        `json:"status"`
+	Summary  string        `json:"summary"`
+	Failing  []string      `json:"failing,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Output   string        `json:"-"`
+}
+
+// Run applies fn to every site with at most parallel workers; results keep site order.
+func Run(sites []Site, parallel int, fn func(Site) Result) []Result {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]Result, len(sites))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, s := range sites {
+		wg.Add(1)
+		go func(i int, s Site) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			start := time.Now()
+			res := fn(s)
+			res.Site, res.Endpoint, res.Duration = s.Name, s.Endpoint(), time.Since(start)
+			results[i] = res
+		}(i, s)
+	}
+	wg.Wait()
+	return results
+}
+
+// Totals counts results by status.
+func Totals(results []Result) map[string]int {
+	t := map[string]int{StatusPass: 0, StatusWarn: 0, StatusFail: 0, StatusError: 0}
+	for _, r := range results {
+		t[r.Status]++
+	}
+	return t
+}
+
+// ExitCode follows agent check: 2 when any site failed or errored, 1 for warnings.
+func ExitCode(results []Result) int {
+	t := Totals(results)
+	switch {
+	case t[StatusFail] > 0 || t[StatusError] > 0:
+		return 2
+	case t[StatusWarn] > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package fleet
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistryRoundTripAndSelect(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "fleet.yaml")
+	reg := &Registry{}
+	if err := reg.Add(Site{Name: "zeta", Host: "ssh://ops@zeta"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := reg.Add(Site{Name: "acme", Context: "acme-pbx"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := reg.Add(Site{Name: "bad name", Host: "ssh://x"}); err == nil {
+		t.Fatalf("expected invalid name error")
+	}
+	if err := reg.Add(Site{Name: "nohost"}); err == nil {
+		t.Fatalf("expected missing endpoint error")
+	}
+	if err := reg.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded.Sites) != 2 || loaded.Sites[0].Name != "acme" {
+		t.Fatalf("sites=%+v", loaded.Sites)
+	}
+	if _, err := loaded.Select([]string{"acme", "missing"}); err == nil {
+		t.Fatalf("expected unknown site error")
+	}
+	if err := loaded.Remove("acme"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if got, _ := loaded.Select(nil); len(got) != 1 || got[0].Name != "zeta" {
+		t.Fatalf("select after remove=%+v", got)
+	}
+}
+
+func TestRunKeepsSiteOrderAndAggregates(t *testing.T) {
+	t.Parallel()
+
+	sites := []Site{{Name: "a", Host: "ssh://a"}, {Name: "b", Host: "ssh://b"}, {Name: "c", Context: "c"}}
+	statuses := map[string]string{"a": StatusPass, "b": StatusWarn, "c": StatusError}
+	results := Run(sites, 3, func(s Site) Result {
+		if s.Name == "a" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return Result{Status: statuses[s.Name]}
+	})
+	for i, r := range results {
+		if r.Site != sites[i].Name || r.Endpoint != sites[i].Endpoint() {
+			t.Fatalf("result %d = %+v", i, r)
+		}
+	}
+	if totals := Totals(results); totals[StatusWarn] != 1 || totals[StatusError] != 1 {
+		t.Fatalf("totals=%v", totals)
+	}
+	if code := ExitCode(results); code != 2 {
+		t.Fatalf("exit=%d", code)
+	}
+}
@@ -0,0 +1,166 @@
+// Package grafana is a small Grafana HTTP API client for provisioning the
+// ai_engine Prometheus datasource and dashboard.
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIError is a non-2xx response from Grafana.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("grafana API %d: %s", e.Status, e.Message)
+}
+
+// IsNotFound reports whether err is a 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
+
+// Client talks to one Grafana instance. Token (a service account token) takes
+// precedence over User/Password basic auth.
+type Client struct {
+	HTTP     *http.Client
+	BaseURL  string
+	Token    string
+	User     string
+	Password string
+}
+
+// Datasource is the subset of a Grafana datasource the bootstrap uses.
+type Datasource struct {
+	ID        int    `json:"id,omitempty"`
+	UID       string `json:"uid,omitempty"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	URL       string `json:"url,omitempty"`
+	Access    string `json:"access,omitempty"`
+	IsDefault bool   `json:"isDefault,omitempty"`
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.User != "":
+		req.SetBasicAuth(c.User, c.Password)
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode/100 != 2 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(raw, &msg) != nil || msg.Message == "" {
+			msg.Message = strings.TrimSpace(string(raw))
+		}
+		return &APIError{Status: resp.StatusCode, Message: msg.Message}
+	}
+	if out != nil {
+		return json.Unmarshal(raw, out)
+	}
+	return nil
+}
+
+// Health checks that the URL is a reachable Grafana and returns its version.
+func (c *Client) Health(ctx context.Context) (string, error) {
+	var h struct {
+		Version  string `json:"version"`
+		Database string `json:"database"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/health", nil, &h); err != nil {
+		return "", err
+	}
+	return h.Version, nil
+}
+
+// Datasources lists every datasource the credentials can see.
+func (c *Client) Datasources(ctx context.Context) ([]Datasource, error) {
+	var out []Datasource
+	err := c.do(ctx, http.MethodGet, "/api/datasources", nil, &out)
+	return out, err
+}
+
+// UpsertDatasource creates ds, or updates the datasource with the same name, and
+// returns it with its UID.
+func (c *Client) UpsertDatasource(ctx context.Context, ds Datasource) (Datasource, error) {
+	var existing Datasource
+	err := c.do(ctx, http.MethodGet, "/api/datasources/name/"+url.PathEscape(ds.Name), nil, &existing)
+	switch {
+	case err == nil:
+		ds.ID, ds.UID = existing.ID, existing.UID
+		if err := c.do(ctx, http.MethodPut, "/api/datasources/uid/"+url.PathEscape(existing.UID), ds, nil); err != nil {
+			return ds, err
+		}
+		return ds, nil
+	case !IsNotFound(err):
+		return ds, err
+	}
+	var created struct {
+		Datasource Datasource `json:"datasource"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/datasources", ds, &created); err != nil {
+		return ds, err
+	}
+	return created.Datasource, nil
+}
+
+// EnsureFolder returns the folder with uid, creating it with title when missing.
+func (c *Client) EnsureFolder(ctx context.Context, uid, title string) error {
+	err := c.do(ctx, http.MethodGet, "/api/folders/"+url.PathEscape(uid), nil, nil)
+	if !IsNotFound(err) {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, "/api/folders", map[string]string{"uid": uid, "title": title}, nil)
+}
+
+// SaveDashboard creates or overwrites a dashboard in the folder and returns its URL path.
+func (c *Client) SaveDashboard(ctx context.Context, dashboard map[string]any, folderUID, message string) (string, error) {
+	var out struct {
+		URL string `json:"url"`
+	}
+	err := c.do(ctx, http.MethodPost, "/api/dashboards/db", map[string]any{
+		"dashboard": dashboard,
+		"folderUid": folderUID,
+		"overwrite": true,
+		"message":   message,
+	}, &out)
+	return out.URL, err
+}
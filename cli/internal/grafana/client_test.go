@@ -0,0 +1,92 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBootstrapFlow(t *testing.T) {
+	var calls []string
+	var saved map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/health":
+			_, _ = w.Write([]byte(`{"version":"11.2.0","database":"ok"}`))
+		case "GET /api/datasources/name/AAVA Prometheus", "GET /api/folders/aava":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+		case "POST /api/datasources":
+			_, _ = w.Write([]byte(`{"datasource":{"id":3,"uid":"prom1","name":"AAVA Prometheus","type":"prometheus"}}`))
+		case "POST /api/folders":
+			_, _ = w.Write([]byte(`{"uid":"aava"}`))
+		case "POST /api/dashboards/db":
+			_ = json.NewDecoder(r.Body).Decode(&saved)
+			_, _ = w.Write([]byte(`{"url":"/d/aava-overview/asterisk-ai-voice-agent"}`))
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Token: "tok"}
+	ctx := context.Background()
+	if v, err := c.Health(ctx); err != nil || v != "11.2.0" {
+		t.Fatalf("health = %q, %v", v, err)
+	}
+	ds, err := c.UpsertDatasource(ctx, Datasource{Name: "AAVA Prometheus", Type: "prometheus", URL: "http://prom:9090", Access: "proxy"})
+	if err != nil || ds.UID != "prom1" {
+		t.Fatalf("datasource = %+v, %v", ds, err)
+	}
+	if err := c.EnsureFolder(ctx, "aava", "Asterisk AI Voice Agent"); err != nil {
+		t.Fatal(err)
+	}
+	url, err := c.SaveDashboard(ctx, Dashboard(ds.UID), "aava", "test")
+	if err != nil || url != "/d/aava-overview/asterisk-ai-voice-agent" {
+		t.Fatalf("save = %q, %v", url, err)
+	}
+	if saved["overwrite"] != true || saved["folderUid"] != "aava" {
+		t.Errorf("save payload = %v", saved)
+	}
+	if !strings.Contains(strings.Join(calls, "\n"), "POST /api/folders") {
+		t.Errorf("folder not created: %v", calls)
+	}
+
+	c.Token = "wrong"
+	if _, err := c.Health(ctx); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("unauthorized error = %v", err)
+	}
+}
+
+func TestDashboardQueriesUseEngineMetrics(t *testing.T) {
+	d := Dashboard("prom1")
+	panels := d["panels"].([]map[string]any)
+	ids := map[int]bool{}
+	for _, p := range panels {
+		if ids[p["id"].(int)] {
+			t.Errorf("duplicate panel id %v", p["id"])
+		}
+		ids[p["id"].(int)] = true
+		targets := p["targets"].([]map[string]any)
+		if len(targets) == 0 {
+			t.Errorf("panel %q has no queries", p["title"])
+		}
+		for _, tg := range targets {
+			expr := tg["expr"].(string)
+			if !strings.Contains(expr, "ai_agent_") || !strings.Contains(expr, `instance=~"$instance"`) {
+				t.Errorf("panel %q query %q", p["title"], expr)
+			}
+			if tg["datasource"].(map[string]any)["uid"] != "prom1" {
+				t.Errorf("panel %q not bound to the datasource", p["title"])
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package grafana
+
+// DashboardUID is fixed so a second bootstrap updates the dashboard in place.
+const DashboardUID = "aava-overview"
+
+type target struct {
+	expr   string
+	legend string
+}
+
+// Dashboard returns the ai_engine overview dashboard bound to the Prometheus
+// datasource dsUID. It only queries metrics ai_engine exports on /metrics; the
+// per-call RCA quality score is not exported, so playback quality is derived
+// from underflow filler frames instead.
+func Dashboard(dsUID string) map[string]any {
+	ds := map[string]any{"type": "prometheus", "uid": dsUID}
+	const sel = `instance=~"$instance"`
+	id := 0
+	panel := func(kind, title, unit string, x, y, w, h int, targets ...target) map[string]any {
+		id++
+		var ts []map[string]any
+		for i, t := range targets {
+			ts = append(ts, map[string]any{
+				"datasource":   ds,
+				"expr":         t.expr,
+				"legendFormat": t.legend,
+				"refId":        string(rune('A' + i)),
+			})
+		}
+		return map[string]any{
+			"id":         id,
+			"type":       kind,
+			"title":      title,
+			"datasource": ds,
+			"gridPos":    map[string]int{"x": x, "y": y, "w": w, "h": h},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]any{"unit": unit},
+				"overrides": []any{},
+			},
+			"targets": ts,
+		}
+	}
+	quantiles := func(metric string) []target {
+		var out []target
+		for _, q := range [][2]string{{"0.5", "p50"}, {"0.9", "p90"}, {"0.99", "p99"}} {
+			out = append(out, target{
+				expr:   "histogram_quantile(" + q[0] + ", sum by (le) (rate(" + metric + "_bucket{" + sel + "}[$__rate_interval])))",
+				legend: q[1],
+			})
+		}
+		return out
+	}
+
+	panels := []map[string]any{
+		panel("stat", "Calls (24h)", "short", 0, 0, 6, 4,
+			target{`sum(increase(ai_agent_call_duration_seconds_count{` + sel + `}[24h]))`, ""}),
+		panel("stat", "Active streams", "short", 6, 0, 6, 4,
+			target{`sum(ai_agent_streaming_active{` + sel + `})`, ""}),
+		panel("stat", "Playback quality (frames without filler)", "percent", 12, 0, 6, 4,
+			target{`100 * (1 - sum(increase(ai_agent_stream_underflow_events_total{` + sel + `}[24h])) / clamp_min(sum(increase(ai_agent_stream_frames_sent_total{` + sel + `}[24h])), 1))`, ""}),
+		panel("stat", "Turn response p90 (24h)", "s", 18, 0, 6, 4,
+			target{`histogram_quantile(0.9, sum by (le) (increase(ai_agent_turn_response_seconds_bucket{` + sel + `}[24h])))`, ""}),
+
+		panel("timeseries", "Call volume (calls per hour)", "short", 0, 4, 12, 8,
+			target{`sum by (provider) (increase(ai_agent_call_duration_seconds_count{` + sel + `}[1h]))`, "{{provider}}"}),
+		panel("timeseries", "Playback quality", "percent", 12, 4, 12, 8,
+			target{`100 * (1 - sum(rate(ai_agent_stream_underflow_events_total{` + sel + `}[$__rate_interval])) / clamp_min(sum(rate(ai_agent_stream_frames_sent_total{` + sel + `}[$__rate_interval])), 1e-9))`, "frames without filler"}),
+
+		panel("timeseries", "Turn response latency (transcript to playback)", "s", 0, 12, 12, 8,
+			quantiles("ai_agent_turn_response_seconds")...),
+		panel("timeseries", "STT to first TTS audio", "s", 12, 12, 12, 8,
+			quantiles("ai_agent_stt_to_tts_seconds")...),
+
+		panel("timeseries", "Underflows per minute", "short", 0, 20, 12, 8,
+			target{`sum(rate(ai_agent_stream_underflow_events_total{` + sel + `}[$__rate_interval])) * 60`, "underflows"},
+			target{`sum(rate(ai_agent_streaming_fallbacks_total{` + sel + `}[$__rate_interval])) * 60`, "streaming fallbacks"}),
+		panel("timeseries", "Stream first frame latency", "s", 12, 20, 12, 8,
+			quantiles("ai_agent_stream_first_frame_seconds")...),
+
+		panel("timeseries", "Call duration", "s", 0, 28, 12, 8,
+			quantiles("ai_agent_call_duration_seconds")...),
+		panel("timeseries", "Stream end reasons (per hour)", "short", 12, 28, 12, 8,
+			target{`sum by (reason) (increase(ai_agent_stream_end_reason_total{` + sel + `}[1h]))`, "{{reason}}"}),
+	}
+
+	return map[string]any{
+		"uid":           DashboardUID,
+		"title":         "Asterisk AI Voice Agent",
+		"tags":          []string{"asterisk", "ai-voice-agent"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"refresh":       "1m",
+		"time":          map[string]string{"from": "now-24h", "to": "now"},
+		"templating": map[string]any{
+			"list": []any{map[string]any{
+				"name":       "instance",
+				"label":      "Instance",
+				"type":       "query",
+				"datasource": ds,
+				"query":      "label_values(ai_agent_streaming_active, instance)",
+				"refresh":    2,
+				"includeAll": true,
+				"multi":      true,
+				"allValue":   ".*",
+				"current":    map[string]any{"text": "All", "value": "$__all"},
+			}},
+		},
+		"panels": panels,
+	}
+}
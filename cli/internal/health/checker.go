@@ -25,6 +25,7 @@ type Check struct {
 }
 
 type HealthResult struct {
+	SchemaVersion int       `json:"schema_version"`
 	Timestamp     time.Time `json:"timestamp"`
 	Checks        []Check   `json:"checks"`
 	PassCount     int       `json:"pass_count"`
@@ -2,12 +2,17 @@ package health
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hostinfo"
 	"gopkg.in/yaml.v3"
 )
 
@@ -80,7 +85,7 @@ func (c *Checker) checkDocker() Check {
 
 func (c *Checker) checkContainers() Check {
 	// Check if ai_engine container is running (note: underscore not hyphen)
-	cmd := exec.Command("docker", "ps", "--format", "{{.Names}}\t{{.Status}}", "--filter", "name=ai_engine")
+	cmd := exec.Command("docker", "ps", "--format", "{{.Names}}\t{{.Status}}", "--filter", "name="+deployment.EngineContainer())
 	output, err := cmd.Output()
 	if err != nil {
 		return Check{
@@ -221,11 +226,12 @@ func (c *Checker) checkAsteriskARI() Check {
 	}
 
 	// Try to connect to ARI HTTP endpoint
-	cmd := exec.Command("curl", "-s", "-o", "/dev/null", "-w", "%{http_code}",
-		"-u", fmt.Sprintf("%s:%s", ariUsername, ariPassword),
-		fmt.Sprintf("http://%s:8088/ari/asterisk/info", ariHost))
-
-	output, err := cmd.Output()
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/ari/asterisk/info", net.JoinHostPort(ariHost, "8088")), nil)
+	if err != nil {
+		return Check{Name: "Asterisk ARI", Status: StatusWarn, Message: "Invalid ARI host", Details: err.Error()}
+	}
+	req.SetBasicAuth(ariUsername, ariPassword)
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
 	if err != nil {
 		return Check{
 			Name:        "Asterisk ARI",
@@ -236,8 +242,9 @@ func (c *Checker) checkAsteriskARI() Check {
 		}
 	}
 
-	httpCode := strings.TrimSpace(string(output))
-	if httpCode == "200" {
+	resp.Body.Close()
+	httpCode := strconv.Itoa(resp.StatusCode)
+	if resp.StatusCode == http.StatusOK {
 		return Check{
 			Name:    "Asterisk ARI",
 			Status:  StatusPass,
@@ -255,8 +262,7 @@ func (c *Checker) checkAsteriskARI() Check {
 
 func (c *Checker) checkAudioSocket() Check {
 	// Check if port 8090 is listening (typical AudioSocket port)
-	cmd := exec.Command("sh", "-c", "netstat -tuln 2>/dev/null | grep :8090 || ss -tuln 2>/dev/null | grep :8090")
-	if err := cmd.Run(); err != nil {
+	if listening, err := hostinfo.Current().Listening(8090); err != nil || !listening {
 		return Check{
 			Name:    "AudioSocket",
 			Status:  StatusWarn,
@@ -443,7 +449,7 @@ func (c *Checker) checkConfiguration() Check {
 }
 
 func (c *Checker) checkProviderKeys() Check {
-	// Check for common provider API keys in environment or .env file
+	// Check for common provider API keys in environment, secrets store or .env file
 	keys := map[string]string{
 		"OPENAI_API_KEY":    "OpenAI",
 		"DEEPGRAM_API_KEY":  "Deepgram",
@@ -454,7 +460,6 @@ func (c *Checker) checkProviderKeys() Check {
 	missing := []string{}
 
 	for env, name := range keys {
-		// Check both OS env and .env file
 		if val := GetEnv(env, c.envMap); val != "" {
 			found = append(found, name)
 		} else {
@@ -467,7 +472,7 @@ func (c *Checker) checkProviderKeys() Check {
 			Name:        "Provider Keys",
 			Status:      StatusFail,
 			Message:     "No provider API keys found",
-			Remediation: "Set API keys in .env file",
+			Remediation: "Set API keys in .env, or keep them in the OS keyring: agent secrets set OPENAI_API_KEY",
 		}
 	}
 
@@ -486,7 +491,7 @@ func (c *Checker) checkProviderKeys() Check {
 
 func (c *Checker) checkAudioPipeline() Check {
 	// Check if we can find recent audio pipeline logs (note: ai_engine with underscore)
-	cmd := exec.Command("docker", "logs", "--tail", "100", "ai_engine")
+	cmd := exec.Command("docker", "logs", "--tail", "100", deployment.EngineContainer())
 	output, err := cmd.Output()
 
 	if err != nil {
@@ -604,7 +609,7 @@ func (c *Checker) checkMediaDirectory() Check {
 
 func (c *Checker) checkLogs() Check {
 	// Check for recent errors in ai_engine logs (note: underscore)
-	cmd := exec.Command("docker", "logs", "--tail", "100", "ai_engine")
+	cmd := exec.Command("docker", "logs", "--tail", "100", deployment.EngineContainer())
 	output, err := cmd.Output()
 
 	if err != nil {
@@ -650,7 +655,7 @@ func (c *Checker) checkLogs() Check {
 
 func (c *Checker) checkRecentCalls() Check {
 	// Try to find recent call info from logs (note: ai_engine with underscore)
-	cmd := exec.Command("docker", "logs", "--tail", "500", "ai_engine")
+	cmd := exec.Command("docker", "logs", "--tail", "500", deployment.EngineContainer())
 	output, err := cmd.Output()
 
 	if err != nil {
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"os"
 	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
 )
 
 // LoadEnvFile loads environment variables from .env file
@@ -38,13 +40,9 @@ func LoadEnvFile(path string) (map[string]string, error) {
 	return envMap, scanner.Err()
 }
 
-// GetEnv gets environment variable with fallback to .env file
+// GetEnv gets environment variable with fallback to the secrets store and
+// the .env file
 func GetEnv(key string, envMap map[string]string) string {
-	// First check OS environment
-	if val := os.Getenv(key); val != "" {
-		return val
-	}
-	
-	// Fallback to .env map
-	return envMap[key]
+	val, _ := secrets.Lookup(key, envMap)
+	return val
 }
@@ -6,9 +6,11 @@ import (
 	"io"
 
 	"github.com/fatih/color"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
 )
 
 func (r *HealthResult) OutputJSON(w io.Writer) error {
+	r.SchemaVersion = contract.SchemaVersion
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(r)
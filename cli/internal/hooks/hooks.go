@@ -0,0 +1,167 @@
+// Package hooks runs the operator's hook scripts: executables in .agent/hooks
+// named after the point they hook (pre-update, post-update, post-rca,
+// on-critical-call), called with an Event as JSON on stdin. They connect the
+// CLI to a site's ticketing and inventory systems without wrapping it in
+// scripts.
+//
+// A hook's output goes to stderr, so the command's own output stays
+// parseable. Only pre-update can stop anything: a non-zero exit aborts the
+// update. Other hooks that fail are reported and ignored.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+)
+
+// Dir holds the hook scripts, relative to the project directory.
+var Dir = filepath.Join(".agent", "hooks")
+
+// Hook points.
+const (
+	PreUpdate      = "pre-update"
+	PostUpdate     = "post-update"
+	PostRCA        = "post-rca"
+	OnCriticalCall = "on-critical-call"
+)
+
+// Names are the hook points, in the order a reader meets them.
+var Names = []string{PreUpdate, PostUpdate, PostRCA, OnCriticalCall}
+
+// Timeout bounds a hook; one that hangs must not hang an update.
+var Timeout = 2 * time.Minute
+
+// Event is what a hook reads from stdin. Update is set for the update hooks
+// and Call for the call hooks.
+type Event struct {
+	SchemaVersion int       `json:"schema_version"`
+	Hook          string    `json:"hook"`
+	Time          time.Time `json:"time"`
+	CLIVersion    string    `json:"cli_version"`
+	Host          string    `json:"host,omitempty"`
+	Update        *Update   `json:"update,omitempty"`
+	Call          *Call     `json:"call,omitempty"`
+}
+
+// Update describes an agent update run. Result, Error and the check counts
+// are only set for post-update.
+type Update struct {
+	Ref          string   `json:"ref"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	ChangedFiles []string `json:"changed_files"`
+	Rebuild      []string `json:"rebuild"`
+	Restart      []string `json:"restart"`
+	// Result is "ok", "warn" (the post-update check warned) or "fail".
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+	WarnCount int    `json:"warn_count,omitempty"`
+	FailCount int    `json:"fail_count,omitempty"`
+}
+
+// Call describes an analyzed call. Report is the call's agent rca --json
+// report.
+type Call struct {
+	CallID string `json:"call_id"`
+	// Result is "PASS", "WARN" or "FAIL", as agent rca prints it.
+	Result string `json:"result"`
+	Report any    `json:"report"`
+}
+
+// Disabled reports whether AAVA_HOOKS=off turns hooks off for this run.
+func Disabled() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("AAVA_HOOKS")), "off")
+}
+
+// Find returns the hook script for name, if one is installed. On Windows the
+// script may have an .exe, .bat or .cmd extension.
+func Find(name string) (string, bool) {
+	candidates := []string{filepath.Join(Dir, name)}
+	if runtime.GOOS == "windows" {
+		candidates = nil
+		for _, ext := range []string{".exe", ".bat", ".cmd"} {
+			candidates = append(candidates, filepath.Join(Dir, name+ext))
+		}
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Usable reports why an installed hook script cannot be run: it is not
+// executable, or other users can write to it.
+func Usable(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not executable; run chmod +x on it", path)
+	}
+	if info.Mode().Perm()&0o022 != 0 {
+		return fmt.Errorf("%s is writable by other users and is not run; run chmod go-w on it", path)
+	}
+	return nil
+}
+
+// Run runs the hook for e.Hook, if installed, and reports whether it ran.
+// The event's schema version, time and host are filled in here.
+func Run(e Event) (bool, error) {
+	if Disabled() {
+		return false, nil
+	}
+	path, ok := Find(e.Hook)
+	if !ok {
+		return false, nil
+	}
+	if err := Usable(path); err != nil {
+		return false, err
+	}
+	e.SchemaVersion = contract.SchemaVersion
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.Host == "" {
+		e.Host, _ = os.Hostname()
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	cmd.Env = append(os.Environ(), "AAVA_HOOK="+e.Hook)
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return true, fmt.Errorf("%s hook timed out after %s", e.Hook, Timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return true, fmt.Errorf("%s hook exited with status %d", e.Hook, exitErr.ExitCode())
+	}
+	if err != nil {
+		return true, fmt.Errorf("%s hook: %w", e.Hook, err)
+	}
+	return true, nil
+}
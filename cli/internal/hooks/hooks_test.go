@@ -0,0 +1,74 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// install writes a shell hook into a temporary Dir.
+func install(t *testing.T, name, script string, mode os.FileMode) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks")
+	}
+	old := Dir
+	Dir = t.TempDir()
+	t.Cleanup(func() { Dir = old })
+	path := filepath.Join(Dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	path := install(t, PostRCA, `cat > "$(dirname "$0")/event.json"; echo "$AAVA_HOOK"`, 0o755)
+	ran, err := Run(Event{Hook: PostRCA, CLIVersion: "1.0", Call: &Call{CallID: "1761518880.2191", Result: "FAIL", Report: map[string]int{"schema_version": 1}}})
+	if !ran || err != nil {
+		t.Fatalf("ran = %v, err = %v", ran, err)
+	}
+	raw, err := os.ReadFile(filepath.Join(filepath.Dir(path), "event.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e Event
+	if err := json.Unmarshal(raw, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Hook != PostRCA || e.SchemaVersion != 1 || e.Time.IsZero() || e.Call == nil || e.Call.CallID != "1761518880.2191" || e.Update != nil {
+		t.Errorf("event = %s", raw)
+	}
+
+	if ran, err := Run(Event{Hook: PreUpdate}); ran || err != nil {
+		t.Errorf("a hook that is not installed: ran = %v, err = %v", ran, err)
+	}
+	t.Setenv("AAVA_HOOKS", "off")
+	if ran, _ := Run(Event{Hook: PostRCA}); ran {
+		t.Error("AAVA_HOOKS=off should not run hooks")
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	install(t, PreUpdate, "exit 3\n", 0o755)
+	if ran, err := Run(Event{Hook: PreUpdate}); !ran || err == nil || !strings.Contains(err.Error(), "status 3") {
+		t.Errorf("ran = %v, err = %v, want exit status 3", ran, err)
+	}
+
+	path := install(t, PostUpdate, "exit 0\n", 0o644)
+	if ran, err := Run(Event{Hook: PostUpdate}); ran || err == nil || !strings.Contains(err.Error(), "not executable") {
+		t.Errorf("ran = %v, err = %v", ran, err)
+	}
+	if err := os.Chmod(path, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if ran, err := Run(Event{Hook: PostUpdate}); ran || err == nil || !strings.Contains(err.Error(), "writable by other users") {
+		t.Errorf("ran = %v, err = %v", ran, err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package hostinfo answers questions about the machine the stack runs on: its
+// name and kernel, whether a port accepts connections and which process holds
+// it, which interface routes an address, and which processes run there. The answers come from Go and the
+// Docker API rather than uname, ss, ip or ps, so the CLI works from macOS and
+// Windows against a remote Linux Docker host as well as on that host itself.
+package hostinfo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// ErrUnsupported is returned for a question this host cannot answer from here.
+var ErrUnsupported = errors.New("not supported on this host")
+
+// Info describes a machine.
+type Info struct {
+	Hostname string
+	Kernel   string // kernel release, e.g. 6.8.0-45-generic
+	OS       string // e.g. linux/amd64, or the daemon's "Ubuntu 24.04.1 LTS"
+}
+
+// Host is the machine the stack runs on.
+type Host interface {
+	// Describe returns its name, kernel and operating system.
+	Describe() (Info, error)
+	// Listening reports whether port accepts TCP connections there.
+	Listening(port int) (bool, error)
+	// RouteInterface returns the network interface that routes ip.
+	RouteInterface(ip string) (string, error)
+	// Processes returns the command lines of the processes named name.
+	Processes(name string) ([]string, error)
+}
+
+// Current is the Docker host of the deployment: this machine, or the remote
+// daemon's when DOCKER_HOST or the descriptor points elsewhere.
+func Current() Host {
+	if d := deployment.Current(); d.Remote() {
+		return dockerHost{endpoint: d.DockerHost}
+	}
+	return Local()
+}
+
+// Local is the machine the CLI runs on.
+func Local() Host { return local{} }
+
+type local struct{}
+
+func (local) Describe() (Info, error) {
+	host, err := os.Hostname()
+	return Info{Hostname: host, Kernel: kernelRelease(), OS: runtime.GOOS + "/" + runtime.GOARCH}, err
+}
+
+// Listening looks for a listening socket in /proc/net first, so a bind to
+// another address than loopback counts, and dials 127.0.0.1 otherwise.
+func (local) Listening(port int) (bool, error) {
+	if socks, err := sockets(); err == nil {
+		for _, s := range socks {
+			if s.Proto == "tcp" && s.Port == port {
+				return true, nil
+			}
+		}
+	}
+	return dialable(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+}
+
+// RouteInterface asks the kernel for the source address it would use to
+// reach ip (connecting a UDP socket sends nothing) and returns the interface
+// holding that address.
+func (local) RouteInterface(ip string) (string, error) {
+	conn, err := net.Dial("udp", net.JoinHostPort(ip, "9"))
+	if err != nil {
+		return "", fmt.Errorf("no route to %s: %w", ip, err)
+	}
+	src := conn.LocalAddr().(*net.UDPAddr).IP
+	conn.Close()
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	if name, ok := interfaceWith(ifaces, src); ok {
+		return name, nil
+	}
+	return "", fmt.Errorf("no interface holds %s, the source address for %s", src, ip)
+}
+
+// interfaceWith returns the interface among ifaces that has address ip.
+func interfaceWith(ifaces []net.Interface, ip net.IP) (string, bool) {
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if n, ok := a.(*net.IPNet); ok && n.IP.Equal(ip) {
+				return iface.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (local) Processes(name string) ([]string, error) { return processes(name) }
+
+// dockerHost is a remote daemon's machine, seen through the Docker API and
+// the network.
+type dockerHost struct{ endpoint string }
+
+func (h dockerHost) Describe() (Info, error) {
+	info, err := dockerapi.Info()
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Hostname: info.Name, Kernel: info.KernelVersion, OS: info.OperatingSystem}, nil
+}
+
+// Listening dials the port on the Docker host's address, so a firewall
+// between here and there reads as not listening.
+func (h dockerHost) Listening(port int) (bool, error) {
+	host := h.address()
+	if host == "" {
+		return false, fmt.Errorf("cannot tell the address of %s: %w", h.endpoint, ErrUnsupported)
+	}
+	return dialable(net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+func (h dockerHost) address() string {
+	u, err := url.Parse(strings.TrimSpace(h.endpoint))
+	if err != nil || (u.Scheme != "ssh" && u.Scheme != "tcp") {
+		return ""
+	}
+	return u.Hostname()
+}
+
+func (h dockerHost) RouteInterface(string) (string, error) {
+	return "", fmt.Errorf("route lookup on %s: %w", h.endpoint, ErrUnsupported)
+}
+
+func (h dockerHost) Processes(string) ([]string, error) {
+	return nil, fmt.Errorf("process list on %s: %w", h.endpoint, ErrUnsupported)
+}
+
+// dialable reports whether addr accepts a TCP connection. A refused
+// connection is an answer; a timeout or unreachable network is an error.
+func dialable(addr string) (bool, error) {
+	conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+	if err == nil {
+		conn.Close()
+		return true, nil
+	}
+	var op *net.OpError
+	if errors.As(err, &op) && op.Op == "dial" && !op.Timeout() && isRefused(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// cmdline joins a NUL-separated /proc/<pid>/cmdline into one line.
+func cmdline(raw []byte) string {
+	return strings.Join(strings.Split(strings.TrimRight(string(raw), "\x00"), "\x00"), " ")
+}
+
+func isRefused(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "refused")
+}
@@ -0,0 +1,76 @@
+package hostinfo
+
+import (
+	"net"
+	"testing"
+)
+
+func TestLocalListening(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback listener: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	if ok, err := Local().Listening(port); !ok || err != nil {
+		t.Fatalf("open port: got %v, %v", ok, err)
+	}
+	l.Close()
+	if ok, err := Local().Listening(port); ok || err != nil {
+		t.Fatalf("closed port: got %v, %v", ok, err)
+	}
+}
+
+func TestRouteInterfaceLoopback(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("no interfaces: %v", err)
+	}
+	want, ok := interfaceWith(ifaces, net.ParseIP("127.0.0.1"))
+	if !ok {
+		t.Skip("no interface holds 127.0.0.1")
+	}
+	got, err := Local().RouteInterface("127.0.0.1")
+	if err != nil || got != want {
+		t.Fatalf("RouteInterface(127.0.0.1) = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestDockerHostAddress(t *testing.T) {
+	cases := map[string]string{
+		"ssh://ops@pbx-west:2222": "pbx-west",
+		"tcp://10.0.0.5:2376":     "10.0.0.5",
+		"unix:///run/docker.sock": "",
+		"npipe:////./pipe/docker": "",
+		"ssh://ops@[2001:db8::1]": "2001:db8::1",
+	}
+	for endpoint, want := range cases {
+		if got := (dockerHost{endpoint: endpoint}).address(); got != want {
+			t.Errorf("address(%q) = %q, want %q", endpoint, got, want)
+		}
+	}
+	if _, err := (dockerHost{endpoint: "unix:///run/docker.sock"}).Listening(5060); err == nil {
+		t.Error("Listening without an address: want an error")
+	}
+}
+
+func TestCmdline(t *testing.T) {
+	raw := []byte("/usr/bin/docker-proxy\x00-proto\x00udp\x00-host-port\x0018080\x00")
+	if got := cmdline(raw); got != "/usr/bin/docker-proxy -proto udp -host-port 18080" {
+		t.Fatalf("cmdline = %q", got)
+	}
+}
+
+func TestParseProcNetLine(t *testing.T) {
+	line := "   0: 0100007F:1F9A 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 41234 1 0000000000000000 100 0 0 10 0"
+	s, ok := parseProcNetLine(line, "tcp", procTCPListen)
+	if !ok || s.Addr != "127.0.0.1" || s.Port != 8090 || s.Inode != "41234" {
+		t.Fatalf("listening row = %+v, %v", s, ok)
+	}
+	if _, ok := parseProcNetLine(line, "udp", procUDPBound); ok {
+		t.Fatalf("row in another state kept")
+	}
+	v6 := "   1: 00000000000000000000000001000000:0FC8 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 5150 2"
+	if s, ok := parseProcNetLine(v6, "udp", procUDPBound); !ok || s.Addr != "::1" || s.Port != 4040 {
+		t.Fatalf("ipv6 row = %+v, %v", s, ok)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package hostinfo
+
+import "golang.org/x/sys/unix"
+
+func kernelRelease() string {
+	var u unix.Utsname
+	if err := unix.Uname(&u); err != nil {
+		return ""
+	}
+	return unix.ByteSliceToString(u.Release[:])
+}
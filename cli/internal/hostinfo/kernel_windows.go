@@ -0,0 +1,14 @@
+//go:build windows
+
+package hostinfo
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+func kernelRelease() string {
+	v := windows.RtlGetVersion()
+	return fmt.Sprintf("%d.%d.%d", v.MajorVersion, v.MinorVersion, v.BuildNumber)
+}
@@ -0,0 +1,32 @@
+package hostinfo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// processes walks /proc for processes whose comm is name. Processes that
+// exit or hide their cmdline during the walk are skipped.
+func processes(name string) ([]string, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, e := range entries {
+		if !e.IsDir() || strings.TrimLeft(e.Name(), "0123456789") != "" {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join("/proc", e.Name(), "comm"))
+		if err != nil || strings.TrimSpace(string(comm)) != name {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join("/proc", e.Name(), "cmdline"))
+		if err != nil || len(raw) == 0 {
+			continue
+		}
+		out = append(out, cmdline(raw))
+	}
+	return out, nil
+}
@@ -0,0 +1,5 @@
+//go:build !linux
+
+package hostinfo
+
+func processes(string) ([]string, error) { return nil, ErrUnsupported }
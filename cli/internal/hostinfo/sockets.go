@@ -0,0 +1,123 @@
+package hostinfo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Socket is a listening TCP or bound UDP socket on this machine.
+type Socket struct {
+	Proto string // "tcp" or "udp"
+	Addr  string
+	Port  int
+	Inode string
+}
+
+// Owner is the process holding a socket.
+type Owner struct {
+	PID  int
+	Comm string
+}
+
+// Socket states in /proc/net/{tcp,udp}[6].
+const (
+	procTCPListen = "0A"
+	procUDPBound  = "07"
+)
+
+// Sockets lists this machine's listening TCP and bound UDP sockets from
+// /proc/net; other systems return ErrUnsupported.
+func Sockets() ([]Socket, error) { return sockets() }
+
+// SocketOwners maps socket inodes to the processes holding them, from
+// /proc/<pid>/fd. Processes the CLI may not inspect are left out, so without
+// root most sockets of other users have no owner.
+func SocketOwners() map[string]Owner { return socketOwners() }
+
+// BindInUse reports whether port is taken, by trying to bind it. It is the
+// fallback where Sockets is unsupported.
+func BindInUse(proto string, port int) bool {
+	addr := fmt.Sprintf(":%d", port)
+	if proto == "udp" {
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return true
+		}
+		pc.Close()
+		return false
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return true
+	}
+	l.Close()
+	return false
+}
+
+// parseProcNet parses one /proc/net/{tcp,udp}[6] table and keeps rows in the wanted state.
+func parseProcNet(r io.Reader, proto string, wantState string) []Socket {
+	var out []Socket
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		if first {
+			first = false
+			continue // header
+		}
+		if s, ok := parseProcNetLine(scanner.Text(), proto, wantState); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// parseProcNetLine parses a single row such as:
+//
+//	0: 00000000:1F9A 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1 ...
+func parseProcNetLine(line string, proto string, wantState string) (Socket, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 10 {
+		return Socket{}, false
+	}
+	if !strings.EqualFold(fields[3], wantState) {
+		return Socket{}, false
+	}
+	host, port, ok := decodeProcAddr(fields[1])
+	if !ok {
+		return Socket{}, false
+	}
+	return Socket{Proto: proto, Addr: host, Port: port, Inode: fields[9]}, true
+}
+
+// decodeProcAddr decodes the kernel's hex "ADDR:PORT" notation. Addresses are
+// stored as little-endian 32-bit words.
+func decodeProcAddr(s string) (string, int, bool) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, false
+	}
+	hexAddr := parts[0]
+	if len(hexAddr) != 8 && len(hexAddr) != 32 {
+		return "", 0, false
+	}
+	ip := make(net.IP, len(hexAddr)/2)
+	for word := 0; word < len(hexAddr)/8; word++ {
+		for b := 0; b < 4; b++ {
+			off := word*8 + (3-b)*2
+			v, err := strconv.ParseUint(hexAddr[off:off+2], 16, 8)
+			if err != nil {
+				return "", 0, false
+			}
+			ip[word*4+b] = byte(v)
+		}
+	}
+	return ip.String(), int(port), true
+}
@@ -0,0 +1,69 @@
+package hostinfo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func sockets() ([]Socket, error) {
+	sources := []struct {
+		path  string
+		proto string
+		state string
+	}{
+		{"/proc/net/tcp", "tcp", procTCPListen},
+		{"/proc/net/tcp6", "tcp", procTCPListen},
+		{"/proc/net/udp", "udp", procUDPBound},
+		{"/proc/net/udp6", "udp", procUDPBound},
+	}
+	var out []Socket
+	read := 0
+	for _, src := range sources {
+		f, err := os.Open(src.path)
+		if err != nil {
+			continue
+		}
+		read++
+		out = append(out, parseProcNet(f, src.proto, src.state)...)
+		f.Close()
+	}
+	if read == 0 {
+		return nil, errors.New("no /proc/net socket tables readable")
+	}
+	return out, nil
+}
+
+func socketOwners() map[string]Owner {
+	owners := map[string]Owner{}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", p.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		comm := ""
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			if comm == "" {
+				raw, _ := os.ReadFile(filepath.Join("/proc", p.Name(), "comm"))
+				comm = strings.TrimSpace(string(raw))
+			}
+			owners[strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")] = Owner{PID: pid, Comm: comm}
+		}
+	}
+	return owners
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package hostinfo
+
+func sockets() ([]Socket, error) { return nil, ErrUnsupported }
+
+func socketOwners() map[string]Owner { return nil }
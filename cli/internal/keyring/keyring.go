@@ -0,0 +1,109 @@
+// Package keyring keeps secrets in the OS keyring: the Secret Service (GNOME
+// Keyring, KWallet) through secret-tool on Linux, and the login keychain
+// through security on macOS. Every entry is under the service "aava-agent"
+// with the secret's name as its account. There is no keyring on Windows.
+package keyring
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Service is the keyring service every entry is stored under.
+const Service = "aava-agent"
+
+const timeout = 5 * time.Second
+
+// ErrUnavailable is returned when this host has no keyring the CLI can use.
+var ErrUnavailable = errors.New("no OS keyring: install secret-tool (libsecret-tools) on Linux; Windows is not supported")
+
+// Available reports whether a keyring tool is installed.
+func Available() error {
+	switch runtime.GOOS {
+	case "windows":
+		return ErrUnavailable
+	case "darwin":
+		if _, err := exec.LookPath("security"); err != nil {
+			return ErrUnavailable
+		}
+	default:
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return ErrUnavailable
+		}
+	}
+	return nil
+}
+
+// Get returns the secret stored for account, or "" when there is none or no
+// keyring to ask.
+func Get(account string) (string, error) {
+	if Available() != nil {
+		return "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", Service, "-a", account, "-w")
+	} else {
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", Service, "account", account)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		// Both tools exit non-zero for a missing entry.
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// Set stores value for account, replacing what was stored before. label
+// describes the entry in keyring managers.
+func Set(account, value, label string) error {
+	if err := Available(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		// security takes the password as an argument; -U updates an existing entry.
+		cmd = exec.CommandContext(ctx, "security", "add-generic-password", "-U", "-s", Service, "-a", account, "-l", label, "-w", value)
+	} else {
+		cmd = exec.CommandContext(ctx, "secret-tool", "store", "--label="+label, "service", Service, "account", account)
+		cmd.Stdin = strings.NewReader(value)
+	}
+	return run(cmd)
+}
+
+// Delete removes the entry for account; a missing entry is not an error.
+func Delete(account string) error {
+	if err := Available(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if runtime.GOOS == "darwin" {
+		if v, _ := Get(account); v == "" {
+			return nil
+		}
+		return run(exec.CommandContext(ctx, "security", "delete-generic-password", "-s", Service, "-a", account))
+	}
+	return run(exec.CommandContext(ctx, "secret-tool", "clear", "service", Service, "account", account))
+}
+
+func run(cmd *exec.Cmd) error {
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package knowledge
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// maxPatternText caps how much of a log message becomes a pattern; the start
+// of a message names the problem, the rest is mostly call-specific detail.
+const maxPatternText = 120
+
+// variableText is what differs between two calls with the same problem:
+// redaction placeholders, UUIDs, numbers (IDs, times, addresses, ports) and
+// the quoting a JSON or console log adds around them.
+var variableText = regexp.MustCompile(`\[(PHONE|EMAIL|CARD|NAME)\]|[0-9a-fA-F]{8}(-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}|\d+([.:/-]\d+)*|[\\"'` + "`" + `]+|\s+`)
+
+// Pattern turns a log message into a fingerprint pattern: the text is kept
+// literally and its variable parts are generalized, so the pattern matches
+// the same message on any call and carries nothing call-specific. Redact the
+// text before; placeholders match anything.
+func Pattern(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) > maxPatternText {
+		cut := strings.LastIndexFunc(text[:maxPatternText], unicode.IsSpace)
+		if cut <= 0 {
+			cut = maxPatternText
+		}
+		text = strings.TrimSpace(text[:cut])
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range variableText.FindAllStringIndex(text, -1) {
+		b.WriteString(regexp.QuoteMeta(text[last:loc[0]]))
+		tok := text[loc[0]:loc[1]]
+		switch {
+		case tok[0] == '[':
+			b.WriteString(".+")
+		case strings.TrimSpace(tok) == "":
+			b.WriteString(`\s+`)
+		case strings.ContainsAny(tok[:1], "\\\"'`"):
+			b.WriteString(`\W*`)
+		case len(tok) == 36 && strings.Count(tok, "-") == 4:
+			b.WriteString(`[0-9a-f-]{36}`)
+		default:
+			b.WriteString(`\d+([.:/-]\d+)*`)
+		}
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(text[last:]))
+	return b.String()
+}
+
+// Slug makes an issue ID from a title.
+func Slug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
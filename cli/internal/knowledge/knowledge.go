@@ -0,0 +1,373 @@
+// Package knowledge is the troubleshooting knowledge base: fingerprints of
+// problems seen before (log patterns and metric conditions) with their cause
+// and fix. agent rca applies it before asking an LLM, so a repeat of a known
+// problem is diagnosed offline and the same way every time.
+package knowledge
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed issues.yaml
+var builtin []byte
+
+// SchemaVersion is the knowledge file format this CLI reads.
+const SchemaVersion = 1
+
+// RepoFile is where a checkout keeps fingerprints added or changed after this
+// CLI was built; agent update brings them in with the rest of the repo.
+const RepoFile = "config/rca-knowledge.yaml"
+
+// PackDir holds the fingerprint packs imported with agent kb import, one
+// knowledge file each.
+var PackDir = filepath.Join(".agent", "knowledge")
+
+// BuiltinSource is the Source of the issues compiled into the CLI.
+const BuiltinSource = "built-in"
+
+// Issue is one known problem.
+type Issue struct {
+	ID    string   `yaml:"id"`
+	Title string   `yaml:"title,omitempty"`
+	Match Match    `yaml:"match,omitempty"`
+	Cause string   `yaml:"cause,omitempty"`
+	Fix   []string `yaml:"fix,omitempty"`
+	Docs  string   `yaml:"docs,omitempty"`
+	// Disabled drops an earlier issue of the same ID.
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Source is the file the issue was loaded from, or BuiltinSource.
+	Source string `yaml:"-"`
+}
+
+// Match is what a call must show for an issue to apply. Every condition given
+// must hold, and at least one log pattern or metric condition is required.
+type Match struct {
+	// Provider and Transport limit the issue to calls on that provider (name
+	// prefix, e.g. "openai" for openai_realtime) or audio transport.
+	Provider  string `yaml:"provider,omitempty"`
+	Transport string `yaml:"transport,omitempty"`
+	// Logs are case-insensitive regular expressions; each must match a line.
+	Logs []string `yaml:"logs,omitempty"`
+	// Metrics are "<name> <op> <value>" conditions over the metric names of
+	// agent rca --json (e.g. "GateClosures > 20"); abs(<name>) compares the
+	// magnitude. Ops are == != < <= > >=.
+	Metrics []string `yaml:"metrics,omitempty"`
+}
+
+// Facts is what one call offers the fingerprints.
+type Facts struct {
+	Log       string
+	Metrics   map[string]string // flattened metric name to value
+	Provider  string
+	Transport string
+}
+
+// Hit is an issue that matched a call, with the evidence it matched on.
+type Hit struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Cause    string   `json:"cause"`
+	Fix      []string `json:"fix,omitempty"`
+	Docs     string   `json:"docs,omitempty"`
+	Source   string   `json:"source"`
+	Evidence []string `json:"evidence"`
+}
+
+// Base is a loaded set of issues.
+type Base struct {
+	Issues []Issue
+
+	compiled map[string]*compiledIssue
+}
+
+type compiledIssue struct {
+	logs    []*regexp.Regexp
+	metrics []condition
+}
+
+type condition struct {
+	name  string
+	abs   bool
+	op    string
+	value string
+}
+
+type file struct {
+	Version int     `yaml:"version"`
+	Issues  []Issue `yaml:"issues"`
+}
+
+// Builtin is the knowledge base compiled into the CLI.
+func Builtin() *Base {
+	b, err := Parse(builtin, BuiltinSource)
+	if err != nil {
+		panic(fmt.Sprintf("built-in knowledge base: %v", err)) // compiled in; TestBuiltin keeps it valid
+	}
+	return b
+}
+
+// Load is the built-in knowledge base with the repo's fingerprints on top
+// (AAVA_KNOWLEDGE_FILE, or RepoFile in the checkout), then the imported packs
+// in PackDir by file name. An issue replaces an earlier one with its ID. Files
+// that do not load are reported together; the rest of the base is usable.
+func Load() (*Base, error) {
+	b := Builtin()
+	var files []string
+	if path := repoFile(); path != "" {
+		files = append(files, path)
+	}
+	packs, err := Packs()
+	files = append(files, packs...)
+	errs := []error{err}
+	for _, path := range files {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		extra, err := Parse(raw, path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		b.merge(extra)
+	}
+	return b, errors.Join(errs...)
+}
+
+// Packs lists the imported pack files, sorted.
+func Packs() ([]string, error) {
+	entries, err := os.ReadDir(PackDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var packs []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".yaml") {
+			packs = append(packs, filepath.Join(PackDir, e.Name()))
+		}
+	}
+	sort.Strings(packs)
+	return packs, nil
+}
+
+func repoFile() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_KNOWLEDGE_FILE")); p != "" {
+		return p
+	}
+	for _, p := range []string{RepoFile, "/app/project/" + RepoFile, "../" + RepoFile} {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// Parse reads a knowledge file; source is recorded on its issues.
+func Parse(raw []byte, source string) (*Base, error) {
+	var f file
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+	if f.Version > SchemaVersion {
+		return nil, fmt.Errorf("version %d is newer than this CLI reads (%d); run agent self-update", f.Version, SchemaVersion)
+	}
+	b := &Base{compiled: map[string]*compiledIssue{}}
+	for _, is := range f.Issues {
+		if is.ID == "" {
+			return nil, fmt.Errorf("issue %q has no id", is.Title)
+		}
+		if _, dup := b.compiled[is.ID]; dup {
+			return nil, fmt.Errorf("issue %s is listed twice", is.ID)
+		}
+		c, err := compile(is)
+		if err != nil {
+			return nil, fmt.Errorf("issue %s: %w", is.ID, err)
+		}
+		is.Source = source
+		b.compiled[is.ID] = c
+		b.Issues = append(b.Issues, is)
+	}
+	return b, nil
+}
+
+func compile(is Issue) (*compiledIssue, error) {
+	if is.Disabled {
+		return &compiledIssue{}, nil
+	}
+	if len(is.Match.Logs) == 0 && len(is.Match.Metrics) == 0 {
+		return nil, fmt.Errorf("needs a log pattern or metric condition")
+	}
+	c := &compiledIssue{}
+	for _, p := range is.Match.Logs {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, err
+		}
+		c.logs = append(c.logs, re)
+	}
+	for _, m := range is.Match.Metrics {
+		cond, err := parseCondition(m)
+		if err != nil {
+			return nil, err
+		}
+		c.metrics = append(c.metrics, cond)
+	}
+	return c, nil
+}
+
+func parseCondition(s string) (condition, error) {
+	f := strings.Fields(s)
+	if len(f) != 3 {
+		return condition{}, fmt.Errorf("metric condition %q is not <name> <op> <value>", s)
+	}
+	switch f[1] {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return condition{}, fmt.Errorf("metric condition %q: unknown op %s", s, f[1])
+	}
+	c := condition{name: f[0], op: f[1], value: f[2]}
+	if strings.HasPrefix(c.name, "abs(") && strings.HasSuffix(c.name, ")") {
+		c.name, c.abs = c.name[len("abs("):len(c.name)-1], true
+	}
+	return c, nil
+}
+
+// Encode writes issues as a knowledge file, the format Parse reads.
+func Encode(issues []Issue) ([]byte, error) {
+	return yaml.Marshal(file{Version: SchemaVersion, Issues: issues})
+}
+
+// Lookup returns the issue with the ID.
+func (b *Base) Lookup(id string) (Issue, bool) {
+	for _, is := range b.Issues {
+		if is.ID == id {
+			return is, true
+		}
+	}
+	return Issue{}, false
+}
+
+func (b *Base) merge(extra *Base) {
+	for _, is := range extra.Issues {
+		replaced := false
+		for i := range b.Issues {
+			if b.Issues[i].ID == is.ID {
+				b.Issues[i], replaced = is, true
+			}
+		}
+		if !replaced {
+			b.Issues = append(b.Issues, is)
+		}
+		b.compiled[is.ID] = extra.compiled[is.ID]
+	}
+}
+
+// Match returns the issues the call shows, in knowledge base order.
+func (b *Base) Match(f Facts) []Hit {
+	var lines []string
+	var hits []Hit
+	for _, is := range b.Issues {
+		c := b.compiled[is.ID]
+		if is.Disabled || c == nil {
+			continue
+		}
+		if is.Match.Provider != "" && !strings.HasPrefix(strings.ToLower(f.Provider), strings.ToLower(is.Match.Provider)) {
+			continue
+		}
+		if is.Match.Transport != "" && !strings.EqualFold(f.Transport, is.Match.Transport) {
+			continue
+		}
+		if len(c.logs) > 0 && lines == nil {
+			lines = strings.Split(f.Log, "\n")
+		}
+		evidence, ok := matchLogs(c.logs, lines)
+		for _, cond := range c.metrics {
+			if !ok {
+				break
+			}
+			var v string
+			if v, ok = cond.holds(f.Metrics); ok {
+				evidence = append(evidence, cond.name+"="+v)
+			}
+		}
+		if !ok {
+			continue
+		}
+		hits = append(hits, Hit{ID: is.ID, Title: is.Title, Cause: is.Cause, Fix: is.Fix, Docs: is.Docs, Source: is.Source, Evidence: evidence})
+	}
+	return hits
+}
+
+// matchLogs finds a line for every pattern; the first such line is the
+// evidence.
+func matchLogs(patterns []*regexp.Regexp, lines []string) ([]string, bool) {
+	var evidence []string
+	for _, re := range patterns {
+		found := ""
+		for _, l := range lines {
+			if re.MatchString(l) {
+				found = strings.TrimSpace(l)
+				break
+			}
+		}
+		if found == "" {
+			return nil, false
+		}
+		if len(found) > 200 {
+			found = found[:197] + "..."
+		}
+		evidence = append(evidence, found)
+	}
+	return evidence, true
+}
+
+// holds compares the call's value; a metric the call did not report never
+// holds.
+func (c condition) holds(metrics map[string]string) (string, bool) {
+	v, ok := metrics[c.name]
+	if !ok {
+		return "", false
+	}
+	have, err1 := strconv.ParseFloat(v, 64)
+	want, err2 := strconv.ParseFloat(c.value, 64)
+	if err1 != nil || err2 != nil {
+		switch c.op {
+		case "==":
+			return v, strings.EqualFold(v, c.value)
+		case "!=":
+			return v, !strings.EqualFold(v, c.value)
+		}
+		return v, false
+	}
+	if c.abs {
+		have = math.Abs(have)
+	}
+	switch c.op {
+	case "==":
+		return v, have == want
+	case "!=":
+		return v, have != want
+	case "<":
+		return v, have < want
+	case "<=":
+		return v, have <= want
+	case ">":
+		return v, have > want
+	}
+	return v, have >= want
+}
@@ -0,0 +1,129 @@
+package knowledge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	b := Builtin()
+	if len(b.Issues) == 0 {
+		t.Fatal("no built-in issues")
+	}
+	for _, is := range b.Issues {
+		if is.Title == "" || is.Cause == "" || len(is.Fix) == 0 {
+			t.Errorf("%s: title, cause and fix are required", is.ID)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	b := Builtin()
+	f := Facts{
+		Log:       "2026-01-31 INFO call started\n2026-01-31 ERROR OpenAI session error: invalid_request_error.missing_model\n",
+		Metrics:   map[string]string{"WorstDriftPct": "-24.5", "UnderflowCount": "0", "len(ProviderSegments)": "3", "GateClosures": "4"},
+		Provider:  "openai_realtime",
+		Transport: "audiosocket",
+	}
+	hits := b.Match(f)
+	if len(hits) != 2 || hits[0].ID != "openai-realtime-model-not-found" || hits[1].ID != "sample-rate-mismatch-drift" {
+		t.Fatalf("hits = %+v", hits)
+	}
+	if hits[0].Evidence[0] != "2026-01-31 ERROR OpenAI session error: invalid_request_error.missing_model" {
+		t.Errorf("log evidence = %q", hits[0].Evidence)
+	}
+	if hits[1].Evidence[0] != "WorstDriftPct=-24.5" {
+		t.Errorf("metric evidence = %q", hits[1].Evidence)
+	}
+
+	f.Provider = "deepgram"
+	f.Metrics["UnderflowCount"] = "12"
+	if hits := b.Match(f); len(hits) != 0 {
+		t.Errorf("provider and metric filters ignored: %+v", hits)
+	}
+}
+
+func TestLoadRepoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rca-knowledge.yaml")
+	t.Setenv("AAVA_KNOWLEDGE_FILE", path)
+	repo := `version: 1
+issues:
+  - id: gate-flutter
+    disabled: true
+  - id: tool-timeout
+    title: Tool call timed out
+    match:
+      logs: ['tool .* timed out']
+    cause: The tool's endpoint did not answer in time.
+    fix: [Raise the tool's timeout_ms]
+`
+	if err := os.WriteFile(path, []byte(repo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits := b.Match(Facts{Log: "WARNING tool transfer_call timed out after 5000ms", Metrics: map[string]string{"GateClosures": "80"}})
+	if len(hits) != 1 || hits[0].ID != "tool-timeout" || hits[0].Source != path {
+		t.Errorf("hits = %+v", hits)
+	}
+
+	for _, bad := range []string{"version: 2\n", "issues:\n  - id: x\n    title: x\n", "issues:\n  - id: x\n    match: {metrics: ['GateClosures ~ 3']}\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if b, err := Load(); err == nil || len(b.Issues) != len(Builtin().Issues) {
+			t.Errorf("%q: err=%v, want the built-in base and an error", bad, err)
+		}
+	}
+}
+
+func TestPattern(t *testing.T) {
+	p := Pattern(`Tool "transfer_call" timed out after 5000ms for [PHONE] (call 1761234567.42)`)
+	want := `Tool\s+\W*transfer_call\W*\s+timed\s+out\s+after\s+\d+([.:/-]\d+)*ms\s+for\s+.+\s+\(call\s+\d+([.:/-]\d+)*\)`
+	if p != want {
+		t.Fatalf("Pattern = %s", p)
+	}
+	b, err := Parse([]byte("version: 1\nissues:\n  - id: x\n    match:\n      logs: ['"+p+"']\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits := b.Match(Facts{Log: `ERROR Tool 'transfer_call' timed out after 12000ms for +15551234567 (call 1761299999.7)`}); len(hits) != 1 {
+		t.Error("pattern does not match the same message on another call")
+	}
+	if got := Slug("Dialplan sends the wrong AudioSocket UUID!"); got != "dialplan-sends-the-wrong-audiosocket-uuid" {
+		t.Errorf("Slug = %q", got)
+	}
+}
+
+func TestLoadPacks(t *testing.T) {
+	t.Setenv("AAVA_KNOWLEDGE_FILE", "")
+	dir := t.TempDir()
+	old := PackDir
+	PackDir = dir
+	defer func() { PackDir = old }()
+
+	pack := filepath.Join(dir, "team.yaml")
+	if err := os.WriteFile(pack, []byte(`version: 1
+issues:
+  - id: gate-flutter
+    title: Team gate flutter
+    match:
+      metrics: ['GateClosures > 5']
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	is, ok := b.Lookup("gate-flutter")
+	if !ok || is.Source != pack || is.Title != "Team gate flutter" {
+		t.Errorf("gate-flutter = %+v", is)
+	}
+	if is, _ := b.Lookup("connection-refused"); is.Source != BuiltinSource {
+		t.Errorf("connection-refused source = %q", is.Source)
+	}
+}
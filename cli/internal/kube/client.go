@@ -0,0 +1,415 @@
+package kube
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// APIError is a non-2xx response from the API server.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("kubernetes API %d: %s", e.Status, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 (no such pod or workload).
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
+
+// Client talks to one API server.
+type Client struct {
+	http *http.Client
+	base string
+	cfg  *Config
+}
+
+// NewClient builds a client for cfg.
+func NewClient(cfg *Config) (*Client, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.Insecure, ServerName: cfg.TLSServerName} // #nosec G402 -- only with insecure-skip-tls-verify in the kubeconfig
+	if len(cfg.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cfg.CAData) {
+			return nil, errors.New("kubeconfig certificate authority holds no PEM certificate")
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if len(cfg.CertData) > 0 {
+		cert, err := tls.X509KeyPair(cfg.CertData, cfg.KeyData)
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = tlsCfg
+	return NewWithHTTP(&http.Client{Transport: tr}, cfg), nil
+}
+
+// NewWithHTTP builds a client around an existing http.Client (tests, custom transports).
+func NewWithHTTP(hc *http.Client, cfg *Config) *Client {
+	return &Client{http: hc, base: strings.TrimRight(cfg.Server, "/"), cfg: cfg}
+}
+
+// Config returns the configuration the client was built from.
+func (c *Client) Config() Config { return *c.cfg }
+
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, body []byte, contentType string) (*http.Response, error) {
+	u := c.base + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json, */*")
+	switch {
+	case c.cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	case c.cfg.Username != "":
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		var status struct {
+			Message string `json:"message"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(raw, &status) != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(raw))
+		}
+		return nil, &APIError{Status: resp.StatusCode, Message: status.Message}
+	}
+	return resp, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, q url.Values, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, q, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Pod is the subset of a pod the CLI reads.
+type Pod struct {
+	Metadata struct {
+		Name              string            `json:"name"`
+		Namespace         string            `json:"namespace"`
+		UID               string            `json:"uid"`
+		Labels            map[string]string `json:"labels"`
+		CreationTimestamp time.Time         `json:"creationTimestamp"`
+		DeletionTimestamp *time.Time        `json:"deletionTimestamp"`
+		OwnerReferences   []ownerRef        `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName    string      `json:"nodeName"`
+		HostNetwork bool        `json:"hostNetwork"`
+		Containers  []Container `json:"containers"`
+		Volumes     []struct {
+			Name     string `json:"name"`
+			HostPath *struct {
+				Path string `json:"path"`
+			} `json:"hostPath"`
+			PersistentVolumeClaim *struct {
+				ClaimName string `json:"claimName"`
+			} `json:"persistentVolumeClaim"`
+		} `json:"volumes"`
+	} `json:"spec"`
+	Status struct {
+		Phase             string            `json:"phase"`
+		StartTime         *time.Time        `json:"startTime"`
+		ContainerStatuses []ContainerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// Container is a container of a pod spec.
+type Container struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	Env   []struct {
+		Name      string                     `json:"name"`
+		Value     string                     `json:"value"`
+		ValueFrom map[string]json.RawMessage `json:"valueFrom"`
+	} `json:"env"`
+	Resources struct {
+		Limits map[string]string `json:"limits"`
+	} `json:"resources"`
+	VolumeMounts []struct {
+		Name      string `json:"name"`
+		MountPath string `json:"mountPath"`
+		ReadOnly  bool   `json:"readOnly"`
+	} `json:"volumeMounts"`
+	ReadinessProbe map[string]json.RawMessage `json:"readinessProbe"`
+}
+
+// ContainerStatus is a container's entry in a pod's status.
+type ContainerStatus struct {
+	Name         string         `json:"name"`
+	Ready        bool           `json:"ready"`
+	RestartCount int            `json:"restartCount"`
+	Image        string         `json:"image"`
+	ImageID      string         `json:"imageID"`
+	State        containerState `json:"state"`
+	LastState    containerState `json:"lastState"`
+}
+
+type containerState struct {
+	Running *struct {
+		StartedAt time.Time `json:"startedAt"`
+	} `json:"running"`
+	Waiting *struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	} `json:"waiting"`
+	Terminated *struct {
+		Reason     string    `json:"reason"`
+		ExitCode   int       `json:"exitCode"`
+		StartedAt  time.Time `json:"startedAt"`
+		FinishedAt time.Time `json:"finishedAt"`
+	} `json:"terminated"`
+}
+
+type ownerRef struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Controller bool   `json:"controller"`
+}
+
+// Pods lists the pods in ns matching selector.
+func (c *Client) Pods(ctx context.Context, ns, selector string) ([]Pod, error) {
+	var list struct {
+		Items []Pod `json:"items"`
+	}
+	q := url.Values{}
+	if selector != "" {
+		q.Set("labelSelector", selector)
+	}
+	err := c.getJSON(ctx, "/api/v1/namespaces/"+url.PathEscape(ns)+"/pods", q, &list)
+	return list.Items, err
+}
+
+// LogOptions select the log lines of a container, like LogsOptions in the
+// docker API client.
+type LogOptions struct {
+	Since      time.Time // zero: from the start
+	Until      time.Time // zero: to the end; applied here, the API has no such filter
+	Tail       int
+	Timestamps bool
+	Follow     bool
+}
+
+// Logs streams a container's log. Lines after Until are dropped, which needs
+// the timestamps the API can prefix; they are stripped again unless asked for.
+func (c *Client) Logs(ctx context.Context, ns, pod, container string, opts LogOptions) (io.ReadCloser, error) {
+	q := url.Values{}
+	if container != "" {
+		q.Set("container", container)
+	}
+	if !opts.Since.IsZero() {
+		q.Set("sinceTime", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if opts.Tail > 0 {
+		q.Set("tailLines", fmt.Sprint(opts.Tail))
+	}
+	if opts.Timestamps || !opts.Until.IsZero() {
+		q.Set("timestamps", "true")
+	}
+	if opts.Follow {
+		q.Set("follow", "true")
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/api/v1/namespaces/"+url.PathEscape(ns)+"/pods/"+url.PathEscape(pod)+"/log", q, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if opts.Until.IsZero() {
+		return resp.Body, nil
+	}
+	return untilReader(resp.Body, opts.Until, opts.Timestamps), nil
+}
+
+// untilReader passes the timestamped lines of rc up to until and stops at the
+// first later one.
+func untilReader(rc io.ReadCloser, until time.Time, keepTimestamps bool) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer rc.Close()
+		r := bufio.NewReader(rc)
+		for {
+			line, err := r.ReadString('\n')
+			if len(line) > 0 {
+				ts, rest, _ := strings.Cut(line, " ")
+				if t, perr := time.Parse(time.RFC3339Nano, ts); perr == nil {
+					if t.After(until) {
+						pw.Close()
+						return
+					}
+					if !keepTimestamps {
+						line = rest
+					}
+				}
+				if _, werr := io.WriteString(pw, line); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	return pr
+}
+
+// Workload is what owns a pod and restarts it: a Deployment, StatefulSet or
+// DaemonSet.
+type Workload struct {
+	Kind string
+	Name string
+}
+
+func (w Workload) String() string { return strings.ToLower(w.Kind) + "/" + w.Name }
+
+// Owner returns the workload that manages pod, following a ReplicaSet to its
+// Deployment.
+func (c *Client) Owner(ctx context.Context, pod Pod) (Workload, error) {
+	ns := pod.Metadata.Namespace
+	for _, ref := range pod.Metadata.OwnerReferences {
+		if !ref.Controller {
+			continue
+		}
+		switch ref.Kind {
+		case "StatefulSet", "DaemonSet", "Deployment":
+			return Workload{Kind: ref.Kind, Name: ref.Name}, nil
+		case "ReplicaSet":
+			var rs struct {
+				Metadata struct {
+					OwnerReferences []ownerRef `json:"ownerReferences"`
+				} `json:"metadata"`
+			}
+			if err := c.getJSON(ctx, "/apis/apps/v1/namespaces/"+url.PathEscape(ns)+"/replicasets/"+url.PathEscape(ref.Name), nil, &rs); err != nil {
+				return Workload{}, err
+			}
+			for _, o := range rs.Metadata.OwnerReferences {
+				if o.Controller && o.Kind == "Deployment" {
+					return Workload{Kind: o.Kind, Name: o.Name}, nil
+				}
+			}
+			return Workload{}, fmt.Errorf("replicaset %s has no owning deployment", ref.Name)
+		}
+	}
+	return Workload{}, fmt.Errorf("pod %s is not managed by a Deployment, StatefulSet or DaemonSet; restart it by hand", pod.Metadata.Name)
+}
+
+// RolloutRestart restarts w's pods the way kubectl rollout restart does: by
+// stamping the pod template, so the controller replaces them in its own
+// rolling fashion.
+func (c *Client) RolloutRestart(ctx context.Context, ns string, w Workload, at time.Time) error {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":%q}}}}}`, at.Format(time.RFC3339))
+	path := "/apis/apps/v1/namespaces/" + url.PathEscape(ns) + "/" + strings.ToLower(w.Kind) + "s/" + url.PathEscape(w.Name)
+	resp, err := c.do(ctx, http.MethodPatch, path, nil, []byte(patch), "application/strategic-merge-patch+json")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Node is the subset of a node the CLI reads.
+type Node struct {
+	Name          string
+	Architecture  string // GOARCH style
+	KernelVersion string
+	OSImage       string
+	CPUs          int
+	Memory        int64
+}
+
+// Node returns the named node.
+func (c *Client) Node(ctx context.Context, name string) (Node, error) {
+	var raw struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			Capacity map[string]string `json:"capacity"`
+			NodeInfo struct {
+				Architecture  string `json:"architecture"`
+				KernelVersion string `json:"kernelVersion"`
+				OSImage       string `json:"osImage"`
+			} `json:"nodeInfo"`
+		} `json:"status"`
+	}
+	if err := c.getJSON(ctx, "/api/v1/nodes/"+url.PathEscape(name), nil, &raw); err != nil {
+		return Node{}, err
+	}
+	mem, _ := ParseQuantity(raw.Status.Capacity["memory"])
+	cpus, _ := ParseQuantity(raw.Status.Capacity["cpu"])
+	return Node{
+		Name:          raw.Metadata.Name,
+		Architecture:  raw.Status.NodeInfo.Architecture,
+		KernelVersion: raw.Status.NodeInfo.KernelVersion,
+		OSImage:       raw.Status.NodeInfo.OSImage,
+		CPUs:          int(cpus),
+		Memory:        mem,
+	}, nil
+}
+
+// ParseQuantity parses a resource quantity such as 512Mi, 2G or 1500m into
+// its integer value (bytes, or cores rounded down).
+func ParseQuantity(q string) (int64, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return 0, errors.New("empty quantity")
+	}
+	suffixes := []struct {
+		s    string
+		mult float64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40}, {"Pi", 1 << 50}, {"Ei", 1 << 60},
+		{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15}, {"E", 1e18}, {"m", 1e-3},
+	}
+	mult := 1.0
+	for _, s := range suffixes {
+		if strings.HasSuffix(q, s.s) {
+			q, mult = strings.TrimSuffix(q, s.s), s.mult
+			break
+		}
+	}
+	var v float64
+	if _, err := fmt.Sscanf(q, "%g", &v); err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", q)
+	}
+	return int64(v * mult), nil
+}
@@ -0,0 +1,305 @@
+package kube
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is what a client needs to reach one cluster as one user.
+type Config struct {
+	Server        string
+	CAData        []byte
+	Insecure      bool
+	TLSServerName string
+	CertData      []byte
+	KeyData       []byte
+	Token         string
+	Username      string
+	Password      string
+	// Namespace is the context's namespace; empty when it sets none.
+	Namespace string
+	// Context is the kubeconfig context used (empty in-cluster).
+	Context string
+}
+
+// k3sConfig is where k3s writes its admin kubeconfig.
+const k3sConfig = "/etc/rancher/k3s/k3s.yaml"
+
+// Service account files mounted into every pod.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server        string `yaml:"server"`
+			CA            string `yaml:"certificate-authority"`
+			CAData        string `yaml:"certificate-authority-data"`
+			Insecure      bool   `yaml:"insecure-skip-tls-verify"`
+			TLSServerName string `yaml:"tls-server-name"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token          string      `yaml:"token"`
+			TokenFile      string      `yaml:"tokenFile"`
+			ClientCert     string      `yaml:"client-certificate"`
+			ClientCertData string      `yaml:"client-certificate-data"`
+			ClientKey      string      `yaml:"client-key"`
+			ClientKeyData  string      `yaml:"client-key-data"`
+			Username       string      `yaml:"username"`
+			Password       string      `yaml:"password"`
+			Exec           *execConfig `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+
+	dir string // relative paths in the file are relative to it
+}
+
+// execConfig is a client-go credential plugin (aws eks get-token, gke-gcloud-auth-plugin, ...).
+type execConfig struct {
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	Env     []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+}
+
+// ConfigPaths returns the kubeconfig files to read: the given list (as in
+// KUBECONFIG), else ~/.kube/config, else the k3s admin kubeconfig.
+func ConfigPaths(list string) []string {
+	var paths []string
+	for _, p := range filepath.SplitList(list) {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, expandHome(p))
+		}
+	}
+	if len(paths) > 0 {
+		return paths
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if p := filepath.Join(home, ".kube", "config"); fileExists(p) {
+			return []string{p}
+		}
+	}
+	if fileExists(k3sConfig) {
+		return []string{k3sConfig}
+	}
+	return nil
+}
+
+// LoadConfig resolves context (the current context when empty) from the
+// kubeconfig files in list, merged the way kubectl merges them: the first file
+// to define a name wins. With no kubeconfig at all, it uses the pod's service
+// account when running in a cluster.
+func LoadConfig(list, context string) (*Config, error) {
+	paths := ConfigPaths(list)
+	if len(paths) == 0 {
+		if cfg, ok := inClusterConfig(); ok {
+			return cfg, nil
+		}
+		return nil, errors.New("no kubeconfig found (set KUBECONFIG or kubernetes.kubeconfig in .agent/deployment.yaml)")
+	}
+	var files []*kubeconfig
+	for _, p := range paths {
+		raw, err := os.ReadFile(p)
+		if os.IsNotExist(err) && len(paths) > 1 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		kc := &kubeconfig{dir: filepath.Dir(p)}
+		if err := yaml.Unmarshal(raw, kc); err != nil {
+			return nil, fmt.Errorf("invalid kubeconfig %s: %w", p, err)
+		}
+		files = append(files, kc)
+	}
+	return resolveConfig(files, context)
+}
+
+func resolveConfig(files []*kubeconfig, context string) (*Config, error) {
+	if context == "" {
+		for _, f := range files {
+			if f.CurrentContext != "" {
+				context = f.CurrentContext
+				break
+			}
+		}
+	}
+	if context == "" {
+		return nil, errors.New("kubeconfig has no current-context; set kubernetes.context in .agent/deployment.yaml")
+	}
+	cfg := &Config{Context: context}
+	var clusterName, userName string
+	found := false
+	for _, f := range files {
+		for _, c := range f.Contexts {
+			if c.Name == context && !found {
+				clusterName, userName, cfg.Namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+				found = true
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", context)
+	}
+
+	found = false
+	for _, f := range files {
+		for _, c := range f.Clusters {
+			if c.Name != clusterName || found {
+				continue
+			}
+			found = true
+			cl := c.Cluster
+			cfg.Server, cfg.Insecure, cfg.TLSServerName = cl.Server, cl.Insecure, cl.TLSServerName
+			var err error
+			if cfg.CAData, err = dataOrFile(cl.CAData, cl.CA, f.dir); err != nil {
+				return nil, fmt.Errorf("cluster %s certificate-authority: %w", clusterName, err)
+			}
+		}
+	}
+	if !found || cfg.Server == "" {
+		return nil, fmt.Errorf("cluster %q of context %q not found in kubeconfig", clusterName, context)
+	}
+
+	found = false
+	for _, f := range files {
+		for _, u := range f.Users {
+			if u.Name != userName || found {
+				continue
+			}
+			found = true
+			us := u.User
+			cfg.Token, cfg.Username, cfg.Password = us.Token, us.Username, us.Password
+			var err error
+			if cfg.Token == "" && us.TokenFile != "" {
+				raw, err := os.ReadFile(relative(us.TokenFile, f.dir))
+				if err != nil {
+					return nil, fmt.Errorf("user %s tokenFile: %w", userName, err)
+				}
+				cfg.Token = strings.TrimSpace(string(raw))
+			}
+			if cfg.CertData, err = dataOrFile(us.ClientCertData, us.ClientCert, f.dir); err != nil {
+				return nil, fmt.Errorf("user %s client-certificate: %w", userName, err)
+			}
+			if cfg.KeyData, err = dataOrFile(us.ClientKeyData, us.ClientKey, f.dir); err != nil {
+				return nil, fmt.Errorf("user %s client-key: %w", userName, err)
+			}
+			if us.Exec != nil && us.Exec.Command != "" {
+				if err := runExecPlugin(us.Exec, cfg); err != nil {
+					return nil, fmt.Errorf("user %s exec credential plugin %s: %w", userName, us.Exec.Command, err)
+				}
+			}
+		}
+	}
+	// A context may name no user (or one defined nowhere): anonymous access.
+	return cfg, nil
+}
+
+func dataOrFile(data, file, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	}
+	if file == "" {
+		return nil, nil
+	}
+	return os.ReadFile(relative(file, dir))
+}
+
+func relative(p, dir string) string {
+	p = expandHome(p)
+	if filepath.IsAbs(p) || dir == "" {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	return p
+}
+
+func fileExists(p string) bool {
+	st, err := os.Stat(p)
+	return err == nil && !st.IsDir()
+}
+
+// runExecPlugin runs a credential plugin and takes the token or client
+// certificate from the ExecCredential it prints.
+func runExecPlugin(ec *execConfig, cfg *Config) error {
+	cmd := exec.Command(ec.Command, ec.Args...)
+	cmd.Env = os.Environ()
+	for _, e := range ec.Env {
+		cmd.Env = append(cmd.Env, e.Name+"="+e.Value)
+	}
+	cmd.Stderr = os.Stderr // plugins prompt and explain failures there
+	out, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	var cred struct {
+		Status struct {
+			Token                 string `json:"token"`
+			ClientCertificateData string `json:"clientCertificateData"`
+			ClientKeyData         string `json:"clientKeyData"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(out, &cred); err != nil {
+		return fmt.Errorf("invalid ExecCredential: %w", err)
+	}
+	if cred.Status.Token != "" {
+		cfg.Token = cred.Status.Token
+	}
+	if cred.Status.ClientCertificateData != "" {
+		cfg.CertData, cfg.KeyData = []byte(cred.Status.ClientCertificateData), []byte(cred.Status.ClientKeyData)
+	}
+	return nil
+}
+
+// inClusterConfig is the pod's service account, when the CLI runs in a pod.
+func inClusterConfig() (*Config, bool) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, false
+	}
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, false
+	}
+	ca, _ := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	ns, _ := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return &Config{
+		Server:    "https://" + host + ":" + port,
+		CAData:    ca,
+		Token:     strings.TrimSpace(string(token)),
+		Namespace: strings.TrimSpace(string(ns)),
+	}, true
+}
@@ -0,0 +1,356 @@
+// Package kube finds the stack's pods on Kubernetes (k3s included) and reads,
+// restarts and runs commands in them, for deployments with runtime: kubernetes.
+//
+// It talks to the API server directly with the kubeconfig's credentials;
+// only commands inside a pod go through kubectl exec. The target comes from
+// the environment, which deployment.Apply fills in from the descriptor, so the
+// docker API client can hand pod lookups here without importing deployment.
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables read by FromEnv.
+const (
+	EnvRuntime   = "AAVA_RUNTIME" // "kubernetes" enables this package
+	EnvContext   = "AAVA_K8S_CONTEXT"
+	EnvNamespace = "AAVA_K8S_NAMESPACE"
+	EnvLabel     = "AAVA_K8S_LABEL"
+)
+
+// DefaultLabel is the pod label whose value is the container name from the
+// deployment descriptor: app=ai_engine finds the ai_engine pod.
+const DefaultLabel = "app"
+
+// Target is the cluster, namespace and label the stack runs under.
+type Target struct {
+	Kubeconfig string // KUBECONFIG-style list; empty uses the defaults
+	Context    string // empty uses the current context
+	Namespace  string // empty uses the context's, else "default"
+	Label      string
+}
+
+// Enabled reports whether the deployment runs on Kubernetes.
+func Enabled() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv(EnvRuntime)), "kubernetes")
+}
+
+// FromEnv returns the target deployment.Apply exported.
+func FromEnv() Target {
+	t := Target{
+		Kubeconfig: strings.TrimSpace(os.Getenv("KUBECONFIG")),
+		Context:    strings.TrimSpace(os.Getenv(EnvContext)),
+		Namespace:  strings.TrimSpace(os.Getenv(EnvNamespace)),
+		Label:      strings.TrimSpace(os.Getenv(EnvLabel)),
+	}
+	if t.Label == "" {
+		t.Label = DefaultLabel
+	}
+	return t
+}
+
+// Session is a client bound to a target's namespace.
+type Session struct {
+	*Client
+	Target    Target
+	Namespace string
+}
+
+var (
+	sessionOnce sync.Once
+	session     *Session
+	sessionErr  error
+)
+
+// Current returns the session for FromEnv, connecting on first use.
+func Current() (*Session, error) {
+	sessionOnce.Do(func() {
+		session, sessionErr = Open(FromEnv())
+	})
+	return session, sessionErr
+}
+
+// Open connects to t's cluster.
+func Open(t Target) (*Session, error) {
+	cfg, err := LoadConfig(t.Kubeconfig, t.Context)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(c, t), nil
+}
+
+func newSession(c *Client, t Target) *Session {
+	ns := t.Namespace
+	if ns == "" {
+		ns = c.cfg.Namespace
+	}
+	if ns == "" {
+		ns = "default"
+	}
+	if t.Label == "" {
+		t.Label = DefaultLabel
+	}
+	return &Session{Client: c, Target: t, Namespace: ns}
+}
+
+// Selector is the label selector for the pods of container name.
+func (s *Session) Selector(name string) string { return s.Target.Label + "=" + name }
+
+// FindPod returns the pod running container name: a running pod that is not
+// shutting down if there is one, the newest first.
+func (s *Session) FindPod(ctx context.Context, name string) (Pod, error) {
+	pods, err := s.Pods(ctx, s.Namespace, s.Selector(name))
+	if err != nil {
+		return Pod{}, err
+	}
+	if len(pods) == 0 {
+		return Pod{}, &APIError{Status: 404, Message: fmt.Sprintf("no pod with label %s in namespace %s", s.Selector(name), s.Namespace)}
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		ri, rj := livePod(pods[i]), livePod(pods[j])
+		if ri != rj {
+			return ri
+		}
+		return pods[i].Metadata.CreationTimestamp.After(pods[j].Metadata.CreationTimestamp)
+	})
+	return pods[0], nil
+}
+
+func livePod(p Pod) bool {
+	return p.Status.Phase == "Running" && p.Metadata.DeletionTimestamp == nil
+}
+
+// ContainerName is the container of p that runs name: the one called name,
+// else the pod's first.
+func ContainerName(p Pod, name string) string {
+	for _, c := range p.Spec.Containers {
+		if c.Name == name {
+			return c.Name
+		}
+	}
+	if len(p.Spec.Containers) > 0 {
+		return p.Spec.Containers[0].Name
+	}
+	return name
+}
+
+// Inspect returns pod name as `docker inspect`-shaped JSON (an array with one
+// object) so the parsers written for containers read it unchanged.
+func (s *Session) Inspect(ctx context.Context, name string) ([]byte, error) {
+	p, err := s.FindPod(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal([]inspectJSON{inspectPod(p, name)})
+}
+
+// inspectJSON is the part of docker's container inspect the CLI parses.
+type inspectJSON struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Image  string `json:"Image"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+		Env    []string          `json:"Env"`
+	} `json:"Config"`
+	State struct {
+		Status     string    `json:"Status"`
+		Running    bool      `json:"Running"`
+		StartedAt  time.Time `json:"StartedAt"`
+		FinishedAt time.Time `json:"FinishedAt"`
+		OOMKilled  bool      `json:"OOMKilled"`
+		ExitCode   int       `json:"ExitCode"`
+		Error      string    `json:"Error"`
+		Health     *struct {
+			Status string `json:"Status"`
+		} `json:"Health,omitempty"`
+	} `json:"State"`
+	RestartCount int `json:"RestartCount"`
+	HostConfig   struct {
+		NetworkMode string `json:"NetworkMode"`
+		Memory      int64  `json:"Memory"`
+	} `json:"HostConfig"`
+	Mounts []inspectMount `json:"Mounts"`
+}
+
+type inspectMount struct {
+	Type        string `json:"Type"`
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+	Mode        string `json:"Mode"`
+	RW          bool   `json:"RW"`
+}
+
+func inspectPod(p Pod, name string) inspectJSON {
+	var out inspectJSON
+	cname := ContainerName(p, name)
+	out.ID = p.Metadata.UID
+	out.Name = "/" + p.Metadata.Name
+	out.Config.Labels = p.Metadata.Labels
+	out.HostConfig.NetworkMode = "pod"
+	if p.Spec.HostNetwork {
+		out.HostConfig.NetworkMode = "host"
+	}
+
+	var spec Container
+	for _, c := range p.Spec.Containers {
+		if c.Name == cname {
+			spec = c
+		}
+	}
+	out.Config.Image = spec.Image
+	for _, e := range spec.Env {
+		if len(e.ValueFrom) == 0 { // secret and field references are not resolved
+			out.Config.Env = append(out.Config.Env, e.Name+"="+e.Value)
+		}
+	}
+	if v, ok := spec.Resources.Limits["memory"]; ok {
+		out.HostConfig.Memory, _ = ParseQuantity(v)
+	}
+	volumes := map[string]string{}
+	for _, v := range p.Spec.Volumes {
+		switch {
+		case v.HostPath != nil:
+			volumes[v.Name] = v.HostPath.Path
+		case v.PersistentVolumeClaim != nil:
+			volumes[v.Name] = "pvc/" + v.PersistentVolumeClaim.ClaimName
+		}
+	}
+	for _, m := range spec.VolumeMounts {
+		src := volumes[m.Name]
+		typ := "bind"
+		if src == "" || strings.HasPrefix(src, "pvc/") {
+			typ = "volume"
+			if src == "" {
+				src = m.Name
+			}
+		}
+		out.Mounts = append(out.Mounts, inspectMount{Type: typ, Source: src, Destination: m.MountPath, RW: !m.ReadOnly})
+	}
+
+	out.State.Status = strings.ToLower(p.Status.Phase)
+	for _, cs := range p.Status.ContainerStatuses {
+		if cs.Name != cname {
+			continue
+		}
+		out.Image = cs.ImageID
+		out.RestartCount = cs.RestartCount
+		switch st := cs.State; {
+		case st.Running != nil:
+			out.State.Status, out.State.Running, out.State.StartedAt = "running", true, st.Running.StartedAt
+		case st.Waiting != nil:
+			out.State.Status = "created"
+			if st.Waiting.Reason == "CrashLoopBackOff" {
+				out.State.Status = "restarting"
+			}
+			out.State.Error = strings.TrimSpace(st.Waiting.Reason + ": " + st.Waiting.Message)
+		case st.Terminated != nil:
+			out.State.Status, out.State.ExitCode = "exited", st.Terminated.ExitCode
+			out.State.StartedAt, out.State.FinishedAt = st.Terminated.StartedAt, st.Terminated.FinishedAt
+		}
+		for _, t := range []containerState{cs.State, cs.LastState} {
+			if t.Terminated != nil && t.Terminated.Reason == "OOMKilled" {
+				out.State.OOMKilled = true
+			}
+		}
+		if len(spec.ReadinessProbe) > 0 {
+			out.State.Health = &struct {
+				Status string `json:"Status"`
+			}{Status: "unhealthy"}
+			if cs.Ready {
+				out.State.Health.Status = "healthy"
+			}
+		}
+	}
+	if out.Image == "" {
+		out.Image = spec.Image
+	}
+	return out
+}
+
+// PodLogs streams the log of container name's pod.
+func (s *Session) PodLogs(ctx context.Context, name string, opts LogOptions) (io.ReadCloser, error) {
+	p, err := s.FindPod(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Logs(ctx, s.Namespace, p.Metadata.Name, ContainerName(p, name), opts)
+}
+
+// Restart rollout-restarts the workload that runs container name and returns it.
+func (s *Session) Restart(ctx context.Context, name string) (Workload, error) {
+	p, err := s.FindPod(ctx, name)
+	if err != nil {
+		return Workload{}, err
+	}
+	w, err := s.Owner(ctx, p)
+	if err != nil {
+		return Workload{}, err
+	}
+	return w, s.RolloutRestart(ctx, s.Namespace, w, time.Now())
+}
+
+// NodeOf returns the node container name's pod runs on.
+func (s *Session) NodeOf(ctx context.Context, name string) (Node, error) {
+	p, err := s.FindPod(ctx, name)
+	if err != nil {
+		return Node{}, err
+	}
+	if p.Spec.NodeName == "" {
+		return Node{}, fmt.Errorf("pod %s is not scheduled on a node", p.Metadata.Name)
+	}
+	return s.Node(ctx, p.Spec.NodeName)
+}
+
+// ExecArgs are the kubectl arguments that run argv in container name's pod,
+// like `docker exec [-i] <container> argv...`.
+func (s *Session) ExecArgs(ctx context.Context, name string, stdin bool, argv ...string) ([]string, error) {
+	p, err := s.FindPod(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	if s.Target.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", s.Target.Kubeconfig)
+	}
+	if s.Target.Context != "" {
+		args = append(args, "--context", s.Target.Context)
+	}
+	args = append(args, "-n", s.Namespace, "exec")
+	if stdin {
+		args = append(args, "-i")
+	}
+	args = append(args, p.Metadata.Name, "-c", ContainerName(p, name), "--")
+	return append(args, argv...), nil
+}
+
+// ExecCommand is the kubectl command for ExecArgs. When the pod cannot be
+// found, running the command returns that error.
+func ExecCommand(ctx context.Context, name string, stdin bool, argv ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "kubectl")
+	s, err := Current()
+	if err == nil {
+		var args []string
+		if args, err = s.ExecArgs(ctx, name, stdin, argv...); err == nil {
+			cmd.Args = append(cmd.Args, args...)
+			return cmd
+		}
+	}
+	cmd.Err = err
+	return cmd
+}
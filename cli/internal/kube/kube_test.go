@@ -0,0 +1,186 @@
+package kube
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigMergesFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := base64.StdEncoding.EncodeToString([]byte("CA PEM"))
+	first := "current-context: k3s\ncontexts:\n- name: k3s\n  context: {cluster: pbx, user: admin, namespace: voice}\n"
+	second := "clusters:\n- name: pbx\n  cluster:\n    server: https://10.0.0.5:6443\n    certificate-authority-data: " + ca + "\n" +
+		"- name: pbx\n  cluster: {server: https://ignored:6443}\n" +
+		"users:\n- name: admin\n  user: {tokenFile: token}\n" +
+		"contexts:\n- name: k3s\n  context: {cluster: other}\n"
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write("token", "s3cret\n")
+	list := write("a.yaml", first) + string(os.PathListSeparator) + write("b.yaml", second) + string(os.PathListSeparator) + filepath.Join(dir, "absent.yaml")
+
+	cfg, err := LoadConfig(list, "")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Context != "k3s" || cfg.Server != "https://10.0.0.5:6443" || cfg.Namespace != "voice" || string(cfg.CAData) != "CA PEM" || cfg.Token != "s3cret" {
+		t.Fatalf("config = %+v", cfg)
+	}
+	if _, err := LoadConfig(list, "staging"); err == nil || !strings.Contains(err.Error(), `context "staging" not found`) {
+		t.Fatalf("unknown context: %v", err)
+	}
+}
+
+// fakeAPI serves the pods of one namespace and records PATCH bodies.
+type fakeAPI struct {
+	pods    []Pod
+	patched map[string]string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/api/v1/namespaces/voice/pods":
+		sel := strings.TrimPrefix(r.URL.Query().Get("labelSelector"), "app=")
+		var items []Pod
+		for _, p := range f.pods {
+			if p.Metadata.Labels["app"] == sel {
+				items = append(items, p)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case r.URL.Path == "/apis/apps/v1/namespaces/voice/replicasets/ai-engine-7d9":
+		_, _ = io.WriteString(w, `{"metadata":{"ownerReferences":[{"kind":"Deployment","name":"ai-engine","controller":true}]}}`)
+	case r.Method == http.MethodPatch:
+		body, _ := io.ReadAll(r.Body)
+		f.patched[r.URL.Path] = r.Header.Get("Content-Type") + " " + string(body)
+	case strings.HasSuffix(r.URL.Path, "/log"):
+		_, _ = io.WriteString(w, "2026-10-15T10:00:00.5Z first\n2026-10-15T10:00:01Z second\n2026-10-15T10:00:02Z third\n")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message":"not found"}`)
+	}
+}
+
+func pod(t *testing.T, doc string) Pod {
+	t.Helper()
+	var p Pod
+	if err := json.Unmarshal([]byte(doc), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func testSession(t *testing.T, pods ...Pod) (*Session, *fakeAPI) {
+	api := &fakeAPI{pods: pods, patched: map[string]string{}}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return newSession(NewWithHTTP(srv.Client(), &Config{Server: srv.URL, Namespace: "voice"}), Target{}), api
+}
+
+const enginePod = `{
+ "metadata": {"name": "ai-engine-7d9-x2", "namespace": "voice", "uid": "u1", "labels": {"app": "ai_engine"},
+  "creationTimestamp": "2026-10-15T09:00:00Z",
+  "ownerReferences": [{"kind": "ReplicaSet", "name": "ai-engine-7d9", "controller": true}]},
+ "spec": {"nodeName": "pi4", "hostNetwork": true,
+  "containers": [{"name": "sidecar", "image": "busybox"}, {"name": "ai_engine", "image": "registry.local/ai-engine:7",
+   "env": [{"name": "TZ", "value": "UTC"}, {"name": "OPENAI_API_KEY", "valueFrom": {"secretKeyRef": {"name": "keys", "key": "openai"}}}],
+   "resources": {"limits": {"memory": "2Gi"}},
+   "volumeMounts": [{"name": "models", "mountPath": "/app/models", "readOnly": true}, {"name": "data", "mountPath": "/app/data"}],
+   "readinessProbe": {"httpGet": {"path": "/health"}}}],
+  "volumes": [{"name": "models", "hostPath": {"path": "/srv/aava/models"}}, {"name": "data", "persistentVolumeClaim": {"claimName": "aava-data"}}]},
+ "status": {"phase": "Running", "containerStatuses": [{"name": "ai_engine", "ready": true, "restartCount": 2, "imageID": "sha256:abc",
+  "state": {"running": {"startedAt": "2026-10-15T09:01:00Z"}},
+  "lastState": {"terminated": {"reason": "OOMKilled", "exitCode": 137}}}]}
+}`
+
+func TestFindPodAndInspect(t *testing.T) {
+	stale := pod(t, `{"metadata": {"name": "ai-engine-old", "labels": {"app": "ai_engine"}, "creationTimestamp": "2026-10-15T10:00:00Z",
+		"deletionTimestamp": "2026-10-15T10:05:00Z"}, "status": {"phase": "Running"}}`)
+	s, _ := testSession(t, stale, pod(t, enginePod))
+
+	p, err := s.FindPod(context.Background(), "ai_engine")
+	if err != nil || p.Metadata.Name != "ai-engine-7d9-x2" {
+		t.Fatalf("FindPod = %s, %v (want the live pod over the newer terminating one)", p.Metadata.Name, err)
+	}
+	if _, err := s.FindPod(context.Background(), "admin_ui"); !IsNotFound(err) {
+		t.Fatalf("missing pod: %v", err)
+	}
+
+	raw, err := s.Inspect(context.Background(), "ai_engine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ci []inspectJSON
+	if err := json.Unmarshal(raw, &ci); err != nil || len(ci) != 1 {
+		t.Fatalf("inspect JSON: %v %s", err, raw)
+	}
+	got := ci[0]
+	if !got.State.Running || !got.State.OOMKilled || got.State.Health == nil || got.State.Health.Status != "healthy" || got.RestartCount != 2 {
+		t.Fatalf("state = %+v restarts=%d", got.State, got.RestartCount)
+	}
+	if got.Config.Image != "registry.local/ai-engine:7" || got.Image != "sha256:abc" || got.HostConfig.NetworkMode != "host" || got.HostConfig.Memory != 2<<30 {
+		t.Fatalf("inspect = %+v", got)
+	}
+	if strings.Join(got.Config.Env, ",") != "TZ=UTC" {
+		t.Fatalf("env = %v (secret references are not resolved)", got.Config.Env)
+	}
+	want := []inspectMount{{Type: "bind", Source: "/srv/aava/models", Destination: "/app/models"}, {Type: "volume", Source: "pvc/aava-data", Destination: "/app/data", RW: true}}
+	if len(got.Mounts) != 2 || got.Mounts[0] != want[0] || got.Mounts[1] != want[1] {
+		t.Fatalf("mounts = %+v", got.Mounts)
+	}
+}
+
+func TestRestartPatchesOwningDeployment(t *testing.T) {
+	s, api := testSession(t, pod(t, enginePod))
+	w, err := s.Restart(context.Background(), "ai_engine")
+	if err != nil || w.String() != "deployment/ai-engine" {
+		t.Fatalf("Restart = %v, %v", w, err)
+	}
+	body := api.patched["/apis/apps/v1/namespaces/voice/deployments/ai-engine"]
+	if !strings.HasPrefix(body, "application/strategic-merge-patch+json ") || !strings.Contains(body, `"kubectl.kubernetes.io/restartedAt"`) {
+		t.Fatalf("patch = %q", body)
+	}
+
+	args, err := s.ExecArgs(context.Background(), "ai_engine", true, "python", "-")
+	if err != nil || strings.Join(args, " ") != "-n voice exec -i ai-engine-7d9-x2 -c ai_engine -- python -" {
+		t.Fatalf("ExecArgs = %v, %v", args, err)
+	}
+}
+
+func TestPodLogsUntil(t *testing.T) {
+	s, _ := testSession(t, pod(t, enginePod))
+	until := time.Date(2026, 10, 15, 10, 0, 1, 0, time.UTC)
+	rc, err := s.PodLogs(context.Background(), "ai_engine", LogOptions{Until: until})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	out, _ := io.ReadAll(rc)
+	if string(out) != "first\nsecond\n" {
+		t.Fatalf("logs = %q", out)
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	for q, want := range map[string]int64{"512Mi": 512 << 20, "2G": 2e9, "4": 4, "1500m": 1, "3977412Ki": 3977412 << 10} {
+		if got, err := ParseQuantity(q); err != nil || got != want {
+			t.Errorf("ParseQuantity(%q) = %d, %v; want %d", q, got, err, want)
+		}
+	}
+	if _, err := ParseQuantity("lots"); err == nil {
+		t.Error("ParseQuantity(lots) should fail")
+	}
+}
@@ -0,0 +1,399 @@
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Setting is one config value that decides a language.
+type Setting struct {
+	Key      string `json:"key"` // providers.local_stt.stt_model
+	Value    string `json:"value"`
+	Language string `json:"language,omitempty"` // code, Multi, or "" when the value does not say
+}
+
+// Side is one direction of a provider: the STT that transcribes the caller or
+// the TTS that speaks to them.
+type Side struct {
+	Provider string `json:"provider"`
+	// Requested is the language the config asks for (stt_language,
+	// language...), when it sets one.
+	Requested *Setting `json:"requested,omitempty"`
+	// Model is the model or voice, and the language it implies.
+	Model *Setting `json:"model,omitempty"`
+}
+
+// Language is the language the side runs in: the requested one, otherwise
+// the model's. It is Multi for a multilingual model with no language set and
+// "" when the config does not say.
+func (s Side) Language() string {
+	if s.Requested != nil && s.Requested.Language != "" {
+		return s.Requested.Language
+	}
+	if s.Model != nil {
+		return s.Model.Language
+	}
+	return ""
+}
+
+// Mismatch describes a requested language the model or voice cannot handle,
+// or returns "".
+func (s Side) Mismatch() string {
+	if s.Requested == nil || s.Model == nil || !Conflict(s.Requested.Language, s.Model.Language) {
+		return ""
+	}
+	return fmt.Sprintf("%s is %s (%s), but %s %s only handles %s",
+		s.Requested.Key, s.Requested.Value, Name(s.Requested.Language), s.Model.Key, s.Model.Value, Name(s.Model.Language))
+}
+
+// Describe names the side's language and where it comes from, as in
+// "Spanish (providers.azure_stt.language es-ES)".
+func (s Side) Describe() string {
+	set := s.Model
+	if s.Requested != nil && s.Requested.Language != "" {
+		set = s.Requested
+	}
+	switch {
+	case set == nil:
+		return "an unknown language"
+	case set.Language == "":
+		return fmt.Sprintf("an unknown language (%s %s)", set.Key, set.Value)
+	}
+	return fmt.Sprintf("%s (%s %s)", Name(set.Language), set.Key, set.Value)
+}
+
+// Env looks up an engine or local AI server environment variable.
+type Env func(string) string
+
+// sttLanguageKeys set the language a provider transcribes; the first one set
+// wins. agent_language is the Deepgram Voice Agent's.
+var sttLanguageKeys = []string{"stt_language", "stt_language_code", "language_code", "kroko_language", "language", "agent_language"}
+
+// sttModelKeys name a provider's STT model.
+var sttModelKeys = []string{"stt_model", "model", "sherpa_model_path"}
+
+// ttsLanguageKeys set the language a provider speaks. lang_tag is the
+// language an Azure multilingual voice is told to speak.
+var ttsLanguageKeys = []string{"lang_tag", "tts_language", "language"}
+
+// ttsVoiceKeys name a provider's voice or TTS model.
+var ttsVoiceKeys = []string{"voice_name", "tts_voice", "tts_voice_name", "tts_model", "model_id", "voice"}
+
+// roles returns whether a provider transcribes and speaks: from its
+// capabilities, or else from a _stt/_tts name or a full agent type.
+func roles(name string, pc map[string]any) (stt, tts bool) {
+	if caps, ok := pc["capabilities"].([]any); ok {
+		for _, c := range caps {
+			switch c {
+			case "stt":
+				stt = true
+			case "tts":
+				tts = true
+			}
+		}
+		return stt, tts
+	}
+	switch {
+	case strings.HasSuffix(name, "_stt"):
+		return true, false
+	case strings.HasSuffix(name, "_tts"):
+		return false, true
+	case str(pc, "type") == "full":
+		return true, true
+	}
+	return false, false
+}
+
+// STT resolves the STT side of provider name with config pc. The local
+// provider's backend and models come from the local AI server's environment
+// when the provider does not set them. ok is false for a provider that does
+// not transcribe.
+func STT(name string, pc map[string]any, env Env) (side Side, ok bool) {
+	if stt, _ := roles(name, pc); !stt {
+		return Side{}, false
+	}
+	side.Provider = name
+	key := func(k string) string { return "providers." + name + "." + k }
+	requestedFrom := func(keys ...string) {
+		for _, k := range keys {
+			if v := str(pc, k); v != "" {
+				side.Requested = &Setting{Key: key(k), Value: v, Language: Base(v)}
+				return
+			}
+		}
+	}
+	if isLocal(name, pc) {
+		backend := first(str(pc, "stt_backend"), env("LOCAL_STT_BACKEND"), "vosk")
+		switch backend {
+		case "faster_whisper":
+			m := first(env("FASTER_WHISPER_MODEL"), "base")
+			side.Model = &Setting{Key: "FASTER_WHISPER_MODEL", Value: m, Language: WhisperLanguage(m)}
+			if v := env("FASTER_WHISPER_LANGUAGE"); v != "" {
+				side.Requested = &Setting{Key: "FASTER_WHISPER_LANGUAGE", Value: v, Language: Base(v)}
+			}
+		case "whisper_cpp":
+			if m := env("WHISPER_CPP_MODEL_PATH"); m != "" {
+				side.Model = &Setting{Key: "WHISPER_CPP_MODEL_PATH", Value: m, Language: WhisperLanguage(strings.TrimSuffix(m, ".bin"))}
+			}
+			if v := env("WHISPER_CPP_LANGUAGE"); v != "" {
+				side.Requested = &Setting{Key: "WHISPER_CPP_LANGUAGE", Value: v, Language: Base(v)}
+			}
+		case "kroko":
+			requestedFrom("kroko_language")
+			if side.Requested == nil {
+				if v := env("KROKO_LANGUAGE"); v != "" {
+					side.Requested = &Setting{Key: "KROKO_LANGUAGE", Value: v, Language: Base(v)}
+				}
+			}
+		case "tone":
+			side.Model = &Setting{Key: "LOCAL_STT_BACKEND", Value: backend, Language: "ru"}
+		default:
+			k, v := key("stt_model"), str(pc, "stt_model")
+			if backend == "sherpa" {
+				k, v = key("sherpa_model_path"), str(pc, "sherpa_model_path")
+				if v == "" {
+					k, v = "SHERPA_MODEL_PATH", env("SHERPA_MODEL_PATH")
+				}
+			} else if v == "" {
+				k, v = "LOCAL_STT_MODEL_PATH", env("LOCAL_STT_MODEL_PATH")
+			}
+			if v != "" {
+				side.Model = &Setting{Key: k, Value: v, Language: ModelLanguage(v)}
+			}
+		}
+		return side, true
+	}
+	requestedFrom(sttLanguageKeys...)
+	for _, k := range sttModelKeys {
+		if v := str(pc, k); v != "" {
+			side.Model = &Setting{Key: key(k), Value: v, Language: ModelLanguage(v)}
+			break
+		}
+	}
+	return side, true
+}
+
+// TTS resolves the TTS side of provider name with config pc, like STT.
+func TTS(name string, pc map[string]any, env Env) (side Side, ok bool) {
+	stt, tts := roles(name, pc)
+	if !tts {
+		return Side{}, false
+	}
+	side.Provider = name
+	key := func(k string) string { return "providers." + name + "." + k }
+	if isLocal(name, pc) {
+		switch first(str(pc, "tts_backend"), env("LOCAL_TTS_BACKEND"), "piper") {
+		case "kokoro":
+			k, v := key("kokoro_voice"), str(pc, "kokoro_voice")
+			if v == "" {
+				k, v = "KOKORO_VOICE", first(env("KOKORO_VOICE"), "af_heart")
+			}
+			side.Model = &Setting{Key: k, Value: v, Language: KokoroLanguage(v)}
+			if l := first(str(pc, "kokoro_lang"), env("KOKORO_LANG")); l != "" {
+				side.Requested = &Setting{Key: "KOKORO_LANG", Value: l, Language: KokoroLanguage(l)}
+			}
+		case "silero":
+			if v := env("SILERO_LANGUAGE"); v != "" {
+				side.Requested = &Setting{Key: "SILERO_LANGUAGE", Value: v, Language: Base(v)}
+			}
+		case "melotts":
+			v := first(env("MELOTTS_VOICE"), "EN-US")
+			side.Model = &Setting{Key: "MELOTTS_VOICE", Value: v, Language: Base(v)}
+		default:
+			k, v := key("tts_voice"), str(pc, "tts_voice")
+			if v == "" {
+				k, v = "LOCAL_TTS_MODEL_PATH", env("LOCAL_TTS_MODEL_PATH")
+			}
+			if v != "" {
+				side.Model = &Setting{Key: k, Value: v, Language: ModelLanguage(v)}
+			}
+		}
+		return side, true
+	}
+	// language is the STT language on a provider that also transcribes.
+	langKeys := ttsLanguageKeys
+	if stt {
+		langKeys = langKeys[:2]
+	}
+	for _, k := range langKeys {
+		if v := str(pc, k); v != "" {
+			side.Requested = &Setting{Key: key(k), Value: v, Language: Base(v)}
+			break
+		}
+	}
+	for _, k := range ttsVoiceKeys {
+		v := str(pc, k)
+		if v == "" {
+			continue
+		}
+		s := &Setting{Key: key(k), Value: v, Language: ModelLanguage(v)}
+		if side.Model == nil || side.Model.Language == "" {
+			side.Model = s
+		}
+	}
+	return side, true
+}
+
+// Resolve returns the STT and TTS sides of name, a provider or a pipeline. A
+// pipeline's options.stt and options.tts override its providers' settings.
+// ok is false when name is neither.
+func Resolve(cfg map[string]any, name string, env Env) (stt, tts Side, ok bool) {
+	providers, _ := cfg["providers"].(map[string]any)
+	if pl, isPipeline := mapAt(cfg, "pipelines")[name].(map[string]any); isPipeline && name != "" {
+		options, _ := pl["options"].(map[string]any)
+		for _, role := range []string{"stt", "tts"} {
+			p := str(pl, role)
+			pc, _ := providers[p].(map[string]any)
+			if pc == nil {
+				continue
+			}
+			merged := overlay(pc, mapAt(options, role))
+			if role == "stt" {
+				stt, _ = STT(p, merged, env)
+				relabel(stt.Requested, p, name, mapAt(options, role))
+				relabel(stt.Model, p, name, mapAt(options, role))
+			} else {
+				tts, _ = TTS(p, merged, env)
+				relabel(tts.Requested, p, name, mapAt(options, role))
+				relabel(tts.Model, p, name, mapAt(options, role))
+			}
+		}
+		return stt, tts, true
+	}
+	pc, isProvider := providers[name].(map[string]any)
+	if !isProvider {
+		return Side{}, Side{}, false
+	}
+	stt, _ = STT(name, pc, env)
+	tts, _ = TTS(name, pc, env)
+	return stt, tts, true
+}
+
+// Check reports the language problems of the enabled providers and of the
+// pipelines built from them: a language the model or voice cannot handle,
+// and a provider or pipeline that listens in one language and speaks another.
+func Check(cfg map[string]any, env Env) []string {
+	var out []string
+	seen := map[string]bool{}
+	add := func(format string, args ...any) {
+		if msg := fmt.Sprintf(format, args...); !seen[msg] {
+			seen[msg] = true
+			out = append(out, msg)
+		}
+	}
+	compare := func(what string, stt, tts Side) {
+		for _, s := range []Side{stt, tts} {
+			if m := s.Mismatch(); m != "" {
+				add("%s", m)
+			}
+		}
+		if Conflict(stt.Language(), tts.Language()) {
+			add("%s transcribes callers in %s but speaks %s", what, stt.Describe(), tts.Describe())
+		}
+	}
+
+	providers := mapAt(cfg, "providers")
+	for _, name := range sortedKeys(providers) {
+		pc, _ := providers[name].(map[string]any)
+		if pc == nil || pc["enabled"] == false {
+			continue
+		}
+		stt, tts, _ := Resolve(cfg, name, env)
+		compare(name, stt, tts)
+	}
+	pipelines := mapAt(cfg, "pipelines")
+	for _, name := range sortedKeys(pipelines) {
+		pl, _ := pipelines[name].(map[string]any)
+		if !enabled(providers, str(pl, "stt")) || !enabled(providers, str(pl, "tts")) {
+			continue
+		}
+		stt, tts, _ := Resolve(cfg, name, env)
+		compare("pipeline "+name, stt, tts)
+	}
+	return out
+}
+
+// ForContext resolves the sides a context runs with: its pipeline, else its
+// provider, else default_provider, with the context's voice in place of the
+// provider's.
+func ForContext(cfg, ctx map[string]any, env Env) (stt, tts Side, ok bool) {
+	name := first(str(ctx, "pipeline"), str(ctx, "provider"), str(cfg, "default_provider"))
+	if stt, tts, ok = Resolve(cfg, name, env); !ok {
+		return Side{}, Side{}, false
+	}
+	if v := str(ctx, "voice"); v != "" && tts.Provider != "" {
+		tts.Model = &Setting{Key: "voice", Value: v, Language: ModelLanguage(v)}
+		if tts.Requested != nil && Conflict(tts.Requested.Language, tts.Model.Language) {
+			tts.Requested = nil
+		}
+	}
+	return stt, tts, true
+}
+
+// isLocal reports whether the provider runs on the local AI server.
+func isLocal(name string, pc map[string]any) bool {
+	return name == "local" || str(pc, "type") == "local"
+}
+
+// relabel points a setting a pipeline option overrides at the option.
+func relabel(s *Setting, provider, pipeline string, options map[string]any) {
+	if s == nil {
+		return
+	}
+	k := strings.TrimPrefix(s.Key, "providers."+provider+".")
+	if _, ok := options[k]; ok && k != s.Key {
+		s.Key = "pipelines." + pipeline + ".options." + k
+	}
+}
+
+func overlay(base, over map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		out[k] = v
+	}
+	return out
+}
+
+func enabled(providers map[string]any, name string) bool {
+	pc, ok := providers[name].(map[string]any)
+	return ok && pc["enabled"] != false
+}
+
+func mapAt(m map[string]any, key string) map[string]any {
+	v, _ := m[key].(map[string]any)
+	return v
+}
+
+func str(m map[string]any, key string) string {
+	v, ok := m[key]
+	if !ok || v == nil {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(s)
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,148 @@
+package locale
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// scripts map a writing system to the language Detect reports for it, and
+// the languages written in it that match that report.
+var scripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+	also  []string
+}{
+	{unicode.Cyrillic, "ru", []string{"uk", "bg", "sr", "mk", "be", "kk"}},
+	{unicode.Arabic, "ar", []string{"fa", "ur"}},
+	{unicode.Hebrew, "he", nil},
+	{unicode.Greek, "el", nil},
+	{unicode.Devanagari, "hi", []string{"mr", "ne"}},
+	{unicode.Thai, "th", nil},
+	{unicode.Hangul, "ko", nil},
+	{unicode.Hiragana, "ja", nil},
+	{unicode.Katakana, "ja", nil},
+	{unicode.Han, "zh", []string{"ja"}},
+}
+
+// stopwords are frequent short words of the Latin-script languages Detect
+// tells apart, with the greetings and courtesies calls open with.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "you", "is", "are", "to", "of", "what", "how", "can", "i", "my", "your", "with", "for", "this", "that", "have", "please", "thank", "thanks", "hello", "help", "it", "we", "do", "me"},
+	"es": {"el", "los", "las", "que", "y", "en", "un", "una", "es", "por", "para", "con", "usted", "hola", "gracias", "ayudar", "puedo", "cómo", "qué", "su", "está", "buenos", "días", "yo", "mi", "necesito"},
+	"fr": {"le", "les", "et", "est", "une", "vous", "je", "nous", "pour", "avec", "bonjour", "merci", "aider", "comment", "qui", "votre", "pas", "suis", "c'est", "au", "du", "oui"},
+	"de": {"der", "die", "das", "und", "ist", "ich", "sie", "wir", "nicht", "mit", "für", "ein", "eine", "hallo", "danke", "helfen", "wie", "kann", "ihnen", "zu", "guten", "tag", "bitte", "ja"},
+	"it": {"il", "lo", "di", "che", "è", "per", "non", "sono", "ciao", "grazie", "aiutare", "come", "posso", "buongiorno", "mi", "lei", "della", "sì", "vorrei"},
+	"pt": {"o", "os", "um", "uma", "é", "não", "você", "olá", "obrigado", "obrigada", "ajudar", "como", "posso", "bom", "dia", "em", "eu", "sim", "do", "da"},
+	"nl": {"het", "een", "ik", "je", "u", "niet", "met", "voor", "van", "hallo", "dank", "helpen", "hoe", "wij", "goedemorgen", "graag", "wat", "ja"},
+	"tr": {"bir", "ve", "bu", "ne", "için", "merhaba", "teşekkür", "ederim", "nasıl", "yardımcı", "olabilirim", "size", "ben", "mi", "var", "evet", "hayır", "lütfen"},
+	"pl": {"w", "nie", "się", "na", "jest", "to", "że", "dzień", "dobry", "dziękuję", "jak", "mogę", "pomóc", "pan", "pani", "czy", "tak"},
+}
+
+var stopwordLangs = func() map[string][]string {
+	m := map[string][]string{}
+	for lang, words := range stopwords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// Detect guesses the language text is written in: by script for non-Latin
+// text, by frequent words for Latin text. It returns "" when the text is too
+// short or too mixed to tell.
+func Detect(text string) string {
+	counts := map[string]int{}
+	letters, latin := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.lang]++
+				break
+			}
+		}
+	}
+	if letters < 3 {
+		return ""
+	}
+	if 2*latin < letters {
+		// Kana marks Japanese even when most characters are Han.
+		if counts["ja"] > 0 {
+			return "ja"
+		}
+		best, n := "", 0
+		for lang, c := range counts {
+			if c > n {
+				best, n = lang, c
+			}
+		}
+		return best
+	}
+
+	hits := map[string]int{}
+	words := 0
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) && r != '\'' }) {
+		words++
+		for _, lang := range stopwordLangs[w] {
+			hits[lang]++
+		}
+	}
+	if words < 3 {
+		return ""
+	}
+	best, first, second := "", 0, 0
+	for lang, n := range hits {
+		switch {
+		case n > first:
+			best, first, second = lang, n, first
+		case n > second:
+			second = n
+		}
+	}
+	if first < 2 || 2*first < 3*second {
+		return ""
+	}
+	return best
+}
+
+// Matches reports whether text detected as detected fits the language want.
+// A script-level detection matches every language written in that script.
+func Matches(want, detected string) bool {
+	if !Conflict(want, detected) {
+		return true
+	}
+	for _, s := range scripts {
+		if s.lang != detected {
+			continue
+		}
+		for _, l := range s.also {
+			if l == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// instruction finds the language a prompt tells the model to use, as in
+// "Always respond in Spanish" or "Speak only in German".
+var instruction = regexp.MustCompile(`(?i)\b(?:respond|reply|speak|answer|talk|converse|communicate)\w*\s+(?:only\s+|always\s+|exclusively\s+)?in\s+([a-z]+)`)
+
+// Instructed returns the language a prompt tells the agent to speak, or "".
+func Instructed(prompt string) string {
+	for _, m := range instruction.FindAllStringSubmatch(prompt, -1) {
+		if c, ok := names[strings.ToLower(m[1])]; ok {
+			return c
+		}
+	}
+	return ""
+}
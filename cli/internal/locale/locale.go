@@ -0,0 +1,186 @@
+// Package locale works out which language each part of a call runs in: the
+// language a provider's STT is asked for and the one its model can transcribe,
+// the language its TTS voice speaks, and the language a context greets
+// callers in. A mismatch between them fails quietly on the call: the caller's
+// words come back empty or as nonsense, or the agent reads Spanish with an
+// English voice.
+package locale
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Multi marks a model or voice that handles many languages.
+const Multi = "multi"
+
+// names maps the language names used in model IDs and prompts, and a few
+// country-style codes found in model names, to ISO 639-1 codes.
+var names = map[string]string{
+	"english": "en", "spanish": "es", "french": "fr", "german": "de", "italian": "it",
+	"portuguese": "pt", "dutch": "nl", "turkish": "tr", "polish": "pl", "russian": "ru",
+	"ukrainian": "uk", "arabic": "ar", "hebrew": "he", "greek": "el", "hindi": "hi",
+	"japanese": "ja", "chinese": "zh", "mandarin": "zh", "cantonese": "zh", "korean": "ko",
+	"thai": "th", "vietnamese": "vi", "swedish": "sv", "danish": "da", "norwegian": "no",
+	"finnish": "fi", "czech": "cs", "indonesian": "id", "malay": "ms", "persian": "fa",
+	"farsi": "fa", "urdu": "ur", "bengali": "bn", "tamil": "ta", "romanian": "ro",
+	"hungarian": "hu", "catalan": "ca", "filipino": "tl", "tagalog": "tl",
+	"cn": "zh", "cmn": "zh", "yue": "zh", "ua": "uk", "jp": "ja", "nb": "no", "nn": "no",
+}
+
+// codes are the languages Base accepts as a primary subtag, with the
+// aliases from names.
+var codes = func() map[string]string {
+	m := map[string]string{}
+	for alias, c := range names {
+		m[c] = c
+		if len(alias) <= 3 {
+			m[alias] = c
+		}
+	}
+	for _, c := range []string{"af", "be", "bg", "cy", "eu", "gl", "gu", "hr", "hy", "is", "ka", "kk", "kn", "lt", "lv", "mk", "ml", "mr", "ne", "pa", "si", "sk", "sl", "sr", "sw", "te"} {
+		m[c] = c
+	}
+	return m
+}()
+
+var displayNames = map[string]string{
+	"en": "English", "es": "Spanish", "fr": "French", "de": "German", "it": "Italian",
+	"pt": "Portuguese", "nl": "Dutch", "tr": "Turkish", "pl": "Polish", "ru": "Russian",
+	"uk": "Ukrainian", "ar": "Arabic", "he": "Hebrew", "el": "Greek", "hi": "Hindi",
+	"ja": "Japanese", "zh": "Chinese", "ko": "Korean", "th": "Thai", "vi": "Vietnamese",
+	"sv": "Swedish", "da": "Danish", "no": "Norwegian", "fi": "Finnish", "cs": "Czech",
+	"id": "Indonesian", "ms": "Malay", "fa": "Persian", "ur": "Urdu", "bn": "Bengali",
+	"ta": "Tamil", "ro": "Romanian", "hu": "Hungarian", "ca": "Catalan", "tl": "Filipino",
+}
+
+// Base returns the ISO 639-1 code of a BCP-47 or POSIX tag ("en-US", "pt_BR",
+// "es"), of an English language name ("Spanish"), or Multi for "multi". It
+// returns "" when tag names no language it knows.
+func Base(tag string) string {
+	t := strings.ToLower(strings.TrimSpace(tag))
+	if t == "" {
+		return ""
+	}
+	if t == Multi || t == "multilingual" || t == "auto" {
+		return Multi
+	}
+	if c, ok := names[t]; ok {
+		return c
+	}
+	primary, _, _ := strings.Cut(strings.NewReplacer("_", "-", ".", "-").Replace(t), "-")
+	return codes[primary]
+}
+
+// Name is the English name of a language code, for messages.
+func Name(code string) string {
+	if n := displayNames[code]; n != "" {
+		return n
+	}
+	if code == Multi {
+		return "many languages"
+	}
+	return code
+}
+
+// Conflict reports whether two languages are both known and differ. A model
+// or voice that handles many languages conflicts with none.
+func Conflict(a, b string) bool {
+	return a != "" && b != "" && a != Multi && b != Multi && a != b
+}
+
+var (
+	// vosk-model-en-us-0.22, vosk-model-small-fr-0.22, vosk-model-small-cn-0.22
+	voskModel = regexp.MustCompile(`^vosk-model-(?:small-)?([a-z]{2,3})\b`)
+	// Piper voices: en_US-lessac-medium.onnx, de_DE-thorsten-high
+	piperVoice = regexp.MustCompile(`^([a-z]{2,3})_[a-z]{2}-`)
+	// Azure and Google Cloud voices: en-US-JennyNeural, es-ES-Neural2-A, cmn-CN-Wavenet-A
+	cloudVoice = regexp.MustCompile(`^([a-z]{2,3})-[a-z]{2,4}-`)
+)
+
+// ModelLanguage returns the language a model or voice ID implies: its code
+// for a single-language model, Multi for a multilingual one, and "" when the
+// ID does not say (an OpenAI voice, an ElevenLabs voice ID).
+func ModelLanguage(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return ""
+	}
+	base := path.Base(strings.ReplaceAll(id, `\`, "/"))
+	for _, ext := range []string{".onnx", ".bin", ".gguf", ".json"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	switch {
+	case strings.Contains(base, "multilingual"), strings.HasSuffix(base, "-multi"):
+		return Multi
+	case strings.Contains(base, "whisper"):
+		return WhisperLanguage(base)
+	case voskModel.MatchString(base):
+		return codes[voskModel.FindStringSubmatch(base)[1]]
+	case piperVoice.MatchString(base):
+		return codes[piperVoice.FindStringSubmatch(base)[1]]
+	case cloudVoice.MatchString(base):
+		return codes[cloudVoice.FindStringSubmatch(base)[1]]
+	case strings.HasPrefix(base, "eleven_"):
+		// eleven_monolingual_v1, eleven_turbo_v2 and eleven_flash_v2 are
+		// English only; the _v2_5 models and v3 are multilingual.
+		if base == "eleven_monolingual_v1" || base == "eleven_turbo_v2" || base == "eleven_flash_v2" {
+			return "en"
+		}
+		return Multi
+	case strings.HasPrefix(base, "nova-2-"), strings.HasPrefix(base, "nova-3-"):
+		// Deepgram's domain models (phonecall, meeting, medical...) are English only.
+		return "en"
+	case strings.HasPrefix(base, "nova-"):
+		return Multi
+	case strings.HasPrefix(base, "t-one"):
+		return "ru"
+	}
+	// A language as the last part (aura-2-thalia-en, flux-general-en,
+	// v3_1_ru) or as a word (orpheus-v1-english, orpheus-arabic-saudi,
+	// sherpa-onnx-zipformer-en-2023-06-26, kroko-en-v1.0).
+	parts := strings.FieldsFunc(base, func(r rune) bool { return r == '-' || r == '_' || r == '.' })
+	for _, p := range parts {
+		if c, ok := names[p]; ok && len(p) > 3 {
+			return c
+		}
+	}
+	if len(parts) > 1 {
+		if c := codes[parts[len(parts)-1]]; c != "" {
+			return c
+		}
+	}
+	if strings.Contains(base, "sherpa") || strings.Contains(base, "kroko") || strings.Contains(base, "zipformer") {
+		for _, p := range parts {
+			if c := codes[p]; c != "" {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// WhisperLanguage is the language of a Whisper model by name: the ".en"
+// models (base.en, ggml-base.en, distil-whisper-large-v3-en) are English
+// only, the others multilingual.
+func WhisperLanguage(model string) string {
+	m := strings.ToLower(model)
+	if strings.HasSuffix(m, ".en") || strings.HasSuffix(m, "-en") || strings.HasSuffix(m, "_en") {
+		return "en"
+	}
+	return Multi
+}
+
+// kokoroLanguages maps the first letter of a Kokoro voice (af_heart,
+// ef_dora) and KOKORO_LANG to the language it speaks.
+var kokoroLanguages = map[byte]string{'a': "en", 'b': "en", 'e': "es", 'f': "fr", 'h': "hi", 'i': "it", 'j': "ja", 'p': "pt", 'z': "zh"}
+
+// KokoroLanguage is the language of a Kokoro voice or language letter.
+func KokoroLanguage(voice string) string {
+	v := strings.ToLower(strings.TrimSpace(voice))
+	if v == "" {
+		return ""
+	}
+	return kokoroLanguages[v[0]]
+}
@@ -0,0 +1,166 @@
+package locale
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+)
+
+func TestModelLanguage(t *testing.T) {
+	for id, want := range map[string]string{
+		"models/stt/vosk-model-en-us-0.22":           "en",
+		"/app/models/stt/vosk-model-small-cn-0.22":   "zh",
+		"/app/models/tts/en_US-lessac-medium.onnx":   "en",
+		"de_DE-thorsten-high":                        "de",
+		"en-US-JennyNeural":                          "en",
+		"es-ES-AlvaroNeural":                         "es",
+		"en-US-AvaMultilingualNeural":                Multi,
+		"cmn-CN-Wavenet-A":                           "zh",
+		"aura-2-thalia-en":                           "en",
+		"aura-2-celeste-es":                          "es",
+		"flux-general-en":                            "en",
+		"flux-general-multi":                         Multi,
+		"nova-3":                                     Multi,
+		"nova-2-phonecall":                           "en",
+		"whisper-large-v3-turbo":                     Multi,
+		"distil-whisper-large-v3-en":                 "en",
+		"/app/models/stt/ggml-base.en.bin":           "en",
+		"canopylabs/orpheus-v1-english":              "en",
+		"canopylabs/orpheus-arabic-saudi":            "ar",
+		"eleven_turbo_v2":                            "en",
+		"eleven_flash_v2_5":                          Multi,
+		"sherpa-onnx-zipformer-en-2023-06-26":        "en",
+		"v3_1_ru":                                    "ru",
+		"gpt-4o-mini-tts":                            "",
+		"alloy":                                      "",
+		"gemini-2.5-flash-native-audio-latest":       "",
+		"mlx-community/Qwen3-8B-4bit":                "",
+		"/app/models/kroko/kroko-en-v1.0.onnx":       "en",
+		"tts-1":                                      "",
+		"models/llm/phi-3-mini-4k-instruct.Q4_K_M":   "",
+		"/app/models/stt/sherpa-onnx-streaming-fr-x": "fr",
+	} {
+		if got := ModelLanguage(id); got != want {
+			t.Errorf("ModelLanguage(%q) = %q, want %q", id, got, want)
+		}
+	}
+	for tag, want := range map[string]string{"en-US": "en", "pt_BR": "pt", "Spanish": "es", "multi": Multi, "cmn-Hans-CN": "zh", "xx-YY": ""} {
+		if got := Base(tag); got != want {
+			t.Errorf("Base(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for text, want := range map[string]string{
+		"Hello! How can I help you today?":                               "en",
+		"Hola, gracias por llamar. ¿En qué puedo ayudarle?":              "es",
+		"Bonjour, je suis votre assistant. Comment puis-je vous aider ?": "fr",
+		"Guten Tag, wie kann ich Ihnen helfen?":                          "de",
+		"Merhaba, size nasıl yardımcı olabilirim?":                       "tr",
+		"Здравствуйте, чем я могу помочь?":                               "ru",
+		"مرحبا، كيف يمكنني مساعدتك؟":                                     "ar",
+		"こんにちは、ご用件をお伺いします":                                               "ja",
+		"您好，请问有什么可以帮您":                                                   "zh",
+		"ok":                                                             "",
+		"Acme Corp":                                                      "",
+	} {
+		if got := Detect(text); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", text, got, want)
+		}
+	}
+	if !Matches("uk", "ru") || Matches("es", "en") || !Matches("es", "") {
+		t.Error("Matches")
+	}
+	if got := Instructed("You are a receptionist. Always respond in Spanish, even when asked in English."); got != "es" {
+		t.Errorf("Instructed = %q", got)
+	}
+}
+
+const testConfig = `
+default_provider: local
+providers:
+  local:
+    type: full
+    capabilities: [stt, llm, tts]
+    stt_model: models/stt/vosk-model-en-us-0.22
+    tts_backend: kokoro
+  azure_stt:
+    type: azure
+    capabilities: [stt]
+    language: es-ES
+  azure_tts:
+    type: azure
+    capabilities: [tts]
+    voice_name: en-US-JennyNeural
+  deepgram:
+    type: full
+    model: flux-general-en
+    stt_language: es
+    tts_model: aura-2-celeste-es
+  groq_stt:
+    type: groq
+    enabled: false
+    stt_model: distil-whisper-large-v3-en
+    language: tr
+  local_stt:
+    type: local
+    capabilities: [stt]
+    stt_model: models/stt/vosk-model-en-us-0.22
+  elevenlabs_tts:
+    type: elevenlabs
+    capabilities: [tts]
+    model_id: eleven_flash_v2_5
+pipelines:
+  azure:
+    stt: azure_stt
+    llm: openai_llm
+    tts: azure_tts
+  spanish:
+    stt: local_stt
+    tts: elevenlabs_tts
+    options:
+      stt:
+        stt_model: models/stt/vosk-model-small-es-0.42
+`
+
+func TestCheck(t *testing.T) {
+	cfg, err := configmerge.ParseYAML([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(Check(cfg, func(string) string { return "" }), "\n")
+	for _, want := range []string{
+		"providers.deepgram.stt_language is es (Spanish), but providers.deepgram.model flux-general-en only handles English",
+		"pipeline azure transcribes callers in Spanish (providers.azure_stt.language es-ES) but speaks English (providers.azure_tts.voice_name en-US-JennyNeural)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Check lacks %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"groq_stt", "local ", "pipeline spanish", "deepgram transcribes"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("Check reports %q:\n%s", unwanted, got)
+		}
+	}
+
+	stt, _, _ := Resolve(cfg, "spanish", func(string) string { return "" })
+	if stt.Language() != "es" || stt.Model.Key != "pipelines.spanish.options.stt_model" {
+		t.Fatalf("pipeline option: %+v", stt.Model)
+	}
+
+	env := map[string]string{"LOCAL_STT_BACKEND": "faster_whisper", "FASTER_WHISPER_MODEL": "base.en", "FASTER_WHISPER_LANGUAGE": "de", "KOKORO_VOICE": "ef_dora"}
+	stt, tts, _ := Resolve(cfg, "local", func(k string) string { return env[k] })
+	if m := stt.Mismatch(); m != "FASTER_WHISPER_LANGUAGE is de (German), but FASTER_WHISPER_MODEL base.en only handles English" {
+		t.Fatalf("whisper mismatch = %q", m)
+	}
+	if tts.Language() != "es" {
+		t.Fatalf("kokoro voice: %+v", tts)
+	}
+
+	_, tts, ok := ForContext(cfg, map[string]any{"pipeline": "azure", "voice": "es-MX-DaliaNeural"}, func(string) string { return "" })
+	if !ok || tts.Language() != "es" || tts.Model.Key != "voice" {
+		t.Fatalf("context voice: %+v", tts)
+	}
+}
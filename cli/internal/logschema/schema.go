@@ -0,0 +1,389 @@
+// Package logschema is the contract between ai_engine's JSON logs and the Go
+// tooling that reads them (agent rca, the call index, agent trend). It names the
+// events and fields the tooling depends on and validates log lines against them,
+// so a renamed field shows up as a violation instead of silently empty analysis.
+package logschema
+
+// Version is raised when an event or field is removed from the contract or
+// changes meaning; adding optional fields does not raise it.
+const Version = 1
+
+// Kind is the JSON type a field must have.
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Bool
+	// ChannelID is an Asterisk channel uniqueid such as "1761518880.2191".
+	ChannelID
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Number:
+		return "number"
+	case Bool:
+		return "bool"
+	case ChannelID:
+		return "channel id"
+	}
+	return "string"
+}
+
+// Field is one key of a log line.
+type Field struct {
+	Name     string
+	Kind     Kind
+	Required bool
+}
+
+// Event is a log event the tooling parses, with the fields it reads.
+type Event struct {
+	Name   string
+	UsedBy string
+	Fields []Field
+}
+
+// Event names the tooling matches on.
+const (
+	EventCallStart          = "RCA_CALL_START"
+	EventSegmentSummary     = "Streaming segment bytes summary v2"
+	EventTuningSummary      = "🎛️ STREAMING TUNING SUMMARY"
+	EventProviderSegment    = "PROVIDER SEGMENT BYTES"
+	EventTransportAlignment = "Transport alignment summary"
+	EventVADSettings        = "🎯 WebRTC VAD settings"
+	EventTurnLatency        = "Turn latency recorded"
+
+	// Jitter buffer events read by agent rca --buffer.
+	EventStreamStarted       = "🎵 STREAMING PLAYBACK - Started"
+	EventStreamStopped       = "🎵 STREAMING PLAYBACK - Stopped"
+	EventStreamIdleCutoff    = "🎵 STREAMING PACER - Idle cutoff"
+	EventStreamTimeout       = "🎵 STREAMING PLAYBACK - Connection timeout"
+	EventStreamFrameSize     = "🎼 STREAM FRAME SIZE"
+	EventAdaptiveWarmup      = "🎚️ STREAMING ADAPTIVE WARM-UP"
+	EventWarmupComplete      = "Streaming jitter buffer warm-up complete"
+	EventWarmupSkipped       = "⚡ CONTINUOUS STREAM - Skipping warm-up for subsequent segment"
+	EventShortSegmentRelease = "Streaming startup released short final segment"
+	EventLowBufferTick       = "Low-buffer backoff tick"
+	EventRemainderDiscarded  = "Skipped remainder flush (barge-in)"
+	EventBargeInApplied      = "🎧 BARGE-IN action applied"
+	EventGateClosed          = "🚪 AUDIO GATE CLOSED - Agent started speaking"
+	EventGateOpened          = "🔓 AUDIO GATE OPENED - Agent finished speaking"
+	EventTTSGating           = "🔇 ConversationCoordinator gating audio"
+	EventTTSGatingCleared    = "🔊 ConversationCoordinator clearing gating"
+
+	// Caller speech onsets read by the echo path analysis.
+	EventBargeInAttempt              = "🎧 Barge-in attempt detected"
+	EventVADSpeechStarted            = "Enhanced VAD - Speech started"
+	EventProviderInterruption        = "🎤 User interruption detected, cancelling response"
+	EventProviderInterruptionNoAudio = "🎤 User interruption detected (no audio), cancelling response"
+	EventProviderSpeechStarted       = "🎤 User speech started (no active response); requesting platform flush"
+	EventBargeInBlockedYoung         = "🛡️  Barge-in blocked - response too young"
+	EventBargeInBlockedGreeting      = "🛡️  Barge-in blocked - protecting greeting response"
+
+	// Keypad digits read by the DTMF flow analysis.
+	EventChannelDTMF     = "Channel DTMF received"
+	EventAudioSocketDTMF = "AudioSocket DTMF received"
+
+	// Outbound answering machine detection read by the AMD analysis.
+	EventOutboundAnswered  = "Outbound answered"
+	EventOutboundAMDResult = "Outbound AMD result"
+
+	// Transfers and hangups read by the call ending analysis.
+	EventTransferRequested        = "Transfer requested"
+	EventTransferInvalid          = "Invalid destination"
+	EventExtensionTransfer        = "✅ Extension transfer initiated"
+	EventQueueTransfer            = "✅ Queue transfer initiated"
+	EventRingGroupTransfer        = "✅ Ring group transfer initiated"
+	EventTransferFailed           = "🔀 TRANSFER FAILED"
+	EventAttendedTransferLeg      = "📞 Attended transfer agent leg originated"
+	EventAttendedTransferDeclined = "🔀 ATTENDED TRANSFER - Declined/timeout, resuming caller"
+	EventAttendedTransferComplete = "🔀 ATTENDED TRANSFER COMPLETE - Caller bridged to destination; AI removed from audio"
+	EventHangupRequested          = "📞 Hangup requested"
+	EventChannelDestroyed         = "Channel destroyed"
+	EventCallCleanup              = "Call cleanup completed"
+	EventCallEnd                  = "RCA_CALL_END"
+
+	// Provider failures and recovery read by agent chaos and agent failover drill.
+	EventProviderStarted          = "Provider session started"
+	EventPipelineStarted          = "Pipeline-only mode: skipping legacy provider session; greeting will be handled by pipeline"
+	EventProviderStartFailed      = "Failed to start provider session"
+	EventProviderDisconnected     = "Provider disconnected"
+	EventProviderFailureRedirect  = "Provider-failure dialplan redirect initiated"
+	EventOpenAIReconnecting       = "Reconnecting to OpenAI Realtime"
+	EventOpenAIReconnected        = "OpenAI Realtime reconnected"
+	EventOpenAIReconnectExhausted = "OpenAI Realtime reconnection exhausted attempts"
+	EventGrokReconnecting         = "Reconnecting to Grok Voice Agent"
+	EventGrokReconnected          = "Grok Voice Agent reconnected"
+	EventGrokReconnectExhausted   = "Grok Voice Agent reconnection exhausted attempts"
+	EventLocalReconnecting        = "Attempting to reconnect to Local AI Server..."
+	EventLocalReconnected         = "✅ Reconnected to Local AI Server, restarting receive loop"
+	EventLocalReconnectFailed     = "Failed to reconnect to Local AI Server"
+
+	// Inbound RTP diagnostics, logged at debug level, read by agent chaos rtp.
+	EventRTPPacketLoss = "RTP packet loss detected"
+	EventRTPOutOfOrder = "RTP out-of-order packet"
+)
+
+// Envelope is required on every JSON line.
+var Envelope = []Field{
+	{Name: "event", Kind: String, Required: true},
+	{Name: "level", Kind: String, Required: true},
+	{Name: "timestamp", Kind: String, Required: true},
+}
+
+// IDFields must hold channel ids wherever they appear: call selection and log
+// correlation match them with a digits.digits pattern.
+var IDFields = []string{"call_id", "caller_channel_id", "audiosocket_channel_id", "external_media_id", "pending_external_media_id"}
+
+// Events are the events with a field contract.
+var Events = []Event{
+	{
+		Name:   EventCallStart,
+		UsedBy: "rca header, baseline selection",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "provider_name", Kind: String, Required: true},
+			{Name: "pipeline_name", Kind: String, Required: true},
+			{Name: "context_name", Kind: String, Required: true},
+			{Name: "audio_transport", Kind: String, Required: true},
+			{Name: "caller_number", Kind: String},
+			{Name: "called_number", Kind: String},
+			{Name: "caller_name", Kind: String},
+			{Name: "downstream_mode", Kind: String},
+			{Name: "tp_encoding", Kind: String},
+			{Name: "tp_sample_rate", Kind: Number},
+			{Name: "audiosocket_format", Kind: String},
+			{Name: "audiosocket_port", Kind: Number},
+			{Name: "external_media_codec", Kind: String},
+			{Name: "external_media_rtp_port", Kind: Number},
+			{Name: "streaming_sample_rate", Kind: Number},
+			{Name: "streaming_jitter_buffer_ms", Kind: Number},
+			{Name: "streaming_min_start_ms", Kind: Number},
+			{Name: "streaming_low_watermark_ms", Kind: Number},
+			{Name: "vad_webrtc_aggressiveness", Kind: Number},
+			{Name: "vad_confidence_threshold", Kind: Number},
+			{Name: "vad_energy_threshold", Kind: Number},
+			{Name: "vad_enhanced_enabled", Kind: Bool},
+			{Name: "barge_in_post_tts_end_protection_ms", Kind: Number},
+		},
+	},
+	{
+		Name:   EventSegmentSummary,
+		UsedBy: "underflow count, quality score, buffer view",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "stream_id", Kind: String, Required: true},
+			{Name: "underflow_events", Kind: Number, Required: true},
+			{Name: "buffered_bytes", Kind: Number},
+		},
+	},
+	{
+		Name:   EventTuningSummary,
+		UsedBy: "drift, underflow rate",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "stream_id", Kind: String, Required: true},
+			{Name: "bytes_sent", Kind: Number, Required: true},
+			{Name: "effective_seconds", Kind: Number, Required: true},
+			{Name: "wall_seconds", Kind: Number, Required: true},
+			{Name: "drift_pct", Kind: Number, Required: true},
+			{Name: "low_watermark", Kind: Number},
+			{Name: "min_start", Kind: Number},
+		},
+	},
+	{
+		Name:   EventProviderSegment,
+		UsedBy: "enqueued byte ratio",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "provider_bytes", Kind: Number, Required: true},
+			{Name: "enqueued_bytes", Kind: Number, Required: true},
+			{Name: "enqueued_ratio", Kind: Number, Required: true},
+		},
+	},
+	{
+		Name:   EventTransportAlignment,
+		UsedBy: "format alignment",
+		Fields: []Field{
+			{Name: "audiosocket_format", Kind: String, Required: true},
+		},
+	},
+	{
+		Name:   EventVADSettings,
+		UsedBy: "VAD settings",
+		Fields: []Field{
+			{Name: "aggressiveness", Kind: Number},
+		},
+	},
+	{
+		Name:   EventAdaptiveWarmup,
+		UsedBy: "buffer view thresholds",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "chunk_ms", Kind: Number, Required: true},
+			{Name: "jb_chunks", Kind: Number, Required: true},
+			{Name: "min_start_chunks", Kind: Number, Required: true},
+			{Name: "low_watermark_chunks", Kind: Number, Required: true},
+		},
+	},
+	{
+		Name:   EventStreamFrameSize,
+		UsedBy: "buffer view byte to ms conversion",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "frame_size_bytes", Kind: Number, Required: true},
+			{Name: "chunk_ms", Kind: Number, Required: true},
+		},
+	},
+	{
+		Name:   EventWarmupComplete,
+		UsedBy: "buffer view samples",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "buffered_chunks", Kind: Number, Required: true},
+		},
+	},
+	{
+		Name:   EventWarmupSkipped,
+		UsedBy: "buffer view samples",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "jitter_depth", Kind: Number, Required: true},
+		},
+	},
+	{
+		Name:   EventShortSegmentRelease,
+		UsedBy: "buffer view samples",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "buffered_frames", Kind: Number, Required: true},
+		},
+	},
+	{
+		Name:   EventChannelDTMF,
+		UsedBy: "DTMF flow (ARI events)",
+		Fields: []Field{
+			{Name: "channel_id", Kind: String, Required: true},
+			{Name: "digit", Kind: String, Required: true},
+		},
+	},
+	{
+		Name:   EventAudioSocketDTMF,
+		UsedBy: "DTMF flow (AudioSocket frames)",
+		Fields: []Field{
+			{Name: "conn_id", Kind: String, Required: true},
+			{Name: "digit", Kind: String, Required: true},
+			{Name: "caller_channel_id", Kind: ChannelID},
+		},
+	},
+	{
+		Name:   EventOutboundAnswered,
+		UsedBy: "AMD detection time",
+		Fields: []Field{
+			{Name: "channel_id", Kind: String, Required: true},
+			{Name: "attempt_id", Kind: String},
+		},
+	},
+	{
+		Name:   EventOutboundAMDResult,
+		UsedBy: "AMD verdict, voicemail symptom",
+		Fields: []Field{
+			{Name: "channel_id", Kind: String, Required: true},
+			{Name: "amd_status", Kind: String, Required: true},
+			{Name: "amd_cause", Kind: String},
+			{Name: "attempt_id", Kind: String},
+			{Name: "consent_result", Kind: String},
+		},
+	},
+	{
+		Name:   EventTransferRequested,
+		UsedBy: "call ending transfers",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "destination", Kind: String, Required: true},
+			{Name: "type", Kind: String},
+			{Name: "target", Kind: String},
+		},
+	},
+	{
+		Name:   EventTransferFailed,
+		UsedBy: "call ending transfers",
+		Fields: []Field{
+			{Name: "caller_id", Kind: String, Required: true},
+			{Name: "target", Kind: String},
+			{Name: "status", Kind: String},
+		},
+	},
+	{
+		Name:   EventCallEnd,
+		UsedBy: "call ending outcome",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "call_outcome", Kind: String, Required: true},
+			{Name: "transferred", Kind: Bool},
+			{Name: "transfer_destination", Kind: String},
+		},
+	},
+	{
+		Name:   EventChannelDestroyed,
+		UsedBy: "call index end, call ending hangup cause",
+		Fields: []Field{
+			{Name: "channel_id", Kind: String, Required: true},
+			{Name: "cause", Kind: Number},
+			{Name: "cause_txt", Kind: String},
+		},
+	},
+	{
+		Name:   EventProviderStarted,
+		UsedBy: "failover drill fallback provider",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "provider", Kind: String, Required: true},
+		},
+	},
+	{
+		Name:   EventPipelineStarted,
+		UsedBy: "failover drill fallback pipeline",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "pipeline", Kind: String, Required: true},
+		},
+	},
+	{
+		Name:   EventProviderStartFailed,
+		UsedBy: "chaos provider start failures, failover drill",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "error", Kind: String},
+		},
+	},
+	{
+		Name:   EventProviderDisconnected,
+		UsedBy: "chaos provider disconnects",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "provider", Kind: String},
+			{Name: "reason", Kind: String},
+		},
+	},
+	{
+		Name:   EventTurnLatency,
+		UsedBy: "trace turn latency",
+		Fields: []Field{
+			{Name: "call_id", Kind: ChannelID, Required: true},
+			{Name: "latency_ms", Kind: Number, Required: true},
+		},
+	},
+}
+
+// Lookup returns the contract for an event name.
+func Lookup(event string) (Event, bool) {
+	for _, e := range Events {
+		if e.Name == event {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
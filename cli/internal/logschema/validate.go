@@ -0,0 +1,155 @@
+package logschema
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var channelIDPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+
+// Violation is one line that breaks the contract.
+type Violation struct {
+	Line    int    `json:"line"`
+	Event   string `json:"event,omitempty"`
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+func (v Violation) String() string {
+	if v.Event != "" {
+		return fmt.Sprintf("line %d: %q %s: %s", v.Line, v.Event, v.Field, v.Problem)
+	}
+	return fmt.Sprintf("line %d: %s: %s", v.Line, v.Field, v.Problem)
+}
+
+// Result summarizes a validated sample of log lines.
+type Result struct {
+	Version    int            `json:"schema_version"`
+	Lines      int            `json:"lines"`
+	JSONLines  int            `json:"json_lines"`
+	Events     map[string]int `json:"events"` // contract events seen
+	Violations []Violation    `json:"violations,omitempty"`
+}
+
+// Validate checks every JSON line of r. Lines that are not JSON objects
+// (tracebacks, output of other processes) are counted but not checked.
+func Validate(r io.Reader) (Result, error) {
+	res := Result{Version: Version, Events: map[string]int{}}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		res.Lines++
+		event, violations, isJSON := ValidateLine(line)
+		if !isJSON {
+			continue
+		}
+		res.JSONLines++
+		if _, ok := Lookup(event); ok {
+			res.Events[event]++
+		}
+		for _, v := range violations {
+			v.Line = res.Lines
+			res.Violations = append(res.Violations, v)
+		}
+	}
+	return res, sc.Err()
+}
+
+// ValidateLine checks one line. isJSON is false for lines that are not a JSON
+// object; those have no violations.
+func ValidateLine(line string) (event string, violations []Violation, isJSON bool) {
+	var entry map[string]any
+	dec := json.NewDecoder(bytes.NewReader([]byte(line)))
+	dec.UseNumber()
+	if !strings.HasPrefix(strings.TrimSpace(line), "{") || dec.Decode(&entry) != nil {
+		return "", nil, false
+	}
+	event, _ = entry["event"].(string)
+	problem := func(field, msg string) {
+		violations = append(violations, Violation{Event: event, Field: field, Problem: msg})
+	}
+
+	for _, f := range Envelope {
+		checkField(entry, f, problem)
+	}
+	if ts, ok := entry["timestamp"].(string); ok {
+		if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+			problem("timestamp", "not an ISO 8601 time with offset")
+		}
+	}
+	contract, known := Lookup(event)
+	checked := map[string]bool{}
+	if known {
+		for _, f := range contract.Fields {
+			checkField(entry, f, problem)
+			checked[f.Name] = true
+		}
+	}
+	for _, name := range IDFields {
+		if !checked[name] {
+			checkField(entry, Field{Name: name, Kind: ChannelID}, problem)
+		}
+	}
+	return event, violations, true
+}
+
+func checkField(entry map[string]any, f Field, problem func(field, msg string)) {
+	v, present := entry[f.Name]
+	if !present {
+		if f.Required {
+			problem(f.Name, "missing")
+		}
+		return
+	}
+	if v == nil {
+		if f.Required && f.Kind != ChannelID {
+			problem(f.Name, "null")
+		}
+		return
+	}
+	ok := false
+	switch f.Kind {
+	case String:
+		_, ok = v.(string)
+	case Number:
+		_, ok = v.(json.Number)
+	case Bool:
+		_, ok = v.(bool)
+	case ChannelID:
+		s, isString := v.(string)
+		// Calls that never reached Stasis log an empty id.
+		ok = isString && (s == "" || channelIDPattern.MatchString(s))
+	}
+	if !ok {
+		problem(f.Name, fmt.Sprintf("want %s, got %s", f.Kind, describe(v)))
+	}
+}
+
+func describe(v any) string {
+	switch t := v.(type) {
+	case string:
+		if len(t) > 40 {
+			t = t[:40] + "…"
+		}
+		return fmt.Sprintf("%q", t)
+	case json.Number:
+		return "number " + t.String()
+	case bool:
+		return "bool"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
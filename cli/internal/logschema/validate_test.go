@@ -0,0 +1,68 @@
+package logschema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAcceptsEngineLines(t *testing.T) {
+	t.Parallel()
+
+	logs := strings.Join([]string{
+		`{"event":"RCA_CALL_START","level":"info","timestamp":"2026-01-30T17:21:40.123456-07:00","call_id":"1769818882.1484","provider_name":"deepgram","pipeline_name":"","context_name":"default","audio_transport":"audiosocket","audiosocket_port":8090,"vad_enhanced_enabled":false}`,
+		`{"event":"Streaming segment bytes summary v2","level":"info","timestamp":"2026-01-30T17:21:45.000000-07:00","call_id":"1769818882.1484","stream_id":"stream-1","underflow_events":0,"wall_seconds":1.2}`,
+		`{"event":"Pipeline runner started","level":"info","timestamp":"2026-01-30T17:21:45.000000-07:00","call_id":"1769818882.1484","audiosocket_channel_id":null}`,
+		`Traceback (most recent call last):`,
+	}, "\n")
+	res, err := Validate(strings.NewReader(logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Lines != 4 || res.JSONLines != 3 || res.Version != Version {
+		t.Fatalf("counts = %+v", res)
+	}
+	if len(res.Violations) != 0 {
+		t.Fatalf("unexpected violations: %v", res.Violations)
+	}
+	if res.Events[EventCallStart] != 1 || res.Events[EventSegmentSummary] != 1 || len(res.Events) != 2 {
+		t.Fatalf("events = %v", res.Events)
+	}
+}
+
+func TestValidateReportsRenamedAndRetypedFields(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		line, field, problem string
+	}{
+		{`{"event":"Streaming segment bytes summary v2","level":"info","timestamp":"2026-01-30T17:21:45Z","call_id":"1.2","stream_id":"s","underflows":3}`, "underflow_events", "missing"},
+		{`{"event":"PROVIDER SEGMENT BYTES","level":"info","timestamp":"2026-01-30T17:21:45Z","call_id":"1.2","provider_bytes":"100","enqueued_bytes":100,"enqueued_ratio":1}`, "provider_bytes", `want number, got "100"`},
+		{`{"event":"Call started","level":"info","timestamp":"2026-01-30T17:21:45Z","call_id":"PJSIP/6000-0000001a"}`, "call_id", "want channel id"},
+		{`{"event":"Call started","level":"info","ts":1769818882.5}`, "timestamp", "missing"},
+		{`{"event":"Call started","level":"info","timestamp":"17:21:45"}`, "timestamp", "not an ISO 8601"},
+		{`{"msg":"Call started","level":"info","timestamp":"2026-01-30T17:21:45Z"}`, "event", "missing"},
+	}
+	for _, c := range cases {
+		_, violations, isJSON := ValidateLine(c.line)
+		if !isJSON {
+			t.Fatalf("not treated as JSON: %s", c.line)
+		}
+		found := false
+		for _, v := range violations {
+			if v.Field == c.field && strings.Contains(v.Problem, c.problem) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: want %s %q, got %v", c.line, c.field, c.problem, violations)
+		}
+	}
+}
+
+func TestValidateLineIgnoresConsoleLines(t *testing.T) {
+	t.Parallel()
+
+	if _, v, isJSON := ValidateLine("2026-01-30 17:21:40 [info     ] RCA_CALL_START call_id=1.2"); isJSON || v != nil {
+		t.Fatalf("console line validated: %v", v)
+	}
+}
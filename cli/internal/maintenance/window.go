@@ -0,0 +1,152 @@
+// Package maintenance parses maintenance windows such as "Sat 02:00" or
+// "Mon-Fri 01:00-03:00" and answers when the next one opens.
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultLength is the window length when the spec has only a start time.
+const DefaultLength = 2 * time.Hour
+
+// Window is a recurring weekly window in local time.
+type Window struct {
+	Days   [7]bool       // indexed by time.Weekday; a window belongs to the day it opens
+	Start  time.Duration // offset from midnight
+	Length time.Duration
+	Spec   string
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parse reads "[days] HH:MM[-HH:MM]". days is "daily" (the default), a day name,
+// a comma list ("Sat,Sun") or a range ("Mon-Fri"). An end time before the start
+// time runs past midnight.
+func Parse(spec string) (*Window, error) {
+	fields := strings.Fields(spec)
+	w := &Window{Spec: strings.Join(fields, " "), Length: DefaultLength}
+	var days, clock string
+	switch len(fields) {
+	case 1:
+		days, clock = "daily", fields[0]
+	case 2:
+		days, clock = fields[0], fields[1]
+	default:
+		return nil, fmt.Errorf("invalid maintenance window %q (expected e.g. \"Sat 02:00\" or \"Mon-Fri 01:00-03:00\")", spec)
+	}
+	if err := w.parseDays(days); err != nil {
+		return nil, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+	}
+
+	startText, endText, hasEnd := strings.Cut(clock, "-")
+	start, err := parseClock(startText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+	}
+	w.Start = start
+	if hasEnd {
+		end, err := parseClock(endText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+		}
+		w.Length = end - start
+		if w.Length <= 0 {
+			w.Length += 24 * time.Hour
+		}
+	}
+	return w, nil
+}
+
+func (w *Window) parseDays(days string) error {
+	lower := strings.ToLower(days)
+	if lower == "daily" || lower == "*" {
+		for i := range w.Days {
+			w.Days[i] = true
+		}
+		return nil
+	}
+	for _, part := range strings.Split(lower, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		a, ok := dayNames[abbrev(from)]
+		if !ok {
+			return fmt.Errorf("unknown day %q", from)
+		}
+		if !isRange {
+			w.Days[a] = true
+			continue
+		}
+		b, ok := dayNames[abbrev(to)]
+		if !ok {
+			return fmt.Errorf("unknown day %q", to)
+		}
+		for d := a; ; d = (d + 1) % 7 {
+			w.Days[d] = true
+			if d == b {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func abbrev(day string) string {
+	day = strings.TrimSpace(day)
+	if len(day) > 3 {
+		return day[:3]
+	}
+	return day
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("time %q is not HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// opening returns the window start on the calendar day of t.
+func (w *Window) opening(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location()).Add(w.Start)
+}
+
+// Current returns the end of the window containing t, if any.
+func (w *Window) Current(t time.Time) (time.Time, bool) {
+	// A window that opened yesterday may still be running past midnight.
+	for back := 0; back <= 1; back++ {
+		day := t.AddDate(0, 0, -back)
+		if !w.Days[day.Weekday()] {
+			continue
+		}
+		start := w.opening(day)
+		end := start.Add(w.Length)
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Next returns the first window opening after t.
+func (w *Window) Next(t time.Time) time.Time {
+	for ahead := 0; ahead <= 7; ahead++ {
+		day := t.AddDate(0, 0, ahead)
+		if !w.Days[day.Weekday()] {
+			continue
+		}
+		if start := w.opening(day); start.After(t) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+func (w *Window) String() string {
+	return fmt.Sprintf("%s (%s)", w.Spec, w.Length)
+}
@@ -0,0 +1,64 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseAndCurrent(t *testing.T) {
+	w, err := Parse("Sat 02:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-10-17 is a Saturday.
+	if end, ok := w.Current(at("2026-10-17 03:30")); !ok || !end.Equal(at("2026-10-17 04:00")) {
+		t.Fatalf("Current(Sat 03:30) = %v, %v", end, ok)
+	}
+	if _, ok := w.Current(at("2026-10-17 04:00")); ok {
+		t.Fatal("window should close after the default two hours")
+	}
+	if next := w.Next(at("2026-10-15 12:00")); !next.Equal(at("2026-10-17 02:00")) {
+		t.Fatalf("Next = %v", next)
+	}
+	if next := w.Next(at("2026-10-17 02:30")); !next.Equal(at("2026-10-24 02:00")) {
+		t.Fatalf("Next from inside the window = %v", next)
+	}
+}
+
+func TestParseRangesAndMidnight(t *testing.T) {
+	w, err := Parse("Mon-Fri 23:00-01:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Length != 2*time.Hour {
+		t.Fatalf("Length = %v", w.Length)
+	}
+	// Friday's window runs into Saturday morning; Saturday has none of its own.
+	if _, ok := w.Current(at("2026-10-17 00:30")); !ok {
+		t.Fatal("Friday 23:00 window should still be open at Saturday 00:30")
+	}
+	if _, ok := w.Current(at("2026-10-17 23:30")); ok {
+		t.Fatal("Saturday is not a window day")
+	}
+	if next := w.Next(at("2026-10-17 12:00")); !next.Equal(at("2026-10-19 23:00")) {
+		t.Fatalf("Next = %v", next)
+	}
+
+	daily, err := Parse("03:15")
+	if err != nil || !daily.Days[time.Wednesday] || daily.Start != 3*time.Hour+15*time.Minute {
+		t.Fatalf("daily = %+v, %v", daily, err)
+	}
+	for _, bad := range []string{"", "Someday 02:00", "Sat 25:00", "Sat 02:00 extra"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
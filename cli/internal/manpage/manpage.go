@@ -0,0 +1,155 @@
+// Package manpage renders cobra commands as roff man pages (section 1), one page
+// per command, in the layout of cobra/doc without its go-md2man dependency.
+package manpage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Header fills the .TH line of every page.
+type Header struct {
+	Source string // e.g. "agent 7.2.0"
+	Manual string // e.g. "Asterisk AI Voice Agent"
+	Date   string // e.g. "Oct 2026"
+}
+
+// Generate writes a page for cmd and each visible subcommand into dir and returns
+// the file names written.
+func Generate(cmd *cobra.Command, dir string, h Header) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var written []string
+	var walk func(c *cobra.Command) error
+	walk = func(c *cobra.Command) error {
+		for _, sub := range c.Commands() {
+			if documented(sub) {
+				if err := walk(sub); err != nil {
+					return err
+				}
+			}
+		}
+		name := PageName(c) + ".1"
+		if err := os.WriteFile(filepath.Join(dir, name), Render(c, h), 0o644); err != nil {
+			return err
+		}
+		written = append(written, name)
+		return nil
+	}
+	if err := walk(cmd); err != nil {
+		return written, err
+	}
+	sort.Strings(written)
+	return written, nil
+}
+
+// PageName is the page name of cmd: its command path joined with dashes.
+func PageName(cmd *cobra.Command) string {
+	return strings.ReplaceAll(cmd.CommandPath(), " ", "-")
+}
+
+// documented skips hidden, deprecated and help-only commands, like cobra/doc.
+func documented(c *cobra.Command) bool {
+	return c.IsAvailableCommand() && !c.IsAdditionalHelpTopicCommand()
+}
+
+// Render returns the roff source of cmd's page.
+func Render(cmd *cobra.Command, h Header) []byte {
+	var b bytes.Buffer
+	name := PageName(cmd)
+	fmt.Fprintf(&b, ".TH %q \"1\" %q %q %q\n", strings.ToUpper(name), h.Date, h.Source, h.Manual)
+	b.WriteString(".nh\n.ad l\n")
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", escape(name), escape(cmd.Short))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, "\\fB%s\\fP\n", escape(cmd.UseLine()))
+
+	b.WriteString(".SH DESCRIPTION\n")
+	long := cmd.Long
+	if long == "" {
+		long = cmd.Short
+	}
+	// Long texts are laid out by hand (lists, examples), so keep them unfilled.
+	fmt.Fprintf(&b, ".nf\n%s\n.fi\n", block(long))
+
+	writeFlags(&b, "OPTIONS", cmd.NonInheritedFlags())
+	writeFlags(&b, "OPTIONS INHERITED FROM PARENT COMMANDS", cmd.InheritedFlags())
+
+	if cmd.Example != "" {
+		fmt.Fprintf(&b, ".SH EXAMPLE\n.nf\n%s\n.fi\n", block(cmd.Example))
+	}
+
+	var see []string
+	if cmd.HasParent() {
+		see = append(see, PageName(cmd.Parent()))
+	}
+	for _, sub := range cmd.Commands() {
+		if documented(sub) {
+			see = append(see, PageName(sub))
+		}
+	}
+	if len(see) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		for i, s := range see {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "\\fB%s\\fP(1)", escape(s))
+		}
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+func writeFlags(b *bytes.Buffer, title string, flags *pflag.FlagSet) {
+	var entries []string
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Deprecated != "" {
+			return
+		}
+		var names string
+		if f.Shorthand != "" && f.ShorthandDeprecated == "" {
+			names = fmt.Sprintf("\\fB\\-%s\\fP, ", f.Shorthand)
+		}
+		names += fmt.Sprintf("\\fB\\-\\-%s\\fP", escape(f.Name))
+		if f.Value.Type() != "bool" && f.DefValue != "" && f.DefValue != "[]" {
+			names += fmt.Sprintf("=%s", escape(f.DefValue))
+		}
+		entries = append(entries, fmt.Sprintf(".TP\n%s\n%s\n", names, escape(f.Usage)))
+	})
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n", title)
+	for _, e := range entries {
+		b.WriteString(e)
+	}
+}
+
+// block escapes multi-line text, protecting lines that roff would read as requests.
+func block(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		l = escape(l)
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			l = "\\&" + l
+		}
+		lines[i] = l
+	}
+	return strings.Join(lines, "\n")
+}
+
+func escape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	return strings.ReplaceAll(s, "-", `\-`)
+}
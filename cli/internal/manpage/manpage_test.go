@@ -0,0 +1,56 @@
+package manpage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func testTree() *cobra.Command {
+	root := &cobra.Command{Use: "agent", Short: "AI agent CLI"}
+	root.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	check := &cobra.Command{Use: "check", Short: "Health report", Long: "Run checks.\n.agent/ holds state\n- item", Run: func(*cobra.Command, []string) {}}
+	check.Flags().String("profile", "full", "check profile")
+	hidden := &cobra.Command{Use: "doctor", Hidden: true, Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(check, hidden)
+	return root
+}
+
+func TestRenderEscapesAndListsFlags(t *testing.T) {
+	root := testTree()
+	check, _, _ := root.Find([]string{"check"})
+	page := string(Render(check, Header{Source: "agent 1.0", Manual: "Manual", Date: "Jan 2026"}))
+
+	for _, want := range []string{
+		`.TH "AGENT-CHECK" "1" "Jan 2026" "agent 1.0" "Manual"`,
+		"agent\\-check \\- Health report",
+		"\\&.agent/ holds state",
+		"\\- item",
+		"\\fB\\-\\-profile\\fP=full",
+		".SH OPTIONS INHERITED FROM PARENT COMMANDS",
+		"\\fB\\-v\\fP, \\fB\\-\\-verbose\\fP",
+		"\\fBagent\\fP(1)",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestGenerateSkipsHiddenCommands(t *testing.T) {
+	dir := t.TempDir()
+	written, err := Generate(testTree(), dir, Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"agent-check.1", "agent.1"}; !reflect.DeepEqual(written, want) {
+		t.Fatalf("written = %v, want %v", written, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "agent-doctor.1")); err == nil {
+		t.Error("hidden command should have no page")
+	}
+}
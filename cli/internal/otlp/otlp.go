@@ -0,0 +1,211 @@
+// Package otlp exports finished spans to an OpenTelemetry collector over
+// OTLP/HTTP with the JSON encoding, without pulling in the OpenTelemetry SDK.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the collector's standard OTLP/HTTP address.
+const DefaultEndpoint = "http://localhost:4318"
+
+// Span kinds used by the exporter (OTLP SpanKind values).
+const (
+	KindInternal = 1
+	KindServer   = 2
+)
+
+// Span is one finished span. A zero ParentID marks the root.
+type Span struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	ParentID   [8]byte
+	Name       string
+	Kind       int
+	Start, End time.Time
+	Attributes map[string]any
+	Events     []Event
+	Error      string // non-empty sets the span status to error
+}
+
+// Event is a timestamped annotation on a span.
+type Event struct {
+	Time       time.Time
+	Name       string
+	Attributes map[string]any
+}
+
+// Exporter posts spans to one collector.
+type Exporter struct {
+	HTTP     *http.Client
+	Endpoint string // full traces URL, see TracesURL
+	Headers  map[string]string
+}
+
+// TracesURL applies the OTLP/HTTP endpoint rules: a signal-specific endpoint
+// (OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) is used as is, a base endpoint
+// (OTEL_EXPORTER_OTLP_ENDPOINT) gets /v1/traces appended.
+func TracesURL(base string, signalSpecific bool) string {
+	base = strings.TrimSpace(base)
+	if signalSpecific {
+		return base
+	}
+	return strings.TrimRight(base, "/") + "/v1/traces"
+}
+
+// ParseHeaders reads the OTEL_EXPORTER_OTLP_HEADERS format: comma-separated
+// key=value pairs with URL-encoded values.
+func ParseHeaders(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid OTLP header %q (want key=value)", strings.TrimSpace(pair))
+		}
+		val, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP header %q: %w", k, err)
+		}
+		out[k] = val
+	}
+	return out, nil
+}
+
+// Payload builds the ExportTraceServiceRequest JSON body for one resource and
+// instrumentation scope.
+func Payload(resource map[string]any, scope string, spans []Span) map[string]any {
+	encoded := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		span := map[string]any{
+			"traceId":           hex.EncodeToString(s.TraceID[:]),
+			"spanId":            hex.EncodeToString(s.SpanID[:]),
+			"name":              s.Name,
+			"kind":              s.Kind,
+			"startTimeUnixNano": unixNano(s.Start),
+			"endTimeUnixNano":   unixNano(s.End),
+			"attributes":        attributes(s.Attributes),
+		}
+		if s.ParentID != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.ParentID[:])
+		}
+		if len(s.Events) > 0 {
+			var events []map[string]any
+			for _, e := range s.Events {
+				events = append(events, map[string]any{
+					"timeUnixNano": unixNano(e.Time),
+					"name":         e.Name,
+					"attributes":   attributes(e.Attributes),
+				})
+			}
+			span["events"] = events
+		}
+		if s.Error != "" {
+			span["status"] = map[string]any{"code": 2, "message": s.Error}
+		}
+		encoded = append(encoded, span)
+	}
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{"attributes": attributes(resource)},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": scope},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+// Export sends the spans in one request.
+func (e *Exporter) Export(ctx context.Context, resource map[string]any, scope string, spans []Span) error {
+	raw, err := json.Marshal(Payload(resource, scope, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	hc := e.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	// A 2xx can still carry partialSuccess with rejected spans.
+	var partial struct {
+		PartialSuccess struct {
+			RejectedSpans json.Number `json:"rejectedSpans"`
+			ErrorMessage  string      `json:"errorMessage"`
+		} `json:"partialSuccess"`
+	}
+	if json.Unmarshal(body, &partial) == nil {
+		if n, _ := partial.PartialSuccess.RejectedSpans.Int64(); n > 0 {
+			return fmt.Errorf("collector rejected %d span(s): %s", n, partial.PartialSuccess.ErrorMessage)
+		}
+	}
+	return nil
+}
+
+func unixNano(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// attributes encodes a map as OTLP KeyValues, sorted by key; nil values are dropped.
+func attributes(m map[string]any) []map[string]any {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		var v map[string]any
+		switch t := m[k].(type) {
+		case string:
+			v = map[string]any{"stringValue": t}
+		case bool:
+			v = map[string]any{"boolValue": t}
+		case int:
+			v = map[string]any{"intValue": strconv.Itoa(t)} // int64 is a JSON string in OTLP
+		case int64:
+			v = map[string]any{"intValue": strconv.FormatInt(t, 10)}
+		case float64:
+			v = map[string]any{"doubleValue": t}
+		case nil:
+			continue
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(t)}
+		}
+		out = append(out, map[string]any{"key": k, "value": v})
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracesURL(t *testing.T) {
+	if got := TracesURL("http://collector:4318/", false); got != "http://collector:4318/v1/traces" {
+		t.Fatalf("base endpoint: %s", got)
+	}
+	if got := TracesURL("http://collector:4318/custom", true); got != "http://collector:4318/custom" {
+		t.Fatalf("traces endpoint: %s", got)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	h, err := ParseHeaders("api-key=abc%3D%3D, x-tenant = ops ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h["api-key"] != "abc==" || h["x-tenant"] != "ops" || len(h) != 2 {
+		t.Fatalf("headers = %v", h)
+	}
+	if _, err := ParseHeaders("novalue"); err == nil {
+		t.Fatal("expected error for a pair without =")
+	}
+}
+
+func TestExportEncodesSpans(t *testing.T) {
+	var got map[string]any
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("api-key")
+		raw, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(raw, &got); err != nil {
+			t.Errorf("body is not JSON: %v", err)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	start := time.Unix(1700000000, 500)
+	root := Span{TraceID: [16]byte{1}, SpanID: [8]byte{2}, Name: "call", Kind: KindServer, Start: start, End: start.Add(time.Second),
+		Attributes: map[string]any{"aava.call_id": "1.2", "aava.turns": 3, "skip": nil}, Error: "boom"}
+	child := Span{TraceID: root.TraceID, SpanID: [8]byte{3}, ParentID: root.SpanID, Name: "stt", Kind: KindInternal, Start: start, End: start,
+		Events: []Event{{Time: start, Name: "log", Attributes: map[string]any{"severity": "error"}}}}
+	e := &Exporter{Endpoint: srv.URL + "/v1/traces", Headers: map[string]string{"api-key": "k"}}
+	if err := e.Export(context.Background(), map[string]any{"service.name": "aava"}, "scope", []Span{root, child}); err != nil {
+		t.Fatal(err)
+	}
+	if header != "k" {
+		t.Fatalf("api-key header = %q", header)
+	}
+	raw, _ := json.Marshal(got)
+	body := string(raw)
+	for _, want := range []string{
+		`"traceId":"01000000000000000000000000000000"`,
+		`"parentSpanId":"0200000000000000"`,
+		`"startTimeUnixNano":"1700000000000000500"`,
+		`{"key":"aava.turns","value":{"intValue":"3"}}`,
+		`"status":{"code":2,"message":"boom"}`,
+		`"stringValue":"aava"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("payload missing %s\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `"skip"`) {
+		t.Error("nil attribute was encoded")
+	}
+}
+
+func TestExportReportsRejections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/partial":
+			w.Write([]byte(`{"partialSuccess":{"rejectedSpans":"2","errorMessage":"too old"}}`))
+		default:
+			http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		}
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/partial", "/v1/traces"} {
+		e := &Exporter{Endpoint: srv.URL + path}
+		if err := e.Export(context.Background(), nil, "scope", []Span{{Name: "call"}}); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package output renders command results in the format selected with --output and
+// strips emoji and box drawing for terminals that cannot show them.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a value of the global --output flag.
+type Format string
+
+const (
+	Text Format = "text"
+	JSON Format = "json"
+	YAML Format = "yaml"
+)
+
+// Parse validates an --output value.
+func Parse(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case Text, JSON, YAML:
+		return f, nil
+	case "":
+		return Text, nil
+	}
+	return "", fmt.Errorf("--output must be text, json or yaml (got %q)", s)
+}
+
+// Structured reports whether f is a machine-readable format.
+func (f Format) Structured() bool {
+	return f == JSON || f == YAML
+}
+
+// Write encodes v as indented JSON or as YAML. YAML keys follow the json tags, so
+// both formats carry the same fields.
+func Write(w io.Writer, f Format, v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if f != YAML {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	// Decode into a node rather than a map so YAML keeps the struct field order.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		return err
+	}
+	blockStyle(&doc)
+	ye := yaml.NewEncoder(w)
+	ye.SetIndent(2)
+	if err := ye.Encode(&doc); err != nil {
+		return err
+	}
+	return ye.Close()
+}
+
+// blockStyle drops the flow and quoting styles the JSON source carries.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
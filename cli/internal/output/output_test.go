@@ -0,0 +1,74 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]Format{"": Text, "text": Text, "JSON": JSON, " yaml ": YAML} {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := Parse("xml"); err == nil {
+		t.Error("Parse(xml) should fail")
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	for in, want := range map[string]Profile{"": Support, "operator": Operator, " Developer ": Developer, "support": Support} {
+		got, err := ParseProfile(in)
+		if err != nil || got != want {
+			t.Errorf("ParseProfile(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseProfile("tier1"); err == nil {
+		t.Error("ParseProfile(tier1) should fail")
+	}
+}
+
+func TestWriteYAMLUsesJSONTags(t *testing.T) {
+	var buf bytes.Buffer
+	v := struct {
+		SchemaVersion int      `json:"schema_version"`
+		Items         []string `json:"items"`
+	}{1, []string{"a"}}
+	if err := Write(&buf, YAML, v); err != nil {
+		t.Fatal(err)
+	}
+	want := "schema_version: 1\nitems:\n  - a\n"
+	if buf.String() != want {
+		t.Errorf("YAML = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestASCII(t *testing.T) {
+	cases := map[string]string{
+		"✅ Docker":          "[OK] Docker",
+		"⚠️  warn":          "[WARN]  warn",
+		"🔍 Checking → next": " Checking -> next",
+		"╔══╗":              "+==+",
+		"├─ item":           "+- item",
+		"naïve":             "naïve",
+	}
+	for in, want := range cases {
+		if got := ASCII(in); got != want {
+			t.Errorf("ASCII(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPlainWriterSplitRune(t *testing.T) {
+	var buf bytes.Buffer
+	w := PlainWriter(&buf)
+	b := []byte("ok ✅ done\n")
+	split := strings.Index(string(b), "✅") + 1
+	_, _ = w.Write(b[:split])
+	_, _ = w.Write(b[split:])
+	if got := buf.String(); got != "ok [OK] done\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// plainRunes replaces the symbols the CLI prints with ASCII.
+var plainRunes = map[rune]string{
+	'✅': "[OK]", '✓': "[OK]", '✔': "[OK]",
+	'❌': "[FAIL]", '✗': "[FAIL]", '✘': "[FAIL]",
+	'⚠': "[WARN]", '⏭': "[SKIP]", 'ℹ': "[INFO]",
+	'•': "*", '→': "->", '←': "<-", '↔': "<->", '…': "...", '—': "-", '–': "-",
+	'═': "=", '║': "|", '╔': "+", '╗': "+", '╚': "+", '╝': "+",
+	'μ': "u", '×': "x", '≥': ">=", '≤': "<=",
+}
+
+// Plain reports whether the environment asks for plain output: NO_COLOR set or
+// TERM=dumb.
+func Plain() bool {
+	return os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb"
+}
+
+// ASCII replaces emoji, symbols and box drawing in s with ASCII.
+func ASCII(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		sb.WriteString(plainRune(r))
+	}
+	return sb.String()
+}
+
+func plainRune(r rune) string {
+	if s, ok := plainRunes[r]; ok {
+		return s
+	}
+	switch {
+	case r < 0x80:
+		return string(r)
+	case r == 0xFE0F || r == 0x200D: // emoji presentation selector and joiner
+		return ""
+	case r >= 0x2500 && r <= 0x257F: // box drawing
+		switch r {
+		case 0x2500, 0x2501, 0x2504, 0x2505, 0x2508, 0x2509, 0x254C, 0x254D:
+			return "-"
+		case 0x2502, 0x2503, 0x2506, 0x2507, 0x250A, 0x250B, 0x254E, 0x254F:
+			return "|"
+		}
+		return "+"
+	case r >= 0x2190 && r <= 0x21FF, r >= 0x2300 && r <= 0x23FF, r >= 0x2600 && r <= 0x27BF,
+		r >= 0x2B00 && r <= 0x2BFF, r >= 0x1F000 && r <= 0x1FAFF:
+		return ""
+	}
+	return string(r)
+}
+
+// PlainWriter rewrites everything written through it with ASCII. A multi-byte
+// character split across writes is held until it is complete.
+func PlainWriter(w io.Writer) io.Writer {
+	return &plainWriter{w: w}
+}
+
+type plainWriter struct {
+	w       io.Writer
+	pending []byte
+}
+
+func (p *plainWriter) Write(b []byte) (int, error) {
+	data := append(p.pending, b...)
+	p.pending = nil
+	end := len(data)
+	// Hold back an incomplete trailing UTF-8 sequence.
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	p.pending = append([]byte(nil), data[end:]...)
+	if _, err := io.WriteString(p.w, ASCII(string(data[:end]))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
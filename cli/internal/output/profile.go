@@ -0,0 +1,33 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile is who a command's text output is written for: how much raw log
+// detail, internal naming and remediation it prints. Structured output is the
+// same for every profile.
+type Profile string
+
+const (
+	// Operator prints the verdict, the main problems in plain words and what
+	// to do next, for a helpdesk that escalates rather than debugs.
+	Operator Profile = "operator"
+	// Support prints the full report. It is the default.
+	Support Profile = "support"
+	// Developer adds what Support trims: every error and warning line in
+	// full and the metrics under their internal names.
+	Developer Profile = "developer"
+)
+
+// ParseProfile validates an --output-profile value; empty is Support.
+func ParseProfile(s string) (Profile, error) {
+	switch p := Profile(strings.ToLower(strings.TrimSpace(s))); p {
+	case Operator, Support, Developer:
+		return p, nil
+	case "":
+		return Support, nil
+	}
+	return "", fmt.Errorf("output profile must be operator, support or developer (got %q)", s)
+}
@@ -0,0 +1,311 @@
+package pjsip
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// Endpoint is a resolved pjsip endpoint with its effective codec allow list
+// and DTMF mode (empty when unset, which means rfc4733).
+type Endpoint struct {
+	Name     string   `json:"name"`
+	Allow    []string `json:"allow"`
+	DTMFMode string   `json:"dtmf_mode,omitempty"`
+}
+
+// Expectation is the codec/rate the engine expects Asterisk to deliver.
+type Expectation struct {
+	Transport  string `json:"transport"`
+	Codec      string `json:"codec"`
+	SampleRate int    `json:"sample_rate"`
+}
+
+// CodecAudit is the comparison of endpoint allow lists against the engine's format chain.
+type CodecAudit struct {
+	Source    string      `json:"source"`
+	Expected  Expectation `json:"expected"`
+	Endpoints []Endpoint  `json:"endpoints"`
+	Issues    []string    `json:"issues,omitempty"`
+}
+
+type section struct {
+	name     string
+	template bool
+	parents  []string
+	typ      string
+	dtmfMode string
+	codecOps []string // "allow=..." / "disallow=..." in file order
+	appendTo bool
+}
+
+// ReadConfig returns the concatenated pjsip*.conf text. It prefers docker exec into the
+// Asterisk container (deployment descriptor / ASTERISK_CONTAINER, default "asterisk") and
+// falls back to /etc/asterisk on the host.
+func ReadConfig() (text string, source string, err error) {
+	container := deployment.AsteriskContainer()
+	out, execErr := dockerapi.ExecOutput(context.Background(), container, "sh", "-c", "cat /etc/asterisk/pjsip*.conf 2>/dev/null")
+	if execErr == nil && strings.TrimSpace(string(out)) != "" {
+		return string(out), "docker exec " + container, nil
+	}
+	if deployment.Current().Remote() {
+		// Host files describe this machine, not the remote PBX.
+		return "", "", fmt.Errorf("pjsip config not readable via docker exec %s on remote host", container)
+	}
+
+	files, _ := filepath.Glob("/etc/asterisk/pjsip*.conf")
+	sort.Strings(files)
+	var sb strings.Builder
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		sb.Write(b)
+		sb.WriteString("\n")
+	}
+	if sb.Len() > 0 {
+		return sb.String(), "/etc/asterisk", nil
+	}
+	if execErr != nil {
+		return "", "", fmt.Errorf("pjsip config not readable (docker exec %s: %v; no /etc/asterisk/pjsip*.conf on host)", container, execErr)
+	}
+	return "", "", fmt.Errorf("pjsip config is empty")
+}
+
+// ParseEndpoints parses pjsip.conf text and returns endpoints (non-template sections with
+// type=endpoint) with template inheritance and allow/disallow applied in order.
+func ParseEndpoints(text string) []Endpoint {
+	sections := map[string]*section{}
+	order := []string{}
+	var cur *section
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				cur = nil
+				continue
+			}
+			name := strings.TrimSpace(line[1:end])
+			s := &section{name: name}
+			rest := strings.TrimSpace(line[end+1:])
+			if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+				for _, p := range strings.Split(rest[1:len(rest)-1], ",") {
+					p = strings.TrimSpace(p)
+					switch p {
+					case "":
+					case "!":
+						s.template = true
+					case "+":
+						s.appendTo = true
+					default:
+						s.parents = append(s.parents, p)
+					}
+				}
+			}
+			if existing, ok := sections[name]; ok && s.appendTo {
+				cur = existing
+				continue
+			}
+			sections[name] = s
+			order = append(order, name)
+			cur = s
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(key), ">")))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "type":
+			cur.typ = strings.ToLower(value)
+		case "dtmf_mode":
+			cur.dtmfMode = strings.ToLower(value)
+		case "allow", "disallow":
+			cur.codecOps = append(cur.codecOps, key+"="+value)
+		}
+	}
+
+	var endpoints []Endpoint
+	for _, name := range order {
+		s := sections[name]
+		if s.template {
+			continue
+		}
+		typ, dtmfMode, ops := resolve(s, sections, map[string]bool{})
+		if typ != "endpoint" {
+			continue
+		}
+		endpoints = append(endpoints, Endpoint{Name: name, Allow: applyCodecOps(ops), DTMFMode: dtmfMode})
+	}
+	return endpoints
+}
+
+func resolve(s *section, all map[string]*section, seen map[string]bool) (string, string, []string) {
+	if seen[s.name] {
+		return s.typ, s.dtmfMode, s.codecOps
+	}
+	seen[s.name] = true
+	typ, dtmfMode := "", ""
+	var ops []string
+	for _, p := range s.parents {
+		parent, ok := all[p]
+		if !ok {
+			continue
+		}
+		pt, pd, pops := resolve(parent, all, seen)
+		if pt != "" {
+			typ = pt
+		}
+		if pd != "" {
+			dtmfMode = pd
+		}
+		ops = append(ops, pops...)
+	}
+	if s.typ != "" {
+		typ = s.typ
+	}
+	if s.dtmfMode != "" {
+		dtmfMode = s.dtmfMode
+	}
+	return typ, dtmfMode, append(ops, s.codecOps...)
+}
+
+func applyCodecOps(ops []string) []string {
+	allow := []string{}
+	for _, op := range ops {
+		key, value, _ := strings.Cut(op, "=")
+		for _, c := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '&' }) {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if c == "" {
+				continue
+			}
+			remove := key == "disallow"
+			if strings.HasPrefix(c, "!") {
+				remove = !remove
+				c = strings.TrimPrefix(c, "!")
+			}
+			if remove {
+				if c == "all" {
+					allow = allow[:0]
+					continue
+				}
+				allow = removeCodec(allow, c)
+				continue
+			}
+			if !containsCodec(allow, c) {
+				allow = append(allow, c)
+			}
+		}
+	}
+	return allow
+}
+
+// CodecSampleRate returns the audio sample rate for an Asterisk codec name, or 0 if unknown.
+func CodecSampleRate(codec string) int {
+	switch strings.ToLower(strings.TrimSpace(codec)) {
+	case "ulaw", "mulaw", "alaw", "g729", "gsm", "ilbc", "g726", "slin", "slin8":
+		return 8000
+	case "g722", "slin16", "siren7", "speex16":
+		return 16000
+	case "slin24":
+		return 24000
+	case "siren14", "slin32":
+		return 32000
+	case "opus", "slin48":
+		return 48000
+	}
+	return 0
+}
+
+// ExpectedFormat derives the codec/rate the engine expects from the transport settings.
+func ExpectedFormat(transport, audioSocketFormat, externalMediaCodec string) Expectation {
+	transport = strings.ToLower(strings.TrimSpace(transport))
+	if transport == "" {
+		transport = "audiosocket"
+	}
+	codec := strings.ToLower(strings.TrimSpace(audioSocketFormat))
+	if transport == "externalmedia" {
+		codec = strings.ToLower(strings.TrimSpace(externalMediaCodec))
+	}
+	if codec == "" {
+		codec = "slin"
+		if transport == "externalmedia" {
+			codec = "ulaw"
+		}
+	}
+	return Expectation{Transport: transport, Codec: codec, SampleRate: CodecSampleRate(codec)}
+}
+
+// Audit compares each endpoint's allow list against the expected format chain.
+func Audit(endpoints []Endpoint, expected Expectation) *CodecAudit {
+	audit := &CodecAudit{Expected: expected, Endpoints: endpoints}
+	expectedRate := expected.SampleRate
+	if expectedRate == 0 {
+		expectedRate = 8000
+	}
+
+	for _, ep := range endpoints {
+		if len(ep.Allow) == 0 {
+			continue
+		}
+		if containsCodec(ep.Allow, "all") {
+			audit.Issues = append(audit.Issues, fmt.Sprintf(
+				"endpoint %s allows all codecs; negotiation order is decided by the peer", ep.Name))
+			continue
+		}
+		preferred := ep.Allow[0]
+		if rate := CodecSampleRate(preferred); rate > expectedRate {
+			audit.Issues = append(audit.Issues, fmt.Sprintf(
+				"endpoint %s prefers %s (%dHz) but the engine expects %s (%dHz); Asterisk will transcode and downsample",
+				ep.Name, preferred, rate, expected.Codec, expectedRate))
+		}
+		if expected.Transport == "externalmedia" && !containsCodec(ep.Allow, expected.Codec) {
+			audit.Issues = append(audit.Issues, fmt.Sprintf(
+				"endpoint %s does not allow %s (external_media.codec); every call is transcoded",
+				ep.Name, expected.Codec))
+		}
+	}
+	return audit
+}
+
+func containsCodec(list []string, codec string) bool {
+	for _, c := range list {
+		if c == codec {
+			return true
+		}
+	}
+	return false
+}
+
+func removeCodec(list []string, codec string) []string {
+	out := list[:0]
+	for _, c := range list {
+		if c != codec {
+			out = append(out, c)
+		}
+	}
+	return out
+}
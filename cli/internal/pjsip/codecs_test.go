@@ -0,0 +1,92 @@
+package pjsip
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEndpointsTemplatesAndOrder(t *testing.T) {
+	t.Parallel()
+
+	conf := `
+[trunk-defaults](!)
+type=endpoint
+disallow=all
+allow=g722,ulaw ; wideband first
+
+[provider-trunk](trunk-defaults)
+allow=alaw
+
+[provider-trunk-auth]
+type=auth
+
+[6001]
+type=endpoint
+disallow=all
+allow=ulaw
+`
+	eps := ParseEndpoints(conf)
+	if len(eps) != 2 {
+		t.Fatalf("expected 2 endpoints, got %d: %+v", len(eps), eps)
+	}
+	if got := strings.Join(eps[0].Allow, ","); eps[0].Name != "provider-trunk" || got != "g722,ulaw,alaw" {
+		t.Fatalf("unexpected trunk endpoint: %s allow=%s", eps[0].Name, got)
+	}
+	if got := strings.Join(eps[1].Allow, ","); got != "ulaw" {
+		t.Fatalf("unexpected 6001 allow list: %s", got)
+	}
+}
+
+func TestAuditFlagsWidebandPreferredForSlin(t *testing.T) {
+	t.Parallel()
+
+	eps := []Endpoint{
+		{Name: "trunk", Allow: []string{"opus", "ulaw"}},
+		{Name: "ext", Allow: []string{"ulaw"}},
+	}
+	audit := Audit(eps, ExpectedFormat("audiosocket", "slin", ""))
+	if len(audit.Issues) != 1 || !strings.Contains(audit.Issues[0], "trunk prefers opus") {
+		t.Fatalf("expected one opus issue, got %v", audit.Issues)
+	}
+
+	audit = Audit([]Endpoint{{Name: "trunk", Allow: []string{"alaw"}}}, ExpectedFormat("externalmedia", "", "ulaw"))
+	if len(audit.Issues) != 1 || !strings.Contains(audit.Issues[0], "does not allow ulaw") {
+		t.Fatalf("expected missing-codec issue, got %v", audit.Issues)
+	}
+}
+
+func TestAuditDTMFModes(t *testing.T) {
+	t.Parallel()
+
+	conf := `
+[trunk-defaults](!)
+type=endpoint
+dtmf_mode=inband
+
+[provider-trunk](trunk-defaults)
+allow=g729,ulaw
+
+[6001]
+type=endpoint
+allow=ulaw
+dtmf_mode=rfc2833
+
+[6002]
+type=endpoint
+allow=ulaw
+`
+	eps := ParseEndpoints(conf)
+	if len(eps) != 3 || eps[0].DTMFMode != "inband" || EffectiveDTMFMode(eps[2]) != DefaultDTMFMode {
+		t.Fatalf("unexpected endpoints: %+v", eps)
+	}
+	audit := AuditDTMF(eps, ExpectedFormat("externalmedia", "", "ulaw"))
+	issues := strings.Join(audit.Issues, "\n")
+	if len(audit.Issues) != 3 {
+		t.Fatalf("expected 3 issues, got %v", audit.Issues)
+	}
+	for _, want := range []string{"provider-trunk uses inband DTMF but prefers g729", "over externalmedia", "6001 sets dtmf_mode=rfc2833"} {
+		if !strings.Contains(issues, want) {
+			t.Errorf("issues missing %q:\n%s", want, issues)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package pjsip
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultDTMFMode is what res_pjsip uses when an endpoint does not set dtmf_mode.
+const DefaultDTMFMode = "rfc4733"
+
+// DTMFAudit is the check of endpoint DTMF modes against the engine's audio transport.
+type DTMFAudit struct {
+	Source    string     `json:"source"`
+	Transport string     `json:"transport"`
+	Endpoints []Endpoint `json:"endpoints"`
+	Issues    []string   `json:"issues,omitempty"`
+}
+
+// EffectiveDTMFMode returns the endpoint's dtmf_mode, or the res_pjsip default.
+func EffectiveDTMFMode(ep Endpoint) string {
+	if ep.DTMFMode == "" {
+		return DefaultDTMFMode
+	}
+	return ep.DTMFMode
+}
+
+// AuditDTMF checks each endpoint's dtmf_mode: values res_pjsip rejects, modes that
+// drop digits, and inband tones that a compressed codec distorts or that the
+// transport carries to the provider as audio.
+func AuditDTMF(endpoints []Endpoint, expected Expectation) *DTMFAudit {
+	audit := &DTMFAudit{Transport: expected.Transport, Endpoints: endpoints}
+	for _, ep := range endpoints {
+		mode := EffectiveDTMFMode(ep)
+		switch mode {
+		case "rfc4733", "auto", "auto_info":
+		case "rfc2833":
+			audit.Issues = append(audit.Issues, fmt.Sprintf(
+				"endpoint %s sets dtmf_mode=rfc2833, the chan_sip name; res_pjsip expects rfc4733", ep.Name))
+		case "none":
+			audit.Issues = append(audit.Issues, fmt.Sprintf(
+				"endpoint %s sets dtmf_mode=none; keypad digits are dropped", ep.Name))
+		case "info":
+			audit.Issues = append(audit.Issues, fmt.Sprintf(
+				"endpoint %s only takes SIP INFO digits; RFC 4733 events from the peer are ignored (use auto_info)", ep.Name))
+		case "inband":
+			if len(ep.Allow) > 0 && lossyCodec(ep.Allow[0]) {
+				audit.Issues = append(audit.Issues, fmt.Sprintf(
+					"endpoint %s uses inband DTMF but prefers %s; the codec distorts the tones and Asterisk misses digits (prefer ulaw/alaw or use rfc4733)",
+					ep.Name, ep.Allow[0]))
+			}
+			audit.Issues = append(audit.Issues, fmt.Sprintf(
+				"endpoint %s uses inband DTMF; the tones stay in the audio sent to the engine over %s and reach the provider as noise",
+				ep.Name, emptyTransport(expected.Transport)))
+		default:
+			audit.Issues = append(audit.Issues, fmt.Sprintf(
+				"endpoint %s sets unknown dtmf_mode=%s (valid: rfc4733, inband, info, auto, auto_info, none)", ep.Name, mode))
+		}
+	}
+	return audit
+}
+
+// lossyCodec reports codecs that compress audio too far for inband DTMF detection.
+func lossyCodec(codec string) bool {
+	switch strings.ToLower(codec) {
+	case "g729", "gsm", "ilbc", "g726", "g723", "opus", "speex", "speex16", "lpc10", "codec2", "silk":
+		return true
+	}
+	return false
+}
+
+func emptyTransport(transport string) string {
+	if transport == "" {
+		return "audiosocket"
+	}
+	return transport
+}
@@ -0,0 +1,307 @@
+// Package plugin runs site plugins: executables in ~/.agent/plugins that add
+// health checks to agent check, findings to agent rca, and receive the
+// finished reports (sinks), so a site can extend the CLI without forking it.
+//
+// A plugin is called with the hook as its only argument and a JSON request
+// on stdin, and answers with one JSON object on stdout; a non-zero exit is a
+// failure, with the reason on stderr. Every plugin answers "describe" with
+// its name and the hooks it implements; the other hooks are only called on
+// plugins that list them:
+//
+//	describe  → {"name": "...", "description": "...", "hooks": ["check", "analyze", "sink"]}
+//	check     → {"items": [{"name", "status": "pass|warn|fail|skip", "message", "details", "remediation"}]}
+//	analyze   → {"errors": [], "warnings": [], "audio_issues": [], "root_causes": [], "actions": []}
+//	sink      → {} (the output is ignored)
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Protocol is the version of the request and response format; it is sent in
+// every request and bumped on incompatible changes.
+const Protocol = 1
+
+// Hooks a plugin can implement.
+const (
+	HookCheck   = "check"
+	HookAnalyze = "analyze"
+	HookSink    = "sink"
+)
+
+const describeHook = "describe"
+
+// Timeouts bound how long a plugin may run; a plugin that hangs must not
+// hang the CLI.
+var (
+	DescribeTimeout = 5 * time.Second
+	Timeout         = 30 * time.Second
+)
+
+// maxOutput caps what is read from a plugin's stdout.
+const maxOutput = 4 << 20
+
+// Plugin is an executable in the plugin directory. Err is set when it cannot
+// be used (it failed to describe itself, or others can write to it); such a
+// plugin is listed but never called.
+type Plugin struct {
+	Name        string
+	Path        string
+	Description string
+	Hooks       []string
+	Err         error
+}
+
+// Dir is the plugin directory: AAVA_PLUGIN_DIR, or ~/.agent/plugins.
+func Dir() string {
+	if d := strings.TrimSpace(os.Getenv("AAVA_PLUGIN_DIR")); d != "" {
+		return d
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".agent", "plugins")
+	}
+	return filepath.Join(home, ".agent", "plugins")
+}
+
+// Disabled reports whether AAVA_PLUGINS=off turns plugins off for this run.
+func Disabled() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("AAVA_PLUGINS")), "off")
+}
+
+// Load discovers the plugins in Dir, or none when plugins are disabled.
+func Load() []Plugin {
+	if Disabled() {
+		return nil
+	}
+	return Discover(Dir())
+}
+
+// Discover describes every executable in dir, sorted by file name. A missing
+// directory has no plugins.
+func Discover(dir string) []Plugin {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	var out []Plugin
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !executable(e.Name(), info.Mode()) {
+			continue
+		}
+		p := Plugin{Name: strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())), Path: filepath.Join(dir, e.Name())}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0o022 != 0 {
+			p.Err = errors.New("writable by other users; run chmod go-w on it")
+			out = append(out, p)
+			continue
+		}
+		p.Err = p.describe()
+		out = append(out, p)
+	}
+	return out
+}
+
+func executable(name string, mode os.FileMode) bool {
+	if !mode.IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".exe", ".bat", ".cmd", ".com":
+			return true
+		}
+		return false
+	}
+	return mode.Perm()&0o111 != 0
+}
+
+func (p *Plugin) describe() error {
+	var d struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Hooks       []string `json:"hooks"`
+	}
+	if err := p.call(describeHook, DescribeTimeout, request{}, &d); err != nil {
+		return err
+	}
+	if n := strings.TrimSpace(d.Name); n != "" {
+		p.Name = n
+	}
+	p.Description = strings.TrimSpace(d.Description)
+	for _, h := range d.Hooks {
+		switch h {
+		case HookCheck, HookAnalyze, HookSink:
+			p.Hooks = append(p.Hooks, h)
+		}
+	}
+	if len(p.Hooks) == 0 {
+		return errors.New("implements no hooks")
+	}
+	return nil
+}
+
+// Has reports whether the plugin is usable and implements hook.
+func (p Plugin) Has(hook string) bool {
+	if p.Err != nil {
+		return false
+	}
+	for _, h := range p.Hooks {
+		if h == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// With returns the plugins that implement hook.
+func With(plugins []Plugin, hook string) []Plugin {
+	var out []Plugin
+	for _, p := range plugins {
+		if p.Has(hook) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// request is the JSON a plugin reads from stdin.
+type request struct {
+	Protocol   int    `json:"protocol"`
+	Hook       string `json:"hook"`
+	CLIVersion string `json:"cli_version,omitempty"`
+	// check: the agent check profile.
+	Profile string `json:"profile,omitempty"`
+	// analyze: the call's report so far, its symptom and engine log lines.
+	// sink: the report kind ("check", "rca" or "digest") and the report.
+	Symptom string `json:"symptom,omitempty"`
+	Log     string `json:"log,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	Report  any    `json:"report,omitempty"`
+}
+
+// CheckItem is a health check result, as in agent check --json.
+type CheckItem struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Message     string `json:"message"`
+	Details     string `json:"details,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Check runs the plugin's health checks. Items without a name are named
+// after the plugin.
+func (p Plugin) Check(cliVersion, profile string) ([]CheckItem, error) {
+	var out struct {
+		Items []CheckItem `json:"items"`
+	}
+	if err := p.call(HookCheck, Timeout, request{CLIVersion: cliVersion, Profile: profile}, &out); err != nil {
+		return nil, err
+	}
+	for i := range out.Items {
+		if strings.TrimSpace(out.Items[i].Name) == "" {
+			out.Items[i].Name = p.Name
+		}
+	}
+	return out.Items, nil
+}
+
+// Findings are what an analyzer adds to a call's report.
+type Findings struct {
+	Errors      []string `json:"errors"`
+	Warnings    []string `json:"warnings"`
+	AudioIssues []string `json:"audio_issues"`
+	RootCauses  []string `json:"root_causes"`
+	Actions     []string `json:"actions"`
+}
+
+// Analyze asks the plugin about a call, given its report so far (the shape of
+// agent rca --json) and the call's engine log lines.
+func (p Plugin) Analyze(cliVersion string, report any, symptom, log string) (Findings, error) {
+	var out Findings
+	err := p.call(HookAnalyze, Timeout, request{CLIVersion: cliVersion, Report: report, Symptom: symptom, Log: log}, &out)
+	return out, err
+}
+
+// Sink hands the plugin a finished report; kind is "check" or "rca".
+func (p Plugin) Sink(cliVersion, kind string, report any) error {
+	return p.call(HookSink, Timeout, request{CLIVersion: cliVersion, Kind: kind, Report: report}, nil)
+}
+
+// call runs the plugin for one hook and decodes its answer into out (unless
+// out is nil).
+func (p Plugin) call(hook string, timeout time.Duration, req request, out any) error {
+	req.Protocol, req.Hook = Protocol, hook
+	in, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.Path, hook)
+	cmd.Stdin = bytes.NewReader(in)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &limitedWriter{w: &stdout, n: maxOutput}
+	cmd.Stderr = &limitedWriter{w: &stderr, n: 64 << 10}
+	cmd.Env = append(os.Environ(), fmt.Sprintf("AAVA_PLUGIN_PROTOCOL=%d", Protocol))
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s: timed out after %s", hook, timeout)
+	}
+	if err != nil {
+		if msg := lastLine(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %v: %s", hook, err, msg)
+		}
+		return fmt.Errorf("%s: %w", hook, err)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), out); err != nil {
+		return fmt.Errorf("%s: invalid response: %w", hook, err)
+	}
+	return nil
+}
+
+// limitedWriter keeps the first n bytes and discards the rest, so a chatty
+// plugin neither fills memory nor blocks on a full pipe.
+type limitedWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitedWriter) Write(b []byte) (int, error) {
+	if l.n > 0 {
+		keep := b
+		if len(keep) > l.n {
+			keep = keep[:l.n]
+		}
+		n, err := l.w.Write(keep)
+		l.n -= n
+		if err != nil {
+			return n, err
+		}
+	}
+	return len(b), nil
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
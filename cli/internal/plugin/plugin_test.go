@@ -0,0 +1,129 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePlugin installs a shell plugin that answers each hook from script.
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugins")
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const sbcPlugin = `
+case "$1" in
+describe) echo '{"name":"sbc","description":"Site SBC","hooks":["check","analyze","sink","bogus"]}' ;;
+check)
+  grep -q '"profile":"quick"' || exit 3
+  echo '{"items":[{"status":"pass","message":"SBC trunk registered"}]}' ;;
+analyze)
+  grep -q 'SBC-503' && echo '{"errors":["SBC rejected the call (503)"],"root_causes":["SBC trunk overloaded"]}' || echo '{}' ;;
+sink) cat > "$(dirname "$0")/sunk.json" ;;
+esac
+`
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "sbc.sh", sbcPlugin)
+	writePlugin(t, dir, "broken", `echo "no config" >&2; exit 1`)
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a plugin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loose := writePlugin(t, dir, "loose", `echo '{"hooks":["check"]}'`)
+	if err := os.Chmod(loose, 0o777); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := Discover(dir)
+	if len(plugins) != 3 {
+		t.Fatalf("got %d plugins, want 3: %+v", len(plugins), plugins)
+	}
+	broken, loosePlugin, sbc := plugins[0], plugins[1], plugins[2]
+	if broken.Err == nil || !strings.Contains(broken.Err.Error(), "no config") {
+		t.Errorf("broken: err = %v, want the stderr reason", broken.Err)
+	}
+	if loosePlugin.Err == nil || loosePlugin.Has(HookCheck) {
+		t.Errorf("a plugin others can write to must not run: %+v", loosePlugin)
+	}
+	if sbc.Err != nil || sbc.Name != "sbc" || sbc.Description != "Site SBC" {
+		t.Fatalf("sbc = %+v", sbc)
+	}
+	if want := []string{HookCheck, HookAnalyze, HookSink}; strings.Join(sbc.Hooks, ",") != strings.Join(want, ",") {
+		t.Errorf("hooks = %v, want %v", sbc.Hooks, want)
+	}
+	if got := With(plugins, HookCheck); len(got) != 1 || got[0].Name != "sbc" {
+		t.Errorf("With(check) = %+v", got)
+	}
+	if Discover(filepath.Join(dir, "missing")) != nil {
+		t.Error("a missing directory has no plugins")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "sbc.sh", sbcPlugin)
+	p := Discover(dir)[0]
+
+	items, err := p.Check("1.0", "quick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Name != "sbc" || items[0].Status != "pass" {
+		t.Errorf("items = %+v, want one pass named after the plugin", items)
+	}
+	if _, err := p.Check("1.0", "full"); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("err = %v, want the exit status", err)
+	}
+
+	f, err := p.Analyze("1.0", map[string]string{"call_id": "1761518880.2191"}, "", "WARNING SBC-503 trunk busy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Errors) != 1 || len(f.RootCauses) != 1 {
+		t.Errorf("findings = %+v", f)
+	}
+
+	if err := p.Sink("1.0", "check", map[string]int{"fail_count": 2}); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "sunk.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"protocol":1`, `"hook":"sink"`, `"kind":"check"`, `"fail_count":2`} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("sink request %s lacks %s", raw, want)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "slow", `[ "$1" = describe ] && echo '{"hooks":["check"]}' && exit 0; exec sleep 5`)
+	p := Discover(dir)[0]
+	old := Timeout
+	Timeout = 200 * time.Millisecond
+	defer func() { Timeout = old }()
+	start := time.Now()
+	if _, err := p.Check("1.0", "full"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("err = %v, want a timeout", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("the plugin was not stopped at the timeout")
+	}
+}
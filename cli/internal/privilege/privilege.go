@@ -0,0 +1,475 @@
+// Package privilege reports whether the CLI has the access each operation
+// needs: the Docker socket, Asterisk's files on this host, raw sockets for
+// packet capture, and the host firewall rules. Operations that lack it fall
+// back or skip with a precise reason. The firewall reads are the only
+// commands run through sudo, and only when a NOPASSWD sudoers entry allows
+// exactly that command (see SudoHelper).
+package privilege
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+)
+
+// Statuses of an operation.
+const (
+	StatusOK       = "ok"       // the CLI has the access it needs
+	StatusDegraded = "degraded" // it works, with a fallback or less detail
+	StatusDenied   = "denied"   // it fails until access is granted
+	StatusSkipped  = "skipped"  // it does not apply on this host
+)
+
+// Operation is the privilege verdict for one kind of operation.
+type Operation struct {
+	Name    string `json:"name"`
+	UsedBy  string `json:"used_by"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// Operation names.
+const (
+	OpDocker   = "docker"
+	OpAsterisk = "asterisk"
+	OpCapture  = "capture"
+	OpFirewall = "firewall"
+)
+
+// facts is what Diagnose learns about this host and process; assess turns
+// it into verdicts.
+type facts struct {
+	goos   string
+	root   bool
+	user   string
+	remote bool // the Docker endpoint is not a local socket
+
+	dockerSocket string
+	dockerErr    error  // dialing the socket
+	socketGroup  string // group owning the socket
+	listedIn     bool   // the user is a member of socketGroup in the group database
+	inGroup      bool   // this process has socketGroup
+
+	asteriskDir    bool
+	asteriskGroup  string
+	unreadable     []string // Asterisk files this user cannot read
+	unwritable     []string // Asterisk files the CLI writes that this user cannot
+	controlDenied  bool     // asterisk.ctl is not usable, so asterisk -rx fails locally
+	asteriskMember bool     // the user is listed in asteriskGroup
+
+	tcpdump     string
+	tcpdumpCaps bool // tcpdump has cap_net_raw as a file capability
+
+	firewall     []string // firewall read commands present here, as in SudoHelper
+	firewallSudo bool     // sudo -n allows every one of them
+}
+
+// Diagnose probes this host and returns a verdict per operation.
+func Diagnose() []Operation {
+	return assess(gather())
+}
+
+// Find returns the verdict for name from ops.
+func Find(ops []Operation, name string) (Operation, bool) {
+	for _, op := range ops {
+		if op.Name == name {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+func gather() facts {
+	f := facts{goos: runtime.GOOS, root: isRoot(), user: currentUser()}
+	if f.goos == "windows" {
+		return f
+	}
+
+	sock, local := dockerSocket()
+	f.remote = !local || deployment.Current().Remote()
+	if !f.remote {
+		f.dockerSocket = sock
+		f.dockerErr = dialSocket(sock)
+		if gid, ok := fileGroup(sock); ok {
+			f.socketGroup, f.listedIn, f.inGroup = groupMembership(gid)
+		}
+	}
+
+	if st, err := os.Stat(asterisk.ConfigDir); err == nil && st.IsDir() {
+		f.asteriskDir = true
+		if gid, ok := fileGroup(asterisk.ConfigDir); ok {
+			f.asteriskGroup, f.asteriskMember, _ = groupMembership(gid)
+		}
+		for _, name := range []string{"ari.conf", "http.conf", "pjsip.conf", "extensions.conf", "manager.conf"} {
+			path := filepath.Join(asterisk.ConfigDir, name)
+			if _, err := os.Stat(path); err == nil && !canRead(path) {
+				f.unreadable = append(f.unreadable, name)
+			}
+		}
+		for _, name := range []string{"extensions_custom.conf", "ari_additional_custom.conf"} {
+			path := filepath.Join(asterisk.ConfigDir, name)
+			if _, err := os.Stat(path); err == nil && !canWrite(path) {
+				f.unwritable = append(f.unwritable, name)
+			}
+		}
+		if _, err := os.Stat(controlSocket); err == nil {
+			f.controlDenied = !canWrite(controlSocket)
+		}
+	}
+
+	if path, err := exec.LookPath("tcpdump"); err == nil {
+		f.tcpdump = path
+		f.tcpdumpCaps = hasCaptureCaps(path)
+	}
+
+	f.firewall = firewallCommands()
+	if len(f.firewall) > 0 && !f.root {
+		f.firewallSudo = true
+		for _, c := range f.firewall {
+			if !sudoAllows(strings.Fields(c)) {
+				f.firewallSudo = false
+			}
+		}
+	}
+	return f
+}
+
+// controlSocket is where asterisk -rx connects to a local Asterisk.
+const controlSocket = "/var/run/asterisk/asterisk.ctl"
+
+func assess(f facts) []Operation {
+	ops := []Operation{
+		assessDocker(f),
+		assessAsterisk(f),
+	}
+	return append(ops, assessCapture(f, ops[0]), assessFirewall(f))
+}
+
+func assessDocker(f facts) Operation {
+	op := Operation{Name: OpDocker, UsedBy: "every command that inspects or runs containers"}
+	switch {
+	case f.goos == "windows":
+		op.Status, op.Message = StatusSkipped, "Docker Desktop manages access on Windows"
+	case f.remote:
+		op.Status, op.Message = StatusSkipped, "the Docker endpoint is remote; access is granted on that host"
+	case f.dockerErr == nil:
+		op.Status, op.Message = StatusOK, "can use "+f.dockerSocket
+	case !errors.Is(f.dockerErr, fs.ErrPermission):
+		op.Status, op.Message = StatusSkipped, fmt.Sprintf("cannot reach %s (is Docker running?): %v", f.dockerSocket, f.dockerErr)
+	case f.socketGroup != "" && f.listedIn && !f.inGroup:
+		op.Status = StatusDenied
+		op.Message = fmt.Sprintf("%s is in the %s group, but this session started before that", f.user, f.socketGroup)
+		op.Fix = "Log out and back in, or run: newgrp " + f.socketGroup
+	case f.socketGroup != "" && f.socketGroup != "root":
+		op.Status = StatusDenied
+		op.Message = fmt.Sprintf("%s is not in the %s group that owns %s", f.user, f.socketGroup, f.dockerSocket)
+		op.Fix = fmt.Sprintf("sudo usermod -aG %s %s, then log in again (the group is root-equivalent; rootless Docker avoids that)", f.socketGroup, f.user)
+	default:
+		op.Status = StatusDenied
+		op.Message = "no permission to use " + f.dockerSocket
+		op.Fix = "Run agent as root, or set up rootless Docker and point DOCKER_HOST at its socket"
+	}
+	return op
+}
+
+func assessAsterisk(f facts) Operation {
+	op := Operation{Name: OpAsterisk, UsedBy: "agent init, ari-user, dialplan and the codec checks, when Asterisk runs on this host"}
+	if f.goos == "windows" || !f.asteriskDir {
+		op.Status, op.Message = StatusSkipped, "no "+asterisk.ConfigDir+" on this host; files are read through docker exec in the Asterisk container"
+		return op
+	}
+	var problems []string
+	if len(f.unreadable) > 0 {
+		problems = append(problems, "cannot read "+strings.Join(f.unreadable, ", ")+" in "+asterisk.ConfigDir)
+	}
+	if len(f.unwritable) > 0 {
+		problems = append(problems, "cannot write "+strings.Join(f.unwritable, ", ")+" in "+asterisk.ConfigDir)
+	}
+	if f.controlDenied {
+		problems = append(problems, "cannot connect to "+controlSocket+" for asterisk -rx")
+	}
+	if len(problems) == 0 {
+		op.Status, op.Message = StatusOK, "can read "+asterisk.ConfigDir
+		return op
+	}
+	op.Status = StatusDegraded
+	op.Message = strings.Join(problems, "; ")
+	group := f.asteriskGroup
+	if group == "" || group == "root" {
+		group = "asterisk"
+	}
+	if f.asteriskMember {
+		op.Fix = fmt.Sprintf("%s is in the %s group already: log in again, and make the files group-readable (FreePBX: fwconsole chown)", f.user, group)
+	} else {
+		op.Fix = fmt.Sprintf("sudo usermod -aG %s %s, then log in again", group, f.user)
+	}
+	return op
+}
+
+func assessCapture(f facts, docker Operation) Operation {
+	op := Operation{Name: OpCapture, UsedBy: "agent capture"}
+	helper := "tcpdump runs in a helper container"
+	if docker.Status == StatusDenied {
+		helper = "the helper container cannot run either (see docker)"
+	}
+	switch {
+	case f.goos == "windows":
+		op.Status, op.Message = StatusDegraded, "tcpdump runs in a helper container on Windows"
+	case f.tcpdump == "":
+		op.Status, op.Message = StatusDegraded, "tcpdump is not installed on this host; "+helper
+		op.Fix = "Install tcpdump to capture on the host"
+	case f.root:
+		op.Status, op.Message = StatusOK, "running as root; tcpdump runs on the host"
+	case f.tcpdumpCaps:
+		op.Status, op.Message = StatusOK, f.tcpdump+" has cap_net_raw; tcpdump runs on the host"
+	default:
+		op.Status = StatusDegraded
+		op.Message = "raw sockets need root or cap_net_raw on " + f.tcpdump + "; " + helper
+		op.Fix = CaptureFix(f.tcpdump)
+	}
+	if op.Status == StatusDegraded && docker.Status == StatusDenied {
+		op.Status = StatusDenied
+	}
+	return op
+}
+
+func assessFirewall(f facts) Operation {
+	op := Operation{Name: OpFirewall, UsedBy: "the RTP Firewall/NAT item of agent check"}
+	switch {
+	case f.goos != "linux":
+		op.Status, op.Message = StatusSkipped, "only Linux firewalls are inspected"
+	case len(f.firewall) == 0:
+		op.Status, op.Message = StatusSkipped, "no ufw, iptables or nft on this host"
+	case f.root:
+		op.Status, op.Message = StatusOK, "running as root; rules are readable"
+	case f.firewallSudo:
+		op.Status, op.Message = StatusOK, "rules are read through sudo -n"
+	default:
+		op.Status = StatusDegraded
+		op.Message = "ufw, iptables and nft need root to list rules; the check reports them as unreadable"
+		op.Fix = "Install the sudoers entry from agent privileges --sudo-helper"
+	}
+	return op
+}
+
+// CaptureFix is how to let tcpdump at path capture without root.
+func CaptureFix(path string) string {
+	return "sudo setcap cap_net_raw,cap_net_admin=eip " + path + " (any user may capture then; restrict with chgrp and chmod 0750)"
+}
+
+// CanCaptureOnHost reports whether tcpdump can run on this host as this user.
+func CanCaptureOnHost() bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	path, err := exec.LookPath("tcpdump")
+	if err != nil {
+		return false
+	}
+	return isRoot() || hasCaptureCaps(path)
+}
+
+// hasCaptureCaps reports whether path carries cap_net_raw in its permitted
+// file capabilities.
+func hasCaptureCaps(path string) bool {
+	out, err := exec.Command(lookSbinOr("getcap"), path).Output()
+	return err == nil && permitsCap(string(out), "cap_net_raw")
+}
+
+// permitsCap reports whether getcap output such as
+// "/usr/bin/tcpdump cap_net_admin,cap_net_raw=eip" grants capability in the
+// permitted set.
+func permitsCap(getcap, capability string) bool {
+	fields := strings.Fields(getcap)
+	if len(fields) < 2 {
+		return false
+	}
+	for _, clause := range fields[1:] {
+		i := strings.IndexAny(clause, "=+")
+		if i < 0 {
+			continue
+		}
+		for _, name := range strings.Split(clause[:i], ",") {
+			if name == capability && strings.Contains(clause[i+1:], "p") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dockerSocket returns the local socket of DOCKER_HOST, or false when the
+// endpoint is not a local unix socket.
+func dockerSocket() (string, bool) {
+	if strings.TrimSpace(os.Getenv("DOCKER_CONTEXT")) != "" {
+		return "", false
+	}
+	host := strings.TrimSpace(os.Getenv("DOCKER_HOST"))
+	if host == "" {
+		return "/var/run/docker.sock", true
+	}
+	if path, ok := strings.CutPrefix(host, "unix://"); ok {
+		return path, true
+	}
+	return "", false
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// groupMembership returns the name of group gid, whether the current user
+// is listed in it, and whether this process has it.
+func groupMembership(gid uint32) (name string, listed, active bool) {
+	id := fmt.Sprint(gid)
+	name = id
+	if g, err := user.LookupGroupId(id); err == nil {
+		name = g.Name
+	}
+	if u, err := user.Current(); err == nil {
+		if u.Gid == id {
+			listed = true
+		} else if ids, err := u.GroupIds(); err == nil {
+			for _, g := range ids {
+				listed = listed || g == id
+			}
+		}
+	}
+	if os.Getegid() == int(gid) {
+		active = true
+	} else if groups, err := os.Getgroups(); err == nil {
+		for _, g := range groups {
+			active = active || g == int(gid)
+		}
+	}
+	return name, listed, active
+}
+
+// Firewall read commands, as the check runs them.
+var firewallReads = [][]string{
+	{"ufw", "status"},
+	{"iptables", "-S"},
+	{"nft", "list", "ruleset"},
+}
+
+// firewallCommands returns the firewall read commands whose tool is
+// installed, with absolute paths, as sudoers needs them.
+func firewallCommands() []string {
+	var out []string
+	for _, argv := range firewallReads {
+		if path := lookSbin(argv[0]); path != "" {
+			out = append(out, strings.Join(append([]string{path}, argv[1:]...), " "))
+		}
+	}
+	return out
+}
+
+// lookSbin finds name on PATH or in the sbin directories, which a non-root
+// PATH often lacks.
+func lookSbin(name string) string {
+	if path, err := exec.LookPath(name); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+	}
+	for _, dir := range []string{"/usr/sbin", "/sbin", "/usr/local/sbin"} {
+		path := filepath.Join(dir, name)
+		if st, err := os.Stat(path); err == nil && !st.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// lookSbinOr is lookSbin, or name itself when it is not found.
+func lookSbinOr(name string) string {
+	if path := lookSbin(name); path != "" {
+		return path
+	}
+	return name
+}
+
+var (
+	sudoMu      sync.Mutex
+	sudoAllowed = map[string]bool{}
+)
+
+// sudoAllows reports whether sudo runs argv (an absolute path and its
+// arguments) without a password.
+func sudoAllows(argv []string) bool {
+	key := strings.Join(argv, " ")
+	sudoMu.Lock()
+	defer sudoMu.Unlock()
+	if ok, seen := sudoAllowed[key]; seen {
+		return ok
+	}
+	ok := false
+	if _, err := exec.LookPath("sudo"); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ok = exec.CommandContext(ctx, "sudo", append([]string{"-n", "-l"}, argv...)...).Run() == nil
+		cancel()
+	}
+	sudoAllowed[key] = ok
+	return ok
+}
+
+// Command returns the command for a firewall read: as is when running as
+// root or when sudo does not allow it, and through sudo -n when it does.
+func Command(name string, args ...string) *exec.Cmd {
+	if isRoot() || runtime.GOOS == "windows" {
+		return exec.Command(name, args...)
+	}
+	if path := lookSbin(name); path != "" {
+		argv := append([]string{path}, args...)
+		if sudoAllows(argv) {
+			return exec.Command("sudo", append([]string{"-n"}, argv...)...)
+		}
+		return exec.Command(path, args...)
+	}
+	return exec.Command(name, args...)
+}
+
+// SudoHelper returns a sudoers file granting user the firewall reads agent
+// runs through sudo, with comments naming the group memberships and file
+// capabilities that cover the other operations without sudo.
+func SudoHelper(user string) string {
+	return sudoers(user, firewallCommands(), lookSbin("tcpdump"))
+}
+
+func sudoers(user string, firewall []string, tcpdump string) string {
+	var b strings.Builder
+	b.WriteString("# /etc/sudoers.d/aava-agent: the read-only commands agent runs through sudo -n.\n")
+	b.WriteString("# Check and install it with:\n")
+	b.WriteString("#   agent privileges --sudo-helper > aava-agent && sudo visudo -cf aava-agent\n")
+	b.WriteString("#   sudo install -m 0440 aava-agent /etc/sudoers.d/aava-agent\n")
+	if len(firewall) > 0 {
+		fmt.Fprintf(&b, "Cmnd_Alias AAVA_FIREWALL = %s\n", strings.Join(firewall, ", "))
+		fmt.Fprintf(&b, "%s ALL=(root) NOPASSWD: AAVA_FIREWALL\n", user)
+	} else {
+		b.WriteString("# No ufw, iptables or nft here: nothing needs sudo.\n")
+	}
+	if tcpdump == "" {
+		tcpdump = "/usr/bin/tcpdump"
+	}
+	b.WriteString("#\n# Not granted through sudo, because such an entry would amount to root:\n")
+	fmt.Fprintf(&b, "#   docker:   sudo usermod -aG docker %s (or rootless Docker)\n", user)
+	fmt.Fprintf(&b, "#   asterisk: sudo usermod -aG asterisk %s\n", user)
+	fmt.Fprintf(&b, "#   capture:  sudo setcap cap_net_raw,cap_net_admin=eip %s (tcpdump -z runs commands)\n", tcpdump)
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package privilege
+
+import (
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func denied() error {
+	return &net.OpError{Op: "dial", Net: "unix", Err: os.NewSyscallError("connect", fs.ErrPermission)}
+}
+
+func TestAssessDocker(t *testing.T) {
+	base := facts{goos: "linux", user: "alice", dockerSocket: "/var/run/docker.sock", socketGroup: "docker"}
+	cases := []struct {
+		name   string
+		change func(*facts)
+		status string
+		fix    string
+	}{
+		{"usable", func(f *facts) {}, StatusOK, ""},
+		{"remote", func(f *facts) { f.remote, f.dockerErr = true, denied() }, StatusSkipped, ""},
+		{"not running", func(f *facts) { f.dockerErr = fs.ErrNotExist }, StatusSkipped, ""},
+		{"not in group", func(f *facts) { f.dockerErr = denied() }, StatusDenied, "sudo usermod -aG docker alice"},
+		{"stale session", func(f *facts) { f.dockerErr, f.listedIn = denied(), true }, StatusDenied, "newgrp docker"},
+		{"root-owned socket", func(f *facts) { f.dockerErr, f.socketGroup = denied(), "root" }, StatusDenied, "rootless Docker"},
+	}
+	for _, c := range cases {
+		f := base
+		c.change(&f)
+		op := assessDocker(f)
+		if op.Status != c.status || !strings.Contains(op.Fix, c.fix) {
+			t.Errorf("%s: got %s %q (fix %q), want %s with fix containing %q", c.name, op.Status, op.Message, op.Fix, c.status, c.fix)
+		}
+	}
+}
+
+func TestAssessAsterisk(t *testing.T) {
+	if op := assessAsterisk(facts{goos: "linux"}); op.Status != StatusSkipped {
+		t.Errorf("no /etc/asterisk: got %s", op.Status)
+	}
+	f := facts{goos: "linux", user: "alice", asteriskDir: true, asteriskGroup: "asterisk", unreadable: []string{"ari.conf"}, controlDenied: true}
+	op := assessAsterisk(f)
+	if op.Status != StatusDegraded || !strings.Contains(op.Message, "cannot read ari.conf in /etc/asterisk") || !strings.Contains(op.Message, "asterisk.ctl") {
+		t.Errorf("got %s %q", op.Status, op.Message)
+	}
+	if op.Fix != "sudo usermod -aG asterisk alice, then log in again" {
+		t.Errorf("fix = %q", op.Fix)
+	}
+	f.unreadable, f.controlDenied = nil, false
+	if op := assessAsterisk(f); op.Status != StatusOK {
+		t.Errorf("readable: got %s %q", op.Status, op.Message)
+	}
+}
+
+func TestAssessCapture(t *testing.T) {
+	ok := Operation{Status: StatusOK}
+	no := Operation{Status: StatusDenied}
+	cases := []struct {
+		name   string
+		f      facts
+		docker Operation
+		status string
+	}{
+		{"root", facts{goos: "linux", root: true, tcpdump: "/usr/bin/tcpdump"}, ok, StatusOK},
+		{"file caps", facts{goos: "linux", tcpdump: "/usr/bin/tcpdump", tcpdumpCaps: true}, no, StatusOK},
+		{"helper container", facts{goos: "linux", tcpdump: "/usr/bin/tcpdump"}, ok, StatusDegraded},
+		{"no tcpdump", facts{goos: "linux"}, ok, StatusDegraded},
+		{"neither", facts{goos: "linux", tcpdump: "/usr/bin/tcpdump"}, no, StatusDenied},
+	}
+	for _, c := range cases {
+		if op := assessCapture(c.f, c.docker); op.Status != c.status {
+			t.Errorf("%s: got %s %q, want %s", c.name, op.Status, op.Message, c.status)
+		}
+	}
+}
+
+func TestAssessFirewall(t *testing.T) {
+	fw := []string{"/usr/sbin/iptables -S"}
+	cases := []struct {
+		f      facts
+		status string
+	}{
+		{facts{goos: "darwin", firewall: fw}, StatusSkipped},
+		{facts{goos: "linux"}, StatusSkipped},
+		{facts{goos: "linux", root: true, firewall: fw}, StatusOK},
+		{facts{goos: "linux", firewall: fw, firewallSudo: true}, StatusOK},
+		{facts{goos: "linux", firewall: fw}, StatusDegraded},
+	}
+	for i, c := range cases {
+		if op := assessFirewall(c.f); op.Status != c.status {
+			t.Errorf("case %d: got %s %q, want %s", i, op.Status, op.Message, c.status)
+		}
+	}
+}
+
+func TestPermitsCap(t *testing.T) {
+	cases := map[string]bool{
+		"/usr/bin/tcpdump cap_net_admin,cap_net_raw=eip": true,
+		"/usr/bin/tcpdump cap_net_raw=ep":                true,
+		"/usr/bin/tcpdump = cap_net_raw+ep":              true,
+		"/usr/bin/tcpdump cap_net_raw+ep":                true,
+		"/usr/bin/tcpdump cap_net_raw=e":                 false,
+		"/usr/bin/tcpdump cap_net_admin=eip":             false,
+		"":                                               false,
+	}
+	for out, want := range cases {
+		if got := permitsCap(out, "cap_net_raw"); got != want {
+			t.Errorf("permitsCap(%q) = %v, want %v", out, got, want)
+		}
+	}
+}
+
+func TestSudoers(t *testing.T) {
+	out := sudoers("alice", []string{"/usr/sbin/ufw status", "/usr/sbin/iptables -S"}, "/usr/bin/tcpdump")
+	for _, want := range []string{
+		"Cmnd_Alias AAVA_FIREWALL = /usr/sbin/ufw status, /usr/sbin/iptables -S\n",
+		"alice ALL=(root) NOPASSWD: AAVA_FIREWALL\n",
+		"setcap cap_net_raw,cap_net_admin=eip /usr/bin/tcpdump",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("sudoers lacks %q:\n%s", want, out)
+		}
+	}
+	// Every line that is not a comment must be a sudoers directive.
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "Cmnd_Alias ") && !strings.HasPrefix(line, "alice ") {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+	if out := sudoers("alice", nil, ""); strings.Contains(out, "NOPASSWD") {
+		t.Errorf("no firewall tools: want no grant:\n%s", out)
+	}
+}
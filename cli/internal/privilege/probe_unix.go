@@ -0,0 +1,39 @@
+//go:build !windows
+
+package privilege
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func isRoot() bool { return os.Geteuid() == 0 }
+
+// dialSocket connects to a unix socket and hangs up; a permission error
+// means this user may not use it.
+func dialSocket(path string) error {
+	conn, err := net.DialTimeout("unix", path, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func fileGroup(path string) (uint32, bool) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	sys, ok := st.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return sys.Gid, true
+}
+
+func canRead(path string) bool  { return unix.Access(path, unix.R_OK) == nil }
+func canWrite(path string) bool { return unix.Access(path, unix.W_OK) == nil }
@@ -0,0 +1,144 @@
+package redact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultNERModel is the Ollama model used when AAVA_REDACT_NER_MODEL is unset.
+const DefaultNERModel = "llama3.2"
+
+// nerMaxChars bounds one NER request; longer text is sent in chunks.
+const nerMaxChars = 6000
+
+// NER finds person names in free text, which no pattern can.
+type NER interface {
+	Names(ctx context.Context, text string) ([]string, error)
+}
+
+// Ollama finds names with a model served by Ollama (the same server the
+// engine's ollama_llm provider can use), so transcripts never leave the host.
+type Ollama struct {
+	URL   string // base URL, e.g. http://127.0.0.1:11434
+	Model string
+	HTTP  *http.Client
+}
+
+// NERFromEnv returns the Ollama NER configured by AAVA_REDACT_NER_URL and
+// AAVA_REDACT_NER_MODEL, or nil when no URL is set.
+func NERFromEnv() NER {
+	url := strings.TrimRight(strings.TrimSpace(os.Getenv("AAVA_REDACT_NER_URL")), "/")
+	if url == "" {
+		return nil
+	}
+	model := strings.TrimSpace(os.Getenv("AAVA_REDACT_NER_MODEL"))
+	if model == "" {
+		model = DefaultNERModel
+	}
+	return &Ollama{URL: url, Model: model, HTTP: &http.Client{Timeout: 60 * time.Second}}
+}
+
+const nerPrompt = `List every personal name (first names, surnames, full names of people) in the text below.
+Do not list company, product or place names. Reply with JSON only: {"names": ["..."]}.
+
+Text:
+`
+
+// Names asks the model for the names in text, a chunk at a time.
+func (o *Ollama) Names(ctx context.Context, text string) ([]string, error) {
+	var names []string
+	for _, chunk := range chunks(text, nerMaxChars) {
+		body, _ := json.Marshal(map[string]any{
+			"model":   o.Model,
+			"prompt":  nerPrompt + chunk,
+			"stream":  false,
+			"format":  "json",
+			"options": map[string]any{"temperature": 0},
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.URL+"/api/generate", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := o.HTTP.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("NER request to %s failed: %w", o.URL, err)
+		}
+		raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("NER model %s: HTTP %d: %s", o.Model, resp.StatusCode, strings.TrimSpace(string(raw)))
+		}
+		var gen struct {
+			Response string `json:"response"`
+		}
+		var found struct {
+			Names []string `json:"names"`
+		}
+		if err := json.Unmarshal(raw, &gen); err != nil {
+			return nil, fmt.Errorf("invalid Ollama response: %w", err)
+		}
+		if err := json.Unmarshal([]byte(gen.Response), &found); err != nil {
+			return nil, fmt.Errorf("NER model %s did not reply with a names list: %w", o.Model, err)
+		}
+		names = append(names, found.Names...)
+	}
+	return names, nil
+}
+
+// chunks splits text at line breaks into pieces of at most max bytes (a single
+// longer line is split where it falls).
+func chunks(text string, max int) []string {
+	var out []string
+	var cur strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if cur.Len() > 0 && cur.Len()+len(line) > max {
+			out = append(out, cur.String())
+			cur.Reset()
+		}
+		for len(line) > max {
+			out = append(out, line[:max])
+			line = line[max:]
+		}
+		cur.WriteString(line)
+	}
+	if strings.TrimSpace(cur.String()) != "" {
+		out = append(out, cur.String())
+	}
+	return out
+}
+
+// SetNER enables name detection in free text with LearnNames.
+func (r *Redactor) SetNER(n NER) {
+	r.ner = n
+}
+
+// HasNER reports whether name detection is configured.
+func (r *Redactor) HasNER() bool {
+	return r != nil && r.ner != nil
+}
+
+// LearnNames runs the NER over text (a transcript) and masks the names it
+// finds from then on, wherever they appear. Without a NER, or with the name
+// category off, it does nothing.
+func (r *Redactor) LearnNames(ctx context.Context, text string) error {
+	if !r.HasNER() || !r.Enabled(Name) || strings.TrimSpace(text) == "" {
+		return nil
+	}
+	names, err := r.ner.Names(ctx, text)
+	if err != nil {
+		return err
+	}
+	r.Known(Name, names...)
+	return nil
+}
@@ -0,0 +1,350 @@
+// Package redact masks personal data in transcripts, log excerpts and reports
+// before they leave the host: phone numbers, email addresses, payment card
+// numbers and names. Patterns find the first three; names come from the
+// call's caller ID and, optionally, a local model (see NER).
+package redact
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Categories of personal data.
+const (
+	Phone = "phone"
+	Email = "email"
+	Card  = "card"
+	Name  = "name"
+)
+
+// Categories lists every category in the order they are applied: card numbers
+// before phone numbers, which would otherwise claim their digits.
+var Categories = []string{Card, Email, Phone, Name}
+
+// placeholder replaces a redacted value.
+func placeholder(category string) string {
+	return "[" + strings.ToUpper(category) + "]"
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	cardPattern  = regexp.MustCompile(`\d(?:[ -]?\d){12,18}`)
+	// phonePatterns: international with +, North American with separators,
+	// and bare runs of 10-15 digits.
+	phonePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\+\d{1,3}(?:[ .-]?\(?\d{1,4}\)?){2,5}`),
+		regexp.MustCompile(`(?:1[ .-]?)?(?:\(\d{3}\) ?|\d{3}[ .-])\d{3}[ .-]\d{4}`),
+		regexp.MustCompile(`\d{10,15}`),
+	}
+)
+
+// Redactor masks the enabled categories. The zero value masks nothing; use
+// Parse.
+type Redactor struct {
+	enabled map[string]bool
+	known   map[string]map[string]bool // category -> exact values seen in this call
+	ner     NER
+}
+
+// Parse builds a Redactor from a comma-separated category list; "all" (or
+// empty) enables every category.
+func Parse(spec string) (*Redactor, error) {
+	r := &Redactor{enabled: map[string]bool{}, known: map[string]map[string]bool{}}
+	spec = strings.TrimSpace(strings.ToLower(spec))
+	if spec == "" || spec == "all" {
+		for _, c := range Categories {
+			r.enabled[c] = true
+		}
+		return r, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		c := strings.TrimSpace(part)
+		if c == "" {
+			continue
+		}
+		if !validCategory(c) {
+			return nil, fmt.Errorf("unknown redaction category %q (use %s or all)", c, strings.Join(Categories, ", "))
+		}
+		r.enabled[c] = true
+	}
+	if len(r.enabled) == 0 {
+		return nil, fmt.Errorf("no redaction categories in %q", spec)
+	}
+	return r, nil
+}
+
+func validCategory(c string) bool {
+	for _, k := range Categories {
+		if c == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether the category is masked.
+func (r *Redactor) Enabled(category string) bool {
+	return r != nil && r.enabled[category]
+}
+
+// Categories returns the enabled categories in the order they are applied.
+func (r *Redactor) Categories() []string {
+	var out []string
+	for _, c := range Categories {
+		if r.Enabled(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Known adds exact values to mask wherever they appear as a whole word, such as
+// the call's caller number and caller name, which patterns alone miss (an
+// extension like 6001, a name). A name is also masked by each of its words.
+func (r *Redactor) Known(category string, values ...string) {
+	if !r.Enabled(category) {
+		return
+	}
+	set := r.known[category]
+	if set == nil {
+		set = map[string]bool{}
+		r.known[category] = set
+	}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len([]rune(v)) < 3 {
+			continue
+		}
+		set[v] = true
+		if category != Name {
+			continue
+		}
+		for _, word := range strings.Fields(v) {
+			if len([]rune(word)) >= 3 {
+				set[word] = true
+			}
+		}
+	}
+}
+
+// String masks the enabled categories in s.
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, c := range r.Categories() {
+		switch c {
+		case Card:
+			s = replaceChecked(cardPattern, s, c, func(m string) bool { return luhn(m) })
+		case Email:
+			s = emailPattern.ReplaceAllString(s, placeholder(Email))
+		case Phone:
+			for _, p := range phonePatterns {
+				s = replaceChecked(p, s, c, nil)
+			}
+		}
+		s = r.replaceKnown(s, c)
+	}
+	return s
+}
+
+// Strings masks every element in place and returns the slice.
+func (r *Redactor) Strings(in []string) []string {
+	for i := range in {
+		in[i] = r.String(in[i])
+	}
+	return in
+}
+
+// replaceChecked replaces the matches of p that stand alone (not part of a
+// longer number, a decimal or a word, so a call ID like 1761518880.2191 or a
+// timestamp is kept) and pass ok.
+func replaceChecked(p *regexp.Regexp, s, category string, ok func(string) bool) string {
+	locs := p.FindAllStringIndex(s, -1)
+	if locs == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, l := range locs {
+		m := s[l[0]:l[1]]
+		if !standsAlone(s, l[0], l[1]) || (ok != nil && !ok(m)) {
+			continue
+		}
+		b.WriteString(s[last:l[0]])
+		b.WriteString(placeholder(category))
+		last = l[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func standsAlone(s string, start, end int) bool {
+	if start > 0 {
+		prev := s[start-1]
+		if isWordByte(prev) || (prev == '.' && start > 1 && isDigit(s[start-2])) {
+			return false
+		}
+	}
+	if end < len(s) {
+		next := s[end]
+		if isWordByte(next) || (next == '.' && end+1 < len(s) && isDigit(s[end+1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+
+func isWordByte(b byte) bool {
+	return isDigit(b) || b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// luhn reports whether the digits of s pass the payment card checksum.
+func luhn(s string) bool {
+	var digits []int
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// replaceKnown masks the category's known values, longest first, matching names
+// without regard to case.
+func (r *Redactor) replaceKnown(s, category string) string {
+	set := r.known[category]
+	if len(set) == 0 {
+		return s
+	}
+	values := make([]string, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		s = replaceWord(s, v, placeholder(category), category == Name)
+	}
+	return s
+}
+
+// replaceWord replaces whole-word occurrences of v.
+func replaceWord(s, v, with string, fold bool) string {
+	hay, needle := s, v
+	if fold {
+		hay, needle = strings.ToLower(s), strings.ToLower(v)
+		if len(hay) != len(s) {
+			// Lowercasing changed byte offsets; fall back to an exact match.
+			hay, needle = s, v
+		}
+	}
+	var b strings.Builder
+	last, from := 0, 0
+	for {
+		i := strings.Index(hay[from:], needle)
+		if i < 0 {
+			break
+		}
+		start, end := from+i, from+i+len(needle)
+		if wordBoundary(s, start, end) {
+			b.WriteString(s[last:start])
+			b.WriteString(with)
+			last = end
+		}
+		from = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func wordBoundary(s string, start, end int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	if r, _ := utf8.DecodeLastRuneInString(s[:start]); start > 0 && isWord(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && isWord(r) {
+		return false
+	}
+	return true
+}
+
+// Walk masks every exported string reachable from v, which must be a pointer:
+// struct fields, slice and array elements, map values and what pointers and
+// interfaces hold. It is how a whole report is redacted before it is printed
+// or exported.
+func (r *Redactor) Walk(v any) {
+	if r == nil {
+		return
+	}
+	r.walk(reflect.ValueOf(v))
+}
+
+func (r *Redactor) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			// Values in an interface cannot be set in place; redact a copy.
+			inner := v.Elem()
+			if inner.Kind() == reflect.String && v.CanSet() {
+				v.Set(reflect.ValueOf(r.String(inner.String())).Convert(inner.Type()))
+				return
+			}
+			if inner.Kind() != reflect.Pointer && v.CanSet() {
+				cp := reflect.New(inner.Type()).Elem()
+				cp.Set(inner)
+				r.walk(cp)
+				v.Set(cp)
+				return
+			}
+		}
+		r.walk(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				r.walk(f)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			r.walk(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			cp := reflect.New(iter.Value().Type()).Elem()
+			cp.Set(iter.Value())
+			r.walk(cp)
+			v.SetMapIndex(iter.Key(), cp)
+		}
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(r.String(v.String()))
+		}
+	}
+}
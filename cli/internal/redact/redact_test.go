@@ -0,0 +1,137 @@
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	r, err := Parse("all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Known(Phone, "6001")
+	r.Known(Name, "Jane Doe")
+	for in, want := range map[string]string{
+		"call from +1 (555) 123-4567 to 6001":                 "call from [PHONE] to [PHONE]",
+		"callback 555-123-4567 or +442079460958":              "callback [PHONE] or [PHONE]",
+		`"caller_number": "15551234567"`:                      `"caller_number": "[PHONE]"`,
+		"email jane.doe@example.co.uk please":                 "email [EMAIL] please",
+		"card 4111 1111 1111 1111 exp 12/29":                  "card [CARD] exp 12/29",
+		"order 4111 1111 1111 1112 (fails the checksum)":      "order 4111 1111 1111 1112 (fails the checksum)",
+		"Hi JANE, is Doe your surname? Janet is not a match.": "Hi [NAME], is [NAME] your surname? Janet is not a match.",
+		"Jane Doe called":                                     "[NAME] called",
+		"call_id=1761518880.2191 at 2026-10-15T12:00:00.123Z": "call_id=1761518880.2191 at 2026-10-15T12:00:00.123Z",
+		"bytes=160010 ext60010 rate 0.1761518880":             "bytes=160010 ext60010 rate 0.1761518880",
+		"10.100.200.45:8088 sip:6001@10.0.0.5":                "10.100.200.45:8088 sip:[PHONE]@10.0.0.5",
+	} {
+		if got := r.String(in); got != want {
+			t.Errorf("String(%q)\n got %q\nwant %q", in, got, want)
+		}
+	}
+
+	only, err := Parse("email, card")
+	if err != nil {
+		t.Fatal(err)
+	}
+	only.Known(Name, "Jane")
+	if got := only.String("Jane at +15551234567, jane@example.com"); got != "Jane at +15551234567, [EMAIL]" {
+		t.Errorf("email only = %q", got)
+	}
+	if _, err := Parse("phone,ssn"); err == nil {
+		t.Error("unknown category accepted")
+	}
+	var none *Redactor
+	if got := none.String("+15551234567"); got != "+15551234567" {
+		t.Errorf("nil redactor changed %q", got)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	type inner struct {
+		Caller string
+		Lines  []string
+		Attrs  map[string]any
+	}
+	type report struct {
+		CallID string
+		Count  int
+		Inner  *inner
+		Any    any
+		hidden string
+	}
+	rep := &report{
+		CallID: "1761518880.2191",
+		Count:  42,
+		Inner: &inner{
+			Caller: "+15551234567",
+			Lines:  []string{"mail bob@example.com"},
+			Attrs:  map[string]any{"to": "555-123-4567", "n": 3, "nested": []any{"+15559876543"}},
+		},
+		Any:    "jane@example.com",
+		hidden: "+15551234567",
+	}
+	r, _ := Parse("")
+	r.Walk(rep)
+	raw, _ := json.Marshal(rep)
+	for _, leak := range []string{"5551234567", "bob@", "555-123", "5559876543", "jane@"} {
+		if strings.Contains(string(raw), leak) {
+			t.Errorf("%s left in %s", leak, raw)
+		}
+	}
+	if rep.CallID != "1761518880.2191" || rep.Count != 42 || rep.hidden != "+15551234567" || rep.Inner.Attrs["n"] != 3 {
+		t.Errorf("walk changed non-string or unexported fields: %+v", rep)
+	}
+}
+
+func TestLearnNamesWithOllama(t *testing.T) {
+	var prompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Model  string `json:"model"`
+			Prompt string `json:"prompt"`
+		}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		if req.URL.Path != "/api/generate" || body.Model != "tiny" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		prompts = append(prompts, body.Prompt)
+		_ = json.NewEncoder(w).Encode(map[string]string{"response": `{"names": ["Maria Lopez"]}`})
+	}))
+	defer srv.Close()
+
+	r, _ := Parse("name")
+	r.SetNER(&Ollama{URL: srv.URL, Model: "tiny", HTTP: srv.Client()})
+	if err := r.LearnNames(context.Background(), "Caller: this is Maria Lopez\nAgent: thanks Maria"); err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "this is Maria Lopez") {
+		t.Fatalf("prompts = %q", prompts)
+	}
+	if got := r.String("Agent: thanks Maria, Ms. Lopez"); got != "Agent: thanks [NAME], Ms. [NAME]" {
+		t.Errorf("after NER = %q", got)
+	}
+
+	r.SetNER(&Ollama{URL: srv.URL, Model: "other", HTTP: srv.Client()})
+	if err := r.LearnNames(context.Background(), "text"); err == nil {
+		t.Error("NER error not returned")
+	}
+}
+
+func TestChunks(t *testing.T) {
+	text := strings.Repeat("a", 8) + "\n" + strings.Repeat("b", 25) + "\nc\n"
+	got := chunks(text, 10)
+	if strings.Join(got, "") != text {
+		t.Fatalf("chunks lost text: %q", got)
+	}
+	for _, c := range got {
+		if len(c) > 10 {
+			t.Errorf("chunk %q over 10 bytes", c)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package schema
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+)
+
+// Generate builds the JSON Schema (draft 2020-12) of v's JSON encoding. Fields
+// without omitempty are required; additional properties are allowed, since
+// new fields may appear without a schema_version bump. Named structs become
+// $defs.
+func Generate(id, title, description string, v any) ([]byte, error) {
+	g := &generator{defs: map[string]any{}, names: map[reflect.Type]string{}}
+	root := g.structSchema(reflect.TypeOf(v))
+	if props, ok := root["properties"].(map[string]any); ok {
+		if _, ok := props["schema_version"]; ok {
+			props["schema_version"] = map[string]any{"const": contract.SchemaVersion}
+		}
+	}
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = id
+	root["title"] = title
+	root["description"] = description
+	if len(g.defs) > 0 {
+		root["$defs"] = g.defs
+	}
+	raw, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(raw, '\n'), nil
+}
+
+type generator struct {
+	defs  map[string]any
+	names map[reflect.Type]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) typeSchema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return g.typeSchema(t.Elem())
+	case t.Kind() == reflect.Struct && t.Name() == "":
+		return g.structSchema(t)
+	case t.Kind() == reflect.Struct:
+		return map[string]any{"$ref": "#/$defs/" + g.define(t)}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]any{"type": "array", "items": g.typeSchema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{} // interfaces: any JSON value
+}
+
+// define adds a named struct to $defs once, under its type name, or qualified
+// by its package when another package's type has the name.
+func (g *generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + t.Name()
+	}
+	g.names[t] = name
+	g.defs[name] = map[string]any{} // placeholder for recursive types
+	g.defs[name] = g.structSchema(t)
+	return name
+}
+
+func (g *generator) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	g.addFields(t, props, &required)
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// addFields follows encoding/json: untagged embedded structs are flattened,
+// "-" and unexported fields are skipped.
+func (g *generator) addFields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := g.typeSchema(f.Type)
+		if hasOpt(opts, "string") {
+			s = map[string]any{"type": "string"}
+		}
+		if hasOpt(opts, "omitempty") {
+			props[name] = s
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			s = nullable(s)
+		}
+		props[name] = s
+		*required = append(*required, name)
+	}
+}
+
+func hasOpt(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable admits null, which a nil pointer, slice or map without omitempty
+// encodes as.
+func nullable(s map[string]any) map[string]any {
+	if typ, ok := s["type"].(string); ok && typ != "" {
+		out := map[string]any{}
+		for k, v := range s {
+			out[k] = v
+		}
+		out["type"] = []string{typ, "null"}
+		return out
+	}
+	if len(s) == 0 {
+		return s
+	}
+	return map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+}
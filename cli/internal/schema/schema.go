@@ -0,0 +1,61 @@
+// Package schema publishes the JSON Schemas of the CLI's machine-readable
+// reports, so external tooling can rely on their field names and types.
+//
+// The schemas are generated from the Go types that produce the reports and
+// kept as files beside this one. TestSchemas fails when a report type drifts
+// from its file, so every change to the output is deliberate: adding a field
+// is compatible, while removing, renaming or retyping one needs a
+// contract.SchemaVersion bump.
+package schema
+
+import (
+	"embed"
+	"fmt"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+//go:embed *.schema.json
+var files embed.FS
+
+// Report is a published schema.
+type Report struct {
+	Name    string // agent docs schema <name>
+	File    string
+	Command string // the commands whose --json output it describes
+	Title   string
+	value   any
+}
+
+// Reports are the published schemas.
+var Reports = []Report{
+	{Name: "check", File: "check-report.schema.json", Command: "agent check --json, agent doctor --json", Title: "agent check report", value: check.Report{}},
+	{Name: "rca", File: "rca-report.schema.json", Command: "agent rca --json (one call)", Title: "agent rca report", value: troubleshoot.RCAReport{}},
+}
+
+// Lookup finds a published schema by name.
+func Lookup(name string) (Report, bool) {
+	for _, r := range Reports {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Report{}, false
+}
+
+// ID is the schema's $id; it carries the schema version.
+func (r Report) ID() string {
+	return fmt.Sprintf("https://asterisk-ai-voice-agent.local/cli/v%d/%s", contract.SchemaVersion, r.File)
+}
+
+// Published is the schema file shipped with this CLI.
+func (r Report) Published() ([]byte, error) {
+	return files.ReadFile(r.File)
+}
+
+// Generate builds the schema from the report type as it is now.
+func (r Report) Generate() ([]byte, error) {
+	return Generate(r.ID(), r.Title, "Output of "+r.Command+", schema_version "+fmt.Sprint(contract.SchemaVersion)+".", r.value)
+}
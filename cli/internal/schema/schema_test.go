@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/check"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+var update = flag.Bool("update", false, "rewrite the schema files from the report types")
+
+// TestSchemas keeps the published schemas in step with the report types. A
+// difference means the --json output changed: adding a field is fine (run
+// go test ./internal/schema -update), removing, renaming or retyping one also
+// needs a contract.SchemaVersion bump and a changelog entry.
+func TestSchemas(t *testing.T) {
+	for _, r := range Reports {
+		got, err := r.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *update {
+			if err := os.WriteFile(r.File, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := r.Published()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s --json output no longer matches %s; if intended, run go test ./internal/schema -update (removed or retyped fields also need a schema_version bump)", r.Name, r.File)
+		}
+	}
+}
+
+// TestRequiredFields pins the fields integrations key on; they must stay
+// required in the published schemas.
+func TestRequiredFields(t *testing.T) {
+	for name, fields := range map[string][]string{
+		"check": {"schema_version", "version", "timestamp", "items", "pass_count", "warn_count", "fail_count", "skip_count", "total"},
+		"rca":   {"schema_version", "call_id", "pipeline"},
+	} {
+		r, _ := Lookup(name)
+		raw, err := r.Published()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s struct {
+			Required []string `json:"required"`
+		}
+		if err := json.Unmarshal(raw, &s); err != nil {
+			t.Fatal(err)
+		}
+		have := map[string]bool{}
+		for _, f := range s.Required {
+			have[f] = true
+		}
+		for _, f := range fields {
+			if !have[f] {
+				t.Errorf("%s: %s is not required", r.File, f)
+			}
+		}
+	}
+}
+
+// TestReportsMatchSchema checks that encoded reports only use properties the
+// schema declares, at the top level.
+func TestReportsMatchSchema(t *testing.T) {
+	for name, v := range map[string]any{
+		"check": &check.Report{Items: []check.Item{{Name: "Docker", Status: check.StatusPass}}},
+		"rca":   &troubleshoot.RCAReport{CallID: "1761518880.2191", Errors: []string{"x"}},
+	} {
+		r, _ := Lookup(name)
+		raw, _ := r.Published()
+		var s struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal(raw, &s); err != nil {
+			t.Fatal(err)
+		}
+		out, _ := json.Marshal(v)
+		var fields map[string]json.RawMessage
+		_ = json.Unmarshal(out, &fields)
+		for f := range fields {
+			if _, ok := s.Properties[f]; !ok {
+				t.Errorf("%s: %s is not in %s", name, f, r.File)
+			}
+		}
+	}
+}
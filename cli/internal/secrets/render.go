@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultRenderPath is where Render writes the keys for ai_engine's env_file.
+// /run is a tmpfs, so the plain keys never reach the disk.
+const DefaultRenderPath = "/run/aava/secrets.env"
+
+// Render writes the keys the configured store holds to path, a dotenv file
+// readable only by its owner, for docker compose to load with env_file. It
+// returns how many keys it wrote; with none the file is still replaced, so
+// removed keys do not linger.
+func Render(path string) (int, error) {
+	store, err := Store()
+	if err != nil {
+		return 0, err
+	}
+	if store == StoreEnv {
+		return 0, fmt.Errorf("the secrets store is env; ai_engine reads its keys from .env already")
+	}
+	values, err := Resolved()
+	if err != nil {
+		return 0, err
+	}
+	quoted := make(map[string]string, len(values))
+	for name, v := range values {
+		quoted[name] = quoteEnvFile(v)
+	}
+	header := fmt.Sprintf("# Rendered by agent secrets render from the %s; do not edit.\n", Label(store))
+	return len(values), writePrivate(path, dotenv(quoted, header))
+}
+
+// quoteEnvFile quotes a value for a compose env_file: single quotes are
+// literal; a value containing one is double-quoted with \, " and $ escaped.
+func quoteEnvFile(v string) string {
+	if !strings.Contains(v, "'") {
+		return "'" + v + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$")
+	return `"` + r.Replace(v) + `"`
+}
+
+// ComposeTemplate returns a docker-compose.override.yml that adds the file
+// rendered at path to ai_engine's env_file, after .env so its keys win.
+func ComposeTemplate(path string) string {
+	return fmt.Sprintf(`# Generated by agent secrets template compose.
+# ai_engine loads the provider keys rendered to %[1]s when it is
+# created. agent secrets render, or a Vault Agent using the template from
+# agent secrets template vault-agent, writes the file; then run
+# docker compose up -d --force-recreate ai_engine. Needs docker compose 2.24+.
+services:
+  ai_engine:
+    env_file:
+      - .env
+      - path: %[1]s
+        required: false
+`, path)
+}
+
+// VaultAgentTemplate returns a Vault Agent (consul-template) template that
+// renders the provider keys of the configured Vault entry in the format
+// Render writes.
+func VaultAgentTemplate() string {
+	v := configured().Vault
+	mount, path := v.Mount, strings.Trim(v.Path, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "{{/* Generated by agent secrets template vault-agent; renders %s/%s for ai_engine's env_file. */ -}}\n", mount, path)
+	fmt.Fprintf(&b, "{{ with secret \"%s/data/%s\" -}}\n", mount, path)
+	for _, name := range Names {
+		fmt.Fprintf(&b, "{{ if .Data.data.%[1]s }}%[1]s='{{ .Data.data.%[1]s }}'\n{{ end -}}\n", name)
+	}
+	b.WriteString("{{ end -}}\n")
+	return b.String()
+}
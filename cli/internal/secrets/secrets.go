@@ -0,0 +1,407 @@
+// Package secrets resolves provider API keys from wherever the operator keeps
+// them, so the wizard, agent check and the other commands agree on one answer.
+//
+// A key is looked up in the process environment, then in the configured store,
+// then in the project's .env. The store is set by secrets: in
+// .agent/deployment.yaml (or AAVA_SECRETS):
+//
+//	env      keys stay in .env (default)
+//	keyring  the OS keyring, one entry per key under the service "aava-agent"
+//	file     an age-encrypted dotenv file, .agent/secrets.env.age
+//	sops     a SOPS-encrypted dotenv file, .agent/secrets.sops.env
+//	vault    a HashiCorp Vault KV v2 entry, one field per key
+//	aws      an AWS Secrets Manager secret holding a JSON object
+//	gcp      a Google Secret Manager secret holding a JSON object
+//
+// The engine container still reads its keys from its environment; Environ
+// returns the stored keys for the docker compose commands that create it, and
+// Render writes them to the file a generated env_file entry loads.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/keyring"
+)
+
+// Stores a deployment can keep its keys in.
+const (
+	StoreEnv     = "env"
+	StoreKeyring = "keyring"
+	StoreFile    = "file"
+	StoreSOPS    = "sops"
+	StoreVault   = "vault"
+	StoreAWS     = "aws"
+	StoreGCP     = "gcp"
+)
+
+// Sources Lookup reports a key was found in, besides the label of the
+// store that held it.
+const (
+	SourceEnvironment = "environment"
+	SourceKeyring     = "OS keyring"
+	SourceDotEnv      = ".env"
+)
+
+// Names are the provider keys the stores manage.
+var Names = []string{
+	"OPENAI_API_KEY",
+	"DEEPGRAM_API_KEY",
+	"ANTHROPIC_API_KEY",
+	"ELEVENLABS_API_KEY",
+	"ELEVENLABS_AGENT_ID",
+	"TELNYX_API_KEY",
+	"GROQ_API_KEY",
+	"GOOGLE_API_KEY",
+	"XAI_API_KEY",
+	"CAMB_API_KEY",
+}
+
+// IsName reports whether name is one of Names.
+func IsName(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// configured is the secrets: setting; tests replace it.
+var configured = func() deployment.Secrets { return deployment.Current().Secrets }
+
+// Store returns the configured store.
+func Store() (string, error) {
+	s := strings.ToLower(strings.TrimSpace(configured().Store))
+	switch s {
+	case "", StoreEnv:
+		return StoreEnv, nil
+	case StoreKeyring, StoreFile, StoreSOPS, StoreVault, StoreAWS, StoreGCP:
+		return s, nil
+	}
+	return "", fmt.Errorf("secrets: %q is not a store (env, keyring, file, sops, vault, aws or gcp)", s)
+}
+
+// Label names a store for messages.
+func Label(store string) string {
+	if store == StoreKeyring {
+		return "OS keyring"
+	}
+	if doc := documentFor(store); doc != nil {
+		return doc.label()
+	}
+	return ".env"
+}
+
+// FilePath is the age or SOPS file: file: under secrets:, AAVA_SECRETS_FILE,
+// or .agent/secrets.env.age (.agent/secrets.sops.env for sops).
+func FilePath() string {
+	if p := strings.TrimSpace(configured().File); p != "" {
+		return p
+	}
+	if s, _ := Store(); s == StoreSOPS {
+		return filepath.Join(".agent", "secrets.sops.env")
+	}
+	return filepath.Join(".agent", "secrets.env.age")
+}
+
+// IdentityPath is the age identity that decrypts the secrets file,
+// AAVA_AGE_IDENTITY or ~/.config/age/keys.txt.
+func IdentityPath() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_AGE_IDENTITY")); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join("age", "keys.txt")
+	}
+	return filepath.Join(dir, "age", "keys.txt")
+}
+
+// Lookup resolves name and reports where it was found: the process
+// environment, the store (for Names only), or dotenv, the parsed .env. It
+// returns "", "" when the key is set nowhere.
+func Lookup(name string, dotenv map[string]string) (string, string) {
+	if v := os.Getenv(name); v != "" {
+		return v, SourceEnvironment
+	}
+	if v, source := Stored(name); v != "" {
+		return v, source
+	}
+	if v := dotenv[name]; v != "" {
+		return v, SourceDotEnv
+	}
+	return "", ""
+}
+
+// Stored returns name's value in the configured store and the store's source,
+// or "" when the store is env, does not hold it or cannot be read (Check
+// reports why).
+func Stored(name string) (string, string) {
+	if !IsName(name) {
+		return "", ""
+	}
+	store, _ := Store()
+	switch store {
+	case StoreEnv:
+		return "", ""
+	case StoreKeyring:
+		return cached.ringValue(name), SourceKeyring
+	}
+	values, _ := cached.document(store)
+	return values[name], Label(store)
+}
+
+// Check reports whether the configured store can be used: the keyring tool is
+// installed, or the store's secret can be read.
+func Check() error {
+	store, err := Store()
+	if err != nil {
+		return err
+	}
+	switch store {
+	case StoreEnv:
+		return nil
+	case StoreKeyring:
+		return keyringAvailable()
+	}
+	_, err = cached.document(store)
+	return err
+}
+
+// Set stores value for name in the configured store.
+func Set(name, value string) error {
+	if !IsName(name) {
+		return fmt.Errorf("%s is not a provider key (%s)", name, strings.Join(Names, ", "))
+	}
+	store, err := Store()
+	if err != nil {
+		return err
+	}
+	switch store {
+	case StoreEnv:
+		return errors.New("the secrets store is env; set secrets: in .agent/deployment.yaml, or edit .env")
+	case StoreKeyring:
+		if err := keyring.Set(name, value, "AAVA "+name); err != nil {
+			return err
+		}
+		cached.setKeyring(name, value)
+		return nil
+	}
+	return cached.update(store, func(m map[string]string) { m[name] = value })
+}
+
+// Delete removes name from the configured store.
+func Delete(name string) error {
+	store, err := Store()
+	if err != nil {
+		return err
+	}
+	switch store {
+	case StoreEnv:
+		return errors.New("the secrets store is env; remove the key from .env")
+	case StoreKeyring:
+		if err := keyring.Delete(name); err != nil {
+			return err
+		}
+		cached.setKeyring(name, "")
+		return nil
+	}
+	return cached.update(store, func(m map[string]string) { delete(m, name) })
+}
+
+// Resolved returns every provider key the configured store holds.
+func Resolved() (map[string]string, error) {
+	if err := Check(); err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for _, name := range Names {
+		if v, _ := Stored(name); v != "" {
+			out[name] = v
+		}
+	}
+	return out, nil
+}
+
+// Environ returns the process environment with the stored keys added, for the
+// docker compose commands that create ai_engine; keys already in the
+// environment win. It returns nil, meaning the unchanged environment, when
+// the store adds nothing.
+func Environ() []string {
+	var add []string
+	for _, name := range Names {
+		if os.Getenv(name) != "" {
+			continue
+		}
+		if v, _ := Stored(name); v != "" {
+			add = append(add, name+"="+v)
+		}
+	}
+	if len(add) == 0 {
+		return nil
+	}
+	return append(os.Environ(), add...)
+}
+
+// run executes an external tool; tests replace it.
+var run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", name, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
+
+// keyringGet and keyringAvailable reach the OS keyring; tests replace them.
+var (
+	keyringGet       = keyring.Get
+	keyringAvailable = keyring.Available
+)
+
+// Keyring lookups and the store's secret are read once per process.
+var cached cache
+
+type cache struct {
+	sync.Mutex
+	ring   map[string]string
+	values map[string]string
+	loaded bool
+	err    error
+}
+
+func (c *cache) ringValue(name string) string {
+	c.Lock()
+	defer c.Unlock()
+	if v, ok := c.ring[name]; ok {
+		return v
+	}
+	v, _ := keyringGet(name)
+	if c.ring == nil {
+		c.ring = map[string]string{}
+	}
+	c.ring[name] = v
+	return v
+}
+
+func (c *cache) setKeyring(name, value string) {
+	c.Lock()
+	defer c.Unlock()
+	if c.ring == nil {
+		c.ring = map[string]string{}
+	}
+	c.ring[name] = value
+}
+
+func (c *cache) document(store string) (map[string]string, error) {
+	c.Lock()
+	defer c.Unlock()
+	if !c.loaded {
+		c.values, c.err = documentFor(store).load()
+		c.loaded = true
+	}
+	return c.values, c.err
+}
+
+// update rereads the store's secret, changes it and writes it back.
+func (c *cache) update(store string, change func(map[string]string)) error {
+	c.Lock()
+	defer c.Unlock()
+	doc := documentFor(store)
+	values, err := doc.load()
+	if err != nil {
+		return err
+	}
+	change(values)
+	if err := doc.save(values); err != nil {
+		return err
+	}
+	c.values, c.err, c.loaded = values, nil, true
+	return nil
+}
+
+func (c *cache) reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.ring, c.values, c.loaded, c.err = nil, nil, false, nil
+}
+
+// DotEnvPath is the project's .env: ./.env, or ../.env when run from a
+// subdirectory such as cli/.
+func DotEnvPath() string {
+	if _, err := os.Stat(".env"); os.IsNotExist(err) {
+		if _, err := os.Stat(filepath.Join("..", ".env")); err == nil {
+			return filepath.Join("..", ".env")
+		}
+	}
+	return ".env"
+}
+
+// ParseDotEnv reads KEY=VALUE lines, skipping comments and removing quotes.
+func ParseDotEnv(data []byte) map[string]string {
+	out := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		out[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), "\"'")
+	}
+	return out
+}
+
+// RemoveFromDotEnv deletes the assignments of names from the .env at path,
+// keeping everything else as it is. It reports the names it removed.
+func RemoveFromDotEnv(path string, names []string) ([]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	drop := map[string]bool{}
+	for _, n := range names {
+		drop[n] = true
+	}
+	var kept []string
+	var removed []string
+	for _, line := range strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n") {
+		key, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		if key = strings.TrimSpace(key); ok && drop[key] {
+			removed = append(removed, key)
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(kept, "\n")+"\n"), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	return removed, os.Rename(tmp, path)
+}
@@ -0,0 +1,309 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+)
+
+// useStore selects store for the test and clears the process-wide cache.
+func useStore(t *testing.T, store string) {
+	t.Helper()
+	useConfig(t, deployment.Secrets{Store: store})
+}
+
+func useConfig(t *testing.T, cfg deployment.Secrets) {
+	t.Helper()
+	saved := configured
+	configured = func() deployment.Secrets { return cfg }
+	cached.reset()
+	t.Cleanup(func() { configured = saved; cached.reset() })
+	for _, name := range Names {
+		t.Setenv(name, "")
+	}
+}
+
+// fakeAge stands in for age and age-keygen: "encryption" prefixes the
+// plaintext with the recipients, which decryption strips again.
+func fakeAge(t *testing.T) *[]string {
+	t.Helper()
+	var calls []string
+	saved := run
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		switch {
+		case name == "age-keygen":
+			return []byte("age1testrecipient\n"), nil
+		case args[0] == "--encrypt":
+			return append([]byte("AGE["+strings.Join(args[2:], " ")+"]\n"), stdin...), nil
+		default:
+			raw, err := os.ReadFile(args[len(args)-1])
+			if err != nil {
+				return nil, err
+			}
+			_, plain, _ := bytes.Cut(raw, []byte("\n"))
+			return plain, nil
+		}
+	}
+	t.Cleanup(func() { run = saved })
+	return &calls
+}
+
+func TestLookupOrder(t *testing.T) {
+	useStore(t, StoreKeyring)
+	saved := keyringGet
+	keyringGet = func(name string) (string, error) {
+		if name == "DEEPGRAM_API_KEY" {
+			return "dg-from-keyring", nil
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { keyringGet = saved })
+
+	dotenv := map[string]string{"OPENAI_API_KEY": "sk-dotenv", "DEEPGRAM_API_KEY": "dg-dotenv", "ASTERISK_HOST": "127.0.0.1"}
+	t.Setenv("OPENAI_API_KEY", "sk-process")
+	for name, want := range map[string][2]string{
+		"OPENAI_API_KEY":    {"sk-process", SourceEnvironment},
+		"DEEPGRAM_API_KEY":  {"dg-from-keyring", SourceKeyring},
+		"ASTERISK_HOST":     {"127.0.0.1", SourceDotEnv},
+		"ANTHROPIC_API_KEY": {"", ""},
+	} {
+		if v, source := Lookup(name, dotenv); v != want[0] || source != want[1] {
+			t.Errorf("Lookup(%s) = %q, %q; want %q, %q", name, v, source, want[0], want[1])
+		}
+	}
+
+	env := Environ()
+	if !contains(env, "DEEPGRAM_API_KEY=dg-from-keyring") || contains(env, "OPENAI_API_KEY=sk-dotenv") {
+		t.Errorf("Environ() lacks the keyring key or overrides the process: %v", env)
+	}
+
+	useStore(t, StoreEnv)
+	if v, source := Lookup("DEEPGRAM_API_KEY", dotenv); v != "dg-dotenv" || source != SourceDotEnv {
+		t.Errorf("env store: Lookup = %q, %q", v, source)
+	}
+	if Environ() != nil {
+		t.Error("env store: Environ() should leave the environment unchanged")
+	}
+}
+
+func TestSecretsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.env.age")
+	useConfig(t, deployment.Secrets{Store: StoreFile, File: path})
+	calls := fakeAge(t)
+	t.Setenv("AAVA_AGE_RECIPIENTS", "")
+	t.Setenv("AAVA_AGE_IDENTITY", "/keys/agent.txt")
+
+	if err := Check(); err != nil {
+		t.Fatalf("Check() with no file yet: %v", err)
+	}
+	if err := Set("OPENAI_API_KEY", "sk-file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("GROQ_API_KEY", "gsk-file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("ASTERISK_HOST", "x"); err == nil {
+		t.Error("Set accepted a name that is not a provider key")
+	}
+	raw, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(raw), "AGE[--recipient age1testrecipient]") {
+		t.Errorf("file not encrypted to the identity's recipient: %q", raw)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v", info.Mode().Perm())
+	}
+
+	// A fresh process decrypts the file with the identity.
+	cached.reset()
+	if v, source := Lookup("OPENAI_API_KEY", nil); v != "sk-file" || source != "secrets file "+path {
+		t.Errorf("Lookup = %q, %q", v, source)
+	}
+	if !strings.Contains(strings.Join(*calls, "\n"), "age --decrypt --identity /keys/agent.txt "+path) {
+		t.Errorf("age calls = %v", *calls)
+	}
+	if err := Delete("OPENAI_API_KEY"); err != nil {
+		t.Fatal(err)
+	}
+	cached.reset()
+	if v, _ := Lookup("OPENAI_API_KEY", nil); v != "" {
+		t.Errorf("deleted key still resolves to %q", v)
+	}
+	if v, _ := Lookup("GROQ_API_KEY", nil); v != "gsk-file" {
+		t.Errorf("other key lost: %q", v)
+	}
+}
+
+func TestVault(t *testing.T) {
+	var stored map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/data/pbx/west" || r.Header.Get("X-Vault-Token") != "s.test" || r.Header.Get("X-Vault-Namespace") != "ops" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if stored == nil {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": stored}})
+		case http.MethodPost:
+			var body struct {
+				Data map[string]any `json:"data"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			stored = body.Data
+		}
+	}))
+	defer srv.Close()
+	useConfig(t, deployment.Secrets{Store: StoreVault, Vault: deployment.SecretsVault{Address: srv.URL, Namespace: "ops", Mount: "kv", Path: "/pbx/west/"}})
+	t.Setenv("VAULT_TOKEN", "s.test")
+
+	if err := Check(); err != nil {
+		t.Fatalf("Check() on a missing entry: %v", err)
+	}
+	if err := Set("DEEPGRAM_API_KEY", "dg-vault"); err != nil {
+		t.Fatal(err)
+	}
+	cached.reset()
+	if v, source := Lookup("DEEPGRAM_API_KEY", nil); v != "dg-vault" || source != "Vault kv/pbx/west" {
+		t.Errorf("Lookup = %q, %q", v, source)
+	}
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	t.Setenv("HOME", t.TempDir())
+	cached.reset()
+	if err := Check(); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Check() with a bad token: %v", err)
+	}
+}
+
+func TestCloudSecrets(t *testing.T) {
+	secret := `{"OPENAI_API_KEY":"sk-aws","unused":1}`
+	var calls []string
+	saved := run
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		if strings.Contains(strings.Join(args, " "), "put-secret-value") || strings.Contains(strings.Join(args, " "), "versions add") {
+			secret = string(stdin)
+			return nil, nil
+		}
+		return []byte(secret + "\n"), nil
+	}
+	t.Cleanup(func() { run = saved })
+
+	useConfig(t, deployment.Secrets{Store: StoreAWS, AWS: deployment.SecretsAWS{SecretID: "aava/keys", Region: "eu-central-1"}})
+	if v, source := Lookup("OPENAI_API_KEY", nil); v != "sk-aws" || source != "AWS secret aava/keys" {
+		t.Errorf("aws Lookup = %q, %q", v, source)
+	}
+	if err := Set("GROQ_API_KEY", "gsk"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(secret, `"GROQ_API_KEY":"gsk"`) || !strings.Contains(secret, `"OPENAI_API_KEY":"sk-aws"`) {
+		t.Errorf("aws secret = %s", secret)
+	}
+	if want := "aws secretsmanager get-secret-value --secret-id aava/keys --query SecretString --output text --region eu-central-1"; calls[0] != want {
+		t.Errorf("aws call = %q", calls[0])
+	}
+
+	calls = nil
+	useConfig(t, deployment.Secrets{Store: StoreGCP, GCP: deployment.SecretsGCP{Secret: "aava-keys", Project: "pbx"}})
+	if v, _ := Lookup("GROQ_API_KEY", nil); v != "gsk" {
+		t.Errorf("gcp Lookup = %q", v)
+	}
+	if want := "gcloud secrets versions access latest --secret aava-keys --project pbx"; calls[0] != want {
+		t.Errorf("gcp call = %q", calls[0])
+	}
+}
+
+func TestSOPSIsReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.sops.env")
+	os.WriteFile(path, []byte("encrypted"), 0o600)
+	useConfig(t, deployment.Secrets{Store: StoreSOPS, File: path})
+	saved := run
+	run = func(stdin []byte, name string, args ...string) ([]byte, error) {
+		return []byte("OPENAI_API_KEY=sk-sops\n"), nil
+	}
+	t.Cleanup(func() { run = saved })
+	if v, _ := Lookup("OPENAI_API_KEY", nil); v != "sk-sops" {
+		t.Errorf("Lookup = %q", v)
+	}
+	if err := Set("OPENAI_API_KEY", "x"); err == nil || !strings.Contains(err.Error(), "sops "+path) {
+		t.Errorf("Set = %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	useStore(t, StoreKeyring)
+	saved, savedAvailable := keyringGet, keyringAvailable
+	keyringAvailable = func() error { return nil }
+	keyringGet = func(name string) (string, error) {
+		switch name {
+		case "OPENAI_API_KEY":
+			return "sk-1", nil
+		case "GROQ_API_KEY":
+			return `it's$x`, nil
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { keyringGet, keyringAvailable = saved, savedAvailable })
+
+	path := filepath.Join(t.TempDir(), "run", "secrets.env")
+	n, err := Render(path)
+	if err != nil || n != 2 {
+		t.Fatalf("Render = %d, %v", n, err)
+	}
+	raw, _ := os.ReadFile(path)
+	if !strings.Contains(string(raw), "GROQ_API_KEY=\"it's$$x\"\nOPENAI_API_KEY='sk-1'\n") {
+		t.Errorf("rendered = %q", raw)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v", info.Mode().Perm())
+	}
+	if !strings.Contains(ComposeTemplate(path), "- path: "+path) {
+		t.Error("compose template does not load the rendered file")
+	}
+	useConfig(t, deployment.Secrets{Store: StoreVault, Vault: deployment.SecretsVault{Mount: "kv", Path: "aava"}})
+	if tmpl := VaultAgentTemplate(); !strings.Contains(tmpl, `{{ with secret "kv/data/aava" -}}`) || !strings.Contains(tmpl, "OPENAI_API_KEY='{{ .Data.data.OPENAI_API_KEY }}'") {
+		t.Errorf("vault agent template = %s", tmpl)
+	}
+}
+
+func TestRemoveFromDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	in := "# Providers\nOPENAI_API_KEY=sk-1\nASTERISK_HOST=127.0.0.1\nexport DEEPGRAM_API_KEY=\"dg\"\n#GROQ_API_KEY=\n"
+	if err := os.WriteFile(path, []byte(in), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := RemoveFromDotEnv(path, []string{"OPENAI_API_KEY", "DEEPGRAM_API_KEY", "GROQ_API_KEY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(removed, ",") != "OPENAI_API_KEY,DEEPGRAM_API_KEY" {
+		t.Errorf("removed = %v", removed)
+	}
+	out, _ := os.ReadFile(path)
+	if string(out) != "# Providers\nASTERISK_HOST=127.0.0.1\n#GROQ_API_KEY=\n" {
+		t.Errorf(".env = %q", out)
+	}
+	if got := ParseDotEnv(out); got["ASTERISK_HOST"] != "127.0.0.1" || len(got) != 1 {
+		t.Errorf("ParseDotEnv = %v", got)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,322 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+)
+
+// document is a store that keeps every key in one secret: an encrypted file,
+// a Vault entry or a cloud secret. A secret that does not exist yet holds no
+// keys.
+type document interface {
+	load() (map[string]string, error)
+	save(map[string]string) error
+	label() string
+}
+
+func documentFor(store string) document {
+	cfg := configured()
+	switch store {
+	case StoreFile:
+		return ageFile{path: FilePath()}
+	case StoreSOPS:
+		return sopsFile{path: FilePath()}
+	case StoreVault:
+		return vaultKV{cfg: cfg.Vault}
+	case StoreAWS:
+		return awsSecret{id: cfg.AWS.SecretID, region: cfg.AWS.Region}
+	case StoreGCP:
+		return gcpSecret{name: cfg.GCP.Secret, project: cfg.GCP.Project}
+	}
+	return nil
+}
+
+// ageFile is a dotenv file encrypted with age.
+type ageFile struct{ path string }
+
+func (f ageFile) label() string { return "secrets file " + f.path }
+
+func (f ageFile) load() (map[string]string, error) {
+	if _, err := os.Stat(f.path); os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	out, err := run(nil, "age", "--decrypt", "--identity", IdentityPath(), f.path)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", f.path, err)
+	}
+	return ParseDotEnv(out), nil
+}
+
+func (f ageFile) save(values map[string]string) error {
+	recipients, err := ageRecipients()
+	if err != nil {
+		return err
+	}
+	args := []string{"--encrypt", "--armor"}
+	for _, r := range recipients {
+		args = append(args, "--recipient", r)
+	}
+	out, err := run(dotenv(values, "# Provider keys for agent; edit with agent secrets set/delete.\n"), "age", args...)
+	if err != nil {
+		return fmt.Errorf("encrypt the secrets file: %w", err)
+	}
+	return writePrivate(f.path, out)
+}
+
+// ageRecipients are AAVA_AGE_RECIPIENTS (comma or space separated), or the
+// public key of the identity.
+func ageRecipients() ([]string, error) {
+	if v := strings.TrimSpace(os.Getenv("AAVA_AGE_RECIPIENTS")); v != "" {
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' }), nil
+	}
+	out, err := run(nil, "age-keygen", "-y", IdentityPath())
+	if err != nil {
+		return nil, fmt.Errorf("no AAVA_AGE_RECIPIENTS and no public key for %s (create one with age-keygen -o %s): %w", IdentityPath(), IdentityPath(), err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// sopsFile is a dotenv file encrypted with SOPS. SOPS picks the keys (age,
+// PGP, cloud KMS, Vault transit) from .sops.yaml; the file is edited with sops
+// itself, so agent only reads it.
+type sopsFile struct{ path string }
+
+func (f sopsFile) label() string { return "SOPS file " + f.path }
+
+func (f sopsFile) load() (map[string]string, error) {
+	if _, err := os.Stat(f.path); os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	out, err := run(nil, "sops", "--decrypt", "--input-type", "dotenv", "--output-type", "dotenv", f.path)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", f.path, err)
+	}
+	return ParseDotEnv(out), nil
+}
+
+func (f sopsFile) save(map[string]string) error {
+	return fmt.Errorf("%s is edited with sops: sops %s", f.path, f.path)
+}
+
+// vaultKV is a HashiCorp Vault KV version 2 entry. The token comes from
+// VAULT_TOKEN or ~/.vault-token, as for the vault CLI.
+type vaultKV struct{ cfg deployment.SecretsVault }
+
+func (v vaultKV) label() string { return "Vault " + v.cfg.Mount + "/" + strings.Trim(v.cfg.Path, "/") }
+
+// vaultClient is replaced by tests.
+var vaultClient = &http.Client{Timeout: 10 * time.Second}
+
+func (v vaultKV) url() (string, error) {
+	if v.cfg.Address == "" {
+		return "", errors.New("no Vault address: set VAULT_ADDR or secrets.vault.address")
+	}
+	return strings.TrimRight(v.cfg.Address, "/") + "/v1/" + url.PathEscape(v.cfg.Mount) + "/data/" + strings.Trim(v.cfg.Path, "/"), nil
+}
+
+func (v vaultKV) do(method string, body []byte) (*http.Response, error) {
+	u, err := v.url()
+	if err != nil {
+		return nil, err
+	}
+	token := strings.TrimSpace(os.Getenv("VAULT_TOKEN"))
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			raw, _ := os.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(raw))
+		}
+	}
+	if token == "" {
+		return nil, errors.New("no Vault token: set VAULT_TOKEN or run vault login")
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return vaultClient.Do(req)
+}
+
+func (v vaultKV) load() (map[string]string, error) {
+	resp, err := v.do(http.MethodGet, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", v.label(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]string{}, nil
+	}
+	if err := vaultError(resp); err != nil {
+		return nil, fmt.Errorf("%s: %w", v.label(), err)
+	}
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s: %w", v.label(), err)
+	}
+	return stringValues(body.Data.Data), nil
+}
+
+func (v vaultKV) save(values map[string]string) error {
+	body, _ := json.Marshal(map[string]any{"data": values})
+	resp, err := v.do(http.MethodPost, body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", v.label(), err)
+	}
+	defer resp.Body.Close()
+	if err := vaultError(resp); err != nil {
+		return fmt.Errorf("%s: %w", v.label(), err)
+	}
+	return nil
+}
+
+func vaultError(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	var body struct {
+		Errors []string `json:"errors"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if json.Unmarshal(raw, &body) == nil && len(body.Errors) > 0 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.Join(body.Errors, "; "))
+	}
+	return errors.New(resp.Status)
+}
+
+// awsSecret is an AWS Secrets Manager secret read and written with the aws
+// CLI, so instance roles, SSO and profiles work as they do for aws itself.
+type awsSecret struct{ id, region string }
+
+func (s awsSecret) label() string { return "AWS secret " + s.id }
+
+func (s awsSecret) args(args ...string) []string {
+	if s.region != "" {
+		args = append(args, "--region", s.region)
+	}
+	return args
+}
+
+func (s awsSecret) load() (map[string]string, error) {
+	out, err := run(nil, "aws", s.args("secretsmanager", "get-secret-value", "--secret-id", s.id, "--query", "SecretString", "--output", "text")...)
+	if err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("%s: %w", s.label(), err)
+	}
+	return jsonValues(s.label(), out)
+}
+
+func (s awsSecret) save(values map[string]string) error {
+	body, _ := json.Marshal(values)
+	// The secret string is passed as a file so it stays out of the process list.
+	if _, err := run(body, "aws", s.args("secretsmanager", "put-secret-value", "--secret-id", s.id, "--secret-string", "file:///dev/stdin")...); err != nil {
+		if strings.Contains(err.Error(), "ResourceNotFoundException") {
+			return fmt.Errorf("%s does not exist; create it with: aws secretsmanager create-secret --name %s", s.label(), s.id)
+		}
+		return fmt.Errorf("%s: %w", s.label(), err)
+	}
+	return nil
+}
+
+// gcpSecret is a Google Secret Manager secret read and written with gcloud.
+type gcpSecret struct{ name, project string }
+
+func (s gcpSecret) label() string { return "GCP secret " + s.name }
+
+func (s gcpSecret) args(args ...string) []string {
+	if s.project != "" {
+		args = append(args, "--project", s.project)
+	}
+	return args
+}
+
+func (s gcpSecret) load() (map[string]string, error) {
+	out, err := run(nil, "gcloud", s.args("secrets", "versions", "access", "latest", "--secret", s.name)...)
+	if err != nil {
+		if strings.Contains(err.Error(), "NOT_FOUND") {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("%s: %w", s.label(), err)
+	}
+	return jsonValues(s.label(), out)
+}
+
+func (s gcpSecret) save(values map[string]string) error {
+	body, _ := json.Marshal(values)
+	if _, err := run(body, "gcloud", s.args("secrets", "versions", "add", s.name, "--data-file", "-")...); err != nil {
+		if strings.Contains(err.Error(), "NOT_FOUND") {
+			return fmt.Errorf("%s does not exist; create it with: gcloud secrets create %s --replication-policy automatic", s.label(), s.name)
+		}
+		return fmt.Errorf("%s: %w", s.label(), err)
+	}
+	return nil
+}
+
+// jsonValues decodes a cloud secret, a JSON object of keys.
+func jsonValues(label string, raw []byte) (map[string]string, error) {
+	var m map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(raw), &m); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON object of keys: %w", label, err)
+	}
+	return stringValues(m), nil
+}
+
+func stringValues(m map[string]any) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
+
+// dotenv formats values as sorted NAME=value lines after header.
+func dotenv(values map[string]string, header string) []byte {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	b.WriteString(header)
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%s\n", name, values[name])
+	}
+	return b.Bytes()
+}
+
+// writePrivate replaces path with data readable only by its owner.
+func writePrivate(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
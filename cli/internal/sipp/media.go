@@ -0,0 +1,207 @@
+package sipp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// SampleRate is the G.711 sample rate media is generated at.
+const SampleRate = 8000
+
+// frameSamples is one 20ms RTP packet.
+const frameSamples = SampleRate / 50
+
+// ReadWAV returns the samples of an 8 kHz mono 16-bit PCM WAV file.
+func ReadWAV(r io.Reader) ([]int16, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+	wantFormat := fmt.Errorf("WAV must be 8 kHz mono 16-bit PCM (convert with: sox in.wav -r 8000 -c 1 -b 16 out.wav)")
+	haveFormat := false
+	for off := 12; off+8 <= len(data); {
+		id := string(data[off : off+4])
+		size := int(binary.LittleEndian.Uint32(data[off+4 : off+8]))
+		body := data[off+8:]
+		if size > len(body) {
+			size = len(body)
+		}
+		body = body[:size]
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, wantFormat
+			}
+			format := binary.LittleEndian.Uint16(body[0:2])
+			channels := binary.LittleEndian.Uint16(body[2:4])
+			rate := binary.LittleEndian.Uint32(body[4:8])
+			bits := binary.LittleEndian.Uint16(body[14:16])
+			if format != 1 || channels != 1 || rate != SampleRate || bits != 16 {
+				return nil, wantFormat
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return nil, wantFormat
+			}
+			samples := make([]int16, len(body)/2)
+			for i := range samples {
+				samples[i] = int16(binary.LittleEndian.Uint16(body[2*i:]))
+			}
+			return samples, nil
+		}
+		off += 8 + size + size%2
+	}
+	return nil, errors.New("WAV file has no data chunk")
+}
+
+// SyntheticSpeech returns d of voiced bursts: a 130 Hz harmonic tone in
+// syllable-length pulses, loud enough for the engine's VAD to treat as a
+// caller speaking.
+func SyntheticSpeech(d time.Duration) []int16 {
+	n := int(d.Seconds() * SampleRate)
+	out := make([]int16, n)
+	const (
+		f0    = 130.0
+		burst = 0.25 // seconds of sound
+		gap   = 0.12 // seconds of silence after it
+	)
+	for i := range out {
+		t := float64(i) / SampleRate
+		pos := math.Mod(t, burst+gap)
+		if pos >= burst {
+			continue
+		}
+		env := math.Sin(math.Pi * pos / burst)
+		v := 0.0
+		for h := 1; h <= 12; h++ {
+			v += math.Sin(2*math.Pi*f0*float64(h)*t) / float64(h)
+		}
+		out[i] = int16(6000 * env * v / 2)
+	}
+	return out
+}
+
+// WritePCAP writes samples as a pcap of 20ms RTP packets in codec c, for
+// SIPp's play_pcap_audio. SIPp rewrites the addresses and ports when it
+// replays them.
+func WritePCAP(w io.Writer, samples []int16, c Codec) error {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	// Global header: microsecond timestamps, Ethernet link type.
+	for _, v := range []any{uint32(0xa1b2c3d4), uint16(2), uint16(4), int32(0), uint32(0), uint32(65535), uint32(1)} {
+		binary.Write(&buf, le, v)
+	}
+
+	const payloadLen = frameSamples
+	const ipLen = 20 + 8 + 12 + payloadLen
+	start := time.Unix(1700000000, 0)
+	for seq := 0; seq*frameSamples < len(samples); seq++ {
+		frame := samples[seq*frameSamples:]
+		at := start.Add(time.Duration(seq) * 20 * time.Millisecond)
+		binary.Write(&buf, le, uint32(at.Unix()))
+		binary.Write(&buf, le, uint32(at.Nanosecond()/1000))
+		binary.Write(&buf, le, uint32(14+ipLen))
+		binary.Write(&buf, le, uint32(14+ipLen))
+
+		// Ethernet
+		buf.Write([]byte{0x02, 0, 0, 0, 0, 0x02, 0x02, 0, 0, 0, 0, 0x01, 0x08, 0x00})
+		// IPv4, 10.0.0.1 -> 10.0.0.2, UDP
+		ip := []byte{0x45, 0, byte(ipLen >> 8), byte(ipLen), byte(seq >> 8), byte(seq), 0, 0, 64, 17, 0, 0, 10, 0, 0, 1, 10, 0, 0, 2}
+		sum := ipChecksum(ip)
+		ip[10], ip[11] = byte(sum>>8), byte(sum)
+		buf.Write(ip)
+		// UDP 6000 -> 6000, no checksum
+		udpLen := ipLen - 20
+		buf.Write([]byte{0x17, 0x70, 0x17, 0x70, byte(udpLen >> 8), byte(udpLen), 0, 0})
+		// RTP, marker on the first packet
+		pt := byte(c.PayloadType)
+		if seq == 0 {
+			pt |= 0x80
+		}
+		rtp := make([]byte, 12)
+		rtp[0], rtp[1] = 0x80, pt
+		binary.BigEndian.PutUint16(rtp[2:], uint16(seq))
+		binary.BigEndian.PutUint32(rtp[4:], uint32(seq*frameSamples))
+		binary.BigEndian.PutUint32(rtp[8:], 0x41415641) // "AAVA"
+		buf.Write(rtp)
+		for i := 0; i < payloadLen; i++ {
+			var s int16
+			if i < len(frame) {
+				s = frame[i]
+			}
+			buf.WriteByte(encode(c, s))
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// DurationMS is how long samples play.
+func DurationMS(samples []int16) int {
+	return len(samples) * 1000 / SampleRate
+}
+
+func ipChecksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(header); i += 2 {
+		sum += uint32(header[i])<<8 | uint32(header[i+1])
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+func encode(c Codec, s int16) byte {
+	if c.Name == "alaw" {
+		return linearToALaw(s)
+	}
+	return linearToULaw(s)
+}
+
+// linearToULaw is the G.711 mu-law encoder.
+func linearToULaw(s int16) byte {
+	const bias, clip = 0x84, 32635
+	v, sign := int(s), 0
+	if v < 0 {
+		v, sign = -v, 0x80
+	}
+	if v > clip {
+		v = clip
+	}
+	v += bias
+	exponent := 7
+	for mask := 0x4000; v&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (v >> (exponent + 3)) & 0x0f
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+// linearToALaw is the G.711 A-law encoder.
+func linearToALaw(s int16) byte {
+	v, sign := int(s), 0x80
+	if v < 0 {
+		v, sign = -v-1, 0
+	}
+	var out int
+	if v < 256 {
+		out = v >> 4
+	} else {
+		exponent := 7
+		for mask := 0x4000; v&mask == 0; mask >>= 1 {
+			exponent--
+		}
+		out = exponent<<4 | (v>>(exponent+3))&0x0f
+	}
+	return byte((out | sign) ^ 0x55)
+}
@@ -0,0 +1,164 @@
+// Package sipp generates SIPp scenarios, RTP media and the matching pjsip
+// endpoint for load testing the agent with an external SIPp rig.
+package sipp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCallerNumber is the caller ID SIPp calls present, so agent loadtest
+// score can tell them from real calls. 555-0100 is reserved for fiction.
+const DefaultCallerNumber = "5550100"
+
+// EndpointName is the pjsip endpoint SIPp calls arrive on.
+const EndpointName = "aava-sipp"
+
+// Codec is a G.711 codec SIPp can replay from a pcap.
+type Codec struct {
+	Name        string // Asterisk name: ulaw or alaw
+	PayloadType int
+	RTPName     string // SDP rtpmap name
+}
+
+// CodecFor returns the codec for an Asterisk or SDP codec name.
+func CodecFor(name string) (Codec, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "ulaw", "pcmu", "g711u", "mulaw":
+		return Codec{Name: "ulaw", PayloadType: 0, RTPName: "PCMU"}, true
+	case "alaw", "pcma", "g711a":
+		return Codec{Name: "alaw", PayloadType: 8, RTPName: "PCMA"}, true
+	}
+	return Codec{}, false
+}
+
+// ScenarioOptions describe one generated UAC scenario.
+type ScenarioOptions struct {
+	CallerNumber string
+	Codec        Codec
+	PCAP         string // media file, relative to where sipp runs
+	AudioMS      int    // length of the media
+	HoldMS       int    // time on the line after the media
+}
+
+// Scenario returns a UAC scenario: INVITE the [service] extension, wait a
+// second after the answer, replay the caller media, stay on the line, then
+// hang up. Call it with -s <extension>.
+func Scenario(o ScenarioOptions) string {
+	caller := o.CallerNumber
+	if caller == "" {
+		caller = DefaultCallerNumber
+	}
+	from := fmt.Sprintf(`"AAVA loadtest" <sip:%s@[local_ip]:[local_port]>;tag=[pid]SIPpTag00[call_number]`, caller)
+	to := "<sip:[service]@[remote_ip]:[remote_port]>"
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="ISO-8859-1" ?>
+<!DOCTYPE scenario SYSTEM "sipp.dtd">
+
+<!-- Generated by agent loadtest export-sipp. -->
+<scenario name="AAVA agent load test">
+  <send retrans="500">
+    <![CDATA[
+
+      INVITE sip:[service]@[remote_ip]:[remote_port] SIP/2.0
+      Via: SIP/2.0/[transport] [local_ip]:[local_port];branch=[branch]
+`)
+	fmt.Fprintf(&sb, "      From: %s\n      To: %s\n", from, to)
+	fmt.Fprintf(&sb, `      Call-ID: [call_id]
+      CSeq: 1 INVITE
+      Contact: sip:%s@[local_ip]:[local_port]
+      Max-Forwards: 70
+      Subject: AAVA load test
+      Content-Type: application/sdp
+      Content-Length: [len]
+
+      v=0
+      o=aava 53655765 2353687637 IN IP[local_ip_type] [local_ip]
+      s=-
+      c=IN IP[media_ip_type] [media_ip]
+      t=0 0
+      m=audio [media_port] RTP/AVP %d 101
+      a=rtpmap:%d %s/8000
+      a=rtpmap:101 telephone-event/8000
+      a=fmtp:101 0-16
+      a=ptime:20
+      a=sendrecv
+
+    ]]>
+  </send>
+
+  <recv response="100" optional="true"/>
+  <recv response="180" optional="true"/>
+  <recv response="183" optional="true"/>
+  <recv response="200" rtd="true"/>
+
+  <send>
+    <![CDATA[
+
+      ACK sip:[service]@[remote_ip]:[remote_port] SIP/2.0
+      Via: SIP/2.0/[transport] [local_ip]:[local_port];branch=[branch]
+`, caller, o.Codec.PayloadType, o.Codec.PayloadType, o.Codec.RTPName)
+	fmt.Fprintf(&sb, "      From: %s\n      To: %s[peer_tag_param]\n", from, to)
+	fmt.Fprintf(&sb, `      Call-ID: [call_id]
+      CSeq: 1 ACK
+      Contact: sip:%s@[local_ip]:[local_port]
+      Max-Forwards: 70
+      Content-Length: 0
+
+    ]]>
+  </send>
+
+  <pause milliseconds="1000"/>
+  <nop>
+    <action>
+      <exec play_pcap_audio="%s"/>
+    </action>
+  </nop>
+  <pause milliseconds="%d"/>
+
+  <send retrans="500">
+    <![CDATA[
+
+      BYE sip:[service]@[remote_ip]:[remote_port] SIP/2.0
+      Via: SIP/2.0/[transport] [local_ip]:[local_port];branch=[branch]
+`, caller, o.PCAP, o.AudioMS+o.HoldMS)
+	fmt.Fprintf(&sb, "      From: %s\n      To: %s[peer_tag_param]\n", from, to)
+	fmt.Fprintf(&sb, `      Call-ID: [call_id]
+      CSeq: 2 BYE
+      Contact: sip:%s@[local_ip]:[local_port]
+      Max-Forwards: 70
+      Content-Length: 0
+
+    ]]>
+  </send>
+
+  <recv response="200" crlf="true"/>
+
+  <ResponseTimeRepartition value="10, 20, 30, 40, 50, 100, 150, 200"/>
+  <CallLengthRepartition value="10, 50, 100, 500, 1000, 5000, 10000"/>
+</scenario>
+`, caller)
+	return sb.String()
+}
+
+// EndpointOptions describe the pjsip endpoint SIPp calls arrive on.
+type EndpointOptions struct {
+	Context  string // dialplan context that enters the agent's Stasis app
+	Codec    Codec
+	DTMFMode string
+	Match    string // address of the SIPp host
+}
+
+// Endpoint returns pjsip.conf sections that accept calls from the SIPp host
+// into the agent's context, with the trunk's codec and DTMF mode.
+func Endpoint(o EndpointOptions) string {
+	dtmf := o.DTMFMode
+	if dtmf == "" {
+		dtmf = "rfc4733"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "; AI Voice Agent - SIPp load test caller (agent loadtest export-sipp)\n")
+	fmt.Fprintf(&sb, "[%s]\ntype = endpoint\ncontext = %s\ndisallow = all\nallow = %s\ndtmf_mode = %s\ndirect_media = no\nrtp_symmetric = yes\n\n", EndpointName, o.Context, o.Codec.Name, dtmf)
+	fmt.Fprintf(&sb, "[%s]\ntype = identify\nendpoint = %s\nmatch = %s\n", EndpointName+"-identify", EndpointName, o.Match)
+	return sb.String()
+}
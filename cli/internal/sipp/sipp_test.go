@@ -0,0 +1,111 @@
+package sipp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScenarioMatchesCodec(t *testing.T) {
+	alaw, _ := CodecFor("PCMA")
+	got := Scenario(ScenarioOptions{Codec: alaw, PCAP: "caller.pcap", AudioMS: 2500, HoldMS: 20000})
+	for _, want := range []string{
+		"m=audio [media_port] RTP/AVP 8 101",
+		"a=rtpmap:8 PCMA/8000",
+		`<exec play_pcap_audio="caller.pcap"/>`,
+		`<pause milliseconds="22500"/>`,
+		"sip:" + DefaultCallerNumber + "@[local_ip]",
+		"CSeq: 2 BYE",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("scenario missing %q", want)
+		}
+	}
+	if _, ok := CodecFor("g729"); ok {
+		t.Fatal("g729 cannot be replayed from a generated pcap")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	ulaw, _ := CodecFor("ulaw")
+	got := Endpoint(EndpointOptions{Context: "from-ai-agent", Codec: ulaw, Match: "10.0.0.5"})
+	for _, want := range []string{"[aava-sipp]\ntype = endpoint\ncontext = from-ai-agent\n", "allow = ulaw\ndtmf_mode = rfc4733\n", "[aava-sipp-identify]\ntype = identify\nendpoint = aava-sipp\nmatch = 10.0.0.5\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("endpoint missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestG711Encoders(t *testing.T) {
+	for _, tc := range []struct {
+		in         int16
+		ulaw, alaw byte
+	}{
+		{0, 0xff, 0xd5},
+		{-32768, 0x00, 0x2a},
+		{32767, 0x80, 0xaa},
+		{1000, 0xce, 0xfa},
+	} {
+		if got := linearToULaw(tc.in); got != tc.ulaw {
+			t.Errorf("ulaw(%d) = %#x, want %#x", tc.in, got, tc.ulaw)
+		}
+		if got := linearToALaw(tc.in); got != tc.alaw {
+			t.Errorf("alaw(%d) = %#x, want %#x", tc.in, got, tc.alaw)
+		}
+	}
+}
+
+func TestWritePCAP(t *testing.T) {
+	ulaw, _ := CodecFor("ulaw")
+	samples := SyntheticSpeech(time.Second)
+	if DurationMS(samples) != 1000 {
+		t.Fatalf("duration = %d", DurationMS(samples))
+	}
+	var buf bytes.Buffer
+	if err := WritePCAP(&buf, samples[:170], ulaw); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// Global header plus two padded 20ms packets.
+	const packet = 16 + 14 + 20 + 8 + 12 + 160
+	if len(data) != 24+2*packet {
+		t.Fatalf("pcap is %d bytes", len(data))
+	}
+	second := data[24+packet:]
+	rtp := second[16+14+20+8:]
+	if rtp[1] != 0 || binary.BigEndian.Uint16(rtp[2:]) != 1 || binary.BigEndian.Uint32(rtp[4:]) != 160 {
+		t.Fatalf("second RTP header = % x", rtp[:12])
+	}
+	if ipChecksum(second[16+14:16+14+20]) != 0 {
+		t.Fatal("bad IPv4 header checksum")
+	}
+	if rtp[12+20] != 0xff {
+		t.Fatalf("padding is %#x, want mu-law silence", rtp[12+20])
+	}
+}
+
+func TestReadWAV(t *testing.T) {
+	var wav bytes.Buffer
+	le := binary.LittleEndian
+	wav.WriteString("RIFF")
+	binary.Write(&wav, le, uint32(36+4))
+	wav.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(1), uint32(8000), uint32(16000), uint16(2), uint16(16)} {
+		binary.Write(&wav, le, v)
+	}
+	wav.WriteString("data")
+	for _, v := range []any{uint32(4), int16(100), int16(-100)} {
+		binary.Write(&wav, le, v)
+	}
+	samples, err := ReadWAV(bytes.NewReader(wav.Bytes()))
+	if err != nil || len(samples) != 2 || samples[1] != -100 {
+		t.Fatalf("samples = %v, err = %v", samples, err)
+	}
+
+	stereo := bytes.Replace(wav.Bytes(), []byte{1, 0, 1, 0, 0x40, 0x1f}, []byte{1, 0, 2, 0, 0x40, 0x1f}, 1)
+	if _, err := ReadWAV(bytes.NewReader(stereo)); err == nil || !strings.Contains(err.Error(), "mono") {
+		t.Fatalf("stereo err = %v", err)
+	}
+}
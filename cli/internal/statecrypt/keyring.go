@@ -0,0 +1,28 @@
+package statecrypt
+
+import (
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/keyring"
+)
+
+// keyringAccount is the state key's entry in the OS keyring.
+const keyringAccount = "state-key"
+
+// keyringLookup returns the key stored in the OS keyring, or "" when there
+// is none or no keyring to ask. Tests replace it.
+var keyringLookup = func() (string, error) {
+	return keyring.Get(keyringAccount)
+}
+
+// StoreKey saves key in the OS keyring, replacing any key stored before.
+func StoreKey(key string) error {
+	if _, err := ParseKey(key); err != nil {
+		return err
+	}
+	if err := keyring.Set(keyringAccount, key, "AAVA agent state key"); err != nil {
+		return err
+	}
+	keyCache.Lock()
+	keyCache.value, keyCache.done = key, true
+	keyCache.Unlock()
+	return nil
+}
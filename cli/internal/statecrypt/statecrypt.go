@@ -0,0 +1,394 @@
+// Package statecrypt encrypts the agent state the CLI keeps in .agent that can
+// hold caller data: the call index, the quality histories and issue drafts.
+//
+// Encryption is on when a key is available, from AAVA_STATE_KEY or the OS
+// keyring. Sealed data is AES-256-GCM, written as one text line:
+//
+//	aava-sealed:v1:<base64(nonce || ciphertext)>
+//
+// A whole file is one such line; a JSON Lines history seals each line, so it
+// can still be appended to. Reading accepts plain and sealed data alike, so
+// state written before a key was set stays readable.
+package statecrypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	prefix  = "aava-sealed:v1:"
+	keySize = 32
+	// SourceEnv and SourceKeyring say where the key came from.
+	SourceEnv     = "AAVA_STATE_KEY"
+	SourceKeyring = "OS keyring"
+)
+
+// additionalData ties sealed data to its use, so a key shared with another
+// tool cannot be used to pass its ciphertexts off as agent state.
+var additionalData = []byte("aava-agent-state")
+
+var (
+	// ErrNoKey is returned for sealed state when no key is available, and for
+	// writing plain data over it.
+	ErrNoKey = errors.New("agent state is encrypted but no key is available: set AAVA_STATE_KEY or store the key in the OS keyring (agent state keygen --keyring)")
+	// ErrKey is returned when sealed state does not open with the key.
+	ErrKey = errors.New("agent state does not decrypt with this key (wrong key or corrupted data)")
+)
+
+var keyCache struct {
+	sync.Mutex
+	done  bool
+	value string
+}
+
+// Key returns the state key and its source, or nil when encryption is off.
+// AAVA_STATE_KEY wins over the keyring; AAVA_STATE_KEYRING=off skips the
+// keyring. A malformed key is an error rather than no encryption.
+func Key() ([]byte, string, error) {
+	if v := strings.TrimSpace(os.Getenv("AAVA_STATE_KEY")); v != "" {
+		k, err := ParseKey(v)
+		if err != nil {
+			return nil, "", fmt.Errorf("AAVA_STATE_KEY: %w", err)
+		}
+		return k, SourceEnv, nil
+	}
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("AAVA_STATE_KEYRING")), "off") {
+		return nil, "", nil
+	}
+	keyCache.Lock()
+	if !keyCache.done {
+		keyCache.value, _ = keyringLookup()
+		keyCache.done = true
+	}
+	v := keyCache.value
+	keyCache.Unlock()
+	if v == "" {
+		return nil, "", nil
+	}
+	k, err := ParseKey(v)
+	if err != nil {
+		return nil, "", fmt.Errorf("key in the OS keyring: %w", err)
+	}
+	return k, SourceKeyring, nil
+}
+
+// NewKey returns a random key in the form AAVA_STATE_KEY takes.
+func NewKey() (string, error) {
+	k := make([]byte, keySize)
+	if _, err := rand.Read(k); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(k), nil
+}
+
+// ParseKey decodes a base64 key of 32 bytes.
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if k, err := enc.DecodeString(s); err == nil {
+			if len(k) != keySize {
+				return nil, fmt.Errorf("key is %d bytes, want %d (agent state keygen makes one)", len(k), keySize)
+			}
+			return k, nil
+		}
+	}
+	return nil, errors.New("key is not base64 (agent state keygen makes one)")
+}
+
+// IsSealed reports whether data, a file or a line, is sealed.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(prefix))
+}
+
+// Seal encrypts data with key into one line without its newline.
+func Seal(key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ct := aead.Seal(nonce, nonce, data, additionalData)
+	out := make([]byte, len(prefix)+base64.RawStdEncoding.EncodedLen(len(ct)))
+	copy(out, prefix)
+	base64.RawStdEncoding.Encode(out[len(prefix):], ct)
+	return out, nil
+}
+
+// Open decrypts a sealed line with key; plain data is returned as it is.
+func Open(key, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	data = bytes.TrimRight(data, "\r\n")
+	if key == nil {
+		return nil, ErrNoKey
+	}
+	ct, err := base64.RawStdEncoding.DecodeString(string(data[len(prefix):]))
+	if err != nil {
+		return nil, ErrKey
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ct) < aead.NonceSize() {
+		return nil, ErrKey
+	}
+	pt, err := aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrKey
+	}
+	return pt, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ReadFile reads a whole state file, decrypting it when it is sealed.
+func ReadFile(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil || !IsSealed(raw) {
+		return raw, err
+	}
+	key, _, err := Key()
+	if err != nil {
+		return nil, err
+	}
+	pt, err := Open(key, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return pt, nil
+}
+
+// WriteFile replaces a whole state file through a temp file, sealed when a
+// key is available. Without a key it will not replace a sealed file with
+// plain data.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	key, err := writeKey(path)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		if data, err = Seal(key, data); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	}
+	return replace(path, data, perm)
+}
+
+// WriteLines replaces a JSON Lines state file through a temp file, each line
+// sealed when a key is available.
+func WriteLines(path string, lines [][]byte, perm os.FileMode) error {
+	key, err := writeKey(path)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	for _, line := range lines {
+		if key != nil {
+			if line, err = Seal(key, line); err != nil {
+				return err
+			}
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return replace(path, b.Bytes(), perm)
+}
+
+// writeKey is the key to write path with: nil for plain data, which is
+// refused when the file is sealed already.
+func writeKey(path string) ([]byte, error) {
+	key, _, err := Key()
+	if err == nil && key == nil && sealedFile(path) {
+		err = fmt.Errorf("%s: %w", path, ErrNoKey)
+	}
+	return key, err
+}
+
+func replace(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadLine decrypts one line of a JSON Lines state file.
+func ReadLine(line []byte) ([]byte, error) {
+	if !IsSealed(line) {
+		return line, nil
+	}
+	key, _, err := Key()
+	if err != nil {
+		return nil, err
+	}
+	return Open(key, line)
+}
+
+// AppendLine appends line to a JSON Lines state file, sealed when a key is
+// available. Without a key it will not add plain lines to a sealed file.
+func AppendLine(path string, line []byte, perm os.FileMode) error {
+	key, err := writeKey(path)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		if line, err = Seal(key, line); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sealedFile reports whether the file at path starts with sealed data.
+func sealedFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(prefix))
+	n, _ := f.Read(head)
+	return IsSealed(head[:n])
+}
+
+// Status is how much of a state file is sealed.
+type Status struct {
+	Path   string `json:"path"`
+	Sealed int    `json:"sealed"`
+	Plain  int    `json:"plain"`
+}
+
+// Inspect counts the sealed and plain records of a state file: its lines for
+// a .jsonl file, otherwise the file itself.
+func Inspect(path string) (Status, error) {
+	st := Status{Path: path}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	for _, rec := range records(path, raw) {
+		if IsSealed(rec) {
+			st.Sealed++
+		} else {
+			st.Plain++
+		}
+	}
+	return st, nil
+}
+
+// Convert rewrites a state file sealed with key, or plain when key is nil.
+// Sealed records must open with from, the key they were written with. It
+// reports whether anything changed.
+func Convert(path string, from, key []byte) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	var out bytes.Buffer
+	changed := false
+	for _, rec := range records(path, raw) {
+		pt, err := Open(from, rec)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", path, err)
+		}
+		next := pt
+		if key != nil {
+			if next, err = Seal(key, pt); err != nil {
+				return false, err
+			}
+		}
+		if IsSealed(rec) != (key != nil) || (IsSealed(rec) && !bytes.Equal(from, key)) {
+			changed = true
+		}
+		out.Write(next)
+		if key != nil || isLines(path) {
+			out.WriteByte('\n')
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, replace(path, out.Bytes(), info.Mode().Perm())
+}
+
+// Decrypt returns a state file's contents with every sealed record opened
+// with key.
+func Decrypt(path string, key []byte) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	for _, rec := range records(path, raw) {
+		pt, err := Open(key, rec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		out.Write(pt)
+		if isLines(path) {
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// records splits a state file into what is sealed separately.
+func records(path string, raw []byte) [][]byte {
+	if !isLines(path) {
+		if len(raw) == 0 {
+			return nil
+		}
+		if IsSealed(raw) {
+			raw = bytes.TrimRight(raw, "\r\n")
+		}
+		return [][]byte{raw}
+	}
+	var out [][]byte
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) > 0 {
+			out = append(out, append([]byte{}, sc.Bytes()...))
+		}
+	}
+	return out
+}
+
+func isLines(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".jsonl")
+}
@@ -0,0 +1,153 @@
+package statecrypt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setKey(t *testing.T) []byte {
+	t.Helper()
+	k, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AAVA_STATE_KEY", k)
+	t.Setenv("AAVA_STATE_KEYRING", "off")
+	key, source, err := Key()
+	if err != nil || source != SourceEnv {
+		t.Fatalf("Key() = %v, %v", source, err)
+	}
+	return key
+}
+
+func noKey(t *testing.T) {
+	t.Helper()
+	t.Setenv("AAVA_STATE_KEY", "")
+	t.Setenv("AAVA_STATE_KEYRING", "off")
+}
+
+func TestSealOpen(t *testing.T) {
+	key := setKey(t)
+	sealed, err := Seal(key, []byte(`{"caller_number":"+4930123456"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || strings.Contains(string(sealed), "4930123456") || strings.ContainsAny(string(sealed), "\n") {
+		t.Fatalf("sealed = %s", sealed)
+	}
+	pt, err := Open(key, append(sealed, '\n'))
+	if err != nil || string(pt) != `{"caller_number":"+4930123456"}` {
+		t.Fatalf("Open = %s, %v", pt, err)
+	}
+	other, _ := NewKey()
+	otherKey, _ := ParseKey(other)
+	if _, err := Open(otherKey, sealed); !errors.Is(err, ErrKey) {
+		t.Errorf("wrong key: %v", err)
+	}
+	if _, err := Open(nil, sealed); !errors.Is(err, ErrNoKey) {
+		t.Errorf("no key: %v", err)
+	}
+	if pt, err := Open(nil, []byte("plain\n")); err != nil || string(pt) != "plain\n" {
+		t.Errorf("plain = %q, %v", pt, err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	for _, bad := range []string{"hunter2", "c2hvcnQ=", "not base64!"} {
+		if _, err := ParseKey(bad); err == nil {
+			t.Errorf("ParseKey(%q) accepted", bad)
+		}
+	}
+	t.Setenv("AAVA_STATE_KEY", "c2hvcnQ=")
+	if _, _, err := Key(); err == nil || !strings.Contains(err.Error(), "AAVA_STATE_KEY") {
+		t.Errorf("malformed AAVA_STATE_KEY: %v", err)
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	k, _ := NewKey()
+	saved := keyringLookup
+	keyringLookup = func() (string, error) { return k, nil }
+	keyCache.done = false
+	t.Cleanup(func() { keyringLookup, keyCache.done, keyCache.value = saved, false, "" })
+	t.Setenv("AAVA_STATE_KEY", "")
+	t.Setenv("AAVA_STATE_KEYRING", "")
+	if key, source, err := Key(); key == nil || source != SourceKeyring || err != nil {
+		t.Errorf("Key() = %v, %q, %v", key, source, err)
+	}
+	t.Setenv("AAVA_STATE_KEYRING", "off")
+	if key, _, _ := Key(); key != nil {
+		t.Error("keyring used with AAVA_STATE_KEYRING=off")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	index := filepath.Join(dir, "calls.json")
+	history := filepath.Join(dir, "samples.jsonl")
+
+	// Written in plain text before a key was set.
+	noKey(t)
+	if err := WriteFile(index, []byte(`{"calls":{}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendLine(history, []byte(`{"call_id":"1.1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	key := setKey(t)
+	if err := AppendLine(history, []byte(`{"call_id":"1.2"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := Inspect(history); st.Sealed != 1 || st.Plain != 1 {
+		t.Errorf("mixed history = %+v", st)
+	}
+	if got, err := Decrypt(history, key); err != nil || string(got) != "{\"call_id\":\"1.1\"}\n{\"call_id\":\"1.2\"}\n" {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+
+	for _, path := range []string{index, history} {
+		if changed, err := Convert(path, key, key); err != nil || !changed {
+			t.Fatalf("Convert(%s) = %v, %v", path, changed, err)
+		}
+		if changed, _ := Convert(path, key, key); changed {
+			t.Errorf("%s converted twice", path)
+		}
+	}
+	raw, _ := os.ReadFile(index)
+	if !IsSealed(raw) {
+		t.Fatalf("index not sealed: %s", raw)
+	}
+	if got, err := ReadFile(index); err != nil || string(got) != `{"calls":{}}` {
+		t.Errorf("ReadFile = %s, %v", got, err)
+	}
+	if err := WriteLines(history, [][]byte{[]byte(`{"call_id":"1.3"}`)}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := Inspect(history); st.Sealed != 1 || st.Plain != 0 {
+		t.Errorf("rewritten history = %+v", st)
+	}
+
+	// Without the key, sealed state is neither read nor overwritten.
+	noKey(t)
+	if _, err := ReadFile(index); !errors.Is(err, ErrNoKey) {
+		t.Errorf("ReadFile without key: %v", err)
+	}
+	if err := WriteFile(index, []byte(`{}`), 0o644); !errors.Is(err, ErrNoKey) {
+		t.Errorf("WriteFile without key: %v", err)
+	}
+	if err := AppendLine(history, []byte(`{}`), 0o644); !errors.Is(err, ErrNoKey) {
+		t.Errorf("AppendLine without key: %v", err)
+	}
+
+	// Decrypting restores the original bytes.
+	if changed, err := Convert(index, key, nil); err != nil || !changed {
+		t.Fatal(changed, err)
+	}
+	if raw, _ := os.ReadFile(index); string(raw) != `{"calls":{}}` {
+		t.Errorf("decrypted index = %q", raw)
+	}
+}
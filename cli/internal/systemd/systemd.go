@@ -0,0 +1,235 @@
+// Package systemd renders and installs the systemd units that run agent's
+// long-running modes as services: agent serve (the HTTP API) and agent watch
+// (the AMI call monitor).
+//
+// Units run as a dynamic user by default, with no capabilities beyond what the
+// mode needs, a read-only view of the system and a restart policy. The project
+// checkout is the working directory because agent finds .env, config/ and
+// .agent relative to it.
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// UnitDir is where installed units go.
+const UnitDir = "/etc/systemd/system"
+
+// Mode is a long-running agent command that can run as a service.
+type Mode struct {
+	Name        string
+	Description string
+	Args        []string // agent arguments
+}
+
+// Modes are the modes install-service accepts.
+var Modes = []Mode{
+	{Name: "monitor", Description: "Asterisk AI Voice Agent call monitor (agent watch)", Args: []string{"watch", "--json"}},
+	{Name: "serve", Description: "Asterisk AI Voice Agent diagnostics API (agent serve)", Args: []string{"serve"}},
+}
+
+// FindMode returns the mode called name.
+func FindMode(name string) (Mode, error) {
+	var names []string
+	for _, m := range Modes {
+		if m.Name == name {
+			return m, nil
+		}
+		names = append(names, m.Name)
+	}
+	return Mode{}, fmt.Errorf("unknown service %q (choose %s)", name, strings.Join(names, " or "))
+}
+
+// UnitName is the unit file name of mode.
+func UnitName(mode string) string { return "aava-" + mode + ".service" }
+
+// UnitPath is where the unit of mode is installed.
+func UnitPath(mode string) string { return filepath.Join(UnitDir, UnitName(mode)) }
+
+// Spec describes one unit.
+type Spec struct {
+	Mode   Mode
+	Binary string   // absolute path of the agent binary
+	Dir    string   // project checkout; the working directory
+	User   string   // existing account to run as; empty runs as a dynamic user
+	Groups []string // supplementary groups (docker, for the socket)
+	Args   []string // arguments after the mode's own
+	// TokenFile is handed to agent serve as a systemd credential, so the
+	// service reads the API token without access to the file itself.
+	TokenFile string
+	// BindLowPort grants CAP_NET_BIND_SERVICE, for agent serve on a port below 1024.
+	BindLowPort bool
+}
+
+// Render returns the unit file for s.
+func Render(s Spec) string {
+	var words []string
+	for _, a := range append([]string{s.Binary}, s.Mode.Args...) {
+		words = append(words, quote(a))
+	}
+	if s.TokenFile != "" {
+		words = append(words, "--token-file", "${CREDENTIALS_DIRECTORY}/api-token") // expanded by systemd
+	}
+	for _, a := range s.Args {
+		words = append(words, quote(a))
+	}
+	caps := ""
+	if s.BindLowPort {
+		caps = "CAP_NET_BIND_SERVICE"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: generated by agent install-service %s; remove with agent uninstall-service %s.\n", UnitName(s.Mode.Name), s.Mode.Name, s.Mode.Name)
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", s.Mode.Description)
+	b.WriteString("Documentation=https://github.com/hkjarral/AVA-AI-Voice-Agent-for-Asterisk/blob/main/docs/CLI_TOOLS_GUIDE.md\n")
+	b.WriteString("After=network-online.target docker.service\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("StartLimitIntervalSec=300\n")
+	b.WriteString("StartLimitBurst=5\n")
+
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(words, " "))
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", quote(s.Dir))
+	b.WriteString("Restart=always\n")
+	b.WriteString("RestartSec=5s\n")
+	if s.User == "" {
+		b.WriteString("DynamicUser=yes\n")
+	} else {
+		fmt.Fprintf(&b, "User=%s\n", s.User)
+	}
+	if len(s.Groups) > 0 {
+		fmt.Fprintf(&b, "SupplementaryGroups=%s\n", strings.Join(s.Groups, " "))
+	}
+	// HOME for the docker CLI's config; the checkout stays read-only except .agent.
+	fmt.Fprintf(&b, "StateDirectory=aava-%s\n", s.Mode.Name)
+	fmt.Fprintf(&b, "Environment=HOME=%%S/aava-%s\n", s.Mode.Name)
+	fmt.Fprintf(&b, "ReadWritePaths=%s\n", quote(filepath.Join(s.Dir, ".agent")))
+	if s.TokenFile != "" {
+		fmt.Fprintf(&b, "LoadCredential=api-token:%s\n", escape(s.TokenFile))
+	}
+
+	b.WriteString("\n# Hardening\n")
+	fmt.Fprintf(&b, "CapabilityBoundingSet=%s\n", caps)
+	fmt.Fprintf(&b, "AmbientCapabilities=%s\n", caps)
+	for _, line := range []string{
+		"NoNewPrivileges=yes",
+		"ProtectSystem=strict",
+		"ProtectHome=read-only",
+		"PrivateTmp=yes",
+		"PrivateDevices=yes",
+		"ProtectKernelTunables=yes",
+		"ProtectKernelModules=yes",
+		"ProtectKernelLogs=yes",
+		"ProtectControlGroups=yes",
+		"ProtectClock=yes",
+		"ProtectHostname=yes",
+		"RestrictNamespaces=yes",
+		"RestrictRealtime=yes",
+		"RestrictSUIDSGID=yes",
+		"LockPersonality=yes",
+		"RemoveIPC=yes",
+		"RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6",
+		"SystemCallArchitectures=native",
+		"SystemCallFilter=@system-service",
+		"SystemCallErrorNumber=EPERM",
+		"UMask=0027",
+	} {
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// quote returns a as one word of a unit setting: specifiers and variables
+// escaped, and double-quoted when it holds spaces or quotes.
+func quote(a string) string {
+	a = escape(a)
+	if a != "" && !strings.ContainsAny(a, " \t\"'\\") {
+		return a
+	}
+	a = strings.ReplaceAll(a, `\`, `\\`)
+	a = strings.ReplaceAll(a, `"`, `\"`)
+	return `"` + a + `"`
+}
+
+// escape keeps systemd from expanding % specifiers and $ variables in a.
+func escape(a string) string {
+	a = strings.ReplaceAll(a, "%", "%%")
+	return strings.ReplaceAll(a, "$", "$$")
+}
+
+// Install writes s's unit, reloads systemd and enables it, starting it too
+// when start is set. It returns the unit's path.
+func Install(s Spec, start bool) (string, error) {
+	path := UnitPath(s.Mode.Name)
+	if err := os.WriteFile(path, []byte(Render(s)), 0o644); err != nil {
+		return "", err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return path, err
+	}
+	if err := systemctl("enable", UnitName(s.Mode.Name)); err != nil {
+		return path, err
+	}
+	if start {
+		// restart rather than start, to pick up a changed unit that was already running.
+		return path, systemctl("restart", UnitName(s.Mode.Name))
+	}
+	return path, nil
+}
+
+// Uninstall stops and disables mode's unit and removes it. It returns
+// fs.ErrNotExist when the unit is not installed.
+func Uninstall(mode string) error {
+	path := UnitPath(mode)
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	// A unit that failed or never started cannot be stopped; removing it is what counts.
+	_ = systemctl("disable", "--now", UnitName(mode))
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	_ = systemctl("reset-failed", UnitName(mode))
+	return nil
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Unreachable returns the outermost directory on the way to dir that a
+// dynamic user cannot enter (no execute permission for others), or "" when it
+// can reach dir. A checkout under /root is the usual case.
+func Unreachable(dir string) string {
+	var chain []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		chain = append([]string{d}, chain...)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	for _, d := range chain {
+		if st, err := os.Stat(d); err == nil && st.Mode().Perm()&0o001 == 0 {
+			return d
+		}
+	}
+	return ""
+}
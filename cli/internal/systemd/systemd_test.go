@@ -0,0 +1,78 @@
+package systemd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderServe(t *testing.T) {
+	serve, err := FindMode("serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unit := Render(Spec{
+		Mode:        serve,
+		Binary:      "/usr/local/bin/agent",
+		Dir:         "/opt/AAVA 100%",
+		Groups:      []string{"docker"},
+		Args:        []string{"--listen", ":443", "--tls-key", `/etc/aava/$KEY`},
+		TokenFile:   "/opt/AAVA 100%/.agent/api-token",
+		BindLowPort: true,
+	})
+	for _, want := range []string{
+		`ExecStart=/usr/local/bin/agent serve --token-file ${CREDENTIALS_DIRECTORY}/api-token --listen :443 --tls-key /etc/aava/$$KEY` + "\n",
+		`WorkingDirectory="/opt/AAVA 100%%"` + "\n",
+		`ReadWritePaths="/opt/AAVA 100%%/.agent"` + "\n",
+		"LoadCredential=api-token:/opt/AAVA 100%%/.agent/api-token\n",
+		"DynamicUser=yes\nSupplementaryGroups=docker\n",
+		"CapabilityBoundingSet=CAP_NET_BIND_SERVICE\nAmbientCapabilities=CAP_NET_BIND_SERVICE\n",
+		"Restart=always\n",
+		"WantedBy=multi-user.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit lacks %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestRenderMonitorAsUser(t *testing.T) {
+	monitor, _ := FindMode("monitor")
+	unit := Render(Spec{Mode: monitor, Binary: "/usr/local/bin/agent", Dir: "/opt/aava", User: "aava"})
+	if !strings.Contains(unit, "ExecStart=/usr/local/bin/agent watch --json\n") || !strings.Contains(unit, "User=aava\n") {
+		t.Fatalf("unit:\n%s", unit)
+	}
+	for _, unwanted := range []string{"DynamicUser", "LoadCredential", "SupplementaryGroups", "CAP_"} {
+		if strings.Contains(unit, unwanted) {
+			t.Errorf("unit has %s:\n%s", unwanted, unit)
+		}
+	}
+	if _, err := FindMode("tui"); err == nil || !strings.Contains(err.Error(), "monitor or serve") {
+		t.Fatalf("FindMode(tui) = %v", err)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "home", "ops", "aava")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// t.TempDir's parent is private to the test; open it like /opt would be.
+	for _, d := range []string{filepath.Dir(base), base, filepath.Join(base, "home"), filepath.Join(base, "home", "ops")} {
+		if err := os.Chmod(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := Unreachable(dir); got != "" {
+		t.Fatalf("Unreachable = %q for an open path", got)
+	}
+	home := filepath.Join(base, "home", "ops")
+	if err := os.Chmod(home, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if got := Unreachable(dir); got != home {
+		t.Fatalf("Unreachable = %q, want %s", got, home)
+	}
+}
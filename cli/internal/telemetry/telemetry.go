@@ -0,0 +1,284 @@
+// Package telemetry keeps the anonymous usage statistics a deployment can opt
+// in to sending: the CLI version, agent check pass/warn/fail counts and which
+// built-in known issues agent rca matched. Logs, transcripts, call IDs,
+// numbers, names and addresses are never recorded.
+//
+// The statistics are aggregated in .agent/telemetry.json. Nothing leaves the
+// host until agent telemetry on, and agent telemetry off (or DO_NOT_TRACK)
+// stops recording and sending until it is turned on again.
+package telemetry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the Report sent.
+const SchemaVersion = 1
+
+// Interval is how often an opted-in deployment sends its statistics.
+const Interval = 24 * time.Hour
+
+// Setting is the deployment's choice.
+type Setting string
+
+const (
+	Undecided Setting = ""
+	On        Setting = "on"
+	Off       Setting = "off"
+)
+
+// Counts are agent check item results.
+type Counts struct {
+	Pass int `json:"pass"`
+	Warn int `json:"warn"`
+	Fail int `json:"fail"`
+	Skip int `json:"skip"`
+}
+
+// State is the choice and the statistics gathered since the last send.
+type State struct {
+	Setting  Setting `json:"setting,omitempty"`
+	Endpoint string  `json:"endpoint,omitempty"`
+	// InstallID is random, made when telemetry is turned on, so reports of
+	// one deployment can be counted once; it is derived from nothing.
+	InstallID string     `json:"install_id,omitempty"`
+	LastSent  *time.Time `json:"last_sent,omitempty"`
+
+	Since       time.Time      `json:"since"`
+	CheckRuns   int            `json:"check_runs"`
+	Checks      Counts         `json:"checks"`
+	RCARuns     int            `json:"rca_runs"`
+	KnownIssues map[string]int `json:"known_issues,omitempty"`
+}
+
+// Report is exactly what is sent.
+type Report struct {
+	SchemaVersion int            `json:"schema_version"`
+	InstallID     string         `json:"install_id"`
+	CLIVersion    string         `json:"cli_version"`
+	OS            string         `json:"os"`
+	Arch          string         `json:"arch"`
+	PeriodStart   string         `json:"period_start"` // days, not times
+	PeriodEnd     string         `json:"period_end"`
+	CheckRuns     int            `json:"check_runs"`
+	Checks        Counts         `json:"checks"`
+	RCARuns       int            `json:"rca_runs"`
+	KnownIssues   map[string]int `json:"known_issues"`
+}
+
+// Path is the state file.
+func Path() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_TELEMETRY_FILE")); p != "" {
+		return p
+	}
+	return filepath.Join(".agent", "telemetry.json")
+}
+
+// EnvOff names the environment variable that turns telemetry off for this
+// process regardless of the setting, or is empty.
+func EnvOff() string {
+	if v := strings.TrimSpace(os.Getenv("DO_NOT_TRACK")); v != "" && v != "0" {
+		return "DO_NOT_TRACK"
+	}
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("AAVA_TELEMETRY")), "off") {
+		return "AAVA_TELEMETRY"
+	}
+	return ""
+}
+
+// Load reads the state; a missing file is an undecided deployment.
+func Load() (*State, error) {
+	s := &State{}
+	raw, err := os.ReadFile(Path())
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", Path(), err)
+	}
+	return s, nil
+}
+
+// Save writes the state.
+func (s *State) Save() error {
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Enabled reports whether statistics are sent.
+func (s *State) Enabled() bool {
+	return s.Setting == On && EnvOff() == ""
+}
+
+// Recording reports whether statistics are gathered. An undecided deployment
+// gathers them locally, so agent telemetry preview shows real numbers before
+// the choice; they are only sent once turned on.
+func (s *State) Recording() bool {
+	return s.Setting != Off && EnvOff() == ""
+}
+
+// EndpointURL is where reports go: AAVA_TELEMETRY_URL, else the endpoint set
+// with agent telemetry on.
+func (s *State) EndpointURL() string {
+	if u := strings.TrimSpace(os.Getenv("AAVA_TELEMETRY_URL")); u != "" {
+		return u
+	}
+	return s.Endpoint
+}
+
+// TurnOn opts in, keeping the statistics gathered so far.
+func (s *State) TurnOn(endpoint string) error {
+	s.Setting = On
+	if endpoint != "" {
+		s.Endpoint = endpoint
+	}
+	if s.InstallID == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		s.InstallID = hex.EncodeToString(b)
+	}
+	return nil
+}
+
+// TurnOff opts out and drops everything gathered, including the install ID.
+func (s *State) TurnOff() {
+	*s = State{Setting: Off}
+}
+
+// RecordCheck adds an agent check run (best-effort).
+func RecordCheck(c Counts) {
+	update(func(s *State) {
+		s.CheckRuns++
+		s.Checks.Pass += c.Pass
+		s.Checks.Warn += c.Warn
+		s.Checks.Fail += c.Fail
+		s.Checks.Skip += c.Skip
+	})
+}
+
+// RecordRCA adds an analyzed call and the built-in known issues it matched
+// (best-effort). Only built-in IDs may be passed: a deployment's own
+// fingerprints are named by the deployment.
+func RecordRCA(knownIssues []string) {
+	update(func(s *State) {
+		s.RCARuns++
+		for _, id := range knownIssues {
+			if s.KnownIssues == nil {
+				s.KnownIssues = map[string]int{}
+			}
+			s.KnownIssues[id]++
+		}
+	})
+}
+
+func update(f func(*State)) {
+	s, err := Load()
+	if err != nil || !s.Recording() {
+		return
+	}
+	if s.Since.IsZero() {
+		s.Since = time.Now().UTC()
+	}
+	f(s)
+	_ = s.Save()
+}
+
+// Report is what would be sent now.
+func (s *State) Report(version string, now time.Time) Report {
+	start := s.Since
+	if start.IsZero() {
+		start = now
+	}
+	known := map[string]int{}
+	for id, n := range s.KnownIssues {
+		known[id] = n
+	}
+	return Report{
+		SchemaVersion: SchemaVersion,
+		InstallID:     s.InstallID,
+		CLIVersion:    version,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		PeriodStart:   start.UTC().Format("2006-01-02"),
+		PeriodEnd:     now.UTC().Format("2006-01-02"),
+		CheckRuns:     s.CheckRuns,
+		Checks:        s.Checks,
+		RCARuns:       s.RCARuns,
+		KnownIssues:   known,
+	}
+}
+
+// Due reports whether a report should be sent now: telemetry is on, has an
+// endpoint and something to report, and the last report is Interval old.
+func (s *State) Due(now time.Time) bool {
+	if !s.Enabled() || s.EndpointURL() == "" || s.CheckRuns+s.RCARuns == 0 {
+		return false
+	}
+	return s.LastSent == nil || now.Sub(*s.LastSent) >= Interval
+}
+
+// Send posts the report as JSON.
+func Send(client *http.Client, endpoint string, r Report) error {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: %s", endpoint, resp.Status)
+	}
+	return nil
+}
+
+// MarkSent starts a new period after a report was sent.
+func (s *State) MarkSent(now time.Time) {
+	s.LastSent = &now
+	s.Since = now.UTC()
+	s.CheckRuns, s.Checks, s.RCARuns, s.KnownIssues = 0, Counts{}, 0, nil
+}
+
+// SendDue sends the statistics when a report is due (best-effort); a failed
+// send is retried after the next command.
+func SendDue(version string) {
+	s, err := Load()
+	now := time.Now()
+	if err != nil || !s.Due(now) {
+		return
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	if err := Send(client, s.EndpointURL(), s.Report(version, now)); err != nil {
+		return
+	}
+	s.MarkSent(now)
+	_ = s.Save()
+}
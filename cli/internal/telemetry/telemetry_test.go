@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T) {
+	t.Helper()
+	t.Setenv("AAVA_TELEMETRY_FILE", filepath.Join(t.TempDir(), "telemetry.json"))
+	t.Setenv("AAVA_TELEMETRY_URL", "")
+	t.Setenv("AAVA_TELEMETRY", "")
+	t.Setenv("DO_NOT_TRACK", "")
+}
+
+func load(t *testing.T) *State {
+	t.Helper()
+	s, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestRecord(t *testing.T) {
+	setup(t)
+	RecordCheck(Counts{Pass: 10, Warn: 2, Fail: 1})
+	RecordCheck(Counts{Pass: 12, Skip: 1})
+	RecordRCA([]string{"gate-flutter"})
+	RecordRCA(nil)
+
+	s := load(t)
+	if s.Enabled() || s.Due(time.Now()) {
+		t.Fatal("undecided deployment would send")
+	}
+	r := s.Report("7.2.0", time.Now())
+	if r.CheckRuns != 2 || r.Checks != (Counts{Pass: 22, Warn: 2, Fail: 1, Skip: 1}) || r.RCARuns != 2 || r.KnownIssues["gate-flutter"] != 1 {
+		t.Errorf("report = %+v", r)
+	}
+
+	s.TurnOff()
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	RecordCheck(Counts{Pass: 1})
+	if s := load(t); s.Setting != Off || s.CheckRuns != 0 || s.InstallID != "" {
+		t.Errorf("recorded after off: %+v", s)
+	}
+
+	s.TurnOn("")
+	_ = s.Save()
+	t.Setenv("DO_NOT_TRACK", "1")
+	RecordCheck(Counts{Pass: 1})
+	if s := load(t); s.CheckRuns != 0 || s.Enabled() {
+		t.Errorf("recorded with DO_NOT_TRACK: %+v", s)
+	}
+}
+
+func TestSendDue(t *testing.T) {
+	setup(t)
+	var got Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	s := load(t)
+	if err := s.TurnOn(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Save()
+	SendDue("7.2.0")
+	if got.CLIVersion != "" {
+		t.Fatal("sent with nothing to report")
+	}
+
+	RecordRCA([]string{"connection-refused"})
+	SendDue("7.2.0")
+	if got.CLIVersion != "7.2.0" || got.RCARuns != 1 || got.KnownIssues["connection-refused"] != 1 || len(got.InstallID) != 32 {
+		t.Fatalf("sent %+v", got)
+	}
+	s = load(t)
+	if s.LastSent == nil || s.RCARuns != 0 || len(s.KnownIssues) != 0 {
+		t.Errorf("state after send = %+v", s)
+	}
+
+	got = Report{}
+	RecordRCA(nil)
+	SendDue("7.2.0")
+	if got.CLIVersion != "" {
+		t.Error("sent twice within the interval")
+	}
+}
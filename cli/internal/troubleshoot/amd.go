@@ -0,0 +1,353 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+// AMDSTATUS values set by Asterisk's AMD().
+const (
+	amdHuman   = "HUMAN"
+	amdMachine = "MACHINE"
+	amdNotSure = "NOTSURE"
+	amdHangup  = "HANGUP"
+)
+
+const (
+	// amdEarlyHangup is how soon after a HUMAN verdict a silent callee's
+	// hangup points to an answering machine ending its recording.
+	amdEarlyHangup = 15 * time.Second
+	// amdSlowDetection is the dead air after which a person who answered is
+	// likely to hang up before AMD decides.
+	amdSlowDetection = 4 * time.Second
+)
+
+// The outbound AMD dialplan logs the verdict with a NoOp, e.g.
+// pbx.c: Executing [s@aava-outbound-amd:5] NoOp("PJSIP/trunk-00000007", "AMDSTATUS=HUMAN AMDCAUSE=HUMAN-300-1000") in new stack
+var asteriskAMDPattern = regexp.MustCompile(`NoOp\("([^"]+)", "AMDSTATUS=(\w*) AMDCAUSE=([\w-]*)`)
+
+// voicemailPhrases are said by answering machine greetings and rarely by
+// people answering a call.
+var voicemailPhrases = []string{
+	"leave a message", "leave your message", "record your message",
+	"after the tone", "after the beep", "at the tone",
+	"voicemail", "voice mail", "mailbox",
+	"not available to take your call", "can't come to the phone", "cannot come to the phone",
+}
+
+// AMDVerdict is one answering machine detection result for the call.
+type AMDVerdict struct {
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status"`          // HUMAN, MACHINE, NOTSURE or HANGUP
+	Cause   string    `json:"cause,omitempty"` // AMDCAUSE, e.g. INITIALSILENCE-2000-2000
+	Source  string    `json:"source"`          // asterisk or ai_engine
+	Channel string    `json:"channel,omitempty"`
+}
+
+// AMDAnalysis is how answering machine detection judged an outbound call,
+// and whether what followed the verdict looks like the agent talking to
+// voicemail.
+type AMDAnalysis struct {
+	Verdicts []AMDVerdict `json:"verdicts,omitempty"`
+	// Status is the verdict the engine acted on; the engine treats NOTSURE
+	// as MACHINE.
+	Status string `json:"status,omitempty"`
+	Cause  string `json:"cause,omitempty"`
+	// Detected is AMD's own verdict when the dialplan's guardrails routed
+	// the call otherwise, such as INITIALSILENCE sent to the agent as HUMAN.
+	Detected         string  `json:"detected,omitempty"`
+	Consent          string  `json:"consent,omitempty"`           // accepted, denied or timeout
+	DetectionSeconds float64 `json:"detection_seconds,omitempty"` // answer to verdict
+	// AfterVerdictSeconds runs from the verdict to the call's last line.
+	AfterVerdictSeconds float64  `json:"after_verdict_seconds,omitempty"`
+	CalleeSpoke         bool     `json:"callee_spoke"`
+	CalleeHungUp        bool     `json:"callee_hung_up"`
+	VoicemailPhrase     string   `json:"voicemail_phrase,omitempty"`
+	Voicemail           bool     `json:"voicemail"` // the agent likely talked to an answering machine
+	Findings            []string `json:"findings,omitempty"`
+}
+
+// amdVerdict returns the AMD result an Asterisk or engine timeline entry
+// carries.
+func amdVerdict(e TimelineEntry) (AMDVerdict, bool) {
+	switch e.Source {
+	case SourceAsterisk:
+		if m := asteriskAMDPattern.FindStringSubmatch(e.Line); m != nil {
+			return AMDVerdict{Time: e.Time, Status: strings.ToUpper(m[2]), Cause: strings.ToUpper(m[3]), Source: SourceAsterisk, Channel: m[1]}, true
+		}
+	case SourceEngine:
+		if _, event, fields, ok := parseLogLine(e.Line); ok && event == logschema.EventOutboundAMDResult {
+			return AMDVerdict{Time: e.Time, Status: strings.ToUpper(fields["amd_status"]), Cause: strings.ToUpper(fields["amd_cause"]), Source: SourceEngine, Channel: fields["channel_id"]}, true
+		}
+	}
+	return AMDVerdict{}, false
+}
+
+// causeVerdict is the AMDSTATUS that AMD() sets along with an AMDCAUSE.
+func causeVerdict(cause string) string {
+	reason, _, _ := strings.Cut(cause, "-")
+	switch reason {
+	case "HUMAN":
+		return amdHuman
+	case "INITIALSILENCE", "LONGGREETING", "MAXWORDS", "MAXWORDLENGTH":
+		return amdMachine
+	case "TOOLONG":
+		return amdNotSure
+	}
+	return ""
+}
+
+// sameRouting reports whether two verdicts take the same dialplan path; the
+// outbound dialplan sends NOTSURE to the machine path.
+func sameRouting(a, b string) bool {
+	if a == amdNotSure {
+		a = amdMachine
+	}
+	if b == amdNotSure {
+		b = amdMachine
+	}
+	return a == b
+}
+
+// extractAMD collects the AMD verdicts of an outbound call and times them
+// against the answer and the call's end. It returns nil for calls the
+// engine did not originate.
+func extractAMD(timeline []TimelineEntry) *AMDAnalysis {
+	a := &AMDAnalysis{}
+	var answered, decided time.Time
+	outbound := false
+	for _, e := range timeline {
+		if v, ok := amdVerdict(e); ok {
+			a.Verdicts = append(a.Verdicts, v)
+		}
+		if e.Source != SourceEngine {
+			continue
+		}
+		_, event, fields, ok := parseLogLine(e.Line)
+		if !ok {
+			continue
+		}
+		switch event {
+		case logschema.EventOutboundAnswered:
+			outbound = true
+			if answered.IsZero() {
+				answered = e.Time
+			}
+		case logschema.EventOutboundAMDResult:
+			a.Consent = emptyTo(fields["consent_result"], a.Consent)
+		case logschema.EventVADSpeechStarted, logschema.EventProviderSpeechStarted, logschema.EventProviderInterruption,
+			logschema.EventProviderInterruptionNoAudio, logschema.EventBargeInAttempt, logschema.EventTurnLatency:
+			// The AI session starts only after a HUMAN verdict.
+			a.CalleeSpoke = true
+		}
+	}
+	if !outbound && len(a.Verdicts) == 0 {
+		return nil
+	}
+	if len(a.Verdicts) == 0 {
+		a.Findings = append(a.Findings, "The engine answered the outbound call but no AMD verdict was logged")
+		return a
+	}
+
+	// The engine's verdict is the one acted on; Asterisk's NoOp keeps what AMD
+	// itself said, before the engine folds NOTSURE into MACHINE.
+	acted := a.Verdicts[len(a.Verdicts)-1]
+	for _, v := range a.Verdicts {
+		if v.Source == SourceEngine {
+			acted = v
+		}
+	}
+	a.Status, a.Cause, decided = acted.Status, acted.Cause, acted.Time
+	detected := causeVerdict(a.Cause)
+	for _, v := range a.Verdicts {
+		if v.Source == SourceAsterisk && detected == "" {
+			detected = v.Status
+		}
+	}
+	if detected != "" && !sameRouting(detected, a.Status) {
+		a.Detected = detected
+	}
+	if !answered.IsZero() && !decided.Before(answered) {
+		a.DetectionSeconds = decided.Sub(answered).Seconds()
+	}
+	if end := timeline[len(timeline)-1].Time; end.After(decided) {
+		a.AfterVerdictSeconds = end.Sub(decided).Seconds()
+	}
+	return a
+}
+
+// judgeAMD correlates the verdict with how the call went on: the callee's
+// words in the transcript and who ended the call how soon.
+func judgeAMD(a *AMDAnalysis, ending *CallEnding, turns []TranscriptTurn) {
+	if a == nil || a.Status == "" {
+		return
+	}
+	a.CalleeHungUp = ending != nil && ending.Culprit == culpritCaller
+	for _, t := range turns {
+		if t.Role != "user" {
+			continue
+		}
+		a.CalleeSpoke = true
+		lower := strings.ToLower(t.Content)
+		for _, p := range voicemailPhrases {
+			if a.VoicemailPhrase == "" && strings.Contains(lower, p) {
+				a.VoicemailPhrase = p
+			}
+		}
+	}
+	early := a.CalleeHungUp && a.AfterVerdictSeconds > 0 && a.AfterVerdictSeconds < amdEarlyHangup.Seconds()
+
+	if a.DetectionSeconds >= amdSlowDetection.Seconds() {
+		a.Findings = append(a.Findings, fmt.Sprintf("The callee heard %.1fs of silence while AMD listened", a.DetectionSeconds))
+	}
+	switch a.Status {
+	case amdHangup:
+		a.Findings = append(a.Findings, "The callee hung up before AMD reached a verdict; people who answer and hear silence hang up")
+	case amdMachine, amdNotSure:
+		a.Findings = append(a.Findings, fmt.Sprintf("AMD judged an answering machine (%s); the engine played the voicemail drop without an AI session", emptyTo(a.Cause, a.Status)))
+		if r := causeVerdict(a.Cause); r == amdMachine && !strings.HasPrefix(a.Cause, "INITIALSILENCE") {
+			a.Findings = append(a.Findings, "MAXWORDS and LONGGREETING also catch people who answer with a long greeting")
+		}
+	case amdHuman:
+		if a.Detected != "" {
+			a.Findings = append(a.Findings, fmt.Sprintf("The dialplan guardrails routed AMD's %s verdict (%s) to the agent as HUMAN", a.Detected, a.Cause))
+		}
+		if a.VoicemailPhrase != "" {
+			a.Voicemail = true
+			a.Findings = append(a.Findings, fmt.Sprintf("The callee's words include %q, which voicemail greetings say", a.VoicemailPhrase))
+		}
+		switch {
+		case early && !a.CalleeSpoke:
+			a.Findings = append(a.Findings, fmt.Sprintf("The callee hung up %.0fs after the HUMAN verdict without saying anything, as an answering machine does when it stops recording", a.AfterVerdictSeconds))
+			a.Voicemail = a.Voicemail || a.Detected != ""
+		case a.Detected != "" && !a.CalleeSpoke:
+			a.Findings = append(a.Findings, "The callee never spoke after the verdict")
+			a.Voicemail = true
+		}
+	}
+}
+
+// amdWarnings flags calls where the agent likely reached voicemail or
+// detection lost the callee.
+func amdWarnings(a *AMDAnalysis) []string {
+	switch {
+	case a == nil:
+		return nil
+	case a.Voicemail:
+		return []string{"The agent likely talked to an answering machine (AMD verdict " + a.Status + ")"}
+	case a.Status == amdHangup:
+		return []string{"The callee hung up during answering machine detection"}
+	}
+	return nil
+}
+
+// callAMD analyzes an outbound call's answering machine detection, with the
+// transcript from Call History when there is one.
+func callAMD(analysis *Analysis, timeline []TimelineEntry) *AMDAnalysis {
+	a := extractAMD(timeline)
+	if a == nil {
+		return nil
+	}
+	var turns []TranscriptTurn
+	if analysis.HasTranscription {
+		turns, _ = loadCallTranscript(analysis.CallID)
+	}
+	judgeAMD(a, analysis.Ending, turns)
+	return a
+}
+
+// analyzeVoicemail checks whether the agent talked to an answering machine
+// on an outbound call, from the AMD verdict and what followed it.
+func (sc *SymptomChecker) analyzeVoicemail(analysis *Analysis) {
+	sa := &SymptomAnalysis{
+		Symptom:     "voicemail",
+		Description: "Agent talked to an answering machine",
+		Findings:    []string{},
+		RootCauses:  []string{},
+		Actions:     []string{},
+	}
+	analysis.SymptomAnalysis = sa
+	a := analysis.AMD
+
+	switch {
+	case a == nil:
+		sa.Findings = append(sa.Findings, "❌ No answering machine detection for this call")
+		sa.RootCauses = append(sa.RootCauses, "The call was not originated by an outbound campaign, which is the only path that runs AMD()")
+		sa.Actions = append(sa.Actions, "Check the call ID belongs to an outbound attempt (Admin UI → Call Scheduling)")
+		return
+	case len(a.Verdicts) == 0:
+		sa.Findings = append(sa.Findings, "❌ The outbound call was answered but no AMD verdict was logged")
+		sa.RootCauses = append(sa.RootCauses, "The AMD dialplan context did not run AMD() or did not return the call to Stasis with outbound_amd")
+		sa.Actions = append(sa.Actions, "Compare the [aava-outbound-amd] context (AAVA_OUTBOUND_AMD_CONTEXT) with the dialplan snippet in Admin UI → Call Scheduling")
+		return
+	}
+	verdict := fmt.Sprintf("AMD verdict: %s", a.Status)
+	if a.Cause != "" {
+		verdict += " (" + a.Cause + ")"
+	}
+	sa.Findings = append(sa.Findings, "ℹ️  "+verdict)
+	for _, f := range a.Findings {
+		sa.Findings = append(sa.Findings, "ℹ️  "+f)
+	}
+
+	switch {
+	case a.Voicemail:
+		sa.Findings = append(sa.Findings, "❌ The agent likely talked to an answering machine")
+		if a.Detected != "" {
+			sa.RootCauses = append(sa.RootCauses, fmt.Sprintf("AMD said %s but the guardrails for silent callers sent the call to the agent", a.Detected))
+			sa.Actions = append(sa.Actions, "Drop the TOOLONG and INITIALSILENCE guardrails from [aava-outbound-amd] if silent callers are rare on this campaign")
+		} else {
+			sa.RootCauses = append(sa.RootCauses, "AMD judged the voicemail greeting a human")
+			sa.Actions = append(sa.Actions, "Lower the campaign's amd_options greeting_ms or maximum_number_of_words; the human-first defaults are 2000 ms and 10 words")
+		}
+		sa.Actions = append(sa.Actions, "Tell the agent in its prompt to end the call with hangup_call when it hears a voicemail greeting")
+	case a.Status == amdHangup:
+		sa.RootCauses = append(sa.RootCauses, "The callee heard silence while AMD listened and hung up")
+		sa.Actions = append(sa.Actions, "Lower the campaign's amd_options initial_silence_ms and total_analysis_time_ms so detection ends sooner")
+	case a.Status == amdHuman:
+		sa.Findings = append(sa.Findings, "✅ Nothing after the HUMAN verdict points to an answering machine")
+	default:
+		sa.Findings = append(sa.Findings, "✅ AMD sent the call to the voicemail drop; the agent did not talk to it")
+		sa.Actions = append(sa.Actions, "If live people get the voicemail drop, raise amd_options maximum_number_of_words and greeting_ms")
+	}
+}
+
+func (r *Runner) displayAMD(a *AMDAnalysis) {
+	if a == nil {
+		return
+	}
+	fmt.Println("Answering Machine Detection:")
+	if a.Status != "" {
+		fmt.Printf("  Verdict: %s", a.Status)
+		if a.Cause != "" {
+			fmt.Printf(" (%s)", a.Cause)
+		}
+		if a.Detected != "" {
+			fmt.Printf("   AMD said: %s", a.Detected)
+		}
+		fmt.Println()
+	}
+	if a.DetectionSeconds > 0 || a.Consent != "" {
+		fmt.Printf("  Detection: %.1fs", a.DetectionSeconds)
+		if a.Consent != "" {
+			fmt.Printf("   Consent: %s", a.Consent)
+		}
+		fmt.Println()
+	}
+	if r.verbose {
+		for _, v := range a.Verdicts {
+			fmt.Printf("    %s  %-8s %-24s %-9s %s\n", v.Time.Local().Format("15:04:05.000"), v.Status, v.Cause, v.Source, v.Channel)
+		}
+	}
+	for _, f := range a.Findings {
+		fmt.Printf("  • %s\n", f)
+	}
+	if a.Voicemail {
+		warningColor.Println("  The agent likely talked to an answering machine (see --symptom voicemail)")
+	}
+	fmt.Println()
+}
@@ -0,0 +1,94 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func amdTimeline(status, cause string, end string) []TimelineEntry {
+	at := func(s string) time.Time {
+		ts, _ := time.Parse(time.RFC3339Nano, s)
+		return ts
+	}
+	asterisk := []TimelineEntry{{
+		Time:   at("2026-01-30T17:21:44.900Z"),
+		Source: SourceAsterisk,
+		Line:   `[Jan 30 17:21:44] VERBOSE[812][C-0000000c] pbx.c: Executing [s@aava-outbound-amd:5] NoOp("PJSIP/trunk-00000001", "AMDSTATUS=` + status + ` AMDCAUSE=` + cause + `") in new stack`,
+	}}
+	routed := status
+	if strings.HasPrefix(cause, "TOOLONG") || strings.HasPrefix(cause, "INITIALSILENCE") {
+		routed = "HUMAN"
+	}
+	return mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"Outbound answered","channel_id":"1.1","attempt_id":"a1"}`,
+		`{"timestamp":"2026-01-30T17:21:45.000Z","level":"info","event":"Outbound AMD result","channel_id":"1.1","attempt_id":"a1","amd_status":"` + routed + `","amd_cause":"` + cause + `","consent_result":null}`,
+		`{"timestamp":"` + end + `","level":"info","event":"Call cleanup completed","call_id":"1.1"}`,
+	}, asterisk)
+}
+
+func TestExtractAMD(t *testing.T) {
+	if a := extractAMD(mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"Incoming call","call_id":"1.1"}`,
+	})); a != nil {
+		t.Fatalf("inbound call amd = %+v", a)
+	}
+
+	a := extractAMD(amdTimeline("NOTSURE", "TOOLONG-5000", "2026-01-30T17:21:53.000Z"))
+	if a == nil || len(a.Verdicts) != 2 || a.Status != amdHuman || a.Detected != amdNotSure || a.Consent != "" {
+		t.Fatalf("amd = %+v", a)
+	}
+	if a.DetectionSeconds != 5 || a.AfterVerdictSeconds != 8 {
+		t.Errorf("timing = %.1fs, %.1fs", a.DetectionSeconds, a.AfterVerdictSeconds)
+	}
+
+	// The engine folds NOTSURE into MACHINE; that is not an override.
+	if a := extractAMD(amdTimeline("MACHINE", "MAXWORDS-11-10", "2026-01-30T17:21:50.000Z")); a.Status != amdMachine || a.Detected != "" {
+		t.Errorf("machine amd = %+v", a)
+	}
+}
+
+func TestJudgeAMD(t *testing.T) {
+	hungUp := &CallEnding{Culprit: culpritCaller}
+
+	// A guardrail HUMAN followed by a silent early hangup is a machine.
+	a := extractAMD(amdTimeline("NOTSURE", "TOOLONG-5000", "2026-01-30T17:21:53.000Z"))
+	judgeAMD(a, hungUp, nil)
+	if !a.Voicemail || !a.CalleeHungUp || len(amdWarnings(a)) != 1 {
+		t.Fatalf("guardrail amd = %+v", a)
+	}
+
+	// A greeting phrase in the transcript gives voicemail away on a genuine HUMAN verdict.
+	a = extractAMD(amdTimeline("HUMAN", "HUMAN-300-1000", "2026-01-30T17:22:30.000Z"))
+	judgeAMD(a, &CallEnding{Culprit: culpritAgent}, []TranscriptTurn{
+		{Role: "assistant", Content: "Hi, this is Ava from the clinic."},
+		{Role: "user", Content: "You've reached Sam. Please leave a message after the tone."},
+	})
+	if !a.Voicemail || a.VoicemailPhrase != "leave a message" {
+		t.Fatalf("phrase amd = %+v", a)
+	}
+
+	// A person who says hello and hangs up soon is not voicemail.
+	a = extractAMD(amdTimeline("HUMAN", "HUMAN-300-1000", "2026-01-30T17:21:50.000Z"))
+	judgeAMD(a, hungUp, []TranscriptTurn{{Role: "user", Content: "Hello? Not interested."}})
+	if a.Voicemail || amdWarnings(a) != nil {
+		t.Fatalf("human amd = %+v", a)
+	}
+}
+
+func TestVoicemailSymptom(t *testing.T) {
+	a := extractAMD(amdTimeline("MACHINE", "INITIALSILENCE-2000-2000", "2026-01-30T17:21:46.000Z"))
+	judgeAMD(a, &CallEnding{Culprit: culpritCaller}, nil)
+	analysis := &Analysis{AMD: a}
+	NewSymptomChecker("voicemail").AnalyzeSymptom(analysis, "")
+	sa := analysis.SymptomAnalysis
+	if sa == nil || len(sa.RootCauses) != 1 || !strings.Contains(sa.RootCauses[0], "guardrails") {
+		t.Fatalf("symptom analysis = %+v", sa)
+	}
+
+	analysis = &Analysis{}
+	NewSymptomChecker("voicemail").AnalyzeSymptom(analysis, "")
+	if sa := analysis.SymptomAnalysis; sa == nil || !strings.Contains(sa.Findings[0], "No answering machine detection") {
+		t.Fatalf("inbound symptom analysis = %+v", sa)
+	}
+}
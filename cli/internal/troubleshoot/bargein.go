@@ -0,0 +1,205 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+// Barge-in thresholds. Stop latency runs from the first caller speech onset
+// to agent playback stopping, so it includes barge_in.min_ms.
+const (
+	bargeInStopGoodMS = 500
+	bargeInStopPoorMS = 1000
+	// bargeInOnsetLookback bounds how far before a barge-in action its
+	// caller speech onset may be.
+	bargeInOnsetLookback = 3 * time.Second
+	// bargeInFalsePoorPct is the false-interruption share rated poor.
+	bargeInFalsePoorPct = 30.0
+)
+
+// Barge-in verdicts.
+const (
+	BargeInGood = "GOOD"
+	BargeInFair = "FAIR"
+	BargeInPoor = "POOR"
+)
+
+// BargeInEvent is one interruption of agent audio.
+type BargeInEvent struct {
+	Time          time.Time
+	Source        string
+	Reason        string
+	HasLatency    bool // a caller speech onset was logged before the action
+	StopLatencyMS int  // onset to playback stopped
+	PlayedMS      int  // agent audio of the interrupted segment that was heard
+	DiscardedMS   int  // agent audio queued but never played
+	False         bool // triggered by echo of the agent's own audio
+}
+
+// BargeInMetrics rates how the call handled interruptions.
+type BargeInMetrics struct {
+	Count              int
+	MeasuredLatency    int
+	StopLatencyAvgMS   int
+	StopLatencyMaxMS   int
+	AgentAudioCutMS    int // queued agent audio discarded over all barge-ins
+	FalseInterruptions int
+	Verdict            string
+	Issues             []string
+	Events             []BargeInEvent
+}
+
+// extractBargeIns finds each barge-in action in the call's engine lines and
+// measures it against the caller speech onset before it and the playback
+// stop and segment summaries logged while it ran. Echo classification from
+// the echo path analysis marks false interruptions.
+func extractBargeIns(timeline []TimelineEntry, echo *EchoAnalysis) *BargeInMetrics {
+	frameBytes, chunkMS := 160, 20
+	var onsets, stops []time.Time
+	var events []BargeInEvent
+	// Evidence from the current segment; the engine logs the summaries of an
+	// interrupted segment before the action line.
+	var playedMS float64
+	var queuedBytes int
+
+	for _, e := range timeline {
+		if e.Source != SourceEngine {
+			continue
+		}
+		_, event, fields, ok := parseLogLine(e.Line)
+		if !ok || e.Time.IsZero() {
+			continue
+		}
+		t := e.Time
+		switch event {
+		case logschema.EventStreamFrameSize:
+			if n := atoiSafe(fields["frame_size_bytes"]); n > 0 {
+				frameBytes = n
+			}
+			if n := atoiSafe(fields["chunk_ms"]); n > 0 {
+				chunkMS = n
+			}
+		case logschema.EventStreamStarted:
+			playedMS, queuedBytes = 0, 0
+		case logschema.EventStreamStopped:
+			stops = append(stops, t)
+		case logschema.EventTTSGatingCleared:
+			if fields["reason"] == "barge-in" {
+				stops = append(stops, t)
+			}
+		case logschema.EventRemainderDiscarded:
+			queuedBytes += atoiSafe(fields["discarded_bytes"])
+		case logschema.EventSegmentSummary:
+			queuedBytes += atoiSafe(fields["buffered_bytes"])
+		case logschema.EventTuningSummary:
+			playedMS = atofSafe(fields["effective_seconds"]) * 1000
+		case logschema.EventBargeInApplied:
+			ev := BargeInEvent{Time: t, Source: fields["source"], Reason: fields["reason"]}
+			ev.PlayedMS = int(playedMS)
+			ev.DiscardedMS = queuedBytes * chunkMS / frameBytes
+			if onset, ok := lastOnset(onsets, t, events); ok {
+				stopped := t
+				for _, s := range stops {
+					if !s.Before(onset) && s.Before(stopped) {
+						stopped = s
+					}
+				}
+				ev.HasLatency = true
+				ev.StopLatencyMS = int(stopped.Sub(onset) / time.Millisecond)
+			}
+			events = append(events, ev)
+			playedMS, queuedBytes = 0, 0
+			continue
+		}
+		if onsetSource(event, fields) != "" {
+			onsets = append(onsets, t)
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	markFalseInterruptions(events, echo)
+	return rateBargeIns(events)
+}
+
+// lastOnset is the first caller speech onset within the lookback before an
+// action at t that is later than the previous action.
+func lastOnset(onsets []time.Time, t time.Time, prev []BargeInEvent) (time.Time, bool) {
+	floor := t.Add(-bargeInOnsetLookback)
+	if n := len(prev); n > 0 && prev[n-1].Time.After(floor) {
+		floor = prev[n-1].Time
+	}
+	for _, o := range onsets {
+		if o.After(floor) && !o.After(t) {
+			return o, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// markFalseInterruptions flags barge-ins that the echo analysis attributes to
+// echo onsets.
+func markFalseInterruptions(events []BargeInEvent, echo *EchoAnalysis) {
+	if echo == nil {
+		return
+	}
+	for _, o := range echo.Events {
+		if o.Class != onsetEcho || !o.Interrupted {
+			continue
+		}
+		for i := range events {
+			if !events[i].Time.Before(o.Time) && events[i].Time.Sub(o.Time) <= echoInterruptAfter {
+				events[i].False = true
+				break
+			}
+		}
+	}
+}
+
+func rateBargeIns(events []BargeInEvent) *BargeInMetrics {
+	m := &BargeInMetrics{Count: len(events), Events: events, Verdict: BargeInGood}
+	total := 0
+	for _, ev := range events {
+		m.AgentAudioCutMS += ev.DiscardedMS
+		if ev.False {
+			m.FalseInterruptions++
+		}
+		if !ev.HasLatency {
+			continue
+		}
+		m.MeasuredLatency++
+		total += ev.StopLatencyMS
+		if ev.StopLatencyMS > m.StopLatencyMaxMS {
+			m.StopLatencyMaxMS = ev.StopLatencyMS
+		}
+	}
+	if m.MeasuredLatency > 0 {
+		m.StopLatencyAvgMS = total / m.MeasuredLatency
+	}
+
+	worsen := func(v string) {
+		if v == BargeInPoor || m.Verdict == BargeInGood {
+			m.Verdict = v
+		}
+	}
+	switch {
+	case m.StopLatencyAvgMS > bargeInStopPoorMS:
+		worsen(BargeInPoor)
+		m.Issues = append(m.Issues, fmt.Sprintf("Slow barge-in: playback stopped %dms after the caller spoke on average (poor above %dms)", m.StopLatencyAvgMS, bargeInStopPoorMS))
+	case m.StopLatencyAvgMS > bargeInStopGoodMS:
+		worsen(BargeInFair)
+		m.Issues = append(m.Issues, fmt.Sprintf("Barge-in latency %dms on average (good up to %dms)", m.StopLatencyAvgMS, bargeInStopGoodMS))
+	}
+	if m.FalseInterruptions > 0 {
+		pct := float64(m.FalseInterruptions) / float64(m.Count) * 100
+		if pct >= bargeInFalsePoorPct {
+			worsen(BargeInPoor)
+		} else {
+			worsen(BargeInFair)
+		}
+		m.Issues = append(m.Issues, fmt.Sprintf("%d of %d barge-in(s) were triggered by echo of the agent's own audio", m.FalseInterruptions, m.Count))
+	}
+	return m
+}
@@ -0,0 +1,57 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractBargeIns(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2026-01-30T17:21:39.900Z","level":"info","event":"🎼 STREAM FRAME SIZE","call_id":"1.1","frame_size_bytes":160,"chunk_ms":20}`,
+		// Echo 150ms into the greeting cuts it off.
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"stream:greeting:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.150Z","level":"debug","event":"🎧 Barge-in attempt detected","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.450Z","level":"info","event":"🎵 STREAMING PLAYBACK - Stopped","call_id":"1.1","stream_id":"stream:greeting:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.460Z","level":"info","event":"🎧 BARGE-IN action applied","call_id":"1.1","source":"local_vad","reason":"tts_overlap"}`,
+		// The caller interrupts a response 3s in; 1.6s of queued audio is dropped.
+		`{"timestamp":"2026-01-30T17:21:45.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"stream:response:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:48.000Z","level":"info","event":"🎤 User interruption detected, cancelling response","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:48.900Z","level":"debug","event":"Skipped remainder flush (barge-in)","call_id":"1.1","discarded_bytes":800}`,
+		`{"timestamp":"2026-01-30T17:21:48.900Z","level":"info","event":"Streaming segment bytes summary v2","call_id":"1.1","stream_id":"stream:response:1.1","underflow_events":0,"buffered_bytes":12000}`,
+		`{"timestamp":"2026-01-30T17:21:48.900Z","level":"info","event":"🎛️ STREAMING TUNING SUMMARY","call_id":"1.1","stream_id":"stream:response:1.1","effective_seconds":2.9}`,
+		`{"timestamp":"2026-01-30T17:21:48.950Z","level":"info","event":"🎵 STREAMING PLAYBACK - Stopped","call_id":"1.1","stream_id":"stream:response:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:49.000Z","level":"info","event":"🎧 BARGE-IN action applied","call_id":"1.1","source":"provider_event","reason":"input_audio_buffer.speech_started"}`,
+	}
+	timeline := mergeTimeline(lines)
+	b := extractBargeIns(timeline, analyzeEcho(timeline, nil, 0))
+	if b == nil || b.Count != 2 || b.MeasuredLatency != 2 {
+		t.Fatalf("barge-ins = %+v", b)
+	}
+	first, second := b.Events[0], b.Events[1]
+	if first.StopLatencyMS != 300 || !first.False || first.DiscardedMS != 0 {
+		t.Fatalf("echo barge-in = %+v", first)
+	}
+	if second.StopLatencyMS != 950 || second.False || second.PlayedMS != 2900 || second.DiscardedMS != 1600 {
+		t.Fatalf("caller barge-in = %+v", second)
+	}
+	if b.StopLatencyAvgMS != 625 || b.StopLatencyMaxMS != 950 || b.AgentAudioCutMS != 1600 || b.FalseInterruptions != 1 {
+		t.Fatalf("totals = %+v", b)
+	}
+	// Half the interruptions were echo.
+	if b.Verdict != BargeInPoor || len(b.Issues) != 2 || !strings.Contains(b.Issues[1], "1 of 2 barge-in(s)") {
+		t.Fatalf("verdict = %s %q", b.Verdict, b.Issues)
+	}
+
+	score, issues := evaluateCallQuality(&CallMetrics{BargeIn: b})
+	if score != 85 || len(issues) != 2 {
+		t.Fatalf("quality = %v %q", score, issues)
+	}
+}
+
+func TestExtractBargeInsNone(t *testing.T) {
+	if b := extractBargeIns(mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"s"}`,
+	}), nil); b != nil {
+		t.Fatalf("barge-ins = %+v", b)
+	}
+}
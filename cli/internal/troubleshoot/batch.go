@@ -0,0 +1,217 @@
+package troubleshoot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+)
+
+// BatchCall is one call's row in the summary of the last calls.
+type BatchCall struct {
+	CallID       string    `json:"call_id"`
+	Started      time.Time `json:"started"`
+	Duration     string    `json:"duration,omitempty"`
+	Transport    string    `json:"transport,omitempty"`
+	QualityScore *float64  `json:"quality_score,omitempty"`
+	// ConversationScore is the mean rubric score (1-5) with --conversation.
+	ConversationScore *float64 `json:"conversation_score,omitempty"`
+	Result            string   `json:"result"` // PASS, WARN or FAIL, as the exit code
+	Errors            int      `json:"errors"`
+	Warnings          int      `json:"warnings"`
+	AudioIssues       int      `json:"audio_issues"`
+	TopIssue          string   `json:"top_issue,omitempty"`
+	Error             string   `json:"error,omitempty"` // why the call could not be analyzed
+}
+
+// BatchReport summarizes the last calls, oldest first.
+type BatchReport struct {
+	SchemaVersion       int          `json:"schema_version"`
+	Calls               []BatchCall  `json:"calls"`
+	Passed              int          `json:"passed"`
+	Warned              int          `json:"warned"`
+	Failed              int          `json:"failed"`
+	AverageScore        *float64     `json:"average_quality_score,omitempty"`
+	AverageConversation *float64     `json:"average_conversation_score,omitempty"`
+	Reports             []*RCAReport `json:"reports,omitempty"` // with full
+}
+
+// SetLast analyzes the n most recent calls instead of one and prints a
+// summary of them; full prints each call's report before it.
+func (r *Runner) SetLast(n int, full bool) {
+	r.last, r.full = n, full
+}
+
+// recentCalls lists the calls runLast analyzes and analyzeCall analyzes one
+// of them; tests replace them.
+var (
+	recentCalls = (*Runner).getRecentCalls
+	analyzeCall = (*Runner).runCall
+)
+
+// runLast analyzes the last calls one by one, each with the runner's
+// settings, and summarizes them.
+func (r *Runner) runLast() error {
+	calls, err := recentCalls(r, r.last)
+	if err != nil {
+		return contract.EnvironmentError(fmt.Errorf("failed to get recent calls: %w", err))
+	}
+	if len(calls) == 0 {
+		if r.jsonOutput {
+			_ = r.outputJSON(&RCAReport{Error: "no recent calls found (make a test call and re-run)"})
+		} else if !r.quiet {
+			errorColor.Println("❌ No recent calls found")
+		}
+		return contract.EnvironmentError(errors.New("no recent calls found"))
+	}
+	if r.decorated() && len(calls) < r.last {
+		warningColor.Printf("Only %d call(s) in the call index\n\n", len(calls))
+	}
+
+	rep := &BatchReport{SchemaVersion: contract.SchemaVersion}
+	var total, convTotal float64
+	scored, convScored := 0, 0
+	for i := len(calls) - 1; i >= 0; i-- {
+		call := calls[i]
+		sub := NewRunner(call.ID, r.symptom, false, false, r.noLLM, r.forceLLM, false, false, r.verbose)
+		sub.echoWindow = r.echoWindow
+		sub.conversation = r.conversation
+		sub.redactor = r.redactor
+		sub.profile = r.profile
+		sub.plugins, sub.cliVersion = r.plugins, r.cliVersion
+		sub.budgets = r.budgets
+		printed := r.full && !r.jsonOutput && !r.quiet
+		sub.silent, sub.quiet = !printed, !printed
+		if printed {
+			fmt.Println()
+			infoColor.Printf("━━━ Call %d of %d: %s ━━━\n", len(calls)-i, len(calls), call.ID)
+		}
+		row := BatchCall{CallID: call.ID, Started: call.Timestamp, Duration: call.Duration, Transport: call.Transport}
+		if err := analyzeCall(sub); err != nil || sub.analysis == nil {
+			if err == nil {
+				err = errors.New("no analysis")
+			}
+			row.Result, row.Error = resultLabel(contract.Fail), err.Error()
+			rep.Failed++
+			rep.Calls = append(rep.Calls, row)
+			r.batchCode = contract.Fail
+			continue
+		}
+		a := sub.analysis
+		code := sub.ExitCode()
+		row.Result = resultLabel(code)
+		row.Errors, row.Warnings, row.AudioIssues = len(a.Errors), len(a.Warnings), len(a.AudioIssues)
+		if t := a.AudioTransport; t != "" && t != "unknown" {
+			row.Transport = t
+		}
+		var issues []string
+		if metricsHasEvidence(a.Metrics) {
+			score, qi := callQualityScore(a)
+			row.QualityScore, issues = &score, qi
+			total += score
+			scored++
+		}
+		if q := a.Conversation; q != nil && q.Error == "" {
+			row.ConversationScore = &q.Overall
+			convTotal += q.Overall
+			convScored++
+		}
+		row.TopIssue = firstOf(a.Errors, issues, a.Warnings, a.AudioIssues)
+		switch code {
+		case contract.Fail:
+			rep.Failed++
+		case contract.Warn:
+			rep.Warned++
+		default:
+			rep.Passed++
+		}
+		if code > r.batchCode {
+			r.batchCode = code
+		}
+		if r.full && r.jsonOutput {
+			rep.Reports = append(rep.Reports, buildRCAReport(a, sub.llm))
+		}
+		rep.Calls = append(rep.Calls, row)
+	}
+	if scored > 0 {
+		avg := total / float64(scored)
+		rep.AverageScore = &avg
+	}
+	if convScored > 0 {
+		avg := convTotal / float64(convScored)
+		rep.AverageConversation = &avg
+	}
+
+	if r.jsonOutput {
+		f := r.format
+		if !f.Structured() {
+			f = output.JSON
+		}
+		return output.Write(os.Stdout, f, rep)
+	}
+	r.displayBatch(rep)
+	return nil
+}
+
+// firstOf returns the first entry of the first non-empty list.
+func firstOf(lists ...[]string) string {
+	for _, l := range lists {
+		if len(l) > 0 {
+			return l[0]
+		}
+	}
+	return ""
+}
+
+func (r *Runner) displayBatch(rep *BatchReport) {
+	if r.quiet {
+		for _, c := range rep.Calls {
+			fmt.Printf("%s call=%s errors=%d warnings=%d audio_issues=%d\n", c.Result, c.CallID, c.Errors, c.Warnings, c.AudioIssues)
+		}
+		return
+	}
+	fmt.Println()
+	fmt.Printf("📋 Last %d call(s), oldest first\n", len(rep.Calls))
+	fmt.Println("═══════════════════════════════════════════")
+	fmt.Printf("  %-22s %-15s %-9s %-14s %-6s %-6s %s\n", "CALL", "STARTED", "DURATION", "TRANSPORT", "SCORE", "RESULT", "TOP ISSUE")
+	for _, c := range rep.Calls {
+		score := "-"
+		if c.QualityScore != nil {
+			score = fmt.Sprintf("%.0f", *c.QualityScore)
+		}
+		issue := c.TopIssue
+		if c.Error != "" {
+			issue = c.Error
+		}
+		line := fmt.Sprintf("  %-22s %-15s %-9s %-14s %-6s %-6s %s", c.CallID, c.Started.Local().Format("Jan 02 15:04:05"),
+			emptyTo(c.Duration, "-"), emptyTo(c.Transport, "-"), score, c.Result, truncate(issue, 70))
+		switch c.Result {
+		case "FAIL":
+			errorColor.Println(line)
+		case "WARN":
+			warningColor.Println(line)
+		default:
+			successColor.Println(line)
+		}
+	}
+	fmt.Println()
+	switch {
+	case rep.Failed == 0 && rep.Warned == 0:
+		successColor.Printf("✅ All %d call(s) passed", len(rep.Calls))
+	case rep.Failed == 0:
+		warningColor.Printf("⚠️  %d passed, %d with warnings", rep.Passed, rep.Warned)
+	default:
+		errorColor.Printf("❌ %d passed, %d with warnings, %d failed", rep.Passed, rep.Warned, rep.Failed)
+	}
+	if rep.AverageScore != nil {
+		fmt.Printf("; average quality %.0f/100", *rep.AverageScore)
+	}
+	if rep.AverageConversation != nil {
+		fmt.Printf("; conversation %.1f/5", *rep.AverageConversation)
+	}
+	fmt.Println()
+	fmt.Println("Details: agent rca --call <id>")
+}
@@ -0,0 +1,184 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+)
+
+// captureStdout returns what fn prints, including colored lines.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	rd, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, colored := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+	done := make(chan string)
+	go func() {
+		raw, _ := io.ReadAll(rd)
+		done <- string(raw)
+	}()
+	fn()
+	os.Stdout, color.Output = stdout, colored
+	w.Close()
+	return <-done
+}
+
+// stubBatch serves calls from an index of four calls a minute apart, newest
+// last, and analyzes each with the given analysis or error.
+func stubBatch(t *testing.T, results map[string]any) (analyzed *[]string) {
+	t.Helper()
+	idx := newCallIndex("test")
+	start := time.Date(2026, 1, 30, 17, 0, 0, 0, time.UTC)
+	for i, id := range []string{"1769792400.1", "1769792460.2", "1769792520.3", "1769792580.4"} {
+		at := start.Add(time.Duration(i) * time.Minute)
+		idx.Calls[id] = &CallIndexEntry{ID: id, FirstSeen: at, LastSeen: at}
+	}
+	savedCalls, savedAnalyze := recentCalls, analyzeCall
+	t.Cleanup(func() { recentCalls, analyzeCall = savedCalls, savedAnalyze })
+
+	analyzed = new([]string)
+	recentCalls = func(_ *Runner, limit int) ([]Call, error) { return idx.recentCalls(limit), nil }
+	analyzeCall = func(sub *Runner) error {
+		*analyzed = append(*analyzed, sub.callID)
+		switch v := results[sub.callID].(type) {
+		case error:
+			return v
+		case *Analysis:
+			sub.analysis = v
+		}
+		return nil
+	}
+	return analyzed
+}
+
+func runBatch(t *testing.T, last int) (*Runner, BatchReport) {
+	t.Helper()
+	r := NewRunner("", "", false, false, true, false, false, true, false)
+	r.SetLast(last, false)
+	var err error
+	out := captureStdout(t, func() { err = r.runLast() })
+	if err != nil {
+		t.Fatalf("runLast: %v", err)
+	}
+	var rep BatchReport
+	if err := json.Unmarshal([]byte(out), &rep); err != nil {
+		t.Fatalf("report %q: %v", out, err)
+	}
+	return r, rep
+}
+
+func TestRunLastAnalyzesNewestCallsOldestFirst(t *testing.T) {
+	analyzed := stubBatch(t, map[string]any{
+		"1769792460.2": &Analysis{},
+		"1769792520.3": &Analysis{Warnings: []string{"slow first response"}},
+		"1769792580.4": errors.New("failed to collect data"),
+	})
+
+	r, rep := runBatch(t, 3)
+	if got := strings.Join(*analyzed, ","); got != "1769792460.2,1769792520.3,1769792580.4" {
+		t.Fatalf("analyzed %s", got)
+	}
+	if len(rep.Calls) != 3 || rep.Calls[0].CallID != "1769792460.2" || rep.Calls[2].CallID != "1769792580.4" {
+		t.Fatalf("calls = %+v", rep.Calls)
+	}
+	if c := rep.Calls[1]; c.Result != "WARN" || c.Warnings != 1 || c.TopIssue != "slow first response" {
+		t.Errorf("warned row = %+v", c)
+	}
+	// A call that cannot be analyzed is a failed row, not the end of the batch.
+	if c := rep.Calls[2]; c.Result != "FAIL" || c.Error != "failed to collect data" {
+		t.Errorf("failed row = %+v", c)
+	}
+	if rep.Passed != 1 || rep.Warned != 1 || rep.Failed != 1 {
+		t.Errorf("passed=%d warned=%d failed=%d", rep.Passed, rep.Warned, rep.Failed)
+	}
+	if code := r.ExitCode(); code != contract.Fail {
+		t.Errorf("exit code = %d, want %d", code, contract.Fail)
+	}
+}
+
+func TestRunLastExitCodeIsWorstCall(t *testing.T) {
+	stubBatch(t, map[string]any{
+		"1769792400.1": &Analysis{},
+		"1769792460.2": &Analysis{AudioIssues: []string{"underflows"}},
+		"1769792520.3": &Analysis{},
+		"1769792580.4": &Analysis{},
+	})
+
+	// Asking for more calls than the index holds analyzes all of them.
+	r, rep := runBatch(t, 10)
+	if len(rep.Calls) != 4 || rep.Passed != 3 || rep.Warned != 1 {
+		t.Fatalf("report = %+v", rep)
+	}
+	if code := r.ExitCode(); code != contract.Warn {
+		t.Errorf("exit code = %d, want %d", code, contract.Warn)
+	}
+
+	r, rep = runBatch(t, 2)
+	if len(rep.Calls) != 2 || rep.Calls[0].CallID != "1769792520.3" {
+		t.Fatalf("calls = %+v", rep.Calls)
+	}
+	if code := r.ExitCode(); code != contract.OK {
+		t.Errorf("exit code = %d, want %d", code, contract.OK)
+	}
+}
+
+func TestRunLastWithoutCalls(t *testing.T) {
+	saved := recentCalls
+	t.Cleanup(func() { recentCalls = saved })
+	recentCalls = func(*Runner, int) ([]Call, error) { return nil, nil }
+
+	r := NewRunner("", "", false, false, true, false, false, false, false)
+	r.SetLast(5, false)
+	r.SetQuiet(true)
+	if err := r.runLast(); contract.CodeOf(err) != contract.Environment {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestFirstOf(t *testing.T) {
+	if got := firstOf(nil, []string{}, []string{"b", "c"}, []string{"d"}); got != "b" {
+		t.Errorf("firstOf = %q", got)
+	}
+	if got := firstOf(nil, nil); got != "" {
+		t.Errorf("firstOf of empty lists = %q", got)
+	}
+}
+
+func TestDisplayBatch(t *testing.T) {
+	score := 87.0
+	rep := &BatchReport{
+		Calls: []BatchCall{
+			{CallID: "1769792460.2", Result: "PASS", QualityScore: &score},
+			{CallID: "1769792580.4", Result: "FAIL", Errors: 2, TopIssue: "ignored", Error: "failed to collect data"},
+		},
+		Passed: 1,
+		Failed: 1,
+	}
+
+	r := NewRunner("", "", false, false, true, false, false, false, false)
+	out := captureStdout(t, func() { r.displayBatch(rep) })
+	for _, want := range []string{"Last 2 call(s), oldest first", "failed to collect data", " 87 ", "1 passed, 0 with warnings, 1 failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "ignored") {
+		t.Errorf("top issue shown over the analysis error:\n%s", out)
+	}
+
+	r.SetQuiet(true)
+	out = captureStdout(t, func() { r.displayBatch(rep) })
+	if want := "PASS call=1769792460.2 errors=0 warnings=0 audio_issues=0\nFAIL call=1769792580.4 errors=2 warnings=0 audio_issues=0\n"; out != want {
+		t.Errorf("quiet = %q", out)
+	}
+}
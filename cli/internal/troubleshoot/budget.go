@@ -0,0 +1,116 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+)
+
+// SetBudgets holds every analyzed call to the budgets in cfg; nil checks none.
+func (r *Runner) SetBudgets(cfg *budget.Config) {
+	r.budgets = cfg
+}
+
+// callBudget checks the call against cfg. Response latency is the p95 of the
+// turn latencies the engine logged, else Call History's slowest turn; the
+// duration is the finished call's (a call still in progress is not checked
+// for duration or cost).
+func callBudget(cfg *budget.Config, a *Analysis, timeline []TimelineEntry) *budget.Result {
+	u := budget.Usage{}
+	if h := a.Header; h != nil {
+		u.Pipeline, u.Provider = h.PipelineName, h.ProviderName
+	}
+	var latencies []float64
+	for _, e := range timeline {
+		if ms, ok := turnLatencyMS(e); ok {
+			latencies = append(latencies, ms)
+		}
+	}
+	if len(latencies) > 0 {
+		sort.Float64s(latencies)
+		u.ResponseLatencyMS = percentile(latencies, 95)
+	} else if a.CallHistory != nil {
+		u.ResponseLatencyMS = a.CallHistory.MaximumTurnLatencyMS
+	}
+	if a.Metrics != nil && (a.Live == nil || !a.Live.InProgress) {
+		u.Duration = time.Duration(a.Metrics.CallDurationSeconds * float64(time.Second))
+	}
+	return cfg.Evaluate(u)
+}
+
+// budgetFindings splits the breaches into errors (critical) and warnings, so
+// they set the exit code and reach the hooks like any other finding.
+func budgetFindings(res *budget.Result) (errs, warns []string) {
+	if res == nil {
+		return nil, nil
+	}
+	for _, b := range res.Breaches {
+		if b.Critical() {
+			errs = append(errs, b.Finding)
+		} else {
+			warns = append(warns, b.Finding)
+		}
+	}
+	return errs, warns
+}
+
+// penalizeBudget lists the breaches as quality issues and takes 10 points per
+// warning breach. Critical breaches are errors, which penalizeErrors already
+// caps the score for.
+func penalizeBudget(score float64, issues []string, res *budget.Result) (float64, []string) {
+	if res == nil {
+		return score, issues
+	}
+	for _, b := range res.Breaches {
+		issues = append(issues, "Over budget: "+b.Finding)
+		if !b.Critical() {
+			score -= 10
+		}
+	}
+	if score < 0 {
+		score = 0
+	}
+	return score, issues
+}
+
+// callQualityScore is the call's quality score as agent rca shows it: the
+// metrics' score, penalized for errors and budget breaches.
+func callQualityScore(a *Analysis) (float64, []string) {
+	score, issues := evaluateCallQuality(a.Metrics)
+	score, issues = penalizeErrors(score, issues, len(a.Errors))
+	return penalizeBudget(score, issues, a.Budget)
+}
+
+func (r *Runner) displayBudget(res *budget.Result) {
+	if res == nil {
+		return
+	}
+	fmt.Println("Budgets:")
+	l := res.Limits
+	if l.MaxResponseLatencyMS > 0 {
+		fmt.Printf("  Response latency: p95 up to %dms\n", l.MaxResponseLatencyMS)
+	}
+	if l.MaxDuration > 0 {
+		fmt.Printf("  Duration: up to %s\n", l.MaxDuration)
+	}
+	if res.Cost != nil {
+		fmt.Printf("  Estimated cost: %.2f %s", *res.Cost, res.Currency)
+		if l.MaxCallCost > 0 {
+			fmt.Printf(" (budget %.2f)", l.MaxCallCost)
+		}
+		fmt.Println()
+	}
+	for _, b := range res.Breaches {
+		if b.Critical() {
+			errorColor.Printf("  ❌ %s\n", b.Finding)
+		} else {
+			warningColor.Printf("  ⚠️  %s\n", b.Finding)
+		}
+	}
+	if len(res.Breaches) == 0 {
+		successColor.Println("  ✅ Within budget")
+	}
+	fmt.Println()
+}
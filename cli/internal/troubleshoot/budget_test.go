@@ -0,0 +1,46 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+)
+
+func TestCallBudget(t *testing.T) {
+	cfg := &budget.Config{
+		Limits:   budget.Limits{MaxResponseLatencyMS: 1500, MaxCallCost: 0.5, MaxDuration: 10 * time.Minute},
+		Currency: "USD",
+		Rates:    map[string]float64{"default": 0.1},
+		Severity: map[string]string{budget.Cost: budget.SeverityCritical},
+	}
+	var lines []string
+	for i, ms := range []int{800, 900, 2400} {
+		lines = append(lines, fmt.Sprintf(`{"timestamp":"2026-01-30T17:20:%02d.000Z","level":"info","event":"Turn latency recorded","call_id":"1.1","latency_ms":%d}`, i, ms))
+	}
+	a := &Analysis{
+		Header:  &RCAHeader{ProviderName: "openai_realtime"},
+		Metrics: &CallMetrics{CallDurationSeconds: 720},
+	}
+	res := callBudget(cfg, a, mergeTimeline(lines))
+	if len(res.Breaches) != 3 || res.Cost == nil || *res.Cost != 1.2 {
+		t.Fatalf("callBudget = %+v", res)
+	}
+	errs, warns := budgetFindings(res)
+	if len(errs) != 1 || len(warns) != 2 {
+		t.Fatalf("findings = %v, %v", errs, warns)
+	}
+
+	// Critical breaches are penalized as errors; only warnings cost points here.
+	score, issues := penalizeBudget(100, nil, res)
+	if score != 80 || len(issues) != 3 {
+		t.Fatalf("penalizeBudget = %v, %v", score, issues)
+	}
+
+	// A call in progress has no duration or cost yet.
+	a.Live = &LiveState{InProgress: true}
+	if res := callBudget(cfg, a, nil); len(res.Breaches) != 0 || res.Cost != nil {
+		t.Fatalf("live call = %+v", res)
+	}
+}
@@ -0,0 +1,398 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+)
+
+// Kinds of buffer timeline marks, in the order the chart prefers them when
+// several fall into one column.
+const (
+	markBargeIn     = "barge_in"
+	markUnderflow   = "underflow"
+	markReset       = "reset"
+	markFull        = "full"
+	markGateClosed  = "gate_closed"
+	markGateOpened  = "gate_opened"
+	markStreamStart = "stream_start"
+)
+
+var markSymbols = []struct{ kind, symbol string }{
+	{markBargeIn, "B"},
+	{markUnderflow, "U"},
+	{markReset, "R"},
+	{markFull, "F"},
+	{markGateClosed, "G"},
+	{markGateOpened, "g"},
+	{markStreamStart, "S"},
+}
+
+const (
+	bufferChartWidth  = 60
+	bufferChartHeight = 8
+	// bufferShownMarks caps the annotation list under the chart.
+	bufferShownMarks = 40
+	// bargeInUnderflowWindow is how soon after a barge-in an underflow counts
+	// as the interrupted response running dry rather than a tuning problem.
+	bargeInUnderflowWindow = 2 * time.Second
+	// defaultChunkMS is the engine's streaming.chunk_size_ms default.
+	defaultChunkMS = 20
+)
+
+// BufferSample is the jitter buffer fill level logged at one moment.
+type BufferSample struct {
+	Time  time.Time `json:"time"`
+	MS    float64   `json:"ms"` // audio queued, in milliseconds
+	Event string    `json:"event"`
+}
+
+// BufferMark is an annotation on the buffer timeline.
+type BufferMark struct {
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Count  int       `json:"count,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// BufferView is the jitter buffer history of one call, rebuilt from the
+// streaming playback log events.
+type BufferView struct {
+	SchemaVersion  int            `json:"schema_version"`
+	CallID         string         `json:"call_id"`
+	ChunkMS        int            `json:"chunk_ms"`
+	CapacityMS     float64        `json:"capacity_ms,omitempty"`
+	MinStartMS     float64        `json:"min_start_ms,omitempty"`
+	LowWatermarkMS float64        `json:"low_watermark_ms,omitempty"`
+	Streams        int            `json:"streams"`
+	Underflows     int            `json:"underflows"` // filler frames sent for lack of audio
+	Full           int            `json:"full"`       // samples at capacity; the provider was held back
+	Resets         int            `json:"resets"`
+	GateClosures   int            `json:"gate_closures"`
+	BargeIns       int            `json:"barge_ins"`
+	Samples        []BufferSample `json:"samples"`
+	Marks          []BufferMark   `json:"marks"`
+	Findings       []string       `json:"findings,omitempty"`
+}
+
+// buildBufferView extracts fill levels and events from the call's engine lines.
+// Thresholds come from the per-stream warm-up line, else the call header.
+func buildBufferView(callID string, header *RCAHeader, timeline []TimelineEntry) *BufferView {
+	v := &BufferView{SchemaVersion: contract.SchemaVersion, CallID: callID, ChunkMS: defaultChunkMS}
+	if header != nil {
+		v.CapacityMS = float64(header.StreamingJitterBufferMs)
+		v.MinStartMS = float64(header.StreamingMinStartMs)
+		v.LowWatermarkMS = float64(header.StreamingLowWatermarkMs)
+	}
+	frameBytes := 0
+	capacityChunks := 0
+	var lastBargeIn, lastTick time.Time
+
+	sample := func(t time.Time, chunks float64, event string) {
+		ms := chunks * float64(v.ChunkMS)
+		v.Samples = append(v.Samples, BufferSample{Time: t, MS: ms, Event: event})
+		if capacityChunks > 0 && chunks >= float64(capacityChunks) {
+			v.Full++
+			v.Marks = append(v.Marks, BufferMark{Time: t, Kind: markFull, Detail: fmt.Sprintf("%.0fms queued", ms)})
+		}
+	}
+	mark := func(t time.Time, kind, detail string) {
+		v.Marks = append(v.Marks, BufferMark{Time: t, Kind: kind, Detail: detail})
+	}
+
+	for _, e := range timeline {
+		if e.Source != SourceEngine {
+			continue
+		}
+		_, event, fields, ok := parseLogLine(e.Line)
+		if !ok || e.Time.IsZero() {
+			continue
+		}
+		t := e.Time
+		switch event {
+		case logschema.EventStreamFrameSize:
+			frameBytes = atoiSafe(fields["frame_size_bytes"])
+			if ms := atoiSafe(fields["chunk_ms"]); ms > 0 {
+				v.ChunkMS = ms
+			}
+		case logschema.EventAdaptiveWarmup:
+			if ms := atoiSafe(fields["chunk_ms"]); ms > 0 {
+				v.ChunkMS = ms
+			}
+			capacityChunks = atoiSafe(fields["jb_chunks"])
+			chunk := float64(v.ChunkMS)
+			v.CapacityMS = float64(capacityChunks) * chunk
+			v.MinStartMS = float64(atoiSafe(fields["min_start_chunks"])) * chunk
+			v.LowWatermarkMS = float64(atoiSafe(fields["low_watermark_chunks"])) * chunk
+		case logschema.EventStreamStarted:
+			v.Streams++
+			v.Samples = append(v.Samples, BufferSample{Time: t, MS: 0, Event: event})
+			mark(t, markStreamStart, fields["stream_id"])
+		case logschema.EventWarmupComplete:
+			sample(t, atofSafe(fields["buffered_chunks"]), event)
+		case logschema.EventWarmupSkipped:
+			sample(t, atofSafe(fields["jitter_depth"]), event)
+		case logschema.EventShortSegmentRelease:
+			sample(t, atofSafe(fields["buffered_frames"]), event)
+		case logschema.EventLowBufferTick:
+			// The pacer logs a tick every 20ms while empty; keep one per run.
+			if lastTick.IsZero() || t.Sub(lastTick) > 200*time.Millisecond {
+				v.Samples = append(v.Samples, BufferSample{Time: t, MS: 0, Event: event})
+			}
+			lastTick = t
+		case logschema.EventSegmentSummary:
+			if frameBytes > 0 && fields["buffered_bytes"] != "" {
+				sample(t, atofSafe(fields["buffered_bytes"])/float64(frameBytes), event)
+			}
+			n := atoiSafe(fields["underflow_events"])
+			if n == 0 {
+				continue
+			}
+			detail := fmt.Sprintf("%d filler frame(s) in %s", n, emptyTo(fields["stream_id"], "segment"))
+			if strings.Contains(fields["stream_id"], "greeting") {
+				detail += " (greeting)"
+			} else if !lastBargeIn.IsZero() && t.Sub(lastBargeIn) <= bargeInUnderflowWindow {
+				detail += " (after barge-in)"
+			}
+			v.Underflows += n
+			v.Marks = append(v.Marks, BufferMark{Time: t, Kind: markUnderflow, Count: n, Detail: detail})
+		case logschema.EventStreamStopped, logschema.EventStreamIdleCutoff, logschema.EventStreamTimeout:
+			v.Resets++
+			v.Samples = append(v.Samples, BufferSample{Time: t, MS: 0, Event: event})
+			mark(t, markReset, resetReason(event, fields))
+		case logschema.EventRemainderDiscarded:
+			v.Resets++
+			mark(t, markReset, fmt.Sprintf("barge-in discarded %s bytes", emptyTo(fields["discarded_bytes"], "?")))
+		case logschema.EventBargeInApplied:
+			v.BargeIns++
+			lastBargeIn = t
+			mark(t, markBargeIn, strings.TrimSpace(fields["source"]+" "+fields["reason"]))
+		case logschema.EventGateClosed, logschema.EventTTSGating:
+			v.GateClosures++
+			mark(t, markGateClosed, "")
+		case logschema.EventGateOpened, logschema.EventTTSGatingCleared:
+			mark(t, markGateOpened, fields["reason"])
+		}
+	}
+	v.Findings = bufferFindings(v)
+	return v
+}
+
+func resetReason(event string, fields map[string]string) string {
+	switch event {
+	case logschema.EventStreamIdleCutoff:
+		return "idle cutoff"
+	case logschema.EventStreamTimeout:
+		return "provider connection timeout"
+	}
+	return "stream stopped " + fields["stream_id"]
+}
+
+// bufferFindings turns the counts into tuning evidence.
+func bufferFindings(v *BufferView) []string {
+	var findings []string
+	midResponse, afterBargeIn := 0, 0
+	for _, m := range v.Marks {
+		if m.Kind != markUnderflow {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(m.Detail, "(after barge-in)"):
+			afterBargeIn += m.Count
+		case !strings.HasSuffix(m.Detail, "(greeting)"):
+			midResponse += m.Count
+		}
+	}
+	if midResponse > 0 {
+		findings = append(findings, fmt.Sprintf(
+			"%d filler frame(s) outside greetings and barge-ins: the buffer ran dry mid-response; raise streaming.min_start_ms (now %.0fms) or low_watermark_ms (now %.0fms) so playback keeps more audio queued",
+			midResponse, v.MinStartMS, v.LowWatermarkMS))
+	}
+	if afterBargeIn > 0 {
+		findings = append(findings, fmt.Sprintf("%d filler frame(s) within %s of a barge-in: the interrupted response, not a tuning problem", afterBargeIn, bargeInUnderflowWindow))
+	}
+	if v.Streams > 0 && v.Underflows == 0 && v.MinStartMS > 0 {
+		findings = append(findings, fmt.Sprintf("no underflows in %d stream(s): streaming.min_start_ms (%.0fms) has headroom and could be lowered to answer sooner", v.Streams, v.MinStartMS))
+	}
+	if v.Full > 0 {
+		findings = append(findings, fmt.Sprintf("buffer at capacity (%.0fms) %d time(s): the provider sends faster than real time and was held back; this costs no audio", v.CapacityMS, v.Full))
+	}
+	if v.GateClosures > 50 {
+		findings = append(findings, fmt.Sprintf("audio gate closed %d times: gate flutter lets echo through and can make the agent interrupt itself", v.GateClosures))
+	}
+	if len(v.Samples) == 0 {
+		findings = append(findings, "no buffer samples: the call did not use streaming playback, or the streaming lines are missing from the logs")
+	}
+	return findings
+}
+
+// renderBufferChart draws fill level over time with the min start and low
+// watermark thresholds, and one annotation row under the time axis.
+func renderBufferChart(v *BufferView, width, height int) []string {
+	var start, end time.Time
+	for _, s := range v.Samples {
+		start, end = widenSpan(start, end, s.Time)
+	}
+	for _, m := range v.Marks {
+		start, end = widenSpan(start, end, m.Time)
+	}
+	if start.IsZero() {
+		return nil
+	}
+	span := end.Sub(start)
+	col := func(t time.Time) int {
+		if span <= 0 {
+			return 0
+		}
+		return int(float64(t.Sub(start)) / float64(span) * float64(width-1))
+	}
+
+	top := math.Max(v.MinStartMS, v.LowWatermarkMS)
+	for _, s := range v.Samples {
+		top = math.Max(top, s.MS)
+	}
+	// The capacity line is drawn only when it does not flatten the samples.
+	if v.CapacityMS > 0 && v.CapacityMS <= 2*top {
+		top = math.Max(top, v.CapacityMS)
+	}
+	if top <= 0 {
+		top = float64(v.ChunkMS)
+	}
+	row := func(ms float64) int {
+		return int(math.Round(math.Min(ms, top) / top * float64(height-1)))
+	}
+
+	grid := make([][]byte, height)
+	labels := make([]string, height)
+	for i := range grid {
+		grid[i] = []byte(strings.Repeat(" ", width))
+	}
+	refs := []struct {
+		ms   float64
+		char byte
+		name string
+	}{
+		{v.CapacityMS, '=', "capacity"},
+		{v.MinStartMS, '.', "min start"},
+		{v.LowWatermarkMS, '-', "low watermark"},
+	}
+	var legend []string
+	for _, ref := range refs {
+		if ref.ms <= 0 || ref.ms > top {
+			continue
+		}
+		r := row(ref.ms)
+		for c := range grid[r] {
+			grid[r][c] = ref.char
+		}
+		labels[r] = fmt.Sprintf("%.0f", ref.ms)
+		legend = append(legend, fmt.Sprintf("%c %s %.0fms", ref.char, ref.name, ref.ms))
+	}
+	for _, s := range v.Samples {
+		grid[row(s.MS)][col(s.Time)] = '*'
+	}
+	labels[height-1] = fmt.Sprintf("%.0f", top)
+	labels[0] = "0"
+
+	annot := []byte(strings.Repeat(" ", width))
+	rank := map[string]int{}
+	for i, m := range markSymbols {
+		rank[m.kind] = len(markSymbols) - i
+	}
+	best := make([]int, width)
+	for _, m := range v.Marks {
+		c := col(m.Time)
+		if rank[m.Kind] > best[c] {
+			best[c] = rank[m.Kind]
+			for _, ms := range markSymbols {
+				if ms.kind == m.Kind {
+					annot[c] = ms.symbol[0]
+				}
+			}
+		}
+	}
+
+	lines := []string{"  ms"}
+	for r := height - 1; r >= 0; r-- {
+		lines = append(lines, fmt.Sprintf("%6s |%s", labels[r], strings.TrimRight(string(grid[r]), " ")))
+	}
+	lines = append(lines, "       +"+strings.Repeat("-", width))
+	lines = append(lines, "        "+strings.TrimRight(string(annot), " "))
+	from, to := start.Format("15:04:05"), end.Format("15:04:05")
+	pad := width - len(from) - len(to)
+	if pad < 1 {
+		pad = 1
+	}
+	lines = append(lines, "        "+from+strings.Repeat(" ", pad)+to)
+	lines = append(lines, "        * sample   "+strings.Join(legend, "   "))
+	lines = append(lines, "        S stream start  R reset  U underflow  F full  G gate closed  g gate opened  B barge-in")
+	return lines
+}
+
+func widenSpan(start, end, t time.Time) (time.Time, time.Time) {
+	if start.IsZero() || t.Before(start) {
+		start = t
+	}
+	if t.After(end) {
+		end = t
+	}
+	return start, end
+}
+
+// runBufferView prints the jitter buffer view in place of the RCA report.
+func (r *Runner) runBufferView(header *RCAHeader) error {
+	v := buildBufferView(r.callID, header, mergeTimeline(strings.Split(r.logData, "\n")))
+	if r.jsonOutput {
+		f := r.format
+		if !f.Structured() {
+			f = output.JSON
+		}
+		return output.Write(os.Stdout, f, v)
+	}
+	if r.quiet {
+		for _, f := range v.Findings {
+			fmt.Printf("buffer: %s\n", f)
+		}
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("🔍 Jitter Buffer")
+	fmt.Println("═══════════════════════════════════════════")
+	fmt.Printf("Call: %s\n", v.CallID)
+	fmt.Printf("Streams: %d   Underflows: %d   At capacity: %d   Resets: %d   Gate closures: %d   Barge-ins: %d\n",
+		v.Streams, v.Underflows, v.Full, v.Resets, v.GateClosures, v.BargeIns)
+	fmt.Println()
+	if lines := renderBufferChart(v, bufferChartWidth, bufferChartHeight); len(lines) > 0 {
+		for _, l := range lines {
+			fmt.Println(l)
+		}
+		fmt.Println()
+	}
+	if len(v.Marks) > 0 {
+		fmt.Println("Events:")
+		for i, m := range v.Marks {
+			if i == bufferShownMarks {
+				fmt.Printf("  ... and %d more\n", len(v.Marks)-i)
+				break
+			}
+			fmt.Printf("  %s  %-12s %s\n", m.Time.Format("15:04:05.000"), m.Kind, m.Detail)
+		}
+		fmt.Println()
+	}
+	if len(v.Findings) > 0 {
+		fmt.Println("Findings:")
+		for _, f := range v.Findings {
+			fmt.Printf("  • %s\n", f)
+		}
+		fmt.Println()
+	}
+	infoColor.Println("Fill levels are logged only at stream start, warm-up, segment ends and while the pacer waits on an empty buffer; the chart shows those samples, not a continuous trace.")
+	return nil
+}
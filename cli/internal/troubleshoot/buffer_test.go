@@ -0,0 +1,74 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildBufferView(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🎼 STREAM FRAME SIZE","call_id":"1.1","frame_size_bytes":320,"chunk_ms":20}`,
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🎚️ STREAMING ADAPTIVE WARM-UP","call_id":"1.1","chunk_ms":20,"jb_chunks":25,"min_start_chunks":15,"low_watermark_chunks":10}`,
+		`{"timestamp":"2026-01-30T17:21:40.010Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"stream:greeting:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.300Z","level":"debug","event":"Streaming jitter buffer warm-up complete","call_id":"1.1","buffered_chunks":15}`,
+		`{"timestamp":"2026-01-30T17:21:43.000Z","level":"info","event":"Streaming segment bytes summary v2","call_id":"1.1","stream_id":"stream:greeting:1.1","underflow_events":2,"buffered_bytes":0}`,
+		`{"timestamp":"2026-01-30T17:21:43.100Z","level":"info","event":"🎵 STREAMING PLAYBACK - Stopped","call_id":"1.1","stream_id":"stream:greeting:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:50.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"stream:response:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:50.000Z","level":"info","event":"🚪 AUDIO GATE CLOSED - Agent started speaking","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:50.400Z","level":"debug","event":"Streaming jitter buffer warm-up complete","call_id":"1.1","buffered_chunks":25}`,
+		`{"timestamp":"2026-01-30T17:21:52.000Z","level":"debug","event":"Low-buffer backoff tick","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:52.020Z","level":"debug","event":"Low-buffer backoff tick","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:54.000Z","level":"info","event":"Streaming segment bytes summary v2","call_id":"1.1","stream_id":"stream:response:1.1","underflow_events":3,"buffered_bytes":1600}`,
+		`{"timestamp":"2026-01-30T17:21:55.000Z","level":"info","event":"🎧 BARGE-IN action applied","call_id":"1.1","source":"local_vad"}`,
+		`{"timestamp":"2026-01-30T17:21:55.010Z","level":"info","event":"Skipped remainder flush (barge-in)","call_id":"1.1","discarded_bytes":960}`,
+		`{"timestamp":"2026-01-30T17:21:56.000Z","level":"info","event":"Streaming segment bytes summary v2","call_id":"1.1","stream_id":"stream:response:1.1","underflow_events":1}`,
+		`{"timestamp":"2026-01-30T17:21:56.100Z","level":"info","event":"🔓 AUDIO GATE OPENED - Agent finished speaking","call_id":"1.1"}`,
+	}
+	v := buildBufferView("1.1", &RCAHeader{StreamingJitterBufferMs: 100}, mergeTimeline(lines))
+
+	if v.CapacityMS != 500 || v.MinStartMS != 300 || v.LowWatermarkMS != 200 {
+		t.Fatalf("thresholds = %v/%v/%v", v.CapacityMS, v.MinStartMS, v.LowWatermarkMS)
+	}
+	if v.Streams != 2 || v.Underflows != 6 || v.Full != 1 || v.Resets != 2 || v.GateClosures != 1 || v.BargeIns != 1 {
+		t.Fatalf("counts = %+v", v)
+	}
+	var ticks int
+	var summaryMS float64
+	for _, s := range v.Samples {
+		if strings.Contains(s.Event, "backoff tick") {
+			ticks++
+		}
+		if s.Time.Second() == 54 {
+			summaryMS = s.MS
+		}
+	}
+	if ticks != 1 || summaryMS != 100 {
+		t.Fatalf("ticks = %d, buffered at summary = %vms", ticks, summaryMS)
+	}
+
+	findings := strings.Join(v.Findings, "\n")
+	for _, want := range []string{"3 filler frame(s) outside greetings", "1 filler frame(s) within 2s of a barge-in", "at capacity (500ms) 1 time(s)"} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("findings missing %q:\n%s", want, findings)
+		}
+	}
+
+	chart := strings.Join(renderBufferChart(v, 60, 8), "\n")
+	for _, want := range []string{"=", "*", "B", "U", "17:21:40", "17:21:56", ". min start 300ms"} {
+		if !strings.Contains(chart, want) {
+			t.Errorf("chart missing %q:\n%s", want, chart)
+		}
+	}
+}
+
+func TestBufferViewWithoutStreaming(t *testing.T) {
+	v := buildBufferView("1.1", nil, mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"Incoming call","call_id":"1.1"}`,
+	}))
+	if len(v.Samples) != 0 || len(v.Findings) != 1 || !strings.Contains(v.Findings[0], "no buffer samples") {
+		t.Fatalf("view = %+v", v)
+	}
+	if renderBufferChart(v, 60, 8) != nil {
+		t.Fatal("chart drawn without samples")
+	}
+}
@@ -1,10 +1,13 @@
 package troubleshoot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
 )
 
 // CallHistorySummary is the canonical persisted result for a call. RCA remains
@@ -59,8 +62,7 @@ if "codec_alignment_ok" in out:
     out["codec_alignment_ok"] = bool(out["codec_alignment_ok"])
 print(json.dumps(out, separators=(",", ":")))
 `
-	cmd := exec.Command("docker", "exec", "ai_engine", "python3", "-c", script, callID)
-	out, err := cmd.CombinedOutput()
+	out, err := dockerapi.ExecCombinedOutput(context.Background(), deployment.EngineContainer(), "python3", "-c", script, callID)
 	if err != nil {
 		return nil, fmt.Errorf("call history query failed: %w (%s)", err, strings.TrimSpace(string(out)))
 	}
@@ -0,0 +1,196 @@
+package troubleshoot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+var (
+	// Field values are quoted in JSON ("caller_number": "+1555...") and may be bare in console kv output.
+	callerNumberPattern = regexp.MustCompile(`caller_number['"]?\s*[=:]\s*(?:['"]([^'"]*)['"]|([^\s,}]+))`)
+	callerNamePattern   = regexp.MustCompile(`caller_name['"]?\s*[=:]\s*(?:['"]([^'"]*)['"]|([^\s,}]+))`)
+	hangupCausePattern  = regexp.MustCompile(`(?:cause_txt|hangup_cause)['"]?\s*[=:]\s*(?:['"]([^'"]*)['"]|([^\s,}]+))`)
+
+	// The raw StasisStart event (event_data=...) is keyed by channel "id" rather than
+	// call_id; its dialplan block carries the dialed extension.
+	stasisEventChannelPattern = regexp.MustCompile(`['"]id['"]:\s*['"]([0-9]+\.[0-9]+)['"]`)
+	stasisEventExtenPattern   = regexp.MustCompile(`['"]exten['"]:\s*['"]([^'"]+)['"]`)
+	stasisEventNumberPattern  = regexp.MustCompile(`['"]caller['"]:\s*\{[^}]*?['"]number['"]:\s*['"]([^'"]*)['"]`)
+
+	callAnsweredMarkers = []string{"Caller channel answered", "Skipping answer (outbound)", "Outbound answered"}
+)
+
+// stasisEventMeta is what the raw StasisStart event tells us before the caller line appears.
+type stasisEventMeta struct {
+	dialed, callerNumber string
+}
+
+// observeStasisEvent records dialed extension and caller number from a raw StasisStart line.
+func observeStasisEvent(line string, pending map[string]stasisEventMeta) {
+	if !strings.Contains(line, "StasisStart event received") {
+		return
+	}
+	m := stasisEventChannelPattern.FindStringSubmatch(line)
+	if len(m) < 2 {
+		return
+	}
+	meta := stasisEventMeta{}
+	if x := stasisEventExtenPattern.FindStringSubmatch(line); len(x) > 1 {
+		meta.dialed = x[1]
+	}
+	if x := stasisEventNumberPattern.FindStringSubmatch(line); len(x) > 1 {
+		meta.callerNumber = x[1]
+	}
+	pending[m[1]] = meta
+}
+
+// observeCallMetadata fills caller and outcome fields from a line attributed to e.
+func observeCallMetadata(e *CallIndexEntry, line string) {
+	if e.CallerNumber == "" {
+		e.CallerNumber = firstNonEmpty(callerNumberPattern.FindStringSubmatch(line), 1, 2)
+	}
+	if e.CallerName == "" {
+		if name := firstNonEmpty(callerNamePattern.FindStringSubmatch(line), 1, 2); name != "None" {
+			e.CallerName = name
+		}
+	}
+	if cause := firstNonEmpty(hangupCausePattern.FindStringSubmatch(line), 1, 2); cause != "" {
+		e.HangupCause = cause
+	}
+	if containsAny(line, callAnsweredMarkers) {
+		answered := true
+		e.Answered = &answered
+	}
+}
+
+// applyStasisMeta copies raw StasisStart details onto entries that lack them.
+func applyStasisMeta(idx *callIndex, pending map[string]stasisEventMeta) {
+	for id, meta := range pending {
+		e := idx.Calls[id]
+		if e == nil {
+			continue
+		}
+		if e.Dialed == "" {
+			e.Dialed = meta.dialed
+		}
+		if e.CallerNumber == "" {
+			e.CallerNumber = meta.callerNumber
+		}
+	}
+}
+
+// callHistoryMeta is the caller/outcome subset of call_records.
+type callHistoryMeta struct {
+	CallerNumber string `json:"caller_number,omitempty"`
+	CallerName   string `json:"caller_name,omitempty"`
+	CalledNumber string `json:"called_number,omitempty"`
+	Outcome      string `json:"outcome,omitempty"`
+}
+
+// loadCallHistoryMeta fetches caller metadata for many calls with one docker exec
+// (same best-effort contract as loadCallHistorySummary).
+func loadCallHistoryMeta(callIDs []string) (map[string]callHistoryMeta, error) {
+	const script = `
+import json, os, sqlite3, sys
+p = os.environ.get("CALL_HISTORY_DB_PATH", "/app/data/call_history.db")
+c = sqlite3.connect(p)
+c.row_factory = sqlite3.Row
+cols = {r[1] for r in c.execute("PRAGMA table_info(call_records)")}
+if "call_id" not in cols:
+    print("{}")
+    raise SystemExit(0)
+keys = [k for k in ("caller_number", "caller_name", "called_number", "outcome") if k in cols]
+ids = sys.argv[1:]
+out = {}
+if keys and ids:
+    q = "SELECT call_id, " + ", ".join(keys) + " FROM call_records WHERE call_id IN (" + ",".join("?" * len(ids)) + ")"
+    for r in c.execute(q, ids):
+        out[r["call_id"]] = {k: r[k] for k in keys if r[k] is not None}
+print(json.dumps(out, separators=(",", ":")))
+`
+	argv := append([]string{"python3", "-c", script}, callIDs...)
+	out, err := dockerapi.ExecCombinedOutput(context.Background(), deployment.EngineContainer(), argv...)
+	if err != nil {
+		return nil, fmt.Errorf("call history query failed: %w (%s)", err, strings.TrimSpace(string(out)))
+	}
+	meta := map[string]callHistoryMeta{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(out))), &meta); err != nil {
+		return nil, fmt.Errorf("invalid call history response: %w", err)
+	}
+	return meta, nil
+}
+
+// enrichFromCallHistory fills caller metadata for finished calls not yet looked up and
+// reports whether the index changed. Call History is authoritative for caller id,
+// dialed number and outcome.
+func enrichFromCallHistory(idx *callIndex, calls []Call) bool {
+	var ids []string
+	for _, c := range calls {
+		if e := idx.Calls[c.ID]; e != nil && e.EndedAt != nil && !e.HistoryChecked {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return false
+	}
+	meta, err := loadCallHistoryMeta(ids)
+	if err != nil {
+		return false
+	}
+	for _, id := range ids {
+		e := idx.Calls[id]
+		e.HistoryChecked = true
+		m, ok := meta[id]
+		if !ok {
+			continue
+		}
+		if m.CallerNumber != "" {
+			e.CallerNumber = m.CallerNumber
+		}
+		if m.CallerName != "" {
+			e.CallerName = m.CallerName
+		}
+		if m.CalledNumber != "" {
+			e.Dialed = m.CalledNumber
+		}
+		if m.Outcome != "" {
+			e.Outcome = m.Outcome
+		}
+	}
+	return true
+}
+
+// describe renders caller, dialed number and outcome for call listings.
+func (c Call) describe() string {
+	var parts []string
+	if c.CallerNumber != "" || c.CallerName != "" {
+		from := c.CallerNumber
+		if c.CallerName != "" && c.CallerName != c.CallerNumber {
+			from = strings.TrimSpace(fmt.Sprintf("%s %q", c.CallerNumber, c.CallerName))
+		}
+		parts = append(parts, "from "+from)
+	}
+	if c.Dialed != "" {
+		parts = append(parts, "to "+c.Dialed)
+	}
+	if c.Answered == nil && c.Outcome == "" && c.Disposition == "" {
+		parts = append(parts, "not answered by agent")
+	}
+	switch {
+	case c.Outcome != "":
+		parts = append(parts, c.Outcome)
+	case c.Disposition != "" && c.HangupCause != "":
+		parts = append(parts, c.Disposition+" ("+c.HangupCause+")")
+	case c.Disposition != "":
+		parts = append(parts, c.Disposition)
+	case c.HangupCause != "":
+		parts = append(parts, "hangup: "+c.HangupCause)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,148 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// callSelectWindow is how far from a requested time a call may start and still match.
+const callSelectWindow = 15 * time.Minute
+
+var (
+	phoneSelectorPattern = regexp.MustCompile(`^\+?[0-9 ().-]{3,}$`)
+	clockSelectorPattern = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2}))?$`)
+)
+
+// isChannelID reports whether s already is an Asterisk channel id (1761518880.2191).
+func isChannelID(s string) bool {
+	return channelIDPattern.MatchString(s)
+}
+
+// resolveCallSelector maps a phone number ("+15551234567") or time ("today 14:05",
+// "yesterday 9:30", "14:05", "2026-01-30 14:05") to an indexed call id.
+func resolveCallSelector(sel string, calls []Call, now time.Time) (string, error) {
+	sel = strings.TrimSpace(sel)
+	if t, ok := parseSelectorTime(sel, now); ok {
+		return callAtTime(calls, t)
+	}
+	if phoneSelectorPattern.MatchString(sel) {
+		return callForNumber(calls, sel)
+	}
+	return "", fmt.Errorf("%q is not a call id, phone number, or time (e.g. \"today 14:05\")", sel)
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// callForNumber returns the newest call whose caller or dialed number matches.
+func callForNumber(calls []Call, number string) (string, error) {
+	hits, err := callsForNumber(calls, number)
+	if err != nil {
+		return "", err
+	}
+	if len(hits) > 1 {
+		fmt.Fprintf(os.Stderr, "%d calls match %s; using the most recent (%s)\n", len(hits), number, hits[0].ID)
+	}
+	return hits[0].ID, nil
+}
+
+// callsForNumber returns the calls, in the given order, whose caller or dialed
+// number matches. Numbers match on the trailing digits so national and E.164
+// forms of the same number agree.
+func callsForNumber(calls []Call, number string) ([]Call, error) {
+	want := digitsOnly(number)
+	if len(want) < 3 {
+		return nil, fmt.Errorf("phone number %q is too short", number)
+	}
+	matches := func(n string) bool {
+		got := digitsOnly(n)
+		if got == "" {
+			return false
+		}
+		if len(got) > len(want) {
+			return strings.HasSuffix(got, want)
+		}
+		return strings.HasSuffix(want, got) && len(got) >= 7
+	}
+	var hits []Call
+	for _, c := range calls {
+		if matches(c.CallerNumber) || matches(c.Dialed) {
+			hits = append(hits, c)
+		}
+	}
+	if len(hits) == 0 {
+		return nil, fmt.Errorf("no indexed call from or to %s (see: agent rca --list)", number)
+	}
+	return hits, nil
+}
+
+// callAtTime prefers a call in progress at t, otherwise the call starting nearest to t.
+func callAtTime(calls []Call, t time.Time) (string, error) {
+	best, bestGap := "", callSelectWindow+time.Nanosecond
+	for _, c := range calls {
+		start := c.Timestamp
+		if c.end != nil && !t.Before(start) && !t.After(*c.end) {
+			return c.ID, nil
+		}
+		gap := start.Sub(t)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap < bestGap {
+			best, bestGap = c.ID, gap
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no indexed call within %s of %s (see: agent rca --list)", callSelectWindow, t.Format("Jan 02 15:04"))
+	}
+	return best, nil
+}
+
+// parseSelectorTime understands "[today|yesterday] HH:MM[:SS]" and "YYYY-MM-DD HH:MM[:SS]"
+// in local time.
+func parseSelectorTime(sel string, now time.Time) (time.Time, bool) {
+	now = now.Local()
+	fields := strings.Fields(strings.ToLower(sel))
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch len(fields) {
+	case 1:
+	case 2:
+		switch fields[0] {
+		case "today":
+		case "yesterday":
+			day = day.AddDate(0, 0, -1)
+		default:
+			d, err := time.ParseInLocation("2006-01-02", fields[0], time.Local)
+			if err != nil {
+				return time.Time{}, false
+			}
+			day = d
+		}
+		fields = fields[1:]
+	default:
+		return time.Time{}, false
+	}
+	m := clockSelectorPattern.FindStringSubmatch(fields[0])
+	if m == nil {
+		return time.Time{}, false
+	}
+	var h, min, sec int
+	fmt.Sscanf(m[1], "%d", &h)
+	fmt.Sscanf(m[2], "%d", &min)
+	if m[3] != "" {
+		fmt.Sscanf(m[3], "%d", &sec)
+	}
+	if h > 23 || min > 59 || sec > 59 {
+		return time.Time{}, false
+	}
+	return day.Add(time.Duration(h)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second), true
+}
@@ -0,0 +1,41 @@
+package troubleshoot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveCallSelector(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 30, 18, 0, 0, 0, time.Local)
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 30, h, m, 0, 0, time.Local) }
+	end := at(14, 9)
+	calls := []Call{ // newest first, as recentCalls returns them
+		{ID: "1769818900.1500", Timestamp: at(16, 30), CallerNumber: "6001"},
+		{ID: "1769818882.1484", Timestamp: at(14, 2), CallerNumber: "+15551234567", Dialed: "8005550100", end: &end},
+		{ID: "1769800000.1000", Timestamp: at(9, 0), CallerNumber: "5551234567"},
+	}
+
+	cases := map[string]string{
+		"+1 (555) 123-4567": "1769818882.1484", // newest of two matches
+		"800-555-0100":      "1769818882.1484", // dialed number
+		"today 14:05":       "1769818882.1484", // in progress
+		"16:40":             "1769818900.1500", // nearest start
+		"2026-01-30 09:10":  "1769800000.1000",
+	}
+	for sel, want := range cases {
+		got, err := resolveCallSelector(sel, calls, now)
+		if err != nil || got != want {
+			t.Errorf("%q: got %q err=%v, want %q", sel, got, err, want)
+		}
+	}
+	for _, sel := range []string{"yesterday 14:05", "+4420", "hello"} {
+		if got, err := resolveCallSelector(sel, calls, now); err == nil {
+			t.Errorf("%q: expected error, got %q", sel, got)
+		}
+	}
+	if !isChannelID("1769818882.1484") || isChannelID("+15551234567") {
+		t.Fatalf("isChannelID misclassifies")
+	}
+}
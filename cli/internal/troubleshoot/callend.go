@@ -0,0 +1,366 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+// Transfer outcomes.
+const (
+	transferRequested  = "requested"
+	transferInitiated  = "initiated"
+	transferOriginated = "originated"
+	transferFailed     = "failed"
+	transferDeclined   = "declined"
+	transferCompleted  = "completed"
+)
+
+// Likely culprits for how a call ended.
+const (
+	culpritCaller      = "caller"
+	culpritAgent       = "agent"
+	culpritTransfer    = "transfer"
+	culpritDestination = "destination"
+	culpritTrunk       = "trunk"
+	culpritRouting     = "routing"
+	culpritSIPTimer    = "sip_timer"
+	culpritRTPTimeout  = "rtp_timeout"
+	culpritEngine      = "engine"
+	culpritUnknown     = "unknown"
+)
+
+var (
+	// app_dial verbose output, e.g. "-- PJSIP/2000-00000003 is busy".
+	asteriskDialStatePattern    = regexp.MustCompile(`-- (\S+) is (busy|circuit-busy)\b`)
+	asteriskDialAnsweredPattern = regexp.MustCompile(`-- (\S+) answered \S+`)
+	asteriskNoAnswerPattern     = regexp.MustCompile(`Nobody picked up in \d+ ms`)
+	asteriskCongestedPattern    = regexp.MustCompile(`Everyone is busy/congested at this time`)
+	// res_pjsip_sdp_rtp: Disconnecting channel 'PJSIP/trunk-00000001' for lack of RTP activity in 30 seconds
+	asteriskRTPTimeoutPattern = regexp.MustCompile(`Disconnecting channel '([^']+)' for lack of (\w+) activity in (\d+) seconds`)
+	// SIP Reason header in pjsip logger output: Reason: Q.850;cause=34;text="..."
+	asteriskQ850Pattern = regexp.MustCompile(`Q\.850\s*;\s*cause=(\d+)`)
+)
+
+// TransferAttempt is one transfer the agent tried.
+type TransferAttempt struct {
+	Time        time.Time `json:"time"`
+	Destination string    `json:"destination,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	Target      string    `json:"target,omitempty"`
+	Outcome     string    `json:"outcome"`
+	DialStatus  string    `json:"dial_status,omitempty"` // DIALSTATUS of a failed transfer
+}
+
+// DialOutcome is how a leg dialed by Asterisk (a transfer target) ended.
+type DialOutcome struct {
+	Time    time.Time `json:"time"`
+	Channel string    `json:"channel,omitempty"`
+	Outcome string    `json:"outcome"` // answered, busy, circuit-busy, no-answer or congested
+}
+
+// CallEnding is how the call ended: transfers, dial outcomes, the Q.850
+// hangup cause and the likely culprit. The cause comes from CEL, then the
+// engine's ARI ChannelDestroyed line, then a SIP Reason header.
+type CallEnding struct {
+	Outcome         string            `json:"outcome,omitempty"` // RCA_CALL_END call_outcome
+	HangupCause     int               `json:"hangup_cause,omitempty"`
+	HangupCauseText string            `json:"hangup_cause_text,omitempty"`
+	CauseSource     string            `json:"cause_source,omitempty"` // cel, ari or sip
+	HangupSource    string            `json:"hangup_source,omitempty"`
+	AgentHangup     bool              `json:"agent_hangup,omitempty"`
+	RTPTimeout      string            `json:"rtp_timeout,omitempty"`
+	Teardown        bool              `json:"teardown"` // the engine logged the call's cleanup
+	Tracebacks      int               `json:"tracebacks,omitempty"`
+	Transfers       []TransferAttempt `json:"transfers,omitempty"`
+	Dials           []DialOutcome     `json:"dials,omitempty"`
+	InProgress      bool              `json:"in_progress,omitempty"`
+	Culprit         string            `json:"culprit,omitempty"`
+	Explanation     string            `json:"explanation,omitempty"`
+}
+
+// extractCallEnding reads the call's transfers, dial outcomes and hangup
+// cause from the timeline and CDR, and names the likely culprit.
+func extractCallEnding(callID string, timeline []TimelineEntry, rec *cdr.Record, inProgress bool) *CallEnding {
+	e := &CallEnding{InProgress: inProgress}
+	ariCause, sipCause := 0, 0
+	lastTransfer := func() *TransferAttempt {
+		if n := len(e.Transfers); n > 0 {
+			return &e.Transfers[n-1]
+		}
+		return nil
+	}
+	settle := func(t time.Time, outcome string) *TransferAttempt {
+		last := lastTransfer()
+		if last == nil || (last.Outcome != transferRequested && last.Outcome != transferInitiated && last.Outcome != transferOriginated) {
+			e.Transfers = append(e.Transfers, TransferAttempt{Time: t})
+			last = lastTransfer()
+		}
+		last.Outcome = outcome
+		return last
+	}
+
+	for _, entry := range timeline {
+		t, line := entry.Time, entry.Line
+		switch entry.Source {
+		case SourceAsterisk:
+			if m := asteriskRTPTimeoutPattern.FindStringSubmatch(line); m != nil {
+				e.RTPTimeout = fmt.Sprintf("%s: no %s for %ss", m[1], m[2], m[3])
+			}
+			if m := asteriskQ850Pattern.FindStringSubmatch(line); m != nil {
+				sipCause = atoiSafe(m[1])
+			}
+			switch {
+			case asteriskDialStatePattern.MatchString(line):
+				m := asteriskDialStatePattern.FindStringSubmatch(line)
+				e.Dials = append(e.Dials, DialOutcome{Time: t, Channel: m[1], Outcome: m[2]})
+			case asteriskDialAnsweredPattern.MatchString(line):
+				m := asteriskDialAnsweredPattern.FindStringSubmatch(line)
+				e.Dials = append(e.Dials, DialOutcome{Time: t, Channel: m[1], Outcome: "answered"})
+			case asteriskNoAnswerPattern.MatchString(line):
+				e.Dials = append(e.Dials, DialOutcome{Time: t, Outcome: "no-answer"})
+			case asteriskCongestedPattern.MatchString(line):
+				e.Dials = append(e.Dials, DialOutcome{Time: t, Outcome: "congested"})
+			}
+		case SourceEngine:
+			if strings.Contains(line, "Traceback (most recent call last)") {
+				e.Tracebacks++
+				continue
+			}
+			_, event, fields, ok := parseLogLine(line)
+			if !ok {
+				continue
+			}
+			switch event {
+			case logschema.EventTransferRequested:
+				e.Transfers = append(e.Transfers, TransferAttempt{Time: t, Destination: fields["destination"],
+					Type: fields["type"], Target: fields["target"], Outcome: transferRequested})
+			case logschema.EventTransferInvalid:
+				e.Transfers = append(e.Transfers, TransferAttempt{Time: t, Destination: fields["destination"], Outcome: transferFailed})
+			case logschema.EventExtensionTransfer, logschema.EventQueueTransfer, logschema.EventRingGroupTransfer:
+				settle(t, transferInitiated)
+			case logschema.EventAttendedTransferLeg:
+				tr := settle(t, transferOriginated)
+				tr.Type = emptyTo(tr.Type, "attended")
+				tr.Destination = emptyTo(tr.Destination, fields["destination_key"])
+			case logschema.EventTransferFailed:
+				tr := settle(t, transferFailed)
+				tr.Target = emptyTo(tr.Target, fields["target"])
+				tr.DialStatus = fields["status"]
+			case logschema.EventAttendedTransferDeclined:
+				settle(t, transferDeclined)
+			case logschema.EventAttendedTransferComplete:
+				settle(t, transferCompleted)
+			case logschema.EventHangupRequested:
+				e.AgentHangup = true
+			case logschema.EventChannelDestroyed:
+				if fields["channel_id"] == callID {
+					e.Teardown = true
+					ariCause = atoiSafe(fields["cause"])
+				}
+			case logschema.EventCallCleanup:
+				e.Teardown = true
+			case logschema.EventCallEnd:
+				e.Teardown = true
+				e.Outcome = fields["call_outcome"]
+			}
+		}
+	}
+
+	switch {
+	case rec != nil && rec.HangupCause > 0:
+		e.HangupCause, e.CauseSource = rec.HangupCause, "cel"
+	case ariCause > 0:
+		e.HangupCause, e.CauseSource = ariCause, "ari"
+	case sipCause > 0:
+		e.HangupCause, e.CauseSource = sipCause, "sip"
+	}
+	e.HangupCauseText = cdr.CauseText(e.HangupCause)
+	if rec != nil {
+		e.HangupSource = rec.HangupSource
+	}
+	if !inProgress {
+		e.Culprit, e.Explanation = callEndingCulprit(e)
+	}
+	return e
+}
+
+// callEndingCulprit maps the evidence to what most likely ended the call.
+func callEndingCulprit(e *CallEnding) (string, string) {
+	dialed := func(outcomes ...string) bool {
+		for _, d := range e.Dials {
+			for _, o := range outcomes {
+				if d.Outcome == o {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	cause := fmt.Sprintf("%s (Q.850 %d)", e.HangupCauseText, e.HangupCause)
+
+	switch {
+	case e.RTPTimeout != "":
+		return culpritRTPTimeout, "Asterisk hung up because media stopped arriving (" + e.RTPTimeout + "); NAT, a firewall or a phone that stopped sending RTP"
+	case !e.Teardown && e.Tracebacks > 0:
+		return culpritEngine, fmt.Sprintf("ai_engine logged %d traceback(s) and no teardown for the call: the engine crashed or restarted mid-call", e.Tracebacks)
+	case !e.Teardown:
+		return culpritUnknown, "ai_engine logged no teardown for the call (Channel destroyed, Call cleanup completed or RCA_CALL_END): it is still in progress, the engine restarted mid-call, or its logs were cut"
+	}
+	switch e.HangupCause {
+	case 34, 38, 41, 42, 44, 47:
+		return culpritTrunk, cause + ": the trunk or carrier had no capacity; check its concurrent call limit and the account's channel limit"
+	case 1, 3, 22, 27, 28:
+		return culpritRouting, cause + ": the dialed number or route is invalid"
+	case 102:
+		return culpritSIPTimer, cause + ": a SIP timer expired, usually a session refresh or re-INVITE that got no answer (NAT keepalive, session timers)"
+	case 17, 18, 19, 21:
+		return culpritDestination, cause + ": the dialed party was busy, did not answer or rejected the call"
+	}
+	if dialed("circuit-busy", "congested") {
+		return culpritTrunk, "A transfer leg found no circuit; the trunk or carrier had no capacity"
+	}
+	switch {
+	case e.Outcome == "transferred" || lastTransferOutcome(e) == transferCompleted || lastTransferOutcome(e) == transferInitiated:
+		return culpritTransfer, "The call left the agent through a transfer"
+	case e.AgentHangup || e.Outcome == "agent_hangup":
+		return culpritAgent, "The agent ended the call with the hangup tool"
+	case e.HangupCause == 0 || e.HangupCause == 16 || e.HangupCause == 31:
+		if e.Outcome != "" && e.Outcome != "caller_hangup" {
+			return culpritAgent, "The engine ended the call (" + e.Outcome + ")"
+		}
+		return culpritCaller, "The caller hung up"
+	}
+	return culpritUnknown, cause
+}
+
+func lastTransferOutcome(e *CallEnding) string {
+	if n := len(e.Transfers); n > 0 {
+		return e.Transfers[n-1].Outcome
+	}
+	return ""
+}
+
+// callEndingWarnings reports endings that are not the caller's or agent's choice.
+func callEndingWarnings(e *CallEnding) []string {
+	if e == nil {
+		return nil
+	}
+	switch e.Culprit {
+	case culpritRTPTimeout, culpritEngine, culpritTrunk, culpritSIPTimer:
+		return []string{"Call ended abnormally: " + e.Explanation}
+	}
+	return nil
+}
+
+// analyzeCallDrop explains a call that dropped mid-conversation from its
+// hangup cause, transfers and teardown.
+func (sc *SymptomChecker) analyzeCallDrop(analysis *Analysis) {
+	sa := &SymptomAnalysis{
+		Symptom:     "call-drop",
+		Description: "Call drops mid-conversation",
+		Findings:    []string{},
+		RootCauses:  []string{},
+		Actions:     []string{},
+	}
+	analysis.SymptomAnalysis = sa
+	e := analysis.Ending
+	if e == nil {
+		sa.Findings = append(sa.Findings, "❌ No call ending evidence in the logs")
+		return
+	}
+	if e.InProgress {
+		sa.Findings = append(sa.Findings, "ℹ️  The call is still in progress")
+		return
+	}
+	if e.HangupCause > 0 {
+		sa.Findings = append(sa.Findings, fmt.Sprintf("ℹ️  Hangup cause: %s (Q.850 %d, from %s)", e.HangupCauseText, e.HangupCause, e.CauseSource))
+	} else {
+		sa.Findings = append(sa.Findings, "ℹ️  No hangup cause recorded (configure CDR/CEL, or update ai_engine to log the ARI cause)")
+	}
+	for _, tr := range e.Transfers {
+		sa.Findings = append(sa.Findings, "ℹ️  "+describeTransfer(tr))
+	}
+	for _, d := range e.Dials {
+		sa.Findings = append(sa.Findings, fmt.Sprintf("ℹ️  Dial: %s %s", emptyTo(d.Channel, "all legs"), d.Outcome))
+	}
+
+	switch e.Culprit {
+	case culpritCaller, culpritAgent, culpritTransfer:
+		sa.Findings = append(sa.Findings, "✅ "+e.Explanation)
+		if e.Culprit == culpritAgent {
+			sa.Actions = append(sa.Actions, "Review the hangup tool policy and the prompt's instructions for ending calls")
+		}
+		return
+	}
+	sa.Findings = append(sa.Findings, "❌ "+e.Explanation)
+	sa.RootCauses = append(sa.RootCauses, e.Explanation)
+	switch e.Culprit {
+	case culpritRTPTimeout:
+		sa.Actions = append(sa.Actions, "Check NAT settings on the endpoint (rtp_symmetric, force_rport, rewrite_contact) and the firewall's UDP RTP range")
+		sa.Actions = append(sa.Actions, "Set direct_media=no so media stays anchored on Asterisk")
+	case culpritEngine:
+		sa.Actions = append(sa.Actions, "Check 'docker logs ai_engine' around the drop for a traceback and 'docker ps' for a recent restart")
+		sa.Actions = append(sa.Actions, "Run 'agent check' to confirm the engine's health and memory limits")
+	case culpritTrunk:
+		sa.Actions = append(sa.Actions, "Compare concurrent calls with the trunk's channel limit and the carrier account's limits")
+	case culpritSIPTimer:
+		sa.Actions = append(sa.Actions, "Enable SIP keepalives (qualify_frequency) and check session timers (timers, timers_sess_expires) on the trunk")
+	case culpritDestination, culpritRouting:
+		sa.Actions = append(sa.Actions, "Check the transfer destinations in tools.transfer and the dialplan they route to")
+	default:
+		sa.Actions = append(sa.Actions, "Look up the Q.850 cause with the trunk provider")
+	}
+}
+
+func describeTransfer(tr TransferAttempt) string {
+	dest := emptyTo(tr.Destination, "?")
+	if tr.Type != "" {
+		dest += " (" + tr.Type
+		if tr.Target != "" {
+			dest += " " + tr.Target
+		}
+		dest += ")"
+	}
+	s := fmt.Sprintf("Transfer to %s: %s", dest, tr.Outcome)
+	if tr.DialStatus != "" {
+		s += " (" + tr.DialStatus + ")"
+	}
+	return s
+}
+
+func (r *Runner) displayCallEnding(e *CallEnding) {
+	if e == nil || (e.Culprit == "" && len(e.Transfers) == 0 && e.HangupCause == 0) {
+		return
+	}
+	fmt.Println("Call Ending:")
+	if e.Outcome != "" {
+		fmt.Printf("  Outcome: %s\n", e.Outcome)
+	}
+	if e.HangupCause > 0 {
+		fmt.Printf("  Hangup Cause: %s (%d, %s)", e.HangupCauseText, e.HangupCause, e.CauseSource)
+		if e.HangupSource != "" {
+			fmt.Printf(" by %s", e.HangupSource)
+		}
+		fmt.Println()
+	}
+	for _, tr := range e.Transfers {
+		fmt.Printf("  %s  %s\n", tr.Time.Local().Format("15:04:05"), describeTransfer(tr))
+	}
+	for _, d := range e.Dials {
+		fmt.Printf("  %s  Dial: %s %s\n", d.Time.Local().Format("15:04:05"), emptyTo(d.Channel, "all legs"), d.Outcome)
+	}
+	switch {
+	case e.Culprit == culpritCaller || e.Culprit == culpritAgent || e.Culprit == culpritTransfer:
+		fmt.Printf("  %s\n", e.Explanation)
+	case len(callEndingWarnings(e)) > 0:
+		errorColor.Printf("  Likely culprit (%s): %s\n", e.Culprit, e.Explanation)
+	case e.Culprit != "":
+		warningColor.Printf("  Likely culprit (%s): %s\n", e.Culprit, e.Explanation)
+	}
+	fmt.Println()
+}
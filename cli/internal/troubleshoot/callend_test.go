@@ -0,0 +1,80 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+)
+
+func TestExtractCallEndingTransferToBusyTrunk(t *testing.T) {
+	at, _ := time.Parse(time.RFC3339, "2026-01-30T17:22:10Z")
+	asterisk := []TimelineEntry{
+		{Time: at, Source: SourceAsterisk, Line: "[Jan 30 17:22:10] VERBOSE[901][C-0000000c] app_dial.c:     -- PJSIP/trunk-00000004 is circuit-busy"},
+		{Time: at, Source: SourceAsterisk, Line: "[Jan 30 17:22:10] VERBOSE[901][C-0000000c] app_dial.c:   == Everyone is busy/congested at this time (1:0/1/0)"},
+	}
+	timeline := mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:22:05Z","level":"info","event":"Transfer requested","call_id":"1.1","destination":"sales","type":"extension","target":"2000"}`,
+		`{"timestamp":"2026-01-30T17:22:05.100Z","level":"info","event":"✅ Extension transfer initiated","call_id":"1.1","extension":"2000"}`,
+		`{"timestamp":"2026-01-30T17:22:11Z","level":"info","event":"Channel destroyed","channel_id":"1.1","cause":34,"cause_txt":"Circuit/channel congestion"}`,
+		`{"timestamp":"2026-01-30T17:22:11Z","level":"info","event":"RCA_CALL_END","call_id":"1.1","call_outcome":"transferred","transferred":true}`,
+	}, asterisk)
+
+	e := extractCallEnding("1.1", timeline, nil, false)
+	if len(e.Transfers) != 1 || e.Transfers[0].Outcome != transferInitiated || e.Transfers[0].Target != "2000" {
+		t.Fatalf("transfers = %+v", e.Transfers)
+	}
+	if len(e.Dials) != 2 || e.Dials[0].Outcome != "circuit-busy" || e.Dials[1].Outcome != "congested" {
+		t.Fatalf("dials = %+v", e.Dials)
+	}
+	if e.HangupCause != 34 || e.CauseSource != "ari" || e.Culprit != culpritTrunk || !e.Teardown {
+		t.Fatalf("ending = %+v", e)
+	}
+	if w := callEndingWarnings(e); len(w) != 1 || !strings.Contains(w[0], "no capacity") {
+		t.Fatalf("warnings = %q", w)
+	}
+
+	// CEL wins over the engine's ARI cause; without the dial lines the
+	// transfer handed the call off normally.
+	var engine []TimelineEntry
+	for _, entry := range timeline {
+		if entry.Source == SourceEngine {
+			engine = append(engine, entry)
+		}
+	}
+	if e := extractCallEnding("1.1", engine, &cdr.Record{HangupCause: 16, HangupSource: "PJSIP/trunk-00000001"}, false); e.CauseSource != "cel" || e.Culprit != culpritTransfer {
+		t.Fatalf("ending with CEL = %+v", e)
+	}
+}
+
+func TestCallDropSymptom(t *testing.T) {
+	rtp := mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:22:00Z","level":"info","event":"Channel destroyed","channel_id":"1.1","cause":16}`,
+	}, []TimelineEntry{{Source: SourceAsterisk, Line: "NOTICE[77][C-0000000c] res_pjsip_sdp_rtp.c: Disconnecting channel 'PJSIP/trunk-00000001' for lack of RTP activity in 30 seconds"}})
+	analysis := &Analysis{Ending: extractCallEnding("1.1", rtp, nil, false)}
+	NewSymptomChecker("call-drop").AnalyzeSymptom(analysis, "")
+	sa := analysis.SymptomAnalysis
+	if analysis.Ending.Culprit != culpritRTPTimeout || len(sa.RootCauses) != 1 || !strings.Contains(sa.RootCauses[0], "no RTP for 30s") {
+		t.Fatalf("rtp timeout = %+v / %+v", analysis.Ending, sa)
+	}
+
+	crash := mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:22:00Z","level":"info","event":"Transcription received","call_id":"1.1"}`,
+		`Traceback (most recent call last):`,
+	})
+	if e := extractCallEnding("1.1", crash, nil, false); e.Culprit != culpritEngine {
+		t.Fatalf("crash = %+v", e)
+	}
+	if e := extractCallEnding("1.1", crash, nil, true); e.Culprit != "" {
+		t.Fatalf("in progress = %+v", e)
+	}
+
+	agent := mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:22:00Z","level":"info","event":"📞 Hangup requested","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:22:03Z","level":"info","event":"Call cleanup completed","call_id":"1.1"}`,
+	})
+	if e := extractCallEnding("1.1", agent, nil, false); e.Culprit != culpritAgent || callEndingWarnings(e) != nil {
+		t.Fatalf("agent hangup = %+v", e)
+	}
+}
@@ -0,0 +1,462 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/statecrypt"
+)
+
+const (
+	callIndexVersion = 1
+	// callIndexRetention bounds the index; older calls have usually rotated out of the logs.
+	callIndexRetention = 7 * 24 * time.Hour
+	// callIndexOverlap re-reads a few seconds of docker logs on each refresh so lines
+	// written while the previous scan was finishing are not missed.
+	callIndexOverlap = 5 * time.Second
+	// callIndexInitialWindow is how far back the first scan looks (matches --list before the index).
+	callIndexInitialWindow = "24h"
+	// callIndexSelectLimit caps how many indexed calls a phone/time selector searches.
+	callIndexSelectLimit = 5000
+)
+
+// Lifecycle markers in ai_engine logs. "Stasis ended" is not terminal (the AMD hop
+// leaves Stasis and returns), so the end of a call is the channel destroy / cleanup.
+var (
+	callStartMarkers = []string{"Caller channel entered Stasis", "StasisStart"}
+	callEndMarkers   = []string{"Channel destroyed", "Call cleanup completed"}
+)
+
+func containsAny(line string, markers []string) bool {
+	for _, m := range markers {
+		if strings.Contains(line, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// CallIndexEntry is one call in the local call index.
+type CallIndexEntry struct {
+	ID        string    `json:"id"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// StartedAt/EndedAt come from the StasisStart and channel-destroyed/cleanup lines.
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	// Duration is the authoritative duration from CDR or Call History (DurationSource).
+	Duration       string `json:"duration,omitempty"`
+	DurationSource string `json:"duration_source,omitempty"`
+	Transport      string `json:"transport,omitempty"`
+	// Caller metadata from the Stasis lines, replaced by Call History values when available.
+	CallerNumber string `json:"caller_number,omitempty"`
+	CallerName   string `json:"caller_name,omitempty"`
+	Dialed       string `json:"dialed,omitempty"`
+	Answered     *bool  `json:"answered,omitempty"`
+	HangupCause  string `json:"hangup_cause,omitempty"`
+	Outcome      string `json:"outcome,omitempty"`
+	Disposition  string `json:"disposition,omitempty"` // CDR disposition (answered, no answer, busy, failed)
+	// HistoryChecked is set once Call History has been queried for this finished call.
+	HistoryChecked bool `json:"history_checked,omitempty"`
+	// QualityScore is the 0-100 score from the last RCA run with metrics (nil until analyzed).
+	QualityScore *float64 `json:"quality_score,omitempty"`
+	// LogOffset/LogEnd are byte offsets of the first and last line for file log sources.
+	LogOffset int64 `json:"log_offset,omitempty"`
+	LogEnd    int64 `json:"log_end,omitempty"`
+	// TimesApproximate is set when a line had no parseable timestamp and scan time was used.
+	TimesApproximate bool       `json:"times_approximate,omitempty"`
+	AnalyzedAt       *time.Time `json:"analyzed_at,omitempty"`
+}
+
+// callIndex is the on-disk index. Helpers remembers AudioSocket / ExternalMedia channel
+// ids so a helper id seen in a later refresh is never listed as a call.
+type callIndex struct {
+	Version       int                        `json:"version"`
+	Source        string                     `json:"source"`
+	ScannedUntil  time.Time                  `json:"scanned_until,omitempty"`
+	ScannedOffset int64                      `json:"scanned_offset,omitempty"`
+	Calls         map[string]*CallIndexEntry `json:"calls"`
+	Helpers       map[string]time.Time       `json:"helpers,omitempty"`
+}
+
+// CallIndexPath returns the index location.
+func CallIndexPath() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_CALL_INDEX")); p != "" {
+		return p
+	}
+	return filepath.Join(".agent", "calls.json")
+}
+
+// callIndexSource identifies the log stream the offsets and scan cursor refer to.
+func callIndexSource() string {
+	if path, ok := deployment.Current().EngineLogFile(); ok {
+		return "file:" + path
+	}
+	d := deployment.Current()
+	endpoint := d.DockerHost
+	if endpoint == "" {
+		endpoint = d.DockerContext
+	}
+	return "docker:" + endpoint + "/" + deployment.EngineContainer()
+}
+
+func newCallIndex(source string) *callIndex {
+	return &callIndex{
+		Version: callIndexVersion,
+		Source:  source,
+		Calls:   map[string]*CallIndexEntry{},
+		Helpers: map[string]time.Time{},
+	}
+}
+
+// loadCallIndex reads the index; a missing, unreadable, or foreign-source index starts empty.
+// An encrypted index without its key reads as empty too, and save then refuses to replace it.
+func loadCallIndex(path, source string) *callIndex {
+	idx := &callIndex{}
+	raw, err := statecrypt.ReadFile(path)
+	if err != nil || json.Unmarshal(raw, idx) != nil || idx.Version != callIndexVersion || idx.Source != source {
+		return newCallIndex(source)
+	}
+	if idx.Calls == nil {
+		idx.Calls = map[string]*CallIndexEntry{}
+	}
+	if idx.Helpers == nil {
+		idx.Helpers = map[string]time.Time{}
+	}
+	return idx
+}
+
+// save is best-effort like other .agent state; it writes through a temp file so a
+// concurrent reader never sees a partial index, encrypted when a state key is set.
+func (idx *callIndex) save(path string) error {
+	raw, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return statecrypt.WriteFile(path, raw, 0o644)
+}
+
+func (idx *callIndex) prune(now time.Time) {
+	cutoff := now.Add(-callIndexRetention)
+	for id, e := range idx.Calls {
+		if e.LastSeen.Before(cutoff) {
+			delete(idx.Calls, id)
+		}
+	}
+	for id, seen := range idx.Helpers {
+		if seen.Before(cutoff) {
+			delete(idx.Helpers, id)
+		}
+	}
+}
+
+// offsetLines is bufio.ScanLines that also tracks the byte offset of each token.
+// With holdPartial, an unterminated final line (still being written) is left for the
+// next scan.
+type offsetLines struct {
+	offset      int64 // start of the current token
+	next        int64 // start of the next token
+	holdPartial bool
+}
+
+func (o *offsetLines) split(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && o.holdPartial && bytes.IndexByte(data, '\n') < 0 {
+		return 0, nil, nil
+	}
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	if advance > 0 {
+		o.offset = o.next
+		o.next += int64(advance)
+	}
+	return advance, token, err
+}
+
+// ingest scans log lines starting at byte base, updating calls and helpers. Lines
+// without a parseable timestamp are stamped with now. For file sources (file=true) it
+// returns the offset after the last complete line.
+func (r *Runner) ingest(idx *callIndex, src io.Reader, base int64, file bool, now time.Time) (int64, error) {
+	helpers := []struct {
+		pattern *regexp.Regexp
+		label   string
+	}{
+		{audioSocketPattern, "AudioSocket"},
+		{externalMediaPattern, "ExternalMedia"},
+		{pendingExternalMediaPattern, "pending ExternalMedia"},
+	}
+
+	pos := &offsetLines{offset: base, next: base, holdPartial: file}
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	scanner.Split(pos.split)
+	stasisEvents := map[string]stasisEventMeta{}
+	lineCount, matchCount := 0, 0
+	for scanner.Scan() {
+		lineCount++
+		// Console format uses colors; JSON format doesn't, so stripping is safe for both.
+		line := StripANSI(scanner.Text())
+		at, timed := engineLineTime(line)
+		if !timed {
+			at = now
+		}
+
+		observeStasisEvent(line, stasisEvents)
+		for _, h := range helpers {
+			if id := firstNonEmpty(h.pattern.FindStringSubmatch(line), 1, 2); id != "" {
+				if _, known := idx.Helpers[id]; !known && r.verbose {
+					fmt.Fprintf(os.Stderr, "[DEBUG] Found %s channel: %s\n", h.label, id)
+				}
+				idx.Helpers[id] = at
+			}
+		}
+
+		for _, pattern := range callIDPatterns {
+			matches := pattern.FindStringSubmatch(line)
+			if len(matches) > 1 {
+				matchCount++
+				e := idx.Calls[matches[1]]
+				if e == nil {
+					e = &CallIndexEntry{ID: matches[1], FirstSeen: at, LogOffset: pos.offset}
+					idx.Calls[e.ID] = e
+				}
+				if !timed {
+					e.TimesApproximate = true
+				}
+				if at.After(e.LastSeen) {
+					e.LastSeen = at
+				}
+				e.LogEnd = pos.offset
+				observeCallMetadata(e, line)
+				if timed {
+					switch {
+					case e.StartedAt == nil && containsAny(line, callStartMarkers):
+						start := at
+						e.StartedAt = &start
+					case containsAny(line, callEndMarkers):
+						end := at
+						e.EndedAt = &end
+					}
+				}
+				break // Found a match, no need to try other patterns
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return pos.next, err
+	}
+	applyStasisMeta(idx, stasisEvents)
+
+	// Skip non-caller channels (AudioSocket / ExternalMedia helper channels)
+	for id := range idx.Helpers {
+		if _, ok := idx.Calls[id]; ok {
+			delete(idx.Calls, id)
+			if r.verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Skipping non-caller channel: %s\n", id)
+			}
+		}
+	}
+
+	if r.verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Read %d new log lines, pattern matches: %d, indexed calls: %d\n", lineCount, matchCount, len(idx.Calls))
+	}
+	return pos.next, nil
+}
+
+// refreshCallIndex brings the index up to date by scanning only log output written
+// since the previous refresh (appended bytes for file sources).
+func (r *Runner) refreshCallIndex() (*callIndex, error) {
+	path := CallIndexPath()
+	idx := loadCallIndex(path, callIndexSource())
+	now := time.Now()
+
+	if logFile, ok := deployment.Current().EngineLogFile(); ok {
+		f, err := os.Open(logFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if st, err := f.Stat(); err == nil && st.Size() < idx.ScannedOffset {
+			// Rotated or truncated: offsets no longer refer to this file.
+			idx = newCallIndex(idx.Source)
+		}
+		if _, err := f.Seek(idx.ScannedOffset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		next, err := r.ingest(idx, f, idx.ScannedOffset, true, now)
+		if err != nil {
+			return nil, err
+		}
+		idx.ScannedOffset = next
+	} else {
+		since := callIndexInitialWindow
+		if !idx.ScannedUntil.IsZero() {
+			since = idx.ScannedUntil.Add(-callIndexOverlap).UTC().Format(time.RFC3339Nano)
+		}
+		src, err := deployment.OpenEngineLogs(since)
+		if err != nil {
+			return nil, err
+		}
+		defer src.Close()
+		if _, err := r.ingest(idx, src, 0, false, now); err != nil {
+			return nil, err
+		}
+		idx.ScannedUntil = now
+	}
+
+	idx.prune(now)
+	if err := idx.save(path); err != nil && r.verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Could not save call index %s: %v\n", path, err)
+	}
+	return idx, nil
+}
+
+// IndexedCalls returns up to limit calls from the saved index, newest first, without
+// reading logs. Shell completion uses it, so it must stay fast and side-effect free.
+func IndexedCalls(limit int) []Call {
+	return loadCallIndex(CallIndexPath(), callIndexSource()).recentCalls(limit)
+}
+
+// recentCalls returns indexed calls, newest first by start time.
+func (idx *callIndex) recentCalls(limit int) []Call {
+	calls := make([]Call, 0, len(idx.Calls))
+	for _, e := range idx.Calls {
+		calls = append(calls, e.call())
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if !calls[i].Timestamp.Equal(calls[j].Timestamp) {
+			return calls[i].Timestamp.After(calls[j].Timestamp)
+		}
+		return calls[i].ID > calls[j].ID
+	})
+	if len(calls) > limit {
+		calls = calls[:limit]
+	}
+	return calls
+}
+
+// call converts an entry for listing. The start time prefers StasisStart over the first
+// log line; duration prefers Call History, then StasisStart to channel destroy.
+func (e *CallIndexEntry) call() Call {
+	c := Call{
+		ID:           e.ID,
+		Timestamp:    e.FirstSeen,
+		Duration:     e.Duration,
+		Transport:    e.Transport,
+		QualityScore: e.QualityScore,
+		CallerNumber: e.CallerNumber,
+		CallerName:   e.CallerName,
+		Dialed:       e.Dialed,
+		Answered:     e.Answered,
+		HangupCause:  e.HangupCause,
+		Outcome:      e.Outcome,
+		Disposition:  e.Disposition,
+		end:          e.EndedAt,
+	}
+	if e.StartedAt != nil {
+		c.Timestamp = *e.StartedAt
+	}
+	if c.Duration == "" && e.EndedAt != nil && !e.EndedAt.Before(c.Timestamp) {
+		c.Duration = formatCallDuration(e.EndedAt.Sub(c.Timestamp))
+	}
+	return c
+}
+
+// formatCallDuration renders a call length with second precision ("42s", "3m05s", "1h02m").
+func formatCallDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// openIndexedCallLogs opens the engine logs narrowed to an indexed call: a seek to the
+// call's first line for file sources, or a --since window for docker. ok is false when
+// the call is not indexed and the caller should read the full window.
+func openIndexedCallLogs(callID string) (io.ReadCloser, bool, error) {
+	idx := loadCallIndex(CallIndexPath(), callIndexSource())
+	e := idx.Calls[callID]
+	if e == nil {
+		return nil, false, nil
+	}
+	if logFile, ok := deployment.Current().EngineLogFile(); ok {
+		f, err := os.Open(logFile)
+		if err != nil {
+			return nil, false, err
+		}
+		if st, err := f.Stat(); err != nil || st.Size() < e.LogEnd {
+			f.Close()
+			return nil, false, nil
+		}
+		if _, err := f.Seek(e.LogOffset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, false, err
+		}
+		return f, true, nil
+	}
+	if e.TimesApproximate {
+		return nil, false, nil
+	}
+	since := e.FirstSeen.Add(-correlationPad).UTC().Format(time.RFC3339Nano)
+	src, err := deployment.OpenEngineLogs(since)
+	return src, err == nil, err
+}
+
+// recordCallAnalysis stores RCA results for the call (best-effort).
+func recordCallAnalysis(analysis *Analysis, metrics *CallMetrics, logData string) {
+	path := CallIndexPath()
+	idx := loadCallIndex(path, callIndexSource())
+	now := time.Now()
+	e := idx.Calls[analysis.CallID]
+	if e == nil {
+		e = &CallIndexEntry{ID: analysis.CallID, FirstSeen: now, LastSeen: now}
+		for _, line := range strings.Split(logData, "\n") {
+			if at, ok := engineLineTime(line); ok {
+				if e.FirstSeen.Equal(now) || at.Before(e.FirstSeen) {
+					e.FirstSeen = at
+				}
+				e.LastSeen = at
+			}
+		}
+		idx.Calls[e.ID] = e
+	}
+	if t := strings.TrimSpace(analysis.AudioTransport); t != "" && t != "unknown" {
+		e.Transport = t
+	}
+	if metrics != nil {
+		if metrics.CallDurationSeconds > 0 {
+			e.Duration = formatCallDuration(time.Duration(metrics.CallDurationSeconds * float64(time.Second)))
+			e.DurationSource = durationFromHistory
+		}
+		if metricsHasEvidence(metrics) {
+			score, _ := evaluateCallQuality(metrics)
+			e.QualityScore = &score
+			sample := QualitySample{CallID: e.ID, At: e.call().Timestamp, Score: score, Underflow: metrics.UnderflowRatePct(), Transport: e.Transport}
+			if metrics.DriftAssessmentSkipped == "" {
+				drift := metrics.WorstDriftPct
+				sample.DriftPct = &drift
+			}
+			for _, k := range analysis.KnownIssues {
+				sample.KnownIssues = append(sample.KnownIssues, k.Title)
+			}
+			recordQualitySample(sample)
+		}
+	}
+	e.AnalyzedAt = &now
+	_ = idx.save(path)
+}
@@ -0,0 +1,140 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+)
+
+func TestIngestExcludesHelperChannels(t *testing.T) {
+	t.Parallel()
+
+	logs := strings.Join([]string{
+		`{"timestamp": "2026-01-30T17:21:39Z", "event": "media", "call_id": "1769818883.1490"}`,
+		`{"timestamp": "2026-01-30T17:21:40Z", "event": "StasisStart", "call_id": "1769818882.1484"}`,
+		`{"timestamp": "2026-01-30T17:21:41Z", "event": "ExternalMedia", "call_id": "1769818882.1484", "external_media_id": "1769818883.1490"}`,
+		"StasisStart call_id=1769818900.1500",
+	}, "\n")
+	idx := newCallIndex("test")
+	now := time.Date(2026, 1, 30, 18, 0, 0, 0, time.UTC)
+	if _, err := (&Runner{}).ingest(idx, strings.NewReader(logs), 0, false, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Calls) != 2 || idx.Calls["1769818883.1490"] != nil {
+		t.Fatalf("unexpected calls: %v", idx.Calls)
+	}
+	e := idx.Calls["1769818882.1484"]
+	if e.FirstSeen.Second() != 40 || e.LastSeen.Second() != 41 || e.TimesApproximate {
+		t.Fatalf("unexpected times: %+v", e)
+	}
+	if !idx.Calls["1769818900.1500"].TimesApproximate {
+		t.Fatalf("untimed console line should be marked approximate")
+	}
+}
+
+func TestIngestTracksFileOffsetsIncrementally(t *testing.T) {
+	t.Parallel()
+
+	first := "noise\nStasisStart call_id=1769818882.1484\n"
+	partial := "Hangup call_id=17698"
+	idx := newCallIndex("test")
+	r := &Runner{}
+	next, err := r.ingest(idx, strings.NewReader(first+partial), 0, true, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != int64(len(first)) {
+		t.Fatalf("partial line should be held back: next=%d want %d", next, len(first))
+	}
+	if e := idx.Calls["1769818882.1484"]; e == nil || e.LogOffset != int64(len("noise\n")) {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+
+	rest := partial + "18900.1500\n"
+	if _, err := r.ingest(idx, strings.NewReader(rest), next, true, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if e := idx.Calls["1769818900.1500"]; e == nil || e.LogOffset != next {
+		t.Fatalf("second scan entry: %+v", e)
+	}
+}
+
+func TestIngestDerivesStartAndDurationFromLifecycle(t *testing.T) {
+	t.Parallel()
+
+	logs := strings.Join([]string{
+		"2026-01-30T17:21:38.000000-07:00 [info     ] Incoming call channel_id=1769818882.1484",
+		"2026-01-30T17:21:40.000000-07:00 [info     ] 🎯 HYBRID ARI - Caller channel entered Stasis channel_id=1769818882.1484",
+		"2026-01-30T17:24:45.400000-07:00 [info     ] Call cleanup completed call_id=1769818882.1484",
+		`{"timestamp": "2026-01-30T17:30:00-07:00", "event": "StasisStart", "call_id": "1769819400.1600"}`,
+	}, "\n")
+	idx := newCallIndex("test")
+	if _, err := (&Runner{}).ingest(idx, strings.NewReader(logs), 0, false, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	calls := idx.recentCalls(10)
+	if len(calls) != 2 || calls[0].ID != "1769819400.1600" {
+		t.Fatalf("expected newest call first: %+v", calls)
+	}
+	if calls[1].Timestamp.Second() != 40 || calls[1].Duration != "3m05s" {
+		t.Fatalf("unexpected start/duration: %v %q", calls[1].Timestamp, calls[1].Duration)
+	}
+	if calls[0].Duration != "" {
+		t.Fatalf("call without an end event should have no duration: %q", calls[0].Duration)
+	}
+}
+
+func TestIngestExtractsCallerMetadata(t *testing.T) {
+	t.Parallel()
+
+	logs := strings.Join([]string{
+		`2026-01-30T17:21:39.900000-07:00 [info     ] 🎯 HYBRID ARI - StasisStart event received event_data={'type': 'StasisStart', 'channel': {'id': '1769818882.1484', 'name': 'PJSIP/trunk-0000001a', 'caller': {'name': 'Jane Doe', 'number': '+15551234567'}, 'dialplan': {'context': 'from-trunk', 'exten': '8005550100', 'priority': 1}}}`,
+		`2026-01-30T17:21:40.000000-07:00 [info     ] 🎯 HYBRID ARI - Caller channel entered Stasis caller_name=Jane caller_number=+15551234567 channel_id=1769818882.1484`,
+		`2026-01-30T17:21:40.200000-07:00 [info     ] 🎯 HYBRID ARI - Step 1: ✅ Caller channel answered channel_id=1769818882.1484`,
+		`{"timestamp": "2026-01-30T17:30:00-07:00", "event": "StasisStart", "call_id": "1769819400.1600", "caller_number": "6001"}`,
+	}, "\n")
+	idx := newCallIndex("test")
+	if _, err := (&Runner{}).ingest(idx, strings.NewReader(logs), 0, false, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	calls := idx.recentCalls(10)
+	if len(calls) != 2 {
+		t.Fatalf("unexpected calls: %+v", calls)
+	}
+	if got := calls[1].describe(); got != `from +15551234567 "Jane", to 8005550100` {
+		t.Fatalf("answered inbound call: %q", got)
+	}
+	if got := calls[0].describe(); got != "from 6001, not answered by agent" {
+		t.Fatalf("unanswered call: %q", got)
+	}
+}
+
+func TestMergeCDRRecordsPrefersCDRDurationAndAddsRotatedStasisCalls(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 30, 17, 21, 40, 0, time.Local)
+	idx := newCallIndex("test")
+	idx.Calls["1769818882.1484"] = &CallIndexEntry{ID: "1769818882.1484", FirstSeen: start}
+	idx.Helpers["1769818883.1490"] = start
+	recs := []cdr.Record{
+		{UniqueID: "1769818882.1484", Start: start, End: start.Add(185 * time.Second), Duration: 185, Src: "+15551234567", Dst: "8005550100", Disposition: "ANSWERED", HangupCauseText: "Normal clearing", LastApp: "Stasis"},
+		{UniqueID: "1769818883.1490", Start: start, Duration: 180, LastApp: "Stasis"},
+		{UniqueID: "1769700000.1000", Start: start.Add(-time.Hour), Duration: 42, LastApp: "Stasis", Disposition: "NO ANSWER"},
+		{UniqueID: "1769700001.1001", Start: start, Duration: 20, LastApp: "Dial"},
+	}
+	if !mergeCDRRecords(idx, recs) {
+		t.Fatal("expected index change")
+	}
+	if len(idx.Calls) != 2 {
+		t.Fatalf("helpers and non-Stasis calls must be skipped: %v", idx.Calls)
+	}
+	calls := idx.recentCalls(10)
+	if calls[0].Duration != "3m05s" || calls[0].describe() != "from +15551234567, to 8005550100, answered (Normal clearing)" {
+		t.Fatalf("unexpected merged call: %+v / %q", calls[0], calls[0].describe())
+	}
+	if calls[1].ID != "1769700000.1000" || calls[1].Duration != "42s" {
+		t.Fatalf("rotated Stasis call not added: %+v", calls[1])
+	}
+}
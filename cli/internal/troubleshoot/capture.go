@@ -0,0 +1,64 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/capture"
+)
+
+// SetCapture merges a packet capture of the call's media into the report.
+func (r *Runner) SetCapture(rep *capture.Report) {
+	r.capture = rep
+}
+
+// captureForCall keeps the streams on the call's ExternalMedia port, when
+// the header names it, and checks them against the call's codec.
+func captureForCall(rep *capture.Report, h *RCAHeader, transport string) *capture.Report {
+	out := *rep
+	if h != nil && h.ExternalMediaRTPPort > 0 {
+		out.Streams = nil
+		for _, s := range rep.Streams {
+			if s.Transport != "rtp" || int(s.EnginePort()) == h.ExternalMediaRTPPort {
+				out.Streams = append(out.Streams, s)
+			}
+		}
+	}
+	expect := capture.Expect{}
+	if h != nil {
+		expect.Codec = h.ExternalMediaCodec
+		if strings.Contains(strings.ToLower(transport), "audiosocket") {
+			expect.Codec = h.AudioSocketFormat
+		}
+	}
+	out.Findings = capture.Findings(out.Streams, expect)
+	if len(out.Streams) == 0 {
+		out.Findings = append(out.Findings, "the capture holds no media for this call; check the capture interface and that the call used the captured transport")
+	}
+	return &out
+}
+
+func (r *Runner) displayCapture(c *capture.Report) {
+	if c == nil {
+		return
+	}
+	fmt.Println("Packet Capture:")
+	if c.File != "" {
+		fmt.Printf("  File: %s (%d packets)\n", c.File, c.Packets)
+	}
+	for _, s := range c.Streams {
+		fmt.Printf("  %-21s %s  %s %gms  %d pkts  loss %.1f%%  jitter %.1fms  reordered %d",
+			s.Label(), s.Src+" → "+s.Dst, s.Codec, s.PtimeMS, s.Packets, s.LossPct, s.JitterMS, s.OutOfOrder)
+		if g := s.LongestGap(); g.MS > 0 {
+			fmt.Printf("  longest gap %.0fms", g.MS)
+		}
+		fmt.Println()
+	}
+	for _, f := range c.Findings {
+		warningColor.Printf("  • %s\n", f)
+	}
+	if len(c.Findings) == 0 {
+		successColor.Println("  ✅ Media on the wire looks clean")
+	}
+	fmt.Println()
+}
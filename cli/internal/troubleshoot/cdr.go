@@ -0,0 +1,112 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+)
+
+// cdrLookback is how far back RCA searches CDRs for a call without an index entry.
+const cdrLookback = 72 * time.Hour
+
+// Duration sources, in increasing precedence: log lifecycle lines, CDR, Call History.
+const (
+	durationFromCDR     = "cdr"
+	durationFromHistory = "call_history"
+)
+
+// mergeCDRRecords folds CDRs into the call index. Records for indexed calls, and for
+// calls that reached Stasis but whose engine logs have rotated away, become entries
+// with CDR start, end, duration, disposition and hangup cause. It reports whether the
+// index changed.
+func mergeCDRRecords(idx *callIndex, recs []cdr.Record) bool {
+	changed := false
+	for _, rec := range recs {
+		if _, helper := idx.Helpers[rec.UniqueID]; helper {
+			continue
+		}
+		e := idx.Calls[rec.UniqueID]
+		if e == nil {
+			if !rec.Stasis() {
+				continue
+			}
+			e = &CallIndexEntry{ID: rec.UniqueID, FirstSeen: rec.Start, LastSeen: rec.End}
+			idx.Calls[e.ID] = e
+		}
+		start, end := rec.Start, rec.End
+		if end.IsZero() {
+			end = start.Add(time.Duration(rec.Duration) * time.Second)
+		}
+		e.StartedAt, e.EndedAt = &start, &end
+		if e.DurationSource != durationFromHistory {
+			e.Duration = formatCallDuration(time.Duration(rec.Duration) * time.Second)
+			e.DurationSource = durationFromCDR
+		}
+		if e.CallerNumber == "" {
+			e.CallerNumber = rec.Src
+		}
+		if e.Dialed == "" {
+			e.Dialed = rec.Dst
+		}
+		e.Disposition = strings.ToLower(rec.Disposition)
+		if rec.HangupCauseText != "" {
+			e.HangupCause = rec.HangupCauseText
+		}
+		changed = true
+	}
+	return changed
+}
+
+// refreshFromCDR merges the last day of CDRs into the index; unavailable sources are
+// silently skipped so listing falls back to log scraping.
+func refreshFromCDR(idx *callIndex) bool {
+	recs, err := cdr.Recent(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		return false
+	}
+	return mergeCDRRecords(idx, recs)
+}
+
+// lookupCallCDR finds the CDR for an RCA call (best-effort).
+func lookupCallCDR(callID string) *cdr.Record {
+	rec, err := cdr.Lookup(callID, cdrSince(callID))
+	if err != nil {
+		return nil
+	}
+	return rec
+}
+
+// cdrSince is where to start searching Asterisk's records for the call: an
+// hour before the call index first saw it, else cdrLookback ago.
+func cdrSince(callID string) time.Time {
+	if e := loadCallIndex(CallIndexPath(), callIndexSource()).Calls[callID]; e != nil && !e.FirstSeen.IsZero() {
+		return e.FirstSeen.Add(-time.Hour)
+	}
+	return time.Now().Add(-cdrLookback)
+}
+
+func (r *Runner) displayCDR(rec *cdr.Record) {
+	if rec == nil {
+		return
+	}
+	fmt.Println("Call Detail Record:")
+	fmt.Printf("  Source: %s\n", rec.Source)
+	if rec.Src != "" || rec.Dst != "" {
+		fmt.Printf("  From/To: %s → %s\n", emptyTo(rec.Src, "?"), emptyTo(rec.Dst, "?"))
+	}
+	fmt.Printf("  Start: %s\n", rec.Start.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Duration: %ds (billable %ds)\n", rec.Duration, rec.BillSec)
+	if rec.Disposition != "" {
+		fmt.Printf("  Disposition: %s\n", rec.Disposition)
+	}
+	if rec.HangupCauseText != "" {
+		fmt.Printf("  Hangup Cause: %s (%d)", rec.HangupCauseText, rec.HangupCause)
+		if rec.HangupSource != "" {
+			fmt.Printf(" by %s", rec.HangupSource)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+}
@@ -0,0 +1,393 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+// Fault verdicts.
+const (
+	FaultHandled   = "HANDLED"   // the engine recovered or ended the call cleanly
+	FaultDegraded  = "DEGRADED"  // the call went on with poorer quality or errors
+	FaultUnhandled = "UNHANDLED" // the caller was left on a dead line
+)
+
+var (
+	reconnectAttemptEvents = map[string]bool{
+		logschema.EventOpenAIReconnecting: true,
+		logschema.EventGrokReconnecting:   true,
+		logschema.EventLocalReconnecting:  true,
+	}
+	reconnectedEvents = map[string]bool{
+		logschema.EventOpenAIReconnected: true,
+		logschema.EventGrokReconnected:   true,
+		logschema.EventLocalReconnected:  true,
+	}
+	reconnectGaveUpEvents = map[string]bool{
+		logschema.EventOpenAIReconnectExhausted: true,
+		logschema.EventGrokReconnectExhausted:   true,
+		logschema.EventLocalReconnectFailed:     true,
+	}
+)
+
+// FaultCall is how one call fared while a provider fault was injected.
+type FaultCall struct {
+	LoadCall
+	StartFailed  bool   `json:"provider_start_failed,omitempty"`
+	Disconnected bool   `json:"provider_disconnected,omitempty"`
+	Reconnects   int    `json:"reconnect_attempts,omitempty"`
+	Reconnected  bool   `json:"reconnected,omitempty"`
+	GaveUp       bool   `json:"reconnect_exhausted,omitempty"`
+	Redirected   bool   `json:"redirected,omitempty"`
+	Ended        bool   `json:"ended"`
+	Errors       int    `json:"errors"`
+	Verdict      string `json:"verdict"`
+	Finding      string `json:"finding"`
+}
+
+// FaultReport is how the engine behaved while a provider fault was injected.
+type FaultReport struct {
+	Calls []FaultCall `json:"calls"`
+	// Engine-wide reconnects, including providers that log them without a
+	// call id.
+	Reconnects  int `json:"reconnect_attempts"`
+	Reconnected int `json:"reconnects_succeeded"`
+	GaveUp      int `json:"reconnects_exhausted"`
+}
+
+// Unhandled counts the calls left on a dead line.
+func (r FaultReport) Unhandled() int {
+	n := 0
+	for _, c := range r.Calls {
+		if c.Verdict == FaultUnhandled {
+			n++
+		}
+	}
+	return n
+}
+
+// AnalyzeFault finds the calls that were up between from and to and judges
+// the engine's fallback on each. outage means every provider packet was
+// dropped, so a call with no provider failure logged went silent unnoticed.
+func AnalyzeFault(lines []string, from, to time.Time, outage bool) FaultReport {
+	report := FaultReport{Calls: []FaultCall{}}
+	for _, line := range lines {
+		if at, ok := engineLineTime(line); !ok || at.Before(from) || at.After(to) {
+			continue
+		}
+		_, event, _, ok := parseLogLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case reconnectAttemptEvents[event]:
+			report.Reconnects++
+		case reconnectedEvents[event]:
+			report.Reconnected++
+		case reconnectGaveUpEvents[event]:
+			report.GaveUp++
+		}
+	}
+	for _, id := range CallsDuring(lines, from, to) {
+		report.Calls = append(report.Calls, AnalyzeFaultCall(id, FilterCallLines(lines, id), outage))
+	}
+	return report
+}
+
+// CallsDuring returns the IDs of the calls that logged a line between from
+// and to.
+func CallsDuring(lines []string, from, to time.Time) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, line := range lines {
+		if at, ok := engineLineTime(line); !ok || at.Before(from) || at.After(to) {
+			continue
+		}
+		_, _, fields, ok := parseLogLine(line)
+		if id := fields["call_id"]; ok && id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// AnalyzeFaultCall reads one call's provider failures, the fallback the
+// engine took and how the call ended, and gives a verdict.
+func AnalyzeFaultCall(callID string, lines []string, outage bool) FaultCall {
+	c := FaultCall{LoadCall: AnalyzeLoadCall(callID, lines)}
+	for _, line := range lines {
+		if isErrorLine(line) && !isBenignRCAErrorLine(line) {
+			c.Errors++
+		}
+		_, event, fields, ok := parseLogLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case event == logschema.EventProviderStartFailed:
+			c.StartFailed = true
+		case event == logschema.EventProviderDisconnected:
+			c.Disconnected = true
+		case event == logschema.EventProviderFailureRedirect:
+			c.Redirected = true
+		case reconnectAttemptEvents[event]:
+			c.Reconnects++
+		case reconnectedEvents[event]:
+			c.Reconnected = true
+		case reconnectGaveUpEvents[event]:
+			c.GaveUp = true
+		case event == logschema.EventCallCleanup || event == logschema.EventCallEnd:
+			c.Ended = true
+		case event == logschema.EventChannelDestroyed && fields["channel_id"] == callID:
+			c.Ended = true
+		}
+	}
+	c.Verdict, c.Finding = faultVerdict(c, outage)
+	return c
+}
+
+func faultVerdict(c FaultCall, outage bool) (string, string) {
+	switch {
+	case c.StartFailed && c.Redirected:
+		return FaultHandled, "provider failed to start; the call was redirected to the on_provider_failure dialplan target"
+	case c.StartFailed && c.Ended:
+		return FaultHandled, "provider failed to start; the engine ended the call instead of leaving dead air"
+	case c.StartFailed:
+		return FaultUnhandled, "provider failed to start and the call stayed up with no agent (expected only with on_provider_failure: leave_open)"
+	case c.Reconnected:
+		return FaultHandled, fmt.Sprintf("provider connection dropped and was restored after %d attempt(s)", c.Reconnects)
+	case (c.Disconnected || c.GaveUp) && c.Ended:
+		return FaultHandled, "provider connection was lost; the engine ended the call instead of leaving dead air"
+	case c.Disconnected || c.GaveUp:
+		return FaultUnhandled, "provider connection was lost and the call stayed up with no agent"
+	case outage:
+		return FaultDegraded, "no provider failure was logged; the call likely sat in dead air until the caller hung up"
+	case c.Score != nil && *c.Score < loadScoreFloor:
+		return FaultDegraded, fmt.Sprintf("call went on with quality %.0f: %s", *c.Score, strings.Join(c.Issues, "; "))
+	case c.Errors > 0:
+		return FaultDegraded, fmt.Sprintf("call went on with %d error(s) logged", c.Errors)
+	}
+	if n := len(c.LatenciesMS); n > 0 {
+		sorted := append([]float64(nil), c.LatenciesMS...)
+		sort.Float64s(sorted)
+		return FaultHandled, fmt.Sprintf("call held up; p95 turn latency %.0fms over %d turn(s)", percentile(sorted, 95), n)
+	}
+	return FaultHandled, "call held up"
+}
+
+// Switchover verdicts.
+const (
+	SwitchEngaged   = "ENGAGED"    // the fallback took the call after the primary failed
+	SwitchMissed    = "MISSED"     // the primary failed and no fallback took the call
+	SwitchNoFailure = "NO FAILURE" // the primary never failed, so nothing was drilled
+)
+
+// Switchover is how one drill call moved from its failed primary to the
+// fallback.
+type Switchover struct {
+	CallID string `json:"call_id"`
+	Logged bool   `json:"logged"`
+	// Failure is how the primary failed: start_failed, disconnected or
+	// reconnect_exhausted.
+	Failure    string `json:"failure,omitempty"`
+	Redirected bool   `json:"redirected"`
+	// Fallback is the provider or pipeline that started after the failure.
+	Fallback string `json:"fallback,omitempty"`
+	// DetectionMS runs from the call's first engine line to the failure;
+	// SwitchoverMS from the failure to the fallback starting (or, for a
+	// redirect that leaves the engine, to the redirect).
+	DetectionMS  int64  `json:"detection_ms,omitempty"`
+	SwitchoverMS int64  `json:"switchover_ms,omitempty"`
+	Verdict      string `json:"verdict"`
+	Finding      string `json:"finding"`
+}
+
+// AnalyzeSwitchover reads one drill call's lines. fallback is the provider
+// or pipeline expected to take over, or "" when the redirect target leaves
+// the engine.
+func AnalyzeSwitchover(callID string, lines []string, fallback string) Switchover {
+	sw := Switchover{CallID: callID}
+	var first, failedAt, switchedAt time.Time
+	for _, line := range lines {
+		at, ok := engineLineTime(line)
+		if !ok {
+			continue
+		}
+		_, event, fields, ok := parseLogLine(line)
+		if !ok {
+			continue
+		}
+		if first.IsZero() {
+			first = at
+			sw.Logged = true
+		}
+		failure := ""
+		switch {
+		case event == logschema.EventProviderStartFailed:
+			failure = "start_failed"
+		case event == logschema.EventProviderDisconnected:
+			failure = "disconnected"
+		case reconnectGaveUpEvents[event]:
+			failure = "reconnect_exhausted"
+		case event == logschema.EventProviderFailureRedirect && !failedAt.IsZero():
+			sw.Redirected = true
+			if fallback == "" && switchedAt.IsZero() {
+				switchedAt = at
+			}
+		case (event == logschema.EventProviderStarted || event == logschema.EventPipelineStarted) && !failedAt.IsZero() && switchedAt.IsZero():
+			name := fields["provider"]
+			if event == logschema.EventPipelineStarted {
+				name = fields["pipeline"]
+			}
+			if fallback == "" || name == fallback {
+				sw.Fallback, switchedAt = name, at
+			}
+		}
+		if failure != "" && failedAt.IsZero() {
+			sw.Failure, failedAt = failure, at
+		}
+	}
+
+	switch {
+	case !sw.Logged:
+		sw.Verdict, sw.Finding = SwitchNoFailure, "the call never reached the engine; check the dialplan context and ARI"
+		return sw
+	case failedAt.IsZero():
+		sw.Verdict, sw.Finding = SwitchNoFailure, "the primary never failed during the call; the outage did not hit its endpoints"
+		return sw
+	}
+	sw.DetectionMS = failedAt.Sub(first).Milliseconds()
+	if switchedAt.IsZero() {
+		sw.Verdict = SwitchMissed
+		switch {
+		case sw.Redirected && fallback != "":
+			sw.Finding = fmt.Sprintf("the call was redirected but %s never started; check AI_PROVIDER in the redirect context and its keys", fallback)
+		case sw.Redirected:
+			sw.Finding = "the call was redirected after the failure"
+		default:
+			sw.Finding = fmt.Sprintf("the primary failed (%s) and the call was not redirected; check on_provider_failure and the redirect context", sw.Failure)
+		}
+		return sw
+	}
+	sw.SwitchoverMS = switchedAt.Sub(failedAt).Milliseconds()
+	sw.Verdict = SwitchEngaged
+	if sw.Fallback != "" {
+		sw.Finding = fmt.Sprintf("%s took the call %.1fs after the primary failed (%s)", sw.Fallback, float64(sw.SwitchoverMS)/1000, sw.Failure)
+	} else {
+		sw.Finding = fmt.Sprintf("the call was redirected out of the engine %.1fs after the primary failed (%s)", float64(sw.SwitchoverMS)/1000, sw.Failure)
+	}
+	return sw
+}
+
+// RTPCall is one call's RCA metrics, for comparing calls made with the
+// ExternalMedia RTP path impaired against a clean baseline call.
+type RTPCall struct {
+	CallID         string   `json:"call_id"`
+	Impaired       bool     `json:"impaired"`
+	Logged         bool     `json:"logged"`
+	Score          *float64 `json:"quality_score,omitempty"`
+	Issues         []string `json:"issues,omitempty"`
+	Underflows     int      `json:"underflows"`
+	WorstDriftPct  float64  `json:"worst_drift_pct"`
+	Turns          int      `json:"turns"`
+	LatencyP50MS   float64  `json:"latency_p50_ms,omitempty"`
+	LatencyP95MS   float64  `json:"latency_p95_ms,omitempty"`
+	RTPLost        int      `json:"rtp_packets_lost"` // inbound; logged at debug level only
+	RTPOutOfOrder  int      `json:"rtp_out_of_order"`
+	JitterBufferMS int      `json:"jitter_buffer_ms,omitempty"` // from RCA_CALL_START
+	LowWatermarkMS int      `json:"low_watermark_ms,omitempty"`
+}
+
+// AnalyzeRTPCall reads the quality score, jitter buffer underflows, drift,
+// turn latency and inbound RTP loss of one call.
+func AnalyzeRTPCall(callID string, lines []string, impaired bool) RTPCall {
+	load := AnalyzeLoadCall(callID, lines)
+	c := RTPCall{CallID: callID, Impaired: impaired, Logged: load.Logged, Score: load.Score, Issues: load.Issues, Turns: len(load.LatenciesMS)}
+	if !c.Logged {
+		return c
+	}
+	metrics := ExtractMetrics(strings.Join(lines, "\n"))
+	c.Underflows, c.WorstDriftPct = metrics.UnderflowCount, metrics.WorstDriftPct
+	if c.Turns > 0 {
+		sorted := append([]float64(nil), load.LatenciesMS...)
+		sort.Float64s(sorted)
+		c.LatencyP50MS, c.LatencyP95MS = percentile(sorted, 50), percentile(sorted, 95)
+	}
+	for _, line := range lines {
+		_, event, fields, ok := parseLogLine(line)
+		if !ok {
+			continue
+		}
+		switch event {
+		case logschema.EventRTPPacketLoss:
+			c.RTPLost += atoiSafe(fields["lost"])
+		case logschema.EventRTPOutOfOrder:
+			c.RTPOutOfOrder++
+		case logschema.EventCallStart:
+			c.JitterBufferMS = atoiSafe(fields["streaming_jitter_buffer_ms"])
+			c.LowWatermarkMS = atoiSafe(fields["streaming_low_watermark_ms"])
+		}
+	}
+	return c
+}
+
+// RTPFindings compares the impaired calls with the first clean one and says
+// whether the jitter buffer absorbed the impairment. degraded is set when an
+// impaired call's quality fell.
+func RTPFindings(calls []RTPCall) (findings []string, degraded bool) {
+	var base *RTPCall
+	for i := range calls {
+		if !calls[i].Impaired && calls[i].Logged {
+			base = &calls[i]
+			break
+		}
+	}
+	for _, c := range calls {
+		if !c.Impaired {
+			continue
+		}
+		say := func(format string, args ...any) {
+			findings = append(findings, c.CallID+": "+fmt.Sprintf(format, args...))
+		}
+		if !c.Logged {
+			say("the call did not reach the engine")
+			degraded = true
+			continue
+		}
+		switch {
+		case c.Score == nil:
+			say("no RCA metrics were logged")
+		case *c.Score < loadScoreFloor:
+			say("quality %.0f is below %.0f: %s", *c.Score, loadScoreFloor, strings.Join(c.Issues, "; "))
+			degraded = true
+		case base != nil && base.Score != nil && *c.Score <= *base.Score-loadScoreDrop:
+			say("quality %.0f vs %.0f on the clean call", *c.Score, *base.Score)
+			degraded = true
+		}
+		baseUnderflows := 0
+		if base != nil {
+			baseUnderflows = base.Underflows
+		}
+		if c.Underflows > baseUnderflows {
+			say("%d jitter buffer underflow(s) vs %d clean; raise streaming.jitter_buffer_ms (%dms) or streaming.low_watermark_ms (%dms)",
+				c.Underflows, baseUnderflows, c.JitterBufferMS, c.LowWatermarkMS)
+			degraded = true
+		}
+		if base != nil && base.LatencyP95MS > 0 && c.LatencyP95MS >= base.LatencyP95MS*loadLatencyGrowth && c.LatencyP95MS-base.LatencyP95MS >= loadLatencyMinDelta {
+			say("p95 turn latency %.0fms vs %.0fms clean", c.LatencyP95MS, base.LatencyP95MS)
+		}
+		if c.RTPLost > 0 || c.RTPOutOfOrder > 0 {
+			say("the engine saw %d lost and %d out-of-order inbound RTP packet(s)", c.RTPLost, c.RTPOutOfOrder)
+		}
+	}
+	if !degraded && len(findings) == 0 {
+		findings = append(findings, "quality held under the impairment")
+	}
+	return findings, degraded
+}
@@ -0,0 +1,108 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeFault(t *testing.T) {
+	lines := []string{
+		// Before the fault: ignored unless the call is still up during it.
+		`{"timestamp":"2026-01-30T17:20:00.000Z","level":"info","event":"Provider session started","call_id":"1.1","provider":"openai_realtime"}`,
+		// Call 1.1 loses its provider and reconnects.
+		`{"timestamp":"2026-01-30T17:21:05.000Z","level":"info","event":"Reconnecting to OpenAI Realtime","call_id":"1.1","attempt":1}`,
+		`{"timestamp":"2026-01-30T17:21:08.000Z","level":"info","event":"Reconnecting to OpenAI Realtime","call_id":"1.1","attempt":2}`,
+		`{"timestamp":"2026-01-30T17:21:09.000Z","level":"info","event":"OpenAI Realtime reconnected","call_id":"1.1"}`,
+		// Call 1.2 cannot start its provider and is hung up.
+		`{"timestamp":"2026-01-30T17:21:10.000Z","level":"error","event":"Failed to start provider session","call_id":"1.2","error":"timed out"}`,
+		`{"timestamp":"2026-01-30T17:21:20.000Z","level":"info","event":"Call cleanup completed","call_id":"1.2"}`,
+		// Call 1.3 is disconnected and left up.
+		`{"timestamp":"2026-01-30T17:21:30.000Z","level":"error","event":"Provider disconnected","call_id":"1.3","provider":"google_live","code":1011}`,
+		// The local provider logs reconnects without a call id.
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"Attempting to reconnect to Local AI Server..."}`,
+		// After the fault.
+		`{"timestamp":"2026-01-30T17:30:00.000Z","level":"info","event":"Provider session started","call_id":"1.4"}`,
+	}
+	from, _ := time.Parse(time.RFC3339, "2026-01-30T17:21:00Z")
+	to := from.Add(time.Minute)
+	r := AnalyzeFault(lines, from, to, true)
+	if len(r.Calls) != 3 || r.Reconnects != 3 || r.Reconnected != 1 || r.Unhandled() != 1 {
+		t.Fatalf("report = %+v", r)
+	}
+	byID := map[string]FaultCall{}
+	for _, c := range r.Calls {
+		byID[c.CallID] = c
+	}
+	if c := byID["1.1"]; c.Verdict != FaultHandled || c.Reconnects != 2 || !c.Reconnected {
+		t.Fatalf("reconnected call = %+v", c)
+	}
+	if c := byID["1.2"]; c.Verdict != FaultHandled || !c.StartFailed || !c.Ended {
+		t.Fatalf("start failure = %+v", c)
+	}
+	if c := byID["1.3"]; c.Verdict != FaultUnhandled || !c.Disconnected || c.Ended {
+		t.Fatalf("disconnect left open = %+v", c)
+	}
+
+	quiet := []string{`{"timestamp":"2026-01-30T17:21:10.000Z","level":"info","event":"Turn latency recorded","call_id":"2.1","latency_ms":900}`}
+	if c := AnalyzeFault(quiet, from, to, true).Calls[0]; c.Verdict != FaultDegraded {
+		t.Fatalf("unnoticed outage = %+v", c)
+	}
+	if c := AnalyzeFault(quiet, from, to, false).Calls[0]; c.Verdict != FaultHandled {
+		t.Fatalf("call under latency = %+v", c)
+	}
+}
+
+func TestRTPFindings(t *testing.T) {
+	clean := []string{
+		`{"timestamp":"2026-01-30T17:20:00.000Z","level":"info","event":"RCA_CALL_START","call_id":"1.1","streaming_jitter_buffer_ms":950,"streaming_low_watermark_ms":80}`,
+		`{"timestamp":"2026-01-30T17:20:05.000Z","level":"info","event":"Turn latency recorded","call_id":"1.1","latency_ms":900}`,
+	}
+	impaired := []string{
+		`{"timestamp":"2026-01-30T17:21:00.000Z","level":"info","event":"RCA_CALL_START","call_id":"1.2","streaming_jitter_buffer_ms":950,"streaming_low_watermark_ms":80}`,
+		`{"timestamp":"2026-01-30T17:21:05.000Z","level":"info","event":"Turn latency recorded","call_id":"1.2","latency_ms":950}`,
+		`{"timestamp":"2026-01-30T17:21:06.000Z","level":"debug","event":"RTP packet loss detected","call_id":"1.2","lost":3}`,
+		`{"timestamp":"2026-01-30T17:21:07.000Z","level":"debug","event":"RTP out-of-order packet","call_id":"1.2"}`,
+	}
+	calls := []RTPCall{AnalyzeRTPCall("1.1", clean, false), AnalyzeRTPCall("1.2", impaired, true)}
+	c := calls[1]
+	if !c.Logged || c.RTPLost != 3 || c.RTPOutOfOrder != 1 || c.JitterBufferMS != 950 || c.LowWatermarkMS != 80 || c.Turns != 1 {
+		t.Fatalf("impaired call = %+v", c)
+	}
+	findings, degraded := RTPFindings(calls)
+	if degraded || len(findings) != 2 || !strings.Contains(findings[1], "3 lost and 1 out-of-order") {
+		t.Fatalf("findings = %v degraded = %v", findings, degraded)
+	}
+
+	calls[1].Underflows = 4
+	findings, degraded = RTPFindings(calls)
+	if !degraded || !strings.Contains(strings.Join(findings, "\n"), "raise streaming.jitter_buffer_ms (950ms)") {
+		t.Fatalf("underflows: findings = %v degraded = %v", findings, degraded)
+	}
+	if _, degraded := RTPFindings([]RTPCall{{CallID: "1.3", Impaired: true}}); !degraded {
+		t.Fatal("a call that never reached the engine should degrade")
+	}
+}
+
+func TestAnalyzeSwitchover(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2026-01-30T17:21:00.000Z","level":"info","event":"RCA_CALL_START","call_id":"1.810001","provider_name":"openai_realtime"}`,
+		`{"timestamp":"2026-01-30T17:21:10.500Z","level":"error","event":"Failed to start provider session","call_id":"1.810001","error":"timed out"}`,
+		`{"timestamp":"2026-01-30T17:21:11.000Z","level":"info","event":"Provider-failure dialplan redirect initiated","call_id":"1.810001","context":"from-ai-agent-local"}`,
+		`{"timestamp":"2026-01-30T17:21:12.000Z","level":"info","event":"Provider session started","call_id":"1.810001","provider":"local"}`,
+	}
+	sw := AnalyzeSwitchover("1.810001", lines, "local")
+	if sw.Verdict != SwitchEngaged || sw.Failure != "start_failed" || !sw.Redirected || sw.Fallback != "local" || sw.DetectionMS != 10500 || sw.SwitchoverMS != 1500 {
+		t.Fatalf("switchover = %+v", sw)
+	}
+
+	if sw := AnalyzeSwitchover("1.810001", lines[:3], "local"); sw.Verdict != SwitchMissed || !strings.Contains(sw.Finding, "local never started") {
+		t.Fatalf("no fallback start = %+v", sw)
+	}
+	if sw := AnalyzeSwitchover("1.810001", lines[:3], ""); sw.Verdict != SwitchEngaged || sw.SwitchoverMS != 500 {
+		t.Fatalf("redirect out of the engine = %+v", sw)
+	}
+	if sw := AnalyzeSwitchover("1.810001", lines[:1], "local"); sw.Verdict != SwitchNoFailure || !sw.Logged {
+		t.Fatalf("no failure = %+v", sw)
+	}
+}
@@ -0,0 +1,40 @@
+package troubleshoot
+
+import (
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/pjsip"
+)
+
+// loadCodecAudit reads the Asterisk pjsip config (docker exec into the Asterisk container,
+// or /etc/asterisk on the host) and audits endpoint allow lists against the format chain
+// recorded in the call's RCA header. Best-effort: returns nil when the config is not
+// reachable, which is normal for remote PBXs. Note this reflects the current config, not
+// necessarily what was live when the call happened.
+func loadCodecAudit(header *RCAHeader) *pjsip.CodecAudit {
+	if header == nil {
+		return nil
+	}
+	text, source, err := pjsip.ReadConfig()
+	if err != nil {
+		return nil
+	}
+	expected := pjsip.ExpectedFormat(header.AudioTransport, header.AudioSocketFormat, header.ExternalMediaCodec)
+	audit := pjsip.Audit(pjsip.ParseEndpoints(text), expected)
+	audit.Source = source
+	return audit
+}
+
+// loadDTMFAudit checks endpoint dtmf_mode settings from the same pjsip config against
+// the call's transport. Best-effort like loadCodecAudit.
+func loadDTMFAudit(header *RCAHeader) *pjsip.DTMFAudit {
+	if header == nil {
+		return nil
+	}
+	text, source, err := pjsip.ReadConfig()
+	if err != nil {
+		return nil
+	}
+	expected := pjsip.ExpectedFormat(header.AudioTransport, header.AudioSocketFormat, header.ExternalMediaCodec)
+	audit := pjsip.AuditDTMF(pjsip.ParseEndpoints(text), expected)
+	audit.Source = source
+	return audit
+}
@@ -0,0 +1,260 @@
+package troubleshoot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+)
+
+// transcriptMaxChars bounds the transcript sent for scoring; longer calls keep
+// their opening and their end, where greetings and task outcomes are.
+const transcriptMaxChars = 12000
+
+// TranscriptTurn is one caller or agent message from Call History.
+type TranscriptTurn struct {
+	Role    string `json:"role"` // user or assistant
+	Content string `json:"content"`
+}
+
+// RubricCriterion is one question the conversation is scored on.
+type RubricCriterion struct {
+	Key      string
+	Question string
+}
+
+// ConversationRubric is what a good call does; each criterion is scored 1-5,
+// higher is better (5 for no_hallucination means nothing was made up).
+var ConversationRubric = []RubricCriterion{
+	{Key: "greeting", Question: "Did the agent open with a clear, polite greeting appropriate to the business?"},
+	{Key: "on_topic", Question: "Did the agent stay on the caller's topic and within its role, without rambling or drifting?"},
+	{Key: "task_completed", Question: "Did the agent complete what the caller needed, or hand off or close the call correctly when it could not?"},
+	{Key: "no_hallucination", Question: "Did the agent avoid inventing facts, prices, policies, actions or tool results the transcript does not support?"},
+}
+
+// RubricScore is one criterion's score.
+type RubricScore struct {
+	Criterion string `json:"criterion"`
+	Score     int    `json:"score"` // 1 (poor) to 5 (good)
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ConversationQuality is the LLM's rubric assessment of the call's transcript:
+// what the caller experienced, as opposed to the audio metrics.
+type ConversationQuality struct {
+	Provider string        `json:"provider,omitempty"`
+	Model    string        `json:"model,omitempty"`
+	Turns    int           `json:"turns"`
+	Scores   []RubricScore `json:"scores,omitempty"`
+	Overall  float64       `json:"overall,omitempty"` // mean score, 1-5
+	Summary  string        `json:"summary,omitempty"`
+	// Error says why the conversation could not be scored.
+	Error string `json:"error,omitempty"`
+}
+
+// Weak returns the criteria scored 2 or lower.
+func (q *ConversationQuality) Weak() []RubricScore {
+	var out []RubricScore
+	for _, s := range q.Scores {
+		if s.Score <= 2 {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// SetConversationQuality scores the call's transcript against
+// ConversationRubric with the configured LLM and adds it to the report.
+func (r *Runner) SetConversationQuality(enabled bool) {
+	r.conversation = enabled
+}
+
+// scoreConversation loads the transcript and scores it, masked by red first
+// when set; failures are reported in the result rather than failing the RCA.
+func scoreConversation(analysis *Analysis, red *redact.Redactor) *ConversationQuality {
+	turns, err := loadCallTranscript(analysis.CallID)
+	if err != nil {
+		return &ConversationQuality{Error: err.Error()}
+	}
+	for i := range turns {
+		turns[i].Content = red.String(turns[i].Content)
+	}
+	if len(turns) == 0 {
+		return &ConversationQuality{Error: "no transcript in Call History for this call"}
+	}
+	llm, err := NewLLMAnalyzer()
+	if err != nil {
+		return &ConversationQuality{Turns: len(turns), Error: err.Error() + " (set OPENAI_API_KEY or ANTHROPIC_API_KEY)"}
+	}
+	q, err := llm.ScoreConversation(analysis, turns)
+	if err != nil {
+		return &ConversationQuality{Provider: llm.provider, Model: llm.model, Turns: len(turns), Error: err.Error()}
+	}
+	return q
+}
+
+// ScoreConversation asks the LLM to score the transcript against the rubric.
+func (llm *LLMAnalyzer) ScoreConversation(analysis *Analysis, turns []TranscriptTurn) (*ConversationQuality, error) {
+	response, err := llm.complete(buildRubricPrompt(analysis, turns))
+	if err != nil {
+		return nil, err
+	}
+	q, err := parseRubricResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	q.Provider, q.Model, q.Turns = llm.provider, llm.model, len(turns)
+	return q, nil
+}
+
+func buildRubricPrompt(analysis *Analysis, turns []TranscriptTurn) string {
+	var b strings.Builder
+	b.WriteString("You review phone calls handled by an AI voice agent. Score the conversation below against each criterion ")
+	b.WriteString("from 1 (poor) to 5 (good), judging only from the transcript and the facts given. The transcript comes from ")
+	b.WriteString("speech recognition, so ignore transcription errors and judge the agent's behaviour.\n\n")
+	if h := analysis.Header; h != nil && h.ContextName != "" {
+		b.WriteString("Agent context: " + h.ContextName + "\n")
+	}
+	if h := analysis.CallHistory; h != nil && h.Outcome != "" {
+		b.WriteString("Call outcome: " + h.Outcome + "\n")
+	}
+	if len(analysis.ToolCalls) > 0 {
+		b.WriteString("Tools the agent called:\n")
+		for _, t := range analysis.ToolCalls {
+			b.WriteString("- " + strings.TrimSpace(t.Name+" "+t.Status) + "\n")
+		}
+	}
+	b.WriteString("\nCriteria:\n")
+	for _, c := range ConversationRubric {
+		b.WriteString(fmt.Sprintf("- %s: %s\n", c.Key, c.Question))
+	}
+	b.WriteString("\nTranscript:\n")
+	b.WriteString(formatTranscript(turns, transcriptMaxChars))
+	b.WriteString("\nReply with JSON only, no prose, in this form:\n")
+	b.WriteString(`{"scores": [{"criterion": "greeting", "score": 4, "reason": "one sentence"}], "summary": "one or two sentences"}`)
+	b.WriteString("\nInclude every criterion exactly once.")
+	return b.String()
+}
+
+// formatTranscript renders the turns as "Caller:" and "Agent:" lines. Past
+// maxChars the middle turns are replaced by a marker.
+func formatTranscript(turns []TranscriptTurn, maxChars int) string {
+	lines := make([]string, len(turns))
+	total := 0
+	for i, t := range turns {
+		who := "Agent"
+		if t.Role == "user" {
+			who = "Caller"
+		}
+		lines[i] = who + ": " + strings.Join(strings.Fields(t.Content), " ") + "\n"
+		total += len(lines[i])
+	}
+	if total <= maxChars {
+		return strings.Join(lines, "")
+	}
+	var head, tail []string
+	used := 0
+	for i, j := 0, len(lines)-1; i <= j; {
+		// Alternate between the start and the end so both are kept.
+		if len(head) <= len(tail) {
+			if used+len(lines[i]) > maxChars {
+				break
+			}
+			head, used, i = append(head, lines[i]), used+len(lines[i]), i+1
+		} else {
+			if used+len(lines[j]) > maxChars {
+				break
+			}
+			tail, used, j = append([]string{lines[j]}, tail...), used+len(lines[j]), j-1
+		}
+	}
+	omitted := len(lines) - len(head) - len(tail)
+	return strings.Join(head, "") + fmt.Sprintf("[... %d turns omitted ...]\n", omitted) + strings.Join(tail, "")
+}
+
+// parseRubricResponse reads the JSON object in the LLM's reply, which may be
+// wrapped in a code fence or prose, and checks every criterion was scored.
+func parseRubricResponse(text string) (*ConversationQuality, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("LLM reply has no JSON object")
+	}
+	var reply struct {
+		Scores []struct {
+			Criterion string  `json:"criterion"`
+			Score     float64 `json:"score"`
+			Reason    string  `json:"reason"`
+		} `json:"scores"`
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return nil, fmt.Errorf("LLM reply is not valid rubric JSON: %w", err)
+	}
+	byKey := map[string]RubricScore{}
+	for _, s := range reply.Scores {
+		key := strings.ToLower(strings.TrimSpace(s.Criterion))
+		score := int(s.Score + 0.5)
+		if score < 1 || score > 5 {
+			return nil, fmt.Errorf("LLM scored %s %v, outside 1-5", key, s.Score)
+		}
+		byKey[key] = RubricScore{Criterion: key, Score: score, Reason: strings.TrimSpace(s.Reason)}
+	}
+	q := &ConversationQuality{Summary: strings.TrimSpace(reply.Summary)}
+	var missing []string
+	sum := 0
+	for _, c := range ConversationRubric {
+		s, ok := byKey[c.Key]
+		if !ok {
+			missing = append(missing, c.Key)
+			continue
+		}
+		q.Scores = append(q.Scores, s)
+		sum += s.Score
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("LLM reply did not score %s", strings.Join(missing, ", "))
+	}
+	q.Overall = float64(sum) / float64(len(q.Scores))
+	return q, nil
+}
+
+// loadCallTranscript reads the call's caller and agent messages from Call
+// History's conversation_history, in order.
+func loadCallTranscript(callID string) ([]TranscriptTurn, error) {
+	const script = `
+import json, os, sqlite3, sys
+p = os.environ.get("CALL_HISTORY_DB_PATH", "/app/data/call_history.db")
+if not os.path.exists(p):
+    print("null")
+    raise SystemExit(0)
+c = sqlite3.connect(p)
+cols = {r[1] for r in c.execute("PRAGMA table_info(call_records)")}
+if not {"call_id", "conversation_history"} <= cols:
+    print("null")
+    raise SystemExit(0)
+r = c.execute("SELECT conversation_history FROM call_records WHERE call_id=? ORDER BY rowid DESC LIMIT 1", (sys.argv[1],)).fetchone()
+turns = []
+for m in json.loads(r[0] or "[]") if r else []:
+    if isinstance(m, dict) and m.get("role") in ("user", "assistant") and isinstance(m.get("content"), str) and m["content"].strip():
+        turns.append({"role": m["role"], "content": m["content"]})
+print(json.dumps(turns, separators=(",", ":")))
+`
+	out, err := dockerapi.ExecCombinedOutput(context.Background(), deployment.EngineContainer(), "python3", "-c", script, callID)
+	if err != nil {
+		return nil, fmt.Errorf("call history query failed: %w (%s)", err, strings.TrimSpace(string(out)))
+	}
+	trimmed := strings.TrimSpace(string(out))
+	if trimmed == "null" {
+		return nil, errors.New("call history is not available (no conversation_history in call_history.db)")
+	}
+	var turns []TranscriptTurn
+	if err := json.Unmarshal([]byte(trimmed), &turns); err != nil {
+		return nil, fmt.Errorf("invalid call history response: %w", err)
+	}
+	return turns, nil
+}
@@ -0,0 +1,79 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseRubricResponse(t *testing.T) {
+	reply := "Here is the assessment:\n```json\n" + `{
+  "scores": [
+    {"criterion": "greeting", "score": 5, "reason": "Greeted by company name."},
+    {"criterion": "On_Topic", "score": 4, "reason": "Stayed on billing."},
+    {"criterion": "task_completed", "score": 2, "reason": "Never booked the appointment."},
+    {"criterion": "no_hallucination", "score": 1.6, "reason": "Quoted a price not in the prompt."}
+  ],
+  "summary": "Polite but did not finish the booking."
+}` + "\n```"
+	q, err := parseRubricResponse(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Scores) != 4 || q.Scores[1].Criterion != "on_topic" || q.Scores[3].Score != 2 {
+		t.Fatalf("scores = %+v", q.Scores)
+	}
+	if q.Overall != 3.25 || q.Summary != "Polite but did not finish the booking." {
+		t.Errorf("overall %.2f, summary %q", q.Overall, q.Summary)
+	}
+	if weak := q.Weak(); len(weak) != 2 || weak[0].Criterion != "task_completed" {
+		t.Errorf("weak = %+v", weak)
+	}
+
+	for _, bad := range []string{
+		"I cannot score this call.",
+		`{"scores": [{"criterion": "greeting", "score": 5}]}`,
+		`{"scores": [{"criterion": "greeting", "score": 9}, {"criterion": "on_topic", "score": 3}, {"criterion": "task_completed", "score": 3}, {"criterion": "no_hallucination", "score": 3}]}`,
+		`{"scores": "all good"}`,
+	} {
+		if _, err := parseRubricResponse(bad); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
+
+func TestFormatTranscript(t *testing.T) {
+	turns := []TranscriptTurn{
+		{Role: "assistant", Content: "Thanks for calling Acme,\n how can I help?"},
+		{Role: "user", Content: "I need to  reschedule."},
+	}
+	if got := formatTranscript(turns, 1000); got != "Agent: Thanks for calling Acme, how can I help?\nCaller: I need to reschedule.\n" {
+		t.Errorf("short transcript = %q", got)
+	}
+
+	var long []TranscriptTurn
+	for i := 0; i < 40; i++ {
+		long = append(long, TranscriptTurn{Role: "user", Content: fmt.Sprintf("turn %02d %s", i, strings.Repeat("x", 80))})
+	}
+	got := formatTranscript(long, 1000)
+	if !strings.Contains(got, "turn 00") || !strings.Contains(got, "turn 39") || strings.Contains(got, "turn 20") {
+		t.Errorf("long transcript keeps the wrong turns:\n%s", got)
+	}
+	if !strings.Contains(got, "turns omitted") || len(got) > 1100 {
+		t.Errorf("long transcript not bounded (%d chars):\n%s", len(got), got)
+	}
+}
+
+func TestBuildRubricPrompt(t *testing.T) {
+	a := &Analysis{
+		Header:      &RCAHeader{ContextName: "sales"},
+		CallHistory: &CallHistorySummary{Outcome: "completed"},
+		ToolCalls:   []ToolCallRecord{{Name: "transfer", Status: "success"}},
+	}
+	p := buildRubricPrompt(a, []TranscriptTurn{{Role: "user", Content: "hello"}})
+	for _, want := range []string{"Agent context: sales", "Call outcome: completed", "- transfer success", "Caller: hello", "no_hallucination:"} {
+		if !strings.Contains(p, want) {
+			t.Errorf("prompt lacks %q", want)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// Timeline sources (stack roles, independent of configured container names).
+const (
+	SourceEngine   = "ai_engine"
+	SourceAsterisk = "asterisk"
+	SourceLocalAI  = "local_ai_server"
+)
+
+// correlationPad widens the engine's call window so setup/teardown on other
+// containers (INVITE, hangup, model reloads) is still captured.
+const correlationPad = 30 * time.Second
+
+// TimelineEntry is one log line from any container, placed on a shared clock.
+type TimelineEntry struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	Line   string    `json:"line"`
+	// Context marks a sidecar line kept only because it fell in the call's
+	// window; it does not name the call, so it may belong to another one.
+	Context bool `json:"-"`
+}
+
+var (
+	// Asterisk tags every line of a call with its callid, e.g. VERBOSE[1234][C-0000000c].
+	asteriskCallTagPattern = regexp.MustCompile(`\[C-[0-9a-fA-F]{8}\]`)
+	// Default full log dateformat: [Jan 30 17:21:43]; also accept [2026-01-30 17:21:43.123].
+	asteriskShortTimePattern = regexp.MustCompile(`^\[([A-Z][a-z]{2}\s+\d{1,2} \d{2}:\d{2}:\d{2})\]`)
+	asteriskISOTimePattern   = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?)\]`)
+	asteriskSeverityPattern  = regexp.MustCompile(`\b(ERROR|WARNING)\[\d+\]`)
+	localAISeverityPattern   = regexp.MustCompile(`\b(ERROR|CRITICAL|WARNING)\b`)
+)
+
+// engineLineTimeLayouts covers structlog isoformat() output and the space-separated
+// form used by plain logging formatters; zone-less values are local time.
+var engineLineTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999",
+}
+
+// engineLineTime extracts the timestamp from a JSON or console ai_engine line.
+// JSON lines may carry it as "timestamp", "ts" or "time" (ISO string or epoch seconds).
+func engineLineTime(line string) (time.Time, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var entry map[string]any
+		if json.Unmarshal([]byte(line), &entry) == nil {
+			for _, k := range []string{"timestamp", "ts", "time"} {
+				switch v := entry[k].(type) {
+				case string:
+					if t, ok := parseEngineTime(v); ok {
+						return t, true
+					}
+				case float64:
+					if v > 1e9 {
+						sec := int64(v)
+						return time.Unix(sec, int64((v-float64(sec))*1e9)), true
+					}
+				}
+			}
+		}
+		return time.Time{}, false
+	}
+	line = strings.TrimPrefix(line, "[")
+	first, rest, _ := strings.Cut(line, " ")
+	if t, ok := parseEngineTime(strings.TrimSuffix(first, "]")); ok {
+		return t, true
+	}
+	// "2026-01-30 17:21:40.123456 [info ...": date and clock are separate tokens.
+	if clock, _, _ := strings.Cut(rest, " "); clock != "" {
+		if t, ok := parseEngineTime(first + " " + strings.TrimSuffix(clock, "]")); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseEngineTime(s string) (time.Time, bool) {
+	if len(s) < len("2006-01-02") || s[4] != '-' {
+		return time.Time{}, false
+	}
+	for _, layout := range engineLineTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dockerTimestamped splits a `docker logs --timestamps` line into its RFC3339 prefix and text.
+func dockerTimestamped(line string) (time.Time, string, bool) {
+	ts, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return time.Time{}, line, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, line, false
+	}
+	return t, rest, true
+}
+
+// asteriskLineTime parses the bracketed full-log timestamp; the short form has
+// no year, so the year of ref is assumed.
+func asteriskLineTime(line string, ref time.Time) (time.Time, bool) {
+	if m := asteriskISOTimePattern.FindStringSubmatch(line); len(m) > 1 {
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05.999999999", m[1], time.Local); err == nil {
+			return t, true
+		}
+	}
+	if m := asteriskShortTimePattern.FindStringSubmatch(line); len(m) > 1 {
+		if t, err := time.ParseInLocation("Jan _2 15:04:05", strings.Join(strings.Fields(m[1]), " "), time.Local); err == nil {
+			return t.AddDate(ref.In(time.Local).Year(), 0, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// callWindow returns the padded time range covered by the engine's call lines.
+func callWindow(engineLines []string) (time.Time, time.Time, bool) {
+	var start, end time.Time
+	for _, line := range engineLines {
+		t, ok := engineLineTime(line)
+		if !ok {
+			continue
+		}
+		if start.IsZero() || t.Before(start) {
+			start = t
+		}
+		if t.After(end) {
+			end = t
+		}
+	}
+	if start.IsZero() {
+		return start, end, false
+	}
+	return start.Add(-correlationPad), end.Add(correlationPad), true
+}
+
+func sidecarLogged(containerName string, start, end time.Time) []TimelineEntry {
+	out, err := dockerapi.LogsOutput(containerName, dockerapi.LogsOptions{
+		Timestamps: true,
+		Since:      start.UTC().Format(time.RFC3339),
+		Until:      end.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil
+	}
+	var entries []TimelineEntry
+	for _, raw := range strings.Split(StripANSI(string(out)), "\n") {
+		if t, text, ok := dockerTimestamped(raw); ok && strings.TrimSpace(text) != "" {
+			entries = append(entries, TimelineEntry{Time: t, Line: text})
+		}
+	}
+	return entries
+}
+
+// readAsteriskFullLog reads the host (or bind-mounted) Asterisk full log.
+func readAsteriskFullLog(path string, start, end time.Time) []TimelineEntry {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var entries []TimelineEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		t, ok := asteriskLineTime(line, start)
+		if !ok || t.Before(start) || t.After(end) {
+			continue
+		}
+		entries = append(entries, TimelineEntry{Time: t, Line: line})
+	}
+	return entries
+}
+
+// collectAsteriskEntries prefers the full log (it carries callid tags and uniqueids);
+// console output from the Asterisk container is the fallback.
+func collectAsteriskEntries(start, end time.Time) []TimelineEntry {
+	if !deployment.Current().Remote() {
+		if entries := readAsteriskFullLog(deployment.Current().Logs.Asterisk, start, end); len(entries) > 0 {
+			return entries
+		}
+	}
+	return sidecarLogged(deployment.AsteriskContainer(), start, end)
+}
+
+// correlateAsterisk keeps Asterisk lines that mention the call (uniqueid or helper
+// channel ids) plus every line sharing an Asterisk callid tag with them.
+func correlateAsterisk(entries []TimelineEntry, c *callCorrelator) []TimelineEntry {
+	tags := map[string]bool{}
+	for _, e := range entries {
+		if c.mentionsCall(e.Line) || c.mentionsRelated(e.Line) {
+			for _, tag := range asteriskCallTagPattern.FindAllString(e.Line, -1) {
+				tags[tag] = true
+			}
+		}
+	}
+	var out []TimelineEntry
+	for _, e := range entries {
+		keep := c.mentionsCall(e.Line) || c.mentionsRelated(e.Line)
+		if !keep {
+			for _, tag := range asteriskCallTagPattern.FindAllString(e.Line, -1) {
+				if tags[tag] {
+					keep = true
+					break
+				}
+			}
+		}
+		if keep {
+			e.Source = SourceAsterisk
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// correlateLocalAI keeps local_ai_server lines in the call window that mention the
+// call or report a problem; the server has no per-call ids for most of its output,
+// so problem lines that do not name the call are kept as context only.
+func correlateLocalAI(entries []TimelineEntry, c *callCorrelator) []TimelineEntry {
+	var out []TimelineEntry
+	for _, e := range entries {
+		named := c.mentionsCall(e.Line) || c.mentionsRelated(e.Line)
+		if named || localAISeverityPattern.MatchString(e.Line) ||
+			strings.Contains(e.Line, "Traceback (most recent call last)") {
+			e.Source = SourceLocalAI
+			e.Context = !named
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// mergeTimeline places engine lines and correlated sidecar entries on one clock.
+// Engine lines without a timestamp (tracebacks) inherit the previous line's time.
+func mergeTimeline(engineLines []string, sidecars ...[]TimelineEntry) []TimelineEntry {
+	var merged []TimelineEntry
+	var last time.Time
+	for _, line := range engineLines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if t, ok := engineLineTime(line); ok {
+			last = t
+		}
+		merged = append(merged, TimelineEntry{Time: last, Source: SourceEngine, Line: line})
+	}
+	for _, s := range sidecars {
+		merged = append(merged, s...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	return merged
+}
+
+// collectCorrelatedTimeline pulls Asterisk and local_ai_server logs for the call's
+// time window and merges them with the engine lines. Best-effort: missing
+// containers or unreadable logs simply contribute nothing.
+func collectCorrelatedTimeline(callID string, engineLines []string) []TimelineEntry {
+	start, end, ok := callWindow(engineLines)
+	if !ok {
+		return nil
+	}
+	c := newCallCorrelator(callID)
+	for _, line := range engineLines {
+		if c.mentionsCall(line) {
+			c.observe(line)
+		}
+	}
+	asterisk := correlateAsterisk(collectAsteriskEntries(start, end), c)
+	localAI := correlateLocalAI(sidecarLogged(deployment.LocalAIContainer(), start, end), c)
+	if len(asterisk) == 0 && len(localAI) == 0 {
+		return nil
+	}
+	return mergeTimeline(engineLines, asterisk, localAI)
+}
+
+// sidecarSeverity is ERROR, CRITICAL or WARNING for problem lines from
+// non-engine containers, and empty otherwise.
+func sidecarSeverity(e TimelineEntry) string {
+	var sev string
+	switch e.Source {
+	case SourceAsterisk:
+		if m := asteriskSeverityPattern.FindStringSubmatch(e.Line); len(m) > 1 {
+			sev = m[1]
+		}
+	case SourceLocalAI:
+		if m := localAISeverityPattern.FindStringSubmatch(e.Line); len(m) > 1 {
+			sev = m[1]
+		}
+		if strings.Contains(e.Line, "Traceback (most recent call last)") {
+			sev = "ERROR"
+		}
+	}
+	return sev
+}
+
+// sidecarProblems returns error/warning lines from non-engine containers, prefixed by source.
+// Context lines are left out: on a busy host they may report another call.
+func sidecarProblems(timeline []TimelineEntry) (errs []string, warns []string) {
+	for _, e := range timeline {
+		if e.Context {
+			continue
+		}
+		sev := sidecarSeverity(e)
+		msg := "[" + e.Source + "] " + strings.TrimSpace(e.Line)
+		switch sev {
+		case "ERROR", "CRITICAL":
+			errs = append(errs, msg)
+		case "WARNING":
+			warns = append(warns, msg)
+		}
+	}
+	return errs, warns
+}
+
+// displayTimeline prints correlated non-engine events and the engine's DTMF lines;
+// --verbose shows the full merged timeline.
+func (r *Runner) displayTimeline(timeline []TimelineEntry) {
+	if len(timeline) == 0 {
+		return
+	}
+	shown := make([]TimelineEntry, 0, len(timeline))
+	for _, e := range timeline {
+		_, dtmf := dtmfEvent(e)
+		_, amd := amdVerdict(e)
+		if r.verbose || e.Source != SourceEngine || dtmf || amd {
+			shown = append(shown, e)
+		}
+	}
+	limit := len(shown)
+	if !r.verbose && limit > 10 {
+		limit = 10
+	}
+	infoColor.Printf("Correlated Events (%d):\n", len(shown))
+	for _, e := range shown[:limit] {
+		fmt.Printf("  %s %-15s %s\n", e.Time.Local().Format("15:04:05.000"), e.Source, truncate(strings.TrimSpace(e.Line), 100))
+	}
+	if len(shown) > limit {
+		fmt.Printf("  ... and %d more (use -v for the full merged timeline)\n", len(shown)-limit)
+	}
+	fmt.Println()
+}
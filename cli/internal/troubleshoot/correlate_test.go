@@ -0,0 +1,110 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCorrelateAsteriskFollowsCallidTag(t *testing.T) {
+	t.Parallel()
+
+	c := newCallCorrelator("1769818882.1484")
+	entries := []TimelineEntry{
+		{Line: "[Jan 30 17:21:40] VERBOSE[101][C-0000000c] pbx.c: Executing [s@from-ai-agent:1] NoOp(\"PJSIP/6000-0000001a\", \"uniqueid=1769818882.1484\")"},
+		{Line: "[Jan 30 17:21:41] WARNING[101][C-0000000c] res_rtp_asterisk.c: RTP read error"},
+		{Line: "[Jan 30 17:21:41] VERBOSE[202][C-0000000d] pbx.c: other call"},
+	}
+	got := correlateAsterisk(entries, c)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 correlated lines, got %d: %+v", len(got), got)
+	}
+	if got[1].Source != SourceAsterisk || !strings.Contains(got[1].Line, "RTP read error") {
+		t.Fatalf("callid-tagged line not correlated: %+v", got[1])
+	}
+
+	errs, warns := sidecarProblems(got)
+	if len(errs) != 0 || len(warns) != 1 || !strings.HasPrefix(warns[0], "[asterisk] ") {
+		t.Fatalf("errs=%v warns=%v", errs, warns)
+	}
+}
+
+func TestCorrelateLocalAIPromotesOnlyTheCall(t *testing.T) {
+	t.Parallel()
+
+	c := newCallCorrelator("1769818882.1484")
+	entries := []TimelineEntry{
+		{Line: "2026-01-30 17:21:41 - ERROR - STT backend not initialized call_id=1769818882.1484"},
+		{Line: "2026-01-30 17:21:42 - ERROR - TTS synthesis failed call_id=1769818999.1500"},
+		{Line: "Traceback (most recent call last):"},
+		{Line: "2026-01-30 17:21:43 - INFO - model loaded"},
+	}
+	got := correlateLocalAI(entries, c)
+	if len(got) != 3 || got[0].Context || !got[1].Context || !got[2].Context {
+		t.Fatalf("correlated = %+v", got)
+	}
+
+	errs, warns := sidecarProblems(got)
+	if len(errs) != 1 || len(warns) != 0 || !strings.Contains(errs[0], "STT backend not initialized") {
+		t.Fatalf("errs=%v warns=%v", errs, warns)
+	}
+}
+
+func TestMergeTimelineIsChronological(t *testing.T) {
+	t.Parallel()
+
+	engine := []string{
+		"2026-01-30T17:21:40.000000-07:00 [info     ] StasisStart call_id=1769818882.1484",
+		"Traceback (most recent call last):",
+		"2026-01-30T17:21:45.000000-07:00 [info     ] Call cleanup call_id=1769818882.1484",
+	}
+	start, end, ok := callWindow(engine)
+	if !ok || end.Sub(start) != 5*time.Second+2*correlationPad {
+		t.Fatalf("window=%v..%v ok=%v", start, end, ok)
+	}
+
+	sideAt, _ := time.Parse(time.RFC3339, "2026-01-31T00:21:42Z")
+	merged := mergeTimeline(engine, []TimelineEntry{{Time: sideAt, Source: SourceLocalAI, Line: "ERROR stt failed"}})
+	if len(merged) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(merged))
+	}
+	if merged[1].Line != "Traceback (most recent call last):" || merged[2].Source != SourceLocalAI {
+		t.Fatalf("unexpected order: %+v", merged)
+	}
+}
+
+func TestAsteriskLineTimeFormats(t *testing.T) {
+	t.Parallel()
+
+	ref := time.Date(2026, 1, 30, 0, 0, 0, 0, time.Local)
+	ts, ok := asteriskLineTime("[Jan  3 09:05:01] NOTICE[1]: x", ref)
+	if !ok || ts.Year() != 2026 || ts.Day() != 3 || ts.Hour() != 9 {
+		t.Fatalf("short format: %v ok=%v", ts, ok)
+	}
+	ts, ok = asteriskLineTime("[2026-01-30 17:21:43.123] ERROR[1]: y", ref)
+	if !ok || ts.Minute() != 21 || ts.Nanosecond() != 123000000 {
+		t.Fatalf("iso format: %v ok=%v", ts, ok)
+	}
+	if _, ok := asteriskLineTime("no timestamp", ref); ok {
+		t.Fatalf("expected no timestamp")
+	}
+}
+
+func TestEngineLineTimeFormats(t *testing.T) {
+	t.Parallel()
+
+	for _, line := range []string{
+		`{"timestamp": "2026-01-30T17:21:40.5-07:00", "event": "x"}`,
+		`{"ts": 1769818900.5, "event": "x"}`,
+		"2026-01-30T17:21:40.500000-07:00 [info     ] x",
+		"2026-01-30 17:21:40.500 [info     ] x",
+		"[2026-01-30 17:21:40,500] INFO x",
+	} {
+		if ts, ok := engineLineTime(line); !ok || ts.Nanosecond() != 500000000 {
+			t.Fatalf("%q: %v ok=%v", line, ts, ok)
+		}
+	}
+	if _, ok := engineLineTime("Traceback (most recent call last):"); ok {
+		t.Fatalf("expected no timestamp")
+	}
+}
@@ -0,0 +1,125 @@
+package troubleshoot
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+)
+
+// Disposition is the summary of a finished call that agent watch posts to
+// CRM webhooks.
+type Disposition struct {
+	SchemaVersion int    `json:"schema_version"`
+	CallID        string `json:"call_id"`
+	CallerNumber  string `json:"caller_number,omitempty"`
+	CallerName    string `json:"caller_name,omitempty"`
+	CalledNumber  string `json:"called_number,omitempty"`
+	Context       string `json:"context,omitempty"`
+	Provider      string `json:"provider,omitempty"`
+	Pipeline      string `json:"pipeline,omitempty"`
+
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	// Outcome is Call History's outcome of the call, else the engine's, else
+	// the CDR disposition.
+	Outcome     string `json:"outcome,omitempty"`
+	Transferred bool   `json:"transferred"`
+	TransferTo  string `json:"transfer_to,omitempty"`
+	// EndedBy is what agent rca names as ending the call: caller, agent,
+	// transfer, destination, trunk, routing, engine or unknown.
+	EndedBy     string `json:"ended_by,omitempty"`
+	HangupCause string `json:"hangup_cause,omitempty"`
+
+	// Result is agent rca's verdict: PASS, WARN or FAIL.
+	Result            string   `json:"result"`
+	QualityScore      *float64 `json:"quality_score,omitempty"`
+	QualityIssues     []string `json:"quality_issues,omitempty"`
+	ConversationScore *float64 `json:"conversation_score,omitempty"` // rubric mean, 1-5
+	// Summary is the LLM's summary of the conversation.
+	Summary    string           `json:"summary,omitempty"`
+	Transcript []TranscriptTurn `json:"transcript,omitempty"`
+}
+
+// Disposition summarizes the analyzed call. The transcript is read from Call
+// History when withTranscript is set; the summary comes from the
+// conversation scoring that SetConversationQuality enables.
+func (r *Runner) Disposition(withTranscript bool) (Disposition, error) {
+	a := r.analysis
+	if a == nil {
+		return Disposition{}, errors.New("no analyzed call")
+	}
+	d := buildDisposition(a, resultLabel(r.ExitCode()))
+	if withTranscript {
+		if turns, err := loadCallTranscript(a.CallID); err == nil {
+			for i := range turns {
+				turns[i].Content = r.redactor.String(turns[i].Content)
+			}
+			d.Transcript = turns
+		}
+	}
+	return d, nil
+}
+
+func buildDisposition(a *Analysis, result string) Disposition {
+	d := Disposition{SchemaVersion: contract.SchemaVersion, CallID: a.CallID, Result: result}
+	if h := a.Header; h != nil {
+		d.CallerNumber, d.CallerName, d.CalledNumber = h.CallerNumber, h.CallerName, h.CalledNumber
+		d.Context, d.Provider, d.Pipeline = h.ContextName, h.ProviderName, h.PipelineName
+	}
+	if rec := a.CDR; rec != nil {
+		d.StartedAt, d.EndedAt = timeOrNil(rec.Start), timeOrNil(rec.End)
+		d.DurationSeconds = float64(rec.Duration)
+	}
+	if ch := a.CallHistory; ch != nil {
+		d.Context = emptyTo(d.Context, ch.ContextName)
+		d.Provider = emptyTo(d.Provider, ch.ProviderName)
+		d.Pipeline = emptyTo(d.Pipeline, ch.PipelineName)
+		if at, ok := parseHistoryTime(ch.StartTime); ok && d.StartedAt == nil {
+			d.StartedAt = &at
+		}
+		if at, ok := parseHistoryTime(ch.EndTime); ok && d.EndedAt == nil {
+			d.EndedAt = &at
+		}
+		if ch.DurationSeconds > 0 {
+			d.DurationSeconds = ch.DurationSeconds
+		}
+		d.Outcome = ch.Outcome
+	}
+	if d.DurationSeconds == 0 && a.Metrics != nil {
+		d.DurationSeconds = a.Metrics.CallDurationSeconds
+	}
+	if e := a.Ending; e != nil {
+		d.Outcome = emptyTo(d.Outcome, e.Outcome)
+		d.EndedBy, d.HangupCause = e.Culprit, e.HangupCauseText
+		for _, t := range e.Transfers {
+			if t.Outcome == transferCompleted || t.Outcome == transferInitiated {
+				d.Transferred, d.TransferTo = true, emptyTo(t.Destination, t.Target)
+			}
+		}
+		d.Transferred = d.Transferred || e.Outcome == "transferred"
+	}
+	if d.Outcome == "" && a.CDR != nil {
+		d.Outcome = a.CDR.Disposition
+	}
+	if metricsHasEvidence(a.Metrics) {
+		score, issues := callQualityScore(a)
+		d.QualityScore, d.QualityIssues = &score, issues
+	}
+	if q := a.Conversation; q != nil && q.Error == "" {
+		d.Summary = q.Summary
+		if q.Overall > 0 {
+			overall := q.Overall
+			d.ConversationScore = &overall
+		}
+	}
+	return d
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,48 @@
+package troubleshoot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+)
+
+func TestBuildDisposition(t *testing.T) {
+	start := time.Date(2026, 1, 30, 17, 20, 0, 0, time.UTC)
+	a := &Analysis{
+		CallID: "1.1",
+		Header: &RCAHeader{CallerNumber: "5551234", CallerName: "Ada", ContextName: "sales"},
+		CDR:    &cdr.Record{UniqueID: "1.1", Start: start, End: start.Add(95 * time.Second), Duration: 95, Disposition: "ANSWERED"},
+		CallHistory: &CallHistorySummary{
+			ProviderName:    "openai_realtime",
+			StartTime:       "2026-01-30T17:20:01",
+			DurationSeconds: 93.5,
+		},
+		Ending: &CallEnding{
+			Outcome:   "transferred",
+			Culprit:   culpritTransfer,
+			Transfers: []TransferAttempt{{Destination: "support", Outcome: transferCompleted}},
+		},
+		Metrics:      ExtractMetrics(`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🎛️ STREAMING TUNING SUMMARY","call_id":"1.1","stream_id":"stream:response:1.1","bytes_sent":32000,"effective_seconds":2.0,"wall_seconds":2.0}`),
+		Conversation: &ConversationQuality{Overall: 4.5, Summary: "Caller asked for support and was transferred."},
+	}
+	d := buildDisposition(a, "PASS")
+	if d.CallerNumber != "5551234" || d.Context != "sales" || d.Provider != "openai_realtime" || d.DurationSeconds != 93.5 {
+		t.Fatalf("disposition = %+v", d)
+	}
+	if d.StartedAt == nil || !d.StartedAt.Equal(start) || d.EndedAt == nil {
+		t.Errorf("times = %v, %v; the CDR's come first", d.StartedAt, d.EndedAt)
+	}
+	if d.Outcome != "transferred" || !d.Transferred || d.TransferTo != "support" || d.EndedBy != culpritTransfer {
+		t.Errorf("ending = %q, %v, %q, %q", d.Outcome, d.Transferred, d.TransferTo, d.EndedBy)
+	}
+	if d.QualityScore == nil || *d.QualityScore != 100 || d.ConversationScore == nil || d.Summary == "" || d.Result != "PASS" {
+		t.Errorf("scores = %v, %v, %q", d.QualityScore, d.ConversationScore, d.Summary)
+	}
+
+	// Without Call History or an engine outcome, the CDR disposition is the outcome.
+	a.CallHistory, a.Ending, a.Conversation = nil, nil, &ConversationQuality{Error: "no LLM"}
+	if d := buildDisposition(a, "WARN"); d.Outcome != "ANSWERED" || d.DurationSeconds != 95 || d.Summary != "" || d.ConversationScore != nil {
+		t.Errorf("CDR-only disposition = %+v", d)
+	}
+}
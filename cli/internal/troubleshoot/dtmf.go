@@ -0,0 +1,187 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/pjsip"
+)
+
+// DTMF sources.
+const (
+	dtmfAsterisk    = "asterisk"
+	dtmfARI         = "ari"
+	dtmfAudioSocket = "audiosocket"
+)
+
+// Asterisk logs keypad digits at the dtmf level, e.g.
+// DTMF[1234][C-0000000c] channel.c: DTMF end '5' received on PJSIP/trunk-00000001, duration 120 ms
+var asteriskDTMFPattern = regexp.MustCompile(`DTMF end '(.)' received on ([^\s,]+)`)
+
+// DTMFEvent is one keypad digit seen on the call.
+type DTMFEvent struct {
+	Time    time.Time `json:"time"`
+	Digit   string    `json:"digit"`
+	Source  string    `json:"source"` // asterisk, ari or audiosocket
+	Channel string    `json:"channel,omitempty"`
+}
+
+// DTMFAnalysis follows keypad digits from Asterisk to ai_engine. Asterisk
+// digits are counted on the first channel that logged one, so helper channels
+// passing the same digit along do not count twice.
+type DTMFAnalysis struct {
+	Asterisk    int              `json:"asterisk"`
+	ARI         int              `json:"ari"`
+	AudioSocket int              `json:"audiosocket"`
+	Digits      string           `json:"digits,omitempty"`
+	Events      []DTMFEvent      `json:"events,omitempty"`
+	Audit       *pjsip.DTMFAudit `json:"audit,omitempty"`
+}
+
+// dtmfEvent returns the digit an Asterisk or engine timeline entry carries.
+func dtmfEvent(e TimelineEntry) (DTMFEvent, bool) {
+	switch e.Source {
+	case SourceAsterisk:
+		if m := asteriskDTMFPattern.FindStringSubmatch(e.Line); m != nil {
+			return DTMFEvent{Time: e.Time, Digit: m[1], Source: dtmfAsterisk, Channel: m[2]}, true
+		}
+	case SourceEngine:
+		_, event, fields, ok := parseLogLine(e.Line)
+		if !ok {
+			return DTMFEvent{}, false
+		}
+		switch event {
+		case logschema.EventChannelDTMF:
+			return DTMFEvent{Time: e.Time, Digit: fields["digit"], Source: dtmfARI, Channel: fields["channel_id"]}, true
+		case logschema.EventAudioSocketDTMF:
+			return DTMFEvent{Time: e.Time, Digit: fields["digit"], Source: dtmfAudioSocket, Channel: fields["caller_channel_id"]}, true
+		}
+	}
+	return DTMFEvent{}, false
+}
+
+// extractDTMF collects the call's keypad digits. It returns nil when none
+// were logged.
+func extractDTMF(timeline []TimelineEntry) *DTMFAnalysis {
+	a := &DTMFAnalysis{}
+	var asteriskChannel string
+	for _, e := range timeline {
+		ev, ok := dtmfEvent(e)
+		if !ok || ev.Digit == "" {
+			continue
+		}
+		switch ev.Source {
+		case dtmfAsterisk:
+			if asteriskChannel == "" {
+				asteriskChannel = ev.Channel
+			}
+			if ev.Channel != asteriskChannel {
+				continue
+			}
+			a.Asterisk++
+		case dtmfARI:
+			a.ARI++
+		case dtmfAudioSocket:
+			a.AudioSocket++
+		}
+		a.Events = append(a.Events, ev)
+	}
+	if len(a.Events) == 0 {
+		return nil
+	}
+	// Prefer what the caller pressed as Asterisk saw it, then what reached the engine.
+	for _, src := range []string{dtmfAsterisk, dtmfARI, dtmfAudioSocket} {
+		for _, ev := range a.Events {
+			if ev.Source == src {
+				a.Digits += ev.Digit
+			}
+		}
+		if a.Digits != "" {
+			break
+		}
+	}
+	return a
+}
+
+// dtmfFindings explains where digits stopped on their way to the provider.
+func dtmfFindings(a *DTMFAnalysis, transport string) []string {
+	var out []string
+	engine := a.ARI + a.AudioSocket
+	if a.Asterisk > 0 && engine == 0 {
+		out = append(out, fmt.Sprintf("Asterisk received %d digit(s) but ai_engine logged none; digits on channels outside the Stasis app raise no ARI event", a.Asterisk))
+	}
+	if strings.EqualFold(transport, "audiosocket") && a.ARI > 0 && a.AudioSocket == 0 {
+		out = append(out, "Digits arrived as ARI events only; the AudioSocket connection carried no DTMF frames")
+	}
+	if engine > 0 {
+		out = append(out, "ai_engine received the digits but does not pass DTMF to the AI provider; keypad menus must be handled in the dialplan or by a tool")
+	}
+	return out
+}
+
+// analyzeDTMF checks why keypad input had no effect: whether digits reached
+// Asterisk and the engine, and whether the endpoints' dtmf_mode suits the
+// transport.
+func (sc *SymptomChecker) analyzeDTMF(analysis *Analysis) {
+	sa := &SymptomAnalysis{
+		Symptom:     "dtmf",
+		Description: "Caller keypad presses have no effect",
+		Findings:    []string{},
+		RootCauses:  []string{},
+		Actions:     []string{},
+	}
+	analysis.SymptomAnalysis = sa
+	a := analysis.DTMF
+
+	switch {
+	case a == nil || len(a.Events) == 0:
+		sa.Findings = append(sa.Findings, "❌ No DTMF digits found for this call")
+		sa.RootCauses = append(sa.RootCauses, "Digits were not decoded by Asterisk (dtmf_mode mismatch with the trunk or phone) or are not logged")
+		sa.Actions = append(sa.Actions, "Asterisk logs digits only at the dtmf level: add it to logger.conf (full => notice,warning,error,verbose,dtmf) and repeat the test call")
+		sa.Actions = append(sa.Actions, "Run 'agent watch' during a call to see DTMFEnd events over AMI")
+	default:
+		sa.Findings = append(sa.Findings, fmt.Sprintf("✅ Digits %q: %d at Asterisk, %d ARI event(s), %d AudioSocket frame(s)", a.Digits, a.Asterisk, a.ARI, a.AudioSocket))
+		for _, f := range dtmfFindings(a, analysis.AudioTransport) {
+			sa.Findings = append(sa.Findings, "ℹ️  "+f)
+		}
+		if a.ARI+a.AudioSocket > 0 {
+			sa.RootCauses = append(sa.RootCauses, "ai_engine does not forward DTMF to the AI provider")
+			sa.Actions = append(sa.Actions, "Collect keypad input in the dialplan (Read/WaitExten) before handing the call to Stasis")
+		}
+	}
+
+	if a == nil || a.Audit == nil {
+		sa.Findings = append(sa.Findings, "ℹ️  pjsip config not readable; dtmf_mode not checked")
+		return
+	}
+	for _, ep := range a.Audit.Endpoints {
+		sa.Findings = append(sa.Findings, fmt.Sprintf("ℹ️  Endpoint %s: dtmf_mode=%s", ep.Name, pjsip.EffectiveDTMFMode(ep)))
+	}
+	for _, issue := range a.Audit.Issues {
+		sa.Findings = append(sa.Findings, "⚠️  "+issue)
+		sa.RootCauses = append(sa.RootCauses, issue)
+	}
+	if len(a.Audit.Issues) > 0 {
+		sa.Actions = append(sa.Actions, "Set dtmf_mode=rfc4733 (or auto when the trunk may send inband tones) on the trunk and phone endpoints")
+	}
+}
+
+func (r *Runner) displayDTMF(a *DTMFAnalysis, transport string) {
+	if a == nil || len(a.Events) == 0 {
+		return
+	}
+	fmt.Println("DTMF:")
+	fmt.Printf("  Digits: %s   Asterisk: %d   ARI: %d   AudioSocket: %d\n", a.Digits, a.Asterisk, a.ARI, a.AudioSocket)
+	if r.verbose {
+		for _, ev := range a.Events {
+			fmt.Printf("    %s  %s  %-11s %s\n", ev.Time.Local().Format("15:04:05.000"), ev.Digit, ev.Source, ev.Channel)
+		}
+	}
+	for _, f := range dtmfFindings(a, transport) {
+		fmt.Printf("  • %s\n", f)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,61 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/pjsip"
+)
+
+func TestExtractDTMF(t *testing.T) {
+	at := func(s string) time.Time {
+		ts, _ := time.Parse(time.RFC3339Nano, s)
+		return ts
+	}
+	asterisk := []TimelineEntry{
+		{Time: at("2026-01-30T17:21:45Z"), Line: "[Jan 30 17:21:45] DTMF[812][C-0000000c] channel.c: DTMF begin '4' received on PJSIP/trunk-00000001"},
+		{Time: at("2026-01-30T17:21:45.120Z"), Line: "[Jan 30 17:21:45] DTMF[812][C-0000000c] channel.c: DTMF end '4' received on PJSIP/trunk-00000001, duration 120 ms"},
+		{Time: at("2026-01-30T17:21:45.130Z"), Line: "[Jan 30 17:21:45] DTMF[812][C-0000000c] channel.c: DTMF end '4' received on Local/7000@ai-agent-00000002;2, duration 120 ms"},
+		{Time: at("2026-01-30T17:21:46.120Z"), Line: "[Jan 30 17:21:46] DTMF[812][C-0000000c] channel.c: DTMF end '2' received on PJSIP/trunk-00000001, duration 100 ms"},
+	}
+	for i := range asterisk {
+		asterisk[i].Source = SourceAsterisk
+	}
+	timeline := mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:21:45.150Z","level":"info","event":"Channel DTMF received","channel_id":"1.1","digit":"4"}`,
+		`{"timestamp":"2026-01-30T17:21:46.150Z","level":"info","event":"Channel DTMF received","channel_id":"1.1","digit":"2"}`,
+	}, asterisk)
+
+	a := extractDTMF(timeline)
+	if a == nil || a.Digits != "42" || a.Asterisk != 2 || a.ARI != 2 || a.AudioSocket != 0 {
+		t.Fatalf("dtmf = %+v", a)
+	}
+	findings := strings.Join(dtmfFindings(a, "audiosocket"), "\n")
+	for _, want := range []string{"AudioSocket connection carried no DTMF frames", "does not pass DTMF to the AI provider"} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("findings missing %q:\n%s", want, findings)
+		}
+	}
+
+	a.Audit = pjsip.AuditDTMF([]pjsip.Endpoint{{Name: "trunk", Allow: []string{"ulaw"}, DTMFMode: "rfc2833"}}, pjsip.ExpectedFormat("audiosocket", "slin", ""))
+	analysis := &Analysis{AudioTransport: "audiosocket", DTMF: a}
+	NewSymptomChecker("dtmf").AnalyzeSymptom(analysis, "")
+	sa := analysis.SymptomAnalysis
+	if sa == nil || len(sa.RootCauses) != 2 || !strings.Contains(sa.RootCauses[1], "dtmf_mode=rfc2833") {
+		t.Fatalf("symptom analysis = %+v", sa)
+	}
+}
+
+func TestDTMFSymptomWithoutDigits(t *testing.T) {
+	if a := extractDTMF(mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"Incoming call","call_id":"1.1"}`,
+	})); a != nil {
+		t.Fatalf("dtmf = %+v", a)
+	}
+	analysis := &Analysis{}
+	NewSymptomChecker("dtmf").AnalyzeSymptom(analysis, "")
+	if sa := analysis.SymptomAnalysis; sa == nil || !strings.Contains(sa.Findings[0], "No DTMF digits") || !strings.Contains(strings.Join(sa.Findings, "\n"), "not checked") {
+		t.Fatalf("symptom analysis = %+v", sa)
+	}
+}
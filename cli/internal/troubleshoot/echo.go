@@ -0,0 +1,336 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+// DefaultEchoWindow is how soon after agent audio starts or stops a caller
+// speech onset counts as echo of that audio.
+const DefaultEchoWindow = 500 * time.Millisecond
+
+const (
+	// echoOnsetMerge folds onsets from several detectors for one utterance
+	// (local VAD and the provider) into one.
+	echoOnsetMerge = 500 * time.Millisecond
+	// echoInterruptAfter is how soon a barge-in action must follow an onset
+	// to count as triggered by it, and so be folded into it.
+	echoInterruptAfter = time.Second
+)
+
+// Onset classes.
+const (
+	onsetEcho    = "echo"
+	onsetBargeIn = "barge_in"
+	onsetTurn    = "turn"
+)
+
+// EchoOnset is one caller speech onset and how it relates to agent audio.
+type EchoOnset struct {
+	Time        time.Time `json:"time"`
+	Source      string    `json:"source"`
+	Class       string    `json:"class"`
+	LagMS       int       `json:"lag_ms,omitempty"`      // since agent audio started, or ended for tail echo
+	Tail        bool      `json:"tail,omitempty"`        // after agent audio ended
+	Interrupted bool      `json:"interrupted,omitempty"` // a barge-in action followed
+
+	// fromAction marks an action line with no onset logged before it (info
+	// logs); the action is logged after playback stopped.
+	fromAction bool
+}
+
+// EchoAnalysis correlates agent playback with caller speech onsets. Onsets
+// within the window of agent audio starting or stopping are treated as the
+// agent's own audio coming back on the uplink; later ones during playback as
+// genuine barge-ins.
+type EchoAnalysis struct {
+	WindowMS          int         `json:"window_ms"`
+	AgentSegments     int         `json:"agent_segments"`
+	Onsets            int         `json:"onsets"`
+	Echo              int         `json:"echo"`
+	TailEcho          int         `json:"tail_echo"`
+	BargeIns          int         `json:"barge_ins"`
+	SelfInterruptions int         `json:"self_interruptions"`
+	LeakagePct        float64     `json:"leakage_pct"` // agent segments followed by echo
+	EchoLagP90MS      int         `json:"echo_lag_p90_ms,omitempty"`
+	Events            []EchoOnset `json:"events,omitempty"`
+	Recommendations   []string    `json:"recommendations,omitempty"`
+}
+
+type agentSpan struct {
+	start, end time.Time
+	echoed     bool
+}
+
+// onsetSource maps a caller speech event to the detector that produced it.
+func onsetSource(event string, fields map[string]string) string {
+	switch event {
+	case logschema.EventBargeInAttempt, logschema.EventVADSpeechStarted:
+		return "local_vad"
+	case logschema.EventProviderInterruption, logschema.EventProviderInterruptionNoAudio,
+		logschema.EventProviderSpeechStarted, logschema.EventBargeInBlockedYoung, logschema.EventBargeInBlockedGreeting:
+		return "provider_vad"
+	case logschema.EventBargeInApplied:
+		return emptyTo(fields["source"], "barge_in")
+	}
+	return ""
+}
+
+// analyzeEcho classifies caller speech onsets against agent audio spans (TTS
+// gating, audio gate and streaming playback) from the call's engine lines.
+// It returns nil when the call has no agent audio to compare against.
+func analyzeEcho(timeline []TimelineEntry, header *RCAHeader, window time.Duration) *EchoAnalysis {
+	if window <= 0 {
+		window = DefaultEchoWindow
+	}
+	open := map[string]time.Time{}
+	var spans []agentSpan
+	var onsets []EchoOnset
+	var actions []time.Time
+	var last time.Time
+
+	begin := func(key string, t time.Time) {
+		if _, ok := open[key]; !ok {
+			open[key] = t
+		}
+	}
+	end := func(key string, t time.Time) {
+		if start, ok := open[key]; ok {
+			spans = append(spans, agentSpan{start: start, end: t})
+			delete(open, key)
+		}
+	}
+
+	for _, e := range timeline {
+		if e.Source != SourceEngine {
+			continue
+		}
+		_, event, fields, ok := parseLogLine(e.Line)
+		if !ok || e.Time.IsZero() {
+			continue
+		}
+		t := e.Time
+		last = t
+		switch event {
+		case logschema.EventTTSGating:
+			begin("tts:"+fields["playback_id"], t)
+		case logschema.EventTTSGatingCleared:
+			end("tts:"+fields["playback_id"], t)
+		case logschema.EventGateClosed:
+			begin("gate", t)
+		case logschema.EventGateOpened:
+			end("gate", t)
+		case logschema.EventStreamStarted:
+			begin("stream:"+fields["stream_id"], t)
+		case logschema.EventStreamStopped:
+			end("stream:"+fields["stream_id"], t)
+		}
+		if src := onsetSource(event, fields); src != "" {
+			merge := echoOnsetMerge
+			if event == logschema.EventBargeInApplied {
+				actions = append(actions, t)
+				merge = echoInterruptAfter
+			}
+			if n := len(onsets); n > 0 && t.Sub(onsets[n-1].Time) <= merge {
+				continue
+			}
+			onsets = append(onsets, EchoOnset{Time: t, Source: src, fromAction: event == logschema.EventBargeInApplied})
+		}
+	}
+	for _, start := range open {
+		spans = append(spans, agentSpan{start: start, end: last})
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	spans = mergeAgentSpans(spans)
+
+	a := &EchoAnalysis{WindowMS: int(window / time.Millisecond), AgentSegments: len(spans), Onsets: len(onsets)}
+	var lags []int
+	for _, o := range onsets {
+		classifyOnset(&o, spans, window)
+		for _, at := range actions {
+			if !at.Before(o.Time) && at.Sub(o.Time) <= echoInterruptAfter {
+				o.Interrupted = true
+				break
+			}
+		}
+		switch o.Class {
+		case onsetEcho:
+			a.Echo++
+			if o.Tail {
+				a.TailEcho++
+			} else {
+				lags = append(lags, o.LagMS)
+			}
+			if o.Interrupted {
+				a.SelfInterruptions++
+			}
+		case onsetBargeIn:
+			a.BargeIns++
+		}
+		a.Events = append(a.Events, o)
+	}
+	echoed := 0
+	for _, s := range spans {
+		if s.echoed {
+			echoed++
+		}
+	}
+	a.LeakagePct = float64(echoed) / float64(len(spans)) * 100
+	if len(lags) > 0 {
+		sort.Ints(lags)
+		a.EchoLagP90MS = lags[(len(lags)*9-1)/10]
+	}
+	a.Recommendations = echoRecommendations(a, header)
+	return a
+}
+
+// mergeAgentSpans sorts spans and joins overlapping ones, so one response
+// tracked by several events is one segment of agent audio.
+func mergeAgentSpans(spans []agentSpan) []agentSpan {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+	out := []agentSpan{spans[0]}
+	for _, s := range spans[1:] {
+		cur := &out[len(out)-1]
+		if !s.start.After(cur.end) {
+			if s.end.After(cur.end) {
+				cur.end = s.end
+			}
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// classifyOnset marks o as echo when it starts within the window of agent
+// audio starting or stopping, a barge-in when it starts later during
+// playback, and a normal turn otherwise.
+func classifyOnset(o *EchoOnset, spans []agentSpan, window time.Duration) {
+	o.Class = onsetTurn
+	at := o.Time
+	if o.fromAction {
+		// Judge a bare action by the playback it stopped.
+		for _, s := range spans {
+			if !s.end.After(at) && at.Sub(s.end) <= echoInterruptAfter {
+				at = s.end
+			}
+		}
+	}
+	for i := range spans {
+		s := &spans[i]
+		switch {
+		case at.Before(s.start):
+			return
+		case !at.After(s.end):
+			lag := at.Sub(s.start)
+			o.LagMS = int(lag / time.Millisecond)
+			if lag <= window {
+				o.Class = onsetEcho
+				s.echoed = true
+			} else {
+				o.Class = onsetBargeIn
+			}
+			return
+		case at.Sub(s.end) <= window:
+			o.Class, o.Tail = onsetEcho, true
+			o.LagMS = int(at.Sub(s.end) / time.Millisecond)
+			s.echoed = true
+			return
+		}
+	}
+}
+
+// echoRecommendations maps the echo pattern to gate and VAD settings.
+func echoRecommendations(a *EchoAnalysis, header *RCAHeader) []string {
+	if a.Echo == 0 {
+		return nil
+	}
+	var recs []string
+	local, provider := 0, 0
+	for _, o := range a.Events {
+		if o.Class != onsetEcho {
+			continue
+		}
+		if o.Source == "provider_vad" || o.Source == "provider_event" {
+			provider++
+		} else {
+			local++
+		}
+	}
+	if a.Echo > a.TailEcho && local > 0 {
+		recs = append(recs, fmt.Sprintf("Echo arrives up to %dms after agent audio starts: set barge_in.initial_protection_ms to at least %dms so the local detector ignores it",
+			a.EchoLagP90MS, roundUpMS(a.EchoLagP90MS+100, 50)))
+	}
+	if a.TailEcho > 0 {
+		current := "the default 250ms"
+		if header != nil && header.BargeInPostTTSEndProtectionMs > 0 {
+			current = fmt.Sprintf("%dms", header.BargeInPostTTSEndProtectionMs)
+		}
+		recs = append(recs, fmt.Sprintf("%d onset(s) right after agent audio ended: raise barge_in.post_tts_end_protection_ms (now %s) to cover the echo tail", a.TailEcho, current))
+	}
+	if provider > 0 {
+		recs = append(recs, "The provider's own VAD hears the agent: raise its turn detection threshold (for OpenAI Realtime, providers.openai_realtime.turn_detection.threshold); server-side providers do not cancel telephone echo")
+	}
+	if a.SelfInterruptions > 0 {
+		recs = append(recs, fmt.Sprintf("Echo cut the agent off %d time(s): raise barge_in.min_ms or barge_in.energy_threshold so a barge-in needs sustained, louder speech", a.SelfInterruptions))
+	}
+	if a.AgentSegments >= 4 && a.LeakagePct >= 25 {
+		recs = append(recs, "Echo follows a large share of agent audio: enable echo cancellation on the phone, gateway or trunk; the engine only gates, it does not cancel echo")
+	}
+	return recs
+}
+
+func roundUpMS(ms, step int) int {
+	return (ms + step - 1) / step * step
+}
+
+// echoAudioIssues reports echo that interrupted the agent; echo that the
+// gates absorbed is informational.
+func echoAudioIssues(a *EchoAnalysis) []string {
+	if a == nil || a.SelfInterruptions == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("Echo leakage: the agent's own audio triggered %d barge-in(s)", a.SelfInterruptions)}
+}
+
+func (r *Runner) displayEcho(a *EchoAnalysis) {
+	if a == nil || a.Onsets == 0 {
+		return
+	}
+	fmt.Println("Echo Path:")
+	fmt.Printf("  Agent audio segments: %d   Caller speech onsets: %d\n", a.AgentSegments, a.Onsets)
+	line := fmt.Sprintf("  Echo (within %dms of agent audio): %d (%d after it ended), %.0f%% of segments", a.WindowMS, a.Echo, a.TailEcho, a.LeakagePct)
+	switch {
+	case a.SelfInterruptions > 0:
+		errorColor.Printf("%s, %d self-interruption(s)\n", line, a.SelfInterruptions)
+	case a.Echo > 0:
+		warningColor.Println(line)
+	default:
+		successColor.Println(line)
+	}
+	fmt.Printf("  Genuine barge-ins: %d\n", a.BargeIns)
+	if r.verbose {
+		for _, o := range a.Events {
+			if o.Class == onsetTurn {
+				continue
+			}
+			detail := fmt.Sprintf("%dms after agent audio started", o.LagMS)
+			if o.Tail {
+				detail = fmt.Sprintf("%dms after agent audio ended", o.LagMS)
+			}
+			if o.Interrupted {
+				detail += ", interrupted the agent"
+			}
+			fmt.Printf("    %s  %-8s %-12s %s\n", o.Time.Format("15:04:05.000"), o.Class, o.Source, detail)
+		}
+	}
+	for _, rec := range a.Recommendations {
+		fmt.Printf("  • %s\n", rec)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,67 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeEcho(t *testing.T) {
+	lines := []string{
+		// Greeting: local VAD fires 180ms in and the echo cuts the agent off.
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🔇 ConversationCoordinator gating audio","call_id":"1.1","playback_id":"greeting:1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.020Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"stream:greeting:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.180Z","level":"debug","event":"🎧 Barge-in attempt detected","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.600Z","level":"info","event":"🎧 BARGE-IN action applied","call_id":"1.1","source":"local_vad","reason":"tts_overlap"}`,
+		`{"timestamp":"2026-01-30T17:21:40.700Z","level":"info","event":"🎵 STREAMING PLAYBACK - Stopped","call_id":"1.1","stream_id":"stream:greeting:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.700Z","level":"info","event":"🔊 ConversationCoordinator clearing gating","call_id":"1.1","playback_id":"greeting:1","reason":"barge-in"}`,
+		// Tail echo 120ms after the response ended.
+		`{"timestamp":"2026-01-30T17:21:45.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"stream:response:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:48.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Stopped","call_id":"1.1","stream_id":"stream:response:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:48.120Z","level":"info","event":"🎤 User speech started (no active response); requesting platform flush","call_id":"1.1"}`,
+		// A genuine barge-in two seconds into the next response.
+		`{"timestamp":"2026-01-30T17:21:52.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"stream:response:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:54.000Z","level":"info","event":"🎤 User interruption detected, cancelling response","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:54.100Z","level":"info","event":"🎧 BARGE-IN action applied","call_id":"1.1","source":"provider_event","reason":"input_audio_buffer.speech_started"}`,
+		`{"timestamp":"2026-01-30T17:21:54.200Z","level":"info","event":"🎵 STREAMING PLAYBACK - Stopped","call_id":"1.1","stream_id":"stream:response:1.1"}`,
+		// The caller's own turn.
+		`{"timestamp":"2026-01-30T17:21:58.000Z","level":"debug","event":"Enhanced VAD - Speech started","call_id":"1.1"}`,
+	}
+	a := analyzeEcho(mergeTimeline(lines), &RCAHeader{BargeInPostTTSEndProtectionMs: 100}, 0)
+	if a == nil {
+		t.Fatal("no analysis")
+	}
+	if a.WindowMS != 500 || a.AgentSegments != 3 || a.Onsets != 4 {
+		t.Fatalf("analysis = %+v", a)
+	}
+	if a.Echo != 2 || a.TailEcho != 1 || a.BargeIns != 1 || a.SelfInterruptions != 1 || a.EchoLagP90MS != 180 {
+		t.Fatalf("classes = %+v", a)
+	}
+	if got := a.Events[3].Class; got != onsetTurn {
+		t.Fatalf("caller turn classified %s", got)
+	}
+
+	recs := strings.Join(a.Recommendations, "\n")
+	for _, want := range []string{"initial_protection_ms to at least 300ms", "post_tts_end_protection_ms (now 100ms)", "turn detection threshold", "cut the agent off 1 time(s)"} {
+		if !strings.Contains(recs, want) {
+			t.Errorf("recommendations missing %q:\n%s", want, recs)
+		}
+	}
+	if issues := echoAudioIssues(a); len(issues) != 1 {
+		t.Fatalf("audio issues = %q", issues)
+	}
+
+	// A wider window turns the 2s barge-in into echo.
+	if wide := analyzeEcho(mergeTimeline(lines), nil, 2500*time.Millisecond); wide.BargeIns != 0 || wide.Echo != 3 {
+		t.Fatalf("wide window = %+v", wide)
+	}
+}
+
+func TestAnalyzeEchoWithoutPlayback(t *testing.T) {
+	a := analyzeEcho(mergeTimeline([]string{
+		`{"timestamp":"2026-01-30T17:21:58.000Z","level":"debug","event":"Enhanced VAD - Speech started","call_id":"1.1"}`,
+	}), nil, 0)
+	if a != nil {
+		t.Fatalf("analysis without agent audio = %+v", a)
+	}
+}
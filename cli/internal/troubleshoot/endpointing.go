@@ -0,0 +1,169 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+// Endpointing verdicts.
+const (
+	EndpointOK      = "OK"       // the agent replied once, after the caller finished
+	EndpointCutoff  = "CUTOFF"   // the caller's turn ended during the pause inside it
+	EndpointNoReply = "NO REPLY" // the agent never replied to the test speech
+)
+
+// SpeechSchedule is when a calibration caller speaks, in milliseconds from
+// the call's first engine line: one utterance from StartMS to EndMS with a
+// pause of PauseMS at PauseAtMS (no pause when PauseMS is 0).
+type SpeechSchedule struct {
+	StartMS   int64 `json:"start_ms"`
+	PauseAtMS int64 `json:"pause_at_ms,omitempty"`
+	PauseMS   int64 `json:"pause_ms"`
+	EndMS     int64 `json:"end_ms"`
+}
+
+// EndpointingCall is how the agent ended one calibration caller's turn.
+type EndpointingCall struct {
+	CallID  string `json:"call_id"`
+	PauseMS int64  `json:"pause_ms"`
+	Logged  bool   `json:"logged"`
+	// CallerTurns is the number of caller messages Call History recorded for
+	// the utterance, or -1 when it was not read.
+	CallerTurns int `json:"caller_turns"`
+	// RepliesMS are when the agent started speaking, from the end of the
+	// caller's speech; a negative one started before the caller finished.
+	RepliesMS []int64 `json:"replies_ms"`
+	// ReplyMS runs from the end of the caller's speech to the agent's reply.
+	// DetectionMS is the part of it before the provider ended the turn, when
+	// the provider logs its turn latency.
+	ReplyMS     int64  `json:"reply_ms,omitempty"`
+	DetectionMS *int64 `json:"detection_ms,omitempty"`
+	Verdict     string `json:"verdict"`
+	Finding     string `json:"finding"`
+}
+
+// agentAudioStarts and agentAudioStops bracket the agent speaking, by the
+// kind of audio path that logs them.
+var (
+	agentAudioStarts = map[string]string{
+		logschema.EventGateClosed:    "gate",
+		logschema.EventStreamStarted: "stream",
+		logschema.EventTTSGating:     "tts",
+	}
+	agentAudioStops = map[string]string{
+		logschema.EventGateOpened:       "gate",
+		logschema.EventStreamStopped:    "stream",
+		logschema.EventTTSGatingCleared: "tts",
+	}
+)
+
+// AnalyzeEndpointing reads one calibration call's lines against the caller's
+// schedule. The agent replies when its audio starts after a quiet spell; a
+// reply, a provider turn or a response the caller interrupted between the
+// pause and the end of the utterance, or more than one caller message in
+// Call History, means the turn was cut at the pause. callerTurns is -1 when
+// Call History was not read.
+func AnalyzeEndpointing(callID string, lines []string, s SpeechSchedule, callerTurns int) EndpointingCall {
+	c := EndpointingCall{CallID: callID, PauseMS: s.PauseMS, CallerTurns: callerTurns, RepliesMS: []int64{}}
+	var first time.Time
+	open := map[string]bool{}
+	var replies []int64
+	type latency struct{ at, ms int64 }
+	var latencies []latency
+	earlyTurn := false
+	for _, line := range lines {
+		at, ok := engineLineTime(line)
+		if !ok {
+			continue
+		}
+		_, event, fields, ok := parseLogLine(line)
+		if !ok {
+			continue
+		}
+		if first.IsZero() {
+			first = at
+			c.Logged = true
+		}
+		ms := at.Sub(first).Milliseconds()
+		if kind, ok := agentAudioStarts[event]; ok {
+			if len(open) == 0 {
+				replies = append(replies, ms)
+			}
+			open[kind] = true
+			continue
+		}
+		if kind, ok := agentAudioStops[event]; ok {
+			delete(open, kind)
+			continue
+		}
+		inUtterance := ms > s.StartMS+s.PauseAtMS && ms < s.EndMS
+		switch event {
+		case logschema.EventTurnLatency:
+			if v, err := strconv.ParseFloat(fields["latency_ms"], 64); err == nil {
+				latencies = append(latencies, latency{ms, int64(v)})
+			}
+			if s.PauseMS > 0 && inUtterance {
+				earlyTurn = true
+			}
+		case logschema.EventProviderInterruption, logschema.EventProviderInterruptionNoAudio:
+			if s.PauseMS > 0 && inUtterance {
+				earlyTurn = true
+			}
+		}
+	}
+	if !c.Logged {
+		c.Verdict, c.Finding = EndpointNoReply, "the call never reached the engine; check the dialplan context and ARI"
+		return c
+	}
+
+	replyAt := int64(-1)
+	for _, r := range replies {
+		if r <= s.StartMS {
+			continue // the greeting
+		}
+		c.RepliesMS = append(c.RepliesMS, r-s.EndMS)
+		if r < s.EndMS {
+			earlyTurn = true
+		} else if replyAt < 0 {
+			replyAt = r
+		}
+	}
+	if replyAt >= 0 {
+		c.ReplyMS = replyAt - s.EndMS
+		for _, l := range latencies {
+			if l.at >= replyAt-1000 && l.at <= replyAt+2000 {
+				d := c.ReplyMS - l.ms
+				c.DetectionMS = &d
+				break
+			}
+		}
+	}
+
+	switch {
+	case earlyTurn || callerTurns > 1:
+		c.Verdict = EndpointCutoff
+		if s.PauseMS > 0 {
+			c.Finding = fmt.Sprintf("the %dms pause ended the caller's turn before they finished", s.PauseMS)
+		} else {
+			c.Finding = "the agent replied before the caller finished"
+		}
+	case replyAt < 0:
+		c.Verdict, c.Finding = EndpointNoReply, "the agent did not reply after the caller finished; check the provider's logs for the turn"
+	default:
+		c.Verdict = EndpointOK
+		c.Finding = fmt.Sprintf("replied %.1fs after the caller finished", float64(c.ReplyMS)/1000)
+		if c.DetectionMS != nil {
+			c.Finding += fmt.Sprintf(", %.1fs of it before the turn ended", float64(*c.DetectionMS)/1000)
+		}
+	}
+	return c
+}
+
+// CallTranscript reads the call's caller and agent messages from Call
+// History, in order.
+func CallTranscript(callID string) ([]TranscriptTurn, error) {
+	return loadCallTranscript(callID)
+}
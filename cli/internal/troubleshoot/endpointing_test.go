@@ -0,0 +1,40 @@
+package troubleshoot
+
+import "testing"
+
+func TestAnalyzeEndpointing(t *testing.T) {
+	s := SpeechSchedule{StartMS: 9000, PauseAtMS: 1500, PauseMS: 700, EndMS: 12500}
+	kept := []string{
+		`{"timestamp":"2026-01-30T17:20:00.000Z","level":"info","event":"RCA_CALL_START","call_id":"1.1"}`,
+		// Greeting.
+		`{"timestamp":"2026-01-30T17:20:00.800Z","level":"info","event":"🚪 AUDIO GATE CLOSED - Agent started speaking","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:20:01.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:20:04.000Z","level":"info","event":"🎵 STREAMING PLAYBACK - Stopped","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:20:04.100Z","level":"info","event":"🔓 AUDIO GATE OPENED - Agent finished speaking","call_id":"1.1"}`,
+		// Reply 1.4s after the caller finished; 0.8s of it after the turn ended.
+		`{"timestamp":"2026-01-30T17:20:13.900Z","level":"info","event":"🚪 AUDIO GATE CLOSED - Agent started speaking","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:20:13.950Z","level":"info","event":"Turn latency recorded","call_id":"1.1","latency_ms":800.4}`,
+	}
+	c := AnalyzeEndpointing("1.1", kept, s, 1)
+	if c.Verdict != EndpointOK || c.ReplyMS != 1400 || c.DetectionMS == nil || *c.DetectionMS != 600 || len(c.RepliesMS) != 1 {
+		t.Fatalf("kept turn = %+v", c)
+	}
+
+	cut := append(kept[:5:5],
+		`{"timestamp":"2026-01-30T17:20:11.200Z","level":"info","event":"Turn latency recorded","call_id":"1.2","latency_ms":700}`,
+		`{"timestamp":"2026-01-30T17:20:11.300Z","level":"info","event":"🎤 User interruption detected, cancelling response","call_id":"1.2"}`,
+		`{"timestamp":"2026-01-30T17:20:14.000Z","level":"info","event":"🚪 AUDIO GATE CLOSED - Agent started speaking","call_id":"1.2"}`,
+	)
+	if c := AnalyzeEndpointing("1.2", cut, s, -1); c.Verdict != EndpointCutoff {
+		t.Fatalf("cut turn = %+v", c)
+	}
+	if c := AnalyzeEndpointing("1.3", kept, s, 2); c.Verdict != EndpointCutoff {
+		t.Fatalf("split transcript = %+v", c)
+	}
+	if c := AnalyzeEndpointing("1.4", kept[:5], s, 1); c.Verdict != EndpointNoReply || c.ReplyMS != 0 {
+		t.Fatalf("no reply = %+v", c)
+	}
+	if c := AnalyzeEndpointing("1.5", nil, s, -1); c.Verdict != EndpointNoReply || c.Logged {
+		t.Fatalf("not logged = %+v", c)
+	}
+}
@@ -0,0 +1,323 @@
+package troubleshoot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// contextVersionsFile is the history of context versions in TrendDir.
+const contextVersionsFile = "context_versions.jsonl"
+
+// experimentAlpha is the two-sided significance level for agent experiment.
+const experimentAlpha = 0.05
+
+// ContextVersion records that a context had a given version from At on. A
+// version is the fingerprint of its file in config/contexts/, or a label given
+// with agent experiment tag for contexts edited elsewhere (agents.db).
+type ContextVersion struct {
+	At      time.Time `json:"at"`
+	Context string    `json:"context"`
+	Version string    `json:"version"`
+	Source  string    `json:"source,omitempty"` // file name, or "tag"
+}
+
+func contextVersionAt(v ContextVersion) time.Time { return v.At }
+
+// RecordContextVersions appends the versions that differ from the latest one
+// recorded for their context and returns them; re-recording an unchanged
+// context is a no-op.
+func RecordContextVersions(versions []ContextVersion) ([]ContextVersion, error) {
+	history, err := LoadContextVersions()
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]string{}
+	for _, v := range history {
+		current[v.Context] = v.Version
+	}
+	var added []ContextVersion
+	for _, v := range versions {
+		if v.Context == "" || v.Version == "" || current[v.Context] == v.Version {
+			continue
+		}
+		if err := RecordTrendSeries(contextVersionsFile, v, contextVersionAt); err != nil {
+			return added, err
+		}
+		current[v.Context] = v.Version
+		added = append(added, v)
+	}
+	return added, nil
+}
+
+// LoadContextVersions returns every recorded context version, oldest first.
+func LoadContextVersions() ([]ContextVersion, error) {
+	return LoadTrendSeries(contextVersionsFile, time.Time{}, contextVersionAt)
+}
+
+// ExperimentCall is one finished call with the metrics agent experiment
+// compares: its quality score when agent rca analyzed it, and the average turn
+// latency and duration Call History recorded.
+type ExperimentCall struct {
+	CallID          string    `json:"call_id"`
+	At              time.Time `json:"at"`
+	Context         string    `json:"context"`
+	Version         string    `json:"version,omitempty"` // empty before the context's first recorded version
+	Score           *float64  `json:"score,omitempty"`
+	TurnLatencyMS   float64   `json:"avg_turn_latency_ms,omitempty"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+}
+
+// LoadExperimentCalls returns the calls Call History recorded since since,
+// tagged with their context's version and quality score.
+func LoadExperimentCalls(since time.Time) ([]ExperimentCall, error) {
+	calls, err := loadCallHistoryRange(since)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := LoadContextVersions()
+	if err != nil {
+		return nil, err
+	}
+	samples, err := LoadQualitySamples(since.Add(-24 * time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	TagExperimentCalls(calls, versions, samples)
+	return calls, nil
+}
+
+// TagExperimentCalls sets each call's version (the latest recorded for its
+// context at or before the call started) and quality score.
+func TagExperimentCalls(calls []ExperimentCall, versions []ContextVersion, samples []QualitySample) {
+	scores := map[string]float64{}
+	for _, s := range samples {
+		scores[s.CallID] = s.Score
+	}
+	for i := range calls {
+		c := &calls[i]
+		c.Version = ""
+		for _, v := range versions {
+			if v.Context == c.Context && !v.At.After(c.At) {
+				c.Version = v.Version
+			}
+		}
+		if s, ok := scores[c.CallID]; ok {
+			c.Score = &s
+		}
+	}
+}
+
+// Variant selects the calls of one side of an experiment: a context, or one
+// version of it (a prefix of the version is enough).
+type Variant struct {
+	Context string `json:"context"`
+	Version string `json:"version,omitempty"`
+}
+
+// ParseVariant parses "context" or "context@version".
+func ParseVariant(s string) (Variant, error) {
+	name, version, _ := strings.Cut(strings.TrimSpace(s), "@")
+	v := Variant{Context: strings.TrimSpace(name), Version: strings.TrimSpace(version)}
+	if v.Context == "" {
+		return v, fmt.Errorf("variant %q has no context name", s)
+	}
+	if strings.Contains(s, "@") && v.Version == "" {
+		return v, fmt.Errorf("variant %q has no version after @", s)
+	}
+	return v, nil
+}
+
+func (v Variant) String() string {
+	if v.Version == "" {
+		return v.Context
+	}
+	return v.Context + "@" + v.Version
+}
+
+// Matches reports whether the call belongs to the variant.
+func (v Variant) Matches(c ExperimentCall) bool {
+	if c.Context != v.Context {
+		return false
+	}
+	return v.Version == "" || (c.Version != "" && strings.HasPrefix(c.Version, v.Version))
+}
+
+// ExperimentMetric is a metric agent experiment compares; Better is +1 when
+// higher is better, -1 when lower is better and 0 when neither is.
+type ExperimentMetric struct {
+	Name   string
+	Label  string
+	Better int
+}
+
+// ExperimentMetrics are the metrics compared between variants.
+var ExperimentMetrics = []ExperimentMetric{
+	{Name: "score", Label: "quality score", Better: 1},
+	{Name: "turn_latency_ms", Label: "turn latency", Better: -1},
+	{Name: "duration_s", Label: "call duration", Better: 0},
+}
+
+func (c ExperimentCall) metricValue(metric string) (float64, bool) {
+	switch metric {
+	case "score":
+		if c.Score == nil {
+			return 0, false
+		}
+		return *c.Score, true
+	case "turn_latency_ms":
+		return c.TurnLatencyMS, c.TurnLatencyMS > 0
+	case "duration_s":
+		return c.DurationSeconds, c.DurationSeconds > 0
+	}
+	return 0, false
+}
+
+// MetricComparison is one metric of variant A against variant B.
+type MetricComparison struct {
+	Metric  string   `json:"metric"`
+	NA      int      `json:"n_a"`
+	NB      int      `json:"n_b"`
+	MedianA *float64 `json:"median_a,omitempty"`
+	MedianB *float64 `json:"median_b,omitempty"`
+	// P is the two-sided Mann-Whitney p-value; nil with fewer than 5 calls a side.
+	P *float64 `json:"p_value,omitempty"`
+	// Winner is "a" or "b" when the difference is significant and the metric
+	// has a better direction; Different is set whenever it is significant.
+	Winner    string `json:"winner,omitempty"`
+	Different bool   `json:"different"`
+}
+
+// ExperimentReport compares two variants over the same calls.
+type ExperimentReport struct {
+	A       Variant            `json:"a"`
+	B       Variant            `json:"b"`
+	CallsA  int                `json:"calls_a"`
+	CallsB  int                `json:"calls_b"`
+	Metrics []MetricComparison `json:"metrics"`
+}
+
+// CompareVariants splits calls into the two variants and compares each metric
+// with a two-sided Mann-Whitney U test, as agent trend does for regressions.
+// A call matching both variants (overlapping selections) counts for neither.
+func CompareVariants(calls []ExperimentCall, a, b Variant) ExperimentReport {
+	rep := ExperimentReport{A: a, B: b}
+	var sideA, sideB []ExperimentCall
+	for _, c := range calls {
+		inA, inB := a.Matches(c), b.Matches(c)
+		switch {
+		case inA && !inB:
+			sideA = append(sideA, c)
+		case inB && !inA:
+			sideB = append(sideB, c)
+		}
+	}
+	rep.CallsA, rep.CallsB = len(sideA), len(sideB)
+	for _, m := range ExperimentMetrics {
+		va, vb := experimentValues(sideA, m.Name), experimentValues(sideB, m.Name)
+		mc := MetricComparison{Metric: m.Name, NA: len(va), NB: len(vb)}
+		if len(va) > 0 {
+			med := median(va)
+			mc.MedianA = &med
+		}
+		if len(vb) > 0 {
+			med := median(vb)
+			mc.MedianB = &med
+		}
+		if len(va) >= regressionMinCalls && len(vb) >= regressionMinCalls {
+			p := math.Min(1, 2*math.Min(mannWhitneyGreater(va, vb), mannWhitneyGreater(vb, va)))
+			mc.P = &p
+			mc.Different = p < experimentAlpha && *mc.MedianA != *mc.MedianB
+			if mc.Different && m.Better != 0 {
+				mc.Winner = "b"
+				if (*mc.MedianA > *mc.MedianB) == (m.Better > 0) {
+					mc.Winner = "a"
+				}
+			}
+		}
+		rep.Metrics = append(rep.Metrics, mc)
+	}
+	return rep
+}
+
+func experimentValues(calls []ExperimentCall, metric string) []float64 {
+	var out []float64
+	for _, c := range calls {
+		if v, ok := c.metricValue(metric); ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// loadCallHistoryRange lists the calls Call History recorded since since with
+// one docker exec, oldest first. Unlike the per-call lookups it fails when
+// Call History is missing, since it is the only source of the calls.
+func loadCallHistoryRange(since time.Time) ([]ExperimentCall, error) {
+	const script = `
+import json, os, sqlite3, sys
+p = os.environ.get("CALL_HISTORY_DB_PATH", "/app/data/call_history.db")
+if not os.path.exists(p):
+    print("null")
+    raise SystemExit(0)
+c = sqlite3.connect(p)
+c.row_factory = sqlite3.Row
+cols = {r[1] for r in c.execute("PRAGMA table_info(call_records)")}
+if not {"call_id", "start_time", "context_name"} <= cols:
+    print("null")
+    raise SystemExit(0)
+keys = ["call_id", "start_time", "context_name"] + [k for k in ("avg_turn_latency_ms", "duration_seconds") if k in cols]
+q = "SELECT " + ", ".join(keys) + " FROM call_records WHERE start_time >= ? ORDER BY start_time"
+print(json.dumps([{k: r[k] for k in keys if r[k] is not None} for r in c.execute(q, (sys.argv[1],))], separators=(",", ":")))
+`
+	// start_time is stored as a UTC isoformat() string, so the bound compares as text.
+	bound := since.UTC().Format("2006-01-02T15:04:05")
+	out, err := dockerapi.ExecCombinedOutput(context.Background(), deployment.EngineContainer(), "python3", "-c", script, bound)
+	if err != nil {
+		return nil, fmt.Errorf("call history query failed: %w (%s)", err, strings.TrimSpace(string(out)))
+	}
+	trimmed := strings.TrimSpace(string(out))
+	if trimmed == "null" {
+		return nil, errors.New("call history is not available (no call_records table with context_name in call_history.db)")
+	}
+	var rows []struct {
+		CallID          string  `json:"call_id"`
+		StartTime       string  `json:"start_time"`
+		ContextName     string  `json:"context_name"`
+		TurnLatencyMS   float64 `json:"avg_turn_latency_ms"`
+		DurationSeconds float64 `json:"duration_seconds"`
+	}
+	if err := json.Unmarshal([]byte(trimmed), &rows); err != nil {
+		return nil, fmt.Errorf("invalid call history response: %w", err)
+	}
+	calls := make([]ExperimentCall, 0, len(rows))
+	for _, r := range rows {
+		at, ok := parseHistoryTime(r.StartTime)
+		if !ok || r.ContextName == "" {
+			continue
+		}
+		calls = append(calls, ExperimentCall{CallID: r.CallID, At: at, Context: r.ContextName, TurnLatencyMS: r.TurnLatencyMS, DurationSeconds: r.DurationSeconds})
+	}
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].At.Before(calls[j].At) })
+	return calls, nil
+}
+
+// parseHistoryTime parses a Call History timestamp; ones without an offset
+// (older rows) are UTC.
+func parseHistoryTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05.999999999", s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,103 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecordContextVersionsSkipsUnchanged(t *testing.T) {
+	t.Setenv("AAVA_QUALITY_TREND", t.TempDir())
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	added, err := RecordContextVersions([]ContextVersion{
+		{At: t0, Context: "sales", Version: "aaaa1111"},
+		{At: t0, Context: "support", Version: "bbbb2222"},
+	})
+	if err != nil || len(added) != 2 {
+		t.Fatalf("first record: %v %v", added, err)
+	}
+	added, err = RecordContextVersions([]ContextVersion{
+		{At: t0.Add(time.Hour), Context: "sales", Version: "aaaa1111"},
+		{At: t0.Add(time.Hour), Context: "support", Version: "cccc3333"},
+	})
+	if err != nil || len(added) != 1 || added[0].Version != "cccc3333" {
+		t.Fatalf("second record: %v %v", added, err)
+	}
+	all, err := LoadContextVersions()
+	if err != nil || len(all) != 3 {
+		t.Fatalf("history = %v %v", all, err)
+	}
+}
+
+func TestParseVariant(t *testing.T) {
+	for in, want := range map[string]Variant{
+		"sales":          {Context: "sales"},
+		"sales@aaaa":     {Context: "sales", Version: "aaaa"},
+		" sales @ v2 ":   {Context: "sales", Version: "v2"},
+		"sales_b@1a2b3c": {Context: "sales_b", Version: "1a2b3c"},
+	} {
+		got, err := ParseVariant(in)
+		if err != nil || got != want {
+			t.Errorf("ParseVariant(%q) = %+v, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"", "@aaaa", "sales@"} {
+		if _, err := ParseVariant(in); err == nil {
+			t.Errorf("ParseVariant(%q) accepted", in)
+		}
+	}
+}
+
+func TestCompareVariants(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	change := t0.Add(24 * time.Hour)
+	versions := []ContextVersion{
+		{At: t0, Context: "sales", Version: "aaaa1111"},
+		{At: change, Context: "sales", Version: "bbbb2222"},
+	}
+	var calls []ExperimentCall
+	var samples []QualitySample
+	for i := 0; i < 8; i++ {
+		// Before the change: lower scores, slower turns; durations overlap.
+		before := ExperimentCall{CallID: fmt.Sprintf("b%d", i), At: t0.Add(time.Duration(i+1) * time.Hour), Context: "sales", TurnLatencyMS: float64(1500 + 10*i), DurationSeconds: float64(100 + i)}
+		after := ExperimentCall{CallID: fmt.Sprintf("a%d", i), At: change.Add(time.Duration(i+1) * time.Hour), Context: "sales", TurnLatencyMS: float64(900 + 10*i), DurationSeconds: float64(100 + i)}
+		calls = append(calls, before, after)
+		samples = append(samples,
+			QualitySample{CallID: before.CallID, Score: float64(60 + i)},
+			QualitySample{CallID: after.CallID, Score: float64(85 + i)})
+	}
+	// Another context and a call before any recorded version stay out.
+	calls = append(calls,
+		ExperimentCall{CallID: "other", At: change.Add(time.Hour), Context: "support", TurnLatencyMS: 100},
+		ExperimentCall{CallID: "early", At: t0.Add(-time.Hour), Context: "sales", TurnLatencyMS: 100})
+	TagExperimentCalls(calls, versions, samples)
+
+	rep := CompareVariants(calls, Variant{Context: "sales", Version: "aaaa"}, Variant{Context: "sales", Version: "bbbb2222"})
+	if rep.CallsA != 8 || rep.CallsB != 8 {
+		t.Fatalf("calls = %d/%d", rep.CallsA, rep.CallsB)
+	}
+	got := map[string]MetricComparison{}
+	for _, m := range rep.Metrics {
+		got[m.Metric] = m
+	}
+	if m := got["score"]; m.P == nil || !m.Different || m.Winner != "b" || *m.MedianA != 63.5 || *m.MedianB != 88.5 {
+		t.Errorf("score = %+v", m)
+	}
+	if m := got["turn_latency_ms"]; !m.Different || m.Winner != "b" {
+		t.Errorf("turn latency = %+v", m)
+	}
+	if m := got["duration_s"]; m.Different || m.Winner != "" || m.P == nil || *m.P < 0.9 {
+		t.Errorf("duration = %+v", m)
+	}
+
+	// Too few calls on one side: medians but no p-value.
+	rep = CompareVariants(calls[:6], Variant{Context: "sales", Version: "aaaa"}, Variant{Context: "sales", Version: "bbbb"})
+	if m := rep.Metrics[0]; m.P != nil || m.MedianA == nil || m.NB != 3 {
+		t.Errorf("small sample = %+v", m)
+	}
+	// A whole context against one of its versions: shared calls count for neither.
+	rep = CompareVariants(calls, Variant{Context: "sales"}, Variant{Context: "sales", Version: "bbbb"})
+	if rep.CallsA != 9 || rep.CallsB != 0 {
+		t.Errorf("overlap calls = %d/%d", rep.CallsA, rep.CallsB)
+	}
+}
@@ -3,13 +3,18 @@ package troubleshoot
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/pjsip"
 )
 
 // AnalyzeFormatAlignment checks config vs runtime format/sampling alignment.
 //
-// IMPORTANT: RCA is log-driven. We do not shell out to read config files here.
-// Config-side values should come from an explicit log header (RCA_CALL_START).
-func AnalyzeFormatAlignment(metrics *CallMetrics, header *RCAHeader) *FormatAlignment {
+// Config-side values come from the call's RCA_CALL_START log header, not from
+// config files. This function reads nothing itself; codecs is the optional pjsip
+// codec audit the caller collected with loadCodecAudit, which reads the current
+// pjsip config via docker exec into the Asterisk container (or /etc/asterisk on
+// the host) when it is reachable. nil skips that signal.
+func AnalyzeFormatAlignment(metrics *CallMetrics, header *RCAHeader, codecs *pjsip.CodecAudit) *FormatAlignment {
 	alignment := &FormatAlignment{
 		Issues: []string{},
 	}
@@ -40,6 +45,17 @@ func AnalyzeFormatAlignment(metrics *CallMetrics, header *RCAHeader) *FormatAlig
 	// Detect misalignments
 	detectMisalignments(alignment)
 
+	// Trunk codec negotiation (pjsip allow lists vs. expected format chain)
+	if codecs != nil {
+		alignment.CodecAudit = codecs
+		if len(codecs.Issues) > 0 {
+			alignment.CodecNegotiationIssue = true
+			for _, issue := range codecs.Issues {
+				alignment.Issues = append(alignment.Issues, "Codec negotiation (current pjsip config): "+issue)
+			}
+		}
+	}
+
 	return alignment
 }
 
@@ -160,6 +176,16 @@ func (fa *FormatAlignment) FormatForLLM() string {
 		}
 	}
 
+	if fa.CodecAudit != nil {
+		out.WriteString(fmt.Sprintf("  Trunk codecs (%s, expected %s@%dHz):",
+			fa.CodecAudit.Source, fa.CodecAudit.Expected.Codec, fa.CodecAudit.Expected.SampleRate))
+		if fa.CodecNegotiationIssue {
+			out.WriteString(" ❌ MISMATCH\n")
+		} else {
+			out.WriteString(" ✅\n")
+		}
+	}
+
 	if len(fa.Issues) > 0 {
 		out.WriteString("\n⚠️  ALIGNMENT ISSUES:\n")
 		for i, issue := range fa.Issues {
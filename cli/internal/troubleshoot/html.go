@@ -0,0 +1,465 @@
+package troubleshoot
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/knowledge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/otlp"
+)
+
+// Chart geometry of the HTML report, in SVG user units.
+const (
+	svgWidth     = 960
+	svgLabelW    = 110
+	svgRowH      = 22
+	svgAxisH     = 22
+	svgBufferH   = 220
+	svgLaneH     = 5
+	htmlMaxTicks = 10
+)
+
+// htmlPage is what the HTML report template renders.
+type htmlPage struct {
+	CallID     string
+	Result     string
+	Score      string
+	Generated  string
+	CLIVersion string
+	Redacted   bool
+
+	Facts       []htmlFact
+	KnownIssues []knowledge.Hit
+	Findings    []htmlList
+	Diagnosis   string
+
+	Timeline template.HTML
+	Problems []htmlLogLine
+
+	Summary      []htmlFact
+	Metrics      []htmlFact
+	Conversation *ConversationQuality
+
+	Buffer         template.HTML
+	BufferStats    []htmlFact
+	BufferFindings []string
+
+	Transcript     []htmlTurn
+	TranscriptNote string
+}
+
+type htmlFact struct{ Label, Value string }
+
+type htmlList struct {
+	Title, Class string
+	Items        []string
+}
+
+type htmlLogLine struct{ Offset, Severity, Source, Message string }
+
+type htmlTurn struct{ Speaker, Class, Text string }
+
+// WriteHTML writes the analyzed call as one self-contained HTML page: the
+// findings, a chart of the call's turns, the metric tables, the jitter buffer
+// graph and the transcript from Call History. It needs no network access to
+// view, so it can be attached to a ticket or mailed to a customer. The
+// transcript is masked like the rest of the report when SetRedactor is set.
+func (r *Runner) WriteHTML(w io.Writer, cliVersion string) error {
+	if r.analysis == nil {
+		return errors.New("no analyzed call")
+	}
+	turns, err := loadCallTranscript(r.analysis.CallID)
+	for i := range turns {
+		turns[i].Content = r.redactor.String(turns[i].Content)
+	}
+	return r.writeHTML(w, cliVersion, time.Now(), turns, err)
+}
+
+func (r *Runner) writeHTML(w io.Writer, cliVersion string, now time.Time, turns []TranscriptTurn, turnsErr error) error {
+	a := r.analysis
+	p := htmlPage{
+		CallID:      a.CallID,
+		Result:      resultLabel(r.ExitCode()),
+		Generated:   now.Format("2006-01-02 15:04:05 MST"),
+		CLIVersion:  cliVersion,
+		Redacted:    r.redactor != nil,
+		KnownIssues: a.KnownIssues,
+	}
+	if metricsHasEvidence(a.Metrics) {
+		score, _ := callQualityScore(a)
+		p.Score = fmt.Sprintf("%.0f", score)
+	}
+	p.Facts = callFacts(a)
+
+	findings := []htmlList{
+		{Title: "Errors", Class: "error", Items: a.Errors},
+		{Title: "Warnings", Class: "warning", Items: a.Warnings},
+		{Title: "Audio issues", Class: "warning", Items: a.AudioIssues},
+	}
+	if sa := a.SymptomAnalysis; sa != nil {
+		findings = append(findings,
+			htmlList{Title: "Likely causes", Items: sa.RootCauses},
+			htmlList{Title: "Recommended actions", Items: sa.Actions})
+	}
+	for _, f := range findings {
+		if len(f.Items) > 0 {
+			p.Findings = append(p.Findings, f)
+		}
+	}
+	if r.llm != nil {
+		p.Diagnosis = strings.TrimSpace(r.llm.Analysis)
+	}
+
+	timeline := a.Timeline
+	if len(timeline) == 0 {
+		timeline = mergeTimeline(strings.Split(r.logData, "\n"))
+	}
+	if spans := buildCallTrace(a, timeline); len(spans) > 0 {
+		p.Timeline = timelineSVG(spans)
+		for _, e := range spans[0].Events {
+			p.Problems = append(p.Problems, htmlLogLine{
+				Offset:   "+" + formatOffset(e.Time.Sub(spans[0].Start)),
+				Severity: fmt.Sprint(e.Attributes["log.severity"]),
+				Source:   fmt.Sprint(e.Attributes["log.source"]),
+				Message:  logMessage(fmt.Sprint(e.Attributes["log.message"])),
+			})
+		}
+	}
+
+	if ch := a.CallHistory; ch != nil {
+		add := func(label, value string) {
+			if value != "" && value != "0" {
+				p.Summary = append(p.Summary, htmlFact{label, value})
+			}
+		}
+		add("Turns", fmt.Sprint(ch.TotalTurns))
+		if ch.AverageTurnLatencyMS > 0 {
+			add("Average turn latency", fmt.Sprintf("%.0f ms", ch.AverageTurnLatencyMS))
+			add("Slowest turn", fmt.Sprintf("%.0f ms", ch.MaximumTurnLatencyMS))
+		}
+		add("Barge-ins", fmt.Sprint(ch.BargeInCount))
+		add("Routing", ch.RoutingMethod)
+		if ch.CodecAlignmentOK != nil {
+			add("Codecs aligned", fmt.Sprint(*ch.CodecAlignmentOK))
+		}
+	}
+	for _, n := range metricNames(a.Metrics) {
+		name, value, _ := strings.Cut(n, "=")
+		p.Metrics = append(p.Metrics, htmlFact{name, value})
+	}
+	p.Conversation = a.Conversation
+
+	if v := buildBufferView(a.CallID, a.Header, mergeTimeline(strings.Split(r.logData, "\n"))); len(v.Samples) > 0 {
+		p.Buffer = bufferSVG(v)
+		p.BufferStats = []htmlFact{
+			{"Streams", fmt.Sprint(v.Streams)},
+			{"Underflows", fmt.Sprint(v.Underflows)},
+			{"At capacity", fmt.Sprint(v.Full)},
+			{"Resets", fmt.Sprint(v.Resets)},
+			{"Gate closures", fmt.Sprint(v.GateClosures)},
+			{"Barge-ins", fmt.Sprint(v.BargeIns)},
+		}
+		p.BufferFindings = v.Findings
+	}
+
+	switch {
+	case turnsErr != nil:
+		p.TranscriptNote = "Transcript not available: " + turnsErr.Error()
+	case len(turns) == 0:
+		p.TranscriptNote = "No transcript in Call History for this call."
+	}
+	for _, t := range turns {
+		turn := htmlTurn{Speaker: "Caller", Class: "caller", Text: t.Content}
+		if t.Role == "assistant" {
+			turn.Speaker, turn.Class = "Agent", "agent"
+		}
+		p.Transcript = append(p.Transcript, turn)
+	}
+	return htmlReport.Execute(w, p)
+}
+
+// callFacts are the header lines: who called whom, through what, and how it
+// ended.
+func callFacts(a *Analysis) []htmlFact {
+	var facts []htmlFact
+	add := func(label, value string) {
+		if v := strings.TrimSpace(value); v != "" && v != "unknown" {
+			facts = append(facts, htmlFact{label, v})
+		}
+	}
+	if h := a.Header; h != nil {
+		add("Caller", strings.TrimSpace(h.CallerName+" "+h.CallerNumber))
+		add("Called", h.CalledNumber)
+		add("Context", h.ContextName)
+		add("Provider", h.ProviderName)
+		add("Pipeline", h.PipelineName)
+	}
+	add("Transport", a.AudioTransport)
+	if ch := a.CallHistory; ch != nil {
+		add("Started", ch.StartTime)
+		if ch.DurationSeconds > 0 {
+			add("Duration", formatOffset(time.Duration(ch.DurationSeconds*float64(time.Second))))
+		}
+		add("Outcome", ch.Outcome)
+	}
+	if e := a.Ending; e != nil {
+		add("Ending", e.Explanation)
+	}
+	if m := a.AMD; m != nil && m.Status != "" {
+		add("AMD", m.Status+" "+m.Cause)
+	}
+	return facts
+}
+
+// logMessage is an engine line's event, or the line as logged.
+func logMessage(line string) string {
+	if _, event, _, ok := parseLogLine(line); ok && event != "" {
+		return event
+	}
+	return line
+}
+
+// formatOffset renders a duration as m:ss.s, or s.s under a minute.
+func formatOffset(d time.Duration) string {
+	s := d.Seconds()
+	if s < 60 {
+		return fmt.Sprintf("%.1fs", s)
+	}
+	return fmt.Sprintf("%d:%04.1f", int(s)/60, math.Mod(s, 60))
+}
+
+// tickStep picks a round axis interval giving at most htmlMaxTicks ticks.
+func tickStep(total time.Duration) time.Duration {
+	for _, s := range []time.Duration{time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute} {
+		if total/s <= htmlMaxTicks {
+			return s
+		}
+	}
+	return 30 * time.Minute
+}
+
+// svgAxis draws the time axis across the top of a chart.
+func svgAxis(b *strings.Builder, total time.Duration, height int, x func(time.Duration) float64) {
+	for t := time.Duration(0); t <= total; t += tickStep(total) {
+		fmt.Fprintf(b, `<line class="grid" x1="%.1f" y1="%d" x2="%.1f" y2="%d"/>`, x(t), svgAxisH-6, x(t), height)
+		fmt.Fprintf(b, `<text class="tick" x="%.1f" y="%d">+%s</text>`, x(t), svgAxisH-9, formatOffset(t))
+	}
+}
+
+// timelineSVG charts the call's turns from its trace: one row per turn with
+// STT, LLM and TTS lanes, and a row of error and warning log lines above.
+func timelineSVG(spans []otlp.Span) template.HTML {
+	call := spans[0]
+	total := call.End.Sub(call.Start)
+	if total <= 0 {
+		total = time.Second
+	}
+	plotW := float64(svgWidth - svgLabelW - 10)
+	x := func(d time.Duration) float64 { return svgLabelW + float64(d)/float64(total)*plotW }
+	at := func(t time.Time) float64 { return x(t.Sub(call.Start)) }
+
+	rows := 1
+	for _, s := range spans[1:] {
+		if s.ParentID == call.SpanID {
+			rows++
+		}
+	}
+	height := svgAxisH + rows*svgRowH + 4
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="Call timeline">`, svgWidth, height)
+	svgAxis(&b, total, height, x)
+
+	y := svgAxisH
+	fmt.Fprintf(&b, `<text class="label" x="4" y="%d">log</text>`, y+15)
+	for _, e := range call.Events {
+		class := "warn"
+		if e.Attributes["log.severity"] == "error" {
+			class = "err"
+		}
+		fmt.Fprintf(&b, `<line class="%s" x1="%.1f" y1="%d" x2="%.1f" y2="%d"><title>+%s %s</title></line>`,
+			class, at(e.Time), y+3, at(e.Time), y+svgRowH-3, formatOffset(e.Time.Sub(call.Start)), html.EscapeString(logMessage(fmt.Sprint(e.Attributes["log.message"]))))
+	}
+
+	lanes := map[string]int{"STT": 0, "LLM": 1, "TTS": 2}
+	var turn otlp.Span
+	for _, s := range spans[1:] {
+		if s.ParentID == call.SpanID {
+			turn = s
+			y += svgRowH
+			label := s.Name
+			if ms, ok := s.Attributes["aava.turn.latency_ms"].(float64); ok {
+				label += fmt.Sprintf(" · %.0fms", ms)
+			}
+			fmt.Fprintf(&b, `<text class="label" x="4" y="%d">%s</text>`, y+15, html.EscapeString(label))
+			fmt.Fprintf(&b, `<rect class="turn" x="%.1f" y="%d" width="%.1f" height="%d"><title>%s: +%s to +%s</title></rect>`,
+				at(s.Start), y+2, math.Max(at(s.End)-at(s.Start), 2), svgRowH-4,
+				html.EscapeString(s.Name), formatOffset(s.Start.Sub(call.Start)), formatOffset(s.End.Sub(call.Start)))
+			continue
+		}
+		if s.ParentID != turn.SpanID {
+			continue
+		}
+		lane, ok := lanes[s.Name]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, `<rect class="%s" x="%.1f" y="%d" width="%.1f" height="%d"><title>%s %s</title></rect>`,
+			strings.ToLower(s.Name), at(s.Start), y+3+lane*(svgLaneH+1), math.Max(at(s.End)-at(s.Start), 3), svgLaneH,
+			s.Name, formatOffset(s.End.Sub(s.Start)))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// bufferSVG plots the jitter buffer fill level against its thresholds, with
+// underflows, resets, gate changes and barge-ins marked.
+func bufferSVG(v *BufferView) template.HTML {
+	var start, end time.Time
+	peak := math.Max(v.CapacityMS, math.Max(v.MinStartMS, v.LowWatermarkMS))
+	for _, s := range v.Samples {
+		start, end = widenSpan(start, end, s.Time)
+		peak = math.Max(peak, s.MS)
+	}
+	for _, m := range v.Marks {
+		start, end = widenSpan(start, end, m.Time)
+	}
+	total := end.Sub(start)
+	if total <= 0 {
+		total = time.Second
+	}
+	if peak <= 0 {
+		peak = 1
+	}
+	peak *= 1.1
+	plotW := float64(svgWidth - svgLabelW - 10)
+	plotTop, plotBottom := float64(svgAxisH), float64(svgBufferH-6)
+	x := func(d time.Duration) float64 { return svgLabelW + float64(d)/float64(total)*plotW }
+	y := func(ms float64) float64 { return plotBottom - ms/peak*(plotBottom-plotTop) }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="Jitter buffer fill level">`, svgWidth, svgBufferH)
+	svgAxis(&b, total, svgBufferH, x)
+	fmt.Fprintf(&b, `<text class="label" x="4" y="%.1f">%.0f ms</text>`, plotTop+10, peak)
+	fmt.Fprintf(&b, `<text class="label" x="4" y="%.1f">0 ms</text>`, plotBottom)
+	for _, th := range []struct {
+		name string
+		ms   float64
+	}{{"capacity", v.CapacityMS}, {"min start", v.MinStartMS}, {"low watermark", v.LowWatermarkMS}} {
+		if th.ms <= 0 {
+			continue
+		}
+		fmt.Fprintf(&b, `<line class="threshold" x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, svgLabelW, y(th.ms), svgWidth-10, y(th.ms))
+		fmt.Fprintf(&b, `<text class="tick" x="%d" y="%.1f">%s %.0fms</text>`, svgWidth-60, y(th.ms)-3, th.name, th.ms)
+	}
+	for _, m := range v.Marks {
+		if m.Kind == markStreamStart {
+			continue
+		}
+		title := m.Kind
+		if m.Detail != "" {
+			title += ": " + m.Detail
+		}
+		fmt.Fprintf(&b, `<line class="mark %s" x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"><title>+%s %s</title></line>`,
+			strings.ReplaceAll(m.Kind, "_", "-"), x(m.Time.Sub(start)), plotTop, x(m.Time.Sub(start)), plotBottom,
+			formatOffset(m.Time.Sub(start)), html.EscapeString(title))
+	}
+	points := make([]string, 0, len(v.Samples))
+	for _, s := range v.Samples {
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x(s.Time.Sub(start)), y(s.MS)))
+	}
+	fmt.Fprintf(&b, `<polyline class="fill" points="%s"/>`, strings.Join(points, " "))
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+var htmlReport = template.Must(template.New("rca").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Call {{.CallID}}: {{.Result}}</title>
+<style>
+body { font: 14px/1.5 -apple-system, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; color: #1f2328; margin: 0 auto; max-width: 1000px; padding: 24px; }
+h1 { font-size: 22px; margin: 0 0 4px; }
+h2 { font-size: 17px; border-bottom: 1px solid #d0d7de; padding-bottom: 4px; margin-top: 32px; }
+h3 { font-size: 14px; margin: 16px 0 4px; }
+.meta { color: #656d76; font-size: 12px; }
+.badge { display: inline-block; padding: 2px 10px; border-radius: 12px; font-weight: 600; color: #fff; }
+.PASS { background: #1a7f37; } .WARN { background: #9a6700; } .FAIL { background: #cf222e; }
+.score { font-size: 15px; margin-left: 12px; }
+table { border-collapse: collapse; margin: 8px 0; }
+td, th { border: 1px solid #d0d7de; padding: 3px 10px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+table.facts th { width: 160px; }
+td.num { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+ul { margin: 4px 0; padding-left: 22px; }
+li.error { color: #cf222e; } li.warning { color: #9a6700; }
+.issue { border-left: 4px solid #9a6700; background: #fff8c5; padding: 6px 12px; margin: 8px 0; }
+pre { white-space: pre-wrap; background: #f6f8fa; padding: 10px; border-radius: 6px; }
+.chart { width: 100%; height: auto; font-size: 11px; }
+.chart .grid { stroke: #eaeef2; } .chart .tick { fill: #656d76; } .chart .label { fill: #1f2328; }
+.chart .turn { fill: #eaeef2; }
+.chart .stt, .legend .stt { fill: #0969da; background: #0969da; }
+.chart .llm, .legend .llm { fill: #8250df; background: #8250df; }
+.chart .tts, .legend .tts { fill: #1a7f37; background: #1a7f37; }
+.chart .err { stroke: #cf222e; stroke-width: 2; } .chart .warn { stroke: #bf8700; stroke-width: 2; }
+.chart .fill { fill: none; stroke: #0969da; stroke-width: 1.5; }
+.chart .threshold { stroke: #656d76; stroke-dasharray: 4 3; }
+.chart .mark { stroke-width: 1.5; opacity: .7; }
+.chart .underflow { stroke: #cf222e; } .chart .reset { stroke: #bf8700; } .chart .full { stroke: #8250df; }
+.chart .barge-in { stroke: #0969da; } .chart .gate-closed, .chart .gate-opened { stroke: #8c959f; }
+.legend span { display: inline-block; width: 10px; height: 10px; margin: 0 4px 0 12px; }
+.turns p { margin: 6px 0; padding: 6px 10px; border-radius: 8px; max-width: 80%; }
+.turns .caller { background: #f6f8fa; }
+.turns .agent { background: #ddf4ff; margin-left: auto; }
+.turns b { display: block; font-size: 11px; color: #656d76; }
+footer { margin-top: 40px; color: #656d76; font-size: 12px; }
+@media print { h2 { break-after: avoid; } .chart { break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Call {{.CallID}}</h1>
+<div><span class="badge {{.Result}}">{{.Result}}</span>{{if .Score}}<span class="score">Quality score <b>{{.Score}}</b>/100</span>{{end}}</div>
+<p class="meta">Generated {{.Generated}} by agent rca {{.CLIVersion}}{{if .Redacted}} · personal data redacted{{end}}</p>
+{{with .Facts}}<table class="facts">{{range .}}<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>{{end}}</table>{{end}}
+
+<h2>Findings</h2>
+{{range .KnownIssues}}<div class="issue"><b>Known issue: {{.Title}}</b> ({{.ID}})<br>{{.Cause}}{{with .Fix}}<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}{{with .Docs}}<a href="{{.}}">{{.}}</a>{{end}}</div>
+{{end}}{{range .Findings}}<h3>{{.Title}}</h3><ul>{{$class := .Class}}{{range .Items}}<li class="{{$class}}">{{.}}</li>{{end}}</ul>
+{{end}}{{if not (or .KnownIssues .Findings)}}<p>No problems found.</p>{{end}}
+{{with .Diagnosis}}<h3>AI diagnosis</h3><pre>{{.}}</pre>{{end}}
+
+<h2>Timeline</h2>
+{{if .Timeline}}{{.Timeline}}
+<p class="legend"><span class="stt"></span>STT<span class="llm"></span>LLM<span class="tts"></span>TTS · red and amber lines on the log row are errors and warnings</p>
+{{with .Problems}}<table><tr><th>Time</th><th>Severity</th><th>Source</th><th>Line</th></tr>{{range .}}<tr><td class="num">{{.Offset}}</td><td>{{.Severity}}</td><td>{{.Source}}</td><td class="num">{{.Message}}</td></tr>{{end}}</table>{{end}}
+{{else}}<p>The call's log lines carry no timestamps.</p>{{end}}
+
+<h2>Metrics</h2>
+{{with .Summary}}<table class="facts">{{range .}}<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>{{end}}</table>{{end}}
+{{with .Conversation}}<h3>Conversation quality{{if .Overall}}: {{printf "%.1f" .Overall}}/5{{end}}</h3>
+{{if .Error}}<p>{{.Error}}</p>{{else}}<table><tr><th>Criterion</th><th>Score</th><th>Reason</th></tr>{{range .Scores}}<tr><td>{{.Criterion}}</td><td class="num">{{.Score}}/5</td><td>{{.Reason}}</td></tr>{{end}}</table>{{with .Summary}}<p>{{.}}</p>{{end}}{{end}}{{end}}
+{{if .Metrics}}<h3>Call metrics</h3><table><tr><th>Metric</th><th>Value</th></tr>{{range .Metrics}}<tr><td class="num">{{.Label}}</td><td class="num">{{.Value}}</td></tr>{{end}}</table>
+{{else}}<p>No call metrics were extracted.</p>{{end}}
+
+{{if .Buffer}}<h2>Jitter buffer</h2>
+{{.Buffer}}
+<table><tr>{{range .BufferStats}}<th>{{.Label}}</th>{{end}}</tr><tr>{{range .BufferStats}}<td class="num">{{.Value}}</td>{{end}}</tr></table>
+{{with .BufferFindings}}<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}
+<p class="meta">Fill levels are logged at stream start, warm-up, segment ends and while the pacer waits on an empty buffer; the graph joins those samples.</p>
+{{end}}
+<h2>Transcript</h2>
+{{with .TranscriptNote}}<p>{{.}}</p>{{end}}
+<div class="turns">{{range .Transcript}}<p class="{{.Class}}"><b>{{.Speaker}}</b>{{.Text}}</p>
+{{end}}</div>
+<footer>Asterisk AI Voice Agent · agent rca --format html</footer>
+</body>
+</html>
+`))
@@ -0,0 +1,100 @@
+package troubleshoot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/knowledge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+)
+
+func TestWriteHTML(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🎚️ STREAMING ADAPTIVE WARM-UP","call_id":"1.1","chunk_ms":20,"jb_chunks":25,"min_start_chunks":15,"low_watermark_chunks":10}`,
+		`{"timestamp":"2026-01-30T17:21:40.010Z","level":"info","event":"🎵 STREAMING PLAYBACK - Started","call_id":"1.1","stream_id":"stream:greeting:1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.300Z","level":"debug","event":"Streaming jitter buffer warm-up complete","call_id":"1.1","buffered_chunks":15}`,
+		`{"timestamp":"2026-01-30T17:21:41.000Z","level":"info","event":"Sent greeting TTS request to Local AI Server","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:43.000Z","level":"info","event":"Streaming segment bytes summary v2","call_id":"1.1","stream_id":"stream:greeting:1.1","underflow_events":2,"buffered_bytes":0}`,
+		`{"timestamp":"2026-01-30T17:21:45.000Z","level":"info","event":"Transcript received","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:45.800Z","level":"info","event":"OpenAI realtime LLM response","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:46.000Z","level":"error","event":"Provider websocket error <closed>","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:46.200Z","level":"info","event":"Turn latency recorded","call_id":"1.1","latency_ms":1200.5}`,
+	}
+	r := &Runner{
+		logData: strings.Join(lines, "\n"),
+		analysis: &Analysis{
+			CallID:         "1.1",
+			AudioTransport: "audiosocket",
+			Header:         &RCAHeader{ProviderName: "local", CallerNumber: "+15551234567", StreamingJitterBufferMs: 100},
+			CallHistory:    &CallHistorySummary{DurationSeconds: 75, Outcome: "caller_hangup", TotalTurns: 2, AverageTurnLatencyMS: 1200},
+			Errors:         []string{"Provider websocket error <closed>"},
+			KnownIssues:    []knowledge.Hit{{ID: "ws-closed", Title: "Provider closed the socket", Cause: "Idle timeout.", Fix: []string{"Raise the idle timeout"}}},
+			Metrics:        &CallMetrics{UnderflowCount: 2, GateClosures: 1},
+		},
+	}
+	turns := []TranscriptTurn{
+		{Role: "assistant", Content: "Hello, how can I help?"},
+		{Role: "user", Content: "<script>alert(1)</script> my order"},
+	}
+	var buf bytes.Buffer
+	now := time.Date(2026, 1, 30, 18, 0, 0, 0, time.UTC)
+	if err := r.writeHTML(&buf, "7.2.0", now, turns, nil); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		"<title>Call 1.1: FAIL</title>",
+		`<span class="badge FAIL">FAIL</span>`,
+		"Quality score <b>",
+		"Generated 2026-01-30 18:00:00 UTC by agent rca 7.2.0",
+		"<th>Caller</th><td>&#43;15551234567</td>",
+		"<th>Duration</th><td>1:15.0</td>",
+		"Known issue: Provider closed the socket", "<li>Raise the idle timeout</li>",
+		`<li class="error">Provider websocket error &lt;closed&gt;</li>`,
+		`aria-label="Call timeline"`, `<rect class="llm"`, `<rect class="tts"`, "turn 2 · 1200ms",
+		`<line class="err"`,
+		`aria-label="Jitter buffer fill level"`, `<polyline class="fill"`, "min start 300ms",
+		"<th>Average turn latency</th><td>1200 ms</td>",
+		"<td class=\"num\">UnderflowCount</td>",
+		`<p class="agent"><b>Agent</b>Hello, how can I help?</p>`,
+		"&lt;script&gt;alert(1)&lt;/script&gt; my order",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	// Self-contained and inert: nothing is fetched and nothing runs.
+	for _, bad := range []string{"<script", "src=", "<link", "@import", "personal data redacted"} {
+		if strings.Contains(page, bad) {
+			t.Errorf("page contains %q", bad)
+		}
+	}
+}
+
+func TestWriteHTMLWithoutEvidence(t *testing.T) {
+	r := &Runner{analysis: &Analysis{CallID: "1.2"}}
+	r.redactor, _ = redact.Parse("all")
+	var buf bytes.Buffer
+	if err := r.writeHTML(&buf, "7.2.0", time.Now(), nil, errors.New("call history is not available")); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		`<span class="badge PASS">PASS</span>`, "personal data redacted", "No problems found.",
+		"The call's log lines carry no timestamps.", "No call metrics were extracted.",
+		"Transcript not available: call history is not available",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	if strings.Contains(page, "Jitter buffer") || strings.Contains(page, "Quality score") {
+		t.Error("page shows sections it has no data for")
+	}
+	if err := (&Runner{}).WriteHTML(&buf, "7.2.0"); err == nil {
+		t.Error("rendered a call that was not analyzed")
+	}
+}
@@ -21,7 +21,14 @@ func SelectCallInteractive(calls []Call) (string, error) {
 
 	for i, call := range calls {
 		age := formatDuration(time.Since(call.Timestamp))
-		fmt.Printf("  %d) %s (%s ago)\n", i+1, call.ID, age)
+		fmt.Printf("  %d) %s  %s (%s ago)", i+1, call.ID, call.Timestamp.Local().Format("Jan 02 15:04:05"), age)
+		if call.Duration != "" {
+			fmt.Printf(", %s", call.Duration)
+		}
+		if desc := call.describe(); desc != "" {
+			fmt.Printf(" - %s", desc)
+		}
+		fmt.Println()
 	}
 
 	fmt.Println()
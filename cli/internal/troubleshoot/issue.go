@@ -0,0 +1,153 @@
+package troubleshoot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// issueListMax is how many findings of each kind an issue lists.
+const issueListMax = 10
+
+// IssueEnv is what a bug report says about the installation besides the call.
+type IssueEnv struct {
+	CLIVersion     string
+	ProjectVersion string
+	OS             string
+}
+
+// IssueDraft is a GitHub bug report for an analyzed call. Diagnostics holds
+// the metrics table, kept apart so a link that would be too long can drop it.
+type IssueDraft struct {
+	CallID      string
+	Title       string
+	Body        string
+	Diagnostics string
+}
+
+// Markdown is the complete issue body.
+func (d IssueDraft) Markdown() string {
+	return d.Body + "\n" + d.Diagnostics
+}
+
+// Issue drafts a bug report for the analyzed call, laid out like the repo's
+// bug report template with the sections the reporter knows left to fill in.
+// Issues are public, so the report must have been redacted (SetRedactor).
+func (r *Runner) Issue(env IssueEnv) (IssueDraft, error) {
+	a := r.analysis
+	if a == nil {
+		return IssueDraft{}, errors.New("no analyzed call")
+	}
+	if r.redactor == nil {
+		return IssueDraft{}, errors.New("the report is not redacted")
+	}
+	var provider, pipeline string
+	if h := a.Header; h != nil {
+		provider, pipeline = h.ProviderName, h.PipelineName
+	}
+
+	var b strings.Builder
+	b.WriteString("## Bug Description\n<!-- What went wrong on the call, in your words -->\n\n")
+	result := "Result: " + resultLabel(r.ExitCode())
+	if metricsHasEvidence(a.Metrics) {
+		score, _ := callQualityScore(a)
+		result += fmt.Sprintf(" (quality %.0f/100)", score)
+	}
+	fmt.Fprintf(&b, "Call `%s`: **%s**", a.CallID, result)
+	if ch := a.CallHistory; ch != nil && ch.DurationSeconds > 0 {
+		fmt.Fprintf(&b, ", %.0fs", ch.DurationSeconds)
+		if ch.Outcome != "" {
+			fmt.Fprintf(&b, ", %s", ch.Outcome)
+		}
+	}
+	b.WriteString("\n\n## Steps to Reproduce\n1. \n2. \n3. \n\n")
+	b.WriteString("## Expected Behavior\n<!-- What you expected to happen -->\n\n")
+
+	b.WriteString("## Actual Behavior\n")
+	for _, k := range a.KnownIssues {
+		fmt.Fprintf(&b, "- Known issue **%s** (`%s`): %s\n", k.Title, k.ID, k.Cause)
+	}
+	writeIssueList(&b, "Errors", a.Errors)
+	writeIssueList(&b, "Warnings", a.Warnings)
+	writeIssueList(&b, "Audio issues", a.AudioIssues)
+	if sa := a.SymptomAnalysis; sa != nil {
+		writeIssueList(&b, "Likely causes", sa.RootCauses)
+	}
+	if e := a.Ending; e != nil && e.Explanation != "" {
+		fmt.Fprintf(&b, "\nHow it ended: %s\n", e.Explanation)
+	}
+	if r.llm != nil {
+		summary, _, _ := strings.Cut(strings.TrimSpace(r.llm.Analysis), "\n\n")
+		fmt.Fprintf(&b, "\nAI diagnosis: %s\n", summary)
+	}
+
+	b.WriteString("\n## Environment\n")
+	fmt.Fprintf(&b, "- **Version**: %s (agent CLI %s)\n", orDash(env.ProjectVersion), env.CLIVersion)
+	fmt.Fprintf(&b, "- **OS**: %s\n", orDash(env.OS))
+	configuration := provider
+	if pipeline != "" {
+		configuration = strings.TrimSpace(pipeline + " " + provider)
+	}
+	fmt.Fprintf(&b, "- **Configuration**: %s\n", orDash(configuration))
+	fmt.Fprintf(&b, "- **Transport**: %s\n", orDash(a.AudioTransport))
+
+	var d strings.Builder
+	d.WriteString("## Diagnostics\n")
+	if names := metricNames(a.Metrics); len(names) > 0 {
+		d.WriteString("<details><summary>Call metrics (names as in <code>agent rca --json</code>)</summary>\n\n| Metric | Value |\n|---|---|\n")
+		for _, n := range names {
+			name, value, _ := strings.Cut(n, "=")
+			fmt.Fprintf(&d, "| %s | %s |\n", name, issueCell(value))
+		}
+		d.WriteString("\n</details>\n")
+	} else {
+		d.WriteString("No call metrics were extracted.\n")
+	}
+	d.WriteString("\n<sub>Drafted by <code>agent rca --open-issue</code>; personal data was redacted. Review before submitting.</sub>\n")
+
+	return IssueDraft{CallID: a.CallID, Title: issueTitle(a, provider), Body: b.String(), Diagnostics: d.String()}, nil
+}
+
+// issueTitle names the call's main problem the way the bug template does.
+func issueTitle(a *Analysis, provider string) string {
+	problem := "Call quality problem"
+	switch {
+	case len(a.KnownIssues) > 0:
+		problem = a.KnownIssues[0].Title
+	case len(a.Errors) > 0:
+		problem = truncate(strings.TrimSpace(a.Errors[0]), 80)
+	case a.SymptomAnalysis != nil && len(a.SymptomAnalysis.RootCauses) > 0:
+		problem = truncate(a.SymptomAnalysis.RootCauses[0], 80)
+	case len(a.AudioIssues) > 0:
+		problem = truncate(a.AudioIssues[0], 80)
+	}
+	var on []string
+	for _, s := range []string{provider, a.AudioTransport} {
+		if s != "" && s != "unknown" {
+			on = append(on, s)
+		}
+	}
+	if len(on) > 0 {
+		problem += " (" + strings.Join(on, ", ") + ")"
+	}
+	return "[BUG] " + problem
+}
+
+func writeIssueList(b *strings.Builder, heading string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s:\n", heading)
+	for i, it := range items {
+		if i == issueListMax {
+			fmt.Fprintf(b, "- ... and %d more\n", len(items)-i)
+			break
+		}
+		fmt.Fprintf(b, "- `%s`\n", strings.ReplaceAll(truncate(strings.TrimSpace(it), 300), "`", "'"))
+	}
+}
+
+// issueCell keeps a value from breaking the markdown table.
+func issueCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,46 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/knowledge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+)
+
+func TestIssue(t *testing.T) {
+	a := &Analysis{
+		CallID:         "1761518880.2191",
+		AudioTransport: "audiosocket",
+		Header:         &RCAHeader{ProviderName: "openai_realtime"},
+		Errors:         []string{"websocket `closed`: connection refused"},
+		KnownIssues:    []knowledge.Hit{{ID: "connection-refused", Title: "Connection refused", Cause: "Nothing listening."}},
+		Metrics:        &CallMetrics{GateClosures: 3},
+	}
+	r := &Runner{analysis: a}
+	if _, err := r.Issue(IssueEnv{}); err == nil {
+		t.Fatal("drafted an unredacted report")
+	}
+	r.redactor, _ = redact.Parse("all")
+	d, err := r.Issue(IssueEnv{CLIVersion: "7.2.0", ProjectVersion: "v7.2.0", OS: "Ubuntu 22.04.4 LTS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Title != "[BUG] Connection refused (openai_realtime, audiosocket)" {
+		t.Errorf("title = %q", d.Title)
+	}
+	for _, want := range []string{
+		"## Bug Description", "## Steps to Reproduce", "## Actual Behavior",
+		"- Known issue **Connection refused** (`connection-refused`): Nothing listening.",
+		"- `websocket 'closed': connection refused`",
+		"- **Version**: v7.2.0 (agent CLI 7.2.0)",
+		"- **Transport**: audiosocket",
+	} {
+		if !strings.Contains(d.Body, want) {
+			t.Errorf("body lacks %q:\n%s", want, d.Body)
+		}
+	}
+	if !strings.Contains(d.Diagnostics, "| GateClosures | 3 |") || strings.Contains(d.Body, "GateClosures") {
+		t.Errorf("diagnostics = %s", d.Diagnostics)
+	}
+}
@@ -0,0 +1,195 @@
+package troubleshoot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/knowledge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+)
+
+// matchKnownIssues applies the knowledge base to the analyzed call. A repo
+// knowledge file that does not load is reported, and the built-in issues are
+// still applied.
+func (r *Runner) matchKnownIssues(analysis *Analysis, logData string) {
+	kb, err := knowledge.Load()
+	if err != nil && r.decorated() {
+		warningColor.Printf("⚠️  Known issues: %v (using the built-in set)\n", err)
+	}
+	facts := knowledge.Facts{
+		Log:       logData,
+		Metrics:   metricValues(analysis.Metrics),
+		Transport: analysis.AudioTransport,
+	}
+	if analysis.Header != nil {
+		facts.Provider = analysis.Header.ProviderName
+	}
+	analysis.KnownIssues = kb.Match(facts)
+}
+
+// builtinIssueIDs are the IDs of the hits from the built-in knowledge base,
+// the only ones telemetry counts.
+func builtinIssueIDs(hits []knowledge.Hit) []string {
+	var ids []string
+	for _, h := range hits {
+		if h.Source == knowledge.BuiltinSource {
+			ids = append(ids, h.ID)
+		}
+	}
+	return ids
+}
+
+// displayKnownIssues shows the known problems the call matched, with their
+// fixes.
+func (r *Runner) displayKnownIssues(hits []knowledge.Hit) {
+	if len(hits) == 0 {
+		return
+	}
+	fmt.Println("═══════════════════════════════════════════")
+	infoColor.Printf("📚 KNOWN ISSUES (%d)\n", len(hits))
+	fmt.Println("═══════════════════════════════════════════")
+	for _, h := range hits {
+		fmt.Println()
+		errorColor.Printf("%s", h.Title)
+		fmt.Printf("  [%s]\n", h.ID)
+		fmt.Printf("  %s\n", h.Cause)
+		for _, e := range h.Evidence {
+			fmt.Printf("  Evidence: %s\n", truncate(e, 100))
+		}
+		for i, f := range h.Fix {
+			fmt.Printf("  %d. %s\n", i+1, f)
+		}
+		if h.Docs != "" {
+			fmt.Printf("  See: %s\n", h.Docs)
+		}
+	}
+	fmt.Println()
+}
+
+// FingerprintOptions describes a solved problem to export as a known issue.
+type FingerprintOptions struct {
+	ID, Title, Cause, Docs string
+	Fix                    []string
+	// Lines pick the call's error or warning lines to fingerprint, by a
+	// substring of each; empty takes the first error, else the first warning.
+	Lines []string
+	// Metrics are conditions the call meets, as in knowledge.Match.
+	Metrics             []string
+	Provider, Transport string
+}
+
+// Fingerprint turns the analyzed call into a known issue: its chosen log
+// lines become patterns with the caller's details and every number, ID and
+// quote generalized, so the issue carries nothing from this call. The issue
+// is checked to match the call it came from.
+func (r *Runner) Fingerprint(opts FingerprintOptions) (knowledge.Issue, error) {
+	a := r.analysis
+	if a == nil {
+		return knowledge.Issue{}, errors.New("no analyzed call")
+	}
+	lines, err := fingerprintLines(a, opts.Lines, len(opts.Metrics) == 0)
+	if err != nil {
+		return knowledge.Issue{}, err
+	}
+	red, _ := redact.Parse("all")
+	if h := a.Header; h != nil {
+		red.Known(redact.Phone, h.CallerNumber, h.CalledNumber)
+		red.Known(redact.Name, h.CallerName)
+	}
+	if c := a.CDR; c != nil {
+		red.Known(redact.Phone, c.Src, c.Dst)
+	}
+	is := knowledge.Issue{
+		ID:    opts.ID,
+		Title: opts.Title,
+		Cause: opts.Cause,
+		Fix:   opts.Fix,
+		Docs:  opts.Docs,
+		Match: knowledge.Match{Provider: opts.Provider, Transport: opts.Transport, Metrics: opts.Metrics},
+	}
+	if is.ID == "" {
+		is.ID = knowledge.Slug(opts.Title)
+	}
+	for _, l := range lines {
+		for _, text := range logMessageParts(l) {
+			p := knowledge.Pattern(red.String(text))
+			if p != "" && !containsString(is.Match.Logs, p) {
+				is.Match.Logs = append(is.Match.Logs, p)
+			}
+		}
+	}
+
+	raw, err := knowledge.Encode([]knowledge.Issue{is})
+	if err != nil {
+		return is, err
+	}
+	kb, err := knowledge.Parse(raw, "")
+	if err != nil {
+		return is, err
+	}
+	facts := knowledge.Facts{Log: r.logData, Metrics: metricValues(a.Metrics), Transport: a.AudioTransport}
+	if a.Header != nil {
+		facts.Provider = a.Header.ProviderName
+	}
+	if len(kb.Match(facts)) == 0 {
+		return is, fmt.Errorf("the fingerprint does not match call %s; check --metric, --provider and --transport against its report", a.CallID)
+	}
+	return is, nil
+}
+
+// fingerprintLines picks the error and warning lines to fingerprint.
+func fingerprintLines(a *Analysis, selectors []string, required bool) ([]string, error) {
+	candidates := append(append([]string{}, a.Errors...), a.Warnings...)
+	if len(selectors) == 0 {
+		if len(candidates) > 0 {
+			return candidates[:1], nil
+		}
+		if required {
+			return nil, errors.New("the call logged no errors or warnings; give --metric conditions instead")
+		}
+		return nil, nil
+	}
+	var lines []string
+	for _, sel := range selectors {
+		found := ""
+		for _, c := range candidates {
+			if strings.Contains(strings.ToLower(c), strings.ToLower(sel)) {
+				found = c
+				break
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("no error or warning line of the call contains %q", sel)
+		}
+		lines = append(lines, found)
+	}
+	return lines, nil
+}
+
+// logMessageParts is what identifies a log line's problem: the event and its
+// error detail for a JSON line, the message for a console line. They become
+// separate patterns since JSON key order is not fixed.
+func logMessageParts(line string) []string {
+	_, event, fields, ok := parseLogLine(line)
+	if !ok {
+		return []string{line}
+	}
+	parts := []string{event}
+	for _, k := range []string{"error", "reason", "detail", "message"} {
+		if v := strings.TrimSpace(fields[k]); v != "" {
+			parts = append(parts, v)
+			break
+		}
+	}
+	return parts
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchKnownIssues(t *testing.T) {
+	t.Setenv("AAVA_KNOWLEDGE_FILE", "")
+	r := &Runner{quiet: true}
+	a := &Analysis{
+		AudioTransport: "audiosocket",
+		Header:         &RCAHeader{ProviderName: "openai_realtime"},
+		Metrics:        &CallMetrics{GateClosures: 64, VADSettings: &VADSettings{WebRTCAggressiveness: 0}},
+	}
+	r.matchKnownIssues(a, "INFO call started\nERROR websocket closed: connection refused\n")
+	var ids []string
+	for _, k := range a.KnownIssues {
+		ids = append(ids, k.ID)
+	}
+	if len(ids) != 3 || ids[0] != "connection-refused" || ids[1] != "gate-flutter" || ids[2] != "openai-vad-too-sensitive" {
+		t.Errorf("known issues = %v", ids)
+	}
+	if got := a.KnownIssues[1].Evidence; len(got) != 1 || got[0] != "GateClosures=64" {
+		t.Errorf("evidence = %q", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	line := `{"level": "error", "event": "Tool execution failed", "error": "Transfer to +15551234567 timed out after 5000ms", "call_id": "1761234567.42"}`
+	r := &Runner{
+		logData: "INFO call started\n" + line + "\n",
+		analysis: &Analysis{
+			CallID:  "1761234567.42",
+			Header:  &RCAHeader{CallerNumber: "+15551234567", ProviderName: "deepgram"},
+			Errors:  []string{line},
+			Metrics: &CallMetrics{GateClosures: 3},
+		},
+	}
+	is, err := r.Fingerprint(FingerprintOptions{Title: "Transfer tool times out", Fix: []string{"Raise tools.transfer.timeout_ms"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if is.ID != "transfer-tool-times-out" || len(is.Match.Logs) != 2 {
+		t.Fatalf("issue = %+v", is)
+	}
+	for _, p := range is.Match.Logs {
+		if strings.Contains(p, "5551234567") || strings.Contains(p, "1761234567") {
+			t.Errorf("pattern keeps call data: %s", p)
+		}
+	}
+
+	if _, err := r.Fingerprint(FingerprintOptions{Title: "x", Lines: []string{"no such text"}}); err == nil {
+		t.Error("unmatched --line accepted")
+	}
+	if _, err := r.Fingerprint(FingerprintOptions{Title: "x", Metrics: []string{"GateClosures > 20"}}); err == nil {
+		t.Error("fingerprint that does not match the call accepted")
+	}
+}
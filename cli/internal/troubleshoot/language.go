@@ -0,0 +1,184 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/locale"
+)
+
+// noSpeech matches caller turns with no words: punctuation, or the markers
+// STT engines put in place of speech they could not recognise.
+var noSpeech = regexp.MustCompile(`(?i)^(?:[\s\p{P}]*|[\[(<]\s*(?:inaudible|noise|unk|blank_audio|silence|music|unintelligible)\s*[\])>])$`)
+
+// noisePhrases are what Whisper-family models write for noise or for speech
+// in a language they were not set up for.
+var noisePhrases = map[string]bool{
+	"you": true, "thank you": true, "thanks for watching": true, "thank you for watching": true,
+	"please subscribe": true, "bye": true, "subtitles by the amara.org community": true,
+}
+
+// LanguageAnalysis compares the caller's transcribed turns with the language
+// the call was held in. It is built for non-English calls only: an STT model
+// for the wrong language raises no error, it just returns empty or
+// meaningless text.
+type LanguageAnalysis struct {
+	Language    string         `json:"language"` // the call's language
+	Source      string         `json:"source"`   // where Language comes from
+	STT         *locale.Side   `json:"stt,omitempty"`
+	CallerTurns int            `json:"caller_turns"`
+	AgentTurns  int            `json:"agent_turns"`
+	Empty       int            `json:"empty"`              // caller turns with no words
+	Garbled     int            `json:"garbled"`            // noise phrases, or text in another language
+	Detected    map[string]int `json:"detected,omitempty"` // caller turns by the language they read as
+	Findings    []string       `json:"findings,omitempty"`
+}
+
+// callLanguage analyzes the call's transcript from Call History against the
+// STT the call's provider or pipeline runs, read from the config next to the
+// working directory.
+func callLanguage(analysis *Analysis) *LanguageAnalysis {
+	if !analysis.HasTranscription {
+		return nil
+	}
+	turns, err := loadCallTranscript(analysis.CallID)
+	if err != nil || len(turns) == 0 {
+		return nil
+	}
+	return analyzeLanguage(turns, callSTT(analysis.Header))
+}
+
+// callSTT resolves the STT side of the call's pipeline or provider, or
+// returns nil when the config is not readable here.
+func callSTT(h *RCAHeader) *locale.Side {
+	if h == nil {
+		return nil
+	}
+	cfg, _, err := configmerge.Effective(filepath.Join("config", "ai-agent.yaml"), os.Getenv(configmerge.EnvVar))
+	if err != nil {
+		return nil
+	}
+	stt, _, ok := locale.Resolve(cfg, emptyTo(h.PipelineName, h.ProviderName), os.Getenv)
+	if !ok || stt.Provider == "" {
+		return nil
+	}
+	return &stt
+}
+
+// analyzeLanguage takes the call's language from the agent's replies, which
+// the LLM writes in the language it was asked for, or else from the STT's
+// configured language. It returns nil for English calls and calls whose
+// language cannot be told.
+func analyzeLanguage(turns []TranscriptTurn, stt *locale.Side) *LanguageAnalysis {
+	a := &LanguageAnalysis{STT: stt}
+	var agent []string
+	var caller []string
+	for _, t := range turns {
+		if t.Role == "assistant" {
+			a.AgentTurns++
+			agent = append(agent, t.Content)
+		} else {
+			a.CallerTurns++
+			caller = append(caller, t.Content)
+		}
+	}
+	a.Language, a.Source = locale.Detect(strings.Join(agent, "\n")), "the agent's replies"
+	if a.Language == "" && stt != nil {
+		a.Language, a.Source = stt.Language(), "the STT config"
+	}
+	if a.Language == "" || a.Language == locale.Multi || a.Language == "en" {
+		return nil
+	}
+
+	for _, text := range caller {
+		norm := strings.Trim(strings.ToLower(strings.TrimSpace(text)), ".!?… ")
+		if noSpeech.MatchString(text) {
+			a.Empty++
+			continue
+		}
+		if noisePhrases[norm] {
+			a.Garbled++
+			continue
+		}
+		if d := locale.Detect(text); d != "" {
+			if a.Detected == nil {
+				a.Detected = map[string]int{}
+			}
+			a.Detected[d]++
+			if !locale.Matches(a.Language, d) {
+				a.Garbled++
+			}
+		}
+	}
+
+	name := locale.Name(a.Language)
+	if stt != nil {
+		if !locale.Matches(stt.Language(), a.Language) {
+			a.Findings = append(a.Findings, fmt.Sprintf("The call was in %s but %s transcribes callers in %s", name, stt.Provider, stt.Describe()))
+		}
+		if m := stt.Mismatch(); m != "" {
+			a.Findings = append(a.Findings, "STT model does not support the configured language: "+m)
+		}
+	}
+	bad := a.Empty + a.Garbled
+	switch {
+	case a.CallerTurns == 0 && a.AgentTurns >= 2:
+		a.Findings = append(a.Findings, fmt.Sprintf("No caller speech was transcribed over %d agent turns in %s; the STT may not recognise the language", a.AgentTurns, name))
+	case bad >= 2 && 2*bad >= a.CallerTurns:
+		msg := fmt.Sprintf("%d of %d caller turns were empty or not %s", bad, a.CallerTurns, name)
+		if other := a.dominantOther(); other != "" {
+			msg += fmt.Sprintf(" (%d read as %s)", a.Detected[other], locale.Name(other))
+		}
+		a.Findings = append(a.Findings, msg+"; the STT model or language probably does not match the callers")
+	}
+	return a
+}
+
+// dominantOther is the language other than the call's that most caller
+// turns read as.
+func (a *LanguageAnalysis) dominantOther() string {
+	langs := make([]string, 0, len(a.Detected))
+	for l := range a.Detected {
+		if !locale.Matches(a.Language, l) {
+			langs = append(langs, l)
+		}
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if a.Detected[langs[i]] != a.Detected[langs[j]] {
+			return a.Detected[langs[i]] > a.Detected[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	if len(langs) == 0 {
+		return ""
+	}
+	return langs[0]
+}
+
+func languageWarnings(a *LanguageAnalysis) []string {
+	if a == nil {
+		return nil
+	}
+	return a.Findings
+}
+
+func (r *Runner) displayLanguage(a *LanguageAnalysis) {
+	if a == nil {
+		return
+	}
+	fmt.Println("Language:")
+	fmt.Printf("  Call: %s (from %s)\n", locale.Name(a.Language), a.Source)
+	if a.STT != nil {
+		fmt.Printf("  STT:  %s\n", a.STT.Describe())
+	}
+	fmt.Printf("  Caller turns: %d   Empty: %d   Garbled: %d\n", a.CallerTurns, a.Empty, a.Garbled)
+	for _, f := range a.Findings {
+		warningColor.Printf("  • %s\n", f)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,50 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/locale"
+)
+
+func TestAnalyzeLanguage(t *testing.T) {
+	agent := func(s string) TranscriptTurn { return TranscriptTurn{Role: "assistant", Content: s} }
+	caller := func(s string) TranscriptTurn { return TranscriptTurn{Role: "user", Content: s} }
+	english := &locale.Side{Provider: "local_stt", Model: &locale.Setting{Key: "providers.local_stt.stt_model", Value: "vosk-model-en-us-0.22", Language: "en"}}
+
+	turns := []TranscriptTurn{
+		agent("Hola, gracias por llamar. ¿En qué puedo ayudarle?"),
+		caller("the key on my"),
+		agent("Perdón, no le he entendido. ¿Puede repetirlo, por favor?"),
+		caller("..."),
+		agent("¿Sigue ahí? ¿En qué puedo ayudarle?"),
+		caller("Thank you."),
+		caller("quiero una cita para mañana"),
+	}
+	a := analyzeLanguage(turns, english)
+	if a == nil || a.Language != "es" || a.Source != "the agent's replies" || a.CallerTurns != 4 || a.Empty != 1 || a.Garbled != 2 {
+		t.Fatalf("analysis = %+v", a)
+	}
+	got := strings.Join(a.Findings, "\n")
+	if !strings.Contains(got, "The call was in Spanish but local_stt transcribes callers in English (providers.local_stt.stt_model vosk-model-en-us-0.22)") {
+		t.Errorf("STT language not flagged:\n%s", got)
+	}
+	if !strings.Contains(got, "3 of 4 caller turns were empty or not Spanish (1 read as English)") {
+		t.Errorf("garbled turns not flagged:\n%s", got)
+	}
+
+	// Only the agent spoke: nothing the caller said was transcribed.
+	spanish := &locale.Side{Provider: "azure_stt", Requested: &locale.Setting{Key: "providers.azure_stt.language", Value: "es-ES", Language: "es"}}
+	a = analyzeLanguage([]TranscriptTurn{agent("Hola, ¿en qué puedo ayudarle?"), agent("¿Sigue ahí?")}, spanish)
+	if a == nil || len(a.Findings) != 1 || !strings.HasPrefix(a.Findings[0], "No caller speech was transcribed over 2 agent turns in Spanish") {
+		t.Fatalf("no caller turns: %+v", a)
+	}
+
+	healthy := []TranscriptTurn{agent("Hola, ¿en qué puedo ayudarle?"), caller("Hola, necesito una cita para el lunes"), agent("Claro, ¿a qué hora?")}
+	if a := analyzeLanguage(healthy, spanish); a == nil || len(a.Findings) != 0 || a.Detected["es"] != 1 {
+		t.Fatalf("healthy Spanish call: %+v", a)
+	}
+	if a := analyzeLanguage([]TranscriptTurn{agent("Hello, how can I help you today?"), caller("I need an appointment")}, nil); a != nil {
+		t.Fatalf("English calls are not analyzed: %+v", a)
+	}
+}
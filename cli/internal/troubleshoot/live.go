@@ -0,0 +1,78 @@
+package troubleshoot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+)
+
+// liveStateTimeout bounds the health server round trip; RCA works without it.
+const liveStateTimeout = 5 * time.Second
+
+// LiveState is what the engine's health server says right now about the
+// analyzed call and its provider. The rest of the report comes from logs.
+type LiveState struct {
+	EngineStatus      string `json:"engine_status"`
+	ARIConnected      bool   `json:"ari_connected"`
+	InProgress        bool   `json:"in_progress"`
+	SessionStatus     string `json:"session_status,omitempty"`
+	ConversationState string `json:"conversation_state,omitempty"`
+	Provider          string `json:"provider,omitempty"`
+	ProviderReady     *bool  `json:"provider_ready,omitempty"`
+	ProviderReason    string `json:"provider_reason,omitempty"`
+}
+
+// lookupLiveState asks the engine about the call (best-effort); nil when the
+// health server cannot be reached.
+func lookupLiveState(callID, provider string) *LiveState {
+	client := engineapi.New()
+	client.Timeout = liveStateTimeout
+	h, err := client.Health(context.Background())
+	if err != nil {
+		return nil
+	}
+	// Engines without /sessions/stats still report provider readiness.
+	s, _ := client.Sessions(context.Background())
+	return liveStateFrom(callID, provider, h, s)
+}
+
+func liveStateFrom(callID, provider string, h *engineapi.Health, s *engineapi.Sessions) *LiveState {
+	live := &LiveState{EngineStatus: h.Status, ARIConnected: h.ARIConnected, Provider: provider}
+	if s != nil {
+		if sess, ok := s.Find(callID); ok {
+			live.InProgress = true
+			live.SessionStatus = sess.Status
+			live.ConversationState = sess.ConversationState
+			if live.Provider == "" {
+				live.Provider = sess.Provider
+			}
+		}
+	}
+	if p, ok := h.Providers[live.Provider]; ok {
+		ready := p.Ready
+		live.ProviderReady = &ready
+		live.ProviderReason = p.Reason
+	}
+	return live
+}
+
+func (r *Runner) displayLiveState(live *LiveState) {
+	if live == nil {
+		return
+	}
+	fmt.Println("Engine (live):")
+	fmt.Printf("  Status: %s (ARI connected: %t)\n", emptyTo(live.EngineStatus, "unknown"), live.ARIConnected)
+	if live.InProgress {
+		warningColor.Printf("  Call in progress (%s); this report covers the lines logged so far\n", emptyTo(live.ConversationState, emptyTo(live.SessionStatus, "active")))
+	}
+	if live.ProviderReady != nil {
+		if *live.ProviderReady {
+			fmt.Printf("  Provider %s: ready\n", live.Provider)
+		} else {
+			warningColor.Printf("  Provider %s: not ready (%s)\n", live.Provider, emptyTo(live.ProviderReason, "no reason given"))
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,30 @@
+package troubleshoot
+
+import (
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+)
+
+func TestLiveStateFrom(t *testing.T) {
+	h := &engineapi.Health{
+		Status:       "healthy",
+		ARIConnected: true,
+		Providers:    map[string]engineapi.Provider{"deepgram": {Ready: false, Reason: "missing_config"}},
+	}
+	s := &engineapi.Sessions{Sessions: []engineapi.Session{{CallID: "1.2", Provider: "deepgram", ConversationState: "listening"}}}
+
+	live := liveStateFrom("1.2", "", h, s)
+	if !live.InProgress || live.ConversationState != "listening" || live.Provider != "deepgram" {
+		t.Fatalf("in progress: %+v", live)
+	}
+	if live.ProviderReady == nil || *live.ProviderReady || live.ProviderReason != "missing_config" {
+		t.Fatalf("provider readiness: %+v", live)
+	}
+
+	// A finished call on a pipeline: no session, no provider entry.
+	live = liveStateFrom("1.1", "local_hybrid", h, nil)
+	if live.InProgress || live.ProviderReady != nil || live.EngineStatus != "healthy" {
+		t.Fatalf("finished: %+v", live)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
 )
 
 // LLMAnalyzer performs AI-powered diagnosis
@@ -24,9 +26,9 @@ func NewLLMAnalyzer() (*LLMAnalyzer, error) {
 	provider := os.Getenv("TROUBLESHOOT_LLM_PROVIDER")
 	if provider == "" {
 		// Try to detect from available keys
-		if os.Getenv("OPENAI_API_KEY") != "" {
+		if v, _ := secrets.Lookup("OPENAI_API_KEY", nil); v != "" {
 			provider = "openai"
-		} else if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		} else if v, _ := secrets.Lookup("ANTHROPIC_API_KEY", nil); v != "" {
 			provider = "anthropic"
 		} else {
 			return nil, fmt.Errorf("no LLM provider configured")
@@ -36,10 +38,10 @@ func NewLLMAnalyzer() (*LLMAnalyzer, error) {
 	var apiKey, model string
 	switch provider {
 	case "openai":
-		apiKey = os.Getenv("OPENAI_API_KEY")
+		apiKey, _ = secrets.Lookup("OPENAI_API_KEY", nil)
 		model = "gpt-4o-mini" // Fast and cost-effective
 	case "anthropic":
-		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+		apiKey, _ = secrets.Lookup("ANTHROPIC_API_KEY", nil)
 		model = "claude-3-haiku-20240307" // Fast and cost-effective
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", provider)
@@ -58,20 +60,7 @@ func NewLLMAnalyzer() (*LLMAnalyzer, error) {
 
 // AnalyzeWithLLM performs AI-powered analysis
 func (llm *LLMAnalyzer) AnalyzeWithLLM(analysis *Analysis, logData string) (*LLMDiagnosis, error) {
-	prompt := llm.buildPrompt(analysis, logData)
-
-	var response string
-	var err error
-
-	switch llm.provider {
-	case "openai":
-		response, err = llm.callOpenAI(prompt)
-	case "anthropic":
-		response, err = llm.callAnthropic(prompt)
-	default:
-		return nil, fmt.Errorf("unsupported provider: %s", llm.provider)
-	}
-
+	response, err := llm.complete(llm.buildPrompt(analysis, logData))
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +72,17 @@ func (llm *LLMAnalyzer) AnalyzeWithLLM(analysis *Analysis, logData string) (*LLM
 	}, nil
 }
 
+// complete sends one prompt to the configured provider and returns its reply.
+func (llm *LLMAnalyzer) complete(prompt string) (string, error) {
+	switch llm.provider {
+	case "openai":
+		return llm.callOpenAI(prompt)
+	case "anthropic":
+		return llm.callAnthropic(prompt)
+	}
+	return "", fmt.Errorf("unsupported provider: %s", llm.provider)
+}
+
 // buildPrompt constructs the LLM prompt
 func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 	var prompt strings.Builder
@@ -105,6 +105,28 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 		prompt.WriteString("\n")
 	}
 
+	if rec := analysis.CDR; rec != nil {
+		prompt.WriteString("Asterisk CDR:\n")
+		prompt.WriteString(fmt.Sprintf("- Disposition: %s\n- Duration: %ds (billable %ds)\n", rec.Disposition, rec.Duration, rec.BillSec))
+		if rec.HangupCauseText != "" {
+			prompt.WriteString(fmt.Sprintf("- Hangup cause: %s (%d) by %s\n", rec.HangupCauseText, rec.HangupCause, emptyTo(rec.HangupSource, "unknown")))
+		}
+		prompt.WriteString("\n")
+	}
+
+	// Queue time is not the agent's; say so before the model reads the CDR
+	// duration as a slow call.
+	if q := analysis.Queue; q != nil {
+		prompt.WriteString("Asterisk queues (queue_log):\n")
+		for _, f := range q.Findings {
+			prompt.WriteString("- " + f + "\n")
+		}
+		for _, w := range q.Warnings {
+			prompt.WriteString("- " + w + "\n")
+		}
+		prompt.WriteString("\n")
+	}
+
 	// Log-derived header snapshot (preferred over guessing).
 	if analysis.Header != nil {
 		prompt.WriteString("RCA Header (log-derived):\n")
@@ -190,6 +212,16 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 		prompt.WriteString(fmt.Sprintf("Reported Symptom: %s\n\n", analysis.Symptom))
 	}
 
+	// Known issues already matched from the knowledge base; confirm or rule
+	// them out rather than rediscovering them.
+	if len(analysis.KnownIssues) > 0 {
+		prompt.WriteString("Known Issues Matched (from the troubleshooting knowledge base):\n")
+		for _, k := range analysis.KnownIssues {
+			prompt.WriteString(fmt.Sprintf("- %s: %s\n", k.Title, k.Cause))
+		}
+		prompt.WriteString("\n")
+	}
+
 	// Extracted metrics (CRITICAL for diagnosis)
 	if analysis.Metrics != nil {
 		prompt.WriteString(analysis.Metrics.FormatForLLM())
@@ -223,6 +255,18 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 		prompt.WriteString("- Do NOT suggest changing jitter_buffer_ms/min_start_ms/low_watermark_ms unless underflows/jitter evidence is present.\n\n")
 	}
 
+	// Provider-side evidence from other containers in the same window
+	if sideErrors, _ := sidecarProblems(analysis.Timeline); len(sideErrors) > 0 {
+		prompt.WriteString("Correlated Errors From Other Containers:\n")
+		for i, e := range sideErrors {
+			if i >= 5 {
+				break
+			}
+			prompt.WriteString("- " + truncate(e, 200) + "\n")
+		}
+		prompt.WriteString("\n")
+	}
+
 	// Sample logs (truncated)
 	prompt.WriteString("Sample Log Lines:\n")
 	lines := strings.Split(logData, "\n")
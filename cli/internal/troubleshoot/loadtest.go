@@ -0,0 +1,232 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+)
+
+// Load test degradation thresholds, against the first (lowest) concurrency
+// level.
+const (
+	loadScoreDrop       = 10.0 // quality points below the baseline average
+	loadScoreFloor      = 70.0 // below this the RCA verdict is POOR
+	loadLatencyGrowth   = 1.5  // p95 turn latency relative to the baseline
+	loadLatencyMinDelta = 300.0
+)
+
+// LoadCall is one synthetic call of a load test, scored from its engine logs.
+type LoadCall struct {
+	CallID      string    `json:"call_id"`
+	Concurrency int       `json:"concurrency"`
+	Logged      bool      `json:"logged"` // the engine logged the call
+	Score       *float64  `json:"quality_score,omitempty"`
+	Issues      []string  `json:"issues,omitempty"`
+	LatenciesMS []float64 `json:"turn_latencies_ms,omitempty"`
+	Error       string    `json:"error,omitempty"` // originate failure
+}
+
+// Failed reports calls that never reached the engine.
+func (c LoadCall) Failed() bool {
+	return c.Error != "" || !c.Logged
+}
+
+// AnalyzeLoadCall scores one call from its engine log lines the way agent rca
+// does, and collects its turn latencies.
+func AnalyzeLoadCall(callID string, lines []string) LoadCall {
+	c := LoadCall{CallID: callID, Logged: len(lines) > 0}
+	if !c.Logged {
+		return c
+	}
+	errors := 0
+	for _, line := range lines {
+		if isErrorLine(line) && !isBenignRCAErrorLine(line) {
+			errors++
+		}
+	}
+	if metrics := ExtractMetrics(strings.Join(lines, "\n")); metricsHasEvidence(metrics) {
+		score, issues := evaluateCallQuality(metrics)
+		score, issues = penalizeErrors(score, issues, errors)
+		c.Score, c.Issues = &score, issues
+	}
+	for _, e := range mergeTimeline(lines) {
+		if ms, ok := turnLatencyMS(e); ok {
+			c.LatenciesMS = append(c.LatenciesMS, ms)
+		}
+	}
+	return c
+}
+
+// ScoreCallsFrom scores the calls from callerNumber that started at or after
+// since, such as calls placed by an external SIPp rig. Each call's
+// concurrency is the most calls from callerNumber that were up at once while
+// it lasted.
+func ScoreCallsFrom(lines []string, callerNumber string, since time.Time) []LoadCall {
+	var ids []string
+	seen := map[string]bool{}
+	for _, line := range lines {
+		_, event, fields, ok := parseLogLine(line)
+		if !ok || event != logschema.EventCallStart || fields["caller_number"] != callerNumber {
+			continue
+		}
+		id := fields["call_id"]
+		if at, ok := engineLineTime(line); id == "" || seen[id] || (ok && at.Before(since)) {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	calls := make([]LoadCall, len(ids))
+	spans := make([][2]time.Time, len(ids))
+	for i, id := range ids {
+		callLines := FilterCallLines(lines, id)
+		calls[i] = AnalyzeLoadCall(id, callLines)
+		for _, line := range callLines {
+			if at, ok := engineLineTime(line); ok {
+				if spans[i][0].IsZero() {
+					spans[i][0] = at
+				}
+				spans[i][1] = at
+			}
+		}
+	}
+	// Concurrency peaks when a call starts, so count the calls up at each start.
+	upAt := make([]int, len(ids))
+	for j := range spans {
+		for k := range spans {
+			if !spans[k][0].After(spans[j][0]) && !spans[k][1].Before(spans[j][0]) {
+				upAt[j]++
+			}
+		}
+	}
+	for i := range calls {
+		for j := range spans {
+			if !spans[j][0].Before(spans[i][0]) && !spans[j][0].After(spans[i][1]) && upAt[j] > calls[i].Concurrency {
+				calls[i].Concurrency = upAt[j]
+			}
+		}
+	}
+	return calls
+}
+
+// LoadStep summarizes the calls placed at one concurrency level.
+type LoadStep struct {
+	Concurrency  int      `json:"concurrency"`
+	Calls        int      `json:"calls"`
+	Failed       int      `json:"failed"`
+	Scored       int      `json:"scored"`
+	ScoreAvg     float64  `json:"quality_avg,omitempty"`
+	ScoreMin     float64  `json:"quality_min,omitempty"`
+	Turns        int      `json:"turns"`
+	LatencyP50MS float64  `json:"latency_p50_ms,omitempty"`
+	LatencyP95MS float64  `json:"latency_p95_ms,omitempty"`
+	LatencyP99MS float64  `json:"latency_p99_ms,omitempty"`
+	Degraded     bool     `json:"degraded"`
+	Reasons      []string `json:"reasons,omitempty"`
+}
+
+// LoadReport is the outcome of a load test ramp.
+type LoadReport struct {
+	Steps      []LoadStep `json:"steps"`
+	MaxHealthy int        `json:"max_healthy"`           // highest level before the first degraded one
+	DegradedAt int        `json:"degraded_at,omitempty"` // first degraded level; 0 when none
+}
+
+// SummarizeLoad groups calls by concurrency level and marks the levels where
+// quality degraded: failed calls, an average score 10 points below the lowest
+// level's or under 70, or p95 turn latency 1.5x the lowest level's.
+func SummarizeLoad(calls []LoadCall) LoadReport {
+	byLevel := map[int][]LoadCall{}
+	var levels []int
+	for _, c := range calls {
+		if _, ok := byLevel[c.Concurrency]; !ok {
+			levels = append(levels, c.Concurrency)
+		}
+		byLevel[c.Concurrency] = append(byLevel[c.Concurrency], c)
+	}
+	sort.Ints(levels)
+
+	report := LoadReport{Steps: []LoadStep{}}
+	var base *LoadStep
+	for _, level := range levels {
+		step := summarizeLoadStep(level, byLevel[level])
+		if base == nil && step.Scored > 0 {
+			b := step
+			base = &b
+		}
+		step.Reasons = loadDegradation(step, base)
+		step.Degraded = len(step.Reasons) > 0
+		if step.Degraded && report.DegradedAt == 0 {
+			report.DegradedAt = level
+		}
+		if !step.Degraded && report.DegradedAt == 0 {
+			report.MaxHealthy = level
+		}
+		report.Steps = append(report.Steps, step)
+	}
+	return report
+}
+
+func summarizeLoadStep(level int, calls []LoadCall) LoadStep {
+	step := LoadStep{Concurrency: level, Calls: len(calls)}
+	var latencies []float64
+	sum := 0.0
+	for _, c := range calls {
+		if c.Failed() {
+			step.Failed++
+		}
+		if c.Score != nil {
+			if step.Scored == 0 || *c.Score < step.ScoreMin {
+				step.ScoreMin = *c.Score
+			}
+			step.Scored++
+			sum += *c.Score
+		}
+		latencies = append(latencies, c.LatenciesMS...)
+	}
+	if step.Scored > 0 {
+		step.ScoreAvg = sum / float64(step.Scored)
+	}
+	step.Turns = len(latencies)
+	if len(latencies) > 0 {
+		sort.Float64s(latencies)
+		step.LatencyP50MS = percentile(latencies, 50)
+		step.LatencyP95MS = percentile(latencies, 95)
+		step.LatencyP99MS = percentile(latencies, 99)
+	}
+	return step
+}
+
+// loadDegradation explains why a level is worse than the baseline level.
+func loadDegradation(step LoadStep, base *LoadStep) []string {
+	var out []string
+	if step.Failed > 0 {
+		out = append(out, fmt.Sprintf("%d of %d call(s) did not reach the engine", step.Failed, step.Calls))
+	}
+	if step.Scored == 0 {
+		return out
+	}
+	if step.ScoreAvg < loadScoreFloor {
+		out = append(out, fmt.Sprintf("average quality %.0f is below %.0f", step.ScoreAvg, loadScoreFloor))
+	} else if base != nil && step.ScoreAvg <= base.ScoreAvg-loadScoreDrop {
+		out = append(out, fmt.Sprintf("average quality %.0f vs %.0f at %d call(s)", step.ScoreAvg, base.ScoreAvg, base.Concurrency))
+	}
+	if base != nil && base.LatencyP95MS > 0 && step.LatencyP95MS >= base.LatencyP95MS*loadLatencyGrowth && step.LatencyP95MS-base.LatencyP95MS >= loadLatencyMinDelta {
+		out = append(out, fmt.Sprintf("p95 turn latency %.0fms vs %.0fms at %d call(s)", step.LatencyP95MS, base.LatencyP95MS, base.Concurrency))
+	}
+	return out
+}
+
+// percentile is the nearest-rank percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
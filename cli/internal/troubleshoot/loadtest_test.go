@@ -0,0 +1,93 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeLoadCall(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🎛️ STREAMING TUNING SUMMARY","call_id":"1.1","stream_id":"stream:response:1.1","bytes_sent":32000,"effective_seconds":2.0,"wall_seconds":2.0}`,
+		`{"timestamp":"2026-01-30T17:21:42.000Z","level":"info","event":"Turn latency recorded","call_id":"1.1","latency_ms":900}`,
+		`{"timestamp":"2026-01-30T17:21:46.000Z","level":"info","event":"Turn latency recorded","call_id":"1.1","latency_ms":1400}`,
+	}
+	c := AnalyzeLoadCall("1.1", lines)
+	if !c.Logged || c.Score == nil || *c.Score != 100 || len(c.LatenciesMS) != 2 || c.LatenciesMS[1] != 1400 {
+		t.Fatalf("call = %+v", c)
+	}
+
+	lines = append(lines, `{"timestamp":"2026-01-30T17:21:47.000Z","level":"error","event":"Provider websocket closed unexpectedly","call_id":"1.1"}`)
+	if c := AnalyzeLoadCall("1.1", lines); c.Score == nil || *c.Score != 50 || len(c.Issues) != 1 {
+		t.Fatalf("call with errors = %+v", c)
+	}
+	if c := AnalyzeLoadCall("1.2", nil); !c.Failed() || c.Score != nil {
+		t.Fatalf("unlogged call = %+v", c)
+	}
+}
+
+func TestSummarizeLoadFindsDegradation(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	var calls []LoadCall
+	for i := 0; i < 2; i++ {
+		calls = append(calls, LoadCall{Concurrency: 2, Logged: true, Score: score(100), LatenciesMS: []float64{800, 1000}})
+	}
+	for i := 0; i < 4; i++ {
+		calls = append(calls, LoadCall{Concurrency: 4, Logged: true, Score: score(95), LatenciesMS: []float64{900, 1100}})
+	}
+	for i := 0; i < 6; i++ {
+		calls = append(calls, LoadCall{Concurrency: 6, Logged: true, Score: score(85), LatenciesMS: []float64{1200, 2000}})
+	}
+	calls = append(calls, LoadCall{Concurrency: 8, Error: "HTTP 500"})
+
+	r := SummarizeLoad(calls)
+	if len(r.Steps) != 4 || r.MaxHealthy != 4 || r.DegradedAt != 6 {
+		t.Fatalf("report = %+v", r)
+	}
+	base, bad := r.Steps[0], r.Steps[2]
+	if base.LatencyP50MS != 800 || base.LatencyP95MS != 1000 || base.Turns != 4 {
+		t.Fatalf("baseline = %+v", base)
+	}
+	if len(bad.Reasons) != 2 || !strings.Contains(bad.Reasons[0], "average quality 85 vs 100") || !strings.Contains(bad.Reasons[1], "p95 turn latency 2000ms") {
+		t.Fatalf("degraded step = %+v", bad)
+	}
+	if last := r.Steps[3]; !last.Degraded || last.Failed != 1 {
+		t.Fatalf("failed step = %+v", last)
+	}
+}
+
+func TestScoreCallsFromConcurrency(t *testing.T) {
+	start := func(id, at, caller string) string {
+		return `{"timestamp":"2026-01-30T17:` + at + `Z","level":"info","event":"RCA_CALL_START","call_id":"` + id + `","caller_number":"` + caller + `"}`
+	}
+	end := func(id, at string) string {
+		return `{"timestamp":"2026-01-30T17:` + at + `Z","level":"info","event":"Turn latency recorded","call_id":"` + id + `","latency_ms":800}`
+	}
+	lines := []string{
+		start("1.1", "00:00.000", "5550100"),
+		start("1.2", "00:05.000", "5550100"),
+		start("1.3", "00:06.000", "15551234567"), // a real caller
+		end("1.1", "00:20.000"),
+		end("1.2", "00:25.000"),
+		start("1.4", "00:30.000", "5550100"),
+		end("1.4", "00:40.000"),
+		start("1.0", "00:00.000", "5550100"),
+	}
+	since, _ := time.Parse(time.RFC3339, "2026-01-30T16:59:00Z")
+	calls := ScoreCallsFrom(lines, "5550100", since)
+	got := map[string]int{}
+	for _, c := range calls {
+		got[c.CallID] = c.Concurrency
+	}
+	if len(calls) != 4 || got["1.0"] != 2 || got["1.1"] != 2 || got["1.2"] != 2 || got["1.4"] != 1 {
+		t.Fatalf("concurrency = %v", got)
+	}
+	if calls[0].LatenciesMS[0] != 800 {
+		t.Fatalf("call = %+v", calls[0])
+	}
+
+	since = since.Add(10 * time.Minute)
+	if calls := ScoreCallsFrom(lines, "5550100", since); len(calls) != 0 {
+		t.Fatalf("calls before --since = %+v", calls)
+	}
+}
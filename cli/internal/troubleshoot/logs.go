@@ -0,0 +1,327 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+var (
+	ansiStripPattern  = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	channelIDPattern  = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+	relatedIDPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`audiosocket_channel_id=([0-9]+\.[0-9]+)`),
+		regexp.MustCompile(`external_media_id=([0-9]+\.[0-9]+)`),
+		regexp.MustCompile(`pending_external_media_id=([0-9]+\.[0-9]+)`),
+		regexp.MustCompile(`\bchannel_id=([0-9]+\.[0-9]+)`),
+		regexp.MustCompile(`\bbridge_id=([0-9a-fA-F-]{36})`),
+	}
+)
+
+// StripANSI removes terminal color sequences from console-rendered logs.
+func StripANSI(s string) string {
+	return ansiStripPattern.ReplaceAllString(s, "")
+}
+
+// callCorrelator tracks a caller channel id plus the helper channel/bridge ids
+// (AudioSocket / ExternalMedia) discovered on lines that mention it. Many
+// ExternalMedia events are emitted on the helper channel id, not the caller id.
+type callCorrelator struct {
+	callID  string
+	related map[string]bool
+}
+
+func newCallCorrelator(callID string) *callCorrelator {
+	return &callCorrelator{callID: callID, related: map[string]bool{}}
+}
+
+// observe records related ids from a line that references the caller id.
+func (c *callCorrelator) observe(line string) {
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(line), &entry); err == nil {
+		for _, k := range []string{"audiosocket_channel_id", "external_media_id", "pending_external_media_id", "channel_id"} {
+			c.addRelated(entry[k])
+		}
+	}
+	// Console logs (and JSON lines with embedded text) may include related ids without structure.
+	for _, re := range relatedIDPatterns {
+		if m := re.FindStringSubmatch(line); len(m) > 1 && m[1] != c.callID {
+			c.related[m[1]] = true
+		}
+	}
+}
+
+func (c *callCorrelator) addRelated(v any) {
+	s, ok := v.(string)
+	if !ok {
+		return
+	}
+	s = strings.TrimSpace(s)
+	if s == "" || s == c.callID {
+		return
+	}
+	// Channel IDs are usually like 1761518880.2191; keep the filter loose but safe.
+	if channelIDPattern.MatchString(s) {
+		c.related[s] = true
+	}
+}
+
+func (c *callCorrelator) mentionsCall(line string) bool {
+	return strings.Contains(line, c.callID)
+}
+
+func (c *callCorrelator) mentionsRelated(line string) bool {
+	for id := range c.related {
+		if strings.Contains(line, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds for the lookback buffer of not-yet-matching lines. Helper channels are
+// created within seconds of the caller, so a recent window is enough to recover
+// lines logged before the helper id was first tied to the call.
+const (
+	lookbackMaxLines = 20000
+	lookbackMaxBytes = 32 << 20
+)
+
+type seqLine struct {
+	seq  int
+	line string
+}
+
+// callLineCollector is a single streaming pass over log lines that keeps lines for one
+// call (caller id plus related helper ids) with bounded memory.
+type callLineCollector struct {
+	c         *callCorrelator
+	seq       int
+	kept      []seqLine
+	seen      map[string]bool
+	lookback  []seqLine
+	backBytes int
+}
+
+func newCallLineCollector(callID string) *callLineCollector {
+	return &callLineCollector{c: newCallCorrelator(callID), seen: map[string]bool{}}
+}
+
+func (f *callLineCollector) add(line string) {
+	f.seq++
+	if line == "" {
+		return
+	}
+	if f.c.mentionsCall(line) {
+		before := len(f.c.related)
+		f.c.observe(line)
+		f.keep(seqLine{f.seq, line})
+		if len(f.c.related) > before {
+			f.recoverLookback()
+		}
+		return
+	}
+	if f.c.mentionsRelated(line) {
+		f.keep(seqLine{f.seq, line})
+		return
+	}
+	f.lookback = append(f.lookback, seqLine{f.seq, line})
+	f.backBytes += len(line)
+	for len(f.lookback) > lookbackMaxLines || f.backBytes > lookbackMaxBytes {
+		f.backBytes -= len(f.lookback[0].line)
+		f.lookback = f.lookback[1:]
+	}
+}
+
+func (f *callLineCollector) keep(l seqLine) {
+	if f.seen[l.line] {
+		return
+	}
+	f.seen[l.line] = true
+	f.kept = append(f.kept, l)
+}
+
+// recoverLookback moves buffered lines that mention a newly learned helper id into kept.
+func (f *callLineCollector) recoverLookback() {
+	rest := f.lookback[:0]
+	recovered := false
+	for _, l := range f.lookback {
+		if f.c.mentionsRelated(l.line) {
+			f.keep(l)
+			f.backBytes -= len(l.line)
+			recovered = true
+			continue
+		}
+		rest = append(rest, l)
+	}
+	f.lookback = rest
+	if recovered {
+		sort.SliceStable(f.kept, func(i, j int) bool { return f.kept[i].seq < f.kept[j].seq })
+	}
+}
+
+func (f *callLineCollector) lines() []string {
+	out := make([]string, len(f.kept))
+	for i, l := range f.kept {
+		out[i] = l.line
+	}
+	return out
+}
+
+// ScanCallLines streams r once and returns the lines for callID (see FilterCallLines).
+func ScanCallLines(r io.Reader, callID string) ([]string, error) {
+	f := newCallLineCollector(callID)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		f.add(StripANSI(scanner.Text()))
+	}
+	return f.lines(), scanner.Err()
+}
+
+// FilterCallLines keeps lines that reference callID or any related helper channel id,
+// in their original order, dropping exact duplicates.
+func FilterCallLines(lines []string, callID string) []string {
+	f := newCallLineCollector(callID)
+	for _, line := range lines {
+		f.add(line)
+	}
+	return f.lines()
+}
+
+// LogOptions controls `agent logs`.
+type LogOptions struct {
+	Container string
+	CallID    string
+	Level     string // minimum level: debug|info|warning|error|critical
+	Since     string
+	Tail      int
+	Follow    bool
+	// Redact, when set, masks personal data in each printed line.
+	Redact func(string) string
+}
+
+var levelRank = map[string]int{
+	"debug":    10,
+	"info":     20,
+	"warning":  30,
+	"warn":     30,
+	"error":    40,
+	"critical": 50,
+}
+
+// ParseLevel validates a --level value.
+func ParseLevel(level string) (int, error) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if level == "" {
+		return 0, nil
+	}
+	rank, ok := levelRank[level]
+	if !ok {
+		return 0, fmt.Errorf("invalid level %q (expected debug, info, warning, error, or critical)", level)
+	}
+	return rank, nil
+}
+
+// lineLevelFilter passes lines at or above a minimum level. Unparseable lines
+// (tracebacks, multi-line messages) follow the decision for the previous line.
+type lineLevelFilter struct {
+	min  int
+	last bool
+}
+
+func (f *lineLevelFilter) keep(line string) bool {
+	if f.min == 0 {
+		return true
+	}
+	level, _, _, ok := parseLogLine(line)
+	rank, known := levelRank[level]
+	if !ok || !known {
+		return f.last
+	}
+	f.last = rank >= f.min
+	return f.last
+}
+
+// StreamLogs prints container logs with ANSI stripping, optional level filtering and
+// call-aware correlation. Without --follow the full window is read first so helper
+// channels referenced later in the call are still included.
+func StreamLogs(opts LogOptions, w io.Writer) error {
+	minLevel, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	container := strings.TrimSpace(opts.Container)
+	if container == "" {
+		container = deployment.EngineContainer()
+	}
+
+	src, err := openLogSource(container, opts)
+	if err != nil {
+		return fmt.Errorf("docker logs %s failed: %w", container, err)
+	}
+	defer src.Close()
+
+	levels := &lineLevelFilter{min: minLevel}
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	emit := func(line string) {
+		if !levels.keep(line) {
+			return
+		}
+		if opts.Redact != nil {
+			line = opts.Redact(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	switch {
+	case opts.CallID == "":
+		for scanner.Scan() {
+			emit(StripANSI(scanner.Text()))
+		}
+	case opts.Follow:
+		// Streaming: correlate incrementally; helper ids are learned as caller lines arrive.
+		c := newCallCorrelator(opts.CallID)
+		for scanner.Scan() {
+			line := StripANSI(scanner.Text())
+			if c.mentionsCall(line) {
+				c.observe(line)
+				emit(line)
+			} else if c.mentionsRelated(line) {
+				emit(line)
+			}
+		}
+	default:
+		f := newCallLineCollector(opts.CallID)
+		for scanner.Scan() {
+			f.add(StripANSI(scanner.Text()))
+		}
+		for _, line := range f.lines() {
+			emit(line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("docker logs %s failed: %w", container, err)
+	}
+	return nil
+}
+
+// openLogSource picks the log reader: the engine log file when configured, otherwise
+// the container log stream (Engine API, or the docker CLI as a fallback).
+func openLogSource(container string, opts LogOptions) (io.ReadCloser, error) {
+	if path, ok := deployment.Current().EngineLogFile(); ok && container == deployment.EngineContainer() {
+		// File log source: --since does not apply; tail and follow work as tail -F.
+		return tailFile(path, opts.Tail, opts.Follow)
+	}
+	return dockerapi.LogsStream(container, dockerapi.LogsOptions{Since: strings.TrimSpace(opts.Since), Tail: opts.Tail, Follow: opts.Follow})
+}
@@ -0,0 +1,79 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterCallLinesIncludesHelperChannels(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{
+		"2026-01-30T17:21:40.000000-07:00 [info     ] StasisStart call_id=1769818882.1484",
+		"2026-01-30T17:21:40.100000-07:00 [info     ] unrelated call_id=1769818000.1000",
+		"2026-01-30T17:21:40.200000-07:00 [info     ] RTP packet routed channel_id=1769818883.1490",
+		"2026-01-30T17:21:40.300000-07:00 [info     ] ExternalMedia created call_id=1769818882.1484 external_media_id=1769818883.1490",
+		"2026-01-30T17:21:40.300000-07:00 [info     ] ExternalMedia created call_id=1769818882.1484 external_media_id=1769818883.1490",
+		"",
+	}
+	got := FilterCallLines(lines, "1769818882.1484")
+	if len(got) != 3 {
+		t.Fatalf("expected 3 lines, got %d: %v", len(got), got)
+	}
+	if !strings.Contains(got[1], "RTP packet routed") {
+		t.Fatalf("helper channel line should keep chronological position: %v", got)
+	}
+	for _, l := range got {
+		if strings.Contains(l, "unrelated") {
+			t.Fatalf("unrelated call leaked into output: %v", got)
+		}
+	}
+}
+
+func TestLineLevelFilterKeepsTracebackContinuation(t *testing.T) {
+	t.Parallel()
+
+	minLevel, err := ParseLevel("error")
+	if err != nil {
+		t.Fatalf("ParseLevel: %v", err)
+	}
+	f := &lineLevelFilter{min: minLevel}
+	cases := []struct {
+		line string
+		want bool
+	}{
+		{`{"level":"info","event":"ok"}`, false},
+		{`{"level":"error","event":"boom"}`, true},
+		{"Traceback (most recent call last):", true},
+		{`{"level":"debug","event":"noise"}`, false},
+		{"  File \"x.py\", line 1", false},
+	}
+	for _, c := range cases {
+		if got := f.keep(c.line); got != c.want {
+			t.Fatalf("keep(%q)=%v want %v", c.line, got, c.want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Fatalf("expected invalid level error")
+	}
+}
+
+func TestScanCallLinesDropsLookbackBeyondBound(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	b.WriteString("early helper line channel_id=1769818883.1490\n")
+	for i := 0; i < lookbackMaxLines; i++ {
+		b.WriteString("noise\n")
+	}
+	b.WriteString("recent helper line channel_id=1769818883.1490\n")
+	b.WriteString("\x1b[32mExternalMedia created\x1b[0m call_id=1769818882.1484 external_media_id=1769818883.1490\n")
+
+	got, err := ScanCallLines(strings.NewReader(b.String()), "1769818882.1484")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !strings.HasPrefix(got[0], "recent helper") || strings.Contains(got[1], "\x1b") {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/pjsip"
 )
 
 // CallMetrics holds extracted metrics from logs
@@ -26,6 +29,12 @@ type CallMetrics struct {
 	GateClosures        int
 	GateFlutterDetected bool
 
+	// Barge-in handling (from timestamped engine lines)
+	BargeIn *BargeInMetrics
+
+	// Network quality (from a packet capture or ARI RTP statistics)
+	MOS *MOSEstimate
+
 	// Transport/Format (from logs)
 	AudioSocketFormat    string
 	ProviderInputFormat  string
@@ -65,6 +74,10 @@ type FormatAlignment struct {
 	ProviderFormatMismatch bool
 	SampleRateMismatch     bool
 	FrameSizeMismatch      bool
+	CodecNegotiationIssue  bool
+
+	// From the Asterisk pjsip config (current, not call-time)
+	CodecAudit *pjsip.CodecAudit
 
 	// Detailed issues
 	Issues []string
@@ -117,7 +130,7 @@ func ExtractMetrics(logData string) *CallMetrics {
 		}
 
 		switch event {
-		case "PROVIDER SEGMENT BYTES":
+		case logschema.EventProviderSegment:
 			// JSON path still supported; console path uses fields parsing.
 			if len(fields) > 0 {
 				extractProviderBytesFields(fields, metrics)
@@ -128,7 +141,7 @@ func ExtractMetrics(logData string) *CallMetrics {
 				}
 			}
 
-		case "🎛️ STREAMING TUNING SUMMARY":
+		case logschema.EventTuningSummary:
 			if len(fields) > 0 {
 				extractStreamingSummaryFields(fields, metrics)
 			} else {
@@ -138,7 +151,7 @@ func ExtractMetrics(logData string) *CallMetrics {
 				}
 			}
 
-		case "Transport alignment summary":
+		case logschema.EventTransportAlignment:
 			if len(fields) > 0 {
 				extractTransportAlignmentFields(fields, metrics)
 			} else {
@@ -148,7 +161,7 @@ func ExtractMetrics(logData string) *CallMetrics {
 				}
 			}
 
-		case "🎯 WebRTC VAD settings":
+		case logschema.EventVADSettings:
 			if len(fields) > 0 {
 				extractVADSettingsFields(fields, metrics)
 			} else {
@@ -158,7 +171,7 @@ func ExtractMetrics(logData string) *CallMetrics {
 				}
 			}
 
-		case "Streaming segment bytes summary v2":
+		case logschema.EventSegmentSummary:
 			// Extract underflow count from segment summary
 			// Check if this is a greeting segment
 			streamID := fields["stream_id"]
@@ -0,0 +1,144 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/capture"
+)
+
+// MOS thresholds, in line with what SBCs flag on G.711 calls.
+const (
+	mosFair = 3.6
+	mosPoor = 3.1
+)
+
+// MOS sources.
+const (
+	MOSFromCapture = "capture"
+	MOSFromRTCP    = "rtcp"
+	MOSFromARI     = "ari"
+)
+
+// MOSLeg is one media direction's network figures and the MOS they give.
+type MOSLeg struct {
+	Name     string
+	Source   string
+	Codec    string
+	LossPct  float64
+	JitterMS float64
+	RTTMS    float64
+	R        float64
+	MOS      float64
+}
+
+// MOSEstimate is the call's estimated MOS: that of its worst leg.
+type MOSEstimate struct {
+	MOS   float64
+	R     float64
+	Worst string
+	Legs  []MOSLeg
+}
+
+// SetRTPStats adds Asterisk's RTP statistics for the caller channel, read
+// through ARI while the call was up, to the MOS estimate.
+func (r *Runner) SetRTPStats(stats *asterisk.RTPStats) {
+	r.rtpStats = stats
+}
+
+// codecImpairment is the E-model equipment impairment (Ie) and packet loss
+// robustness (Bpl) of a codec, from ITU-T G.113. Unknown codecs are taken
+// as G.711 with packet loss concealment.
+func codecImpairment(codec string) (ie, bpl float64) {
+	switch capture.NormalizeCodec(codec) {
+	case "g729":
+		return 11, 19
+	case "gsm":
+		return 20, 10
+	default:
+		return 0, 25.1
+	}
+}
+
+// emodel computes the R factor and MOS of ITU-T G.107 in its simplified
+// form: one-way delay is half the round trip, plus twice the jitter for the
+// jitter buffer and 10ms of codec delay.
+func emodel(codec string, lossPct, jitterMS, rttMS float64) (r, mos float64) {
+	delay := rttMS/2 + 2*jitterMS + 10
+	id := delay / 40
+	if delay >= 160 {
+		id = (delay - 120) / 10
+	}
+	ie, bpl := codecImpairment(codec)
+	ieEff := ie + (95-ie)*lossPct/(lossPct+bpl)
+	r = 93.2 - id - ieEff
+	switch {
+	case r <= 0:
+		mos = 1
+	case r >= 100:
+		mos = 4.5
+	default:
+		mos = 1 + 0.035*r + 7e-6*r*(r-60)*(100-r)
+	}
+	return math.Round(r), math.Round(mos*100) / 100
+}
+
+// estimateMOS rates each leg the call's media can be measured on: the RTP
+// streams in a capture, what their receivers reported in RTCP, and
+// Asterisk's statistics for the caller channel. Nil when there are none.
+func estimateMOS(c *capture.Report, stats *asterisk.RTPStats) *MOSEstimate {
+	var legs []MOSLeg
+	add := func(name, source, codec string, loss, jitter, rtt float64) {
+		l := MOSLeg{Name: name, Source: source, Codec: codec, LossPct: loss, JitterMS: jitter, RTTMS: rtt}
+		l.R, l.MOS = emodel(codec, loss, jitter, rtt)
+		legs = append(legs, l)
+	}
+	if c != nil {
+		for _, s := range c.Streams {
+			if s.Transport != "rtp" || s.Packets == 0 {
+				continue
+			}
+			rtt := 0.0
+			if s.RTCP != nil {
+				rtt = s.RTCP.RTTMS
+			}
+			add(s.Label(), MOSFromCapture, s.Codec, s.LossPct, s.JitterMS, rtt)
+			if rc := s.RTCP; rc != nil && rc.CumulativeLost > 0 {
+				add(s.Label()+" (receiver)", MOSFromRTCP, s.Codec, 100*float64(rc.CumulativeLost)/float64(s.Packets+rc.CumulativeLost), rc.JitterMS, rc.RTTMS)
+			}
+		}
+	}
+	if stats != nil {
+		rtt := stats.RTT * 1000
+		if stats.RxCount > 0 {
+			add("caller → Asterisk", MOSFromARI, "", 100*float64(stats.RxPLoss)/float64(stats.RxCount+stats.RxPLoss), stats.RxJitter*1000, rtt)
+		}
+		if stats.TxCount > 0 {
+			add("Asterisk → caller", MOSFromARI, "", 100*float64(stats.TxPLoss)/float64(stats.TxCount), stats.TxJitter*1000, rtt)
+		}
+	}
+	if len(legs) == 0 {
+		return nil
+	}
+	est := &MOSEstimate{MOS: 5, Legs: legs}
+	for _, l := range legs {
+		if l.MOS < est.MOS {
+			est.MOS, est.R, est.Worst = l.MOS, l.R, l.Name
+		}
+	}
+	return est
+}
+
+// mosIssue describes a MOS below what an SBC would call fair.
+func mosIssue(m *MOSEstimate) string {
+	if m == nil || m.MOS >= mosFair {
+		return ""
+	}
+	for _, l := range m.Legs {
+		if l.Name == m.Worst {
+			return fmt.Sprintf("estimated MOS %.1f on %s (loss %.1f%%, jitter %.0fms, RTT %.0fms)", m.MOS, l.Name, l.LossPct, l.JitterMS, l.RTTMS)
+		}
+	}
+	return fmt.Sprintf("estimated MOS %.1f", m.MOS)
+}
@@ -0,0 +1,53 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/capture"
+)
+
+func TestEModel(t *testing.T) {
+	for _, tc := range []struct {
+		codec                 string
+		loss, jitter, rtt     float64
+		wantR                 float64
+		wantMOSLow, wantMOSHi float64
+	}{
+		{"ulaw", 0, 0, 0, 93, 4.39, 4.41},
+		{"alaw", 5, 0, 0, 77, 3.90, 3.92},
+		{"pcmu", 10, 40, 0, 64, 3.29, 3.31},
+		{"g729", 0, 0, 0, 82, 4.06, 4.10},
+		{"ulaw", 0, 20, 400, 80, 4.02, 4.04},
+	} {
+		r, mos := emodel(tc.codec, tc.loss, tc.jitter, tc.rtt)
+		if r != tc.wantR || mos < tc.wantMOSLow || mos > tc.wantMOSHi {
+			t.Errorf("emodel(%s, loss %g, jitter %g, rtt %g) = R %g, MOS %g", tc.codec, tc.loss, tc.jitter, tc.rtt, r, mos)
+		}
+	}
+}
+
+func TestEstimateMOS(t *testing.T) {
+	if estimateMOS(nil, nil) != nil {
+		t.Fatal("no media figures should give no estimate")
+	}
+	c := &capture.Report{Streams: []capture.Stream{
+		{Transport: "rtp", Direction: capture.ToEngine, Codec: "ulaw", Packets: 1000, JitterMS: 2},
+		{Transport: "audiosocket", Direction: capture.ToEngine, Codec: "slin", Packets: 1000},
+	}}
+	// The trunk loses 10% of what the caller sends.
+	stats := &asterisk.RTPStats{RxCount: 900, RxPLoss: 100, RxJitter: 0.04, TxCount: 1000, RTT: 0.06}
+	m := estimateMOS(c, stats)
+	if m == nil || len(m.Legs) != 3 || m.Worst != "caller → Asterisk" || m.MOS > mosPoor+0.3 {
+		t.Fatalf("estimate = %+v", m)
+	}
+	if m.Legs[1].Source != MOSFromARI || m.Legs[1].LossPct != 10 || m.Legs[1].JitterMS != 40 {
+		t.Fatalf("ARI leg = %+v", m.Legs[1])
+	}
+
+	score, issues := evaluateCallQuality(&CallMetrics{MOS: m})
+	if score != 90 || len(issues) != 1 || !strings.Contains(issues[0], "estimated MOS 3.3 on caller → Asterisk (loss 10.0%") {
+		t.Fatalf("score %v, issues %v", score, issues)
+	}
+}
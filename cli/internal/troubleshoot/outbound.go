@@ -0,0 +1,126 @@
+package troubleshoot
+
+import (
+	"sort"
+	"time"
+)
+
+// Outbound call outcomes.
+const (
+	OutboundFailed    = "failed"    // the originate request failed
+	OutboundNoAnswer  = "no answer" // the callee did not pick up
+	OutboundAnswered  = "answered"  // picked up, but the engine never logged the call
+	OutboundConnected = "connected" // the agent took the call
+)
+
+// OutboundCall is one test call the agent placed to a number.
+type OutboundCall struct {
+	CallID    string    `json:"call_id"`
+	To        string    `json:"to"`
+	Started   time.Time `json:"started"`
+	Answered  bool      `json:"answered"`
+	Connected bool      `json:"connected"` // the engine logged the call
+	// Hangup is the CDR disposition and cause of a call that was not answered.
+	Hangup       string    `json:"hangup,omitempty"`
+	Score        *float64  `json:"quality_score,omitempty"`
+	Issues       []string  `json:"issues,omitempty"`
+	LatenciesMS  []float64 `json:"turn_latencies_ms,omitempty"`
+	LatencyP95MS float64   `json:"latency_p95_ms,omitempty"`
+	Error        string    `json:"error,omitempty"` // originate failure
+}
+
+// Outcome is how far the call got.
+func (c OutboundCall) Outcome() string {
+	switch {
+	case c.Error != "":
+		return OutboundFailed
+	case c.Connected:
+		return OutboundConnected
+	case c.Answered:
+		return OutboundAnswered
+	}
+	return OutboundNoAnswer
+}
+
+// ScoreOutboundCall scores the call from its engine log lines as agent rca
+// would. A call the engine logged was answered, even if no poll saw it up.
+func ScoreOutboundCall(c OutboundCall, lines []string) OutboundCall {
+	if c.Error != "" {
+		return c
+	}
+	scored := AnalyzeLoadCall(c.CallID, lines)
+	c.Connected = scored.Logged
+	c.Answered = c.Answered || scored.Logged
+	c.Score, c.Issues, c.LatenciesMS = scored.Score, scored.Issues, scored.LatenciesMS
+	if len(c.LatenciesMS) > 0 {
+		sorted := append([]float64(nil), c.LatenciesMS...)
+		sort.Float64s(sorted)
+		c.LatencyP95MS = percentile(sorted, 95)
+	}
+	return c
+}
+
+// OutboundReport sums up an outbound test campaign.
+type OutboundReport struct {
+	Placed    int `json:"placed"`
+	Failed    int `json:"failed"` // originate failures
+	Answered  int `json:"answered"`
+	Connected int `json:"connected"`
+	// AnswerRate is answered of placed calls; ConnectRate is connected of
+	// answered ones, the calls that reached the agent once picked up.
+	AnswerRate   float64 `json:"answer_rate"`
+	ConnectRate  float64 `json:"connect_rate"`
+	Scored       int     `json:"scored"`
+	ScoreAvg     float64 `json:"quality_avg,omitempty"`
+	ScoreMin     float64 `json:"quality_min,omitempty"`
+	LowQuality   int     `json:"low_quality"` // scored under 70
+	Turns        int     `json:"turns"`
+	LatencyP50MS float64 `json:"latency_p50_ms,omitempty"`
+	LatencyP95MS float64 `json:"latency_p95_ms,omitempty"`
+}
+
+// SummarizeOutbound counts the campaign's calls by outcome and pools the
+// connected calls' scores and turn latencies.
+func SummarizeOutbound(calls []OutboundCall) OutboundReport {
+	r := OutboundReport{Placed: len(calls)}
+	var latencies []float64
+	sum := 0.0
+	for _, c := range calls {
+		switch c.Outcome() {
+		case OutboundFailed:
+			r.Failed++
+		case OutboundConnected:
+			r.Connected++
+			r.Answered++
+		case OutboundAnswered:
+			r.Answered++
+		}
+		if c.Score != nil {
+			if r.Scored == 0 || *c.Score < r.ScoreMin {
+				r.ScoreMin = *c.Score
+			}
+			r.Scored++
+			sum += *c.Score
+			if *c.Score < loadScoreFloor {
+				r.LowQuality++
+			}
+		}
+		latencies = append(latencies, c.LatenciesMS...)
+	}
+	if r.Placed > 0 {
+		r.AnswerRate = float64(r.Answered) / float64(r.Placed)
+	}
+	if r.Answered > 0 {
+		r.ConnectRate = float64(r.Connected) / float64(r.Answered)
+	}
+	if r.Scored > 0 {
+		r.ScoreAvg = sum / float64(r.Scored)
+	}
+	r.Turns = len(latencies)
+	if len(latencies) > 0 {
+		sort.Float64s(latencies)
+		r.LatencyP50MS = percentile(latencies, 50)
+		r.LatencyP95MS = percentile(latencies, 95)
+	}
+	return r
+}
@@ -0,0 +1,33 @@
+package troubleshoot
+
+import "testing"
+
+func TestSummarizeOutbound(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"🎛️ STREAMING TUNING SUMMARY","call_id":"1.1","stream_id":"stream:response:1.1","bytes_sent":32000,"effective_seconds":2.0,"wall_seconds":2.0}`,
+		`{"timestamp":"2026-01-30T17:21:42.000Z","level":"info","event":"Turn latency recorded","call_id":"1.1","latency_ms":900}`,
+	}
+	calls := []OutboundCall{
+		ScoreOutboundCall(OutboundCall{CallID: "1.1"}, lines),
+		ScoreOutboundCall(OutboundCall{CallID: "1.2", Answered: true}, nil),
+		ScoreOutboundCall(OutboundCall{CallID: "1.3", Hangup: "BUSY"}, nil),
+		ScoreOutboundCall(OutboundCall{CallID: "1.4", Error: "HTTP 500"}, lines),
+	}
+	want := []string{OutboundConnected, OutboundAnswered, OutboundNoAnswer, OutboundFailed}
+	for i, c := range calls {
+		if c.Outcome() != want[i] {
+			t.Errorf("%s outcome = %q, want %q", c.CallID, c.Outcome(), want[i])
+		}
+	}
+	if !calls[0].Answered || calls[0].Score == nil || *calls[0].Score != 100 || calls[0].LatencyP95MS != 900 || calls[3].Score != nil {
+		t.Fatalf("scored calls = %+v", calls)
+	}
+
+	r := SummarizeOutbound(calls)
+	if r.Placed != 4 || r.Failed != 1 || r.Answered != 2 || r.Connected != 1 {
+		t.Fatalf("report = %+v", r)
+	}
+	if r.AnswerRate != 0.5 || r.ConnectRate != 0.5 || r.Scored != 1 || r.LowQuality != 0 || r.LatencyP95MS != 900 {
+		t.Fatalf("rates = %+v", r)
+	}
+}
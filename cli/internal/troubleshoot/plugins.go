@@ -0,0 +1,63 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+)
+
+// SetPlugins adds the analyzer plugins' findings to every analyzed call.
+// cliVersion is passed on to the plugins.
+func (r *Runner) SetPlugins(plugins []plugin.Plugin, cliVersion string) {
+	r.plugins = plugin.With(plugins, plugin.HookAnalyze)
+	r.cliVersion = cliVersion
+}
+
+// Report is the analyzed call's report, as agent rca --json prints it, or
+// nil before a call has been analyzed.
+func (r *Runner) Report() *RCAReport {
+	if r.analysis == nil {
+		return nil
+	}
+	rep := buildRCAReport(r.analysis, r.llm)
+	rep.SchemaVersion = contract.SchemaVersion
+	return rep
+}
+
+// applyPlugins runs the analyzer plugins on the call and merges what they
+// find. Each sees the report as it stands before the knowledge base and the
+// LLM; one that fails is skipped with a note on stderr.
+func (r *Runner) applyPlugins(analysis *Analysis, logData string) {
+	for _, p := range r.plugins {
+		rep := buildRCAReport(analysis, nil)
+		rep.SchemaVersion = contract.SchemaVersion
+		f, err := p.Analyze(r.cliVersion, rep, r.symptom, logData)
+		if err != nil {
+			if !r.silent {
+				fmt.Fprintf(os.Stderr, "plugin %s: %v\n", p.Name, err)
+			}
+			continue
+		}
+		mergeFindings(analysis, f)
+	}
+}
+
+// mergeFindings adds a plugin's findings; likely causes and actions go to the
+// symptom analysis, which is started for them when no symptom was given.
+func mergeFindings(analysis *Analysis, f plugin.Findings) {
+	analysis.Errors = append(analysis.Errors, f.Errors...)
+	analysis.Warnings = append(analysis.Warnings, f.Warnings...)
+	analysis.AudioIssues = append(analysis.AudioIssues, f.AudioIssues...)
+	if len(f.RootCauses) == 0 && len(f.Actions) == 0 {
+		return
+	}
+	sa := analysis.SymptomAnalysis
+	if sa == nil {
+		sa = &SymptomAnalysis{Symptom: "plugins", Description: "Findings of site analyzer plugins"}
+		analysis.SymptomAnalysis = sa
+	}
+	sa.RootCauses = append(sa.RootCauses, f.RootCauses...)
+	sa.Actions = append(sa.Actions, f.Actions...)
+}
@@ -0,0 +1,30 @@
+package troubleshoot
+
+import (
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+)
+
+func TestMergeFindings(t *testing.T) {
+	a := &Analysis{Errors: []string{"engine error"}}
+	mergeFindings(a, plugin.Findings{Warnings: []string{"SBC retransmits INVITE"}})
+	if len(a.Errors) != 1 || len(a.Warnings) != 1 || a.SymptomAnalysis != nil {
+		t.Fatalf("analysis = %+v", a)
+	}
+	mergeFindings(a, plugin.Findings{Errors: []string{"SBC rejected the call (503)"}, RootCauses: []string{"SBC trunk overloaded"}, Actions: []string{"Raise the SBC call limit"}})
+	if len(a.Errors) != 2 {
+		t.Errorf("errors = %v", a.Errors)
+	}
+	sa := a.SymptomAnalysis
+	if sa == nil || sa.Symptom != "plugins" || len(sa.RootCauses) != 1 || len(sa.Actions) != 1 {
+		t.Fatalf("symptom analysis = %+v", sa)
+	}
+
+	// A symptom's analysis is extended, not replaced.
+	b := &Analysis{SymptomAnalysis: &SymptomAnalysis{Symptom: "echo", RootCauses: []string{"AEC off"}}}
+	mergeFindings(b, plugin.Findings{RootCauses: []string{"SBC loops media back"}})
+	if b.SymptomAnalysis.Symptom != "echo" || len(b.SymptomAnalysis.RootCauses) != 2 {
+		t.Errorf("symptom analysis = %+v", b.SymptomAnalysis)
+	}
+}
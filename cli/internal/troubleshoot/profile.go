@@ -0,0 +1,193 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+)
+
+// operatorFindings is how many findings and next steps the operator profile
+// lists before pointing at the full report.
+const operatorFindings = 3
+
+// SetProfile picks how much of the report the text output shows: the operator
+// profile a short summary and next steps, the developer profile every finding
+// as logged and the metrics under their internal names. JSON output is the
+// same for every profile.
+func (r *Runner) SetProfile(p output.Profile) {
+	r.profile = p
+}
+
+// displayOperator prints what happened on the call, whether it was bad, and
+// what to do about it, without log lines or metric names.
+func (r *Runner) displayOperator(analysis *Analysis, llm *LLMDiagnosis) {
+	fmt.Println()
+	fmt.Printf("📞 Call %s\n", analysis.CallID)
+	if h := analysis.Header; h != nil && (h.CallerNumber != "" || h.CalledNumber != "") {
+		from := strings.TrimSpace(h.CallerName + " " + h.CallerNumber)
+		fmt.Printf("   %s → %s\n", orDash(from), orDash(h.CalledNumber))
+	}
+	if ch := analysis.CallHistory; ch != nil && (ch.DurationSeconds > 0 || ch.Outcome != "") {
+		fmt.Printf("   %.0fs, %s\n", ch.DurationSeconds, orDash(ch.Outcome))
+	}
+	fmt.Println()
+
+	code := r.ExitCode()
+	line := "Result: " + resultLabel(code)
+	if metricsHasEvidence(analysis.Metrics) {
+		score, _ := callQualityScore(analysis)
+		line += fmt.Sprintf(" (quality %.0f/100)", score)
+	}
+	switch code {
+	case contract.OK:
+		successColor.Println(line)
+	case contract.Warn:
+		warningColor.Println(line)
+	default:
+		errorColor.Println(line)
+	}
+
+	var problems, steps []string
+	for _, k := range analysis.KnownIssues {
+		problems = append(problems, k.Title)
+		steps = append(steps, k.Fix...)
+	}
+	problems = append(problems, analysis.Errors...)
+	if analysis.SymptomAnalysis != nil {
+		problems = append(problems, analysis.SymptomAnalysis.RootCauses...)
+	}
+	problems = append(problems, analysis.Warnings...)
+	problems = append(problems, analysis.AudioIssues...)
+	if len(problems) > 0 {
+		fmt.Println()
+		fmt.Println("What went wrong:")
+		printOperatorList(problems)
+	}
+	if e := analysis.Ending; e != nil && e.Explanation != "" {
+		fmt.Println()
+		fmt.Printf("How it ended: %s\n", e.Explanation)
+	}
+
+	if analysis.SymptomAnalysis != nil {
+		steps = append(steps, analysis.SymptomAnalysis.Actions...)
+	}
+	steps = append(steps, recommendations(analysis)...)
+	if len(steps) > 0 {
+		fmt.Println()
+		fmt.Println("What to do:")
+		printOperatorList(steps)
+	}
+
+	if q := analysis.Conversation; q != nil && q.Error == "" {
+		fmt.Println()
+		fmt.Printf("Conversation: %.1f/5", q.Overall)
+		if q.Summary != "" {
+			fmt.Printf(" - %s", q.Summary)
+		}
+		fmt.Println()
+	}
+	if llm != nil {
+		summary, _, _ := strings.Cut(strings.TrimSpace(llm.Analysis), "\n\n")
+		fmt.Println()
+		infoColor.Println("AI diagnosis:")
+		fmt.Println(summary)
+	}
+	fmt.Println()
+}
+
+func printOperatorList(items []string) {
+	for i, it := range items {
+		if i == operatorFindings {
+			fmt.Printf("  ... and %d more (agent rca --output-profile support for the full report)\n", len(items)-i)
+			return
+		}
+		fmt.Printf("  • %s\n", truncate(it, 100))
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// displayMetricNames lists the extracted metrics under the names the --json
+// report uses, for matching the text report against scripts and dashboards.
+func (r *Runner) displayMetricNames(metrics *CallMetrics) {
+	names := metricNames(metrics)
+	if len(names) == 0 {
+		return
+	}
+	fmt.Println("Raw metrics:")
+	for _, n := range names {
+		fmt.Printf("  %s\n", n)
+	}
+	fmt.Println()
+}
+
+// metricNames lists the metrics as sorted name=value lines.
+func metricNames(metrics *CallMetrics) []string {
+	values := metricValues(metrics)
+	lines := make([]string, 0, len(values))
+	for name, v := range values {
+		lines = append(lines, name+"="+v)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// metricValues flattens the metrics by their --json names. Nested records are
+// dotted and a list of records is len(<name>); the known issue fingerprints
+// use the same names.
+func metricValues(metrics *CallMetrics) map[string]string {
+	values := map[string]string{}
+	raw, err := json.Marshal(metrics)
+	if err != nil {
+		return values
+	}
+	var tree map[string]interface{}
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		return values
+	}
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		name := strings.TrimSuffix(prefix, ".")
+		switch v := v.(type) {
+		case nil:
+		case map[string]interface{}:
+			for k, child := range v {
+				walk(prefix+k+".", child)
+			}
+		case []interface{}:
+			scalars := make([]string, 0, len(v))
+			for _, el := range v {
+				if _, nested := el.(map[string]interface{}); nested {
+					values["len("+name+")"] = strconv.Itoa(len(v))
+					return
+				}
+				scalars = append(scalars, metricValue(el))
+			}
+			if len(scalars) > 0 {
+				values[name] = strings.Join(scalars, ",")
+			}
+		default:
+			values[name] = metricValue(v)
+		}
+	}
+	walk("", tree)
+	return values
+}
+
+// metricValue prints a decoded JSON scalar; byte counts stay whole numbers.
+func metricValue(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,33 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricNames(t *testing.T) {
+	m := &CallMetrics{
+		ProviderSegments:   []ProviderSegment{{}, {}},
+		ProviderBytesTotal: 1234567,
+		WorstDriftPct:      12.5,
+		VADSettings:        &VADSettings{},
+		ConfigErrors:       []string{"a", "b"},
+	}
+	got := strings.Join(metricNames(m), "\n")
+	for _, want := range []string{"ProviderBytesTotal=1234567", "WorstDriftPct=12.5", "len(ProviderSegments)=2", "ConfigErrors=a,b"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "MOS") {
+		t.Errorf("nil record listed:\n%s", got)
+	}
+}
+
+func TestRecommendations(t *testing.T) {
+	a := &Analysis{AudioTransport: "externalmedia", Errors: make([]string, 11)}
+	recs := recommendations(a)
+	if len(recs) != 4 || !strings.Contains(recs[1], "UDP 18080") || !strings.HasPrefix(recs[2], "High error count") {
+		t.Errorf("recommendations = %q", recs)
+	}
+}
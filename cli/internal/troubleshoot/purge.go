@@ -0,0 +1,310 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/statecrypt"
+)
+
+// Purged artifact kinds.
+const (
+	PurgeCallIndex     = "call_index"
+	PurgeQualitySample = "quality_sample"
+	PurgeCapture       = "capture"
+	PurgeRecording     = "recording"
+)
+
+// recordingExtensions are the audio files Asterisk's MixMonitor writes, as the
+// Admin UI's recording playback matches them.
+var recordingExtensions = map[string]bool{".wav": true, ".ulaw": true, ".gsm": true}
+
+// artifactCallPattern finds a channel ID (epoch.sequence) in a file name.
+var artifactCallPattern = regexp.MustCompile(`[0-9]{9,11}\.[0-9]+`)
+
+// PurgeItem is one stored artifact of a call that was (or, in a dry run, would
+// be) deleted. A call index entry or quality sample is a record inside Path.
+type PurgeItem struct {
+	Kind   string    `json:"kind"`
+	CallID string    `json:"call_id,omitempty"` // empty for a capture no call was matched to
+	At     time.Time `json:"at"`
+	Path   string    `json:"path"`
+}
+
+// PurgeOptions picks the calls to purge, either by channel ID or by start
+// time, and where their files are kept.
+type PurgeOptions struct {
+	CallIDs []string
+	Before  time.Time
+	// CaptureDir holds agent capture's pcaps; RecordingDir Asterisk's call
+	// recordings. Empty skips them.
+	CaptureDir   string
+	RecordingDir string
+	DryRun       bool
+}
+
+func (o PurgeOptions) matches(callID string, at time.Time) bool {
+	if len(o.CallIDs) > 0 {
+		for _, id := range o.CallIDs {
+			if callID == id {
+				return true
+			}
+		}
+		return false
+	}
+	return !o.Before.IsZero() && !at.IsZero() && at.Before(o.Before)
+}
+
+// ResolvePurgeCalls maps --call selectors to channel IDs. A channel ID is
+// taken as given; a phone number selects every indexed call from or to it.
+func ResolvePurgeCalls(selectors []string) ([]string, error) {
+	var ids []string
+	seen := map[string]bool{}
+	var calls []Call
+	for _, sel := range selectors {
+		sel = strings.TrimSpace(sel)
+		if sel == "" {
+			continue
+		}
+		found := []string{sel}
+		if !isChannelID(sel) {
+			if !phoneSelectorPattern.MatchString(sel) {
+				return nil, fmt.Errorf("%q is not a call id or phone number", sel)
+			}
+			if calls == nil {
+				calls = IndexedCalls(1 << 30)
+			}
+			hits, err := callsForNumber(calls, sel)
+			if err != nil {
+				return nil, err
+			}
+			found = found[:0]
+			for _, c := range hits {
+				found = append(found, c.ID)
+			}
+		}
+		for _, id := range found {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Purge deletes what the CLI stored about the selected calls: call index
+// entries, quality trend samples, packet captures and call recordings. Other
+// records in the index and trend files are kept. It returns what was deleted,
+// or would be with DryRun.
+func Purge(opts PurgeOptions) ([]PurgeItem, error) {
+	var items []PurgeItem
+	for _, step := range []func(PurgeOptions) ([]PurgeItem, error){
+		purgeCallIndex,
+		purgeQualitySamples,
+		func(o PurgeOptions) ([]PurgeItem, error) { return purgeFiles(o, PurgeCapture, o.CaptureDir) },
+		func(o PurgeOptions) ([]PurgeItem, error) { return purgeFiles(o, PurgeRecording, o.RecordingDir) },
+	} {
+		found, err := step(opts)
+		items = append(items, found...)
+		if err != nil {
+			return items, err
+		}
+	}
+	return items, nil
+}
+
+// CallFiles lists a call's packet captures and recordings, the files agent
+// purge would delete for it, without deleting them.
+func CallFiles(callID, captureDir, recordingDir string) ([]PurgeItem, error) {
+	opts := PurgeOptions{CallIDs: []string{callID}, DryRun: true}
+	items, err := purgeFiles(opts, PurgeCapture, captureDir)
+	if err != nil {
+		return items, err
+	}
+	recordings, err := purgeFiles(opts, PurgeRecording, recordingDir)
+	return append(items, recordings...), err
+}
+
+// purgeCallIndex drops the calls from the index. The file is read as it is, not
+// through loadCallIndex, so an index kept for another log source is edited
+// rather than replaced.
+func purgeCallIndex(opts PurgeOptions) ([]PurgeItem, error) {
+	path := CallIndexPath()
+	raw, err := statecrypt.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	idx := &callIndex{}
+	if err := json.Unmarshal(raw, idx); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var items []PurgeItem
+	for id, e := range idx.Calls {
+		at := e.FirstSeen
+		if e.StartedAt != nil {
+			at = *e.StartedAt
+		}
+		if opts.matches(id, at) {
+			items = append(items, PurgeItem{Kind: PurgeCallIndex, CallID: id, At: at, Path: path})
+			delete(idx.Calls, id)
+		}
+	}
+	sortPurgeItems(items)
+	if len(items) == 0 || opts.DryRun {
+		return items, nil
+	}
+	return items, idx.save(path)
+}
+
+// purgeQualitySamples rewrites samples.jsonl without the calls' samples; every
+// other line is kept as it was, encrypted or not.
+func purgeQualitySamples(opts PurgeOptions) ([]PurgeItem, error) {
+	path := filepath.Join(TrendDir(), "samples.jsonl")
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var kept strings.Builder
+	var items []PurgeItem
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line, err := statecrypt.ReadLine(sc.Bytes())
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		var s QualitySample
+		if json.Unmarshal(line, &s) == nil && s.CallID != "" && opts.matches(s.CallID, s.At) {
+			items = append(items, PurgeItem{Kind: PurgeQualitySample, CallID: s.CallID, At: s.At, Path: path})
+			continue
+		}
+		kept.Write(sc.Bytes())
+		kept.WriteByte('\n')
+	}
+	f.Close()
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	sortPurgeItems(items)
+	if len(items) == 0 || opts.DryRun {
+		return items, nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(kept.String()), 0o644); err != nil {
+		return nil, err
+	}
+	return items, os.Rename(tmp, path)
+}
+
+// purgeFiles deletes the files under dir named for a selected call: pcaps
+// named <call_id>.pcap by agent capture, and recordings whose name holds the
+// channel ID, in dir or its YYYY/MM/DD folders. With Before, a capture no
+// call was matched to (capture-<time>.pcap) goes by the time in its name.
+func purgeFiles(opts PurgeOptions, kind, dir string) ([]PurgeItem, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var items []PurgeItem
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel, _ := filepath.Rel(dir, path); rel != "." && strings.Count(rel, string(filepath.Separator)) >= 3 {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(d.Name()))
+		if (kind == PurgeCapture && ext != ".pcap") || (kind == PurgeRecording && !recordingExtensions[ext]) {
+			return nil
+		}
+		id, at := artifactCall(d.Name(), opts)
+		if id == "" && kind == PurgeCapture {
+			at = captureFileTime(d.Name())
+		}
+		if !opts.matches(id, at) {
+			return nil
+		}
+		items = append(items, PurgeItem{Kind: kind, CallID: id, At: at, Path: path})
+		if opts.DryRun {
+			return nil
+		}
+		return os.Remove(path)
+	})
+	sortPurgeItems(items)
+	return items, err
+}
+
+// artifactCall returns the channel ID in a file name and the time it encodes
+// (the integer part is the channel's creation time). Of several IDs in the
+// name, a selected one is preferred, then the last, where MixMonitor puts
+// the unique ID.
+func artifactCall(name string, opts PurgeOptions) (string, time.Time) {
+	var id string
+	for _, loc := range artifactCallPattern.FindAllStringIndex(name, -1) {
+		if (loc[0] > 0 && isDigitByte(name[loc[0]-1])) || (loc[1] < len(name) && isDigitByte(name[loc[1]])) {
+			continue // part of a longer number, as .26 is of .265
+		}
+		id = name[loc[0]:loc[1]]
+		if opts.matches(id, channelIDTime(id)) {
+			break
+		}
+	}
+	if id == "" {
+		return "", time.Time{}
+	}
+	return id, channelIDTime(id)
+}
+
+// channelIDTime is the creation time in an Asterisk channel ID.
+func channelIDTime(id string) time.Time {
+	sec, _, _ := strings.Cut(id, ".")
+	n, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(n, 0)
+}
+
+// captureFileTime reads the local time agent capture names an unmatched
+// capture with (capture-20060102-150405.pcap).
+func captureFileTime(name string) time.Time {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, "capture-"), filepath.Ext(name))
+	t, err := time.ParseInLocation("20060102-150405", stamp, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func isDigitByte(b byte) bool { return b >= '0' && b <= '9' }
+
+func sortPurgeItems(items []PurgeItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].At.Equal(items[j].At) {
+			return items[i].At.Before(items[j].At)
+		}
+		return items[i].Path < items[j].Path
+	})
+}
@@ -0,0 +1,160 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPurge(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AAVA_CALL_INDEX", filepath.Join(dir, "calls.json"))
+	t.Setenv("AAVA_QUALITY_TREND", filepath.Join(dir, "quality"))
+	captures, recordings := filepath.Join(dir, "captures"), filepath.Join(dir, "monitor")
+
+	old, kept := "1767225600.10", "1769818882.1484" // 2026-01-01 and 2026-01-31 UTC
+	idx := newCallIndex("other-source")
+	for _, id := range []string{old, kept} {
+		at := channelIDTime(id)
+		idx.Calls[id] = &CallIndexEntry{ID: id, FirstSeen: at, LastSeen: at, CallerNumber: "+15551234567"}
+	}
+	if err := idx.save(CallIndexPath()); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{old, kept} {
+		recordQualitySample(QualitySample{CallID: id, At: channelIDTime(id), Score: 90})
+	}
+	files := []string{
+		filepath.Join(captures, old+".pcap"),
+		filepath.Join(captures, kept+".pcap"),
+		filepath.Join(captures, "capture-20251230-101500.pcap"),
+		filepath.Join(recordings, "2026", "01", "01", "in-6001-20260101-000000-"+old+".wav"),
+		filepath.Join(recordings, "out-6001-"+old+"5.wav"), // another call whose ID extends this one
+		filepath.Join(recordings, "in-6001-"+kept+".wav"),
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := PurgeOptions{CallIDs: []string{old}, CaptureDir: captures, RecordingDir: recordings, DryRun: true}
+	items, err := Purge(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, it := range items {
+		if it.CallID != old {
+			t.Errorf("purged another call: %+v", it)
+		}
+		kinds = append(kinds, it.Kind)
+	}
+	if got := strings.Join(kinds, ","); got != "call_index,quality_sample,capture,recording" {
+		t.Fatalf("kinds = %s", got)
+	}
+	if _, err := os.Stat(files[0]); err != nil {
+		t.Fatal("dry run deleted a file")
+	}
+
+	opts.DryRun = false
+	if _, err := Purge(opts); err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range files {
+		_, err := os.Stat(f)
+		if gone := os.IsNotExist(err); gone != (i == 0 || i == 3) {
+			t.Errorf("%s: gone=%v", filepath.Base(f), gone)
+		}
+	}
+	raw, _ := os.ReadFile(CallIndexPath())
+	var saved callIndex
+	if err := json.Unmarshal(raw, &saved); err != nil || saved.Source != "other-source" || len(saved.Calls) != 1 || saved.Calls[kept] == nil {
+		t.Errorf("index after purge: %s", raw)
+	}
+	samples, _ := LoadQualitySamples(time.Time{})
+	if len(samples) != 1 || samples[0].CallID != kept {
+		t.Errorf("samples after purge: %+v", samples)
+	}
+
+	items, err = Purge(PurgeOptions{Before: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), CaptureDir: captures, RecordingDir: recordings})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The unmatched capture from Dec 30 and the other call's recording, not the Jan 31 call.
+	if len(items) != 2 || items[0].Kind != PurgeCapture || items[0].CallID != "" || items[1].CallID != old+"5" {
+		t.Errorf("before: %+v", items)
+	}
+}
+
+func TestResolvePurgeCalls(t *testing.T) {
+	t.Setenv("AAVA_CALL_INDEX", filepath.Join(t.TempDir(), "calls.json"))
+	t.Setenv("AAVA_ENGINE_LOG", "")
+	idx := newCallIndex(callIndexSource())
+	now := time.Now()
+	for id, number := range map[string]string{"1769818882.1484": "+15551234567", "1769818900.1500": "5551234567", "1769800000.1000": "6001"} {
+		idx.Calls[id] = &CallIndexEntry{ID: id, FirstSeen: now, LastSeen: now, CallerNumber: number}
+	}
+	if err := idx.save(CallIndexPath()); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := ResolvePurgeCalls([]string{"(555) 123-4567", "1769818882.1484", "1769800000.1000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 {
+		t.Errorf("ids = %v", ids)
+	}
+	if _, err := ResolvePurgeCalls([]string{"8005550100"}); err == nil {
+		t.Error("number with no calls accepted")
+	}
+	if _, err := ResolvePurgeCalls([]string{"last"}); err == nil {
+		t.Error("selector that is neither an ID nor a number accepted")
+	}
+}
+
+func TestPurgeEncryptedState(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AAVA_CALL_INDEX", filepath.Join(dir, "calls.json"))
+	t.Setenv("AAVA_QUALITY_TREND", filepath.Join(dir, "quality"))
+	t.Setenv("AAVA_STATE_KEY", "9Bq0oGk4Qm2v6yWn7tX3cJ5hL8eR1uZsA0dF2gH4jK8=")
+	t.Setenv("AAVA_STATE_KEYRING", "off")
+
+	old, kept := "1767225600.10", "1769818882.1484"
+	idx := newCallIndex("other-source")
+	for _, id := range []string{old, kept} {
+		at := channelIDTime(id)
+		idx.Calls[id] = &CallIndexEntry{ID: id, FirstSeen: at, LastSeen: at, CallerNumber: "+15551234567"}
+		recordQualitySample(QualitySample{CallID: id, At: at, Score: 90})
+	}
+	if err := idx.save(CallIndexPath()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Purge(PurgeOptions{CallIDs: []string{old}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{CallIndexPath(), filepath.Join(TrendDir(), "samples.jsonl")} {
+		raw, _ := os.ReadFile(path)
+		if strings.Contains(string(raw), kept) || strings.Contains(string(raw), "+1555") {
+			t.Errorf("%s holds plain text: %s", filepath.Base(path), raw)
+		}
+	}
+	if got := loadCallIndex(CallIndexPath(), "other-source"); len(got.Calls) != 1 || got.Calls[kept].CallerNumber != "+15551234567" {
+		t.Errorf("index after purge: %+v", got.Calls)
+	}
+	samples, err := LoadQualitySamples(time.Time{})
+	if err != nil || len(samples) != 1 || samples[0].CallID != kept {
+		t.Errorf("samples after purge: %+v, %v", samples, err)
+	}
+
+	t.Setenv("AAVA_STATE_KEY", "")
+	if _, err := LoadQualitySamples(time.Time{}); err == nil {
+		t.Error("read encrypted samples without the key")
+	}
+}
@@ -0,0 +1,143 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+)
+
+// QueueStay is a queue visit of the call, before it reached the agent or
+// after the agent transferred it to a queue.
+type QueueStay struct {
+	cdr.QueueVisit
+	BeforeAgent bool `json:"before_agent"`
+}
+
+// QueueAnalysis is the call's time in Asterisk queues, so that a caller who
+// waited in a queue before the agent answered is not read as a slow agent.
+type QueueAnalysis struct {
+	Visits []QueueStay `json:"visits"`
+	// WaitBeforeAgentSeconds is the caller's time in queues before the call
+	// reached the agent.
+	WaitBeforeAgentSeconds int `json:"wait_before_agent_seconds"`
+	// FirstAudioMS runs from the call reaching the agent to its first audio.
+	FirstAudioMS *int64   `json:"first_audio_ms,omitempty"`
+	Findings     []string `json:"findings,omitempty"`
+	// Warnings are the findings about transferred callers the queue lost.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// lookupQueueVisits reads the queue_log visits of the call's channel and, with
+// CEL, of the caller channel it is linked to, such as when the agent answers
+// as a queue member through a Local channel (best-effort).
+func lookupQueueVisits(callID string, rec *cdr.Record) []cdr.QueueVisit {
+	ids := []string{callID}
+	if rec != nil && rec.LinkedID != "" && rec.LinkedID != callID {
+		ids = append(ids, rec.LinkedID)
+	}
+	visits, err := cdr.QueueVisits(ids, cdrSince(callID))
+	if err != nil {
+		return nil
+	}
+	return visits
+}
+
+// analyzeQueue places the visits before or after the call reached the agent,
+// the engine's first line for it, and says what each one cost the caller.
+func analyzeQueue(visits []cdr.QueueVisit, timeline []TimelineEntry) *QueueAnalysis {
+	if len(visits) == 0 {
+		return nil
+	}
+	var start time.Time
+	var firstAudio *int64
+	for _, e := range timeline {
+		if e.Source != SourceEngine || e.Time.IsZero() {
+			continue
+		}
+		if start.IsZero() {
+			start = e.Time
+		}
+		if _, event, _, ok := parseLogLine(e.Line); ok {
+			if _, speaking := agentAudioStarts[event]; speaking {
+				ms := e.Time.Sub(start).Milliseconds()
+				firstAudio = &ms
+				break
+			}
+		}
+	}
+	sort.SliceStable(visits, func(i, j int) bool { return visits[i].Entered.Before(visits[j].Entered) })
+
+	q := &QueueAnalysis{}
+	for _, v := range visits {
+		before := !start.IsZero() && v.Entered.Before(start)
+		q.Visits = append(q.Visits, QueueStay{QueueVisit: v, BeforeAgent: before})
+		if before {
+			q.WaitBeforeAgentSeconds += v.WaitSeconds
+			switch v.Outcome {
+			case cdr.QueueAnswered:
+				q.Findings = append(q.Findings, fmt.Sprintf("Caller waited %ds in queue %s%s before the call reached the agent: queue wait, not agent latency", v.WaitSeconds, v.Queue, joinedAt(v)))
+			case cdr.QueueAbandoned:
+				q.Findings = append(q.Findings, fmt.Sprintf("Caller abandoned queue %s after %ds%s", v.Queue, v.WaitSeconds, joinedAt(v)))
+			default:
+				q.Findings = append(q.Findings, fmt.Sprintf("Caller waited %ds in queue %s%s, which sent them on to the agent (%s)", v.WaitSeconds, v.Queue, joinedAt(v), v.Outcome))
+			}
+			continue
+		}
+		switch v.Outcome {
+		case cdr.QueueAnswered:
+			q.Findings = append(q.Findings, fmt.Sprintf("After the transfer, %s answered the caller in queue %s after %ds", emptyTo(v.Member, "a member"), v.Queue, v.WaitSeconds))
+		case cdr.QueueAbandoned:
+			q.Warnings = append(q.Warnings, fmt.Sprintf("Caller hung up after %ds in queue %s, after the agent transferred them", v.WaitSeconds, v.Queue))
+		case cdr.QueueWaiting:
+			q.Findings = append(q.Findings, fmt.Sprintf("The transferred caller is still waiting in queue %s", v.Queue))
+		default:
+			q.Warnings = append(q.Warnings, fmt.Sprintf("No member of queue %s answered the transferred caller (%s after %ds)", v.Queue, v.Outcome, v.WaitSeconds))
+		}
+	}
+	if q.WaitBeforeAgentSeconds > 0 && firstAudio != nil {
+		q.FirstAudioMS = firstAudio
+		q.Findings = append(q.Findings, fmt.Sprintf("Once the call reached it, the agent's first audio came after %.1fs", float64(*firstAudio)/1000))
+	}
+	return q
+}
+
+func joinedAt(v cdr.QueueVisit) string {
+	if v.Position > 0 {
+		return fmt.Sprintf(" (joined at position %d)", v.Position)
+	}
+	return ""
+}
+
+func queueWarnings(q *QueueAnalysis) []string {
+	if q == nil {
+		return nil
+	}
+	return q.Warnings
+}
+
+func (r *Runner) displayQueue(q *QueueAnalysis) {
+	if q == nil {
+		return
+	}
+	fmt.Println("Queues:")
+	for _, v := range q.Visits {
+		when := "after the agent"
+		if v.BeforeAgent {
+			when = "before the agent"
+		}
+		fmt.Printf("  %-12s %s  waited %ds  %s", v.Queue, when, v.WaitSeconds, v.Outcome)
+		if v.Member != "" {
+			fmt.Printf(" by %s", v.Member)
+		}
+		fmt.Println()
+	}
+	for _, f := range q.Findings {
+		infoColor.Printf("  • %s\n", f)
+	}
+	for _, w := range q.Warnings {
+		warningColor.Printf("  • %s\n", w)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,38 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+)
+
+func TestAnalyzeQueue(t *testing.T) {
+	lines := []string{
+		`{"timestamp":"2026-01-30T17:21:00.000Z","level":"info","event":"StasisStart event received","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:01.200Z","level":"info","event":"🚪 AUDIO GATE CLOSED - Agent started speaking","call_id":"1.1"}`,
+	}
+	start := time.Date(2026, 1, 30, 17, 21, 0, 0, time.UTC)
+	visits := []cdr.QueueVisit{
+		{Queue: "support", Entered: start.Add(2 * time.Minute), WaitSeconds: 55, Outcome: cdr.QueueAbandoned},
+		{Queue: "sales", Entered: start.Add(-40 * time.Second), WaitSeconds: 40, Position: 3, Outcome: cdr.QueueAnswered, Member: "Local/ai@from-ai"},
+	}
+	q := analyzeQueue(visits, mergeTimeline(lines))
+	if q == nil || len(q.Visits) != 2 || !q.Visits[0].BeforeAgent || q.Visits[1].BeforeAgent {
+		t.Fatalf("analyzeQueue = %+v", q)
+	}
+	if q.WaitBeforeAgentSeconds != 40 || q.FirstAudioMS == nil || *q.FirstAudioMS != 1200 {
+		t.Fatalf("wait = %d, first audio = %v", q.WaitBeforeAgentSeconds, q.FirstAudioMS)
+	}
+	if len(q.Findings) != 2 || !strings.Contains(q.Findings[0], "waited 40s in queue sales (joined at position 3)") {
+		t.Errorf("findings = %q", q.Findings)
+	}
+	if w := queueWarnings(q); len(w) != 1 || !strings.Contains(w[0], "hung up after 55s in queue support") {
+		t.Errorf("warnings = %q", w)
+	}
+
+	if analyzeQueue(nil, mergeTimeline(lines)) != nil {
+		t.Error("no visits, no analysis")
+	}
+}
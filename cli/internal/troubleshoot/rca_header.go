@@ -3,6 +3,8 @@ package troubleshoot
 import (
 	"strconv"
 	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
 )
 
 // RCAHeader is a log-derived snapshot emitted by ai_engine (RCA_CALL_START).
@@ -62,7 +64,7 @@ func ExtractRCAHeader(logData string) *RCAHeader {
 		if !ok {
 			continue
 		}
-		if strings.TrimSpace(event) != "RCA_CALL_START" {
+		if strings.TrimSpace(event) != logschema.EventCallStart {
 			continue
 		}
 		h := &RCAHeader{}
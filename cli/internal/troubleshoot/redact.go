@@ -0,0 +1,39 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+)
+
+// SetRedactor masks personal data in the report, its exports and everything
+// sent to the LLM; nil reports everything as logged.
+func (r *Runner) SetRedactor(red *redact.Redactor) {
+	r.redactor = red
+}
+
+// redactAnalysis masks the analyzed call in place, along with its log lines.
+// The call's own caller ID is masked wherever it appears, and with a NER the
+// names said in the transcript are too; a NER that fails fails the report
+// rather than let names through.
+func (r *Runner) redactAnalysis(analysis *Analysis, logData string) (string, error) {
+	red := r.redactor
+	if h := analysis.Header; h != nil {
+		red.Known(redact.Phone, h.CallerNumber, h.CalledNumber)
+		red.Known(redact.Name, h.CallerName)
+	}
+	if c := analysis.CDR; c != nil {
+		red.Known(redact.Phone, c.Src, c.Dst)
+	}
+	if red.HasNER() && red.Enabled(redact.Name) {
+		// Without Call History there is no transcript; the patterns still apply.
+		if turns, err := loadCallTranscript(analysis.CallID); err == nil && len(turns) > 0 {
+			if err := red.LearnNames(r.ctx, formatTranscript(turns, math.MaxInt)); err != nil {
+				return "", fmt.Errorf("redaction: name detection failed: %w", err)
+			}
+		}
+	}
+	red.Walk(analysis)
+	return red.String(logData), nil
+}
@@ -0,0 +1,42 @@
+package troubleshoot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+)
+
+func TestRedactAnalysis(t *testing.T) {
+	red, err := redact.Parse("all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Runner{ctx: context.Background(), redactor: red}
+	a := &Analysis{
+		CallID: "1761518880.2191",
+		Header: &RCAHeader{CallerNumber: "6001", CallerName: "Maria Lopez", CalledNumber: "+15551234567"},
+		CDR:    &cdr.Record{UniqueID: "1761518880.2191", Src: "6001", CallerID: `"Maria Lopez" <6001>`},
+		Errors: []string{"transfer to 6001 failed for maria lopez"},
+	}
+	logData := `{"call_id": "1761518880.2191", "caller_number": "6001", "event": "email jane@example.com"}`
+	logData, err = r.redactAnalysis(a, logData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(a)
+	for _, leak := range []string{"6001", "Maria", "Lopez", "maria", "5551234567"} {
+		if strings.Contains(string(raw), leak) || strings.Contains(logData, leak) {
+			t.Errorf("%s left in:\n%s\n%s", leak, raw, logData)
+		}
+	}
+	if a.CallID != "1761518880.2191" || !strings.Contains(logData, `"call_id": "1761518880.2191"`) || !strings.Contains(logData, "[EMAIL]") {
+		t.Errorf("call ID lost or email kept: %s", logData)
+	}
+	if a.Errors[0] != "transfer to [PHONE] failed for [NAME]" {
+		t.Errorf("errors = %q", a.Errors)
+	}
+}
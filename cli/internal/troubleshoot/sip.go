@@ -0,0 +1,498 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/capture"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+)
+
+// SIP ladder sources.
+const (
+	SIPFromCapture     = "capture"
+	SIPFromAsteriskLog = "asterisk_log"
+)
+
+// sipPartyAsterisk names Asterisk in ladders read from its own log.
+const sipPartyAsterisk = "Asterisk"
+
+// sipDialogWindow bounds how far from the call's start its initial INVITE
+// may be.
+const sipDialogWindow = 20 * time.Second
+
+var (
+	// res_pjsip_logger, e.g. "<--- Received SIP request (1013 bytes) from UDP:203.0.113.5:5060 --->".
+	pjsipLoggerPattern = regexp.MustCompile(`<--- (Received|Transmitting) SIP (?:request|response) \(\d+ bytes\) (?:from|to) (?:\w+:)?(\S+) --->`)
+	// chan_sip "sip set debug on", e.g. "<--- SIP read from UDP:203.0.113.5:5060 --->".
+	chanSIPReadPattern     = regexp.MustCompile(`<--- SIP read from (?:\w+:)?(\S+) --->`)
+	chanSIPTransmitPattern = regexp.MustCompile(`<--- (?:Reliably )?Transmitting \((?:no )?NAT\) to (\S+) --->`)
+)
+
+// SDPSummary is what an SDP body offers or answers for audio.
+type SDPSummary struct {
+	Codecs    []string `json:"codecs"`
+	Addr      string   `json:"addr,omitempty"`
+	Port      int      `json:"port,omitempty"`
+	Direction string   `json:"direction,omitempty"` // sendonly, recvonly or inactive; empty is sendrecv
+}
+
+// SIPMessage is one request or response of the call's dialog.
+type SIPMessage struct {
+	Time        time.Time   `json:"time"`
+	From        string      `json:"from"` // sending party
+	To          string      `json:"to"`
+	Method      string      `json:"method,omitempty"` // requests
+	Status      int         `json:"status,omitempty"` // responses
+	Reason      string      `json:"reason,omitempty"`
+	CSeq        string      `json:"cseq"`
+	CallID      string      `json:"call_id"`
+	SDP         *SDPSummary `json:"sdp,omitempty"`
+	Retransmits int         `json:"retransmits,omitempty"`
+
+	fromHeader string
+	toHeader   string
+	toTag      bool
+	startLine  string
+}
+
+// SIPLadder is the call's SIP dialog.
+type SIPLadder struct {
+	Source   string       `json:"source"`
+	CallID   string       `json:"call_id"`
+	Parties  [2]string    `json:"parties"` // the initial INVITE goes from the first to the second
+	Messages []SIPMessage `json:"messages"`
+	Findings []string     `json:"findings,omitempty"`
+}
+
+// cseqMethod is the method a response answers.
+func (m SIPMessage) cseqMethod() string {
+	_, method, _ := strings.Cut(m.CSeq, " ")
+	return method
+}
+
+func (m SIPMessage) label() string {
+	label := m.Method
+	if m.Method == "" {
+		label = strings.TrimSpace(fmt.Sprintf("%d %s", m.Status, m.Reason))
+	}
+	if m.SDP != nil {
+		label += " (SDP " + strings.Join(m.SDP.Codecs, ",")
+		if m.SDP.Direction != "" {
+			label += " " + m.SDP.Direction
+		}
+		label += ")"
+	} else if m.Method == "INVITE" && !m.toTag {
+		label += " (no SDP)"
+	}
+	if m.Retransmits > 0 {
+		label += fmt.Sprintf(" ×%d", m.Retransmits+1)
+	}
+	return label
+}
+
+// parseSIPMessage reads a SIP message's start line, the headers the ladder
+// needs and its SDP body.
+func parseSIPMessage(text string) (SIPMessage, bool) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return SIPMessage{}, false
+	}
+	var m SIPMessage
+	m.startLine = strings.TrimSpace(lines[0])
+	first := strings.Fields(m.startLine)
+	switch {
+	case len(first) >= 2 && first[0] == "SIP/2.0":
+		m.Status, _ = strconv.Atoi(first[1])
+		m.Reason = strings.Join(first[2:], " ")
+	case len(first) == 3 && first[2] == "SIP/2.0":
+		m.Method = first[0]
+	default:
+		return SIPMessage{}, false
+	}
+	contentLength := -1
+	i := 1
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "call-id", "i":
+			m.CallID = value
+		case "cseq":
+			m.CSeq = value
+		case "from", "f":
+			m.fromHeader = value
+		case "to", "t":
+			m.toHeader = value
+			m.toTag = strings.Contains(strings.ToLower(value), ";tag=")
+		case "content-length", "l":
+			contentLength, _ = strconv.Atoi(value)
+		}
+	}
+	if m.CallID == "" || m.CSeq == "" {
+		return SIPMessage{}, false
+	}
+	// Log output can run on past the body; Content-Length says where it ends.
+	var body []string
+	for n := 0; i < len(lines) && (contentLength < 0 || n < contentLength); i++ {
+		body = append(body, lines[i])
+		n += len(lines[i]) + 2
+	}
+	m.SDP = parseSDP(body)
+	return m, true
+}
+
+var staticPayloadNames = map[string]string{"0": "PCMU", "3": "GSM", "8": "PCMA", "9": "G722", "18": "G729"}
+
+// parseSDP summarizes the first audio stream of an SDP body.
+func parseSDP(lines []string) *SDPSummary {
+	var sdp SDPSummary
+	var pts []string
+	names := map[string]string{}
+	inAudio, seen := false, false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "c=IN IP"):
+			if f := strings.Fields(line); len(f) == 3 && (sdp.Addr == "" || inAudio) {
+				sdp.Addr = f[2]
+			}
+		case strings.HasPrefix(line, "m="):
+			f := strings.Fields(strings.TrimPrefix(line, "m="))
+			inAudio = len(f) >= 3 && f[0] == "audio" && !seen
+			if inAudio {
+				seen = true
+				sdp.Port, _ = strconv.Atoi(f[1])
+				pts = f[3:]
+			}
+		case inAudio && strings.HasPrefix(line, "a=rtpmap:"):
+			pt, enc, _ := strings.Cut(strings.TrimPrefix(line, "a=rtpmap:"), " ")
+			name, _, _ := strings.Cut(enc, "/")
+			names[pt] = name
+		case inAudio && (line == "a=sendonly" || line == "a=recvonly" || line == "a=inactive"):
+			sdp.Direction = strings.TrimPrefix(line, "a=")
+		}
+	}
+	if !seen {
+		return nil
+	}
+	for _, pt := range pts {
+		name := names[pt]
+		if name == "" {
+			name = staticPayloadNames[pt]
+		}
+		if name == "" {
+			name = pt
+		}
+		if !strings.EqualFold(name, "telephone-event") {
+			sdp.Codecs = append(sdp.Codecs, name)
+		}
+	}
+	return &sdp
+}
+
+// parseSIPLog reads the SIP messages Asterisk logged with "pjsip set logger
+// on" (or chan_sip's "sip set debug on"). Each message follows its
+// direction line, one log line per message line.
+func parseSIPLog(entries []TimelineEntry) []SIPMessage {
+	var out []SIPMessage
+	for i := 0; i < len(entries); i++ {
+		received, peer, ok := sipLogDirection(entries[i].Line)
+		if !ok {
+			continue
+		}
+		at := entries[i].Time
+		var text []string
+		j := i + 1
+		for ; j < len(entries); j++ {
+			line := entries[j].Line
+			if _, timed := asteriskLineTime(line, entries[j].Time); timed {
+				break
+			}
+			if _, _, next := sipLogDirection(line); next {
+				break
+			}
+			text = append(text, line)
+		}
+		i = j - 1
+		m, ok := parseSIPMessage(strings.Join(text, "\n"))
+		if !ok {
+			continue
+		}
+		m.Time, m.From, m.To = at, sipPartyAsterisk, peer
+		if received {
+			m.From, m.To = peer, sipPartyAsterisk
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func sipLogDirection(line string) (received bool, peer string, ok bool) {
+	if m := pjsipLoggerPattern.FindStringSubmatch(line); len(m) > 2 {
+		return m[1] == "Received", m[2], true
+	}
+	if m := chanSIPReadPattern.FindStringSubmatch(line); len(m) > 1 {
+		return true, m[1], true
+	}
+	if m := chanSIPTransmitPattern.FindStringSubmatch(line); len(m) > 1 {
+		return false, m[1], true
+	}
+	return false, "", false
+}
+
+// sipFromCapture parses the SIP messages of a packet capture.
+func sipFromCapture(packets []capture.SIPPacket) []SIPMessage {
+	var out []SIPMessage
+	for _, p := range packets {
+		if m, ok := parseSIPMessage(p.Text); ok {
+			m.Time, m.From, m.To = p.Time, p.Src, p.Dst
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// readAsteriskSIPLog reads the Asterisk full log like readAsteriskFullLog,
+// but keeps the untimestamped lines of logged SIP messages, at the time of
+// the line before them.
+func readAsteriskSIPLog(path string, start, end time.Time) []TimelineEntry {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var entries []TimelineEntry
+	var last time.Time
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if t, ok := asteriskLineTime(line, start); ok {
+			last = t
+		}
+		if last.IsZero() || last.Before(start) || last.After(end) {
+			continue
+		}
+		entries = append(entries, TimelineEntry{Time: last, Line: line})
+	}
+	return entries
+}
+
+// collectSIPLog returns the SIP messages Asterisk logged between start and
+// end, from the full log or, failing that, the container's console output.
+func collectSIPLog(start, end time.Time) []SIPMessage {
+	if !deployment.Current().Remote() {
+		if msgs := parseSIPLog(readAsteriskSIPLog(deployment.Current().Logs.Asterisk, start, end)); len(msgs) > 0 {
+			return msgs
+		}
+	}
+	return parseSIPLog(sidecarLogged(deployment.AsteriskContainer(), start, end))
+}
+
+// sipCallHints returns when the call entered Stasis, or its first line, and
+// the caller's number, to pick the call's dialog among all logged ones.
+func sipCallHints(lines []string) (time.Time, string) {
+	var start, first time.Time
+	number := ""
+	for _, line := range lines {
+		t, ok := engineLineTime(line)
+		if ok && first.IsZero() {
+			first = t
+		}
+		if ok && start.IsZero() && containsAny(line, callStartMarkers) {
+			start = t
+		}
+		if number == "" {
+			number = firstNonEmpty(callerNumberPattern.FindStringSubmatch(line), 1, 2)
+		}
+	}
+	if start.IsZero() {
+		start = first
+	}
+	return start, number
+}
+
+// sipLadder reconstructs the call's SIP dialog from the packet capture when
+// it holds SIP, otherwise from Asterisk's log around the call.
+func (r *Runner) sipLadder(logData string) *SIPLadder {
+	lines := strings.Split(logData, "\n")
+	start, number := sipCallHints(lines)
+	if r.capture != nil && len(r.capture.SIP) > 0 {
+		if l := buildSIPLadder(sipFromCapture(r.capture.SIP), SIPFromCapture, start, number); l != nil {
+			return l
+		}
+	}
+	from, to, ok := callWindow(lines)
+	if !ok {
+		return nil
+	}
+	return buildSIPLadder(collectSIPLog(from, to), SIPFromAsteriskLog, start, number)
+}
+
+// buildSIPLadder picks the dialog whose initial INVITE is nearest the call's
+// start, preferring one that names the caller's number, and lays it out.
+func buildSIPLadder(msgs []SIPMessage, source string, start time.Time, callerNumber string) *SIPLadder {
+	if start.IsZero() {
+		return nil
+	}
+	digits := strings.TrimLeft(callerNumber, "+")
+	best := -1
+	bestMatch := false
+	var bestDist time.Duration
+	for i, m := range msgs {
+		if m.Method != "INVITE" || m.toTag {
+			continue
+		}
+		dist := m.Time.Sub(start)
+		if dist < 0 {
+			dist = -dist
+		}
+		if dist > sipDialogWindow {
+			continue
+		}
+		match := digits != "" && (strings.Contains(m.fromHeader, digits) || strings.Contains(m.toHeader, digits))
+		if best < 0 || (match && !bestMatch) || (match == bestMatch && dist < bestDist) {
+			best, bestMatch, bestDist = i, match, dist
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	invite := msgs[best]
+	ladder := &SIPLadder{Source: source, CallID: invite.CallID, Parties: [2]string{invite.From, invite.To}}
+	seen := map[string]int{}
+	for _, m := range msgs {
+		if m.CallID != invite.CallID {
+			continue
+		}
+		// A message sent again unchanged is a retransmission, not a new step.
+		key := m.From + "|" + m.startLine + "|" + m.CSeq
+		if i, ok := seen[key]; ok && m.Status != 100 {
+			ladder.Messages[i].Retransmits++
+			continue
+		}
+		seen[key] = len(ladder.Messages)
+		ladder.Messages = append(ladder.Messages, m)
+	}
+	ladder.Findings = sipFindings(ladder.Messages)
+	return ladder
+}
+
+// sipFindings flags the signaling problems a ladder shows: rejected
+// requests, retransmissions, a 200 OK never acknowledged, an offer without
+// an answer, and re-INVITEs that change the codec.
+func sipFindings(msgs []SIPMessage) []string {
+	var out []string
+	codec := ""
+	offered, answered := false, false
+	inviteCSeq := ""
+	for i, m := range msgs {
+		at := m.Time.Local().Format("15:04:05.000")
+		switch {
+		case m.Method == "INVITE" && !m.toTag:
+			inviteCSeq = m.CSeq
+			offered = m.SDP != nil
+		case m.Method == "INVITE":
+			if m.SDP != nil && codec != "" && len(m.SDP.Codecs) > 0 && !strings.EqualFold(m.SDP.Codecs[0], codec) {
+				out = append(out, fmt.Sprintf("re-INVITE at %s from %s changes the codec from %s to %s", at, m.From, codec, m.SDP.Codecs[0]))
+			}
+		case m.Status >= 300 && m.Status != 401 && m.Status != 407:
+			out = append(out, fmt.Sprintf("%s answered %d %s at %s", m.cseqMethod(), m.Status, m.Reason, at))
+		case m.Status >= 200 && m.cseqMethod() == "INVITE":
+			if m.SDP != nil && len(m.SDP.Codecs) > 0 {
+				codec = m.SDP.Codecs[0]
+			}
+			if m.CSeq == inviteCSeq && !answered {
+				answered = true
+				if m.SDP == nil {
+					out = append(out, fmt.Sprintf("the %d %s to the INVITE carries no SDP", m.Status, m.Reason))
+				} else if !offered {
+					out = append(out, "the INVITE carried no SDP (late offer): the codec was offered in the 200 OK and answered in the ACK")
+				}
+				if !sipAcked(msgs[i+1:], m) {
+					out = append(out, fmt.Sprintf("no ACK for the %d %s from %s", m.Status, m.Reason, m.From))
+				}
+			}
+		}
+		if m.Retransmits > 0 {
+			out = append(out, fmt.Sprintf("%s sent %d time(s) by %s: the other side's reply is not getting through", sipStep(m), m.Retransmits+1, m.From))
+		}
+	}
+	return out
+}
+
+func sipStep(m SIPMessage) string {
+	if m.Method != "" {
+		return m.Method
+	}
+	return fmt.Sprintf("%d %s", m.Status, m.Reason)
+}
+
+// sipAcked reports whether the answer ok was acknowledged in rest.
+func sipAcked(rest []SIPMessage, ok SIPMessage) bool {
+	num, _, _ := strings.Cut(ok.CSeq, " ")
+	for _, m := range rest {
+		if m.Method == "ACK" && m.From == ok.To && strings.HasPrefix(m.CSeq, num+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// sipArrowWidth is the width of a ladder arrow, label included.
+const sipArrowWidth = 46
+
+func sipArrow(label string, right bool) string {
+	fill := sipArrowWidth - utf8.RuneCountInString(label) - 6
+	if fill < 1 {
+		fill = 1
+	}
+	if right {
+		return "── " + label + " " + strings.Repeat("─", fill) + "─▶"
+	}
+	return "◀─ " + label + " " + strings.Repeat("─", fill) + "──"
+}
+
+func (r *Runner) displaySIPLadder(l *SIPLadder) {
+	if l == nil {
+		return
+	}
+	source := "Asterisk log"
+	if l.Source == SIPFromCapture {
+		source = "capture"
+	}
+	fmt.Printf("SIP Ladder (%s, Call-ID %s):\n", source, l.CallID)
+	fmt.Printf("  %-12s  %-*s%s\n", "", sipArrowWidth-len(l.Parties[1]), l.Parties[0], l.Parties[1])
+	for _, m := range l.Messages {
+		line := fmt.Sprintf("  %s  %s", m.Time.Local().Format("15:04:05.000"), sipArrow(m.label(), m.From == l.Parties[0]))
+		switch {
+		case m.Status >= 300 && m.Status != 401 && m.Status != 407, m.Retransmits > 0:
+			warningColor.Println(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+	for _, f := range l.Findings {
+		warningColor.Printf("  • %s\n", f)
+	}
+	fmt.Println()
+}
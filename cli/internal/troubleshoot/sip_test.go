@@ -0,0 +1,106 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pjsipLogged renders a SIP message the way "pjsip set logger on" writes it
+// to the full log.
+func pjsipLogged(at, direction, peer, msg string) string {
+	kind := "request"
+	if strings.HasPrefix(msg, "SIP/2.0") {
+		kind = "response"
+	}
+	verb := "from"
+	if direction == "Transmitting" {
+		verb = "to"
+	}
+	return fmt.Sprintf("[2026-01-30 %s] VERBOSE[4242] res_pjsip_logger.c: <--- %s SIP %s (%d bytes) %s UDP:%s --->\n%s\n",
+		at, direction, kind, len(msg), verb, peer, msg)
+}
+
+func sipMsg(start, callID, cseq, to, sdp string) string {
+	msg := start + "\nVia: SIP/2.0/UDP 203.0.113.5:5060;branch=z9hG4bK1\nFrom: <sip:+15551234567@203.0.113.5>;tag=a1\nTo: " + to +
+		"\nCall-ID: " + callID + "\nCSeq: " + cseq + fmt.Sprintf("\nContent-Length: %d\n\n", len(sdp)) + sdp
+	return strings.TrimRight(msg, "\n")
+}
+
+func TestSIPLadderFromAsteriskLog(t *testing.T) {
+	offer := "v=0\no=- 1 1 IN IP4 203.0.113.5\ns=-\nc=IN IP4 203.0.113.5\nt=0 0\nm=audio 30000 RTP/AVP 0 8 101\na=rtpmap:101 telephone-event/8000\n"
+	answer := "v=0\no=- 2 2 IN IP4 10.0.0.2\ns=-\nc=IN IP4 10.0.0.2\nt=0 0\nm=audio 14000 RTP/AVP 0 101\na=rtpmap:101 telephone-event/8000\n"
+	reoffer := "v=0\no=- 1 2 IN IP4 203.0.113.5\ns=-\nc=IN IP4 203.0.113.5\nt=0 0\nm=audio 30000 RTP/AVP 8\n"
+	peer := "203.0.113.5:5060"
+	untagged, tagged := "<sip:100@10.0.0.2>", "<sip:100@10.0.0.2>;tag=b2"
+	log := "[2026-01-30 17:20:55.000] VERBOSE[12] pbx.c: an older call\n" +
+		pjsipLogged("17:20:55.000", "Received", peer, sipMsg("INVITE sip:100@10.0.0.2 SIP/2.0", "older@203.0.113.5", "1 INVITE", untagged, offer)) +
+		pjsipLogged("17:21:39.100", "Received", peer, sipMsg("INVITE sip:100@10.0.0.2 SIP/2.0", "call@203.0.113.5", "1 INVITE", untagged, offer)) +
+		pjsipLogged("17:21:39.102", "Transmitting", peer, sipMsg("SIP/2.0 100 Trying", "call@203.0.113.5", "1 INVITE", untagged, "")) +
+		pjsipLogged("17:21:39.500", "Transmitting", peer, sipMsg("SIP/2.0 200 OK", "call@203.0.113.5", "1 INVITE", tagged, answer)) +
+		pjsipLogged("17:21:40.000", "Transmitting", peer, sipMsg("SIP/2.0 200 OK", "call@203.0.113.5", "1 INVITE", tagged, answer)) +
+		pjsipLogged("17:21:40.200", "Received", peer, sipMsg("ACK sip:100@10.0.0.2 SIP/2.0", "call@203.0.113.5", "1 ACK", tagged, "")) +
+		"[2026-01-30 17:21:41.000] VERBOSE[12][C-0000000c] pbx.c:     -- Executing [100@from-trunk:1] Stasis\n" +
+		pjsipLogged("17:21:50.000", "Received", peer, sipMsg("INVITE sip:100@10.0.0.2 SIP/2.0", "call@203.0.113.5", "2 INVITE", tagged, reoffer)) +
+		pjsipLogged("17:21:50.010", "Transmitting", peer, sipMsg("SIP/2.0 200 OK", "call@203.0.113.5", "2 INVITE", tagged, strings.Replace(answer, "RTP/AVP 0 101", "RTP/AVP 8 101", 1))) +
+		pjsipLogged("17:21:50.100", "Received", peer, sipMsg("ACK sip:100@10.0.0.2 SIP/2.0", "call@203.0.113.5", "2 ACK", tagged, "")) +
+		pjsipLogged("17:22:10.000", "Received", peer, sipMsg("BYE sip:100@10.0.0.2 SIP/2.0", "call@203.0.113.5", "3 BYE", tagged, ""))
+
+	path := filepath.Join(t.TempDir(), "full")
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 1, 30, 17, 20, 0, 0, time.Local)
+	msgs := parseSIPLog(readAsteriskSIPLog(path, from, from.Add(5*time.Minute)))
+	if len(msgs) != 10 {
+		t.Fatalf("parsed %d messages: %+v", len(msgs), msgs)
+	}
+
+	start, number := sipCallHints([]string{
+		`{"timestamp":"2026-01-30T17:21:39.800","level":"info","event":"Caller channel entered Stasis","call_id":"1.1","caller_number":"+15551234567"}`,
+	})
+	l := buildSIPLadder(msgs, SIPFromAsteriskLog, start, number)
+	if l == nil || l.CallID != "call@203.0.113.5" || l.Parties != [2]string{peer, sipPartyAsterisk} || len(l.Messages) != 8 {
+		t.Fatalf("ladder = %+v", l)
+	}
+	ok := l.Messages[2]
+	if ok.Status != 200 || ok.Retransmits != 1 || ok.SDP == nil || ok.SDP.Codecs[0] != "PCMU" || ok.SDP.Port != 14000 {
+		t.Fatalf("200 OK = %+v", ok)
+	}
+	if got := l.Messages[0].label(); got != "INVITE (SDP PCMU,PCMA)" {
+		t.Fatalf("INVITE label = %q", got)
+	}
+
+	findings := strings.Join(l.Findings, "\n")
+	for _, want := range []string{
+		"200 OK sent 2 time(s) by Asterisk",
+		"from 203.0.113.5:5060 changes the codec from PCMU to PCMA",
+	} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("findings missing %q:\n%s", want, findings)
+		}
+	}
+	if strings.Contains(findings, "no ACK") || strings.Contains(findings, "late offer") {
+		t.Errorf("unexpected findings:\n%s", findings)
+	}
+}
+
+func TestSIPFindingsLateOfferAndRejection(t *testing.T) {
+	at := time.Date(2026, 1, 30, 17, 0, 0, 0, time.Local)
+	sdp := &SDPSummary{Codecs: []string{"PCMA"}}
+	msgs := []SIPMessage{
+		{Time: at, From: "Asterisk", To: "trunk", Method: "INVITE", CSeq: "1 INVITE", CallID: "x"},
+		{Time: at, From: "trunk", To: "Asterisk", Status: 200, Reason: "OK", CSeq: "1 INVITE", CallID: "x", SDP: sdp, toTag: true},
+		{Time: at, From: "Asterisk", To: "trunk", Method: "BYE", CSeq: "2 BYE", CallID: "x", toTag: true},
+		{Time: at, From: "trunk", To: "Asterisk", Status: 481, Reason: "Call/Transaction Does Not Exist", CSeq: "2 BYE", CallID: "x", toTag: true},
+	}
+	findings := strings.Join(sipFindings(msgs), "\n")
+	for _, want := range []string{"late offer", "no ACK for the 200 OK from trunk", "BYE answered 481"} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("findings missing %q:\n%s", want, findings)
+		}
+	}
+}
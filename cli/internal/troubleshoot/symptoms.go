@@ -5,6 +5,24 @@ import (
 	"strings"
 )
 
+// Symptom is a name accepted by --symptom.
+type Symptom struct {
+	Name        string
+	Description string
+}
+
+// Symptoms lists the symptoms AnalyzeSymptom knows, for help text and shell completion.
+var Symptoms = []Symptom{
+	{"no-audio", "Complete silence"},
+	{"garbled", "Distorted/fast/slow audio"},
+	{"echo", "Agent hears itself"},
+	{"interruption", "Self-interruption loop"},
+	{"one-way", "Only one direction works"},
+	{"dtmf", "Keypad input ignored"},
+	{"call-drop", "Call drops mid-conversation"},
+	{"voicemail", "Agent talked to an answering machine"},
+}
+
 // SymptomChecker performs symptom-specific analysis
 type SymptomChecker struct {
 	symptom string
@@ -28,6 +46,12 @@ func (sc *SymptomChecker) AnalyzeSymptom(analysis *Analysis, logData string) {
 		sc.analyzeInterruption(analysis, logData)
 	case "one-way":
 		sc.analyzeOneWay(analysis, logData)
+	case "dtmf":
+		sc.analyzeDTMF(analysis)
+	case "call-drop":
+		sc.analyzeCallDrop(analysis)
+	case "voicemail":
+		sc.analyzeVoicemail(analysis)
 	}
 }
 
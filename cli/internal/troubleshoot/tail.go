@@ -0,0 +1,118 @@
+package troubleshoot
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// tailPoll is how often a followed file is checked for new data.
+const tailPoll = 250 * time.Millisecond
+
+// tailFile streams the last n lines of path (all of it when n <= 0) and, with
+// follow, what is appended afterwards, reopening the file when it is rotated
+// or truncated as tail -F does.
+func tailFile(path string, n int, follow bool) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	start := int64(0)
+	if n > 0 {
+		if start, err = lastLinesOffset(f, n); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	s := &tailStream{PipeReader: pr, stop: make(chan struct{}), done: make(chan struct{})}
+	go s.copy(f, pw, path, follow)
+	return s, nil
+}
+
+type tailStream struct {
+	*io.PipeReader
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (s *tailStream) Close() error {
+	close(s.stop)
+	err := s.PipeReader.Close()
+	<-s.done
+	return err
+}
+
+func (s *tailStream) copy(f *os.File, pw *io.PipeWriter, path string, follow bool) {
+	defer close(s.done)
+	defer func() { f.Close() }()
+	for {
+		if _, err := io.Copy(pw, f); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if !follow {
+			pw.Close()
+			return
+		}
+		select {
+		case <-s.stop:
+			pw.Close()
+			return
+		case <-time.After(tailPoll):
+		}
+		// Rotated: read the new file from its start. Truncated: start over.
+		if cur, err := os.Stat(path); err == nil {
+			if open, err := f.Stat(); err == nil && !os.SameFile(cur, open) {
+				if nf, err := os.Open(path); err == nil {
+					_, _ = io.Copy(pw, f) // the rest of the old file
+					f.Close()
+					f = nf
+				}
+			} else if pos, err := f.Seek(0, io.SeekCurrent); err == nil && cur.Size() < pos {
+				_, _ = f.Seek(0, io.SeekStart)
+			}
+		}
+	}
+}
+
+// lastLinesOffset returns the offset of the n-th line from the end of f. A
+// final newline does not start an empty last line.
+func lastLinesOffset(f *os.File, n int) (int64, error) {
+	st, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	end := st.Size()
+	if end == 0 {
+		return 0, nil
+	}
+	buf := make([]byte, 32*1024)
+	newlines := 0
+	pos := end
+	for pos > 0 {
+		size := int64(len(buf))
+		if pos < size {
+			size = pos
+		}
+		pos -= size
+		chunk := buf[:size]
+		if _, err := f.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' || pos+int64(i) == end-1 {
+				continue
+			}
+			newlines++
+			if newlines == n {
+				return pos + int64(i) + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,76 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTailFileLastLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[int]string{0: "one\ntwo\nthree\nfour\n", 2: "three\nfour\n", 10: "one\ntwo\nthree\nfour\n"}
+	for n, want := range cases {
+		rc, err := tailFile(path, n, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(got) != want {
+			t.Errorf("tail -n %d = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestTailFileFollowsRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engine.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := tailFile(path, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	lines := make(chan string)
+	go func() {
+		sc := bufio.NewScanner(rc)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+	next := func() string {
+		select {
+		case l := <-lines:
+			return l
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out waiting for a line")
+			return ""
+		}
+	}
+	if l := next(); l != "old" {
+		t.Fatalf("first line %q", l)
+	}
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("appended\n")
+	f.Close()
+	if l := next(); l != "appended" {
+		t.Fatalf("appended line %q", l)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("rotated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if l := next(); l != "rotated" {
+		t.Fatalf("line after rotation %q", l)
+	}
+}
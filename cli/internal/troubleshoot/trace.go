@@ -0,0 +1,264 @@
+package troubleshoot
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/logschema"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/otlp"
+)
+
+// Conversation stages a timeline line can belong to.
+const (
+	stageSTT = "stt"
+	stageLLM = "llm"
+	stageTTS = "tts"
+)
+
+// maxTraceEvents caps the log events attached to the call span; collectors
+// commonly drop spans with more than 128 events.
+const maxTraceEvents = 128
+
+// traceSetupMarkers are one-off session setup lines that mention a stage but
+// are not part of a turn ("Pipeline STT adapter session opened").
+var traceSetupMarkers = []string{"session opened", "enabled", "configured", "resolved", "loaded", "policy"}
+
+type traceStage struct {
+	start, end time.Time
+}
+
+type traceTurn struct {
+	start, end time.Time
+	stages     map[string]*traceStage
+	latencyMS  float64
+}
+
+// CallTrace converts the analyzed call's timeline into spans: one span for the
+// call, one per turn, and STT, LLM and TTS spans inside each turn. The trace ID
+// is derived from the call ID, so exporting a call twice yields the same trace.
+func (r *Runner) CallTrace() ([]otlp.Span, error) {
+	if r.analysis == nil {
+		return nil, errors.New("no analyzed call")
+	}
+	timeline := r.analysis.Timeline
+	if len(timeline) == 0 {
+		// Without sidecar lines the correlated timeline is not kept; the engine lines are enough.
+		timeline = mergeTimeline(strings.Split(r.logData, "\n"))
+	}
+	spans := buildCallTrace(r.analysis, timeline)
+	if len(spans) == 0 {
+		return nil, errors.New("the call's log lines carry no timestamps")
+	}
+	return spans, nil
+}
+
+func buildCallTrace(a *Analysis, timeline []TimelineEntry) []otlp.Span {
+	var start, end time.Time
+	var turns []*traceTurn
+	var cur *traceTurn
+	var events []otlp.Event
+	for _, e := range timeline {
+		if e.Time.IsZero() {
+			continue
+		}
+		if start.IsZero() {
+			start = e.Time
+		}
+		end = e.Time
+		if sev := traceSeverity(e); sev != "" && len(events) < maxTraceEvents {
+			events = append(events, otlp.Event{Time: e.Time, Name: "log", Attributes: map[string]any{
+				"log.severity": sev,
+				"log.source":   emptyTo(e.Source, SourceEngine),
+				"log.message":  truncate(strings.TrimSpace(e.Line), 500),
+			}})
+		}
+
+		stage := timelineStage(e)
+		if stage == "" {
+			continue
+		}
+		// A transcript after the agent answered opens the next turn; anything
+		// before the first transcript (the greeting) is a turn of its own.
+		if cur == nil || (stage == stageSTT && (cur.stages[stageLLM] != nil || cur.stages[stageTTS] != nil)) {
+			cur = &traceTurn{start: e.Time, stages: map[string]*traceStage{}}
+			turns = append(turns, cur)
+		}
+		cur.end = e.Time
+		if s := cur.stages[stage]; s != nil {
+			s.end = e.Time
+		} else {
+			cur.stages[stage] = &traceStage{start: e.Time, end: e.Time}
+		}
+		if ms, ok := turnLatencyMS(e); ok {
+			cur.latencyMS = ms
+		}
+	}
+	if start.IsZero() {
+		return nil
+	}
+
+	traceID := callTraceID(a.CallID)
+	callAttrs := map[string]any{
+		"aava.call_id": a.CallID,
+		"aava.turns":   len(turns),
+	}
+	if t := strings.TrimSpace(a.AudioTransport); t != "" && t != "unknown" {
+		callAttrs["aava.audio_transport"] = t
+	}
+	if h := a.Header; h != nil {
+		setNonEmpty(callAttrs, "aava.provider", h.ProviderName)
+		setNonEmpty(callAttrs, "aava.pipeline", h.PipelineName)
+		setNonEmpty(callAttrs, "aava.context", h.ContextName)
+	}
+	if a.CallHistory != nil {
+		setNonEmpty(callAttrs, "aava.outcome", a.CallHistory.Outcome)
+	}
+	if metricsHasEvidence(a.Metrics) {
+		score, _ := evaluateCallQuality(a.Metrics)
+		callAttrs["aava.quality_score"] = score
+		callAttrs["aava.underflows"] = a.Metrics.UnderflowCount
+	}
+	call := otlp.Span{
+		TraceID:    traceID,
+		SpanID:     traceSpanID(traceID, "call", 0),
+		Name:       "call",
+		Kind:       otlp.KindServer,
+		Start:      start,
+		End:        end,
+		Attributes: callAttrs,
+		Events:     events,
+	}
+	if len(a.Errors) > 0 {
+		call.Error = truncate(a.Errors[0], 200)
+	}
+
+	spans := []otlp.Span{call}
+	for i, t := range turns {
+		name := "turn " + strconv.Itoa(i+1)
+		if i == 0 && t.stages[stageSTT] == nil {
+			name = "greeting"
+		}
+		attrs := map[string]any{"aava.turn.index": i + 1}
+		if t.latencyMS > 0 {
+			attrs["aava.turn.latency_ms"] = t.latencyMS
+		}
+		turn := otlp.Span{
+			TraceID:    traceID,
+			SpanID:     traceSpanID(traceID, "turn", i),
+			ParentID:   call.SpanID,
+			Name:       name,
+			Kind:       otlp.KindInternal,
+			Start:      t.start,
+			End:        t.end,
+			Attributes: attrs,
+		}
+		spans = append(spans, turn)
+		for _, stage := range []string{stageSTT, stageLLM, stageTTS} {
+			s := t.stages[stage]
+			if s == nil {
+				continue
+			}
+			spans = append(spans, otlp.Span{
+				TraceID:    traceID,
+				SpanID:     traceSpanID(traceID, stage, i),
+				ParentID:   turn.SpanID,
+				Name:       strings.ToUpper(stage),
+				Kind:       otlp.KindInternal,
+				Start:      s.start,
+				End:        s.end,
+				Attributes: map[string]any{"aava.stage": stage},
+			})
+		}
+	}
+	return spans
+}
+
+// timelineStage classifies a line as STT, LLM or TTS work by its log event
+// (the whole line for sidecars), or "" when it belongs to none.
+func timelineStage(e TimelineEntry) string {
+	text := e.Line
+	if e.Source == SourceEngine || e.Source == "" {
+		if _, event, _, ok := parseLogLine(e.Line); ok && event != "" {
+			text = event
+		}
+	}
+	l := strings.ToLower(text)
+	for _, m := range traceSetupMarkers {
+		if strings.Contains(l, m) {
+			return ""
+		}
+	}
+	has := func(subs ...string) bool {
+		for _, s := range subs {
+			if strings.Contains(l, s) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("tts", "playback", "audio emitted", "segment bytes", "turn latency recorded"):
+		return stageTTS
+	case has("llm", "response generation", "tool call", "tool response", "executing pipeline tool", "function call"):
+		return stageLLM
+	case has("transcript", "stt", "speech_stopped", "speech stopped"):
+		return stageSTT
+	}
+	return ""
+}
+
+// turnLatencyMS reads the engine's "Turn latency recorded" measurement.
+func turnLatencyMS(e TimelineEntry) (float64, bool) {
+	if e.Source != SourceEngine && e.Source != "" {
+		return 0, false
+	}
+	_, event, fields, ok := parseLogLine(e.Line)
+	if !ok || event != logschema.EventTurnLatency {
+		return 0, false
+	}
+	ms, err := strconv.ParseFloat(fields["latency_ms"], 64)
+	return ms, err == nil
+}
+
+// traceSeverity is "error" or "warning" for problem lines from any container.
+func traceSeverity(e TimelineEntry) string {
+	if e.Source == SourceEngine || e.Source == "" {
+		switch {
+		case isErrorLine(e.Line) && !isBenignRCAErrorLine(e.Line):
+			return "error"
+		case isWarningLine(e.Line):
+			return "warning"
+		}
+		return ""
+	}
+	switch sidecarSeverity(e) {
+	case "ERROR", "CRITICAL":
+		return "error"
+	case "WARNING":
+		return "warning"
+	}
+	return ""
+}
+
+func callTraceID(callID string) [16]byte {
+	var id [16]byte
+	sum := sha256.Sum256([]byte("aava-call:" + callID))
+	copy(id[:], sum[:])
+	return id
+}
+
+func traceSpanID(traceID [16]byte, kind string, index int) [8]byte {
+	var id [8]byte
+	sum := sha256.Sum256([]byte(string(traceID[:]) + kind + strconv.Itoa(index)))
+	copy(id[:], sum[:])
+	return id
+}
+
+func setNonEmpty(m map[string]any, key, value string) {
+	if v := strings.TrimSpace(value); v != "" {
+		m[key] = v
+	}
+}
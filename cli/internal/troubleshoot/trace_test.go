@@ -0,0 +1,68 @@
+package troubleshoot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildCallTraceSplitsTurnsAndStages(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{
+		`{"timestamp":"2026-01-30T17:21:40.000Z","level":"info","event":"Pipeline STT adapter session opened","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:40.500Z","level":"info","event":"Pipeline greeting resolved from context","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:41.000Z","level":"info","event":"Sent greeting TTS request to Local AI Server","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:42.000Z","level":"info","event":"TTS response received and delivered","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:45.000Z","level":"info","event":"Transcript received","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:45.800Z","level":"info","event":"OpenAI realtime LLM response","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:46.000Z","level":"error","event":"Provider websocket error","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:46.200Z","level":"info","event":"Turn latency recorded","call_id":"1.1","latency_ms":1200.5}`,
+		`{"timestamp":"2026-01-30T17:21:50.000Z","level":"info","event":"Transcript received","call_id":"1.1"}`,
+		`{"timestamp":"2026-01-30T17:21:51.000Z","level":"info","event":"Call cleanup completed","call_id":"1.1"}`,
+	}
+	a := &Analysis{CallID: "1.1", Header: &RCAHeader{ProviderName: "local"}, Errors: []string{"Provider websocket error"}}
+	spans := buildCallTrace(a, mergeTimeline(lines))
+
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "call,greeting,TTS,turn 2,STT,LLM,TTS,turn 3,STT" {
+		t.Fatalf("spans = %s", got)
+	}
+	call := spans[0]
+	if call.Attributes["aava.turns"] != 3 || call.Attributes["aava.provider"] != "local" || call.Error == "" {
+		t.Fatalf("call span = %+v", call)
+	}
+	if call.End.Sub(call.Start).Seconds() != 11 {
+		t.Fatalf("call span covers %s", call.End.Sub(call.Start))
+	}
+	if len(call.Events) != 1 || call.Events[0].Attributes["log.severity"] != "error" {
+		t.Fatalf("events = %+v", call.Events)
+	}
+	turn2 := spans[3]
+	if turn2.ParentID != call.SpanID || turn2.Attributes["aava.turn.latency_ms"] != 1200.5 {
+		t.Fatalf("turn 2 = %+v", turn2)
+	}
+	for _, s := range spans[4:7] {
+		if s.ParentID != turn2.SpanID || s.TraceID != call.TraceID {
+			t.Fatalf("stage %s not under turn 2", s.Name)
+		}
+	}
+	if llm := spans[5]; llm.End.Sub(llm.Start) != 0 || !llm.Start.Equal(turn2.Start.Add(800e6)) {
+		t.Fatalf("LLM span = %s..%s", llm.Start, llm.End)
+	}
+
+	again := buildCallTrace(a, mergeTimeline(lines))
+	if again[0].TraceID != call.TraceID || again[3].SpanID != turn2.SpanID {
+		t.Fatal("trace and span IDs are not stable across exports")
+	}
+}
+
+func TestBuildCallTraceNeedsTimestamps(t *testing.T) {
+	t.Parallel()
+
+	if spans := buildCallTrace(&Analysis{CallID: "1.1"}, mergeTimeline([]string{"Transcript received"})); spans != nil {
+		t.Fatalf("expected no spans, got %d", len(spans))
+	}
+}
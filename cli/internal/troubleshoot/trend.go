@@ -0,0 +1,392 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/statecrypt"
+)
+
+const (
+	// trendRetention bounds the quality history; it outlives the call index on purpose.
+	trendRetention = 90 * 24 * time.Hour
+	// trendCompactSize is how large the samples file may grow before old lines are dropped.
+	trendCompactSize = 1 << 20
+	// RegressionWindow is how far before and after an event calls are compared.
+	RegressionWindow = 7 * 24 * time.Hour
+	// regressionMinCalls is the fewest scored calls each side of an event needs.
+	regressionMinCalls = 5
+	// regressionAlpha is the one-sided significance level.
+	regressionAlpha = 0.05
+)
+
+// QualitySample is the quality of one analyzed call, kept for trends.
+type QualitySample struct {
+	CallID    string    `json:"call_id"`
+	At        time.Time `json:"at"`
+	Score     float64   `json:"score"`
+	DriftPct  *float64  `json:"drift_pct,omitempty"` // nil when drift was not assessed
+	Underflow float64   `json:"underflow_rate_pct"`
+	Transport string    `json:"transport,omitempty"`
+	// KnownIssues are the titles of the known issues the call matched.
+	KnownIssues []string `json:"known_issues,omitempty"`
+}
+
+// TrendEvent is something a regression can be attributed to.
+type TrendEvent struct {
+	At    time.Time `json:"at"`
+	Kind  string    `json:"kind"` // update | config | asterisk_restart | note
+	Label string    `json:"label"`
+}
+
+// Regression is a metric that got significantly worse after an event.
+type Regression struct {
+	Event   TrendEvent `json:"event"`
+	Metric  string     `json:"metric"`
+	Before  float64    `json:"before_median"`
+	After   float64    `json:"after_median"`
+	NBefore int        `json:"n_before"`
+	NAfter  int        `json:"n_after"`
+	P       float64    `json:"p_value"`
+}
+
+// TrendDir holds the quality history (samples.jsonl) and recorded events (events.jsonl).
+func TrendDir() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_QUALITY_TREND")); p != "" {
+		return p
+	}
+	return filepath.Join(".agent", "quality")
+}
+
+// recordQualitySample appends the call to the history (best-effort, like the call index).
+func recordQualitySample(s QualitySample) {
+	path := filepath.Join(TrendDir(), "samples.jsonl")
+	if err := appendJSONLine(path, s); err != nil {
+		return
+	}
+	if st, err := os.Stat(path); err == nil && st.Size() > trendCompactSize {
+		samples, err := LoadQualitySamples(s.At.Add(-trendRetention))
+		if err == nil {
+			_ = rewriteJSONLines(path, samples)
+		}
+	}
+}
+
+// RecordTrendSeries appends v to name, a JSON-lines file in TrendDir, for the
+// histories other commands keep next to the call quality (agent check's engine
+// resource samples). at gives an entry's time, used to drop entries past the
+// retention once the file grows large.
+func RecordTrendSeries[T any](name string, v T, at func(T) time.Time) error {
+	path := filepath.Join(TrendDir(), name)
+	if err := appendJSONLine(path, v); err != nil {
+		return err
+	}
+	if st, err := os.Stat(path); err == nil && st.Size() > trendCompactSize {
+		if kept, err := LoadTrendSeries(name, at(v).Add(-trendRetention), at); err == nil {
+			_ = rewriteJSONLines(path, kept)
+		}
+	}
+	return nil
+}
+
+// LoadTrendSeries returns the entries of name since since, oldest first.
+func LoadTrendSeries[T any](name string, since time.Time, at func(T) time.Time) ([]T, error) {
+	var out []T
+	err := readJSONLines(filepath.Join(TrendDir(), name), func(raw []byte) {
+		var v T
+		if json.Unmarshal(raw, &v) == nil && !at(v).Before(since) {
+			out = append(out, v)
+		}
+	})
+	sort.SliceStable(out, func(i, j int) bool { return at(out[i]).Before(at(out[j])) })
+	return out, err
+}
+
+// Trend event kinds.
+const (
+	EventUpdate          = "update"
+	EventConfig          = "config"
+	EventAsteriskRestart = "asterisk_restart"
+	EventNote            = "note" // agent annotate
+)
+
+// RecordTrendEvent appends an event for agent trend. An event of the same kind at
+// the same time is stored once, so an observation that repeats (the Asterisk
+// start time every agent check sees) does not pile up.
+func RecordTrendEvent(ev TrendEvent) error {
+	existing, err := LoadTrendEvents(ev.At.Add(-time.Second))
+	if err != nil {
+		return err
+	}
+	for _, e := range existing {
+		if e.Kind == ev.Kind && e.At.Equal(ev.At) {
+			return nil
+		}
+	}
+	return appendJSONLine(filepath.Join(TrendDir(), "events.jsonl"), ev)
+}
+
+// LoadQualitySamples returns the calls analyzed since since, oldest first. A call
+// analyzed more than once keeps its latest result.
+func LoadQualitySamples(since time.Time) ([]QualitySample, error) {
+	byID := map[string]QualitySample{}
+	err := readJSONLines(filepath.Join(TrendDir(), "samples.jsonl"), func(raw []byte) {
+		var s QualitySample
+		if json.Unmarshal(raw, &s) == nil && s.CallID != "" && !s.At.Before(since) {
+			byID[s.CallID] = s
+		}
+	})
+	out := make([]QualitySample, 0, len(byID))
+	for _, s := range byID {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out, err
+}
+
+// LoadTrendEvents returns the recorded events since since, oldest first.
+func LoadTrendEvents(since time.Time) ([]TrendEvent, error) {
+	var out []TrendEvent
+	err := readJSONLines(filepath.Join(TrendDir(), "events.jsonl"), func(raw []byte) {
+		var e TrendEvent
+		if json.Unmarshal(raw, &e) == nil && !e.At.Before(since) {
+			out = append(out, e)
+		}
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out, err
+}
+
+// TrendMetrics are the metrics trends and regressions cover; for each, worse is
+// lower (score) or higher (drift, underflow).
+var TrendMetrics = []string{"score", "drift", "underflow"}
+
+// MetricValue returns the sample's value for a trend metric; ok is false when the
+// call has none (drift not assessed).
+func (s QualitySample) MetricValue(metric string) (float64, bool) {
+	switch metric {
+	case "score":
+		return s.Score, true
+	case "drift":
+		if s.DriftPct == nil {
+			return 0, false
+		}
+		return math.Abs(*s.DriftPct), true
+	case "underflow":
+		return s.Underflow, true
+	}
+	return 0, false
+}
+
+// DetectRegressions compares the calls in the RegressionWindow before each event
+// with those after it (up to the next event) using a one-sided Mann-Whitney U
+// test, which suits scores that move in fixed steps. A metric is flagged when it
+// is worse after the event at p < 0.05 and its median moved the wrong way.
+func DetectRegressions(samples []QualitySample, events []TrendEvent) []Regression {
+	var out []Regression
+	for i, ev := range events {
+		end := ev.At.Add(RegressionWindow)
+		if i+1 < len(events) && events[i+1].At.Before(end) {
+			end = events[i+1].At
+		}
+		for _, metric := range TrendMetrics {
+			var before, after []float64
+			for _, s := range samples {
+				v, ok := s.MetricValue(metric)
+				if !ok {
+					continue
+				}
+				switch {
+				case !s.At.Before(ev.At.Add(-RegressionWindow)) && s.At.Before(ev.At):
+					before = append(before, v)
+				case !s.At.Before(ev.At) && s.At.Before(end):
+					after = append(after, v)
+				}
+			}
+			if len(before) < regressionMinCalls || len(after) < regressionMinCalls {
+				continue
+			}
+			higherIsWorse := metric != "score"
+			p := mannWhitneyGreater(after, before)
+			if !higherIsWorse {
+				p = mannWhitneyGreater(before, after)
+			}
+			mb, ma := median(before), median(after)
+			worse := ma > mb
+			if !higherIsWorse {
+				worse = ma < mb
+			}
+			if p < regressionAlpha && worse {
+				out = append(out, Regression{Event: ev, Metric: metric, Before: mb, After: ma, NBefore: len(before), NAfter: len(after), P: p})
+			}
+		}
+	}
+	return out
+}
+
+// mannWhitneyGreater is the one-sided p-value that values in a tend to exceed
+// those in b (normal approximation with tie and continuity correction).
+func mannWhitneyGreater(a, b []float64) float64 {
+	type ranked struct {
+		v     float64
+		fromA bool
+	}
+	all := make([]ranked, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, ranked{v, true})
+	}
+	for _, v := range b {
+		all = append(all, ranked{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+	var rankSumA, tieTerm float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // average of ranks i+1..j
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankSumA += rank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+	u := rankSumA - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (u - mean - 0.5) / math.Sqrt(variance)
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+func median(v []float64) float64 {
+	s := append([]float64(nil), v...)
+	sort.Float64s(s)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
+
+// Sparkline renders one character per value; NaN (no data) is a space.
+func Sparkline(values []float64) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v):
+			b.WriteRune(' ')
+		case hi-lo < 1e-9*math.Max(1, math.Abs(hi)): // flat, up to rounding in the daily means
+			b.WriteRune(bars[len(bars)/2])
+		default:
+			b.WriteRune(bars[int((v-lo)/(hi-lo)*float64(len(bars)-1)+0.5)])
+		}
+	}
+	return b.String()
+}
+
+// DailyMeans buckets samples into days local days ending today and averages the
+// metric per day (NaN for days without a call).
+func DailyMeans(samples []QualitySample, metric string, days int, now time.Time) []float64 {
+	y, m, d := now.Date()
+	first := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(days - 1))
+	sums := make([]float64, days)
+	counts := make([]int, days)
+	for _, s := range samples {
+		v, ok := s.MetricValue(metric)
+		if !ok || s.At.Before(first) {
+			continue
+		}
+		i := DayIndex(first, s.At)
+		if i < days {
+			sums[i] += v
+			counts[i]++
+		}
+	}
+	out := make([]float64, days)
+	for i := range out {
+		out[i] = math.NaN()
+		if counts[i] > 0 {
+			out[i] = sums[i] / float64(counts[i])
+		}
+	}
+	return out
+}
+
+// DayIndex is the number of calendar days from first (a local midnight) to t.
+func DayIndex(first, t time.Time) int {
+	y, m, d := t.In(first.Location()).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, first.Location())
+	return int(math.Round(day.Sub(first).Hours() / 24))
+}
+
+func appendJSONLine(path string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return statecrypt.AppendLine(path, raw, 0o644)
+}
+
+func rewriteJSONLines[T any](path string, items []T) error {
+	lines := make([][]byte, 0, len(items))
+	for _, it := range items {
+		raw, err := json.Marshal(it)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, raw)
+	}
+	return statecrypt.WriteLines(path, lines, 0o644)
+}
+
+// readJSONLines calls fn for each line, decrypted when it is sealed; a missing
+// file is empty.
+func readJSONLines(path string, fn func([]byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line, err := statecrypt.ReadLine(sc.Bytes())
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		fn(line)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package troubleshoot
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDetectRegressions(t *testing.T) {
+	ev := TrendEvent{At: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), Kind: "update", Label: "update"}
+	var samples []QualitySample
+	add := func(offset time.Duration, score, underflow float64) {
+		samples = append(samples, QualitySample{CallID: offset.String(), At: ev.At.Add(offset), Score: score, Underflow: underflow})
+	}
+	for i, s := range []float64{100, 95, 100, 100, 95, 100, 100} {
+		add(-time.Duration(i+1)*6*time.Hour, s, 0)
+	}
+	for i, s := range []float64{80, 75, 95, 80, 80, 75} {
+		add(time.Duration(i+1)*6*time.Hour, s, 0)
+	}
+	// A call outside the window must not count.
+	add(-10*24*time.Hour, 20, 0)
+
+	regs := DetectRegressions(samples, []TrendEvent{ev})
+	if len(regs) != 1 || regs[0].Metric != "score" {
+		t.Fatalf("regressions = %+v", regs)
+	}
+	if regs[0].Before != 100 || regs[0].After != 80 || regs[0].NBefore != 7 || regs[0].NAfter != 6 || regs[0].P >= 0.05 {
+		t.Errorf("regression = %+v", regs[0])
+	}
+
+	// An improvement, or too few calls, is not a regression.
+	if regs := DetectRegressions(samples, []TrendEvent{{At: ev.At.Add(-7 * 24 * time.Hour)}}); len(regs) != 0 {
+		t.Errorf("too few calls: %+v", regs)
+	}
+}
+
+func TestMannWhitneyGreater(t *testing.T) {
+	if p := mannWhitneyGreater([]float64{5, 6, 7, 8, 9}, []float64{1, 2, 3, 4, 5}); p > 0.02 {
+		t.Errorf("separated samples p = %f", p)
+	}
+	if p := mannWhitneyGreater([]float64{1, 1, 1}, []float64{1, 1, 1}); p != 1 {
+		t.Errorf("identical samples p = %f", p)
+	}
+}
+
+func TestSparklineAndDailyMeans(t *testing.T) {
+	if got := Sparkline([]float64{0, math.NaN(), 7, 3.5}); got != "▁ █▅" {
+		t.Errorf("sparkline = %q", got)
+	}
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	samples := []QualitySample{
+		{CallID: "a", At: now.Add(-time.Hour), Score: 90},
+		{CallID: "b", At: now.Add(-2 * time.Hour), Score: 70},
+		{CallID: "c", At: now.AddDate(0, 0, -2), Score: 100},
+	}
+	got := DailyMeans(samples, "score", 3, now)
+	if got[0] != 100 || !math.IsNaN(got[1]) || got[2] != 80 {
+		t.Errorf("daily = %v", got)
+	}
+}
+
+func TestQualitySamplesKeepLatestAnalysis(t *testing.T) {
+	t.Setenv("AAVA_QUALITY_TREND", t.TempDir())
+	at := time.Now().Add(-time.Hour)
+	recordQualitySample(QualitySample{CallID: "1.1", At: at, Score: 60})
+	recordQualitySample(QualitySample{CallID: "1.1", At: at, Score: 90})
+	recordQualitySample(QualitySample{CallID: "old", At: at.AddDate(0, 0, -40), Score: 10})
+	for i := 0; i < 2; i++ {
+		if err := RecordTrendEvent(TrendEvent{At: at, Kind: EventAsteriskRestart, Label: "Asterisk restarted"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RecordTrendEvent(TrendEvent{At: at, Kind: EventNote, Label: "switched provider"}); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := LoadQualitySamples(at.AddDate(0, 0, -30))
+	if err != nil || len(samples) != 1 || samples[0].Score != 90 {
+		t.Fatalf("samples = %+v, %v", samples, err)
+	}
+	events, err := LoadTrendEvents(at.Add(-time.Minute))
+	if err != nil || len(events) != 2 {
+		t.Fatalf("events = %+v, %v", events, err)
+	}
+
+	atOf := func(e TrendEvent) time.Time { return e.At }
+	for _, d := range []time.Duration{time.Minute, -time.Minute, -48 * time.Hour} {
+		if err := RecordTrendSeries("series.jsonl", TrendEvent{At: at.Add(d), Label: d.String()}, atOf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	series, err := LoadTrendSeries("series.jsonl", at.Add(-time.Hour), atOf)
+	if err != nil || len(series) != 2 || series[0].Label != "-1m0s" {
+		t.Fatalf("series = %+v, %v", series, err)
+	}
+}
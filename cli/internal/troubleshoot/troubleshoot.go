@@ -2,16 +2,25 @@ package troubleshoot
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"regexp"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/budget"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/capture"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/cdr"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/contract"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/knowledge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/output"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/plugin"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/redact"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/telemetry"
 )
 
 var (
@@ -23,25 +32,57 @@ var (
 
 // Call represents a call record
 type Call struct {
-	ID        string
-	Timestamp time.Time
-	Duration  string
-	Status    string
-	Channel   string
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"started"`
+	Duration  string    `json:"duration,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+	Transport string    `json:"transport,omitempty"`
+	// QualityScore is set once the call has been analyzed by agent rca.
+	QualityScore *float64 `json:"quality_score,omitempty"`
+	CallerNumber string   `json:"caller_number,omitempty"`
+	CallerName   string   `json:"caller_name,omitempty"`
+	Dialed       string   `json:"dialed,omitempty"`
+	Answered     *bool    `json:"answered,omitempty"`
+	HangupCause  string   `json:"hangup_cause,omitempty"`
+	Outcome      string   `json:"outcome,omitempty"`
+	Disposition  string   `json:"disposition,omitempty"`
+
+	end *time.Time // call end from the index, used for time-based selection
 }
 
 // Runner orchestrates troubleshooting
 type Runner struct {
-	verbose     bool
-	ctx         context.Context
-	callID      string
-	symptom     string
-	interactive bool
-	collectOnly bool
-	noLLM       bool
-	forceLLM    bool
-	list        bool
-	jsonOutput  bool
+	verbose      bool
+	ctx          context.Context
+	callID       string
+	symptom      string
+	interactive  bool
+	collectOnly  bool
+	noLLM        bool
+	forceLLM     bool
+	list         bool
+	jsonOutput   bool
+	format       output.Format
+	quiet        bool
+	buffer       bool
+	echoWindow   time.Duration
+	capture      *capture.Report
+	rtpStats     *asterisk.RTPStats
+	last         int  // analyze the last calls instead of one
+	full         bool // with last: print each call's report too
+	silent       bool // analyze without printing
+	conversation bool // score the transcript against ConversationRubric
+	redactor     *redact.Redactor
+	profile      output.Profile  // text detail; empty is output.Support
+	plugins      []plugin.Plugin // analyzer plugins
+	cliVersion   string
+	budgets      *budget.Config
+
+	analysis  *Analysis     // set once a call has been analyzed
+	llm       *LLMDiagnosis // the analyzed call's AI diagnosis, if any
+	logData   string        // the analyzed call's engine lines
+	batchCode int           // worst exit code over the last calls
 }
 
 // NewRunner creates a new troubleshoot runner
@@ -60,6 +101,50 @@ func NewRunner(callID, symptom string, interactive, collectOnly, noLLM, forceLLM
 	}
 }
 
+// SetFormat selects the structured format used when JSON output is enabled.
+func (r *Runner) SetFormat(f output.Format) {
+	r.format = f
+}
+
+// SetQuiet replaces the human-readable report with plain finding lines.
+func (r *Runner) SetQuiet(quiet bool) {
+	r.quiet = quiet
+}
+
+// SetSilent analyzes the call without printing anything, for commands that
+// use the analysis itself.
+func (r *Runner) SetSilent(silent bool) {
+	r.silent, r.quiet = silent, silent
+}
+
+// SetBufferView replaces the report with the jitter buffer view of the call.
+func (r *Runner) SetBufferView(buffer bool) {
+	r.buffer = buffer
+}
+
+// SetEchoWindow sets how soon after agent audio a caller speech onset counts
+// as echo; zero keeps DefaultEchoWindow.
+func (r *Runner) SetEchoWindow(d time.Duration) {
+	r.echoWindow = d
+}
+
+// ExitCode is the contract exit code for the analyzed call: Fail when it has
+// errors, Warn for warnings or audio issues, OK otherwise.
+func (r *Runner) ExitCode() int {
+	if r.last > 0 {
+		return r.batchCode
+	}
+	if r.analysis == nil {
+		return contract.OK
+	}
+	return contract.ReportCode(len(r.analysis.Warnings)+len(r.analysis.AudioIssues), len(r.analysis.Errors))
+}
+
+// decorated reports whether progress lines and banners are printed.
+func (r *Runner) decorated() bool {
+	return !r.jsonOutput && !r.quiet
+}
+
 // Run executes troubleshooting workflow
 func (r *Runner) Run() error {
 	// Load .env file for API keys
@@ -69,12 +154,19 @@ func (r *Runner) Run() error {
 	if r.list {
 		return r.listCalls()
 	}
+	if r.last > 0 {
+		return r.runLast()
+	}
+	return r.runCall()
+}
 
+// runCall analyzes the one call the runner was created for.
+func (r *Runner) runCall() error {
 	// Determine which call to analyze
 	if r.callID == "" || r.callID == "last" {
 		calls, err := r.getRecentCalls(10)
 		if err != nil {
-			return fmt.Errorf("failed to get recent calls: %w", err)
+			return contract.EnvironmentError(fmt.Errorf("failed to get recent calls: %w", err))
 		}
 		if len(calls) == 0 {
 			if r.jsonOutput {
@@ -82,7 +174,10 @@ func (r *Runner) Run() error {
 					CallID: r.callID,
 					Error:  "no recent calls found (make a test call and re-run)",
 				})
-				return fmt.Errorf("no recent calls found")
+				return contract.EnvironmentError(fmt.Errorf("no recent calls found"))
+			}
+			if r.quiet {
+				return contract.EnvironmentError(fmt.Errorf("no recent calls found"))
 			}
 			errorColor.Println("❌ No recent calls found")
 			fmt.Println()
@@ -90,13 +185,13 @@ func (r *Runner) Run() error {
 			fmt.Println("  • Make a test call first")
 			fmt.Println("  • Check if ai_engine container is running")
 			fmt.Println("  • Verify logs: docker logs ai_engine")
-			return fmt.Errorf("no calls to analyze")
+			return contract.EnvironmentError(fmt.Errorf("no calls to analyze"))
 		}
 
 		// If --last flag or "last", use most recent
 		if r.callID == "last" {
 			r.callID = calls[0].ID
-			if !r.jsonOutput {
+			if r.decorated() {
 				infoColor.Printf("Analyzing most recent call: %s\n", r.callID)
 				fmt.Println()
 			}
@@ -107,17 +202,36 @@ func (r *Runner) Run() error {
 				return err
 			}
 			r.callID = selectedID
-			if !r.jsonOutput {
+			if r.decorated() {
 				infoColor.Printf("Analyzing call: %s\n", r.callID)
 				fmt.Println()
 			}
 		}
 	}
 
+	// Resolve a phone number or time ("today 14:05") to a channel id via the call index.
+	if !isChannelID(r.callID) {
+		calls, err := r.getRecentCalls(callIndexSelectLimit)
+		if err != nil {
+			return fmt.Errorf("failed to get recent calls: %w", err)
+		}
+		selector := r.callID
+		if r.callID, err = resolveCallSelector(selector, calls, time.Now()); err != nil {
+			if r.jsonOutput {
+				_ = r.outputJSON(&RCAReport{CallID: selector, Error: err.Error()})
+			}
+			return err
+		}
+		if r.decorated() {
+			infoColor.Printf("Analyzing call %s (matched %q)\n", r.callID, selector)
+			fmt.Println()
+		}
+	}
+
 	// Collect logs and data
-	logData, err := r.collectCallData()
+	logData, timeline, err := r.collectCallData()
 	if err != nil {
-		return fmt.Errorf("failed to collect data: %w", err)
+		return contract.EnvironmentError(fmt.Errorf("failed to collect data: %w", err))
 	}
 	if strings.TrimSpace(logData) == "" {
 		if r.jsonOutput {
@@ -125,16 +239,20 @@ func (r *Runner) Run() error {
 				CallID: r.callID,
 				Error:  "no ai_engine logs found for this call_id (enable info/debug logging, make a test call, and re-run)",
 			})
-			return fmt.Errorf("no logs found for call_id: %s", r.callID)
+			return contract.EnvironmentError(fmt.Errorf("no logs found for call_id: %s", r.callID))
+		}
+		if r.quiet {
+			return contract.EnvironmentError(fmt.Errorf("no logs found for call_id: %s", r.callID))
 		}
 		errorColor.Println("❌ No ai_engine log lines found for this call ID")
 		fmt.Println("Tips:")
 		fmt.Println("  • Make a test call, then immediately run: agent rca")
 		fmt.Println("  • Ensure ai_engine logging is enabled (info or debug)")
 		fmt.Println("  • If you only have info logs, enable debug for richer RCA details")
-		return fmt.Errorf("no logs found for call_id: %s", r.callID)
+		return contract.EnvironmentError(fmt.Errorf("no logs found for call_id: %s", r.callID))
 	}
 
+	r.logData = logData
 	header := ExtractRCAHeader(logData)
 
 	if r.collectOnly {
@@ -148,10 +266,17 @@ func (r *Runner) Run() error {
 		fmt.Println("Data collection complete.")
 		return nil
 	}
+	if r.buffer {
+		return r.runBufferView(header)
+	}
 
 	// Analyze logs
 	analysis := r.analyzeBasic(logData)
 	analysis.Header = header
+	analysis.Timeline = timeline
+	sideErrors, sideWarnings := sidecarProblems(timeline)
+	analysis.Errors = append(analysis.Errors, sideErrors...)
+	analysis.Warnings = append(analysis.Warnings, sideWarnings...)
 	analysis.ProviderRuntime = ExtractProviderRuntimeAudio(logData)
 	if (analysis.AudioTransport == "" || strings.ToLower(strings.TrimSpace(analysis.AudioTransport)) == "unknown") && header != nil && header.AudioTransport != "" {
 		analysis.AudioTransport = strings.ToLower(strings.TrimSpace(header.AudioTransport))
@@ -183,11 +308,56 @@ func (r *Runner) Run() error {
 			analysis.HasTranscription = true
 		}
 	}
+	// Asterisk CDR/CEL (when configured) supply billing-grade duration, disposition and
+	// hangup cause; Call History still wins for duration when both exist.
+	if rec := lookupCallCDR(r.callID); rec != nil {
+		analysis.CDR = rec
+		if metrics.CallDurationSeconds == 0 {
+			metrics.CallDurationSeconds = float64(rec.Duration)
+		}
+	}
+	// Live state comes from the engine's health server when it answers.
+	provider := ""
+	if analysis.Header != nil {
+		provider = analysis.Header.ProviderName
+	}
+	analysis.Live = lookupLiveState(r.callID, provider)
+	engineTimeline := mergeTimeline(strings.Split(logData, "\n"))
+	analysis.Echo = analyzeEcho(engineTimeline, analysis.Header, r.echoWindow)
+	metrics.BargeIn = extractBargeIns(engineTimeline, analysis.Echo)
+	callTimeline := timeline
+	if len(callTimeline) == 0 {
+		callTimeline = engineTimeline
+	}
+	analysis.DTMF = extractDTMF(callTimeline)
+	analysis.Ending = extractCallEnding(r.callID, callTimeline, analysis.CDR, analysis.Live != nil && analysis.Live.InProgress)
+	analysis.Warnings = append(analysis.Warnings, callEndingWarnings(analysis.Ending)...)
+	analysis.AMD = callAMD(analysis, callTimeline)
+	analysis.Warnings = append(analysis.Warnings, amdWarnings(analysis.AMD)...)
+	analysis.Queue = analyzeQueue(lookupQueueVisits(r.callID, analysis.CDR), engineTimeline)
+	analysis.Warnings = append(analysis.Warnings, queueWarnings(analysis.Queue)...)
+	analysis.Language = callLanguage(analysis)
+	analysis.Warnings = append(analysis.Warnings, languageWarnings(analysis.Language)...)
+	if r.budgets != nil {
+		analysis.Budget = callBudget(r.budgets, analysis, engineTimeline)
+		errs, warns := budgetFindings(analysis.Budget)
+		analysis.Errors = append(analysis.Errors, errs...)
+		analysis.Warnings = append(analysis.Warnings, warns...)
+	}
 	metrics.ApplyCallContext(analysis.Header)
-	analysis.AudioIssues = audioIssuesFromMetrics(metrics)
+	analysis.AudioIssues = append(audioIssuesFromMetrics(metrics), echoAudioIssues(analysis.Echo)...)
+	if r.capture != nil {
+		analysis.Capture = captureForCall(r.capture, analysis.Header, analysis.AudioTransport)
+		analysis.AudioIssues = append(analysis.AudioIssues, analysis.Capture.Findings...)
+	}
+	metrics.MOS = estimateMOS(analysis.Capture, r.rtpStats)
+	analysis.SIP = r.sipLadder(logData)
+	if analysis.SIP != nil {
+		analysis.Warnings = append(analysis.Warnings, analysis.SIP.Findings...)
+	}
 
 	// Analyze format/sampling alignment
-	formatAlignment := AnalyzeFormatAlignment(metrics, header)
+	formatAlignment := AnalyzeFormatAlignment(metrics, header, loadCodecAudit(header))
 	metrics.FormatAlignment = formatAlignment
 
 	// Compare to golden baselines
@@ -195,22 +365,46 @@ func (r *Runner) Run() error {
 	if baselineName != "" {
 		comparison := CompareToBaseline(metrics, baselineName)
 		analysis.BaselineComparison = comparison
-		if r.verbose && !r.jsonOutput && comparison != nil {
+		if r.verbose && r.decorated() && comparison != nil {
 			infoColor.Printf("  Using baseline: %s\n", comparison.BaselineName)
 		}
 	}
 
 	// Apply symptom-specific analysis
 	if r.symptom != "" {
+		if r.symptom == "dtmf" {
+			if analysis.DTMF == nil {
+				analysis.DTMF = &DTMFAnalysis{}
+			}
+			analysis.DTMF.Audit = loadDTMFAudit(analysis.Header)
+		}
 		checker := NewSymptomChecker(r.symptom)
 		checker.AnalyzeSymptom(analysis, logData)
 	}
+	r.applyPlugins(analysis, logData)
+
+	// Known problems are diagnosed from the knowledge base; the LLM is only
+	// asked about calls none of them explains.
+	r.matchKnownIssues(analysis, logData)
+	telemetry.RecordRCA(builtinIssueIDs(analysis.KnownIssues))
+	recordCallAnalysis(analysis, metrics, logData)
+
+	if r.redactor != nil {
+		var err error
+		if logData, err = r.redactAnalysis(analysis, logData); err != nil {
+			if r.jsonOutput && !r.silent {
+				_ = r.outputJSON(&RCAReport{CallID: r.callID, Error: err.Error()})
+			}
+			return contract.EnvironmentError(err)
+		}
+		r.logData = logData
+	}
 
 	// LLM analysis
 	var llmDiagnosis *LLMDiagnosis
 	runLLM := false
 	if !r.noLLM {
-		runLLM = r.forceLLM || shouldRunLLM(analysis, metrics, logData)
+		runLLM = r.forceLLM || (len(analysis.KnownIssues) == 0 && shouldRunLLM(analysis, metrics, logData))
 	}
 	if runLLM {
 		llmAnalyzer, err := NewLLMAnalyzer()
@@ -224,9 +418,27 @@ func (r *Runner) Run() error {
 		}
 	}
 
+	if r.conversation {
+		analysis.Conversation = scoreConversation(analysis, r.redactor)
+	}
+
+	r.analysis = analysis
+	r.llm = llmDiagnosis
+	if r.silent {
+		return nil
+	}
 	if r.jsonOutput {
 		return r.outputJSON(buildRCAReport(analysis, llmDiagnosis))
 	}
+	if r.quiet {
+		r.displayQuiet(analysis)
+		return nil
+	}
+
+	if r.profile == output.Operator {
+		r.displayOperator(analysis, llmDiagnosis)
+		return nil
+	}
 
 	// Human-readable output
 	fmt.Println()
@@ -234,6 +446,8 @@ func (r *Runner) Run() error {
 	fmt.Println("═══════════════════════════════════════════")
 	fmt.Println()
 	r.displayHeader(analysis.Header, analysis.ProviderRuntime)
+	r.displayCDR(analysis.CDR)
+	r.displayLiveState(analysis.Live)
 	infoColor.Println("Collecting call data...")
 	successColor.Println("✅ Data collected")
 	fmt.Println()
@@ -248,6 +462,8 @@ func (r *Runner) Run() error {
 		infoColor.Println("AI diagnosis: disabled")
 	} else if runLLM {
 		infoColor.Println("Requesting AI diagnosis...")
+	} else if len(analysis.KnownIssues) > 0 {
+		infoColor.Println("AI diagnosis: skipped (matched a known issue; use --llm to force)")
 	} else {
 		infoColor.Println("AI diagnosis: skipped (call looks healthy; use --llm to force)")
 	}
@@ -255,15 +471,30 @@ func (r *Runner) Run() error {
 
 	// Show findings
 	r.displayFindings(analysis)
+	r.displayKnownIssues(analysis.KnownIssues)
+	r.displayEcho(analysis.Echo)
+	r.displayDTMF(analysis.DTMF, analysis.AudioTransport)
+	r.displayCallEnding(analysis.Ending)
+	r.displayAMD(analysis.AMD)
+	r.displayQueue(analysis.Queue)
+	r.displayLanguage(analysis.Language)
+	r.displayBudget(analysis.Budget)
+	r.displayCapture(analysis.Capture)
+	r.displaySIPLadder(analysis.SIP)
 
 	// Show detailed metrics (RCA-level)
 	if analysis.Metrics != nil {
 		r.displayMetrics(analysis.Metrics)
+		if r.profile == output.Developer {
+			r.displayMetricNames(analysis.Metrics)
+		}
 
 		// Show overall call quality verdict
 		r.displayCallQuality(analysis)
 	}
 
+	r.displayConversation(analysis.Conversation)
+
 	// Show LLM diagnosis
 	if llmDiagnosis != nil {
 		r.displayLLMDiagnosis(llmDiagnosis)
@@ -278,11 +509,23 @@ func (r *Runner) Run() error {
 }
 
 type RCAReport struct {
+	SchemaVersion   int                   `json:"schema_version"`
 	CallID          string                `json:"call_id"`
 	Error           string                `json:"error,omitempty"`
 	Header          *RCAHeader            `json:"header,omitempty"`
 	ProviderRuntime *ProviderRuntimeAudio `json:"provider_runtime,omitempty"`
 	CallHistory     *CallHistorySummary   `json:"call_history,omitempty"`
+	CDR             *cdr.Record           `json:"cdr,omitempty"`
+	Live            *LiveState            `json:"live,omitempty"`
+	Echo            *EchoAnalysis         `json:"echo,omitempty"`
+	DTMF            *DTMFAnalysis         `json:"dtmf,omitempty"`
+	Ending          *CallEnding           `json:"ending,omitempty"`
+	AMD             *AMDAnalysis          `json:"amd,omitempty"`
+	Queue           *QueueAnalysis        `json:"queue,omitempty"`
+	Language        *LanguageAnalysis     `json:"language,omitempty"`
+	Budget          *budget.Result        `json:"budget,omitempty"`
+	Capture         *capture.Report       `json:"capture,omitempty"`
+	SIP             *SIPLadder            `json:"sip,omitempty"`
 
 	AudioTransport string `json:"audio_transport,omitempty"`
 
@@ -301,10 +544,14 @@ type RCAReport struct {
 
 	Symptom         string           `json:"symptom,omitempty"`
 	SymptomAnalysis *SymptomAnalysis `json:"symptom_analysis,omitempty"`
+	KnownIssues     []knowledge.Hit  `json:"known_issues,omitempty"`
 
-	Metrics            *CallMetrics        `json:"metrics,omitempty"`
-	BaselineComparison *BaselineComparison `json:"baseline_comparison,omitempty"`
-	LLMDiagnosis       *LLMDiagnosis       `json:"llm_diagnosis,omitempty"`
+	Metrics            *CallMetrics         `json:"metrics,omitempty"`
+	BaselineComparison *BaselineComparison  `json:"baseline_comparison,omitempty"`
+	LLMDiagnosis       *LLMDiagnosis        `json:"llm_diagnosis,omitempty"`
+	Conversation       *ConversationQuality `json:"conversation_quality,omitempty"`
+
+	Timeline []TimelineEntry `json:"timeline,omitempty"`
 }
 
 func buildRCAReport(analysis *Analysis, llm *LLMDiagnosis) *RCAReport {
@@ -313,6 +560,17 @@ func buildRCAReport(analysis *Analysis, llm *LLMDiagnosis) *RCAReport {
 		Header:          analysis.Header,
 		ProviderRuntime: analysis.ProviderRuntime,
 		CallHistory:     analysis.CallHistory,
+		CDR:             analysis.CDR,
+		Live:            analysis.Live,
+		Echo:            analysis.Echo,
+		DTMF:            analysis.DTMF,
+		Ending:          analysis.Ending,
+		AMD:             analysis.AMD,
+		Queue:           analysis.Queue,
+		Language:        analysis.Language,
+		Budget:          analysis.Budget,
+		Capture:         analysis.Capture,
+		SIP:             analysis.SIP,
 		Errors:          capSlice(analysis.Errors, 20),
 		Warnings:        capSlice(analysis.Warnings, 20),
 		AudioIssues:     capSlice(analysis.AudioIssues, 50),
@@ -320,6 +578,7 @@ func buildRCAReport(analysis *Analysis, llm *LLMDiagnosis) *RCAReport {
 		Symptom:         analysis.Symptom,
 		Metrics:         analysis.Metrics,
 		LLMDiagnosis:    llm,
+		Conversation:    analysis.Conversation,
 		AudioTransport:  analysis.AudioTransport,
 	}
 	rep.Pipeline.HasAudioSocket = analysis.HasAudioSocket
@@ -327,10 +586,44 @@ func buildRCAReport(analysis *Analysis, llm *LLMDiagnosis) *RCAReport {
 	rep.Pipeline.HasTranscription = analysis.HasTranscription
 	rep.Pipeline.HasPlayback = analysis.HasPlayback
 	rep.SymptomAnalysis = analysis.SymptomAnalysis
+	rep.KnownIssues = analysis.KnownIssues
 	rep.BaselineComparison = analysis.BaselineComparison
+	if len(analysis.Timeline) > 500 {
+		rep.Timeline = analysis.Timeline[:500]
+	} else {
+		rep.Timeline = analysis.Timeline
+	}
 	return rep
 }
 
+// displayQuiet prints one plain line per finding, for scripts and --quiet.
+func (r *Runner) displayQuiet(analysis *Analysis) {
+	for _, e := range analysis.Errors {
+		fmt.Printf("error: %s\n", e)
+	}
+	for _, w := range analysis.Warnings {
+		fmt.Printf("warning: %s\n", w)
+	}
+	for _, a := range analysis.AudioIssues {
+		fmt.Printf("audio: %s\n", a)
+	}
+	for _, k := range analysis.KnownIssues {
+		fmt.Printf("known: %s: %s\n", k.ID, k.Title)
+	}
+	fmt.Printf("%s call=%s errors=%d warnings=%d audio_issues=%d\n", resultLabel(r.ExitCode()), analysis.CallID, len(analysis.Errors), len(analysis.Warnings), len(analysis.AudioIssues))
+}
+
+// resultLabel names an exit code in quiet and batch output.
+func resultLabel(code int) string {
+	switch code {
+	case contract.Fail:
+		return "FAIL"
+	case contract.Warn:
+		return "WARN"
+	}
+	return "PASS"
+}
+
 func capSlice(in []string, n int) []string {
 	if len(in) <= n {
 		return in
@@ -339,9 +632,12 @@ func capSlice(in []string, n int) []string {
 }
 
 func (r *Runner) outputJSON(rep *RCAReport) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(rep)
+	rep.SchemaVersion = contract.SchemaVersion
+	f := r.format
+	if !f.Structured() {
+		f = output.JSON
+	}
+	return output.Write(os.Stdout, f, rep)
 }
 
 // listCalls lists recent calls
@@ -355,230 +651,117 @@ func (r *Runner) listCalls() error {
 		warningColor.Println("No recent calls found")
 		return nil
 	}
+	redactCalls(calls, r.redactor)
 
 	fmt.Printf("Recent calls (%d):\n\n", len(calls))
 	for i, call := range calls {
 		age := time.Since(call.Timestamp)
 		ageStr := formatDuration(age)
-		fmt.Printf("%2d. %s - %s ago", i+1, call.ID, ageStr)
+		fmt.Printf("%2d. %s - %s (%s ago)", i+1, call.ID, call.Timestamp.Local().Format("Jan 02 15:04:05"), ageStr)
 		if call.Duration != "" {
 			fmt.Printf(" (duration: %s)", call.Duration)
 		}
+		if call.Transport != "" {
+			fmt.Printf(" [%s]", call.Transport)
+		}
+		if call.QualityScore != nil {
+			fmt.Printf(" quality %.0f/100", *call.QualityScore)
+		}
 		fmt.Println()
+		if desc := call.describe(); desc != "" {
+			fmt.Printf("    %s\n", desc)
+		}
 	}
 	fmt.Println()
 	fmt.Println("Usage: agent rca --call <id>")
 	return nil
 }
 
-// getRecentCalls extracts recent calls from logs
-func (r *Runner) getRecentCalls(limit int) ([]Call, error) {
-	cmd := exec.Command("docker", "logs", "--since", "24h", "ai_engine")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read logs: %w", err)
-	}
-
-	// Strip ANSI color codes from log output (console format uses colors)
-	// JSON format doesn't have ANSI codes, so this is safe for both
-	ansiStripPattern := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	cleanOutput := ansiStripPattern.ReplaceAllString(string(output), "")
-
-	callMap := make(map[string]*Call)
-	excludedChannels := make(map[string]bool)
-
-	// First pass: identify AudioSocket channels (internal infrastructure)
-	audioSocketPattern := regexp.MustCompile(`(?i)(?:"audiosocket_channel_id"\s*:\s*"([0-9]+\.[0-9]+)"|audiosocket_channel_id=([0-9]+\.[0-9]+))`)
-	externalMediaPattern := regexp.MustCompile(`(?i)(?:"external_media_id"\s*:\s*"([0-9]+\.[0-9]+)"|external_media_id=([0-9]+\.[0-9]+))`)
-	pendingExternalMediaPattern := regexp.MustCompile(`(?i)(?:"pending_external_media_id"\s*:\s*"([0-9]+\.[0-9]+)"|pending_external_media_id=([0-9]+\.[0-9]+))`)
-	lines := strings.Split(cleanOutput, "\n")
-
-	if r.verbose {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Read %d lines from Docker logs\n", len(lines))
-	}
-
-	for _, line := range lines {
-		matches := audioSocketPattern.FindStringSubmatch(line)
-		if id := firstNonEmpty(matches, 1, 2); id != "" {
-			excludedChannels[id] = true
-			if r.verbose {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Found AudioSocket channel: %s\n", id)
-			}
-		}
-		matches = externalMediaPattern.FindStringSubmatch(line)
-		if id := firstNonEmpty(matches, 1, 2); id != "" {
-			excludedChannels[id] = true
-			if r.verbose {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Found ExternalMedia channel: %s\n", id)
-			}
-		}
-		matches = pendingExternalMediaPattern.FindStringSubmatch(line)
-		if id := firstNonEmpty(matches, 1, 2); id != "" {
-			excludedChannels[id] = true
-			if r.verbose {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Found pending ExternalMedia channel: %s\n", id)
-			}
-		}
-	}
+var (
+	audioSocketPattern          = regexp.MustCompile(`(?i)(?:"audiosocket_channel_id"\s*:\s*"([0-9]+\.[0-9]+)"|audiosocket_channel_id=([0-9]+\.[0-9]+))`)
+	externalMediaPattern        = regexp.MustCompile(`(?i)(?:"external_media_id"\s*:\s*"([0-9]+\.[0-9]+)"|external_media_id=([0-9]+\.[0-9]+))`)
+	pendingExternalMediaPattern = regexp.MustCompile(`(?i)(?:"pending_external_media_id"\s*:\s*"([0-9]+\.[0-9]+)"|pending_external_media_id=([0-9]+\.[0-9]+))`)
 
-	// Second pass: collect call IDs, excluding AudioSocket channels
-	patterns := []*regexp.Regexp{
+	callIDPatterns = []*regexp.Regexp{
 		regexp.MustCompile(`"call_id":\s*"([0-9]+\.[0-9]+)"`),                     // JSON: "call_id": "1761518880.2191"
 		regexp.MustCompile(`(?:call_id|channel_id)[=:][\s]*"?([0-9]+\.[0-9]+)"?`), // call_id= or channel_id=
 		regexp.MustCompile(`"caller_channel_id":\s*"([0-9]+\.[0-9]+)"`),           // Explicit caller channel
 		regexp.MustCompile(`caller_channel_id[=:][\s]*"?([0-9]+\.[0-9]+)"?`),      // Console caller channel
 	}
+)
 
-	matchCount := 0
-	for _, line := range lines {
-		for _, pattern := range patterns {
-			matches := pattern.FindStringSubmatch(line)
-			if len(matches) > 1 {
-				matchCount++
-				callID := matches[1]
-				// Skip non-caller channels (AudioSocket / ExternalMedia helper channels)
-				if excludedChannels[callID] {
-					if r.verbose {
-						fmt.Fprintf(os.Stderr, "[DEBUG] Skipping non-caller channel: %s\n", callID)
-					}
-					continue
-				}
-				if _, exists := callMap[callID]; !exists {
-					callMap[callID] = &Call{
-						ID:        callID,
-						Timestamp: time.Now(), // Will be refined from log timestamp
-					}
-					if r.verbose {
-						fmt.Fprintf(os.Stderr, "[DEBUG] Found call ID: %s\n", callID)
-					}
-				}
-				break // Found a match, no need to try other patterns
-			}
-		}
+// RecentCalls lists up to limit calls from the call index, newest first,
+// after bringing the index up to date. red, when set, masks their personal
+// data.
+func RecentCalls(limit int, red *redact.Redactor) ([]Call, error) {
+	r := &Runner{ctx: context.Background(), redactor: red}
+	calls, err := r.getRecentCalls(limit)
+	if err != nil {
+		return nil, err
 	}
+	redactCalls(calls, red)
+	return calls, nil
+}
 
-	if r.verbose {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Total pattern matches: %d, Unique calls: %d\n", matchCount, len(callMap))
+func redactCalls(calls []Call, red *redact.Redactor) {
+	for i := range calls {
+		red.Known(redact.Phone, calls[i].CallerNumber, calls[i].Dialed)
+		red.Known(redact.Name, calls[i].CallerName)
+		red.Walk(&calls[i])
 	}
+}
 
-	// Convert to slice and sort by ID (descending, newer first)
-	calls := make([]Call, 0, len(callMap))
-	for _, call := range callMap {
-		calls = append(calls, *call)
+// getRecentCalls lists recent calls from the local call index, refreshing it with
+// log output written since the last run instead of re-scanning the whole window.
+func (r *Runner) getRecentCalls(limit int) ([]Call, error) {
+	idx, err := r.refreshCallIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
 	}
-
-	sort.Slice(calls, func(i, j int) bool {
-		return calls[i].ID > calls[j].ID
-	})
-
-	if len(calls) > limit {
-		calls = calls[:limit]
+	if refreshFromCDR(idx) {
+		_ = idx.save(CallIndexPath())
+	}
+	calls := idx.recentCalls(limit)
+	if enrichFromCallHistory(idx, calls) {
+		_ = idx.save(CallIndexPath())
+		calls = idx.recentCalls(limit)
 	}
-
 	return calls, nil
 }
 
-// collectCallData collects logs for specific call
-func (r *Runner) collectCallData() (string, error) {
+// collectCallData collects logs for specific call. The timeline additionally merges
+// correlated Asterisk and local_ai_server lines (nil when neither contributed).
+func (r *Runner) collectCallData() (string, []TimelineEntry, error) {
 	// Log-driven RCA: collect from all available ai_engine logs (not time-windowed),
 	// then filter down to the requested call_id + any related helper channel ids.
+	// Indexed calls are read from their first log line onward instead.
 	since := os.Getenv("RCA_LOG_SINCE")
+	var src io.ReadCloser
 	if since == "" {
-		since = "72h"
-	}
-	cmd := exec.Command("docker", "logs", "--since", since, "ai_engine")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", err
-	}
-
-	// Filter logs for this call ID, including related helper channels (AudioSocket / ExternalMedia).
-	// Many ExternalMedia events are emitted on the ExternalMedia channel id, not the caller channel id.
-	ansiStripPattern := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	allLogs := ansiStripPattern.ReplaceAllString(string(output), "")
-	lines := strings.Split(allLogs, "\n")
-
-	relatedIDs := make(map[string]bool)
-	included := make([]string, 0, 1024)
-	includedSet := make(map[string]bool)
-	channelIDPattern := regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
-
-	addLine := func(line string) {
-		if line == "" {
-			return
-		}
-		if includedSet[line] {
-			return
-		}
-		includedSet[line] = true
-		included = append(included, line)
-	}
-
-	addRelated := func(v any) {
-		s, ok := v.(string)
-		if !ok {
-			return
-		}
-		s = strings.TrimSpace(s)
-		if s == "" || s == r.callID {
-			return
+		indexed, ok, err := openIndexedCallLogs(r.callID)
+		if err != nil {
+			return "", nil, err
 		}
-		// Channel IDs are usually like 1761518880.2191; keep the filter loose but safe.
-		if channelIDPattern.MatchString(s) {
-			relatedIDs[s] = true
+		if ok {
+			src = indexed
 		}
+		since = "72h"
 	}
-
-	addRelatedFromLine := func(line string) {
-		for _, re := range []*regexp.Regexp{
-			regexp.MustCompile(`audiosocket_channel_id=([0-9]+\.[0-9]+)`),
-			regexp.MustCompile(`external_media_id=([0-9]+\.[0-9]+)`),
-			regexp.MustCompile(`pending_external_media_id=([0-9]+\.[0-9]+)`),
-			regexp.MustCompile(`\bchannel_id=([0-9]+\.[0-9]+)`),
-			regexp.MustCompile(`\bbridge_id=([0-9a-fA-F-]{36})`),
-		} {
-			if m := re.FindStringSubmatch(line); len(m) > 1 {
-				relatedIDs[m[1]] = true
-			}
+	if src == nil {
+		var err error
+		if src, err = deployment.OpenEngineLogs(since); err != nil {
+			return "", nil, err
 		}
 	}
+	defer src.Close()
 
-	// First pass: include lines that reference the caller id; capture related channel ids.
-	for _, line := range lines {
-		if !strings.Contains(line, r.callID) {
-			continue
-		}
-
-		addLine(line)
-
-		var entry map[string]any
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			// Console logs: still attempt to discover related ids.
-			addRelatedFromLine(line)
-			continue
-		}
-		addRelated(entry["audiosocket_channel_id"])
-		addRelated(entry["external_media_id"])
-		addRelated(entry["pending_external_media_id"])
-		// Some log lines use "channel_id" to reference the helper channel.
-		addRelated(entry["channel_id"])
-		// Console logs may include related ids without JSON structure.
-		addRelatedFromLine(line)
-	}
-
-	// Second pass: include any log lines that reference a related channel id.
-	if len(relatedIDs) > 0 {
-		for _, line := range lines {
-			for id := range relatedIDs {
-				if strings.Contains(line, id) {
-					addLine(line)
-					break
-				}
-			}
-		}
+	// Filter logs for this call ID, including related helper channels (AudioSocket / ExternalMedia),
+	// in one streaming pass so multi-GB logs are never held in memory.
+	callLines, err := ScanCallLines(src, r.callID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read logs: %w", err)
 	}
-
-	return strings.Join(included, "\n"), nil
+	return strings.Join(callLines, "\n"), collectCorrelatedTimeline(r.callID, callLines), nil
 }
 
 // Analysis holds analysis results
@@ -587,6 +770,17 @@ type Analysis struct {
 	Header             *RCAHeader
 	ProviderRuntime    *ProviderRuntimeAudio
 	CallHistory        *CallHistorySummary
+	CDR                *cdr.Record
+	Live               *LiveState
+	Echo               *EchoAnalysis
+	DTMF               *DTMFAnalysis
+	Ending             *CallEnding
+	AMD                *AMDAnalysis
+	Queue              *QueueAnalysis
+	Language           *LanguageAnalysis
+	Budget             *budget.Result
+	Capture            *capture.Report
+	SIP                *SIPLadder
 	Errors             []string
 	Warnings           []string
 	AudioIssues        []string
@@ -601,6 +795,9 @@ type Analysis struct {
 	HasPlayback        bool
 	Symptom            string
 	SymptomAnalysis    *SymptomAnalysis
+	KnownIssues        []knowledge.Hit
+	Conversation       *ConversationQuality
+	Timeline           []TimelineEntry
 }
 
 // analyzeBasic performs basic log analysis
@@ -924,35 +1121,20 @@ func (r *Runner) displayFindings(analysis *Analysis) {
 	// Errors
 	if len(analysis.Errors) > 0 {
 		errorColor.Printf("Errors (%d):\n", len(analysis.Errors))
-		count := len(analysis.Errors)
-		if count > 5 {
-			count = 5
-		}
-		for i := 0; i < count; i++ {
-			fmt.Printf("  %d. %s\n", i+1, truncate(analysis.Errors[i], 100))
-		}
-		if len(analysis.Errors) > 5 {
-			fmt.Printf("  ... and %d more\n", len(analysis.Errors)-5)
-		}
+		r.displayFindingLines(analysis.Errors, 5)
 		fmt.Println()
 	}
 
 	// Warnings
 	if len(analysis.Warnings) > 0 {
 		warningColor.Printf("Warnings (%d):\n", len(analysis.Warnings))
-		count := len(analysis.Warnings)
-		if count > 3 {
-			count = 3
-		}
-		for i := 0; i < count; i++ {
-			fmt.Printf("  %d. %s\n", i+1, truncate(analysis.Warnings[i], 100))
-		}
-		if len(analysis.Warnings) > 3 {
-			fmt.Printf("  ... and %d more\n", len(analysis.Warnings)-3)
-		}
+		r.displayFindingLines(analysis.Warnings, 3)
 		fmt.Println()
 	}
 
+	// Correlated events from Asterisk / local_ai_server
+	r.displayTimeline(analysis.Timeline)
+
 	// Tool calls
 	if len(analysis.ToolCalls) > 0 {
 		infoColor.Printf("Tool Calls (%d):\n", len(analysis.ToolCalls))
@@ -1015,40 +1197,71 @@ func (r *Runner) displayFindings(analysis *Analysis) {
 	r.displayRecommendations(analysis)
 }
 
+// displayFindingLines numbers the first max findings, cut to 100 characters;
+// the developer profile gets every line as logged.
+func (r *Runner) displayFindingLines(lines []string, max int) {
+	if r.profile == output.Developer {
+		for i, l := range lines {
+			fmt.Printf("  %d. %s\n", i+1, l)
+		}
+		return
+	}
+	count := len(lines)
+	if count > max {
+		count = max
+	}
+	for i := 0; i < count; i++ {
+		fmt.Printf("  %d. %s\n", i+1, truncate(lines[i], 100))
+	}
+	if len(lines) > max {
+		fmt.Printf("  ... and %d more\n", len(lines)-max)
+	}
+}
+
 // displayRecommendations shows basic recommendations
 func (r *Runner) displayRecommendations(analysis *Analysis) {
 	fmt.Println("Recommendations:")
+	for _, rec := range recommendations(analysis) {
+		fmt.Printf("  • %s\n", rec)
+	}
+	fmt.Println()
+}
+
+// recommendations are the basic next steps for the call's transport, audio
+// issues, drift, provider rate and error count.
+func recommendations(analysis *Analysis) []string {
+	var recs []string
 
 	transport := strings.ToLower(strings.TrimSpace(analysis.AudioTransport))
 	if transport == "audiosocket" {
 		if !analysis.HasAudioSocket {
-			fmt.Println("  • Check if AudioSocket is configured correctly")
-			fmt.Println("  • Verify AudioSocket port is reachable from Asterisk")
+			recs = append(recs, "Check if AudioSocket is configured correctly")
+			recs = append(recs, "Verify AudioSocket port is reachable from Asterisk")
 		}
 	} else if transport == "externalmedia" {
 		if !analysis.HasExternalMedia {
-			fmt.Println("  • Check if ExternalMedia RTP is configured correctly")
-			fmt.Println("  • Verify UDP 18080 reachability (firewall/NAT)")
+			recs = append(recs, "Check if ExternalMedia RTP is configured correctly")
+			recs = append(recs, "Verify UDP 18080 reachability (firewall/NAT)")
 		}
 	} else {
 		if !analysis.HasAudioSocket && !analysis.HasExternalMedia {
-			fmt.Println("  • Check which transport you're using (audiosocket vs externalmedia)")
-			fmt.Println("  • Confirm config/ai-agent.yaml has a valid audio_transport value")
+			recs = append(recs, "Check which transport you're using (audiosocket vs externalmedia)")
+			recs = append(recs, "Confirm config/ai-agent.yaml has a valid audio_transport value")
 		}
 	}
 
 	if len(analysis.AudioIssues) > 0 {
-		fmt.Println("  • Run: agent check (for detailed diagnostics)")
-		fmt.Println("  • Check jitter_buffer_ms settings")
-		fmt.Println("  • Verify network stability")
+		recs = append(recs, "Run: agent check (for detailed diagnostics)")
+		recs = append(recs, "Check jitter_buffer_ms settings")
+		recs = append(recs, "Verify network stability")
 	}
 
 	// Drift-focused guidance: most commonly sample-rate / resampling mismatch (not jitter).
 	if analysis.Metrics != nil && metricsHasEvidence(analysis.Metrics) {
 		if absFloat(analysis.Metrics.WorstDriftPct) > 10.0 && analysis.Metrics.UnderflowCount == 0 {
-			fmt.Println("  • High drift with zero underflows usually indicates a sample-rate mismatch or resampling issue")
-			fmt.Println("  • Verify provider output sample rate matches what the provider actually sends (e.g., google_live is typically 24000 Hz)")
-			fmt.Println("  • Confirm provider output is resampled to target/wire rate (e.g., ulaw@8000 for telephony)")
+			recs = append(recs, "High drift with zero underflows usually indicates a sample-rate mismatch or resampling issue")
+			recs = append(recs, "Verify provider output sample rate matches what the provider actually sends (e.g., google_live is typically 24000 Hz)")
+			recs = append(recs, "Confirm provider output is resampled to target/wire rate (e.g., ulaw@8000 for telephony)")
 		}
 	}
 
@@ -1059,26 +1272,25 @@ func (r *Runner) displayRecommendations(analysis *Analysis) {
 			if providerName == "" {
 				providerName = "provider"
 			}
-			fmt.Printf(
-				"  • Provider-reported output rate (%d Hz) differs from configured (%d Hz); align the provider setting to reduce confusion\n",
+			recs = append(recs, fmt.Sprintf(
+				"Provider-reported output rate (%d Hz) differs from configured (%d Hz); align the provider setting to reduce confusion",
 				analysis.ProviderRuntime.ProviderReportedOutputSampleRateHz,
 				analysis.ProviderRuntime.ConfiguredOutputSampleRateHz,
-			)
-			fmt.Printf(
-				"  • Suggested: set %s Output Sample Rate (Hz) to %d (config key: providers.%s.output_sample_rate_hz)\n",
+			))
+			recs = append(recs, fmt.Sprintf(
+				"Suggested: set %s Output Sample Rate (Hz) to %d (config key: providers.%s.output_sample_rate_hz)",
 				providerName,
 				analysis.ProviderRuntime.ProviderReportedOutputSampleRateHz,
 				providerName,
-			)
+			))
 		}
 	}
 
 	if len(analysis.Errors) > 10 {
-		fmt.Println("  • High error count - check container logs")
-		fmt.Println("  • Run: docker logs ai_engine | grep ERROR")
+		recs = append(recs, "High error count - check container logs")
+		recs = append(recs, "Run: docker logs ai_engine | grep ERROR")
 	}
-
-	fmt.Println()
+	return recs
 }
 
 // displayMetrics shows RCA-level metrics
@@ -1195,6 +1407,65 @@ func (r *Runner) displayMetrics(metrics *CallMetrics) {
 		fmt.Println()
 	}
 
+	// Barge-in handling
+	if b := metrics.BargeIn; b != nil {
+		successColor.Println("Barge-in:")
+		fmt.Printf("  Interruptions: %d\n", b.Count)
+		if b.MeasuredLatency > 0 {
+			line := fmt.Sprintf("  Stop latency: avg %dms, max %dms (%d measured)", b.StopLatencyAvgMS, b.StopLatencyMaxMS, b.MeasuredLatency)
+			switch {
+			case b.StopLatencyAvgMS > bargeInStopPoorMS:
+				errorColor.Printf("%s ❌ SLOW\n", line)
+			case b.StopLatencyAvgMS > bargeInStopGoodMS:
+				warningColor.Printf("%s ⚠️  SLUGGISH\n", line)
+			default:
+				successColor.Printf("%s ✅ GOOD\n", line)
+			}
+		}
+		if b.AgentAudioCutMS > 0 {
+			fmt.Printf("  Queued agent audio discarded: %dms\n", b.AgentAudioCutMS)
+		}
+		if b.FalseInterruptions > 0 {
+			errorColor.Printf("  False interruptions (echo): %d ❌\n", b.FalseInterruptions)
+		} else {
+			successColor.Println("  False interruptions (echo): 0 ✅")
+		}
+		fmt.Printf("  Verdict: %s\n", b.Verdict)
+		if r.verbose {
+			for _, ev := range b.Events {
+				latency := "latency unknown"
+				if ev.HasLatency {
+					latency = fmt.Sprintf("stopped after %dms", ev.StopLatencyMS)
+				}
+				detail := fmt.Sprintf("%s, %dms played, %dms discarded", latency, ev.PlayedMS, ev.DiscardedMS)
+				if ev.False {
+					detail += ", echo"
+				}
+				fmt.Printf("    %s  %-14s %s\n", ev.Time.Format("15:04:05.000"), emptyTo(ev.Source, "unknown"), detail)
+			}
+		}
+		fmt.Println()
+	}
+
+	// Network quality
+	if m := metrics.MOS; m != nil {
+		successColor.Println("Network (E-model):")
+		for _, l := range m.Legs {
+			fmt.Printf("  %-30s %-8s loss %.1f%%  jitter %.1fms  RTT %.0fms  MOS %.2f\n",
+				l.Name, "("+l.Source+")", l.LossPct, l.JitterMS, l.RTTMS, l.MOS)
+		}
+		line := fmt.Sprintf("  Estimated MOS: %.2f (R %.0f, worst leg %s)", m.MOS, m.R, m.Worst)
+		switch {
+		case m.MOS < mosPoor:
+			errorColor.Printf("%s ❌ POOR\n", line)
+		case m.MOS < mosFair:
+			warningColor.Printf("%s ⚠️  FAIR\n", line)
+		default:
+			successColor.Printf("%s ✅ GOOD\n", line)
+		}
+		fmt.Println()
+	}
+
 	// Transport/Format
 	if metrics.AudioSocketFormat != "" || metrics.ProviderInputFormat != "" {
 		transport := ""
@@ -1272,6 +1543,27 @@ func evaluateCallQuality(metrics *CallMetrics) (float64, []string) {
 		score -= 20.0
 	}
 
+	// Check barge-in handling
+	if metrics.BargeIn != nil {
+		switch metrics.BargeIn.Verdict {
+		case BargeInPoor:
+			score -= 15.0
+		case BargeInFair:
+			score -= 5.0
+		}
+		issues = append(issues, metrics.BargeIn.Issues...)
+	}
+
+	// Check the network, as an SBC would
+	if m := metrics.MOS; m != nil && m.MOS < mosFair {
+		issues = append(issues, mosIssue(m))
+		if m.MOS < mosPoor {
+			score -= 25.0
+		} else {
+			score -= 10.0
+		}
+	}
+
 	// Check VAD issues
 	if metrics.VADSettings != nil && metrics.VADSettings.WebRTCAggressiveness == 0 {
 		issues = append(issues, "VAD too sensitive")
@@ -1292,6 +1584,9 @@ func evaluateCallQuality(metrics *CallMetrics) (float64, []string) {
 			issues = append(issues, "Frame size mismatch")
 			score -= 20.0
 		}
+		// CodecNegotiationIssue is not scored: it audits today's pjsip config,
+		// which may differ from what the call negotiated. It stays listed under
+		// the format alignment issues.
 	}
 
 	return score, issues
@@ -1317,21 +1612,7 @@ func (r *Runner) displayCallQuality(analysis *Analysis) {
 		return
 	}
 
-	score, issues := evaluateCallQuality(metrics)
-
-	// Treat errors as call-stability issues even if audio metrics look good.
-	// (e.g., provider websocket closes, auth failures, ARI failures, etc.)
-	if len(analysis.Errors) > 0 {
-		issues = append(issues, fmt.Sprintf("Errors in logs (%d) - call stability issue", len(analysis.Errors)))
-		// Cap score at 70 and apply a penalty so we don't show "EXCELLENT" with hard errors.
-		if score > 70 {
-			score = 70
-		}
-		score -= 20.0
-		if score < 0 {
-			score = 0
-		}
-	}
+	score, issues := callQualityScore(analysis)
 
 	// Determine verdict
 	if score >= 90 {
@@ -1362,6 +1643,24 @@ func (r *Runner) displayCallQuality(analysis *Analysis) {
 	fmt.Println()
 }
 
+// penalizeErrors treats errors as call-stability issues even if audio metrics
+// look good (e.g., provider websocket closes, auth failures, ARI failures).
+func penalizeErrors(score float64, issues []string, errors int) (float64, []string) {
+	if errors == 0 {
+		return score, issues
+	}
+	issues = append(issues, fmt.Sprintf("Errors in logs (%d) - call stability issue", errors))
+	// Cap score at 70 and apply a penalty so we don't show "EXCELLENT" with hard errors.
+	if score > 70 {
+		score = 70
+	}
+	score -= 20.0
+	if score < 0 {
+		score = 0
+	}
+	return score, issues
+}
+
 func metricsHasEvidence(metrics *CallMetrics) bool {
 	if metrics == nil {
 		return false
@@ -1372,6 +1671,9 @@ func metricsHasEvidence(metrics *CallMetrics) bool {
 	if metrics.UnderflowCount > 0 || metrics.GateClosures > 0 || metrics.GateFlutterDetected {
 		return true
 	}
+	if metrics.BargeIn != nil || metrics.MOS != nil {
+		return true
+	}
 	if metrics.VADSettings != nil {
 		return true
 	}
@@ -1389,6 +1691,37 @@ func metricsHasEvidence(metrics *CallMetrics) bool {
 	return false
 }
 
+// displayConversation shows the transcript's rubric scores.
+func (r *Runner) displayConversation(q *ConversationQuality) {
+	if q == nil {
+		return
+	}
+	fmt.Println("═══════════════════════════════════════════")
+	if q.Error != "" {
+		warningColor.Printf("💬 CONVERSATION QUALITY: not scored (%s)\n", q.Error)
+		fmt.Println()
+		return
+	}
+	infoColor.Printf("💬 CONVERSATION QUALITY (%s - %s, %d turns): %.1f/5\n", q.Provider, q.Model, q.Turns, q.Overall)
+	fmt.Println("═══════════════════════════════════════════")
+	for _, s := range q.Scores {
+		c := successColor
+		switch {
+		case s.Score <= 2:
+			c = errorColor
+		case s.Score == 3:
+			c = warningColor
+		}
+		c.Printf("  %-17s %d/5", s.Criterion, s.Score)
+		fmt.Printf("  %s\n", s.Reason)
+	}
+	if q.Summary != "" {
+		fmt.Println()
+		fmt.Println("  " + q.Summary)
+	}
+	fmt.Println()
+}
+
 // displayLLMDiagnosis shows AI-powered diagnosis
 func (r *Runner) displayLLMDiagnosis(diagnosis *LLMDiagnosis) {
 	fmt.Println("═══════════════════════════════════════════")
@@ -45,3 +45,13 @@ func TestIsBenignRCAErrorLine(t *testing.T) {
 		t.Fatalf("expected benign ARI variable 404 to be ignored")
 	}
 }
+
+func TestCodecAuditDoesNotLowerQualityScore(t *testing.T) {
+	t.Parallel()
+
+	m := &CallMetrics{FormatAlignment: &FormatAlignment{CodecNegotiationIssue: true}}
+	score, issues := evaluateCallQuality(m)
+	if score != 100 || len(issues) != 0 {
+		t.Fatalf("score %v, issues %v", score, issues)
+	}
+}
@@ -0,0 +1,210 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+)
+
+// Clip is synthesized audio in the format the pipeline plays to callers.
+type Clip struct {
+	Encoding   string `json:"encoding"` // mulaw, alaw, slin16...
+	SampleRate int    `json:"sample_rate"`
+	Audio      []byte `json:"-"`
+}
+
+// Seconds is the clip's length.
+func (c *Clip) Seconds() float64 {
+	bps := c.bytesPerSample()
+	if c.SampleRate <= 0 {
+		return 0
+	}
+	return float64(len(c.Audio)) / float64(bps*c.SampleRate)
+}
+
+// previewScript builds the TTS adapter the way the pipeline orchestrator does
+// for a call, from the engine's own config, options and credentials, and
+// prints the audio it yields with the format the engine streams it as. The
+// engine's logs are sent to stderr so stdout carries only the result.
+const previewScript = `
+import asyncio, base64, json, sys
+out, sys.stdout = sys.stdout, sys.stderr
+sys.path.insert(0, "/app")
+pipeline, component, voice_key, voice, text = sys.argv[1:6]
+
+async def main():
+    from src.config import load_config
+    from src.pipelines.orchestrator import PipelineOrchestrator
+    config = load_config()
+    options = {}
+    if pipeline:
+        entry = (config.pipelines or {}).get(pipeline)
+        if entry is None:
+            raise RuntimeError("pipeline %s is not loaded by the engine" % pipeline)
+        component = entry.tts
+        options = dict((entry.options or {}).get("tts", {}))
+    if voice:
+        options[voice_key] = voice
+    adapter = PipelineOrchestrator(config)._build_component(component, options)
+    fmt = options.get("format")
+    if not isinstance(fmt, dict):
+        fmt = options.get("target_format")
+    if not isinstance(fmt, dict):
+        fmt = {}
+    call_id = "agent-tts-preview"
+    audio = bytearray()
+    await adapter.start()
+    try:
+        await adapter.open_call(call_id, options)
+        async for chunk in adapter.synthesize(call_id, text, options):
+            audio.extend(chunk or b"")
+        await adapter.close_call(call_id)
+    finally:
+        await adapter.stop()
+    return {
+        "encoding": str(fmt.get("encoding") or fmt.get("format") or "mulaw"),
+        "sample_rate": int(fmt.get("sample_rate") or fmt.get("sample_rate_hz") or 8000),
+        "audio": base64.b64encode(bytes(audio)).decode(),
+    }
+
+try:
+    result = asyncio.run(main())
+except Exception as e:
+    result = {"error": "%s: %s" % (type(e).__name__, e)}
+out.write(json.dumps(result))
+`
+
+// Synthesize speaks text with t's adapter inside the engine container, with
+// voice in place of the configured one when it is set.
+func Synthesize(ctx context.Context, t Target, voice, text string) (*Clip, error) {
+	if voice != "" && t.VoiceKey == "" {
+		return nil, fmt.Errorf("the local AI server's voice is set in its environment for every call; preview it by changing %s", localVoiceEnv(t))
+	}
+	var stdout, stderr bytes.Buffer
+	err := dockerapi.Exec(ctx, deployment.EngineContainer(), dockerapi.ExecOptions{Stdout: &stdout, Stderr: &stderr},
+		"python3", "-c", previewScript, t.Pipeline, t.Provider, t.VoiceKey, voice, text)
+	out := stdout.Bytes()
+	if err != nil && len(bytes.TrimSpace(out)) == 0 {
+		return nil, fmt.Errorf("synthesis in %s failed: %w (%s)", deployment.EngineContainer(), err, lastLine(stderr.String()))
+	}
+	return parseClip(out)
+}
+
+func parseClip(out []byte) (*Clip, error) {
+	var resp struct {
+		Encoding   string `json:"encoding"`
+		SampleRate int    `json:"sample_rate"`
+		Audio      string `json:"audio"`
+		Error      string `json:"error"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(out), &resp); err != nil {
+		return nil, fmt.Errorf("invalid synthesis response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	audio, err := base64.StdEncoding.DecodeString(resp.Audio)
+	if err != nil {
+		return nil, fmt.Errorf("invalid synthesis response: %w", err)
+	}
+	if len(audio) == 0 {
+		return nil, errors.New("the TTS returned no audio; see agent logs for the adapter's error")
+	}
+	return &Clip{Encoding: strings.ToLower(resp.Encoding), SampleRate: resp.SampleRate, Audio: audio}, nil
+}
+
+func localVoiceEnv(t Target) string {
+	if str(t.Config, "tts_backend") == "kokoro" {
+		return "KOKORO_VOICE"
+	}
+	return "LOCAL_TTS_MODEL_PATH"
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// WAV format tags.
+const (
+	wavPCM   = 1
+	wavALaw  = 6
+	wavMuLaw = 7
+)
+
+func (c *Clip) wavFormat() (tag uint16, bits uint16) {
+	switch c.Encoding {
+	case "mulaw", "ulaw", "mu-law", "g711_ulaw":
+		return wavMuLaw, 8
+	case "alaw", "a-law", "g711_alaw":
+		return wavALaw, 8
+	}
+	return wavPCM, 16
+}
+
+func (c *Clip) bytesPerSample() int {
+	_, bits := c.wavFormat()
+	return int(bits) / 8
+}
+
+// WriteWAV writes the clip as a mono WAV file, keeping its encoding: a μ-law
+// clip stays μ-law, so it sounds exactly as a caller hears it.
+func (c *Clip) WriteWAV(w io.Writer) error {
+	tag, bits := c.wavFormat()
+	blockAlign := bits / 8
+	fmtSize := uint32(16)
+	if tag != wavPCM {
+		fmtSize = 18 // non-PCM formats carry cbSize
+	}
+	var h bytes.Buffer
+	h.WriteString("RIFF")
+	binary.Write(&h, binary.LittleEndian, uint32(4+8+fmtSize+8+uint32(len(c.Audio))))
+	h.WriteString("WAVEfmt ")
+	binary.Write(&h, binary.LittleEndian, fmtSize)
+	binary.Write(&h, binary.LittleEndian, tag)
+	binary.Write(&h, binary.LittleEndian, uint16(1))
+	binary.Write(&h, binary.LittleEndian, uint32(c.SampleRate))
+	binary.Write(&h, binary.LittleEndian, uint32(c.SampleRate)*uint32(blockAlign))
+	binary.Write(&h, binary.LittleEndian, blockAlign)
+	binary.Write(&h, binary.LittleEndian, bits)
+	if tag != wavPCM {
+		binary.Write(&h, binary.LittleEndian, uint16(0))
+	}
+	h.WriteString("data")
+	binary.Write(&h, binary.LittleEndian, uint32(len(c.Audio)))
+	if _, err := w.Write(h.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(c.Audio)
+	return err
+}
+
+// players are tried in order to play a WAV file on this host.
+var players = [][]string{
+	{"aplay", "-q"},
+	{"paplay"},
+	{"afplay"},
+	{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+	{"play", "-q"},
+}
+
+// Player returns the command that plays the WAV file at path, or an error
+// when no player is installed.
+func Player(path string) (*exec.Cmd, error) {
+	for _, p := range players {
+		if bin, err := exec.LookPath(p[0]); err == nil {
+			return exec.Command(bin, append(p[1:], path)...), nil
+		}
+	}
+	return nil, errors.New("no audio player found (aplay, paplay, afplay, ffplay or sox play); write the sample with --out instead")
+}
@@ -0,0 +1,171 @@
+// Package tts lists the voices a pipeline's TTS provider offers and
+// synthesizes samples with the engine's own TTS adapter, so a voice can be
+// chosen by ear before the config is edited.
+//
+// Voices come from the provider's API where it has a voice list (ElevenLabs,
+// Azure, Google, Deepgram, CAMB AI), from a built-in catalog where it does not
+// (OpenAI, Groq), and from the models directory for the local AI server.
+package tts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/locale"
+)
+
+// Target is the TTS a preview runs and whose voices are listed: the TTS
+// component of a pipeline, or a TTS provider on its own.
+type Target struct {
+	Pipeline string `json:"pipeline,omitempty"`
+	Provider string `json:"provider"` // elevenlabs_tts, local_tts...
+	Kind     string `json:"kind"`     // openai, elevenlabs, azure, google, deepgram, groq, cambai, local
+	// Voice is the voice the config selects, and VoiceKey the adapter option
+	// that selects it. VoiceKey is empty for the local AI server, whose voice
+	// is set in its environment for every call.
+	Voice    string `json:"voice,omitempty"`
+	VoiceKey string `json:"voice_key,omitempty"`
+	// Config is the provider's config with the pipeline's options.tts over it.
+	Config map[string]any `json:"-"`
+}
+
+// kinds maps each TTS adapter to the option that selects its voice and the
+// provider keys the configured voice is read from, in order.
+var kinds = map[string]struct {
+	option string
+	config []string
+}{
+	"openai":     {"voice", []string{"voice"}},
+	"groq":       {"voice", []string{"voice"}},
+	"deepgram":   {"model", []string{"model", "voice", "tts_model"}},
+	"elevenlabs": {"voice_id", []string{"voice_id"}},
+	"cambai":     {"voice_id", []string{"voice_id"}},
+	"azure":      {"voice_name", []string{"voice_name"}},
+	"google":     {"voice", []string{"voice", "tts_voice_name"}},
+	"local":      {"", nil},
+}
+
+// Resolve finds the TTS of name, a pipeline or a TTS provider; an empty name
+// means active_pipeline, else default_provider. A full agent provider has no
+// TTS the CLI can drive on its own and is an error.
+func Resolve(cfg map[string]any, name string, env locale.Env) (Target, error) {
+	if name == "" {
+		name = first(str(cfg, "active_pipeline"), str(cfg, "default_provider"))
+	}
+	if name == "" {
+		return Target{}, fmt.Errorf("no active_pipeline or default_provider is set; name a pipeline")
+	}
+	providers := mapAt(cfg, "providers")
+	t := Target{Provider: name}
+	var options map[string]any
+	if pl, ok := mapAt(cfg, "pipelines")[name].(map[string]any); ok {
+		t.Pipeline = name
+		t.Provider = str(pl, "tts")
+		if t.Provider == "" {
+			return Target{}, fmt.Errorf("pipeline %s has no tts", name)
+		}
+		options = mapAt(mapAt(pl, "options"), "tts")
+	}
+	pc, ok := providers[t.Provider].(map[string]any)
+	if !ok {
+		if t.Pipeline != "" {
+			return Target{}, fmt.Errorf("pipeline %s speaks with %s, which is not under providers", name, t.Provider)
+		}
+		return Target{}, fmt.Errorf("%s is neither a pipeline nor a provider", name)
+	}
+	t.Kind = Kind(t.Provider, pc)
+	if t.Pipeline == "" && (str(pc, "type") == "full" || !strings.HasSuffix(t.Provider, "_tts") && !hasCapability(pc, "tts")) {
+		return Target{}, fmt.Errorf("%s is a full agent provider, whose voice is part of its own session; name a pipeline or a *_tts provider", name)
+	}
+	k, known := kinds[t.Kind]
+	if !known {
+		return Target{}, fmt.Errorf("%s uses TTS type %q, which agent tts does not know", t.Provider, t.Kind)
+	}
+	t.Config = make(map[string]any, len(pc)+len(options))
+	for key, v := range pc {
+		t.Config[key] = v
+	}
+	for key, v := range options {
+		t.Config[key] = v
+	}
+	t.VoiceKey = k.option
+	for _, key := range k.config {
+		if v := str(t.Config, key); v != "" {
+			t.Voice = v
+			break
+		}
+	}
+	if t.Kind == "local" {
+		if _, side, ok := locale.Resolve(cfg, name, env); ok && side.Model != nil {
+			t.Voice = side.Model.Value
+		}
+	}
+	return t, nil
+}
+
+// Kind is the TTS adapter a provider runs: its type, else the start of its
+// name (deepgram_tts is deepgram).
+func Kind(name string, pc map[string]any) string {
+	if k := str(pc, "type"); k != "" && k != "full" {
+		return k
+	}
+	if name == "local" || strings.HasPrefix(name, "local_") {
+		return "local"
+	}
+	prefix, _, _ := strings.Cut(name, "_")
+	return prefix
+}
+
+// Label names the target for messages: "pipeline x (elevenlabs_tts)" or the
+// provider.
+func (t Target) Label() string {
+	if t.Pipeline != "" {
+		return fmt.Sprintf("pipeline %s (%s)", t.Pipeline, t.Provider)
+	}
+	return t.Provider
+}
+
+func hasCapability(pc map[string]any, role string) bool {
+	caps, _ := pc["capabilities"].([]any)
+	for _, c := range caps {
+		if c == role {
+			return true
+		}
+	}
+	return false
+}
+
+func mapAt(m map[string]any, key string) map[string]any {
+	v, _ := m[key].(map[string]any)
+	return v
+}
+
+func str(m map[string]any, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case int, int64, float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sortVoices orders voices by language, then name.
+func sortVoices(voices []Voice) {
+	sort.SliceStable(voices, func(i, j int) bool {
+		if voices[i].Locale != voices[j].Locale {
+			return voices[i].Locale < voices[j].Locale
+		}
+		return strings.ToLower(voices[i].Name) < strings.ToLower(voices[j].Name)
+	})
+}
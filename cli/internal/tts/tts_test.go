@@ -0,0 +1,141 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+)
+
+const testConfig = `
+active_pipeline: hybrid_elevenlabs
+default_provider: local
+providers:
+  local:
+    type: full
+    tts_backend: kokoro
+  local_tts:
+    type: local
+  elevenlabs_tts:
+    type: elevenlabs
+    voice_id: 21m00Tcm4TlvDq8ikWAM
+  azure_tts:
+    type: azure
+    voice_name: en-US-JennyNeural
+  openai_realtime:
+    type: openai_realtime
+    voice: alloy
+pipelines:
+  hybrid_elevenlabs:
+    stt: local_stt
+    llm: openai_llm
+    tts: elevenlabs_tts
+    options:
+      tts:
+        voice_id: pNInz6obpgDQGcFmaJgB
+        format: {encoding: mulaw, sample_rate: 8000}
+  local_hybrid:
+    stt: local_stt
+    llm: openai_llm
+    tts: local_tts
+`
+
+func TestResolve(t *testing.T) {
+	cfg, err := configmerge.ParseYAML([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := func(k string) string { return map[string]string{"KOKORO_VOICE": "ef_dora"}[k] }
+
+	got, err := Resolve(cfg, "", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Pipeline != "hybrid_elevenlabs" || got.Provider != "elevenlabs_tts" || got.Kind != "elevenlabs" ||
+		got.VoiceKey != "voice_id" || got.Voice != "pNInz6obpgDQGcFmaJgB" {
+		t.Fatalf("active pipeline: %+v", got)
+	}
+	if got, err := Resolve(cfg, "azure_tts", env); err != nil || got.Voice != "en-US-JennyNeural" || got.VoiceKey != "voice_name" {
+		t.Fatalf("provider: %+v, %v", got, err)
+	}
+	if got, err := Resolve(cfg, "local_hybrid", env); err != nil || got.Kind != "local" || got.VoiceKey != "" {
+		t.Fatalf("local pipeline: %+v, %v", got, err)
+	}
+	for _, name := range []string{"openai_realtime", "local", "nope"} {
+		if _, err := Resolve(cfg, name, env); err == nil {
+			t.Errorf("Resolve(%q) succeeded", name)
+		}
+	}
+}
+
+func TestElevenLabsVoices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("xi-api-key") != "k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"voices":[
+			{"voice_id":"b","name":"Bella","category":"premade","labels":{"gender":"female","accent":"american"},
+			 "verified_languages":[{"language":"en","locale":"en-US"},{"language":"es","locale":"es-ES"}]},
+			{"voice_id":"a","name":"Alma","labels":{},"verified_languages":[{"language":"es","locale":"es-MX"}]}]}`))
+	}))
+	defer srv.Close()
+	defer func(u string) { elevenLabsURL = u }(elevenLabsURL)
+	elevenLabsURL = srv.URL
+
+	target := Target{Kind: "elevenlabs", Config: map[string]any{"api_key": "${ELEVENLABS_API_KEY}"}}
+	voices, err := Voices(context.Background(), target, func(string) string { return "k" }, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voices) != 2 || voices[0].ID != "b" || voices[0].Language != "multi" || voices[1].Locale != "es-MX" || voices[1].Language != "es" {
+		t.Fatalf("voices = %+v", voices)
+	}
+	if voices[1].Speaks("German") || !voices[0].Speaks("de") || !voices[1].Speaks("es-AR") {
+		t.Error("Speaks")
+	}
+
+	_, err = Voices(context.Background(), target, func(string) string { return "bad" }, "")
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("bad key: %v", err)
+	}
+	if _, err = Voices(context.Background(), target, func(string) string { return "" }, ""); err == nil || !strings.Contains(err.Error(), "ELEVENLABS_API_KEY") {
+		t.Fatalf("no key: %v", err)
+	}
+}
+
+func TestClip(t *testing.T) {
+	clip, err := parseClip([]byte(`{"encoding":"mulaw","sample_rate":8000,"audio":"` + strings.Repeat("f39/", 2000) + `"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := clip.Seconds(); got != 0.75 {
+		t.Fatalf("Seconds = %v", got)
+	}
+	var buf bytes.Buffer
+	if err := clip.WriteWAV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	wav := buf.Bytes()
+	if string(wav[0:4]) != "RIFF" || string(wav[8:16]) != "WAVEfmt " {
+		t.Fatalf("header %q", wav[:16])
+	}
+	if tag := binary.LittleEndian.Uint16(wav[20:22]); tag != wavMuLaw {
+		t.Fatalf("format tag %d", tag)
+	}
+	if size := binary.LittleEndian.Uint32(wav[4:8]); int(size) != len(wav)-8 {
+		t.Fatalf("RIFF size %d for %d bytes", size, len(wav))
+	}
+
+	if _, err := parseClip([]byte(`{"error":"RuntimeError: pipeline x is not loaded by the engine"}`)); err == nil || !strings.Contains(err.Error(), "not loaded") {
+		t.Fatalf("error response: %v", err)
+	}
+	if _, err := parseClip([]byte(`{"encoding":"mulaw","sample_rate":8000,"audio":""}`)); err == nil {
+		t.Fatal("empty audio accepted")
+	}
+}
@@ -0,0 +1,328 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/locale"
+)
+
+// Voice is one voice a TTS provider offers.
+type Voice struct {
+	ID       string `json:"id"` // the value the voice option takes
+	Name     string `json:"name,omitempty"`
+	Locale   string `json:"locale,omitempty"`   // en-US, when the provider says
+	Language string `json:"language,omitempty"` // code, locale.Multi, or "" when unknown
+	Gender   string `json:"gender,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// Speaks reports whether the voice fits language lang (a code or a name); a
+// voice of unknown or many languages fits every language.
+func (v Voice) Speaks(lang string) bool {
+	return !locale.Conflict(locale.Base(lang), v.Language)
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// Provider API endpoints, variables for tests.
+var (
+	elevenLabsURL = "https://api.elevenlabs.io/v1/voices"
+	deepgramURL   = "https://api.deepgram.com/v1/models"
+	googleURL     = "https://texttospeech.googleapis.com/v1/voices"
+	// azureURL takes the region.
+	azureURL = "https://%s.tts.speech.microsoft.com/cognitiveservices/voices/list"
+)
+
+// openAIVoices are the voices of OpenAI's speech endpoint; ballad and verse
+// need gpt-4o-mini-tts. They speak the language of the text.
+var openAIVoices = []string{"alloy", "ash", "ballad", "coral", "echo", "fable", "nova", "onyx", "sage", "shimmer", "verse"}
+
+// groqVoices are the Orpheus voices, by the model that speaks them.
+var groqVoices = map[string][]string{
+	"en": {"autumn", "diana", "hannah", "austin", "daniel", "troy"},
+	"ar": {"fahad", "sultan", "lulwa", "noura"},
+}
+
+// kokoroVoices are listed when the Kokoro voices directory is not on this
+// host.
+var kokoroVoices = []string{"af_heart", "af_bella", "af_nicole", "af_sarah", "af_sky", "am_adam", "am_michael", "bf_emma", "bf_isabella", "bm_george", "bm_lewis"}
+
+// Voices lists the voices t's provider offers, sorted by locale and name.
+// API keys come from env, the local AI server's models from modelsDir.
+func Voices(ctx context.Context, t Target, env locale.Env, modelsDir string) ([]Voice, error) {
+	var voices []Voice
+	var err error
+	switch t.Kind {
+	case "openai":
+		for _, id := range openAIVoices {
+			voices = append(voices, Voice{ID: id, Name: id, Language: locale.Multi})
+		}
+	case "groq":
+		model := first(str(t.Config, "model"), str(t.Config, "tts_model"), "canopylabs/orpheus-v1-english")
+		lang := locale.ModelLanguage(model)
+		for _, id := range groqVoices[lang] {
+			voices = append(voices, Voice{ID: id, Name: id, Language: lang, Notes: model})
+		}
+		if voices == nil {
+			return nil, fmt.Errorf("no voices known for Groq model %s", model)
+		}
+	case "elevenlabs":
+		voices, err = elevenLabsVoices(ctx, apiKey(t, env, "ELEVENLABS_API_KEY"))
+	case "deepgram":
+		voices, err = deepgramVoices(ctx, apiKey(t, env, "DEEPGRAM_API_KEY"))
+	case "azure":
+		region := first(str(t.Config, "region"), env("AZURE_SPEECH_REGION"), "eastus")
+		voices, err = azureVoices(ctx, region, apiKey(t, env, "AZURE_SPEECH_KEY"))
+	case "google":
+		voices, err = googleVoices(ctx, apiKey(t, env, "GOOGLE_API_KEY"))
+	case "cambai":
+		base := first(str(t.Config, "base_url"), "https://client.camb.ai/apis")
+		voices, err = cambVoices(ctx, strings.TrimRight(base, "/")+"/list-voices", apiKey(t, env, "CAMB_API_KEY"))
+	case "local":
+		voices, err = localVoices(t, env, modelsDir)
+	default:
+		return nil, fmt.Errorf("agent tts cannot list %s voices", t.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sortVoices(voices)
+	return voices, nil
+}
+
+// apiKey is the provider's api_key when it is set literally, else the env
+// var the engine falls back to.
+func apiKey(t Target, env locale.Env, envVar string) string {
+	if k := str(t.Config, "api_key"); k != "" && !strings.Contains(k, "${") {
+		return k
+	}
+	if v := str(t.Config, "api_key_env"); v != "" {
+		envVar = v
+	}
+	return env(envVar)
+}
+
+// getJSON fetches url into v, with the headers given as name/value pairs.
+func getJSON(ctx context.Context, provider, rawURL string, v any, headers ...string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s voice list: %w", provider, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%s voice list: the API key was rejected (HTTP %d)", provider, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s voice list: HTTP %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s voice list: %w", provider, err)
+	}
+	return nil
+}
+
+func needKey(provider, envVar, key string) error {
+	if key == "" {
+		return fmt.Errorf("listing %s voices needs %s in .env", provider, envVar)
+	}
+	return nil
+}
+
+func elevenLabsVoices(ctx context.Context, key string) ([]Voice, error) {
+	if err := needKey("ElevenLabs", "ELEVENLABS_API_KEY", key); err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Voices []struct {
+			ID       string            `json:"voice_id"`
+			Name     string            `json:"name"`
+			Category string            `json:"category"`
+			Labels   map[string]string `json:"labels"`
+			Verified []struct {
+				Language string `json:"language"`
+				Locale   string `json:"locale"`
+			} `json:"verified_languages"`
+		} `json:"voices"`
+	}
+	if err := getJSON(ctx, "ElevenLabs", elevenLabsURL, &resp, "xi-api-key", key); err != nil {
+		return nil, err
+	}
+	voices := make([]Voice, 0, len(resp.Voices))
+	for _, v := range resp.Voices {
+		voice := Voice{ID: v.ID, Name: v.Name, Gender: v.Labels["gender"], Notes: strings.TrimSpace(v.Labels["accent"] + " " + v.Category)}
+		// Voices speak every language of a multilingual model; the
+		// verified languages are the ones ElevenLabs checked.
+		switch len(v.Verified) {
+		case 0:
+		case 1:
+			voice.Locale, voice.Language = v.Verified[0].Locale, locale.Base(v.Verified[0].Language)
+		default:
+			voice.Language = locale.Multi
+		}
+		voices = append(voices, voice)
+	}
+	return voices, nil
+}
+
+func deepgramVoices(ctx context.Context, key string) ([]Voice, error) {
+	if err := needKey("Deepgram", "DEEPGRAM_API_KEY", key); err != nil {
+		return nil, err
+	}
+	var resp struct {
+		TTS []struct {
+			Name      string   `json:"name"`
+			Canonical string   `json:"canonical_name"`
+			Languages []string `json:"languages"`
+			Metadata  struct {
+				Accent string   `json:"accent"`
+				Tags   []string `json:"tags"`
+			} `json:"metadata"`
+		} `json:"tts"`
+	}
+	if err := getJSON(ctx, "Deepgram", deepgramURL, &resp, "Authorization", "Token "+key); err != nil {
+		return nil, err
+	}
+	voices := make([]Voice, 0, len(resp.TTS))
+	for _, v := range resp.TTS {
+		voice := Voice{ID: v.Canonical, Name: v.Name, Language: locale.ModelLanguage(v.Canonical), Notes: v.Metadata.Accent}
+		for _, l := range v.Languages {
+			if strings.Contains(l, "-") {
+				voice.Locale = l
+				break
+			}
+		}
+		for _, tag := range v.Metadata.Tags {
+			if tag == "feminine" || tag == "masculine" {
+				voice.Gender = tag
+			}
+		}
+		voices = append(voices, voice)
+	}
+	return voices, nil
+}
+
+func azureVoices(ctx context.Context, region, key string) ([]Voice, error) {
+	if err := needKey("Azure", "AZURE_SPEECH_KEY", key); err != nil {
+		return nil, err
+	}
+	var resp []struct {
+		ShortName   string `json:"ShortName"`
+		DisplayName string `json:"DisplayName"`
+		Locale      string `json:"Locale"`
+		Gender      string `json:"Gender"`
+		VoiceType   string `json:"VoiceType"`
+	}
+	if err := getJSON(ctx, "Azure", fmt.Sprintf(azureURL, url.PathEscape(region)), &resp, "Ocp-Apim-Subscription-Key", key); err != nil {
+		return nil, err
+	}
+	voices := make([]Voice, 0, len(resp))
+	for _, v := range resp {
+		lang := locale.ModelLanguage(v.ShortName)
+		if lang == "" {
+			lang = locale.Base(v.Locale)
+		}
+		voices = append(voices, Voice{ID: v.ShortName, Name: v.DisplayName, Locale: v.Locale, Language: lang, Gender: v.Gender, Notes: v.VoiceType})
+	}
+	return voices, nil
+}
+
+func googleVoices(ctx context.Context, key string) ([]Voice, error) {
+	if key == "" {
+		return nil, fmt.Errorf("listing Google voices needs GOOGLE_API_KEY in .env (a service account is not enough)")
+	}
+	var resp struct {
+		Voices []struct {
+			Name          string   `json:"name"`
+			LanguageCodes []string `json:"languageCodes"`
+			Gender        string   `json:"ssmlGender"`
+		} `json:"voices"`
+	}
+	if err := getJSON(ctx, "Google", googleURL+"?key="+url.QueryEscape(key), &resp); err != nil {
+		return nil, err
+	}
+	voices := make([]Voice, 0, len(resp.Voices))
+	for _, v := range resp.Voices {
+		voice := Voice{ID: v.Name, Name: v.Name, Gender: strings.ToLower(v.Gender), Language: locale.ModelLanguage(v.Name)}
+		if len(v.LanguageCodes) > 0 {
+			voice.Locale = v.LanguageCodes[0]
+		}
+		if voice.Language == "" {
+			voice.Language = locale.Base(voice.Locale)
+		}
+		voices = append(voices, voice)
+	}
+	return voices, nil
+}
+
+func cambVoices(ctx context.Context, rawURL, key string) ([]Voice, error) {
+	if err := needKey("CAMB AI", "CAMB_API_KEY", key); err != nil {
+		return nil, err
+	}
+	var resp []struct {
+		ID   json.Number `json:"id"`
+		Name string      `json:"voice_name"`
+	}
+	if err := getJSON(ctx, "CAMB AI", rawURL, &resp, "x-api-key", key); err != nil {
+		return nil, err
+	}
+	voices := make([]Voice, 0, len(resp))
+	for _, v := range resp {
+		voices = append(voices, Voice{ID: v.ID.String(), Name: v.Name, Language: locale.Multi})
+	}
+	return voices, nil
+}
+
+// localVoices lists the voices of the local AI server's TTS backend from the
+// models directory it mounts. They are chosen with the server's environment,
+// not per call.
+func localVoices(t Target, env locale.Env, modelsDir string) ([]Voice, error) {
+	backend := first(str(t.Config, "tts_backend"), env("LOCAL_TTS_BACKEND"), "piper")
+	switch backend {
+	case "kokoro":
+		ids := kokoroVoices
+		if files, _ := filepath.Glob(filepath.Join(modelsDir, "tts", "kokoro", "voices", "*.pt")); len(files) > 0 {
+			ids = nil
+			for _, f := range files {
+				ids = append(ids, strings.TrimSuffix(filepath.Base(f), ".pt"))
+			}
+		}
+		voices := make([]Voice, 0, len(ids))
+		for _, id := range ids {
+			voices = append(voices, Voice{ID: id, Name: id, Language: locale.KokoroLanguage(id), Notes: "KOKORO_VOICE"})
+		}
+		return voices, nil
+	case "piper":
+		files, _ := filepath.Glob(filepath.Join(modelsDir, "tts", "*.onnx"))
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no Piper voices (*.onnx) in %s", filepath.Join(modelsDir, "tts"))
+		}
+		var voices []Voice
+		for _, f := range files {
+			id := "/app/models/tts/" + filepath.Base(f)
+			name := strings.TrimSuffix(filepath.Base(f), ".onnx")
+			v := Voice{ID: id, Name: name, Language: locale.ModelLanguage(name), Notes: "LOCAL_TTS_MODEL_PATH"}
+			if l, _, ok := strings.Cut(name, "-"); ok {
+				v.Locale = strings.ReplaceAll(l, "_", "-")
+			}
+			voices = append(voices, v)
+		}
+		return voices, nil
+	}
+	return nil, fmt.Errorf("agent tts cannot list voices of the %s backend", backend)
+}
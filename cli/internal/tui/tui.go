@@ -0,0 +1,523 @@
+// Package tui is the agent tui dashboard: the stack's containers, the
+// engine's active calls with their conversation stage, recent calls with
+// their quality scores and a tail of the engine log on one screen, with the
+// RCA of a selected call a key away.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+// Container is a stack container as the dashboard shows it.
+type Container struct {
+	Name     string
+	Status   string // docker state: running, restarting, exited...
+	Health   string // healthcheck status, when the image defines one
+	Restarts int
+	Err      error
+}
+
+// RCA is an analyzed call: the report and agent rca's verdict (PASS, WARN or
+// FAIL).
+type RCA struct {
+	Report *troubleshoot.RCAReport
+	Result string
+}
+
+// Sources fetch what the dashboard shows. They run in tea commands, off the
+// update loop, so a slow docker or engine does not freeze the screen.
+type Sources struct {
+	Containers func() []Container
+	Sessions   func(ctx context.Context) ([]engineapi.Session, error)
+	Calls      func() ([]troubleshoot.Call, error)
+	Analyze    func(callID string) (RCA, error)
+}
+
+// LogLine is an engine log line. The command tails the log and sends each
+// line to the program.
+type LogLine string
+
+// Stages are the engine's conversation states, in the order a call goes
+// through them.
+var Stages = []string{"greeting", "listening", "processing"}
+
+const (
+	// callsEvery is how many live refreshes pass between call list
+	// refreshes; the call index reads the engine log and costs more.
+	callsEvery = 5
+	// recentRows is how many recent calls are listed.
+	recentRows = 8
+	// maxLogLines is how much of the log tail is kept.
+	maxLogLines = 500
+)
+
+type pane int
+
+const (
+	activePane pane = iota
+	recentPane
+)
+
+type (
+	liveTick  struct{}
+	callsTick struct{}
+	liveMsg   struct {
+		containers []Container
+		sessions   []engineapi.Session
+		err        error
+		at         time.Time
+	}
+	callsMsg struct {
+		calls []troubleshoot.Call
+		err   error
+	}
+	rcaMsg struct {
+		callID string
+		rca    RCA
+		err    error
+	}
+)
+
+// rcaView is the RCA screen for one call.
+type rcaView struct {
+	callID  string
+	running bool
+	rca     RCA
+	err     error
+	scroll  int
+}
+
+// Model is the dashboard's bubbletea model.
+type Model struct {
+	src     Sources
+	refresh time.Duration
+
+	width, height int
+
+	containers  []Container
+	sessions    []engineapi.Session
+	sessionsErr error
+	calls       []troubleshoot.Call
+	callsErr    error
+	logs        []string
+	updated     time.Time
+
+	focus  pane
+	cursor [2]int
+	rca    *rcaView
+}
+
+// New returns the dashboard, refreshing containers and active calls every
+// refresh.
+func New(src Sources, refresh time.Duration) Model {
+	return Model{src: src, refresh: refresh, width: 100, height: 30}
+}
+
+// Init starts the first refresh.
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.pollLive, m.pollCalls)
+}
+
+func (m Model) pollLive() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), m.refresh+5*time.Second)
+	defer cancel()
+	msg := liveMsg{at: time.Now()}
+	if m.src.Containers != nil {
+		msg.containers = m.src.Containers()
+	}
+	if m.src.Sessions != nil {
+		msg.sessions, msg.err = m.src.Sessions(ctx)
+	}
+	return msg
+}
+
+func (m Model) pollCalls() tea.Msg {
+	if m.src.Calls == nil {
+		return callsMsg{}
+	}
+	calls, err := m.src.Calls()
+	return callsMsg{calls: calls, err: err}
+}
+
+func (m Model) analyze(callID string) tea.Cmd {
+	return func() tea.Msg {
+		if m.src.Analyze == nil {
+			return rcaMsg{callID: callID, err: errors.New("analysis is not available")}
+		}
+		rca, err := m.src.Analyze(callID)
+		return rcaMsg{callID: callID, rca: rca, err: err}
+	}
+}
+
+// Update handles keys, refresh results and log lines. The next refresh is
+// scheduled when the previous one returns, so slow sources never pile up.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case liveMsg:
+		m.containers, m.sessions, m.sessionsErr, m.updated = msg.containers, msg.sessions, msg.err, msg.at
+		m.clampCursors()
+		return m, tea.Tick(m.refresh, func(time.Time) tea.Msg { return liveTick{} })
+	case liveTick:
+		return m, m.pollLive
+	case callsMsg:
+		m.calls, m.callsErr = msg.calls, msg.err
+		if len(m.calls) > recentRows {
+			m.calls = m.calls[:recentRows]
+		}
+		m.clampCursors()
+		return m, tea.Tick(callsEvery*m.refresh, func(time.Time) tea.Msg { return callsTick{} })
+	case callsTick:
+		return m, m.pollCalls
+	case rcaMsg:
+		if m.rca != nil && m.rca.callID == msg.callID {
+			m.rca.running, m.rca.rca, m.rca.err = false, msg.rca, msg.err
+		}
+	case LogLine:
+		m.logs = append(m.logs, string(msg))
+		if len(m.logs) > maxLogLines {
+			m.logs = m.logs[len(m.logs)-maxLogLines:]
+		}
+	case tea.KeyMsg:
+		return m.key(msg.String())
+	}
+	return m, nil
+}
+
+func (m Model) key(k string) (tea.Model, tea.Cmd) {
+	if k == "ctrl+c" || k == "q" {
+		return m, tea.Quit
+	}
+	if m.rca != nil {
+		switch k {
+		case "esc", "backspace", "left", "h":
+			m.rca = nil
+		case "up", "k":
+			if m.rca.scroll > 0 {
+				m.rca.scroll--
+			}
+		case "down", "j":
+			if m.rca.scroll < len(m.rcaBody())-m.rcaRoom() {
+				m.rca.scroll++
+			}
+		}
+		return m, nil
+	}
+	switch k {
+	case "tab":
+		m.focus = 1 - m.focus
+	case "up", "k":
+		if m.cursor[m.focus] > 0 {
+			m.cursor[m.focus]--
+		}
+	case "down", "j":
+		if m.cursor[m.focus] < m.rows(m.focus)-1 {
+			m.cursor[m.focus]++
+		}
+	case "enter", "r":
+		if id := m.Selected(); id != "" {
+			m.rca = &rcaView{callID: id, running: true}
+			return m, m.analyze(id)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) rows(p pane) int {
+	if p == activePane {
+		return len(m.sessions)
+	}
+	return len(m.calls)
+}
+
+func (m *Model) clampCursors() {
+	for _, p := range []pane{activePane, recentPane} {
+		if n := m.rows(p); m.cursor[p] >= n {
+			m.cursor[p] = max(n-1, 0)
+		}
+	}
+}
+
+// Selected is the call ID under the cursor in the focused list, if any.
+func (m Model) Selected() string {
+	i := m.cursor[m.focus]
+	switch {
+	case m.focus == activePane && i < len(m.sessions):
+		return m.sessions[i].CallID
+	case m.focus == recentPane && i < len(m.calls):
+		return m.calls[i].ID
+	}
+	return ""
+}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	goodStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	warnStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	badStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	stageStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+)
+
+// View renders the dashboard, or the RCA screen when one is open.
+func (m Model) View() string {
+	if m.rca != nil {
+		return m.rcaScreen()
+	}
+	var lines []string
+	updated := "waiting for the first refresh"
+	if !m.updated.IsZero() {
+		updated = "updated " + m.updated.Format("15:04:05")
+	}
+	lines = append(lines, titleStyle.Render("Asterisk AI Voice Agent")+"  "+dimStyle.Render(updated), "")
+
+	lines = append(lines, titleStyle.Render("Containers"))
+	if len(m.containers) == 0 {
+		lines = append(lines, dimStyle.Render("  no containers found"))
+	}
+	for _, c := range m.containers {
+		lines = append(lines, containerLine(c))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, m.heading(activePane, fmt.Sprintf("Active calls (%d)", len(m.sessions))))
+	switch {
+	case errors.Is(m.sessionsErr, engineapi.ErrUnsupported):
+		lines = append(lines, dimStyle.Render("  the engine does not report sessions (older release)"))
+	case m.sessionsErr != nil:
+		lines = append(lines, badStyle.Render("  engine status unavailable: "+m.sessionsErr.Error()))
+	case len(m.sessions) == 0:
+		lines = append(lines, dimStyle.Render("  no active calls"))
+	}
+	for i, s := range m.sessions {
+		target := s.Provider
+		if s.Pipeline != "" {
+			target = "pipeline " + s.Pipeline
+		}
+		row := fmt.Sprintf("%-24s %-22s ", s.CallID, orDash(target))
+		lines = append(lines, m.row(activePane, i, row)+stageTrack(s.ConversationState)+"  "+dimStyle.Render(s.Status))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, m.heading(recentPane, "Recent calls"))
+	switch {
+	case m.callsErr != nil:
+		lines = append(lines, badStyle.Render("  "+m.callsErr.Error()))
+	case len(m.calls) == 0:
+		lines = append(lines, dimStyle.Render("  no calls in the log window"))
+	}
+	for i, c := range m.calls {
+		outcome := c.Outcome
+		if outcome == "" {
+			outcome = c.Status
+		}
+		row := fmt.Sprintf("%-24s %s %8s  %-14s ", c.ID, c.Timestamp.Local().Format("01-02 15:04"), orDash(c.Duration), orDash(outcome))
+		lines = append(lines, m.row(recentPane, i, row)+scoreLabel(c.QualityScore))
+	}
+	lines = append(lines, "")
+
+	footer := dimStyle.Render("↑/↓ select · tab switch list · enter RCA · q quit")
+	lines = append(lines, titleStyle.Render("Engine log"))
+	room := m.height - len(lines) - 2
+	if room < 3 {
+		room = 3
+	}
+	tail := m.logs
+	if len(tail) > room {
+		tail = tail[len(tail)-room:]
+	}
+	for _, l := range tail {
+		lines = append(lines, dimStyle.Render(l))
+	}
+	for i := len(tail); i < room; i++ {
+		lines = append(lines, "")
+	}
+	lines = append(lines, footer)
+	return m.fit(lines)
+}
+
+func (m Model) heading(p pane, title string) string {
+	if m.focus == p {
+		return titleStyle.Render("▸ " + title)
+	}
+	return titleStyle.Render("  " + title)
+}
+
+func (m Model) row(p pane, i int, text string) string {
+	if m.focus == p && m.cursor[p] == i {
+		return "  " + selectedStyle.Render(text)
+	}
+	return "  " + text
+}
+
+// fit clips every line to the terminal width.
+func (m Model) fit(lines []string) string {
+	clip := lipgloss.NewStyle().MaxWidth(m.width)
+	for i, l := range lines {
+		lines[i] = clip.Render(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func containerLine(c Container) string {
+	if c.Err != nil {
+		return badStyle.Render("  ● ") + fmt.Sprintf("%-20s %s", c.Name, c.Err)
+	}
+	state := c.Status
+	if c.Health != "" {
+		state += " (" + c.Health + ")"
+	}
+	dot := goodStyle
+	switch {
+	case c.Status == "restarting" || c.Health == "starting":
+		dot = warnStyle
+	case c.Status != "running" || c.Health == "unhealthy":
+		dot = badStyle
+	}
+	return dot.Render("  ● ") + fmt.Sprintf("%-20s %-22s restarts=%d", c.Name, state, c.Restarts)
+}
+
+// stageTrack shows where a call is in Stages: stages already passed are dim,
+// the current one is highlighted. A state outside Stages is shown on its own.
+func stageTrack(state string) string {
+	current := -1
+	for i, s := range Stages {
+		if s == state {
+			current = i
+		}
+	}
+	if current < 0 {
+		if state == "" {
+			return dimStyle.Render("-")
+		}
+		return stageStyle.Render(state)
+	}
+	parts := make([]string, len(Stages))
+	for i, s := range Stages {
+		switch {
+		case i == current:
+			parts[i] = stageStyle.Render("● " + s)
+		case i < current:
+			parts[i] = dimStyle.Render("✓ " + s)
+		default:
+			parts[i] = dimStyle.Render("○ " + s)
+		}
+	}
+	return strings.Join(parts, dimStyle.Render(" › "))
+}
+
+// scoreLabel grades a quality score as agent rca's verdict does.
+func scoreLabel(score *float64) string {
+	if score == nil {
+		return dimStyle.Render("not analyzed")
+	}
+	label := fmt.Sprintf("score %.0f", *score)
+	switch {
+	case *score >= 90:
+		return goodStyle.Render(label)
+	case *score >= 50:
+		return warnStyle.Render(label)
+	}
+	return badStyle.Render(label)
+}
+
+func (m Model) rcaRoom() int {
+	return max(m.height-3, 1)
+}
+
+func (m Model) rcaBody() []string {
+	v := m.rca
+	switch {
+	case v.running:
+		return []string{dimStyle.Render("analyzing...")}
+	case v.err != nil:
+		return []string{badStyle.Render(v.err.Error())}
+	}
+	return rcaLines(v.rca)
+}
+
+func (m Model) rcaScreen() string {
+	body, room := m.rcaBody(), m.rcaRoom()
+	body = body[min(m.rca.scroll, len(body)):]
+	if len(body) > room {
+		body = body[:room]
+	}
+	lines := append([]string{titleStyle.Render("RCA " + m.rca.callID), ""}, body...)
+	for i := len(body); i < room; i++ {
+		lines = append(lines, "")
+	}
+	lines = append(lines, dimStyle.Render("↑/↓ scroll · esc back · q quit · agent rca "+m.rca.callID+" for the full report"))
+	return m.fit(lines)
+}
+
+func rcaLines(r RCA) []string {
+	rep := r.Report
+	if rep == nil {
+		return []string{dimStyle.Render("no report")}
+	}
+	verdict := goodStyle
+	switch r.Result {
+	case "FAIL":
+		verdict = badStyle
+	case "WARN":
+		verdict = warnStyle
+	}
+	lines := []string{"Result: " + verdict.Render(r.Result)}
+	if h := rep.Header; h != nil {
+		target := h.ProviderName
+		if h.PipelineName != "" {
+			target = "pipeline " + h.PipelineName
+		}
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("Caller %s → %s via %s", orDash(h.CallerNumber), orDash(h.CalledNumber), orDash(target))))
+	}
+	summary := len(lines)
+	section := func(title string, style lipgloss.Style, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		lines = append(lines, "", titleStyle.Render(title))
+		for _, it := range items {
+			lines = append(lines, style.Render("  • "+it))
+		}
+	}
+	section("Errors", badStyle, rep.Errors)
+	section("Warnings", warnStyle, rep.Warnings)
+	section("Audio issues", warnStyle, rep.AudioIssues)
+	if a := rep.SymptomAnalysis; a != nil {
+		section("Likely causes", lipgloss.NewStyle(), a.RootCauses)
+		section("Actions", lipgloss.NewStyle(), a.Actions)
+	}
+	var known []string
+	for _, h := range rep.KnownIssues {
+		known = append(known, h.Title+": "+h.Cause)
+	}
+	section("Known issues", lipgloss.NewStyle(), known)
+	if d := rep.LLMDiagnosis; d != nil && strings.TrimSpace(d.Analysis) != "" {
+		lines = append(lines, "", titleStyle.Render("AI diagnosis ("+d.Provider+")"))
+		for _, l := range strings.Split(strings.TrimSpace(d.Analysis), "\n") {
+			lines = append(lines, "  "+l)
+		}
+	}
+	if len(lines) == summary {
+		lines = append(lines, "", goodStyle.Render("No problems found."))
+	}
+	return lines
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/engineapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/troubleshoot"
+)
+
+func step(t *testing.T, m Model, msg tea.Msg) (Model, tea.Cmd) {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	return next.(Model), cmd
+}
+
+func key(t *testing.T, m Model, k string) (Model, tea.Cmd) {
+	t.Helper()
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+	switch k {
+	case "tab":
+		msg = tea.KeyMsg{Type: tea.KeyTab}
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return step(t, m, msg)
+}
+
+func loaded(t *testing.T, src Sources) Model {
+	t.Helper()
+	score := 94.0
+	m := New(src, time.Second)
+	m, _ = step(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = step(t, m, liveMsg{
+		containers: []Container{
+			{Name: "ai_engine", Status: "running", Health: "healthy"},
+			{Name: "admin_ui", Status: "exited", Restarts: 3},
+		},
+		sessions: []engineapi.Session{
+			{CallID: "1761518880.2191", Pipeline: "local_hybrid", ConversationState: "listening", Status: "active"},
+		},
+		at: time.Now(),
+	})
+	m, _ = step(t, m, callsMsg{calls: []troubleshoot.Call{
+		{ID: "1761518700.2180", Timestamp: time.Now(), Duration: "1m2s", Outcome: "completed", QualityScore: &score},
+		{ID: "1761518600.2175", Timestamp: time.Now(), Duration: "12s", Status: "failed"},
+	}})
+	return m
+}
+
+func TestViewShowsEveryPane(t *testing.T) {
+	m := loaded(t, Sources{})
+	m, _ = step(t, m, LogLine("INFO engine ready"))
+	view := m.View()
+	for _, want := range []string{
+		"ai_engine", "running (healthy)", "admin_ui", "restarts=3",
+		"Active calls (1)", "1761518880.2191", "pipeline local_hybrid", "✓ greeting", "● listening", "○ processing",
+		"1761518700.2180", "score 94", "1761518600.2175", "not analyzed",
+		"INFO engine ready",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+	if n := strings.Count(view, "\n") + 1; n > 40 {
+		t.Errorf("view is %d lines on a 40-line terminal", n)
+	}
+}
+
+func TestEnterAnalyzesTheSelectedCall(t *testing.T) {
+	var analyzed string
+	m := loaded(t, Sources{Analyze: func(id string) (RCA, error) {
+		analyzed = id
+		return RCA{Result: "FAIL", Report: &troubleshoot.RCAReport{CallID: id, Errors: []string{"provider websocket closed"}}}, nil
+	}})
+	m, _ = key(t, m, "tab")
+	m, _ = key(t, m, "down")
+	if got := m.Selected(); got != "1761518600.2175" {
+		t.Fatalf("selected %q", got)
+	}
+	m, cmd := key(t, m, "enter")
+	if cmd == nil || !strings.Contains(m.View(), "analyzing") {
+		t.Fatalf("enter did not start an analysis:\n%s", m.View())
+	}
+	m, _ = step(t, m, cmd())
+	if analyzed != "1761518600.2175" {
+		t.Fatalf("analyzed %q", analyzed)
+	}
+	view := m.View()
+	for _, want := range []string{"RCA 1761518600.2175", "Result: FAIL", "provider websocket closed"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("RCA view lacks %q:\n%s", want, view)
+		}
+	}
+	m, _ = key(t, m, "esc")
+	if !strings.Contains(m.View(), "Recent calls") {
+		t.Errorf("esc did not return to the dashboard")
+	}
+}
+
+func TestAnalysisErrorIsShown(t *testing.T) {
+	m := loaded(t, Sources{Analyze: func(string) (RCA, error) { return RCA{}, errors.New("no logs for call") }})
+	m, cmd := key(t, m, "enter")
+	m, _ = step(t, m, cmd())
+	if !strings.Contains(m.View(), "no logs for call") {
+		t.Errorf("error not shown:\n%s", m.View())
+	}
+}
+
+func TestCursorFollowsShrinkingLists(t *testing.T) {
+	m := loaded(t, Sources{})
+	m, _ = key(t, m, "tab")
+	m, _ = key(t, m, "down")
+	m, _ = step(t, m, callsMsg{calls: []troubleshoot.Call{{ID: "1761518900.2200", Timestamp: time.Now()}}})
+	if got := m.Selected(); got != "1761518900.2200" {
+		t.Errorf("selected %q after the list shrank", got)
+	}
+	m, _ = key(t, m, "tab")
+	m, _ = step(t, m, liveMsg{at: time.Now()})
+	if got := m.Selected(); got != "" {
+		t.Errorf("selected %q with no active calls", got)
+	}
+	if !strings.Contains(m.View(), "no active calls") {
+		t.Errorf("empty call list not explained")
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	m := loaded(t, Sources{})
+	m, _ = step(t, m, liveMsg{err: engineapi.ErrUnsupported, at: time.Now()})
+	if !strings.Contains(m.View(), "older release") {
+		t.Errorf("unsupported engine not explained:\n%s", m.View())
+	}
+	m, _ = step(t, m, liveMsg{err: errors.New("connection refused"), at: time.Now()})
+	if !strings.Contains(m.View(), "engine status unavailable: connection refused") {
+		t.Errorf("engine error not shown:\n%s", m.View())
+	}
+}
+
+func TestLogPaneKeepsTheNewestLines(t *testing.T) {
+	m := loaded(t, Sources{})
+	for i := 0; i < maxLogLines+10; i++ {
+		m, _ = step(t, m, LogLine(fmt.Sprintf("line %d", i)))
+	}
+	if len(m.logs) != maxLogLines {
+		t.Fatalf("kept %d lines", len(m.logs))
+	}
+	view := m.View()
+	if !strings.Contains(view, fmt.Sprintf("line %d", maxLogLines+9)) || strings.Contains(view, "line 0\n") {
+		t.Errorf("log pane does not show the tail:\n%s", view)
+	}
+}
+
+func TestStageTrack(t *testing.T) {
+	for state, want := range map[string]string{
+		"greeting":   "● greeting › ○ listening › ○ processing",
+		"processing": "✓ greeting › ✓ listening › ● processing",
+		"transfer":   "transfer",
+		"":           "-",
+	} {
+		if got := stageTrack(state); got != want {
+			t.Errorf("stageTrack(%q) = %q, want %q", state, got, want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Package webhook posts call dispositions to CRM and other HTTP endpoints.
+// agent watch sends one when a call ends on the engine: who called, how long
+// the call lasted, how it ended, a summary of the conversation and its
+// quality score.
+//
+// The endpoints are configured in .agent/webhooks.yaml. Each request is
+// signed with HMAC-SHA256 when the endpoint has a secret, and retried with
+// backoff when the endpoint is unreachable or answers 408, 429 or 5xx.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Transcript modes: what of the conversation an endpoint receives.
+const (
+	TranscriptNone    = "none"    // no conversation text
+	TranscriptSummary = "summary" // the LLM's summary of the conversation
+	TranscriptFull    = "full"    // the summary and every caller and agent message
+)
+
+// Request headers. The signature covers "<timestamp>.<body>", so a receiver
+// can reject replayed requests by their timestamp.
+const (
+	HeaderEvent     = "X-AAVA-Event"
+	HeaderDelivery  = "X-AAVA-Delivery" // the same on every retry of a delivery
+	HeaderTimestamp = "X-AAVA-Timestamp"
+	HeaderSignature = "X-AAVA-Signature" // sha256=<hex HMAC>
+)
+
+// EventCallCompleted is the event of a call disposition.
+const EventCallCompleted = "call.completed"
+
+// retryDelay is the wait before the first retry; it doubles on each one.
+var retryDelay = 2 * time.Second
+
+// Endpoint is one receiver of the dispositions.
+type Endpoint struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// SecretEnv names the environment variable (or .env key) holding the
+	// HMAC key, so the secret stays out of the file.
+	SecretEnv string `yaml:"secret_env"`
+	// Headers are added to each request; ${VAR} in a value is replaced from
+	// the environment, such as an API key.
+	Headers    map[string]string `yaml:"headers"`
+	Retries    int               `yaml:"retries"`
+	Timeout    time.Duration     `yaml:"timeout"`
+	Transcript string            `yaml:"transcript"`
+}
+
+// Config is .agent/webhooks.yaml.
+type Config struct {
+	Endpoints []Endpoint `yaml:"endpoints"`
+}
+
+// Path returns the configuration file.
+func Path() string {
+	if p := strings.TrimSpace(os.Getenv("AAVA_WEBHOOKS_FILE")); p != "" {
+		return p
+	}
+	return filepath.Join(".agent", "webhooks.yaml")
+}
+
+// Load reads and checks the configuration. A missing file returns nil and no
+// error: dispositions are not sent.
+func Load(file string) (*Config, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(raw, cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Endpoints) == 0 {
+		return errors.New("no endpoints")
+	}
+	names := map[string]bool{}
+	for i := range c.Endpoints {
+		e := &c.Endpoints[i]
+		if e.Name == "" {
+			e.Name = fmt.Sprintf("endpoint%d", i+1)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("endpoints: %s is listed twice", e.Name)
+		}
+		names[e.Name] = true
+		u, err := url.Parse(e.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("endpoints: %s: url %q is not an http(s) URL", e.Name, e.URL)
+		}
+		if e.SecretEnv != "" && os.Getenv(e.SecretEnv) == "" {
+			return fmt.Errorf("endpoints: %s: secret_env %s is not set", e.Name, e.SecretEnv)
+		}
+		switch {
+		case e.Retries < 0 || e.Retries > 10:
+			return fmt.Errorf("endpoints: %s: retries must be from 0 to 10", e.Name)
+		case e.Retries == 0:
+			e.Retries = 3
+		}
+		switch {
+		case e.Timeout < 0:
+			return fmt.Errorf("endpoints: %s: timeout cannot be negative", e.Name)
+		case e.Timeout == 0:
+			e.Timeout = 10 * time.Second
+		}
+		switch e.Transcript {
+		case "":
+			e.Transcript = TranscriptSummary
+		case TranscriptNone, TranscriptSummary, TranscriptFull:
+		default:
+			return fmt.Errorf("endpoints: %s: transcript %q is not %s, %s or %s", e.Name, e.Transcript, TranscriptNone, TranscriptSummary, TranscriptFull)
+		}
+	}
+	return nil
+}
+
+// Wants reports whether any endpoint receives the given transcript mode, or
+// a fuller one.
+func (c *Config) Wants(mode string) bool {
+	for _, e := range c.Endpoints {
+		if e.Transcript == TranscriptFull || e.Transcript == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign is the signature header value of body sent at timestamp.
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Result is the outcome of one delivery.
+type Result struct {
+	Endpoint string `json:"endpoint"`
+	Status   int    `json:"status,omitempty"` // last HTTP status
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Deliver posts body to the endpoint as event, retrying failures the
+// endpoint may recover from. id identifies the delivery to the receiver.
+func (e Endpoint) Deliver(ctx context.Context, event, id string, body []byte) Result {
+	res := Result{Endpoint: e.Name}
+	client := &http.Client{Timeout: e.Timeout}
+	delay := retryDelay
+	for {
+		res.Attempts++
+		status, retry, err := e.post(ctx, client, event, id, body)
+		res.Status = status
+		if err == nil {
+			res.Error = ""
+			return res
+		}
+		res.Error = err.Error()
+		if !retry || res.Attempts > e.Retries {
+			return res
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			res.Error = ctx.Err().Error()
+			return res
+		}
+		delay *= 2
+	}
+}
+
+// post sends one request; retry says whether a failure is worth retrying.
+// The URL may hold a token, so it is kept out of the errors.
+func (e Endpoint) post(ctx context.Context, client *http.Client, event, id string, body []byte) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, errors.New("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aava-agent")
+	for k, v := range e.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+	ts := time.Now().Unix()
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, id)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	if e.SecretEnv != "" {
+		req.Header.Set(HeaderSignature, Sign([]byte(os.Getenv(e.SecretEnv)), ts, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return 0, ctx.Err() == nil, fmt.Errorf("post to %s: %w", e.Name, err)
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	retry := resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	err = fmt.Errorf("%s answered HTTP %d", e.Name, resp.StatusCode)
+	if s := strings.TrimSpace(string(snippet)); s != "" {
+		err = fmt.Errorf("%w: %s", err, s)
+	}
+	return resp.StatusCode, retry, err
+}
@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("CRM_SECRET", "s3cret")
+	dir := t.TempDir()
+	write := func(body string) string {
+		p := filepath.Join(dir, "webhooks.yaml")
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	if cfg, err := Load(filepath.Join(dir, "missing.yaml")); cfg != nil || err != nil {
+		t.Fatalf("missing file = %v, %v", cfg, err)
+	}
+	cfg, err := Load(write("endpoints:\n  - name: crm\n    url: https://crm.example.com/hooks\n    secret_env: CRM_SECRET\n  - url: http://127.0.0.1:9000/calls\n    transcript: full\n    retries: 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crm, local := cfg.Endpoints[0], cfg.Endpoints[1]
+	if crm.Retries != 3 || crm.Timeout != 10*time.Second || crm.Transcript != TranscriptSummary {
+		t.Errorf("defaults = %+v", crm)
+	}
+	if local.Name != "endpoint2" || local.Retries != 1 || !cfg.Wants(TranscriptFull) || !cfg.Wants(TranscriptSummary) {
+		t.Errorf("second endpoint = %+v", local)
+	}
+
+	for body, want := range map[string]string{
+		"endpoints: []\n": "no endpoints",
+		"endpoints:\n  - url: ftp://crm.example.com\n":                                                           "not an http(s) URL",
+		"endpoints:\n  - url: https://a.example.com\n    secret_env: CRM_MISSING\n":                              "CRM_MISSING is not set",
+		"endpoints:\n  - url: https://a.example.com\n    transcript: words\n":                                    `transcript "words"`,
+		"endpoints:\n  - name: a\n    url: https://a.example.com\n  - name: a\n    url: https://b.example.com\n": "listed twice",
+	} {
+		if _, err := Load(write(body)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(%q) = %v, want %q", body, err, want)
+		}
+	}
+}
+
+func TestDeliverRetriesAndSigns(t *testing.T) {
+	retryDelay = time.Millisecond
+	t.Setenv("CRM_SECRET", "s3cret")
+	t.Setenv("CRM_TOKEN", "tok")
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if r.Header.Get(HeaderSignature) != Sign([]byte("s3cret"), ts, body) {
+			t.Errorf("bad signature %q", r.Header.Get(HeaderSignature))
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" || r.Header.Get(HeaderDelivery) != "1.1" || r.Header.Get(HeaderEvent) != EventCallCompleted {
+			t.Errorf("headers = %v", r.Header)
+		}
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	e := Endpoint{Name: "crm", URL: srv.URL, SecretEnv: "CRM_SECRET", Headers: map[string]string{"Authorization": "Bearer ${CRM_TOKEN}"}, Retries: 3, Timeout: time.Second}
+	res := e.Deliver(context.Background(), EventCallCompleted, "1.1", []byte(`{"call_id":"1.1"}`))
+	if res.Error != "" || res.Attempts != 2 || res.Status != http.StatusAccepted {
+		t.Fatalf("Deliver = %+v", res)
+	}
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	retryDelay = time.Millisecond
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "unknown field", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	e := Endpoint{Name: "crm", URL: srv.URL, Retries: 3, Timeout: time.Second}
+	res := e.Deliver(context.Background(), EventCallCompleted, "1.1", []byte(`{}`))
+	if calls != 1 || res.Attempts != 1 || res.Status != http.StatusBadRequest || !strings.Contains(res.Error, "HTTP 400: unknown field") {
+		t.Fatalf("Deliver = %+v after %d call(s)", res, calls)
+	}
+}
@@ -0,0 +1,238 @@
+package wizard
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Answers are the wizard inputs for a headless `agent init --non-interactive`.
+// The file may be YAML or JSON; values may reference the environment as ${VAR}.
+type Answers struct {
+	Asterisk struct {
+		Host        string `yaml:"host" json:"host"`
+		ARIPort     string `yaml:"ari_port" json:"ari_port"`
+		ARIScheme   string `yaml:"ari_scheme" json:"ari_scheme"`
+		ARIUsername string `yaml:"ari_username" json:"ari_username"`
+		ARIPassword string `yaml:"ari_password" json:"ari_password"`
+	} `yaml:"asterisk" json:"asterisk"`
+	Transport       string `yaml:"transport" json:"transport"` // audiosocket | externalmedia
+	AudioSocketPort string `yaml:"audiosocket_port" json:"audiosocket_port"`
+	// Preset applies a curated provider setup (see Presets); Pipeline and
+	// Provider then must be empty.
+	Preset   string `yaml:"preset" json:"preset"`
+	Pipeline string `yaml:"pipeline" json:"pipeline"`
+	Provider string `yaml:"provider" json:"provider"`
+	// Keys maps env-var names (OPENAI_API_KEY, ...) to values.
+	Keys map[string]string `yaml:"keys" json:"keys"`
+	// Start runs docker compose up after writing the configuration.
+	Start bool `yaml:"start" json:"start"`
+	// SkipValidation writes the configuration without the live ARI and API key tests.
+	SkipValidation bool `yaml:"skip_validation" json:"skip_validation"`
+}
+
+// LoadAnswers reads an answers file. An empty path yields empty answers, so the
+// run is driven by the environment alone.
+func LoadAnswers(path string) (*Answers, error) {
+	a := &Answers{}
+	if path == "" {
+		return a, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, so one decoder serves both formats.
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), a); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+// applyEnv fills answers left empty from the same variables .env uses, so
+// provisioning tools can pass secrets through the environment instead of a file.
+func (a *Answers) applyEnv() {
+	fill := func(dst *string, key string) {
+		if *dst == "" {
+			*dst = strings.TrimSpace(os.Getenv(key))
+		}
+	}
+	fill(&a.Asterisk.Host, "ASTERISK_HOST")
+	fill(&a.Asterisk.ARIPort, "ASTERISK_ARI_PORT")
+	fill(&a.Asterisk.ARIScheme, "ASTERISK_ARI_SCHEME")
+	fill(&a.Asterisk.ARIUsername, "ASTERISK_ARI_USERNAME")
+	fill(&a.Asterisk.ARIPassword, "ASTERISK_ARI_PASSWORD")
+	fill(&a.Transport, "AUDIO_TRANSPORT")
+	fill(&a.AudioSocketPort, "AUDIOSOCKET_PORT")
+	if a.Keys == nil {
+		a.Keys = map[string]string{}
+	}
+	for key := range keySpecs {
+		if a.Keys[key] == "" {
+			if v := os.Getenv(key); v != "" {
+				a.Keys[key] = v
+			}
+		}
+	}
+}
+
+// apply copies the answers onto cfg, keeping current values for empty answers.
+func (a *Answers) apply(cfg *Config) error {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&cfg.AsteriskHost, a.Asterisk.Host)
+	set(&cfg.AsteriskARIPort, a.Asterisk.ARIPort)
+	set(&cfg.AsteriskARIScheme, a.Asterisk.ARIScheme)
+	set(&cfg.AsteriskUsername, a.Asterisk.ARIUsername)
+	set(&cfg.AsteriskPassword, a.Asterisk.ARIPassword)
+
+	// The preset goes first so an explicit transport answer still wins.
+	if a.Preset != "" {
+		if a.Pipeline != "" || a.Provider != "" {
+			return fmt.Errorf("preset cannot be combined with pipeline or provider")
+		}
+		p, err := FindPreset(a.Preset)
+		if err != nil {
+			return err
+		}
+		cfg.ApplyPreset(p)
+	}
+
+	switch t := strings.ToLower(a.Transport); t {
+	case "":
+	case "audiosocket", "externalmedia":
+		cfg.AudioTransport = t
+	default:
+		return fmt.Errorf("transport %q: must be audiosocket or externalmedia", a.Transport)
+	}
+	set(&cfg.AudioSocketPort, a.AudioSocketPort)
+	if cfg.AudioTransport == "audiosocket" && cfg.AudioSocketPort == "" {
+		cfg.AudioSocketPort = "8090"
+	}
+
+	switch {
+	case a.Pipeline != "":
+		if !contains(cfg.AvailablePipelines, a.Pipeline) {
+			return fmt.Errorf("pipeline %q is not defined (available: %s)", a.Pipeline, strings.Join(cfg.AvailablePipelines, ", "))
+		}
+		cfg.ActivePipeline = a.Pipeline
+		cfg.DefaultProvider = a.Pipeline
+	case a.Provider != "":
+		if !contains(cfg.AvailableProviders, a.Provider) {
+			return fmt.Errorf("provider %q is not defined (available: %s)", a.Provider, strings.Join(cfg.AvailableProviders, ", "))
+		}
+		cfg.ActivePipeline = ""
+		cfg.DefaultProvider = a.Provider
+	}
+
+	for key, v := range a.Keys {
+		if v != "" {
+			cfg.SetKey(key, v)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RunNonInteractive performs agent init from answers without prompting. It prints
+// the same step results as the interactive wizard and fails on ARI or API key
+// validation errors unless SkipValidation is set.
+func RunNonInteractive(a *Answers) error {
+	fmt.Println()
+	fmt.Println("🚀 Asterisk AI Voice Agent - Setup (non-interactive)")
+	fmt.Println("══════════════════════════════════════════")
+
+	created, err := EnsureBaseYAML()
+	if err != nil {
+		return err
+	}
+	if created {
+		PrintSuccess("Created config/ai-agent.yaml from config/ai-agent.example.yaml")
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	a.applyEnv()
+	if err := a.apply(cfg); err != nil {
+		return err
+	}
+
+	var failed []string
+	PrintStep(1, 3, "Validation")
+	if cfg.AsteriskHost == "" || cfg.AsteriskUsername == "" {
+		PrintError("ARI host and username are required (asterisk.host, asterisk.ari_username)")
+		failed = append(failed, "ari")
+	} else if a.SkipValidation {
+		PrintInfo("Skipping ARI test (skip_validation)")
+	} else if err := TestARIConnectivity(cfg.ARIBaseURL(), cfg.AsteriskUsername, cfg.AsteriskPassword); err != nil {
+		PrintError(fmt.Sprintf("ARI test failed: %v", err))
+		failed = append(failed, "ari")
+	} else {
+		PrintSuccess(fmt.Sprintf("ARI accessible at %s", cfg.ARIBaseURL()))
+	}
+
+	required := cfg.RequiredEnvKeys()
+	for _, envVar := range required {
+		spec, known := keySpecs[envVar]
+		if !known {
+			spec = keySpec{Label: envVar}
+		}
+		value := cfg.GetKey(envVar)
+		switch {
+		case value == "":
+			PrintError(fmt.Sprintf("%s missing (keys.%s)", spec.Label, envVar))
+			failed = append(failed, envVar)
+		case spec.Validate == nil || a.SkipValidation:
+			PrintInfo(fmt.Sprintf("%s: %s (not tested)", spec.Label, GetMaskedKey(value)))
+		default:
+			if err := spec.Validate(value); err != nil {
+				PrintError(fmt.Sprintf("%s test failed: %v", spec.Label, err))
+				failed = append(failed, envVar)
+			} else {
+				PrintSuccess(fmt.Sprintf("%s valid", spec.Label))
+			}
+		}
+	}
+	if len(required) == 0 {
+		PrintInfo("No cloud API keys required for this pipeline.")
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("validation failed: %s; .env and config were not updated", strings.Join(failed, ", "))
+	}
+
+	PrintStep(2, 3, "Apply")
+	if err := cfg.SaveEnv(); err != nil {
+		return fmt.Errorf("failed to save .env: %w", err)
+	}
+	PrintSuccess("Updated " + cfg.EnvPath)
+	if err := cfg.SaveYAML(""); err != nil {
+		return fmt.Errorf("failed to update YAML: %w", err)
+	}
+	PrintSuccess("Updated config/ai-agent.local.yaml")
+
+	PrintStep(3, 3, "Start")
+	if !a.Start {
+		PrintInfo("Not starting containers (set start: true to run docker compose up)")
+		return nil
+	}
+	if err := TestDockerRunning(); err != nil {
+		return err
+	}
+	return StartStack(cfg.DefaultProvider)
+}
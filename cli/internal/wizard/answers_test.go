@@ -0,0 +1,65 @@
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const answersBase = `default_provider: openai_realtime
+pipelines:
+  local_hybrid: {stt: local_stt, llm: openai_llm, tts: local_tts}
+providers:
+  openai_realtime: {type: full, capabilities: [stt, llm, tts]}
+`
+
+func TestRunNonInteractiveWritesEnvAndOverride(t *testing.T) {
+	withTempProject(t, answersBase, "", func() {
+		t.Setenv("TEST_ARI_SECRET", "from-env")
+		path := filepath.Join(t.TempDir(), "answers.json")
+		answers := `{"asterisk": {"host": "10.0.0.5", "ari_username": "aava", "ari_password": "${TEST_ARI_SECRET}"},
+ "transport": "externalmedia", "pipeline": "local_hybrid",
+ "keys": {"OPENAI_API_KEY": "sk-test"}, "skip_validation": true}`
+		if err := os.WriteFile(path, []byte(answers), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		a, err := LoadAnswers(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := RunNonInteractive(a); err != nil {
+			t.Fatalf("RunNonInteractive: %v", err)
+		}
+		env, _ := os.ReadFile(".env")
+		for _, want := range []string{"ASTERISK_HOST=10.0.0.5", "ASTERISK_ARI_PASSWORD=from-env", "AUDIO_TRANSPORT=externalmedia", "OPENAI_API_KEY=sk-test"} {
+			if !strings.Contains(string(env), want) {
+				t.Errorf(".env missing %q:\n%s", want, env)
+			}
+		}
+		local, _ := os.ReadFile(filepath.Join("config", "ai-agent.local.yaml"))
+		if !strings.Contains(string(local), "active_pipeline: local_hybrid") {
+			t.Errorf("local override = %s", local)
+		}
+	})
+}
+
+func TestRunNonInteractiveRejectsMissingKeyAndUnknownTarget(t *testing.T) {
+	withTempProject(t, answersBase, "", func() {
+		t.Setenv("OPENAI_API_KEY", "")
+		a := &Answers{Provider: "openai_realtime", SkipValidation: true}
+		a.Asterisk.ARIUsername = "aava"
+		err := RunNonInteractive(a)
+		if err == nil || !strings.Contains(err.Error(), "OPENAI_API_KEY") {
+			t.Fatalf("err = %v", err)
+		}
+		if env, _ := os.ReadFile(".env"); strings.Contains(string(env), "ASTERISK_ARI_USERNAME") {
+			t.Fatalf(".env updated despite validation failure:\n%s", env)
+		}
+
+		a = &Answers{Pipeline: "nope"}
+		if err := RunNonInteractive(a); err == nil || !strings.Contains(err.Error(), `pipeline "nope"`) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package wizard
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+)
+
+// ARIUser is an ARI account found in Asterisk's configuration.
+type ARIUser struct {
+	Username string
+	Password string
+	Source   string
+}
+
+// ariConfigPaths are read in order; FreePBX keeps the users it manages in
+// ari_additional.conf and leaves ari.conf as an #include shell.
+var ariConfigPaths = []string{
+	"/etc/asterisk/ari_additional.conf",
+	"/etc/asterisk/ari.conf",
+	"/etc/asterisk/ari_general_custom.conf",
+}
+
+// httpConfigPaths hold the http.conf bindport ARI is served on.
+var httpConfigPaths = []string{
+	"/etc/asterisk/http_additional.conf",
+	"/etc/asterisk/http.conf",
+	"/etc/asterisk/http_custom.conf",
+}
+
+// DetectARIUsers lists ARI users with plain-text passwords from the local Asterisk
+// configuration. Crypt passwords cannot be reused and are skipped.
+func DetectARIUsers() []ARIUser {
+	var users []ARIUser
+	seen := map[string]bool{}
+	for _, path := range ariConfigPaths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		for _, u := range parseARIUsers(f) {
+			if seen[u.Username] {
+				continue
+			}
+			seen[u.Username] = true
+			u.Source = path
+			users = append(users, u)
+		}
+		f.Close()
+	}
+	return users
+}
+
+// DetectARIPort returns the http.conf bindport, or "" when it cannot be read.
+func DetectARIPort() string {
+	for _, path := range httpConfigPaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		sections := asterisk.ParseConf(string(data))
+		if port := sections["general"]["bindport"]; port != "" {
+			return port
+		}
+	}
+	return ""
+}
+
+func parseARIUsers(r io.Reader) []ARIUser {
+	var users []ARIUser
+	data, _ := io.ReadAll(r)
+	for name, kv := range asterisk.ParseConf(string(data)) {
+		if kv["type"] != "user" || kv["password"] == "" {
+			continue
+		}
+		if f := strings.ToLower(kv["password_format"]); f != "" && f != "plain" {
+			continue
+		}
+		users = append(users, ARIUser{Username: name, Password: kv["password"]})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
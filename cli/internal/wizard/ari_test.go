@@ -0,0 +1,70 @@
+package wizard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseARIUsersSkipsCryptAndNonUsers(t *testing.T) {
+	conf := `; FreePBX managed
+[general]
+enabled = yes
+
+[AsteriskAIVoiceAgent]
+type = user
+read_only = no
+password = s3cret ; plain
+
+[hashed](+)
+type = user
+password = $6$abc
+password_format = crypt
+
+[other]
+password = nope
+`
+	users := parseARIUsers(strings.NewReader(conf))
+	if len(users) != 1 || users[0].Username != "AsteriskAIVoiceAgent" || users[0].Password != "s3cret" {
+		t.Fatalf("users = %+v", users)
+	}
+}
+
+func TestARIBaseURLDefaults(t *testing.T) {
+	c := &Config{AsteriskHost: "pbx.local"}
+	if got := c.ARIBaseURL(); got != "http://pbx.local:8088" {
+		t.Fatalf("ARIBaseURL = %q", got)
+	}
+	c.AsteriskARIScheme, c.AsteriskARIPort = "https", "8089"
+	if got := c.ARIBaseURL(); got != "https://pbx.local:8089" {
+		t.Fatalf("ARIBaseURL = %q", got)
+	}
+}
+
+func TestEnsureBaseYAMLCopiesExample(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config", "ai-agent.example.yaml"), []byte("default_provider: openai_realtime\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(old) })
+
+	created, err := EnsureBaseYAML()
+	if err != nil || !created {
+		t.Fatalf("EnsureBaseYAML = %v, %v", created, err)
+	}
+	if created, _ := EnsureBaseYAML(); created {
+		t.Fatal("second call recreated existing config")
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "config", "ai-agent.yaml"))
+	if string(data) != "default_provider: openai_realtime\n" {
+		t.Fatalf("ai-agent.yaml = %q", data)
+	}
+}
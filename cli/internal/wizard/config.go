@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
 	"gopkg.in/yaml.v3"
 )
 
@@ -54,12 +57,15 @@ type Config struct {
 	AsteriskHost     string
 	AsteriskUsername string
 	AsteriskPassword string
-	AudioTransport   string
-	AudioSocketHost  string
-	AudioSocketPort  string
-	OpenAIKey        string
-	DeepgramKey      string
-	AnthropicKey     string
+	// AsteriskARIPort and AsteriskARIScheme default to 8088 and http when empty.
+	AsteriskARIPort   string
+	AsteriskARIScheme string
+	AudioTransport    string
+	AudioSocketHost   string
+	AudioSocketPort   string
+	OpenAIKey         string
+	DeepgramKey       string
+	AnthropicKey      string
 	// Keys holds additional provider API keys keyed by env-var name.
 	Keys map[string]string
 
@@ -74,6 +80,9 @@ type Config struct {
 	// File paths
 	EnvPath  string
 	YAMLPath string
+
+	// preset is merged into the local override by SaveYAML (see ApplyPreset).
+	preset *Preset
 }
 
 // extraEnvKeys is the list of provider API key env vars beyond the three
@@ -157,6 +166,18 @@ func (c *Config) RequiredEnvKeys() []string {
 	return keys
 }
 
+// ProviderEnvKeys returns the env vars a full-agent provider or a pipeline
+// adapter needs, for the names the tables above know.
+func ProviderEnvKeys(name string) []string {
+	if envVars, mapped := providerEnvKey[name]; mapped {
+		return append([]string(nil), envVars...)
+	}
+	if envVar, mapped := adapterEnvKey[name]; mapped {
+		return []string{envVar}
+	}
+	return nil
+}
+
 // LoadConfig reads current configuration from .env and YAML
 func LoadConfig() (*Config, error) {
 	// Try to find .env - check current dir and parent dir
@@ -191,6 +212,7 @@ func LoadConfig() (*Config, error) {
 	if err := cfg.loadEnv(); err != nil {
 		return nil, fmt.Errorf("failed to load .env: %w", err)
 	}
+	cfg.loadStoredKeys()
 
 	// Load YAML
 	if err := cfg.loadYAML(); err != nil {
@@ -322,6 +344,10 @@ func (c *Config) loadEnv() error {
 			c.AsteriskUsername = value
 		case "ASTERISK_ARI_PASSWORD":
 			c.AsteriskPassword = value
+		case "ASTERISK_ARI_PORT":
+			c.AsteriskARIPort = value
+		case "ASTERISK_ARI_SCHEME":
+			c.AsteriskARIScheme = value
 		case "AUDIO_TRANSPORT":
 			c.AudioTransport = value
 		case "AUDIOSOCKET_HOST":
@@ -351,6 +377,19 @@ func (c *Config) loadEnv() error {
 	return scanner.Err()
 }
 
+// loadStoredKeys fills provider keys that .env lacks from the secrets store
+// (OS keyring or age-encrypted file), when one is configured.
+func (c *Config) loadStoredKeys() {
+	for _, name := range secrets.Names {
+		if c.GetKey(name) != "" {
+			continue
+		}
+		if v, _ := secrets.Stored(name); v != "" {
+			c.SetKey(name, v)
+		}
+	}
+}
+
 // createEnvFromExample creates .env from .env.example
 func (c *Config) createEnvFromExample() error {
 	input, err := os.ReadFile(".env.example")
@@ -417,6 +456,8 @@ func (c *Config) SaveEnv() error {
 		"ASTERISK_HOST":         c.AsteriskHost,
 		"ASTERISK_ARI_USERNAME": c.AsteriskUsername,
 		"ASTERISK_ARI_PASSWORD": c.AsteriskPassword,
+		"ASTERISK_ARI_PORT":     c.AsteriskARIPort,
+		"ASTERISK_ARI_SCHEME":   c.AsteriskARIScheme,
 		"AUDIO_TRANSPORT":       c.AudioTransport,
 		"AUDIOSOCKET_HOST":      c.AudioSocketHost,
 		"AUDIOSOCKET_PORT":      c.AudioSocketPort,
@@ -430,6 +471,38 @@ func (c *Config) SaveEnv() error {
 		}
 	}
 
+	// With a secrets store configured, provider keys go there and leave .env.
+	store, err := secrets.Store()
+	if err != nil {
+		return err
+	}
+	if store != secrets.StoreEnv {
+		stored := 0
+		for _, name := range secrets.Names {
+			if v := updates[name]; v != "" {
+				if cur, _ := secrets.Stored(name); cur != v {
+					if err := secrets.Set(name, v); err != nil {
+						return fmt.Errorf("store %s in the %s: %w", name, secrets.Label(store), err)
+					}
+				}
+				stored++
+			}
+			delete(updates, name)
+		}
+		kept := lines[:0]
+		for _, line := range lines {
+			key, _, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if ok && secrets.IsName(strings.TrimSpace(key)) {
+				continue
+			}
+			kept = append(kept, line)
+		}
+		lines = kept
+		if stored > 0 {
+			PrintInfo(fmt.Sprintf("%d provider key(s) kept in the %s, not in .env", stored, secrets.Label(store)))
+		}
+	}
+
 	// Apply updates
 	for key, value := range updates {
 		if value == "" {
@@ -478,6 +551,10 @@ func (c *Config) SaveYAML(template string) error {
 		}
 	}
 
+	if c.preset != nil {
+		yamlData = configmerge.DeepMerge(yamlData, c.preset.overlay())
+	}
+
 	// Always write active_pipeline, including null when switching from a
 	// pipeline to a full-agent provider. Leaving the previous override behind
 	// silently routed calls through the wrong engine path.
@@ -499,6 +576,40 @@ func (c *Config) SaveYAML(template string) error {
 	return os.WriteFile(localPath, output, 0644)
 }
 
+// ARIBaseURL is the ARI endpoint the engine will use, e.g. http://127.0.0.1:8088.
+func (c *Config) ARIBaseURL() string {
+	scheme := c.AsteriskARIScheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	port := c.AsteriskARIPort
+	if port == "" {
+		port = "8088"
+	}
+	return fmt.Sprintf("%s://%s:%s", scheme, c.AsteriskHost, port)
+}
+
+// EnsureBaseYAML creates config/ai-agent.yaml from config/ai-agent.example.yaml on
+// a fresh checkout. It reports whether the file was created.
+func EnsureBaseYAML() (bool, error) {
+	dir := "config"
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		dir = "../config"
+	}
+	base := filepath.Join(dir, "ai-agent.yaml")
+	if _, err := os.Stat(base); err == nil {
+		return false, nil
+	}
+	input, err := os.ReadFile(filepath.Join(dir, "ai-agent.example.yaml"))
+	if err != nil {
+		return false, fmt.Errorf("no %s and no template to create it from: %w", base, err)
+	}
+	if err := os.WriteFile(base, input, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // GetMaskedKey returns masked version of API key for display
 func GetMaskedKey(key string) string {
 	if key == "" {
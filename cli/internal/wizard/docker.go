@@ -1,9 +1,14 @@
 package wizard
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/secrets"
 )
 
 // RebuildContainers rebuilds and recreates containers
@@ -13,7 +18,7 @@ func RebuildContainers(pipeline string) error {
 
 	// Add local-ai-server if using local models
 	if strings.Contains(pipeline, "local") {
-		if TestContainerExists("local_ai_server") {
+		if TestContainerExists(deployment.LocalAIContainer()) {
 			containers = append(containers, "local_ai_server")
 		}
 	}
@@ -23,14 +28,15 @@ func RebuildContainers(pipeline string) error {
 	for _, container := range containers {
 		// Build
 		PrintInfo(fmt.Sprintf("Building %s...", container))
-		buildCmd := exec.Command("docker", "compose", "-p", "asterisk-ai-voice-agent", "build", container)
+		buildCmd := exec.Command("docker", "compose", "-p", deployment.ComposeProject(), "build", container)
 		if output, err := buildCmd.CombinedOutput(); err != nil {
 			return fmt.Errorf("build failed for %s: %w\n%s", container, err, string(output))
 		}
 
 		// Force recreate
 		PrintInfo(fmt.Sprintf("Recreating %s...", container))
-		upCmd := exec.Command("docker", "compose", "-p", "asterisk-ai-voice-agent", "up", "-d", "--force-recreate", container)
+		upCmd := exec.Command("docker", "compose", "-p", deployment.ComposeProject(), "up", "-d", "--force-recreate", container)
+		upCmd.Env = secrets.Environ()
 		if output, err := upCmd.CombinedOutput(); err != nil {
 			return fmt.Errorf("recreate failed for %s: %w\n%s", container, err, string(output))
 		}
@@ -40,19 +46,40 @@ func RebuildContainers(pipeline string) error {
 	return nil
 }
 
+// StartStack brings up the compose project after a first-run setup. ai_engine is
+// always started; local_ai_server too when the pipeline uses local models.
+func StartStack(pipeline string) error {
+	services := []string{"ai_engine"}
+	if strings.Contains(pipeline, "local") {
+		services = append(services, "local_ai_server")
+	}
+	PrintInfo("Starting " + strings.Join(services, ", ") + " (first start builds images and may take several minutes)...")
+	args := append([]string{"compose", "-p", deployment.ComposeProject(), "up", "-d", "--build"}, services...)
+	upCmd := exec.Command("docker", args...)
+	upCmd.Env = secrets.Environ()
+	if output, err := upCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker compose up failed: %w\n%s", err, string(output))
+	}
+	PrintSuccess("Containers started")
+	return nil
+}
+
 // GetContainerStatus checks if container is running
 func GetContainerStatus(name string) (bool, error) {
-	cmd := exec.Command("docker", "ps", "--format", "{{.Names}}\t{{.Status}}", "--filter", "name="+name)
-	output, err := cmd.Output()
+	out, err := dockerapi.Inspect(name)
+	if dockerapi.IsNotFound(err) {
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
-
-	status := strings.TrimSpace(string(output))
-	if status == "" {
-		return false, nil
+	var cis []struct {
+		State struct {
+			Running bool `json:"Running"`
+		} `json:"State"`
 	}
-
-	// Container exists and is running if output is not empty
-	return strings.Contains(status, "Up"), nil
+	if err := json.Unmarshal(out, &cis); err != nil || len(cis) == 0 {
+		return false, fmt.Errorf("unexpected docker inspect output for %s", name)
+	}
+	return cis[0].State.Running, nil
 }
@@ -0,0 +1,170 @@
+package wizard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+)
+
+// Preset is a curated provider setup. Its overlay sets the transport format, audio
+// profile and provider codec/sample-rate options together, taken from the golden
+// baseline configs, so they cannot drift apart the way hand edits do.
+type Preset struct {
+	Name        string
+	Title       string
+	Description string
+	Overlay     string // merged into config/ai-agent.local.yaml
+}
+
+// Presets are listed in the wizard and by `agent config preset`.
+var Presets = []Preset{
+	{
+		Name:        "openai-realtime",
+		Title:       "OpenAI Realtime",
+		Description: "Full agent over AudioSocket slin; 8 kHz μ-law from Asterisk, 24 kHz PCM to and from OpenAI",
+		Overlay: `
+default_provider: openai_realtime
+audio_transport: audiosocket
+audiosocket:
+  format: slin
+profiles:
+  default: openai_realtime_24k
+providers:
+  openai_realtime:
+    enabled: true
+    base_url: wss://api.openai.com/v1/realtime
+    input_encoding: ulaw
+    input_sample_rate_hz: 8000
+    provider_input_encoding: linear16
+    provider_input_sample_rate_hz: 24000
+    output_encoding: linear16
+    output_sample_rate_hz: 24000
+    target_encoding: mulaw
+    target_sample_rate_hz: 8000
+`,
+	},
+	{
+		Name:        "deepgram-gpt-elevenlabs",
+		Title:       "Deepgram + GPT + ElevenLabs",
+		Description: "Pipeline: Deepgram nova-3 STT, OpenAI gpt-4o-mini, ElevenLabs TTS at ulaw_8000 over AudioSocket ulaw",
+		Overlay: `
+default_provider: deepgram_openai_elevenlabs
+active_pipeline: deepgram_openai_elevenlabs
+audio_transport: audiosocket
+audiosocket:
+  format: ulaw
+profiles:
+  default: telephony_ulaw_8k
+pipelines:
+  deepgram_openai_elevenlabs:
+    stt: deepgram_stt
+    llm: openai_llm
+    tts: elevenlabs_tts
+    options:
+      stt:
+        model: nova-3
+      llm:
+        base_url: https://api.openai.com/v1
+        model: gpt-4o-mini
+        temperature: 0.7
+        max_tokens: 200
+      tts:
+        format:
+          encoding: mulaw
+          sample_rate: 8000
+providers:
+  elevenlabs_tts:
+    enabled: true
+    output_format: ulaw_8000
+`,
+	},
+	{
+		Name:        "local",
+		Title:       "Fully local",
+		Description: "Pipeline: local_ai_server STT, LLM and TTS; 16 kHz PCM to STT, 8 kHz μ-law TTS over AudioSocket ulaw",
+		Overlay: `
+default_provider: local_only
+active_pipeline: local_only
+audio_transport: audiosocket
+audiosocket:
+  format: ulaw
+profiles:
+  default: telephony_ulaw_8k
+pipelines:
+  local_only:
+    stt: local_stt
+    llm: local_llm
+    tts: local_tts
+    options:
+      stt:
+        chunk_ms: 320
+        mode: stt
+        streaming: true
+        stream_format: pcm16_16k
+      llm:
+        temperature: 0.4
+        max_tokens: 64
+      tts:
+        mode: tts
+        format:
+          encoding: mulaw
+          sample_rate: 8000
+providers:
+  local:
+    enabled: true
+`,
+	},
+}
+
+// FindPreset looks a preset up by name.
+func FindPreset(name string) (Preset, error) {
+	for _, p := range Presets {
+		if p.Name == strings.ToLower(strings.TrimSpace(name)) {
+			return p, nil
+		}
+	}
+	names := make([]string, 0, len(Presets))
+	for _, p := range Presets {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return Preset{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+func (p Preset) overlay() map[string]any {
+	m, err := configmerge.ParseYAML([]byte(p.Overlay))
+	if err != nil {
+		panic(fmt.Sprintf("preset %s: %v", p.Name, err)) // presets are compiled in
+	}
+	return m
+}
+
+// ApplyPreset selects the preset's provider or pipeline and transport. The overlay
+// itself is written by SaveYAML.
+func (c *Config) ApplyPreset(p Preset) {
+	o := p.overlay()
+	c.DefaultProvider, _ = o["default_provider"].(string)
+	c.ActivePipeline, _ = o["active_pipeline"].(string)
+	if t, ok := o["audio_transport"].(string); ok {
+		// AUDIO_TRANSPORT in .env overrides the YAML, so keep both in step.
+		c.AudioTransport = t
+	}
+	if c.AudioTransport == "audiosocket" && c.AudioSocketPort == "" {
+		c.AudioSocketPort = "8090"
+	}
+	if pipes, ok := o["pipelines"].(map[string]any); ok {
+		if c.Pipelines == nil {
+			c.Pipelines = make(map[string]PipelineComponents)
+		}
+		for name, raw := range pipes {
+			entry, _ := raw.(map[string]any)
+			stt, _ := entry["stt"].(string)
+			llm, _ := entry["llm"].(string)
+			tts, _ := entry["tts"].(string)
+			c.Pipelines[name] = PipelineComponents{STT: stt, LLM: llm, TTS: tts}
+		}
+	}
+	c.preset = &p
+}
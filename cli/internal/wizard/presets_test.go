@@ -0,0 +1,56 @@
+package wizard
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/configmerge"
+)
+
+func TestPresetsSelectProviderAndTransport(t *testing.T) {
+	for _, p := range Presets {
+		o := p.overlay()
+		if o["default_provider"] == nil || o["audio_transport"] == nil {
+			t.Errorf("%s: overlay lacks default_provider or audio_transport", p.Name)
+		}
+		if pipe, ok := o["active_pipeline"].(string); ok {
+			if _, defined := o["pipelines"].(map[string]any)[pipe]; !defined {
+				t.Errorf("%s: active_pipeline %q not defined in overlay", p.Name, pipe)
+			}
+		}
+	}
+	if _, err := FindPreset("nope"); err == nil {
+		t.Fatal("FindPreset accepted an unknown name")
+	}
+}
+
+func TestApplyPresetMergesIntoLocalOverride(t *testing.T) {
+	withTempProject(t, "default_provider: local_hybrid\n", "tools:\n  transfer: {enabled: true}\n", func() {
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, _ := FindPreset("deepgram-gpt-elevenlabs")
+		cfg.ApplyPreset(p)
+		if got := cfg.RequiredEnvKeys(); !reflect.DeepEqual(got, []string{"DEEPGRAM_API_KEY", "ELEVENLABS_API_KEY", "OPENAI_API_KEY"}) {
+			t.Fatalf("RequiredEnvKeys = %v", got)
+		}
+		if err := cfg.SaveYAML(""); err != nil {
+			t.Fatal(err)
+		}
+		local, err := configmerge.ReadYAMLFile(filepath.Join("config", "ai-agent.local.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if local["active_pipeline"] != "deepgram_openai_elevenlabs" || local["audio_transport"] != "audiosocket" {
+			t.Fatalf("local = %v", local)
+		}
+		if local["tools"] == nil {
+			t.Fatal("existing override keys were dropped")
+		}
+		if cfg.AudioTransport != "audiosocket" {
+			t.Fatalf("AudioTransport = %q", cfg.AudioTransport)
+		}
+	})
+}
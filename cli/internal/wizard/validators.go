@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os/exec"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/dockerapi"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/hostinfo"
 )
 
-// TestARIConnectivity tests Asterisk ARI connection
-func TestARIConnectivity(host, username, password string) error {
-	url := fmt.Sprintf("http://%s:8088/ari/asterisk/info", host)
+// TestARIConnectivity tests Asterisk ARI connection (baseURL as from Config.ARIBaseURL)
+func TestARIConnectivity(baseURL, username, password string) error {
+	url := strings.TrimRight(baseURL, "/") + "/ari/asterisk/info"
 
 	client := &http.Client{Timeout: 5 * time.Second}
 	req, err := http.NewRequest("GET", url, nil)
@@ -27,6 +30,9 @@ func TestARIConnectivity(host, username, password string) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == 401 {
+		return fmt.Errorf("ARI rejected username/password (HTTP 401)")
+	}
 	if resp.StatusCode != 200 {
 		return fmt.Errorf("HTTP %d (expected 200)", resp.StatusCode)
 	}
@@ -34,12 +40,18 @@ func TestARIConnectivity(host, username, password string) error {
 	return nil
 }
 
-// TestAudioSocketPort checks if AudioSocket port is listening
+// TestAudioSocketPort checks if AudioSocket port is listening on the Docker host.
+// Locally that is read from /proc/net (see hostinfo), with a dial as fallback.
 func TestAudioSocketPort(port string) error {
-	cmd := exec.Command("sh", "-c",
-		fmt.Sprintf("netstat -tuln 2>/dev/null | grep :%s || ss -tuln 2>/dev/null | grep :%s", port, port))
-
-	if err := cmd.Run(); err != nil {
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	listening, err := hostinfo.Current().Listening(n)
+	if err != nil {
+		return fmt.Errorf("cannot probe port %s: %w", port, err)
+	}
+	if !listening {
 		return fmt.Errorf("port %s not listening", port)
 	}
 
@@ -131,8 +143,7 @@ func TestAnthropicKey(apiKey string) error {
 
 // TestDockerRunning checks if Docker daemon is running
 func TestDockerRunning() error {
-	cmd := exec.Command("docker", "info")
-	if err := cmd.Run(); err != nil {
+	if _, err := dockerapi.Info(); err != nil {
 		return fmt.Errorf("Docker daemon not running")
 	}
 	return nil
@@ -140,18 +151,6 @@ func TestDockerRunning() error {
 
 // TestContainerExists checks if a container exists
 func TestContainerExists(name string) bool {
-	cmd := exec.Command("docker", "ps", "-a", "--format", "{{.Names}}", "--filter", "name="+name)
-	output, err := cmd.Output()
-	if err != nil {
-		return false
-	}
-
-	containers := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, c := range containers {
-		if c == name {
-			return true
-		}
-	}
-
-	return false
+	_, err := dockerapi.Inspect(name)
+	return err == nil
 }
@@ -3,6 +3,9 @@ package wizard
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/asterisk"
+	"github.com/hkjarral/ava-ai-voice-agent-for-asterisk/cli/internal/deployment"
 )
 
 // Wizard orchestrates the interactive configuration
@@ -10,6 +13,11 @@ type Wizard struct {
 	config     *Config
 	hasChanges bool
 	totalSteps int
+	// firstRun is set by agent init: templates are created when missing, ARI users
+	// are offered from the Asterisk config, and the stack is started at the end.
+	firstRun bool
+	// presetChosen skips mode selection when --preset was given.
+	presetChosen bool
 }
 
 // NewWizard creates a new wizard instance
@@ -26,6 +34,32 @@ func NewWizard() (*Wizard, error) {
 	}, nil
 }
 
+// NewInitWizard creates the first-run wizard used by agent init. It creates
+// config/ai-agent.yaml from the shipped example before loading configuration.
+func NewInitWizard() (*Wizard, error) {
+	created, err := EnsureBaseYAML()
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWizard()
+	if err != nil {
+		return nil, err
+	}
+	w.firstRun = true
+	if created {
+		PrintSuccess("Created config/ai-agent.yaml from config/ai-agent.example.yaml")
+		w.hasChanges = true
+	}
+	return w, nil
+}
+
+// UsePreset applies a preset up front; the mode selection step is then skipped.
+func (w *Wizard) UsePreset(p Preset) {
+	w.config.ApplyPreset(p)
+	w.presetChosen = true
+	w.hasChanges = true
+}
+
 // Run executes the wizard
 func (w *Wizard) Run() error {
 	// Header
@@ -43,7 +77,10 @@ func (w *Wizard) Run() error {
 	}
 
 	// Step 1: Mode selection
-	if err := w.stepModeSelection(); err != nil {
+	if w.presetChosen {
+		PrintStep(1, w.totalSteps, "Mode Selection")
+		PrintInfo(fmt.Sprintf("Using preset (provider: %s)", w.config.DefaultProvider))
+	} else if err := w.stepModeSelection(); err != nil {
 		return err
 	}
 
@@ -91,6 +128,10 @@ func (w *Wizard) stepModeSelection() error {
 
 	options := []string{"Keep current configuration"}
 	targets := []string{"keep:"}
+	for _, p := range Presets {
+		options = append(options, fmt.Sprintf("Preset: %s — %s", p.Title, p.Description))
+		targets = append(targets, "preset:"+p.Name)
+	}
 	for _, name := range w.config.AvailablePipelines {
 		options = append(options, "Pipeline: "+name)
 		targets = append(targets, "pipeline:"+name)
@@ -107,7 +148,10 @@ func (w *Wizard) stepModeSelection() error {
 		return nil
 	}
 	kind, name, _ := strings.Cut(targets[choice], ":")
-	if kind == "pipeline" {
+	if kind == "preset" {
+		p, _ := FindPreset(name)
+		w.config.ApplyPreset(p)
+	} else if kind == "pipeline" {
 		w.config.ActivePipeline = name
 		w.config.DefaultProvider = name
 	} else {
@@ -124,44 +168,83 @@ func (w *Wizard) stepAsteriskConfig() error {
 	PrintStep(2, w.totalSteps, "Asterisk Configuration")
 
 	if w.config.AsteriskHost != "" {
-		PrintInfo(fmt.Sprintf("Current: %s:8088 (user: %s)",
-			w.config.AsteriskHost, w.config.AsteriskUsername))
+		PrintInfo(fmt.Sprintf("Current: %s (user: %s)",
+			w.config.ARIBaseURL(), w.config.AsteriskUsername))
 	}
-	fmt.Println()
-
-	// Prompts
-	newHost := PromptText("Asterisk Host", w.config.AsteriskHost)
-	if newHost != w.config.AsteriskHost {
-		w.config.AsteriskHost = newHost
-		w.hasChanges = true
-	}
-
-	newUser := PromptText("ARI Username", w.config.AsteriskUsername)
-	if newUser != w.config.AsteriskUsername {
-		w.config.AsteriskUsername = newUser
-		w.hasChanges = true
+	if w.firstRun || w.config.AsteriskPassword == "" {
+		w.offerDetectedARI()
 	}
+	fmt.Println()
 
-	newPass := PromptPassword("ARI Password", w.config.AsteriskPassword != "")
-	if newPass != "" && newPass != w.config.AsteriskPassword {
-		w.config.AsteriskPassword = newPass
-		w.hasChanges = true
-	}
+	for {
+		w.promptARI()
 
-	// Test connectivity
-	fmt.Println()
-	PrintInfo("Testing ARI connection...")
-	if err := TestARIConnectivity(w.config.AsteriskHost,
-		w.config.AsteriskUsername, w.config.AsteriskPassword); err != nil {
+		// Test connectivity
+		fmt.Println()
+		PrintInfo("Testing ARI connection...")
+		err := TestARIConnectivity(w.config.ARIBaseURL(),
+			w.config.AsteriskUsername, w.config.AsteriskPassword)
+		if err == nil {
+			PrintSuccess(fmt.Sprintf("ARI accessible at %s", w.config.ARIBaseURL()))
+			return nil
+		}
 		PrintWarning(fmt.Sprintf("ARI test failed: %v", err))
+		if PromptConfirm("Re-enter ARI settings?", true) {
+			continue
+		}
 		if !PromptConfirm("Continue anyway?", false) {
 			return fmt.Errorf("ARI connectivity required")
 		}
-	} else {
-		PrintSuccess(fmt.Sprintf("ARI accessible at %s:8088", w.config.AsteriskHost))
+		return nil
 	}
+}
 
-	return nil
+func (w *Wizard) promptARI() {
+	update := func(dst *string, val string) {
+		if val != *dst {
+			*dst = val
+			w.hasChanges = true
+		}
+	}
+	update(&w.config.AsteriskHost, PromptText("Asterisk Host", w.config.AsteriskHost))
+	port := w.config.AsteriskARIPort
+	if port == "" {
+		port = "8088"
+	}
+	if newPort := PromptText("ARI Port", port); newPort != "8088" || w.config.AsteriskARIPort != "" {
+		update(&w.config.AsteriskARIPort, newPort)
+	}
+	update(&w.config.AsteriskUsername, PromptText("ARI Username", w.config.AsteriskUsername))
+	if newPass := PromptPassword("ARI Password", w.config.AsteriskPassword != ""); newPass != "" {
+		update(&w.config.AsteriskPassword, newPass)
+	}
+}
+
+// offerDetectedARI proposes an ARI user from /etc/asterisk when Asterisk runs on
+// this host, so FreePBX installs do not need the credentials copied by hand.
+func (w *Wizard) offerDetectedARI() {
+	if port := DetectARIPort(); port != "" && w.config.AsteriskARIPort == "" && port != "8088" {
+		PrintInfo(fmt.Sprintf("Detected ARI port %s from http.conf", port))
+		w.config.AsteriskARIPort = port
+		w.hasChanges = true
+	}
+	users := DetectARIUsers()
+	if len(users) == 0 {
+		return
+	}
+	options := make([]string, 0, len(users)+1)
+	for _, u := range users {
+		options = append(options, fmt.Sprintf("%s (from %s)", u.Username, u.Source))
+	}
+	options = append(options, "Enter credentials manually")
+	choice := PromptSelect("ARI users found in the Asterisk configuration:", options, 0)
+	if choice < 0 || choice >= len(users) {
+		return
+	}
+	w.config.AsteriskUsername = users[choice].Username
+	w.config.AsteriskPassword = users[choice].Password
+	w.hasChanges = true
+	PrintSuccess(fmt.Sprintf("Using ARI user %s", users[choice].Username))
 }
 
 // stepAudioTransport handles Step 3: Audio Transport
@@ -354,9 +437,25 @@ func (w *Wizard) stepReviewAndApply() error {
 		}
 	}
 
-	// Rebuild containers
+	// Start or rebuild containers
 	fmt.Println()
-	if PromptConfirm("Rebuild ai_engine container?", true) {
+	if w.firstRun {
+		if PromptConfirm("Start the stack now (docker compose up -d)?", true) {
+			PrintInfo("Checking Docker...")
+			if err := TestDockerRunning(); err != nil {
+				PrintWarning("Docker not running, skipping start")
+			} else {
+				pipeline := w.config.ActivePipeline
+				if pipeline == "" {
+					pipeline = w.config.DefaultProvider
+				}
+				if err := StartStack(pipeline); err != nil {
+					PrintError(fmt.Sprintf("Start failed: %v", err))
+					PrintInfo("Run manually: docker compose -p " + deployment.ComposeProject() + " up -d")
+				}
+			}
+		}
+	} else if PromptConfirm("Rebuild ai_engine container?", true) {
 		PrintInfo("Checking Docker...")
 		if err := TestDockerRunning(); err != nil {
 			PrintWarning("Docker not running, skipping rebuild")
@@ -379,6 +478,13 @@ func (w *Wizard) stepReviewAndApply() error {
 	fmt.Println("═══════════════════════════════════════════")
 	fmt.Println()
 	fmt.Println("Next steps:")
+	if source, ok := asterisk.DetectFreePBX(); ok {
+		fmt.Printf("  • FreePBX detected (%s): paste `agent dialplan` output into\n", source)
+		fmt.Println("    extensions_custom.conf via Admin → Config Edit, then add a Custom Destination")
+		fmt.Println("    (do not edit extensions.conf or *_additional.conf; Apply Config rewrites them)")
+	} else {
+		fmt.Println("  • agent dialplan   (dialplan snippet for extensions_custom.conf)")
+	}
 	fmt.Println("  • agent check      (verify health)")
 	fmt.Println("  • Make a test call")
 	fmt.Println("  • agent rca        (analyze the most recent call)")
@@ -19,6 +19,10 @@ _PROJ_DIR = Path(__file__).parent.parent.parent.resolve()
 # Pattern to match ${VAR:-default} or ${VAR:=default} shell-style syntax
 _ENV_VAR_PATTERN = re.compile(r'\$\{([^}:]+)(:-|:=)?([^}]*)?\}')
 
+# Environment selecting config/overlays/<env>.yaml (see load_yaml_with_local_override)
+CONFIG_ENV_VAR = "AAVA_CONFIG_ENV"
+_CONFIG_ENV_PATTERN = re.compile(r'^[a-z0-9][a-z0-9_-]*$')
+
 
 def _expand_env_vars_with_defaults(text: str) -> str:
     """
@@ -153,6 +157,21 @@ def deep_merge_dicts(base: dict, override: dict) -> dict:
     return merged
 
 
+def config_overlay_path(path: str, env: str) -> str:
+    """
+    Return the overlay file of *env* for the base config at *path*:
+    ``config/ai-agent.yaml`` → ``config/overlays/<env>.yaml``.
+
+    Raises:
+        ValueError: If *env* is not a valid environment name.
+    """
+    if not _CONFIG_ENV_PATTERN.match(env):
+        raise ValueError(
+            f"Invalid {CONFIG_ENV_VAR} {env!r} (use lowercase letters, digits, - and _)"
+        )
+    return os.path.join(os.path.dirname(path), "overlays", f"{env}.yaml")
+
+
 def load_yaml_with_local_override(path: str) -> dict:
     """
     Load the base YAML config and deep-merge an optional local override file.
@@ -160,11 +179,16 @@ def load_yaml_with_local_override(path: str) -> dict:
     Given a base path like ``config/ai-agent.yaml``, this function:
 
     1. Loads and env-expands the base file (required — raises if missing).
-    2. Looks for a sibling ``config/ai-agent.local.yaml``.
-    3. If the local file exists, loads/env-expands it and deep-merges over the base.
+    2. If ``AAVA_CONFIG_ENV`` is set, loads ``config/overlays/<env>.yaml``
+       (required — raises if missing) and deep-merges it over the base.
+    3. Looks for a sibling ``config/ai-agent.local.yaml``.
+    4. If the local file exists, loads/env-expands it and deep-merges over the result.
 
     This allows operators to keep their customisations in a gitignored local
     file while the upstream base stays clean and conflict-free during updates.
+    Overlays carry what differs between environments (dev/staging/prod) and
+    are operator-owned in the same way. ``agent config render`` prints the
+    same merge.
 
     Args:
         path: Absolute path to the base YAML configuration file.
@@ -177,6 +201,17 @@ def load_yaml_with_local_override(path: str) -> dict:
 
     base_data = load_yaml_with_env_expansion(path)
 
+    env = (os.environ.get(CONFIG_ENV_VAR) or "").strip()
+    if env:
+        # A selected environment without its overlay is a config error, not a
+        # reason to run production on the base settings.
+        overlay_path = config_overlay_path(path, env)
+        overlay_data = load_yaml_with_env_expansion(overlay_path)
+        if not isinstance(overlay_data, dict):
+            raise ValueError(f"Config overlay {overlay_path} is not a mapping")
+        logger.info("Merging config environment overlay", env=env, overlay_path=overlay_path)
+        base_data = deep_merge_dicts(base_data, overlay_data)
+
     # Derive the local override path: config/ai-agent.yaml → config/ai-agent.local.yaml
     stem, ext = os.path.splitext(path)
     local_path = f"{stem}.local{ext}"
@@ -698,6 +698,9 @@ class Engine:
         # abandoned-persist path skip it (same mechanism as _seen_aux_channels), while the
         # genuine pre-Stasis INBOUND abandoned case is preserved.
         self._seen_outbound_channels: Set[str] = set()
+        # Set by POST /drain (agent update) before a restart: new inbound callers are
+        # returned to the dialplan while calls already in progress finish normally.
+        self._draining: bool = False
         # Health server runner
         self._health_runner: Optional[web.AppRunner] = None
         # MCP client manager (experimental)
@@ -3784,6 +3787,10 @@ class Engine:
             await self._handle_agent_action_stasis(channel_id, channel, args)
             return
         
+        if self._is_caller_channel(channel) and getattr(self, "_draining", False):
+            await self._decline_call_while_draining(channel_id, channel)
+            return
+
         if self._is_caller_channel(channel):
             # This is the caller channel entering Stasis - MAIN FLOW
             logger.info("🎯 HYBRID ARI - Processing caller channel", channel_id=channel_id)
@@ -3824,6 +3831,33 @@ class Engine:
                           channel_id=channel_id, 
                           channel_name=channel_name)
 
+    async def _decline_call_while_draining(self, channel_id: str, channel: dict) -> None:
+        """Send a new caller on to the next dialplan priority while the engine drains.
+
+        The dialplan decides what happens next (voicemail, a ring group, Hangup), exactly
+        as if Stasis() had returned. If continuing fails the channel is hung up rather
+        than left in an app that will not answer it.
+        """
+        dialplan = channel.get("dialplan") or {}
+        logger.info(
+            "Engine draining - returning new caller to dialplan",
+            channel_id=channel_id,
+            context=dialplan.get("context"),
+            exten=dialplan.get("exten"),
+        )
+        try:
+            ok = await self.ari_client.continue_in_dialplan(
+                channel_id,
+                context=str(dialplan.get("context") or ""),
+                extension=str(dialplan.get("exten") or "s"),
+                priority=int(dialplan.get("priority") or 1) + 1,
+            )
+        except Exception:
+            logger.debug("Continue while draining failed", channel_id=channel_id, exc_info=True)
+            ok = False
+        if not ok:
+            await self.ari_client.hangup_channel(channel_id)
+
     async def _start_external_media_channel(self, caller_channel_id: str) -> Optional[str]:
         """Allocate RTP resources and originate the ExternalMedia channel via ARI."""
         if not self.config.external_media:
@@ -8045,7 +8079,12 @@ class Engine:
                         reason="audiosocket-destroyed-before-stasis",
                     )
             await self._handle_outbound_channel_destroyed(event)
-            logger.info("Channel destroyed", channel_id=channel_id)
+            logger.info(
+                "Channel destroyed",
+                channel_id=channel_id,
+                cause=event.get("cause"),
+                cause_txt=event.get("cause_txt"),
+            )
             await self._cleanup_call(channel_id)
         except Exception as exc:
             logger.error("Error handling ChannelDestroyed", error=str(exc), exc_info=True)
@@ -18453,6 +18492,7 @@ class Engine:
             # (similar to /mcp/status) and should not include secrets or PII.
             app.router.add_get('/tools/definitions', self._tools_definitions_handler)
             app.router.add_get('/sessions/stats', self._sessions_stats_handler)
+            app.router.add_post('/drain', self._drain_handler)
             app.router.add_get('/config/state', self._config_state_handler)
             runner = web.AppRunner(app)
             await runner.setup()
@@ -18557,12 +18597,44 @@ class Engine:
                 status=403
             )
         try:
-            stats = await self.session_store.get_session_stats()
+            stats = dict(await self.session_store.get_session_stats())
+            stats["draining"] = bool(getattr(self, "_draining", False))
             return web.json_response(stats, status=200)
         except Exception as exc:
             logger.debug("Sessions stats handler failed", error=str(exc), exc_info=True)
             return web.json_response({"active_calls": 0, "error": "internal_error"}, status=500)
 
+    async def _drain_handler(self, request):
+        """Stop or resume taking new calls ahead of a restart.
+
+        POST /drain                      -> stop taking new calls
+        POST /drain {"draining": false}  -> resume
+
+        Calls in progress are not touched; poll /sessions/stats for active_calls.
+        A restart clears the flag. SECURITY: Requires localhost or HEALTH_API_TOKEN.
+        """
+        if not self._is_request_authorized(request):
+            return web.json_response(
+                {"draining": False, "error": "Forbidden: requires localhost or valid HEALTH_API_TOKEN"},
+                status=403,
+            )
+        draining = True
+        if request.can_read_body:
+            try:
+                body = await request.json()
+            except Exception:
+                return web.json_response({"error": "invalid JSON body"}, status=400)
+            if isinstance(body, dict) and "draining" in body:
+                draining = bool(body["draining"])
+        self._draining = draining
+        logger.info("Engine drain state changed", draining=draining)
+        try:
+            stats = await self.session_store.get_session_stats()
+            active_calls = int(stats.get("active_calls", 0))
+        except Exception:
+            active_calls = None
+        return web.json_response({"draining": draining, "active_calls": active_calls}, status=200)
+
     async def _mcp_status_handler(self, request):
         """Return MCP server/tool status for Admin UI (sanitized)."""
         try:
@@ -13,7 +13,12 @@ import tempfile
 import yaml
 from pathlib import Path
 
-from src.config.loaders import resolve_config_path, load_yaml_with_env_expansion, deep_merge_dicts
+from src.config.loaders import (
+    resolve_config_path,
+    load_yaml_with_env_expansion,
+    load_yaml_with_local_override,
+    deep_merge_dicts,
+)
 
 
 class TestResolveConfigPath:
@@ -194,3 +199,41 @@ class TestDeepMergeDicts:
         override = {"tools": {"transfer": {"destinations": {"support_queue": None}}}}
         merged = deep_merge_dicts(base, override)
         assert "support_queue" not in merged["tools"]["transfer"]["destinations"]
+
+
+class TestConfigEnvironmentOverlay:
+    def _write(self, tmp_path):
+        (tmp_path / "overlays").mkdir()
+        (tmp_path / "ai-agent.yaml").write_text(
+            "default_provider: local\nlogging: {level: debug, format: console}\nbarge_in: {enabled: true}\n"
+        )
+        (tmp_path / "overlays" / "prod.yaml").write_text(
+            "default_provider: openai_realtime\nlogging: {level: info}\nbarge_in: null\n"
+        )
+        (tmp_path / "ai-agent.local.yaml").write_text("logging: {format: json}\n")
+        return str(tmp_path / "ai-agent.yaml")
+
+    def test_overlay_merges_between_base_and_local(self, tmp_path, monkeypatch):
+        path = self._write(tmp_path)
+        monkeypatch.setenv("AAVA_CONFIG_ENV", "prod")
+        merged = load_yaml_with_local_override(path)
+        assert merged == {
+            "default_provider": "openai_realtime",
+            "logging": {"level": "info", "format": "json"},
+        }
+
+    def test_no_env_ignores_overlays(self, tmp_path, monkeypatch):
+        path = self._write(tmp_path)
+        monkeypatch.delenv("AAVA_CONFIG_ENV", raising=False)
+        merged = load_yaml_with_local_override(path)
+        assert merged["default_provider"] == "local"
+        assert merged["logging"] == {"level": "debug", "format": "json"}
+
+    def test_missing_or_invalid_overlay_fails(self, tmp_path, monkeypatch):
+        path = self._write(tmp_path)
+        monkeypatch.setenv("AAVA_CONFIG_ENV", "staging")
+        with pytest.raises(FileNotFoundError):
+            load_yaml_with_local_override(path)
+        monkeypatch.setenv("AAVA_CONFIG_ENV", "../prod")
+        with pytest.raises(ValueError):
+            load_yaml_with_local_override(path)
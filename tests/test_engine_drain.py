@@ -0,0 +1,94 @@
+"""agent update drains the engine before restarting it.
+
+POST /drain stops new inbound callers from entering the agent: they are sent on
+to the next dialplan priority (as if Stasis() had returned) while calls already
+in progress finish. /sessions/stats reports the flag so the CLI can poll it.
+"""
+
+import json
+from unittest.mock import AsyncMock, MagicMock
+
+from src.engine import Engine
+
+
+def _make_engine(draining=False):
+    engine = Engine.__new__(Engine)
+    engine._draining = draining
+    engine._pre_stasis_channels = set()
+    engine._seen_caller_stasis_channels = set()
+    engine.ari_client = MagicMock()
+    engine.ari_client.continue_in_dialplan = AsyncMock(return_value=True)
+    engine.ari_client.hangup_channel = AsyncMock(return_value=True)
+    engine._handle_caller_stasis_start_hybrid = AsyncMock()
+    engine.session_store = MagicMock()
+    engine.session_store.get_session_stats = AsyncMock(return_value={"active_calls": 2})
+    return engine
+
+
+def _stasis_start(channel_id="1700000000.1"):
+    return {
+        "channel": {
+            "id": channel_id,
+            "name": "PJSIP/trunk-00000001",
+            "dialplan": {"context": "from-ai-agent", "exten": "s", "priority": 3},
+        },
+        "args": [],
+    }
+
+
+async def test_draining_engine_returns_new_callers_to_dialplan():
+    engine = _make_engine(draining=True)
+
+    await engine._handle_stasis_start(_stasis_start())
+
+    engine._handle_caller_stasis_start_hybrid.assert_not_awaited()
+    engine.ari_client.continue_in_dialplan.assert_awaited_once_with(
+        "1700000000.1", context="from-ai-agent", extension="s", priority=4
+    )
+    engine.ari_client.hangup_channel.assert_not_awaited()
+
+
+async def test_failed_continue_hangs_up_instead_of_stranding_the_caller():
+    engine = _make_engine(draining=True)
+    engine.ari_client.continue_in_dialplan = AsyncMock(return_value=False)
+
+    await engine._handle_stasis_start(_stasis_start())
+
+    engine.ari_client.hangup_channel.assert_awaited_once_with("1700000000.1")
+
+
+async def test_not_draining_handles_caller_normally():
+    engine = _make_engine(draining=False)
+
+    await engine._handle_stasis_start(_stasis_start())
+
+    engine._handle_caller_stasis_start_hybrid.assert_awaited_once()
+    engine.ari_client.continue_in_dialplan.assert_not_awaited()
+
+
+async def test_drain_handler_toggles_and_reports_active_calls():
+    engine = _make_engine()
+    engine._is_request_authorized = MagicMock(return_value=True)
+
+    request = MagicMock()
+    request.can_read_body = False
+    resp = await engine._drain_handler(request)
+    assert resp.status == 200
+    assert json.loads(resp.body) == {"draining": True, "active_calls": 2}
+    assert engine._draining is True
+
+    request.can_read_body = True
+    request.json = AsyncMock(return_value={"draining": False})
+    resp = await engine._drain_handler(request)
+    assert json.loads(resp.body)["draining"] is False
+    assert engine._draining is False
+
+
+async def test_drain_handler_requires_authorization():
+    engine = _make_engine()
+    engine._is_request_authorized = MagicMock(return_value=False)
+
+    resp = await engine._drain_handler(MagicMock())
+
+    assert resp.status == 403
+    assert engine._draining is False
@@ -222,3 +222,15 @@ def test_source_built_cli_is_written_as_the_project_owner() -> None:
     assert '--user "$(id -u):$(id -g)"' in runner
     assert "-e GOCACHE=/tmp/go-build" in runner
     assert "-e GOMODCACHE=/tmp/go-mod" in runner
+
+
+def test_update_run_passes_insecure_choice_and_explains_signature_refusals() -> None:
+    runner = (ROOT / "updater" / "run.sh").read_text(encoding="utf-8")
+
+    assert 'ALLOW_UNSIGNED="${AAVA_UPDATE_ALLOW_UNSIGNED:-false}"' in runner
+    assert runner.count('--insecure="${ALLOW_UNSIGNED}"') == 2
+    unsigned = runner.index('grep -q "refusing unverified update target"')
+    bad = runner.index('grep -q "refusing update target"')
+    assert unsigned < bad < runner.index('grep -qi "cannot fast-forward"')
+    assert 'failure_stage="unsigned_target"' in runner
+    assert 'failure_stage="bad_signature"' in runner
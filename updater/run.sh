@@ -14,6 +14,7 @@ CHECKOUT="${AAVA_UPDATE_CHECKOUT:-false}" # true|false
 LOCAL_CHANGES="${AAVA_UPDATE_LOCAL_CHANGES:-ask}" # ask|retain|overwrite|abort
 ROLLBACK_FROM_JOB="${AAVA_UPDATE_ROLLBACK_FROM_JOB:-}"
 FORCE_ACTIVE_CALLS="${AAVA_UPDATE_FORCE_ACTIVE_CALLS:-false}" # true|false
+ALLOW_UNSIGNED="${AAVA_UPDATE_ALLOW_UNSIGNED:-false}" # true|false (agent update --insecure)
 UPDATE_CLI_HOST="${AAVA_UPDATE_UPDATE_CLI_HOST:-true}" # true|false
 CLI_INSTALL_PATH="${AAVA_UPDATE_CLI_INSTALL_PATH:-}" # optional absolute host path
 BUILD_CLI_FROM_SOURCE="${AAVA_UPDATE_BUILD_CLI_FROM_SOURCE:-false}" # true|false
@@ -583,9 +584,9 @@ run_update() {
 
   set +e
   if [ -x "${BUILTIN_AGENT}" ]; then
-    "${BUILTIN_AGENT}" update -v --self-update=false --remote="${REMOTE}" --ref="${REF}" --checkout="${CHECKOUT}" --backup-id="${JOB_ID}" --include-ui="${INCLUDE_UI}" --local-changes="${LOCAL_CHANGES}" 2>&1 | tee "${JOB_LOG_PATH}"
+    "${BUILTIN_AGENT}" update -v --self-update=false --remote="${REMOTE}" --ref="${REF}" --checkout="${CHECKOUT}" --backup-id="${JOB_ID}" --include-ui="${INCLUDE_UI}" --local-changes="${LOCAL_CHANGES}" --insecure="${ALLOW_UNSIGNED}" 2>&1 | tee "${JOB_LOG_PATH}"
   else
-    "${AGENT_BIN}" update -v --self-update=false --remote="${REMOTE}" --ref="${REF}" --checkout="${CHECKOUT}" --backup-id="${JOB_ID}" --include-ui="${INCLUDE_UI}" --local-changes="${LOCAL_CHANGES}" 2>&1 | tee "${JOB_LOG_PATH}"
+    "${AGENT_BIN}" update -v --self-update=false --remote="${REMOTE}" --ref="${REF}" --checkout="${CHECKOUT}" --backup-id="${JOB_ID}" --include-ui="${INCLUDE_UI}" --local-changes="${LOCAL_CHANGES}" --insecure="${ALLOW_UNSIGNED}" 2>&1 | tee "${JOB_LOG_PATH}"
   fi
   code="${PIPESTATUS[0]}"
   set -e
@@ -605,6 +606,12 @@ run_update() {
       failure_status="validation_failed"
       failure_stage="post_update_check"
       failure_reason="post-update agent check failed"
+    elif grep -q "refusing unverified update target" "${JOB_LOG_PATH}" 2>/dev/null; then
+      failure_stage="unsigned_target"
+      failure_reason="update target is unsigned or signed by a key not in the host keyring; check the target commit, then allow an unsigned target to proceed"
+    elif grep -q "refusing update target" "${JOB_LOG_PATH}" 2>/dev/null; then
+      failure_stage="bad_signature"
+      failure_reason="update target signature does not verify; the target was refused before checkout"
     elif grep -qi "cannot fast-forward" "${JOB_LOG_PATH}" 2>/dev/null; then
       failure_stage="diverged_branch"
       failure_reason="local branch diverged from target"